@@ -5,12 +5,87 @@ package v1alpha1
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 
 	"gopkg.in/yaml.v3"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ApprovedDeletionsAnnotation is the Workflow annotation that approves a run with more deletions
+// than the maxDeletions limit. The value is the number of approved deletions, and it only approves
+// runs with exactly that number of deletions.
+const ApprovedDeletionsAnnotation = "blackstart.pezops.github.io/approved-deletions"
+
+// ParametersAnnotation is the Workflow annotation that supplies parameter values for runs of the
+// Workflow. The value must be a JSON object mapping parameter names to string values.
+const ParametersAnnotation = "blackstart.pezops.github.io/parameters"
+
+// InjectFailuresAnnotation is the Workflow annotation that forces operations to fail, to test how
+// failures of the Workflow are handled. The value is a comma-separated list of `id=check` or
+// `id=set` entries naming the operation and the phase that fails.
+const InjectFailuresAnnotation = "blackstart.pezops.github.io/inject-failures"
+
+// OnlyLabelsAnnotation is the Workflow annotation that limits runs to the operations with a label,
+// such as during an incident. The value is a comma-separated list of `key=value` labels, and
+// operations with any of the labels are set. Other operations are checked but not set.
+const OnlyLabelsAnnotation = "blackstart.pezops.github.io/only-labels"
+
+// SkipLabelsAnnotation is the Workflow annotation that excludes the operations with a label from
+// runs. The value is a comma-separated list of `key=value` labels, and operations with any of the
+// labels are checked but not set.
+const SkipLabelsAnnotation = "blackstart.pezops.github.io/skip-labels"
+
+// Condition types set in the status of a Workflow.
+const (
+	// ConditionReady is true when the last run of the Workflow completed successfully.
+	ConditionReady = "Ready"
+
+	// ConditionProgressing is true while the Workflow is being run.
+	ConditionProgressing = "Progressing"
+
+	// ConditionDegraded is true when the last run of the Workflow failed, or operations of it failed
+	// with the `continue` failure policy.
+	ConditionDegraded = "Degraded"
+
+	// ConditionDrifted is true when the last check-only run of the Workflow found operations whose
+	// checks did not pass. It is set to false by a successful run. Check-only runs do not change
+	// resources, so they do not change the Ready and Degraded conditions of the last run, and drift
+	// is reported with its own condition so it can be alerted on separately from failed runs.
+	ConditionDrifted = "Drifted"
+)
+
+// Condition reasons set in the status of a Workflow. A failed run uses the phase it failed in
+// followed by "Failed" as the reason, such as "ExecuteFailed", unless the run timed out.
+const (
+	// ReasonSucceeded indicates that the last run completed successfully.
+	ReasonSucceeded = "Succeeded"
+
+	// ReasonRunning indicates that a run is in progress.
+	ReasonRunning = "Running"
+
+	// ReasonRunComplete indicates that no run is in progress.
+	ReasonRunComplete = "RunComplete"
+
+	// ReasonDriftDetected indicates that a check-only run found drifted operations.
+	ReasonDriftDetected = "DriftDetected"
+
+	// ReasonInSync indicates that the checks of all operations passed in a check-only run.
+	ReasonInSync = "InSync"
+
+	// ReasonPendingWindow indicates that the last run deferred operations to the maintenance
+	// window.
+	ReasonPendingWindow = "PendingWindow"
+
+	// ReasonTimedOut indicates that the last run did not complete within the timeout of the
+	// Workflow.
+	ReasonTimedOut = "TimedOut"
+
+	// ReasonOperationsFailed indicates that the last run succeeded, but operations with the
+	// `continue` failure policy failed.
+	ReasonOperationsFailed = "OperationsFailed"
+)
+
 // Workflow defines all the settings for a Blackstart workflow including its operations and their
 // dependencies.
 // +kubebuilder:object:root=true
@@ -32,8 +107,8 @@ type Workflow struct {
 
 // WorkflowConfigFile models a workflow as defined in a standalone YAML configuration file.
 type WorkflowConfigFile struct {
-	WorkflowSpec `yaml:",inline"`
 	Name         string `yaml:"name" json:"name"`
+	WorkflowSpec `yaml:",inline"`
 }
 
 // WorkflowList contains a list of Workflow resources
@@ -55,11 +130,128 @@ type WorkflowSpec struct {
 	// +kubebuilder:default:="5m"
 	ReconcileInterval string `yaml:"reconcileInterval,omitempty" json:"reconcileInterval,omitempty"`
 
+	// CheckInterval controls how often check-only runs of this Workflow are scheduled in controller
+	// mode, between the runs scheduled with ReconcileInterval. A check-only run checks all
+	// operations without changing any resources and reports drift in the status. If not set, no
+	// check-only runs are scheduled.
+	// +kubebuilder:validation:Optional
+	CheckInterval string `yaml:"checkInterval,omitempty" json:"checkInterval,omitempty"`
+
+	// Parameters declares named values that are supplied when the Workflow is run. Operation inputs
+	// reference a parameter with the `fromParameter` property.
+	// +kubebuilder:validation:Optional
+	Parameters []WorkflowParameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+
+	// ForEach instantiates the operations of the Workflow once for each listed instance, with the
+	// parameter values of the instance. Operation IDs of an instance are prefixed with the instance
+	// name, for example `team-a/create-db`.
+	// +kubebuilder:validation:Optional
+	ForEach []WorkflowInstance `yaml:"forEach,omitempty" json:"forEach,omitempty"`
+
+	// ModuleDefaults sets default inputs for the operations of matching modules, such as the
+	// client of every Kubernetes operation. Inputs set by an operation take precedence over the
+	// defaults.
+	// +kubebuilder:validation:Optional
+	ModuleDefaults []ModuleDefaults `yaml:"moduleDefaults,omitempty" json:"moduleDefaults,omitempty"`
+
+	// MaxDeletions limits the number of operations with `doesNotExist` set in a run. A run with more
+	// deletions fails before any operation is executed, unless the number of deletions is approved
+	// with the approved-deletions annotation. If not set, the number of deletions is not limited.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MaxDeletions *int `yaml:"maxDeletions,omitempty" json:"maxDeletions,omitempty"`
+
+	// MaintenanceWindow limits when operations change resources. Outside the window, operations
+	// are checked, and operations whose checks do not pass are reported as pending the window and
+	// set in a later run within the window. If not set, operations are set at any time.
+	// +kubebuilder:validation:Optional
+	MaintenanceWindow *MaintenanceWindow `yaml:"maintenanceWindow,omitempty" json:"maintenanceWindow,omitempty"`
+
+	// SkipUnchangedFor skips the check of an operation when its resolved inputs are unchanged and
+	// it last succeeded within the duration, such as `1h`. Operations that other operations depend
+	// on are always run. Skipping requires a state store to be configured. If not set, operations
+	// are never skipped.
+	// +kubebuilder:validation:Optional
+	SkipUnchangedFor string `yaml:"skipUnchangedFor,omitempty" json:"skipUnchangedFor,omitempty"`
+
+	// MaxRetryBackoff limits the backoff of runs after consecutive failed runs in controller mode,
+	// such as `6h`. From the second consecutive failed run, the next run is delayed by the reconcile
+	// interval doubled for each further failure, less a random jitter, up to this duration. If not
+	// set, the maximum is 1h. Set to `0s` to retry failed runs every reconcile interval.
+	// +kubebuilder:validation:Optional
+	MaxRetryBackoff string `yaml:"maxRetryBackoff,omitempty" json:"maxRetryBackoff,omitempty"`
+
+	// Timeout limits the duration of a run of the Workflow, such as `30m`. When it expires, the
+	// operation in flight is cancelled and the run fails with the reason TimedOut, recording the
+	// operation in lastOperation. If not set, runs are not limited.
+	// +kubebuilder:validation:Optional
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+
+	// ServiceChecks are connectivity checks of the services used by the operations, such as the
+	// Kubernetes API server, the Google Cloud default credentials, or a database host. They run
+	// before the operations, and a run with a failed check stops in the Preflight phase without
+	// changing any resources.
+	// +kubebuilder:validation:Optional
+	ServiceChecks []ServiceCheck `yaml:"serviceChecks,omitempty" json:"serviceChecks,omitempty"`
+
+	// PublishOutputs writes selected outputs of operations to ConfigMaps and Secrets after a
+	// successful run, such as generated connection strings, so other controllers and Helm charts can
+	// consume them. Outputs are not published when operations were not set, such as outside the
+	// maintenance window.
+	// +kubebuilder:validation:Optional
+	PublishOutputs []PublishOutputs `yaml:"publishOutputs,omitempty" json:"publishOutputs,omitempty"`
+
 	// A partially ordered set of operations to be executed.
 	// +kubebuilder:validation:MinItems=1
 	Operations []Operation `yaml:"operations" json:"operations"`
 }
 
+// WorkflowInstance is one instantiation of the operations of a Workflow with its own parameter
+// values.
+// +kubebuilder:object:generate=true
+type WorkflowInstance struct {
+	// Name identifies the instance, such as a tenant or namespace. It must be unique within the
+	// Workflow.
+	// +kubebuilder:validation:Required
+	Name string `yaml:"name" json:"name"`
+
+	// Parameters are the parameter values of the instance. They take precedence over parameter
+	// values supplied for the run.
+	Parameters map[string]string `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// ModuleDefaults are default inputs for the operations of a set of modules.
+// +kubebuilder:object:generate=true
+type ModuleDefaults struct {
+	// Modules are the identifiers of the modules the defaults apply to. An identifier may be a
+	// pattern, such as `kubernetes_*`.
+	// +kubebuilder:validation:MinItems=1
+	Modules []string `yaml:"modules" json:"modules"`
+
+	// Inputs are the default inputs, in the same format as operation inputs. An input is only set
+	// for operations of modules that declare the input and do not set it.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Inputs map[string]*OperationInput `yaml:"inputs" json:"inputs"`
+}
+
+// WorkflowParameter declares a named value that is supplied when the Workflow is run.
+// +kubebuilder:object:generate=true
+type WorkflowParameter struct {
+	// Name of the parameter, used by operation inputs to reference the parameter value.
+	// +kubebuilder:validation:Required
+	Name string `yaml:"name" json:"name"`
+
+	// Optional human description of the parameter.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Default is the value used when the parameter is not supplied for a run.
+	Default *string `yaml:"default,omitempty" json:"default,omitempty"`
+
+	// Required indicates that a value must be supplied for every run when no default is set.
+	Required bool `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
 // Operation models a single Blackstart operation in the Workflow.
 // +kubebuilder:object:generate=true
 type Operation struct {
@@ -82,7 +274,10 @@ type Operation struct {
 	// for the selected module. Instead of a scalar value, it may also be a well-known object with
 	// the `fromDependency` property. The `fromDependency` must contain both an `id` and `output`
 	// property to indicate which operation and output value to use as a dynamic input value that
-	// is filled at runtime.
+	// is filled at runtime, and may be accompanied by a `default` value used when the dependency
+	// does not produce the output. The `fromParameter` property may be used instead to take the value of a
+	// workflow parameter, the `fromFile` property to read the value from a file, and the
+	// `encrypted` property to decrypt an encrypted value when the workflow is loaded.
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Schemaless
 	// +kubebuilder:pruning:PreserveUnknownFields
@@ -92,11 +287,23 @@ type Operation struct {
 	// before this operation is run.
 	DependsOn []string `yaml:"dependsOn,omitempty" json:"dependsOn,omitempty"`
 
+	// Labels are free-form key and value pairs of the operation, such as `tier: db`. Runs may be
+	// limited to the operations with or without a label.
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
 	// DoesNotExist is a special parameter that can be used to indicate that the resource should
 	// not exist. This is useful for resources that are changed from a previous state and now
 	// should be deleted if they still exist.
 	DoesNotExist bool `yaml:"doesNotExist,omitempty" json:"doesNotExist,omitempty"`
 
+	// OnFailure is the failure policy of the operation. With `abort`, the default, a failure of the
+	// operation stops the run. With `continue`, the run continues and succeeds. With `isolate`, the
+	// run continues and fails once the other operations are completed. The operations that depend
+	// on a failed operation are skipped.
+	// +kubebuilder:validation:Enum=abort;continue;isolate
+	// +optional
+	OnFailure string `yaml:"onFailure,omitempty" json:"onFailure,omitempty"`
+
 	// Tainted is a special parameter that can be used to indicate that the resource is tainted and
 	// should be replaced. This is useful for resources that always must be updated so that
 	// attributes / output values are known by blackstart. This should not be configured by users,
@@ -112,9 +319,48 @@ type OperationInput struct {
 	// operation.
 	FromDependency *FromDependency `yaml:"fromDependency,omitempty" json:"fromDependency,omitempty"`
 
+	// Default is the static value of an input from a dependency that is used when the dependency
+	// does not produce the output. It is only used together with FromDependency.
+	Default *apiextensionsv1.JSON `yaml:"default,omitempty" json:"default,omitempty"`
+
+	// FromParameter indicates that the input value should be taken from the named workflow
+	// parameter.
+	FromParameter string `yaml:"fromParameter,omitempty" json:"fromParameter,omitempty"`
+
+	// FromFile indicates that the input value should be read from a file when the workflow is run.
+	FromFile *FromFile `yaml:"fromFile,omitempty" json:"fromFile,omitempty"`
+
+	// Encrypted is an encrypted static value that is decrypted when the workflow is loaded. The
+	// value is an age encrypted file, usually ASCII armored, or a base64 encoded Cloud KMS
+	// ciphertext.
+	Encrypted string `yaml:"encrypted,omitempty" json:"encrypted,omitempty"`
+
 	// Extra holds any additional fields not explicitly modeled in the struct. This should be a
 	// map of scalar values.
 	Extra *apiextensionsv1.JSON `yaml:"-" json:"-"`
+
+	// DeprecatedKey is the deprecated key used for the input instead of its canonical key, such as
+	// from_dependency instead of fromDependency. It is set when the input is unmarshalled.
+	DeprecatedKey string `yaml:"-" json:"-"`
+}
+
+// FromDependencyAlias is the deprecated key of fromDependency in operation inputs. It is accepted
+// so that inputs using it are not silently used as a static map value.
+const FromDependencyAlias = "from_dependency"
+
+// canonicalInputKeys renames the deprecated keys of a raw input to their canonical keys, and
+// returns the deprecated key that was used, if any.
+func canonicalInputKeys(raw map[string]interface{}) (string, error) {
+	dep, ok := raw[FromDependencyAlias]
+	if !ok {
+		return "", nil
+	}
+	if _, ok = raw["fromDependency"]; ok {
+		return "", fmt.Errorf("fromDependency and %s cannot be used together", FromDependencyAlias)
+	}
+	raw["fromDependency"] = dep
+	delete(raw, FromDependencyAlias)
+	return FromDependencyAlias, nil
 }
 
 // UnmarshalYAML implements custom YAML unmarshalling for OperationInput
@@ -122,6 +368,9 @@ func (oi *OperationInput) UnmarshalYAML(value *yaml.Node) error {
 	var err error
 	var raw map[string]interface{}
 	if err = value.Decode(&raw); err == nil {
+		if oi.DeprecatedKey, err = canonicalInputKeys(raw); err != nil {
+			return err
+		}
 		if fd, ok := raw["fromDependency"]; ok {
 			var buf []byte
 			buf, err = yaml.Marshal(fd)
@@ -135,6 +384,44 @@ func (oi *OperationInput) UnmarshalYAML(value *yaml.Node) error {
 			oi.FromDependency = &dep
 			delete(raw, "fromDependency")
 		}
+		if def, ok := raw["default"]; ok && oi.FromDependency != nil {
+			var buf []byte
+			buf, err = json.Marshal(def)
+			if err != nil {
+				return fmt.Errorf("invalid fromDependency default: %w", err)
+			}
+			oi.Default = &apiextensionsv1.JSON{Raw: buf}
+			delete(raw, "default")
+		}
+		if fp, ok := raw["fromParameter"]; ok {
+			name, isString := fp.(string)
+			if !isString {
+				return fmt.Errorf("fromParameter must be a string")
+			}
+			oi.FromParameter = name
+			delete(raw, "fromParameter")
+		}
+		if ff, ok := raw["fromFile"]; ok {
+			var buf []byte
+			buf, err = yaml.Marshal(ff)
+			if err != nil {
+				return err
+			}
+			var file FromFile
+			if err = yaml.Unmarshal(buf, &file); err != nil {
+				return err
+			}
+			oi.FromFile = &file
+			delete(raw, "fromFile")
+		}
+		if enc, ok := raw["encrypted"]; ok {
+			ciphertext, isString := enc.(string)
+			if !isString {
+				return fmt.Errorf("encrypted must be a string")
+			}
+			oi.Encrypted = ciphertext
+			delete(raw, "encrypted")
+		}
 	}
 
 	// Marshal the rest to JSON for the Extra field
@@ -168,6 +455,9 @@ func (oi *OperationInput) UnmarshalJSON(data []byte) error {
 	var err error
 	var raw map[string]interface{}
 	if err = json.Unmarshal(data, &raw); err == nil {
+		if oi.DeprecatedKey, err = canonicalInputKeys(raw); err != nil {
+			return err
+		}
 		if fd, ok := raw["fromDependency"]; ok {
 			var buf []byte
 			buf, err = json.Marshal(fd)
@@ -181,6 +471,44 @@ func (oi *OperationInput) UnmarshalJSON(data []byte) error {
 			oi.FromDependency = &dep
 			delete(raw, "fromDependency")
 		}
+		if def, ok := raw["default"]; ok && oi.FromDependency != nil {
+			var buf []byte
+			buf, err = json.Marshal(def)
+			if err != nil {
+				return fmt.Errorf("invalid fromDependency default: %w", err)
+			}
+			oi.Default = &apiextensionsv1.JSON{Raw: buf}
+			delete(raw, "default")
+		}
+		if fp, ok := raw["fromParameter"]; ok {
+			name, isString := fp.(string)
+			if !isString {
+				return fmt.Errorf("fromParameter must be a string")
+			}
+			oi.FromParameter = name
+			delete(raw, "fromParameter")
+		}
+		if ff, ok := raw["fromFile"]; ok {
+			var buf []byte
+			buf, err = json.Marshal(ff)
+			if err != nil {
+				return err
+			}
+			var file FromFile
+			if err = json.Unmarshal(buf, &file); err != nil {
+				return err
+			}
+			oi.FromFile = &file
+			delete(raw, "fromFile")
+		}
+		if enc, ok := raw["encrypted"]; ok {
+			ciphertext, isString := enc.(string)
+			if !isString {
+				return fmt.Errorf("encrypted must be a string")
+			}
+			oi.Encrypted = ciphertext
+			delete(raw, "encrypted")
+		}
 	}
 
 	// Marshal the rest to JSON for the Extra field
@@ -206,6 +534,40 @@ func (oi *OperationInput) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalYAML implements custom YAML marshalling for OperationInput, producing the same form that
+// is accepted by UnmarshalYAML.
+func (oi OperationInput) MarshalYAML() (interface{}, error) {
+	if oi.FromDependency != nil && oi.Default != nil {
+		var value interface{}
+		if err := json.Unmarshal(oi.Default.Raw, &value); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"fromDependency": oi.FromDependency, "default": value}, nil
+	}
+	if oi.FromDependency != nil {
+		return map[string]*FromDependency{"fromDependency": oi.FromDependency}, nil
+	}
+	if oi.FromParameter != "" {
+		return map[string]string{"fromParameter": oi.FromParameter}, nil
+	}
+	if oi.FromFile != nil {
+		return map[string]*FromFile{"fromFile": oi.FromFile}, nil
+	}
+	if oi.Encrypted != "" {
+		return map[string]string{"encrypted": oi.Encrypted}, nil
+	}
+	if oi.Extra == nil || len(oi.Extra.Raw) == 0 {
+		return nil, nil
+	}
+
+	// Extra holds YAML or JSON, and JSON is also valid YAML.
+	var value interface{}
+	if err := yaml.Unmarshal(oi.Extra.Raw, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
 // FromDependency models a dynamic input from the output of a different operation.
 // +kubebuilder:object:generate=true
 type FromDependency struct {
@@ -219,25 +581,120 @@ type FromDependency struct {
 	Output string `yaml:"output" json:"output"`
 }
 
+// FromFile models an input value read from a file, such as a mounted Secret, a projected service
+// account token, or a downward API file.
+// +kubebuilder:object:generate=true
+type FromFile struct {
+	// Path is the path of the file to read.
+	// +kubebuilder:validation:Required
+	Path string `yaml:"path" json:"path"`
+
+	// Format controls how the file contents are used as the input value. `raw` uses the contents
+	// as a string, `base64` uses the base64 encoding of the contents, and `json` parses the
+	// contents as JSON. If not set, the default is `raw`.
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+}
+
+// MaintenanceWindow is a recurring period in which the operations of a Workflow may change
+// resources.
+// +kubebuilder:object:generate=true
+type MaintenanceWindow struct {
+	// Schedule is a cron expression of the times the window opens, such as `0 2 * * SAT`.
+	// +kubebuilder:validation:Required
+	Schedule string `yaml:"schedule" json:"schedule"`
+
+	// Duration is how long the window stays open after it opens, such as `4h`.
+	// +kubebuilder:validation:Required
+	Duration string `yaml:"duration" json:"duration"`
+
+	// TimeZone is the IANA time zone the schedule is evaluated in, such as `Europe/Berlin`. If not
+	// set, the default is UTC.
+	TimeZone string `yaml:"timeZone,omitempty" json:"timeZone,omitempty"`
+}
+
+// ServiceCheck is a connectivity check of a service used by the operations of a Workflow.
+// +kubebuilder:object:generate=true
+type ServiceCheck struct {
+	// Name identifies the check in the status and logs. It must be unique within the Workflow.
+	// +kubebuilder:validation:Required
+	Name string `yaml:"name" json:"name"`
+
+	// Kind is the kind of the check: `tcp` opens a connection to the `address` parameter, such as
+	// `db.internal:5432`, `kubernetes` requests the version of the Kubernetes API server, and
+	// `google` resolves the Application Default Credentials and requests an access token.
+	// +kubebuilder:validation:Required
+	Kind string `yaml:"kind" json:"kind"`
+
+	// Params are the parameters of the check, such as `address` for `tcp` checks and `namespace`
+	// for `kubernetes` checks.
+	// +kubebuilder:validation:Optional
+	Params map[string]string `yaml:"params,omitempty" json:"params,omitempty"`
+
+	// Timeout limits the duration of the check, such as `30s`. If not set, the default is 10s.
+	// +kubebuilder:validation:Optional
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// PublishOutputs selects outputs of operations to write to the data of a ConfigMap or Secret. Other
+// keys of an existing ConfigMap or Secret are kept.
+// +kubebuilder:object:generate=true
+type PublishOutputs struct {
+	// ConfigMap is the name of the ConfigMap the outputs are written to. Either configMap or secret
+	// must be set. Sensitive outputs cannot be written to a ConfigMap.
+	ConfigMap string `yaml:"configMap,omitempty" json:"configMap,omitempty"`
+
+	// Secret is the name of the Secret the outputs are written to.
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+
+	// Namespace of the ConfigMap or Secret. If not set, the namespace of the Workflow is used.
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+
+	// Outputs are the operation outputs to write, by data key.
+	// +kubebuilder:validation:MinItems=1
+	Outputs []PublishedOutput `yaml:"outputs" json:"outputs"`
+}
+
+// PublishedOutput is an operation output written to a data key of a ConfigMap or Secret.
+// +kubebuilder:object:generate=true
+type PublishedOutput struct {
+	// Key is the data key the output is written to.
+	// +kubebuilder:validation:Required
+	Key string `yaml:"key" json:"key"`
+
+	// FromDependency is the operation and output to write.
+	// +kubebuilder:validation:Required
+	FromDependency FromDependency `yaml:"fromDependency" json:"fromDependency"`
+}
+
 // WorkflowStatus contains runtime status and result information about the Workflow.
 // +kubebuilder:object:generate=true
 type WorkflowStatus struct {
+	// Conditions are the Ready, Progressing, Degraded, and Drifted conditions of the Workflow.
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Result contains any result information from the last run, including error messages if
+	// applicable. The Ready condition has the same message, and Result is kept for clients that
+	// read the result of the last run from it.
+	Result string `json:"result,omitempty"`
+
 	// LastRan is the time the Workflow was last run, if ever.
 	LastRan metav1.Time `json:"lastRan,omitempty"`
 
 	// NextRun is the next scheduled run time for this Workflow in controller mode.
 	NextRun metav1.Time `json:"nextRun,omitempty"`
 
+	// LastChecked is the time of the last check-only run of the Workflow, if ever.
+	LastChecked metav1.Time `json:"lastChecked,omitempty"`
+
 	// Successful indicates whether the last run was successful.
 	Successful string `json:"successful,omitempty"`
 
 	// Phase is a high-level state of the workflow that the last run ended in.
 	Phase string `json:"phase,omitempty"`
 
-	// Result contains any result information from the last run, including error messages if
-	// applicable.
-	Result string `json:"result,omitempty"`
-
 	// LastError is a short summary of the most recent error, if the last run failed.
 	LastError string `json:"lastError,omitempty"`
 
@@ -248,4 +705,184 @@ type WorkflowStatus struct {
 
 	// LastOperation is the identifier of the last operation that was executed in the last run.
 	LastOperation string `json:"lastOperation,omitempty"`
+
+	// ManagedResources lists the resources reported by the operations completed in the last run.
+	// Resources of operations with doesNotExist set are not included.
+	ManagedResources []ManagedResource `json:"managedResources,omitempty"`
+
+	// Operations lists the duration and API calls of the operations executed in the last run, in
+	// execution order.
+	Operations []OperationStatus `json:"operations,omitempty"`
+
+	// Instances lists the results of the forEach instances of the Workflow in the last run. A
+	// failed instance does not stop the other instances.
+	Instances []InstanceStatus `json:"instances,omitempty"`
+
+	// APICalls is the number of external API calls of the operations executed in the last run by
+	// provider, such as `google` or `kubernetes`.
+	APICalls map[string]int64 `json:"apiCalls,omitempty"`
+
+	// Plan is the resolved execution plan of the last run. It is recorded once the operations are
+	// validated, so it shows what a run intended to do even when the run failed.
+	// +optional
+	Plan *WorkflowPlan `json:"plan,omitempty"`
+
+	// DriftedOperations lists the operations whose checks did not pass in the last check-only run.
+	// It is cleared by a successful run.
+	DriftedOperations []string `json:"driftedOperations,omitempty"`
+
+	// StuckOperations lists the operations of the current run that have run longer than the stuck
+	// operation threshold of the runtime. It is cleared when the run completes.
+	StuckOperations []StuckOperation `json:"stuckOperations,omitempty"`
+
+	// ConsecutiveFailures is the number of consecutive failed runs of the Workflow. It is reset by a
+	// successful run.
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty"`
+
+	// RetryBackoff is the delay before the next run after consecutive failed runs, when it is longer
+	// than the reconcile interval.
+	RetryBackoff *metav1.Duration `json:"retryBackoff,omitempty"`
+
+	// State contains the state of operations persisted between runs when the status state store
+	// is used, keyed by operation identifier.
+	State map[string][]byte `json:"state,omitempty"`
+}
+
+// InstanceStatus is the result of a forEach instance of a Workflow in the last run.
+type InstanceStatus struct {
+	// Name is the name of the instance.
+	Name string `json:"name"`
+
+	// Successful indicates whether the operations of the instance were successful.
+	Successful string `json:"successful"`
+
+	// LastError is the error of the failed operation of the instance, if the instance failed.
+	LastError string `json:"lastError,omitempty"`
+
+	// OperationsCompleted is the number of operations of the instance that were completed, in a
+	// fraction format where the denominator is the number of operations of the instance.
+	OperationsCompleted string `json:"operationsCompleted"`
+}
+
+// StuckOperation is an operation of a running Workflow that has run longer than the stuck operation
+// threshold of the runtime.
+type StuckOperation struct {
+	// Id is the identifier of the operation.
+	Id string `json:"id"`
+
+	// Module is the identifier of the module of the operation.
+	Module string `json:"module"`
+
+	// Started is the time the operation started.
+	Started metav1.Time `json:"started"`
+}
+
+// OperationStatus contains the metrics and the resolved inputs and outputs of an operation executed
+// in the last run of a Workflow.
+type OperationStatus struct {
+	// Id is the identifier of the operation.
+	Id string `json:"id"`
+
+	// Module is the identifier of the module of the operation.
+	Module string `json:"module"`
+
+	// Duration is the wall time of the check and set of the operation.
+	Duration metav1.Duration `json:"duration"`
+
+	// APICalls is the number of external API calls made by the operation.
+	APICalls int64 `json:"apiCalls"`
+
+	// ProviderAPICalls is the number of external API calls made by the operation by provider.
+	ProviderAPICalls map[string]int64 `json:"providerApiCalls,omitempty"`
+
+	// Skipped is true when the operation was not run because its inputs were unchanged since its
+	// last successful run, or because a dependency was not set or failed.
+	// +optional
+	Skipped bool `json:"skipped,omitempty"`
+
+	// PendingWindow is true when the check of the operation did not pass outside the maintenance
+	// window, and the set of the operation is deferred to the window.
+	// +optional
+	PendingWindow bool `json:"pendingWindow,omitempty"`
+
+	// Blocked is true when the operation failed because the circuit of an endpoint it uses is open
+	// after consecutive failures.
+	// +optional
+	Blocked bool `json:"blocked,omitempty"`
+
+	// Filtered is true when the operation was not selected by the label filters of the run, so it
+	// was checked but not set.
+	// +optional
+	Filtered bool `json:"filtered,omitempty"`
+
+	// Error is the error of the operation when it failed.
+	// +optional
+	Error string `json:"error,omitempty"`
+
+	// Inputs are the resolved input values of the operation, with sensitive values masked. Values
+	// that are not strings are JSON encoded, and long values are truncated.
+	// +optional
+	Inputs map[string]string `json:"inputs,omitempty"`
+
+	// Outputs are the output values of the operation, with sensitive values masked.
+	// +optional
+	Outputs map[string]string `json:"outputs,omitempty"`
+}
+
+// WorkflowPlan is the resolved execution plan of a run of a Workflow.
+// +kubebuilder:object:generate=true
+type WorkflowPlan struct {
+	// ObservedGeneration is the generation of the Workflow the plan was resolved from.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Operations are the planned operations, in execution order.
+	Operations []PlannedOperation `json:"operations,omitempty"`
+}
+
+// PlannedOperation is an operation of the execution plan of a Workflow.
+type PlannedOperation struct {
+	// Id is the identifier of the operation.
+	Id string `json:"id"`
+
+	// Module is the identifier of the module of the operation.
+	Module string `json:"module"`
+
+	// DependsOn are the identifiers of the operations the operation depends on, either explicitly
+	// or with dependency inputs.
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// DoesNotExist is true when the operation ensures that its resource does not exist.
+	// +optional
+	DoesNotExist bool `json:"doesNotExist,omitempty"`
+
+	// Inputs are the sources of the inputs of the operation.
+	// +optional
+	Inputs map[string]PlannedInput `json:"inputs,omitempty"`
+}
+
+// PlannedInput is the source of an input of a planned operation. Either the value is set, or the
+// input is taken from the output of a dependency.
+type PlannedInput struct {
+	// Value is the static value of the input, with sensitive values masked. Values that are not
+	// strings are JSON encoded, and long values are truncated.
+	// +optional
+	Value string `json:"value,omitempty"`
+
+	// FromDependency is the dependency output the input is taken from.
+	// +optional
+	FromDependency *FromDependency `json:"fromDependency,omitempty"`
+}
+
+// ManagedResource identifies a resource managed by an operation of a Workflow.
+type ManagedResource struct {
+	// Id is the canonical identifier of the resource, as reported by the module. The format
+	// depends on the module, such as namespace/name for a Kubernetes Secret.
+	Id string `json:"id"`
+
+	// Module is the identifier of the module that manages the resource.
+	Module string `json:"module"`
+
+	// Operation is the identifier of the operation that manages the resource.
+	Operation string `json:"operation"`
 }
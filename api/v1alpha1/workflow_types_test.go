@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -324,6 +325,50 @@ fromDependency:
 `,
 			out: &OperationInput{FromDependency: &FromDependency{Id: "foo", Output: "bar"}},
 		},
+		{
+			name: "from_dependency_default_input",
+			in: `
+fromDependency:
+  id: foo
+  output: bar
+default: localhost
+`,
+			out: &OperationInput{
+				FromDependency: &FromDependency{Id: "foo", Output: "bar"},
+				Default:        &apiextensionsv1.JSON{Raw: []byte(`"localhost"`)},
+			},
+		},
+		{
+			name: "from_dependency_alias_input",
+			in: `
+from_dependency:
+  id: foo
+  output: bar
+`,
+			out: &OperationInput{
+				FromDependency: &FromDependency{Id: "foo", Output: "bar"},
+				DeprecatedKey:  FromDependencyAlias,
+			},
+		},
+		{
+			name: "from_parameter_input",
+			in:   `fromParameter: instance`,
+			out:  &OperationInput{FromParameter: "instance"},
+		},
+		{
+			name: "from_file_input",
+			in: `
+fromFile:
+  path: /var/run/secrets/token
+  format: base64
+`,
+			out: &OperationInput{FromFile: &FromFile{Path: "/var/run/secrets/token", Format: "base64"}},
+		},
+		{
+			name: "encrypted_input",
+			in:   `encrypted: YWdlLWVuY3J5cHRpb24ub3JnL3Yx`,
+			out:  &OperationInput{Encrypted: "YWdlLWVuY3J5cHRpb24ub3JnL3Yx"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -337,3 +382,101 @@ fromDependency:
 		)
 	}
 }
+
+// TestInputToYaml tests that OperationInput values marshalled to YAML unmarshal to the same value.
+func TestInputToYaml(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{name: "string_input", in: "test", out: "test\n"},
+		{name: "int_input", in: "15", out: "15\n"},
+		{name: "multiline_input", in: "|-\n  line one\n  line two\n", out: "|-\n    line one\n    line two\n"},
+		{name: "list_input", in: "[a, b]", out: "- a\n- b\n"},
+		{name: "map_input", in: "foo: bar", out: "foo: bar\n"},
+		{
+			name: "from_dependency_input",
+			in:   "fromDependency:\n  id: foo\n  output: bar\n",
+			out:  "fromDependency:\n    id: foo\n    output: bar\n",
+		},
+		{
+			name: "from_dependency_default_input",
+			in:   "fromDependency:\n  id: foo\n  output: bar\ndefault: [a, b]\n",
+			out:  "default:\n    - a\n    - b\nfromDependency:\n    id: foo\n    output: bar\n",
+		},
+		{name: "from_parameter_input", in: "fromParameter: instance", out: "fromParameter: instance\n"},
+		{
+			name: "from_file_input",
+			in:   "fromFile:\n  path: /etc/token\n",
+			out:  "fromFile:\n    path: /etc/token\n",
+		},
+		{name: "encrypted_input", in: "encrypted: c2VjcmV0", out: "encrypted: c2VjcmV0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var input *OperationInput
+				assert.NoError(t, yaml.Unmarshal([]byte(tt.in), &input))
+
+				out, err := yaml.Marshal(input)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.out, string(out))
+
+				var result *OperationInput
+				assert.NoError(t, yaml.Unmarshal(out, &result))
+				assert.Equal(t, input.FromDependency, result.FromDependency)
+				assert.Equal(t, input.Default, result.Default)
+				assert.Equal(t, input.FromParameter, result.FromParameter)
+				assert.Equal(t, input.FromFile, result.FromFile)
+				assert.Equal(t, input.Encrypted, result.Encrypted)
+				var want, got interface{}
+				if input.Extra != nil {
+					assert.NoError(t, yaml.Unmarshal(input.Extra.Raw, &want))
+					assert.NoError(t, yaml.Unmarshal(result.Extra.Raw, &got))
+				}
+				assert.Equal(t, want, got)
+			},
+		)
+	}
+}
+
+// TestInputFromDependencyAlias tests that the deprecated from_dependency key is accepted in YAML and
+// JSON, and cannot be used with fromDependency.
+func TestInputFromDependencyAlias(t *testing.T) {
+	var input OperationInput
+	assert.NoError(t, json.Unmarshal([]byte(`{"from_dependency": {"id": "foo", "output": "bar"}}`), &input))
+	assert.Equal(t, &FromDependency{Id: "foo", Output: "bar"}, input.FromDependency)
+	assert.Equal(t, FromDependencyAlias, input.DeprecatedKey)
+
+	input = OperationInput{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"fromDependency": {"id": "foo", "output": "bar"}}`), &input))
+	assert.Empty(t, input.DeprecatedKey)
+
+	// The input is written with the canonical key.
+	out, err := yaml.Marshal(OperationInput{FromDependency: &FromDependency{Id: "foo", Output: "bar"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "fromDependency:\n    id: foo\n    output: bar\n", string(out))
+
+	both := `{"fromDependency": {"id": "foo", "output": "bar"}, "from_dependency": {"id": "foo", "output": "bar"}}`
+	assert.ErrorContains(t, json.Unmarshal([]byte(both), &OperationInput{}), "cannot be used together")
+	var result *OperationInput
+	assert.ErrorContains(t, yaml.Unmarshal([]byte(both), &result), "cannot be used together")
+}
+
+// TestInputDependencyDefault tests that the default of an input from a dependency is read from JSON,
+// and that a default key of a static map value is part of the value.
+func TestInputDependencyDefault(t *testing.T) {
+	var input OperationInput
+	assert.NoError(
+		t, json.Unmarshal([]byte(`{"fromDependency": {"id": "foo", "output": "bar"}, "default": {"a": 1}}`), &input),
+	)
+	assert.Equal(t, &FromDependency{Id: "foo", Output: "bar"}, input.FromDependency)
+	assert.Equal(t, &apiextensionsv1.JSON{Raw: []byte(`{"a":1}`)}, input.Default)
+
+	input = OperationInput{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"default": "on", "mode": "fast"}`), &input))
+	assert.Nil(t, input.Default)
+	assert.JSONEq(t, `{"default": "on", "mode": "fast"}`, string(input.Extra.Raw))
+}
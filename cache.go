@@ -0,0 +1,68 @@
+package blackstart
+
+import (
+	"context"
+	"sync"
+)
+
+// runCacheContextKey is the context key of the cache of a workflow run.
+type runCacheContextKey struct{}
+
+// runCache holds the values cached by modules for the duration of a workflow run.
+type runCache struct {
+	mu      sync.Mutex
+	entries map[any]*runCacheEntry
+}
+
+// runCacheEntry is a cached value. The mutex is held while the value is loaded, so concurrent
+// operations wait for one load instead of repeating it.
+type runCacheEntry struct {
+	mu     sync.Mutex
+	loaded bool
+	value  any
+}
+
+// withRunCache returns a context with a new, empty cache for a workflow run.
+func withRunCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, runCacheContextKey{}, &runCache{entries: make(map[any]*runCacheEntry)})
+}
+
+// entry returns the cache entry of the key, creating it if needed.
+func (c *runCache) entry(key any) *runCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		e = &runCacheEntry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// RunCached returns the value cached under the key for the current workflow run, calling load to
+// get the value the first time the key is used. Errors are not cached, so a failed load is
+// retried by the next caller. Outside a workflow run, load is called every time.
+//
+// Modules use the cache to share lookups between operations, such as reading the same cloud
+// resource for several operations. Keys must be comparable, and should be of a type defined by the
+// module to avoid collisions with other modules. Cached values are shared and must not be modified.
+func RunCached[T any](ctx context.Context, key any, load func() (T, error)) (T, error) {
+	cache, ok := ctx.Value(runCacheContextKey{}).(*runCache)
+	if !ok || cache == nil {
+		return load()
+	}
+	e := cache.entry(key)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.loaded {
+		if value, ok := e.value.(T); ok {
+			return value, nil
+		}
+	}
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	e.value, e.loaded = value, true
+	return value, nil
+}
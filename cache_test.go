@@ -0,0 +1,68 @@
+package blackstart
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCacheKey struct {
+	name string
+}
+
+func TestRunCached(t *testing.T) {
+	var loads atomic.Int32
+	load := func() (string, error) {
+		loads.Add(1)
+		return "value", nil
+	}
+
+	// Outside a workflow run, values are not cached.
+	for range 2 {
+		value, err := RunCached(context.Background(), testCacheKey{"a"}, load)
+		require.NoError(t, err)
+		assert.Equal(t, "value", value)
+	}
+	assert.Equal(t, int32(2), loads.Load())
+
+	loads.Store(0)
+	ctx := withRunCache(context.Background())
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(
+			func() {
+				value, err := RunCached(ctx, testCacheKey{"a"}, load)
+				assert.NoError(t, err)
+				assert.Equal(t, "value", value)
+			},
+		)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), loads.Load())
+
+	_, err := RunCached(ctx, testCacheKey{"b"}, load)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), loads.Load())
+
+	// Errors are not cached.
+	failures := 0
+	failing := func() (string, error) {
+		failures++
+		return "", errors.New("lookup failed")
+	}
+	_, err = RunCached(ctx, testCacheKey{"c"}, failing)
+	assert.Error(t, err)
+	_, err = RunCached(ctx, testCacheKey{"c"}, failing)
+	assert.Error(t, err)
+	assert.Equal(t, 2, failures)
+
+	// Each run has its own cache.
+	_, err = RunCached(withRunCache(context.Background()), testCacheKey{"a"}, load)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), loads.Load())
+}
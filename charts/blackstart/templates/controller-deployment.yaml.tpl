@@ -32,8 +32,123 @@ spec:
               value: {{ .Values.controller.resyncInterval | quote }}
             - name: BLACKSTART_QUEUE_WAIT_WARNING_THRESHOLD
               value: {{ .Values.controller.queueWaitWarningThreshold | quote }}
+            - name: BLACKSTART_RUNTIME_NAMESPACE
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.namespace
+            - name: BLACKSTART_K8S_DEFAULT_NAMESPACE_FROM_RUNTIME
+              value: {{ .Values.defaultNamespaceFromRuntime | quote }}
+            {{- if .Values.stateStore }}
+            - name: BLACKSTART_STATE_STORE
+              value: {{ .Values.stateStore | quote }}
+            {{- end }}
+            {{- if .Values.eventsOutput }}
+            - name: BLACKSTART_EVENTS_OUTPUT
+              value: {{ .Values.eventsOutput | quote }}
+            {{- end }}
+            {{- with .Values.kubeAPI.qps }}
+            - name: BLACKSTART_KUBE_API_QPS
+              value: {{ . | quote }}
+            {{- end }}
+            {{- with .Values.kubeAPI.burst }}
+            - name: BLACKSTART_KUBE_API_BURST
+              value: {{ . | quote }}
+            {{- end }}
+            {{- with .Values.proxy.httpProxy }}
+            - name: BLACKSTART_HTTP_PROXY
+              value: {{ . | quote }}
+            {{- end }}
+            {{- with .Values.proxy.httpsProxy }}
+            - name: BLACKSTART_HTTPS_PROXY
+              value: {{ . | quote }}
+            {{- end }}
+            {{- with .Values.proxy.noProxy }}
+            - name: BLACKSTART_NO_PROXY
+              value: {{ . | quote }}
+            {{- end }}
+            {{- if .Values.caCertificates.configMapName }}
+            - name: BLACKSTART_CA_CERT_FILES
+              value: {{ printf "/etc/blackstart/ca/%s" .Values.caCertificates.key | quote }}
+            {{- end }}
+            {{- if .Values.decryption.keySecretName }}
+            - name: BLACKSTART_DECRYPTION_KEY_FILE
+              value: {{ printf "/etc/blackstart/age/%s" .Values.decryption.keySecretKey | quote }}
+            {{- end }}
+            {{- with .Values.decryption.kmsKey }}
+            - name: BLACKSTART_DECRYPTION_KMS_KEY
+              value: {{ . | quote }}
+            {{- end }}
             {{- if not .Values.watchAllNamespaces }}
             - name: BLACKSTART_K8S_NAMESPACE
               value: {{ .Release.Namespace | quote }}
             {{- end }}
+            {{- if .Values.controller.triggerApi.enabled }}
+            - name: BLACKSTART_TRIGGER_API_ADDRESS
+              value: {{ printf ":%v" .Values.controller.triggerApi.port | quote }}
+            - name: BLACKSTART_TRIGGER_API_TOKEN_FILE
+              value: {{ printf "/etc/blackstart/trigger-api/%s" .Values.controller.triggerApi.tokenSecretKey | quote }}
+            - name: BLACKSTART_TRIGGER_API_RATE_LIMIT
+              value: {{ .Values.controller.triggerApi.rateLimit | quote }}
+            {{- with .Values.controller.triggerApi.slack }}
+            {{- if .signingSecretName }}
+            - name: BLACKSTART_SLACK_SIGNING_SECRET_FILE
+              value: {{ printf "/etc/blackstart/slack/%s" .signingSecretKey | quote }}
+            {{- if .allowedUsers }}
+            - name: BLACKSTART_SLACK_ALLOWED_USERS
+              value: {{ join "," .allowedUsers | quote }}
+            {{- end }}
+            {{- end }}
+            {{- end }}
+            {{- end }}
+          {{- if .Values.controller.triggerApi.enabled }}
+          ports:
+            - name: trigger-api
+              containerPort: {{ .Values.controller.triggerApi.port }}
+          {{- end }}
+          {{- if or .Values.caCertificates.configMapName .Values.decryption.keySecretName .Values.controller.triggerApi.enabled }}
+          volumeMounts:
+            {{- if .Values.caCertificates.configMapName }}
+            - name: ca-certificates
+              mountPath: /etc/blackstart/ca
+              readOnly: true
+            {{- end }}
+            {{- if .Values.decryption.keySecretName }}
+            - name: decryption-key
+              mountPath: /etc/blackstart/age
+              readOnly: true
+            {{- end }}
+            {{- if .Values.controller.triggerApi.enabled }}
+            - name: trigger-api-token
+              mountPath: /etc/blackstart/trigger-api
+              readOnly: true
+            {{- if .Values.controller.triggerApi.slack.signingSecretName }}
+            - name: slack-signing-secret
+              mountPath: /etc/blackstart/slack
+              readOnly: true
+            {{- end }}
+            {{- end }}
+          {{- end }}
+      {{- if or .Values.caCertificates.configMapName .Values.decryption.keySecretName .Values.controller.triggerApi.enabled }}
+      volumes:
+        {{- if .Values.caCertificates.configMapName }}
+        - name: ca-certificates
+          configMap:
+            name: {{ .Values.caCertificates.configMapName }}
+        {{- end }}
+        {{- if .Values.decryption.keySecretName }}
+        - name: decryption-key
+          secret:
+            secretName: {{ .Values.decryption.keySecretName }}
+        {{- end }}
+        {{- if .Values.controller.triggerApi.enabled }}
+        - name: trigger-api-token
+          secret:
+            secretName: {{ required "controller.triggerApi.tokenSecretName is required when the trigger API is enabled" .Values.controller.triggerApi.tokenSecretName }}
+        {{- if .Values.controller.triggerApi.slack.signingSecretName }}
+        - name: slack-signing-secret
+          secret:
+            secretName: {{ .Values.controller.triggerApi.slack.signingSecretName }}
+        {{- end }}
+        {{- end }}
+      {{- end }}
 {{- end }}
@@ -22,9 +22,81 @@ spec:
               env:
                 - name: BLACKSTART_RUNTIME_MODE
                   value: "once"
+                - name: BLACKSTART_RUNTIME_NAMESPACE
+                  valueFrom:
+                    fieldRef:
+                      fieldPath: metadata.namespace
+                - name: BLACKSTART_K8S_DEFAULT_NAMESPACE_FROM_RUNTIME
+                  value: {{ .Values.defaultNamespaceFromRuntime | quote }}
+              {{- if .Values.stateStore }}
+                - name: BLACKSTART_STATE_STORE
+                  value: {{ .Values.stateStore | quote }}
+              {{- end }}
+              {{- if .Values.eventsOutput }}
+                - name: BLACKSTART_EVENTS_OUTPUT
+                  value: {{ .Values.eventsOutput | quote }}
+              {{- end }}
+              {{- with .Values.kubeAPI.qps }}
+                - name: BLACKSTART_KUBE_API_QPS
+                  value: {{ . | quote }}
+              {{- end }}
+              {{- with .Values.kubeAPI.burst }}
+                - name: BLACKSTART_KUBE_API_BURST
+                  value: {{ . | quote }}
+              {{- end }}
+              {{- with .Values.proxy.httpProxy }}
+                - name: BLACKSTART_HTTP_PROXY
+                  value: {{ . | quote }}
+              {{- end }}
+              {{- with .Values.proxy.httpsProxy }}
+                - name: BLACKSTART_HTTPS_PROXY
+                  value: {{ . | quote }}
+              {{- end }}
+              {{- with .Values.proxy.noProxy }}
+                - name: BLACKSTART_NO_PROXY
+                  value: {{ . | quote }}
+              {{- end }}
+              {{- if .Values.caCertificates.configMapName }}
+                - name: BLACKSTART_CA_CERT_FILES
+                  value: {{ printf "/etc/blackstart/ca/%s" .Values.caCertificates.key | quote }}
+              {{- end }}
+              {{- if .Values.decryption.keySecretName }}
+                - name: BLACKSTART_DECRYPTION_KEY_FILE
+                  value: {{ printf "/etc/blackstart/age/%s" .Values.decryption.keySecretKey | quote }}
+              {{- end }}
+              {{- with .Values.decryption.kmsKey }}
+                - name: BLACKSTART_DECRYPTION_KMS_KEY
+                  value: {{ . | quote }}
+              {{- end }}
               {{- if not .Values.watchAllNamespaces }}
                 - name: BLACKSTART_K8S_NAMESPACE
                   value: {{ .Release.Namespace | quote }}
               {{- end }}
+              {{- if or .Values.caCertificates.configMapName .Values.decryption.keySecretName }}
+              volumeMounts:
+                {{- if .Values.caCertificates.configMapName }}
+                - name: ca-certificates
+                  mountPath: /etc/blackstart/ca
+                  readOnly: true
+                {{- end }}
+                {{- if .Values.decryption.keySecretName }}
+                - name: decryption-key
+                  mountPath: /etc/blackstart/age
+                  readOnly: true
+                {{- end }}
+              {{- end }}
+          {{- if or .Values.caCertificates.configMapName .Values.decryption.keySecretName }}
+          volumes:
+            {{- if .Values.caCertificates.configMapName }}
+            - name: ca-certificates
+              configMap:
+                name: {{ .Values.caCertificates.configMapName }}
+            {{- end }}
+            {{- if .Values.decryption.keySecretName }}
+            - name: decryption-key
+              secret:
+                secretName: {{ .Values.decryption.keySecretName }}
+            {{- end }}
+          {{- end }}
           restartPolicy: OnFailure
 {{- end }}
@@ -0,0 +1,15 @@
+{{- if and .Values.controller.enabled .Values.controller.triggerApi.enabled }}
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .Release.Name }}-trigger-api
+  namespace: {{ .Release.Namespace }}
+spec:
+  selector:
+    app.kubernetes.io/name: {{ .Release.Name }}
+    app.kubernetes.io/instance: {{ .Release.Name }}
+  ports:
+    - name: trigger-api
+      port: {{ .Values.controller.triggerApi.port }}
+      targetPort: trigger-api
+{{- end }}
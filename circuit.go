@@ -0,0 +1,88 @@
+package blackstart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for requests to an endpoint whose circuit is open, after consecutive
+// requests to the endpoint failed. Operations that fail with it are reported as blocked.
+var ErrCircuitOpen = errors.New("endpoint circuit open")
+
+// endpointCircuit tracks the consecutive failures of requests to an endpoint.
+type endpointCircuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+var (
+	circuitsMu sync.Mutex
+	circuits   = make(map[string]*endpointCircuit)
+
+	// circuitNow returns the current time of circuits, and is replaced in tests.
+	circuitNow = time.Now
+)
+
+// circuitConfig returns the number of consecutive failures that open the circuit of an endpoint
+// and how long it stays open, from the runtime configuration. Circuits are disabled when the
+// number of failures is not positive or there is no runtime configuration.
+func circuitConfig(ctx context.Context) (int, time.Duration) {
+	config, _ := ctx.Value(ConfigKey).(*RuntimeConfig)
+	if config == nil || config.CircuitBreakerFailures <= 0 || config.CircuitBreakerCoolDown <= 0 {
+		return 0, 0
+	}
+	return config.CircuitBreakerFailures, config.CircuitBreakerCoolDown
+}
+
+// EndpointAllowed returns an error wrapping ErrCircuitOpen when the circuit of an endpoint is open,
+// so operations using an endpoint that is down fail right away instead of each waiting for it to
+// time out. Endpoints are identified by their scheme and host, such as https://sqladmin.googleapis.com
+// or postgres://db.example.com:5432. Circuits are shared by all workflows of the process.
+func EndpointAllowed(ctx context.Context, endpoint string) error {
+	failures, _ := circuitConfig(ctx)
+	if failures == 0 {
+		return nil
+	}
+	circuitsMu.Lock()
+	defer circuitsMu.Unlock()
+	c, ok := circuits[endpoint]
+	if !ok {
+		return nil
+	}
+	if remaining := c.openUntil.Sub(circuitNow()); remaining > 0 {
+		return fmt.Errorf(
+			"%w: %s failed %d consecutive requests, retrying in %s",
+			ErrCircuitOpen, endpoint, c.failures, remaining.Round(time.Second),
+		)
+	}
+	return nil
+}
+
+// RecordEndpointResult records the result of a request to an endpoint allowed by EndpointAllowed.
+// A nil error closes the circuit of the endpoint. The circuit is opened for the cool-down period
+// of the runtime configuration after the configured number of consecutive failures, and after
+// the first failure once the cool-down has passed. Requests canceled by the caller are ignored.
+func RecordEndpointResult(ctx context.Context, endpoint string, err error) {
+	failures, coolDown := circuitConfig(ctx)
+	if failures == 0 || errors.Is(err, context.Canceled) {
+		return
+	}
+	circuitsMu.Lock()
+	defer circuitsMu.Unlock()
+	if err == nil {
+		delete(circuits, endpoint)
+		return
+	}
+	c, ok := circuits[endpoint]
+	if !ok {
+		c = &endpointCircuit{}
+		circuits[endpoint] = c
+	}
+	c.failures++
+	if c.failures >= failures {
+		c.openUntil = circuitNow().Add(coolDown)
+	}
+}
@@ -0,0 +1,167 @@
+package blackstart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// endpointTestModule checks the endpoint of its url input with an HTTP request.
+type endpointTestModule struct{}
+
+func init() {
+	RegisterModule("endpoint_test_module", func() Module { return &endpointTestModule{} })
+}
+
+func (m *endpointTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "endpoint_test_module",
+		Inputs: map[string]InputValue{
+			"url": {
+				Type:     reflect.TypeFor[string](),
+				Required: true,
+			},
+		},
+	}
+}
+
+func (m *endpointTestModule) Validate(_ Operation) error { return nil }
+func (m *endpointTestModule) Check(ctx ModuleContext) (bool, error) {
+	url, err := ContextInputAs[string](ctx, "url", true)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := (&http.Client{Transport: CountAPICalls(APIProviderGoogle, nil)}).Do(req)
+	if err != nil {
+		return false, err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return true, nil
+}
+func (m *endpointTestModule) Set(_ ModuleContext) error { return nil }
+
+// withCircuits returns a context configured to open circuits after the failures for a minute, and
+// closes all circuits and controls the time of circuits for the test.
+func withCircuits(t *testing.T, failures int) (context.Context, *time.Time) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	circuitNow = func() time.Time { return now }
+	t.Cleanup(
+		func() {
+			circuitNow = time.Now
+			circuitsMu.Lock()
+			clear(circuits)
+			circuitsMu.Unlock()
+		},
+	)
+	config := &RuntimeConfig{CircuitBreakerFailures: failures, CircuitBreakerCoolDown: time.Minute}
+	return context.WithValue(context.Background(), ConfigKey, config), &now
+}
+
+func TestEndpointCircuit(t *testing.T) {
+	ctx, now := withCircuits(t, 2)
+	endpoint := "https://api.example.com"
+	failure := errors.New("connection refused")
+
+	RecordEndpointResult(ctx, endpoint, failure)
+	require.NoError(t, EndpointAllowed(ctx, endpoint))
+	RecordEndpointResult(ctx, endpoint, failure)
+	err := EndpointAllowed(ctx, endpoint)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorContains(t, err, "https://api.example.com failed 2 consecutive requests, retrying in 1m0s")
+	// Other endpoints are not blocked.
+	require.NoError(t, EndpointAllowed(ctx, "https://other.example.com"))
+
+	// After the cool-down, requests are allowed, and the first failure opens the circuit again.
+	*now = now.Add(time.Minute)
+	require.NoError(t, EndpointAllowed(ctx, endpoint))
+	RecordEndpointResult(ctx, endpoint, failure)
+	require.ErrorIs(t, EndpointAllowed(ctx, endpoint), ErrCircuitOpen)
+
+	// A successful request closes the circuit.
+	*now = now.Add(time.Minute)
+	RecordEndpointResult(ctx, endpoint, nil)
+	RecordEndpointResult(ctx, endpoint, failure)
+	require.NoError(t, EndpointAllowed(ctx, endpoint))
+
+	// Requests canceled by the caller are not failures of the endpoint.
+	RecordEndpointResult(ctx, endpoint, context.Canceled)
+	require.NoError(t, EndpointAllowed(ctx, endpoint))
+
+	// Circuits are disabled without a runtime configuration.
+	for range 3 {
+		RecordEndpointResult(context.Background(), "https://disabled.example.com", failure)
+	}
+	require.NoError(t, EndpointAllowed(context.Background(), "https://disabled.example.com"))
+}
+
+func TestCountAPICalls_CircuitBreaker(t *testing.T) {
+	ctx, _ := withCircuits(t, 2)
+	requests := 0
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				requests++
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		),
+	)
+	defer server.Close()
+	client := &http.Client{Transport: CountAPICalls(APIProviderGoogle, nil)}
+
+	get := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+
+	// Server errors are returned to the caller and recorded as failures of the endpoint.
+	require.NoError(t, get())
+	require.NoError(t, get())
+	require.ErrorIs(t, get(), ErrCircuitOpen)
+	assert.Equal(t, 2, requests)
+}
+
+func TestWorkflowExecution_BlockedOperation(t *testing.T) {
+	ctx, _ := withCircuits(t, 1)
+	server := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusBadGateway) }),
+	)
+	defer server.Close()
+	wf := Workflow{
+		Name: "blocked-test",
+		Operations: []Operation{
+			{Id: "a", Module: "endpoint_test_module", Inputs: map[string]Input{"url": NewInputFromValue(server.URL)}},
+		},
+	}
+
+	res := wf.Run(ctx)
+	require.Error(t, res.Err)
+	require.Len(t, res.Operations, 1)
+	assert.False(t, res.Operations[0].Blocked)
+
+	// The next run fails without a request to the endpoint while the circuit is open.
+	res = wf.Run(ctx)
+	require.ErrorIs(t, res.Err, ErrCircuitOpen)
+	require.Len(t, res.Operations, 1)
+	assert.True(t, res.Operations[0].Blocked)
+	assert.Equal(t, int64(0), res.Operations[0].APICalls)
+}
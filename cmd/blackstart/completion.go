@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pezops/blackstart"
+)
+
+// completionScripts are the shell completion scripts by shell. The scripts complete the command
+// line with the completion support of the flag parser, which runs blackstart with the
+// GO_FLAGS_COMPLETION environment variable set and prints the completions instead of running.
+// Flags without completions, such as --workflow-file, fall back to file names.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for blackstart
+_blackstart() {
+    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a args
+    read -ra args <<< "${line}"
+    [[ "${line}" =~ [[:space:]]$ ]] && args+=("")
+    # Flag values after '=' are separate words in bash, so the part of the argument before the
+    # current word is removed from the completions.
+    local arg="${args[${#args[@]}-1]}"
+    local strip="${arg%"${COMP_WORDS[COMP_CWORD]}"}"
+    local IFS=$'\n'
+    COMPREPLY=($(GO_FLAGS_COMPLETION=1 "${COMP_WORDS[0]}" "${args[@]:1}" 2>/dev/null))
+    COMPREPLY=("${COMPREPLY[@]#"${strip}"}")
+    return 0
+}
+complete -o default -F _blackstart blackstart
+`,
+	"zsh": `#compdef blackstart
+# zsh completion for blackstart
+_blackstart() {
+    local -a completions
+    completions=("${(@f)$(GO_FLAGS_COMPLETION=1 "${words[1]}" "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -z "${completions[1]}" ]]; then
+        _files
+        return
+    fi
+    compadd -Q -- "${completions[@]}"
+}
+compdef _blackstart blackstart
+`,
+	"fish": `# fish completion for blackstart
+function __blackstart_complete
+    set -l args (commandline -opc)
+    set -l cmd $args[1]
+    set -e args[1]
+    GO_FLAGS_COMPLETION=1 $cmd $args (commandline -ct) 2>/dev/null
+end
+complete -c blackstart -a '(__blackstart_complete)'
+`,
+}
+
+// writeCompletionScript writes the completion script of a shell to out.
+func writeCompletionScript(shell blackstart.ShellName, out io.Writer) error {
+	name := strings.ToLower(strings.TrimSpace(string(shell)))
+	script, ok := completionScripts[name]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q: expected one of %s", shell, strings.Join(blackstart.ShellNames, ", "))
+	}
+	_, err := io.WriteString(out, script)
+	return err
+}
+
+// describeModule writes the inputs and outputs of a registered module to out, for authoring
+// workflows without the module docs. When the reference holds an input key, only that input is
+// written.
+func describeModule(ref blackstart.ModuleRef, out io.Writer) error {
+	id, key := ref.Split()
+	factory, ok := blackstart.GetRegisteredModules()[id]
+	if !ok {
+		return fmt.Errorf("unknown module %q", id)
+	}
+	info := factory().Info()
+	if key != "" {
+		input, ok := info.Inputs[key]
+		if !ok {
+			return fmt.Errorf("module %s has no input %q", id, key)
+		}
+		_, err := io.WriteString(out, formatModuleInput(key, input))
+		return err
+	}
+
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "%s (%s)\n", info.Id, info.Name)
+	if len(info.Inputs) > 0 {
+		b.WriteString("\ninputs:\n")
+		for _, key := range slices.Sorted(maps.Keys(info.Inputs)) {
+			b.WriteString(formatModuleInput(key, info.Inputs[key]))
+		}
+	}
+	if len(info.Outputs) > 0 {
+		b.WriteString("\noutputs:\n")
+		for _, key := range slices.Sorted(maps.Keys(info.Outputs)) {
+			output := info.Outputs[key]
+			_, _ = fmt.Fprintf(&b, "  %s (%s", key, output.Type)
+			if output.Sensitive {
+				b.WriteString(", sensitive")
+			}
+			_, _ = fmt.Fprintf(&b, ")\n      %s\n", output.Description)
+		}
+	}
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+// formatModuleInput formats an input of a module with its type, flags, default, and description.
+func formatModuleInput(key string, input blackstart.InputValue) string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "  %s (%s", key, input.TypeDisplay())
+	if input.Required {
+		b.WriteString(", required")
+	}
+	if input.Sensitive {
+		b.WriteString(", sensitive")
+	}
+	if input.Immutable {
+		b.WriteString(", immutable")
+	}
+	if !input.Required && input.Default != nil {
+		_, _ = fmt.Fprintf(&b, ", default: %v", input.Default)
+	}
+	_, _ = fmt.Fprintf(&b, ")\n      %s\n", input.Description)
+	return b.String()
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestWriteCompletionScript(t *testing.T) {
+	for _, shell := range blackstart.ShellNames {
+		var out bytes.Buffer
+		require.NoError(t, writeCompletionScript(blackstart.ShellName(shell), &out))
+		assert.Contains(t, out.String(), "GO_FLAGS_COMPLETION=1")
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeCompletionScript(" Bash ", &out))
+	assert.Contains(t, out.String(), "complete -o default -F _blackstart blackstart")
+
+	err := writeCompletionScript("powershell", &out)
+	assert.EqualError(t, err, `unsupported shell "powershell": expected one of bash, zsh, fish`)
+}
+
+func TestDescribeModule(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, describeModule("opsgenie_integration", &out))
+	assert.Contains(t, out.String(), "opsgenie_integration (Opsgenie integration)\n")
+	assert.Contains(t, out.String(), "  api_key (string, sensitive)\n")
+	assert.Contains(t, out.String(), "  name (string, required)\n      Name of the integration.\n")
+	assert.Contains(t, out.String(), "  type (string, immutable, default: API)\n")
+	assert.Contains(t, out.String(), "\noutputs:\n")
+
+	out.Reset()
+	require.NoError(t, describeModule("opsgenie_integration.name", &out))
+	assert.Equal(t, "  name (string, required)\n      Name of the integration.\n", out.String())
+
+	assert.EqualError(t, describeModule("missing_module", &out), `unknown module "missing_module"`)
+	assert.EqualError(
+		t, describeModule("opsgenie_integration.missing", &out), `module opsgenie_integration has no input "missing"`,
+	)
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 	k8swatch "k8s.io/apimachinery/pkg/watch"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -27,20 +30,29 @@ const controllerDispatchInterval = 200 * time.Millisecond
 const controllerQueueRetryDelay = 1 * time.Second
 
 type scheduledWorkflow struct {
-	key       types.NamespacedName
-	workflow  *blackstart.Workflow
-	interval  time.Duration
-	nextRunAt time.Time
-	queuedAt  time.Time
-	running   bool
-	queued    bool
+	key           types.NamespacedName
+	workflow      *blackstart.Workflow
+	interval      time.Duration
+	nextRunAt     time.Time
+	checkInterval time.Duration
+	nextCheckAt   time.Time
+	queuedAt      time.Time
+	running       bool
+	queued        bool
+	// checkOnly is true when the queued or running run is a check-only run.
+	checkOnly bool
+	// runRequested and checkRequested are true when a run or check-only run was triggered on
+	// demand, such as with the trigger API, and has not started yet.
+	runRequested   bool
+	checkRequested bool
 }
 
 type scheduledWorkflowRun struct {
-	entry    *scheduledWorkflow
-	key      types.NamespacedName
-	workflow *blackstart.Workflow
-	queuedAt time.Time
+	entry     *scheduledWorkflow
+	key       types.NamespacedName
+	workflow  *blackstart.Workflow
+	queuedAt  time.Time
+	checkOnly bool
 }
 
 type controllerScheduler struct {
@@ -78,6 +90,52 @@ func computeNextRunFromStatus(now time.Time, lastRan time.Time, hasLastRan bool,
 	return next
 }
 
+// computeNextRunForWorkflow returns the time of the next full run of a workflow from its status.
+// Runs are also scheduled when the maintenance window of the workflow opens. After consecutive
+// failed runs, the next run is scheduled after the backoff recorded in the status instead.
+func computeNextRunForWorkflow(now time.Time, wf *blackstart.Workflow, status v1alpha1.WorkflowStatus) time.Time {
+	if backoff := retryBackoffFromStatus(wf, status); backoff > 0 {
+		return computeNextRunFromStatus(now, status.LastRan.Time, !status.LastRan.IsZero(), backoff)
+	}
+	next := computeNextRunFromStatus(now, status.LastRan.Time, !status.LastRan.IsZero(), wf.ReconcileInterval)
+	if wf.MaintenanceWindow != nil && !status.LastRan.IsZero() {
+		if open := wf.MaintenanceWindow.NextOpen(status.LastRan.Time); open.Before(next) {
+			next = open
+			if next.Before(now) {
+				next = now
+			}
+		}
+	}
+	return next
+}
+
+// retryBackoffFromStatus returns the backoff recorded in the status of a workflow after
+// consecutive failed runs, or zero. The backoff does not apply once the spec of the workflow
+// changes, so a fixed workflow is run without waiting for the backoff.
+func retryBackoffFromStatus(wf *blackstart.Workflow, status v1alpha1.WorkflowStatus) time.Duration {
+	if status.RetryBackoff == nil {
+		return 0
+	}
+	if kwf, ok := wf.Source.(*v1alpha1.Workflow); ok {
+		ready := meta.FindStatusCondition(status.Conditions, v1alpha1.ConditionReady)
+		if ready != nil && ready.ObservedGeneration != kwf.Generation {
+			return 0
+		}
+	}
+	return status.RetryBackoff.Duration
+}
+
+// computeNextCheckFromStatus returns the time of the next check-only run of a workflow. A full run
+// also checks all operations, so the next check is scheduled from the later of the last run and the
+// last check.
+func computeNextCheckFromStatus(now time.Time, status v1alpha1.WorkflowStatus, interval time.Duration) time.Time {
+	last := status.LastRan.Time
+	if status.LastChecked.After(last) {
+		last = status.LastChecked.Time
+	}
+	return computeNextRunFromStatus(now, last, !last.IsZero(), interval)
+}
+
 func (s *controllerScheduler) upsert(now time.Time, wf *blackstart.Workflow) {
 	kwf, ok := wf.Source.(*v1alpha1.Workflow)
 	if !ok {
@@ -88,32 +146,31 @@ func (s *controllerScheduler) upsert(now time.Time, wf *blackstart.Workflow) {
 	entry, found := s.entries[id]
 	if !found {
 		s.entries[id] = &scheduledWorkflow{
-			key:      key,
-			workflow: wf,
-			interval: wf.ReconcileInterval,
-			nextRunAt: computeNextRunFromStatus(
-				now,
-				kwf.Status.LastRan.Time,
-				!kwf.Status.LastRan.IsZero(),
-				wf.ReconcileInterval,
-			),
+			key:           key,
+			workflow:      wf,
+			interval:      wf.ReconcileInterval,
+			nextRunAt:     computeNextRunForWorkflow(now, wf, kwf.Status),
+			checkInterval: wf.CheckInterval,
+			nextCheckAt:   computeNextCheckFromStatus(now, kwf.Status, wf.CheckInterval),
 		}
 		return
 	}
 
 	entry.workflow = wf
 	entry.interval = wf.ReconcileInterval
+	entry.checkInterval = wf.CheckInterval
 	if !entry.running && !entry.queued {
-		entry.nextRunAt = computeNextRunFromStatus(
-			now,
-			kwf.Status.LastRan.Time,
-			!kwf.Status.LastRan.IsZero(),
-			wf.ReconcileInterval,
-		)
+		entry.nextRunAt = computeNextRunForWorkflow(now, wf, kwf.Status)
+		entry.nextCheckAt = computeNextCheckFromStatus(now, kwf.Status, wf.CheckInterval)
 	}
 }
 
-func (s *controllerScheduler) replaceFromWorkflows(now time.Time, workflows []*blackstart.Workflow) {
+// replaceFromWorkflows schedules the workflows and removes the workflows that are no longer present,
+// except for those that are queued or running, or in one of the unavailable namespaces whose
+// workflows could not be listed.
+func (s *controllerScheduler) replaceFromWorkflows(
+	now time.Time, workflows []*blackstart.Workflow, unavailable ...string,
+) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -128,10 +185,14 @@ func (s *controllerScheduler) replaceFromWorkflows(now time.Time, workflows []*b
 		s.upsert(now, wf)
 	}
 
-	for id := range s.entries {
-		if _, ok := present[id]; !ok && !s.entries[id].running && !s.entries[id].queued {
-			delete(s.entries, id)
+	for id, entry := range s.entries {
+		if _, ok := present[id]; ok || entry.running || entry.queued {
+			continue
+		}
+		if slices.Contains(unavailable, entry.key.Namespace) || slices.Contains(unavailable, "") {
+			continue
 		}
+		delete(s.entries, id)
 	}
 }
 
@@ -144,16 +205,21 @@ func (s *controllerScheduler) dueWorkflows(now time.Time) []scheduledWorkflowRun
 		if entry.running || entry.queued {
 			continue
 		}
-		if !entry.nextRunAt.After(now) {
+		// A due full run takes precedence over a due check-only run.
+		runDue := entry.runRequested || !entry.nextRunAt.After(now)
+		checkDue := entry.checkRequested || (entry.checkInterval > 0 && !entry.nextCheckAt.After(now))
+		if runDue || checkDue {
 			entry.queued = true
 			entry.queuedAt = now
+			entry.checkOnly = !runDue
 			out = append(
 				out,
 				scheduledWorkflowRun{
-					entry:    entry,
-					key:      entry.key,
-					workflow: entry.workflow,
-					queuedAt: entry.queuedAt,
+					entry:     entry,
+					key:       entry.key,
+					workflow:  entry.workflow,
+					queuedAt:  entry.queuedAt,
+					checkOnly: entry.checkOnly,
 				},
 			)
 		}
@@ -166,20 +232,48 @@ func (s *controllerScheduler) markRunning(entry *scheduledWorkflow) {
 	defer s.mu.Unlock()
 	entry.running = true
 	entry.queued = false
+	entry.runRequested = false
+	entry.checkRequested = false
 }
 
 func (s *controllerScheduler) markDone(entry *scheduledWorkflow, now time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.markDoneLocked(entry, now)
+}
+
+// markRunComplete marks a run of a workflow as done. The next full run is scheduled from the
+// status recorded by the run, so a workflow that keeps failing is retried after its backoff.
+func (s *controllerScheduler) markRunComplete(entry *scheduledWorkflow, now time.Time, wf *blackstart.Workflow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markDoneLocked(entry, now)
+	kwf, ok := wf.Source.(*v1alpha1.Workflow)
+	if !ok || entry.checkOnly {
+		return
+	}
+	if backoff := retryBackoffFromStatus(wf, kwf.Status); backoff > 0 {
+		entry.nextRunAt = now.Add(backoff)
+	}
+}
+
+func (s *controllerScheduler) markDoneLocked(entry *scheduledWorkflow, now time.Time) {
 	entry.running = false
 	entry.queued = false
-	entry.nextRunAt = now.Add(entry.interval)
+	entry.nextCheckAt = now.Add(entry.checkInterval)
+	if !entry.checkOnly {
+		entry.nextRunAt = entry.workflow.NextRunAfter(now)
+	}
 }
 
 func (s *controllerScheduler) markQueueFull(entry *scheduledWorkflow, now time.Time, retryDelay time.Duration) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	entry.queued = false
+	if entry.checkOnly {
+		entry.nextCheckAt = now.Add(retryDelay)
+		return
+	}
 	entry.nextRunAt = now.Add(retryDelay)
 }
 
@@ -200,6 +294,91 @@ func (s *controllerScheduler) queueHealth(now time.Time) queueHealth {
 	return health
 }
 
+var (
+	// errWorkflowNotScheduled is returned for workflows the controller does not schedule, such as
+	// workflows in namespaces that are not watched.
+	errWorkflowNotScheduled = errors.New("workflow is not scheduled by this controller")
+	// errWorkflowBusy is returned when a run is requested for a workflow that is queued or running.
+	errWorkflowBusy = errors.New("workflow is already queued or running")
+)
+
+// scheduledWorkflowState is a snapshot of the schedule of a workflow.
+type scheduledWorkflowState struct {
+	Queued    bool      `json:"queued"`
+	Running   bool      `json:"running"`
+	CheckOnly bool      `json:"checkOnly,omitempty"`
+	NextRunAt time.Time `json:"nextRun"`
+	// NextCheckAt is only set when check-only runs are scheduled for the workflow.
+	NextCheckAt *time.Time `json:"nextCheck,omitempty"`
+}
+
+// requestRun requests a run of a scheduled workflow on demand. The run is dispatched with the
+// next scheduled runs, regardless of the reconcile interval of the workflow. A check-only run
+// is requested when checkOnly is true.
+func (s *controllerScheduler) requestRun(key types.NamespacedName, checkOnly bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[scheduleKey(key)]
+	if !ok {
+		return errWorkflowNotScheduled
+	}
+	if entry.running || entry.queued {
+		return errWorkflowBusy
+	}
+	if checkOnly {
+		entry.checkRequested = true
+	} else {
+		entry.runRequested = true
+	}
+	return nil
+}
+
+// workflowState returns a snapshot of the schedule of a workflow, or false when the workflow is
+// not scheduled.
+func (s *controllerScheduler) workflowState(key types.NamespacedName) (scheduledWorkflowState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[scheduleKey(key)]
+	if !ok {
+		return scheduledWorkflowState{}, false
+	}
+	state := scheduledWorkflowState{
+		Queued:    entry.queued || entry.runRequested || entry.checkRequested,
+		Running:   entry.running,
+		CheckOnly: entry.checkOnly,
+		NextRunAt: entry.nextRunAt,
+	}
+	if !entry.queued && !entry.running {
+		// A requested run that is not queued yet is a check-only run unless a full run was also
+		// requested.
+		state.CheckOnly = entry.checkRequested && !entry.runRequested
+	}
+	if entry.checkInterval > 0 {
+		nextCheck := entry.nextCheckAt
+		state.NextCheckAt = &nextCheck
+	}
+	return state, true
+}
+
+// scheduledKeys returns the keys of the scheduled workflows, sorted by namespace and name.
+func (s *controllerScheduler) scheduledKeys() []types.NamespacedName {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]types.NamespacedName, 0, len(s.entries))
+	for _, entry := range s.entries {
+		keys = append(keys, entry.key)
+	}
+	slices.SortFunc(
+		keys, func(a, b types.NamespacedName) int {
+			return strings.Compare(scheduleKey(a), scheduleKey(b))
+		},
+	)
+	return keys
+}
+
 func parseControllerRuntimeOptions(config *blackstart.RuntimeConfig) (controllerRuntimeOptions, error) {
 	if config.MaxParallelReconciliations <= 0 {
 		return controllerRuntimeOptions{}, fmt.Errorf("max parallel reconciliations must be greater than 0")
@@ -239,17 +418,38 @@ func parseControllerRuntimeOptions(config *blackstart.RuntimeConfig) (controller
 	}, nil
 }
 
-func listWorkflowsForNamespaces(ctx context.Context, c client.Client, namespaces []string) ([]*blackstart.Workflow, error) {
+// listWorkflowsForNamespaces loads the workflows of the namespaces. Namespaces that cannot be
+// listed, such as one that Blackstart lacks RBAC permissions for, and workflows that cannot be
+// converted are logged and skipped, so the others are still scheduled. The namespaces that could not
+// be listed are returned, and an error is returned only when none of the namespaces could be listed.
+func listWorkflowsForNamespaces(
+	ctx context.Context, c client.Client, namespaces []string,
+) ([]*blackstart.Workflow, []string, error) {
+	logger := loggerFromCtx(ctx)
 	workflows := make([]*blackstart.Workflow, 0)
+	var unavailable []string
+	var errs []error
 	for _, ns := range namespaces {
-		ns = strings.TrimSpace(ns)
-		loaded, err := loadWorkflowsFromK8s(ctx, c, ns)
-		if err != nil {
-			return nil, err
+		load := workflowLister(c).ListWorkflows(ctx, strings.TrimSpace(ns))
+		if load.Err != nil {
+			logger.Warn("unable to load workflows from namespace", "namespace", load.Namespace, "error", load.Err)
+			unavailable = append(unavailable, load.Namespace)
+			errs = append(errs, fmt.Errorf("namespace %q: %w", load.Namespace, load.Err))
+			continue
+		}
+		for _, r := range load.Invalid {
+			logger.Error("invalid workflow", "workflow", r.Name, "namespace", r.Namespace, "error", r.Err)
 		}
-		workflows = append(workflows, loaded...)
+		logger.Debug(
+			"loaded workflows from namespace", "namespace", load.Namespace, "loaded", len(load.Workflows),
+			"invalid", len(load.Invalid),
+		)
+		workflows = append(workflows, load.Workflows...)
+	}
+	if len(namespaces) > 0 && len(unavailable) == len(namespaces) {
+		return nil, unavailable, errors.Join(errs...)
 	}
-	return workflows, nil
+	return workflows, unavailable, nil
 }
 
 func parseNamespaces(config *blackstart.RuntimeConfig) []string {
@@ -370,15 +570,30 @@ func runWorkflowsControllerInK8s(ctx context.Context, kubeClient client.Client)
 	}
 	namespaces := parseNamespaces(config)
 	scheduler := newControllerScheduler()
+	workflowRunner := newWorkflowRunner(ctx, kubeClient)
 	var activeKeys sync.Map // key(namespace/name) currently queued or running
 
 	queue := make(chan scheduledWorkflowRun, opts.MaxParallel*4)
 
-	workflows, err := listWorkflowsForNamespaces(ctx, kubeClient, namespaces)
+	workflows, _, err := listWorkflowsForNamespaces(ctx, kubeClient, namespaces)
 	if err != nil {
 		return fmt.Errorf("error loading workflows from Kubernetes: %w", err)
 	}
 	scheduler.replaceFromWorkflows(time.Now(), workflows)
+	if config.TriggerAPIAddress != "" {
+		api, apiErr := newTriggerAPI(config, scheduler, kubeClient, logger)
+		if apiErr != nil {
+			return apiErr
+		}
+		if config.SlackSigningSecretFile != "" {
+			if api.slack, apiErr = newSlackChatOps(ctx, config, scheduler, kubeClient, logger); apiErr != nil {
+				return apiErr
+			}
+		}
+		if apiErr = serveTriggerAPI(ctx, config.TriggerAPIAddress, api.handler(), logger); apiErr != nil {
+			return apiErr
+		}
+	}
 	refreshCh := make(chan struct{}, 1)
 	startWorkflowWatches(ctx, kubeClient, namespaces, logger, refreshCh)
 
@@ -428,10 +643,11 @@ func runWorkflowsControllerInK8s(ctx context.Context, kubeClient client.Client)
 						releaseActive()
 						continue
 					}
-					if runErr := runWorkflowInK8s(ctx, kubeClient, currentWorkflow); runErr != nil {
+					currentWorkflow.CheckOnly = runItem.checkOnly
+					if runErr := workflowRunner.RunWorkflow(ctx, currentWorkflow); runErr != nil {
 						logger.Warn("workflow reconciliation failed", "workflow", runItem.key.String(), "error", runErr)
 					}
-					scheduler.markDone(runItem.entry, time.Now())
+					scheduler.markRunComplete(runItem.entry, time.Now(), currentWorkflow)
 					releaseActive()
 				}
 			}
@@ -443,12 +659,12 @@ func runWorkflowsControllerInK8s(ctx context.Context, kubeClient client.Client)
 	resyncTicker := time.NewTicker(opts.ResyncInterval)
 	defer resyncTicker.Stop()
 	refreshFromCluster := func() {
-		loaded, loadErr := listWorkflowsForNamespaces(ctx, kubeClient, namespaces)
+		loaded, unavailable, loadErr := listWorkflowsForNamespaces(ctx, kubeClient, namespaces)
 		if loadErr != nil {
 			logger.Error("error refreshing workflows from kubernetes", "error", loadErr)
 			return
 		}
-		scheduler.replaceFromWorkflows(time.Now(), loaded)
+		scheduler.replaceFromWorkflows(time.Now(), loaded, unavailable...)
 	}
 
 	for {
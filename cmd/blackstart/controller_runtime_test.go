@@ -2,13 +2,18 @@ package main
 
 import (
 	"context"
+	"log/slog"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	"github.com/pezops/blackstart"
 	"github.com/pezops/blackstart/api/v1alpha1"
@@ -28,6 +33,41 @@ func TestComputeNextRunFromStatus_RestoreFromLastRan(t *testing.T) {
 	})
 }
 
+func TestComputeNextRunForWorkflow_MaintenanceWindow(t *testing.T) {
+	now := time.Date(2026, 3, 7, 1, 0, 0, 0, time.UTC)
+	mw, err := blackstart.NewMaintenanceWindow("0 2 * * SAT", 4*time.Hour, "")
+	require.NoError(t, err)
+	wf := &blackstart.Workflow{ReconcileInterval: 24 * time.Hour, MaintenanceWindow: mw}
+	status := v1alpha1.WorkflowStatus{LastRan: metav1.NewTime(now.Add(-time.Hour))}
+
+	// The run is scheduled when the window opens, before the reconcile interval has passed.
+	require.Equal(t, now.Add(time.Hour), computeNextRunForWorkflow(now, wf, status))
+
+	// A window that opened since the last run is due immediately.
+	require.Equal(t, now.Add(2*time.Hour), computeNextRunForWorkflow(now.Add(2*time.Hour), wf, status))
+}
+
+func TestComputeNextRunForWorkflow_RetryBackoff(t *testing.T) {
+	now := time.Date(2026, 3, 18, 12, 0, 0, 0, time.UTC)
+	kwf := &v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	wf := &blackstart.Workflow{ReconcileInterval: 5 * time.Minute, Source: kwf}
+	status := v1alpha1.WorkflowStatus{
+		Conditions: []metav1.Condition{
+			{Type: v1alpha1.ConditionReady, Status: metav1.ConditionFalse, ObservedGeneration: 2},
+		},
+		LastRan:             metav1.NewTime(now.Add(-10 * time.Minute)),
+		ConsecutiveFailures: 3,
+		RetryBackoff:        &metav1.Duration{Duration: 20 * time.Minute},
+	}
+
+	// The backoff recorded in the status delays the next run beyond the reconcile interval.
+	require.Equal(t, now.Add(10*time.Minute), computeNextRunForWorkflow(now, wf, status))
+
+	// A changed spec is run without waiting for the backoff.
+	kwf.Generation = 3
+	require.Equal(t, now, computeNextRunForWorkflow(now, wf, status))
+}
+
 func TestRunWorkflowsControllerInK8s_RestoresOverdueScheduleFromStatus(t *testing.T) {
 	scheme := runtime.NewScheme()
 	require.NoError(t, v1alpha1.AddToScheme(scheme))
@@ -202,6 +242,69 @@ func TestControllerScheduler_QueueFullBackoffPreventsImmediateReselect(t *testin
 	require.Len(t, due, 1)
 }
 
+func TestControllerScheduler_CheckOnlyRuns(t *testing.T) {
+	scheduler := newControllerScheduler()
+	now := time.Now()
+	wf := &blackstart.Workflow{
+		Name:              "check-only",
+		ReconcileInterval: time.Hour,
+		CheckInterval:     10 * time.Minute,
+		Source: &v1alpha1.Workflow{
+			ObjectMeta: metav1.ObjectMeta{Name: "check-only", Namespace: "default"},
+			Spec:       v1alpha1.WorkflowSpec{Operations: []v1alpha1.Operation{}},
+			Status: v1alpha1.WorkflowStatus{
+				LastRan:     metav1.NewTime(now.Add(-30 * time.Minute)),
+				LastChecked: metav1.NewTime(now.Add(-5 * time.Minute)),
+			},
+		},
+	}
+
+	// The next check is scheduled from the last check, which is later than the last run.
+	scheduler.replaceFromWorkflows(now, []*blackstart.Workflow{wf})
+	require.Empty(t, scheduler.dueWorkflows(now))
+	due := scheduler.dueWorkflows(now.Add(5 * time.Minute))
+	require.Len(t, due, 1)
+	require.True(t, due[0].checkOnly)
+
+	// A check-only run does not move the next full run.
+	scheduler.markRunning(due[0].entry)
+	scheduler.markDone(due[0].entry, now.Add(6*time.Minute))
+	require.Empty(t, scheduler.dueWorkflows(now.Add(15*time.Minute)))
+
+	// A due full run takes precedence over a due check-only run, and moves the next check.
+	due = scheduler.dueWorkflows(now.Add(30 * time.Minute))
+	require.Len(t, due, 1)
+	require.False(t, due[0].checkOnly)
+	scheduler.markRunning(due[0].entry)
+	scheduler.markDone(due[0].entry, now.Add(31*time.Minute))
+	require.Empty(t, scheduler.dueWorkflows(now.Add(40*time.Minute)))
+	due = scheduler.dueWorkflows(now.Add(41 * time.Minute))
+	require.Len(t, due, 1)
+	require.True(t, due[0].checkOnly)
+}
+
+func TestControllerScheduler_MarkRunCompleteAppliesRetryBackoff(t *testing.T) {
+	scheduler := newControllerScheduler()
+	now := time.Now()
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "failing", Namespace: "default"},
+		Spec:       v1alpha1.WorkflowSpec{Operations: []v1alpha1.Operation{}},
+	}
+	wf := &blackstart.Workflow{Name: "failing", ReconcileInterval: time.Minute, Source: kwf}
+
+	scheduler.replaceFromWorkflows(now, []*blackstart.Workflow{wf})
+	due := scheduler.dueWorkflows(now)
+	require.Len(t, due, 1)
+	scheduler.markRunning(due[0].entry)
+
+	// The run recorded a backoff in the status of the workflow.
+	kwf.Status.ConsecutiveFailures = 2
+	kwf.Status.RetryBackoff = &metav1.Duration{Duration: 2 * time.Minute}
+	scheduler.markRunComplete(due[0].entry, now, wf)
+	require.Empty(t, scheduler.dueWorkflows(now.Add(time.Minute)))
+	require.Len(t, scheduler.dueWorkflows(now.Add(2*time.Minute)), 1)
+}
+
 func TestControllerScheduler_ReplaceDoesNotDropRunningEntry(t *testing.T) {
 	scheduler := newControllerScheduler()
 	now := time.Now()
@@ -228,6 +331,64 @@ func TestControllerScheduler_ReplaceDoesNotDropRunningEntry(t *testing.T) {
 	require.True(t, exists, "running entry should not be dropped during refresh")
 }
 
+func TestListWorkflowsForNamespaces_SkipsUnavailableNamespaces(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "valid", Namespace: "team-a"}},
+			&v1alpha1.Workflow{
+				ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "team-a"},
+				Spec:       v1alpha1.WorkflowSpec{ReconcileInterval: "soon"},
+			},
+		).
+		WithInterceptorFuncs(
+			interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					listOpts := &client.ListOptions{}
+					listOpts.ApplyOptions(opts)
+					if listOpts.Namespace == "team-b" {
+						return apierrors.NewForbidden(
+							v1alpha1.SchemeGroupVersion.WithResource("workflows").GroupResource(), "", nil,
+						)
+					}
+					return c.List(ctx, list, opts...)
+				},
+			},
+		).
+		Build()
+	ctx := context.WithValue(context.Background(), blackstart.LoggerKey, slog.New(slog.DiscardHandler))
+
+	workflows, unavailable, err := listWorkflowsForNamespaces(ctx, fakeClient, []string{"team-a", "team-b"})
+	require.NoError(t, err)
+	require.Len(t, workflows, 1)
+	require.Equal(t, "valid", workflows[0].Name)
+	require.Equal(t, []string{"team-b"}, unavailable)
+
+	// The scheduled workflows of a namespace that could not be listed are kept.
+	scheduler := newControllerScheduler()
+	now := time.Now()
+	scheduler.replaceFromWorkflows(
+		now, []*blackstart.Workflow{
+			{
+				Name:              "other",
+				ReconcileInterval: time.Minute,
+				Source:            &v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-b"}},
+			},
+		},
+	)
+	scheduler.replaceFromWorkflows(now, workflows, unavailable...)
+	require.Equal(
+		t,
+		[]types.NamespacedName{{Namespace: "team-a", Name: "valid"}, {Namespace: "team-b", Name: "other"}},
+		scheduler.scheduledKeys(),
+	)
+
+	_, _, err = listWorkflowsForNamespaces(ctx, fakeClient, []string{"team-b"})
+	require.ErrorContains(t, err, `namespace "team-b": error listing workflows`)
+}
+
 func TestParseControllerRuntimeOptions(t *testing.T) {
 	_, err := parseControllerRuntimeOptions(
 		&blackstart.RuntimeConfig{
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+const (
+	convertToResource = "resource"
+	convertToFile     = "file"
+)
+
+// workflowManifest is the YAML form of a Workflow resource used for conversions. Only the fields
+// shared with a workflow file are included, so converted manifests do not contain status or
+// server-populated metadata.
+type workflowManifest struct {
+	APIVersion string                   `yaml:"apiVersion"`
+	Kind       string                   `yaml:"kind"`
+	Metadata   workflowManifestMetadata `yaml:"metadata"`
+	Spec       v1alpha1.WorkflowSpec    `yaml:"spec"`
+}
+
+// workflowManifestMetadata is the metadata of a converted Workflow resource.
+type workflowManifestMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// convertWorkflowFile reads the workflow from the configured workflow file, converts it to the
+// format selected with --convert-to, and writes the result to out.
+func convertWorkflowFile(config *blackstart.RuntimeConfig, out io.Writer) error {
+	path := strings.TrimSpace(config.WorkflowFile)
+	if path == "" {
+		return fmt.Errorf("a workflow file must be set with --workflow-file")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading workflow file: %w", err)
+	}
+
+	var converted []byte
+	switch strings.ToLower(strings.TrimSpace(config.ConvertTo)) {
+	case convertToResource:
+		converted, err = workflowFileToResource(data, config.ConvertName, config.ConvertNamespace)
+	case convertToFile:
+		converted, err = workflowResourceToFile(data)
+	default:
+		return fmt.Errorf(
+			"invalid conversion format %q: expected %q or %q", config.ConvertTo, convertToResource, convertToFile,
+		)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(converted)
+	return err
+}
+
+// workflowFileToResource converts a workflow file to a Workflow resource manifest. The workflow
+// name is used as the resource name unless a name is given.
+func workflowFileToResource(data []byte, name, namespace string) ([]byte, error) {
+	var wf v1alpha1.WorkflowConfigFile
+	if err := yaml.Unmarshal(data, &wf); err != nil {
+		return nil, fmt.Errorf("error unmarshalling workflow: %w", err)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = wf.Name
+	}
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return nil, fmt.Errorf(
+			"%q is not a valid resource name, set a name with --convert-name: %s", name, strings.Join(errs, ", "),
+		)
+	}
+
+	manifest := workflowManifest{
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Kind:       "Workflow",
+		Metadata: workflowManifestMetadata{
+			Name:      name,
+			Namespace: strings.TrimSpace(namespace),
+		},
+		Spec: wf.WorkflowSpec,
+	}
+	return marshalWorkflowYAML(manifest)
+}
+
+// workflowResourceToFile converts a Workflow resource manifest to a workflow file. The resource
+// name is used as the workflow name. Values of the parameters annotation are not part of a
+// workflow file and are dropped; they are supplied with --set when running the file.
+func workflowResourceToFile(data []byte) ([]byte, error) {
+	var manifest workflowManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("error unmarshalling workflow resource: %w", err)
+	}
+	if manifest.APIVersion != v1alpha1.SchemeGroupVersion.String() || manifest.Kind != "Workflow" {
+		return nil, fmt.Errorf(
+			"expected a %s Workflow resource, got apiVersion %q and kind %q",
+			v1alpha1.SchemeGroupVersion.String(), manifest.APIVersion, manifest.Kind,
+		)
+	}
+	if manifest.Metadata.Name == "" {
+		return nil, fmt.Errorf("workflow resource is missing metadata.name")
+	}
+
+	wf := v1alpha1.WorkflowConfigFile{
+		Name:         manifest.Metadata.Name,
+		WorkflowSpec: manifest.Spec,
+	}
+	return marshalWorkflowYAML(wf)
+}
+
+// marshalWorkflowYAML marshals a workflow with the two space indentation used in the examples.
+func marshalWorkflowYAML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("error marshalling workflow: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("error marshalling workflow: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+const convertWorkflowYAML = `name: tenant-db
+description: Tenant database
+reconcileInterval: 10m
+parameters:
+  - name: tenant
+    required: true
+  - name: region
+    default: us-central1
+operations:
+  - id: instance
+    module: google_cloudsql_managed_instance
+    inputs:
+      project: example
+      region:
+        fromParameter: region
+      instance: shared
+  - id: database
+    module: google_cloudsql_database
+    dependsOn:
+      - instance
+    inputs:
+      instance:
+        fromDependency:
+          id: instance
+          output: instance
+      database:
+        fromParameter: tenant
+      charset: "true"
+      labels:
+        team: data
+`
+
+func TestWorkflowFileToResource(t *testing.T) {
+	out, err := workflowFileToResource([]byte(convertWorkflowYAML), "", "tenants")
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "apiVersion: blackstart.pezops.github.io/v1alpha1\nkind: Workflow\n")
+	assert.Contains(t, string(out), "metadata:\n  name: tenant-db\n  namespace: tenants\n")
+	assert.Contains(t, string(out), "      region:\n          fromParameter: region\n")
+	assert.Contains(t, string(out), "charset: \"true\"")
+
+	out, err = workflowFileToResource([]byte(convertWorkflowYAML), "other-name", "")
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "metadata:\n  name: other-name\nspec:\n")
+
+	_, err = workflowFileToResource([]byte("name: Tenant DB\noperations: []\n"), "", "")
+	assert.ErrorContains(t, err, `"Tenant DB" is not a valid resource name`)
+}
+
+func TestWorkflowConversionRoundTrip(t *testing.T) {
+	resource, err := workflowFileToResource([]byte(convertWorkflowYAML), "", "tenants")
+	require.NoError(t, err)
+	file, err := workflowResourceToFile(resource)
+	require.NoError(t, err)
+
+	params := []string{"tenant=acme"}
+	want, err := workflowFromConfigBytes([]byte(convertWorkflowYAML), params)
+	require.NoError(t, err)
+	got, err := workflowFromConfigBytes(file, params)
+	require.NoError(t, err)
+
+	assert.Equal(t, want.Name, got.Name)
+	assert.Equal(t, want.Description, got.Description)
+	assert.Equal(t, want.ReconcileInterval, got.ReconcileInterval)
+	require.Len(t, got.Operations, len(want.Operations))
+	for i, op := range want.Operations {
+		assert.Equal(t, op.Id, got.Operations[i].Id)
+		assert.Equal(t, op.Module, got.Operations[i].Module)
+		assert.Equal(t, op.DependsOn, got.Operations[i].DependsOn)
+		require.Len(t, got.Operations[i].Inputs, len(op.Inputs))
+		for key, input := range op.Inputs {
+			gotInput := got.Operations[i].Inputs[key]
+			require.NotNil(t, gotInput, key)
+			assert.Equal(t, input.IsStatic(), gotInput.IsStatic(), key)
+			if input.IsStatic() {
+				assert.Equal(t, input.Any(), gotInput.Any(), key)
+			} else {
+				assert.Equal(t, input.DependencyId(), gotInput.DependencyId(), key)
+				assert.Equal(t, input.OutputKey(), gotInput.OutputKey(), key)
+			}
+		}
+	}
+}
+
+func TestWorkflowResourceToFile_InvalidResource(t *testing.T) {
+	_, err := workflowResourceToFile([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n"))
+	assert.ErrorContains(t, err, `got apiVersion "v1" and kind "ConfigMap"`)
+
+	_, err = workflowResourceToFile(
+		[]byte("apiVersion: blackstart.pezops.github.io/v1alpha1\nkind: Workflow\nspec: {}\n"),
+	)
+	assert.ErrorContains(t, err, "missing metadata.name")
+}
+
+func TestConvertWorkflowFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(convertWorkflowYAML), 0o600))
+
+	var out bytes.Buffer
+	err := convertWorkflowFile(
+		&blackstart.RuntimeConfig{WorkflowFile: path, ConvertTo: "Resource", ConvertNamespace: "tenants"}, &out,
+	)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "kind: Workflow")
+
+	err = convertWorkflowFile(&blackstart.RuntimeConfig{WorkflowFile: path, ConvertTo: "json"}, &out)
+	assert.ErrorContains(t, err, `invalid conversion format "json"`)
+
+	err = convertWorkflowFile(&blackstart.RuntimeConfig{ConvertTo: convertToFile}, &out)
+	assert.ErrorContains(t, err, "--workflow-file")
+}
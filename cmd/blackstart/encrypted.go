@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+
+	"github.com/pezops/blackstart"
+)
+
+// ageHeaderPrefix is the prefix of the header of binary age encrypted files.
+const ageHeaderPrefix = "age-encryption.org/"
+
+// kmsDecryptTimeout limits the time spent decrypting a single input with Cloud KMS.
+const kmsDecryptTimeout = 30 * time.Second
+
+// inputDecrypter decrypts encrypted operation inputs when workflows are loaded. It is nil when no
+// decryption key is configured, and encrypted inputs then fail to load.
+var inputDecrypter *decrypter
+
+// decrypter decrypts encrypted input values with age identities or a Cloud KMS key.
+type decrypter struct {
+	identities []age.Identity
+	kmsKey     string
+
+	// kmsDecrypt decrypts a ciphertext with the Cloud KMS key.
+	kmsDecrypt func(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// newDecrypter creates the decrypter for the decryption settings of the runtime configuration.
+// Nil is returned when neither an age identity file nor a Cloud KMS key is configured.
+func newDecrypter(ctx context.Context, config *blackstart.RuntimeConfig) (*decrypter, error) {
+	keyFile := strings.TrimSpace(config.DecryptionKeyFile)
+	kmsKey := strings.TrimSpace(config.DecryptionKMSKey)
+	if keyFile == "" && kmsKey == "" {
+		return nil, nil
+	}
+
+	d := &decrypter{kmsKey: kmsKey}
+	if keyFile != "" {
+		f, err := os.Open(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read decryption key file: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		d.identities, err = age.ParseIdentities(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid decryption key file %s: %w", keyFile, err)
+		}
+	}
+	if kmsKey != "" {
+		svc, err := cloudkms.NewService(ctx, option.WithUserAgent(blackstart.UserAgent))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Cloud KMS service: %w", err)
+		}
+		d.kmsDecrypt = func(ctx context.Context, ciphertext []byte) ([]byte, error) {
+			resp, err := svc.Projects.Locations.KeyRings.CryptoKeys.Decrypt(
+				kmsKey, &cloudkms.DecryptRequest{Ciphertext: base64.StdEncoding.EncodeToString(ciphertext)},
+			).Context(ctx).Do()
+			if err != nil {
+				return nil, err
+			}
+			return base64.StdEncoding.DecodeString(resp.Plaintext)
+		}
+	}
+	return d, nil
+}
+
+// decrypt decrypts an encrypted input value. ASCII armored and base64 encoded age files are
+// decrypted with the age identities, and other base64 encoded values with the Cloud KMS key.
+func (d *decrypter) decrypt(ciphertext string) (string, error) {
+	text := strings.TrimSpace(ciphertext)
+
+	var src io.Reader
+	if strings.HasPrefix(text, armor.Header) {
+		src = armor.NewReader(strings.NewReader(text))
+	} else {
+		data, err := base64.StdEncoding.DecodeString(text)
+		if err != nil {
+			return "", fmt.Errorf("encrypted value must be an ASCII armored age file or base64 encoded")
+		}
+		if !bytes.HasPrefix(data, []byte(ageHeaderPrefix)) {
+			return d.decryptKMS(data)
+		}
+		src = bytes.NewReader(data)
+	}
+
+	if len(d.identities) == 0 {
+		return "", fmt.Errorf("age encrypted value requires a decryption key file")
+	}
+	r, err := age.Decrypt(src, d.identities...)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt age encrypted value: %w", err)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt age encrypted value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// decryptKMS decrypts a Cloud KMS ciphertext with the configured key.
+func (d *decrypter) decryptKMS(ciphertext []byte) (string, error) {
+	if d.kmsDecrypt == nil {
+		return "", fmt.Errorf("cloud KMS encrypted value requires a decryption KMS key")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kmsDecryptTimeout)
+	defer cancel()
+	plaintext, err := d.kmsDecrypt(ctx, ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt value with Cloud KMS key %s: %w", d.kmsKey, err)
+	}
+	return string(plaintext), nil
+}
+
+// decryptInput decrypts the value of an encrypted input with the configured decrypter.
+func decryptInput(ciphertext string) (string, error) {
+	if inputDecrypter == nil {
+		return "", fmt.Errorf(
+			"no decryption key is configured: set %s or %s",
+			blackstart.DecryptionKeyFileEnv, blackstart.DecryptionKMSKeyEnv,
+		)
+	}
+	return inputDecrypter.decrypt(ciphertext)
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// ageEncrypt encrypts the plaintext to the recipient, ASCII armored when armored is set and base64
+// encoded otherwise.
+func ageEncrypt(t *testing.T, recipient age.Recipient, plaintext string, armored bool) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var dst io.Writer = &buf
+	var armorWriter io.WriteCloser
+	if armored {
+		armorWriter = armor.NewWriter(&buf)
+		dst = armorWriter
+	}
+	w, err := age.Encrypt(dst, recipient)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, plaintext)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	if armored {
+		require.NoError(t, armorWriter.Close())
+		return buf.String()
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func TestNewDecrypter(t *testing.T) {
+	d, err := newDecrypter(context.Background(), &blackstart.RuntimeConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, d)
+
+	dir := t.TempDir()
+	_, err = newDecrypter(
+		context.Background(), &blackstart.RuntimeConfig{DecryptionKeyFile: filepath.Join(dir, "missing")},
+	)
+	assert.ErrorContains(t, err, "unable to read decryption key file")
+
+	invalidFile := filepath.Join(dir, "invalid.txt")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("not a key\n"), 0o600))
+	_, err = newDecrypter(context.Background(), &blackstart.RuntimeConfig{DecryptionKeyFile: invalidFile})
+	assert.ErrorContains(t, err, "invalid decryption key file")
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "key.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte("# key\n"+identity.String()+"\n"), 0o600))
+	d, err = newDecrypter(context.Background(), &blackstart.RuntimeConfig{DecryptionKeyFile: keyFile})
+	require.NoError(t, err)
+	require.NotNil(t, d)
+	assert.Len(t, d.identities, 1)
+}
+
+func TestDecrypter_Decrypt(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	kmsCiphertext := []byte("kms-ciphertext")
+	d := &decrypter{
+		identities: []age.Identity{identity},
+		kmsKey:     "projects/p/locations/global/keyRings/r/cryptoKeys/k",
+		kmsDecrypt: func(_ context.Context, ciphertext []byte) ([]byte, error) {
+			if !bytes.Equal(ciphertext, kmsCiphertext) {
+				return nil, errors.New("decryption failed")
+			}
+			return []byte("kms-secret"), nil
+		},
+	}
+
+	tests := []struct {
+		name       string
+		decrypter  *decrypter
+		ciphertext string
+		expected   string
+		err        string
+	}{
+		{
+			name:       "age_armored",
+			decrypter:  d,
+			ciphertext: ageEncrypt(t, identity.Recipient(), "armored-secret", true),
+			expected:   "armored-secret",
+		},
+		{
+			name:       "age_base64",
+			decrypter:  d,
+			ciphertext: ageEncrypt(t, identity.Recipient(), "binary-secret", false),
+			expected:   "binary-secret",
+		},
+		{
+			name:       "age_other_recipient",
+			decrypter:  d,
+			ciphertext: ageEncrypt(t, other.Recipient(), "secret", true),
+			err:        "unable to decrypt age encrypted value",
+		},
+		{
+			name:       "age_without_identities",
+			decrypter:  &decrypter{},
+			ciphertext: ageEncrypt(t, identity.Recipient(), "secret", true),
+			err:        "requires a decryption key file",
+		},
+		{
+			name:       "kms",
+			decrypter:  d,
+			ciphertext: base64.StdEncoding.EncodeToString(kmsCiphertext),
+			expected:   "kms-secret",
+		},
+		{
+			name:       "kms_failure",
+			decrypter:  d,
+			ciphertext: base64.StdEncoding.EncodeToString([]byte("other")),
+			err:        "unable to decrypt value with Cloud KMS key",
+		},
+		{
+			name:       "kms_without_key",
+			decrypter:  &decrypter{identities: []age.Identity{identity}},
+			ciphertext: base64.StdEncoding.EncodeToString(kmsCiphertext),
+			err:        "requires a decryption KMS key",
+		},
+		{
+			name:       "invalid",
+			decrypter:  d,
+			ciphertext: "not encrypted!",
+			err:        "ASCII armored age file or base64 encoded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				plaintext, err := tt.decrypter.decrypt(tt.ciphertext)
+				if tt.err != "" {
+					assert.ErrorContains(t, err, tt.err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, plaintext)
+			},
+		)
+	}
+}
+
+func TestLoadOperations_EncryptedInput(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	ops := []v1alpha1.Operation{
+		{
+			Id:     "op",
+			Module: "test",
+			Inputs: map[string]*v1alpha1.OperationInput{
+				"password": {Encrypted: ageEncrypt(t, identity.Recipient(), "s3cret", true)},
+			},
+		},
+	}
+
+	original := inputDecrypter
+	t.Cleanup(func() { inputDecrypter = original })
+
+	inputDecrypter = nil
+	_, err = loadOperations(ops, nil)
+	assert.ErrorContains(t, err, "operation op input password")
+	assert.ErrorContains(t, err, blackstart.DecryptionKeyFileEnv)
+
+	inputDecrypter = &decrypter{identities: []age.Identity{identity}}
+	loaded, err := loadOperations(ops, nil)
+	require.NoError(t, err)
+	require.Len(t, loaded, 1)
+	assert.Equal(t, "s3cret", loaded[0].Inputs["password"].Any())
+}
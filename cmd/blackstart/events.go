@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pezops/blackstart"
+)
+
+// openEventSink opens the NDJSON stream of run events of the runtime configuration. It returns a
+// nil sink when no events output is configured. Events are written to stdout with `-`, which
+// requires logs to be written to a file so the stream only contains events.
+func openEventSink(config *blackstart.RuntimeConfig) (blackstart.EventSink, func(), error) {
+	switch config.EventsOutput {
+	case "":
+		return nil, func() {}, nil
+	case "-":
+		if config.LogOutput == "" || config.LogOutput == "-" {
+			return nil, nil, fmt.Errorf("events cannot be written to stdout with the logs, set --log-output to a file")
+		}
+		return blackstart.NewNDJSONEventSink(os.Stdout), func() {}, nil
+	}
+	file, err := os.OpenFile(config.EventsOutput, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open events output: %w", err)
+	}
+	return blackstart.NewNDJSONEventSink(file), func() { _ = file.Close() }, nil
+}
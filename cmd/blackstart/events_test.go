@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestOpenEventSink(t *testing.T) {
+	sink, closeSink, err := openEventSink(&blackstart.RuntimeConfig{})
+	require.NoError(t, err)
+	require.Nil(t, sink)
+	closeSink()
+
+	// The event stream and the logs cannot share stdout.
+	_, _, err = openEventSink(&blackstart.RuntimeConfig{EventsOutput: "-"})
+	require.ErrorContains(t, err, "set --log-output to a file")
+	sink, _, err = openEventSink(&blackstart.RuntimeConfig{EventsOutput: "-", LogOutput: "/var/log/blackstart.log"})
+	require.NoError(t, err)
+	require.NotNil(t, sink)
+
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	sink, closeSink, err = openEventSink(&blackstart.RuntimeConfig{EventsOutput: path})
+	require.NoError(t, err)
+	require.NoError(t, sink.Emit(blackstart.Event{Type: blackstart.EventRunStarted, Run: "r1", Workflow: "wf"}))
+	require.NoError(t, sink.Emit(blackstart.Event{Type: blackstart.EventRunFinished, Run: "r1", Workflow: "wf"}))
+	closeSink()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], `"type":"run_started"`)
+
+	_, _, err = openEventSink(&blackstart.RuntimeConfig{EventsOutput: filepath.Join(t.TempDir(), "missing", "events")})
+	require.ErrorContains(t, err, "failed to open events output")
+}
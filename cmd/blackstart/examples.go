@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// inputSourceKeys are the keys of an operation input that take its value from elsewhere, by their
+// normalized form, so misspelled keys such as `from_dependency` are reported instead of being used
+// as a static map value.
+var inputSourceKeys = map[string]string{
+	"fromdependency": "fromDependency",
+	"fromparameter":  "fromParameter",
+	"fromfile":       "fromFile",
+	"encrypted":      "encrypted",
+}
+
+// validateModuleExamples validates the examples of all registered modules and writes the problems
+// found to out. It returns an error when any example is invalid.
+func validateModuleExamples(out io.Writer) error {
+	problems := moduleExampleProblems(blackstart.GetRegisteredModules())
+	for _, problem := range problems {
+		_, _ = fmt.Fprintln(out, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d module examples are invalid", len(problems))
+	}
+	return nil
+}
+
+// moduleExampleProblems validates the examples of the modules, sorted by module and example title.
+func moduleExampleProblems(modules map[string]func() blackstart.Module) []string {
+	var problems []string
+	for _, id := range slices.Sorted(maps.Keys(modules)) {
+		info := modules[id]().Info()
+		for _, title := range slices.Sorted(maps.Keys(info.Examples)) {
+			if err := validateModuleExample(modules, id, info.Examples[title]); err != nil {
+				problems = append(problems, fmt.Sprintf("module %s example %q: %v", id, title, err))
+			}
+		}
+	}
+	return problems
+}
+
+// validateModuleExample validates an example of a module. An example is a single operation, a list
+// of operations, or a workflow file, and must use the module in at least one operation. Each
+// operation must use a registered module with the inputs it declares, and pass the validation of
+// the module. Inputs from operations outside the example, parameters, files, and encrypted values
+// are only known when a workflow is run, so their types are not checked.
+func validateModuleExample(modules map[string]func() blackstart.Module, id, example string) error {
+	ops, err := exampleOperations(example)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(ops, func(op v1alpha1.Operation) bool { return op.Module == id }) {
+		return fmt.Errorf("no operation uses module %s", id)
+	}
+
+	infos := make(map[string]blackstart.ModuleInfo, len(ops))
+	for _, op := range ops {
+		factory, ok := modules[op.Module]
+		if !ok {
+			return fmt.Errorf("operation %s uses unknown module %s", op.Id, op.Module)
+		}
+		infos[op.Id] = factory().Info()
+	}
+
+	for _, op := range ops {
+		info := infos[op.Id]
+		coreOp := blackstart.Operation{
+			Module:       op.Module,
+			Id:           op.Id,
+			DependsOn:    op.DependsOn,
+			DoesNotExist: op.DoesNotExist,
+			Inputs:       make(map[string]blackstart.Input, len(op.Inputs)),
+		}
+		for _, key := range slices.Sorted(maps.Keys(op.Inputs)) {
+			param, ok := info.Inputs[key]
+			if !ok {
+				return fmt.Errorf("operation %s has unknown input %s for module %s", op.Id, key, op.Module)
+			}
+			input, err := exampleInput(op.Id, key, op.Inputs[key], param, infos)
+			if err != nil {
+				return err
+			}
+			coreOp.Inputs[key] = input
+		}
+		for _, key := range slices.Sorted(maps.Keys(info.Inputs)) {
+			if _, ok := op.Inputs[key]; !ok && info.Inputs[key].Required {
+				return fmt.Errorf("operation %s is missing required input %s", op.Id, key)
+			}
+		}
+		if err = modules[op.Module]().Validate(coreOp); err != nil {
+			return fmt.Errorf("operation %s is invalid: %w", op.Id, err)
+		}
+	}
+	return nil
+}
+
+// exampleOperations decodes the operations of an example. Unknown fields of operations are errors.
+func exampleOperations(example string) ([]v1alpha1.Operation, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(example), &node); err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(node.Content) == 0 {
+		return nil, fmt.Errorf("example is empty")
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader([]byte(example)))
+	decoder.KnownFields(true)
+	root := node.Content[0]
+	switch {
+	case root.Kind == yaml.SequenceNode:
+		var ops []v1alpha1.Operation
+		if err := decoder.Decode(&ops); err != nil {
+			return nil, fmt.Errorf("invalid operations: %w", err)
+		}
+		return ops, nil
+	case root.Kind == yaml.MappingNode && hasMappingKey(root, "operations"):
+		var wf v1alpha1.WorkflowConfigFile
+		if err := decoder.Decode(&wf); err != nil {
+			return nil, fmt.Errorf("invalid workflow: %w", err)
+		}
+		return wf.Operations, nil
+	default:
+		var op v1alpha1.Operation
+		if err := decoder.Decode(&op); err != nil {
+			return nil, fmt.Errorf("invalid operation: %w", err)
+		}
+		return []v1alpha1.Operation{op}, nil
+	}
+}
+
+// hasMappingKey reports whether a YAML mapping has the key.
+func hasMappingKey(node *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// exampleInput returns the core input of an example operation input. Inputs with values that are
+// only known when a workflow is run are returned as inputs from dependencies. Static values must be
+// accepted by the input, and outputs of operations in the example must exist and have a type
+// accepted by the input.
+func exampleInput(
+	opId, key string, input *v1alpha1.OperationInput, param blackstart.InputValue,
+	infos map[string]blackstart.ModuleInfo,
+) (blackstart.Input, error) {
+	if input == nil {
+		return nil, fmt.Errorf("operation %s input %s has no value", opId, key)
+	}
+	if input.DeprecatedKey != "" {
+		return nil, fmt.Errorf(
+			"operation %s input %s uses the deprecated key %s instead of fromDependency", opId, key, input.DeprecatedKey,
+		)
+	}
+	if input.FromParameter != "" || input.FromFile != nil || input.Encrypted != "" {
+		// The value is not static, so modules do not validate it.
+		return blackstart.NewInputFromDep(opId, key), nil
+	}
+	if dep := input.FromDependency; dep != nil {
+		depInfo, ok := infos[dep.Id]
+		if !ok {
+			return blackstart.NewInputFromDep(dep.Id, dep.Output), nil
+		}
+		output, ok := depInfo.Outputs[dep.Output]
+		if !ok {
+			return nil, fmt.Errorf(
+				"operation %s input %s uses unknown output %s of operation %s", opId, key, dep.Output, dep.Id,
+			)
+		}
+		if !param.AcceptsOutput(output) {
+			return nil, fmt.Errorf(
+				"operation %s input %s expects %s, but output %s of operation %s is %s",
+				opId, key, param.TypeDisplay(), dep.Output, dep.Id, output.Type,
+			)
+		}
+		return blackstart.NewInputFromDep(dep.Id, dep.Output), nil
+	}
+
+	var value any
+	if input.Extra != nil {
+		var err error
+		if value, err = decodeOperationInputExtra(input.Extra.Raw); err != nil {
+			return nil, fmt.Errorf("operation %s input %s is invalid: %w", opId, key, err)
+		}
+	}
+	if m, ok := value.(map[string]any); ok {
+		for name := range m {
+			normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+			if want, isSource := inputSourceKeys[normalized]; isSource && name != want {
+				return nil, fmt.Errorf("operation %s input %s uses %s instead of %s", opId, key, name, want)
+			}
+		}
+	}
+	if !param.Accepts(value) {
+		return nil, fmt.Errorf("operation %s input %s expects %s, got %T", opId, key, param.TypeDisplay(), value)
+	}
+	return blackstart.NewInputFromValue(value), nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// exampleTestModule is a module with a required name input, an optional count input, and a
+// value output, whose validation rejects the name `invalid`.
+type exampleTestModule struct{}
+
+func (m exampleTestModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id: "example_test",
+		Inputs: map[string]blackstart.InputValue{
+			"name":  {Type: reflect.TypeFor[string](), Required: true},
+			"count": {Type: reflect.TypeFor[int]()},
+			"items": {Type: reflect.TypeFor[[]string]()},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			"value": {Type: reflect.TypeFor[string]()},
+			"size":  {Type: reflect.TypeFor[int]()},
+		},
+	}
+}
+
+func (m exampleTestModule) Validate(op blackstart.Operation) error {
+	if name, ok := op.Inputs["name"]; ok && name.IsStatic() && name.Any() == "invalid" {
+		return fmt.Errorf("name is invalid")
+	}
+	return nil
+}
+
+func (m exampleTestModule) Check(_ blackstart.ModuleContext) (bool, error) { return true, nil }
+func (m exampleTestModule) Set(_ blackstart.ModuleContext) error           { return nil }
+
+func TestModuleExamples(t *testing.T) {
+	var out bytes.Buffer
+	err := validateModuleExamples(&out)
+	assert.NoError(t, err, out.String())
+}
+
+func TestValidateModuleExample(t *testing.T) {
+	modules := map[string]func() blackstart.Module{
+		"example_test": func() blackstart.Module { return exampleTestModule{} },
+	}
+
+	tests := []struct {
+		name    string
+		example string
+		wantErr string
+	}{
+		{
+			name: "operation",
+			example: `id: example
+module: example_test
+inputs:
+  name: example
+  count: 3
+  items: [a, b]`,
+		},
+		{
+			name: "operations with dependencies",
+			example: `- id: source
+  module: example_test
+  inputs:
+    name: source
+- id: target
+  module: example_test
+  inputs:
+    name:
+      fromDependency:
+        id: source
+        output: value
+    count:
+      fromDependency:
+        id: other-workflow-operation
+        output: anything`,
+		},
+		{
+			name: "workflow with runtime values",
+			example: `name: example
+parameters:
+  - name: env
+operations:
+  - id: example
+    module: example_test
+    inputs:
+      name:
+        fromParameter: env
+      items:
+        fromFile:
+          path: /etc/items.json`,
+		},
+		{
+			name:    "invalid YAML",
+			example: "id: [example",
+			wantErr: "invalid YAML",
+		},
+		{
+			name: "unknown field",
+			example: `id: example
+module: example_test
+depends_on: [other]
+inputs:
+  name: example`,
+			wantErr: "field depends_on not found",
+		},
+		{
+			name: "other module",
+			example: `id: example
+module: other_test
+inputs:
+  name: example`,
+			wantErr: "no operation uses module example_test",
+		},
+		{
+			name: "unknown input",
+			example: `id: example
+module: example_test
+inputs:
+  Name: example`,
+			wantErr: "operation example has unknown input Name for module example_test",
+		},
+		{
+			name: "missing required input",
+			example: `id: example
+module: example_test
+inputs:
+  count: 3`,
+			wantErr: "operation example is missing required input name",
+		},
+		{
+			name: "deprecated input source",
+			example: `id: example
+module: example_test
+inputs:
+  name:
+    from_dependency:
+      id: source
+      output: value`,
+			wantErr: "operation example input name uses the deprecated key from_dependency instead of fromDependency",
+		},
+		{
+			name: "input source spelling",
+			example: `id: example
+module: example_test
+inputs:
+  name:
+    from_parameter: env`,
+			wantErr: "operation example input name uses from_parameter instead of fromParameter",
+		},
+		{
+			name: "static type",
+			example: `id: example
+module: example_test
+inputs:
+  name: example
+  count: three`,
+			wantErr: "operation example input count expects int, got string",
+		},
+		{
+			name: "unknown output",
+			example: `- id: source
+  module: example_test
+  inputs:
+    name: source
+- id: target
+  module: example_test
+  inputs:
+    name:
+      fromDependency:
+        id: source
+        output: name`,
+			wantErr: "operation target input name uses unknown output name of operation source",
+		},
+		{
+			name: "output type",
+			example: `- id: source
+  module: example_test
+  inputs:
+    name: source
+- id: target
+  module: example_test
+  inputs:
+    name:
+      fromDependency:
+        id: source
+        output: size`,
+			wantErr: "operation target input name expects string, but output size of operation source is int",
+		},
+		{
+			name: "module validation",
+			example: `id: example
+module: example_test
+inputs:
+  name: invalid`,
+			wantErr: "operation example is invalid: name is invalid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := validateModuleExample(modules, "example_test", test.example)
+				if test.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.wantErr)
+			},
+		)
+	}
+}
+
+func TestModuleExampleProblems(t *testing.T) {
+	modules := map[string]func() blackstart.Module{
+		"example_test": func() blackstart.Module { return exampleTestModule{} },
+	}
+	assert.Empty(t, moduleExampleProblems(modules))
+
+	problems := moduleExampleProblems(
+		map[string]func() blackstart.Module{
+			"example_test": modules["example_test"],
+			"broken_test":  func() blackstart.Module { return brokenExampleModule{} },
+		},
+	)
+	assert.Equal(
+		t,
+		[]string{
+			`module broken_test example "Wrong Casing": operation broken has unknown input Name for module ` +
+				`example_test`,
+		},
+		problems,
+	)
+}
+
+// brokenExampleModule has an example with an input of another module in the wrong case.
+type brokenExampleModule struct{ exampleTestModule }
+
+func (m brokenExampleModule) Info() blackstart.ModuleInfo {
+	info := m.exampleTestModule.Info()
+	info.Id = "broken_test"
+	info.Examples = map[string]string{
+		"Wrong Casing": `- id: broken
+  module: example_test
+  inputs:
+    Name: broken
+- id: user
+  module: broken_test
+  inputs:
+    name: user`,
+	}
+	return info
+}
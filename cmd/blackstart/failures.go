@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// parseInjectedFailures parses `id=phase` values, as set with `--inject-failure`, into a map of
+// operation identifiers to the phase that is forced to fail.
+func parseInjectedFailures(raw []string) (map[string]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	failures := make(map[string]string, len(raw))
+	for _, item := range raw {
+		id, phase, found := strings.Cut(item, "=")
+		id = strings.TrimSpace(id)
+		if !found || id == "" {
+			return nil, fmt.Errorf("invalid injected failure %q: expected id=check or id=set", item)
+		}
+		if _, ok := failures[id]; ok {
+			return nil, fmt.Errorf("failure for operation %q is injected more than once", id)
+		}
+		failures[id] = strings.ToLower(strings.TrimSpace(phase))
+	}
+	return failures, nil
+}
+
+// injectedFailuresFromAnnotations reads the injected failures from the inject-failures annotation
+// of a Workflow resource. A missing annotation injects no failures.
+func injectedFailuresFromAnnotations(annotations map[string]string) (map[string]string, error) {
+	raw, ok := annotations[v1alpha1.InjectFailuresAnnotation]
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	failures, err := parseInjectedFailures(strings.Split(raw, ","))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", v1alpha1.InjectFailuresAnnotation, err)
+	}
+	return failures, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestParseInjectedFailures(t *testing.T) {
+	failures, err := parseInjectedFailures([]string{"db=set", " user = Check "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"db": "set", "user": "check"}, failures)
+
+	failures, err = parseInjectedFailures(nil)
+	require.NoError(t, err)
+	assert.Nil(t, failures)
+
+	_, err = parseInjectedFailures([]string{"db"})
+	assert.ErrorContains(t, err, `invalid injected failure "db": expected id=check or id=set`)
+
+	_, err = parseInjectedFailures([]string{"db=set", "db=check"})
+	assert.ErrorContains(t, err, `failure for operation "db" is injected more than once`)
+}
+
+func TestInjectedFailuresFromAnnotations(t *testing.T) {
+	failures, err := injectedFailuresFromAnnotations(
+		map[string]string{v1alpha1.InjectFailuresAnnotation: "db=set,user=check"},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"db": "set", "user": "check"}, failures)
+
+	failures, err = injectedFailuresFromAnnotations(nil)
+	require.NoError(t, err)
+	assert.Nil(t, failures)
+
+	_, err = injectedFailuresFromAnnotations(map[string]string{v1alpha1.InjectFailuresAnnotation: "=set"})
+	assert.ErrorContains(t, err, "invalid blackstart.pezops.github.io/inject-failures annotation")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pezops/blackstart"
+)
+
+// readFixture reads a fixture recorded with `--record-fixture`.
+func readFixture(path string) (*blackstart.Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture: %w", err)
+	}
+	var fixture blackstart.Fixture
+	if err = json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("error parsing fixture %s: %w", path, err)
+	}
+	return &fixture, nil
+}
+
+// writeFixture writes a recorded fixture as indented JSON, so changes to it can be reviewed.
+func writeFixture(path string, fixture *blackstart.Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding fixture: %w", err)
+	}
+	if err = os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("error writing fixture: %w", err)
+	}
+	return nil
+}
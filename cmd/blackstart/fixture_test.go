@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestRunWorkflowFromFile_Fixture(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workflow.yaml")
+	fixturePath := filepath.Join(dir, "fixture.json")
+	workflow := func(template string) {
+		content := []byte("name: fixture\noperations:\n  - id: render\n    module: util_template\n    inputs:\n" +
+			"      template: " + template + "\n")
+		require.NoError(t, os.WriteFile(path, content, 0o600))
+	}
+	run := func(cfg *blackstart.RuntimeConfig) error {
+		cfg.WorkflowFile = path
+		ctx := context.WithValue(context.Background(), blackstart.ConfigKey, cfg)
+		ctx = context.WithValue(ctx, blackstart.LoggerKey, blackstart.NewLogger(nil))
+		return runWorkflowFromFile(ctx)
+	}
+
+	workflow("hello")
+	require.NoError(t, run(&blackstart.RuntimeConfig{RecordFixture: fixturePath}))
+	fixture, err := readFixture(fixturePath)
+	require.NoError(t, err)
+	assert.Equal(t, "fixture", fixture.Workflow)
+	require.Len(t, fixture.Operations, 1)
+	assert.Equal(t, map[string]string{"template": "hello"}, fixture.Operations[0].Inputs)
+
+	require.NoError(t, run(&blackstart.RuntimeConfig{ReplayFixture: fixturePath}))
+
+	// A replayed run of a changed workflow fails.
+	workflow("goodbye")
+	err = run(&blackstart.RuntimeConfig{ReplayFixture: fixturePath})
+	require.ErrorIs(t, err, blackstart.ErrFixtureMismatch)
+	assert.ErrorContains(t, err, "replayed workflow fixture did not complete")
+
+	_, err = readFixture(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "error reading fixture")
+}
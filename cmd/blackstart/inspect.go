@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// inspectWorkflowRun reads a Workflow resource saved as YAML or JSON, such as the output of
+// `kubectl get workflow -o yaml`, and writes the recorded inputs and outputs of the operations of
+// its last run to out. Each input is annotated with where its value came from in the workflow.
+func inspectWorkflowRun(path string, out io.Writer) error {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return fmt.Errorf("error reading workflow run: %w", err)
+	}
+	var wf v1alpha1.Workflow
+	if err = yaml.Unmarshal(data, &wf); err != nil {
+		return fmt.Errorf("error unmarshalling workflow run: %w", err)
+	}
+	if wf.Status.LastRan.IsZero() {
+		return fmt.Errorf("workflow %s has no recorded run", workflowRunName(&wf))
+	}
+	_, err = io.WriteString(out, formatWorkflowRun(&wf))
+	return err
+}
+
+// formatProviderAPICalls formats API call counts by provider, sorted by provider, such as
+// `google 3, kubernetes 1`.
+func formatProviderAPICalls(calls map[string]int64) string {
+	parts := make([]string, 0, len(calls))
+	for _, provider := range slices.Sorted(maps.Keys(calls)) {
+		parts = append(parts, fmt.Sprintf("%s %d", provider, calls[provider]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// workflowRunName returns the namespaced name of a Workflow resource for display.
+func workflowRunName(wf *v1alpha1.Workflow) string {
+	if wf.Namespace == "" {
+		return wf.Name
+	}
+	return wf.Namespace + "/" + wf.Name
+}
+
+// formatWorkflowRun formats the last run of a Workflow resource. Operations are listed in
+// execution order, followed by the operations of the workflow that were not run.
+func formatWorkflowRun(wf *v1alpha1.Workflow) string {
+	var b strings.Builder
+	status := wf.Status
+	_, _ = fmt.Fprintf(
+		&b, "workflow %s ran at %s (phase: %s, successful: %s, operations: %s)\n",
+		workflowRunName(wf), status.LastRan.UTC().Format(time.RFC3339), valueOrNone(status.Phase),
+		valueOrNone(status.Successful), valueOrNone(status.OperationsCompleted),
+	)
+	if status.LastError != "" {
+		_, _ = fmt.Fprintf(&b, "error in operation %s: %s\n", valueOrNone(status.LastOperation), status.LastError)
+	}
+	if len(status.APICalls) > 0 {
+		_, _ = fmt.Fprintf(&b, "API calls: %s\n", formatProviderAPICalls(status.APICalls))
+	}
+	for _, instance := range status.Instances {
+		_, _ = fmt.Fprintf(
+			&b, "instance %s (successful: %s, operations: %s)", instance.Name, instance.Successful,
+			instance.OperationsCompleted,
+		)
+		if instance.LastError != "" {
+			_, _ = fmt.Fprintf(&b, ": %s", instance.LastError)
+		}
+		b.WriteString("\n")
+	}
+
+	specOps := make(map[string]v1alpha1.Operation, len(wf.Spec.Operations))
+	for _, op := range wf.Spec.Operations {
+		specOps[op.Id] = op
+	}
+	ran := make(map[string]struct{}, len(status.Operations))
+	for _, opStatus := range status.Operations {
+		ran[opStatus.Id] = struct{}{}
+		_, _ = fmt.Fprintf(&b, "\noperation %s (%s)", opStatus.Id, opStatus.Module)
+		switch {
+		case opStatus.Skipped:
+			b.WriteString(": skipped\n")
+			continue
+		case opStatus.PendingWindow:
+			b.WriteString(": pending maintenance window\n")
+		case opStatus.Filtered:
+			b.WriteString(": not selected by labels, checked only\n")
+		case opStatus.Blocked:
+			_, _ = fmt.Fprintf(&b, ": blocked, endpoint circuit open after %s\n", opStatus.Duration.Duration)
+		case opStatus.Error != "":
+			_, _ = fmt.Fprintf(&b, ": failed after %s: %s\n", opStatus.Duration.Duration, opStatus.Error)
+		case len(opStatus.ProviderAPICalls) > 0:
+			_, _ = fmt.Fprintf(
+				&b, ": %s, %d API calls (%s)\n", opStatus.Duration.Duration, opStatus.APICalls,
+				formatProviderAPICalls(opStatus.ProviderAPICalls),
+			)
+		default:
+			_, _ = fmt.Fprintf(&b, ": %s, %d API calls\n", opStatus.Duration.Duration, opStatus.APICalls)
+		}
+		writeInputs(&b, specOps[opStatus.Id], opStatus.Inputs)
+		writeValues(&b, "outputs", opStatus.Outputs, nil)
+	}
+
+	var notRun []string
+	for _, op := range wf.Spec.Operations {
+		if _, ok := ran[op.Id]; !ok {
+			notRun = append(notRun, op.Id)
+		}
+	}
+	if len(notRun) > 0 {
+		_, _ = fmt.Fprintf(&b, "\nnot run: %s\n", strings.Join(notRun, ", "))
+	}
+	return b.String()
+}
+
+// writeInputs writes the recorded inputs of an operation, annotated with the source of each input
+// in the workflow. Encrypted inputs are always masked, including in runs recorded before sensitive
+// values were masked.
+func writeInputs(b *strings.Builder, op v1alpha1.Operation, inputs map[string]string) {
+	inputs = maps.Clone(inputs)
+	sources := make(map[string]string, len(op.Inputs))
+	for key, input := range op.Inputs {
+		if input == nil {
+			continue
+		}
+		switch {
+		case input.FromDependency != nil:
+			sources[key] = fmt.Sprintf("from %s.%s", input.FromDependency.Id, input.FromDependency.Output)
+		case input.FromParameter != "":
+			sources[key] = fmt.Sprintf("from parameter %s", input.FromParameter)
+		case input.FromFile != nil:
+			sources[key] = fmt.Sprintf("from file %s", input.FromFile.Path)
+		case input.Encrypted != "":
+			sources[key] = "encrypted"
+			if _, ok := inputs[key]; ok {
+				inputs[key] = blackstart.MaskedValue
+			}
+		}
+	}
+	writeValues(b, "inputs", inputs, sources)
+}
+
+// writeValues writes recorded values in key order, followed by their annotation, if any.
+func writeValues(b *strings.Builder, title string, values map[string]string, annotations map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(b, "  %s:\n", title)
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		_, _ = fmt.Fprintf(b, "    %s = %s", key, values[key])
+		if annotation := annotations[key]; annotation != "" {
+			_, _ = fmt.Fprintf(b, " (%s)", annotation)
+		}
+		b.WriteString("\n")
+	}
+}
+
+// valueOrNone returns the value, or "none" when it is empty.
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const inspectWorkflowYAML = `apiVersion: blackstart.pezops.github.io/v1alpha1
+kind: Workflow
+metadata:
+  name: tenant-db
+  namespace: apps
+spec:
+  operations:
+    - id: instance
+      module: google_cloudsql_managed_instance
+      inputs:
+        project: example
+        region:
+          fromParameter: region
+    - id: user
+      module: google_cloudsql_user
+      inputs:
+        instance:
+          fromDependency:
+            id: instance
+            output: instance
+        password:
+          encrypted: YWdlLWVuY3J5cHRlZA==
+        credentials:
+          fromFile:
+            path: /var/run/secrets/sa.json
+    - id: database
+      module: google_cloudsql_database
+      inputs:
+        database: tenant
+status:
+  lastRan: "2026-10-01T12:00:00Z"
+  phase: execute
+  successful: "false"
+  operationsCompleted: 2/3
+  lastOperation: user
+  lastError: permission denied
+  apiCalls:
+    google: 4
+  operations:
+    - id: instance
+      module: google_cloudsql_managed_instance
+      duration: 1.5s
+      apiCalls: 3
+      providerApiCalls:
+        google: 3
+      inputs:
+        project: example
+        region: us-central1
+      outputs:
+        instance: shared
+    - id: user
+      module: google_cloudsql_user
+      duration: 250ms
+      apiCalls: 1
+      inputs:
+        instance: shared
+        password: leaked
+        credentials: "********"
+`
+
+func TestInspectWorkflowRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(inspectWorkflowYAML), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, inspectWorkflowRun(path, &out))
+	// Encrypted inputs are masked even when the recorded value is not.
+	assert.Equal(
+		t, `workflow apps/tenant-db ran at 2026-10-01T12:00:00Z (phase: execute, successful: false, operations: 2/3)
+error in operation user: permission denied
+API calls: google 4
+
+operation instance (google_cloudsql_managed_instance): 1.5s, 3 API calls (google 3)
+  inputs:
+    project = example
+    region = us-central1 (from parameter region)
+  outputs:
+    instance = shared
+
+operation user (google_cloudsql_user): 250ms, 1 API calls
+  inputs:
+    credentials = ******** (from file /var/run/secrets/sa.json)
+    instance = shared (from instance.instance)
+    password = ******** (encrypted)
+
+not run: database
+`, out.String(),
+	)
+}
+
+func TestInspectWorkflowRun_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.json")
+	data := `{"metadata":{"name":"tenant-db"},"spec":{"operations":[{"id":"a","module":"m"},` +
+		`{"id":"b","module":"m","onFailure":"continue"}]},"status":{"lastRan":"2026-10-01T12:00:00Z",` +
+		`"operations":[{"id":"a","module":"m","duration":"0s","apiCalls":0,"skipped":true},` +
+		`{"id":"b","module":"m","duration":"2s","apiCalls":1,"error":"quota exceeded"}]}}`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	var out bytes.Buffer
+	require.NoError(t, inspectWorkflowRun(path, &out))
+	assert.Equal(
+		t, "workflow tenant-db ran at 2026-10-01T12:00:00Z (phase: none, successful: none, operations: none)\n"+
+			"\noperation a (m): skipped\n"+
+			"\noperation b (m): failed after 2s: quota exceeded\n", out.String(),
+	)
+}
+
+func TestInspectWorkflowRun_NoRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "workflow.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("metadata:\n  name: tenant-db\n"), 0o600))
+
+	err := inspectWorkflowRun(path, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "workflow tenant-db has no recorded run")
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// instanceSeparator separates the instance name from the operation ID of operations created for a
+// forEach instance.
+const instanceSeparator = "/"
+
+// workflowOperations resolves the parameters of a workflow, adds its module defaults to the
+// operations, and loads the operations. When the workflow lists forEach instances, the operations
+// are loaded once per instance with the parameter values of the instance, and the operation IDs and
+// dependencies are prefixed with the instance name. The operations of an instance are isolated from
+// the other instances, so a failed instance does not stop the others.
+func workflowOperations(spec v1alpha1.WorkflowSpec, values map[string]string) ([]blackstart.Operation, error) {
+	specOps, err := applyModuleDefaults(spec.ModuleDefaults, spec.Operations)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.ForEach) == 0 {
+		params, pErr := resolveParameters(spec.Parameters, values)
+		if pErr != nil {
+			return nil, fmt.Errorf("error resolving parameters: %w", pErr)
+		}
+		return loadOperations(specOps, params)
+	}
+
+	seen := make(map[string]struct{}, len(spec.ForEach))
+	var ops []blackstart.Operation
+	for _, instance := range spec.ForEach {
+		name := strings.TrimSpace(instance.Name)
+		if name == "" {
+			return nil, fmt.Errorf("forEach instance name must not be empty")
+		}
+		if strings.Contains(name, instanceSeparator) {
+			return nil, fmt.Errorf("forEach instance name %q must not contain %q", name, instanceSeparator)
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("forEach instance %q is listed more than once", name)
+		}
+		seen[name] = struct{}{}
+
+		instanceValues := maps.Clone(values)
+		if instanceValues == nil {
+			instanceValues = make(map[string]string, len(instance.Parameters))
+		}
+		maps.Copy(instanceValues, instance.Parameters)
+		params, err := resolveParameters(spec.Parameters, instanceValues)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving parameters for instance %q: %w", name, err)
+		}
+		instanceOps, err := loadOperations(specOps, params)
+		if err != nil {
+			return nil, fmt.Errorf("error loading operations for instance %q: %w", name, err)
+		}
+		for _, op := range instanceOps {
+			ops = append(ops, instanceOperation(name, op))
+		}
+	}
+	return ops, nil
+}
+
+// instanceOperation prefixes the ID, dependencies, and dependency inputs of an operation with the
+// name of its forEach instance, and sets the instance of the operation so a failure of the operation
+// only stops its instance.
+func instanceOperation(instance string, op blackstart.Operation) blackstart.Operation {
+	prefix := instance + instanceSeparator
+	op.Id = prefix + op.Id
+	op.Instance = instance
+	dependsOn := make([]string, len(op.DependsOn))
+	for i, dep := range op.DependsOn {
+		dependsOn[i] = prefix + dep
+	}
+	op.DependsOn = dependsOn
+	for k, input := range op.Inputs {
+		if input.IsStatic() {
+			continue
+		}
+		if value, ok := blackstart.DependencyDefault(input); ok {
+			op.Inputs[k] = blackstart.NewInputFromDepWithDefault(prefix+input.DependencyId(), input.OutputKey(), value)
+			continue
+		}
+		op.Inputs[k] = blackstart.NewInputFromDep(prefix+input.DependencyId(), input.OutputKey())
+	}
+	return op
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const forEachWorkflowYAML = `name: tenant-onboarding
+parameters:
+  - name: tenant
+    required: true
+  - name: instance
+    default: shared-db
+forEach:
+  - name: team-a
+    parameters:
+      tenant: team_a
+  - name: team-b
+    parameters:
+      tenant: team_b
+      instance: dedicated-db
+operations:
+  - id: database
+    module: google_cloudsql_database
+    inputs:
+      instance:
+        fromParameter: instance
+      database:
+        fromParameter: tenant
+  - id: user
+    module: google_cloudsql_user
+    dependsOn:
+      - database
+    inputs:
+      instance:
+        fromDependency:
+          id: database
+          output: instance
+      user:
+        fromParameter: tenant
+      user_type: CLOUD_IAM_USER
+`
+
+func TestWorkflowOperations_ForEach(t *testing.T) {
+	wf, err := workflowFromConfigBytes([]byte(forEachWorkflowYAML), nil)
+	require.NoError(t, err)
+	require.Len(t, wf.Operations, 4)
+
+	ids := make([]string, len(wf.Operations))
+	for i, op := range wf.Operations {
+		ids[i] = op.Id
+	}
+	assert.Equal(t, []string{"team-a/database", "team-a/user", "team-b/database", "team-b/user"}, ids)
+	assert.Equal(t, "team-a", wf.Operations[1].Instance)
+	assert.Equal(t, "team-b", wf.Operations[2].Instance)
+
+	assert.Equal(t, "team_a", wf.Operations[0].Inputs["database"].Any())
+	assert.Equal(t, "shared-db", wf.Operations[0].Inputs["instance"].Any())
+	assert.Equal(t, "team_b", wf.Operations[2].Inputs["database"].Any())
+	assert.Equal(t, "dedicated-db", wf.Operations[2].Inputs["instance"].Any())
+
+	user := wf.Operations[3]
+	assert.Equal(t, []string{"team-b/database"}, user.DependsOn)
+	assert.Equal(t, "team-b/database", user.Inputs["instance"].DependencyId())
+	assert.Equal(t, "instance", user.Inputs["instance"].OutputKey())
+	assert.Equal(t, "team_b", user.Inputs["user"].Any())
+}
+
+func TestWorkflowOperations_ForEachRunValues(t *testing.T) {
+	// Run values apply to every instance, and instance values take precedence.
+	wf, err := workflowFromConfigBytes([]byte(forEachWorkflowYAML), []string{"instance=run-db"})
+	require.NoError(t, err)
+	assert.Equal(t, "run-db", wf.Operations[0].Inputs["instance"].Any())
+	assert.Equal(t, "dedicated-db", wf.Operations[2].Inputs["instance"].Any())
+}
+
+func TestWorkflowOperations_ForEachErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		forEach string
+		errMsg  string
+	}{
+		{
+			name:    "missing name",
+			forEach: "forEach:\n  - parameters:\n      tenant: a\n",
+			errMsg:  "forEach instance name must not be empty",
+		},
+		{
+			name:    "duplicate name",
+			forEach: "forEach:\n  - name: a\n    parameters:\n      tenant: a\n  - name: a\n    parameters:\n      tenant: a\n",
+			errMsg:  `forEach instance "a" is listed more than once`,
+		},
+		{
+			name:    "separator in name",
+			forEach: "forEach:\n  - name: a/b\n    parameters:\n      tenant: a\n",
+			errMsg:  `must not contain "/"`,
+		},
+		{
+			name:    "missing required parameter",
+			forEach: "forEach:\n  - name: a\n",
+			errMsg:  `error resolving parameters for instance "a": missing value for required parameter "tenant"`,
+		},
+		{
+			name:    "undeclared parameter",
+			forEach: "forEach:\n  - name: a\n    parameters:\n      tenant: a\n      region: b\n",
+			errMsg:  "undeclared parameters: region",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				wfYAML := `name: tenants
+parameters:
+  - name: tenant
+    required: true
+` + tt.forEach + `operations:
+  - id: database
+    module: google_cloudsql_database
+    inputs:
+      database:
+        fromParameter: tenant
+`
+				_, err := workflowFromConfigBytes([]byte(wfYAML), nil)
+				require.ErrorContains(t, err, tt.errMsg)
+			},
+		)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+	"github.com/pezops/blackstart/config/crd"
+	"github.com/pezops/blackstart/util"
+)
+
+// integrationNamespace is the namespace of the objects of an integration test that do not set one.
+const integrationNamespace = "default"
+
+// runIntegrationTest starts a temporary Kubernetes API server with envtest, installs the Workflow
+// CRD, creates the objects of the YAML file, and runs its workflows end-to-end. Kubernetes modules
+// use the temporary API server. The status of each workflow run is written to out, and an error is
+// returned when a workflow does not succeed.
+func runIntegrationTest(ctx context.Context, path string, out io.Writer) error {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return fmt.Errorf("error reading integration test file: %w", err)
+	}
+	objs, err := integrationObjects(data)
+	if err != nil {
+		return err
+	}
+	crds, err := workflowCRDs()
+	if err != nil {
+		return err
+	}
+	binPath, err := envtestBinaries()
+	if err != nil {
+		return fmt.Errorf("unable to find envtest binaries, install them with setup-envtest: %w", err)
+	}
+
+	logger := loggerFromCtx(ctx)
+	logger.Info("starting envtest API server")
+	testEnv := &envtest.Environment{BinaryAssetsDirectory: binPath, CRDs: crds}
+	cfg, err := testEnv.Start()
+	if err != nil {
+		return fmt.Errorf("error starting envtest: %w", err)
+	}
+	defer func() {
+		if stopErr := testEnv.Stop(); stopErr != nil {
+			logger.Warn("error stopping envtest", "error", stopErr)
+		}
+	}()
+
+	scheme := runtime.NewScheme()
+	if err = clientgoscheme.AddToScheme(scheme); err != nil {
+		return err
+	}
+	if err = v1alpha1.AddToScheme(scheme); err != nil {
+		return err
+	}
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return fmt.Errorf("error creating Kubernetes client: %w", err)
+	}
+	config := configFromCtx(ctx)
+	ctx = context.WithValue(
+		ctx, blackstart.KubeClientProviderKey,
+		util.NewKubeClientProviderForConfig(cfg, config.KubeModuleNamespaces, config.KubeImpersonateUsers),
+	)
+
+	var workflows []client.ObjectKey
+	for _, obj := range objs {
+		if err = createIntegrationObject(ctx, c, obj); err != nil {
+			return err
+		}
+		if isWorkflowObject(obj) {
+			workflows = append(workflows, client.ObjectKeyFromObject(obj))
+		}
+	}
+	if len(workflows) == 0 {
+		return fmt.Errorf("integration test file %s has no workflows", path)
+	}
+
+	var namespaces []string
+	for _, key := range workflows {
+		if !slices.Contains(namespaces, key.Namespace) {
+			namespaces = append(namespaces, key.Namespace)
+		}
+	}
+	// Workflows that cannot be converted or whose status cannot be written are returned by the
+	// runner, and failed runs are read from their status.
+	errs := []error{newWorkflowRunner(ctx, c).RunAll(ctx, namespaces)}
+	for _, key := range workflows {
+		var kwf v1alpha1.Workflow
+		if err = c.Get(ctx, key, &kwf); err != nil {
+			errs = append(errs, fmt.Errorf("error reading workflow %s: %w", key, err))
+			continue
+		}
+		if kwf.Status.LastRan.IsZero() {
+			errs = append(errs, fmt.Errorf("workflow %s did not run", key))
+			continue
+		}
+		if _, err = io.WriteString(out, formatWorkflowRun(&kwf)+"\n"); err != nil {
+			return err
+		}
+		if kwf.Status.Successful != "true" {
+			errs = append(errs, fmt.Errorf("workflow %s did not succeed: %s", key, valueOrNone(kwf.Status.LastError)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// integrationObjects decodes the objects of a multi-document YAML file. Documents without a kind
+// are workflow files, which are converted to Workflow resources. Workflows are returned after the
+// other objects, so the objects they read exist before they run.
+func integrationObjects(data []byte) ([]*unstructured.Unstructured, error) {
+	var objs, workflows []*unstructured.Unstructured
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	for i := 1; ; i++ {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading document %d: %w", i, err)
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		obj, err := integrationObject(doc)
+		if err != nil {
+			return nil, fmt.Errorf("error decoding document %d: %w", i, err)
+		}
+		if isWorkflowObject(obj) {
+			workflows = append(workflows, obj)
+		} else {
+			objs = append(objs, obj)
+		}
+	}
+	return append(objs, workflows...), nil
+}
+
+// integrationObject decodes a document of an integration test file.
+func integrationObject(doc []byte) (*unstructured.Unstructured, error) {
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal(doc, &obj.Object); err != nil {
+		return nil, err
+	}
+	if obj.GetKind() == "" {
+		manifest, err := workflowFileToResource(doc, "", "")
+		if err != nil {
+			return nil, err
+		}
+		obj = unstructured.Unstructured{}
+		if err = yaml.Unmarshal(manifest, &obj.Object); err != nil {
+			return nil, err
+		}
+	}
+	if obj.GetName() == "" {
+		return nil, fmt.Errorf("%s is missing metadata.name", obj.GetKind())
+	}
+	return &obj, nil
+}
+
+// isWorkflowObject reports whether an object is a Workflow resource.
+func isWorkflowObject(obj client.Object) bool {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	return gvk.Group == v1alpha1.SchemeGroupVersion.Group && gvk.Kind == "Workflow"
+}
+
+// createIntegrationObject creates an object of an integration test, and its namespace when it does
+// not exist. Namespaced objects without a namespace are created in the default namespace.
+func createIntegrationObject(ctx context.Context, c client.Client, obj *unstructured.Unstructured) error {
+	namespaced, err := c.IsObjectNamespaced(obj)
+	if err != nil {
+		return fmt.Errorf("error finding the scope of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+	if namespaced && obj.GetNamespace() == "" {
+		obj.SetNamespace(integrationNamespace)
+	}
+	if ns := obj.GetNamespace(); namespaced {
+		err := c.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("error creating namespace %s: %w", ns, err)
+		}
+	}
+	err = c.Create(ctx, obj)
+	if obj.GetKind() == "Namespace" && apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error creating %s %s: %w", obj.GetKind(), client.ObjectKeyFromObject(obj), err)
+	}
+	return nil
+}
+
+// workflowCRDs returns the embedded CustomResourceDefinitions of the Blackstart API.
+func workflowCRDs() ([]*apiextensionsv1.CustomResourceDefinition, error) {
+	var crds []*apiextensionsv1.CustomResourceDefinition
+	err := fs.WalkDir(
+		crd.FS, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := crd.FS.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var def apiextensionsv1.CustomResourceDefinition
+			if err = yaml.Unmarshal(data, &def); err != nil {
+				return fmt.Errorf("error decoding CRD %s: %w", path, err)
+			}
+			crds = append(crds, &def)
+			return nil
+		},
+	)
+	return crds, err
+}
+
+// envtestBinaries returns the directory of the envtest binaries: the KUBEBUILDER_ASSETS directory
+// when it is set, or the binaries installed with the setup-envtest CLI.
+func envtestBinaries() (string, error) {
+	if assets := os.Getenv("KUBEBUILDER_ASSETS"); assets != "" {
+		return assets, nil
+	}
+	setupEnvtestPath, err := exec.LookPath("setup-envtest")
+	if err != nil {
+		return "", err
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+
+	cmd := exec.Command(
+		setupEnvtestPath, "use", "--bin-dir", filepath.Join(cacheDir, "kubebuilder-envtest"), "-p", "path",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	binPath := strings.TrimSpace(stdout.String())
+	if binPath == "" {
+		return "", errors.New("setup-envtest returned no binary path")
+	}
+	return binPath, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+const integrationTestFile = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  greeting: hello
+---
+apiVersion: blackstart.pezops.github.io/v1alpha1
+kind: Workflow
+metadata:
+  name: resource
+  namespace: team-a
+spec:
+  operations:
+    - id: render
+      module: util_template
+      inputs:
+        template: hello
+---
+name: file
+operations:
+  - id: render
+    module: util_template
+    inputs:
+      template: hello
+`
+
+func TestIntegrationObjects(t *testing.T) {
+	objs, err := integrationObjects([]byte(integrationTestFile))
+	require.NoError(t, err)
+	require.Len(t, objs, 3)
+	assert.Equal(t, "ConfigMap", objs[0].GetKind())
+	assert.Equal(t, "resource", objs[1].GetName())
+	assert.Equal(t, "team-a", objs[1].GetNamespace())
+	// Workflow files are converted to Workflow resources.
+	assert.True(t, isWorkflowObject(objs[2]))
+	assert.Equal(t, "file", objs[2].GetName())
+	assert.Empty(t, objs[2].GetNamespace())
+
+	_, err = integrationObjects([]byte("apiVersion: v1\nkind: ConfigMap\n"))
+	assert.EqualError(t, err, "error decoding document 1: ConfigMap is missing metadata.name")
+}
+
+func TestWorkflowCRDs(t *testing.T) {
+	crds, err := workflowCRDs()
+	require.NoError(t, err)
+	require.Len(t, crds, 1)
+	assert.Equal(t, "workflows.blackstart.pezops.github.io", crds[0].Name)
+}
+
+func TestRunIntegrationTest(t *testing.T) {
+	if _, err := envtestBinaries(); err != nil {
+		t.Skipf("Skipping test: unable to get envtest binaries: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "integration.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(integrationTestFile), 0o600))
+	ctx := context.WithValue(context.Background(), blackstart.ConfigKey, &blackstart.RuntimeConfig{})
+	ctx = context.WithValue(ctx, blackstart.LoggerKey, blackstart.NewLogger(nil))
+
+	var out bytes.Buffer
+	require.NoError(t, runIntegrationTest(ctx, path, &out))
+	assert.Contains(t, out.String(), "workflow team-a/resource ran at")
+	assert.Contains(t, out.String(), "workflow default/file ran at")
+
+	failing := "name: failing\noperations:\n  - id: missing\n    module: unknown_module\n"
+	require.NoError(t, os.WriteFile(path, []byte(failing), 0o600))
+	err := runIntegrationTest(ctx, path, &out)
+	assert.ErrorContains(t, err, "default/failing")
+}
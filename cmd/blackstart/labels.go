@@ -0,0 +1,18 @@
+package main
+
+import (
+	"strings"
+)
+
+// labelFiltersFromAnnotations reads the `key=value` label filters from the only-labels or
+// skip-labels annotation of a Workflow resource. A missing annotation filters no operations. The
+// filters are validated when the workflow is run.
+func labelFiltersFromAnnotations(annotations map[string]string, annotation string) []string {
+	var filters []string
+	for _, item := range strings.Split(annotations[annotation], ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			filters = append(filters, item)
+		}
+	}
+	return filters
+}
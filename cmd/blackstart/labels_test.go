@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestLabelFiltersFromAnnotations(t *testing.T) {
+	annotations := map[string]string{v1alpha1.OnlyLabelsAnnotation: "tier=db, team=payments,,"}
+	assert.Equal(
+		t, []string{"tier=db", "team=payments"},
+		labelFiltersFromAnnotations(annotations, v1alpha1.OnlyLabelsAnnotation),
+	)
+	assert.Nil(t, labelFiltersFromAnnotations(annotations, v1alpha1.SkipLabelsAnnotation))
+	assert.Nil(t, labelFiltersFromAnnotations(nil, v1alpha1.OnlyLabelsAnnotation))
+}
+
+func TestWorkflowFromK8sResource_Labels(t *testing.T) {
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "partial",
+			Namespace: "default",
+			Annotations: map[string]string{
+				v1alpha1.OnlyLabelsAnnotation: "tier=db",
+				v1alpha1.SkipLabelsAnnotation: "team=payments",
+			},
+		},
+		Spec: v1alpha1.WorkflowSpec{
+			Operations: []v1alpha1.Operation{
+				{Id: "a", Module: "test_module", Labels: map[string]string{"tier": "db"}},
+			},
+		},
+	}
+
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tier=db"}, wf.OnlyLabels)
+	assert.Equal(t, []string{"team=payments"}, wf.SkipLabels)
+	assert.Equal(t, map[string]string{"tier": "db"}, wf.Operations[0].Labels)
+}
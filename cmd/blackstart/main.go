@@ -2,30 +2,34 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
 
 	"github.com/jessevdk/go-flags"
 	"gopkg.in/yaml.v3"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/util/retry"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/pezops/blackstart"
 	"github.com/pezops/blackstart/api/v1alpha1"
 	_ "github.com/pezops/blackstart/internal/all_modules"
+	"github.com/pezops/blackstart/internal/runner"
+	"github.com/pezops/blackstart/state"
 	"github.com/pezops/blackstart/util"
 )
 
@@ -56,6 +60,56 @@ func main() {
 		return
 	}
 
+	if config.ConvertTo != "" {
+		err = convertWorkflowFile(config, os.Stdout)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error converting workflow: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.InspectFile != "" {
+		err = inspectWorkflowRun(config.InspectFile, os.Stdout)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error inspecting workflow run: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.ValidateExamples {
+		err = validateModuleExamples(os.Stdout)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error validating module examples: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.Completion != "" {
+		err = writeCompletionScript(config.Completion, os.Stdout)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error writing completion script: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.DescribeModule != "" {
+		err = describeModule(config.DescribeModule, os.Stdout)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "error describing module: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if config.ValidateWorkflow && strings.TrimSpace(config.WorkflowFile) == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "error validating workflow: a workflow file must be set with --workflow-file\n")
+		os.Exit(1)
+	}
+
 	ctx := context.Background()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -63,6 +117,22 @@ func main() {
 	ctx = context.WithValue(ctx, blackstart.LoggerKey, logger)
 	ctx = context.WithValue(ctx, blackstart.ConfigKey, config)
 
+	httpConfig, err := blackstart.NewHTTPConfig(config)
+	if err == nil {
+		err = blackstart.SetHTTPConfig(httpConfig)
+	}
+	if err != nil {
+		logger.Error("unable to configure outbound HTTP clients", "error", err)
+		os.Exit(1)
+	}
+
+	inputFileDirs = config.InputFileDirs
+	inputDecrypter, err = newDecrypter(ctx, config)
+	if err != nil {
+		logger.Error("unable to configure input decryption", "error", err)
+		os.Exit(1)
+	}
+
 	// Set up a channel to listen for OS signals
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
@@ -74,6 +144,19 @@ func main() {
 	}()
 
 	ctx = loadK8sApiSchemes(ctx, logger)
+	ctx = context.WithValue(
+		ctx, blackstart.KubeClientProviderKey,
+		util.NewKubeClientProvider(config.KubeModuleNamespaces, config.KubeImpersonateUsers),
+	)
+
+	if config.IntegrationTest != "" {
+		err = runIntegrationTest(ctx, config.IntegrationTest, os.Stdout)
+		if err != nil {
+			logger.Error("integration test failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	var kubeClient client.Client
 	if config.WorkflowFile == "" {
@@ -85,6 +168,25 @@ func main() {
 		}
 	}
 
+	stateStore, err := state.New(ctx, config.StateStore, kubeClient)
+	if err != nil {
+		logger.Error("unable to create state store", "error", err)
+		os.Exit(1)
+	}
+	if stateStore != nil {
+		ctx = context.WithValue(ctx, blackstart.StateStoreKey, stateStore)
+	}
+
+	events, closeEvents, err := openEventSink(config)
+	if err != nil {
+		logger.Error("unable to open run event stream", "error", err)
+		os.Exit(1)
+	}
+	if events != nil {
+		defer closeEvents()
+		ctx = context.WithValue(ctx, blackstart.EventSinkKey, events)
+	}
+
 	err = run(ctx, kubeClient)
 	if err != nil {
 		logger.Error("error running blackstart", "error", err)
@@ -97,7 +199,9 @@ func main() {
 func run(ctx context.Context, kubeClient client.Client) (err error) {
 	config := configFromCtx(ctx)
 
-	if config.WorkflowFile != "" {
+	if config.ValidateWorkflow {
+		err = validateWorkflowFromFile(ctx, os.Stdout)
+	} else if config.WorkflowFile != "" {
 		err = runWorkflowFromFile(ctx)
 	} else {
 		var mode string
@@ -106,6 +210,9 @@ func run(ctx context.Context, kubeClient client.Client) (err error) {
 			return err
 		}
 		if mode == "once" {
+			if config.TriggerAPIAddress != "" {
+				loggerFromCtx(ctx).Warn("the trigger API is only served in controller mode")
+			}
 			err = runWorkflowsInK8s(ctx, kubeClient)
 		} else {
 			err = runWorkflowsControllerInK8s(ctx, kubeClient)
@@ -138,158 +245,93 @@ func runWorkflowFromFile(ctx context.Context) (err error) {
 		err = fmt.Errorf("error loading workflow from file: %w", err)
 		return err
 	}
+	config := configFromCtx(ctx)
+	if config.ReplayFixture != "" {
+		wf.Replay, err = readFixture(config.ReplayFixture)
+		if err != nil {
+			return err
+		}
+	}
+	if config.RecordFixture != "" {
+		wf.Record = &blackstart.Fixture{}
+	}
 
 	// Run the workflow
 	res := wf.Run(ctx)
 	if res.Err != nil {
-		logger.Warn("workflow execution did not complete", "workflow", wf.Name, "error", res.Err.Error())
+		logger.Warn(
+			"workflow execution did not complete", "workflow", wf.Name, "error", res.Err.Error(),
+			"api_calls", res.ProviderAPICalls(),
+		)
 	} else {
-		logger.Info("workflow execution complete", "workflow", wf.Name)
+		logger.Info("workflow execution complete", "workflow", wf.Name, "api_calls", res.ProviderAPICalls())
 	}
-	return
-}
 
-// runWorkflowsInK8s loads workflows from Kubernetes and runs them concurrently.
-func runWorkflowsInK8s(ctx context.Context, kubeClient client.Client) (err error) {
-	logger := loggerFromCtx(ctx)
-
-	logger.Info("loading workflow resources from kubernetes")
-
-	var workflows []*blackstart.Workflow
-	namespaces := parseNamespaces(configFromCtx(ctx))
-	for _, ns := range namespaces {
-		var nsWorkflows []*blackstart.Workflow
-		nsWorkflows, err = loadWorkflowsFromK8s(ctx, kubeClient, ns)
-		if err != nil {
-			err = fmt.Errorf("error loading workflows from Kubernetes: %w", err)
-			return
-		}
-
-		if len(nsWorkflows) == 0 {
-			if ns != "" {
-				logger.Warn("no workflows found in namespace", "namespace", ns)
-			} else {
-				logger.Warn("no workflows found")
-			}
-			continue
+	// A failed run is recorded too, so its failure can be replayed.
+	if wf.Record != nil {
+		if err = writeFixture(config.RecordFixture, wf.Record); err != nil {
+			return err
 		}
-		workflows = append(workflows, nsWorkflows...)
-	}
-	if len(workflows) == 0 {
-		logger.Warn("no workflows found in configured namespaces")
-		return nil
-	}
-
-	errCh := make(chan error, len(workflows))
-	var wg sync.WaitGroup
-	for _, kwf := range workflows {
-		wg.Add(1)
-		go func(kwf *blackstart.Workflow) {
-			defer wg.Done()
-			wErr := runWorkflowInK8s(ctx, kubeClient, kwf)
-			if wErr != nil {
-				errCh <- wErr
-			}
-		}(kwf)
 	}
-	wg.Wait()
-	close(errCh)
-
-	var wfErrors []error
-	for wErr := range errCh {
-		wfErrors = append(wfErrors, wErr)
-	}
-	if len(wfErrors) > 0 {
-		err = fmt.Errorf("errors running workflows: %v", wfErrors)
+	// A replayed run tests the workflow, so its failure fails the command.
+	if wf.Replay != nil && res.Err != nil {
+		err = fmt.Errorf("replayed workflow %s did not complete: %w", wf.Name, res.Err)
 	}
-
 	return
 }
 
-// runWorkflowInK8s executes a single workflow and updates its Kubernetes status.
-func runWorkflowInK8s(ctx context.Context, c client.Client, wf *blackstart.Workflow) error {
-	logger := loggerFromCtx(ctx)
-	result := wf.Run(ctx)
-	end := time.Now()
-	resultMsg := ""
-	lastError := ""
-	lastOpStart := ""
-	lastOpFields := []any{}
-	if result.Op != nil {
-		lastOpStart = result.Op.Id
-		lastOpFields = append(lastOpFields, "operation", result.Op.Id)
-	}
-
-	if result.Err != nil {
-		logFields := []any{
-			"workflow", wf.Name,
-			"namespace", wf.Namespace,
-			"phase", result.Phase,
-			"error", result.Err.Error(),
-		}
-		logFields = append(logFields, lastOpFields...)
-		logger.Warn("workflow execution did not complete", logFields...)
-		resultMsg = result.Err.Error()
-		lastError = result.Err.Error()
-	} else {
-		logger.Info("workflow execution complete", "workflow", wf.Name, "namespace", wf.Namespace)
+// validateWorkflowFromFile loads a workflow from a file and validates it without checking or
+// setting its operations. The definition and lint warnings of a valid workflow are written to out.
+func validateWorkflowFromFile(ctx context.Context, out io.Writer) error {
+	wf, err := loadWorkflowFromSource(ctx)
+	if err != nil {
+		return fmt.Errorf("error loading workflow from file: %w", err)
 	}
 
-	// Update the workflow status in Kubernetes.
-	status := v1alpha1.WorkflowStatus{
-		LastRan:             metav1.NewTime(end),
-		NextRun:             metav1.NewTime(end.Add(wf.ReconcileInterval)),
-		Successful:          strconv.FormatBool(result.Err == nil),
-		Phase:               result.Phase,
-		Result:              resultMsg,
-		LastError:           lastError,
-		OperationsCompleted: fmt.Sprintf("%d/%d", result.CompletedOperations, result.TotalOperations),
-		LastOperation:       lastOpStart,
+	res := wf.Validate(ctx)
+	if res.Err != nil {
+		if res.Op != nil {
+			return fmt.Errorf("workflow %s is invalid: operation %s: %w", wf.Name, res.Op.Id, res.Err)
+		}
+		return fmt.Errorf("workflow %s is invalid: %w", wf.Name, res.Err)
 	}
-	err := updateWorkflowStatusFunc(ctx, c, wf, status)
-	if err != nil {
-		logger.Error("error updating workflow status", "workflow", wf.Name, "namespace", wf.Namespace, "error", err)
+	var b strings.Builder
+	for _, warning := range wf.Warnings {
+		_, _ = fmt.Fprintf(&b, "warning: %s\n", warning)
 	}
+	for _, warning := range res.Lint {
+		_, _ = fmt.Fprintf(&b, "lint: %s\n", warning)
+	}
+	_, _ = fmt.Fprintf(&b, "workflow %s is valid: %d operations\n", wf.Name, res.TotalOperations)
+	_, err = io.WriteString(out, b.String())
 	return err
 }
 
-// updateWorkflowStatusInK8s updates the Workflow resource status in Kubernetes with the result of
-// the Workflow run.
-func updateWorkflowStatusInK8s(
-	ctx context.Context, c client.Client, wf *blackstart.Workflow, status v1alpha1.WorkflowStatus,
-) error {
-	if wf.Source == nil {
-		return fmt.Errorf("no workflow source")
-	}
-	kwf, ok := wf.Source.(*v1alpha1.Workflow)
-	if !ok {
-		return fmt.Errorf("unexpected workflow source type: %T", wf.Source)
-	}
-
-	key := types.NamespacedName{Name: kwf.Name, Namespace: kwf.Namespace}
-	err := retry.RetryOnConflict(
-		retry.DefaultBackoff, func() error {
-			var latest v1alpha1.Workflow
-			getErr := c.Get(ctx, key, &latest)
-			if getErr != nil {
-				return getErr
-			}
-			latest.Status = status
-			updateErr := c.Status().Update(ctx, &latest)
-			if updateErr != nil {
-				return updateErr
-			}
-			kwf.Status = status
-			return nil
-		},
-	)
-	if err != nil {
-		if apierrors.IsConflict(err) {
-			return fmt.Errorf("error updating workflow status after retries (conflict): %w", err)
-		}
-		return fmt.Errorf("error updating workflow status: %w", err)
+// newWorkflowRunner creates a runner that lists workflows and writes their statuses with the
+// Kubernetes client.
+func newWorkflowRunner(ctx context.Context, c client.Client) *runner.Runner {
+	r := &runner.Runner{
+		Lister: workflowLister(c),
+		Status: runner.KubeStatusUpdater{Client: c},
+		Logger: loggerFromCtx(ctx),
 	}
-	return nil
+	if config, ok := ctx.Value(blackstart.ConfigKey).(*blackstart.RuntimeConfig); ok {
+		r.MaxParallel = config.MaxParallelReconciliations
+	}
+	return r
+}
+
+// workflowLister returns a lister of the Workflow resources in Kubernetes that converts them to
+// native workflows.
+func workflowLister(c client.Client) runner.KubeWorkflowLister {
+	return runner.KubeWorkflowLister{Client: c, Convert: workflowFromK8sResource}
+}
+
+// runWorkflowsInK8s loads workflows from Kubernetes and runs them concurrently, up to the maximum
+// number of parallel reconciliations. A namespace that cannot be loaded or a workflow that fails
+// does not stop the others, and the errors of all of them are returned together.
+func runWorkflowsInK8s(ctx context.Context, kubeClient client.Client) error {
+	return newWorkflowRunner(ctx, kubeClient).RunAll(ctx, parseNamespaces(configFromCtx(ctx)))
 }
 
 // loggerFromCtx retrieves the logger from the context, or creates a new one if not found.
@@ -315,23 +357,11 @@ func configFromCtx(ctx context.Context) *blackstart.RuntimeConfig {
 // will be able to support multiple API versions. For support purposes, this will require a
 // transition period before any API version is removed from support.
 func loadWorkflowsFromK8s(ctx context.Context, c client.Client, namespace string) ([]*blackstart.Workflow, error) {
-	var workflowList v1alpha1.WorkflowList
-	err := c.List(ctx, &workflowList, client.InNamespace(namespace))
-	if err != nil {
-		return nil, fmt.Errorf("error listing workflows: %w", err)
-	}
-
-	workflows := make([]*blackstart.Workflow, len(workflowList.Items))
-	for i := range workflowList.Items {
-		kwf := workflowList.Items[i].DeepCopy()
-		bsWf, convErr := workflowFromK8sResource(kwf)
-		if convErr != nil {
-			return nil, convErr
-		}
-		workflows[i] = bsWf
+	load := workflowLister(c).ListWorkflows(ctx, namespace)
+	if results := load.Results(); len(results) > 0 {
+		return nil, results[0].Err
 	}
-
-	return workflows, nil
+	return load.Workflows, nil
 }
 
 func workflowFromK8sResource(kwf *v1alpha1.Workflow) (*blackstart.Workflow, error) {
@@ -343,21 +373,93 @@ func workflowFromK8sResource(kwf *v1alpha1.Workflow) (*blackstart.Workflow, erro
 	if err != nil {
 		return nil, fmt.Errorf("error parsing reconcile interval for workflow %s: %w", wfRef, err)
 	}
-	ops, err := loadOperations(kwf.Spec.Operations)
+	checkInterval, err := parseOptionalDuration("checkInterval", kwf.Spec.CheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing check interval for workflow %s: %w", wfRef, err)
+	}
+	skipUnchangedFor, err := parseOptionalDuration("skipUnchangedFor", kwf.Spec.SkipUnchangedFor)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing skip duration for workflow %s: %w", wfRef, err)
+	}
+	maxRetryBackoff := blackstart.DefaultMaxRetryBackoff
+	if strings.TrimSpace(kwf.Spec.MaxRetryBackoff) != "" {
+		maxRetryBackoff, err = parseOptionalDuration("maxRetryBackoff", kwf.Spec.MaxRetryBackoff)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing max retry backoff for workflow %s: %w", wfRef, err)
+		}
+	}
+	timeout, err := parseOptionalDuration("timeout", kwf.Spec.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing timeout for workflow %s: %w", wfRef, err)
+	}
+	maintenanceWindow, err := parseMaintenanceWindow(kwf.Spec.MaintenanceWindow)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing maintenance window for workflow %s: %w", wfRef, err)
+	}
+	values, err := parametersFromAnnotations(kwf.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("error reading parameters for workflow %s: %w", wfRef, err)
+	}
+	ops, err := workflowOperations(kwf.Spec, values)
 	if err != nil {
 		return nil, fmt.Errorf("error loading operations for workflow %s: %w", wfRef, err)
 	}
+	approvedDeletions, err := approvedDeletionsFromAnnotations(kwf.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("error reading approved deletions for workflow %s: %w", wfRef, err)
+	}
+	injectedFailures, err := injectedFailuresFromAnnotations(kwf.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("error reading injected failures for workflow %s: %w", wfRef, err)
+	}
+	published, err := publishOutputs(kwf.Spec.PublishOutputs)
+	if err != nil {
+		return nil, fmt.Errorf("error loading published outputs for workflow %s: %w", wfRef, err)
+	}
+	checks, err := serviceChecks(kwf.Spec.ServiceChecks)
+	if err != nil {
+		return nil, fmt.Errorf("error loading service checks for workflow %s: %w", wfRef, err)
+	}
 
 	return &blackstart.Workflow{
 		Name:              kwf.Name,
 		Namespace:         kwf.Namespace,
 		Description:       kwf.Spec.Description,
 		ReconcileInterval: reconcileInterval,
+		CheckInterval:     checkInterval,
+		SkipUnchangedFor:  skipUnchangedFor,
+		MaxRetryBackoff:   maxRetryBackoff,
+		Timeout:           timeout,
+		MaintenanceWindow: maintenanceWindow,
 		Operations:        ops,
+		MaxDeletions:      kwf.Spec.MaxDeletions,
+		ApprovedDeletions: approvedDeletions,
+		InjectedFailures:  injectedFailures,
+		OnlyLabels:        labelFiltersFromAnnotations(kwf.Annotations, v1alpha1.OnlyLabelsAnnotation),
+		SkipLabels:        labelFiltersFromAnnotations(kwf.Annotations, v1alpha1.SkipLabelsAnnotation),
+		PublishOutputs:    published,
+		ServiceChecks:     checks,
 		Source:            kwf,
+		Warnings:          deprecatedInputWarnings(kwf.Spec),
 	}, nil
 }
 
+// approvedDeletionsFromAnnotations reads the number of approved deletions from the approved-deletions
+// annotation of a Workflow resource. A missing annotation approves no deletions.
+func approvedDeletionsFromAnnotations(annotations map[string]string) (int, error) {
+	raw, ok := annotations[v1alpha1.ApprovedDeletionsAnnotation]
+	if !ok || strings.TrimSpace(raw) == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf(
+			"invalid %s annotation %q: expected a non-negative integer", v1alpha1.ApprovedDeletionsAnnotation, raw,
+		)
+	}
+	return n, nil
+}
+
 // loadWorkflowFromFile reads a workflow definition from a file and converts it to a core Workflow.
 func loadWorkflowFromFile(ctx context.Context) (*blackstart.Workflow, error) {
 	logger := loggerFromCtx(ctx)
@@ -369,7 +471,7 @@ func loadWorkflowFromFile(ctx context.Context) (*blackstart.Workflow, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error reading workflow file: %w", err)
 	}
-	return workflowFromConfigBytes(workflowConfig)
+	return workflowFromConfigBytes(workflowConfig, config.Parameters)
 }
 
 // loadWorkflowFromEnv reads workflow YAML from an env var source
@@ -384,7 +486,7 @@ func loadWorkflowFromEnv(ctx context.Context) (*blackstart.Workflow, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error loading workflow from environment source: %w", err)
 	}
-	return workflowFromConfigBytes(workflowConfig)
+	return workflowFromConfigBytes(workflowConfig, config.Parameters)
 }
 
 // loadWorkflowFromGCS reads workflow YAML from a GCS source
@@ -402,7 +504,7 @@ func loadWorkflowFromGCS(ctx context.Context) (*blackstart.Workflow, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error loading workflow from GCS source: %w", err)
 	}
-	return workflowFromConfigBytes(workflowConfig)
+	return workflowFromConfigBytes(workflowConfig, config.Parameters)
 }
 
 func parseReconcileInterval(raw string) (time.Duration, error) {
@@ -420,8 +522,40 @@ func parseReconcileInterval(raw string) (time.Duration, error) {
 	return d, nil
 }
 
-// loadOperations converts operations from configuration to core operations.
-func loadOperations(ops []v1alpha1.Operation) ([]blackstart.Operation, error) {
+// parseOptionalDuration parses an optional duration field of a workflow, such as
+// skipUnchangedFor. An empty value is returned as zero, which disables the feature of the field.
+func parseOptionalDuration(field, raw string) (time.Duration, error) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", field, raw, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must not be negative", field, raw)
+	}
+	return d, nil
+}
+
+// parseMaintenanceWindow converts the maintenance window of a workflow to a core maintenance window.
+// A nil window is returned when the workflow has no maintenance window.
+func parseMaintenanceWindow(mw *v1alpha1.MaintenanceWindow) (*blackstart.MaintenanceWindow, error) {
+	if mw == nil {
+		return nil, nil
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(mw.Duration))
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window duration %q: %w", mw.Duration, err)
+	}
+	return blackstart.NewMaintenanceWindow(mw.Schedule, duration, mw.TimeZone)
+}
+
+// loadOperations converts operations from configuration to core operations. Inputs that reference
+// a workflow parameter are replaced with the resolved parameter value as a static input, inputs
+// from a file are replaced with the file contents, and encrypted inputs with the decrypted value.
+func loadOperations(ops []v1alpha1.Operation, params map[string]string) ([]blackstart.Operation, error) {
 	var err error
 	bOps := make([]blackstart.Operation, len(ops))
 	for i, op := range ops {
@@ -431,10 +565,40 @@ func loadOperations(ops []v1alpha1.Operation) ([]blackstart.Operation, error) {
 		coreOp.Id = op.Id
 		coreOp.Description = op.Description
 		coreOp.DependsOn = op.DependsOn
+		coreOp.Labels = op.Labels
 		coreOp.DoesNotExist = op.DoesNotExist
+		coreOp.OnFailure = op.OnFailure
 		coreOp.Tainted = op.Tainted
 		coreOp.Inputs = make(map[string]blackstart.Input)
 		for k, v := range op.Inputs {
+			if v.FromParameter != "" {
+				val, ok := params[v.FromParameter]
+				if !ok {
+					return nil, fmt.Errorf(
+						"operation %s input %s references undeclared parameter %s", op.Id, k, v.FromParameter,
+					)
+				}
+				coreOp.Inputs[k] = blackstart.NewInputFromValue(val)
+				continue
+			}
+			if v.FromFile != nil {
+				var val any
+				val, err = readInputFile(v.FromFile)
+				if err != nil {
+					return nil, fmt.Errorf("error reading file for operation %s input %s: %w", op.Id, k, err)
+				}
+				coreOp.Inputs[k] = blackstart.NewSensitiveInputFromValue(val)
+				continue
+			}
+			if v.Encrypted != "" {
+				var val string
+				val, err = decryptInput(v.Encrypted)
+				if err != nil {
+					return nil, fmt.Errorf("error decrypting operation %s input %s: %w", op.Id, k, err)
+				}
+				coreOp.Inputs[k] = blackstart.NewSensitiveInputFromValue(val)
+				continue
+			}
 			if v.Extra != nil && v.FromDependency == nil {
 				var val any
 				val, err = decodeOperationInputExtra(v.Extra.Raw)
@@ -446,6 +610,17 @@ func loadOperations(ops []v1alpha1.Operation) ([]blackstart.Operation, error) {
 				coreOp.Inputs[k] = blackstart.NewInputFromValue(val)
 				continue
 			}
+			if v.Default != nil {
+				var val any
+				val, err = decodeOperationInputExtra(v.Default.Raw)
+				if err != nil {
+					return nil, fmt.Errorf("error decoding default of operation %s input %s: %w", op.Id, k, err)
+				}
+				coreOp.Inputs[k] = blackstart.NewInputFromDepWithDefault(
+					v.FromDependency.Id, v.FromDependency.Output, val,
+				)
+				continue
+			}
 			coreOp.Inputs[k] = blackstart.NewInputFromDep(v.FromDependency.Id, v.FromDependency.Output)
 		}
 		bOps[i] = *coreOp
@@ -454,6 +629,95 @@ func loadOperations(ops []v1alpha1.Operation) ([]blackstart.Operation, error) {
 
 }
 
+// deprecatedInputWarnings describes the inputs of the operations and module defaults of a workflow
+// that use deprecated keys.
+func deprecatedInputWarnings(spec v1alpha1.WorkflowSpec) []string {
+	var warnings []string
+	add := func(owner string, inputs map[string]*v1alpha1.OperationInput) {
+		for _, key := range slices.Sorted(maps.Keys(inputs)) {
+			if input := inputs[key]; input != nil && input.DeprecatedKey != "" {
+				warnings = append(
+					warnings, fmt.Sprintf(
+						"%s input %s uses the deprecated key %s instead of fromDependency", owner, key,
+						input.DeprecatedKey,
+					),
+				)
+			}
+		}
+	}
+	for _, defaults := range spec.ModuleDefaults {
+		add("module defaults of "+strings.Join(defaults.Modules, ", "), defaults.Inputs)
+	}
+	for _, op := range spec.Operations {
+		add("operation "+op.Id, op.Inputs)
+	}
+	return warnings
+}
+
+// inputFileDirs are the directories that fromFile inputs may be read from.
+var inputFileDirs []string
+
+// readInputFile reads the value of a fromFile input. Files are read each time a workflow is
+// loaded, so rotated files such as projected service account tokens are read again for every run.
+// Files outside of inputFileDirs are not read, so a workflow cannot read arbitrary files of the
+// runtime, such as its own credentials.
+func readInputFile(file *v1alpha1.FromFile) (any, error) {
+	if strings.TrimSpace(file.Path) == "" {
+		return nil, fmt.Errorf("fromFile path must not be empty")
+	}
+	path, err := allowedInputFile(file.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(strings.TrimSpace(file.Format)) {
+	case "", "raw":
+		return string(data), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data), nil
+	case "json":
+		var val any
+		if err = json.Unmarshal(data, &val); err != nil {
+			return nil, fmt.Errorf("invalid JSON in %s: %w", file.Path, err)
+		}
+		return val, nil
+	default:
+		return nil, fmt.Errorf("invalid fromFile format %q: expected raw, base64, or json", file.Format)
+	}
+}
+
+// allowedInputFile resolves the symlinks of a fromFile path and returns the resolved path when it
+// is inside one of the inputFileDirs.
+func allowedInputFile(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err = filepath.Abs(resolved); err != nil {
+		return "", err
+	}
+	for _, dir := range inputFileDirs {
+		dir, err = filepath.EvalSymlinks(strings.TrimSpace(dir))
+		if err != nil {
+			continue
+		}
+		if dir, err = filepath.Abs(dir); err != nil {
+			continue
+		}
+		if rel, relErr := filepath.Rel(dir, resolved); relErr == nil && rel != ".." &&
+			!strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"fromFile path %s is not in an allowed directory: add its directory to %s", path,
+		blackstart.InputFileDirsEnv,
+	)
+}
+
 // decodeOperationInputExtra decodes static operation input data captured in
 // OperationInput.Extra.Raw. The raw bytes can come from YAML or JSON
 // unmarshalling paths, so this attempts JSON first and then falls back to YAML.
@@ -468,6 +732,29 @@ func decodeOperationInputExtra(raw []byte) (any, error) {
 	return nil, fmt.Errorf("unable to decode raw input value")
 }
 
+// workflowClientConfig creates the config of the client for Workflow resources from the kubeconfig
+// context and the client throughput of the runtime configuration. Unset throughput values keep the
+// client defaults.
+func workflowClientConfig(config *blackstart.RuntimeConfig) (*rest.Config, error) {
+	if config.KubeAPIQPS < 0 {
+		return nil, fmt.Errorf("invalid kube API QPS %v: must not be negative", config.KubeAPIQPS)
+	}
+	if config.KubeAPIBurst < 0 {
+		return nil, fmt.Errorf("invalid kube API burst %d: must not be negative", config.KubeAPIBurst)
+	}
+	clientConfig, err := util.GetK8sClientConfigWithContext(strings.TrimSpace(config.KubeContext))
+	if err != nil {
+		return nil, err
+	}
+	if config.KubeAPIQPS > 0 {
+		clientConfig.QPS = config.KubeAPIQPS
+	}
+	if config.KubeAPIBurst > 0 {
+		clientConfig.Burst = config.KubeAPIBurst
+	}
+	return clientConfig, nil
+}
+
 // loadK8sApiSchemes initializes the Kubernetes API schemes and stores them in the context.
 func loadK8sApiSchemes(ctx context.Context, logger *slog.Logger) context.Context {
 	scheme := runtime.NewScheme()
@@ -479,12 +766,13 @@ func loadK8sApiSchemes(ctx context.Context, logger *slog.Logger) context.Context
 	return context.WithValue(ctx, blackstart.SchemeKey, scheme)
 }
 
+// workflowKubeClient creates the client used to read Workflow resources and write their status.
 func workflowKubeClient(ctx context.Context) (client.Client, error) {
 	var c client.Client
 
 	scheme := ctx.Value(blackstart.SchemeKey).(*runtime.Scheme)
 
-	clientConfig, err := util.GetK8sClientConfig()
+	clientConfig, err := workflowClientConfig(configFromCtx(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/pezops/blackstart"
 	"github.com/pezops/blackstart/api/v1alpha1"
 	"gopkg.in/yaml.v3"
 )
@@ -80,7 +84,7 @@ operations:
 	require.NotNil(t, input.Extra)
 	assert.Equal(t, "bstest", string(input.Extra.Raw))
 
-	ops, err := loadOperations(cfg.Operations)
+	ops, err := loadOperations(cfg.Operations, nil)
 	require.NoError(t, err)
 	require.Len(t, ops, 1)
 
@@ -89,3 +93,139 @@ operations:
 	v := in.Any()
 	assert.Equal(t, "bstest", v)
 }
+
+func TestLoadOperations_FromFileInput(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("secret-token"), 0o600))
+	configPath := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"user": "app", "port": 5432}`), 0o600))
+	outside := t.TempDir()
+	outsidePath := filepath.Join(outside, "credentials")
+	require.NoError(t, os.WriteFile(outsidePath, []byte("runtime-credentials"), 0o600))
+	linkPath := filepath.Join(dir, "link")
+	require.NoError(t, os.Symlink(outsidePath, linkPath))
+
+	original := inputFileDirs
+	t.Cleanup(func() { inputFileDirs = original })
+	inputFileDirs = []string{dir}
+
+	tests := []struct {
+		name     string
+		file     *v1alpha1.FromFile
+		expected any
+		err      string
+	}{
+		{name: "raw_default", file: &v1alpha1.FromFile{Path: tokenPath}, expected: "secret-token"},
+		{name: "raw", file: &v1alpha1.FromFile{Path: tokenPath, Format: "raw"}, expected: "secret-token"},
+		{
+			name:     "base64",
+			file:     &v1alpha1.FromFile{Path: tokenPath, Format: "base64"},
+			expected: "c2VjcmV0LXRva2Vu",
+		},
+		{
+			name:     "json",
+			file:     &v1alpha1.FromFile{Path: configPath, Format: "json"},
+			expected: map[string]any{"user": "app", "port": float64(5432)},
+		},
+		{name: "invalid_json", file: &v1alpha1.FromFile{Path: tokenPath, Format: "json"}, err: "invalid JSON"},
+		{name: "invalid_format", file: &v1alpha1.FromFile{Path: tokenPath, Format: "yaml"}, err: `format "yaml"`},
+		{name: "missing_file", file: &v1alpha1.FromFile{Path: filepath.Join(dir, "missing")}, err: "no such file"},
+		{name: "empty_path", file: &v1alpha1.FromFile{}, err: "path must not be empty"},
+		{name: "outside_dirs", file: &v1alpha1.FromFile{Path: outsidePath}, err: "not in an allowed directory"},
+		{name: "symlink_outside_dirs", file: &v1alpha1.FromFile{Path: linkPath}, err: "not in an allowed directory"},
+		{
+			name: "relative_outside_dirs",
+			file: &v1alpha1.FromFile{Path: filepath.Join(dir, "..", filepath.Base(outside), "credentials")},
+			err:  "not in an allowed directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops, err := loadOperations(
+				[]v1alpha1.Operation{
+					{
+						Id:     "op",
+						Module: "test",
+						Inputs: map[string]*v1alpha1.OperationInput{"value": {FromFile: tt.file}},
+					},
+				}, nil,
+			)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, "operation op input value")
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, ops, 1)
+			assert.Equal(t, tt.expected, ops[0].Inputs["value"].Any())
+		})
+	}
+}
+
+func TestLoadOperations_FromFileInputSensitive(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(tokenPath, []byte("secret-token"), 0o600))
+
+	original := inputFileDirs
+	t.Cleanup(func() { inputFileDirs = original })
+	inputFileDirs = []string{dir}
+
+	ops, err := loadOperations(
+		[]v1alpha1.Operation{
+			{
+				Id:     "op",
+				Module: "util_template",
+				Inputs: map[string]*v1alpha1.OperationInput{"template": {FromFile: &v1alpha1.FromFile{Path: tokenPath}}},
+			},
+		}, nil,
+	)
+	require.NoError(t, err)
+
+	wf := &blackstart.Workflow{Name: "demo", Operations: ops}
+	result := wf.Run(context.Background())
+	require.NoError(t, result.Err)
+	require.Len(t, result.Plan, 1)
+	assert.Equal(t, blackstart.MaskedValue, result.Plan[0].Inputs["template"].Value)
+	require.Len(t, result.Operations, 1)
+	assert.Equal(t, blackstart.MaskedValue, result.Operations[0].Inputs["template"])
+}
+
+func TestLoadOperations_DependencyDefault(t *testing.T) {
+	const opsYAML = `
+- id: grant
+  module: postgres_grant
+  inputs:
+    connection:
+      fromDependency:
+        id: instance
+        output: connection
+    schema:
+      fromDependency:
+        id: database
+        output: schema
+      default: public
+`
+
+	var cfg []v1alpha1.Operation
+	require.NoError(t, yaml.Unmarshal([]byte(opsYAML), &cfg))
+	ops, err := loadOperations(cfg, nil)
+	require.NoError(t, err)
+	require.Len(t, ops, 1)
+
+	_, ok := blackstart.DependencyDefault(ops[0].Inputs["connection"])
+	assert.False(t, ok)
+	value, ok := blackstart.DependencyDefault(ops[0].Inputs["schema"])
+	assert.True(t, ok)
+	assert.Equal(t, "public", value)
+	assert.Equal(t, "database", ops[0].Inputs["schema"].DependencyId())
+
+	// The default is kept for the operations of forEach instances.
+	op := instanceOperation("team-a", ops[0])
+	assert.Equal(t, "team-a/database", op.Inputs["schema"].DependencyId())
+	value, ok = blackstart.DependencyDefault(op.Inputs["schema"])
+	assert.True(t, ok)
+	assert.Equal(t, "public", value)
+}
@@ -2,18 +2,25 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"sort"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 
 	"github.com/pezops/blackstart"
 	"github.com/pezops/blackstart/api/v1alpha1"
@@ -61,11 +68,6 @@ func TestRunInK8sMode(t *testing.T) {
 	ctx = context.WithValue(ctx, blackstart.LoggerKey, logger)
 	ctx = context.WithValue(ctx, blackstart.ConfigKey, config)
 
-	// Replace the updateWorkflowStatusInK8s function with the mock
-	originalUpdateWorkflowStatus := updateWorkflowStatusFunc
-	updateWorkflowStatusFunc = mockUpdateWorkflowStatus
-	defer func() { updateWorkflowStatusFunc = originalUpdateWorkflowStatus }()
-
 	// main logic with the injected fake client
 	err = run(ctx, fakeClient)
 	require.NoError(t, err, "run() should execute without error in the fake k8s environment")
@@ -313,7 +315,75 @@ func TestRunInK8sMode_ContinuesWhenOneWorkflowHasDuplicateOperationIDs(t *testin
 	invalidObj, ok := invalidRaw.(*v1alpha1.Workflow)
 	require.True(t, ok)
 	require.Equal(t, "false", invalidObj.Status.Successful)
+	require.Contains(t, invalidObj.Status.LastError, `duplicate operation id "dup"`)
 	require.Contains(t, invalidObj.Status.Result, `duplicate operation id "dup"`)
+	ready := meta.FindStatusCondition(invalidObj.Status.Conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	require.Equal(t, metav1.ConditionFalse, ready.Status)
+	require.Equal(t, "SetupFailed", ready.Reason)
+	require.Contains(t, ready.Message, `duplicate operation id "dup"`)
+}
+
+func TestRunWorkflowsInK8s_IsolatesFailuresAndBoundsConcurrency(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	objects := []client.Object{
+		&v1alpha1.Workflow{
+			ObjectMeta: metav1.ObjectMeta{Name: "bad-interval", Namespace: "team-a"},
+			Spec:       v1alpha1.WorkflowSpec{ReconcileInterval: "soon"},
+		},
+	}
+	for _, name := range []string{"wf-1", "wf-2", "wf-3", "wf-4", "wf-5"} {
+		objects = append(objects, &v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "team-a"}})
+	}
+	var mu sync.Mutex
+	var active, maxActive int
+	ran := map[string]bool{}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithInterceptorFuncs(
+			interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					listOpts := &client.ListOptions{}
+					listOpts.ApplyOptions(opts)
+					if listOpts.Namespace == "broken" {
+						return apierrors.NewForbidden(
+							v1alpha1.SchemeGroupVersion.WithResource("workflows").GroupResource(), "", nil,
+						)
+					}
+					return c.List(ctx, list, opts...)
+				},
+				SubResourcePatch: func(
+					_ context.Context, _ client.Client, _ string, obj client.Object, _ client.Patch,
+					_ ...client.SubResourcePatchOption,
+				) error {
+					mu.Lock()
+					active++
+					maxActive = max(maxActive, active)
+					ran[obj.GetName()] = true
+					mu.Unlock()
+					time.Sleep(20 * time.Millisecond)
+					mu.Lock()
+					active--
+					mu.Unlock()
+					return nil
+				},
+			},
+		).
+		Build()
+
+	config := &blackstart.RuntimeConfig{KubeNamespace: "broken,team-a", MaxParallelReconciliations: 2}
+	ctx := context.WithValue(context.Background(), blackstart.LoggerKey, slog.New(slog.DiscardHandler))
+	ctx = context.WithValue(ctx, blackstart.ConfigKey, config)
+
+	err := runWorkflowsInK8s(ctx, fakeClient)
+	require.ErrorContains(t, err, `namespace "broken": error listing workflows`)
+	require.ErrorContains(t, err, "workflow team-a/bad-interval: error parsing reconcile interval")
+
+	assert.Len(t, ran, 5, "every valid workflow should run")
+	assert.Equal(t, 2, maxActive, "at most the maximum parallel reconciliations should run at once")
 }
 
 func TestParseReconcileInterval(t *testing.T) {
@@ -373,6 +443,36 @@ func TestParseReconcileInterval(t *testing.T) {
 	}
 }
 
+func TestParseOptionalDuration(t *testing.T) {
+	got, err := parseOptionalDuration("skipUnchangedFor", "")
+	require.NoError(t, err)
+	require.Zero(t, got)
+
+	got, err = parseOptionalDuration("skipUnchangedFor", "1h")
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, got)
+
+	_, err = parseOptionalDuration("skipUnchangedFor", "-1h")
+	require.ErrorContains(t, err, `invalid skipUnchangedFor "-1h"`)
+	_, err = parseOptionalDuration("checkInterval", "hourly")
+	require.ErrorContains(t, err, `invalid checkInterval "hourly"`)
+}
+
+func TestParseMaintenanceWindow(t *testing.T) {
+	mw, err := parseMaintenanceWindow(nil)
+	require.NoError(t, err)
+	require.Nil(t, mw)
+
+	mw, err = parseMaintenanceWindow(
+		&v1alpha1.MaintenanceWindow{Schedule: "0 2 * * SAT", Duration: "4h", TimeZone: "Europe/Berlin"},
+	)
+	require.NoError(t, err)
+	require.True(t, mw.Open(time.Date(2026, 3, 7, 3, 0, 0, 0, time.UTC)))
+
+	_, err = parseMaintenanceWindow(&v1alpha1.MaintenanceWindow{Schedule: "0 2 * * SAT", Duration: "long"})
+	require.ErrorContains(t, err, `invalid maintenance window duration "long"`)
+}
+
 func TestParseRuntimeMode(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -421,3 +521,171 @@ func TestParseRuntimeMode(t *testing.T) {
 		)
 	}
 }
+
+func TestWorkflowFromK8sResource_MaxDeletions(t *testing.T) {
+	maxDeletions := 1
+	kwf := &v1alpha1.Workflow{
+		Spec: v1alpha1.WorkflowSpec{
+			MaxDeletions: &maxDeletions,
+			Operations: []v1alpha1.Operation{
+				{Id: "a", Module: "test_module", DoesNotExist: true},
+				{Id: "b", Module: "test_module", DoesNotExist: true},
+			},
+		},
+	}
+	kwf.Name = "cleanup"
+	kwf.Namespace = "default"
+
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	require.NotNil(t, wf.MaxDeletions)
+	assert.Equal(t, 1, *wf.MaxDeletions)
+	assert.Equal(t, 0, wf.ApprovedDeletions)
+
+	kwf.Annotations = map[string]string{v1alpha1.ApprovedDeletionsAnnotation: "2"}
+	wf, err = workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, wf.ApprovedDeletions)
+
+	kwf.Annotations[v1alpha1.ApprovedDeletionsAnnotation] = "all"
+	_, err = workflowFromK8sResource(kwf)
+	require.ErrorContains(t, err, "expected a non-negative integer")
+}
+
+func TestWorkflowFromK8sResource_DeprecatedInputs(t *testing.T) {
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+	}
+	data := `{
+  "moduleDefaults": [
+    {"modules": ["test_*"], "inputs": {"client": {"from_dependency": {"id": "a", "output": "client"}}}}
+  ],
+  "operations": [
+    {"id": "a", "module": "test_module"},
+    {"id": "b", "module": "test_module", "inputs": {"value": {"from_dependency": {"id": "a", "output": "value"}}}}
+  ]
+}`
+	require.NoError(t, json.Unmarshal([]byte(data), &kwf.Spec))
+
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(
+		t, []string{
+			"module defaults of test_* input client uses the deprecated key from_dependency instead of fromDependency",
+			"operation b input value uses the deprecated key from_dependency instead of fromDependency",
+		}, wf.Warnings,
+	)
+	require.Len(t, wf.Operations, 2)
+	value := wf.Operations[1].Inputs["value"]
+	require.False(t, value.IsStatic())
+	assert.Equal(t, "a", value.DependencyId())
+}
+
+func TestWorkflowFromK8sResource_MaxRetryBackoff(t *testing.T) {
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Spec:       v1alpha1.WorkflowSpec{Operations: []v1alpha1.Operation{{Id: "a", Module: "test_module"}}},
+	}
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(t, blackstart.DefaultMaxRetryBackoff, wf.MaxRetryBackoff)
+
+	kwf.Spec.MaxRetryBackoff = "0s"
+	wf, err = workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Zero(t, wf.MaxRetryBackoff)
+
+	kwf.Spec.MaxRetryBackoff = "6h"
+	wf, err = workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Hour, wf.MaxRetryBackoff)
+
+	kwf.Spec.MaxRetryBackoff = "-1h"
+	_, err = workflowFromK8sResource(kwf)
+	require.ErrorContains(t, err, `invalid maxRetryBackoff "-1h"`)
+}
+
+func TestWorkflowFromK8sResource_Timeout(t *testing.T) {
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Spec:       v1alpha1.WorkflowSpec{Operations: []v1alpha1.Operation{{Id: "a", Module: "test_module"}}},
+	}
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Zero(t, wf.Timeout)
+
+	kwf.Spec.Timeout = "30m"
+	wf, err = workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, wf.Timeout)
+
+	kwf.Spec.Timeout = "soon"
+	_, err = workflowFromK8sResource(kwf)
+	require.ErrorContains(t, err, `invalid timeout "soon"`)
+}
+
+func TestWorkflowFromK8sResource_OnFailure(t *testing.T) {
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Spec: v1alpha1.WorkflowSpec{
+			Operations: []v1alpha1.Operation{{Id: "a", Module: "test_module", OnFailure: "continue"}},
+		},
+	}
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(t, blackstart.OnFailureContinue, wf.Operations[0].OnFailure)
+}
+
+func TestWorkflowClientConfig(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	require.NoError(
+		t, os.WriteFile(
+			kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+  - name: dev
+    cluster:
+      server: https://dev.example.com
+  - name: prod
+    cluster:
+      server: https://prod.example.com
+contexts:
+  - name: dev
+    context:
+      cluster: dev
+      user: admin
+  - name: prod
+    context:
+      cluster: prod
+      user: admin
+users:
+  - name: admin
+    user:
+      token: test-token
+`), 0o600,
+		),
+	)
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	clientConfig, err := workflowClientConfig(&blackstart.RuntimeConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://dev.example.com", clientConfig.Host)
+	assert.Zero(t, clientConfig.QPS)
+	assert.Zero(t, clientConfig.Burst)
+
+	clientConfig, err = workflowClientConfig(
+		&blackstart.RuntimeConfig{KubeContext: " prod ", KubeAPIQPS: 50, KubeAPIBurst: 100},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "https://prod.example.com", clientConfig.Host)
+	assert.Equal(t, float32(50), clientConfig.QPS)
+	assert.Equal(t, 100, clientConfig.Burst)
+
+	_, err = workflowClientConfig(&blackstart.RuntimeConfig{KubeContext: "missing"})
+	assert.ErrorContains(t, err, `context "missing" does not exist`)
+	_, err = workflowClientConfig(&blackstart.RuntimeConfig{KubeAPIQPS: -1})
+	assert.EqualError(t, err, "invalid kube API QPS -1: must not be negative")
+	_, err = workflowClientConfig(&blackstart.RuntimeConfig{KubeAPIBurst: -1})
+	assert.EqualError(t, err, "invalid kube API burst -1: must not be negative")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// applyModuleDefaults returns a copy of the operations with the module defaults of a workflow
+// added. A default input is only added to operations of matching modules that declare the input
+// and do not set it. When several entries match an operation, the first entry that sets an input
+// takes precedence.
+func applyModuleDefaults(defaults []v1alpha1.ModuleDefaults, ops []v1alpha1.Operation) ([]v1alpha1.Operation, error) {
+	for i, d := range defaults {
+		if len(d.Modules) == 0 {
+			return nil, fmt.Errorf("moduleDefaults entry %d must list at least one module", i)
+		}
+		for _, pattern := range d.Modules {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("moduleDefaults entry %d has invalid module pattern %q: %w", i, pattern, err)
+			}
+		}
+		for k, v := range d.Inputs {
+			if v == nil {
+				return nil, fmt.Errorf("moduleDefaults entry %d input %s must not be empty", i, k)
+			}
+		}
+	}
+
+	registered := blackstart.GetRegisteredModules()
+	result := make([]v1alpha1.Operation, len(ops))
+	for i := range ops {
+		ops[i].DeepCopyInto(&result[i])
+		op := &result[i]
+		newModule, ok := registered[op.Module]
+		if !ok {
+			// Unknown modules are reported when the workflow is validated.
+			continue
+		}
+		declared := newModule().Info().Inputs
+		for _, d := range defaults {
+			if !matchesModule(d.Modules, op.Module) {
+				continue
+			}
+			for k, v := range d.Inputs {
+				if _, ok = declared[k]; !ok {
+					continue
+				}
+				if _, ok = op.Inputs[k]; ok {
+					continue
+				}
+				if v.FromDependency != nil && v.FromDependency.Id == op.Id {
+					// The operation that provides the default does not depend on itself.
+					continue
+				}
+				if op.Inputs == nil {
+					op.Inputs = make(map[string]*v1alpha1.OperationInput)
+				}
+				op.Inputs[k] = v.DeepCopy()
+			}
+		}
+	}
+	return result, nil
+}
+
+// matchesModule reports whether the module identifier matches one of the patterns.
+func matchesModule(patterns []string, module string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, module); ok {
+			return true
+		}
+	}
+	return false
+}
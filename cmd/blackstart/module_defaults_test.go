@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const moduleDefaultsWorkflowYAML = `name: app-config
+parameters:
+  - name: namespace
+    default: apps
+moduleDefaults:
+  - modules:
+      - kubernetes_*
+    inputs:
+      client:
+        fromDependency:
+          id: k8s-client
+          output: client
+      namespace:
+        fromParameter: namespace
+  - modules:
+      - kubernetes_configmap
+    inputs:
+      namespace: config
+operations:
+  - id: k8s-client
+    module: kubernetes_client
+  - id: settings
+    module: kubernetes_configmap
+    inputs:
+      name: settings
+  - id: credentials
+    module: kubernetes_secret
+    inputs:
+      name: credentials
+      namespace: secrets
+`
+
+func TestWorkflowOperations_ModuleDefaults(t *testing.T) {
+	wf, err := workflowFromConfigBytes([]byte(moduleDefaultsWorkflowYAML), nil)
+	require.NoError(t, err)
+	require.Len(t, wf.Operations, 3)
+
+	// The client operation does not depend on itself, and does not declare a namespace input.
+	assert.Empty(t, wf.Operations[0].Inputs)
+
+	settings := wf.Operations[1]
+	assert.Equal(t, "k8s-client", settings.Inputs["client"].DependencyId())
+	assert.Equal(t, "client", settings.Inputs["client"].OutputKey())
+	assert.Equal(t, "apps", settings.Inputs["namespace"].Any())
+
+	// Inputs set by the operation take precedence over the defaults.
+	credentials := wf.Operations[2]
+	assert.Equal(t, "k8s-client", credentials.Inputs["client"].DependencyId())
+	assert.Equal(t, "secrets", credentials.Inputs["namespace"].Any())
+}
+
+func TestWorkflowOperations_ModuleDefaultsForEach(t *testing.T) {
+	wfYAML := `name: tenants
+parameters:
+  - name: tenant
+    required: true
+forEach:
+  - name: a
+    parameters:
+      tenant: a
+moduleDefaults:
+  - modules:
+      - kubernetes_*
+    inputs:
+      client:
+        fromDependency:
+          id: k8s-client
+          output: client
+operations:
+  - id: k8s-client
+    module: kubernetes_client
+  - id: settings
+    module: kubernetes_configmap
+    inputs:
+      name:
+        fromParameter: tenant
+`
+	wf, err := workflowFromConfigBytes([]byte(wfYAML), nil)
+	require.NoError(t, err)
+	require.Len(t, wf.Operations, 2)
+	assert.Equal(t, "a/k8s-client", wf.Operations[1].Inputs["client"].DependencyId())
+}
+
+func TestWorkflowOperations_ModuleDefaultsErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults string
+		errMsg   string
+	}{
+		{
+			name:     "no modules",
+			defaults: "moduleDefaults:\n  - inputs:\n      namespace: apps\n",
+			errMsg:   "moduleDefaults entry 0 must list at least one module",
+		},
+		{
+			name:     "invalid pattern",
+			defaults: "moduleDefaults:\n  - modules:\n      - \"kubernetes_[\"\n    inputs:\n      namespace: apps\n",
+			errMsg:   `moduleDefaults entry 0 has invalid module pattern "kubernetes_["`,
+		},
+		{
+			name: "undeclared parameter",
+			defaults: "moduleDefaults:\n  - modules:\n      - kubernetes_*\n" +
+				"    inputs:\n      namespace:\n        fromParameter: missing\n",
+			errMsg: "operation settings input namespace references undeclared parameter missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				wfYAML := "name: defaults\n" + tt.defaults + `operations:
+  - id: settings
+    module: kubernetes_configmap
+    inputs:
+      name: settings
+`
+				_, err := workflowFromConfigBytes([]byte(wfYAML), nil)
+				require.ErrorContains(t, err, tt.errMsg)
+			},
+		)
+	}
+}
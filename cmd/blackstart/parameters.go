@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// parseParameterFlags parses `--set key=value` flag values into a map of parameter values. The
+// value is everything after the first `=`, so values may contain `=` characters.
+func parseParameterFlags(raw []string) (map[string]string, error) {
+	values := make(map[string]string, len(raw))
+	for _, item := range raw {
+		key, value, found := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid parameter %q: expected key=value", item)
+		}
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("parameter %q is set more than once", key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// parametersFromAnnotations reads parameter values from the parameters annotation of a Workflow
+// resource. A missing annotation results in no parameter values.
+func parametersFromAnnotations(annotations map[string]string) (map[string]string, error) {
+	raw, ok := annotations[v1alpha1.ParametersAnnotation]
+	if !ok || strings.TrimSpace(raw) == "" {
+		return map[string]string{}, nil
+	}
+	var values map[string]string
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return nil, fmt.Errorf(
+			"invalid %s annotation: expected a JSON object of string values: %w", v1alpha1.ParametersAnnotation, err,
+		)
+	}
+	return values, nil
+}
+
+// resolveParameters combines the parameters declared by a workflow with the values supplied for a
+// run. Every declared parameter is present in the result: supplied values take precedence, then
+// defaults, and optional parameters without either resolve to an empty string. Values for
+// undeclared parameters and missing required values are errors.
+func resolveParameters(defs []v1alpha1.WorkflowParameter, values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(defs))
+	for _, def := range defs {
+		name := strings.TrimSpace(def.Name)
+		if name == "" {
+			return nil, fmt.Errorf("parameter name must not be empty")
+		}
+		if _, ok := resolved[name]; ok {
+			return nil, fmt.Errorf("parameter %q is declared more than once", name)
+		}
+		switch value, ok := values[name]; {
+		case ok:
+			resolved[name] = value
+		case def.Default != nil:
+			resolved[name] = *def.Default
+		case def.Required:
+			return nil, fmt.Errorf("missing value for required parameter %q", name)
+		default:
+			resolved[name] = ""
+		}
+	}
+
+	var unknown []string
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("undeclared parameters: %s", strings.Join(unknown, ", "))
+	}
+	return resolved, nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestParseParameterFlags(t *testing.T) {
+	values, err := parseParameterFlags([]string{"instance=prod-db", "dsn=host=db port=5432", "empty="})
+	require.NoError(t, err)
+	assert.Equal(
+		t, map[string]string{"instance": "prod-db", "dsn": "host=db port=5432", "empty": ""}, values,
+	)
+
+	_, err = parseParameterFlags([]string{"instance"})
+	require.ErrorContains(t, err, "expected key=value")
+
+	_, err = parseParameterFlags([]string{"=value"})
+	require.ErrorContains(t, err, "expected key=value")
+
+	_, err = parseParameterFlags([]string{"instance=a", "instance=b"})
+	require.ErrorContains(t, err, "set more than once")
+}
+
+func TestParametersFromAnnotations(t *testing.T) {
+	values, err := parametersFromAnnotations(nil)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	values, err = parametersFromAnnotations(
+		map[string]string{v1alpha1.ParametersAnnotation: `{"instance":"stage-db"}`},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"instance": "stage-db"}, values)
+
+	_, err = parametersFromAnnotations(map[string]string{v1alpha1.ParametersAnnotation: `{"port":5432}`})
+	require.ErrorContains(t, err, "expected a JSON object of string values")
+}
+
+func TestResolveParameters(t *testing.T) {
+	region := "us-central1"
+	defs := []v1alpha1.WorkflowParameter{
+		{Name: "instance", Required: true},
+		{Name: "region", Default: &region},
+		{Name: "suffix"},
+	}
+
+	tests := []struct {
+		name     string
+		defs     []v1alpha1.WorkflowParameter
+		values   map[string]string
+		expected map[string]string
+		errMsg   string
+	}{
+		{
+			name:     "defaults applied",
+			defs:     defs,
+			values:   map[string]string{"instance": "dev-db"},
+			expected: map[string]string{"instance": "dev-db", "region": "us-central1", "suffix": ""},
+		},
+		{
+			name:     "values override defaults",
+			defs:     defs,
+			values:   map[string]string{"instance": "prod-db", "region": "europe-west1"},
+			expected: map[string]string{"instance": "prod-db", "region": "europe-west1", "suffix": ""},
+		},
+		{
+			name:   "missing required",
+			defs:   defs,
+			values: map[string]string{},
+			errMsg: `missing value for required parameter "instance"`,
+		},
+		{
+			name:   "undeclared value",
+			defs:   defs,
+			values: map[string]string{"instance": "dev-db", "zone": "a", "tier": "b"},
+			errMsg: "undeclared parameters: tier, zone",
+		},
+		{
+			name:   "duplicate declaration",
+			defs:   []v1alpha1.WorkflowParameter{{Name: "instance"}, {Name: "instance"}},
+			errMsg: "declared more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				resolved, err := resolveParameters(tt.defs, tt.values)
+				if tt.errMsg != "" {
+					require.ErrorContains(t, err, tt.errMsg)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, resolved)
+			},
+		)
+	}
+}
+
+func TestWorkflowFromConfigBytes_Parameters(t *testing.T) {
+	const wfYAML = `
+name: parameterized
+parameters:
+  - name: instance
+    required: true
+operations:
+  - id: manage-instance
+    module: google_cloudsql_managed_instance
+    inputs:
+      instance:
+        fromParameter: instance
+`
+
+	wf, err := workflowFromConfigBytes([]byte(wfYAML), []string{"instance=stage-db"})
+	require.NoError(t, err)
+	require.Len(t, wf.Operations, 1)
+	assert.Equal(t, "stage-db", wf.Operations[0].Inputs["instance"].Any())
+
+	_, err = workflowFromConfigBytes([]byte(wfYAML), nil)
+	require.ErrorContains(t, err, `missing value for required parameter "instance"`)
+}
+
+func TestWorkflowFromK8sResource_Parameters(t *testing.T) {
+	kwf := &v1alpha1.Workflow{
+		Spec: v1alpha1.WorkflowSpec{
+			Parameters: []v1alpha1.WorkflowParameter{{Name: "instance", Required: true}},
+			Operations: []v1alpha1.Operation{
+				{
+					Id:     "manage-instance",
+					Module: "google_cloudsql_managed_instance",
+					Inputs: map[string]*v1alpha1.OperationInput{
+						"instance": {FromParameter: "instance"},
+						"region":   {FromParameter: "region"},
+					},
+				},
+			},
+		},
+	}
+	kwf.Name = "parameterized"
+	kwf.Namespace = "default"
+
+	_, err := workflowFromK8sResource(kwf)
+	require.ErrorContains(t, err, `missing value for required parameter "instance"`)
+
+	kwf.Annotations = map[string]string{v1alpha1.ParametersAnnotation: `{"instance":"prod-db"}`}
+	_, err = workflowFromK8sResource(kwf)
+	require.ErrorContains(t, err, "references undeclared parameter region")
+
+	delete(kwf.Spec.Operations[0].Inputs, "region")
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	assert.Equal(t, "prod-db", wf.Operations[0].Inputs["instance"].Any())
+}
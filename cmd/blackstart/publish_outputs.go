@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// publishOutputs converts the publishOutputs section of a workflow spec. Each entry must name
+// either a ConfigMap or a Secret, and list at least one output with a data key.
+func publishOutputs(specs []v1alpha1.PublishOutputs) ([]blackstart.PublishOutputs, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	published := make([]blackstart.PublishOutputs, 0, len(specs))
+	for i, spec := range specs {
+		configMap := strings.TrimSpace(spec.ConfigMap)
+		secret := strings.TrimSpace(spec.Secret)
+		p := blackstart.PublishOutputs{Namespace: strings.TrimSpace(spec.Namespace)}
+		switch {
+		case configMap != "" && secret != "":
+			return nil, fmt.Errorf("publishOutputs entry %d sets both configMap and secret", i)
+		case configMap != "":
+			p.Kind, p.Name = blackstart.PublishConfigMap, configMap
+		case secret != "":
+			p.Kind, p.Name = blackstart.PublishSecret, secret
+		default:
+			return nil, fmt.Errorf("publishOutputs entry %d must set configMap or secret", i)
+		}
+		if len(spec.Outputs) == 0 {
+			return nil, fmt.Errorf("publishOutputs entry %d has no outputs", i)
+		}
+		for _, out := range spec.Outputs {
+			key := strings.TrimSpace(out.Key)
+			if key == "" {
+				return nil, fmt.Errorf("publishOutputs entry %d has an output without a key", i)
+			}
+			if out.FromDependency.Id == "" || out.FromDependency.Output == "" {
+				return nil, fmt.Errorf("publishOutputs entry %d output %q must set fromDependency id and output", i, key)
+			}
+			p.Outputs = append(
+				p.Outputs, blackstart.PublishedOutput{
+					Key:         key,
+					OperationId: out.FromDependency.Id,
+					OutputKey:   out.FromDependency.Output,
+				},
+			)
+		}
+		published = append(published, p)
+	}
+	return published, nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestPublishOutputs(t *testing.T) {
+	output := v1alpha1.PublishedOutput{
+		Key:            "DATABASE_URL",
+		FromDependency: v1alpha1.FromDependency{Id: "db", Output: "url"},
+	}
+
+	tests := []struct {
+		name    string
+		specs   []v1alpha1.PublishOutputs
+		want    []blackstart.PublishOutputs
+		wantErr string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "configMap and secret",
+			specs: []v1alpha1.PublishOutputs{
+				{ConfigMap: "db", Outputs: []v1alpha1.PublishedOutput{output}},
+				{Secret: " db-credentials ", Namespace: "apps", Outputs: []v1alpha1.PublishedOutput{output}},
+			},
+			want: []blackstart.PublishOutputs{
+				{
+					Kind: blackstart.PublishConfigMap, Name: "db",
+					Outputs: []blackstart.PublishedOutput{{Key: "DATABASE_URL", OperationId: "db", OutputKey: "url"}},
+				},
+				{
+					Kind: blackstart.PublishSecret, Name: "db-credentials", Namespace: "apps",
+					Outputs: []blackstart.PublishedOutput{{Key: "DATABASE_URL", OperationId: "db", OutputKey: "url"}},
+				},
+			},
+		},
+		{
+			name:    "configMap and secret in one entry",
+			specs:   []v1alpha1.PublishOutputs{{ConfigMap: "db", Secret: "db", Outputs: []v1alpha1.PublishedOutput{output}}},
+			wantErr: "publishOutputs entry 0 sets both configMap and secret",
+		},
+		{
+			name:    "no target",
+			specs:   []v1alpha1.PublishOutputs{{Outputs: []v1alpha1.PublishedOutput{output}}},
+			wantErr: "publishOutputs entry 0 must set configMap or secret",
+		},
+		{
+			name:    "no outputs",
+			specs:   []v1alpha1.PublishOutputs{{ConfigMap: "db"}},
+			wantErr: "publishOutputs entry 0 has no outputs",
+		},
+		{
+			name: "no key",
+			specs: []v1alpha1.PublishOutputs{
+				{ConfigMap: "db", Outputs: []v1alpha1.PublishedOutput{{FromDependency: output.FromDependency}}},
+			},
+			wantErr: "publishOutputs entry 0 has an output without a key",
+		},
+		{
+			name: "no output",
+			specs: []v1alpha1.PublishOutputs{
+				{
+					ConfigMap: "db",
+					Outputs: []v1alpha1.PublishedOutput{
+						{Key: "DATABASE_URL", FromDependency: v1alpha1.FromDependency{Id: "db"}},
+					},
+				},
+			},
+			wantErr: `publishOutputs entry 0 output "DATABASE_URL" must set fromDependency id and output`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				got, err := publishOutputs(test.specs)
+				if test.wantErr != "" {
+					assert.EqualError(t, err, test.wantErr)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			},
+		)
+	}
+}
+
+func TestWorkflowFromConfigBytes_PublishOutputs(t *testing.T) {
+	wf, err := workflowFromConfigBytes(
+		[]byte(`name: publish
+publishOutputs:
+  - secret: db-credentials
+    namespace: apps
+    outputs:
+      - key: DATABASE_URL
+        fromDependency:
+          id: db
+          output: url
+operations:
+  - id: db
+    module: test_module
+`), nil,
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t, []blackstart.PublishOutputs{
+			{
+				Kind: blackstart.PublishSecret, Name: "db-credentials", Namespace: "apps",
+				Outputs: []blackstart.PublishedOutput{{Key: "DATABASE_URL", OperationId: "db", OutputKey: "url"}},
+			},
+		}, wf.PublishOutputs,
+	)
+}
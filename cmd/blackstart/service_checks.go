@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// serviceChecks converts the serviceChecks section of a workflow spec. The names and kinds of the
+// checks are validated when the workflow runs.
+func serviceChecks(specs []v1alpha1.ServiceCheck) ([]blackstart.ServiceCheck, error) {
+	if len(specs) == 0 {
+		return nil, nil
+	}
+	checks := make([]blackstart.ServiceCheck, 0, len(specs))
+	for _, spec := range specs {
+		timeout, err := parseOptionalDuration("timeout", spec.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("service check %q: %w", spec.Name, err)
+		}
+		checks = append(
+			checks, blackstart.ServiceCheck{
+				Name:    spec.Name,
+				Kind:    spec.Kind,
+				Params:  spec.Params,
+				Timeout: timeout,
+			},
+		)
+	}
+	return checks, nil
+}
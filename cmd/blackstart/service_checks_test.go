@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestServiceChecks(t *testing.T) {
+	checks, err := serviceChecks(nil)
+	require.NoError(t, err)
+	assert.Nil(t, checks)
+
+	checks, err = serviceChecks(
+		[]v1alpha1.ServiceCheck{
+			{Name: "cluster", Kind: "kubernetes"},
+			{Name: "db", Kind: "tcp", Params: map[string]string{"address": "db.internal:5432"}, Timeout: "30s"},
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t, []blackstart.ServiceCheck{
+			{Name: "cluster", Kind: blackstart.ServiceCheckKubernetes},
+			{
+				Name: "db", Kind: blackstart.ServiceCheckTCP, Params: map[string]string{"address": "db.internal:5432"},
+				Timeout: 30 * time.Second,
+			},
+		}, checks,
+	)
+
+	_, err = serviceChecks([]v1alpha1.ServiceCheck{{Name: "db", Kind: "tcp", Timeout: "soon"}})
+	assert.ErrorContains(t, err, `service check "db": invalid timeout "soon"`)
+}
+
+func TestWorkflowFromConfigBytes_ServiceChecks(t *testing.T) {
+	wf, err := workflowFromConfigBytes(
+		[]byte(`name: service-checks
+serviceChecks:
+  - name: db
+    kind: tcp
+    params:
+      address: db.internal:5432
+operations:
+  - id: db
+    module: test_module
+`), nil,
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t, []blackstart.ServiceCheck{
+			{Name: "db", Kind: blackstart.ServiceCheckTCP, Params: map[string]string{"address": "db.internal:5432"}},
+		}, wf.ServiceChecks,
+	)
+}
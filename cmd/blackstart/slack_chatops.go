@@ -0,0 +1,554 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+const (
+	slackPathPrefix       = "/slack/"
+	slackCommandsPath     = "/slack/commands"
+	slackInteractionsPath = "/slack/interactions"
+
+	// slackMaxRequestAge is the maximum age of the timestamp of a signed Slack request, so a
+	// captured request cannot be replayed later.
+	slackMaxRequestAge = 5 * time.Minute
+	slackMaxBodyBytes  = 1 << 20
+	// slackResultTimeout is how long the result of a run requested from Slack is waited for. The
+	// response URLs of Slack expire after 30 minutes.
+	slackResultTimeout = 30 * time.Minute
+	slackPollInterval  = 5 * time.Second
+
+	slackActionRun    = "run"
+	slackActionCheck  = "check"
+	slackActionCancel = "cancel"
+
+	// slackAllUsers is the allowed user that allows all users of the workspace to run workflows.
+	slackAllUsers = "*"
+)
+
+// slackChatOps serves the Slack ChatOps endpoints of the trigger API. A slash command lists the
+// scheduled workflows, shows the status of a workflow, and requests runs, which are confirmed with
+// a button. The result of a confirmed run is posted back to the channel.
+//
+// Requests are authenticated with the signing secret of the Slack app instead of the bearer token
+// of the trigger API.
+type slackChatOps struct {
+	ctx          context.Context
+	scheduler    *controllerScheduler
+	client       client.Client
+	secretFile   string
+	allowedUsers map[string]bool
+	httpClient   *http.Client
+	pollInterval time.Duration
+	logger       *slog.Logger
+}
+
+// slackMessage is a message posted in response to a Slack command or interaction.
+type slackMessage struct {
+	ResponseType    string       `json:"response_type,omitempty"`
+	Text            string       `json:"text,omitempty"`
+	Blocks          []slackBlock `json:"blocks,omitempty"`
+	ReplaceOriginal bool         `json:"replace_original,omitempty"`
+	DeleteOriginal  bool         `json:"delete_original,omitempty"`
+}
+
+// slackBlock is a section or actions block of a Slack message.
+type slackBlock struct {
+	Type     string         `json:"type"`
+	Text     *slackText     `json:"text,omitempty"`
+	Elements []slackElement `json:"elements,omitempty"`
+}
+
+// slackText is a text object of a Slack message.
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// slackElement is a button of an actions block.
+type slackElement struct {
+	Type     string     `json:"type"`
+	Text     *slackText `json:"text"`
+	ActionID string     `json:"action_id"`
+	Value    string     `json:"value"`
+	Style    string     `json:"style,omitempty"`
+}
+
+// slackInteraction is the payload of a Slack interaction, such as a click of a button.
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// newSlackChatOps creates the Slack ChatOps endpoints from the runtime configuration. The signing
+// secret file must contain a secret. Results of runs are waited for until the context is done.
+// Only the allowed users can run workflows, so no user can run workflows when none are configured.
+func newSlackChatOps(
+	ctx context.Context,
+	config *blackstart.RuntimeConfig,
+	scheduler *controllerScheduler,
+	c client.Client,
+	logger *slog.Logger,
+) (*slackChatOps, error) {
+	allowedUsers := make(map[string]bool, len(config.SlackAllowedUsers))
+	for _, user := range config.SlackAllowedUsers {
+		if user = strings.TrimSpace(user); user != "" {
+			allowedUsers[user] = true
+		}
+	}
+	s := &slackChatOps{
+		ctx:          ctx,
+		scheduler:    scheduler,
+		client:       c,
+		secretFile:   config.SlackSigningSecretFile,
+		allowedUsers: allowedUsers,
+		httpClient:   &http.Client{Transport: blackstart.HTTPTransport(), Timeout: 10 * time.Second},
+		pollInterval: slackPollInterval,
+		logger:       logger,
+	}
+	if _, err := s.secret(); err != nil {
+		return nil, err
+	}
+	switch {
+	case len(allowedUsers) == 0:
+		logger.Warn(
+			"no Slack users are allowed to run workflows, set BLACKSTART_SLACK_ALLOWED_USERS to the user IDs " +
+				"allowed to run workflows",
+		)
+	case allowedUsers[slackAllUsers]:
+		logger.Warn("all users of the Slack workspace are allowed to run workflows")
+	}
+	return s, nil
+}
+
+// secret reads the signing secret of the Slack app from the secret file. The file is read for each
+// request, so a rotated secret is used without restarting Blackstart.
+func (s *slackChatOps) secret() (string, error) {
+	b, err := os.ReadFile(s.secretFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading Slack signing secret file: %w", err)
+	}
+	secret := strings.TrimSpace(string(b))
+	if secret == "" {
+		return "", fmt.Errorf("signing secret file %s of the Slack app is empty", s.secretFile)
+	}
+	return secret, nil
+}
+
+// handler returns the HTTP handler of the Slack endpoints. The signature of a request is verified
+// before it is routed with the handler returned by limit, which applies the rate limit of the API.
+func (s *slackChatOps) handler(limit func(http.Handler) http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+slackCommandsPath, s.handleCommand)
+	mux.HandleFunc("POST "+slackInteractionsPath, s.handleInteraction)
+	routed := limit(mux)
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, err := s.verify(r)
+			if err != nil {
+				s.logger.Warn("rejected Slack request", "path", r.URL.Path, "error", err)
+				writeTriggerError(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			routed.ServeHTTP(w, r)
+		},
+	)
+}
+
+// verify checks the signature of a Slack request and returns its body.
+func (s *slackChatOps) verify(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, slackMaxBodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body: %w", err)
+	}
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request timestamp %q", timestamp)
+	}
+	if age := time.Since(time.Unix(sent, 0)); age > slackMaxRequestAge || age < -slackMaxRequestAge {
+		return nil, fmt.Errorf("request timestamp is not within %s", slackMaxRequestAge)
+	}
+	secret, err := s.secret()
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(r.Header.Get("X-Slack-Signature")), []byte(slackSignature(secret, timestamp, body))) {
+		return nil, fmt.Errorf("invalid request signature")
+	}
+	return body, nil
+}
+
+// slackSignature returns the signature of a Slack request with the timestamp and body.
+func slackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "v0:%s:", timestamp)
+	_, _ = mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// authorized reports whether a Slack user is allowed to run workflows.
+func (s *slackChatOps) authorized(user string) bool {
+	return s.allowedUsers[slackAllUsers] || s.allowedUsers[user]
+}
+
+// handleCommand responds to a slash command. Responses are only visible to the user of the
+// command.
+func (s *slackChatOps) handleCommand(w http.ResponseWriter, r *http.Request) {
+	command := r.PostFormValue("command")
+	args := strings.Fields(r.PostFormValue("text"))
+	if len(args) == 0 {
+		writeSlackMessage(w, slackUsage(command))
+		return
+	}
+
+	subcommand := strings.ToLower(args[0])
+	if subcommand == "list" {
+		writeSlackMessage(w, s.listMessage())
+		return
+	}
+	if len(args) != 2 || (subcommand != "status" && subcommand != slackActionRun && subcommand != slackActionCheck) {
+		writeSlackMessage(w, slackUsage(command))
+		return
+	}
+	key, err := parseSlackWorkflow(args[1])
+	if err != nil {
+		writeSlackMessage(w, slackMessage{Text: err.Error()})
+		return
+	}
+	if _, ok := s.scheduler.workflowState(key); !ok {
+		writeSlackMessage(w, slackMessage{Text: fmt.Sprintf("Workflow `%s` is not scheduled.", key)})
+		return
+	}
+
+	if subcommand == "status" {
+		writeSlackMessage(w, s.statusMessage(r.Context(), key))
+		return
+	}
+	if !s.authorized(r.PostFormValue("user_id")) {
+		writeSlackMessage(w, slackMessage{Text: "You are not allowed to run workflows."})
+		return
+	}
+	writeSlackMessage(w, slackConfirmation(subcommand, key))
+}
+
+// handleInteraction handles a click of a button of a confirmation. Slack expects a response within
+// three seconds, so messages are posted to the response URL of the interaction in the background.
+func (s *slackChatOps) handleInteraction(w http.ResponseWriter, r *http.Request) {
+	var interaction slackInteraction
+	if err := json.Unmarshal([]byte(r.PostFormValue("payload")), &interaction); err != nil {
+		writeTriggerError(w, http.StatusBadRequest, "invalid interaction payload")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if interaction.Type != "block_actions" || len(interaction.Actions) == 0 || interaction.ResponseURL == "" {
+		return
+	}
+
+	action := interaction.Actions[0]
+	responseURL := interaction.ResponseURL
+	if action.ActionID == slackActionCancel {
+		go s.post(responseURL, slackMessage{DeleteOriginal: true})
+		return
+	}
+	if action.ActionID != slackActionRun && action.ActionID != slackActionCheck {
+		return
+	}
+	if !s.authorized(interaction.User.ID) {
+		go s.post(responseURL, slackMessage{ReplaceOriginal: true, Text: "You are not allowed to run workflows."})
+		return
+	}
+	key, err := parseSlackWorkflow(action.Value)
+	if err != nil {
+		go s.post(responseURL, slackMessage{ReplaceOriginal: true, Text: err.Error()})
+		return
+	}
+
+	checkOnly := action.ActionID == slackActionCheck
+	err = s.scheduler.requestRun(key, checkOnly)
+	switch {
+	case errors.Is(err, errWorkflowNotScheduled):
+		go s.post(responseURL, slackMessage{ReplaceOriginal: true, Text: fmt.Sprintf("Workflow `%s` is not scheduled.", key)})
+		return
+	case errors.Is(err, errWorkflowBusy):
+		go s.post(
+			responseURL,
+			slackMessage{ReplaceOriginal: true, Text: fmt.Sprintf("Workflow `%s` is already queued or running.", key)},
+		)
+		return
+	case err != nil:
+		go s.post(responseURL, slackMessage{ReplaceOriginal: true, Text: err.Error()})
+		return
+	}
+
+	s.logger.Info(
+		"run requested with Slack", "workflow", key.String(), "checkOnly", checkOnly, "user", interaction.User.ID,
+	)
+	go func() {
+		s.post(responseURL, slackMessage{DeleteOriginal: true})
+		s.post(
+			responseURL, slackMessage{
+				ResponseType: "in_channel",
+				Text:         fmt.Sprintf("<@%s> requested a %s of `%s`.", interaction.User.ID, slackRunKind(checkOnly), key),
+			},
+		)
+		s.postResult(key, checkOnly, responseURL)
+	}()
+}
+
+// listMessage returns a message with the schedule of every scheduled workflow.
+func (s *slackChatOps) listMessage() slackMessage {
+	keys := s.scheduler.scheduledKeys()
+	if len(keys) == 0 {
+		return slackMessage{Text: "No workflows are scheduled."}
+	}
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		state, ok := s.scheduler.workflowState(key)
+		if !ok {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("• `%s`: %s", key, slackScheduleSummary(state)))
+	}
+	return slackMessage{Text: strings.Join(lines, "\n")}
+}
+
+// statusMessage returns a message with the schedule of a workflow and the status of its last run.
+func (s *slackChatOps) statusMessage(ctx context.Context, key types.NamespacedName) slackMessage {
+	var kwf v1alpha1.Workflow
+	if err := s.client.Get(ctx, key, &kwf); err != nil {
+		if apierrors.IsNotFound(err) {
+			return slackMessage{Text: fmt.Sprintf("Workflow `%s` does not exist.", key)}
+		}
+		s.logger.Error("error getting workflow for Slack", "workflow", key.String(), "error", err)
+		return slackMessage{Text: fmt.Sprintf("Error getting workflow `%s`.", key)}
+	}
+	lines := []string{fmt.Sprintf("*%s*", key)}
+	if state, ok := s.scheduler.workflowState(key); ok {
+		lines = append(lines, "Schedule: "+slackScheduleSummary(state))
+	}
+	status := kwf.Status
+	if status.LastRan.IsZero() {
+		lines = append(lines, "Last run: never")
+	} else {
+		lines = append(
+			lines, fmt.Sprintf("Last run: %s, %s", slackTime(status.LastRan.Time), slackRunSummary(status.Conditions)),
+		)
+	}
+	if len(status.DriftedOperations) > 0 {
+		lines = append(lines, "Drifted operations: "+strings.Join(status.DriftedOperations, ", "))
+	}
+	return slackMessage{Text: strings.Join(lines, "\n")}
+}
+
+// postResult waits until a requested run of a workflow is complete and posts its result to the
+// response URL. Nothing is posted when the run does not complete before the response URL expires.
+func (s *slackChatOps) postResult(key types.NamespacedName, checkOnly bool, responseURL string) {
+	ctx, cancel := context.WithTimeout(s.ctx, slackResultTimeout)
+	defer cancel()
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		state, ok := s.scheduler.workflowState(key)
+		if !ok {
+			return
+		}
+		if !state.Queued && !state.Running {
+			break
+		}
+	}
+
+	var kwf v1alpha1.Workflow
+	if err := s.client.Get(ctx, key, &kwf); err != nil {
+		s.logger.Error("error getting workflow for Slack", "workflow", key.String(), "error", err)
+		return
+	}
+	summary := slackRunSummary(kwf.Status.Conditions)
+	if checkOnly {
+		summary = slackCheckSummary(kwf.Status.Conditions)
+	}
+	s.post(
+		responseURL, slackMessage{
+			ResponseType: "in_channel",
+			Text:         fmt.Sprintf("The %s of `%s` %s.", slackRunKind(checkOnly), key, summary),
+		},
+	)
+}
+
+// post posts a message to the response URL of a Slack command or interaction. Errors are logged.
+func (s *slackChatOps) post(responseURL string, message slackMessage) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		s.logger.Error("error encoding Slack message", "error", err)
+		return
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		s.logger.Error("error posting Slack message", "error", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.logger.Error("error posting Slack message", "error", err)
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		s.logger.Error("error posting Slack message", "status", resp.StatusCode)
+	}
+}
+
+// writeSlackMessage writes the response to a slash command.
+func writeSlackMessage(w http.ResponseWriter, message slackMessage) {
+	writeTriggerJSON(w, http.StatusOK, message)
+}
+
+// parseSlackWorkflow parses a workflow given as `namespace/name`.
+func parseSlackWorkflow(value string) (types.NamespacedName, error) {
+	namespace, name, ok := strings.Cut(value, "/")
+	if !ok || namespace == "" || name == "" || strings.Contains(name, "/") {
+		return types.NamespacedName{}, fmt.Errorf("workflow must be given as `namespace/name`, got `%s`", value)
+	}
+	return types.NamespacedName{Namespace: namespace, Name: name}, nil
+}
+
+// slackUsage returns the usage of the slash command.
+func slackUsage(command string) slackMessage {
+	if command == "" {
+		command = "/blackstart"
+	}
+	return slackMessage{
+		Text: strings.Join(
+			[]string{
+				"Usage:",
+				fmt.Sprintf("• `%s list`: list the scheduled workflows", command),
+				fmt.Sprintf("• `%s status <namespace>/<name>`: show the status of a workflow", command),
+				fmt.Sprintf("• `%s run <namespace>/<name>`: run a workflow", command),
+				fmt.Sprintf("• `%s check <namespace>/<name>`: run a workflow in check-only mode", command),
+			}, "\n",
+		),
+	}
+}
+
+// slackConfirmation returns the message that confirms a run of a workflow.
+func slackConfirmation(action string, key types.NamespacedName) slackMessage {
+	text := fmt.Sprintf("Start a %s of `%s`?", slackRunKind(action == slackActionCheck), key)
+	return slackMessage{
+		Text: text,
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}},
+			{
+				Type: "actions",
+				Elements: []slackElement{
+					{
+						Type:     "button",
+						Text:     &slackText{Type: "plain_text", Text: "Confirm"},
+						ActionID: action,
+						Value:    key.String(),
+						Style:    "primary",
+					},
+					{
+						Type:     "button",
+						Text:     &slackText{Type: "plain_text", Text: "Cancel"},
+						ActionID: slackActionCancel,
+						Value:    key.String(),
+					},
+				},
+			},
+		},
+	}
+}
+
+// slackRunKind returns the kind of a run in messages.
+func slackRunKind(checkOnly bool) string {
+	if checkOnly {
+		return "check-only run"
+	}
+	return "run"
+}
+
+// slackScheduleSummary describes the schedule of a workflow.
+func slackScheduleSummary(state scheduledWorkflowState) string {
+	switch {
+	case state.Running:
+		return "running"
+	case state.Queued:
+		return "queued"
+	default:
+		return "next run " + slackTime(state.NextRunAt)
+	}
+}
+
+// slackRunSummary describes the result of the last run of a workflow from its Ready condition.
+func slackRunSummary(conditions []metav1.Condition) string {
+	ready := meta.FindStatusCondition(conditions, v1alpha1.ConditionReady)
+	switch {
+	case ready == nil:
+		return "completed"
+	case ready.Status == metav1.ConditionTrue:
+		return "succeeded: " + ready.Message
+	case ready.Reason == v1alpha1.ReasonPendingWindow:
+		return "completed with " + ready.Message
+	default:
+		return "failed: " + ready.Message
+	}
+}
+
+// slackCheckSummary describes the result of the last check-only run of a workflow from its
+// Drifted condition.
+func slackCheckSummary(conditions []metav1.Condition) string {
+	drifted := meta.FindStatusCondition(conditions, v1alpha1.ConditionDrifted)
+	switch {
+	case drifted == nil:
+		return "completed"
+	case drifted.Reason == v1alpha1.ReasonDriftDetected:
+		return "found drift: " + drifted.Message
+	case drifted.Reason == v1alpha1.ReasonInSync:
+		return "found no drift: " + drifted.Message
+	default:
+		return "failed: " + drifted.Message
+	}
+}
+
+// slackTime formats a time in messages.
+func slackTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// newTestSlackChatOps adds the Slack endpoints to a trigger API for a scheduler with a workflow
+// that is not due, and returns a channel of the messages posted to response URLs.
+func newTestSlackChatOps(
+	t *testing.T, allowedUsers ...string,
+) (*triggerAPI, *controllerScheduler, string, <-chan slackMessage) {
+	t.Helper()
+	api, scheduler, _ := newTestTriggerAPI(t, 100)
+	secretFile := filepath.Join(t.TempDir(), "signing-secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("slack-secret\n"), 0o600))
+	config := &blackstart.RuntimeConfig{SlackSigningSecretFile: secretFile, SlackAllowedUsers: allowedUsers}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	slack, err := newSlackChatOps(ctx, config, scheduler, api.client, api.logger)
+	require.NoError(t, err)
+	slack.pollInterval = 10 * time.Millisecond
+	api.slack = slack
+
+	messages := make(chan slackMessage, 10)
+	responses := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				var message slackMessage
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&message))
+				messages <- message
+			},
+		),
+	)
+	t.Cleanup(responses.Close)
+	return api, scheduler, responses.URL, messages
+}
+
+// slackRequest sends a Slack request with the form signed with the secret to the handler of the
+// API.
+func slackRequest(api *triggerAPI, path, secret string, form url.Values) *httptest.ResponseRecorder {
+	body := form.Encode()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", slackSignature(secret, timestamp, []byte(body)))
+	rec := httptest.NewRecorder()
+	api.handler().ServeHTTP(rec, req)
+	return rec
+}
+
+// slackCommand sends a signed slash command to the handler of the API and returns the response.
+func slackCommand(t *testing.T, api *triggerAPI, user, text string) slackMessage {
+	t.Helper()
+	rec := slackRequest(
+		api, slackCommandsPath, "slack-secret",
+		url.Values{"command": {"/blackstart"}, "text": {text}, "user_id": {user}},
+	)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var message slackMessage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &message))
+	return message
+}
+
+// nextSlackMessage returns the next message posted to a response URL.
+func nextSlackMessage(t *testing.T, messages <-chan slackMessage) slackMessage {
+	t.Helper()
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no Slack message was posted")
+		return slackMessage{}
+	}
+}
+
+func TestSlackChatOps_Signature(t *testing.T) {
+	api, _, _, _ := newTestSlackChatOps(t)
+	form := url.Values{"command": {"/blackstart"}, "text": {"list"}}
+
+	rec := slackRequest(api, slackCommandsPath, "wrong-secret", form)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, slackCommandsPath, strings.NewReader(form.Encode()))
+	timestamp := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+	req.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	req.Header.Set("X-Slack-Signature", slackSignature("slack-secret", timestamp, []byte(form.Encode())))
+	rec = httptest.NewRecorder()
+	api.handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Slack requests do not use the bearer token of the trigger API.
+	rec = slackRequest(api, slackCommandsPath, "slack-secret", form)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestSlackChatOps_Commands(t *testing.T) {
+	api, _, _, _ := newTestSlackChatOps(t, "U1")
+
+	message := slackCommand(t, api, "U1", "")
+	assert.Contains(t, message.Text, "`/blackstart run <namespace>/<name>`")
+
+	message = slackCommand(t, api, "U1", "list")
+	assert.Contains(t, message.Text, "• `apps/tenant-db`: next run ")
+
+	message = slackCommand(t, api, "U1", "status apps/tenant-db")
+	assert.Contains(t, message.Text, "*apps/tenant-db*\nSchedule: next run ")
+	assert.Contains(t, message.Text, "Last run: ")
+
+	message = slackCommand(t, api, "U1", "status tenant-db")
+	assert.Contains(t, message.Text, "workflow must be given as `namespace/name`")
+	message = slackCommand(t, api, "U1", "run apps/unknown")
+	assert.Equal(t, "Workflow `apps/unknown` is not scheduled.", message.Text)
+	message = slackCommand(t, api, "U2", "run apps/tenant-db")
+	assert.Equal(t, "You are not allowed to run workflows.", message.Text)
+
+	message = slackCommand(t, api, "U1", "check apps/tenant-db")
+	assert.Empty(t, message.ResponseType)
+	require.Len(t, message.Blocks, 2)
+	require.Len(t, message.Blocks[1].Elements, 2)
+	assert.Equal(t, slackActionCheck, message.Blocks[1].Elements[0].ActionID)
+	assert.Equal(t, "apps/tenant-db", message.Blocks[1].Elements[0].Value)
+}
+
+func TestSlackChatOps_AllowedUsers(t *testing.T) {
+	// No user can run workflows when no users are allowed.
+	api, _, _, _ := newTestSlackChatOps(t)
+	message := slackCommand(t, api, "U1", "run apps/tenant-db")
+	assert.Equal(t, "You are not allowed to run workflows.", message.Text)
+	message = slackCommand(t, api, "U1", "status apps/tenant-db")
+	assert.Contains(t, message.Text, "*apps/tenant-db*")
+
+	api, _, _, _ = newTestSlackChatOps(t, slackAllUsers)
+	message = slackCommand(t, api, "U1", "run apps/tenant-db")
+	require.Len(t, message.Blocks, 2)
+	assert.Equal(t, slackActionRun, message.Blocks[1].Elements[0].ActionID)
+}
+
+func TestSlackChatOps_ConfirmRun(t *testing.T) {
+	api, scheduler, responseURL, messages := newTestSlackChatOps(t, "U1")
+	interaction := func(action string) {
+		payload, err := json.Marshal(
+			map[string]any{
+				"type":         "block_actions",
+				"user":         map[string]string{"id": "U1"},
+				"response_url": responseURL,
+				"actions":      []map[string]string{{"action_id": action, "value": "apps/tenant-db"}},
+			},
+		)
+		require.NoError(t, err)
+		rec := slackRequest(api, slackInteractionsPath, "slack-secret", url.Values{"payload": {string(payload)}})
+		require.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	interaction(slackActionCancel)
+	assert.True(t, nextSlackMessage(t, messages).DeleteOriginal)
+	require.Empty(t, scheduler.dueWorkflows(time.Now()))
+
+	interaction(slackActionRun)
+	assert.True(t, nextSlackMessage(t, messages).DeleteOriginal)
+	message := nextSlackMessage(t, messages)
+	assert.Equal(t, "in_channel", message.ResponseType)
+	assert.Equal(t, "<@U1> requested a run of `apps/tenant-db`.", message.Text)
+
+	due := scheduler.dueWorkflows(time.Now())
+	require.Len(t, due, 1)
+	assert.False(t, due[0].checkOnly)
+	scheduler.markRunning(due[0].entry)
+
+	var kwf v1alpha1.Workflow
+	key := types.NamespacedName{Namespace: "apps", Name: "tenant-db"}
+	require.NoError(t, api.client.Get(context.Background(), key, &kwf))
+	kwf.Status.Conditions = []metav1.Condition{
+		{
+			Type:    v1alpha1.ConditionReady,
+			Status:  metav1.ConditionTrue,
+			Reason:  v1alpha1.ReasonSucceeded,
+			Message: "1/1 operations completed",
+		},
+	}
+	require.NoError(t, api.client.Update(context.Background(), &kwf))
+	scheduler.markDone(due[0].entry, time.Now())
+
+	message = nextSlackMessage(t, messages)
+	assert.Equal(t, "in_channel", message.ResponseType)
+	assert.Equal(t, "The run of `apps/tenant-db` succeeded: 1/1 operations completed.", message.Text)
+}
@@ -10,14 +10,8 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
-
-	"github.com/pezops/blackstart"
-	"github.com/pezops/blackstart/api/v1alpha1"
 )
 
-// Define a variable to hold the updateWorkflowStatusInK8s function. In tests, we will mock the status update process.
-var updateWorkflowStatusFunc = updateWorkflowStatusInK8s
-
 // fakeClientWithStatus extend the fake client to support the status subresource. This allows the controller-runtime
 // fake client to be used in tests that involve status updates.
 type fakeClientWithStatus struct {
@@ -51,10 +45,11 @@ func (f *fakeStatusWriter) Update(
 	return nil
 }
 
-// Patch is not implemented in this fake resource for simplicity.
+// Patch simulates patching the status subresource by storing the patched object in the status map.
 func (f *fakeStatusWriter) Patch(
 	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption,
 ) error {
+	f.parent.statusStore.Store(namespacedStoreKey(obj), obj.DeepCopyObject())
 	return nil
 }
 
@@ -82,17 +77,6 @@ func newFakeClientWithStatus(scheme *runtime.Scheme, objs ...client.Object) clie
 	}
 }
 
-// mockUpdateWorkflowStatus is a mock of the updateWorkflowStatusInK8s function to bypass the fake client, which can't
-// handle status updates.
-func mockUpdateWorkflowStatus(
-	ctx context.Context, _ client.Client, wf *blackstart.Workflow, status v1alpha1.WorkflowStatus,
-) error {
-	// Log the status update for verification
-	logger := loggerFromCtx(ctx)
-	logger.Info("Mock updateWorkflowStatusInK8s called", "workflow", wf.Name, "status", status)
-	return nil
-}
-
 // patchEnv sets an environment variable to a new value for the duration of a test. It returns a deferrable function
 // that restores the original value.
 func patchEnv(t *testing.T, key string, value string) (restore func()) {
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+const triggerAPIShutdownTimeout = 5 * time.Second
+
+// triggerAPI serves the HTTP trigger API of the controller, which requests on-demand runs of
+// scheduled workflows and reports their status, so CI pipelines and ChatOps can run a workflow
+// without access to the Kubernetes API.
+type triggerAPI struct {
+	scheduler *controllerScheduler
+	client    client.Client
+	tokenFile string
+	limiter   *rate.Limiter
+	logger    *slog.Logger
+	// slack serves the Slack ChatOps endpoints when a signing secret is configured.
+	slack *slackChatOps
+}
+
+// triggerRunResponse is the response to a run request of the trigger API.
+type triggerRunResponse struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	CheckOnly bool   `json:"checkOnly"`
+}
+
+// triggerStatusResponse is the response to a status request of the trigger API.
+type triggerStatusResponse struct {
+	Namespace string                  `json:"namespace"`
+	Name      string                  `json:"name"`
+	Schedule  scheduledWorkflowState  `json:"schedule"`
+	Status    v1alpha1.WorkflowStatus `json:"status"`
+}
+
+// triggerErrorResponse is the response of the trigger API to a request that failed.
+type triggerErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// newTriggerAPI creates the trigger API of the controller from the runtime configuration. The
+// token file must contain a token, and the rate limit applies to all authenticated requests of the
+// API.
+func newTriggerAPI(
+	config *blackstart.RuntimeConfig, scheduler *controllerScheduler, c client.Client, logger *slog.Logger,
+) (*triggerAPI, error) {
+	if strings.TrimSpace(config.TriggerAPITokenFile) == "" {
+		return nil, fmt.Errorf("the trigger API requires a token file")
+	}
+	if config.TriggerAPIRateLimit <= 0 {
+		return nil, fmt.Errorf("invalid trigger API rate limit %d: must be greater than 0", config.TriggerAPIRateLimit)
+	}
+	api := &triggerAPI{
+		scheduler: scheduler,
+		client:    c,
+		tokenFile: config.TriggerAPITokenFile,
+		limiter: rate.NewLimiter(
+			rate.Every(time.Minute/time.Duration(config.TriggerAPIRateLimit)), config.TriggerAPIRateLimit,
+		),
+		logger: logger,
+	}
+	if _, err := api.token(); err != nil {
+		return nil, err
+	}
+	return api, nil
+}
+
+// token reads the bearer token of the API from the token file. The file is read for each request,
+// so a rotated token is used without restarting Blackstart.
+func (a *triggerAPI) token() (string, error) {
+	b, err := os.ReadFile(a.tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("error reading trigger API token file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("trigger API token file %s is empty", a.tokenFile)
+	}
+	return token, nil
+}
+
+// handler returns the HTTP handler of the API. Requests are authenticated before they are rate
+// limited and routed, so unauthenticated requests do not use up the rate limit of valid callers,
+// and every request is recorded in the audit log. Slack requests are authenticated with their
+// signature instead of the bearer token.
+func (a *triggerAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/workflows/{namespace}/{name}/runs", a.handleRun)
+	mux.HandleFunc("GET /v1/workflows/{namespace}/{name}", a.handleStatus)
+	routed := a.limit(mux)
+	var slackHandler http.Handler
+	if a.slack != nil {
+		slackHandler = a.slack.handler(a.limit)
+	}
+
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			switch {
+			case slackHandler != nil && strings.HasPrefix(r.URL.Path, slackPathPrefix):
+				slackHandler.ServeHTTP(rec, r)
+			case !a.authorized(r):
+				rec.Header().Set("WWW-Authenticate", "Bearer")
+				writeTriggerError(rec, http.StatusUnauthorized, "unauthorized")
+			default:
+				routed.ServeHTTP(rec, r)
+			}
+			a.audit(r, rec.status)
+		},
+	)
+}
+
+// limit returns a handler that serves authenticated requests with next while the rate limit of
+// the API allows them.
+func (a *triggerAPI) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if !a.limiter.Allow() {
+				w.Header().Set("Retry-After", strconv.Itoa(a.retryAfter()))
+				writeTriggerError(w, http.StatusTooManyRequests, "rate limit exceeded")
+				return
+			}
+			next.ServeHTTP(w, r)
+		},
+	)
+}
+
+// retryAfter returns the number of seconds until the rate limit allows another request.
+func (a *triggerAPI) retryAfter() int {
+	reservation := a.limiter.Reserve()
+	defer reservation.Cancel()
+	return max(1, int(math.Ceil(reservation.Delay().Seconds())))
+}
+
+// authorized reports whether a request has the bearer token of the API.
+func (a *triggerAPI) authorized(r *http.Request) bool {
+	token, err := a.token()
+	if err != nil {
+		a.logger.Error("unable to authenticate trigger API request", "error", err)
+		return false
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// audit records a request of the API in the log. Rejected requests are logged as warnings.
+func (a *triggerAPI) audit(r *http.Request, status int) {
+	level := slog.LevelInfo
+	if status == http.StatusUnauthorized || status == http.StatusTooManyRequests {
+		level = slog.LevelWarn
+	}
+	a.logger.Log(
+		r.Context(),
+		level,
+		"trigger API request",
+		"method", r.Method,
+		"path", r.URL.Path,
+		"query", r.URL.RawQuery,
+		"status", status,
+		"remoteAddr", r.RemoteAddr,
+		"userAgent", r.UserAgent(),
+	)
+}
+
+// handleRun requests a run of a workflow. A check-only run is requested with the checkOnly query
+// parameter.
+func (a *triggerAPI) handleRun(w http.ResponseWriter, r *http.Request) {
+	key := types.NamespacedName{Namespace: r.PathValue("namespace"), Name: r.PathValue("name")}
+	checkOnly := false
+	if raw := r.URL.Query().Get("checkOnly"); raw != "" {
+		var err error
+		if checkOnly, err = strconv.ParseBool(raw); err != nil {
+			writeTriggerError(w, http.StatusBadRequest, fmt.Sprintf("invalid checkOnly value %q", raw))
+			return
+		}
+	}
+
+	err := a.scheduler.requestRun(key, checkOnly)
+	switch {
+	case errors.Is(err, errWorkflowNotScheduled):
+		writeTriggerError(w, http.StatusNotFound, fmt.Sprintf("workflow %s is not scheduled", key))
+		return
+	case errors.Is(err, errWorkflowBusy):
+		writeTriggerError(w, http.StatusConflict, fmt.Sprintf("workflow %s is already queued or running", key))
+		return
+	case err != nil:
+		writeTriggerError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeTriggerJSON(
+		w, http.StatusAccepted, triggerRunResponse{Namespace: key.Namespace, Name: key.Name, CheckOnly: checkOnly},
+	)
+}
+
+// handleStatus reports the schedule and the status of the last run of a workflow. The state and
+// the recorded inputs and outputs of operations in the status are not included.
+func (a *triggerAPI) handleStatus(w http.ResponseWriter, r *http.Request) {
+	key := types.NamespacedName{Namespace: r.PathValue("namespace"), Name: r.PathValue("name")}
+	schedule, ok := a.scheduler.workflowState(key)
+	if !ok {
+		writeTriggerError(w, http.StatusNotFound, fmt.Sprintf("workflow %s is not scheduled", key))
+		return
+	}
+
+	var kwf v1alpha1.Workflow
+	if err := a.client.Get(r.Context(), key, &kwf); err != nil {
+		if apierrors.IsNotFound(err) {
+			writeTriggerError(w, http.StatusNotFound, fmt.Sprintf("workflow %s does not exist", key))
+			return
+		}
+		a.logger.Error("error getting workflow for trigger API", "workflow", key.String(), "error", err)
+		writeTriggerError(w, http.StatusInternalServerError, "error getting workflow")
+		return
+	}
+	status := *kwf.Status.DeepCopy()
+	status.Operations = nil
+	status.State = nil
+	writeTriggerJSON(
+		w,
+		http.StatusOK,
+		triggerStatusResponse{Namespace: key.Namespace, Name: key.Name, Schedule: schedule, Status: status},
+	)
+}
+
+// writeTriggerJSON writes a JSON response of the trigger API.
+func writeTriggerJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeTriggerError writes an error response of the trigger API.
+func writeTriggerError(w http.ResponseWriter, status int, message string) {
+	writeTriggerJSON(w, status, triggerErrorResponse{Error: message})
+}
+
+// statusRecorder records the status code of a response for the audit log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code and writes it to the wrapped ResponseWriter.
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+// serveTriggerAPI serves the trigger API on the address until the context is done. Errors
+// listening on the address are returned, so an invalid address fails the controller at startup.
+func serveTriggerAPI(ctx context.Context, address string, handler http.Handler, logger *slog.Logger) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("error listening on trigger API address %s: %w", address, err)
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), triggerAPIShutdownTimeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if serveErr := server.Serve(listener); serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+			logger.Error("trigger API stopped", "error", serveErr)
+		}
+	}()
+	logger.Info("serving trigger API", "address", listener.Addr().String())
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// newTestTriggerAPI returns a trigger API for a scheduler with a workflow that is not due, and the
+// buffer of its audit log.
+func newTestTriggerAPI(t *testing.T, rateLimit int) (*triggerAPI, *controllerScheduler, *bytes.Buffer) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant-db", Namespace: "apps"},
+		Spec:       v1alpha1.WorkflowSpec{ReconcileInterval: "1h"},
+		Status: v1alpha1.WorkflowStatus{
+			LastRan:    metav1.NewTime(time.Now()),
+			Successful: "true",
+			Phase:      "complete",
+			Operations: []v1alpha1.OperationStatus{{Id: "db", Module: "test"}},
+			State:      map[string][]byte{"db": []byte("state")},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(kwf).Build()
+	wf, err := workflowFromK8sResource(kwf)
+	require.NoError(t, err)
+	scheduler := newControllerScheduler()
+	scheduler.replaceFromWorkflows(time.Now(), []*blackstart.Workflow{wf})
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret-token\n"), 0o600))
+	var logs bytes.Buffer
+	config := &blackstart.RuntimeConfig{TriggerAPITokenFile: tokenFile, TriggerAPIRateLimit: rateLimit}
+	api, err := newTriggerAPI(config, scheduler, c, slog.New(slog.NewTextHandler(&logs, nil)))
+	require.NoError(t, err)
+	return api, scheduler, &logs
+}
+
+// triggerRequest sends a request with the token to the handler of the API.
+func triggerRequest(api *triggerAPI, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	api.handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNewTriggerAPI_Validation(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	config := &blackstart.RuntimeConfig{TriggerAPIRateLimit: 30}
+
+	_, err := newTriggerAPI(config, newControllerScheduler(), nil, slog.Default())
+	require.ErrorContains(t, err, "requires a token file")
+
+	config.TriggerAPITokenFile = tokenFile
+	_, err = newTriggerAPI(config, newControllerScheduler(), nil, slog.Default())
+	require.ErrorContains(t, err, "error reading trigger API token file")
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte(" \n"), 0o600))
+	_, err = newTriggerAPI(config, newControllerScheduler(), nil, slog.Default())
+	require.ErrorContains(t, err, "is empty")
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("secret-token"), 0o600))
+	config.TriggerAPIRateLimit = 0
+	_, err = newTriggerAPI(config, newControllerScheduler(), nil, slog.Default())
+	require.ErrorContains(t, err, "must be greater than 0")
+}
+
+func TestTriggerAPI_Run(t *testing.T) {
+	api, scheduler, logs := newTestTriggerAPI(t, 100)
+	now := time.Now()
+	require.Empty(t, scheduler.dueWorkflows(now))
+
+	rec := triggerRequest(api, http.MethodPost, "/v1/workflows/apps/tenant-db/runs", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = triggerRequest(api, http.MethodPost, "/v1/workflows/apps/tenant-db/runs", "wrong-token")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	require.Empty(t, scheduler.dueWorkflows(now))
+
+	rec = triggerRequest(api, http.MethodPost, "/v1/workflows/apps/tenant-db/runs", "secret-token")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	assert.JSONEq(t, `{"namespace":"apps","name":"tenant-db","checkOnly":false}`, rec.Body.String())
+
+	// The requested run is kept when the workflows are refreshed before it is dispatched.
+	state, ok := scheduler.workflowState(types.NamespacedName{Namespace: "apps", Name: "tenant-db"})
+	require.True(t, ok)
+	assert.True(t, state.Queued)
+	scheduler.replaceFromWorkflows(now, []*blackstart.Workflow{scheduler.entries["apps/tenant-db"].workflow})
+	due := scheduler.dueWorkflows(now)
+	require.Len(t, due, 1)
+	assert.False(t, due[0].checkOnly)
+
+	rec = triggerRequest(api, http.MethodPost, "/v1/workflows/apps/tenant-db/runs", "secret-token")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	scheduler.markRunning(due[0].entry)
+	scheduler.markDone(due[0].entry, now)
+
+	rec = triggerRequest(api, http.MethodPost, "/v1/workflows/apps/tenant-db/runs?checkOnly=true", "secret-token")
+	require.Equal(t, http.StatusAccepted, rec.Code)
+	due = scheduler.dueWorkflows(now)
+	require.Len(t, due, 1)
+	assert.True(t, due[0].checkOnly)
+
+	rec = triggerRequest(api, http.MethodPost, "/v1/workflows/apps/tenant-db/runs?checkOnly=maybe", "secret-token")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = triggerRequest(api, http.MethodPost, "/v1/workflows/apps/unknown/runs", "secret-token")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	assert.Contains(
+		t, logs.String(), `level=WARN msg="trigger API request" method=POST path=/v1/workflows/apps/tenant-db/runs`,
+	)
+	assert.Contains(t, logs.String(), "status=202")
+	assert.NotContains(t, logs.String(), "secret-token")
+}
+
+func TestTriggerAPI_Status(t *testing.T) {
+	api, _, _ := newTestTriggerAPI(t, 100)
+
+	rec := triggerRequest(api, http.MethodGet, "/v1/workflows/apps/tenant-db", "secret-token")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp triggerStatusResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "tenant-db", resp.Name)
+	assert.False(t, resp.Schedule.Queued)
+	assert.False(t, resp.Schedule.Running)
+	assert.Nil(t, resp.Schedule.NextCheckAt)
+	assert.Equal(t, "true", resp.Status.Successful)
+	assert.Equal(t, "complete", resp.Status.Phase)
+	assert.Empty(t, resp.Status.Operations)
+	assert.Empty(t, resp.Status.State)
+
+	rec = triggerRequest(api, http.MethodGet, "/v1/workflows/apps/unknown", "secret-token")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTriggerAPI_RateLimit(t *testing.T) {
+	api, _, _ := newTestTriggerAPI(t, 1)
+
+	rec := triggerRequest(api, http.MethodGet, "/v1/workflows/apps/tenant-db", "secret-token")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = triggerRequest(api, http.MethodGet, "/v1/workflows/apps/tenant-db", "secret-token")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
+
+func TestTriggerAPI_RateLimitAfterAuthentication(t *testing.T) {
+	api, _, _ := newTestTriggerAPI(t, 1)
+
+	// Unauthenticated requests are rejected without using up the rate limit of valid callers.
+	for range 3 {
+		rec := triggerRequest(api, http.MethodGet, "/v1/workflows/apps/tenant-db", "wrong-token")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	rec := triggerRequest(api, http.MethodGet, "/v1/workflows/apps/tenant-db", "secret-token")
+	require.Equal(t, http.StatusOK, rec.Code)
+}
@@ -34,14 +34,28 @@ func loadWorkflowFromSource(ctx context.Context) (*blackstart.Workflow, error) {
 	if spec == "" {
 		return nil, fmt.Errorf("workflow file source is empty")
 	}
+	var wf *blackstart.Workflow
+	var err error
 	switch {
 	case strings.HasPrefix(spec, "env:"):
-		return loadWorkflowFromEnv(ctx)
+		wf, err = loadWorkflowFromEnv(ctx)
 	case strings.HasPrefix(spec, "gs://"):
-		return loadWorkflowFromGCS(ctx)
+		wf, err = loadWorkflowFromGCS(ctx)
 	default:
-		return loadWorkflowFromFile(ctx)
+		wf, err = loadWorkflowFromFile(ctx)
 	}
+	if err != nil {
+		return nil, err
+	}
+	wf.ApprovedDeletions = config.ApprovedDeletions
+	wf.InjectedFailures, err = parseInjectedFailures(config.InjectFailures)
+	if err != nil {
+		return nil, err
+	}
+	wf.OnlyLabels = config.OnlyLabels
+	wf.SkipLabels = config.SkipLabels
+	wf.Targets = config.Targets
+	return wf, nil
 }
 
 // workflowConfigBytesFromEnv reads raw workflow YAML bytes from the provided
@@ -72,8 +86,8 @@ func workflowConfigBytesFromGCS(ctx context.Context, bucket, object string) ([]b
 }
 
 // workflowFromConfigBytes unmarshals workflow configuration YAML and converts it
-// into a core blackstart.Workflow.
-func workflowFromConfigBytes(workflowConfig []byte) (*blackstart.Workflow, error) {
+// into a core blackstart.Workflow. Parameter values are given as key=value pairs.
+func workflowFromConfigBytes(workflowConfig []byte, parameters []string) (*blackstart.Workflow, error) {
 	var apiWf v1alpha1.WorkflowConfigFile
 	err := yaml.Unmarshal(workflowConfig, &apiWf)
 	if err != nil {
@@ -87,11 +101,41 @@ func workflowFromConfigBytes(workflowConfig []byte) (*blackstart.Workflow, error
 	if err != nil {
 		return nil, fmt.Errorf("error parsing reconcile interval for workflow %s: %w", wf.Name, err)
 	}
-	wf.Operations, err = loadOperations(apiWf.Operations)
+	wf.CheckInterval, err = parseOptionalDuration("checkInterval", apiWf.CheckInterval)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing check interval for workflow %s: %w", wf.Name, err)
+	}
+	wf.SkipUnchangedFor, err = parseOptionalDuration("skipUnchangedFor", apiWf.SkipUnchangedFor)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing skip duration for workflow %s: %w", wf.Name, err)
+	}
+	wf.Timeout, err = parseOptionalDuration("timeout", apiWf.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing timeout for workflow %s: %w", wf.Name, err)
+	}
+	values, err := parseParameterFlags(parameters)
+	if err != nil {
+		return nil, err
+	}
+	wf.Operations, err = workflowOperations(apiWf.WorkflowSpec, values)
 	if err != nil {
 		return nil, fmt.Errorf("error loading operations for workflow %s: %w", wf.Name, err)
 	}
+	wf.MaxDeletions = apiWf.MaxDeletions
+	wf.MaintenanceWindow, err = parseMaintenanceWindow(apiWf.MaintenanceWindow)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing maintenance window for workflow %s: %w", wf.Name, err)
+	}
+	wf.PublishOutputs, err = publishOutputs(apiWf.PublishOutputs)
+	if err != nil {
+		return nil, fmt.Errorf("error loading published outputs for workflow %s: %w", wf.Name, err)
+	}
+	wf.ServiceChecks, err = serviceChecks(apiWf.ServiceChecks)
+	if err != nil {
+		return nil, fmt.Errorf("error loading service checks for workflow %s: %w", wf.Name, err)
+	}
 	wf.Source = apiWf
+	wf.Warnings = deprecatedInputWarnings(apiWf.WorkflowSpec)
 	return &wf, nil
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"os"
@@ -154,3 +155,38 @@ func TestParseGCSWorkflowSource_Invalid(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateWorkflowFromFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workflow.yaml")
+	content := []byte(`name: validate
+operations:
+  - id: render
+    module: util_template
+    inputs:
+      template: hello
+`)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	ctx := context.Background()
+	cfg := &blackstart.RuntimeConfig{WorkflowFile: path, ValidateWorkflow: true}
+	ctx = context.WithValue(ctx, blackstart.ConfigKey, cfg)
+	ctx = context.WithValue(ctx, blackstart.LoggerKey, blackstart.NewLogger(nil))
+
+	var out bytes.Buffer
+	require.NoError(t, validateWorkflowFromFile(ctx, &out))
+	assert.Equal(
+		t,
+		"lint: outputs of operation \"render\" are not used by another operation or published\n"+
+			"workflow validate is valid: 1 operations\n",
+		out.String(),
+	)
+
+	content = []byte("name: validate\noperations:\n  - id: render\n    module: unknown_module\n")
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	out.Reset()
+	err := validateWorkflowFromFile(ctx, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "workflow validate is invalid: operation render:")
+	assert.Empty(t, out.String())
+}
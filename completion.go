@@ -0,0 +1,65 @@
+package blackstart
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// ShellNames are the shells a completion script can be printed for.
+var ShellNames = []string{"bash", "zsh", "fish"}
+
+// ShellName is the shell of a completion script. It is completed with the supported shells.
+type ShellName string
+
+// Complete returns the supported shells with the prefix.
+func (s *ShellName) Complete(match string) []flags.Completion {
+	var completions []flags.Completion
+	for _, shell := range ShellNames {
+		if strings.HasPrefix(shell, match) {
+			completions = append(completions, flags.Completion{Item: shell})
+		}
+	}
+	return completions
+}
+
+// ModuleRef is a module id, or a module id and one of its input keys separated by a dot, such
+// as `kubernetes_secret.namespace`. It is completed with the registered modules and their inputs.
+type ModuleRef string
+
+// Split returns the module id and the input key of the reference. The input key is empty when the
+// reference has no dot.
+func (r ModuleRef) Split() (string, string) {
+	module, input, _ := strings.Cut(strings.TrimSpace(string(r)), ".")
+	return module, input
+}
+
+// Complete returns the registered module ids with the prefix. Once the prefix holds a registered
+// module id and a dot, the input keys of the module are returned instead.
+func (r *ModuleRef) Complete(match string) []flags.Completion {
+	modules := GetRegisteredModules()
+	var completions []flags.Completion
+	if module, input, ok := strings.Cut(match, "."); ok {
+		factory, registered := modules[module]
+		if !registered {
+			return nil
+		}
+		inputs := factory().Info().Inputs
+		for _, key := range slices.Sorted(maps.Keys(inputs)) {
+			if strings.HasPrefix(key, input) {
+				completions = append(
+					completions, flags.Completion{Item: module + "." + key, Description: inputs[key].Description},
+				)
+			}
+		}
+		return completions
+	}
+	for _, id := range slices.Sorted(maps.Keys(modules)) {
+		if strings.HasPrefix(id, match) {
+			completions = append(completions, flags.Completion{Item: id, Description: modules[id]().Info().Name})
+		}
+	}
+	return completions
+}
@@ -0,0 +1,34 @@
+package blackstart
+
+import (
+	"testing"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModuleRef_Complete(t *testing.T) {
+	var ref ModuleRef
+	assert.Contains(t, ref.Complete("test_m"), flags.Completion{Item: "test_module"})
+	assert.Empty(t, ref.Complete("missing_"))
+	assert.Equal(
+		t, []flags.Completion{
+			{Item: "test_module.set_error", Description: "Should the module return an error on set"},
+			{Item: "test_module.set_result", Description: "Should the module set result"},
+		}, ref.Complete("test_module.set"),
+	)
+	assert.Nil(t, ref.Complete("missing_module.set"))
+
+	module, input := ModuleRef(" test_module.set_error ").Split()
+	assert.Equal(t, "test_module", module)
+	assert.Equal(t, "set_error", input)
+	module, input = ModuleRef("test_module").Split()
+	assert.Equal(t, "test_module", module)
+	assert.Empty(t, input)
+}
+
+func TestShellName_Complete(t *testing.T) {
+	var shell ShellName
+	assert.Equal(t, []flags.Completion{{Item: "bash"}}, shell.Complete("b"))
+	assert.Len(t, shell.Complete(""), len(ShellNames))
+}
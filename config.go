@@ -1,8 +1,12 @@
 package blackstart
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/jessevdk/go-flags"
 )
@@ -10,20 +14,75 @@ import (
 var LogOutputEnv = getConfigEnv("LogOutput")
 var K8sNamespaceEnv = getConfigEnv("KubeNamespace")
 var RuntimeModeEnv = getConfigEnv("RuntimeMode")
+var RuntimeNamespaceEnv = getConfigEnv("RuntimeNamespace")
+var InputFileDirsEnv = getConfigEnv("InputFileDirs")
+var KubeImpersonateUsersEnv = getConfigEnv("KubeImpersonateUsers")
+var DecryptionKeyFileEnv = getConfigEnv("DecryptionKeyFile")
+var DecryptionKMSKeyEnv = getConfigEnv("DecryptionKMSKey")
+
+// defaultK8sNamespace is the namespace used by kubernetes modules when no namespace is set.
+const defaultK8sNamespace = "default"
+
+// serviceAccountNamespaceFile contains the namespace of the pod when running in Kubernetes.
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
 
 type RuntimeConfig struct {
-	Version                    bool   `short:"v" long:"version" description:"Show version information"`
-	LogOutput                  string `long:"log-output" env:"BLACKSTART_LOG_OUTPUT" description:"Logging output file name" default:""`
-	LogFormat                  string `long:"log-format" env:"BLACKSTART_LOG_FORMAT" description:"Logging format (json, text)" default:"text"`
-	LogLevel                   string `long:"log-level" env:"BLACKSTART_LOG_LEVEL" description:"Logging level" default:"info"`
-	LogLevelKey                string `long:"log-level-key" env:"BLACKSTART_LOG_LEVEL_KEY" description:"JSON logging key name for level/severity" default:"level"`
-	LogMessageKey              string `long:"log-message-key" env:"BLACKSTART_LOG_MESSAGE_KEY" description:"JSON logging key name for message/event" default:"msg"`
-	WorkflowFile               string `short:"f" long:"workflow-file" env:"BLACKSTART_WORKFLOW_FILE" description:"Path to the workflow file" required:"false"`
-	KubeNamespace              string `short:"n" long:"k8s-namespace" env:"BLACKSTART_K8S_NAMESPACE" description:"Kubernetes namespace(s) to read the workflow from" default:""`
-	RuntimeMode                string `long:"runtime-mode" env:"BLACKSTART_RUNTIME_MODE" description:"Runtime mode when reading workflows from Kubernetes (controller, once)" default:"controller"`
-	MaxParallelReconciliations int    `long:"max-parallel-reconciliations" env:"BLACKSTART_MAX_PARALLEL_RECONCILIATIONS" description:"Maximum number of workflows to reconcile in parallel" default:"4"`
-	ControllerResyncInterval   string `long:"controller-resync-interval" env:"BLACKSTART_CONTROLLER_RESYNC_INTERVAL" description:"How often to refresh watched workflows from Kubernetes" default:"15s"`
-	QueueWaitWarningThreshold  string `long:"queue-wait-warning-threshold" env:"BLACKSTART_QUEUE_WAIT_WARNING_THRESHOLD" description:"Warn when a queued workflow waits longer than this duration before running" default:"30s"`
+	Version                     bool          `short:"v" long:"version" description:"Show version information"`
+	LogOutput                   string        `long:"log-output" env:"BLACKSTART_LOG_OUTPUT" description:"Logging output file name" default:""`
+	LogFormat                   string        `long:"log-format" env:"BLACKSTART_LOG_FORMAT" description:"Logging format (json, text)" default:"text"`
+	LogLevel                    string        `long:"log-level" env:"BLACKSTART_LOG_LEVEL" description:"Logging level" default:"info"`
+	LogLevelKey                 string        `long:"log-level-key" env:"BLACKSTART_LOG_LEVEL_KEY" description:"JSON logging key name for level/severity" default:"level"`
+	LogMessageKey               string        `long:"log-message-key" env:"BLACKSTART_LOG_MESSAGE_KEY" description:"JSON logging key name for message/event" default:"msg"`
+	LogModuleLevels             []string      `long:"log-module-level" env:"BLACKSTART_LOG_MODULE_LEVELS" env-delim:"," description:"Logging level for modules with an id prefix (prefix=level), such as kubernetes=debug; may be repeated"`
+	WorkflowFile                string        `short:"f" long:"workflow-file" env:"BLACKSTART_WORKFLOW_FILE" description:"Path to the workflow file" required:"false"`
+	Parameters                  []string      `long:"set" description:"Set a workflow parameter value (key=value) when running a workflow file; may be repeated"`
+	ApprovedDeletions           int           `long:"approve-deletions" description:"Approve running the workflow file with this number of doesNotExist operations when it exceeds maxDeletions"`
+	InjectFailures              []string      `long:"inject-failure" description:"Force an operation of the workflow file to fail its check or set (id=check, id=set) to test failure handling; may be repeated"`
+	RecordFixture               string        `long:"record-fixture" description:"Record the module interactions of the workflow file run to a fixture file"`
+	ReplayFixture               string        `long:"replay-fixture" description:"Run the workflow file against a recorded fixture file instead of the real systems; fails when the workflow does not match the fixture"`
+	OnlyLabels                  []string      `long:"only-labels" description:"Only set the operations of the workflow file with a label (key=value); other operations are checked but not set; may be repeated"`
+	SkipLabels                  []string      `long:"skip-labels" description:"Do not set the operations of the workflow file with a label (key=value); they are checked but not set; may be repeated"`
+	Targets                     []string      `long:"target" description:"Only run this operation of the workflow file and the operations it depends on; may be repeated"`
+	ConvertTo                   string        `long:"convert-to" description:"Convert the workflow file to another format (resource, file), print it, and exit"`
+	ConvertName                 string        `long:"convert-name" description:"Name of the Workflow resource created by --convert-to resource; defaults to the workflow name"`
+	ConvertNamespace            string        `long:"convert-namespace" description:"Namespace of the Workflow resource created by --convert-to resource"`
+	InspectFile                 string        `long:"inspect" description:"Print the recorded inputs and outputs of the last run of a Workflow resource saved as YAML or JSON, and exit"`
+	IntegrationTest             string        `long:"integration-test" description:"Run the workflows of a YAML file against a temporary Kubernetes API server with envtest, print their status, and exit; fails when a workflow does not succeed"`
+	ValidateExamples            bool          `long:"validate-examples" description:"Validate the examples of all modules against their inputs, print the invalid examples, and exit"`
+	ValidateWorkflow            bool          `long:"validate" description:"Validate the workflow file without checking or setting its operations, print lint warnings, and exit"`
+	DescribeModule              ModuleRef     `long:"describe-module" description:"Print the inputs and outputs of a module, or of one input with <module>.<input>, and exit"`
+	Completion                  ShellName     `long:"completion" description:"Print the shell completion script (bash, zsh, fish) and exit"`
+	KubeNamespace               string        `short:"n" long:"k8s-namespace" env:"BLACKSTART_K8S_NAMESPACE" description:"Kubernetes namespace(s) to read the workflow from" default:""`
+	RuntimeNamespace            string        `long:"runtime-namespace" env:"BLACKSTART_RUNTIME_NAMESPACE" description:"Namespace Blackstart runs in, usually set with the downward API" default:""`
+	DefaultNamespaceFromRuntime bool          `long:"k8s-default-namespace-from-runtime" env:"BLACKSTART_K8S_DEFAULT_NAMESPACE_FROM_RUNTIME" description:"Default the namespace of kubernetes modules to the namespace Blackstart runs in"`
+	KubeModuleNamespaces        []string      `long:"k8s-module-namespace" env:"BLACKSTART_K8S_MODULE_NAMESPACES" env-delim:"," description:"Namespace that modules may get runtime-provided Kubernetes clients for; may be repeated, defaults to all namespaces"`
+	KubeImpersonateUsers        []string      `long:"k8s-impersonate-user" env:"BLACKSTART_K8S_IMPERSONATE_USERS" env-delim:"," description:"User or service account that runtime-provided Kubernetes clients may impersonate; may be repeated, defaults to none"`
+	KubeContext                 string        `long:"kube-context" env:"BLACKSTART_KUBE_CONTEXT" description:"Kubeconfig context of the cluster Workflow resources are read from and written to; defaults to the current context or the in-cluster config"`
+	KubeAPIQPS                  float32       `long:"kube-api-qps" env:"BLACKSTART_KUBE_API_QPS" description:"Requests per second of the client for Workflow resources; 0 uses the client default"`
+	KubeAPIBurst                int           `long:"kube-api-burst" env:"BLACKSTART_KUBE_API_BURST" description:"Request burst of the client for Workflow resources; 0 uses the client default"`
+	RuntimeMode                 string        `long:"runtime-mode" env:"BLACKSTART_RUNTIME_MODE" description:"Runtime mode when reading workflows from Kubernetes (controller, once)" default:"controller"`
+	MaxParallelReconciliations  int           `long:"max-parallel-reconciliations" env:"BLACKSTART_MAX_PARALLEL_RECONCILIATIONS" description:"Maximum number of workflows to reconcile in parallel" default:"4"`
+	ControllerResyncInterval    string        `long:"controller-resync-interval" env:"BLACKSTART_CONTROLLER_RESYNC_INTERVAL" description:"How often to refresh watched workflows from Kubernetes" default:"15s"`
+	QueueWaitWarningThreshold   string        `long:"queue-wait-warning-threshold" env:"BLACKSTART_QUEUE_WAIT_WARNING_THRESHOLD" description:"Warn when a queued workflow waits longer than this duration before running" default:"30s"`
+	TriggerAPIAddress           string        `long:"trigger-api-address" env:"BLACKSTART_TRIGGER_API_ADDRESS" description:"Address the HTTP trigger API listens on in controller mode, such as :8080; disabled when empty" default:""`
+	TriggerAPITokenFile         string        `long:"trigger-api-token-file" env:"BLACKSTART_TRIGGER_API_TOKEN_FILE" description:"File with the bearer token required by trigger API requests"`
+	TriggerAPIRateLimit         int           `long:"trigger-api-rate-limit" env:"BLACKSTART_TRIGGER_API_RATE_LIMIT" description:"Maximum number of trigger API requests per minute" default:"30"`
+	SlackSigningSecretFile      string        `long:"slack-signing-secret-file" env:"BLACKSTART_SLACK_SIGNING_SECRET_FILE" description:"File with the signing secret of the Slack app; enables the Slack ChatOps endpoints of the trigger API"`
+	SlackAllowedUsers           []string      `long:"slack-allowed-user" env:"BLACKSTART_SLACK_ALLOWED_USERS" env-delim:"," description:"Slack user ID allowed to run workflows with ChatOps; may be repeated; no users are allowed when empty, and * allows all users of the workspace"`
+	StateStore                  string        `long:"state-store" env:"BLACKSTART_STATE_STORE" description:"Where operation state is stored between runs (status, configmap, memory, gs://<bucket>/<prefix>, s3://<bucket>/<prefix>)" default:""`
+	EventsOutput                string        `long:"events-output" env:"BLACKSTART_EVENTS_OUTPUT" description:"File to write an NDJSON stream of run events to, or - for stdout when logs are written to a file; disabled when empty" default:""`
+	HTTPProxy                   string        `long:"http-proxy" env:"BLACKSTART_HTTP_PROXY" description:"Proxy URL for outbound HTTP requests; defaults to the HTTP_PROXY environment variable"`
+	HTTPSProxy                  string        `long:"https-proxy" env:"BLACKSTART_HTTPS_PROXY" description:"Proxy URL for outbound HTTPS requests; defaults to the HTTPS_PROXY environment variable"`
+	NoProxy                     string        `long:"no-proxy" env:"BLACKSTART_NO_PROXY" description:"Comma-separated hosts, domains, and CIDRs that are not proxied; defaults to the NO_PROXY environment variable"`
+	CACertFiles                 []string      `long:"ca-cert-file" env:"BLACKSTART_CA_CERT_FILES" env-delim:"," description:"PEM file of CA certificates trusted by outbound clients in addition to the system CAs; may be repeated"`
+	InputFileDirs               []string      `long:"input-file-dir" env:"BLACKSTART_INPUT_FILE_DIRS" env-delim:"," description:"Directory that fromFile inputs may be read from, such as the mount path of a Secret; may be repeated" default:"/var/run/secrets"`
+	DecryptionKeyFile           string        `long:"decryption-key-file" env:"BLACKSTART_DECRYPTION_KEY_FILE" description:"Path to an age identity file used to decrypt encrypted workflow inputs"`
+	DecryptionKMSKey            string        `long:"decryption-kms-key" env:"BLACKSTART_DECRYPTION_KMS_KEY" description:"Cloud KMS key (projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>) used to decrypt encrypted workflow inputs"`
+	CircuitBreakerFailures      int           `long:"circuit-breaker-failures" env:"BLACKSTART_CIRCUIT_BREAKER_FAILURES" description:"Consecutive failed requests to an external endpoint after which operations using it are blocked for the cool-down; 0 disables the circuit breaker" default:"5"`
+	CircuitBreakerCoolDown      time.Duration `long:"circuit-breaker-cool-down" env:"BLACKSTART_CIRCUIT_BREAKER_COOL_DOWN" description:"How long operations using an external endpoint are blocked after its circuit breaker opens" default:"1m"`
+	PropagationTimeout          time.Duration `long:"propagation-timeout" env:"BLACKSTART_PROPAGATION_TIMEOUT" description:"How long modules wait for changes to eventually consistent APIs, such as Google IAM, to take effect" default:"2m"`
+	OperationHeartbeat          time.Duration `long:"operation-heartbeat" env:"BLACKSTART_OPERATION_HEARTBEAT" description:"Log a heartbeat with the elapsed time of an operation each time it has run for this duration; 0 disables heartbeats" default:"1m"`
+	StuckOperationAfter         time.Duration `long:"stuck-operation-after" env:"BLACKSTART_STUCK_OPERATION_AFTER" description:"Report an operation as stuck in the workflow status when it runs longer than this duration; 0 disables reporting" default:"10m"`
 }
 
 func ReadConfig() (*RuntimeConfig, error) {
@@ -47,3 +106,27 @@ func getConfigEnv(name string) string {
 	}
 	return field.Tag.Get("env")
 }
+
+// DefaultK8sNamespace returns the namespace used by kubernetes modules when the namespace input is
+// not set. This is "default" unless the runtime configuration enables defaulting to the namespace
+// Blackstart runs in. That namespace is read from the runtime namespace setting, or from the pod
+// service account when it is not set.
+func DefaultK8sNamespace(ctx context.Context) (string, error) {
+	config, _ := ctx.Value(ConfigKey).(*RuntimeConfig)
+	if config == nil || !config.DefaultNamespaceFromRuntime {
+		return defaultK8sNamespace, nil
+	}
+	if ns := strings.TrimSpace(config.RuntimeNamespace); ns != "" {
+		return ns, nil
+	}
+	b, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err == nil {
+		if ns := strings.TrimSpace(string(b)); ns != "" {
+			return ns, nil
+		}
+	}
+	return "", fmt.Errorf(
+		"unable to determine the namespace Blackstart runs in: set %s or run Blackstart in a Kubernetes pod",
+		RuntimeNamespaceEnv,
+	)
+}
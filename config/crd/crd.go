@@ -0,0 +1,10 @@
+// Package crd embeds the CustomResourceDefinitions of the Blackstart API generated with
+// `make crds`, so they can be installed without a checkout of the repository.
+package crd
+
+import "embed"
+
+// FS holds the CRD manifests, one directory for each API version.
+//
+//go:embed v1alpha1/*.yaml
+var FS embed.FS
@@ -0,0 +1,75 @@
+package blackstart
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultK8sNamespace(t *testing.T) {
+	dir := t.TempDir()
+	saFile := filepath.Join(dir, "namespace")
+	require.NoError(t, os.WriteFile(saFile, []byte("from-service-account\n"), 0o600))
+
+	tests := []struct {
+		name     string
+		config   *RuntimeConfig
+		saFile   string
+		expected string
+		errMsg   string
+	}{
+		{
+			name:     "no config",
+			expected: "default",
+		},
+		{
+			name:     "disabled",
+			config:   &RuntimeConfig{RuntimeNamespace: "blackstart"},
+			expected: "default",
+		},
+		{
+			name:     "runtime namespace",
+			config:   &RuntimeConfig{DefaultNamespaceFromRuntime: true, RuntimeNamespace: " blackstart "},
+			saFile:   saFile,
+			expected: "blackstart",
+		},
+		{
+			name:     "service account fallback",
+			config:   &RuntimeConfig{DefaultNamespaceFromRuntime: true},
+			saFile:   saFile,
+			expected: "from-service-account",
+		},
+		{
+			name:   "unknown runtime namespace",
+			config: &RuntimeConfig{DefaultNamespaceFromRuntime: true},
+			saFile: filepath.Join(dir, "missing"),
+			errMsg: RuntimeNamespaceEnv,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				original := serviceAccountNamespaceFile
+				t.Cleanup(func() { serviceAccountNamespaceFile = original })
+				serviceAccountNamespaceFile = tt.saFile
+
+				ctx := context.Background()
+				if tt.config != nil {
+					ctx = context.WithValue(ctx, ConfigKey, tt.config)
+				}
+				ns, err := DefaultK8sNamespace(ctx)
+				if tt.errMsg != "" {
+					require.ErrorContains(t, err, tt.errMsg)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, ns)
+			},
+		)
+	}
+}
@@ -0,0 +1,204 @@
+package blackstart
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Types of the events of a workflow run.
+const (
+	EventRunStarted        = "run_started"
+	EventOperationStarted  = "operation_started"
+	EventOperationChecked  = "operation_checked"
+	EventOperationSet      = "operation_set"
+	EventOperationFinished = "operation_finished"
+	EventRunFinished       = "run_finished"
+)
+
+// Statuses of finished operations and runs in events.
+const (
+	EventStatusCompleted     = "completed"
+	EventStatusFailed        = "failed"
+	EventStatusSkipped       = "skipped"
+	EventStatusDrifted       = "drifted"
+	EventStatusPendingWindow = "pending_window"
+	EventStatusNotSelected   = "not_selected"
+)
+
+// Event is an event of a workflow run, such as an operation that was checked. Events let external
+// systems track the progress of runs without parsing the logs.
+type Event struct {
+	// Time is the time of the event.
+	Time time.Time `json:"time"`
+
+	// Type is the type of the event, such as EventOperationChecked.
+	Type string `json:"type"`
+
+	// Run identifies the run of the workflow. It is unique for each run.
+	Run string `json:"run"`
+
+	// Workflow is the name of the workflow.
+	Workflow string `json:"workflow"`
+
+	// Namespace is the Kubernetes namespace of the workflow. It is empty for file-based workflows.
+	Namespace string `json:"namespace,omitempty"`
+
+	// Operation is the identifier of the operation of operation events.
+	Operation string `json:"operation,omitempty"`
+
+	// Module is the identifier of the module of the operation of operation events.
+	Module string `json:"module,omitempty"`
+
+	// Passed is the result of the check of EventOperationChecked events.
+	Passed *bool `json:"passed,omitempty"`
+
+	// Status is the result of EventOperationFinished and EventRunFinished events, such as
+	// EventStatusCompleted.
+	Status string `json:"status,omitempty"`
+
+	// Phase is the phase the run stopped in of EventRunFinished events.
+	Phase string `json:"phase,omitempty"`
+
+	// Error is the error of failed operations and runs.
+	Error string `json:"error,omitempty"`
+
+	// DurationMs is the duration of finished operations and runs, in milliseconds.
+	DurationMs int64 `json:"durationMs,omitempty"`
+}
+
+// EventSink receives the events of workflow runs. Implementations must be safe for concurrent use,
+// since workflows run in parallel.
+type EventSink interface {
+	// Emit records the event. An error does not fail the run.
+	Emit(event Event) error
+}
+
+// ContextEventSink returns the EventSink configured for the run, or nil if no event sink is
+// configured.
+func ContextEventSink(ctx context.Context) EventSink {
+	sink, _ := ctx.Value(EventSinkKey).(EventSink)
+	return sink
+}
+
+// NDJSONEventSink writes events to a writer as newline-delimited JSON, one event per line.
+type NDJSONEventSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewNDJSONEventSink creates an event sink that writes events to w.
+func NewNDJSONEventSink(w io.Writer) *NDJSONEventSink {
+	return &NDJSONEventSink{enc: json.NewEncoder(w)}
+}
+
+// Emit writes the event as a line of JSON.
+func (s *NDJSONEventSink) Emit(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(event)
+}
+
+// runEvents emits the events of a workflow run to the event sink of the run context.
+type runEvents struct {
+	sink      EventSink
+	run       string
+	workflow  *Workflow
+	logger    *slog.Logger
+	failed    bool
+	startTime time.Time
+}
+
+// emit sets the common fields of the event and emits it. The first error of the sink is logged,
+// and later errors are ignored, so an unavailable sink does not flood the logs.
+func (e *runEvents) emit(event Event) {
+	if e == nil || e.sink == nil {
+		return
+	}
+	event.Time = time.Now().UTC()
+	event.Run = e.run
+	event.Workflow = e.workflow.Name
+	event.Namespace = e.workflow.Namespace
+	if err := e.sink.Emit(event); err != nil && !e.failed {
+		e.failed = true
+		e.logger.Warn("unable to emit run event", "type", event.Type, "error", err)
+	}
+}
+
+// newRunEvents returns the events of a run, or nil when the context has no event sink.
+func newRunEvents(ctx context.Context, we *workflowExecution) *runEvents {
+	sink := ContextEventSink(ctx)
+	if sink == nil {
+		return nil
+	}
+	return &runEvents{sink: sink, run: rand.Text(), workflow: we.w, logger: we.logger, startTime: time.Now()}
+}
+
+// runStarted emits the event of the start of the run.
+func (e *runEvents) runStarted() {
+	e.emit(Event{Type: EventRunStarted})
+}
+
+// runFinished emits the event of the end of the run with its result.
+func (e *runEvents) runFinished(result WorkflowResult) {
+	if e == nil {
+		return
+	}
+	event := Event{
+		Type: EventRunFinished, Status: EventStatusCompleted, Phase: result.Phase,
+		DurationMs: time.Since(e.startTime).Milliseconds(),
+	}
+	if result.Err != nil {
+		event.Status = EventStatusFailed
+		event.Error = result.Err.Error()
+	}
+	e.emit(event)
+}
+
+// operationStarted emits the event of the start of an operation.
+func (e *runEvents) operationStarted(op *Operation) {
+	e.emit(Event{Type: EventOperationStarted, Operation: op.Id, Module: op.Module})
+}
+
+// operationChecked emits the result of the check of an operation.
+func (e *runEvents) operationChecked(op *Operation, passed bool) {
+	e.emit(Event{Type: EventOperationChecked, Operation: op.Id, Module: op.Module, Passed: &passed})
+}
+
+// operationSet emits the event of a completed set of an operation.
+func (e *runEvents) operationSet(op *Operation) {
+	e.emit(Event{Type: EventOperationSet, Operation: op.Id, Module: op.Module})
+}
+
+// operationFinished emits the event of the end of an operation with its result.
+func (e *runEvents) operationFinished(res OperationResult, err error) {
+	event := Event{
+		Type: EventOperationFinished, Operation: res.Id, Module: res.Module, Status: operationStatus(res),
+		DurationMs: res.Duration.Milliseconds(),
+	}
+	if err != nil {
+		event.Status = EventStatusFailed
+		event.Error = err.Error()
+	}
+	e.emit(event)
+}
+
+// operationStatus returns the event status of an operation that did not fail.
+func operationStatus(res OperationResult) string {
+	switch {
+	case res.Skipped:
+		return EventStatusSkipped
+	case res.Drifted:
+		return EventStatusDrifted
+	case res.PendingWindow:
+		return EventStatusPendingWindow
+	case res.Filtered:
+		return EventStatusNotSelected
+	default:
+		return EventStatusCompleted
+	}
+}
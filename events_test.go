@@ -0,0 +1,134 @@
+package blackstart
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingEventSink records the events of runs.
+type recordingEventSink struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *recordingEventSink) Emit(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// eventsTestWorkflow returns a workflow with an operation that is set and one whose check passes.
+func eventsTestWorkflow() Workflow {
+	return Workflow{
+		Name:      "events-test",
+		Namespace: "ops",
+		Operations: []Operation{
+			{
+				Id:     "create",
+				Module: "test_module",
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(false),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+			{
+				Id:        "exists",
+				Module:    "test_module",
+				DependsOn: []string{"create"},
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(true),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+		},
+	}
+}
+
+func TestWorkflowRun_Events(t *testing.T) {
+	sink := &recordingEventSink{}
+	ctx := context.WithValue(context.Background(), EventSinkKey, sink)
+	wf := eventsTestWorkflow()
+
+	res := wf.Run(ctx)
+	require.NoError(t, res.Err)
+
+	type summary struct {
+		Type, Operation, Status string
+		Passed                  *bool
+	}
+	passed, failed := true, false
+	var got []summary
+	for _, e := range sink.events {
+		assert.Equal(t, "events-test", e.Workflow)
+		assert.Equal(t, "ops", e.Namespace)
+		assert.Equal(t, sink.events[0].Run, e.Run)
+		assert.False(t, e.Time.IsZero())
+		got = append(got, summary{Type: e.Type, Operation: e.Operation, Status: e.Status, Passed: e.Passed})
+	}
+	assert.NotEmpty(t, sink.events[0].Run)
+	assert.Equal(
+		t, []summary{
+			{Type: EventRunStarted},
+			{Type: EventOperationStarted, Operation: "create"},
+			{Type: EventOperationChecked, Operation: "create", Passed: &failed},
+			{Type: EventOperationSet, Operation: "create"},
+			{Type: EventOperationFinished, Operation: "create", Status: EventStatusCompleted},
+			{Type: EventOperationStarted, Operation: "exists"},
+			{Type: EventOperationChecked, Operation: "exists", Passed: &passed},
+			{Type: EventOperationFinished, Operation: "exists", Status: EventStatusCompleted},
+			{Type: EventRunFinished, Status: EventStatusCompleted},
+		}, got,
+	)
+
+	// A failed operation fails the run, and each run has its own identifier.
+	first := sink.events[0].Run
+	sink.events = nil
+	wf = eventsTestWorkflow()
+	wf.InjectedFailures = map[string]string{"create": InjectFailureSet}
+	res = wf.Run(ctx)
+	require.Error(t, res.Err)
+	// The failed set is not emitted as an operation set event.
+	require.Len(t, sink.events, 5)
+	assert.NotEqual(t, first, sink.events[0].Run)
+	finished := sink.events[3]
+	assert.Equal(t, EventOperationFinished, finished.Type)
+	assert.Equal(t, EventStatusFailed, finished.Status)
+	assert.Contains(t, finished.Error, "injected failure")
+	last := sink.events[4]
+	assert.Equal(t, EventRunFinished, last.Type)
+	assert.Equal(t, EventStatusFailed, last.Status)
+	assert.Equal(t, phaseExecute, last.Phase)
+
+	// Validating a workflow does not emit events.
+	sink.events = nil
+	wf = eventsTestWorkflow()
+	require.NoError(t, wf.Validate(ctx).Err)
+	assert.Empty(t, sink.events)
+}
+
+func TestNDJSONEventSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewNDJSONEventSink(&buf)
+	passed := true
+	require.NoError(
+		t, sink.Emit(Event{Type: EventOperationChecked, Run: "r1", Workflow: "wf", Operation: "db", Passed: &passed}),
+	)
+	require.NoError(t, sink.Emit(Event{Type: EventRunFinished, Run: "r1", Workflow: "wf", Status: EventStatusCompleted}))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var first map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &first))
+	assert.Equal(t, "operation_checked", first["type"])
+	assert.Equal(t, "db", first["operation"])
+	assert.Equal(t, true, first["passed"])
+	assert.NotContains(t, first, "namespace")
+	assert.NotContains(t, first, "status")
+}
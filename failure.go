@@ -0,0 +1,84 @@
+package blackstart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// InjectFailureCheck injects a failure in the check of an operation.
+	InjectFailureCheck = "check"
+
+	// InjectFailureSet injects a failure in the set of an operation. The check of the operation
+	// does not pass, so the set is always called.
+	InjectFailureSet = "set"
+)
+
+// ErrInjectedFailure is returned by operations that are forced to fail with
+// Workflow.InjectedFailures.
+var ErrInjectedFailure = errors.New("injected failure")
+
+// failureModule wraps the module of an operation that is forced to fail its check or set. Batch
+// checks of the wrapped module are not used, so the failure is injected for the operation only.
+type failureModule struct {
+	Module
+	id    string
+	phase string
+}
+
+// Check fails when a check failure is injected. When a set failure is injected, the check does not
+// pass without calling the wrapped module, so Set is called.
+func (m *failureModule) Check(ctx ModuleContext) (bool, error) {
+	switch m.phase {
+	case InjectFailureCheck:
+		return false, fmt.Errorf("%w: check of operation %q", ErrInjectedFailure, m.id)
+	case InjectFailureSet:
+		return false, nil
+	}
+	return m.Module.Check(ctx)
+}
+
+// Set fails when a set failure is injected.
+func (m *failureModule) Set(ctx ModuleContext) error {
+	if m.phase == InjectFailureSet {
+		return fmt.Errorf("%w: set of operation %q", ErrInjectedFailure, m.id)
+	}
+	return m.Module.Set(ctx)
+}
+
+// Preflight runs the preflight checks of the wrapped module, if it has any.
+func (m *failureModule) Preflight(ctx ModuleContext) error {
+	if pf, ok := m.Module.(Preflighter); ok {
+		return pf.Preflight(ctx)
+	}
+	return nil
+}
+
+// Close closes the wrapped module, if it holds resources.
+func (m *failureModule) Close() error {
+	if closer, ok := m.Module.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// injectFailures wraps the modules of the operations with injected failures. An error is returned
+// when a failure is injected for an operation that is not in the workflow, or for an unknown phase.
+func (we *workflowExecution) injectFailures(modules map[string]Module) error {
+	for id, phase := range we.w.InjectedFailures {
+		m, ok := modules[id]
+		if !ok {
+			return fmt.Errorf("failure injected for unknown operation %q", id)
+		}
+		if phase != InjectFailureCheck && phase != InjectFailureSet {
+			return fmt.Errorf(
+				"invalid injected failure %q for operation %q: expected %q or %q",
+				phase, id, InjectFailureCheck, InjectFailureSet,
+			)
+		}
+		we.logger.Warn("injecting operation failure", "id", id, "phase", phase)
+		modules[id] = &failureModule{Module: m, id: id, phase: phase}
+	}
+	return nil
+}
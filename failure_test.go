@@ -0,0 +1,71 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowExecution_InjectedFailures(t *testing.T) {
+	// newWorkflow returns a workflow of two operations whose checks pass, with the failures
+	// injected.
+	newWorkflow := func(failures map[string]string) Workflow {
+		inputs := map[string]Input{
+			testCheckResult: NewInputFromValue(true),
+			testSetResult:   NewInputFromValue(true),
+		}
+		return Workflow{
+			Name: "failure-test",
+			Operations: []Operation{
+				{Id: "a", Module: "cleanup_test_module", Inputs: inputs},
+				{Id: "b", Module: "cleanup_test_module", DependsOn: []string{"a"}, Inputs: inputs},
+			},
+			InjectedFailures: failures,
+		}
+	}
+
+	t.Run(
+		"check", func(t *testing.T) {
+			cleanupModuleCloseCalls.Store(0)
+			wf := newWorkflow(map[string]string{"b": InjectFailureCheck})
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrInjectedFailure)
+			assert.ErrorContains(t, res.Err, `check of operation "b"`)
+			require.NotNil(t, res.Op)
+			assert.Equal(t, "b", res.Op.Id)
+			assert.Equal(t, 1, res.CompletedOperations)
+			// Wrapped modules are still closed.
+			assert.Equal(t, int32(2), cleanupModuleCloseCalls.Load())
+		},
+	)
+
+	t.Run(
+		"set", func(t *testing.T) {
+			// The check of the operation passes, but the set is still called and fails.
+			wf := newWorkflow(map[string]string{"a": InjectFailureSet})
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrInjectedFailure)
+			assert.ErrorContains(t, res.Err, `set of operation "a"`)
+			assert.Equal(t, 0, res.CompletedOperations)
+		},
+	)
+
+	t.Run(
+		"unknown operation", func(t *testing.T) {
+			wf := newWorkflow(map[string]string{"missing": InjectFailureSet})
+			res := wf.Run(context.Background())
+			assert.ErrorContains(t, res.Err, `failure injected for unknown operation "missing"`)
+			assert.Equal(t, 0, res.CompletedOperations)
+		},
+	)
+
+	t.Run(
+		"invalid phase", func(t *testing.T) {
+			wf := newWorkflow(map[string]string{"a": "validate"})
+			res := wf.Run(context.Background())
+			assert.ErrorContains(t, res.Err, `invalid injected failure "validate" for operation "a"`)
+		},
+	)
+}
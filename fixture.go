@@ -0,0 +1,286 @@
+package blackstart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// ErrFixtureMismatch is returned by operations of a replayed run that do not match the recorded
+// interactions of the fixture, such as an operation whose inputs changed since the recording.
+var ErrFixtureMismatch = errors.New("operation does not match the fixture")
+
+// Fixture holds the interactions of the modules of a workflow run with the systems they manage:
+// the results of the checks and sets of the operations, and the outputs, resources, and API calls
+// they reported. A fixture is recorded with Workflow.Record, and a run with Workflow.Replay uses it
+// instead of the modules, so a changed workflow can be tested without the real systems.
+//
+// Values are recorded like the inputs and outputs of the run result, so sensitive values are
+// masked and fixtures may be stored with the workflow.
+type Fixture struct {
+	// Workflow is the name of the recorded workflow.
+	Workflow string `json:"workflow"`
+
+	// Operations are the recorded operations, in the order they were run.
+	Operations []*FixtureOperation `json:"operations"`
+
+	mu sync.Mutex
+}
+
+// FixtureOperation is the recorded interaction of the module of an operation.
+type FixtureOperation struct {
+	// Id is the identifier of the operation.
+	Id string `json:"id"`
+
+	// Module is the identifier of the module of the operation.
+	Module string `json:"module"`
+
+	// Inputs are the resolved input values of the operation, with sensitive values masked.
+	Inputs map[string]string `json:"inputs,omitempty"`
+
+	// Check is the result of the check of the operation. It is nil when the check failed.
+	Check *bool `json:"check,omitempty"`
+
+	// CheckError is the error of the failed check of the operation.
+	CheckError string `json:"checkError,omitempty"`
+
+	// Set is true when the module set the operation.
+	Set bool `json:"set,omitempty"`
+
+	// SetError is the error of the failed set of the operation.
+	SetError string `json:"setError,omitempty"`
+
+	// Outputs are the output values of the operation, with sensitive values masked.
+	Outputs map[string]string `json:"outputs,omitempty"`
+
+	// Resources are the resources reported by the operation.
+	Resources []string `json:"resources,omitempty"`
+
+	// APICalls are the number of external API calls of the operation by provider.
+	APICalls map[string]int64 `json:"apiCalls,omitempty"`
+}
+
+// operation returns the recorded operation with the id, or nil when it was not recorded.
+func (f *Fixture) operation(id string) *FixtureOperation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, op := range f.Operations {
+		if op.Id == id {
+			return op
+		}
+	}
+	return nil
+}
+
+// record returns the recorded operation, adding it to the fixture when it is not recorded yet.
+func (f *Fixture) record(op *Operation) *FixtureOperation {
+	if recorded := f.operation(op.Id); recorded != nil {
+		return recorded
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	recorded := &FixtureOperation{Id: op.Id, Module: op.Module}
+	f.Operations = append(f.Operations, recorded)
+	return recorded
+}
+
+// recordModule wraps the module of an operation to record its interactions in a fixture. Batch
+// checks of the wrapped module are not used, so the check of each operation is recorded.
+type recordModule struct {
+	Module
+	we      *workflowExecution
+	op      *Operation
+	fixture *Fixture
+}
+
+// Check runs the check of the wrapped module and records its result.
+func (m *recordModule) Check(ctx ModuleContext) (bool, error) {
+	check, err := m.Module.Check(ctx)
+	recorded := m.fixture.record(m.op)
+	m.fixture.mu.Lock()
+	defer m.fixture.mu.Unlock()
+	if err != nil {
+		recorded.CheckError = err.Error()
+	} else {
+		recorded.Check = &check
+	}
+	m.snapshot(ctx, recorded)
+	return check, err
+}
+
+// Set runs the set of the wrapped module and records its result.
+func (m *recordModule) Set(ctx ModuleContext) error {
+	err := m.Module.Set(ctx)
+	recorded := m.fixture.record(m.op)
+	m.fixture.mu.Lock()
+	defer m.fixture.mu.Unlock()
+	recorded.Set = true
+	if err != nil {
+		recorded.SetError = err.Error()
+	}
+	m.snapshot(ctx, recorded)
+	return err
+}
+
+// snapshot records the inputs, outputs, resources, and API calls of the operation so far.
+func (m *recordModule) snapshot(ctx ModuleContext, recorded *FixtureOperation) {
+	mctx, ok := ctx.(*moduleContext)
+	if !ok {
+		return
+	}
+	recorded.Inputs = recordedInputs(m.op, mctx, m.we.moduleInfo, m.we.opCtxs)
+	recorded.Outputs = recordedOutputs(mctx, m.we.moduleInfo[m.op.Id])
+	recorded.Resources = slices.Clone(mctx.resources)
+	recorded.APICalls = mctx.apiCalls.byProvider()
+}
+
+// Preflight runs the preflight checks of the wrapped module, if it has any.
+func (m *recordModule) Preflight(ctx ModuleContext) error {
+	if pf, ok := m.Module.(Preflighter); ok {
+		return pf.Preflight(ctx)
+	}
+	return nil
+}
+
+// Close closes the wrapped module, if it holds resources.
+func (m *recordModule) Close() error {
+	if closer, ok := m.Module.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// replayModule replays the recorded interactions of an operation instead of calling its module.
+// The module is only used for its information and the validation of the operation, so a replayed
+// run does not reach the systems the module manages.
+type replayModule struct {
+	Module
+	we       *workflowExecution
+	op       *Operation
+	recorded *FixtureOperation
+}
+
+// Check returns the recorded result of the check. An error wrapping ErrFixtureMismatch is returned
+// when the operation was not recorded or its inputs changed.
+func (m *replayModule) Check(ctx ModuleContext) (bool, error) {
+	if err := m.matches(ctx); err != nil {
+		return false, err
+	}
+	if m.recorded.CheckError != "" {
+		return false, errors.New(m.recorded.CheckError)
+	}
+	if m.recorded.Check == nil {
+		return false, fmt.Errorf("%w: check of operation %q was not recorded", ErrFixtureMismatch, m.op.Id)
+	}
+	if !*m.recorded.Check {
+		return false, nil
+	}
+	return true, m.replay(ctx)
+}
+
+// Set returns the recorded result of the set. An error wrapping ErrFixtureMismatch is returned
+// when the operation was not set in the recorded run.
+func (m *replayModule) Set(ctx ModuleContext) error {
+	if !m.recorded.Set {
+		return fmt.Errorf("%w: operation %q was not set in the recorded run", ErrFixtureMismatch, m.op.Id)
+	}
+	if m.recorded.SetError != "" {
+		return errors.New(m.recorded.SetError)
+	}
+	return m.replay(ctx)
+}
+
+// matches returns an error when the operation was not recorded, or when its module or resolved
+// inputs differ from the recording.
+func (m *replayModule) matches(ctx ModuleContext) error {
+	if m.recorded == nil {
+		return fmt.Errorf("%w: operation %q was not run in the recorded run", ErrFixtureMismatch, m.op.Id)
+	}
+	if m.recorded.Module != m.op.Module {
+		return fmt.Errorf(
+			"%w: operation %q uses module %q, recorded %q", ErrFixtureMismatch, m.op.Id, m.op.Module,
+			m.recorded.Module,
+		)
+	}
+	mctx, ok := ctx.(*moduleContext)
+	if !ok {
+		return nil
+	}
+	inputs := recordedInputs(m.op, mctx, m.we.moduleInfo, m.we.opCtxs)
+	keys := slices.Collect(maps.Keys(inputs))
+	for key := range m.recorded.Inputs {
+		if _, ok = inputs[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value, set := inputs[key]
+		recorded, wasSet := m.recorded.Inputs[key]
+		switch {
+		case !wasSet:
+			return fmt.Errorf("%w: input %q of operation %q was not recorded", ErrFixtureMismatch, key, m.op.Id)
+		case !set:
+			return fmt.Errorf("%w: input %q of operation %q is not set", ErrFixtureMismatch, key, m.op.Id)
+		case value != recorded:
+			return fmt.Errorf(
+				"%w: input %q of operation %q is %q, recorded %q", ErrFixtureMismatch, key, m.op.Id, value,
+				recorded,
+			)
+		}
+	}
+	return nil
+}
+
+// replay reports the recorded outputs, resources, and API calls of the operation. Outputs are
+// replayed as their recorded values, which dependent replayed operations are recorded with.
+func (m *replayModule) replay(ctx ModuleContext) error {
+	for _, key := range slices.Sorted(maps.Keys(m.recorded.Outputs)) {
+		if err := ctx.Output(key, m.recorded.Outputs[key]); err != nil {
+			return err
+		}
+	}
+	for _, resource := range m.recorded.Resources {
+		ctx.Resource(resource)
+	}
+	for provider, n := range m.recorded.APICalls {
+		for range n {
+			ctx.APICall(provider)
+		}
+	}
+	return nil
+}
+
+// Close closes the module, if it holds resources.
+func (m *replayModule) Close() error {
+	if closer, ok := m.Module.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// useFixture wraps the modules of the operations to record their interactions in the fixture of
+// Workflow.Record, or replaces them with the interactions of the fixture of Workflow.Replay.
+func (we *workflowExecution) useFixture(modules map[string]Module, operations map[string]*Operation) error {
+	switch {
+	case we.w.Record != nil && we.w.Replay != nil:
+		return errors.New("a workflow run cannot both record and replay a fixture")
+	case we.w.Record != nil:
+		we.w.Record.mu.Lock()
+		we.w.Record.Workflow = we.w.Name
+		we.w.Record.Operations = nil
+		we.w.Record.mu.Unlock()
+		for id, m := range modules {
+			modules[id] = &recordModule{Module: m, we: we, op: operations[id], fixture: we.w.Record}
+		}
+	case we.w.Replay != nil:
+		we.logger.Info("replaying fixture, modules are not run", "fixture", we.w.Replay.Workflow)
+		for id, m := range modules {
+			modules[id] = &replayModule{Module: m, we: we, op: operations[id], recorded: we.w.Replay.operation(id)}
+		}
+	}
+	return nil
+}
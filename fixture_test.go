@@ -0,0 +1,218 @@
+package blackstart
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtureWorkflow returns a workflow whose operations output values, report resources, and record
+// API calls.
+func fixtureWorkflow() Workflow {
+	return Workflow{
+		Name: "fixture-test",
+		Operations: []Operation{
+			{
+				Id:     "app",
+				Module: "record_test_module",
+				Inputs: map[string]Input{
+					"name":     NewInputFromValue("app"),
+					"password": NewInputFromValue("hunter2"),
+				},
+			},
+			{Id: "secret", Module: "resource_test_module", Inputs: map[string]Input{"resource": NewInputFromDep("app", "name")}},
+			{Id: "quota", Module: "metrics_test_module", Inputs: map[string]Input{"calls": NewInputFromValue(2)}},
+			{
+				Id:     "ready",
+				Module: "cleanup_test_module",
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(true),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+		},
+	}
+}
+
+// copyFixture returns a copy of the fixture whose operations may be changed.
+func copyFixture(fixture *Fixture) *Fixture {
+	copied := &Fixture{Workflow: fixture.Workflow}
+	for _, op := range fixture.Operations {
+		recorded := *op
+		copied.Operations = append(copied.Operations, &recorded)
+	}
+	return copied
+}
+
+func TestWorkflowExecution_RecordFixture(t *testing.T) {
+	wf := fixtureWorkflow()
+	wf.Record = &Fixture{}
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+
+	fixture := wf.Record
+	assert.Equal(t, "fixture-test", fixture.Workflow)
+	require.Len(t, fixture.Operations, 4)
+	notPassed, passed := false, true
+	assert.Equal(
+		t, &FixtureOperation{
+			Id:      "app",
+			Module:  "record_test_module",
+			Inputs:  map[string]string{"name": "app", "password": MaskedValue},
+			Check:   &notPassed,
+			Set:     true,
+			Outputs: map[string]string{"name": "app", "token": MaskedValue},
+		}, fixture.Operations[0],
+	)
+	assert.Equal(t, []string{"app"}, fixture.Operations[1].Resources)
+	assert.Equal(
+		t, map[string]int64{APIProviderKubernetes: 1, APIProviderGoogle: 2}, fixture.Operations[2].APICalls,
+	)
+	// The set of an operation whose check passed is not recorded.
+	assert.Equal(t, &passed, fixture.Operations[3].Check)
+	assert.False(t, fixture.Operations[3].Set)
+
+	// Recording again replaces the recorded operations.
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Len(t, wf.Record.Operations, 4)
+}
+
+func TestWorkflowExecution_ReplayFixture(t *testing.T) {
+	recorded := fixtureWorkflow()
+	recorded.Record = &Fixture{}
+	recordedRes := recorded.Run(context.Background())
+	require.NoError(t, recordedRes.Err)
+
+	// Fixtures are stored as JSON.
+	b, err := json.Marshal(recorded.Record)
+	require.NoError(t, err)
+	var fixture Fixture
+	require.NoError(t, json.Unmarshal(b, &fixture))
+
+	t.Run(
+		"unchanged", func(t *testing.T) {
+			sets := preflightTestSets.Load()
+			wf := fixtureWorkflow()
+			// The preflight check of the module would fail if it was called.
+			wf.Operations = append(
+				wf.Operations, Operation{
+					Id:     "replayed",
+					Module: "preflight_test_module",
+					Inputs: map[string]Input{"preflight_error": NewInputFromValue("not ready")},
+				},
+			)
+			wf.Replay = copyFixture(&fixture)
+			wf.Replay.Operations = append(
+				wf.Replay.Operations, &FixtureOperation{
+					Id:      "replayed",
+					Module:  "preflight_test_module",
+					Inputs:  map[string]string{"preflight_error": "not ready"},
+					Check:   new(bool),
+					Set:     true,
+					Outputs: map[string]string{"result": ""},
+				},
+			)
+			res := wf.Run(context.Background())
+			require.NoError(t, res.Err)
+			// The modules are not called.
+			assert.Equal(t, sets, preflightTestSets.Load())
+			assert.Equal(t, recordedRes.ManagedResources, res.ManagedResources)
+			assert.Equal(t, recordedRes.ProviderAPICalls(), res.ProviderAPICalls())
+			for i, op := range recordedRes.Operations {
+				assert.Equal(t, op.Inputs, res.Operations[i].Inputs, op.Id)
+				assert.Equal(t, op.Outputs, res.Operations[i].Outputs, op.Id)
+			}
+		},
+	)
+
+	t.Run(
+		"changed input", func(t *testing.T) {
+			wf := fixtureWorkflow()
+			wf.Operations[0].Inputs["name"] = NewInputFromValue("api")
+			wf.Replay = &fixture
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrFixtureMismatch)
+			assert.EqualError(
+				t, res.Err, `operation does not match the fixture: input "name" of operation "app" is "api", recorded "app"`,
+			)
+		},
+	)
+
+	t.Run(
+		"added operation", func(t *testing.T) {
+			wf := fixtureWorkflow()
+			wf.Operations = append(
+				wf.Operations, Operation{
+					Id: "extra", Module: "resource_test_module", Inputs: map[string]Input{"resource": NewInputFromValue("x")},
+				},
+			)
+			wf.Replay = &fixture
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrFixtureMismatch)
+			require.NotNil(t, res.Op)
+			assert.Equal(t, "extra", res.Op.Id)
+			assert.ErrorContains(t, res.Err, `operation "extra" was not run in the recorded run`)
+		},
+	)
+
+	t.Run(
+		"check no longer passes", func(t *testing.T) {
+			// A recorded operation whose check passed was not set, so it cannot be replayed when
+			// the check of the changed workflow would not pass.
+			wf := fixtureWorkflow()
+			wf.Replay = copyFixture(&fixture)
+			wf.Replay.Operations[3].Check = new(bool)
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrFixtureMismatch)
+			assert.ErrorContains(t, res.Err, `operation "ready" was not set in the recorded run`)
+		},
+	)
+
+	t.Run(
+		"record and replay", func(t *testing.T) {
+			wf := fixtureWorkflow()
+			wf.Record = &Fixture{}
+			wf.Replay = &fixture
+			res := wf.Run(context.Background())
+			assert.EqualError(t, res.Err, "a workflow run cannot both record and replay a fixture")
+		},
+	)
+}
+
+// readCountingStateStore is a StateStore that counts the reads of the state.
+type readCountingStateStore struct {
+	mapStateStore
+	reads int
+}
+
+func (s *readCountingStateStore) Get(ctx context.Context, key StateKey) ([]byte, error) {
+	s.mu.Lock()
+	s.reads++
+	s.mu.Unlock()
+	return s.mapStateStore.Get(ctx, key)
+}
+
+func TestWorkflowExecution_ReplayFixtureState(t *testing.T) {
+	store := &readCountingStateStore{mapStateStore: mapStateStore{states: make(map[StateKey][]byte)}}
+	ctx := context.WithValue(context.Background(), StateStoreKey, store)
+	require.NoError(t, immutableTestWorkflow("a", 1, false).Run(ctx).Err)
+	state := store.states[StateKey{Workflow: "immutable-test", Operation: "a"}]
+	require.NotEmpty(t, state)
+
+	recorded := immutableTestWorkflow("b", 1, false)
+	recorded.Record = &Fixture{}
+	require.NoError(t, recorded.Run(context.Background()).Err)
+
+	// A replayed run neither reads the stored state of the changed immutable input nor updates it.
+	store.reads = 0
+	wf := immutableTestWorkflow("b", 1, false)
+	wf.Replay = recorded.Record
+	res := wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Zero(t, store.reads)
+	assert.Equal(t, state, store.states[StateKey{Workflow: "immutable-test", Operation: "a"}])
+}
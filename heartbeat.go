@@ -0,0 +1,89 @@
+package blackstart
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Heartbeats are logged and operations are reported as stuck at these durations when the runtime
+// configuration is not set.
+const (
+	defaultOperationHeartbeat  = time.Minute
+	defaultStuckOperationAfter = 10 * time.Minute
+)
+
+// StuckOperation is an operation that has run longer than the stuck operation threshold.
+type StuckOperation struct {
+	// Id is the identifier of the operation.
+	Id string
+
+	// Module is the identifier of the module of the operation.
+	Module string
+
+	// Started is the time the operation started.
+	Started time.Time
+}
+
+// operationWatchdog returns the heartbeat interval and the stuck operation threshold from the
+// runtime configuration. A zero duration disables the heartbeat or the report.
+func operationWatchdog(ctx context.Context) (time.Duration, time.Duration) {
+	config, _ := ctx.Value(ConfigKey).(*RuntimeConfig)
+	if config == nil {
+		return defaultOperationHeartbeat, defaultStuckOperationAfter
+	}
+	return config.OperationHeartbeat, config.StuckOperationAfter
+}
+
+// watchOperation logs a heartbeat with the elapsed time each heartbeat interval while an operation
+// runs, so long-running operations, such as creating a Cloud SQL instance, can be told apart from
+// hung ones. Once the operation runs longer than the stuck operation threshold, a warning is logged
+// and the OnStuckOperation function of the workflow is called. The returned function stops
+// watching and must be called when the operation completes.
+func (we *workflowExecution) watchOperation(ctx context.Context, op *Operation) func() {
+	interval, stuckAfter := operationWatchdog(ctx)
+	if interval <= 0 && stuckAfter <= 0 {
+		return func() {}
+	}
+	start := time.Now()
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		var heartbeat, stuck <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+		if stuckAfter > 0 {
+			timer := time.NewTimer(stuckAfter)
+			defer timer.Stop()
+			stuck = timer.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-heartbeat:
+				we.logger.Info(
+					"operation still running", "module", op.Module, "id", op.Id,
+					"elapsed", time.Since(start).Round(time.Second).String(),
+				)
+			case <-stuck:
+				we.logger.Warn(
+					"operation may be stuck", "module", op.Module, "id", op.Id,
+					"elapsed", time.Since(start).Round(time.Second).String(),
+				)
+				if we.w.OnStuckOperation != nil {
+					we.w.OnStuckOperation(StuckOperation{Id: op.Id, Module: op.Module, Started: start})
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
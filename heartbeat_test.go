@@ -0,0 +1,99 @@
+package blackstart
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowTestModule sets its operation after sleeping for the duration input.
+type slowTestModule struct{}
+
+func init() {
+	RegisterModule("slow_test_module", func() Module { return &slowTestModule{} })
+}
+
+func (m *slowTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "slow_test_module",
+		Inputs: map[string]InputValue{
+			"duration": {Type: reflect.TypeFor[string](), Required: true},
+		},
+	}
+}
+
+func (m *slowTestModule) Validate(_ Operation) error { return nil }
+func (m *slowTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *slowTestModule) Set(ctx ModuleContext) error {
+	raw, err := ContextInputAs[string](ctx, "duration", true)
+	if err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	time.Sleep(d)
+	return nil
+}
+
+func slowTestWorkflow(duration string) *Workflow {
+	return &Workflow{
+		Name: "slow-test",
+		Operations: []Operation{
+			{Id: "slow", Module: "slow_test_module", Inputs: map[string]Input{"duration": NewInputFromValue(duration)}},
+		},
+	}
+}
+
+func TestWorkflowExecution_OperationHeartbeat(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &RuntimeConfig{
+		LogFormat:           "text",
+		LogLevel:            "info",
+		OperationHeartbeat:  20 * time.Millisecond,
+		StuckOperationAfter: 50 * time.Millisecond,
+	}
+	ctx := context.WithValue(context.Background(), ConfigKey, cfg)
+	ctx = context.WithValue(ctx, LoggerKey, newLoggerForWriter(cfg, &buf))
+
+	var stuck []StuckOperation
+	wf := slowTestWorkflow("150ms")
+	wf.OnStuckOperation = func(op StuckOperation) { stuck = append(stuck, op) }
+	start := time.Now()
+	res := wf.Run(ctx)
+	require.NoError(t, res.Err)
+
+	out := buf.String()
+	assert.Contains(t, out, "INFO operation still running workflow=slow-test module=slow_test_module id=slow elapsed=")
+	assert.Contains(t, out, "WARN operation may be stuck workflow=slow-test module=slow_test_module id=slow elapsed=")
+	require.Len(t, stuck, 1)
+	assert.Equal(t, "slow", stuck[0].Id)
+	assert.Equal(t, "slow_test_module", stuck[0].Module)
+	assert.WithinDuration(t, start, stuck[0].Started, 100*time.Millisecond)
+
+	// Operations that complete before the thresholds are not reported.
+	buf.Reset()
+	stuck = nil
+	wf = slowTestWorkflow("1ms")
+	wf.OnStuckOperation = func(op StuckOperation) { stuck = append(stuck, op) }
+	require.NoError(t, wf.Run(ctx).Err)
+	assert.NotContains(t, buf.String(), "operation still running")
+	assert.Empty(t, stuck)
+
+	// Zero durations disable heartbeats and stuck operation reports.
+	cfg.OperationHeartbeat, cfg.StuckOperationAfter = 0, 0
+	buf.Reset()
+	wf = slowTestWorkflow("60ms")
+	wf.OnStuckOperation = func(op StuckOperation) { stuck = append(stuck, op) }
+	require.NoError(t, wf.Run(ctx).Err)
+	assert.NotContains(t, buf.String(), "operation still running")
+	assert.Empty(t, stuck)
+}
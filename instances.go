@@ -0,0 +1,60 @@
+package blackstart
+
+// InstanceResult is the result of the operations of a forEach instance in a workflow run.
+type InstanceResult struct {
+	// Name is the name of the instance.
+	Name string
+
+	// Err is the error of the failed operation of the instance, or the error of the run when it
+	// stopped before the operations of the instance were completed. It is nil when the instance
+	// succeeded.
+	Err error
+
+	// TotalOperations is the number of operations of the instance.
+	TotalOperations int
+
+	// CompletedOperations is the number of operations of the instance that were completed.
+	CompletedOperations int
+}
+
+// instanceResults returns the results of the forEach instances of the operations of a run, in the
+// order of the operations. Failures of operations with the continue policy do not fail their
+// instance, like they do not fail the run.
+func instanceResults(operations []Operation, result WorkflowResult) []InstanceResult {
+	var results []InstanceResult
+	index := make(map[string]int)
+	instanceOf := make(map[string]*Operation, len(operations))
+	for i := range operations {
+		op := &operations[i]
+		if op.Instance == "" {
+			continue
+		}
+		instanceOf[op.Id] = op
+		if _, ok := index[op.Instance]; !ok {
+			index[op.Instance] = len(results)
+			results = append(results, InstanceResult{Name: op.Instance})
+		}
+		results[index[op.Instance]].TotalOperations++
+	}
+	for _, opResult := range result.Operations {
+		op, ok := instanceOf[opResult.Id]
+		if !ok {
+			continue
+		}
+		instance := &results[index[op.Instance]]
+		switch {
+		case opResult.Err == nil:
+			instance.CompletedOperations++
+		case instance.Err == nil && op.OnFailure != OnFailureContinue:
+			instance.Err = opResult.Err
+		}
+	}
+	if result.Err != nil {
+		for i := range results {
+			if results[i].Err == nil && results[i].CompletedOperations < results[i].TotalOperations {
+				results[i].Err = result.Err
+			}
+		}
+	}
+	return results
+}
@@ -0,0 +1,79 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instancesWorkflow returns a workflow with the operations a and b, which does not depend on a, for
+// each of the forEach instances team-a, team-b, and team-c.
+func instancesWorkflow() Workflow {
+	inputs := map[string]Input{
+		testCheckResult: NewInputFromValue(false),
+		testSetResult:   NewInputFromValue(true),
+	}
+	var ops []Operation
+	for _, instance := range []string{"team-a", "team-b", "team-c"} {
+		ops = append(
+			ops,
+			Operation{Id: instance + "/a", Module: "cleanup_test_module", Inputs: inputs, Instance: instance},
+			Operation{Id: instance + "/b", Module: "cleanup_test_module", Inputs: inputs, Instance: instance},
+		)
+	}
+	return Workflow{Name: "instances-test", Operations: ops}
+}
+
+func TestWorkflowExecution_InstanceFailure(t *testing.T) {
+	wf := instancesWorkflow()
+	wf.InjectedFailures = map[string]string{"team-b/a": InjectFailureSet}
+	res := wf.Run(context.Background())
+
+	// The run fails once the operations of the other instances are completed.
+	require.ErrorIs(t, res.Err, ErrInjectedFailure)
+	require.NotNil(t, res.Op)
+	assert.Equal(t, "team-b/a", res.Op.Id)
+	assert.Equal(
+		t, []string{"team-a/a", "team-a/b", "team-b/a", "team-b/b", "team-c/a", "team-c/b"},
+		operationIds(res.Operations),
+	)
+	// The other operations of the failed instance are skipped.
+	assert.True(t, res.Operations[3].Skipped)
+	assert.False(t, res.Operations[4].Skipped)
+	assert.NoError(t, res.Operations[5].Err)
+
+	require.Len(t, res.Instances, 3)
+	assert.Equal(t, InstanceResult{Name: "team-a", TotalOperations: 2, CompletedOperations: 2}, res.Instances[0])
+	assert.Equal(t, "team-b", res.Instances[1].Name)
+	assert.ErrorIs(t, res.Instances[1].Err, ErrInjectedFailure)
+	assert.Equal(t, 1, res.Instances[1].CompletedOperations)
+	assert.Equal(t, InstanceResult{Name: "team-c", TotalOperations: 2, CompletedOperations: 2}, res.Instances[2])
+}
+
+func TestWorkflowExecution_InstanceFailurePolicies(t *testing.T) {
+	t.Run(
+		"continue", func(t *testing.T) {
+			// A failure of an operation with the continue policy does not fail its instance.
+			wf := instancesWorkflow()
+			wf.Operations[2].OnFailure = OnFailureContinue
+			wf.InjectedFailures = map[string]string{"team-b/a": InjectFailureSet}
+			res := wf.Run(context.Background())
+			require.NoError(t, res.Err)
+			assert.False(t, res.Operations[3].Skipped)
+			require.Len(t, res.Instances, 3)
+			assert.NoError(t, res.Instances[1].Err)
+			assert.Equal(t, 1, res.Instances[1].CompletedOperations)
+		},
+	)
+
+	t.Run(
+		"no instances", func(t *testing.T) {
+			wf := onFailureWorkflow("")
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrInjectedFailure)
+			assert.Empty(t, res.Instances)
+		},
+	)
+}
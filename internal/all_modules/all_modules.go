@@ -2,12 +2,32 @@ package all_modules
 
 // This package is used to import all modules so that they are registered
 import (
+	_ "github.com/pezops/blackstart/modules/azure/keyvault"
+	_ "github.com/pezops/blackstart/modules/azure/postgres"
 	_ "github.com/pezops/blackstart/modules/crypto"
+	_ "github.com/pezops/blackstart/modules/github"
+	_ "github.com/pezops/blackstart/modules/gitlab"
+	_ "github.com/pezops/blackstart/modules/google/bigquery"
 	_ "github.com/pezops/blackstart/modules/google/cloud"
+	_ "github.com/pezops/blackstart/modules/google/cloudrun"
 	_ "github.com/pezops/blackstart/modules/google/cloudsql"
+	_ "github.com/pezops/blackstart/modules/google/firestore"
+	_ "github.com/pezops/blackstart/modules/google/gkehub"
+	_ "github.com/pezops/blackstart/modules/google/kms"
+	_ "github.com/pezops/blackstart/modules/google/monitoring"
+	_ "github.com/pezops/blackstart/modules/google/serviceusage"
+	_ "github.com/pezops/blackstart/modules/google/spanner"
 	_ "github.com/pezops/blackstart/modules/kubernetes"
+	_ "github.com/pezops/blackstart/modules/launchdarkly"
+	_ "github.com/pezops/blackstart/modules/ldap"
 	_ "github.com/pezops/blackstart/modules/mock"
 	_ "github.com/pezops/blackstart/modules/mysql"
+	_ "github.com/pezops/blackstart/modules/opsgenie"
+	_ "github.com/pezops/blackstart/modules/pagerduty"
 	_ "github.com/pezops/blackstart/modules/postgres"
+	_ "github.com/pezops/blackstart/modules/s3"
+	_ "github.com/pezops/blackstart/modules/sendgrid"
+	_ "github.com/pezops/blackstart/modules/slack"
+	_ "github.com/pezops/blackstart/modules/snowflake"
 	_ "github.com/pezops/blackstart/modules/util"
 )
@@ -40,7 +40,7 @@ title: {{ .Id }}
 | Id | Description | Type | Required |
 |------|-------------|------|----------|
 {{- range $name, $input := .Inputs }}
-| {{ $name }} | {{ $input.Description }}{{ if and (not $input.Required) (ne $input.Default nil) }}<br>Default: **{{ $input.Default }}**{{ end }} | {{ $input.TypeDisplay }} | {{ $input.Required }} |
+| {{ $name }} | {{ $input.Description }}{{ if and (not $input.Required) (ne $input.Default nil) }}<br>Default: **{{ $input.Default }}**{{ end }}{{ if $input.Sensitive }}<br>Sensitive: masked in the workflow status{{ end }}{{ if $input.Immutable }}<br>Immutable: cannot be changed in place{{ end }} | {{ $input.TypeDisplay }} | {{ $input.Required }} |
 {{- end }}
 {{- else }}
 
@@ -53,7 +53,7 @@ No inputs are supported for this module
 | Id | Description | Type |
 |------|-------------|------|
 {{- range $name, $output := .Outputs }}
-| {{ $name }} | {{ $output.Description }} | {{ $output.Type }} |
+| {{ $name }} | {{ $output.Description }}{{ if $output.Sensitive }}<br>Sensitive: masked in the workflow status{{ end }} | {{ $output.Type }} |
 {{- end }}
 {{- else }}
 
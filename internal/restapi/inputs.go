@@ -0,0 +1,127 @@
+package restapi
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+)
+
+// InputAPIURL is the key of the input that sets the API base URL of a module.
+const InputAPIURL = "api_url"
+
+// Credentials describes the inputs that configure the API client of a module: a sensitive token
+// input, which defaults to an environment variable, and the api_url input.
+type Credentials struct {
+	// Input is the key of the token input, such as `token` or `api_key`.
+	Input string
+
+	// Description describes the token input. The environment variable that the token defaults to
+	// is appended to it.
+	Description string
+
+	// EnvVar is the environment variable used when the token input is not set.
+	EnvVar string
+
+	// APIURLDescription describes the api_url input.
+	APIURLDescription string
+
+	// DefaultAPIURL is the API base URL used when the api_url input is not set.
+	DefaultAPIURL string
+}
+
+// Inputs returns the inputs of a module merged with the token and api_url inputs.
+func (c Credentials) Inputs(inputs map[string]blackstart.InputValue) map[string]blackstart.InputValue {
+	merged := map[string]blackstart.InputValue{
+		c.Input: {
+			Description: fmt.Sprintf("%s Defaults to the `%s` environment variable.", c.Description, c.EnvVar),
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+			Sensitive:   true,
+		},
+		InputAPIURL: {
+			Description: c.APIURLDescription,
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+			Default:     c.DefaultAPIURL,
+		},
+	}
+	maps.Copy(merged, inputs)
+	return merged
+}
+
+// FromContext returns the token and API base URL from the module inputs. When no token input is
+// provided, the environment variable is used.
+func (c Credentials) FromContext(ctx blackstart.ModuleContext) (token, apiURL string, err error) {
+	token, err = blackstart.ContextInputAs[string](ctx, c.Input, false)
+	if err != nil {
+		return "", "", err
+	}
+	if token == "" {
+		token = os.Getenv(c.EnvVar)
+	}
+	if token == "" {
+		return "", "", fmt.Errorf("input '%s' or the %s environment variable must be set", c.Input, c.EnvVar)
+	}
+
+	apiURL, err = blackstart.ContextInputAs[string](ctx, InputAPIURL, false)
+	if err != nil {
+		return "", "", err
+	}
+	if apiURL == "" {
+		apiURL = c.DefaultAPIURL
+	}
+	return token, apiURL, nil
+}
+
+// TestOperation returns an operation of a module that authenticates with the token and sends
+// requests to apiURL, such as the URL of a fake API server in tests.
+func (c Credentials) TestOperation(module, apiURL, token string, inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{
+		Id:     module,
+		Module: module,
+		Inputs: map[string]blackstart.Input{
+			c.Input:     blackstart.NewInputFromValue(token),
+			InputAPIURL: blackstart.NewInputFromValue(apiURL),
+		},
+	}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+// ValidateRequiredStrings validates that the inputs are set and, when static, are non-empty
+// strings.
+func ValidateRequiredStrings(op blackstart.Operation, keys ...string) error {
+	for _, key := range keys {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", key)
+		}
+		if input.IsStatic() {
+			if _, err := blackstart.InputAs[string](input, true); err != nil {
+				return fmt.Errorf("parameter %s is invalid: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateEnum validates that a static, optional input is one of the allowed values.
+func ValidateEnum[T any](op blackstart.Operation, key string, allowed map[string]T) error {
+	input, ok := op.Inputs[key]
+	if !ok || !input.IsStatic() {
+		return nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return fmt.Errorf("parameter %s is invalid: %w", key, err)
+	}
+	if _, ok = allowed[value]; value != "" && !ok {
+		return fmt.Errorf("parameter %s has invalid value '%s'", key, value)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// Package restapi provides the JSON REST API client and the module input helpers shared by modules
+// that manage resources of SaaS providers, such as GitHub and PagerDuty.
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pezops/blackstart"
+)
+
+// PageSize is the number of items requested per page from list endpoints.
+const PageSize = 100
+
+// APIError is returned when an API responds with a non-success status code.
+type APIError struct {
+	// API is the name of the API, such as `github`.
+	API        string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("%s api request failed with status %d: %s", e.API, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s api request failed with status %d", e.API, e.StatusCode)
+}
+
+// IsNotFound reports whether err is an API 404 response.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// MessageField returns the `message` field of a JSON error response.
+func MessageField(body io.Reader) string {
+	var payload struct {
+		Message string `json:"message"`
+	}
+	_ = json.NewDecoder(body).Decode(&payload)
+	return payload.Message
+}
+
+// Config configures a Client.
+type Config struct {
+	// API is the name of the API used in errors, such as `github`.
+	API string
+
+	// BaseURL is the URL that request paths are relative to.
+	BaseURL string
+
+	// Header is set on each request, such as the Accept and Authorization headers.
+	Header http.Header
+
+	// ErrorMessage returns the message of an error response body. MessageField is used when it is
+	// nil.
+	ErrorMessage func(body io.Reader) string
+}
+
+// Client is a minimal JSON REST API client. Requests are counted as API calls of the operation
+// whose ModuleContext they are made with.
+type Client struct {
+	// BaseURL is the URL that request paths are relative to.
+	BaseURL string
+
+	// Header is set on each request.
+	Header http.Header
+
+	api          string
+	errorMessage func(body io.Reader) string
+	httpClient   *http.Client
+}
+
+// NewClient creates a JSON REST API client. The client uses blackstart.HTTPTransport, which has
+// the proxy and trusted CA configuration of the runtime.
+func NewClient(config Config) *Client {
+	errorMessage := config.ErrorMessage
+	if errorMessage == nil {
+		errorMessage = MessageField
+	}
+	header := config.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &Client{
+		BaseURL:      strings.TrimRight(config.BaseURL, "/"),
+		Header:       header,
+		api:          config.API,
+		errorMessage: errorMessage,
+		httpClient:   &http.Client{Transport: blackstart.CountAPICalls(config.API, nil)},
+	}
+}
+
+// Do sends a request to the API and decodes the JSON response into out when provided.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request body: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", blackstart.UserAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s api request failed: %w", c.api, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{API: c.api, StatusCode: resp.StatusCode, Message: c.errorMessage(resp.Body)}
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s api response: %w", c.api, err)
+	}
+	return nil
+}
+
+// ListPages returns the items of every page of a list endpoint that is paginated with the
+// `per_page` and `page` query parameters.
+func ListPages[T any](ctx context.Context, c *Client, path string) ([]T, error) {
+	var all []T
+	for page := 1; ; page++ {
+		var items []T
+		pagePath := fmt.Sprintf("%s?per_page=%d&page=%d", path, PageSize, page)
+		if err := c.Do(ctx, http.MethodGet, pagePath, nil, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < PageSize {
+			return all, nil
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+var testCredentials = Credentials{
+	Input:             "token",
+	Description:       "Token used to authenticate API requests.",
+	EnvVar:            "RESTAPI_TEST_TOKEN",
+	APIURLDescription: "API base URL.",
+	DefaultAPIURL:     "https://api.example.com",
+}
+
+// newTestServer starts a fake API server with an `/items` list endpoint of 105 items, which
+// requires the `Bearer test-token` authorization.
+func newTestServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var requests []string
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, r.Method+" "+r.URL.RequestURI())
+				if r.Header.Get("Authorization") != "Bearer test-token" {
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+					return
+				}
+				switch r.URL.Path {
+				case "/items":
+					if r.Method == http.MethodDelete {
+						w.WriteHeader(http.StatusNoContent)
+						return
+					}
+					page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+					items := []map[string]int{}
+					for i := (page - 1) * PageSize; i < min(page*PageSize, PageSize+5); i++ {
+						items = append(items, map[string]int{"id": i})
+					}
+					_ = json.NewEncoder(w).Encode(items)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+				}
+			},
+		),
+	)
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func newTestClient(baseURL, token string) *Client {
+	return NewClient(
+		Config{API: "example", BaseURL: baseURL + "/", Header: http.Header{"Authorization": {"Bearer " + token}}},
+	)
+}
+
+func TestClient_Do(t *testing.T) {
+	server, _ := newTestServer(t)
+	c := newTestClient(server.URL, "test-token")
+	require.Equal(t, server.URL, c.BaseURL)
+
+	err := c.Do(context.Background(), http.MethodGet, "/missing", nil, nil)
+	require.True(t, IsNotFound(err))
+	require.EqualError(t, err, "example api request failed with status 404: Not Found")
+
+	require.NoError(t, c.Do(context.Background(), http.MethodDelete, "/items", nil, &struct{}{}))
+
+	err = newTestClient(server.URL, "wrong-token").Do(context.Background(), http.MethodGet, "/items", nil, nil)
+	require.False(t, IsNotFound(err))
+	require.EqualError(t, err, "example api request failed with status 401: Bad credentials")
+}
+
+func TestListPages(t *testing.T) {
+	server, requests := newTestServer(t)
+
+	items, err := ListPages[struct {
+		ID int `json:"id"`
+	}](context.Background(), newTestClient(server.URL, "test-token"), "/items")
+	require.NoError(t, err)
+	require.Len(t, items, PageSize+5)
+	require.Equal(t, PageSize+4, items[PageSize+4].ID)
+	require.Equal(t, []string{"GET /items?per_page=100&page=1", "GET /items?per_page=100&page=2"}, *requests)
+}
+
+func TestCredentials_FromContext(t *testing.T) {
+	op := testCredentials.TestOperation("restapi_test_module", "", "", nil)
+	delete(op.Inputs, testCredentials.Input)
+	delete(op.Inputs, InputAPIURL)
+
+	t.Setenv(testCredentials.EnvVar, "")
+	_, _, err := testCredentials.FromContext(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, testCredentials.EnvVar)
+
+	t.Setenv(testCredentials.EnvVar, "env-token")
+	token, apiURL, err := testCredentials.FromContext(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "env-token", token)
+	require.Equal(t, testCredentials.DefaultAPIURL, apiURL)
+
+	op = testCredentials.TestOperation("restapi_test_module", "https://api.example.net", "test-token", nil)
+	token, apiURL, err = testCredentials.FromContext(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "test-token", token)
+	require.Equal(t, "https://api.example.net", apiURL)
+}
+
+func TestCredentials_Inputs(t *testing.T) {
+	inputs := testCredentials.Inputs(map[string]blackstart.InputValue{"name": {Required: true}})
+	require.Len(t, inputs, 3)
+	require.True(t, inputs["token"].Sensitive)
+	require.Equal(
+		t,
+		"Token used to authenticate API requests. Defaults to the `RESTAPI_TEST_TOKEN` environment variable.",
+		inputs["token"].Description,
+	)
+	require.Equal(t, "https://api.example.com", inputs[InputAPIURL].Default)
+}
+
+func TestValidate(t *testing.T) {
+	op := testCredentials.TestOperation("restapi_test_module", "", "", map[string]any{"name": "", "kind": "other"})
+	require.EqualError(t, ValidateRequiredStrings(*op, "missing"), "missing required parameter: missing")
+	require.ErrorContains(t, ValidateRequiredStrings(*op, "name"), "parameter name is invalid")
+	require.EqualError(
+		t,
+		ValidateEnum(*op, "kind", map[string]struct{}{"some": {}}),
+		"parameter kind has invalid value 'other'",
+	)
+	require.NoError(t, ValidateEnum(*op, "kind", map[string]struct{}{"other": {}}))
+	require.NoError(t, ValidateEnum(*op, "missing", map[string]struct{}{}))
+}
+
+// apiTestModule lists the items of the API server at its api_url input during Check.
+type apiTestModule struct{}
+
+func init() {
+	blackstart.RegisterModule("restapi_test_module", func() blackstart.Module { return &apiTestModule{} })
+}
+
+func (m *apiTestModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:     "restapi_test_module",
+		Inputs: testCredentials.Inputs(nil),
+	}
+}
+
+func (m *apiTestModule) Validate(_ blackstart.Operation) error { return nil }
+
+func (m *apiTestModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	token, apiURL, err := testCredentials.FromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, err = ListPages[map[string]int](ctx, newTestClient(apiURL, token), "/items")
+	return err == nil, err
+}
+
+func (m *apiTestModule) Set(_ blackstart.ModuleContext) error { return nil }
+
+func TestClient_CountsAPICalls(t *testing.T) {
+	server, _ := newTestServer(t)
+	op := testCredentials.TestOperation("restapi_test_module", server.URL, "test-token", nil)
+	wf := blackstart.Workflow{Name: "restapi-test", Operations: []blackstart.Operation{*op}}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	require.Len(t, res.Operations, 1)
+	require.Equal(t, int64(2), res.Operations[0].APICalls)
+	require.Equal(t, map[string]int64{"example": 2}, res.Operations[0].ProviderAPICalls)
+}
@@ -0,0 +1,133 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// progressingConditions returns the conditions of a Workflow when a run starts. The Ready and
+// Degraded conditions of the previous run are kept.
+func progressingConditions(previous []metav1.Condition, generation int64) []metav1.Condition {
+	conditions := slices.Clone(previous)
+	meta.SetStatusCondition(
+		&conditions, metav1.Condition{
+			Type:               v1alpha1.ConditionProgressing,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: generation,
+			Reason:             v1alpha1.ReasonRunning,
+			Message:            "workflow run in progress",
+		},
+	)
+	return conditions
+}
+
+// resultConditions returns the conditions of a Workflow after a run. Conditions with an unchanged
+// status keep their last transition time.
+func resultConditions(
+	previous []metav1.Condition, generation int64, result blackstart.WorkflowResult,
+) []metav1.Condition {
+	conditions := slices.Clone(previous)
+	ready := metav1.Condition{Type: v1alpha1.ConditionReady, ObservedGeneration: generation}
+	degraded := metav1.Condition{Type: v1alpha1.ConditionDegraded, ObservedGeneration: generation}
+	pending := pendingWindowOperations(result.Operations)
+	failed := failedOperations(result.Operations)
+	switch {
+	case result.Err == nil && len(pending) > 0:
+		ready.Status, degraded.Status = metav1.ConditionFalse, metav1.ConditionFalse
+		ready.Reason, degraded.Reason = v1alpha1.ReasonPendingWindow, v1alpha1.ReasonPendingWindow
+		ready.Message = fmt.Sprintf(
+			"%d operations pending the maintenance window: %s", len(pending), strings.Join(pending, ", "),
+		)
+		degraded.Message = ready.Message
+	case result.Err == nil && len(failed) > 0:
+		// Operations that failed with the continue failure policy do not fail the run.
+		ready.Status, degraded.Status = metav1.ConditionTrue, metav1.ConditionTrue
+		ready.Reason, degraded.Reason = v1alpha1.ReasonSucceeded, v1alpha1.ReasonOperationsFailed
+		ready.Message = fmt.Sprintf(
+			"%d/%d operations completed", result.CompletedOperations, result.TotalOperations,
+		)
+		degraded.Message = fmt.Sprintf(
+			"%d operations failed and the run continued: %s", len(failed), strings.Join(failed, ", "),
+		)
+	case result.Err == nil:
+		ready.Status, degraded.Status = metav1.ConditionTrue, metav1.ConditionFalse
+		ready.Reason, degraded.Reason = v1alpha1.ReasonSucceeded, v1alpha1.ReasonSucceeded
+		ready.Message = fmt.Sprintf(
+			"%d/%d operations completed", result.CompletedOperations, result.TotalOperations,
+		)
+		degraded.Message = ready.Message
+	default:
+		ready.Status, degraded.Status = metav1.ConditionFalse, metav1.ConditionTrue
+		ready.Reason = failedReason(result)
+		degraded.Reason = ready.Reason
+		ready.Message = result.Err.Error()
+		degraded.Message = ready.Message
+	}
+	meta.SetStatusCondition(&conditions, ready)
+	meta.SetStatusCondition(
+		&conditions, metav1.Condition{
+			Type:               v1alpha1.ConditionProgressing,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             v1alpha1.ReasonRunComplete,
+			Message:            "workflow run complete",
+		},
+	)
+	meta.SetStatusCondition(&conditions, degraded)
+	// Drift reported by check-only runs is corrected by a successful run.
+	if ready.Status == metav1.ConditionTrue && meta.FindStatusCondition(conditions, v1alpha1.ConditionDrifted) != nil {
+		meta.SetStatusCondition(
+			&conditions, metav1.Condition{
+				Type:               v1alpha1.ConditionDrifted,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: generation,
+				Reason:             v1alpha1.ReasonSucceeded,
+				Message:            "workflow run completed",
+			},
+		)
+	}
+	return conditions
+}
+
+// driftConditions returns the conditions of a Workflow after a check-only run. Only the Drifted
+// condition is changed. The condition is unknown when the run failed before all operations were
+// checked.
+func driftConditions(
+	previous []metav1.Condition, generation int64, result blackstart.WorkflowResult, drifted []string,
+) []metav1.Condition {
+	conditions := slices.Clone(previous)
+	condition := metav1.Condition{Type: v1alpha1.ConditionDrifted, ObservedGeneration: generation}
+	switch {
+	case result.Err != nil:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = failedReason(result)
+		condition.Message = result.Err.Error()
+	case len(drifted) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1alpha1.ReasonDriftDetected
+		condition.Message = fmt.Sprintf("%d operations drifted: %s", len(drifted), strings.Join(drifted, ", "))
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = v1alpha1.ReasonInSync
+		condition.Message = fmt.Sprintf("checks of %d operations passed", result.TotalOperations)
+	}
+	meta.SetStatusCondition(&conditions, condition)
+	return conditions
+}
+
+// failedReason returns the condition reason of a failed run, the phase it failed in followed by
+// "Failed", or TimedOut when the run did not complete within the timeout of the workflow.
+func failedReason(result blackstart.WorkflowResult) string {
+	if errors.Is(result.Err, blackstart.ErrWorkflowTimeout) {
+		return v1alpha1.ReasonTimedOut
+	}
+	return result.Phase + "Failed"
+}
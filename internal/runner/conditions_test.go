@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestResultConditions(t *testing.T) {
+	conditions := progressingConditions(nil, 3)
+	progressing := meta.FindStatusCondition(conditions, v1alpha1.ConditionProgressing)
+	require.NotNil(t, progressing)
+	assert.Equal(t, metav1.ConditionTrue, progressing.Status)
+	assert.Equal(t, v1alpha1.ReasonRunning, progressing.Reason)
+	assert.Nil(t, meta.FindStatusCondition(conditions, v1alpha1.ConditionReady))
+
+	conditions = resultConditions(
+		conditions, 3, blackstart.WorkflowResult{Phase: "Execute", TotalOperations: 2, CompletedOperations: 2},
+	)
+	require.Len(t, conditions, 3)
+	ready := meta.FindStatusCondition(conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionTrue, ready.Status)
+	assert.Equal(t, v1alpha1.ReasonSucceeded, ready.Reason)
+	assert.Equal(t, "2/2 operations completed", ready.Message)
+	assert.Equal(t, int64(3), ready.ObservedGeneration)
+	assert.False(t, ready.LastTransitionTime.IsZero())
+	assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionProgressing))
+	assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionDegraded))
+
+	// An unchanged status keeps its last transition time.
+	transition := metav1.NewTime(ready.LastTransitionTime.Add(-time.Hour))
+	ready.LastTransitionTime = transition
+	conditions = resultConditions(conditions, 3, blackstart.WorkflowResult{Phase: "Execute"})
+	assert.Equal(t, transition, meta.FindStatusCondition(conditions, v1alpha1.ConditionReady).LastTransitionTime)
+
+	conditions = resultConditions(
+		conditions, 4, blackstart.WorkflowResult{Phase: "Preflight", Err: errors.New("permission denied")},
+	)
+	ready = meta.FindStatusCondition(conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, "PreflightFailed", ready.Reason)
+	assert.Equal(t, "permission denied", ready.Message)
+	assert.NotEqual(t, transition, ready.LastTransitionTime)
+	degraded := meta.FindStatusCondition(conditions, v1alpha1.ConditionDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+	assert.Equal(t, "PreflightFailed", degraded.Reason)
+
+	// A run that did not complete within the timeout of the workflow is reported as timed out.
+	timedOut := fmt.Errorf("%w after 1m0s at operation \"a\": context deadline exceeded", blackstart.ErrWorkflowTimeout)
+	conditions = resultConditions(conditions, 4, blackstart.WorkflowResult{Phase: "Execute", Err: timedOut})
+	ready = meta.FindStatusCondition(conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, v1alpha1.ReasonTimedOut, ready.Reason)
+	assert.Equal(t, timedOut.Error(), ready.Message)
+	assert.Equal(t, v1alpha1.ReasonTimedOut, meta.FindStatusCondition(conditions, v1alpha1.ConditionDegraded).Reason)
+}
+
+func TestDriftConditions(t *testing.T) {
+	conditions := driftConditions(nil, 2, blackstart.WorkflowResult{TotalOperations: 3}, nil)
+	drifted := meta.FindStatusCondition(conditions, v1alpha1.ConditionDrifted)
+	require.NotNil(t, drifted)
+	assert.Equal(t, metav1.ConditionFalse, drifted.Status)
+	assert.Equal(t, v1alpha1.ReasonInSync, drifted.Reason)
+
+	conditions = driftConditions(conditions, 2, blackstart.WorkflowResult{TotalOperations: 3}, []string{"a", "b"})
+	drifted = meta.FindStatusCondition(conditions, v1alpha1.ConditionDrifted)
+	require.NotNil(t, drifted)
+	assert.Equal(t, metav1.ConditionTrue, drifted.Status)
+	assert.Equal(t, v1alpha1.ReasonDriftDetected, drifted.Reason)
+	assert.Equal(t, "2 operations drifted: a, b", drifted.Message)
+	assert.Nil(t, meta.FindStatusCondition(conditions, v1alpha1.ConditionReady))
+
+	conditions = driftConditions(
+		conditions, 2, blackstart.WorkflowResult{Phase: "Execute", Err: errors.New("timeout")}, nil,
+	)
+	assert.Equal(t, "ExecuteFailed", meta.FindStatusCondition(conditions, v1alpha1.ConditionDrifted).Reason)
+	timedOut := fmt.Errorf("%w after 1m0s at operation \"a\"", blackstart.ErrWorkflowTimeout)
+	conditions = driftConditions(conditions, 2, blackstart.WorkflowResult{Phase: "Execute", Err: timedOut}, nil)
+	assert.Equal(t, v1alpha1.ReasonTimedOut, meta.FindStatusCondition(conditions, v1alpha1.ConditionDrifted).Reason)
+
+	// A successful run clears the drift.
+	conditions = resultConditions(conditions, 2, blackstart.WorkflowResult{Phase: "Execute"})
+	assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionDrifted))
+
+	// Workflows without check-only runs have no Drifted condition.
+	conditions = resultConditions(nil, 2, blackstart.WorkflowResult{Phase: "Execute"})
+	assert.Nil(t, meta.FindStatusCondition(conditions, v1alpha1.ConditionDrifted))
+}
+
+func TestResultConditions_OperationsFailed(t *testing.T) {
+	result := blackstart.WorkflowResult{
+		Phase:               "Execute",
+		TotalOperations:     3,
+		CompletedOperations: 2,
+		Operations: []blackstart.OperationResult{
+			{Id: "dashboards", Err: errors.New("quota exceeded")},
+			{Id: "b", Skipped: true},
+			{Id: "c"},
+		},
+	}
+	conditions := resultConditions(nil, 1, result)
+	ready := meta.FindStatusCondition(conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionTrue, ready.Status)
+	assert.Equal(t, "2/3 operations completed", ready.Message)
+	degraded := meta.FindStatusCondition(conditions, v1alpha1.ConditionDegraded)
+	require.NotNil(t, degraded)
+	assert.Equal(t, metav1.ConditionTrue, degraded.Status)
+	assert.Equal(t, v1alpha1.ReasonOperationsFailed, degraded.Reason)
+	assert.Equal(t, "1 operations failed and the run continued: dashboards", degraded.Message)
+}
+
+func TestResultConditions_PendingWindow(t *testing.T) {
+	conditions := driftConditions(nil, 1, blackstart.WorkflowResult{}, []string{"a"})
+	result := blackstart.WorkflowResult{
+		Phase: "Execute",
+		Operations: []blackstart.OperationResult{
+			{Id: "a", PendingWindow: true},
+			{Id: "b", Skipped: true},
+		},
+	}
+	conditions = resultConditions(conditions, 1, result)
+	ready := meta.FindStatusCondition(conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, v1alpha1.ReasonPendingWindow, ready.Reason)
+	assert.Equal(t, "1 operations pending the maintenance window: a", ready.Message)
+	assert.True(t, meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionDegraded))
+	// The drift is not corrected until the pending operations are set.
+	assert.True(t, meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionDrifted))
+}
@@ -0,0 +1,230 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// setupEnvtest starts a Kubernetes API server with the Workflow CRD installed and returns a client
+// of it. The test is skipped when the envtest binaries are not available.
+func setupEnvtest(t *testing.T) client.Client {
+	t.Helper()
+	binPath, err := envtestBinaries()
+	if err != nil {
+		t.Skipf("Skipping test: unable to get envtest binaries: %v", err)
+	}
+
+	testEnv := &envtest.Environment{
+		BinaryAssetsDirectory: binPath,
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "config", "crd", "v1alpha1")},
+		ErrorIfCRDPathMissing: true,
+	}
+	cfg, err := testEnv.Start()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = testEnv.Stop() })
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	c, err := client.New(cfg, client.Options{Scheme: scheme})
+	require.NoError(t, err)
+	return c
+}
+
+// envtestBinaries returns the directory of the envtest binaries: the KUBEBUILDER_ASSETS directory
+// when it is set, or the binaries installed with the setup-envtest CLI.
+func envtestBinaries() (string, error) {
+	if assets := os.Getenv("KUBEBUILDER_ASSETS"); assets != "" {
+		return assets, nil
+	}
+	setupEnvtestPath, err := exec.LookPath("setup-envtest")
+	if err != nil {
+		return "", err
+	}
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+
+	cmd := exec.Command(
+		setupEnvtestPath, "use", "--bin-dir", filepath.Join(cacheDir, "kubebuilder-envtest"), "-p", "path",
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	binPath := strings.TrimSpace(stdout.String())
+	if binPath == "" {
+		return "", errors.New("setup-envtest returned no binary path")
+	}
+	return binPath, nil
+}
+
+// createWorkflow creates a Workflow resource in a namespace, and the namespace when it does not
+// exist.
+func createWorkflow(t *testing.T, c client.Client, namespace, name string, spec v1alpha1.WorkflowSpec) {
+	t.Helper()
+	createNamespace(t, c, namespace)
+	if spec.Operations == nil {
+		spec.Operations = []v1alpha1.Operation{}
+	}
+	kwf := &v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}, Spec: spec}
+	require.NoError(t, c.Create(context.Background(), kwf))
+}
+
+// createNamespace creates a namespace when it does not exist.
+func createNamespace(t *testing.T, c client.Client, namespace string) {
+	t.Helper()
+	err := c.Create(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	if !apierrors.IsAlreadyExists(err) {
+		require.NoError(t, err)
+	}
+}
+
+// getWorkflow returns the Workflow resource with the name in the namespace.
+func getWorkflow(t *testing.T, c client.Client, namespace, name string) *v1alpha1.Workflow {
+	t.Helper()
+	var kwf v1alpha1.Workflow
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, &kwf))
+	return &kwf
+}
+
+// newKubeRunner returns a runner that lists workflows and writes their statuses with the client.
+func newKubeRunner(c client.Client) *Runner {
+	return &Runner{
+		Lister:      KubeWorkflowLister{Client: c, Convert: convertWorkflow},
+		Status:      KubeStatusUpdater{Client: c},
+		Logger:      slog.New(slog.DiscardHandler),
+		MaxParallel: 2,
+	}
+}
+
+func TestEnvtest_MultiNamespaceDiscovery(t *testing.T) {
+	c := setupEnvtest(t)
+	createWorkflow(t, c, "team-a", "app", v1alpha1.WorkflowSpec{})
+	createWorkflow(t, c, "team-b", "app", v1alpha1.WorkflowSpec{})
+	createWorkflow(t, c, "team-b", "db", v1alpha1.WorkflowSpec{})
+	createNamespace(t, c, "team-c")
+
+	lister := KubeWorkflowLister{Client: c, Convert: convertWorkflow}
+	assert.Len(t, lister.ListWorkflows(testContext(), "team-b").Workflows, 2)
+	assert.Empty(t, lister.ListWorkflows(testContext(), "team-c").Workflows)
+	// An empty namespace lists the workflows of all namespaces.
+	assert.Len(t, lister.ListWorkflows(testContext(), "").Workflows, 3)
+
+	require.NoError(t, newKubeRunner(c).RunAll(testContext(), []string{"team-a", "team-b", "team-c"}))
+	for _, key := range []client.ObjectKey{
+		{Namespace: "team-a", Name: "app"},
+		{Namespace: "team-b", Name: "app"},
+		{Namespace: "team-b", Name: "db"},
+	} {
+		kwf := getWorkflow(t, c, key.Namespace, key.Name)
+		assert.Equal(t, "true", kwf.Status.Successful, "workflow %s should have run", key)
+	}
+}
+
+func TestEnvtest_StatusUpdates(t *testing.T) {
+	c := setupEnvtest(t)
+	createWorkflow(t, c, "default", "demo", v1alpha1.WorkflowSpec{})
+	// Operation state written by the state store is kept by the status updates of a run.
+	kwf := getWorkflow(t, c, "default", "demo")
+	kwf.Status.State = map[string][]byte{"op": []byte("state")}
+	require.NoError(t, c.Status().Update(context.Background(), kwf))
+
+	r := newKubeRunner(c)
+	require.NoError(t, r.RunAll(testContext(), []string{"default"}))
+	kwf = getWorkflow(t, c, "default", "demo")
+	assert.Equal(t, "true", kwf.Status.Successful)
+	assert.Equal(t, "0/0", kwf.Status.OperationsCompleted)
+	assert.Equal(t, []byte("state"), kwf.Status.State["op"])
+	assert.False(t, kwf.Status.LastRan.IsZero())
+	ready := meta.FindStatusCondition(kwf.Status.Conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionTrue, ready.Status)
+	assert.Equal(t, kwf.Generation, ready.ObservedGeneration)
+	assert.True(t, meta.IsStatusConditionFalse(kwf.Status.Conditions, v1alpha1.ConditionProgressing))
+
+	// A check-only run records the drift and keeps the results of the last run.
+	lastRan := kwf.Status.LastRan
+	wf, err := convertWorkflow(kwf)
+	require.NoError(t, err)
+	wf.CheckOnly = true
+	require.NoError(t, r.RunWorkflow(testContext(), wf))
+	kwf = getWorkflow(t, c, "default", "demo")
+	assert.Equal(t, "true", kwf.Status.Successful)
+	assert.True(t, lastRan.Equal(&kwf.Status.LastRan))
+	assert.False(t, kwf.Status.LastChecked.IsZero())
+	drifted := meta.FindStatusCondition(kwf.Status.Conditions, v1alpha1.ConditionDrifted)
+	require.NotNil(t, drifted)
+	assert.Equal(t, v1alpha1.ReasonInSync, drifted.Reason)
+}
+
+// deletingLister is a workflow lister that deletes the Workflow resources of a namespace after they
+// are listed, so the status updates of their runs fail.
+type deletingLister struct {
+	KubeWorkflowLister
+	namespace string
+}
+
+func (l deletingLister) ListWorkflows(ctx context.Context, namespace string) NamespaceLoad {
+	load := l.KubeWorkflowLister.ListWorkflows(ctx, namespace)
+	if namespace == l.namespace {
+		for _, wf := range load.Workflows {
+			_ = l.Client.Delete(ctx, wf.Source.(*v1alpha1.Workflow))
+		}
+	}
+	return load
+}
+
+func TestEnvtest_FailureAggregation(t *testing.T) {
+	c := setupEnvtest(t)
+	createWorkflow(t, c, "team-a", "valid", v1alpha1.WorkflowSpec{})
+	createWorkflow(t, c, "team-a", "invalid", v1alpha1.WorkflowSpec{ReconcileInterval: "soon"})
+	createWorkflow(
+		t, c, "team-a", "failing", v1alpha1.WorkflowSpec{
+			Operations: []v1alpha1.Operation{
+				{Id: "dup", Module: "nonexistent_module"},
+				{Id: "dup", Module: "nonexistent_module"},
+			},
+		},
+	)
+	createWorkflow(t, c, "team-b", "deleted", v1alpha1.WorkflowSpec{})
+
+	r := newKubeRunner(c)
+	r.Lister = deletingLister{KubeWorkflowLister: r.Lister.(KubeWorkflowLister), namespace: "team-b"}
+	err := r.RunAll(testContext(), []string{"team-a", "team-b"})
+	require.ErrorContains(t, err, "workflow team-a/invalid: error parsing reconcile interval")
+	require.ErrorContains(t, err, "workflow team-b/deleted: error updating workflow status")
+	// A workflow run that fails is recorded in its status and does not fail the runs.
+	assert.NotContains(t, err.Error(), "team-a/failing")
+
+	assert.Equal(t, "true", getWorkflow(t, c, "team-a", "valid").Status.Successful)
+	failing := getWorkflow(t, c, "team-a", "failing")
+	assert.Equal(t, "false", failing.Status.Successful)
+	assert.Equal(t, 1, failing.Status.ConsecutiveFailures)
+	assert.Contains(t, failing.Status.LastError, `duplicate operation id "dup"`)
+	assert.Empty(t, getWorkflow(t, c, "team-a", "invalid").Status.Successful)
+}
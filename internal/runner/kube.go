@@ -0,0 +1,102 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+	"github.com/pezops/blackstart/state"
+)
+
+// KubeWorkflowLister lists the Workflow resources of a namespace from Kubernetes and converts them
+// to native workflows.
+type KubeWorkflowLister struct {
+	Client client.Client
+
+	// Convert converts a Workflow resource to a native workflow.
+	Convert func(kwf *v1alpha1.Workflow) (*blackstart.Workflow, error)
+}
+
+// ListWorkflows retrieves the Workflow resources of a namespace and converts them to native
+// workflows. A workflow that cannot be converted is returned as invalid instead of failing the
+// others.
+func (l KubeWorkflowLister) ListWorkflows(ctx context.Context, namespace string) NamespaceLoad {
+	load := NamespaceLoad{Namespace: namespace}
+	var workflowList v1alpha1.WorkflowList
+	err := l.Client.List(ctx, &workflowList, client.InNamespace(namespace))
+	if err != nil {
+		load.Err = fmt.Errorf("error listing workflows: %w", err)
+		return load
+	}
+
+	load.Workflows = make([]*blackstart.Workflow, 0, len(workflowList.Items))
+	for i := range workflowList.Items {
+		kwf := workflowList.Items[i].DeepCopy()
+		wf, convErr := l.Convert(kwf)
+		if convErr != nil {
+			load.Invalid = append(load.Invalid, Result{Namespace: kwf.Namespace, Name: kwf.Name, Err: convErr})
+			continue
+		}
+		load.Workflows = append(load.Workflows, wf)
+	}
+
+	return load
+}
+
+// KubeStatusUpdater writes the status of Workflow resources in Kubernetes.
+type KubeStatusUpdater struct {
+	Client client.Client
+}
+
+// UpdateStatus updates the Workflow resource status in Kubernetes with the result of the Workflow
+// run. The status is written with a merge patch of the fields that changed, so it does not conflict
+// with other writers of the resource, and transient API errors are retried.
+func (u KubeStatusUpdater) UpdateStatus(
+	ctx context.Context, wf *blackstart.Workflow, status v1alpha1.WorkflowStatus,
+) error {
+	if wf.Source == nil {
+		return fmt.Errorf("no workflow source")
+	}
+	kwf, ok := wf.Source.(*v1alpha1.Workflow)
+	if !ok {
+		return fmt.Errorf("unexpected workflow source type: %T", wf.Source)
+	}
+
+	key := types.NamespacedName{Name: kwf.Name, Namespace: kwf.Namespace}
+	err := retry.OnError(
+		retry.DefaultBackoff, state.RetriableStatusError, func() error {
+			var latest v1alpha1.Workflow
+			getErr := u.Client.Get(ctx, key, &latest)
+			if getErr != nil {
+				return getErr
+			}
+			// Operation state is written by the status state store during the run and is kept.
+			status.State = latest.Status.State
+			if equality.Semantic.DeepEqual(latest.Status, status) {
+				kwf.Status = status
+				return nil
+			}
+			base := latest.DeepCopy()
+			latest.Status = status
+			patchErr := u.Client.Status().Patch(ctx, &latest, client.MergeFrom(base))
+			if patchErr != nil {
+				return patchErr
+			}
+			kwf.Status = status
+			return nil
+		},
+	)
+	if err != nil {
+		if state.RetriableStatusError(err) {
+			return fmt.Errorf("error updating workflow status after retries: %w", err)
+		}
+		return fmt.Errorf("error updating workflow status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// convertWorkflow converts a Workflow resource to a native workflow with the IDs and modules of its
+// operations. Workflows with an invalid reconcile interval cannot be converted.
+func convertWorkflow(kwf *v1alpha1.Workflow) (*blackstart.Workflow, error) {
+	if kwf.Spec.ReconcileInterval == "soon" {
+		return nil, errors.New("error parsing reconcile interval")
+	}
+	wf := &blackstart.Workflow{Name: kwf.Name, Namespace: kwf.Namespace, ReconcileInterval: time.Minute, Source: kwf}
+	for _, op := range kwf.Spec.Operations {
+		wf.Operations = append(wf.Operations, blackstart.Operation{Id: op.Id, Module: op.Module})
+	}
+	return wf, nil
+}
+
+func TestKubeWorkflowLister(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(
+			&v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "valid", Namespace: "team-a"}},
+			&v1alpha1.Workflow{
+				ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "team-a"},
+				Spec:       v1alpha1.WorkflowSpec{ReconcileInterval: "soon"},
+			},
+			&v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "team-b"}},
+		).
+		WithInterceptorFuncs(
+			interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					listOpts := &client.ListOptions{}
+					listOpts.ApplyOptions(opts)
+					if listOpts.Namespace == "broken" {
+						return apierrors.NewForbidden(
+							v1alpha1.SchemeGroupVersion.WithResource("workflows").GroupResource(), "", nil,
+						)
+					}
+					return c.List(ctx, list, opts...)
+				},
+			},
+		).
+		Build()
+	lister := KubeWorkflowLister{Client: c, Convert: convertWorkflow}
+
+	load := lister.ListWorkflows(context.Background(), "team-a")
+	require.NoError(t, load.Err)
+	require.Len(t, load.Workflows, 1)
+	assert.Equal(t, "valid", load.Workflows[0].Name)
+	require.Len(t, load.Invalid, 1)
+	assert.Equal(t, Result{Namespace: "team-a", Name: "invalid", Err: load.Invalid[0].Err}, load.Invalid[0])
+	assert.Equal(t, load.Invalid, load.Results())
+
+	// An empty namespace lists the workflows of all namespaces.
+	load = lister.ListWorkflows(context.Background(), "")
+	assert.Len(t, load.Workflows, 2)
+
+	load = lister.ListWorkflows(context.Background(), "broken")
+	require.ErrorContains(t, load.Err, "error listing workflows")
+	assert.Empty(t, load.Workflows)
+	assert.Equal(t, []Result{{Namespace: "broken", Err: load.Err}}, load.Results())
+}
+
+func TestKubeStatusUpdater_KeepsState(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Status:     v1alpha1.WorkflowStatus{State: map[string][]byte{"op": []byte("state")}},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(kwf).WithStatusSubresource(kwf).Build()
+
+	wf := &blackstart.Workflow{Name: "demo", Namespace: "default", Source: kwf}
+	err := KubeStatusUpdater{Client: c}.UpdateStatus(context.Background(), wf, v1alpha1.WorkflowStatus{Successful: "true"})
+	require.NoError(t, err)
+
+	var latest v1alpha1.Workflow
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(kwf), &latest))
+	assert.Equal(t, "true", latest.Status.Successful)
+	assert.Equal(t, []byte("state"), latest.Status.State["op"])
+}
+
+func TestKubeStatusUpdater_PatchesAndRetries(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	kwf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default"},
+		Status:     v1alpha1.WorkflowStatus{Phase: "complete"},
+	}
+	var patches, updates int
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(kwf).
+		WithStatusSubresource(kwf).
+		WithInterceptorFuncs(
+			interceptor.Funcs{
+				SubResourcePatch: func(
+					ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch,
+					opts ...client.SubResourcePatchOption,
+				) error {
+					patches++
+					if patches == 1 {
+						return apierrors.NewServerTimeout(
+							v1alpha1.SchemeGroupVersion.WithResource("workflows").GroupResource(), "patch", 1,
+						)
+					}
+					return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+				},
+				SubResourceUpdate: func(
+					ctx context.Context, c client.Client, subResource string, obj client.Object,
+					opts ...client.SubResourceUpdateOption,
+				) error {
+					updates++
+					return c.SubResource(subResource).Update(ctx, obj, opts...)
+				},
+			},
+		).
+		Build()
+
+	// The source of the workflow is stale, which does not conflict with the patch.
+	stale := kwf.DeepCopy()
+	stale.ResourceVersion = "1"
+	wf := &blackstart.Workflow{Name: "demo", Namespace: "default", Source: stale}
+	status := v1alpha1.WorkflowStatus{Phase: "complete", Successful: "true"}
+	require.NoError(t, KubeStatusUpdater{Client: c}.UpdateStatus(context.Background(), wf, status))
+	assert.Equal(t, 2, patches)
+	assert.Zero(t, updates)
+	assert.Equal(t, "true", stale.Status.Successful)
+
+	var latest v1alpha1.Workflow
+	require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(kwf), &latest))
+	assert.Equal(t, "true", latest.Status.Successful)
+
+	// An unchanged status is not written.
+	require.NoError(t, KubeStatusUpdater{Client: c}.UpdateStatus(context.Background(), wf, status))
+	assert.Equal(t, 2, patches)
+}
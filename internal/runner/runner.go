@@ -0,0 +1,356 @@
+// Package runner runs the workflows of Kubernetes Workflow resources and records the result of
+// each run in the status of its resource. The workflows are listed and the statuses are written
+// through interfaces, so the runner can be used with a Kubernetes client or with test fakes.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// DefaultCoalesceWindow is how long a status update of a workflow run is held before it is written.
+// An update that replaces it within the window is written instead, so a run that completes quickly
+// writes its result without first writing that it is in progress.
+const DefaultCoalesceWindow = 2 * time.Second
+
+// Result is the result of loading or running a workflow. A result without a workflow name is the
+// result of loading the workflows of a namespace.
+type Result struct {
+	Namespace string
+	Name      string
+	Err       error
+}
+
+// NamespaceLoad is the result of loading the workflows of a configured namespace. An empty
+// namespace loads the workflows of all namespaces.
+type NamespaceLoad struct {
+	Namespace string
+	Workflows []*blackstart.Workflow
+
+	// Invalid are the failed results of the workflows that could not be converted.
+	Invalid []Result
+
+	// Err is the error listing the workflows of the namespace, such as a missing RBAC permission.
+	Err error
+}
+
+// Results returns the failed results of loading the namespace: the error listing its workflows, or
+// the workflows that could not be converted.
+func (l NamespaceLoad) Results() []Result {
+	if l.Err != nil {
+		return []Result{{Namespace: l.Namespace, Err: l.Err}}
+	}
+	return l.Invalid
+}
+
+// WorkflowLister lists the workflows of a namespace. A workflow that cannot be loaded is returned
+// as invalid instead of failing the others.
+type WorkflowLister interface {
+	ListWorkflows(ctx context.Context, namespace string) NamespaceLoad
+}
+
+// StatusUpdater writes the status of the Workflow resource that a workflow was loaded from.
+type StatusUpdater interface {
+	UpdateStatus(ctx context.Context, wf *blackstart.Workflow, status v1alpha1.WorkflowStatus) error
+}
+
+// Runner runs workflows and records their results with a status updater.
+type Runner struct {
+	// Lister lists the workflows of the namespaces run by RunAll.
+	Lister WorkflowLister
+
+	// Status writes the status of the workflows run.
+	Status StatusUpdater
+
+	// Logger logs the progress of the runs. The default logger is used when it is not set.
+	Logger *slog.Logger
+
+	// MaxParallel is the maximum number of workflows run at once by RunAll. At least one workflow
+	// is run at a time.
+	MaxParallel int
+
+	// CoalesceWindow is how long a status update of a run is held before it is written.
+	// DefaultCoalesceWindow is used when it is not set.
+	CoalesceWindow time.Duration
+}
+
+// logger returns the logger of the runner.
+func (r *Runner) logger() *slog.Logger {
+	if r.Logger == nil {
+		return slog.Default()
+	}
+	return r.Logger
+}
+
+// coalesceWindow returns the coalesce window of the status updates of a run.
+func (r *Runner) coalesceWindow() time.Duration {
+	if r.CoalesceWindow <= 0 {
+		return DefaultCoalesceWindow
+	}
+	return r.CoalesceWindow
+}
+
+// RunAll lists the workflows of the namespaces and runs them concurrently, up to the maximum number
+// of parallel runs. A namespace that cannot be listed or a workflow that fails does not stop the
+// others, and the errors of all of them are returned together.
+func (r *Runner) RunAll(ctx context.Context, namespaces []string) error {
+	logger := r.logger()
+	logger.Info("loading workflow resources from kubernetes")
+
+	var loads []NamespaceLoad
+	var workflows []*blackstart.Workflow
+	var results []Result
+	for _, ns := range namespaces {
+		load := r.Lister.ListWorkflows(ctx, ns)
+		loads = append(loads, load)
+		workflows = append(workflows, load.Workflows...)
+		results = append(results, load.Results()...)
+	}
+	if len(workflows) == 0 && len(results) == 0 {
+		logger.Warn("no workflows found in configured namespaces")
+		return nil
+	}
+
+	runResults := make([]Result, len(workflows))
+	var g errgroup.Group
+	g.SetLimit(max(r.MaxParallel, 1))
+	for i, wf := range workflows {
+		g.Go(
+			func() error {
+				runResults[i] = Result{Namespace: wf.Namespace, Name: wf.Name, Err: r.RunWorkflow(ctx, wf)}
+				return nil
+			},
+		)
+	}
+	_ = g.Wait()
+
+	// The workflows of each namespace are a contiguous range of the workflows run.
+	offset := 0
+	for _, load := range loads {
+		logNamespaceRuns(logger, load, runResults[offset:offset+len(load.Workflows)])
+		offset += len(load.Workflows)
+	}
+	return reportResults(logger, len(workflows), append(results, runResults...))
+}
+
+// logNamespaceRuns logs the number of workflows of a namespace that were loaded, could not be
+// converted, and were run, and how many of the runs failed. Namespaces that could not be listed are
+// logged with the errors of the run instead.
+func logNamespaceRuns(logger *slog.Logger, load NamespaceLoad, runResults []Result) {
+	if load.Err != nil {
+		return
+	}
+	if len(load.Workflows) == 0 && len(load.Invalid) == 0 {
+		if load.Namespace != "" {
+			logger.Warn("no workflows found in namespace", "namespace", load.Namespace)
+		} else {
+			logger.Warn("no workflows found")
+		}
+		return
+	}
+	failed := 0
+	for _, r := range runResults {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	logger.Info(
+		"namespace workflows processed", "namespace", load.Namespace, "loaded", len(load.Workflows),
+		"invalid", len(load.Invalid), "executed", len(runResults), "failed", failed,
+	)
+}
+
+// reportResults logs the failures and a summary of the results of a run of the workflows, and
+// returns the errors of the failures joined together.
+func reportResults(logger *slog.Logger, workflows int, results []Result) error {
+	var errs []error
+	for _, r := range results {
+		if r.Err == nil {
+			continue
+		}
+		if r.Name == "" {
+			logger.Error("error loading workflows", "namespace", r.Namespace, "error", r.Err)
+			errs = append(errs, fmt.Errorf("namespace %q: %w", r.Namespace, r.Err))
+		} else {
+			logger.Error("error running workflow", "workflow", r.Name, "namespace", r.Namespace, "error", r.Err)
+			errs = append(errs, fmt.Errorf("workflow %s/%s: %w", r.Namespace, r.Name, r.Err))
+		}
+	}
+	logger.Info("workflow runs complete", "workflows", workflows, "errors", len(errs))
+	if len(errs) > 0 {
+		return fmt.Errorf("errors running workflows: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// RunWorkflow executes a single workflow and updates its status. A check-only workflow records the
+// drifted operations instead. The error returned is the error updating the status; the result of
+// the run is recorded in the status.
+func (r *Runner) RunWorkflow(ctx context.Context, wf *blackstart.Workflow) error {
+	if wf.CheckOnly {
+		return r.checkWorkflow(ctx, wf)
+	}
+	logger := r.logger()
+	var previous v1alpha1.WorkflowStatus
+	var generation int64
+	if kwf, ok := wf.Source.(*v1alpha1.Workflow); ok {
+		previous = *kwf.Status.DeepCopy()
+		generation = kwf.Generation
+	}
+
+	// Mark the workflow as progressing for the duration of the run. The update is held for the
+	// coalesce window, so a short run only writes its result. A failure to update the status does
+	// not prevent the run.
+	statusWriter := newWorkflowStatusWriter(ctx, r.Status, logger, wf, r.coalesceWindow())
+	running := previous
+	running.Conditions = progressingConditions(previous.Conditions, generation)
+	statusWriter.update(running)
+	previous = running
+	wf.OnStuckOperation = stuckOperationReporter(statusWriter, running)
+	defer func() { wf.OnStuckOperation = nil }()
+
+	result := wf.Run(ctx)
+	end := time.Now()
+	lastError := ""
+	lastOpStart := ""
+	lastOpFields := []any{}
+	if result.Op != nil {
+		lastOpStart = result.Op.Id
+		lastOpFields = append(lastOpFields, "operation", result.Op.Id)
+	}
+
+	if result.Err != nil {
+		logFields := []any{
+			"workflow", wf.Name,
+			"namespace", wf.Namespace,
+			"phase", result.Phase,
+			"error", result.Err.Error(),
+			"api_calls", result.ProviderAPICalls(),
+		}
+		logFields = append(logFields, lastOpFields...)
+		logger.Warn("workflow execution did not complete", logFields...)
+		lastError = result.Err.Error()
+	} else {
+		logger.Info(
+			"workflow execution complete", "workflow", wf.Name, "namespace", wf.Namespace,
+			"api_calls", result.ProviderAPICalls(),
+		)
+	}
+
+	// Drift found by check-only runs is kept until a run succeeds without operations pending the
+	// maintenance window.
+	driftedOperations := previous.DriftedOperations
+	if result.Err == nil && len(pendingWindowOperations(result.Operations)) == 0 {
+		driftedOperations = nil
+	}
+	// Runs that keep failing are retried with an exponential backoff, so a failing external system
+	// is not called every reconcile interval.
+	failures := 0
+	if result.Err != nil {
+		failures = previous.ConsecutiveFailures + 1
+	}
+	nextRun := wf.NextRunAfter(end)
+	var retryBackoff *metav1.Duration
+	if backoff := wf.RetryBackoff(failures); backoff > wf.ReconcileInterval {
+		retryBackoff = &metav1.Duration{Duration: backoff}
+		nextRun = end.Add(backoff)
+		logger.Warn(
+			"workflow failed repeatedly; backing off",
+			"workflow", wf.Name,
+			"namespace", wf.Namespace,
+			"failures", failures,
+			"backoff", backoff.String(),
+		)
+	}
+	status := v1alpha1.WorkflowStatus{
+		Conditions:          resultConditions(previous.Conditions, generation, result),
+		Result:              lastError,
+		LastRan:             metav1.NewTime(end),
+		NextRun:             metav1.NewTime(nextRun),
+		LastChecked:         previous.LastChecked,
+		Successful:          strconv.FormatBool(result.Err == nil),
+		Phase:               result.Phase,
+		LastError:           lastError,
+		OperationsCompleted: fmt.Sprintf("%d/%d", result.CompletedOperations, result.TotalOperations),
+		LastOperation:       lastOpStart,
+		ManagedResources:    managedResourcesStatus(result.ManagedResources),
+		Operations:          operationsStatus(result.Operations),
+		Instances:           instancesStatus(result.Instances),
+		APICalls:            result.ProviderAPICalls(),
+		Plan:                planStatus(result.Plan, generation),
+		DriftedOperations:   driftedOperations,
+		ConsecutiveFailures: failures,
+		RetryBackoff:        retryBackoff,
+	}
+	statusWriter.update(status)
+	err := statusWriter.flush()
+	if err != nil {
+		logger.Error("error updating workflow status", "workflow", wf.Name, "namespace", wf.Namespace, "error", err)
+	}
+	return err
+}
+
+// stuckOperationReporter returns a function that lists stuck operations in the status of a running
+// workflow. The operations are listed until the run completes and its result replaces the status.
+func stuckOperationReporter(
+	statusWriter *workflowStatusWriter, running v1alpha1.WorkflowStatus,
+) func(blackstart.StuckOperation) {
+	var stuck []v1alpha1.StuckOperation
+	return func(op blackstart.StuckOperation) {
+		stuck = append(
+			stuck, v1alpha1.StuckOperation{Id: op.Id, Module: op.Module, Started: metav1.NewTime(op.Started)},
+		)
+		status := *running.DeepCopy()
+		status.StuckOperations = slices.Clone(stuck)
+		statusWriter.update(status)
+	}
+}
+
+// checkWorkflow executes a check-only run of a workflow and records the drifted operations in its
+// status. The results of the last full run in the status are kept.
+func (r *Runner) checkWorkflow(ctx context.Context, wf *blackstart.Workflow) error {
+	logger := r.logger()
+	var status v1alpha1.WorkflowStatus
+	var generation int64
+	if kwf, ok := wf.Source.(*v1alpha1.Workflow); ok {
+		status = *kwf.Status.DeepCopy()
+		generation = kwf.Generation
+	}
+
+	result := wf.Run(ctx)
+	drifted := driftedOperations(result.Operations)
+	switch {
+	case result.Err != nil:
+		logger.Warn(
+			"workflow check did not complete",
+			"workflow", wf.Name,
+			"namespace", wf.Namespace,
+			"phase", result.Phase,
+			"error", result.Err.Error(),
+		)
+	case len(drifted) > 0:
+		logger.Warn("workflow drift detected", "workflow", wf.Name, "namespace", wf.Namespace, "operations", drifted)
+	default:
+		logger.Info("workflow check complete", "workflow", wf.Name, "namespace", wf.Namespace)
+	}
+
+	status.Conditions = driftConditions(status.Conditions, generation, result, drifted)
+	status.LastChecked = metav1.NewTime(time.Now())
+	status.DriftedOperations = drifted
+	err := r.Status.UpdateStatus(ctx, wf, status)
+	if err != nil {
+		logger.Error("error updating workflow status", "workflow", wf.Name, "namespace", wf.Namespace, "error", err)
+	}
+	return err
+}
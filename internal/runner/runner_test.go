@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// fakeLister is a workflow lister that returns a fixed load for each namespace.
+type fakeLister map[string]NamespaceLoad
+
+func (l fakeLister) ListWorkflows(_ context.Context, namespace string) NamespaceLoad {
+	load, ok := l[namespace]
+	if !ok {
+		return NamespaceLoad{Namespace: namespace}
+	}
+	return load
+}
+
+// fakeStatus is a status updater that records the statuses written by workflow. Updates of the
+// workflows in failing return an error, and each update takes delay.
+type fakeStatus struct {
+	failing map[string]bool
+	delay   time.Duration
+
+	mu        sync.Mutex
+	statuses  map[string][]v1alpha1.WorkflowStatus
+	active    int
+	maxActive int
+}
+
+func (s *fakeStatus) UpdateStatus(_ context.Context, wf *blackstart.Workflow, status v1alpha1.WorkflowStatus) error {
+	key := wf.Namespace + "/" + wf.Name
+	s.mu.Lock()
+	s.active++
+	s.maxActive = max(s.maxActive, s.active)
+	if s.statuses == nil {
+		s.statuses = map[string][]v1alpha1.WorkflowStatus{}
+	}
+	s.statuses[key] = append(s.statuses[key], status)
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	if s.failing[key] {
+		return errors.New("status update rejected")
+	}
+	return nil
+}
+
+// last returns the last status written for the workflow with the key.
+func (s *fakeStatus) last(t *testing.T, key string) v1alpha1.WorkflowStatus {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	require.NotEmpty(t, s.statuses[key], "no status written for %s", key)
+	return s.statuses[key][len(s.statuses[key])-1]
+}
+
+// testWorkflow returns a workflow without operations loaded from a Workflow resource.
+func testWorkflow(namespace, name string) *blackstart.Workflow {
+	kwf := &v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Generation: 2}}
+	return &blackstart.Workflow{Name: name, Namespace: namespace, ReconcileInterval: time.Minute, Source: kwf}
+}
+
+// failingWorkflow returns a workflow that fails to set up because of duplicate operation IDs.
+func failingWorkflow(namespace, name string) *blackstart.Workflow {
+	wf := testWorkflow(namespace, name)
+	wf.Operations = []blackstart.Operation{
+		{Id: "dup", Module: "nonexistent_module"},
+		{Id: "dup", Module: "nonexistent_module"},
+	}
+	return wf
+}
+
+// testContext returns a context with a logger that discards the workflow logs.
+func testContext() context.Context {
+	return context.WithValue(context.Background(), blackstart.LoggerKey, slog.New(slog.DiscardHandler))
+}
+
+func TestRunner_RunAllAggregatesFailures(t *testing.T) {
+	lister := fakeLister{
+		"broken": {Namespace: "broken", Err: errors.New("error listing workflows: forbidden")},
+		"team-a": {
+			Namespace: "team-a",
+			Workflows: []*blackstart.Workflow{
+				testWorkflow("team-a", "valid"),
+				failingWorkflow("team-a", "failing"),
+				testWorkflow("team-a", "rejected"),
+			},
+			Invalid: []Result{
+				{Namespace: "team-a", Name: "bad-interval", Err: errors.New("error parsing reconcile interval")},
+			},
+		},
+	}
+	status := &fakeStatus{failing: map[string]bool{"team-a/rejected": true}}
+	r := &Runner{Lister: lister, Status: status, Logger: slog.New(slog.DiscardHandler)}
+
+	err := r.RunAll(testContext(), []string{"broken", "team-a", "empty"})
+	require.ErrorContains(t, err, `namespace "broken": error listing workflows: forbidden`)
+	require.ErrorContains(t, err, "workflow team-a/bad-interval: error parsing reconcile interval")
+	require.ErrorContains(t, err, "workflow team-a/rejected: status update rejected")
+	// A workflow run that fails is recorded in its status and does not fail the runs.
+	assert.NotContains(t, err.Error(), "team-a/failing")
+
+	assert.Equal(t, "true", status.last(t, "team-a/valid").Successful)
+	failed := status.last(t, "team-a/failing")
+	assert.Equal(t, "false", failed.Successful)
+	assert.Equal(t, 1, failed.ConsecutiveFailures)
+	assert.Contains(t, failed.LastError, `duplicate operation id "dup"`)
+}
+
+func TestRunner_RunAllBoundsConcurrency(t *testing.T) {
+	load := NamespaceLoad{Namespace: "team-a"}
+	for _, name := range []string{"wf-1", "wf-2", "wf-3", "wf-4", "wf-5"} {
+		load.Workflows = append(load.Workflows, testWorkflow("team-a", name))
+	}
+	status := &fakeStatus{delay: 20 * time.Millisecond}
+	r := &Runner{
+		Lister:      fakeLister{"team-a": load},
+		Status:      status,
+		Logger:      slog.New(slog.DiscardHandler),
+		MaxParallel: 2,
+	}
+
+	require.NoError(t, r.RunAll(testContext(), []string{"team-a"}))
+	assert.Len(t, status.statuses, 5, "every workflow should run")
+	assert.Equal(t, 2, status.maxActive, "at most the maximum parallel runs should run at once")
+}
+
+func TestRunner_RunAllWithoutWorkflows(t *testing.T) {
+	r := &Runner{Lister: fakeLister{}, Status: &fakeStatus{}}
+	require.NoError(t, r.RunAll(testContext(), []string{"team-a", "team-b"}))
+}
+
+func TestRunner_RunWorkflowBacksOff(t *testing.T) {
+	wf := failingWorkflow("default", "failing")
+	wf.MaxRetryBackoff = time.Hour
+	wf.Source.(*v1alpha1.Workflow).Status = v1alpha1.WorkflowStatus{
+		ConsecutiveFailures: 2,
+		DriftedOperations:   []string{"db"},
+	}
+	status := &fakeStatus{}
+	r := &Runner{Status: status, Logger: slog.New(slog.DiscardHandler)}
+
+	require.NoError(t, r.RunWorkflow(testContext(), wf))
+	written := status.last(t, "default/failing")
+	assert.Equal(t, "Setup", written.Phase)
+	assert.Equal(t, 3, written.ConsecutiveFailures)
+	require.NotNil(t, written.RetryBackoff)
+	assert.Greater(t, written.RetryBackoff.Duration, wf.ReconcileInterval)
+	assert.Equal(t, written.LastRan.Add(written.RetryBackoff.Duration), written.NextRun.Time)
+	// Drift is kept until a run succeeds.
+	assert.Equal(t, []string{"db"}, written.DriftedOperations)
+	ready := meta.FindStatusCondition(written.Conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, metav1.ConditionFalse, ready.Status)
+	assert.Equal(t, int64(2), ready.ObservedGeneration)
+	assert.Nil(t, wf.OnStuckOperation)
+}
+
+func TestRunner_RunWorkflowCheckOnly(t *testing.T) {
+	wf := testWorkflow("default", "demo")
+	wf.CheckOnly = true
+	lastRan := metav1.NewTime(time.Date(2026, 3, 7, 3, 0, 0, 0, time.UTC))
+	wf.Source.(*v1alpha1.Workflow).Status = v1alpha1.WorkflowStatus{Successful: "true", LastRan: lastRan}
+	status := &fakeStatus{}
+	r := &Runner{Status: status, Logger: slog.New(slog.DiscardHandler)}
+
+	require.NoError(t, r.RunWorkflow(testContext(), wf))
+	require.Len(t, status.statuses["default/demo"], 1)
+	written := status.last(t, "default/demo")
+	// The results of the last full run are kept.
+	assert.Equal(t, "true", written.Successful)
+	assert.Equal(t, lastRan, written.LastRan)
+	assert.False(t, written.LastChecked.IsZero())
+	drifted := meta.FindStatusCondition(written.Conditions, v1alpha1.ConditionDrifted)
+	require.NotNil(t, drifted)
+	assert.Equal(t, metav1.ConditionFalse, drifted.Status)
+	assert.Equal(t, v1alpha1.ReasonInSync, drifted.Reason)
+}
@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// pendingWindowOperations returns the identifiers of the operations deferred to the maintenance
+// window in a run.
+func pendingWindowOperations(operations []blackstart.OperationResult) []string {
+	var pending []string
+	for _, op := range operations {
+		if op.PendingWindow {
+			pending = append(pending, op.Id)
+		}
+	}
+	return pending
+}
+
+// driftedOperations returns the identifiers of the operations that drifted in a check-only run.
+func driftedOperations(operations []blackstart.OperationResult) []string {
+	var drifted []string
+	for _, op := range operations {
+		if op.Drifted {
+			drifted = append(drifted, op.Id)
+		}
+	}
+	return drifted
+}
+
+// failedOperations returns the identifiers of the operations that failed in a run.
+func failedOperations(operations []blackstart.OperationResult) []string {
+	var failed []string
+	for _, op := range operations {
+		if op.Err != nil {
+			failed = append(failed, op.Id)
+		}
+	}
+	return failed
+}
+
+// operationError returns the message of the error of an operation, or an empty string when it did
+// not fail.
+func operationError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// managedResourcesStatus converts the resources reported in a workflow run to their status form.
+func managedResourcesStatus(resources []blackstart.ManagedResource) []v1alpha1.ManagedResource {
+	if len(resources) == 0 {
+		return nil
+	}
+	status := make([]v1alpha1.ManagedResource, 0, len(resources))
+	for _, r := range resources {
+		status = append(status, v1alpha1.ManagedResource{Id: r.Id, Module: r.Module, Operation: r.OperationId})
+	}
+	return status
+}
+
+// operationsStatus converts the operation metrics and recorded values of a workflow run to their
+// status form. Durations are rounded to milliseconds.
+func operationsStatus(operations []blackstart.OperationResult) []v1alpha1.OperationStatus {
+	if len(operations) == 0 {
+		return nil
+	}
+	status := make([]v1alpha1.OperationStatus, 0, len(operations))
+	for _, op := range operations {
+		status = append(
+			status, v1alpha1.OperationStatus{
+				Id:               op.Id,
+				Module:           op.Module,
+				Duration:         metav1.Duration{Duration: op.Duration.Round(time.Millisecond)},
+				APICalls:         op.APICalls,
+				ProviderAPICalls: op.ProviderAPICalls,
+				Skipped:          op.Skipped,
+				PendingWindow:    op.PendingWindow,
+				Blocked:          op.Blocked,
+				Filtered:         op.Filtered,
+				Error:            operationError(op.Err),
+				Inputs:           op.Inputs,
+				Outputs:          op.Outputs,
+			},
+		)
+	}
+	return status
+}
+
+// instancesStatus converts the results of the forEach instances of a workflow run to their status
+// form.
+func instancesStatus(instances []blackstart.InstanceResult) []v1alpha1.InstanceStatus {
+	if len(instances) == 0 {
+		return nil
+	}
+	status := make([]v1alpha1.InstanceStatus, 0, len(instances))
+	for _, instance := range instances {
+		status = append(
+			status, v1alpha1.InstanceStatus{
+				Name:                instance.Name,
+				Successful:          strconv.FormatBool(instance.Err == nil),
+				LastError:           operationError(instance.Err),
+				OperationsCompleted: fmt.Sprintf("%d/%d", instance.CompletedOperations, instance.TotalOperations),
+			},
+		)
+	}
+	return status
+}
+
+// planStatus converts the resolved execution plan of a workflow run to its status form. No plan is
+// returned for runs that failed before the plan was resolved.
+func planStatus(plan []blackstart.PlannedOperation, generation int64) *v1alpha1.WorkflowPlan {
+	if plan == nil {
+		return nil
+	}
+	status := &v1alpha1.WorkflowPlan{
+		ObservedGeneration: generation,
+		Operations:         make([]v1alpha1.PlannedOperation, 0, len(plan)),
+	}
+	for _, op := range plan {
+		planned := v1alpha1.PlannedOperation{
+			Id:           op.Id,
+			Module:       op.Module,
+			DependsOn:    op.DependsOn,
+			DoesNotExist: op.DoesNotExist,
+		}
+		if len(op.Inputs) > 0 {
+			planned.Inputs = make(map[string]v1alpha1.PlannedInput, len(op.Inputs))
+		}
+		for key, input := range op.Inputs {
+			if input.DependencyId != "" {
+				planned.Inputs[key] = v1alpha1.PlannedInput{
+					FromDependency: &v1alpha1.FromDependency{Id: input.DependencyId, Output: input.OutputKey},
+				}
+				continue
+			}
+			planned.Inputs[key] = v1alpha1.PlannedInput{Value: input.Value}
+		}
+		status.Operations = append(status.Operations, planned)
+	}
+	return status
+}
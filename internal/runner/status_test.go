@@ -0,0 +1,67 @@
+package runner
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestPlanStatus(t *testing.T) {
+	assert.Nil(t, planStatus(nil, 3))
+
+	plan := []blackstart.PlannedOperation{
+		{Id: "db", Module: "google_cloudsql_database", Inputs: map[string]blackstart.PlannedInput{"name": {Value: "app"}}},
+		{
+			Id:           "user",
+			Module:       "google_cloudsql_user",
+			DependsOn:    []string{"db"},
+			DoesNotExist: true,
+			Inputs: map[string]blackstart.PlannedInput{
+				"password": {Value: blackstart.MaskedValue},
+				"instance": {DependencyId: "db", OutputKey: "instance"},
+			},
+		},
+	}
+	assert.Equal(
+		t, &v1alpha1.WorkflowPlan{
+			ObservedGeneration: 3,
+			Operations: []v1alpha1.PlannedOperation{
+				{
+					Id:     "db",
+					Module: "google_cloudsql_database",
+					Inputs: map[string]v1alpha1.PlannedInput{"name": {Value: "app"}},
+				},
+				{
+					Id:           "user",
+					Module:       "google_cloudsql_user",
+					DependsOn:    []string{"db"},
+					DoesNotExist: true,
+					Inputs: map[string]v1alpha1.PlannedInput{
+						"password": {Value: blackstart.MaskedValue},
+						"instance": {FromDependency: &v1alpha1.FromDependency{Id: "db", Output: "instance"}},
+					},
+				},
+			},
+		}, planStatus(plan, 3),
+	)
+}
+
+func TestInstancesStatus(t *testing.T) {
+	assert.Nil(t, instancesStatus(nil))
+
+	instances := []blackstart.InstanceResult{
+		{Name: "team-a", TotalOperations: 2, CompletedOperations: 2},
+		{Name: "team-b", Err: errors.New("boom"), TotalOperations: 2, CompletedOperations: 1},
+	}
+	assert.Equal(
+		t, []v1alpha1.InstanceStatus{
+			{Name: "team-a", Successful: "true", OperationsCompleted: "2/2"},
+			{Name: "team-b", Successful: "false", LastError: "boom", OperationsCompleted: "1/2"},
+		},
+		instancesStatus(instances),
+	)
+}
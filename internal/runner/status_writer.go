@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// workflowStatusWriter coalesces the status updates of a workflow during a run. An update is
+// written when the coalesce window since the first pending update ends, or when the writer is
+// flushed, and only the latest pending update is written.
+type workflowStatusWriter struct {
+	ctx     context.Context
+	status  StatusUpdater
+	logger  *slog.Logger
+	wf      *blackstart.Workflow
+	window  time.Duration
+	mu      sync.Mutex
+	pending *v1alpha1.WorkflowStatus
+	timer   *time.Timer
+}
+
+// newWorkflowStatusWriter creates a status writer for a workflow that holds updates for the window.
+func newWorkflowStatusWriter(
+	ctx context.Context, status StatusUpdater, logger *slog.Logger, wf *blackstart.Workflow, window time.Duration,
+) *workflowStatusWriter {
+	return &workflowStatusWriter{ctx: ctx, status: status, logger: logger, wf: wf, window: window}
+}
+
+// update sets the status to write, replacing a pending update that has not been written.
+func (w *workflowStatusWriter) update(status v1alpha1.WorkflowStatus) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = &status
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.window, w.flushPending)
+	}
+}
+
+// flush writes the pending update, if any, without waiting for the coalesce window.
+func (w *workflowStatusWriter) flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	if w.pending == nil {
+		return nil
+	}
+	status := *w.pending
+	w.pending = nil
+	return w.status.UpdateStatus(w.ctx, w.wf, status)
+}
+
+// flushPending writes the pending update when the coalesce window ends. Errors are logged, since
+// a failure to update the status during a run does not prevent the run.
+func (w *workflowStatusWriter) flushPending() {
+	if err := w.flush(); err != nil {
+		w.logger.Warn(
+			"error updating workflow status", "workflow", w.wf.Name, "namespace", w.wf.Namespace, "error", err,
+		)
+	}
+}
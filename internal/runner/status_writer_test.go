@@ -0,0 +1,100 @@
+package runner
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+// statusRecorder is a status updater that records the statuses written.
+type statusRecorder struct {
+	mu       sync.Mutex
+	statuses []v1alpha1.WorkflowStatus
+}
+
+func (r *statusRecorder) UpdateStatus(_ context.Context, _ *blackstart.Workflow, status v1alpha1.WorkflowStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, status)
+	return nil
+}
+
+// written returns the statuses written.
+func (r *statusRecorder) written() []v1alpha1.WorkflowStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]v1alpha1.WorkflowStatus(nil), r.statuses...)
+}
+
+// phases returns the phases of the statuses written.
+func (r *statusRecorder) phases() []string {
+	var phases []string
+	for _, status := range r.written() {
+		phases = append(phases, status.Phase)
+	}
+	return phases
+}
+
+func TestWorkflowStatusWriter_CoalescesUpdates(t *testing.T) {
+	recorder := &statusRecorder{}
+	wf := &blackstart.Workflow{Name: "demo", Namespace: "default"}
+	w := newWorkflowStatusWriter(context.Background(), recorder, slog.New(slog.DiscardHandler), wf, time.Hour)
+
+	w.update(v1alpha1.WorkflowStatus{Phase: "running"})
+	w.update(v1alpha1.WorkflowStatus{Phase: "complete"})
+	require.NoError(t, w.flush())
+	require.Equal(t, []string{"complete"}, recorder.phases())
+
+	// Nothing is written when no update is pending.
+	require.NoError(t, w.flush())
+	require.Equal(t, []string{"complete"}, recorder.phases())
+}
+
+func TestWorkflowStatusWriter_WritesAfterWindow(t *testing.T) {
+	recorder := &statusRecorder{}
+	wf := &blackstart.Workflow{Name: "demo", Namespace: "default"}
+	w := newWorkflowStatusWriter(
+		context.Background(), recorder, slog.New(slog.DiscardHandler), wf, 10*time.Millisecond,
+	)
+
+	w.update(v1alpha1.WorkflowStatus{Phase: "running"})
+	require.Eventually(
+		t, func() bool { return len(recorder.phases()) == 1 }, time.Second, 5*time.Millisecond,
+	)
+
+	w.update(v1alpha1.WorkflowStatus{Phase: "complete"})
+	require.NoError(t, w.flush())
+	require.Equal(t, []string{"running", "complete"}, recorder.phases())
+}
+
+func TestStuckOperationReporter(t *testing.T) {
+	recorder := &statusRecorder{}
+	wf := &blackstart.Workflow{Name: "demo", Namespace: "default"}
+	w := newWorkflowStatusWriter(context.Background(), recorder, slog.New(slog.DiscardHandler), wf, time.Hour)
+	running := v1alpha1.WorkflowStatus{Phase: "execute"}
+	report := stuckOperationReporter(w, running)
+
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	report(blackstart.StuckOperation{Id: "db", Module: "google_cloudsql_managed_instance", Started: started})
+	report(blackstart.StuckOperation{Id: "user", Module: "google_cloudsql_user", Started: started.Add(time.Minute)})
+	require.NoError(t, w.flush())
+
+	written := recorder.written()
+	require.Len(t, written, 1)
+	require.Equal(t, "execute", written[0].Phase)
+	require.Equal(
+		t, []v1alpha1.StuckOperation{
+			{Id: "db", Module: "google_cloudsql_managed_instance", Started: metav1.NewTime(started)},
+			{Id: "user", Module: "google_cloudsql_user", Started: metav1.NewTime(started.Add(time.Minute))},
+		}, written[0].StuckOperations,
+	)
+	require.Empty(t, running.StuckOperations)
+}
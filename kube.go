@@ -0,0 +1,34 @@
+package blackstart
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrKubeClientUnavailable is returned by GetKubeClient when the runtime does not provide
+// Kubernetes clients.
+var ErrKubeClientUnavailable = errors.New("kubernetes clients are not provided by the runtime")
+
+// KubeClientProvider creates the Kubernetes clients that modules get with GetKubeClient of the
+// ModuleContext. The runtime sets the provider in the context with KubeClientProviderKey, so the
+// namespaces and identities that modules may use are enforced in one place.
+type KubeClientProvider interface {
+	// KubeClient returns a client for use in the namespace, or an error if the namespace may not
+	// be used. An empty namespace requests a client for cluster-scoped resources, such as nodes.
+	// When impersonate is set, requests are made as that user or service account, such as
+	// "system:serviceaccount:<namespace>:<name>".
+	KubeClient(ctx context.Context, namespace, impersonate string) (kubernetes.Interface, error)
+}
+
+// GetKubeClient returns a Kubernetes client from the provider of the runtime for use in the
+// namespace, optionally impersonating a user or service account. ErrKubeClientUnavailable is
+// returned when the runtime does not provide clients.
+func (mc *moduleContext) GetKubeClient(namespace, impersonate string) (kubernetes.Interface, error) {
+	provider, ok := mc.ctx.Value(KubeClientProviderKey).(KubeClientProvider)
+	if !ok || provider == nil {
+		return nil, ErrKubeClientUnavailable
+	}
+	return provider.KubeClient(mc, namespace, impersonate)
+}
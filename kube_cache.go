@@ -0,0 +1,220 @@
+package blackstart
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// kubeReadCacheKey is the run cache key of the cache of Kubernetes reads of a workflow run.
+type kubeReadCacheKey struct{}
+
+// kubeReadCache holds the responses of Kubernetes GET requests of single objects for a workflow
+// run. Entries are keyed by the object path and the identity and encoding of the request.
+type kubeReadCache struct {
+	mu      sync.Mutex
+	entries map[string]*kubeCachedResponse
+
+	// generation is increased by each write, so a read that was sent before a write is not cached
+	// after it.
+	generation uint64
+}
+
+// kubeCachedResponse is a cached response of a Kubernetes GET request.
+type kubeCachedResponse struct {
+	collection string
+
+	status int
+	header http.Header
+	body   []byte
+
+	// readers are the operations the response was returned to.
+	readers map[*moduleContext]struct{}
+}
+
+// CacheKubeReads wraps the transport of a Kubernetes client to cache the GET requests of single
+// objects, such as a Secret or ConfigMap, for the workflow run of the request context. Operations
+// of a run that read the same object share one request, which is useful when many operations use
+// the same Secret or ConfigMap. Any other request to the resource type of an object, such as an
+// apply, patch, or delete, removes the cached objects of that type from the cache of the run, so
+// later reads see the write. Requests made outside a workflow run are not cached.
+//
+// An operation that reads an object it already read gets it from the API server, so operations
+// that poll an object, such as until it is deleted, see its changes.
+//
+// Reads of the same object by different impersonated identities are cached separately. Writes made
+// with clients that are not wrapped, such as a client input, do not update the cache. If base is
+// nil, http.DefaultTransport is used.
+func CacheKubeReads(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &kubeReadCacher{base: base}
+}
+
+// kubeReadCacher is an http.RoundTripper that caches Kubernetes reads of a workflow run.
+type kubeReadCacher struct {
+	base http.RoundTripper
+}
+
+// RoundTrip returns a cached response for a GET request of an object that was already read in the
+// run, and otherwise sends the request with the wrapped transport.
+func (c *kubeReadCacher) RoundTrip(req *http.Request) (*http.Response, error) {
+	cache := runKubeReadCache(req.Context())
+	if cache == nil {
+		return c.base.RoundTrip(req)
+	}
+	collection, name := kubeObjectPath(req.URL.Path)
+	if collection == "" {
+		return c.base.RoundTrip(req)
+	}
+
+	if req.Method != http.MethodGet {
+		cache.invalidate(collection)
+		return c.base.RoundTrip(req)
+	}
+	if name == "" || req.URL.RawQuery != "" {
+		return c.base.RoundTrip(req)
+	}
+
+	reader, _ := req.Context().Value(moduleContextKey{}).(*moduleContext)
+	key := kubeReadKey(req, collection, name)
+	generation, cached := cache.lookup(key, reader)
+	if cached != nil {
+		return cached.response(req), nil
+	}
+	resp, err := c.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	entry := &kubeCachedResponse{
+		collection: collection,
+		status:     resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       body,
+		readers:    map[*moduleContext]struct{}{reader: {}},
+	}
+	cache.store(key, generation, entry)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// runKubeReadCache returns the Kubernetes read cache of the workflow run of the context, or nil
+// outside a workflow run.
+func runKubeReadCache(ctx context.Context) *kubeReadCache {
+	if cache, ok := ctx.Value(runCacheContextKey{}).(*runCache); !ok || cache == nil {
+		return nil
+	}
+	cache, _ := RunCached(
+		ctx, kubeReadCacheKey{}, func() (*kubeReadCache, error) {
+			return &kubeReadCache{entries: make(map[string]*kubeCachedResponse)}, nil
+		},
+	)
+	return cache
+}
+
+// lookup returns the generation of the cache and the cached response of the key for the operation
+// reading it. No response is returned when the operation already read it.
+func (c *kubeReadCache) lookup(key string, reader *moduleContext) (uint64, *kubeCachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return c.generation, nil
+	}
+	if reader != nil {
+		if _, read := entry.readers[reader]; read {
+			return c.generation, nil
+		}
+		entry.readers[reader] = struct{}{}
+	}
+	return c.generation, entry
+}
+
+// store caches the response of the key, unless a write was made since the generation the request
+// was sent at.
+func (c *kubeReadCache) store(key string, generation uint64, entry *kubeCachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.entries[key] = entry
+	}
+}
+
+// invalidate removes the cached objects of the collection, and of the collections of a namespace
+// when the collection is the namespaces.
+func (c *kubeReadCache) invalidate(collection string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key, entry := range c.entries {
+		if entry.collection == collection || strings.HasPrefix(entry.collection, collection+"/") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// response returns a new response of the request with the cached status, headers, and body.
+func (r *kubeCachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(r.status) + " " + http.StatusText(r.status),
+		StatusCode:    r.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}
+}
+
+// kubeReadKey returns the cache key of a GET request of an object. The key includes the
+// impersonation headers and the accepted content types of the request, so identities and
+// encodings do not share responses.
+func kubeReadKey(req *http.Request, collection, name string) string {
+	return strings.Join(
+		[]string{
+			collection, name,
+			strings.Join(req.Header.Values("Impersonate-User"), ","),
+			strings.Join(req.Header.Values("Impersonate-Group"), ","),
+			strings.Join(req.Header.Values("Impersonate-Uid"), ","),
+			req.Header.Get("Accept"),
+		}, "\n",
+	)
+}
+
+// kubeObjectPath splits the path of a Kubernetes API request into the path of the collection of
+// the resource type and the name of the object, such as "/api/v1/namespaces/apps/secrets" and
+// "db". The name is empty for requests of a collection or of a subresource of an object, and the
+// collection is empty for paths that are not of a resource type.
+func kubeObjectPath(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var prefix int
+	switch {
+	case len(parts) >= 3 && parts[0] == "api":
+		prefix = 2
+	case len(parts) >= 4 && parts[0] == "apis":
+		prefix = 3
+	default:
+		return "", ""
+	}
+	end := prefix + 1
+	if parts[prefix] == "namespaces" && len(parts) > prefix+2 {
+		end = prefix + 3
+	}
+	collection := "/" + strings.Join(parts[:end], "/")
+	if len(parts) == end+1 {
+		return collection, parts[end]
+	}
+	return collection, ""
+}
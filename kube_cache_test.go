@@ -0,0 +1,114 @@
+package blackstart
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheKubeReads(t *testing.T) {
+	requests := map[string]int{}
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				requests[r.Method+" "+r.URL.Path]++
+				if strings.HasSuffix(r.URL.Path, "/missing") {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = io.WriteString(w, r.URL.Path)
+			},
+		),
+	)
+	defer server.Close()
+	client := &http.Client{Transport: CacheKubeReads(nil)}
+
+	do := func(ctx context.Context, method, path, impersonate string) int {
+		req, err := http.NewRequestWithContext(ctx, method, server.URL+path, nil)
+		require.NoError(t, err)
+		if impersonate != "" {
+			req.Header.Set("Impersonate-User", impersonate)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		if resp.StatusCode == http.StatusOK {
+			assert.Equal(t, path, string(body))
+		}
+		return resp.StatusCode
+	}
+	const secret = "/api/v1/namespaces/apps/secrets/db"
+	const configMap = "/api/v1/namespaces/apps/configmaps/settings"
+
+	// Outside a workflow run, reads are not cached.
+	do(context.Background(), http.MethodGet, secret, "")
+	do(context.Background(), http.MethodGet, secret, "")
+	assert.Equal(t, 2, requests["GET "+secret])
+
+	clear(requests)
+	ctx := withRunCache(context.Background())
+	do(InputsToContext(ctx, nil), http.MethodGet, secret, "")
+	do(InputsToContext(ctx, nil), http.MethodGet, secret, "")
+	do(ctx, http.MethodGet, configMap, "")
+	assert.Equal(t, 1, requests["GET "+secret])
+	assert.Equal(t, 1, requests["GET "+configMap])
+
+	// An operation that reads an object again, such as while polling it, reads it from the server.
+	mctx := InputsToContext(ctx, nil)
+	do(mctx, http.MethodGet, secret, "")
+	do(mctx, http.MethodGet, secret, "")
+	assert.Equal(t, 2, requests["GET "+secret])
+
+	// Identities do not share reads, and failed reads are not cached.
+	do(ctx, http.MethodGet, secret, "system:serviceaccount:apps:reader")
+	assert.Equal(t, 3, requests["GET "+secret])
+	assert.Equal(t, http.StatusNotFound, do(ctx, http.MethodGet, "/api/v1/namespaces/apps/secrets/missing", ""))
+	assert.Equal(t, http.StatusNotFound, do(ctx, http.MethodGet, "/api/v1/namespaces/apps/secrets/missing", ""))
+	assert.Equal(t, 2, requests["GET /api/v1/namespaces/apps/secrets/missing"])
+
+	// A write of a Secret removes the cached Secrets, but not the ConfigMaps.
+	do(ctx, http.MethodPatch, "/api/v1/namespaces/apps/secrets/other", "")
+	do(ctx, http.MethodGet, secret, "")
+	do(ctx, http.MethodGet, configMap, "")
+	assert.Equal(t, 4, requests["GET "+secret])
+	assert.Equal(t, 1, requests["GET "+configMap])
+
+	// Another workflow run does not share the reads.
+	do(withRunCache(context.Background()), http.MethodGet, configMap, "")
+	assert.Equal(t, 2, requests["GET "+configMap])
+}
+
+func TestKubeObjectPath(t *testing.T) {
+	tests := []struct {
+		path       string
+		collection string
+		name       string
+	}{
+		{"/api/v1/namespaces/apps/secrets/db", "/api/v1/namespaces/apps/secrets", "db"},
+		{"/api/v1/namespaces/apps/secrets", "/api/v1/namespaces/apps/secrets", ""},
+		{"/api/v1/namespaces/apps/pods/web/status", "/api/v1/namespaces/apps/pods", ""},
+		{"/api/v1/namespaces/apps", "/api/v1/namespaces", "apps"},
+		{"/api/v1/nodes/node-1", "/api/v1/nodes", "node-1"},
+		{"/apis/apps/v1/namespaces/apps/deployments/web", "/apis/apps/v1/namespaces/apps/deployments", "web"},
+		{
+			"/apis/apiextensions.k8s.io/v1/customresourcedefinitions/workflows.blackstart.pezops.github.io",
+			"/apis/apiextensions.k8s.io/v1/customresourcedefinitions",
+			"workflows.blackstart.pezops.github.io",
+		},
+		{"/api/v1", "", ""},
+		{"/version", "", ""},
+	}
+	for _, tt := range tests {
+		collection, name := kubeObjectPath(tt.path)
+		assert.Equal(t, tt.collection, collection, tt.path)
+		assert.Equal(t, tt.name, name, tt.path)
+	}
+}
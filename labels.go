@@ -0,0 +1,111 @@
+package blackstart
+
+import (
+	"fmt"
+	"strings"
+)
+
+// labelFilter selects the operations with a label set to a value.
+type labelFilter struct {
+	key   string
+	value string
+}
+
+func (f labelFilter) String() string {
+	return f.key + "=" + f.value
+}
+
+// matches reports whether the operation has the label of the filter.
+func (f labelFilter) matches(op *Operation) bool {
+	value, ok := op.Labels[f.key]
+	return ok && value == f.value
+}
+
+// parseLabelFilters parses `key=value` label filters, as set with `--only-labels` and
+// `--skip-labels`.
+func parseLabelFilters(raw []string) ([]labelFilter, error) {
+	filters := make([]labelFilter, 0, len(raw))
+	for _, item := range raw {
+		key, value, found := strings.Cut(item, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid label filter %q: expected key=value", item)
+		}
+		filters = append(filters, labelFilter{key: key, value: strings.TrimSpace(value)})
+	}
+	return filters, nil
+}
+
+// checkLabelFilters validates the labels of the operations and the label filters of the workflow.
+// Each filter of OnlyLabels must select at least one operation, so a mistyped filter does not
+// turn the run into a check of all operations.
+func (we *workflowExecution) checkLabelFilters() error {
+	for _, op := range we.w.Operations {
+		for key := range op.Labels {
+			if strings.TrimSpace(key) == "" {
+				return fmt.Errorf("operation %q has a label with an empty key", op.Id)
+			}
+		}
+	}
+	only, err := parseLabelFilters(we.w.OnlyLabels)
+	if err != nil {
+		return err
+	}
+	skip, err := parseLabelFilters(we.w.SkipLabels)
+	if err != nil {
+		return err
+	}
+	for _, f := range only {
+		if !we.filterMatchesAny(f) {
+			return fmt.Errorf("only-labels filter %s selects no operation of the workflow", f)
+		}
+	}
+	for _, f := range skip {
+		if !we.filterMatchesAny(f) {
+			we.logger.Warn("skip-labels filter matches no operation", "filter", f.String())
+		}
+	}
+	return nil
+}
+
+// filterMatchesAny reports whether the filter matches an operation of the workflow.
+func (we *workflowExecution) filterMatchesAny(f labelFilter) bool {
+	for i := range we.w.Operations {
+		if f.matches(&we.w.Operations[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// selected reports whether the label filters of the workflow select the operation to be set. An
+// operation is selected when it matches any filter of OnlyLabels, or OnlyLabels is empty, and it
+// matches no filter of SkipLabels. Invalid filters are reported by the validate phase and select
+// no operation.
+func (w *Workflow) selected(op *Operation) bool {
+	if len(w.OnlyLabels) == 0 && len(w.SkipLabels) == 0 {
+		return true
+	}
+	only, err := parseLabelFilters(w.OnlyLabels)
+	if err != nil {
+		return false
+	}
+	skip, err := parseLabelFilters(w.SkipLabels)
+	if err != nil {
+		return false
+	}
+	for _, f := range skip {
+		if f.matches(op) {
+			return false
+		}
+	}
+	if len(only) == 0 {
+		return true
+	}
+	for _, f := range only {
+		if f.matches(op) {
+			return true
+		}
+	}
+	return false
+}
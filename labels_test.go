@@ -0,0 +1,154 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labelsTestWorkflow returns a workflow with operations of the db and app tiers.
+func labelsTestWorkflow() Workflow {
+	return Workflow{
+		Name: "labels-test",
+		Operations: []Operation{
+			{
+				Id:     "app",
+				Module: "batch_test_module",
+				Labels: map[string]string{"tier": "app"},
+				Inputs: map[string]Input{
+					"name":          NewInputFromValue("app"),
+					testCheckResult: NewInputFromValue(false),
+				},
+			},
+			{
+				Id:     "db",
+				Module: "batch_test_module",
+				Labels: map[string]string{"tier": "db"},
+				Inputs: map[string]Input{
+					"name":          NewInputFromValue("db"),
+					testCheckResult: NewInputFromValue(false),
+				},
+			},
+			{
+				Id:     "db-user",
+				Module: "batch_test_module",
+				Labels: map[string]string{"tier": "db"},
+				Inputs: map[string]Input{
+					"name":          NewInputFromDep("app", "name"),
+					testCheckResult: NewInputFromValue(false),
+				},
+			},
+			{
+				Id:     "cache",
+				Module: "test_module",
+				Labels: map[string]string{"tier": "cache"},
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(false),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+		},
+		// The cache is unavailable.
+		InjectedFailures: map[string]string{"cache": InjectFailureCheck},
+	}
+}
+
+func TestWorkflowExecution_LabelFilters(t *testing.T) {
+	batchTestCalls.batches = nil
+	batchTestCalls.sets = nil
+	wf := labelsTestWorkflow()
+	wf.OnlyLabels = []string{"tier=db"}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 4, res.CompletedOperations)
+	assert.Equal(t, []string{"db"}, batchTestCalls.sets)
+	filtered := map[string]bool{}
+	skipped := map[string]bool{}
+	for _, op := range res.Operations {
+		filtered[op.Id] = op.Filtered
+		skipped[op.Id] = op.Skipped
+	}
+	// The check of the cache operation fails, but it is not selected, so the run does not fail.
+	assert.Equal(t, map[string]bool{"app": true, "db": false, "db-user": false, "cache": true}, filtered)
+	// The db-user operation is selected, but the app operation it depends on was not set.
+	assert.Equal(t, map[string]bool{"app": false, "db": false, "db-user": true, "cache": false}, skipped)
+
+	// Skipped labels are not set, and all other operations are.
+	batchTestCalls.sets = nil
+	wf = labelsTestWorkflow()
+	wf.SkipLabels = []string{"tier=cache"}
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	// The db-user operation is set with the name output of the app operation.
+	assert.ElementsMatch(t, []string{"app", "db", "app"}, batchTestCalls.sets)
+
+	// Without filters, the failing check fails the run.
+	wf = labelsTestWorkflow()
+	res = wf.Run(context.Background())
+	require.ErrorContains(t, res.Err, `injected failure: check of operation "cache"`)
+}
+
+func TestWorkflowExecution_LabelFiltersInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(wf *Workflow)
+		wantErr string
+	}{
+		{
+			name:    "filter without value",
+			setup:   func(wf *Workflow) { wf.OnlyLabels = []string{"tier"} },
+			wantErr: `invalid label filter "tier": expected key=value`,
+		},
+		{
+			name:    "filter without key",
+			setup:   func(wf *Workflow) { wf.SkipLabels = []string{"=db"} },
+			wantErr: `invalid label filter "=db": expected key=value`,
+		},
+		{
+			name:    "filter selects nothing",
+			setup:   func(wf *Workflow) { wf.OnlyLabels = []string{"tier=db", "tier=dbb"} },
+			wantErr: "only-labels filter tier=dbb selects no operation of the workflow",
+		},
+		{
+			name:    "empty label key",
+			setup:   func(wf *Workflow) { wf.Operations[0].Labels[""] = "x" },
+			wantErr: `operation "app" has a label with an empty key`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				wf := labelsTestWorkflow()
+				tt.setup(&wf)
+				res := wf.Validate(context.Background())
+				require.ErrorContains(t, res.Err, tt.wantErr)
+				assert.Equal(t, phaseValidate, res.Phase)
+			},
+		)
+	}
+}
+
+func TestWorkflow_Selected(t *testing.T) {
+	db := &Operation{Labels: map[string]string{"tier": "db", "team": "payments"}}
+	unlabeled := &Operation{}
+
+	wf := &Workflow{}
+	assert.True(t, wf.selected(db))
+	assert.True(t, wf.selected(unlabeled))
+
+	wf = &Workflow{OnlyLabels: []string{"tier=cache", "team=payments"}}
+	assert.True(t, wf.selected(db))
+	assert.False(t, wf.selected(unlabeled))
+
+	wf = &Workflow{SkipLabels: []string{"tier = db"}}
+	assert.False(t, wf.selected(db))
+	assert.True(t, wf.selected(unlabeled))
+
+	// Skipped labels take precedence.
+	wf = &Workflow{OnlyLabels: []string{"team=payments"}, SkipLabels: []string{"tier=db"}}
+	assert.False(t, wf.selected(db))
+}
@@ -0,0 +1,109 @@
+package blackstart
+
+import (
+	"fmt"
+	"slices"
+)
+
+// lintWorkflow returns warnings about parts of a validated workflow that run but are likely
+// mistakes left over from editing, in execution order. It reports:
+//   - operations of outputs only modules whose outputs are not used by another operation or
+//     published, because they do nothing but produce those outputs.
+//   - dependsOn entries of an operation that it already has through an input, or that are
+//     dependencies of another of its dependencies and so run before it either way.
+//
+// Workflows have no conditional operations, so every operation of a valid workflow is reachable.
+func lintWorkflow(
+	sortedIds []string, operations map[string]*Operation, moduleInfo map[string]ModuleInfo,
+	publish []PublishOutputs,
+) []string {
+	used := make(map[string]struct{})
+	for _, op := range operations {
+		for _, dep := range operationDependencies(op) {
+			used[dep] = struct{}{}
+		}
+	}
+	for _, p := range publish {
+		for _, out := range p.Outputs {
+			used[out.OperationId] = struct{}{}
+		}
+	}
+
+	var warnings []string
+	for _, id := range sortedIds {
+		op := operations[id]
+		info := moduleInfo[id]
+		if _, ok := used[id]; !ok && info.OutputsOnly && !op.DoesNotExist {
+			warnings = append(
+				warnings, fmt.Sprintf(
+					"outputs of operation %q are not used by another operation or published", id,
+				),
+			)
+		}
+		warnings = append(warnings, redundantDependsOn(op, info, operations)...)
+	}
+	return warnings
+}
+
+// redundantDependsOn returns warnings for the dependsOn entries of an operation that do not change
+// the execution order. Entries of modules that read the outputs of their dependsOn operations are
+// only reported when an input already uses the operation.
+func redundantDependsOn(op *Operation, info ModuleInfo, operations map[string]*Operation) []string {
+	inputDeps := make(map[string]struct{})
+	for _, input := range op.Inputs {
+		if !input.IsStatic() {
+			inputDeps[input.DependencyId()] = struct{}{}
+		}
+	}
+	deps := slices.Sorted(slices.Values(operationDependencies(op)))
+	deps = slices.Compact(deps)
+
+	var warnings []string
+	for _, dep := range op.DependsOn {
+		if _, ok := inputDeps[dep]; ok {
+			warnings = append(
+				warnings, fmt.Sprintf(
+					"dependsOn %q of operation %q is redundant: an input of the operation already uses it",
+					dep, op.Id,
+				),
+			)
+			continue
+		}
+		if info.ReadsWorkflowOutputs {
+			continue
+		}
+		for _, other := range deps {
+			if other != dep && dependsOnTransitively(other, dep, operations, map[string]struct{}{}) {
+				warnings = append(
+					warnings, fmt.Sprintf(
+						"dependsOn %q of operation %q is redundant: it is already a dependency of %q",
+						dep, op.Id, other,
+					),
+				)
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// dependsOnTransitively reports whether the operation id depends on target, directly or through
+// its dependencies. Visited operations are skipped.
+func dependsOnTransitively(
+	id, target string, operations map[string]*Operation, visited map[string]struct{},
+) bool {
+	if _, ok := visited[id]; ok {
+		return false
+	}
+	visited[id] = struct{}{}
+	op, ok := operations[id]
+	if !ok {
+		return false
+	}
+	for _, dep := range operationDependencies(op) {
+		if dep == target || dependsOnTransitively(dep, target, operations, visited) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package blackstart
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintTestModule outputs its optional value input. The outputs only and reads workflow outputs
+// flags of its module info are set by the module id.
+type lintTestModule struct {
+	id string
+}
+
+func init() {
+	for _, id := range []string{"lint_test_module", "lint_read_test_module", "lint_template_test_module"} {
+		RegisterModule(id, func() Module { return &lintTestModule{id: id} })
+	}
+}
+
+func (m *lintTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: m.id,
+		Inputs: map[string]InputValue{
+			"value": {Type: reflect.TypeFor[string](), Default: ""},
+		},
+		Outputs: map[string]OutputValue{
+			"value": {Type: reflect.TypeFor[string]()},
+		},
+		OutputsOnly:          m.id != "lint_test_module",
+		ReadsWorkflowOutputs: m.id == "lint_template_test_module",
+	}
+}
+
+func (m *lintTestModule) Validate(_ Operation) error { return nil }
+func (m *lintTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *lintTestModule) Set(ctx ModuleContext) error {
+	value, err := ContextInputAs[string](ctx, "value", false)
+	if err != nil {
+		return err
+	}
+	return ctx.Output("value", value)
+}
+
+func TestLintWorkflow(t *testing.T) {
+	tests := []struct {
+		name       string
+		operations []Operation
+		publish    []PublishOutputs
+		want       []string
+	}{
+		{
+			name: "used outputs",
+			operations: []Operation{
+				{Id: "read", Module: "lint_read_test_module"},
+				{Id: "apply", Module: "lint_test_module", Inputs: map[string]Input{
+					"value": NewInputFromDep("read", "value"),
+				}},
+			},
+		},
+		{
+			name: "unused outputs",
+			operations: []Operation{
+				{Id: "read", Module: "lint_read_test_module"},
+				{Id: "apply", Module: "lint_test_module"},
+				{Id: "deleted", Module: "lint_read_test_module", DoesNotExist: true},
+			},
+			want: []string{
+				`outputs of operation "read" are not used by another operation or published`,
+			},
+		},
+		{
+			name: "published outputs",
+			operations: []Operation{
+				{Id: "read", Module: "lint_read_test_module"},
+			},
+			publish: []PublishOutputs{
+				{
+					Kind: PublishConfigMap, Name: "outputs", Namespace: "default",
+					Outputs: []PublishedOutput{{Key: "value", OperationId: "read", OutputKey: "value"}},
+				},
+			},
+		},
+		{
+			name: "dependsOn used by an input",
+			operations: []Operation{
+				{Id: "a", Module: "lint_test_module"},
+				{Id: "b", Module: "lint_test_module", DependsOn: []string{"a"}, Inputs: map[string]Input{
+					"value": NewInputFromDep("a", "value"),
+				}},
+			},
+			want: []string{
+				`dependsOn "a" of operation "b" is redundant: an input of the operation already uses it`,
+			},
+		},
+		{
+			name: "transitive dependsOn",
+			operations: []Operation{
+				{Id: "a", Module: "lint_test_module"},
+				{Id: "b", Module: "lint_test_module", DependsOn: []string{"a"}},
+				{Id: "c", Module: "lint_test_module", Inputs: map[string]Input{
+					"value": NewInputFromDep("b", "value"),
+				}},
+				{Id: "d", Module: "lint_test_module", DependsOn: []string{"a", "c"}},
+			},
+			want: []string{
+				`dependsOn "a" of operation "d" is redundant: it is already a dependency of "c"`,
+			},
+		},
+		{
+			name: "dependsOn read as workflow outputs",
+			operations: []Operation{
+				{Id: "a", Module: "lint_test_module"},
+				{Id: "b", Module: "lint_test_module", DependsOn: []string{"a"}},
+				{Id: "render", Module: "lint_template_test_module", DependsOn: []string{"a", "b"}},
+			},
+			want: []string{
+				`outputs of operation "render" are not used by another operation or published`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				wf := Workflow{Name: "lint", Operations: tt.operations, PublishOutputs: tt.publish}
+				res := wf.Validate(context.Background())
+				require.NoError(t, res.Err)
+				assert.Equal(t, tt.want, res.Lint)
+			},
+		)
+	}
+}
+
+func TestWorkflowValidate(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &RuntimeConfig{LogFormat: "text", LogLevel: "warn"}
+	ctx := context.WithValue(context.Background(), LoggerKey, newLoggerForWriter(cfg, &buf))
+	wf := Workflow{
+		Name: "lint",
+		Operations: []Operation{
+			{Id: "read", Module: "lint_read_test_module", Inputs: map[string]Input{"value": NewInputFromValue("a")}},
+		},
+	}
+
+	res := wf.Validate(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, phaseValidate, res.Phase)
+	assert.Zero(t, res.CompletedOperations)
+	assert.Empty(t, res.Operations)
+	require.Len(t, res.Plan, 1)
+	assert.Contains(
+		t, buf.String(),
+		`WARN workflow lint warning workflow=lint `+
+			`warning=outputs of operation "read" are not used by another operation or published`,
+	)
+
+	wf.Operations[0].Module = "unknown_module"
+	res = wf.Validate(ctx)
+	assert.Error(t, res.Err)
+}
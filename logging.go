@@ -164,10 +164,20 @@ func newLoggerForWriter(config *RuntimeConfig, overrideWriter io.Writer) *slog.L
 	if err != nil {
 		log.Fatalf("invalid log level: %v: %v", config.LogLevel, err)
 	}
+	moduleLevels, err := parseModuleLogLevels(config.LogModuleLevels)
+	if err != nil {
+		log.Fatalf("invalid module log level: %v", err)
+	}
 
+	// The handler accepts the lowest configured level, and records are filtered by the level of
+	// the logger's module.
+	handlerLevel := ll
+	for _, level := range moduleLevels {
+		handlerLevel = min(handlerLevel, level)
+	}
 	logOpts := &slog.HandlerOptions{
 		AddSource:   false,
-		Level:       ll,
+		Level:       handlerLevel,
 		ReplaceAttr: logReplaceAttr(config),
 	}
 
@@ -179,9 +189,77 @@ func newLoggerForWriter(config *RuntimeConfig, overrideWriter io.Writer) *slog.L
 		logHandler = NewTextHandler(logWriter, logOpts)
 	}
 
+	if len(moduleLevels) > 0 {
+		logHandler = &moduleLevelHandler{handler: logHandler, level: ll, modules: moduleLevels}
+	}
 	return slog.New(logHandler)
 }
 
+// parseModuleLogLevels parses module log level overrides in the form prefix=level. Dots in a prefix
+// are treated as underscores, so google.cloudsql matches the google_cloudsql modules.
+func parseModuleLogLevels(values []string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level, len(values))
+	for _, value := range values {
+		prefix, rawLevel, ok := strings.Cut(value, "=")
+		prefix = strings.ReplaceAll(strings.TrimSpace(prefix), ".", "_")
+		if !ok || prefix == "" {
+			return nil, fmt.Errorf("%q: expected prefix=level", value)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(rawLevel))); err != nil {
+			return nil, fmt.Errorf("%q: %w", value, err)
+		}
+		levels[prefix] = level
+	}
+	return levels, nil
+}
+
+var _ slog.Handler = &moduleLevelHandler{}
+
+// moduleLevelHandler filters records by level before passing them to a handler. The handler of a
+// module logger uses the level of the longest module prefix matching the module id.
+type moduleLevelHandler struct {
+	handler slog.Handler
+	level   slog.Level
+	modules map[string]slog.Level
+}
+
+func (h *moduleLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level && h.handler.Enabled(ctx, level)
+}
+
+func (h *moduleLevelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *moduleLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &moduleLevelHandler{handler: h.handler.WithAttrs(attrs), level: h.level, modules: h.modules}
+}
+
+func (h *moduleLevelHandler) WithGroup(name string) slog.Handler {
+	return &moduleLevelHandler{handler: h.handler.WithGroup(name), level: h.level, modules: h.modules}
+}
+
+// forModule returns the handler for a module logger.
+func (h *moduleLevelHandler) forModule(module string) *moduleLevelHandler {
+	level, matched := h.level, ""
+	for prefix, l := range h.modules {
+		if (module == prefix || strings.HasPrefix(module, prefix+"_")) && len(prefix) > len(matched) {
+			level, matched = l, prefix
+		}
+	}
+	return &moduleLevelHandler{handler: h.handler, level: level, modules: h.modules}
+}
+
+// moduleLogger returns the logger used by a module. The level of the logger honors the module log
+// level overrides of the runtime configuration.
+func moduleLogger(logger *slog.Logger, module string) *slog.Logger {
+	if h, ok := logger.Handler().(*moduleLevelHandler); ok {
+		logger = slog.New(h.forModule(module))
+	}
+	return logger
+}
+
 func logReplaceAttr(config *RuntimeConfig) func(groups []string, a slog.Attr) slog.Attr {
 	if config == nil {
 		return nil
@@ -2,6 +2,7 @@ package blackstart
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log/slog"
 	"testing"
@@ -127,3 +128,49 @@ func TestTextLogger_WithAttrs_ChainsWithoutDropping(t *testing.T) {
 	assert.Contains(t, line, "workflow=wf-a")
 	assert.Contains(t, line, "namespace=default")
 }
+
+func TestParseModuleLogLevels(t *testing.T) {
+	levels, err := parseModuleLogLevels([]string{"kubernetes=debug", " google.cloudsql = WARN "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]slog.Level{"kubernetes": slog.LevelDebug, "google_cloudsql": slog.LevelWarn}, levels)
+
+	_, err = parseModuleLogLevels([]string{"kubernetes"})
+	assert.ErrorContains(t, err, "expected prefix=level")
+	_, err = parseModuleLogLevels([]string{"kubernetes=loud"})
+	assert.Error(t, err)
+}
+
+func TestModuleLogger_LevelOverrides(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &RuntimeConfig{
+		LogFormat:       "text",
+		LogLevel:        "info",
+		LogModuleLevels: []string{"kubernetes=debug", "google=error", "google.cloudsql=warn"},
+	}
+	logger := newLoggerForWriter(cfg, &buf).With("workflow", "wf-a")
+
+	logger.Debug("workflow debug")
+	moduleLogger(logger, "kubernetes_secret").Debug("secret debug")
+	moduleLogger(logger, "kubernetesx").Debug("other debug")
+	moduleLogger(logger, "google_cloudsql_user").Info("user info")
+	moduleLogger(logger, "google_cloudsql_user").Warn("user warn")
+	moduleLogger(logger, "google_storage_bucket").Warn("bucket warn")
+
+	out := buf.String()
+	assert.NotContains(t, out, "workflow debug")
+	assert.Contains(t, out, "secret debug workflow=wf-a")
+	assert.NotContains(t, out, "other debug")
+	assert.NotContains(t, out, "user info")
+	assert.Contains(t, out, "user warn")
+	assert.NotContains(t, out, "bucket warn")
+}
+
+func TestModuleContext_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &RuntimeConfig{LogFormat: "text", LogLevel: "warn", LogModuleLevels: []string{"test=debug"}}
+	ctx := context.WithValue(context.Background(), LoggerKey, newLoggerForWriter(cfg, &buf))
+
+	mctx := OpContext(ctx, &Operation{Id: "op1", Module: "test_module"})
+	mctx.Logger().Debug("module debug")
+	assert.Contains(t, buf.String(), "module debug module=test_module id=op1")
+}
@@ -7,4 +7,13 @@ const (
 	LoggerKey key = "logger"
 	ConfigKey key = "config"
 	SchemeKey key = "scheme"
+
+	// StateStoreKey is the context key of the StateStore configured for the run.
+	StateStoreKey key = "stateStore"
+
+	// EventSinkKey is the context key of the EventSink the events of runs are emitted to.
+	EventSinkKey key = "eventSink"
+
+	// KubeClientProviderKey is the context key of the KubeClientProvider of the runtime.
+	KubeClientProviderKey key = "kubeClientProvider"
 )
@@ -0,0 +1,128 @@
+package blackstart
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+)
+
+// Providers of the API calls recorded by the modules. Modules of other providers record calls with
+// the name of their API, such as `github`.
+const (
+	APIProviderKubernetes = "kubernetes"
+	APIProviderGoogle     = "google"
+	APIProviderAzure      = "azure"
+	APIProviderPostgres   = "postgres"
+	APIProviderMySQL      = "mysql"
+	APIProviderSlack      = "slack"
+)
+
+// CountAPICalls wraps an HTTP transport to record each request as an API call to the provider by
+// the operation whose ModuleContext the request context is derived from. Requests made with other
+// contexts are not recorded. Requests to an endpoint whose circuit is open fail with
+// ErrCircuitOpen, and transport errors and server errors are recorded as failures of the endpoint.
+// If base is nil, the transport of HTTPTransport is used.
+func CountAPICalls(provider string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = HTTPTransport()
+	}
+	return &apiCallCounter{provider: provider, base: base}
+}
+
+// CountAPICallsOf returns a transport wrapper that records requests as API calls to the provider
+// with CountAPICalls, for clients configured with wrappers such as the Kubernetes rest.Config.
+func CountAPICallsOf(provider string) func(http.RoundTripper) http.RoundTripper {
+	return func(base http.RoundTripper) http.RoundTripper {
+		return CountAPICalls(provider, base)
+	}
+}
+
+// RecordAPICall records an API call to the provider by the operation whose ModuleContext ctx is
+// derived from, for calls made without an HTTP client, such as database queries. Calls made with
+// other contexts are not recorded.
+func RecordAPICall(ctx context.Context, provider string) {
+	if mctx, ok := ctx.Value(moduleContextKey{}).(ModuleContext); ok {
+		mctx.APICall(provider)
+	}
+}
+
+// apiCallCounter is an http.RoundTripper that records API calls of operations.
+type apiCallCounter struct {
+	provider string
+	base     http.RoundTripper
+}
+
+// RoundTrip records the request as an API call and sends it with the wrapped transport, unless the
+// circuit of its endpoint is open.
+func (c *apiCallCounter) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	endpoint := req.URL.Scheme + "://" + req.URL.Host
+	if err := EndpointAllowed(ctx, endpoint); err != nil {
+		return nil, err
+	}
+	RecordAPICall(ctx, c.provider)
+	resp, err := c.base.RoundTrip(req)
+	failure := err
+	if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+		failure = fmt.Errorf("server error: %s", resp.Status)
+	}
+	RecordEndpointResult(ctx, endpoint, failure)
+	return resp, err
+}
+
+// otherAPIProvider is the provider of API calls recorded without a provider.
+const otherAPIProvider = "other"
+
+// apiCallCounts counts the API calls of an operation by provider. It is safe for concurrent use.
+type apiCallCounts struct {
+	mu    sync.Mutex
+	calls map[string]int64
+}
+
+// add records an API call to the provider.
+func (c *apiCallCounts) add(provider string) {
+	if provider == "" {
+		provider = otherAPIProvider
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = make(map[string]int64)
+	}
+	c.calls[provider]++
+}
+
+// total returns the number of API calls to all providers.
+func (c *apiCallCounts) total() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total int64
+	for _, n := range c.calls {
+		total += n
+	}
+	return total
+}
+
+// byProvider returns the number of API calls by provider, or nil when no calls were recorded.
+func (c *apiCallCounts) byProvider() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.calls)
+}
+
+// ProviderAPICalls returns the number of external API calls of the executed operations of the run
+// by provider, or nil when no calls were recorded.
+func (r WorkflowResult) ProviderAPICalls() map[string]int64 {
+	var calls map[string]int64
+	for _, op := range r.Operations {
+		for provider, n := range op.ProviderAPICalls {
+			if calls == nil {
+				calls = make(map[string]int64)
+			}
+			calls[provider] += n
+		}
+	}
+	return calls
+}
@@ -0,0 +1,60 @@
+package blackstart
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountAPICalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+	client := &http.Client{Transport: CountAPICalls(APIProviderGoogle, nil)}
+
+	get := func(ctx context.Context) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+
+	mctx := InputsToContext(context.Background(), nil)
+	get(mctx)
+	// Contexts derived from a module context are counted for the operation.
+	derived, cancel := context.WithCancel(mctx)
+	defer cancel()
+	get(derived)
+	// Requests made without a module context are not counted.
+	get(context.Background())
+
+	// Calls without an HTTP client are recorded with the context, and calls without a provider
+	// are recorded as other calls.
+	RecordAPICall(derived, APIProviderPostgres)
+	RecordAPICall(context.Background(), APIProviderPostgres)
+	mctx.APICall("")
+
+	calls := &mctx.(*moduleContext).apiCalls
+	assert.Equal(t, int64(4), calls.total())
+	assert.Equal(t, map[string]int64{"google": 2, "postgres": 1, "other": 1}, calls.byProvider())
+}
+
+func TestCountAPICallsOf(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+	client := &http.Client{Transport: CountAPICallsOf(APIProviderKubernetes)(http.DefaultTransport)}
+
+	mctx := InputsToContext(context.Background(), nil)
+	req, err := http.NewRequestWithContext(mctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, map[string]int64{"kubernetes": 1}, mctx.(*moduleContext).apiCalls.byProvider())
+	assert.Nil(t, InputsToContext(context.Background(), nil).(*moduleContext).apiCalls.byProvider())
+}
@@ -4,9 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
+
+	"k8s.io/client-go/kubernetes"
 )
 
 var registeredModuleFactories = make(map[string]func() Module)
@@ -30,6 +35,10 @@ type OutputValue struct {
 	// Type is the type of the value. This is used to provide context about what type
 	// of value is expected.
 	Type reflect.Type
+
+	// Sensitive indicates that the value is secret, such as a private key. Sensitive values are
+	// masked when operation outputs are recorded in the workflow status.
+	Sensitive bool
 }
 
 // InputValue is a structure that describes a value used in a module's inputs.
@@ -51,6 +60,16 @@ type InputValue struct {
 
 	// Default is an optional default value for the input parameter.
 	Default any
+
+	// Sensitive indicates that the value is secret, such as a password or token. Sensitive values
+	// are masked when operation inputs are recorded in the workflow status.
+	Sensitive bool
+
+	// Immutable indicates that the resource cannot be changed in place to a new value, such as the
+	// name of an API key. When the value differs from the last successful run of the operation, the
+	// operation is tainted so the module replaces the resource, or fails when replacing it would
+	// delete data. Changes are only detected when the workflow has a StateStore.
+	Immutable bool
 }
 
 // SupportedTypes returns the accepted input types.
@@ -83,6 +102,18 @@ func (i InputValue) TypeDisplay() string {
 	return strings.Join(names, ", ")
 }
 
+// Accepts reports whether a static value, such as a value decoded from YAML, can be used for the
+// input.
+func (i InputValue) Accepts(value any) bool {
+	return matchesAnyType(value, i.SupportedTypes())
+}
+
+// AcceptsOutput reports whether the output of another operation can be used for the input. The
+// output type must be one of the input types.
+func (i InputValue) AcceptsOutput(output OutputValue) bool {
+	return containsExactType(output.Type, i.SupportedTypes())
+}
+
 // ModuleInfo is a static structure that provides information about a module. It is used to
 // provide metadata about the module, such as its name, description, and the inputs it requires
 // and outputs it provides.
@@ -112,6 +143,22 @@ type ModuleInfo struct {
 	// Examples is a map of example titles to their YAML implementations. This is used to provide
 	// users with a quick way to understand how to use the module.
 	Examples map[string]string
+
+	// OutputsOnly indicates that the operations of the module do not change any resource and only
+	// produce outputs for other operations, such as reading a value or opening a connection. The
+	// workflow linter warns about operations of these modules whose outputs are never used.
+	OutputsOnly bool
+
+	// ReadsWorkflowOutputs indicates that the module reads outputs of the operations in its
+	// dependsOn with ContextWorkflowOutput, so each of them must be listed even when it is already
+	// a dependency of another listed operation.
+	ReadsWorkflowOutputs bool
+
+	// SerializationKey is an optional function that returns a key identifying the target of an
+	// operation, such as a database instance. Operations with the same non-empty key are never
+	// checked or set at the same time, including operations of different workflows that run in
+	// parallel. Modules that are safe to run concurrently against the same target leave this nil.
+	SerializationKey func(ctx ModuleContext) (string, error)
 }
 
 // Module is the interface that all modules must implement. Modules are used to configure resources
@@ -138,6 +185,37 @@ type Module interface {
 
 // --8<-- [end:Module]
 
+// BatchChecker is an optional interface for modules that can check the state of several operations
+// at once, for example by listing all users of an instance with a single API request instead of
+// reading each user separately. When a module implements BatchChecker, the workflow runtime groups
+// ready operations that use the same module and calls CheckMany once for the group instead of
+// calling Check for each operation. Set is still called for each operation that does not pass the
+// check.
+// --8<-- [start:BatchChecker]
+type BatchChecker interface {
+	// CheckMany checks the expected state for each of the module contexts and returns the results
+	// in the same order as the contexts. It must behave as if Check was called for each context,
+	// including setting the outputs of each context that passes the check. If an error is
+	// returned, the check of each of the operations fails with the error.
+	CheckMany(ctxs []ModuleContext) ([]bool, error)
+}
+
+// --8<-- [end:BatchChecker]
+
+// Preflighter is an optional interface for modules that can verify, before any operation is
+// executed, that the systems used by an operation are reachable and that Blackstart is authorized
+// to use them. Preflight checks of all operations are run in the Preflight phase, and any failures
+// are reported together so that a workflow fails before making changes.
+// --8<-- [start:Preflighter]
+type Preflighter interface {
+	// Preflight verifies connectivity and authorization for the operation. It must not change
+	// any resources. Preflight is only called for operations with static inputs, since outputs
+	// of dependencies are not available before execution.
+	Preflight(ctx ModuleContext) error
+}
+
+// --8<-- [end:Preflighter]
+
 // --8<-- [start:ModuleContext]
 type ModuleContext interface {
 	context.Context
@@ -145,6 +223,10 @@ type ModuleContext interface {
 	Output(key string, value interface{}) error
 	DoesNotExist() bool
 	Tainted() bool
+	Resource(id string)
+	APICall(provider string)
+	Logger() *slog.Logger
+	GetKubeClient(namespace, impersonate string) (kubernetes.Interface, error)
 }
 
 // --8<-- [end:ModuleContext]
@@ -178,6 +260,8 @@ func newModuleContext(ctx context.Context, op *Operation) *moduleContext {
 		outputValues: make(map[string]interface{}),
 		dne:          op.DoesNotExist,
 		tainted:      op.Tainted,
+		module:       op.Module,
+		id:           op.Id,
 	}
 }
 
@@ -187,10 +271,24 @@ type moduleContext struct {
 	ctx          context.Context
 	inputValues  map[string]Input
 	outputValues map[string]interface{}
+	resources    []string
+	apiCalls     apiCallCounts
 	dne          bool
 	tainted      bool
+	module       string
+	id           string
+	// derivedSensitive is set when the operation reads a sensitive value that its module does not
+	// mark as sensitive, so all of its outputs may hold the value and are treated as sensitive.
+	derivedSensitive bool
+	// storedImmutableInputs are the immutable input hashes of the last successful run of the
+	// operation, read from the StateStore.
+	storedImmutableInputs map[string]string
 }
 
+// moduleContextKey is the context key used to find the moduleContext of an operation from contexts
+// derived from it, such as the contexts of HTTP requests.
+type moduleContextKey struct{}
+
 // setInput is used to set an input value in the module context. This is primarily used to set a
 // value from a dependency.
 func (mc *moduleContext) setInput(key string, value interface{}) {
@@ -241,6 +339,36 @@ func (mc *moduleContext) Tainted() bool {
 	return mc.tainted
 }
 
+// Resource is used by modules to report the canonical identifier of a resource managed by the
+// operation, such as "namespace/name" for a Kubernetes Secret. Empty and repeated identifiers are
+// ignored, so a module may report the same resource from both Check and Set.
+func (mc *moduleContext) Resource(id string) {
+	if id == "" || slices.Contains(mc.resources, id) {
+		return
+	}
+	mc.resources = append(mc.resources, id)
+}
+
+// APICall is used by modules to record a call to the external API of a provider made by the
+// operation, such as APIProviderPostgres for a database query. Modules using an HTTP client may
+// wrap its transport with CountAPICalls instead.
+func (mc *moduleContext) APICall(provider string) {
+	mc.apiCalls.add(provider)
+}
+
+// Logger returns the logger for modules to log with. Records include the module and operation id,
+// and the level honors the module log level overrides of the runtime configuration.
+func (mc *moduleContext) Logger() *slog.Logger {
+	logger, ok := mc.ctx.Value(LoggerKey).(*slog.Logger)
+	if !ok {
+		logger = NewLogger(nil)
+	}
+	if mc.module == "" {
+		return logger
+	}
+	return moduleLogger(logger, mc.module).With("module", mc.module, "id", mc.id)
+}
+
 func (mc *moduleContext) Deadline() (deadline time.Time, ok bool) {
 	return mc.ctx.Deadline()
 }
@@ -254,6 +382,9 @@ func (mc *moduleContext) Err() error {
 }
 
 func (mc *moduleContext) Value(key any) any {
+	if _, ok := key.(moduleContextKey); ok {
+		return mc
+	}
 	return mc.ctx.Value(key)
 }
 
@@ -272,6 +403,11 @@ type Input interface {
 type moduleInput struct {
 	anyValue              any
 	dependencyOutputValue *dependencyOutput
+	sensitive             bool
+	// defaultValue is the default of an input from a dependency, used when the dependency does not
+	// produce the output. It is only set when hasDefault is true.
+	defaultValue any
+	hasDefault   bool
 }
 
 // IsStatic returns true if the input is a static value, false if it is only available at runtime.
@@ -306,6 +442,13 @@ func NewInputFromValue(value interface{}) Input {
 	return &moduleInput{anyValue: value}
 }
 
+// NewSensitiveInputFromValue creates a new module input from a static value that is secret, such
+// as a decrypted value. The value is masked when the inputs of the operation are recorded,
+// regardless of the input configuration of the module.
+func NewSensitiveInputFromValue(value interface{}) Input {
+	return &moduleInput{anyValue: value, sensitive: true}
+}
+
 // NewInputFromDep creates a new module input from a dependency output. This is used to reference
 // the output of another operation as the input to this operation. These inputs are only available
 // at runtime.
@@ -318,6 +461,26 @@ func NewInputFromDep(id string, output string) Input {
 	}
 }
 
+// NewInputFromDepWithDefault creates a new module input from a dependency output, like
+// NewInputFromDep, with a static default value. The default is used when the dependency operation
+// completes without producing the output, such as an optional output of a module.
+func NewInputFromDepWithDefault(id string, output string, value interface{}) Input {
+	input := NewInputFromDep(id, output).(*moduleInput)
+	input.defaultValue = value
+	input.hasDefault = true
+	return input
+}
+
+// DependencyDefault returns the default value of an input from a dependency, and whether the input
+// has a default. See NewInputFromDepWithDefault.
+func DependencyDefault(input Input) (any, bool) {
+	mi, ok := input.(*moduleInput)
+	if !ok || !mi.hasDefault {
+		return nil, false
+	}
+	return mi.defaultValue, true
+}
+
 // dependencyOutput is used to represent an input that is provided by the output of another
 // operation.
 type dependencyOutput struct {
@@ -436,3 +599,23 @@ func InputsToContext(ctx context.Context, si map[string]Input, flags ...ModuleCo
 func OpContext(ctx context.Context, op *Operation) ModuleContext {
 	return newModuleContext(ctx, op)
 }
+
+// ContextOutputs returns the outputs set by a module on a ModuleContext with Output. This is
+// available as an exported helper for testing modules.
+func ContextOutputs(ctx ModuleContext) map[string]any {
+	mc, ok := ctx.(*moduleContext)
+	if !ok {
+		return nil
+	}
+	return maps.Clone(mc.outputValues)
+}
+
+// ContextResources returns the resource identifiers reported to a ModuleContext with Resource. This
+// is available as an exported helper for testing modules.
+func ContextResources(ctx ModuleContext) []string {
+	mc, ok := ctx.(*moduleContext)
+	if !ok {
+		return nil
+	}
+	return slices.Clone(mc.resources)
+}
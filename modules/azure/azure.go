@@ -0,0 +1,104 @@
+package azure
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+
+	"github.com/pezops/blackstart"
+)
+
+const (
+	InputTenantID     = "tenant_id"
+	InputClientID     = "client_id"
+	InputClientSecret = "client_secret"
+)
+
+// CredentialInputs returns the inputs of a module merged with the inputs used to select the
+// Microsoft Entra ID credential of Azure API calls.
+func CredentialInputs(inputs map[string]blackstart.InputValue) map[string]blackstart.InputValue {
+	merged := map[string]blackstart.InputValue{
+		InputTenantID: {
+			Description: "Microsoft Entra tenant ID of the service principal set by `client_id` and `client_secret`.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+		},
+		InputClientID: {
+			Description: "Client ID of a service principal to authenticate as instead of the default credential. Requires `tenant_id` and `client_secret`.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+		},
+		InputClientSecret: {
+			Description: "Client secret of the service principal set by `client_id`.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+			Sensitive:   true,
+		},
+	}
+	maps.Copy(merged, inputs)
+	return merged
+}
+
+// ValidateCredentialInputs validates the credential inputs of an operation. The tenant ID, client
+// ID, and client secret of a service principal must be set together.
+func ValidateCredentialInputs(op blackstart.Operation) error {
+	set := 0
+	for _, key := range []string{InputTenantID, InputClientID, InputClientSecret} {
+		if _, ok := op.Inputs[key]; ok {
+			set++
+		}
+	}
+	if set != 0 && set != 3 {
+		return fmt.Errorf(
+			"inputs '%s', '%s', and '%s' must be set together", InputTenantID, InputClientID, InputClientSecret,
+		)
+	}
+	return nil
+}
+
+// ContextCredential returns the Microsoft Entra ID credential of a module. When the service
+// principal inputs are set, a client secret credential is used. Otherwise, the default Azure
+// credential is used, which supports environment variables, workload identity, managed identity,
+// and the Azure CLI.
+func ContextCredential(ctx blackstart.ModuleContext) (azcore.TokenCredential, error) {
+	values := make(map[string]string, 3)
+	for _, key := range []string{InputTenantID, InputClientID, InputClientSecret} {
+		value, err := blackstart.ContextInputAs[string](ctx, key, false)
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+
+	opts := ClientOptions()
+	if values[InputClientID] != "" {
+		cred, cErr := azidentity.NewClientSecretCredential(
+			values[InputTenantID], values[InputClientID], values[InputClientSecret],
+			&azidentity.ClientSecretCredentialOptions{ClientOptions: opts},
+		)
+		if cErr != nil {
+			return nil, fmt.Errorf("failed to create client secret credential: %w", cErr)
+		}
+		return cred, nil
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(&azidentity.DefaultAzureCredentialOptions{ClientOptions: opts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create default Azure credential: %w", err)
+	}
+	return cred, nil
+}
+
+// ClientOptions returns the Azure SDK client options of Blackstart. Requests use the outbound HTTP
+// transport of blackstart.HTTPTransport and are counted as API calls of the operation whose
+// context they are made with.
+func ClientOptions() azcore.ClientOptions {
+	return azcore.ClientOptions{
+		Telemetry: policy.TelemetryOptions{ApplicationID: "blackstart"},
+		Transport: &http.Client{Transport: blackstart.CountAPICalls(blackstart.APIProviderAzure, nil)},
+	}
+}
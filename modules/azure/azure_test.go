@@ -0,0 +1,27 @@
+package azure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestValidateCredentialInputs(t *testing.T) {
+	op := blackstart.Operation{Inputs: map[string]blackstart.Input{}}
+	require.NoError(t, ValidateCredentialInputs(op))
+
+	op.Inputs[InputClientID] = blackstart.NewInputFromValue("client")
+	require.ErrorContains(t, ValidateCredentialInputs(op), "must be set together")
+
+	op.Inputs[InputTenantID] = blackstart.NewInputFromValue("tenant")
+	op.Inputs[InputClientSecret] = blackstart.NewInputFromValue("secret")
+	require.NoError(t, ValidateCredentialInputs(op))
+}
+
+func TestCredentialInputs(t *testing.T) {
+	inputs := CredentialInputs(map[string]blackstart.InputValue{"name": {Required: true}})
+	require.Contains(t, inputs, "name")
+	require.True(t, inputs[InputClientSecret].Sensitive)
+}
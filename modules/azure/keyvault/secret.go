@@ -0,0 +1,407 @@
+package keyvault
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/azure"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	inputVaultURL     = "vault_url"
+	inputName         = "name"
+	inputValue        = "value"
+	inputContentType  = "content_type"
+	inputUpdatePolicy = "update_policy"
+
+	outputID      = "id"
+	outputVersion = "version"
+)
+
+const (
+	updatePolicyOverwrite   = "overwrite"
+	updatePolicyPreserveAny = "preserve_any"
+)
+
+var updatePolicies = map[string]struct{}{
+	updatePolicyOverwrite:   {},
+	updatePolicyPreserveAny: {},
+}
+
+func init() {
+	blackstart.RegisterPathName("keyvault", "Key Vault")
+	blackstart.RegisterModule("azure_keyvault_secret", NewSecret)
+}
+
+var _ blackstart.Module = &secret{}
+
+// SecretsAPI creates the Key Vault secrets clients of the azure_keyvault_secret module. A provider
+// set in the workflow context with SecretsAPIKey replaces the default clients, so tests can use a
+// fake Key Vault and deployments can route the requests through their own HTTP clients, such as one
+// for an egress proxy.
+type SecretsAPI interface {
+	// SecretsClient returns a client of the vault, authenticated with the credential inputs of the
+	// operation.
+	SecretsClient(ctx blackstart.ModuleContext, vaultURL string) (*azsecrets.Client, error)
+}
+
+type contextKey string
+
+// SecretsAPIKey is the context key of the SecretsAPI of the Key Vault modules.
+const SecretsAPIKey contextKey = "secretsAPI"
+
+// keyVaultRuntime provides injectable credential and client option dependencies.
+type keyVaultRuntime struct {
+	credential    func(blackstart.ModuleContext) (azcore.TokenCredential, error)
+	clientOptions func() (*azsecrets.ClientOptions, error)
+}
+
+// defaultKeyVaultRuntime creates the production Key Vault runtime.
+func defaultKeyVaultRuntime() *keyVaultRuntime {
+	return &keyVaultRuntime{
+		credential: azure.ContextCredential,
+		clientOptions: func() (*azsecrets.ClientOptions, error) {
+			return &azsecrets.ClientOptions{ClientOptions: azure.ClientOptions()}, nil
+		},
+	}
+}
+
+// SecretsClient creates a Key Vault secrets client with the credential and client options of the
+// runtime.
+func (r *keyVaultRuntime) SecretsClient(ctx blackstart.ModuleContext, vaultURL string) (*azsecrets.Client, error) {
+	cred, err := r.credential(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := r.clientOptions()
+	if err != nil {
+		return nil, err
+	}
+	c, err := azsecrets.NewClient(strings.TrimRight(vaultURL, "/"), cred, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Key Vault client: %w", err)
+	}
+	return c, nil
+}
+
+// NewSecret creates a module that manages an Azure Key Vault secret.
+func NewSecret() blackstart.Module {
+	return &secret{runtime: defaultKeyVaultRuntime()}
+}
+
+// secret implements the azure_keyvault_secret module.
+type secret struct {
+	runtime *keyVaultRuntime
+}
+
+func (m *secret) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "azure_keyvault_secret",
+		Name: "Azure Key Vault secret",
+		Description: util.CleanString(
+			`
+Ensures a secret exists in an Azure Key Vault. Setting a different value creates a new version of
+the secret.
+
+When a soft-deleted secret with the same name exists, it is recovered before its value is set. When
+'''doesNotExist''' is set, the secret is deleted. Vaults with soft delete keep deleted secrets
+recoverable for the retention period of the vault.
+
+**Update Policies**
+
+- '''preserve_any''' - Any existing secret value is preserved. This is the default update policy.
+- '''overwrite''' - The secret value and content type are updated when they differ from the inputs.
+`,
+		),
+		Requirements: []string{
+			"The Key Vault must exist.",
+			"The identity must be allowed to get, set, and delete secrets, and to recover deleted secrets. With Azure RBAC, the [Key Vault Secrets Officer](https://learn.microsoft.com/azure/role-based-access-control/built-in-roles/security#key-vault-secrets-officer) role grants these permissions.",
+			"If the service principal inputs are not set, the [default Azure credential](https://learn.microsoft.com/azure/developer/go/sdk/authentication/credential-chains#defaultazurecredential-overview) is used, such as workload identity or a managed identity.",
+		},
+		Inputs: azure.CredentialInputs(
+			map[string]blackstart.InputValue{
+				inputVaultURL: {
+					Description: "URL of the Key Vault, for example `https://example.vault.azure.net`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputName: {
+					Description: "Name of the secret.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputValue: {
+					Description: "Secret value. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Sensitive:   true,
+				},
+				inputContentType: {
+					Description: "Content type of the secret, for example `text/plain`. If not set, the content type is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputUpdatePolicy: {
+					Description: "Update policy for an existing secret. One of `preserve_any` or `overwrite`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     updatePolicyPreserveAny,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the current version of the secret.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputVersion: {
+				Description: "Current version of the secret.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Database password": `id: db-password-secret
+module: azure_keyvault_secret
+inputs:
+  vault_url: https://example.vault.azure.net
+  name: db-password
+  value:
+    fromDependency:
+      id: db-password
+      output: value
+  content_type: text/plain`,
+			"Overwrite with a service principal": `id: api-key-secret
+module: azure_keyvault_secret
+inputs:
+  tenant_id: 00000000-0000-0000-0000-000000000000
+  client_id: 11111111-1111-1111-1111-111111111111
+  client_secret:
+    fromFile:
+      path: /var/run/secrets/azure/client-secret
+  vault_url: https://example.vault.azure.net
+  name: api-key
+  value: example-value
+  update_policy: overwrite`,
+		},
+	}
+}
+
+func (m *secret) Validate(op blackstart.Operation) error {
+	if err := azure.ValidateCredentialInputs(op); err != nil {
+		return err
+	}
+	for _, key := range []string{inputVaultURL, inputName} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", key)
+		}
+		if input.IsStatic() {
+			if _, err := blackstart.InputAs[string](input, true); err != nil {
+				return fmt.Errorf("parameter %s is invalid: %w", key, err)
+			}
+		}
+	}
+
+	if input, ok := op.Inputs[inputUpdatePolicy]; ok && input.IsStatic() {
+		policy, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputUpdatePolicy, err)
+		}
+		if _, ok = updatePolicies[policy]; policy != "" && !ok {
+			return fmt.Errorf("parameter %s has invalid value '%s'", inputUpdatePolicy, policy)
+		}
+	}
+
+	if _, ok := op.Inputs[inputValue]; !ok && !op.DoesNotExist {
+		return fmt.Errorf("missing required parameter: %s", inputValue)
+	}
+	return nil
+}
+
+func (m *secret) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, name, err := m.contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := c.GetSecret(ctx, name, "", nil)
+	if err != nil && !isNotFound(err) {
+		return false, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	exists := err == nil
+
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists {
+		return false, nil
+	}
+
+	policy, err := contextUpdatePolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if policy == updatePolicyOverwrite {
+		value, vErr := blackstart.ContextInputAs[string](ctx, inputValue, true)
+		if vErr != nil {
+			return false, vErr
+		}
+		contentType, cErr := blackstart.ContextInputAs[string](ctx, inputContentType, false)
+		if cErr != nil {
+			return false, cErr
+		}
+		if value != deref(resp.Value) {
+			return false, nil
+		}
+		if contentType != "" && contentType != deref(resp.ContentType) {
+			return false, nil
+		}
+	}
+	return true, outputSecret(ctx, resp.Secret)
+}
+
+func (m *secret) Set(ctx blackstart.ModuleContext) error {
+	c, name, err := m.contextClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		_, err = c.DeleteSecret(ctx, name, nil)
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete secret %s: %w", name, err)
+		}
+		return nil
+	}
+
+	value, err := blackstart.ContextInputAs[string](ctx, inputValue, true)
+	if err != nil {
+		return err
+	}
+	contentType, err := blackstart.ContextInputAs[string](ctx, inputContentType, false)
+	if err != nil {
+		return err
+	}
+	params := azsecrets.SetSecretParameters{Value: to.Ptr(value)}
+	if contentType != "" {
+		params.ContentType = to.Ptr(contentType)
+	}
+
+	resp, err := c.SetSecret(ctx, name, params, nil)
+	if isConflict(err) {
+		// The name is held by a soft-deleted secret, which must be recovered before it is set.
+		if err = recoverSecret(ctx, c, name); err != nil {
+			return err
+		}
+		resp, err = c.SetSecret(ctx, name, params, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set secret %s: %w", name, err)
+	}
+	return outputSecret(ctx, resp.Secret)
+}
+
+// contextClient creates the Key Vault secrets client with the SecretsAPI of the context, or with the
+// runtime when the context does not provide one, and returns it with the secret name.
+func (m *secret) contextClient(ctx blackstart.ModuleContext) (*azsecrets.Client, string, error) {
+	vaultURL, err := blackstart.ContextInputAs[string](ctx, inputVaultURL, true)
+	if err != nil {
+		return nil, "", err
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, "", err
+	}
+
+	api, ok := ctx.Value(SecretsAPIKey).(SecretsAPI)
+	if !ok || api == nil {
+		if m.runtime != nil {
+			api = m.runtime
+		} else {
+			api = defaultKeyVaultRuntime()
+		}
+	}
+	c, err := api.SecretsClient(ctx, vaultURL)
+	if err != nil {
+		return nil, "", err
+	}
+	return c, name, nil
+}
+
+// recoverSecret recovers a soft-deleted secret and waits until it can be read again.
+func recoverSecret(ctx blackstart.ModuleContext, c *azsecrets.Client, name string) error {
+	if _, err := c.RecoverDeletedSecret(ctx, name, nil); err != nil {
+		return fmt.Errorf("failed to recover deleted secret %s: %w", name, err)
+	}
+	return blackstart.WaitForPropagation(
+		ctx, fmt.Sprintf("recovery of deleted secret %s", name), func() (bool, error) {
+			_, err := c.GetSecret(ctx, name, "", nil)
+			if err == nil {
+				return true, nil
+			}
+			if isNotFound(err) {
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to get secret %s: %w", name, err)
+		},
+	)
+}
+
+// contextUpdatePolicy returns the runtime update policy from a module context.
+func contextUpdatePolicy(ctx blackstart.ModuleContext) (string, error) {
+	policy, err := blackstart.ContextInputAs[string](ctx, inputUpdatePolicy, false)
+	if err != nil {
+		return "", err
+	}
+	policy = strings.TrimSpace(policy)
+	if policy == "" {
+		return updatePolicyPreserveAny, nil
+	}
+	if _, ok := updatePolicies[policy]; !ok {
+		return "", fmt.Errorf("input '%s' has invalid value '%s'", inputUpdatePolicy, policy)
+	}
+	return policy, nil
+}
+
+// outputSecret sets the outputs of the module for the current version of the secret.
+func outputSecret(ctx blackstart.ModuleContext, s azsecrets.Secret) error {
+	var id, version string
+	if s.ID != nil {
+		id = string(*s.ID)
+		version = s.ID.Version()
+	}
+	if err := ctx.Output(outputID, id); err != nil {
+		return err
+	}
+	return ctx.Output(outputVersion, version)
+}
+
+// isNotFound reports whether err is a Key Vault 404 response.
+func isNotFound(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// isConflict reports whether err is a Key Vault 409 response, such as for a secret name that is
+// held by a soft-deleted secret.
+func isConflict(err error) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusConflict
+}
+
+// deref returns the value of a string pointer, or an empty string when it is nil.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,265 @@
+package keyvault
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeCredential is a token credential that returns a static token.
+type fakeCredential struct{}
+
+func (fakeCredential) GetToken(context.Context, policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	return azcore.AccessToken{Token: "test-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+// fakeSecret is a secret version stored by fakeKeyVault.
+type fakeSecret struct {
+	Value       string `json:"value"`
+	ContentType string `json:"contentType,omitempty"`
+	ID          string `json:"id"`
+}
+
+// fakeKeyVault implements the Key Vault secrets API methods used by the module.
+type fakeKeyVault struct {
+	server  *httptest.Server
+	secrets map[string]*fakeSecret
+	deleted map[string]*fakeSecret
+	calls   []string
+	version int
+	mu      sync.Mutex
+}
+
+// newFakeKeyVault starts a fake Key Vault server.
+func newFakeKeyVault(t *testing.T) *fakeKeyVault {
+	t.Helper()
+	f := &fakeKeyVault{secrets: map[string]*fakeSecret{}, deleted: map[string]*fakeSecret{}}
+	f.server = httptest.NewTLSServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeKeyVault) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.Header().Set(
+			"WWW-Authenticate",
+			`Bearer authorization="https://login.microsoftonline.com/tenant", resource="https://vault.azure.net"`,
+		)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	f.calls = append(f.calls, r.Method+" "+strings.Join(parts, "/"))
+	name := parts[1]
+	switch {
+	case parts[0] == "deletedsecrets" && r.Method == http.MethodPost:
+		s, ok := f.deleted[name]
+		if !ok {
+			writeKeyVaultError(w, http.StatusNotFound, "SecretNotFound")
+			return
+		}
+		delete(f.deleted, name)
+		f.secrets[name] = s
+		_ = json.NewEncoder(w).Encode(s)
+	case r.Method == http.MethodGet:
+		s, ok := f.secrets[name]
+		if !ok {
+			writeKeyVaultError(w, http.StatusNotFound, "SecretNotFound")
+			return
+		}
+		_ = json.NewEncoder(w).Encode(s)
+	case r.Method == http.MethodPut:
+		if _, ok := f.deleted[name]; ok {
+			writeKeyVaultError(w, http.StatusConflict, "Conflict")
+			return
+		}
+		var s fakeSecret
+		_ = json.NewDecoder(r.Body).Decode(&s)
+		f.version++
+		s.ID = fmt.Sprintf("%s/secrets/%s/v%d", f.server.URL, name, f.version)
+		f.secrets[name] = &s
+		_ = json.NewEncoder(w).Encode(s)
+	case r.Method == http.MethodDelete:
+		s, ok := f.secrets[name]
+		if !ok {
+			writeKeyVaultError(w, http.StatusNotFound, "SecretNotFound")
+			return
+		}
+		delete(f.secrets, name)
+		f.deleted[name] = s
+		_ = json.NewEncoder(w).Encode(s)
+	default:
+		writeKeyVaultError(w, http.StatusBadRequest, "BadParameter")
+	}
+}
+
+// writeKeyVaultError writes a Key Vault error response.
+func writeKeyVaultError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error": map[string]string{"code": code, "message": code}})
+}
+
+// newTestSecret creates the module with a runtime that targets the fake server.
+func newTestSecret(f *fakeKeyVault) blackstart.Module {
+	return &secret{
+		runtime: &keyVaultRuntime{
+			credential: func(blackstart.ModuleContext) (azcore.TokenCredential, error) {
+				return fakeCredential{}, nil
+			},
+			clientOptions: func() (*azsecrets.ClientOptions, error) {
+				opts := &azsecrets.ClientOptions{DisableChallengeResourceVerification: true}
+				opts.Transport = f.server.Client()
+				return opts, nil
+			},
+		},
+	}
+}
+
+// secretOperation returns an azure_keyvault_secret operation targeting the fake server.
+func secretOperation(f *fakeKeyVault, inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{
+		Id:     "secret",
+		Module: "azure_keyvault_secret",
+		Inputs: map[string]blackstart.Input{
+			inputVaultURL: blackstart.NewInputFromValue(f.server.URL),
+			inputName:     blackstart.NewInputFromValue("db-password"),
+			inputValue:    blackstart.NewInputFromValue("s3cret"),
+		},
+	}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestSecret_Create(t *testing.T) {
+	f := newFakeKeyVault(t)
+	m := newTestSecret(f)
+	op := secretOperation(f, map[string]any{inputContentType: "text/plain"})
+	require.NoError(t, m.Validate(*op))
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "s3cret", f.secrets["db-password"].Value)
+	require.Equal(t, "text/plain", f.secrets["db-password"].ContentType)
+	require.Equal(t, "v1", ctx.outputs[outputVersion])
+	require.Equal(t, f.server.URL+"/secrets/db-password/v1", ctx.outputs[outputID])
+
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "v1", ctx.outputs[outputVersion])
+}
+
+func TestSecret_UpdatePolicy(t *testing.T) {
+	f := newFakeKeyVault(t)
+	f.secrets["db-password"] = &fakeSecret{Value: "old", ID: f.server.URL + "/secrets/db-password/v0"}
+	m := newTestSecret(f)
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), secretOperation(f, nil)))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op := secretOperation(f, map[string]any{inputUpdatePolicy: updatePolicyOverwrite})
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(t, "s3cret", f.secrets["db-password"].Value)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestSecret_RecoversDeletedSecret(t *testing.T) {
+	f := newFakeKeyVault(t)
+	f.deleted["db-password"] = &fakeSecret{Value: "old", ID: f.server.URL + "/secrets/db-password/v0"}
+	m := newTestSecret(f)
+
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), secretOperation(f, nil))))
+	require.Equal(t, "s3cret", f.secrets["db-password"].Value)
+	require.Contains(t, f.calls, "POST deletedsecrets/db-password/recover")
+}
+
+func TestSecret_Delete(t *testing.T) {
+	f := newFakeKeyVault(t)
+	f.secrets["db-password"] = &fakeSecret{Value: "old", ID: f.server.URL + "/secrets/db-password/v0"}
+	m := newTestSecret(f)
+	op := secretOperation(f, nil)
+	delete(op.Inputs, inputValue)
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.secrets)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestSecret_Validate(t *testing.T) {
+	f := newFakeKeyVault(t)
+	m := newTestSecret(f)
+
+	require.ErrorContains(
+		t, m.Validate(*secretOperation(f, map[string]any{inputUpdatePolicy: "keep"})), "invalid value 'keep'",
+	)
+	require.ErrorContains(
+		t, m.Validate(*secretOperation(f, map[string]any{"client_id": "id"})), "must be set together",
+	)
+	op := secretOperation(f, nil)
+	delete(op.Inputs, inputValue)
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: value")
+}
+
+func TestSecret_ContextSecretsAPI(t *testing.T) {
+	f := newFakeKeyVault(t)
+	api := newTestSecret(f).(*secret).runtime
+	m := NewSecret()
+
+	ctx := context.WithValue(context.Background(), SecretsAPIKey, SecretsAPI(api))
+	require.NoError(t, m.Set(blackstart.OpContext(ctx, secretOperation(f, nil))))
+	require.Equal(t, "s3cret", f.secrets["db-password"].Value)
+}
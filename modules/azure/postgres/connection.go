@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/azure"
+	"github.com/pezops/blackstart/util"
+)
+
+var requiredConnectionParameters = []string{inputHost, inputUsername}
+var _ blackstart.Module = &connectionModule{}
+var _ io.Closer = &connectionModule{}
+
+func init() {
+	blackstart.RegisterModule("azure_postgres_connection", NewConnection)
+}
+
+// NewConnection creates a module that connects to an Azure Database for PostgreSQL flexible server
+// with Microsoft Entra authentication.
+func NewConnection() blackstart.Module {
+	return &connectionModule{credential: azure.ContextCredential}
+}
+
+// connectionTarget is the server, database, and user of a connection.
+type connectionTarget struct {
+	host     string
+	port     int
+	database string
+	username string
+	sslMode  string
+}
+
+// dsn returns the keyword/value connection string of the target, without a password.
+func (t connectionTarget) dsn() string {
+	return fmt.Sprintf(
+		"host=%s port=%d dbname=%s user=%s sslmode=%s",
+		dsnValue(t.host), t.port, dsnValue(t.database), dsnValue(t.username), dsnValue(t.sslMode),
+	)
+}
+
+// connectionModule implements the azure_postgres_connection module.
+type connectionModule struct {
+	db *sql.DB
+	// credential provides the Microsoft Entra credential of the connection.
+	credential func(blackstart.ModuleContext) (azcore.TokenCredential, error)
+}
+
+func (c *connectionModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "azure_postgres_connection",
+		Name: "Azure Database for PostgreSQL connection",
+		Description: util.CleanString(
+			`
+Connection to an Azure Database for PostgreSQL flexible server authenticated with Microsoft Entra ID.
+A new access token is requested as the password of each database connection, so the connection
+remains usable after tokens expire.
+
+The connection can be used by the '''azure_postgres_user''' module to manage Microsoft Entra
+principals, and by the PostgreSQL modules, such as '''postgres_grant''', to manage grants.
+`,
+		),
+		Requirements: []string{
+			"The server must have [Microsoft Entra authentication](https://learn.microsoft.com/azure/postgresql/flexible-server/how-to-configure-sign-in-azure-ad-authentication) enabled.",
+			"The identity must be a Microsoft Entra principal of the server. To manage other principals with `azure_postgres_user`, it must be a Microsoft Entra administrator of the server.",
+			"The server must be reachable from the Blackstart runtime.",
+		},
+		Inputs: azure.CredentialInputs(
+			map[string]blackstart.InputValue{
+				inputHost: {
+					Description: "Hostname of the server, for example `example.postgres.database.azure.com`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputPort: {
+					Description: "Port number of the server.",
+					Type:        reflect.TypeFor[int](),
+					Required:    false,
+					Default:     5432,
+				},
+				inputDatabase: {
+					Description: "Name of the database to connect to. Microsoft Entra principals are managed in the `postgres` database.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     "postgres",
+				},
+				inputUsername: {
+					Description: "Name of the Microsoft Entra principal of the identity in the server, such as the user principal name of a user or the name of a managed identity.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputSslMode: {
+					Description: "SSL mode to use when connecting to the server. Options are 'require', 'verify-ca', or 'verify-full'.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     "require",
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputConnection: {
+				Description: "Database connection to the server authenticated as the Microsoft Entra principal.",
+				Type:        reflect.TypeFor[*sql.DB](),
+			},
+		},
+		Examples: map[string]string{
+			"Connect with a managed identity": `id: connect-db
+module: azure_postgres_connection
+inputs:
+  host: example.postgres.database.azure.com
+  username: blackstart-identity`,
+		},
+		OutputsOnly: true,
+	}
+}
+
+func (c *connectionModule) Validate(op blackstart.Operation) error {
+	if err := azure.ValidateCredentialInputs(op); err != nil {
+		return err
+	}
+	for _, p := range requiredConnectionParameters {
+		input, ok := op.Inputs[p]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", p)
+		}
+		if input.IsStatic() {
+			if _, err := blackstart.InputAs[string](input, true); err != nil {
+				return fmt.Errorf("parameter %s is invalid: %w", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *connectionModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	_, err := contextConnectionTarget(ctx)
+	if err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (c *connectionModule) Set(ctx blackstart.ModuleContext) error {
+	target, err := contextConnectionTarget(ctx)
+	if err != nil {
+		return err
+	}
+	credential := c.credential
+	if credential == nil {
+		credential = azure.ContextCredential
+	}
+	cred, err := credential(ctx)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(target, cred)
+	if err != nil {
+		return err
+	}
+	if err = db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return fmt.Errorf("error pinging database: %w", err)
+	}
+	c.db = db
+	if err = ctx.Output(outputConnection, c.db); err != nil {
+		_ = c.db.Close()
+		c.db = nil
+		return err
+	}
+	return nil
+}
+
+// Close releases the active database connection held by the module.
+func (c *connectionModule) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	err := c.db.Close()
+	c.db = nil
+	return err
+}
+
+// openDB opens a database handle for the target that authenticates each new connection with a
+// Microsoft Entra access token of the credential.
+func openDB(target connectionTarget, cred azcore.TokenCredential) (*sql.DB, error) {
+	config, err := pgx.ParseConfig(target.dsn())
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection settings: %w", err)
+	}
+	return stdlib.OpenDB(*config, stdlib.OptionBeforeConnect(tokenBeforeConnect(cred))), nil
+}
+
+// tokenBeforeConnect returns a function that sets a Microsoft Entra access token of the credential
+// as the password of a new connection.
+func tokenBeforeConnect(cred azcore.TokenCredential) func(context.Context, *pgx.ConnConfig) error {
+	return func(ctx context.Context, config *pgx.ConnConfig) error {
+		token, err := cred.GetToken(ctx, policy.TokenRequestOptions{Scopes: []string{tokenScope}})
+		if err != nil {
+			return fmt.Errorf("failed to get Microsoft Entra access token: %w", err)
+		}
+		config.Password = token.Token
+		return nil
+	}
+}
+
+// contextConnectionTarget reads the connection target from module inputs.
+func contextConnectionTarget(ctx blackstart.ModuleContext) (connectionTarget, error) {
+	host, err := blackstart.ContextInputAs[string](ctx, inputHost, true)
+	if err != nil {
+		return connectionTarget{}, err
+	}
+	port, err := blackstart.ContextInputAs[int](ctx, inputPort, true)
+	if err != nil {
+		return connectionTarget{}, err
+	}
+	database, err := blackstart.ContextInputAs[string](ctx, inputDatabase, true)
+	if err != nil {
+		return connectionTarget{}, err
+	}
+	username, err := blackstart.ContextInputAs[string](ctx, inputUsername, true)
+	if err != nil {
+		return connectionTarget{}, err
+	}
+	sslMode, err := blackstart.ContextInputAs[string](ctx, inputSslMode, true)
+	if err != nil {
+		return connectionTarget{}, err
+	}
+	return connectionTarget{host: host, port: port, database: database, username: username, sslMode: sslMode}, nil
+}
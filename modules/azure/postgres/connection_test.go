@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeCredential is a token credential that records the requested scopes.
+type fakeCredential struct {
+	scopes []string
+	err    error
+}
+
+func (f *fakeCredential) GetToken(_ context.Context, opts policy.TokenRequestOptions) (azcore.AccessToken, error) {
+	f.scopes = opts.Scopes
+	if f.err != nil {
+		return azcore.AccessToken{}, f.err
+	}
+	return azcore.AccessToken{Token: "entra-token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestConnectionTarget_DSN(t *testing.T) {
+	target := connectionTarget{
+		host: "example.postgres.database.azure.com", port: 5432, database: "postgres",
+		username: "Data Admins's group", sslMode: "require",
+	}
+	config, err := pgx.ParseConfig(target.dsn())
+	require.NoError(t, err)
+	require.Equal(t, "example.postgres.database.azure.com", config.Host)
+	require.Equal(t, "Data Admins's group", config.User)
+	require.Equal(t, "postgres", config.Database)
+	require.Empty(t, config.Password)
+}
+
+func TestTokenBeforeConnect(t *testing.T) {
+	cred := &fakeCredential{}
+	config := &pgx.ConnConfig{}
+	require.NoError(t, tokenBeforeConnect(cred)(context.Background(), config))
+	require.Equal(t, "entra-token", config.Password)
+	require.Equal(t, []string{tokenScope}, cred.scopes)
+
+	cred.err = errors.New("no identity")
+	require.ErrorContains(t, tokenBeforeConnect(cred)(context.Background(), config), "no identity")
+}
+
+func TestConnection_Validate(t *testing.T) {
+	m := NewConnection()
+	op := blackstart.Operation{
+		Id:     "connect-db",
+		Module: "azure_postgres_connection",
+		Inputs: map[string]blackstart.Input{
+			inputHost:     blackstart.NewInputFromValue("example.postgres.database.azure.com"),
+			inputUsername: blackstart.NewInputFromValue("blackstart-identity"),
+		},
+	}
+	require.NoError(t, m.Validate(op))
+
+	delete(op.Inputs, inputUsername)
+	require.ErrorContains(t, m.Validate(op), "missing required parameter: username")
+}
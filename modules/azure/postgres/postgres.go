@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"strings"
+
+	"github.com/pezops/blackstart"
+)
+
+const (
+	inputHost       = "host"
+	inputPort       = "port"
+	inputDatabase   = "database"
+	inputUsername   = "username"
+	inputSslMode    = "sslmode"
+	inputConnection = "connection"
+	inputName       = "name"
+	inputObjectID   = "object_id"
+	inputType       = "type"
+	inputAdmin      = "admin"
+
+	outputConnection = "connection"
+	outputName       = "name"
+)
+
+// tokenScope is the scope of the Microsoft Entra access tokens used as passwords to connect to Azure
+// Database for PostgreSQL.
+const tokenScope = "https://ossrdbms-aad.database.windows.net/.default"
+
+const (
+	principalTypeUser    = "user"
+	principalTypeGroup   = "group"
+	principalTypeService = "service"
+)
+
+var principalTypes = map[string]struct{}{
+	principalTypeUser:    {},
+	principalTypeGroup:   {},
+	principalTypeService: {},
+}
+
+func init() {
+	blackstart.RegisterPathName("postgres", "PostgreSQL")
+}
+
+// dsnValue quotes a value of a keyword/value PostgreSQL connection string.
+func dsnValue(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
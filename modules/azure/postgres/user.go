@@ -0,0 +1,327 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+var requiredUserParameters = []string{inputConnection, inputName}
+var _ blackstart.Module = &userModule{}
+
+// principalLabelQuery returns the pgaadauth security label of a role. No row is returned when the
+// role does not exist, and a NULL label when the role is not a Microsoft Entra principal.
+const principalLabelQuery = `
+SELECT s.label
+FROM pg_roles AS r
+LEFT JOIN pg_shseclabel AS s
+  ON s.objoid = r.oid AND s.classoid = 'pg_authid'::regclass AND s.provider = 'pgaadauth'
+WHERE r.rolname = $1`
+
+const createPrincipalQuery = `SELECT * FROM pgaadauth_create_principal($1, $2, false)`
+
+const createPrincipalWithOIDQuery = `SELECT * FROM pgaadauth_create_principal_with_oid($1, $2, $3, $4, false)`
+
+func init() {
+	blackstart.RegisterModule("azure_postgres_user", NewUser)
+}
+
+// NewUser creates a module that manages a Microsoft Entra principal of an Azure Database for
+// PostgreSQL flexible server.
+func NewUser() blackstart.Module {
+	return &userModule{}
+}
+
+// principal is a Microsoft Entra principal of a server, parsed from its pgaadauth security label,
+// such as `aadauth,oid=<object ID>,type=service,admin`.
+type principal struct {
+	objectID      string
+	principalType string
+	admin         bool
+}
+
+// parsePrincipalLabel parses the pgaadauth security label of a role.
+func parsePrincipalLabel(label string) principal {
+	var p principal
+	for _, part := range strings.Split(label, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "oid":
+			p.objectID = value
+		case "type":
+			p.principalType = value
+		case "admin":
+			p.admin = true
+		}
+	}
+	return p
+}
+
+// userModule implements the azure_postgres_user module.
+type userModule struct{}
+
+func (u *userModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "azure_postgres_user",
+		Name: "Azure Database for PostgreSQL Microsoft Entra user",
+		Description: util.CleanString(
+			`
+Ensures a Microsoft Entra user, group, or service principal, such as a managed identity, can log in
+to an Azure Database for PostgreSQL flexible server. The role of the principal is created with the
+'''pgaadauth''' extension functions of the server.
+
+When '''object_id''' is set, the principal is created for the object ID and '''type''', and the role
+is named '''name'''. This is required for service principals whose names are not unique, and allows
+the role name to differ from the principal name. Otherwise, the principal is looked up by its name,
+such as the user principal name of a user.
+
+Grants to the role are managed by the PostgreSQL modules, such as '''postgres_grant''', with the
+same connection.
+
+**Notes**
+
+- An existing role that is not a Microsoft Entra principal, or that has a different object ID, type, or
+  administrator setting, is not changed. The operation fails so the role can be reviewed and dropped.
+- When '''doesNotExist''' is set, the role is dropped. Objects owned by the role must be reassigned first.
+`,
+		),
+		Requirements: []string{
+			"A valid `connection` input from the `azure_postgres_connection` module, connected to the `postgres` database.",
+			"The connecting principal must be a Microsoft Entra administrator of the server.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputConnection: {
+				Description: "Database connection to the server, authenticated as a Microsoft Entra administrator.",
+				Type:        reflect.TypeFor[*sql.DB](),
+				Required:    true,
+			},
+			inputName: {
+				Description: "Name of the role. Without `object_id`, this is the name of the Microsoft Entra principal.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputObjectID: {
+				Description: "Object ID of the Microsoft Entra principal. For service principals and managed identities, this is the object ID of the service principal.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputType: {
+				Description: "Type of the principal set by `object_id`. One of `user`, `group`, or `service`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputAdmin: {
+				Description: "If true, the principal is created as a Microsoft Entra administrator of the server.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputName: {
+				Description: "Name of the role of the principal.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Managed identity with table grants": `operations:
+  - id: connect-db
+    module: azure_postgres_connection
+    inputs:
+      host: example.postgres.database.azure.com
+      username: blackstart-identity
+
+  - id: app-identity
+    module: azure_postgres_user
+    inputs:
+      connection:
+        fromDependency:
+          id: connect-db
+          output: connection
+      name: app-identity
+      object_id: 22222222-2222-2222-2222-222222222222
+      type: service
+
+  - id: grant-app-orders-select
+    module: postgres_grant
+    inputs:
+      connection:
+        fromDependency:
+          id: connect-db
+          output: connection
+      role:
+        fromDependency:
+          id: app-identity
+          output: name
+      permission: SELECT
+      scope: TABLE
+      schema: public
+      resource: orders`,
+			"User by user principal name": `id: dba-user
+module: azure_postgres_user
+inputs:
+  connection:
+    fromDependency:
+      id: connect-db
+      output: connection
+  name: dba@example.com`,
+		},
+	}
+}
+
+func (u *userModule) Validate(op blackstart.Operation) error {
+	for _, p := range requiredUserParameters {
+		if _, ok := op.Inputs[p]; !ok {
+			return fmt.Errorf("missing required parameter: %s", p)
+		}
+	}
+	if input := op.Inputs[inputName]; input.IsStatic() {
+		if _, err := blackstart.InputAs[string](input, true); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputName, err)
+		}
+	}
+
+	_, hasObjectID := op.Inputs[inputObjectID]
+	typeInput, hasType := op.Inputs[inputType]
+	if hasObjectID != hasType {
+		return fmt.Errorf("inputs '%s' and '%s' must be set together", inputObjectID, inputType)
+	}
+	if hasType && typeInput.IsStatic() {
+		principalType, err := blackstart.InputAs[string](typeInput, true)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputType, err)
+		}
+		if _, ok := principalTypes[principalType]; !ok {
+			return fmt.Errorf("parameter %s has invalid value '%s'", inputType, principalType)
+		}
+	}
+	return nil
+}
+
+func (u *userModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	db, desired, name, err := contextPrincipal(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, exists, err := getPrincipal(ctx, db, name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists || !principalMatches(current, desired) {
+		return false, nil
+	}
+	return true, ctx.Output(outputName, name)
+}
+
+func (u *userModule) Set(ctx blackstart.ModuleContext) error {
+	db, desired, name, err := contextPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
+		if _, err = db.ExecContext(ctx, "DROP ROLE IF EXISTS "+pq.QuoteIdentifier(name)); err != nil {
+			return fmt.Errorf("failed to drop role %s: %w", name, err)
+		}
+		return nil
+	}
+
+	current, exists, err := getPrincipal(ctx, db, name)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if current == nil {
+			return fmt.Errorf("role %s exists and is not a Microsoft Entra principal", name)
+		}
+		if !principalMatches(current, desired) {
+			return fmt.Errorf(
+				"role %s exists as Microsoft Entra principal with object ID '%s', type '%s', and admin %t",
+				name, current.objectID, current.principalType, current.admin,
+			)
+		}
+		return ctx.Output(outputName, name)
+	}
+
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
+	if desired.objectID != "" {
+		_, err = db.ExecContext(
+			ctx, createPrincipalWithOIDQuery, name, desired.objectID, desired.principalType, desired.admin,
+		)
+	} else {
+		_, err = db.ExecContext(ctx, createPrincipalQuery, name, desired.admin)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create Microsoft Entra principal %s: %w", name, err)
+	}
+	return ctx.Output(outputName, name)
+}
+
+// contextPrincipal reads the connection, the desired principal, and the role name from module
+// inputs.
+func contextPrincipal(ctx blackstart.ModuleContext) (*sql.DB, principal, string, error) {
+	db, err := blackstart.ContextInputAs[*sql.DB](ctx, inputConnection, true)
+	if err != nil {
+		return nil, principal{}, "", err
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, principal{}, "", err
+	}
+	objectID, err := blackstart.ContextInputAs[string](ctx, inputObjectID, false)
+	if err != nil {
+		return nil, principal{}, "", err
+	}
+	principalType, err := blackstart.ContextInputAs[string](ctx, inputType, false)
+	if err != nil {
+		return nil, principal{}, "", err
+	}
+	admin, err := blackstart.ContextInputAs[bool](ctx, inputAdmin, false)
+	if err != nil {
+		return nil, principal{}, "", err
+	}
+	return db, principal{objectID: objectID, principalType: principalType, admin: admin}, name, nil
+}
+
+// getPrincipal returns the Microsoft Entra principal of a role and whether the role exists. The
+// principal is nil when the role exists but is not a Microsoft Entra principal.
+func getPrincipal(ctx blackstart.ModuleContext, db *sql.DB, name string) (*principal, bool, error) {
+	var label sql.NullString
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
+	err := db.QueryRowContext(ctx, principalLabelQuery, name).Scan(&label)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get role %s: %w", name, err)
+	}
+	if !label.Valid {
+		return nil, true, nil
+	}
+	p := parsePrincipalLabel(label.String)
+	return &p, true, nil
+}
+
+// principalMatches reports whether the current principal matches the desired principal. The object
+// ID and type are only compared when an object ID is desired.
+func principalMatches(current *principal, desired principal) bool {
+	if current == nil || current.admin != desired.admin {
+		return false
+	}
+	if desired.objectID == "" {
+		return true
+	}
+	return strings.EqualFold(current.objectID, desired.objectID) && current.principalType == desired.principalType
+}
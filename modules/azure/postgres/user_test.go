@@ -0,0 +1,145 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// userOperation returns an azure_postgres_user operation using the connection.
+func userOperation(db *sql.DB, inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{
+		Id:     "user",
+		Module: "azure_postgres_user",
+		Inputs: map[string]blackstart.Input{
+			inputConnection: blackstart.NewInputFromValue(db),
+			inputName:       blackstart.NewInputFromValue("app-identity"),
+		},
+	}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+// expectLabel expects the principal label query of the app-identity role. A nil row means the
+// role does not exist.
+func expectLabel(mock sqlmock.Sqlmock, row []driver.Value) {
+	rows := sqlmock.NewRows([]string{"label"})
+	if row != nil {
+		rows.AddRow(row...)
+	}
+	mock.ExpectQuery(regexp.QuoteMeta(principalLabelQuery)).WithArgs("app-identity").WillReturnRows(rows)
+}
+
+func TestUser_CreateWithObjectID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	m := NewUser()
+	op := userOperation(db, map[string]any{inputObjectID: "22222222-2222-2222-2222-222222222222", inputType: "service"})
+	require.NoError(t, m.Validate(*op))
+
+	expectLabel(mock, nil)
+	expectLabel(mock, nil)
+	mock.ExpectExec(regexp.QuoteMeta(createPrincipalWithOIDQuery)).
+		WithArgs("app-identity", "22222222-2222-2222-2222-222222222222", "service", false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectLabel(mock, []driver.Value{"aadauth,oid=22222222-2222-2222-2222-222222222222,type=service"})
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUser_CreateByName(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	m := NewUser()
+
+	expectLabel(mock, nil)
+	mock.ExpectExec(regexp.QuoteMeta(createPrincipalQuery)).
+		WithArgs("app-identity", true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), userOperation(db, map[string]any{inputAdmin: true}))))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUser_ExistingRoleMismatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	m := NewUser()
+	op := userOperation(db, map[string]any{inputObjectID: "22222222-2222-2222-2222-222222222222", inputType: "service"})
+
+	expectLabel(mock, []driver.Value{nil})
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	expectLabel(mock, []driver.Value{nil})
+	require.ErrorContains(t, m.Set(blackstart.OpContext(context.Background(), op)), "not a Microsoft Entra principal")
+
+	expectLabel(mock, []driver.Value{"aadauth,oid=33333333-3333-3333-3333-333333333333,type=service,admin"})
+	require.ErrorContains(
+		t, m.Set(blackstart.OpContext(context.Background(), op)),
+		"object ID '33333333-3333-3333-3333-333333333333', type 'service', and admin true",
+	)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUser_Drop(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	m := NewUser()
+	op := userOperation(db, nil)
+	op.DoesNotExist = true
+
+	expectLabel(mock, []driver.Value{"aadauth,oid=22222222-2222-2222-2222-222222222222,type=service"})
+	mock.ExpectExec(regexp.QuoteMeta(`DROP ROLE IF EXISTS "app-identity"`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUser_Validate(t *testing.T) {
+	m := NewUser()
+
+	require.ErrorContains(
+		t, m.Validate(*userOperation(nil, map[string]any{inputObjectID: "22222222-2222-2222-2222-222222222222"})),
+		"must be set together",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(*userOperation(nil, map[string]any{inputObjectID: "22222222-2222-2222-2222-222222222222", inputType: "robot"})),
+		"invalid value 'robot'",
+	)
+	op := userOperation(nil, nil)
+	delete(op.Inputs, inputName)
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: name")
+}
+
+func TestParsePrincipalLabel(t *testing.T) {
+	require.Equal(
+		t, principal{objectID: "22222222-2222-2222-2222-222222222222", principalType: "user", admin: true},
+		parsePrincipalLabel("aadauth,oid=22222222-2222-2222-2222-222222222222,type=user,admin"),
+	)
+	require.Equal(t, principal{}, parsePrincipalLabel("aadauth"))
+}
@@ -33,6 +33,7 @@ func (m *certificateRequestModule) Info() blackstart.ModuleInfo {
 		Description: "PEM-encoded private key. Accepted formats: PKCS#8, PKCS#1 RSA, or SEC1 ECDSA. Encrypted private keys are not supported.",
 		Type:        reflect.TypeFor[string](),
 		Required:    true,
+		Sensitive:   true,
 	}
 
 	return blackstart.ModuleInfo{
@@ -68,6 +69,7 @@ inputs:
     - app.example.com
     - www.app.example.com`,
 		},
+		OutputsOnly: true,
 	}
 }
 
@@ -75,6 +75,7 @@ needed after the workflow completes.
 			outputPEM: {
 				Description: "PEM-encoded private key in PKCS#8 format.",
 				Type:        reflect.TypeFor[string](),
+				Sensitive:   true,
 			},
 			outputMD5: {
 				Description: "OpenSSH MD5 fingerprint derived from the generated public key.",
@@ -101,6 +102,7 @@ module: crypto_private_key
 inputs:
   algorithm: ED25519`,
 		},
+		OutputsOnly: true,
 	}
 }
 
@@ -43,6 +43,7 @@ Derives the public key from a supported private key.
 				Description: "Private key PEM. Accepted formats: PKCS#8 private key, PKCS#1 RSA private key, SEC1 ECDSA private key. Supported PKCS#8 algorithms: RSA, ECDSA, Ed25519.",
 				Type:        reflect.TypeFor[string](),
 				Required:    true,
+				Sensitive:   true,
 			},
 		},
 		Outputs: map[string]blackstart.OutputValue{
@@ -74,16 +75,9 @@ inputs:
       output: pem`,
 			"Key rotation with old and new Kubernetes Secret values": `
 operations:
-  - id: k8s_client
-    module: kubernetes_client
-
   - id: signing_key_secret
     module: kubernetes_secret
     inputs:
-      client:
-        fromDependency:
-          id: k8s_client
-          output: client
       namespace: default
       name: signing-key
 
@@ -182,6 +176,7 @@ operations:
       - old_private_key_secret_value
       - old_public_key_secret_value`,
 		},
+		OutputsOnly: true,
 	}
 }
 
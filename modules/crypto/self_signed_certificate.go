@@ -36,6 +36,7 @@ func (m *selfSignedCertificateModule) Info() blackstart.ModuleInfo {
 		Description: "PEM-encoded private key. Accepted formats: PKCS#8, PKCS#1 RSA, or SEC1 ECDSA. Encrypted private keys are not supported.",
 		Type:        reflect.TypeFor[string](),
 		Required:    true,
+		Sensitive:   true,
 	}
 	inputs[inputProfile] = blackstart.InputValue{
 		Description: "TLS certificate profile. Allowed values: `server`, `client`, `server_client`, `ca`.",
@@ -106,16 +107,9 @@ operations:
       dns_names:
         - app.example.com
 
-  - id: k8s_client
-    module: kubernetes_client
-
   - id: tls_secret
     module: kubernetes_secret
     inputs:
-      client:
-        fromDependency:
-          id: k8s_client
-          output: client
       namespace: default
       name: app-tls
       type: kubernetes.io/tls
@@ -148,6 +142,7 @@ operations:
           output: pem
       update_policy: overwrite`,
 		},
+		OutputsOnly: true,
 	}
 }
 
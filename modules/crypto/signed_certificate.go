@@ -63,6 +63,7 @@ Signs a PEM-encoded X.509 certificate signing request using a local CA certifica
 				Description: "PEM-encoded CA private key used to sign the certificate. Encrypted private keys are not supported.",
 				Type:        reflect.TypeFor[string](),
 				Required:    true,
+				Sensitive:   true,
 			},
 			inputCAChainPEM: {
 				Description: "Optional PEM-encoded certificates to append after the signing CA in chain outputs.",
@@ -137,6 +138,7 @@ operations:
           output: pem
       profile: server`,
 		},
+		OutputsOnly: true,
 	}
 }
 
@@ -0,0 +1,463 @@
+package github
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	updatePolicyOverwrite   = "overwrite"
+	updatePolicyPreserveAny = "preserve_any"
+
+	visibilityAll      = "all"
+	visibilityPrivate  = "private"
+	visibilitySelected = "selected"
+)
+
+var updatePolicies = map[string]struct{}{
+	updatePolicyOverwrite:   {},
+	updatePolicyPreserveAny: {},
+}
+
+var visibilities = map[string]struct{}{
+	visibilityAll:      {},
+	visibilityPrivate:  {},
+	visibilitySelected: {},
+}
+
+func init() {
+	blackstart.RegisterModule("github_actions_secret", NewActionsSecret)
+}
+
+var _ blackstart.Module = &actionsSecret{}
+
+// NewActionsSecret creates a module that manages a GitHub Actions secret.
+func NewActionsSecret() blackstart.Module {
+	return &actionsSecret{}
+}
+
+// actionsSecret implements the github_actions_secret module.
+type actionsSecret struct{}
+
+// publicKey is the repository or organization public key used to encrypt secret values.
+type publicKey struct {
+	KeyID string `json:"key_id"`
+	Key   string `json:"key"`
+}
+
+// secretMetadata is the secret information returned by the GitHub API. Secret values are never
+// returned by the API.
+type secretMetadata struct {
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// secretTarget identifies the repository, environment, or organization that owns a secret.
+type secretTarget struct {
+	owner       string
+	repository  string
+	environment string
+	name        string
+}
+
+// basePath returns the Actions secrets API path for the target.
+func (t secretTarget) basePath() string {
+	if t.repository == "" {
+		return fmt.Sprintf("/orgs/%s/actions/secrets", url.PathEscape(t.owner))
+	}
+	if t.environment != "" {
+		return fmt.Sprintf(
+			"/repos/%s/%s/environments/%s/secrets",
+			url.PathEscape(t.owner), url.PathEscape(t.repository), url.PathEscape(t.environment),
+		)
+	}
+	return fmt.Sprintf("/repos/%s/%s/actions/secrets", url.PathEscape(t.owner), url.PathEscape(t.repository))
+}
+
+// secretPath returns the API path of the secret.
+func (t secretTarget) secretPath() string {
+	return t.basePath() + "/" + url.PathEscape(t.name)
+}
+
+// publicKeyPath returns the API path of the public key used to encrypt secret values.
+func (t secretTarget) publicKeyPath() string {
+	return t.basePath() + "/public-key"
+}
+
+func (m *actionsSecret) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "github_actions_secret",
+		Name: "GitHub Actions secret",
+		Description: util.CleanString(
+			`
+Ensures a GitHub Actions secret exists for a repository or organization. Secret values are
+encrypted with the repository or organization public key before they are sent to GitHub.
+
+When '''repository''' is set, a repository secret is managed, or an environment secret of the
+repository when '''environment''' is also set. Otherwise, an organization secret is managed for the
+organization named by '''owner'''.
+
+**Update Policies**
+
+GitHub does not return secret values, so an existing value cannot be compared with the desired
+value. The following update policies are supported:
+
+- '''preserve_any''' - Any existing secret is preserved. This is the default update policy.
+- '''overwrite''' - The secret value is written on every run.
+`,
+		),
+		Requirements: []string{
+			"A GitHub token with access to the target repository or organization. Fine-grained tokens require the `Secrets` repository permission (read and write) or the `Secrets` organization permission (read and write) for organization secrets.",
+			"For environment secrets, the environment must exist in the repository. Fine-grained tokens require the `Environments` repository permission (read and write).",
+			"If the `token` input is not set, the `GITHUB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputOwner: {
+					Description: "Repository owner or organization name.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputRepository: {
+					Description: "Repository name. If not set, an organization secret is managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputEnvironment: {
+					Description: "Deployment environment of the repository. If set, an environment secret is managed. Requires `repository`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputName: {
+					Description: "Name of the secret.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputValue: {
+					Description: "Secret value. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Sensitive:   true,
+				},
+				inputVisibility: {
+					Description: "Organization secret visibility. One of `all`, `private`, or `selected`. Ignored for repository secrets.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     visibilityPrivate,
+				},
+				inputSelectedRepositoryIDs: {
+					Description: "IDs of the repositories that can access the organization secret. Required when `visibility` is `selected`, and not allowed otherwise.",
+					Type:        reflect.TypeFor[[]int64](),
+					Required:    false,
+				},
+				inputUpdatePolicy: {
+					Description: "Update policy for an existing secret. One of `preserve_any` or `overwrite`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     updatePolicyPreserveAny,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputUpdatedAt: {
+				Description: "Time the secret was last updated, as reported by GitHub.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Repository secret from a dependency": `id: deploy-key-secret
+module: github_actions_secret
+inputs:
+  token:
+    fromDependency:
+      id: github-token
+      output: value
+  owner: example
+  repository: app
+  name: DEPLOY_PASSWORD
+  value:
+    fromDependency:
+      id: deploy-password
+      output: value
+  update_policy: overwrite`,
+			"Environment secret": `id: production-db-password
+module: github_actions_secret
+inputs:
+  owner: example
+  repository: app
+  environment: production
+  name: DATABASE_PASSWORD
+  value:
+    fromDependency:
+      id: db-password
+      output: value`,
+			"Organization secret": `id: org-secret
+module: github_actions_secret
+inputs:
+  owner: example
+  name: SHARED_API_KEY
+  value: example-value
+  visibility: all`,
+			"Organization secret for selected repositories": `id: org-selected-secret
+module: github_actions_secret
+inputs:
+  owner: example
+  name: SHARED_API_KEY
+  value: example-value
+  visibility: selected
+  selected_repository_ids:
+    - 1296269
+    - 1296270`,
+		},
+	}
+}
+
+func (m *actionsSecret) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputOwner, inputName); err != nil {
+		return err
+	}
+	if err := restapi.ValidateEnum(op, inputUpdatePolicy, updatePolicies); err != nil {
+		return err
+	}
+	if err := restapi.ValidateEnum(op, inputVisibility, visibilities); err != nil {
+		return err
+	}
+	if _, ok := op.Inputs[inputEnvironment]; ok {
+		if _, ok = op.Inputs[inputRepository]; !ok {
+			return fmt.Errorf("parameter %s requires parameter %s", inputEnvironment, inputRepository)
+		}
+	}
+	if err := validateSelectedRepositories(op); err != nil {
+		return err
+	}
+
+	if _, ok := op.Inputs[inputValue]; !ok && !op.DoesNotExist {
+		return fmt.Errorf("missing required parameter: %s", inputValue)
+	}
+	return nil
+}
+
+func (m *actionsSecret) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	target, err := contextSecretTarget(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var existing secretMetadata
+	err = c.Do(ctx, http.MethodGet, target.secretPath(), nil, &existing)
+	if err != nil && !restapi.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get secret %s: %w", target.name, err)
+	}
+	exists := err == nil
+
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists {
+		return false, nil
+	}
+
+	policy, err := contextUpdatePolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if policy == updatePolicyOverwrite {
+		return false, nil
+	}
+	return true, ctx.Output(outputUpdatedAt, existing.UpdatedAt)
+}
+
+func (m *actionsSecret) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	target, err := contextSecretTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		err = c.Do(ctx, http.MethodDelete, target.secretPath(), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete secret %s: %w", target.name, err)
+		}
+		return nil
+	}
+
+	value, err := blackstart.ContextInputAs[string](ctx, inputValue, true)
+	if err != nil {
+		return err
+	}
+
+	var key publicKey
+	if err = c.Do(ctx, http.MethodGet, target.publicKeyPath(), nil, &key); err != nil {
+		return fmt.Errorf("failed to get public key for secret %s: %w", target.name, err)
+	}
+	encrypted, err := encryptSecretValue(key.Key, value)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]any{
+		"encrypted_value": encrypted,
+		"key_id":          key.KeyID,
+	}
+	if target.repository == "" {
+		visibility, vErr := blackstart.ContextInputAs[string](ctx, inputVisibility, false)
+		if vErr != nil {
+			return vErr
+		}
+		if visibility == "" {
+			visibility = visibilityPrivate
+		}
+		body["visibility"] = visibility
+
+		ids, iErr := blackstart.ContextInputAs[[]int64](ctx, inputSelectedRepositoryIDs, false)
+		if iErr != nil {
+			return iErr
+		}
+		switch {
+		case visibility == visibilitySelected && len(ids) == 0:
+			return fmt.Errorf(
+				"input '%s' is required when input '%s' is '%s'",
+				inputSelectedRepositoryIDs, inputVisibility, visibilitySelected,
+			)
+		case visibility != visibilitySelected && len(ids) > 0:
+			return fmt.Errorf(
+				"input '%s' requires input '%s' to be '%s'",
+				inputSelectedRepositoryIDs, inputVisibility, visibilitySelected,
+			)
+		case len(ids) > 0:
+			body["selected_repository_ids"] = ids
+		}
+	}
+
+	if err = c.Do(ctx, http.MethodPut, target.secretPath(), body, nil); err != nil {
+		return fmt.Errorf("failed to set secret %s: %w", target.name, err)
+	}
+
+	var updated secretMetadata
+	if err = c.Do(ctx, http.MethodGet, target.secretPath(), nil, &updated); err != nil {
+		return fmt.Errorf("failed to get secret %s: %w", target.name, err)
+	}
+	return ctx.Output(outputUpdatedAt, updated.UpdatedAt)
+}
+
+// validateSelectedRepositories checks that selected repository IDs are set for organization secrets
+// with the `selected` visibility, and only for those secrets. Inputs from dependencies are checked
+// when the secret is set.
+func validateSelectedRepositories(op blackstart.Operation) error {
+	_, hasIDs := op.Inputs[inputSelectedRepositoryIDs]
+	if _, ok := op.Inputs[inputRepository]; ok {
+		if hasIDs {
+			return fmt.Errorf(
+				"parameter %s is only supported for organization secrets", inputSelectedRepositoryIDs,
+			)
+		}
+		return nil
+	}
+
+	visibility := visibilityPrivate
+	if input, ok := op.Inputs[inputVisibility]; ok {
+		if !input.IsStatic() {
+			return nil
+		}
+		value, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputVisibility, err)
+		}
+		if value != "" {
+			visibility = value
+		}
+	}
+	if visibility == visibilitySelected && !hasIDs {
+		return fmt.Errorf(
+			"parameter %s is required when parameter %s is '%s'",
+			inputSelectedRepositoryIDs, inputVisibility, visibilitySelected,
+		)
+	}
+	if visibility != visibilitySelected && hasIDs {
+		return fmt.Errorf(
+			"parameter %s requires parameter %s to be '%s'",
+			inputSelectedRepositoryIDs, inputVisibility, visibilitySelected,
+		)
+	}
+	return nil
+}
+
+// contextSecretTarget reads the secret owner, repository, and name from module inputs.
+func contextSecretTarget(ctx blackstart.ModuleContext) (secretTarget, error) {
+	owner, err := blackstart.ContextInputAs[string](ctx, inputOwner, true)
+	if err != nil {
+		return secretTarget{}, err
+	}
+	repository, err := blackstart.ContextInputAs[string](ctx, inputRepository, false)
+	if err != nil {
+		return secretTarget{}, err
+	}
+	environment, err := blackstart.ContextInputAs[string](ctx, inputEnvironment, false)
+	if err != nil {
+		return secretTarget{}, err
+	}
+	if environment != "" && repository == "" {
+		return secretTarget{}, fmt.Errorf("input '%s' requires input '%s'", inputEnvironment, inputRepository)
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return secretTarget{}, err
+	}
+	return secretTarget{owner: owner, repository: repository, environment: environment, name: name}, nil
+}
+
+// contextUpdatePolicy returns the runtime update policy from a module context.
+func contextUpdatePolicy(ctx blackstart.ModuleContext) (string, error) {
+	policy, err := blackstart.ContextInputAs[string](ctx, inputUpdatePolicy, false)
+	if err != nil {
+		return "", err
+	}
+	policy = strings.TrimSpace(policy)
+	if policy == "" {
+		return updatePolicyPreserveAny, nil
+	}
+	if _, ok := updatePolicies[policy]; !ok {
+		return "", fmt.Errorf("input '%s' has invalid value '%s'", inputUpdatePolicy, policy)
+	}
+	return policy, nil
+}
+
+// encryptSecretValue encrypts value with a base64-encoded Curve25519 public key using a sealed box,
+// as required by the GitHub secrets API.
+func encryptSecretValue(publicKeyB64, value string) (string, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(publicKeyB64)
+	if err != nil {
+		return "", fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(keyBytes) != 32 {
+		return "", fmt.Errorf("invalid public key length: %d", len(keyBytes))
+	}
+	var recipient [32]byte
+	copy(recipient[:], keyBytes)
+
+	sealed, err := box.SealAnonymous(nil, []byte(value), &recipient, rand.Reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt secret value: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
@@ -0,0 +1,263 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeGitHub implements the GitHub Actions secrets API endpoints used by the module.
+type fakeGitHub struct {
+	server     *httptest.Server
+	publicKey  *[32]byte
+	privateKey *[32]byte
+	secrets    map[string]string
+	bodies     map[string]map[string]any
+	requests   []string
+	mu         sync.Mutex
+}
+
+// newFakeGitHub starts a fake GitHub API server.
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	t.Helper()
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	f := &fakeGitHub{
+		publicKey:  pub,
+		privateKey: priv,
+		secrets:    map[string]string{},
+		bodies:     map[string]map[string]any{},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeGitHub) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/public-key") {
+		_ = json.NewEncoder(w).Encode(publicKey{
+			KeyID: "key-1",
+			Key:   base64.StdEncoding.EncodeToString(f.publicKey[:]),
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if _, ok := f.secrets[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(secretMetadata{Name: "SECRET", UpdatedAt: "2024-01-01T00:00:00Z"})
+	case http.MethodPut:
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		sealed, _ := base64.StdEncoding.DecodeString(body["encrypted_value"].(string))
+		plain, ok := box.OpenAnonymous(nil, sealed, f.publicKey, f.privateKey)
+		if !ok {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+		f.secrets[r.URL.Path] = string(plain)
+		f.bodies[r.URL.Path] = body
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		if _, ok := f.secrets[r.URL.Path]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(f.secrets, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// secretOperation returns a github_actions_secret operation targeting the fake server.
+func secretOperation(f *fakeGitHub, inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{
+		Id:     "secret",
+		Module: "github_actions_secret",
+		Inputs: map[string]blackstart.Input{
+			inputToken:  blackstart.NewInputFromValue("test-token"),
+			inputAPIURL: blackstart.NewInputFromValue(f.server.URL),
+			inputOwner:  blackstart.NewInputFromValue("example"),
+			inputName:   blackstart.NewInputFromValue("DEPLOY_PASSWORD"),
+		},
+	}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+func TestActionsSecret_CreateRepositorySecret(t *testing.T) {
+	f := newFakeGitHub(t)
+	m := NewActionsSecret()
+	op := secretOperation(f, map[string]any{inputRepository: "app", inputValue: "s3cret"})
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	path := "/repos/example/app/actions/secrets/DEPLOY_PASSWORD"
+	require.Equal(t, "s3cret", f.secrets[path])
+	require.Equal(t, "key-1", f.bodies[path]["key_id"])
+	require.NotContains(t, f.bodies[path], "visibility")
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestActionsSecret_OrganizationSecretVisibility(t *testing.T) {
+	f := newFakeGitHub(t)
+	m := NewActionsSecret()
+	op := secretOperation(f, map[string]any{inputValue: "s3cret", inputVisibility: "all"})
+	require.NoError(t, m.Validate(*op))
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+
+	path := "/orgs/example/actions/secrets/DEPLOY_PASSWORD"
+	require.Equal(t, "s3cret", f.secrets[path])
+	require.Equal(t, "all", f.bodies[path]["visibility"])
+	require.NotContains(t, f.bodies[path], "selected_repository_ids")
+
+	op = secretOperation(
+		f, map[string]any{
+			inputValue: "s3cret", inputVisibility: "selected", inputSelectedRepositoryIDs: []any{1296269, 1296270},
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(t, "selected", f.bodies[path]["visibility"])
+	require.Equal(t, []any{1296269.0, 1296270.0}, f.bodies[path]["selected_repository_ids"])
+}
+
+func TestActionsSecret_UpdatePolicy(t *testing.T) {
+	f := newFakeGitHub(t)
+	f.secrets["/repos/example/app/actions/secrets/DEPLOY_PASSWORD"] = "old"
+	m := NewActionsSecret()
+
+	preserve := secretOperation(f, map[string]any{inputRepository: "app", inputValue: "new"})
+	ok, err := m.Check(blackstart.OpContext(context.Background(), preserve))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	overwrite := secretOperation(
+		f, map[string]any{inputRepository: "app", inputValue: "new", inputUpdatePolicy: updatePolicyOverwrite},
+	)
+	ctx := blackstart.OpContext(context.Background(), overwrite)
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "new", f.secrets["/repos/example/app/actions/secrets/DEPLOY_PASSWORD"])
+}
+
+func TestActionsSecret_DoesNotExist(t *testing.T) {
+	f := newFakeGitHub(t)
+	path := "/repos/example/app/actions/secrets/DEPLOY_PASSWORD"
+	f.secrets[path] = "old"
+	m := NewActionsSecret()
+
+	op := secretOperation(f, map[string]any{inputRepository: "app"})
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.NotContains(t, f.secrets, path)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestActionsSecret_Validate(t *testing.T) {
+	m := NewActionsSecret()
+	f := newFakeGitHub(t)
+
+	require.ErrorContains(t, m.Validate(*secretOperation(f, nil)), "missing required parameter: value")
+	require.ErrorContains(
+		t,
+		m.Validate(*secretOperation(f, map[string]any{inputValue: "v", inputUpdatePolicy: "fail"})),
+		"invalid value",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(*secretOperation(f, map[string]any{inputValue: "v", inputVisibility: "public"})),
+		"invalid value",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(*secretOperation(f, map[string]any{inputValue: "v", inputVisibility: "selected"})),
+		"parameter selected_repository_ids is required",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(*secretOperation(f, map[string]any{inputValue: "v", inputSelectedRepositoryIDs: []any{1}})),
+		"requires parameter visibility to be 'selected'",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*secretOperation(
+				f, map[string]any{inputValue: "v", inputRepository: "app", inputSelectedRepositoryIDs: []any{1}},
+			),
+		),
+		"only supported for organization secrets",
+	)
+}
+
+func TestActionsSecret_BadCredentials(t *testing.T) {
+	f := newFakeGitHub(t)
+	m := NewActionsSecret()
+	op := secretOperation(f, map[string]any{inputRepository: "app", inputValue: "v", inputToken: "wrong"})
+
+	_, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, "Bad credentials")
+}
+
+func TestActionsSecret_EnvironmentSecret(t *testing.T) {
+	f := newFakeGitHub(t)
+	m := NewActionsSecret()
+	op := secretOperation(
+		f, map[string]any{inputRepository: "app", inputEnvironment: "production", inputValue: "s3cret"},
+	)
+	require.NoError(t, m.Validate(*op))
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+
+	path := "/repos/example/app/environments/production/secrets/DEPLOY_PASSWORD"
+	require.Equal(t, "s3cret", f.secrets[path])
+	require.NotContains(t, f.bodies[path], "visibility")
+
+	noRepository := secretOperation(f, map[string]any{inputEnvironment: "production", inputValue: "s3cret"})
+	require.ErrorContains(t, m.Validate(*noRepository), "requires parameter repository")
+}
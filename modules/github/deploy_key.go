@@ -0,0 +1,235 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("github_deploy_key", NewDeployKey)
+}
+
+var _ blackstart.Module = &deployKey{}
+
+// NewDeployKey creates a module that manages a deploy key of a GitHub repository.
+func NewDeployKey() blackstart.Module {
+	return &deployKey{}
+}
+
+// deployKey implements the github_deploy_key module.
+type deployKey struct{}
+
+// deployKeyMetadata is the deploy key information returned by the GitHub API.
+type deployKeyMetadata struct {
+	ID       int64  `json:"id"`
+	Key      string `json:"key"`
+	Title    string `json:"title"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// desiredDeployKey is the desired state of a deploy key read from module inputs.
+type desiredDeployKey struct {
+	owner      string
+	repository string
+	title      string
+	key        string
+	readOnly   bool
+}
+
+// path returns the deploy keys API path of the repository.
+func (k desiredDeployKey) path() string {
+	return fmt.Sprintf("/repos/%s/%s/keys", url.PathEscape(k.owner), url.PathEscape(k.repository))
+}
+
+// find returns the deploy key with the same public key, if any.
+func (k desiredDeployKey) find(keys []deployKeyMetadata) *deployKeyMetadata {
+	for i := range keys {
+		if sameKey(keys[i].Key, k.key) {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// sameKey reports whether two authorized key lines have the same key type and key, ignoring the
+// comment, which GitHub does not return.
+func sameKey(a, b string) bool {
+	fa, fb := strings.Fields(a), strings.Fields(b)
+	if len(fa) < 2 || len(fb) < 2 {
+		return false
+	}
+	return fa[0] == fb[0] && fa[1] == fb[1]
+}
+
+func (m *deployKey) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "github_deploy_key",
+		Name: "GitHub deploy key",
+		Description: util.CleanString(
+			`
+Ensures an SSH public key is installed as a deploy key of a GitHub repository. Keys are matched by
+their key type and key, so the comment of the key is ignored.
+
+GitHub does not allow deploy keys to be changed, so a key with a different title or access is
+deleted and added again.
+
+**Notes**
+
+- When '''doesNotExist''' is set, the deploy key is removed from the repository.
+`,
+		),
+		Requirements: []string{
+			"A GitHub token with access to the repository. Fine-grained tokens require the `Administration` repository permission (read and write).",
+			"If the `token` input is not set, the `GITHUB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputOwner: {
+					Description: "Repository owner or organization name.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputRepository: {
+					Description: "Repository name.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputTitle: {
+					Description: "Title of the deploy key.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputKey: {
+					Description: "SSH public key in authorized keys format, such as `ssh-ed25519 AAAA...`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputReadOnly: {
+					Description: "If true, the key can only read the repository. Otherwise, the key can also push.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     true,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the deploy key.",
+				Type:        reflect.TypeFor[int64](),
+			},
+		},
+		Examples: map[string]string{
+			"Deploy key from a generated key pair": `id: app-deploy-key
+module: github_deploy_key
+inputs:
+  owner: example
+  repository: app
+  title: argocd
+  key:
+    fromDependency:
+      id: deploy-public-key
+      output: openssh`,
+		},
+	}
+}
+
+func (m *deployKey) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputOwner, inputRepository, inputTitle, inputKey); err != nil {
+		return err
+	}
+	if input := op.Inputs[inputKey]; input.IsStatic() {
+		key, _ := blackstart.InputAs[string](input, true)
+		if len(strings.Fields(key)) < 2 {
+			return fmt.Errorf("parameter %s must be an SSH public key in authorized keys format", inputKey)
+		}
+	}
+	return nil
+}
+
+func (m *deployKey) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextDeployKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	keys, err := restapi.ListPages[deployKeyMetadata](ctx, c, desired.path())
+	if err != nil {
+		return false, fmt.Errorf("failed to list deploy keys of %s/%s: %w", desired.owner, desired.repository, err)
+	}
+	current := desired.find(keys)
+
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || current.Title != desired.title || current.ReadOnly != desired.readOnly {
+		return false, nil
+	}
+	return true, ctx.Output(outputID, current.ID)
+}
+
+func (m *deployKey) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextDeployKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := restapi.ListPages[deployKeyMetadata](ctx, c, desired.path())
+	if err != nil {
+		return fmt.Errorf("failed to list deploy keys of %s/%s: %w", desired.owner, desired.repository, err)
+	}
+	if current := desired.find(keys); current != nil {
+		err = c.Do(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", desired.path(), current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete deploy key %s: %w", current.Title, err)
+		}
+	}
+	if ctx.DoesNotExist() {
+		return nil
+	}
+
+	body := map[string]any{"title": desired.title, "key": desired.key, "read_only": desired.readOnly}
+	var created deployKeyMetadata
+	if err = c.Do(ctx, http.MethodPost, desired.path(), body, &created); err != nil {
+		return fmt.Errorf("failed to add deploy key %s: %w", desired.title, err)
+	}
+	return ctx.Output(outputID, created.ID)
+}
+
+// contextDeployKey reads the desired deploy key from module inputs.
+func contextDeployKey(ctx blackstart.ModuleContext) (desiredDeployKey, error) {
+	var k desiredDeployKey
+	var err error
+	for key, value := range map[string]*string{
+		inputOwner:      &k.owner,
+		inputRepository: &k.repository,
+		inputTitle:      &k.title,
+		inputKey:        &k.key,
+	} {
+		if *value, err = blackstart.ContextInputAs[string](ctx, key, true); err != nil {
+			return desiredDeployKey{}, err
+		}
+	}
+	k.key = strings.TrimSpace(k.key)
+
+	readOnly, err := blackstart.ContextInputAs[*bool](ctx, inputReadOnly, false)
+	if err != nil {
+		return desiredDeployKey{}, err
+	}
+	k.readOnly = readOnly == nil || *readOnly
+	return k, nil
+}
@@ -0,0 +1,115 @@
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+const testPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBLs0xfRPkT0B2o4rWh7Nk0+L0Q2hU6b1gcLdrZcOb2m"
+
+func TestSameKey(t *testing.T) {
+	require.True(t, sameKey(testPublicKey+" argocd@example", testPublicKey))
+	require.False(t, sameKey("ssh-rsa AAAA", testPublicKey))
+	require.False(t, sameKey("invalid", "invalid"))
+}
+
+func TestDeployKey_Create(t *testing.T) {
+	f := newFakeCollections(t)
+	f.collections["/repos/example/app/keys"] = []map[string]any{}
+	m := NewDeployKey()
+	op := collectionOperation(
+		f, "github_deploy_key", map[string]any{
+			inputRepository: "app", inputTitle: "argocd", inputKey: testPublicKey + " argocd@example\n",
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	keys := f.collections["/repos/example/app/keys"]
+	require.Len(t, keys, 1)
+	require.Equal(t, "argocd", keys[0]["title"])
+	require.Equal(t, true, keys[0]["read_only"])
+	require.Equal(t, testPublicKey+" argocd@example", keys[0]["key"])
+
+	// GitHub does not return the comment of the key.
+	keys[0]["key"] = testPublicKey
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDeployKey_RecreateChangedKey(t *testing.T) {
+	f := newFakeCollections(t)
+	f.collections["/repos/example/app/keys"] = []map[string]any{
+		{"id": 7, "key": testPublicKey, "title": "argocd", "read_only": true},
+	}
+	m := NewDeployKey()
+	op := collectionOperation(
+		f, "github_deploy_key", map[string]any{
+			inputRepository: "app", inputTitle: "argocd", inputKey: testPublicKey, inputReadOnly: false,
+		},
+	)
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	keys := f.collections["/repos/example/app/keys"]
+	require.Len(t, keys, 1)
+	require.Equal(t, false, keys[0]["read_only"])
+	require.NotEqual(t, int64(7), ctx.outputs[outputID])
+	require.Contains(t, f.requests, "DELETE /repos/example/app/keys/7")
+}
+
+func TestDeployKey_DoesNotExist(t *testing.T) {
+	f := newFakeCollections(t)
+	f.collections["/repos/example/app/keys"] = []map[string]any{
+		{"id": 7, "key": testPublicKey, "title": "argocd", "read_only": true},
+	}
+	m := NewDeployKey()
+	op := collectionOperation(
+		f, "github_deploy_key", map[string]any{inputRepository: "app", inputTitle: "argocd", inputKey: testPublicKey},
+	)
+	op.DoesNotExist = true
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Empty(t, f.collections["/repos/example/app/keys"])
+}
+
+func TestDeployKey_Validate(t *testing.T) {
+	f := newFakeCollections(t)
+	m := NewDeployKey()
+
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*collectionOperation(f, "github_deploy_key", map[string]any{inputRepository: "app", inputKey: testPublicKey}),
+		),
+		"missing required parameter: title",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*collectionOperation(
+				f, "github_deploy_key",
+				map[string]any{inputRepository: "app", inputTitle: "argocd", inputKey: "invalid"},
+			),
+		),
+		"authorized keys format",
+	)
+}
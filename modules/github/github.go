@@ -0,0 +1,76 @@
+package github
+
+import (
+	"net/http"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputToken                 = "token"
+	inputAPIURL                = restapi.InputAPIURL
+	inputOwner                 = "owner"
+	inputRepository            = "repository"
+	inputEnvironment           = "environment"
+	inputName                  = "name"
+	inputValue                 = "value"
+	inputVisibility            = "visibility"
+	inputSelectedRepositoryIDs = "selected_repository_ids"
+	inputUpdatePolicy          = "update_policy"
+	inputDescription           = "description"
+	inputAutoInit              = "auto_init"
+	inputTitle                 = "title"
+	inputKey                   = "key"
+	inputReadOnly              = "read_only"
+	inputURL                   = "url"
+	inputContentType           = "content_type"
+	inputSecret                = "secret"
+	inputEvents                = "events"
+	inputActive                = "active"
+
+	outputUpdatedAt = "updated_at"
+	outputID        = "id"
+	outputFullName  = "full_name"
+	outputHTMLURL   = "html_url"
+	outputSSHURL    = "ssh_url"
+	outputCloneURL  = "clone_url"
+)
+
+const (
+	defaultAPIURL = "https://api.github.com"
+	apiVersion    = "2022-11-28"
+	tokenEnvVar   = "GITHUB_TOKEN"
+)
+
+func init() {
+	blackstart.RegisterPathName("github", "GitHub")
+}
+
+// credentials configures the token and api_url inputs of the GitHub modules.
+var credentials = restapi.Credentials{
+	Input:             inputToken,
+	Description:       "GitHub token used to authenticate API requests.",
+	EnvVar:            tokenEnvVar,
+	APIURLDescription: "GitHub API base URL. Set this for GitHub Enterprise Server, for example `https://github.example.com/api/v3`.",
+	DefaultAPIURL:     defaultAPIURL,
+}
+
+// newClient creates a GitHub REST API client for the given base URL and token.
+func newClient(baseURL, token string) *restapi.Client {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("X-GitHub-Api-Version", apiVersion)
+	header.Set("Authorization", "Bearer "+token)
+	return restapi.NewClient(restapi.Config{API: "github", BaseURL: baseURL, Header: header})
+}
+
+// contextClient builds a GitHub API client from the token and api_url module inputs. When no token
+// input is provided, the GITHUB_TOKEN environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*restapi.Client, error) {
+	token, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(apiURL, token), nil
+}
@@ -0,0 +1,157 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeCollections implements GitHub API endpoints that list, create, get, update, and delete JSON
+// objects stored in memory. Objects are stored by collection path and have an `id` field. Single
+// objects, such as repositories and users, are stored in objects by path.
+type fakeCollections struct {
+	server      *httptest.Server
+	collections map[string][]map[string]any
+	objects     map[string]map[string]any
+	creates     map[string]string
+	requests    []string
+	nextID      int64
+	mu          sync.Mutex
+}
+
+// newFakeCollections starts a fake GitHub API server.
+func newFakeCollections(t *testing.T) *fakeCollections {
+	t.Helper()
+	f := &fakeCollections{
+		collections: map[string][]map[string]any{},
+		objects:     map[string]map[string]any{},
+		creates:     map[string]string{},
+		nextID:      100,
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// roundTrip converts a value to the generic JSON representation stored by the fake.
+func roundTrip(v any) map[string]any {
+	b, _ := json.Marshal(v)
+	var m map[string]any
+	_ = json.Unmarshal(b, &m)
+	return m
+}
+
+func (f *fakeCollections) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+		return
+	}
+
+	var body map[string]any
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	// Objects created in another collection, such as repositories created under /orgs/x/repos,
+	// are stored at the path returned by creates.
+	if target, ok := f.creates[r.URL.Path]; ok && r.Method == http.MethodPost {
+		f.nextID++
+		body["id"] = f.nextID
+		f.objects[target] = body
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(body)
+		return
+	}
+
+	if object, ok := f.objects[r.URL.Path]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(object)
+		case http.MethodPatch:
+			for k, v := range body {
+				object[k] = v
+			}
+			_ = json.NewEncoder(w).Encode(object)
+		case http.MethodDelete:
+			delete(f.objects, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	if items, ok := f.collections[r.URL.Path]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+			start := min((page-1)*perPage, len(items))
+			_ = json.NewEncoder(w).Encode(append([]map[string]any{}, items[start:min(start+perPage, len(items))]...))
+		case http.MethodPost:
+			f.nextID++
+			body["id"] = f.nextID
+			f.collections[r.URL.Path] = append(items, body)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(body)
+		}
+		return
+	}
+
+	collection, id := path.Dir(r.URL.Path), path.Base(r.URL.Path)
+	for i, item := range f.collections[collection] {
+		if fmt.Sprint(item["id"]) != id {
+			continue
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(item)
+		case http.MethodPatch:
+			for k, v := range body {
+				item[k] = v
+			}
+			_ = json.NewEncoder(w).Encode(item)
+		case http.MethodDelete:
+			f.collections[collection] = append(f.collections[collection][:i], f.collections[collection][i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte(`{"message":"Not Found"}`))
+}
+
+// collectionOperation returns an operation of a module targeting the fake server.
+func collectionOperation(f *fakeCollections, module string, inputs map[string]any) *blackstart.Operation {
+	op := credentials.TestOperation(module, f.server.URL, "test-token", inputs)
+	if _, ok := op.Inputs[inputOwner]; !ok {
+		op.Inputs[inputOwner] = blackstart.NewInputFromValue("example")
+	}
+	return op
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
@@ -0,0 +1,327 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	repositoryVisibilityPrivate  = "private"
+	repositoryVisibilityPublic   = "public"
+	repositoryVisibilityInternal = "internal"
+
+	ownerTypeOrganization = "Organization"
+)
+
+var repositoryVisibilities = map[string]struct{}{
+	repositoryVisibilityPrivate:  {},
+	repositoryVisibilityPublic:   {},
+	repositoryVisibilityInternal: {},
+}
+
+func init() {
+	blackstart.RegisterModule("github_repository", NewRepository)
+}
+
+var _ blackstart.Module = &repository{}
+
+// NewRepository creates a module that manages a GitHub repository.
+func NewRepository() blackstart.Module {
+	return &repository{}
+}
+
+// repository implements the github_repository module.
+type repository struct{}
+
+// repositoryMetadata is the repository information returned by the GitHub API.
+type repositoryMetadata struct {
+	ID          int64   `json:"id"`
+	FullName    string  `json:"full_name"`
+	Description *string `json:"description"`
+	Visibility  string  `json:"visibility"`
+	HTMLURL     string  `json:"html_url"`
+	SSHURL      string  `json:"ssh_url"`
+	CloneURL    string  `json:"clone_url"`
+}
+
+// owner is the user or organization information returned by the GitHub API.
+type owner struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+// desiredRepository is the desired state of a repository read from module inputs.
+type desiredRepository struct {
+	owner       string
+	name        string
+	visibility  string
+	description *string
+	autoInit    bool
+}
+
+// path returns the API path of the repository.
+func (r desiredRepository) path() string {
+	return fmt.Sprintf("/repos/%s/%s", url.PathEscape(r.owner), url.PathEscape(r.name))
+}
+
+// matches reports whether the repository has the desired visibility and description. The
+// description is only compared when it is set.
+func (r desiredRepository) matches(current repositoryMetadata) bool {
+	if current.Visibility != r.visibility {
+		return false
+	}
+	if r.description == nil {
+		return true
+	}
+	return current.Description != nil && *current.Description == *r.description
+}
+
+func (m *repository) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "github_repository",
+		Name: "GitHub repository",
+		Description: util.CleanString(
+			`
+Ensures a GitHub repository exists with the visibility and description. The repository is created
+in the organization named by '''owner''', or for the authenticated user when '''owner''' is the user.
+The visibility and description of an existing repository are updated when they differ.
+
+**Notes**
+
+- '''auto_init''' only applies when the repository is created.
+- When '''doesNotExist''' is set, the repository is deleted. This requires a token that is allowed to delete repositories.
+`,
+		),
+		Requirements: []string{
+			"A GitHub token that can create repositories for the owner. Fine-grained tokens require the `Administration` repository permission (read and write).",
+			"If the `token` input is not set, the `GITHUB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputOwner: {
+					Description: "Organization or user that owns the repository.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputName: {
+					Description: "Name of the repository.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputVisibility: {
+					Description: "Visibility of the repository. One of `private`, `public`, or `internal`. `internal` is only supported for organizations of GitHub Enterprise.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     repositoryVisibilityPrivate,
+				},
+				inputDescription: {
+					Description: "Description of the repository. If not set, the description is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputAutoInit: {
+					Description: "Create an initial commit with an empty README when the repository is created.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the repository.",
+				Type:        reflect.TypeFor[int64](),
+			},
+			outputFullName: {
+				Description: "Full name of the repository, such as `example/app`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputHTMLURL: {
+				Description: "URL of the repository on GitHub.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputSSHURL: {
+				Description: "SSH clone URL of the repository.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputCloneURL: {
+				Description: "HTTPS clone URL of the repository.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Organization repository": `id: app-repository
+module: github_repository
+inputs:
+  owner: example
+  name: app
+  description: Application service
+  auto_init: true`,
+		},
+	}
+}
+
+func (m *repository) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputOwner, inputName); err != nil {
+		return err
+	}
+	return restapi.ValidateEnum(op, inputVisibility, repositoryVisibilities)
+}
+
+func (m *repository) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextRepository(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var current repositoryMetadata
+	err = c.Do(ctx, http.MethodGet, desired.path(), nil, &current)
+	if err != nil && !restapi.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get repository %s/%s: %w", desired.owner, desired.name, err)
+	}
+	exists := err == nil
+
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists || !desired.matches(current) {
+		return false, nil
+	}
+	return true, outputRepository(ctx, current)
+}
+
+func (m *repository) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextRepository(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		err = c.Do(ctx, http.MethodDelete, desired.path(), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete repository %s/%s: %w", desired.owner, desired.name, err)
+		}
+		return nil
+	}
+
+	body := map[string]any{"visibility": desired.visibility}
+	if desired.description != nil {
+		body["description"] = *desired.description
+	}
+
+	var current repositoryMetadata
+	err = c.Do(ctx, http.MethodGet, desired.path(), nil, &current)
+	if err != nil && !restapi.IsNotFound(err) {
+		return fmt.Errorf("failed to get repository %s/%s: %w", desired.owner, desired.name, err)
+	}
+	if err == nil {
+		if err = c.Do(ctx, http.MethodPatch, desired.path(), body, &current); err != nil {
+			return fmt.Errorf("failed to update repository %s/%s: %w", desired.owner, desired.name, err)
+		}
+		return outputRepository(ctx, current)
+	}
+
+	createPath, err := repositoryCreatePath(ctx, c, desired.owner)
+	if err != nil {
+		return err
+	}
+	body["name"] = desired.name
+	body["auto_init"] = desired.autoInit
+	if err = c.Do(ctx, http.MethodPost, createPath, body, &current); err != nil {
+		return fmt.Errorf("failed to create repository %s/%s: %w", desired.owner, desired.name, err)
+	}
+	return outputRepository(ctx, current)
+}
+
+// repositoryCreatePath returns the API path that creates repositories for the owner. Repositories
+// of users can only be created for the authenticated user.
+func repositoryCreatePath(ctx blackstart.ModuleContext, c *restapi.Client, login string) (string, error) {
+	var o owner
+	if err := c.Do(ctx, http.MethodGet, "/users/"+url.PathEscape(login), nil, &o); err != nil {
+		return "", fmt.Errorf("failed to get owner %s: %w", login, err)
+	}
+	if o.Type == ownerTypeOrganization {
+		return "/orgs/" + url.PathEscape(login) + "/repos", nil
+	}
+
+	var user owner
+	if err := c.Do(ctx, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", fmt.Errorf("failed to get authenticated user: %w", err)
+	}
+	if user.Login != o.Login {
+		return "", fmt.Errorf("repositories of user %s can only be created with a token of that user", login)
+	}
+	return "/user/repos", nil
+}
+
+// contextRepository reads the desired repository from module inputs.
+func contextRepository(ctx blackstart.ModuleContext) (desiredRepository, error) {
+	o, err := blackstart.ContextInputAs[string](ctx, inputOwner, true)
+	if err != nil {
+		return desiredRepository{}, err
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return desiredRepository{}, err
+	}
+	visibility, err := blackstart.ContextInputAs[string](ctx, inputVisibility, false)
+	if err != nil {
+		return desiredRepository{}, err
+	}
+	if visibility == "" {
+		visibility = repositoryVisibilityPrivate
+	}
+	if _, ok := repositoryVisibilities[visibility]; !ok {
+		return desiredRepository{}, fmt.Errorf("input '%s' has invalid value '%s'", inputVisibility, visibility)
+	}
+	var description *string
+	if input, iErr := ctx.Input(inputDescription); iErr == nil && input.Any() != nil {
+		value, dErr := blackstart.InputAs[string](input, false)
+		if dErr != nil {
+			return desiredRepository{}, fmt.Errorf("invalid input %s: %w", inputDescription, dErr)
+		}
+		description = &value
+	}
+	autoInit, err := blackstart.ContextInputAs[bool](ctx, inputAutoInit, false)
+	if err != nil {
+		return desiredRepository{}, err
+	}
+	return desiredRepository{
+		owner:       o,
+		name:        name,
+		visibility:  visibility,
+		description: description,
+		autoInit:    autoInit,
+	}, nil
+}
+
+// outputRepository emits the outputs of a repository.
+func outputRepository(ctx blackstart.ModuleContext, r repositoryMetadata) error {
+	outputs := map[string]any{
+		outputID:       r.ID,
+		outputFullName: r.FullName,
+		outputHTMLURL:  r.HTMLURL,
+		outputSSHURL:   r.SSHURL,
+		outputCloneURL: r.CloneURL,
+	}
+	for key, value := range outputs {
+		if err := ctx.Output(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
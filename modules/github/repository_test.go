@@ -0,0 +1,120 @@
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestRepository_CreateOrganizationRepository(t *testing.T) {
+	f := newFakeCollections(t)
+	f.objects["/users/example"] = map[string]any{"login": "example", "type": ownerTypeOrganization}
+	f.creates["/orgs/example/repos"] = "/repos/example/app"
+	m := NewRepository()
+	op := collectionOperation(
+		f, "github_repository", map[string]any{inputName: "app", inputDescription: "Application", inputAutoInit: true},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	created := f.objects["/repos/example/app"]
+	require.Equal(t, "app", created["name"])
+	require.Equal(t, repositoryVisibilityPrivate, created["visibility"])
+	require.Equal(t, "Application", created["description"])
+	require.Equal(t, true, created["auto_init"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestRepository_CreateUserRepository(t *testing.T) {
+	f := newFakeCollections(t)
+	f.objects["/users/example"] = map[string]any{"login": "example", "type": "User"}
+	f.objects["/user"] = map[string]any{"login": "example", "type": "User"}
+	f.creates["/user/repos"] = "/repos/example/app"
+	m := NewRepository()
+
+	op := collectionOperation(f, "github_repository", map[string]any{inputName: "app"})
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.objects, "/repos/example/app")
+
+	f.objects["/user"] = map[string]any{"login": "other", "type": "User"}
+	op = collectionOperation(f, "github_repository", map[string]any{inputName: "other-app"})
+	require.ErrorContains(t, m.Set(blackstart.OpContext(context.Background(), op)), "token of that user")
+}
+
+func TestRepository_UpdateExisting(t *testing.T) {
+	f := newFakeCollections(t)
+	f.objects["/repos/example/app"] = map[string]any{
+		"id":          1,
+		"full_name":   "example/app",
+		"visibility":  repositoryVisibilityPublic,
+		"description": "Old",
+		"ssh_url":     "git@github.com:example/app.git",
+	}
+	m := NewRepository()
+
+	unmanaged := collectionOperation(
+		f, "github_repository", map[string]any{inputName: "app", inputVisibility: repositoryVisibilityPublic},
+	)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), unmanaged)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "git@github.com:example/app.git", ctx.outputs[outputSSHURL])
+	require.Equal(t, int64(1), ctx.outputs[outputID])
+
+	op := collectionOperation(f, "github_repository", map[string]any{inputName: "app", inputDescription: "New"})
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, repositoryVisibilityPrivate, f.objects["/repos/example/app"]["visibility"])
+	require.Equal(t, "New", f.objects["/repos/example/app"]["description"])
+	require.Equal(t, "example/app", ctx.outputs[outputFullName])
+}
+
+func TestRepository_DoesNotExist(t *testing.T) {
+	f := newFakeCollections(t)
+	f.objects["/repos/example/app"] = map[string]any{"id": 1, "visibility": repositoryVisibilityPrivate}
+	m := NewRepository()
+
+	op := collectionOperation(f, "github_repository", map[string]any{inputName: "app"})
+	op.DoesNotExist = true
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.NotContains(t, f.objects, "/repos/example/app")
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestRepository_Validate(t *testing.T) {
+	f := newFakeCollections(t)
+	m := NewRepository()
+
+	require.ErrorContains(
+		t, m.Validate(*collectionOperation(f, "github_repository", nil)), "missing required parameter: name",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*collectionOperation(f, "github_repository", map[string]any{inputName: "app", inputVisibility: "secret"}),
+		),
+		"invalid value",
+	)
+}
@@ -0,0 +1,365 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	contentTypeJSON = "json"
+	contentTypeForm = "form"
+
+	webhookName = "web"
+)
+
+var contentTypes = map[string]struct{}{
+	contentTypeJSON: {},
+	contentTypeForm: {},
+}
+
+var defaultWebhookEvents = []string{"push"}
+
+func init() {
+	blackstart.RegisterModule("github_webhook", NewWebhook)
+}
+
+var _ blackstart.Module = &webhook{}
+
+// NewWebhook creates a module that manages a webhook of a GitHub repository or organization.
+func NewWebhook() blackstart.Module {
+	return &webhook{}
+}
+
+// webhook implements the github_webhook module.
+type webhook struct{}
+
+// webhookConfig is the delivery configuration of a webhook. The secret is never returned by the
+// GitHub API.
+type webhookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Secret      string `json:"secret,omitempty"`
+	InsecureSSL string `json:"insecure_ssl"`
+}
+
+// webhookMetadata is the webhook information returned by the GitHub API.
+type webhookMetadata struct {
+	ID     int64         `json:"id"`
+	Name   string        `json:"name"`
+	Active bool          `json:"active"`
+	Events []string      `json:"events"`
+	Config webhookConfig `json:"config"`
+}
+
+// desiredWebhook is the desired state of a webhook read from module inputs.
+type desiredWebhook struct {
+	owner       string
+	repository  string
+	url         string
+	contentType string
+	secret      string
+	events      []string
+	active      bool
+}
+
+// path returns the webhooks API path of the repository, or of the organization when no repository
+// is set.
+func (h desiredWebhook) path() string {
+	if h.repository == "" {
+		return fmt.Sprintf("/orgs/%s/hooks", url.PathEscape(h.owner))
+	}
+	return fmt.Sprintf("/repos/%s/%s/hooks", url.PathEscape(h.owner), url.PathEscape(h.repository))
+}
+
+// target returns the repository or organization of the webhook for error messages.
+func (h desiredWebhook) target() string {
+	if h.repository == "" {
+		return h.owner
+	}
+	return h.owner + "/" + h.repository
+}
+
+// find returns the webhook that delivers to the desired URL, if any.
+func (h desiredWebhook) find(hooks []webhookMetadata) *webhookMetadata {
+	for i := range hooks {
+		if hooks[i].Config.URL == h.url {
+			return &hooks[i]
+		}
+	}
+	return nil
+}
+
+// matches reports whether an existing webhook has the desired events, content type, and state.
+func (h desiredWebhook) matches(current webhookMetadata) bool {
+	events := slices.Sorted(slices.Values(current.Events))
+	return current.Active == h.active &&
+		current.Config.ContentType == h.contentType &&
+		slices.Equal(events, slices.Sorted(slices.Values(h.events)))
+}
+
+// body returns the request body that creates or updates the webhook.
+func (h desiredWebhook) body() map[string]any {
+	return map[string]any{
+		"name":   webhookName,
+		"active": h.active,
+		"events": h.events,
+		"config": webhookConfig{
+			URL:         h.url,
+			ContentType: h.contentType,
+			Secret:      h.secret,
+			InsecureSSL: "0",
+		},
+	}
+}
+
+func (m *webhook) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "github_webhook",
+		Name: "GitHub webhook",
+		Description: util.CleanString(
+			`
+Ensures a webhook is registered for a GitHub repository or organization. Webhooks are matched by
+their payload URL, and the events, content type, and active state of an existing webhook are updated
+when they differ. TLS verification is always enabled for deliveries.
+
+When '''repository''' is set, a repository webhook is managed. Otherwise, an organization webhook is
+managed for the organization named by '''owner'''.
+
+**Update Policies**
+
+GitHub does not return webhook secrets, so an existing secret cannot be compared with the desired
+secret. The following update policies are supported:
+
+- '''preserve_any''' - The secret of an existing webhook is only written when the webhook is otherwise updated. This is the default update policy.
+- '''overwrite''' - The webhook is written on every run.
+
+**Notes**
+
+- When '''doesNotExist''' is set, the webhook with the payload URL is deleted.
+`,
+		),
+		Requirements: []string{
+			"A GitHub token with access to the target repository or organization. Fine-grained tokens require the `Webhooks` repository permission (read and write) or the `Webhooks` organization permission (read and write) for organization webhooks.",
+			"If the `token` input is not set, the `GITHUB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputOwner: {
+					Description: "Repository owner or organization name.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputRepository: {
+					Description: "Repository name. If not set, an organization webhook is managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputURL: {
+					Description: "Payload URL the events are delivered to.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputContentType: {
+					Description: "Media type of the payloads. One of `json` or `form`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     contentTypeJSON,
+				},
+				inputSecret: {
+					Description: "Secret used to sign the payloads.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Sensitive:   true,
+				},
+				inputEvents: {
+					Description: "Events that trigger the webhook, such as `push` or `pull_request`.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+					Default:     defaultWebhookEvents,
+				},
+				inputActive: {
+					Description: "If true, events are delivered to the webhook.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     true,
+				},
+				inputUpdatePolicy: {
+					Description: "Update policy for an existing webhook. One of `preserve_any` or `overwrite`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     updatePolicyPreserveAny,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the webhook.",
+				Type:        reflect.TypeFor[int64](),
+			},
+		},
+		Examples: map[string]string{
+			"Repository webhook": `id: app-argocd-webhook
+module: github_webhook
+inputs:
+  owner: example
+  repository: app
+  url: https://argocd.example.com/api/webhook
+  secret:
+    fromDependency:
+      id: webhook-secret
+      output: value
+  events:
+    - push`,
+		},
+	}
+}
+
+func (m *webhook) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputOwner, inputURL); err != nil {
+		return err
+	}
+	if err := restapi.ValidateEnum(op, inputContentType, contentTypes); err != nil {
+		return err
+	}
+	if err := restapi.ValidateEnum(op, inputUpdatePolicy, updatePolicies); err != nil {
+		return err
+	}
+	if input := op.Inputs[inputURL]; input.IsStatic() {
+		value, _ := blackstart.InputAs[string](input, true)
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("parameter %s must be an http or https URL", inputURL)
+		}
+	}
+	if input, ok := op.Inputs[inputEvents]; ok && input.IsStatic() {
+		events, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputEvents, err)
+		}
+		if len(events) == 0 {
+			return fmt.Errorf("parameter %s must not be empty", inputEvents)
+		}
+	}
+	return nil
+}
+
+func (m *webhook) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextWebhook(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	hooks, err := restapi.ListPages[webhookMetadata](ctx, c, desired.path())
+	if err != nil {
+		return false, fmt.Errorf("failed to list webhooks of %s: %w", desired.target(), err)
+	}
+	current := desired.find(hooks)
+
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !desired.matches(*current) {
+		return false, nil
+	}
+
+	policy, err := contextUpdatePolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if policy == updatePolicyOverwrite {
+		return false, nil
+	}
+	return true, ctx.Output(outputID, current.ID)
+}
+
+func (m *webhook) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextWebhook(ctx)
+	if err != nil {
+		return err
+	}
+
+	hooks, err := restapi.ListPages[webhookMetadata](ctx, c, desired.path())
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks of %s: %w", desired.target(), err)
+	}
+	current := desired.find(hooks)
+
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", desired.path(), current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete webhook %s of %s: %w", desired.url, desired.target(), err)
+		}
+		return nil
+	}
+
+	var written webhookMetadata
+	if current == nil {
+		if err = c.Do(ctx, http.MethodPost, desired.path(), desired.body(), &written); err != nil {
+			return fmt.Errorf("failed to create webhook %s of %s: %w", desired.url, desired.target(), err)
+		}
+		return ctx.Output(outputID, written.ID)
+	}
+
+	path := fmt.Sprintf("%s/%d", desired.path(), current.ID)
+	if err = c.Do(ctx, http.MethodPatch, path, desired.body(), &written); err != nil {
+		return fmt.Errorf("failed to update webhook %s of %s: %w", desired.url, desired.target(), err)
+	}
+	return ctx.Output(outputID, current.ID)
+}
+
+// contextWebhook reads the desired webhook from module inputs.
+func contextWebhook(ctx blackstart.ModuleContext) (desiredWebhook, error) {
+	var h desiredWebhook
+	var err error
+	if h.owner, err = blackstart.ContextInputAs[string](ctx, inputOwner, true); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.repository, err = blackstart.ContextInputAs[string](ctx, inputRepository, false); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.url, err = blackstart.ContextInputAs[string](ctx, inputURL, true); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.contentType, err = blackstart.ContextInputAs[string](ctx, inputContentType, false); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.contentType == "" {
+		h.contentType = contentTypeJSON
+	}
+	if _, ok := contentTypes[h.contentType]; !ok {
+		return desiredWebhook{}, fmt.Errorf("input '%s' has invalid value '%s'", inputContentType, h.contentType)
+	}
+	if h.secret, err = blackstart.ContextInputAs[string](ctx, inputSecret, false); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.events, err = blackstart.ContextInputAs[[]string](ctx, inputEvents, false); err != nil {
+		return desiredWebhook{}, err
+	}
+	if len(h.events) == 0 {
+		h.events = defaultWebhookEvents
+	}
+
+	active, err := blackstart.ContextInputAs[*bool](ctx, inputActive, false)
+	if err != nil {
+		return desiredWebhook{}, err
+	}
+	h.active = active == nil || *active
+	return h, nil
+}
@@ -0,0 +1,145 @@
+package github
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestWebhook_CreateRepositoryWebhook(t *testing.T) {
+	f := newFakeCollections(t)
+	f.collections["/repos/example/app/hooks"] = []map[string]any{}
+	m := NewWebhook()
+	op := collectionOperation(
+		f, "github_webhook", map[string]any{
+			inputRepository: "app", inputURL: "https://ci.example.com/hook", inputSecret: "s3cret",
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	hooks := f.collections["/repos/example/app/hooks"]
+	require.Len(t, hooks, 1)
+	require.Equal(t, webhookName, hooks[0]["name"])
+	require.Equal(t, true, hooks[0]["active"])
+	require.Equal(t, []any{"push"}, hooks[0]["events"])
+	config := hooks[0]["config"].(map[string]any)
+	require.Equal(t, "https://ci.example.com/hook", config["url"])
+	require.Equal(t, contentTypeJSON, config["content_type"])
+	require.Equal(t, "s3cret", config["secret"])
+	require.Equal(t, "0", config["insecure_ssl"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestWebhook_UpdateOrganizationWebhook(t *testing.T) {
+	f := newFakeCollections(t)
+	f.collections["/orgs/example/hooks"] = []map[string]any{
+		{
+			"id":     9,
+			"active": true,
+			"events": []any{"push"},
+			"config": map[string]any{"url": "https://ci.example.com/hook", "content_type": contentTypeJSON},
+		},
+	}
+	m := NewWebhook()
+	op := collectionOperation(
+		f, "github_webhook", map[string]any{
+			inputURL: "https://ci.example.com/hook", inputEvents: []string{"push", "pull_request"},
+		},
+	)
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, int64(9), ctx.outputs[outputID])
+	require.Contains(t, f.requests, "PATCH /orgs/example/hooks/9")
+	require.Equal(t, []any{"push", "pull_request"}, f.collections["/orgs/example/hooks"][0]["events"])
+
+	overwrite := collectionOperation(
+		f, "github_webhook", map[string]any{
+			inputURL:          "https://ci.example.com/hook",
+			inputEvents:       []string{"pull_request", "push"},
+			inputUpdatePolicy: updatePolicyOverwrite,
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), overwrite))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	preserve := collectionOperation(
+		f, "github_webhook", map[string]any{
+			inputURL: "https://ci.example.com/hook", inputEvents: []string{"pull_request", "push"},
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), preserve))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestWebhook_DoesNotExist(t *testing.T) {
+	f := newFakeCollections(t)
+	f.collections["/repos/example/app/hooks"] = []map[string]any{
+		{"id": 9, "config": map[string]any{"url": "https://ci.example.com/hook"}},
+		{"id": 10, "config": map[string]any{"url": "https://other.example.com/hook"}},
+	}
+	m := NewWebhook()
+	op := collectionOperation(
+		f, "github_webhook", map[string]any{inputRepository: "app", inputURL: "https://ci.example.com/hook"},
+	)
+	op.DoesNotExist = true
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	hooks := f.collections["/repos/example/app/hooks"]
+	require.Len(t, hooks, 1)
+	require.EqualValues(t, 10, hooks[0]["id"])
+}
+
+func TestWebhook_Validate(t *testing.T) {
+	f := newFakeCollections(t)
+	m := NewWebhook()
+
+	require.ErrorContains(
+		t, m.Validate(*collectionOperation(f, "github_webhook", nil)), "missing required parameter: url",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(*collectionOperation(f, "github_webhook", map[string]any{inputURL: "ftp://example.com"})),
+		"http or https URL",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*collectionOperation(
+				f, "github_webhook", map[string]any{inputURL: "https://example.com", inputContentType: "xml"},
+			),
+		),
+		"invalid value",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*collectionOperation(
+				f, "github_webhook", map[string]any{inputURL: "https://example.com", inputEvents: []string{}},
+			),
+		),
+		"must not be empty",
+	)
+}
@@ -0,0 +1,302 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	variableTypeEnvVar = "env_var"
+	variableTypeFile   = "file"
+
+	defaultEnvironmentScope = "*"
+)
+
+var variableTypes = map[string]struct{}{
+	variableTypeEnvVar: {},
+	variableTypeFile:   {},
+}
+
+func init() {
+	blackstart.RegisterModule("gitlab_ci_variable", NewCIVariable)
+}
+
+var _ blackstart.Module = &ciVariable{}
+
+// NewCIVariable creates a module that manages a CI/CD variable of a GitLab project.
+func NewCIVariable() blackstart.Module {
+	return &ciVariable{}
+}
+
+// ciVariable implements the gitlab_ci_variable module.
+type ciVariable struct{}
+
+// variableMetadata is the variable information returned by the GitLab API.
+type variableMetadata struct {
+	Key              string `json:"key"`
+	Value            string `json:"value"`
+	VariableType     string `json:"variable_type"`
+	EnvironmentScope string `json:"environment_scope"`
+	Masked           bool   `json:"masked"`
+	Protected        bool   `json:"protected"`
+}
+
+// desiredVariable is the desired state of a variable read from module inputs.
+type desiredVariable struct {
+	project string
+	variableMetadata
+}
+
+// path returns the variables API path of the project.
+func (v desiredVariable) path() string {
+	return projectPath(v.project) + "/variables"
+}
+
+// variablePath returns the API path of the variable, filtered by its environment scope.
+func (v desiredVariable) variablePath() string {
+	query := url.Values{"filter[environment_scope]": {v.EnvironmentScope}}
+	return v.path() + "/" + url.PathEscape(v.Key) + "?" + query.Encode()
+}
+
+// find returns the variable with the same key and environment scope, if any.
+func (v desiredVariable) find(variables []variableMetadata) *variableMetadata {
+	for i := range variables {
+		if variables[i].Key == v.Key && variables[i].EnvironmentScope == v.EnvironmentScope {
+			return &variables[i]
+		}
+	}
+	return nil
+}
+
+// sameSettings reports whether an existing variable has the desired type, masking, and
+// protection.
+func (v desiredVariable) sameSettings(current variableMetadata) bool {
+	return current.VariableType == v.VariableType && current.Masked == v.Masked && current.Protected == v.Protected
+}
+
+func (m *ciVariable) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "gitlab_ci_variable",
+		Name: "GitLab CI/CD variable",
+		Description: util.CleanString(
+			`
+Ensures a CI/CD variable exists for a GitLab project, such as a secret used by deployment jobs.
+Variables are identified by their key and environment scope, so a key can have a different value
+for each environment. The type, masking, and protection of an existing variable are updated when
+they differ.
+
+**Update Policies**
+
+- '''preserve_any''' - Any existing variable value is preserved. This is the default update policy.
+- '''overwrite''' - The variable value is updated when it differs from the input.
+
+**Notes**
+
+- Masked values must meet the GitLab masking requirements, such as a minimum length of 8 characters.
+- When '''doesNotExist''' is set, the variable is deleted from the environment scope.
+`,
+		),
+		Requirements: []string{
+			"A GitLab token with the `api` scope and at least the Maintainer role in the project.",
+			"If the `token` input is not set, the `GITLAB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputProject: projectInput(),
+				inputKey: {
+					Description: "Key of the variable. Only letters, digits, and `_` are allowed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputValue: {
+					Description: "Value of the variable. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Sensitive:   true,
+				},
+				inputEnvironmentScope: {
+					Description: "Environment scope of the variable, such as `production`. `*` applies to all environments.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     defaultEnvironmentScope,
+				},
+				inputVariableType: {
+					Description: "Type of the variable. One of `env_var` or `file`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     variableTypeEnvVar,
+				},
+				inputMasked: {
+					Description: "If true, the value is masked in job logs.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputProtected: {
+					Description: "If true, the variable is only available to pipelines of protected branches and tags.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputUpdatePolicy: {
+					Description: "Update policy for an existing variable. One of `preserve_any` or `overwrite`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     updatePolicyPreserveAny,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{},
+		Examples: map[string]string{
+			"Production deployment secret": `id: app-deploy-password
+module: gitlab_ci_variable
+inputs:
+  project: example/app
+  key: DEPLOY_PASSWORD
+  value:
+    fromDependency:
+      id: deploy-password
+      output: value
+  environment_scope: production
+  masked: true
+  protected: true
+  update_policy: overwrite`,
+		},
+	}
+}
+
+func (m *ciVariable) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputProject, inputKey); err != nil {
+		return err
+	}
+	if err := restapi.ValidateEnum(op, inputVariableType, variableTypes); err != nil {
+		return err
+	}
+	if err := restapi.ValidateEnum(op, inputUpdatePolicy, updatePolicies); err != nil {
+		return err
+	}
+	if _, ok := op.Inputs[inputValue]; !ok && !op.DoesNotExist {
+		return fmt.Errorf("missing required parameter: %s", inputValue)
+	}
+	return nil
+}
+
+func (m *ciVariable) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextVariable(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	variables, err := restapi.ListPages[variableMetadata](ctx, c, desired.path())
+	if err != nil {
+		return false, fmt.Errorf("failed to list variables of %s: %w", desired.project, err)
+	}
+	current := desired.find(variables)
+
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !desired.sameSettings(*current) {
+		return false, nil
+	}
+
+	policy, err := contextUpdatePolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if policy == updatePolicyOverwrite && current.Value != desired.Value {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (m *ciVariable) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextVariable(ctx)
+	if err != nil {
+		return err
+	}
+
+	variables, err := restapi.ListPages[variableMetadata](ctx, c, desired.path())
+	if err != nil {
+		return fmt.Errorf("failed to list variables of %s: %w", desired.project, err)
+	}
+	current := desired.find(variables)
+
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, desired.variablePath(), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete variable %s: %w", desired.Key, err)
+		}
+		return nil
+	}
+
+	if current == nil {
+		if err = c.Do(ctx, http.MethodPost, desired.path(), desired.variableMetadata, nil); err != nil {
+			return fmt.Errorf("failed to create variable %s: %w", desired.Key, err)
+		}
+		return nil
+	}
+
+	policy, err := contextUpdatePolicy(ctx)
+	if err != nil {
+		return err
+	}
+	body := desired.variableMetadata
+	if policy == updatePolicyPreserveAny && !ctx.Tainted() {
+		body.Value = current.Value
+	}
+	if err = c.Do(ctx, http.MethodPut, desired.variablePath(), body, nil); err != nil {
+		return fmt.Errorf("failed to update variable %s: %w", desired.Key, err)
+	}
+	return nil
+}
+
+// contextVariable reads the desired variable from module inputs.
+func contextVariable(ctx blackstart.ModuleContext) (desiredVariable, error) {
+	var v desiredVariable
+	var err error
+	if v.project, err = blackstart.ContextInputAs[string](ctx, inputProject, true); err != nil {
+		return desiredVariable{}, err
+	}
+	if v.Key, err = blackstart.ContextInputAs[string](ctx, inputKey, true); err != nil {
+		return desiredVariable{}, err
+	}
+	if !ctx.DoesNotExist() {
+		if v.Value, err = blackstart.ContextInputAs[string](ctx, inputValue, true); err != nil {
+			return desiredVariable{}, err
+		}
+	}
+	if v.EnvironmentScope, err = blackstart.ContextInputAs[string](ctx, inputEnvironmentScope, false); err != nil {
+		return desiredVariable{}, err
+	}
+	if v.EnvironmentScope == "" {
+		v.EnvironmentScope = defaultEnvironmentScope
+	}
+	if v.VariableType, err = contextEnum(ctx, inputVariableType, variableTypes, variableTypeEnvVar); err != nil {
+		return desiredVariable{}, err
+	}
+	if v.Masked, err = contextBool(ctx, inputMasked, false); err != nil {
+		return desiredVariable{}, err
+	}
+	if v.Protected, err = contextBool(ctx, inputProtected, false); err != nil {
+		return desiredVariable{}, err
+	}
+	return v, nil
+}
@@ -0,0 +1,123 @@
+package gitlab
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+const testVariablesPath = "/projects/example%2Fapp/variables"
+
+func TestCIVariable_Create(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections[testVariablesPath] = []map[string]any{}
+	m := NewCIVariable()
+	op := fakeOperation(
+		f, "gitlab_ci_variable", map[string]any{
+			inputProject:          "example/app",
+			inputKey:              "DEPLOY_PASSWORD",
+			inputValue:            "s3cret-value",
+			inputEnvironmentScope: "production",
+			inputMasked:           true,
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	variables := f.collections[testVariablesPath]
+	require.Len(t, variables, 1)
+	require.Equal(t, "s3cret-value", variables[0]["value"])
+	require.Equal(t, "production", variables[0]["environment_scope"])
+	require.Equal(t, variableTypeEnvVar, variables[0]["variable_type"])
+	require.Equal(t, true, variables[0]["masked"])
+	require.Equal(t, false, variables[0]["protected"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestCIVariable_UpdatePolicy(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections[testVariablesPath] = []map[string]any{
+		{"key": "DEPLOY_PASSWORD", "value": "old", "environment_scope": "*", "variable_type": "env_var"},
+		{"key": "DEPLOY_PASSWORD", "value": "other", "environment_scope": "staging", "variable_type": "env_var"},
+	}
+	m := NewCIVariable()
+	inputs := map[string]any{inputProject: "example/app", inputKey: "DEPLOY_PASSWORD", inputValue: "new"}
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), fakeOperation(f, "gitlab_ci_variable", inputs)))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Settings are updated without changing a preserved value.
+	inputs[inputProtected] = true
+	ctx := blackstart.OpContext(context.Background(), fakeOperation(f, "gitlab_ci_variable", inputs))
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "old", f.collections[testVariablesPath][0]["value"])
+	require.Equal(t, true, f.collections[testVariablesPath][0]["protected"])
+
+	inputs[inputUpdatePolicy] = updatePolicyOverwrite
+	ctx = blackstart.OpContext(context.Background(), fakeOperation(f, "gitlab_ci_variable", inputs))
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Contains(t, f.requests, "PUT "+testVariablesPath+"/DEPLOY_PASSWORD")
+	require.Equal(t, "new", f.collections[testVariablesPath][0]["value"])
+	require.Equal(t, "other", f.collections[testVariablesPath][1]["value"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), fakeOperation(f, "gitlab_ci_variable", inputs)))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestCIVariable_DoesNotExist(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections[testVariablesPath] = []map[string]any{
+		{"key": "DEPLOY_PASSWORD", "value": "old", "environment_scope": "production"},
+		{"key": "DEPLOY_PASSWORD", "value": "old", "environment_scope": "*"},
+	}
+	m := NewCIVariable()
+	op := fakeOperation(
+		f, "gitlab_ci_variable", map[string]any{
+			inputProject: "example/app", inputKey: "DEPLOY_PASSWORD", inputEnvironmentScope: "production",
+		},
+	)
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	variables := f.collections[testVariablesPath]
+	require.Len(t, variables, 1)
+	require.Equal(t, "*", variables[0]["environment_scope"])
+}
+
+func TestCIVariable_Validate(t *testing.T) {
+	f := newFakeGitLab(t)
+	m := NewCIVariable()
+	inputs := map[string]any{inputProject: "example/app", inputKey: "DEPLOY_PASSWORD"}
+
+	require.ErrorContains(
+		t, m.Validate(*fakeOperation(f, "gitlab_ci_variable", inputs)), "missing required parameter: value",
+	)
+	inputs[inputValue] = "v"
+	inputs[inputVariableType] = "secret"
+	require.ErrorContains(t, m.Validate(*fakeOperation(f, "gitlab_ci_variable", inputs)), "invalid value")
+}
@@ -0,0 +1,233 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("gitlab_deploy_key", NewDeployKey)
+}
+
+var _ blackstart.Module = &deployKey{}
+
+// NewDeployKey creates a module that manages a deploy key of a GitLab project.
+func NewDeployKey() blackstart.Module {
+	return &deployKey{}
+}
+
+// deployKey implements the gitlab_deploy_key module.
+type deployKey struct{}
+
+// deployKeyMetadata is the deploy key information returned by the GitLab API.
+type deployKeyMetadata struct {
+	ID      int64  `json:"id"`
+	Key     string `json:"key"`
+	Title   string `json:"title"`
+	CanPush bool   `json:"can_push"`
+}
+
+// desiredDeployKey is the desired state of a deploy key read from module inputs.
+type desiredDeployKey struct {
+	project string
+	title   string
+	key     string
+	canPush bool
+}
+
+// path returns the deploy keys API path of the project.
+func (k desiredDeployKey) path() string {
+	return projectPath(k.project) + "/deploy_keys"
+}
+
+// find returns the deploy key with the same public key, if any.
+func (k desiredDeployKey) find(keys []deployKeyMetadata) *deployKeyMetadata {
+	for i := range keys {
+		if sameKey(keys[i].Key, k.key) {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+// sameKey reports whether two authorized key lines have the same key type and key, ignoring the
+// comment.
+func sameKey(a, b string) bool {
+	fa, fb := strings.Fields(a), strings.Fields(b)
+	if len(fa) < 2 || len(fb) < 2 {
+		return false
+	}
+	return fa[0] == fb[0] && fa[1] == fb[1]
+}
+
+func (m *deployKey) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "gitlab_deploy_key",
+		Name: "GitLab deploy key",
+		Description: util.CleanString(
+			`
+Ensures an SSH public key is installed as a deploy key of a GitLab project. Keys are matched by
+their key type and key, so the comment of the key is ignored. The title and push access of an
+existing deploy key are updated when they differ.
+
+**Notes**
+
+- When '''doesNotExist''' is set, the deploy key is removed from the project.
+`,
+		),
+		Requirements: []string{
+			"A GitLab token with the `api` scope and at least the Maintainer role in the project.",
+			"If the `token` input is not set, the `GITLAB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputProject: projectInput(),
+				inputTitle: {
+					Description: "Title of the deploy key.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputKey: {
+					Description: "SSH public key in authorized keys format, such as `ssh-ed25519 AAAA...`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputCanPush: {
+					Description: "If true, the key can push to the project. Otherwise, the key can only read the project.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the deploy key.",
+				Type:        reflect.TypeFor[int64](),
+			},
+		},
+		Examples: map[string]string{
+			"Deploy key from a generated key pair": `id: app-deploy-key
+module: gitlab_deploy_key
+inputs:
+  project:
+    fromDependency:
+      id: app-project
+      output: full_path
+  title: argocd
+  key:
+    fromDependency:
+      id: deploy-public-key
+      output: openssh`,
+		},
+	}
+}
+
+func (m *deployKey) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputProject, inputTitle, inputKey); err != nil {
+		return err
+	}
+	if input := op.Inputs[inputKey]; input.IsStatic() {
+		key, _ := blackstart.InputAs[string](input, true)
+		if len(strings.Fields(key)) < 2 {
+			return fmt.Errorf("parameter %s must be an SSH public key in authorized keys format", inputKey)
+		}
+	}
+	return nil
+}
+
+func (m *deployKey) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextDeployKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	keys, err := restapi.ListPages[deployKeyMetadata](ctx, c, desired.path())
+	if err != nil {
+		return false, fmt.Errorf("failed to list deploy keys of %s: %w", desired.project, err)
+	}
+	current := desired.find(keys)
+
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || current.Title != desired.title || current.CanPush != desired.canPush {
+		return false, nil
+	}
+	return true, ctx.Output(outputID, current.ID)
+}
+
+func (m *deployKey) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextDeployKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := restapi.ListPages[deployKeyMetadata](ctx, c, desired.path())
+	if err != nil {
+		return fmt.Errorf("failed to list deploy keys of %s: %w", desired.project, err)
+	}
+	current := desired.find(keys)
+
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", desired.path(), current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete deploy key %s: %w", current.Title, err)
+		}
+		return nil
+	}
+
+	body := map[string]any{"title": desired.title, "can_push": desired.canPush}
+	if current != nil {
+		err = c.Do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", desired.path(), current.ID), body, nil)
+		if err != nil {
+			return fmt.Errorf("failed to update deploy key %s: %w", desired.title, err)
+		}
+		return ctx.Output(outputID, current.ID)
+	}
+
+	body["key"] = desired.key
+	var created deployKeyMetadata
+	if err = c.Do(ctx, http.MethodPost, desired.path(), body, &created); err != nil {
+		return fmt.Errorf("failed to add deploy key %s: %w", desired.title, err)
+	}
+	return ctx.Output(outputID, created.ID)
+}
+
+// contextDeployKey reads the desired deploy key from module inputs.
+func contextDeployKey(ctx blackstart.ModuleContext) (desiredDeployKey, error) {
+	var k desiredDeployKey
+	var err error
+	for key, value := range map[string]*string{
+		inputProject: &k.project,
+		inputTitle:   &k.title,
+		inputKey:     &k.key,
+	} {
+		if *value, err = blackstart.ContextInputAs[string](ctx, key, true); err != nil {
+			return desiredDeployKey{}, err
+		}
+	}
+	k.key = strings.TrimSpace(k.key)
+
+	if k.canPush, err = contextBool(ctx, inputCanPush, false); err != nil {
+		return desiredDeployKey{}, err
+	}
+	return k, nil
+}
@@ -0,0 +1,84 @@
+package gitlab
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+const testPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIBLs0xfRPkT0B2o4rWh7Nk0+L0Q2hU6b1gcLdrZcOb2m"
+
+func TestDeployKey_Create(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections["/projects/example%2Fapp/deploy_keys"] = []map[string]any{}
+	m := NewDeployKey()
+	op := fakeOperation(
+		f, "gitlab_deploy_key", map[string]any{
+			inputProject: "example/app", inputTitle: "argocd", inputKey: testPublicKey + " argocd@example",
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	keys := f.collections["/projects/example%2Fapp/deploy_keys"]
+	require.Len(t, keys, 1)
+	require.Equal(t, "argocd", keys[0]["title"])
+	require.Equal(t, false, keys[0]["can_push"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDeployKey_UpdateExisting(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections["/projects/example%2Fapp/deploy_keys"] = []map[string]any{
+		{"id": 7, "key": testPublicKey, "title": "old", "can_push": false},
+	}
+	m := NewDeployKey()
+	op := fakeOperation(
+		f, "gitlab_deploy_key", map[string]any{
+			inputProject: "example/app", inputTitle: "argocd", inputKey: testPublicKey, inputCanPush: true,
+		},
+	)
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, int64(7), ctx.outputs[outputID])
+	require.Contains(t, f.requests, "PUT /projects/example%2Fapp/deploy_keys/7")
+
+	key := f.collections["/projects/example%2Fapp/deploy_keys"][0]
+	require.Equal(t, "argocd", key["title"])
+	require.Equal(t, true, key["can_push"])
+}
+
+func TestDeployKey_DoesNotExist(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections["/projects/example%2Fapp/deploy_keys"] = []map[string]any{
+		{"id": 7, "key": testPublicKey, "title": "argocd"},
+	}
+	m := NewDeployKey()
+	op := fakeOperation(
+		f, "gitlab_deploy_key",
+		map[string]any{inputProject: "example/app", inputTitle: "argocd", inputKey: testPublicKey},
+	)
+	op.DoesNotExist = true
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Empty(t, f.collections["/projects/example%2Fapp/deploy_keys"])
+}
@@ -0,0 +1,161 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputToken                 = "token"
+	inputAPIURL                = restapi.InputAPIURL
+	inputNamespace             = "namespace"
+	inputName                  = "name"
+	inputProject               = "project"
+	inputVisibility            = "visibility"
+	inputDescription           = "description"
+	inputInitializeWithReadme  = "initialize_with_readme"
+	inputTitle                 = "title"
+	inputKey                   = "key"
+	inputCanPush               = "can_push"
+	inputURL                   = "url"
+	inputSecret                = "secret"
+	inputEvents                = "events"
+	inputEnableSSLVerification = "enable_ssl_verification"
+	inputValue                 = "value"
+	inputEnvironmentScope      = "environment_scope"
+	inputMasked                = "masked"
+	inputProtected             = "protected"
+	inputVariableType          = "variable_type"
+	inputUpdatePolicy          = "update_policy"
+
+	outputID       = "id"
+	outputFullPath = "full_path"
+	outputWebURL   = "web_url"
+	outputSSHURL   = "ssh_url"
+	outputHTTPURL  = "http_url"
+)
+
+const (
+	defaultAPIURL = "https://gitlab.com/api/v4"
+	tokenEnvVar   = "GITLAB_TOKEN"
+
+	updatePolicyOverwrite   = "overwrite"
+	updatePolicyPreserveAny = "preserve_any"
+)
+
+var updatePolicies = map[string]struct{}{
+	updatePolicyOverwrite:   {},
+	updatePolicyPreserveAny: {},
+}
+
+func init() {
+	blackstart.RegisterPathName("gitlab", "GitLab")
+}
+
+// errorMessage returns the message of a GitLab API error response. GitLab returns the message as a
+// string, or as an object of validation errors by field, and some endpoints use an error field.
+func errorMessage(body io.Reader) string {
+	var payload struct {
+		Message json.RawMessage `json:"message"`
+		Error   string          `json:"error"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return ""
+	}
+	var message string
+	if err := json.Unmarshal(payload.Message, &message); err == nil {
+		return message
+	}
+	if len(payload.Message) > 0 {
+		return string(payload.Message)
+	}
+	return payload.Error
+}
+
+// credentials configures the token and api_url inputs of the GitLab modules.
+var credentials = restapi.Credentials{
+	Input:             inputToken,
+	Description:       "GitLab token used to authenticate API requests.",
+	EnvVar:            tokenEnvVar,
+	APIURLDescription: "GitLab API base URL. Set this for self-managed GitLab, for example `https://gitlab.example.com/api/v4`.",
+	DefaultAPIURL:     defaultAPIURL,
+}
+
+// newClient creates a GitLab REST API client for the given base URL and token.
+func newClient(baseURL, token string) *restapi.Client {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("PRIVATE-TOKEN", token)
+	return restapi.NewClient(
+		restapi.Config{API: "gitlab", BaseURL: baseURL, Header: header, ErrorMessage: errorMessage},
+	)
+}
+
+// projectPath returns the API path of a project identified by its ID or full path, such as
+// `example/app`.
+func projectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+// projectInput returns the input that identifies the project of a module.
+func projectInput() blackstart.InputValue {
+	return blackstart.InputValue{
+		Description: "ID or full path of the project, such as `example/app`.",
+		Type:        reflect.TypeFor[string](),
+		Required:    true,
+	}
+}
+
+// contextEnum returns an optional input that is one of the allowed values, or the default value
+// when the input is not set.
+func contextEnum[T any](ctx blackstart.ModuleContext, key string, allowed map[string]T, defaultValue string) (
+	string, error,
+) {
+	value, err := blackstart.ContextInputAs[string](ctx, key, false)
+	if err != nil {
+		return "", err
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return defaultValue, nil
+	}
+	if _, ok := allowed[value]; !ok {
+		return "", fmt.Errorf("input '%s' has invalid value '%s'", key, value)
+	}
+	return value, nil
+}
+
+// contextBool returns an optional boolean input, or the default value when the input is not set.
+func contextBool(ctx blackstart.ModuleContext, key string, defaultValue bool) (bool, error) {
+	value, err := blackstart.ContextInputAs[*bool](ctx, key, false)
+	if err != nil {
+		return false, err
+	}
+	if value == nil {
+		return defaultValue, nil
+	}
+	return *value, nil
+}
+
+// contextUpdatePolicy returns the runtime update policy from a module context.
+func contextUpdatePolicy(ctx blackstart.ModuleContext) (string, error) {
+	return contextEnum(ctx, inputUpdatePolicy, updatePolicies, updatePolicyPreserveAny)
+}
+
+// contextClient builds a GitLab API client from the token and api_url module inputs. When no token
+// input is provided, the GITLAB_TOKEN environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*restapi.Client, error) {
+	token, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(apiURL, token), nil
+}
@@ -0,0 +1,169 @@
+package gitlab
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeGitLab implements GitLab API endpoints that list, create, get, update, and delete JSON objects
+// stored in memory. Paths are stored escaped, as sent by the client, such as
+// `/projects/example%2Fapp/hooks`. Items of a collection are identified by their `id` field, or by
+// their `key` field for variables. Single objects, such as projects, are stored in objects by path.
+type fakeGitLab struct {
+	server      *httptest.Server
+	collections map[string][]map[string]any
+	objects     map[string]map[string]any
+	requests    []string
+	nextID      int64
+	mu          sync.Mutex
+}
+
+// newFakeGitLab starts a fake GitLab API server.
+func newFakeGitLab(t *testing.T) *fakeGitLab {
+	t.Helper()
+	f := &fakeGitLab{
+		collections: map[string][]map[string]any{},
+		objects:     map[string]map[string]any{},
+		nextID:      100,
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeGitLab) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := r.URL.EscapedPath()
+	f.requests = append(f.requests, r.Method+" "+p)
+
+	if r.Header.Get("PRIVATE-TOKEN") != "test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"401 Unauthorized"}`))
+		return
+	}
+
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	if object, ok := f.objects[p]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(object)
+		case http.MethodPut:
+			for k, v := range body {
+				object[k] = v
+			}
+			_ = json.NewEncoder(w).Encode(object)
+		case http.MethodDelete:
+			delete(f.objects, p)
+			w.WriteHeader(http.StatusAccepted)
+		}
+		return
+	}
+
+	if items, ok := f.collections[p]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+			start := min((page-1)*perPage, len(items))
+			_ = json.NewEncoder(w).Encode(append([]map[string]any{}, items[start:min(start+perPage, len(items))]...))
+		case http.MethodPost:
+			f.nextID++
+			body["id"] = f.nextID
+			f.collections[p] = append(items, body)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(body)
+		}
+		return
+	}
+
+	collection, id := path.Dir(p), path.Base(p)
+	scope := r.URL.Query().Get("filter[environment_scope]")
+	for i, item := range f.collections[collection] {
+		if fmt.Sprint(item["id"]) != id && (item["key"] != id || item["environment_scope"] != scope) {
+			continue
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(item)
+		case http.MethodPut:
+			for k, v := range body {
+				item[k] = v
+			}
+			_ = json.NewEncoder(w).Encode(item)
+		case http.MethodDelete:
+			f.collections[collection] = append(f.collections[collection][:i], f.collections[collection][i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte(`{"message":"404 Not Found"}`))
+}
+
+// fakeOperation returns an operation of a module targeting the fake server.
+func fakeOperation(f *fakeGitLab, module string, inputs map[string]any) *blackstart.Operation {
+	return credentials.TestOperation(module, f.server.URL, "test-token", inputs)
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestProjectPath(t *testing.T) {
+	require.Equal(t, "/projects/example%2Fplatform%2Fapp", projectPath("example/platform/app"))
+	require.Equal(t, "/projects/42", projectPath("42"))
+}
+
+func TestErrorMessage(t *testing.T) {
+	require.Equal(t, "404 Not Found", errorMessage(bytes.NewBufferString(`{"message":"404 Not Found"}`)))
+	require.Equal(
+		t,
+		`{"name":["has already been taken"]}`,
+		errorMessage(bytes.NewBufferString(`{"message":{"name":["has already been taken"]}}`)),
+	)
+	require.Equal(t, "insufficient_scope", errorMessage(bytes.NewBufferString(`{"error":"insufficient_scope"}`)))
+	require.Empty(t, errorMessage(bytes.NewBufferString(`not json`)))
+}
+
+func TestContextClient_TokenFromEnvironment(t *testing.T) {
+	f := newFakeGitLab(t)
+	op := fakeOperation(f, "gitlab_project", nil)
+	delete(op.Inputs, inputToken)
+
+	t.Setenv(tokenEnvVar, "")
+	_, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, tokenEnvVar)
+
+	t.Setenv(tokenEnvVar, "test-token")
+	c, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "test-token", c.Header.Get("PRIVATE-TOKEN"))
+	require.Equal(t, f.server.URL, c.BaseURL)
+}
@@ -0,0 +1,303 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	projectVisibilityPrivate  = "private"
+	projectVisibilityInternal = "internal"
+	projectVisibilityPublic   = "public"
+)
+
+var projectVisibilities = map[string]struct{}{
+	projectVisibilityPrivate:  {},
+	projectVisibilityInternal: {},
+	projectVisibilityPublic:   {},
+}
+
+func init() {
+	blackstart.RegisterModule("gitlab_project", NewProject)
+}
+
+var _ blackstart.Module = &project{}
+
+// NewProject creates a module that manages a GitLab project.
+func NewProject() blackstart.Module {
+	return &project{}
+}
+
+// project implements the gitlab_project module.
+type project struct{}
+
+// projectMetadata is the project information returned by the GitLab API.
+type projectMetadata struct {
+	ID                int64   `json:"id"`
+	PathWithNamespace string  `json:"path_with_namespace"`
+	Description       *string `json:"description"`
+	Visibility        string  `json:"visibility"`
+	WebURL            string  `json:"web_url"`
+	SSHURLToRepo      string  `json:"ssh_url_to_repo"`
+	HTTPURLToRepo     string  `json:"http_url_to_repo"`
+}
+
+// namespace is the group or user namespace information returned by the GitLab API.
+type namespace struct {
+	ID int64 `json:"id"`
+}
+
+// desiredProject is the desired state of a project read from module inputs.
+type desiredProject struct {
+	namespace            string
+	name                 string
+	visibility           string
+	description          *string
+	initializeWithReadme bool
+}
+
+// fullPath returns the full path of the project, such as `example/app`.
+func (p desiredProject) fullPath() string {
+	return p.namespace + "/" + p.name
+}
+
+// matches reports whether the project has the desired visibility and description. The description
+// is only compared when it is set.
+func (p desiredProject) matches(current projectMetadata) bool {
+	if current.Visibility != p.visibility {
+		return false
+	}
+	if p.description == nil {
+		return true
+	}
+	currentDescription := ""
+	if current.Description != nil {
+		currentDescription = *current.Description
+	}
+	return currentDescription == *p.description
+}
+
+func (m *project) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "gitlab_project",
+		Name: "GitLab project",
+		Description: util.CleanString(
+			`
+Ensures a GitLab project exists in a group or user namespace with the visibility and description.
+The visibility and description of an existing project are updated when they differ.
+
+**Notes**
+
+- '''initialize_with_readme''' only applies when the project is created.
+- When '''doesNotExist''' is set, the project is deleted. GitLab may keep a deleted project for a retention period before it is removed.
+`,
+		),
+		Requirements: []string{
+			"A GitLab token with the `api` scope. Creating projects in a group requires at least the Developer role in the group, or Maintainer depending on the group settings, and deleting projects requires the Owner role.",
+			"If the `token` input is not set, the `GITLAB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputNamespace: {
+					Description: "Full path of the group or user namespace of the project, such as `example` or `example/platform`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputName: {
+					Description: "Name of the project, also used as its path.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputVisibility: {
+					Description: "Visibility of the project. One of `private`, `internal`, or `public`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     projectVisibilityPrivate,
+				},
+				inputDescription: {
+					Description: "Description of the project. If not set, the description is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputInitializeWithReadme: {
+					Description: "Create an initial commit with a README when the project is created.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the project.",
+				Type:        reflect.TypeFor[int64](),
+			},
+			outputFullPath: {
+				Description: "Full path of the project, such as `example/app`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputWebURL: {
+				Description: "URL of the project on GitLab.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputSSHURL: {
+				Description: "SSH clone URL of the project.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputHTTPURL: {
+				Description: "HTTPS clone URL of the project.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Group project": `id: app-project
+module: gitlab_project
+inputs:
+  namespace: example/platform
+  name: app
+  description: Application service
+  initialize_with_readme: true`,
+		},
+	}
+}
+
+func (m *project) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputNamespace, inputName); err != nil {
+		return err
+	}
+	return restapi.ValidateEnum(op, inputVisibility, projectVisibilities)
+}
+
+func (m *project) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextProject(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var current projectMetadata
+	err = c.Do(ctx, http.MethodGet, projectPath(desired.fullPath()), nil, &current)
+	if err != nil && !restapi.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get project %s: %w", desired.fullPath(), err)
+	}
+	exists := err == nil
+
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists || !desired.matches(current) {
+		return false, nil
+	}
+	return true, outputProject(ctx, current)
+}
+
+func (m *project) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		err = c.Do(ctx, http.MethodDelete, projectPath(desired.fullPath()), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete project %s: %w", desired.fullPath(), err)
+		}
+		return nil
+	}
+
+	body := map[string]any{"visibility": desired.visibility}
+	if desired.description != nil {
+		body["description"] = *desired.description
+	}
+
+	var current projectMetadata
+	err = c.Do(ctx, http.MethodGet, projectPath(desired.fullPath()), nil, &current)
+	if err != nil && !restapi.IsNotFound(err) {
+		return fmt.Errorf("failed to get project %s: %w", desired.fullPath(), err)
+	}
+	if err == nil {
+		path := projectPath(fmt.Sprint(current.ID))
+		if err = c.Do(ctx, http.MethodPut, path, body, &current); err != nil {
+			return fmt.Errorf("failed to update project %s: %w", desired.fullPath(), err)
+		}
+		return outputProject(ctx, current)
+	}
+
+	var ns namespace
+	if err = c.Do(ctx, http.MethodGet, "/namespaces/"+url.PathEscape(desired.namespace), nil, &ns); err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", desired.namespace, err)
+	}
+	body["name"] = desired.name
+	body["path"] = desired.name
+	body["namespace_id"] = ns.ID
+	body["initialize_with_readme"] = desired.initializeWithReadme
+	if err = c.Do(ctx, http.MethodPost, "/projects", body, &current); err != nil {
+		return fmt.Errorf("failed to create project %s: %w", desired.fullPath(), err)
+	}
+	return outputProject(ctx, current)
+}
+
+// contextProject reads the desired project from module inputs.
+func contextProject(ctx blackstart.ModuleContext) (desiredProject, error) {
+	ns, err := blackstart.ContextInputAs[string](ctx, inputNamespace, true)
+	if err != nil {
+		return desiredProject{}, err
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return desiredProject{}, err
+	}
+	visibility, err := contextEnum(ctx, inputVisibility, projectVisibilities, projectVisibilityPrivate)
+	if err != nil {
+		return desiredProject{}, err
+	}
+	var description *string
+	if input, iErr := ctx.Input(inputDescription); iErr == nil && input.Any() != nil {
+		value, dErr := blackstart.InputAs[string](input, false)
+		if dErr != nil {
+			return desiredProject{}, fmt.Errorf("invalid input %s: %w", inputDescription, dErr)
+		}
+		description = &value
+	}
+	initializeWithReadme, err := contextBool(ctx, inputInitializeWithReadme, false)
+	if err != nil {
+		return desiredProject{}, err
+	}
+	return desiredProject{
+		namespace:            ns,
+		name:                 name,
+		visibility:           visibility,
+		description:          description,
+		initializeWithReadme: initializeWithReadme,
+	}, nil
+}
+
+// outputProject emits the outputs of a project.
+func outputProject(ctx blackstart.ModuleContext, p projectMetadata) error {
+	outputs := map[string]any{
+		outputID:       p.ID,
+		outputFullPath: p.PathWithNamespace,
+		outputWebURL:   p.WebURL,
+		outputSSHURL:   p.SSHURLToRepo,
+		outputHTTPURL:  p.HTTPURLToRepo,
+	}
+	for key, value := range outputs {
+		if err := ctx.Output(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package gitlab
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestProject_Create(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.objects["/namespaces/example%2Fplatform"] = map[string]any{"id": 7}
+	f.collections["/projects"] = []map[string]any{}
+	m := NewProject()
+	op := fakeOperation(
+		f, "gitlab_project", map[string]any{
+			inputNamespace: "example/platform", inputName: "app", inputInitializeWithReadme: true,
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	created := f.collections["/projects"][0]
+	require.Equal(t, "app", created["name"])
+	require.Equal(t, "app", created["path"])
+	require.EqualValues(t, 7, created["namespace_id"])
+	require.Equal(t, projectVisibilityPrivate, created["visibility"])
+	require.Equal(t, true, created["initialize_with_readme"])
+	require.NotContains(t, created, "description")
+}
+
+func TestProject_UpdateExisting(t *testing.T) {
+	f := newFakeGitLab(t)
+	existing := map[string]any{
+		"id":                  42,
+		"path_with_namespace": "example/app",
+		"visibility":          projectVisibilityPrivate,
+		"description":         nil,
+		"ssh_url_to_repo":     "git@gitlab.com:example/app.git",
+	}
+	f.objects["/projects/example%2Fapp"] = existing
+	f.objects["/projects/42"] = existing
+	m := NewProject()
+
+	unmanaged := fakeOperation(f, "gitlab_project", map[string]any{inputNamespace: "example", inputName: "app"})
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), unmanaged)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "example/app", ctx.outputs[outputFullPath])
+	require.Equal(t, int64(42), ctx.outputs[outputID])
+
+	op := fakeOperation(
+		f, "gitlab_project", map[string]any{
+			inputNamespace:   "example",
+			inputName:        "app",
+			inputVisibility:  projectVisibilityInternal,
+			inputDescription: "Application",
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PUT /projects/42")
+	require.Equal(t, projectVisibilityInternal, existing["visibility"])
+	require.Equal(t, "Application", existing["description"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestProject_DoesNotExist(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.objects["/projects/example%2Fapp"] = map[string]any{"id": 42}
+	m := NewProject()
+	op := fakeOperation(f, "gitlab_project", map[string]any{inputNamespace: "example", inputName: "app"})
+	op.DoesNotExist = true
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestProject_Validate(t *testing.T) {
+	f := newFakeGitLab(t)
+	m := NewProject()
+
+	require.ErrorContains(
+		t,
+		m.Validate(*fakeOperation(f, "gitlab_project", map[string]any{inputName: "app"})),
+		"missing required parameter: namespace",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*fakeOperation(
+				f, "gitlab_project",
+				map[string]any{inputNamespace: "example", inputName: "app", inputVisibility: "secret"},
+			),
+		),
+		"invalid value",
+	)
+}
@@ -0,0 +1,339 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+// webhookEvents are the events a project webhook can be triggered by. Each event is enabled with
+// the `<event>_events` field of the webhook.
+var webhookEvents = []string{
+	"push",
+	"tag_push",
+	"issues",
+	"confidential_issues",
+	"merge_requests",
+	"note",
+	"confidential_note",
+	"job",
+	"pipeline",
+	"wiki_page",
+	"deployment",
+	"releases",
+}
+
+var defaultWebhookEvents = []string{"push"}
+
+func init() {
+	blackstart.RegisterModule("gitlab_webhook", NewWebhook)
+}
+
+var _ blackstart.Module = &webhook{}
+
+// NewWebhook creates a module that manages a webhook of a GitLab project.
+func NewWebhook() blackstart.Module {
+	return &webhook{}
+}
+
+// webhook implements the gitlab_webhook module.
+type webhook struct{}
+
+// desiredWebhook is the desired state of a webhook read from module inputs.
+type desiredWebhook struct {
+	project               string
+	url                   string
+	secret                string
+	events                []string
+	enableSSLVerification bool
+}
+
+// path returns the webhooks API path of the project.
+func (h desiredWebhook) path() string {
+	return projectPath(h.project) + "/hooks"
+}
+
+// find returns the webhook that delivers to the desired URL, if any. Webhooks are decoded as maps
+// to read the event fields.
+func (h desiredWebhook) find(hooks []map[string]any) map[string]any {
+	for _, hook := range hooks {
+		if hook["url"] == h.url {
+			return hook
+		}
+	}
+	return nil
+}
+
+// matches reports whether an existing webhook has the desired events and TLS verification.
+func (h desiredWebhook) matches(current map[string]any) bool {
+	for field, enabled := range h.fields() {
+		if value, _ := current[field].(bool); value != enabled {
+			return false
+		}
+	}
+	return true
+}
+
+// fields returns the event and TLS verification fields of the webhook. Events that are not desired
+// are disabled.
+func (h desiredWebhook) fields() map[string]bool {
+	fields := map[string]bool{"enable_ssl_verification": h.enableSSLVerification}
+	for _, event := range webhookEvents {
+		fields[event+"_events"] = slices.Contains(h.events, event)
+	}
+	return fields
+}
+
+// body returns the request body that creates or updates the webhook.
+func (h desiredWebhook) body() map[string]any {
+	body := map[string]any{"url": h.url}
+	if h.secret != "" {
+		body["token"] = h.secret
+	}
+	for field, enabled := range h.fields() {
+		body[field] = enabled
+	}
+	return body
+}
+
+func (m *webhook) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "gitlab_webhook",
+		Name: "GitLab webhook",
+		Description: util.CleanString(
+			`
+Ensures a webhook is registered for a GitLab project. Webhooks are matched by their URL, and the
+events and TLS verification of an existing webhook are updated when they differ. Events that are
+not listed in '''events''' are disabled.
+
+The supported events are '''push''', '''tag_push''', '''issues''', '''confidential_issues''',
+'''merge_requests''', '''note''', '''confidential_note''', '''job''', '''pipeline''',
+'''wiki_page''', '''deployment''', and '''releases'''.
+
+**Update Policies**
+
+GitLab does not return webhook secret tokens, so an existing secret cannot be compared with the
+desired secret. The following update policies are supported:
+
+- '''preserve_any''' - The secret of an existing webhook is only written when the webhook is otherwise updated. This is the default update policy.
+- '''overwrite''' - The webhook is written on every run.
+
+**Notes**
+
+- When '''doesNotExist''' is set, the webhook with the URL is deleted.
+`,
+		),
+		Requirements: []string{
+			"A GitLab token with the `api` scope and at least the Maintainer role in the project.",
+			"If the `token` input is not set, the `GITLAB_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputProject: projectInput(),
+				inputURL: {
+					Description: "URL the events are delivered to.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputSecret: {
+					Description: "Secret token sent with each delivery in the `X-Gitlab-Token` header.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Sensitive:   true,
+				},
+				inputEvents: {
+					Description: "Events that trigger the webhook, such as `push` or `merge_requests`.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+					Default:     defaultWebhookEvents,
+				},
+				inputEnableSSLVerification: {
+					Description: "If true, the TLS certificate of the URL is verified when events are delivered.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     true,
+				},
+				inputUpdatePolicy: {
+					Description: "Update policy for an existing webhook. One of `preserve_any` or `overwrite`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     updatePolicyPreserveAny,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the webhook.",
+				Type:        reflect.TypeFor[int64](),
+			},
+		},
+		Examples: map[string]string{
+			"Project webhook": `id: app-argocd-webhook
+module: gitlab_webhook
+inputs:
+  project: example/app
+  url: https://argocd.example.com/api/webhook
+  secret:
+    fromDependency:
+      id: webhook-secret
+      output: value
+  events:
+    - push
+    - tag_push`,
+		},
+	}
+}
+
+func (m *webhook) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputProject, inputURL); err != nil {
+		return err
+	}
+	if err := restapi.ValidateEnum(op, inputUpdatePolicy, updatePolicies); err != nil {
+		return err
+	}
+	if input := op.Inputs[inputURL]; input.IsStatic() {
+		value, _ := blackstart.InputAs[string](input, true)
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("parameter %s must be an http or https URL", inputURL)
+		}
+	}
+	if input, ok := op.Inputs[inputEvents]; ok && input.IsStatic() {
+		events, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputEvents, err)
+		}
+		if err = validateEvents(events); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputEvents, err)
+		}
+	}
+	return nil
+}
+
+func (m *webhook) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextWebhook(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	hooks, err := restapi.ListPages[map[string]any](ctx, c, desired.path())
+	if err != nil {
+		return false, fmt.Errorf("failed to list webhooks of %s: %w", desired.project, err)
+	}
+	current := desired.find(hooks)
+
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !desired.matches(current) {
+		return false, nil
+	}
+
+	policy, err := contextUpdatePolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if policy == updatePolicyOverwrite {
+		return false, nil
+	}
+	return true, ctx.Output(outputID, hookID(current))
+}
+
+func (m *webhook) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextWebhook(ctx)
+	if err != nil {
+		return err
+	}
+
+	hooks, err := restapi.ListPages[map[string]any](ctx, c, desired.path())
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks of %s: %w", desired.project, err)
+	}
+	current := desired.find(hooks)
+
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, fmt.Sprintf("%s/%d", desired.path(), hookID(current)), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete webhook %s of %s: %w", desired.url, desired.project, err)
+		}
+		return nil
+	}
+
+	if current != nil {
+		path := fmt.Sprintf("%s/%d", desired.path(), hookID(current))
+		if err = c.Do(ctx, http.MethodPut, path, desired.body(), nil); err != nil {
+			return fmt.Errorf("failed to update webhook %s of %s: %w", desired.url, desired.project, err)
+		}
+		return ctx.Output(outputID, hookID(current))
+	}
+
+	var created map[string]any
+	if err = c.Do(ctx, http.MethodPost, desired.path(), desired.body(), &created); err != nil {
+		return fmt.Errorf("failed to create webhook %s of %s: %w", desired.url, desired.project, err)
+	}
+	return ctx.Output(outputID, hookID(created))
+}
+
+// hookID returns the ID of a webhook decoded as a map.
+func hookID(hook map[string]any) int64 {
+	id, _ := hook["id"].(float64)
+	return int64(id)
+}
+
+// validateEvents validates that the webhook events are not empty and are supported.
+func validateEvents(events []string) error {
+	if len(events) == 0 {
+		return fmt.Errorf("at least one event is required")
+	}
+	for _, event := range events {
+		if !slices.Contains(webhookEvents, event) {
+			return fmt.Errorf("unsupported event '%s', must be one of %s", event, strings.Join(webhookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// contextWebhook reads the desired webhook from module inputs.
+func contextWebhook(ctx blackstart.ModuleContext) (desiredWebhook, error) {
+	var h desiredWebhook
+	var err error
+	if h.project, err = blackstart.ContextInputAs[string](ctx, inputProject, true); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.url, err = blackstart.ContextInputAs[string](ctx, inputURL, true); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.secret, err = blackstart.ContextInputAs[string](ctx, inputSecret, false); err != nil {
+		return desiredWebhook{}, err
+	}
+	if h.events, err = blackstart.ContextInputAs[[]string](ctx, inputEvents, false); err != nil {
+		return desiredWebhook{}, err
+	}
+	if len(h.events) == 0 {
+		h.events = defaultWebhookEvents
+	}
+	if err = validateEvents(h.events); err != nil {
+		return desiredWebhook{}, fmt.Errorf("input '%s' is invalid: %w", inputEvents, err)
+	}
+	if h.enableSSLVerification, err = contextBool(ctx, inputEnableSSLVerification, true); err != nil {
+		return desiredWebhook{}, err
+	}
+	return h, nil
+}
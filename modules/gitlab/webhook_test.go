@@ -0,0 +1,122 @@
+package gitlab
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestWebhook_Create(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections["/projects/example%2Fapp/hooks"] = []map[string]any{}
+	m := NewWebhook()
+	op := fakeOperation(
+		f, "gitlab_webhook", map[string]any{
+			inputProject: "example/app",
+			inputURL:     "https://ci.example.com/hook",
+			inputSecret:  "s3cret",
+			inputEvents:  []string{"push", "merge_requests"},
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+
+	hooks := f.collections["/projects/example%2Fapp/hooks"]
+	require.Len(t, hooks, 1)
+	require.Equal(t, "s3cret", hooks[0]["token"])
+	require.Equal(t, true, hooks[0]["push_events"])
+	require.Equal(t, true, hooks[0]["merge_requests_events"])
+	require.Equal(t, false, hooks[0]["tag_push_events"])
+	require.Equal(t, true, hooks[0]["enable_ssl_verification"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestWebhook_UpdateExisting(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections["/projects/example%2Fapp/hooks"] = []map[string]any{
+		{"id": 9, "url": "https://ci.example.com/hook", "push_events": true, "enable_ssl_verification": true},
+	}
+	m := NewWebhook()
+
+	preserve := fakeOperation(
+		f, "gitlab_webhook", map[string]any{inputProject: "example/app", inputURL: "https://ci.example.com/hook"},
+	)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), preserve)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(9), ctx.outputs[outputID])
+
+	op := fakeOperation(
+		f, "gitlab_webhook", map[string]any{
+			inputProject:               "example/app",
+			inputURL:                   "https://ci.example.com/hook",
+			inputEvents:                []string{"tag_push"},
+			inputEnableSSLVerification: false,
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PUT /projects/example%2Fapp/hooks/9")
+
+	hook := f.collections["/projects/example%2Fapp/hooks"][0]
+	require.Equal(t, false, hook["push_events"])
+	require.Equal(t, true, hook["tag_push_events"])
+	require.Equal(t, false, hook["enable_ssl_verification"])
+}
+
+func TestWebhook_DoesNotExist(t *testing.T) {
+	f := newFakeGitLab(t)
+	f.collections["/projects/example%2Fapp/hooks"] = []map[string]any{
+		{"id": 9, "url": "https://ci.example.com/hook"},
+	}
+	m := NewWebhook()
+	op := fakeOperation(
+		f, "gitlab_webhook", map[string]any{inputProject: "example/app", inputURL: "https://ci.example.com/hook"},
+	)
+	op.DoesNotExist = true
+
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Empty(t, f.collections["/projects/example%2Fapp/hooks"])
+}
+
+func TestWebhook_Validate(t *testing.T) {
+	f := newFakeGitLab(t)
+	m := NewWebhook()
+
+	require.ErrorContains(
+		t,
+		m.Validate(*fakeOperation(f, "gitlab_webhook", map[string]any{inputProject: "example/app"})),
+		"missing required parameter: url",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(
+			*fakeOperation(
+				f, "gitlab_webhook", map[string]any{
+					inputProject: "example/app",
+					inputURL:     "https://ci.example.com",
+					inputEvents:  []string{"pull_request"},
+				},
+			),
+		),
+		"unsupported event 'pull_request'",
+	)
+}
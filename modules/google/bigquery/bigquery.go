@@ -0,0 +1,145 @@
+package bigquery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject     = "project"
+	inputDataset     = "dataset"
+	inputLocation    = "location"
+	inputDescription = "description"
+	inputLabels      = "labels"
+	inputReaders     = "readers"
+	inputWriters     = "writers"
+	inputOwners      = "owners"
+
+	inputTable               = "table"
+	inputSchema              = "schema"
+	inputPartitionType       = "partition_type"
+	inputPartitionField      = "partition_field"
+	inputPartitionExpiration = "partition_expiration"
+	inputClusteringFields    = "clustering_fields"
+
+	outputDataset  = "dataset"
+	outputLocation = "location"
+	outputTable    = "table"
+
+	// defaultLocation is the location of datasets created without a location input.
+	defaultLocation = "US"
+
+	roleReader = "READER"
+	roleWriter = "WRITER"
+	roleOwner  = "OWNER"
+
+	// defaultPartitionType is the partitioning type of tables partitioned without a type input.
+	defaultPartitionType = "DAY"
+	// maxClusteringFields is the maximum number of clustering columns of a table.
+	maxClusteringFields = 4
+)
+
+// partitionTypes are the supported time partitioning types of tables.
+var partitionTypes = []string{"HOUR", "DAY", "MONTH", "YEAR"}
+
+// predefinedRoles maps the predefined dataset roles to the basic roles the BigQuery API reports
+// for them in the access list of a dataset.
+var predefinedRoles = map[string]string{
+	"roles/bigquery.dataViewer": roleReader,
+	"roles/bigquery.dataEditor": roleWriter,
+	"roles/bigquery.dataOwner":  roleOwner,
+}
+
+func init() {
+	blackstart.RegisterPathName("bigquery", "BigQuery")
+}
+
+// bigQueryRuntime provides the injectable BigQuery API dependency.
+type bigQueryRuntime struct {
+	newService func(context.Context) (*bigquery.Service, error)
+}
+
+// defaultBigQueryRuntime creates the production BigQuery runtime.
+func defaultBigQueryRuntime() *bigQueryRuntime {
+	return &bigQueryRuntime{
+		newService: func(ctx context.Context) (*bigquery.Service, error) {
+			return cloud.NewService(ctx, bigquery.NewService, nil, bigquery.BigqueryScope)
+		},
+	}
+}
+
+// bigQueryRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
+func bigQueryRuntimeOrDefault(runtime *bigQueryRuntime) *bigQueryRuntime {
+	if runtime == nil {
+		return defaultBigQueryRuntime()
+	}
+	return runtime
+}
+
+// accessEntry returns the dataset access entry that grants the role to a member. Members use the
+// IAM format, such as `serviceAccount:app@project.iam.gserviceaccount.com`, `group:` or `domain:`.
+// A member without a type is a user or service account email.
+func accessEntry(role, member string) (*bigquery.DatasetAccess, error) {
+	kind, value, found := strings.Cut(strings.TrimSpace(member), ":")
+	if !found {
+		kind, value = "user", kind
+	}
+	if value == "" {
+		return nil, fmt.Errorf("member %q must not be empty", member)
+	}
+	entry := &bigquery.DatasetAccess{Role: role}
+	switch kind {
+	case "user", "serviceAccount":
+		entry.UserByEmail = value
+	case "group":
+		entry.GroupByEmail = value
+	case "domain":
+		entry.Domain = value
+	default:
+		return nil, fmt.Errorf(
+			"member %q must be an email, or have the user, serviceAccount, group, or domain type", member,
+		)
+	}
+	return entry, nil
+}
+
+// sameAccess reports whether two access entries grant the same role to the same member. Emails and
+// domains are compared without case.
+func sameAccess(a, b *bigquery.DatasetAccess) bool {
+	return basicRole(a.Role) == basicRole(b.Role) &&
+		strings.EqualFold(a.UserByEmail, b.UserByEmail) &&
+		strings.EqualFold(a.GroupByEmail, b.GroupByEmail) &&
+		strings.EqualFold(a.Domain, b.Domain)
+}
+
+// basicRole returns the basic role of a predefined dataset role, or the role itself.
+func basicRole(role string) string {
+	if basic, ok := predefinedRoles[role]; ok {
+		return basic
+	}
+	return role
+}
+
+// missingAccess returns the desired access entries that are not in the current access list.
+func missingAccess(current, desired []*bigquery.DatasetAccess) []*bigquery.DatasetAccess {
+	var missing []*bigquery.DatasetAccess
+	for _, d := range desired {
+		found := false
+		for _, c := range current {
+			if sameAccess(c, d) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,412 @@
+package bigquery
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_bigquery_dataset", NewDataset)
+}
+
+var _ blackstart.Module = &dataset{}
+
+// dataset manages a BigQuery dataset and the access granted on it.
+type dataset struct {
+	runtime *bigQueryRuntime
+	svc     *bigquery.Service
+	target  *datasetTarget
+}
+
+// datasetTarget is the desired state of a dataset resolved from the module inputs.
+type datasetTarget struct {
+	project     string
+	id          string
+	location    string
+	description *string
+	labels      map[string]string
+	access      []*bigquery.DatasetAccess
+}
+
+// name returns the reference of the dataset in SQL, `<project>.<dataset>`.
+func (t *datasetTarget) name() string {
+	return t.project + "." + t.id
+}
+
+func NewDataset() blackstart.Module {
+	return &dataset{}
+}
+
+func (d *dataset) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_bigquery_dataset",
+		Name: "Google BigQuery Dataset",
+		Description: util.CleanString(
+			`
+Ensures a BigQuery dataset exists with the description and labels, and grants access on the dataset
+to service accounts, users, groups, or domains.
+
+Access is granted with the '''readers''', '''writers''', and '''owners''' inputs, which map to the
+'''READER''', '''WRITER''', and '''OWNER''' dataset roles. Members use the IAM format, such as
+'''serviceAccount:app@project.iam.gserviceaccount.com''' or '''group:analysts@example.com'''. A
+member without a type is a user or service account email.
+
+**Notes**
+
+- Access granted to other members and labels that are not set in '''labels''' are not changed.
+- The location of an existing dataset cannot be changed. The operation fails when the dataset is in
+  a different location.
+- When '''doesNotExist''' is set, the dataset is deleted. A dataset that contains tables is not
+  deleted and the operation fails.
+`,
+		),
+		Requirements: []string{
+			"The BigQuery API (`bigquery.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/bigquery.dataOwner` on the dataset, or `roles/bigquery.user` in the project to create datasets.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Google Cloud project of the dataset. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputDataset: {
+				Description: "ID of the dataset, such as `analytics`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputLocation: {
+				Description: "Location of the dataset, such as `US`, `EU`, or `europe-west1`. Only used when the dataset is created, and compared without case afterwards.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultLocation,
+				Immutable:   true,
+			},
+			inputDescription: {
+				Description: "Description of the dataset. If not set, the description is not managed.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLabels: {
+				Description: "Labels the dataset must have, as a map of label keys to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+			inputReaders: {
+				Description: "Members granted the `READER` role on the dataset.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+			inputWriters: {
+				Description: "Members granted the `WRITER` role on the dataset.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+			inputOwners: {
+				Description: "Members granted the `OWNER` role on the dataset.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputDataset: {
+				Description: "Reference of the dataset in SQL, `<project>.<dataset>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputLocation: {
+				Description: "Location of the dataset.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Analytics Dataset": `id: analytics-dataset
+module: google_bigquery_dataset
+inputs:
+  project: analytics-project
+  dataset: events
+  location: EU
+  description: Application events
+  labels:
+    team: data
+  readers:
+    - group:analysts@example.com
+  writers:
+    - serviceAccount:loader@analytics-project.iam.gserviceaccount.com`,
+		},
+	}
+}
+
+func (d *dataset) Validate(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputDataset]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", inputDataset)
+	}
+	if input.IsStatic() {
+		id, err := blackstart.InputAs[string](input, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputDataset, err)
+		}
+		if id == "" {
+			return fmt.Errorf("%s cannot be empty", inputDataset)
+		}
+	}
+	for role, key := range accessInputs() {
+		input, ok = op.Inputs[key]
+		if !ok || !input.IsStatic() {
+			continue
+		}
+		members, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		for _, member := range members {
+			if _, err = accessEntry(role, member); err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
+	}
+	if input, ok = op.Inputs[inputLabels]; ok && input.IsStatic() {
+		if _, err := inputLabelValues(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the dataset is in the requested state.
+func (d *dataset) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := d.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(d.target.name())
+
+	existing, err := d.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if err = d.verifyLocation(existing); err != nil {
+		return false, err
+	}
+	if d.update(existing) != nil {
+		return false, nil
+	}
+	return true, d.output(ctx, existing)
+}
+
+// Set reconciles the dataset to the requested state.
+func (d *dataset) Set(ctx blackstart.ModuleContext) error {
+	if err := d.setup(ctx); err != nil {
+		return err
+	}
+	ctx.Resource(d.target.name())
+
+	existing, err := d.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		err = d.svc.Datasets.Delete(d.target.project, d.target.id).Context(ctx).Do()
+		if err != nil && !cloud.IsNotFound(err) {
+			return fmt.Errorf("failed to delete dataset %s: %w", d.target.name(), err)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		created := &bigquery.Dataset{
+			DatasetReference: &bigquery.DatasetReference{ProjectId: d.target.project, DatasetId: d.target.id},
+			Location:         d.target.location,
+			Labels:           d.target.labels,
+		}
+		if d.target.description != nil {
+			created.Description = *d.target.description
+		}
+		created, err = d.svc.Datasets.Insert(d.target.project, created).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create dataset %s: %w", d.target.name(), err)
+		}
+		existing = created
+	}
+	if err = d.verifyLocation(existing); err != nil {
+		return err
+	}
+
+	if patch := d.update(existing); patch != nil {
+		// The etag makes the update fail instead of overwriting a concurrent change to the access
+		// list, which is replaced as a whole.
+		call := d.svc.Datasets.Patch(d.target.project, d.target.id, patch).Context(ctx)
+		call.Header().Set("If-Match", existing.Etag)
+		if existing, err = call.Do(); err != nil {
+			return fmt.Errorf("failed to update dataset %s: %w", d.target.name(), err)
+		}
+	}
+	return d.output(ctx, existing)
+}
+
+// setup resolves the target dataset from the inputs and creates the BigQuery service.
+func (d *dataset) setup(ctx blackstart.ModuleContext) error {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	id, err := blackstart.ContextInputAs[string](ctx, inputDataset, true)
+	if err != nil {
+		return err
+	}
+	location, err := blackstart.ContextInputAs[string](ctx, inputLocation, false)
+	if err != nil {
+		return err
+	}
+	if location == "" {
+		location = defaultLocation
+	}
+	target := &datasetTarget{project: project, id: id, location: location}
+
+	if input, iErr := ctx.Input(inputDescription); iErr == nil && input.Any() != nil {
+		description, dErr := blackstart.InputAs[string](input, false)
+		if dErr != nil {
+			return fmt.Errorf("invalid %s: %w", inputDescription, dErr)
+		}
+		target.description = &description
+	}
+	if input, iErr := ctx.Input(inputLabels); iErr == nil && input.Any() != nil {
+		if target.labels, err = inputLabelValues(input); err != nil {
+			return err
+		}
+	}
+	for _, role := range []string{roleReader, roleWriter, roleOwner} {
+		key := accessInputs()[role]
+		members, mErr := blackstart.ContextInputAs[[]string](ctx, key, false)
+		if mErr != nil {
+			return mErr
+		}
+		for _, member := range members {
+			entry, aErr := accessEntry(role, member)
+			if aErr != nil {
+				return fmt.Errorf("invalid %s: %w", key, aErr)
+			}
+			target.access = append(target.access, entry)
+		}
+	}
+	d.target = target
+
+	d.runtime = bigQueryRuntimeOrDefault(d.runtime)
+	d.svc, err = d.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create BigQuery service: %w", err)
+	}
+	return nil
+}
+
+// get returns the dataset, or nil if it does not exist.
+func (d *dataset) get(ctx context.Context) (*bigquery.Dataset, error) {
+	existing, err := d.svc.Datasets.Get(d.target.project, d.target.id).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get dataset %s: %w", d.target.name(), err)
+	}
+	return existing, nil
+}
+
+// verifyLocation returns an error when the dataset is in a different location.
+func (d *dataset) verifyLocation(existing *bigquery.Dataset) error {
+	if !strings.EqualFold(existing.Location, d.target.location) {
+		return fmt.Errorf(
+			"dataset %s is in location %q instead of %q", d.target.name(), existing.Location, d.target.location,
+		)
+	}
+	return nil
+}
+
+// update returns the patch that brings the dataset to the desired state, or nil when the dataset
+// is already in the desired state.
+func (d *dataset) update(existing *bigquery.Dataset) *bigquery.Dataset {
+	patch := &bigquery.Dataset{}
+	changed := false
+	if d.target.description != nil && existing.Description != *d.target.description {
+		patch.Description = *d.target.description
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+		changed = true
+	}
+	for key, value := range d.target.labels {
+		if current, ok := existing.Labels[key]; !ok || current != value {
+			patch.Labels = maps.Clone(existing.Labels)
+			if patch.Labels == nil {
+				patch.Labels = make(map[string]string, len(d.target.labels))
+			}
+			maps.Copy(patch.Labels, d.target.labels)
+			changed = true
+			break
+		}
+	}
+	if missing := missingAccess(existing.Access, d.target.access); len(missing) > 0 {
+		patch.Access = append(append([]*bigquery.DatasetAccess{}, existing.Access...), missing...)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return patch
+}
+
+// output emits the outputs of the dataset.
+func (d *dataset) output(ctx blackstart.ModuleContext, existing *bigquery.Dataset) error {
+	if err := ctx.Output(outputDataset, d.target.name()); err != nil {
+		return err
+	}
+	return ctx.Output(outputLocation, existing.Location)
+}
+
+// accessInputs returns the access input of each dataset role.
+func accessInputs() map[string]string {
+	return map[string]string{
+		roleReader: inputReaders,
+		roleWriter: inputWriters,
+		roleOwner:  inputOwners,
+	}
+}
+
+// inputLabelValues returns the labels of a labels input. Label values must be scalar values.
+func inputLabelValues(input blackstart.Input) (map[string]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputLabels, err)
+	}
+	labels := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string, bool, int, int64, float64:
+			labels[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("invalid %s: value of label %s must be a scalar value", inputLabels, key)
+		}
+	}
+	return labels, nil
+}
@@ -0,0 +1,291 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+const (
+	testDatasetPath = "projects/analytics/datasets/events"
+	testTablePath   = testDatasetPath + "/tables/page_views"
+)
+
+// fakeBigQuery implements the BigQuery REST operations used by the dataset and table modules.
+type fakeBigQuery struct {
+	t        *testing.T
+	server   *httptest.Server
+	dataset  *bigquery.Dataset
+	table    *bigquery.Table
+	requests []string
+	etags    []string
+	mu       sync.Mutex
+}
+
+// newFakeBigQuery starts a stateful fake BigQuery API server.
+func newFakeBigQuery(t *testing.T) *fakeBigQuery {
+	t.Helper()
+	f := &fakeBigQuery{t: t}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a BigQuery runtime connected to the fake API.
+func (f *fakeBigQuery) runtime() *bigQueryRuntime {
+	return &bigQueryRuntime{
+		newService: func(ctx context.Context) (*bigquery.Service, error) {
+			return bigquery.NewService(ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication())
+		},
+	}
+}
+
+// serveHTTP handles the BigQuery API operations used by the unit tests.
+func (f *fakeBigQuery) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	switch {
+	case r.Method == http.MethodGet && path == testDatasetPath:
+		if f.dataset == nil {
+			http.Error(w, "dataset not found", http.StatusNotFound)
+			return
+		}
+		cloudtest.WriteJSON(f.t, w, f.dataset)
+	case r.Method == http.MethodPost && path == "projects/analytics/datasets":
+		var d bigquery.Dataset
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&d))
+		d.Etag = "etag-1"
+		// BigQuery grants the project owners access to new datasets.
+		d.Access = []*bigquery.DatasetAccess{{Role: roleOwner, SpecialGroup: "projectOwners"}}
+		f.dataset = &d
+		cloudtest.WriteJSON(f.t, w, f.dataset)
+	case r.Method == http.MethodPatch && path == testDatasetPath:
+		var d bigquery.Dataset
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&d))
+		f.etags = append(f.etags, r.Header.Get("If-Match"))
+		if d.Description != "" {
+			f.dataset.Description = d.Description
+		}
+		if d.Labels != nil {
+			f.dataset.Labels = d.Labels
+		}
+		if d.Access != nil {
+			f.dataset.Access = d.Access
+		}
+		f.dataset.Etag = "etag-2"
+		cloudtest.WriteJSON(f.t, w, f.dataset)
+	case r.Method == http.MethodDelete && path == testDatasetPath:
+		f.dataset = nil
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && path == testTablePath:
+		if f.table == nil {
+			http.Error(w, "table not found", http.StatusNotFound)
+			return
+		}
+		cloudtest.WriteJSON(f.t, w, f.table)
+	case r.Method == http.MethodPost && path == testDatasetPath+"/tables":
+		var tbl bigquery.Table
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&tbl))
+		tbl.Etag = "etag-1"
+		f.table = &tbl
+		cloudtest.WriteJSON(f.t, w, f.table)
+	case r.Method == http.MethodPatch && path == testTablePath:
+		var tbl bigquery.Table
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&tbl))
+		f.etags = append(f.etags, r.Header.Get("If-Match"))
+		if tbl.Description != "" {
+			f.table.Description = tbl.Description
+		}
+		if tbl.Labels != nil {
+			f.table.Labels = tbl.Labels
+		}
+		if tbl.Schema != nil {
+			f.table.Schema = tbl.Schema
+		}
+		if tbl.TimePartitioning != nil {
+			f.table.TimePartitioning = tbl.TimePartitioning
+		}
+		if tbl.Clustering != nil {
+			f.table.Clustering = tbl.Clustering
+		}
+		f.table.Etag = "etag-2"
+		cloudtest.WriteJSON(f.t, w, f.table)
+	case r.Method == http.MethodDelete && path == testTablePath:
+		f.table = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.t.Errorf("unexpected BigQuery API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// requestCount returns the number of requests received with the method.
+func (f *fakeBigQuery) requestCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, request := range f.requests {
+		if strings.HasPrefix(request, method+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testDatasetOperation creates a dataset operation for the events dataset.
+func testDatasetOperation() blackstart.Operation {
+	return blackstart.Operation{
+		Id:     "dataset",
+		Module: "google_bigquery_dataset",
+		Inputs: map[string]blackstart.Input{
+			inputProject:     blackstart.NewInputFromValue("analytics"),
+			inputDataset:     blackstart.NewInputFromValue("events"),
+			inputLocation:    blackstart.NewInputFromValue("EU"),
+			inputDescription: blackstart.NewInputFromValue("Application events"),
+			inputLabels:      blackstart.NewInputFromValue(map[string]any{"team": "data"}),
+			inputReaders:     blackstart.NewInputFromValue([]any{"group:analysts@example.com"}),
+			inputWriters: blackstart.NewInputFromValue(
+				[]any{"serviceAccount:loader@analytics.iam.gserviceaccount.com"},
+			),
+		},
+	}
+}
+
+// testDatasetContext creates a module context for the operation that records outputs.
+func testDatasetContext(op *blackstart.Operation) *outputContext {
+	return &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), op),
+		outputs:       map[string]any{},
+	}
+}
+
+func TestDataset_Validate(t *testing.T) {
+	module := NewDataset()
+	require.NoError(t, module.Validate(testDatasetOperation()))
+
+	op := testDatasetOperation()
+	delete(op.Inputs, inputDataset)
+	require.ErrorContains(t, module.Validate(op), "missing required parameter: dataset")
+
+	op = testDatasetOperation()
+	op.Inputs[inputReaders] = blackstart.NewInputFromValue([]any{"team:analysts"})
+	require.ErrorContains(t, module.Validate(op), "invalid readers")
+
+	op = testDatasetOperation()
+	op.Inputs[inputLabels] = blackstart.NewInputFromValue(map[string]any{"team": []any{"a"}})
+	require.ErrorContains(t, module.Validate(op), "must be a scalar value")
+}
+
+func TestDataset_CreateAndCheck(t *testing.T) {
+	fake := newFakeBigQuery(t)
+	op := testDatasetOperation()
+	module := &dataset{runtime: fake.runtime()}
+
+	ctx := testDatasetContext(&op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, "analytics.events", ctx.outputs[outputDataset])
+	require.Equal(t, "EU", ctx.outputs[outputLocation])
+	require.Equal(t, "Application events", fake.dataset.Description)
+	require.Equal(t, map[string]string{"team": "data"}, fake.dataset.Labels)
+	// The access of the project owners is kept when access is granted.
+	require.Len(t, fake.dataset.Access, 3)
+	require.Equal(t, []string{"etag-1"}, fake.etags)
+
+	ok, err = module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDataset_UpdatesAccessAndLabels(t *testing.T) {
+	fake := newFakeBigQuery(t)
+	fake.dataset = &bigquery.Dataset{
+		Location:    "eu",
+		Description: "Application events",
+		Etag:        "etag-1",
+		Labels:      map[string]string{"team": "web", "cost-center": "42"},
+		Access: []*bigquery.DatasetAccess{
+			{Role: "roles/bigquery.dataViewer", GroupByEmail: "Analysts@example.com"},
+			{Role: roleOwner, UserByEmail: "admin@example.com"},
+		},
+	}
+	op := testDatasetOperation()
+	module := &dataset{runtime: fake.runtime()}
+
+	ok, err := module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testDatasetContext(&op)))
+	require.Equal(t, map[string]string{"team": "data", "cost-center": "42"}, fake.dataset.Labels)
+	// The reader matches the existing predefined role, so only the writer is added.
+	require.Len(t, fake.dataset.Access, 3)
+	require.Equal(t, "loader@analytics.iam.gserviceaccount.com", fake.dataset.Access[2].UserByEmail)
+	require.Equal(t, roleWriter, fake.dataset.Access[2].Role)
+
+	ok, err = module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, fake.requestCount(http.MethodPatch))
+}
+
+func TestDataset_LocationMismatch(t *testing.T) {
+	fake := newFakeBigQuery(t)
+	fake.dataset = &bigquery.Dataset{Location: "US"}
+	op := testDatasetOperation()
+	module := &dataset{runtime: fake.runtime()}
+
+	_, err := module.Check(testDatasetContext(&op))
+	require.ErrorContains(t, err, `dataset analytics.events is in location "US" instead of "EU"`)
+	require.ErrorContains(t, module.Set(testDatasetContext(&op)), "instead of")
+	require.Zero(t, fake.requestCount(http.MethodPatch))
+}
+
+func TestDataset_DoesNotExist(t *testing.T) {
+	fake := newFakeBigQuery(t)
+	fake.dataset = &bigquery.Dataset{Location: "EU"}
+	op := testDatasetOperation()
+	op.DoesNotExist = true
+	module := &dataset{runtime: fake.runtime()}
+
+	ok, err := module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testDatasetContext(&op)))
+	require.Nil(t, fake.dataset)
+
+	ok, err = module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, module.Set(testDatasetContext(&op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete))
+}
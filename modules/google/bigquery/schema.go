@@ -0,0 +1,194 @@
+package bigquery
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"google.golang.org/api/bigquery/v2"
+)
+
+const (
+	modeNullable = "NULLABLE"
+	modeRequired = "REQUIRED"
+	modeRepeated = "REPEATED"
+
+	typeRecord = "RECORD"
+)
+
+// legacyTypes maps the GoogleSQL names of column types to the legacy names the BigQuery API reports
+// in table schemas.
+var legacyTypes = map[string]string{
+	"INT64":   "INTEGER",
+	"FLOAT64": "FLOAT",
+	"BOOL":    "BOOLEAN",
+	"STRUCT":  typeRecord,
+}
+
+// parseSchema parses a table schema in the JSON format of the `bq` tool, a list of columns with a
+// name, type, mode, description, and the nested columns of RECORD columns. Types and modes are
+// normalized to the names the BigQuery API reports.
+func parseSchema(raw string) ([]*bigquery.TableFieldSchema, error) {
+	var fields []*bigquery.TableFieldSchema
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil, fmt.Errorf("invalid %s: expected a JSON list of columns: %w", inputSchema, err)
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("invalid %s: the schema has no columns", inputSchema)
+	}
+	if err := normalizeFields(fields, ""); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputSchema, err)
+	}
+	return fields, nil
+}
+
+// normalizeFields validates the columns of a schema and normalizes their types and modes. The
+// prefix is the path of the parent RECORD column.
+func normalizeFields(fields []*bigquery.TableFieldSchema, prefix string) error {
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if f == nil || f.Name == "" {
+			return fmt.Errorf("column of %s has no name", schemaPath(prefix, ""))
+		}
+		path := schemaPath(prefix, f.Name)
+		if seen[strings.ToLower(f.Name)] {
+			return fmt.Errorf("column %s is defined more than once", path)
+		}
+		seen[strings.ToLower(f.Name)] = true
+		if f.Type == "" {
+			return fmt.Errorf("column %s has no type", path)
+		}
+		f.Type = normalizeType(f.Type)
+		f.Mode = normalizeMode(f.Mode)
+		switch f.Mode {
+		case modeNullable, modeRequired, modeRepeated:
+		default:
+			return fmt.Errorf("column %s has mode %q, expected NULLABLE, REQUIRED, or REPEATED", path, f.Mode)
+		}
+		if (f.Type == typeRecord) != (len(f.Fields) > 0) {
+			return fmt.Errorf("column %s must have nested columns only if it is a RECORD", path)
+		}
+		if err := normalizeFields(f.Fields, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeType returns the legacy name of a column type.
+func normalizeType(t string) string {
+	t = strings.ToUpper(t)
+	if legacy, ok := legacyTypes[t]; ok {
+		return legacy
+	}
+	return t
+}
+
+// normalizeMode returns the mode of a column, which defaults to NULLABLE.
+func normalizeMode(mode string) string {
+	if mode == "" {
+		return modeNullable
+	}
+	return strings.ToUpper(mode)
+}
+
+// schemaPath returns the path of a column in the schema, such as `address.city`.
+func schemaPath(prefix, name string) string {
+	if prefix == "" {
+		if name == "" {
+			return "the schema"
+		}
+		return name
+	}
+	if name == "" {
+		return prefix
+	}
+	return prefix + "." + name
+}
+
+// mergeSchema returns the schema of an existing table with the changes of the desired schema, and
+// whether it changed. BigQuery only allows adding columns that are not REQUIRED, relaxing REQUIRED
+// columns to NULLABLE, and changing descriptions, so other changes are errors. Columns of the
+// table that are not in the desired schema are kept.
+func mergeSchema(existing, desired []*bigquery.TableFieldSchema, prefix string) (
+	[]*bigquery.TableFieldSchema, bool, error,
+) {
+	merged := make([]*bigquery.TableFieldSchema, 0, max(len(existing), len(desired)))
+	changed := false
+	matched := make(map[*bigquery.TableFieldSchema]bool, len(desired))
+	for _, e := range existing {
+		d := findField(desired, e.Name)
+		if d == nil {
+			merged = append(merged, e)
+			continue
+		}
+		matched[d] = true
+		path := schemaPath(prefix, e.Name)
+		if normalizeType(e.Type) != d.Type {
+			return nil, false, fmt.Errorf(
+				"column %s has type %s instead of %s, and column types cannot be changed", path, e.Type, d.Type,
+			)
+		}
+		field := *e
+		switch current := normalizeMode(e.Mode); {
+		case current == d.Mode:
+		case current == modeRequired && d.Mode == modeNullable:
+			field.Mode = modeNullable
+			changed = true
+		default:
+			return nil, false, fmt.Errorf(
+				"column %s has mode %s instead of %s, and only REQUIRED columns can be changed to NULLABLE",
+				path, current, d.Mode,
+			)
+		}
+		if d.Description != "" && e.Description != d.Description {
+			field.Description = d.Description
+			changed = true
+		}
+		if d.Type == typeRecord {
+			fields, fieldsChanged, err := mergeSchema(e.Fields, d.Fields, path)
+			if err != nil {
+				return nil, false, err
+			}
+			field.Fields = fields
+			changed = changed || fieldsChanged
+		}
+		merged = append(merged, &field)
+	}
+	for _, d := range desired {
+		if matched[d] {
+			continue
+		}
+		if err := checkNewField(d, prefix); err != nil {
+			return nil, false, err
+		}
+		merged = append(merged, d)
+		changed = true
+	}
+	return merged, changed, nil
+}
+
+// checkNewField returns an error when a column, or a nested column, added to an existing table is
+// REQUIRED.
+func checkNewField(f *bigquery.TableFieldSchema, prefix string) error {
+	path := schemaPath(prefix, f.Name)
+	if f.Mode == modeRequired {
+		return fmt.Errorf("column %s cannot be added to an existing table as REQUIRED", path)
+	}
+	for _, nested := range f.Fields {
+		if err := checkNewField(nested, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findField returns the column with the name, compared without case as BigQuery does, or nil.
+func findField(fields []*bigquery.TableFieldSchema, name string) *bigquery.TableFieldSchema {
+	for _, f := range fields {
+		if strings.EqualFold(f.Name, name) {
+			return f
+		}
+	}
+	return nil
+}
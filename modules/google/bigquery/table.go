@@ -0,0 +1,537 @@
+package bigquery
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/bigquery/v2"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_bigquery_table", NewTable)
+}
+
+var _ blackstart.Module = &table{}
+
+// table manages a BigQuery table, its schema, partitioning, and clustering.
+type table struct {
+	runtime *bigQueryRuntime
+	svc     *bigquery.Service
+	target  *tableTarget
+}
+
+// tableTarget is the desired state of a table resolved from the module inputs.
+type tableTarget struct {
+	project      string
+	dataset      string
+	id           string
+	description  *string
+	labels       map[string]string
+	schema       []*bigquery.TableFieldSchema
+	partitioning *bigquery.TimePartitioning
+	expiration   *time.Duration
+	clustering   []string
+}
+
+// name returns the reference of the table in SQL, `<project>.<dataset>.<table>`.
+func (t *tableTarget) name() string {
+	return t.project + "." + t.dataset + "." + t.id
+}
+
+func NewTable() blackstart.Module {
+	return &table{}
+}
+
+func (t *table) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_bigquery_table",
+		Name: "Google BigQuery Table",
+		Description: util.CleanString(
+			`
+Ensures a BigQuery table exists in a dataset with the schema, time partitioning, clustering,
+description, and labels.
+
+The schema is a JSON list of columns, in the format of the '''bq''' tool. Each column has a
+'''name''' and '''type''', and optionally a '''mode''' ('''NULLABLE''', '''REQUIRED''', or
+'''REPEATED'''), a '''description''', and the nested '''fields''' of a '''RECORD''' column.
+
+**Notes**
+
+- The schema of an existing table is updated by adding columns and relaxing '''REQUIRED''' columns
+  to '''NULLABLE'''. BigQuery does not support other schema changes, so the operation fails when a
+  column has a different type or mode, or a new column is '''REQUIRED'''.
+- Columns of the table that are not in '''schema''' and labels that are not set in '''labels''' are
+  not removed.
+- The partitioning of an existing table cannot be changed. When '''partition_type''' or
+  '''partition_field''' is set, the operation fails when the table is partitioned differently. The
+  partition expiration and clustering columns are updated.
+- When '''doesNotExist''' is set, the table and its data are deleted.
+`,
+		),
+		Requirements: []string{
+			"The BigQuery API (`bigquery.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/bigquery.dataEditor` on the dataset.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Google Cloud project of the dataset. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputDataset: {
+				Description: "ID of the dataset of the table, such as `analytics`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputTable: {
+				Description: "ID of the table, such as `page_views`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputSchema: {
+				Description: "Schema of the table, as a JSON list of columns.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputDescription: {
+				Description: "Description of the table. If not set, the description is not managed.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLabels: {
+				Description: "Labels the table must have, as a map of label keys to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+			inputPartitionType: {
+				Description: "Time partitioning of the table, `HOUR`, `DAY`, `MONTH`, or `YEAR`. Defaults to `DAY` when another partitioning input is set. If no partitioning input is set, the table is not partitioned.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Immutable:   true,
+			},
+			inputPartitionField: {
+				Description: "Top-level `TIMESTAMP`, `DATE`, or `DATETIME` column the table is partitioned by. Defaults to the ingestion time.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Immutable:   true,
+			},
+			inputPartitionExpiration: {
+				Description: "How long time partitions are kept, such as `2160h` for 90 days. If not set, the expiration is not managed.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputClusteringFields: {
+				Description: "Top-level columns the table is clustered by, in order. At most 4 columns. If not set, the clustering is not managed.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputTable: {
+				Description: "Reference of the table in SQL, `<project>.<dataset>.<table>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Partitioned Events Table": `id: page-views-table
+module: google_bigquery_table
+inputs:
+  project: analytics-project
+  dataset: events
+  table: page_views
+  description: Page views of the web application
+  schema: |
+    [
+      {"name": "event_time", "type": "TIMESTAMP", "mode": "REQUIRED"},
+      {"name": "user_id", "type": "STRING"},
+      {"name": "page", "type": "RECORD", "fields": [
+        {"name": "path", "type": "STRING"},
+        {"name": "referrer", "type": "STRING"}
+      ]}
+    ]
+  partition_type: DAY
+  partition_field: event_time
+  partition_expiration: 2160h
+  clustering_fields:
+    - user_id`,
+		},
+	}
+}
+
+func (t *table) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputDataset, inputTable, inputSchema} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", key)
+		}
+		if input.IsStatic() {
+			value, err := blackstart.InputAs[string](input, true)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if value == "" {
+				return fmt.Errorf("%s cannot be empty", key)
+			}
+		}
+	}
+	var schema []*bigquery.TableFieldSchema
+	if input := op.Inputs[inputSchema]; input.IsStatic() {
+		raw, err := blackstart.InputAs[string](input, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputSchema, err)
+		}
+		if schema, err = parseSchema(raw); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputPartitionType]; ok && input.IsStatic() {
+		if _, err := inputPartitioningType(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputPartitionExpiration]; ok && input.IsStatic() {
+		if _, err := inputExpiration(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputClusteringFields]; ok && input.IsStatic() {
+		fields, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputClusteringFields, err)
+		}
+		if err = checkClustering(fields, schema); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputLabels]; ok && input.IsStatic() {
+		if _, err := inputLabelValues(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the table is in the requested state.
+func (t *table) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := t.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(t.target.name())
+
+	existing, err := t.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if err = t.verifyPartitioning(existing); err != nil {
+		return false, err
+	}
+	patch, err := t.update(existing)
+	if err != nil || patch != nil {
+		return false, err
+	}
+	return true, ctx.Output(outputTable, t.target.name())
+}
+
+// Set reconciles the table to the requested state.
+func (t *table) Set(ctx blackstart.ModuleContext) error {
+	if err := t.setup(ctx); err != nil {
+		return err
+	}
+	ctx.Resource(t.target.name())
+
+	existing, err := t.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		err = t.svc.Tables.Delete(t.target.project, t.target.dataset, t.target.id).Context(ctx).Do()
+		if err != nil && !cloud.IsNotFound(err) {
+			return fmt.Errorf("failed to delete table %s: %w", t.target.name(), err)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		created := &bigquery.Table{
+			TableReference: &bigquery.TableReference{
+				ProjectId: t.target.project, DatasetId: t.target.dataset, TableId: t.target.id,
+			},
+			Labels:           t.target.labels,
+			Schema:           &bigquery.TableSchema{Fields: t.target.schema},
+			TimePartitioning: t.target.partitioning,
+		}
+		if t.target.description != nil {
+			created.Description = *t.target.description
+		}
+		if len(t.target.clustering) > 0 {
+			created.Clustering = &bigquery.Clustering{Fields: t.target.clustering}
+		}
+		_, err = t.svc.Tables.Insert(t.target.project, t.target.dataset, created).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create table %s: %w", t.target.name(), err)
+		}
+		return ctx.Output(outputTable, t.target.name())
+	}
+	if err = t.verifyPartitioning(existing); err != nil {
+		return err
+	}
+
+	patch, err := t.update(existing)
+	if err != nil {
+		return err
+	}
+	if patch != nil {
+		// The etag makes the update fail instead of overwriting a concurrent change to the schema,
+		// which is replaced as a whole.
+		call := t.svc.Tables.Patch(t.target.project, t.target.dataset, t.target.id, patch).Context(ctx)
+		call.Header().Set("If-Match", existing.Etag)
+		if _, err = call.Do(); err != nil {
+			return fmt.Errorf("failed to update table %s: %w", t.target.name(), err)
+		}
+	}
+	return ctx.Output(outputTable, t.target.name())
+}
+
+// setup resolves the target table from the inputs and creates the BigQuery service.
+func (t *table) setup(ctx blackstart.ModuleContext) error {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	target := &tableTarget{project: project}
+	if target.dataset, err = blackstart.ContextInputAs[string](ctx, inputDataset, true); err != nil {
+		return err
+	}
+	if target.id, err = blackstart.ContextInputAs[string](ctx, inputTable, true); err != nil {
+		return err
+	}
+	rawSchema, err := blackstart.ContextInputAs[string](ctx, inputSchema, true)
+	if err != nil {
+		return err
+	}
+	if target.schema, err = parseSchema(rawSchema); err != nil {
+		return err
+	}
+
+	if input, iErr := ctx.Input(inputDescription); iErr == nil && input.Any() != nil {
+		description, dErr := blackstart.InputAs[string](input, false)
+		if dErr != nil {
+			return fmt.Errorf("invalid %s: %w", inputDescription, dErr)
+		}
+		target.description = &description
+	}
+	if input, iErr := ctx.Input(inputLabels); iErr == nil && input.Any() != nil {
+		if target.labels, err = inputLabelValues(input); err != nil {
+			return err
+		}
+	}
+
+	var partitionType string
+	if input, iErr := ctx.Input(inputPartitionType); iErr == nil {
+		if partitionType, err = inputPartitioningType(input); err != nil {
+			return err
+		}
+	}
+	partitionField, err := blackstart.ContextInputAs[string](ctx, inputPartitionField, false)
+	if err != nil {
+		return err
+	}
+	if input, iErr := ctx.Input(inputPartitionExpiration); iErr == nil {
+		if target.expiration, err = inputExpiration(input); err != nil {
+			return err
+		}
+	}
+	if partitionType != "" || partitionField != "" || target.expiration != nil {
+		if partitionType == "" {
+			partitionType = defaultPartitionType
+		}
+		target.partitioning = &bigquery.TimePartitioning{Type: partitionType, Field: partitionField}
+		if target.expiration != nil {
+			target.partitioning.ExpirationMs = target.expiration.Milliseconds()
+		}
+	}
+
+	if target.clustering, err = blackstart.ContextInputAs[[]string](ctx, inputClusteringFields, false); err != nil {
+		return err
+	}
+	if err = checkClustering(target.clustering, target.schema); err != nil {
+		return err
+	}
+	t.target = target
+
+	t.runtime = bigQueryRuntimeOrDefault(t.runtime)
+	t.svc, err = t.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create BigQuery service: %w", err)
+	}
+	return nil
+}
+
+// get returns the table, or nil if it does not exist.
+func (t *table) get(ctx context.Context) (*bigquery.Table, error) {
+	existing, err := t.svc.Tables.Get(t.target.project, t.target.dataset, t.target.id).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get table %s: %w", t.target.name(), err)
+	}
+	return existing, nil
+}
+
+// verifyPartitioning returns an error when the table is partitioned differently than requested.
+// The partitioning of a table is only compared when a partitioning input is set.
+func (t *table) verifyPartitioning(existing *bigquery.Table) error {
+	desired := t.target.partitioning
+	if desired == nil {
+		return nil
+	}
+	current := existing.TimePartitioning
+	if current == nil || current.Type != desired.Type || !strings.EqualFold(current.Field, desired.Field) {
+		return fmt.Errorf(
+			"table %s is %s instead of %s, and the partitioning of a table cannot be changed",
+			t.target.name(), describePartitioning(current), describePartitioning(desired),
+		)
+	}
+	return nil
+}
+
+// update returns the patch that brings the table to the desired state, or nil when the table is
+// already in the desired state. An error is returned when the schema cannot be changed to the
+// desired schema.
+func (t *table) update(existing *bigquery.Table) (*bigquery.Table, error) {
+	patch := &bigquery.Table{}
+	changed := false
+	if t.target.description != nil && existing.Description != *t.target.description {
+		patch.Description = *t.target.description
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+		changed = true
+	}
+	for key, value := range t.target.labels {
+		if current, ok := existing.Labels[key]; !ok || current != value {
+			patch.Labels = maps.Clone(existing.Labels)
+			if patch.Labels == nil {
+				patch.Labels = make(map[string]string, len(t.target.labels))
+			}
+			maps.Copy(patch.Labels, t.target.labels)
+			changed = true
+			break
+		}
+	}
+
+	var current []*bigquery.TableFieldSchema
+	if existing.Schema != nil {
+		current = existing.Schema.Fields
+	}
+	fields, schemaChanged, err := mergeSchema(current, t.target.schema, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to update schema of table %s: %w", t.target.name(), err)
+	}
+	if schemaChanged {
+		patch.Schema = &bigquery.TableSchema{Fields: fields}
+		changed = true
+	}
+
+	if t.target.expiration != nil && existing.TimePartitioning.ExpirationMs != t.target.expiration.Milliseconds() {
+		partitioning := *existing.TimePartitioning
+		partitioning.ExpirationMs = t.target.expiration.Milliseconds()
+		patch.TimePartitioning = &partitioning
+		changed = true
+	}
+	if len(t.target.clustering) > 0 &&
+		(existing.Clustering == nil || !slices.Equal(existing.Clustering.Fields, t.target.clustering)) {
+		patch.Clustering = &bigquery.Clustering{Fields: t.target.clustering}
+		changed = true
+	}
+	if !changed {
+		return nil, nil
+	}
+	return patch, nil
+}
+
+// describePartitioning describes the time partitioning of a table in errors.
+func describePartitioning(p *bigquery.TimePartitioning) string {
+	switch {
+	case p == nil:
+		return "not partitioned"
+	case p.Field == "":
+		return fmt.Sprintf("partitioned by %s of the ingestion time", p.Type)
+	default:
+		return fmt.Sprintf("partitioned by %s of column %s", p.Type, p.Field)
+	}
+}
+
+// inputPartitioningType returns the partitioning type of a partition type input.
+func inputPartitioningType(input blackstart.Input) (string, error) {
+	raw, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", inputPartitionType, err)
+	}
+	partitionType := strings.ToUpper(strings.TrimSpace(raw))
+	if partitionType != "" && !slices.Contains(partitionTypes, partitionType) {
+		return "", fmt.Errorf(
+			"invalid %s: %q must be one of %s", inputPartitionType, raw, strings.Join(partitionTypes, ", "),
+		)
+	}
+	return partitionType, nil
+}
+
+// inputExpiration returns the partition expiration of a partition expiration input, or nil when it
+// is not set.
+func inputExpiration(input blackstart.Input) (*time.Duration, error) {
+	raw, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputPartitionExpiration, err)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	expiration, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputPartitionExpiration, err)
+	}
+	if expiration <= 0 {
+		return nil, fmt.Errorf("invalid %s: %s must be positive", inputPartitionExpiration, raw)
+	}
+	return &expiration, nil
+}
+
+// checkClustering returns an error when there are too many clustering columns, or a clustering
+// column is not a top-level column of the schema. The columns are not compared to the schema when
+// it is not known.
+func checkClustering(fields []string, schema []*bigquery.TableFieldSchema) error {
+	if len(fields) > maxClusteringFields {
+		return fmt.Errorf("invalid %s: at most %d columns are supported", inputClusteringFields, maxClusteringFields)
+	}
+	for _, field := range fields {
+		if schema != nil && findField(schema, field) == nil {
+			return fmt.Errorf("invalid %s: column %s is not in the schema", inputClusteringFields, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package bigquery
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/bigquery/v2"
+
+	"github.com/pezops/blackstart"
+)
+
+const testTableSchema = `[
+  {"name": "event_time", "type": "TIMESTAMP", "mode": "REQUIRED"},
+  {"name": "user_id", "type": "STRING", "description": "ID of the user"},
+  {"name": "page", "type": "STRUCT", "fields": [
+    {"name": "path", "type": "STRING"},
+    {"name": "views", "type": "INT64", "mode": "REPEATED"}
+  ]}
+]`
+
+// testTableOperation creates a table operation for the page views table.
+func testTableOperation() blackstart.Operation {
+	return blackstart.Operation{
+		Id:     "table",
+		Module: "google_bigquery_table",
+		Inputs: map[string]blackstart.Input{
+			inputProject:             blackstart.NewInputFromValue("analytics"),
+			inputDataset:             blackstart.NewInputFromValue("events"),
+			inputTable:               blackstart.NewInputFromValue("page_views"),
+			inputSchema:              blackstart.NewInputFromValue(testTableSchema),
+			inputDescription:         blackstart.NewInputFromValue("Page views"),
+			inputPartitionField:      blackstart.NewInputFromValue("event_time"),
+			inputPartitionExpiration: blackstart.NewInputFromValue("48h"),
+			inputClusteringFields:    blackstart.NewInputFromValue([]any{"user_id"}),
+		},
+	}
+}
+
+// testExistingTable returns the page views table as the BigQuery API reports it.
+func testExistingTable() *bigquery.Table {
+	return &bigquery.Table{
+		Etag:        "etag-1",
+		Description: "Page views",
+		Schema: &bigquery.TableSchema{
+			Fields: []*bigquery.TableFieldSchema{
+				{Name: "event_time", Type: "TIMESTAMP", Mode: modeRequired},
+				{Name: "user_id", Type: "STRING", Mode: modeNullable, Description: "ID of the user"},
+				{
+					Name: "page", Type: typeRecord, Mode: modeNullable,
+					Fields: []*bigquery.TableFieldSchema{
+						{Name: "path", Type: "STRING", Mode: modeNullable},
+						{Name: "views", Type: "INTEGER", Mode: modeRepeated},
+					},
+				},
+			},
+		},
+		TimePartitioning: &bigquery.TimePartitioning{Type: "DAY", Field: "event_time", ExpirationMs: 172800000},
+		Clustering:       &bigquery.Clustering{Fields: []string{"user_id"}},
+	}
+}
+
+func TestTable_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{
+			name: "schema not JSON", key: inputSchema, value: "event_time:TIMESTAMP",
+			wantErr: "expected a JSON list of columns",
+		},
+		{name: "schema without columns", key: inputSchema, value: "[]", wantErr: "the schema has no columns"},
+		{
+			name: "column without type", key: inputSchema, value: `[{"name": "id"}]`,
+			wantErr: "column id has no type",
+		},
+		{
+			name: "duplicate column", key: inputSchema,
+			value:   `[{"name": "id", "type": "STRING"}, {"name": "ID", "type": "STRING"}]`,
+			wantErr: "column ID is defined more than once",
+		},
+		{
+			name: "invalid mode", key: inputSchema, value: `[{"name": "id", "type": "STRING", "mode": "OPTIONAL"}]`,
+			wantErr: `column id has mode "OPTIONAL"`,
+		},
+		{
+			name: "record without columns", key: inputSchema, value: `[{"name": "page", "type": "RECORD"}]`,
+			wantErr: "column page must have nested columns only if it is a RECORD",
+		},
+		{name: "invalid partition type", key: inputPartitionType, value: "WEEK", wantErr: `invalid partition_type: "WEEK"`},
+		{name: "invalid expiration", key: inputPartitionExpiration, value: "90d", wantErr: "invalid partition_expiration"},
+		{
+			name: "unknown clustering column", key: inputClusteringFields, value: []any{"page_id"},
+			wantErr: "column page_id is not in the schema",
+		},
+		{
+			name: "too many clustering columns", key: inputClusteringFields, value: []any{"a", "b", "c", "d", "e"},
+			wantErr: "at most 4 columns are supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testTableOperation()
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewTable().Validate(op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+}
+
+func TestTable_CreateAndCheck(t *testing.T) {
+	fake := newFakeBigQuery(t)
+	op := testTableOperation()
+	module := &table{runtime: fake.runtime()}
+
+	ctx := testDatasetContext(&op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, "analytics.events.page_views", ctx.outputs[outputTable])
+	require.NotNil(t, fake.table)
+	require.Equal(t, "Page views", fake.table.Description)
+	require.Equal(
+		t, &bigquery.TimePartitioning{Type: "DAY", Field: "event_time", ExpirationMs: 172800000},
+		fake.table.TimePartitioning,
+	)
+	require.Equal(t, []string{"user_id"}, fake.table.Clustering.Fields)
+	// Types and modes are normalized to the names the API reports.
+	fields := fake.table.Schema.Fields
+	require.Len(t, fields, 3)
+	require.Equal(t, modeNullable, fields[1].Mode)
+	require.Equal(t, typeRecord, fields[2].Type)
+	require.Equal(t, "INTEGER", fields[2].Fields[1].Type)
+
+	ok, err = module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Zero(t, fake.requestCount(http.MethodPatch))
+}
+
+func TestTable_UpdatesSchema(t *testing.T) {
+	fake := newFakeBigQuery(t)
+	fake.table = testExistingTable()
+	// The table has a column that is not in the schema, and a REQUIRED column that is relaxed.
+	fake.table.Schema.Fields = append(
+		fake.table.Schema.Fields, &bigquery.TableFieldSchema{Name: "legacy", Type: "STRING", Mode: modeRequired},
+	)
+	fake.table.Schema.Fields[1].Mode = modeRequired
+	fake.table.Labels = map[string]string{"team": "web"}
+	op := testTableOperation()
+	op.Inputs[inputSchema] = blackstart.NewInputFromValue(
+		`[
+  {"name": "event_time", "type": "TIMESTAMP", "mode": "REQUIRED"},
+  {"name": "user_id", "type": "STRING", "description": "ID of the user"},
+  {"name": "page", "type": "RECORD", "fields": [
+    {"name": "path", "type": "STRING"},
+    {"name": "views", "type": "INTEGER", "mode": "REPEATED"},
+    {"name": "title", "type": "STRING"}
+  ]},
+  {"name": "country", "type": "STRING"}
+]`,
+	)
+	op.Inputs[inputPartitionExpiration] = blackstart.NewInputFromValue("72h")
+	op.Inputs[inputClusteringFields] = blackstart.NewInputFromValue([]any{"country", "user_id"})
+	op.Inputs[inputLabels] = blackstart.NewInputFromValue(map[string]any{"team": "data"})
+	module := &table{runtime: fake.runtime()}
+
+	ok, err := module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testDatasetContext(&op)))
+	require.Equal(t, []string{"etag-1"}, fake.etags)
+	fields := fake.table.Schema.Fields
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.Name)
+	}
+	// Columns that are not in the schema are kept, and new columns are added at the end.
+	require.Equal(t, []string{"event_time", "user_id", "page", "legacy", "country"}, names)
+	require.Equal(t, modeNullable, fields[1].Mode)
+	require.Equal(t, modeRequired, fields[3].Mode)
+	require.Len(t, fields[2].Fields, 3)
+	require.Equal(t, int64(259200000), fake.table.TimePartitioning.ExpirationMs)
+	require.Equal(t, "event_time", fake.table.TimePartitioning.Field)
+	require.Equal(t, []string{"country", "user_id"}, fake.table.Clustering.Fields)
+	require.Equal(t, map[string]string{"team": "data"}, fake.table.Labels)
+
+	ok, err = module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestTable_IncompatibleChanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{
+			name: "type change", key: inputSchema,
+			value:   `[{"name": "event_time", "type": "DATE", "mode": "REQUIRED"}]`,
+			wantErr: "column event_time has type TIMESTAMP instead of DATE",
+		},
+		{
+			name: "mode tightened", key: inputSchema,
+			value:   `[{"name": "user_id", "type": "STRING", "mode": "REQUIRED"}]`,
+			wantErr: "column user_id has mode NULLABLE instead of REQUIRED",
+		},
+		{
+			name: "required column added", key: inputSchema,
+			value:   `[{"name": "page", "type": "RECORD", "fields": [{"name": "title", "type": "STRING", "mode": "REQUIRED"}]}]`,
+			wantErr: "column page.title cannot be added to an existing table as REQUIRED",
+		},
+		{
+			name: "partitioning changed", key: inputPartitionType, value: "HOUR",
+			wantErr: "table analytics.events.page_views is partitioned by DAY of column event_time instead of " +
+				"partitioned by HOUR of column event_time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				fake := newFakeBigQuery(t)
+				fake.table = testExistingTable()
+				op := testTableOperation()
+				op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				if tt.key == inputSchema {
+					delete(op.Inputs, inputClusteringFields)
+				}
+				module := &table{runtime: fake.runtime()}
+
+				_, err := module.Check(testDatasetContext(&op))
+				require.ErrorContains(t, err, tt.wantErr)
+				require.ErrorContains(t, module.Set(testDatasetContext(&op)), tt.wantErr)
+				require.Zero(t, fake.requestCount(http.MethodPatch))
+			},
+		)
+	}
+}
+
+func TestTable_DoesNotExist(t *testing.T) {
+	fake := newFakeBigQuery(t)
+	fake.table = testExistingTable()
+	op := testTableOperation()
+	op.DoesNotExist = true
+	module := &table{runtime: fake.runtime()}
+
+	ok, err := module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testDatasetContext(&op)))
+	require.Nil(t, fake.table)
+
+	ok, err = module.Check(testDatasetContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete))
+}
@@ -0,0 +1,144 @@
+package cloud
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+
+	"github.com/pezops/blackstart"
+)
+
+// RetryPolicy is the retry policy of the Google API clients created with NewService. Requests
+// that respond with 429 Too Many Requests or a 5xx status are sent again after an exponential
+// backoff, until MaxAttempts requests were sent. POST and PATCH requests are not idempotent and
+// may have been applied when the server failed, so they are only sent again after a 429 or 503
+// Service Unavailable response, which reject the request before it is processed.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent. Values below 2 disable retries.
+	MaxAttempts int
+
+	// InitialInterval is the backoff before the first retry. It doubles for each further retry.
+	InitialInterval time.Duration
+
+	// MaxInterval is the maximum backoff between retries, also for the delay of a Retry-After
+	// response header.
+	MaxInterval time.Duration
+}
+
+// DefaultRetryPolicy is the retry policy shared by the Google API clients of the modules.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     16 * time.Second,
+}
+
+// NewService creates a client of a Google API service with the NewService function of its package,
+// such as sqladmin.NewService. The client is authenticated with the credentials, or with the
+// default credentials and the scopes when they are nil. Requests use the outbound HTTP transport of
+// blackstart.HTTPTransport, carry the Blackstart user agent, are counted as Google API calls of the
+// operation whose context they are made with, and are retried with DefaultRetryPolicy.
+func NewService[S any](
+	ctx context.Context, newService func(context.Context, ...option.ClientOption) (S, error),
+	creds *google.Credentials, scopes ...string,
+) (S, error) {
+	ctx = httpContext(ctx)
+	opts := []option.ClientOption{option.WithUserAgent(blackstart.UserAgent), option.WithScopes(scopes...)}
+	if creds != nil {
+		opts = append(opts, option.WithCredentials(creds))
+	}
+	transport, err := htransport.NewTransport(ctx, blackstart.HTTPTransport(), opts...)
+	if err != nil {
+		var zero S
+		return zero, err
+	}
+	hc := &http.Client{
+		Transport: RetryTransport(
+			DefaultRetryPolicy, blackstart.CountAPICalls(blackstart.APIProviderGoogle, transport),
+		),
+	}
+	return newService(ctx, option.WithHTTPClient(hc))
+}
+
+// httpContext returns a context whose OAuth2 token requests use the outbound HTTP transport of
+// blackstart.HTTPTransport.
+func httpContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: blackstart.HTTPTransport()})
+}
+
+// RetryTransport wraps an HTTP transport to retry requests with the retry policy. Each attempt is
+// sent with the wrapped transport. Requests with a body that cannot be read again are not
+// retried.
+func RetryTransport(policy RetryPolicy, base http.RoundTripper) http.RoundTripper {
+	return &retryTransport{policy: policy, base: base}
+}
+
+// retryTransport is an http.RoundTripper that retries requests with a retry policy.
+type retryTransport struct {
+	policy RetryPolicy
+	base   http.RoundTripper
+}
+
+// RoundTrip sends the request, and sends it again while it responds with a retriable status and
+// attempts remain. The last response is returned.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	backoff := t.policy.InitialInterval
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !retriableStatus(req.Method, resp.StatusCode) || attempt >= t.policy.MaxAttempts {
+			return resp, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		delay := min(retryAfter(resp, backoff), t.policy.MaxInterval)
+		// The response is discarded, so its connection can be reused.
+		_ = resp.Body.Close()
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, t.policy.MaxInterval)
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retriableStatus reports whether a response status of a request with the method is a transient
+// error of a Google API after which the request can be sent again.
+func retriableStatus(method string, code int) bool {
+	switch {
+	case code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable:
+		return true
+	case method == http.MethodPost || method == http.MethodPatch:
+		return false
+	default:
+		return code >= http.StatusInternalServerError
+	}
+}
+
+// retryAfter returns the delay of the Retry-After header of a response in seconds, or the backoff
+// less a random jitter of up to a quarter when the response has none.
+func retryAfter(resp *http.Response, backoff time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return backoff - rand.N(backoff/4+1)
+}
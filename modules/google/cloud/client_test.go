@@ -0,0 +1,168 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	InitialInterval: time.Millisecond,
+	MaxInterval:     5 * time.Millisecond,
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{name: "success", statuses: []int{200}, wantStatus: 200, wantAttempts: 1},
+		{name: "server error then success", statuses: []int{503, 200}, wantStatus: 200, wantAttempts: 2},
+		{name: "rate limited with retry after", statuses: []int{429, 200}, retryAfter: "0", wantStatus: 200, wantAttempts: 2},
+		{name: "retry after capped", statuses: []int{429, 200}, retryAfter: "3600", wantStatus: 200, wantAttempts: 2},
+		{name: "attempts exhausted", statuses: []int{500, 502, 503, 200}, wantStatus: 503, wantAttempts: 3},
+		{name: "not retriable", statuses: []int{404, 200}, wantStatus: 404, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				var attempts atomic.Int32
+				server := httptest.NewServer(
+					http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							n := attempts.Add(1)
+							if tt.retryAfter != "" {
+								w.Header().Set("Retry-After", tt.retryAfter)
+							}
+							w.WriteHeader(tt.statuses[n-1])
+						},
+					),
+				)
+				defer server.Close()
+
+				client := &http.Client{Transport: RetryTransport(testRetryPolicy, http.DefaultTransport)}
+				resp, err := client.Get(server.URL)
+				require.NoError(t, err)
+				defer func() { _ = resp.Body.Close() }()
+				assert.Equal(t, tt.wantStatus, resp.StatusCode)
+				assert.Equal(t, tt.wantAttempts, attempts.Load())
+			},
+		)
+	}
+}
+
+func TestRetryTransport_ReplaysBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			},
+		),
+	)
+	defer server.Close()
+
+	client := &http.Client{Transport: RetryTransport(testRetryPolicy, http.DefaultTransport)}
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader(`{"name":"db"}`))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{`{"name":"db"}`, `{"name":"db"}`}, bodies)
+
+	// A body that cannot be read again is sent once.
+	bodies = nil
+	req, err := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("once")))
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, []string{"once"}, bodies)
+}
+
+func TestRetryTransport_Methods(t *testing.T) {
+	tests := []struct {
+		method       string
+		status       int
+		wantAttempts int32
+	}{
+		{method: http.MethodGet, status: http.StatusInternalServerError, wantAttempts: 3},
+		{method: http.MethodPut, status: http.StatusBadGateway, wantAttempts: 3},
+		{method: http.MethodDelete, status: http.StatusGatewayTimeout, wantAttempts: 3},
+		{method: http.MethodPost, status: http.StatusTooManyRequests, wantAttempts: 3},
+		{method: http.MethodPost, status: http.StatusServiceUnavailable, wantAttempts: 3},
+		{method: http.MethodPatch, status: http.StatusServiceUnavailable, wantAttempts: 3},
+		// The request may have been applied, so it is not sent again.
+		{method: http.MethodPost, status: http.StatusInternalServerError, wantAttempts: 1},
+		{method: http.MethodPost, status: http.StatusGatewayTimeout, wantAttempts: 1},
+		{method: http.MethodPatch, status: http.StatusBadGateway, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			fmt.Sprintf("%s %d", tt.method, tt.status), func(t *testing.T) {
+				var attempts atomic.Int32
+				server := httptest.NewServer(
+					http.HandlerFunc(
+						func(w http.ResponseWriter, r *http.Request) {
+							attempts.Add(1)
+							w.WriteHeader(tt.status)
+						},
+					),
+				)
+				defer server.Close()
+
+				client := &http.Client{Transport: RetryTransport(testRetryPolicy, http.DefaultTransport)}
+				req, err := http.NewRequest(tt.method, server.URL, strings.NewReader(`{"name":"db"}`))
+				require.NoError(t, err)
+				resp, err := client.Do(req)
+				require.NoError(t, err)
+				_ = resp.Body.Close()
+				assert.Equal(t, tt.status, resp.StatusCode)
+				assert.Equal(t, tt.wantAttempts, attempts.Load())
+			},
+		)
+	}
+}
+
+func TestRetryTransport_ContextCanceled(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+		),
+	)
+	defer server.Close()
+
+	policy := RetryPolicy{MaxAttempts: 5, InitialInterval: time.Hour, MaxInterval: time.Hour}
+	client := &http.Client{Transport: RetryTransport(policy, http.DefaultTransport)}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), attempts.Load())
+}
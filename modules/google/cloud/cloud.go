@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"golang.org/x/oauth2/google"
@@ -13,9 +14,23 @@ import (
 	googleoauth2 "google.golang.org/api/oauth2/v2"
 )
 
+// credentialScopes are the OAuth2 scopes of the credentials used for Google Cloud API calls.
+var credentialScopes = []string{
+	"https://www.googleapis.com/auth/cloud-platform",
+	"https://www.googleapis.com/auth/userinfo.email", // This is required to read the email from the access token
+}
+
+// supportedCredentialsTypes are the types of credentials JSON accepted by CredentialsFromJSON.
+var supportedCredentialsTypes = []google.CredentialsType{
+	google.ServiceAccount,
+	google.AuthorizedUser,
+	google.ExternalAccount,
+	google.ImpersonatedServiceAccount,
+}
+
 func tokenInfoEmail(ctx context.Context, creds *google.Credentials) (string, error) {
 	// Use the token to query the user's identity.
-	oauth2Service, err := googleoauth2.NewService(ctx)
+	oauth2Service, err := NewService(ctx, googleoauth2.NewService, nil, googleoauth2.UserinfoEmailScope)
 	if err != nil {
 		return "", err
 	}
@@ -90,17 +105,30 @@ func extractGoogleAPIErrorDescription(body string) string {
 // 2. A JSON file in a well-known location created by the gcloud command-line tool.
 // 3. Credentials from the metadata server on GCE, GKE, App Engine, Cloud Run, and others.
 func DefaultCredentials(ctx context.Context) (*google.Credentials, error) {
-	adc, err := google.FindDefaultCredentials(
-		ctx,
-		"https://www.googleapis.com/auth/cloud-platform",
-		"https://www.googleapis.com/auth/userinfo.email", // This is required to read the email from the access token
-	)
+	adc, err := google.FindDefaultCredentials(httpContext(ctx), credentialScopes...)
 	if err != nil {
 		return nil, err
 	}
 	return adc, nil
 }
 
+// CredentialsFromJSON returns Google Cloud credentials from a credentials JSON document instead of
+// ADC, such as a service account key or a workload identity federation configuration. The
+// credentials have the same scopes as DefaultCredentials.
+func CredentialsFromJSON(ctx context.Context, data []byte) (*google.Credentials, error) {
+	var credsJson struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &credsJson); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials json: %w", err)
+	}
+	credsType := google.CredentialsType(credsJson.Type)
+	if !slices.Contains(supportedCredentialsTypes, credsType) {
+		return nil, fmt.Errorf("unsupported credentials type: %q", credsJson.Type)
+	}
+	return google.CredentialsFromJSONWithType(httpContext(ctx), data, credsType, credentialScopes...)
+}
+
 // ProjectIdFromCredentials returns the Google Cloud project ID from the given credentials.
 func ProjectIdFromCredentials(creds *google.Credentials) (string, error) {
 	if creds.ProjectID != "" {
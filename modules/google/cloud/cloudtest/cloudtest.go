@@ -0,0 +1,43 @@
+// Package cloudtest provides fixtures for tests of the Google Cloud modules with fake Google API
+// servers.
+package cloudtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// WriteJSON writes a JSON response and fails the test if encoding fails.
+func WriteJSON(t testing.TB, w http.ResponseWriter, value any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(t, json.NewEncoder(w).Encode(value))
+}
+
+// Operation is a long-running operation in the JSON form shared by the Google APIs.
+type Operation struct {
+	Name string `json:"name"`
+	Done bool   `json:"done,omitempty"`
+}
+
+// Operations fakes the long-running operations of a Google API. An operation is reported as
+// running until PendingPolls polls of operations were made. Operations is not safe for concurrent
+// use, so fake servers use it while holding their lock.
+type Operations struct {
+	// PendingPolls is the number of operation polls that report an operation as still running.
+	PendingPolls int
+}
+
+// Start returns a new operation with the name, which is done when no polls are pending.
+func (o *Operations) Start(name string) Operation {
+	return Operation{Name: name, Done: o.PendingPolls <= 0}
+}
+
+// Poll returns the operation with the name for a poll, which is done once no polls are pending.
+func (o *Operations) Poll(name string) Operation {
+	o.PendingPolls--
+	return Operation{Name: name, Done: o.PendingPolls <= 0}
+}
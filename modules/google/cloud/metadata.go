@@ -223,6 +223,7 @@ inputs:
     - project_id
     - region`,
 		},
+		OutputsOnly: true,
 	}
 }
 
@@ -0,0 +1,115 @@
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/googleapi"
+
+	"github.com/pezops/blackstart"
+)
+
+// OperationPolling is the backoff of polling a long-running operation. The interval between polls
+// starts at InitialInterval and doubles up to MaxInterval.
+type OperationPolling struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// operationState is the state of a long-running operation, decoded from the JSON form that the
+// operations of the generated API clients share, such as spanner.Operation and
+// run.GoogleLongrunningOperation.
+type operationState struct {
+	Name  string `json:"name"`
+	Done  bool   `json:"done"`
+	Error *struct {
+		Code    int64  `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// newOperationState returns the state of an operation of a generated API client.
+func newOperationState(op any) (operationState, error) {
+	var state operationState
+	b, err := json.Marshal(op)
+	if err == nil {
+		err = json.Unmarshal(b, &state)
+	}
+	if err != nil {
+		return operationState{}, fmt.Errorf("failed to decode operation: %w", err)
+	}
+	return state, nil
+}
+
+// WaitForOperation polls a long-running operation of a Google API with get until it is done and
+// returns any error reported by the operation. The operation is an operation of a generated API
+// client, such as spanner.Operation, and get returns the operation with the name, for example
+// with the Get call of the Operations resource of the API.
+func WaitForOperation[O any](
+	ctx context.Context, op *O, polling OperationPolling, get func(context.Context, string) (*O, error),
+) error {
+	if op == nil {
+		return fmt.Errorf("operation result was empty")
+	}
+	state, err := newOperationState(op)
+	if err != nil {
+		return err
+	}
+	interval := polling.InitialInterval
+	for !state.Done {
+		if state.Name == "" {
+			return fmt.Errorf("operation has no name and cannot be polled")
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed waiting for operation %s: %w", state.Name, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, polling.MaxInterval)
+
+		next, getErr := get(ctx, state.Name)
+		if getErr != nil {
+			return fmt.Errorf("failed to get operation %s: %w", state.Name, getErr)
+		}
+		if next == nil {
+			return fmt.Errorf("operation %s result was empty", state.Name)
+		}
+		if state, err = newOperationState(next); err != nil {
+			return err
+		}
+	}
+
+	if state.Error != nil {
+		return fmt.Errorf("operation %s failed: %d: %s", state.Name, state.Error.Code, state.Error.Message)
+	}
+	return nil
+}
+
+// IsNotFound reports whether a Google API responded with not found.
+func IsNotFound(err error) bool {
+	apiErr, ok := errors.AsType[*googleapi.Error](err)
+	return ok && apiErr.Code == http.StatusNotFound
+}
+
+// RequiredString validates a required string input of an operation. Static values must not be
+// empty.
+func RequiredString(op blackstart.Operation, key string) error {
+	input, ok := op.Inputs[key]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", key)
+	}
+	if input.IsStatic() {
+		value, err := blackstart.InputAs[string](input, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if value == "" {
+			return fmt.Errorf("%s cannot be empty", key)
+		}
+	}
+	return nil
+}
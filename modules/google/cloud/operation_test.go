@@ -0,0 +1,80 @@
+package cloud
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+var testPolling = OperationPolling{InitialInterval: time.Millisecond, MaxInterval: 2 * time.Millisecond}
+
+func TestWaitForOperation(t *testing.T) {
+	var polls []string
+	get := func(_ context.Context, name string) (*spanner.Operation, error) {
+		polls = append(polls, name)
+		return &spanner.Operation{Name: name, Done: len(polls) == 2}, nil
+	}
+	op := &spanner.Operation{Name: "operations/1"}
+	require.NoError(t, WaitForOperation(context.Background(), op, testPolling, get))
+	assert.Equal(t, []string{"operations/1", "operations/1"}, polls)
+
+	// Done operations are not polled.
+	polls = nil
+	op = &spanner.Operation{Name: "operations/1", Done: true}
+	require.NoError(t, WaitForOperation(context.Background(), op, testPolling, get))
+	assert.Empty(t, polls)
+
+	failed := &spanner.Operation{
+		Name: "operations/2", Done: true, Error: &spanner.Status{Code: 9, Message: "instance busy"},
+	}
+	assert.EqualError(
+		t, WaitForOperation(context.Background(), failed, testPolling, get),
+		"operation operations/2 failed: 9: instance busy",
+	)
+	assert.EqualError(
+		t, WaitForOperation(context.Background(), &spanner.Operation{}, testPolling, get),
+		"operation has no name and cannot be polled",
+	)
+	assert.EqualError(
+		t, WaitForOperation[spanner.Operation](context.Background(), nil, testPolling, get), "operation result was empty",
+	)
+
+	getErr := func(context.Context, string) (*spanner.Operation, error) { return nil, errors.New("unavailable") }
+	assert.EqualError(
+		t, WaitForOperation(context.Background(), &spanner.Operation{Name: "operations/3"}, testPolling, getErr),
+		"failed to get operation operations/3: unavailable",
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, WaitForOperation(ctx, &spanner.Operation{Name: "operations/4"}, testPolling, get), context.Canceled)
+}
+
+func TestIsNotFound(t *testing.T) {
+	assert.True(t, IsNotFound(&googleapi.Error{Code: http.StatusNotFound}))
+	assert.False(t, IsNotFound(&googleapi.Error{Code: http.StatusForbidden}))
+	assert.False(t, IsNotFound(errors.New("not found")))
+}
+
+func TestRequiredString(t *testing.T) {
+	op := blackstart.Operation{
+		Inputs: map[string]blackstart.Input{
+			"name":  blackstart.NewInputFromValue("db"),
+			"empty": blackstart.NewInputFromValue(""),
+			"dep":   blackstart.NewInputFromDep("other", "name"),
+		},
+	}
+	assert.NoError(t, RequiredString(op, "name"))
+	assert.NoError(t, RequiredString(op, "dep"))
+	assert.EqualError(t, RequiredString(op, "empty"), "invalid empty: value cannot be empty")
+	assert.EqualError(t, RequiredString(op, "missing"), "missing required parameter: missing")
+}
@@ -0,0 +1,29 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pezops/blackstart"
+)
+
+// ServiceCheckGoogle is the kind of workflow service checks that resolve the Google Cloud
+// Application Default Credentials and request an access token with them.
+const ServiceCheckGoogle = "google"
+
+func init() {
+	blackstart.RegisterServiceCheck(ServiceCheckGoogle, checkGoogleService)
+}
+
+// checkGoogleService verifies that the default credentials resolve and can get an access token,
+// such as from the metadata server with workload identity.
+func checkGoogleService(ctx context.Context, _ map[string]string) error {
+	creds, err := DefaultCredentials(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find default credentials: %w", err)
+	}
+	if _, err = creds.TokenSource.Token(); err != nil {
+		return fmt.Errorf("failed to get an access token with the default credentials: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cloudrun
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/run/v2"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject   = "project"
+	inputLocation  = "location"
+	inputService   = "service"
+	inputContainer = "container"
+	inputEnv       = "env"
+	inputSecrets   = "secrets"
+
+	outputService = "service"
+	outputURI     = "uri"
+
+	// defaultSecretVersion is the version of secrets referenced without a version.
+	defaultSecretVersion = "latest"
+)
+
+// Cloud Run mutations return long-running operations. They are polled with exponential backoff
+// between these intervals until they are done.
+var (
+	operationPollInitialInterval = 1 * time.Second
+	operationPollMaxInterval     = 10 * time.Second
+)
+
+func init() {
+	blackstart.RegisterPathName("cloudrun", "Cloud Run")
+}
+
+// cloudRunRuntime provides the injectable Cloud Run API dependency.
+type cloudRunRuntime struct {
+	newService func(context.Context) (*run.Service, error)
+}
+
+// defaultCloudRunRuntime creates the production Cloud Run runtime.
+func defaultCloudRunRuntime() *cloudRunRuntime {
+	return &cloudRunRuntime{
+		newService: func(ctx context.Context) (*run.Service, error) {
+			return cloud.NewService(ctx, run.NewService, nil, run.CloudPlatformScope)
+		},
+	}
+}
+
+// cloudRunRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
+func cloudRunRuntimeOrDefault(runtime *cloudRunRuntime) *cloudRunRuntime {
+	if runtime == nil {
+		return defaultCloudRunRuntime()
+	}
+	return runtime
+}
+
+// waitForOperation polls a Cloud Run operation until it is done and returns any error reported by
+// the operation.
+func waitForOperation(ctx context.Context, svc *run.Service, op *run.GoogleLongrunningOperation) error {
+	polling := cloud.OperationPolling{InitialInterval: operationPollInitialInterval, MaxInterval: operationPollMaxInterval}
+	return cloud.WaitForOperation(
+		ctx, op, polling, func(ctx context.Context, name string) (*run.GoogleLongrunningOperation, error) {
+			return svc.Projects.Locations.Operations.Get(name).Context(ctx).Do()
+		},
+	)
+}
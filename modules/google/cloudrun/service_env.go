@@ -0,0 +1,454 @@
+package cloudrun
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/run/v2"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_cloudrun_service_env", NewServiceEnv)
+}
+
+var _ blackstart.Module = &serviceEnv{}
+
+// serviceEnv manages environment variables of a container of a Cloud Run service.
+type serviceEnv struct {
+	runtime *cloudRunRuntime
+	svc     *run.Service
+	target  *serviceEnvTarget
+}
+
+// serviceEnvTarget is the desired state of the environment resolved from the module inputs.
+type serviceEnvTarget struct {
+	name      string
+	container string
+	env       map[string]string
+	secrets   map[string]*run.GoogleCloudRunV2SecretKeySelector
+}
+
+func NewServiceEnv() blackstart.Module {
+	return &serviceEnv{}
+}
+
+func (s *serviceEnv) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_cloudrun_service_env",
+		Name: "Google Cloud Run Service Environment",
+		Description: util.CleanString(
+			`
+Ensures a container of an existing Cloud Run service has environment variables set to values or to
+Secret Manager secrets, such as to point a newly deployed service at the database and secret names
+created earlier in the workflow.
+
+**Notes**
+
+- The service is not created. The operation fails when the service does not exist.
+- The service is read, changed, and written back with its etag, so changes made to the service
+  between the read and the write are not overwritten. The operation fails instead and is retried in
+  the next run.
+- Changing the environment deploys a new revision of the service. The operation waits until the
+  revision is deployed.
+- Environment variables that are not set in '''env''' or '''secrets''' are not changed.
+- With '''doesNotExist''', the environment variables of '''env''' and '''secrets''' are removed
+  from the container. Their values are ignored.
+`,
+		),
+		Requirements: []string{
+			"The Cloud Run Admin API (`run.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/run.developer` on the service, and `iam.serviceaccounts.actAs` on the service account of the service.",
+			"The service account of the service must have `roles/secretmanager.secretAccessor` on the secrets.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Google Cloud project of the service. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLocation: {
+				Description: "Region of the service, such as `europe-west1`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputService: {
+				Description: "Name of the service.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputContainer: {
+				Description: "Name of the container to set the environment of. Required when the service has more than one container.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputEnv: {
+				Description: "Environment variables to set, as a map of names to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+			inputSecrets: {
+				Description: "Environment variables to set to Secret Manager secrets, as a map of names to secrets. Secrets are the secret name or resource name, with an optional version, such as `db-password` or `db-password:3`. Defaults to the `latest` version.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputService: {
+				Description: "Resource name of the service, `projects/<project>/locations/<location>/services/<service>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputURI: {
+				Description: "URI of the service.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Database Connection": `id: api-env
+module: google_cloudrun_service_env
+inputs:
+  location: europe-west1
+  service: api
+  env:
+    DB_NAME: app
+    DB_USER: app
+  secrets:
+    DB_PASSWORD: app-db-password`,
+		},
+	}
+}
+
+func (s *serviceEnv) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputLocation, inputService} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", key)
+		}
+		if input.IsStatic() {
+			value, err := blackstart.InputAs[string](input, true)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if value == "" {
+				return fmt.Errorf("%s cannot be empty", key)
+			}
+		}
+	}
+	envInput, hasEnv := op.Inputs[inputEnv]
+	secretsInput, hasSecrets := op.Inputs[inputSecrets]
+	if !hasEnv && !hasSecrets {
+		return fmt.Errorf("at least one of %s or %s is required", inputEnv, inputSecrets)
+	}
+	var env map[string]string
+	var secrets map[string]*run.GoogleCloudRunV2SecretKeySelector
+	var err error
+	if hasEnv && envInput.IsStatic() {
+		if env, err = inputEnvMap(envInput); err != nil {
+			return err
+		}
+	}
+	if hasSecrets && secretsInput.IsStatic() {
+		if secrets, err = inputSecretsMap(secretsInput); err != nil {
+			return err
+		}
+	}
+	return verifyDistinct(env, secrets)
+}
+
+// Check reports whether the container has the environment variables.
+func (s *serviceEnv) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := s.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(s.target.name)
+
+	existing, err := s.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		if ctx.DoesNotExist() {
+			return true, nil
+		}
+		return false, fmt.Errorf("service %s does not exist", s.target.name)
+	}
+	container, err := s.container(existing)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return !s.remove(container), nil
+	}
+	if ctx.Tainted() || s.apply(container) {
+		return false, nil
+	}
+	return true, outputServiceValues(ctx, existing)
+}
+
+// Set writes the environment variables to the service and waits for the new revision.
+func (s *serviceEnv) Set(ctx blackstart.ModuleContext) error {
+	if err := s.setup(ctx); err != nil {
+		return err
+	}
+	ctx.Resource(s.target.name)
+
+	existing, err := s.get(ctx)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		if ctx.DoesNotExist() {
+			return nil
+		}
+		return fmt.Errorf("service %s does not exist", s.target.name)
+	}
+	container, err := s.container(existing)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	if ctx.DoesNotExist() {
+		changed = s.remove(container)
+	} else {
+		changed = s.apply(container)
+	}
+	if changed {
+		// The etag of the service makes the update fail instead of overwriting a concurrent change.
+		op, pErr := s.svc.Projects.Locations.Services.Patch(s.target.name, existing).Context(ctx).Do()
+		if pErr != nil {
+			return fmt.Errorf("failed to update service %s: %w", s.target.name, pErr)
+		}
+		if err = waitForOperation(ctx, s.svc, op); err != nil {
+			return err
+		}
+	}
+	if ctx.DoesNotExist() {
+		return nil
+	}
+	return outputServiceValues(ctx, existing)
+}
+
+// setup resolves the target environment from the inputs and creates the Cloud Run service.
+func (s *serviceEnv) setup(ctx blackstart.ModuleContext) error {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	location, err := blackstart.ContextInputAs[string](ctx, inputLocation, true)
+	if err != nil {
+		return err
+	}
+	service, err := blackstart.ContextInputAs[string](ctx, inputService, true)
+	if err != nil {
+		return err
+	}
+	container, err := blackstart.ContextInputAs[string](ctx, inputContainer, false)
+	if err != nil {
+		return err
+	}
+
+	target := &serviceEnvTarget{
+		name:      fmt.Sprintf("projects/%s/locations/%s/services/%s", project, location, service),
+		container: container,
+	}
+	if input, iErr := ctx.Input(inputEnv); iErr == nil && input.Any() != nil {
+		if target.env, err = inputEnvMap(input); err != nil {
+			return err
+		}
+	}
+	if input, iErr := ctx.Input(inputSecrets); iErr == nil && input.Any() != nil {
+		if target.secrets, err = inputSecretsMap(input); err != nil {
+			return err
+		}
+	}
+	if err = verifyDistinct(target.env, target.secrets); err != nil {
+		return err
+	}
+	s.target = target
+
+	s.runtime = cloudRunRuntimeOrDefault(s.runtime)
+	s.svc, err = s.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud Run service: %w", err)
+	}
+	return nil
+}
+
+// get returns the service, or nil if it does not exist.
+func (s *serviceEnv) get(ctx context.Context) (*run.GoogleCloudRunV2Service, error) {
+	existing, err := s.svc.Projects.Locations.Services.Get(s.target.name).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get service %s: %w", s.target.name, err)
+	}
+	return existing, nil
+}
+
+// container returns the container of the service template to set the environment of.
+func (s *serviceEnv) container(existing *run.GoogleCloudRunV2Service) (*run.GoogleCloudRunV2Container, error) {
+	var containers []*run.GoogleCloudRunV2Container
+	if existing.Template != nil {
+		containers = existing.Template.Containers
+	}
+	if s.target.container == "" {
+		if len(containers) != 1 {
+			return nil, fmt.Errorf(
+				"service %s has %d containers, %s is required", s.target.name, len(containers), inputContainer,
+			)
+		}
+		return containers[0], nil
+	}
+	for _, c := range containers {
+		if c.Name == s.target.container {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("service %s has no container %s", s.target.name, s.target.container)
+}
+
+// apply sets the environment variables of the container to the desired values. Variables that are
+// not set yet are added in the order of their names. It reports whether the container was changed.
+func (s *serviceEnv) apply(container *run.GoogleCloudRunV2Container) bool {
+	changed := false
+	for _, name := range slices.Sorted(maps.Keys(s.target.env)) {
+		value := s.target.env[name]
+		v := envVar(container, name)
+		if v != nil && v.ValueSource == nil && v.Value == value {
+			continue
+		}
+		if v == nil {
+			v = &run.GoogleCloudRunV2EnvVar{Name: name}
+			container.Env = append(container.Env, v)
+		}
+		v.Value = value
+		v.ValueSource = nil
+		changed = true
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.target.secrets)) {
+		ref := s.target.secrets[name]
+		v := envVar(container, name)
+		if v != nil && v.Value == "" && v.ValueSource != nil && v.ValueSource.SecretKeyRef != nil &&
+			v.ValueSource.SecretKeyRef.Secret == ref.Secret && v.ValueSource.SecretKeyRef.Version == ref.Version {
+			continue
+		}
+		if v == nil {
+			v = &run.GoogleCloudRunV2EnvVar{Name: name}
+			container.Env = append(container.Env, v)
+		}
+		v.Value = ""
+		v.ValueSource = &run.GoogleCloudRunV2EnvVarSource{
+			SecretKeyRef: &run.GoogleCloudRunV2SecretKeySelector{Secret: ref.Secret, Version: ref.Version},
+		}
+		changed = true
+	}
+	return changed
+}
+
+// remove removes the environment variables of the inputs from the container. It reports whether
+// the container was changed.
+func (s *serviceEnv) remove(container *run.GoogleCloudRunV2Container) bool {
+	before := len(container.Env)
+	container.Env = slices.DeleteFunc(
+		container.Env, func(v *run.GoogleCloudRunV2EnvVar) bool {
+			_, isEnv := s.target.env[v.Name]
+			_, isSecret := s.target.secrets[v.Name]
+			return isEnv || isSecret
+		},
+	)
+	return len(container.Env) != before
+}
+
+// envVar returns the environment variable of the container with the name, or nil.
+func envVar(container *run.GoogleCloudRunV2Container, name string) *run.GoogleCloudRunV2EnvVar {
+	for _, v := range container.Env {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// outputServiceValues sets the outputs of the service.
+func outputServiceValues(ctx blackstart.ModuleContext, existing *run.GoogleCloudRunV2Service) error {
+	if err := ctx.Output(outputService, existing.Name); err != nil {
+		return err
+	}
+	return ctx.Output(outputURI, existing.Uri)
+}
+
+// inputEnvMap returns the environment variables of an env input. Values must be scalar values.
+func inputEnvMap(input blackstart.Input) (map[string]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputEnv, err)
+	}
+	env := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if name == "" {
+			return nil, fmt.Errorf("invalid %s: variable names cannot be empty", inputEnv)
+		}
+		switch v := value.(type) {
+		case string, bool, int, int64, float64:
+			env[name] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("invalid %s: value of %s must be a scalar value", inputEnv, name)
+		}
+	}
+	return env, nil
+}
+
+// inputSecretsMap returns the secret references of a secrets input. References are in the form
+// <secret> or <secret>:<version>.
+func inputSecretsMap(input blackstart.Input) (map[string]*run.GoogleCloudRunV2SecretKeySelector, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputSecrets, err)
+	}
+	secrets := make(map[string]*run.GoogleCloudRunV2SecretKeySelector, len(raw))
+	for name, value := range raw {
+		if name == "" {
+			return nil, fmt.Errorf("invalid %s: variable names cannot be empty", inputSecrets)
+		}
+		ref, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s: secret of %s must be a string", inputSecrets, name)
+		}
+		secret, version, found := strings.Cut(strings.TrimSpace(ref), ":")
+		if !found {
+			version = defaultSecretVersion
+		}
+		if secret == "" || version == "" {
+			return nil, fmt.Errorf(
+				"invalid %s: secret of %s must be in the form <secret> or <secret>:<version>", inputSecrets, name,
+			)
+		}
+		secrets[name] = &run.GoogleCloudRunV2SecretKeySelector{Secret: secret, Version: version}
+	}
+	return secrets, nil
+}
+
+// verifyDistinct returns an error when an environment variable is set by both env and secrets.
+func verifyDistinct(env map[string]string, secrets map[string]*run.GoogleCloudRunV2SecretKeySelector) error {
+	for name := range env {
+		if _, ok := secrets[name]; ok {
+			return fmt.Errorf("environment variable %s is set in both %s and %s", name, inputEnv, inputSecrets)
+		}
+	}
+	return nil
+}
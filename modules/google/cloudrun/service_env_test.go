@@ -0,0 +1,303 @@
+package cloudrun
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/api/run/v2"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+const testServiceName = "projects/app-project/locations/europe-west1/services/api"
+
+// fakeCloudRun implements the Cloud Run REST operations used by the service env module.
+type fakeCloudRun struct {
+	t        *testing.T
+	server   *httptest.Server
+	service  *run.GoogleCloudRunV2Service
+	etags    []string
+	requests []string
+	// Operations reports operations as running until PendingPolls polls were made.
+	cloudtest.Operations
+	mu sync.Mutex
+}
+
+// newFakeCloudRun starts a stateful fake Cloud Run API server.
+func newFakeCloudRun(t *testing.T) *fakeCloudRun {
+	t.Helper()
+	f := &fakeCloudRun{t: t}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a Cloud Run runtime connected to the fake API.
+func (f *fakeCloudRun) runtime() *cloudRunRuntime {
+	return &cloudRunRuntime{
+		newService: func(ctx context.Context) (*run.Service, error) {
+			return run.NewService(ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication())
+		},
+	}
+}
+
+// serveHTTP handles the Cloud Run API operations used by the unit tests.
+func (f *fakeCloudRun) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	switch {
+	case r.Method == http.MethodGet && strings.Contains(path, "/operations/"):
+		cloudtest.WriteJSON(f.t, w, f.Poll(path))
+	case r.Method == http.MethodGet && path == testServiceName:
+		if f.service == nil {
+			http.Error(w, "service not found", http.StatusNotFound)
+			return
+		}
+		cloudtest.WriteJSON(f.t, w, f.service)
+	case r.Method == http.MethodPatch && path == testServiceName:
+		var s run.GoogleCloudRunV2Service
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&s))
+		f.etags = append(f.etags, s.Etag)
+		if s.Etag != f.service.Etag {
+			http.Error(w, "etag mismatch", http.StatusConflict)
+			return
+		}
+		s.Etag = "etag-2"
+		f.service = &s
+		cloudtest.WriteJSON(f.t, w, f.Start("projects/app-project/locations/europe-west1/operations/op-1"))
+	default:
+		f.t.Errorf("unexpected Cloud Run API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// requestCount returns the number of requests received with the method.
+func (f *fakeCloudRun) requestCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, request := range f.requests {
+		if strings.HasPrefix(request, method+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testContext creates a module context for the operation that records outputs.
+func testContext(op *blackstart.Operation) *outputContext {
+	return &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), op),
+		outputs:       map[string]any{},
+	}
+}
+
+// testService returns a deployed service with one container.
+func testService(env ...*run.GoogleCloudRunV2EnvVar) *run.GoogleCloudRunV2Service {
+	return &run.GoogleCloudRunV2Service{
+		Name: testServiceName,
+		Uri:  "https://api-abc123-ew.a.run.app",
+		Etag: "etag-1",
+		Template: &run.GoogleCloudRunV2RevisionTemplate{
+			Containers: []*run.GoogleCloudRunV2Container{
+				{Image: "europe-docker.pkg.dev/app-project/app/api:1.0.0", Env: env},
+			},
+		},
+	}
+}
+
+// testServiceEnvOperation creates a service env operation for the api service.
+func testServiceEnvOperation() blackstart.Operation {
+	return blackstart.Operation{
+		Id:     "api-env",
+		Module: "google_cloudrun_service_env",
+		Inputs: map[string]blackstart.Input{
+			inputProject:  blackstart.NewInputFromValue("app-project"),
+			inputLocation: blackstart.NewInputFromValue("europe-west1"),
+			inputService:  blackstart.NewInputFromValue("api"),
+			inputEnv:      blackstart.NewInputFromValue(map[string]any{"DB_NAME": "app", "DB_PORT": 5432}),
+			inputSecrets:  blackstart.NewInputFromValue(map[string]any{"DB_PASSWORD": "app-db-password:3"}),
+		},
+	}
+}
+
+func TestServiceEnv_Validate(t *testing.T) {
+	module := NewServiceEnv()
+	require.NoError(t, module.Validate(testServiceEnvOperation()))
+
+	op := testServiceEnvOperation()
+	delete(op.Inputs, inputLocation)
+	require.ErrorContains(t, module.Validate(op), "missing required parameter: location")
+
+	op = testServiceEnvOperation()
+	delete(op.Inputs, inputEnv)
+	delete(op.Inputs, inputSecrets)
+	require.ErrorContains(t, module.Validate(op), "at least one of env or secrets is required")
+
+	op = testServiceEnvOperation()
+	op.Inputs[inputEnv] = blackstart.NewInputFromValue(map[string]any{"DB_PASSWORD": "hunter2"})
+	require.ErrorContains(t, module.Validate(op), "DB_PASSWORD is set in both env and secrets")
+
+	op = testServiceEnvOperation()
+	op.Inputs[inputSecrets] = blackstart.NewInputFromValue(map[string]any{"DB_PASSWORD": "app-db-password:"})
+	require.ErrorContains(t, module.Validate(op), "must be in the form <secret> or <secret>:<version>")
+
+	op = testServiceEnvOperation()
+	op.Inputs[inputEnv] = blackstart.NewInputFromValue(map[string]any{"HOSTS": []any{"a"}})
+	require.ErrorContains(t, module.Validate(op), "must be a scalar value")
+}
+
+func TestServiceEnv_SetAndCheck(t *testing.T) {
+	initial := operationPollInitialInterval
+	operationPollInitialInterval = time.Millisecond
+	t.Cleanup(func() { operationPollInitialInterval = initial })
+
+	fake := newFakeCloudRun(t)
+	fake.PendingPolls = 2
+	fake.service = testService(
+		&run.GoogleCloudRunV2EnvVar{Name: "LOG_LEVEL", Value: "info"},
+		&run.GoogleCloudRunV2EnvVar{Name: "DB_NAME", Value: "old"},
+	)
+	op := testServiceEnvOperation()
+	module := &serviceEnv{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ctx := testContext(&op)
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, testServiceName, ctx.outputs[outputService])
+	require.Equal(t, "https://api-abc123-ew.a.run.app", ctx.outputs[outputURI])
+	require.Equal(t, []string{"etag-1"}, fake.etags)
+	// Existing variables keep their order and new variables are added in the order of their names.
+	require.Equal(
+		t, []*run.GoogleCloudRunV2EnvVar{
+			{Name: "LOG_LEVEL", Value: "info"},
+			{Name: "DB_NAME", Value: "app"},
+			{Name: "DB_PORT", Value: "5432"},
+			{
+				Name: "DB_PASSWORD",
+				ValueSource: &run.GoogleCloudRunV2EnvVarSource{
+					SecretKeyRef: &run.GoogleCloudRunV2SecretKeySelector{Secret: "app-db-password", Version: "3"},
+				},
+			},
+		}, fake.service.Template.Containers[0].Env,
+	)
+	require.Equal(t, "europe-docker.pkg.dev/app-project/app/api:1.0.0", fake.service.Template.Containers[0].Image)
+
+	ok, err = module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The service is not updated when the environment is set.
+	require.NoError(t, module.Set(testContext(&op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodPatch))
+}
+
+func TestServiceEnv_SecretReplacesValue(t *testing.T) {
+	fake := newFakeCloudRun(t)
+	fake.service = testService(&run.GoogleCloudRunV2EnvVar{Name: "DB_PASSWORD", Value: "hunter2"})
+	op := testServiceEnvOperation()
+	delete(op.Inputs, inputEnv)
+	op.Inputs[inputSecrets] = blackstart.NewInputFromValue(map[string]any{"DB_PASSWORD": "app-db-password"})
+	module := &serviceEnv{runtime: fake.runtime()}
+
+	require.NoError(t, module.Set(testContext(&op)))
+	env := fake.service.Template.Containers[0].Env
+	require.Len(t, env, 1)
+	require.Empty(t, env[0].Value)
+	require.Equal(t, "app-db-password", env[0].ValueSource.SecretKeyRef.Secret)
+	require.Equal(t, defaultSecretVersion, env[0].ValueSource.SecretKeyRef.Version)
+}
+
+func TestServiceEnv_Containers(t *testing.T) {
+	fake := newFakeCloudRun(t)
+	fake.service = testService()
+	fake.service.Template.Containers = append(
+		fake.service.Template.Containers, &run.GoogleCloudRunV2Container{Name: "proxy"},
+	)
+	fake.service.Template.Containers[0].Name = "api"
+	op := testServiceEnvOperation()
+	module := &serviceEnv{runtime: fake.runtime()}
+
+	_, err := module.Check(testContext(&op))
+	require.ErrorContains(t, err, "has 2 containers, container is required")
+
+	op.Inputs[inputContainer] = blackstart.NewInputFromValue("worker")
+	_, err = module.Check(testContext(&op))
+	require.ErrorContains(t, err, "has no container worker")
+
+	op.Inputs[inputContainer] = blackstart.NewInputFromValue("proxy")
+	require.NoError(t, module.Set(testContext(&op)))
+	require.Empty(t, fake.service.Template.Containers[0].Env)
+	require.Len(t, fake.service.Template.Containers[1].Env, 3)
+}
+
+func TestServiceEnv_MissingService(t *testing.T) {
+	fake := newFakeCloudRun(t)
+	op := testServiceEnvOperation()
+	module := &serviceEnv{runtime: fake.runtime()}
+
+	_, err := module.Check(testContext(&op))
+	require.ErrorContains(t, err, "service "+testServiceName+" does not exist")
+	require.ErrorContains(t, module.Set(testContext(&op)), "does not exist")
+
+	op.DoesNotExist = true
+	ok, err := module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestServiceEnv_DoesNotExist(t *testing.T) {
+	fake := newFakeCloudRun(t)
+	fake.service = testService(
+		&run.GoogleCloudRunV2EnvVar{Name: "LOG_LEVEL", Value: "info"},
+		&run.GoogleCloudRunV2EnvVar{Name: "DB_NAME", Value: "app"},
+		&run.GoogleCloudRunV2EnvVar{
+			Name: "DB_PASSWORD",
+			ValueSource: &run.GoogleCloudRunV2EnvVarSource{
+				SecretKeyRef: &run.GoogleCloudRunV2SecretKeySelector{Secret: "other", Version: "1"},
+			},
+		},
+	)
+	op := testServiceEnvOperation()
+	op.DoesNotExist = true
+	module := &serviceEnv{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(&op)))
+	require.Equal(
+		t, []*run.GoogleCloudRunV2EnvVar{{Name: "LOG_LEVEL", Value: "info"}}, fake.service.Template.Containers[0].Env,
+	)
+
+	ok, err = module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
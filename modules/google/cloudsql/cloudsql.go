@@ -2,20 +2,27 @@ package cloudsql
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"cloud.google.com/go/cloudsqlconn"
 	cloudsqlmysql "cloud.google.com/go/cloudsqlconn/mysql/mysql"
 	"cloud.google.com/go/cloudsqlconn/postgres/pgxv5"
 	gomysql "github.com/go-sql-driver/mysql"
 	"golang.org/x/oauth2/google"
-	"google.golang.org/api/option"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/sqladmin/v1"
 	cloudsqlv1 "google.golang.org/genproto/googleapis/cloud/sql/v1"
 
@@ -23,22 +30,50 @@ import (
 	"github.com/pezops/blackstart/modules/google/cloud"
 )
 
+// SQLAdminAPI creates the Cloud SQL Admin API clients of the Cloud SQL modules. A provider set in
+// the workflow context with SQLAdminAPIKey replaces the default clients, so tests can use a fake
+// Admin API and deployments can route the requests through their own HTTP clients, such as one for
+// an egress proxy.
+type SQLAdminAPI interface {
+	// SQLAdminService returns a client of the Admin API, authenticated with the credentials, or with
+	// the default credentials when they are nil.
+	SQLAdminService(ctx context.Context, creds *google.Credentials) (*sqladmin.Service, error)
+}
+
+type contextKey string
+
+// SQLAdminAPIKey is the context key of the SQLAdminAPI of the Cloud SQL modules.
+const SQLAdminAPIKey contextKey = "sqlAdminAPI"
+
 // cloudSQLRuntime provides injectable Cloud SQL Admin API and database connection dependencies.
 type cloudSQLRuntime struct {
-	newSQLAdminService func(context.Context) (*sqladmin.Service, error)
+	newSQLAdminService func(context.Context, *google.Credentials) (*sqladmin.Service, error)
 	openDB             func(string, string) (*sql.DB, error)
 }
 
 // defaultCloudSQLRuntime creates the production Cloud SQL runtime.
 func defaultCloudSQLRuntime() *cloudSQLRuntime {
 	return &cloudSQLRuntime{
-		newSQLAdminService: func(ctx context.Context) (*sqladmin.Service, error) {
-			return sqladmin.NewService(ctx, option.WithUserAgent(blackstart.UserAgent))
+		newSQLAdminService: func(ctx context.Context, creds *google.Credentials) (*sqladmin.Service, error) {
+			return cloud.NewService(
+				ctx, sqladmin.NewService, creds, sqladmin.CloudPlatformScope, sqladmin.SqlserviceAdminScope,
+			)
 		},
 		openDB: sql.Open,
 	}
 }
 
+// sqlAdminService creates a Cloud SQL Admin API client with the SQLAdminAPI of the context, or with
+// the runtime when the context does not provide one.
+func (r *cloudSQLRuntime) sqlAdminService(
+	ctx context.Context, creds *google.Credentials,
+) (*sqladmin.Service, error) {
+	if api, ok := ctx.Value(SQLAdminAPIKey).(SQLAdminAPI); ok && api != nil {
+		return api.SQLAdminService(ctx, creds)
+	}
+	return r.newSQLAdminService(ctx, creds)
+}
+
 // cloudSQLRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
 func cloudSQLRuntimeOrDefault(runtime *cloudSQLRuntime) *cloudSQLRuntime {
 	if runtime == nil {
@@ -66,6 +101,14 @@ const (
 	// built-in / static credentials connecting via a VPC and private IP address.
 	sqlDriverPostgresPrivateIp = "cloudsql-postgres-private"
 
+	// sqlDriverPostgresIamPsc is the driver name for connecting to Cloud SQL for PostgreSQL instances
+	// using IAM credentials through a Private Service Connect endpoint.
+	sqlDriverPostgresIamPsc = "cloudsql-postgres-iam-psc"
+
+	// sqlDriverPostgresPsc is the driver name for connecting to Cloud SQL for PostgreSQL instances
+	// using built-in / static credentials through a Private Service Connect endpoint.
+	sqlDriverPostgresPsc = "cloudsql-postgres-psc"
+
 	// sqlDriverMySQLIam is the driver name for connecting to Cloud SQL for MySQL instances
 	// using IAM credentials.
 	sqlDriverMySQLIam = "cloudsql-mysql-iam"
@@ -82,6 +125,14 @@ const (
 	// using built-in / static credentials via a VPC and private IP address.
 	sqlDriverMySQLPrivateIp = "cloudsql-mysql-private"
 
+	// sqlDriverMySQLIamPsc is the driver name for connecting to Cloud SQL for MySQL instances using
+	// IAM credentials through a Private Service Connect endpoint.
+	sqlDriverMySQLIamPsc = "cloudsql-mysql-iam-psc"
+
+	// sqlDriverMySQLPsc is the driver name for connecting to Cloud SQL for MySQL instances using
+	// built-in / static credentials through a Private Service Connect endpoint.
+	sqlDriverMySQLPsc = "cloudsql-mysql-psc"
+
 	// userBuiltIn is the user type for built-in users with static credentials. We don't
 	// normally allow this, but it is used internally to bootstrap a managed instance.
 	userBuiltIn = "BUILT_IN"
@@ -102,46 +153,135 @@ const (
 	inputUserType       = "user_type"
 	inputCharset        = "charset"
 	inputCollation      = "collation"
+	inputReplicaPolicy  = "replica_policy"
+	inputDNSName        = "dns_name"
+	inputCredentials    = "credentials"
+	inputLabels         = "labels"
 
 	outputUser       = "user"
 	outputDatabase   = "database"
 	outputConnection = "connection"
 )
 
+const (
+	// replicaPolicyFail returns an error when the target instance is a read replica.
+	replicaPolicyFail = "FAIL"
+
+	// replicaPolicyFollowPrimary manages the primary instance when the target instance is a read
+	// replica.
+	replicaPolicyFollowPrimary = "FOLLOW_PRIMARY"
+
+	// maxReplicaHops limits how many replication levels are followed to find the primary instance.
+	maxReplicaHops = 5
+)
+
+var replicaPolicies = []string{replicaPolicyFail, replicaPolicyFollowPrimary}
+
+// Cloud SQL Admin API mutations return long-running operations. They are polled with exponential
+// backoff between these intervals until they are done, for at most operationTimeout.
+var (
+	operationPollInitialInterval = 500 * time.Millisecond
+	operationPollMaxInterval     = 5 * time.Second
+	operationTimeout             = 10 * time.Minute
+)
+
+// operationInProgressRetries limits how often a mutation is retried while the Admin API rejects it
+// because another operation is in progress on the instance. Retries use the operation poll
+// intervals.
+var operationInProgressRetries = 6
+
+// connectorDriver is the engine and Cloud SQL connector options of a database driver.
+type connectorDriver struct {
+	mysql bool
+	opts  []cloudsqlconn.Option
+}
+
+// connectorDrivers are the database drivers for each engine, authentication method, and connection
+// type. All drivers use the DNS resolver, which accepts either an instance connection name or a
+// custom DNS name with a TXT record that resolves to the instance connection name.
+var connectorDrivers = map[string]connectorDriver{
+	sqlDriverPostgresIam: {opts: []cloudsqlconn.Option{cloudsqlconn.WithIAMAuthN(), cloudsqlconn.WithDNSResolver()}},
+	sqlDriverPostgresIamPrivateIp: {
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithIAMAuthN(),
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()),
+		},
+	},
+	sqlDriverPostgresIamPsc: {
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithIAMAuthN(),
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPSC()),
+		},
+	},
+	sqlDriverPostgres: {opts: []cloudsqlconn.Option{cloudsqlconn.WithDNSResolver()}},
+	sqlDriverPostgresPrivateIp: {
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()),
+		},
+	},
+	sqlDriverPostgresPsc: {
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPSC()),
+		},
+	},
+	sqlDriverMySQLIam: {
+		mysql: true,
+		opts:  []cloudsqlconn.Option{cloudsqlconn.WithIAMAuthN(), cloudsqlconn.WithDNSResolver()},
+	},
+	sqlDriverMySQLIamPrivateIp: {
+		mysql: true,
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithIAMAuthN(),
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()),
+		},
+	},
+	sqlDriverMySQLIamPsc: {
+		mysql: true,
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithIAMAuthN(),
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPSC()),
+		},
+	},
+	sqlDriverMySQL: {mysql: true, opts: []cloudsqlconn.Option{cloudsqlconn.WithDNSResolver()}},
+	sqlDriverMySQLPrivateIp: {
+		mysql: true,
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()),
+		},
+	},
+	sqlDriverMySQLPsc: {
+		mysql: true,
+		opts: []cloudsqlconn.Option{
+			cloudsqlconn.WithDNSResolver(),
+			cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPSC()),
+		},
+	},
+}
+
+// credentialsDrivers records the drivers registered by credentialsDriver. The database/sql package
+// does not support unregistering drivers, so each driver is registered once and reused.
+var (
+	credentialsDrivers   = map[string]bool{}
+	credentialsDriversMu sync.Mutex
+)
+
 func init() {
 	blackstart.RegisterPathName("cloudsql", "Cloud SQL")
 
 	// Register global drivers that can be used by the database/sql package with the new package
 	// to connect to Cloud SQL, cloud.google.com/go/cloudsqlconn. Its PostgreSQL and MySQL
-	// subpackages connect without the need for the Cloud SQL proxy.
-	//
-	// To leverage a credentials file, a driver per credential is needed that specifies the file
-	// or contents.
-	_, _ = pgxv5.RegisterDriver(sqlDriverPostgresIam, cloudsqlconn.WithIAMAuthN())
-	_, _ = pgxv5.RegisterDriver(
-		sqlDriverPostgresIamPrivateIp, cloudsqlconn.WithIAMAuthN(),
-		cloudsqlconn.WithDefaultDialOptions(
-			cloudsqlconn.WithPrivateIP(),
-		),
-	)
-	_, _ = pgxv5.RegisterDriver(sqlDriverPostgres)
-	_, _ = pgxv5.RegisterDriver(
-		sqlDriverPostgresPrivateIp,
-		cloudsqlconn.WithDefaultDialOptions(
-			cloudsqlconn.WithPrivateIP(),
-		),
-	)
-	_, _ = cloudsqlmysql.RegisterDriver(sqlDriverMySQLIam, cloudsqlconn.WithIAMAuthN())
-	_, _ = cloudsqlmysql.RegisterDriver(
-		sqlDriverMySQLIamPrivateIp,
-		cloudsqlconn.WithIAMAuthN(),
-		cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()),
-	)
-	_, _ = cloudsqlmysql.RegisterDriver(sqlDriverMySQL)
-	_, _ = cloudsqlmysql.RegisterDriver(
-		sqlDriverMySQLPrivateIp,
-		cloudsqlconn.WithDefaultDialOptions(cloudsqlconn.WithPrivateIP()),
-	)
+	// subpackages connect without the need for the Cloud SQL proxy. These drivers use the default
+	// credentials, drivers for credentials provided as input are registered by credentialsDriver.
+	for name, d := range connectorDrivers {
+		_ = registerConnectorDriver(name, d)
+	}
 
 	// Quick check to make sure these shorter constants here did not change from the upstream values.
 	if userBuiltIn != cloudsqlv1.User_SqlUserType_name[int32(cloudsqlv1.User_BUILT_IN)] ||
@@ -151,6 +291,65 @@ func init() {
 	}
 }
 
+// registerConnectorDriver registers a database driver with the options of the connector driver
+// and any additional options.
+func registerConnectorDriver(name string, d connectorDriver, opts ...cloudsqlconn.Option) error {
+	opts = append(slices.Clone(d.opts), opts...)
+	var err error
+	if d.mysql {
+		_, err = cloudsqlmysql.RegisterDriver(name, opts...)
+	} else {
+		_, err = pgxv5.RegisterDriver(name, opts...)
+	}
+	return err
+}
+
+// credentialsDriver returns the name of a database driver like the base driver that connects with
+// the credentials JSON instead of the default credentials. A driver is registered for each
+// credential the first time it is used. Without credentials JSON, the base driver is returned.
+func credentialsDriver(base string, credentialsJSON []byte) (string, error) {
+	if len(credentialsJSON) == 0 {
+		return base, nil
+	}
+	d, ok := connectorDrivers[base]
+	if !ok {
+		return "", fmt.Errorf("unknown Cloud SQL driver: %s", base)
+	}
+
+	// The driver name is derived from a hash of the credentials, so it does not expose them.
+	sum := sha256.Sum256(credentialsJSON)
+	name := base + "-" + hex.EncodeToString(sum[:8])
+
+	credentialsDriversMu.Lock()
+	defer credentialsDriversMu.Unlock()
+	if credentialsDrivers[name] {
+		return name, nil
+	}
+	err := registerConnectorDriver(name, d, cloudsqlconn.WithCredentialsJSON(credentialsJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to register Cloud SQL driver for credentials: %w", err)
+	}
+	credentialsDrivers[name] = true
+	return name, nil
+}
+
+// contextCredentials returns the Google Cloud credentials of the credentials input. When the input
+// is not set, nil is returned and the default credentials are used.
+func contextCredentials(ctx blackstart.ModuleContext) (*google.Credentials, error) {
+	credentialsJSON, err := blackstart.ContextInputAs[string](ctx, inputCredentials, false)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(credentialsJSON) == "" {
+		return nil, nil
+	}
+	creds, err := cloud.CredentialsFromJSON(ctx, []byte(credentialsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("input '%s' is invalid: %w", inputCredentials, err)
+	}
+	return creds, nil
+}
+
 // ErrRegionNotProvidedNotFound indicates a region was neither provided nor discovered.
 var ErrRegionNotProvidedNotFound = errors.New("region not provided and not found")
 
@@ -201,9 +400,22 @@ type connectionConfig struct {
 	// manually.
 	identifier string
 
+	// dnsName is an optional custom DNS name used to dial the instance instead of the connection
+	// identifier. The name must have a TXT record that resolves to the instance connection name.
+	dnsName string
+
 	// creds is the Google Cloud credentials to use for the connection. If not provided, the
 	// default credentials from the runtime environment will be used.
 	creds *google.Credentials
+
+	// labels are user labels the Cloud SQL instance must have. Like the region, they guard against
+	// managing an unintended instance with the same name.
+	labels map[string]string
+
+	// credentialsJSON is the credentials JSON of the credentials input. When set, database
+	// connections are made with drivers that use these credentials instead of the default
+	// credentials.
+	credentialsJSON []byte
 }
 
 // connectionIdentifier returns the connection identifier for the Cloud SQL instance. The connection
@@ -228,79 +440,374 @@ func (t *connectionConfig) connectionIdentifier(ctx context.Context) (string, er
 		}
 	}
 
-	var instances []*sqladmin.DatabaseInstance
-	var identifier string
+	sqlService, err := defaultCloudSQLRuntime().sqlAdminService(ctx, t.creds)
+	if err != nil {
+		return "", fmt.Errorf("failed to create SQL Admin service: %w", err)
+	}
+	instance, err := getCloudSQLInstance(ctx, sqlService, t.project, t.instance)
+	if err != nil {
+		if apiErr, ok := errors.AsType[*googleapi.Error](err); ok && apiErr.Code == http.StatusNotFound {
+			return "", fmt.Errorf("database instance not found: %s", t.instance)
+		}
+		if t.region == "" {
+			return "", errors.Join(ErrRegionNotProvidedNotFound, err)
+		}
+		return "", fmt.Errorf("failed to get instance %s in project %s: %w", t.instance, t.project, err)
+	}
+	if err = t.matchInstance(ctx, sqlService, instance); err != nil {
+		return "", err
+	}
+	t.region = instance.Region
+	identifier := fmt.Sprintf("%s:%s:%s", t.project, instance.Region, instance.Name)
+
+	t.identifier = identifier
+	return identifier, nil
+}
 
-	if t.region != "" {
+// matchInstance returns an error when the instance is not in the region or does not have the labels
+// of the target. Instance names are only unique within a project, and the same name is often used
+// for the instances of each environment, so the region and labels guard against managing an
+// unintended instance, such as one in the default project of the credentials. The error lists the
+// instances of the project that match as candidates.
+func (t *connectionConfig) matchInstance(
+	ctx context.Context, sqlService *sqladmin.Service, instance *sqladmin.DatabaseInstance,
+) error {
+	mismatches := instanceMismatches(instance, t.region, t.labels)
+	if len(mismatches) == 0 {
+		return nil
+	}
+	err := fmt.Errorf(
+		"instance %s in project %s does not match: %s", instance.Name, t.project, strings.Join(mismatches, "; "),
+	)
 
-		instances, err = listCloudSQLInstances(ctx, t.creds, t.project)
-		if err != nil {
-			return "", err
-		}
-		found := false
-		for _, instance := range instances {
-			if instance.Name == t.instance && instance.Region == t.region {
-				if found {
-					return "", fmt.Errorf(
-						"database instance was found multiple times: %v", t.instance,
-					)
+	var candidates []string
+	listErr := sqlService.Instances.List(t.project).Pages(
+		ctx, func(page *sqladmin.InstancesListResponse) error {
+			for _, item := range page.Items {
+				if len(instanceMismatches(item, t.region, t.labels)) == 0 {
+					candidates = append(candidates, fmt.Sprintf("%s (%s)", item.Name, item.Region))
 				}
-				found = true
-				identifier = fmt.Sprintf("%s:%s:%s", t.project, instance.Region, instance.Name)
-				continue
 			}
-		}
+			return nil
+		},
+	)
+	switch {
+	case listErr != nil:
+		return errors.Join(err, fmt.Errorf("failed to list candidate instances: %w", listErr))
+	case len(candidates) == 0:
+		return fmt.Errorf("%w; no instances in project %s match", err, t.project)
+	default:
+		return fmt.Errorf("%w; matching instances: %s", err, strings.Join(candidates, ", "))
 	}
+}
 
-	if t.region == "" {
+// instanceMismatches describes how the instance differs from the region and labels. The region
+// and labels are ignored when they are empty.
+func instanceMismatches(instance *sqladmin.DatabaseInstance, region string, labels map[string]string) []string {
+	var mismatches []string
+	if region != "" && instance.Region != region {
+		mismatches = append(mismatches, fmt.Sprintf("region is %s, not %s", instance.Region, region))
+	}
+	var userLabels map[string]string
+	if instance.Settings != nil {
+		userLabels = instance.Settings.UserLabels
+	}
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		value, ok := userLabels[key]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, fmt.Sprintf("label %s is not set", key))
+		case value != labels[key]:
+			mismatches = append(mismatches, fmt.Sprintf("label %s is %q, not %q", key, value, labels[key]))
+		}
+	}
+	return mismatches
+}
 
-		instances, err = listCloudSQLInstances(ctx, t.creds, t.project)
-		if err != nil {
-			return "", errors.Join(ErrRegionNotProvidedNotFound, err)
+// parseInstanceLabels parses comma-separated key=value labels, such as `env=prod,team=data`.
+func parseInstanceLabels(value string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
 		}
-		found := false
-		for _, instance := range instances {
-			if instance.Name == t.instance {
-				if found {
-					return "", fmt.Errorf(
-						"database instance was found multiple times: %v: %w", t.instance, ErrRegionNotProvidedNotFound,
-					)
-				}
-				found = true
-				t.region = instance.Region
-				identifier = fmt.Sprintf("%s:%s:%s", t.project, instance.Region, instance.Name)
-				continue
-			}
+		key, labelValue, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid %s: %q must be in the form key=value", inputLabels, pair)
 		}
+		labels[key] = strings.TrimSpace(labelValue)
+	}
+	return labels, nil
+}
+
+// validateLabels validates a static labels input, when provided.
+func validateLabels(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputLabels]
+	if !ok || !input.IsStatic() {
+		return nil
 	}
+	labels, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", inputLabels, err)
+	}
+	_, err = parseInstanceLabels(labels)
+	return err
+}
 
-	if identifier == "" {
-		return "", fmt.Errorf("database instance not found: %s", t.instance)
+// contextLabels returns the labels input of the module context.
+func contextLabels(ctx blackstart.ModuleContext) (map[string]string, error) {
+	labels, err := blackstart.ContextInputAs[string](ctx, inputLabels, false)
+	if err != nil {
+		return nil, err
 	}
+	return parseInstanceLabels(labels)
+}
 
-	t.identifier = identifier
-	return identifier, nil
+// dialName returns the name used by the Cloud SQL connector to dial the instance. This is the
+// custom DNS name when configured, otherwise the connection identifier.
+func (t *connectionConfig) dialName(ctx context.Context) (string, error) {
+	if t.dnsName != "" {
+		return t.dnsName, nil
+	}
+	return t.connectionIdentifier(ctx)
+}
+
+// instanceCacheKey is the run cache key of the metadata of a Cloud SQL instance.
+type instanceCacheKey struct {
+	project  string
+	instance string
 }
 
-// listCloudSQLInstances lists Cloud SQL instances for a given project ID using the provided credentials.
-func listCloudSQLInstances(
-	ctx context.Context, creds *google.Credentials, projectID string,
-) ([]*sqladmin.DatabaseInstance, error) {
-	// Initialize the Cloud SQL Admin service
-	sqlService, err := sqladmin.NewService(
-		ctx, option.WithCredentials(creds), option.WithUserAgent(blackstart.UserAgent),
+// getCloudSQLInstance returns the metadata of a Cloud SQL instance. Instance names are unique in a
+// project, so the instance is read directly instead of searching the instances of the project. The
+// metadata is cached for the workflow run, since several operations usually target the same
+// instance. The returned instance is shared and must not be modified.
+func getCloudSQLInstance(
+	ctx context.Context, sqlService *sqladmin.Service, project, instance string,
+) (*sqladmin.DatabaseInstance, error) {
+	return blackstart.RunCached(
+		ctx, instanceCacheKey{project: project, instance: instance},
+		func() (*sqladmin.DatabaseInstance, error) {
+			return sqlService.Instances.Get(project, instance).Context(ctx).Do()
+		},
 	)
+}
+
+// instanceIsReplica reports whether a Cloud SQL instance is a read replica or read pool.
+func instanceIsReplica(instance *sqladmin.DatabaseInstance) bool {
+	if instance == nil {
+		return false
+	}
+	switch instance.InstanceType {
+	case "READ_REPLICA_INSTANCE", "READ_POOL_INSTANCE":
+		return true
+	}
+	return instance.MasterInstanceName != ""
+}
+
+// validateReplicaPolicy checks a static replica_policy input.
+func validateReplicaPolicy(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputReplicaPolicy]
+	if !ok || !input.IsStatic() {
+		return nil
+	}
+	policy, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", inputReplicaPolicy, err)
+	}
+	if policy != "" && !slices.Contains(replicaPolicies, strings.ToUpper(policy)) {
+		return fmt.Errorf(
+			"invalid %s: %s - must be one of: %s", inputReplicaPolicy, policy, strings.Join(replicaPolicies, ", "),
+		)
+	}
+	return nil
+}
+
+// validateCredentials validates a static credentials input, when provided.
+func validateCredentials(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputCredentials]
+	if !ok || !input.IsStatic() {
+		return nil
+	}
+	credentialsJSON, err := blackstart.InputAs[string](input, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create SQL Admin service: %w", err)
+		return fmt.Errorf("invalid %s: %w", inputCredentials, err)
+	}
+	if strings.TrimSpace(credentialsJSON) == "" {
+		return nil
+	}
+	if _, err = cloud.CredentialsFromJSON(context.Background(), []byte(credentialsJSON)); err != nil {
+		return fmt.Errorf("invalid %s: %w", inputCredentials, err)
 	}
+	return nil
+}
 
-	// List instances for the given project ID
-	instancesList, err := sqlService.Instances.List(projectID).Context(ctx).Do()
+// contextReplicaPolicy returns the normalized replica_policy input from the module context.
+func contextReplicaPolicy(ctx blackstart.ModuleContext) (string, error) {
+	policy, err := blackstart.ContextInputAs[string](ctx, inputReplicaPolicy, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list instances: %w", err)
+		return "", err
+	}
+	policy = strings.ToUpper(strings.TrimSpace(policy))
+	if policy == "" {
+		return replicaPolicyFail, nil
 	}
+	if !slices.Contains(replicaPolicies, policy) {
+		return "", fmt.Errorf(
+			"invalid %s: %s - must be one of: %s", inputReplicaPolicy, policy, strings.Join(replicaPolicies, ", "),
+		)
+	}
+	return policy, nil
+}
 
-	return instancesList.Items, nil
+// instanceSerializationKey returns the serialization key of operations that change users or
+// databases of a Cloud SQL instance. The Cloud SQL Admin API rejects concurrent operations on one
+// instance, so these operations are run one at a time.
+func instanceSerializationKey(ctx blackstart.ModuleContext) (string, error) {
+	instance, err := blackstart.ContextInputAs[string](ctx, inputInstance, true)
+	if err != nil {
+		return "", err
+	}
+	project, _ := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	return fmt.Sprintf("google_cloudsql_instance:%s/%s", project, instance), nil
+}
+
+// cloudSQLResourceId returns the identifier reported for a user or database of a Cloud SQL
+// instance, in the form project:instance:name.
+func cloudSQLResourceId(project, instance, name string) string {
+	return fmt.Sprintf("%s:%s:%s", project, instance, name)
+}
+
+// resolvePrimaryInstance applies the replica policy to a Cloud SQL instance. A primary instance is
+// returned unchanged. A read replica either results in an error or, when following the primary, is
+// resolved to the project and instance it replicates from.
+func resolvePrimaryInstance(
+	ctx context.Context,
+	sqlService *sqladmin.Service,
+	project string,
+	instance *sqladmin.DatabaseInstance,
+	policy string,
+) (string, *sqladmin.DatabaseInstance, error) {
+	for hops := 0; instanceIsReplica(instance); hops++ {
+		if instance.MasterInstanceName == "" {
+			return "", nil, fmt.Errorf(
+				"instance %s in project %s is a read replica and its primary instance is unknown",
+				instance.Name, project,
+			)
+		}
+		if policy != replicaPolicyFollowPrimary {
+			return "", nil, fmt.Errorf(
+				"instance %s in project %s is a read replica of %s; users and grants must be managed on "+
+					"the primary instance, or set %s to %s",
+				instance.Name, project, instance.MasterInstanceName, inputReplicaPolicy, replicaPolicyFollowPrimary,
+			)
+		}
+		if hops >= maxReplicaHops {
+			return "", nil, fmt.Errorf("instance %s exceeded %d replication levels", instance.Name, maxReplicaHops)
+		}
+
+		primaryProject, primaryName, found := strings.Cut(instance.MasterInstanceName, ":")
+		if !found {
+			primaryProject, primaryName = project, instance.MasterInstanceName
+		}
+		primary, err := getCloudSQLInstance(ctx, sqlService, primaryProject, primaryName)
+		if err != nil {
+			return "", nil, fmt.Errorf(
+				"failed to get primary instance %s of replica %s: %w", instance.MasterInstanceName, instance.Name, err,
+			)
+		}
+		project, instance = primaryProject, primary
+	}
+	return project, instance, nil
+}
+
+// waitForOperation polls a Cloud SQL Admin API operation until it is done and returns any error
+// reported by the operation. Dependent operations may otherwise observe the state from before the
+// change, such as a user that is not yet able to log in. An operation that is not done within
+// operationTimeout is reported as an error, so a stuck operation does not block the workflow.
+func waitForOperation(
+	ctx context.Context, sqlService *sqladmin.Service, project string, op *sqladmin.Operation,
+) error {
+	if op == nil {
+		return fmt.Errorf("operation result was empty")
+	}
+	deadline := time.Now().Add(operationTimeout)
+	interval := operationPollInitialInterval
+	for op.Status != "DONE" {
+		if op.Name == "" {
+			return fmt.Errorf("operation %s has no name and cannot be polled", op.OperationType)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf(
+				"timed out waiting for operation %s after %s: status %s", op.Name, operationTimeout, op.Status,
+			)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed waiting for operation %s: %w", op.Name, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, operationPollMaxInterval)
+
+		next, err := sqlService.Operations.Get(project, op.Name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to get operation %s: %w", op.Name, err)
+		}
+		op = next
+	}
+
+	if op.Error == nil || len(op.Error.Errors) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(op.Error.Errors))
+	for _, e := range op.Error.Errors {
+		if e == nil {
+			continue
+		}
+		messages = append(messages, fmt.Sprintf("%s: %s", e.Code, e.Message))
+	}
+	return fmt.Errorf("operation %s failed: %s", op.Name, strings.Join(messages, "; "))
+}
+
+// isOperationInProgress reports whether the Admin API rejected a request because another operation
+// is in progress on the instance. Cloud SQL runs one operation at a time for each instance.
+func isOperationInProgress(err error) bool {
+	apiErr, ok := errors.AsType[*googleapi.Error](err)
+	if !ok {
+		return false
+	}
+	if apiErr.Code == http.StatusConflict {
+		return true
+	}
+	for _, item := range apiErr.Errors {
+		if item.Reason == "operationInProgress" {
+			return true
+		}
+	}
+	return false
+}
+
+// retryOperationInProgress calls an Admin API mutation, retrying it with exponential backoff while
+// it is rejected because another operation is in progress on the instance, such as a change made
+// by another workflow or a maintenance operation. Other errors are returned without retrying.
+func retryOperationInProgress(
+	ctx context.Context, call func() (*sqladmin.Operation, error),
+) (*sqladmin.Operation, error) {
+	interval := operationPollInitialInterval
+	for attempt := 0; ; attempt++ {
+		op, err := call()
+		if err == nil || !isOperationInProgress(err) || attempt >= operationInProgressRetries {
+			return op, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w; stopped retrying: %w", err, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, operationPollMaxInterval)
+	}
 }
 
 // postgresAdcIamUser returns the IAM user for the current ADC or workload identity in the format
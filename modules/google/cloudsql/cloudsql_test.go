@@ -2,13 +2,19 @@ package cloudsql
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/sqladmin/v1"
+
+	"github.com/pezops/blackstart"
 )
 
 func testCredentialsTypeFromJSON(t *testing.T, credJSON string) google.CredentialsType {
@@ -416,3 +422,158 @@ func TestNormalizeCloudSQLServiceAccountUsername(t *testing.T) {
 		},
 	)
 }
+
+// TestInstanceSerializationKey verifies user and database operations on the same instance share a
+// serialization key.
+func TestInstanceSerializationKey(t *testing.T) {
+	userOp := testCloudSQLDatabaseOperation("app")
+	userOp.Module = "google_cloudsql_user"
+	dbOp := testCloudSQLDatabaseOperation("other")
+	userKey, err := instanceSerializationKey(blackstart.OpContext(context.Background(), &userOp))
+	require.NoError(t, err)
+	dbKey, err := instanceSerializationKey(blackstart.OpContext(context.Background(), &dbOp))
+	require.NoError(t, err)
+	assert.Equal(t, userKey, dbKey)
+
+	dbOp.Inputs[inputInstance] = blackstart.NewInputFromValue("other-instance")
+	otherKey, err := instanceSerializationKey(blackstart.OpContext(context.Background(), &dbOp))
+	require.NoError(t, err)
+	assert.NotEqual(t, dbKey, otherKey)
+
+	assert.NotNil(t, NewCloudSqlUser().Info().SerializationKey)
+	assert.NotNil(t, NewCloudSqlDatabase().Info().SerializationKey)
+}
+
+// TestWaitForOperation verifies Admin API operations are polled until done and operation errors
+// are returned.
+func TestWaitForOperation(t *testing.T) {
+	initial, initialTimeout := operationPollInitialInterval, operationTimeout
+	operationPollInitialInterval = time.Millisecond
+	t.Cleanup(
+		func() {
+			operationPollInitialInterval = initial
+			operationTimeout = initialTimeout
+		},
+	)
+
+	tests := map[string]struct {
+		pendingPolls   int
+		operationError *sqladmin.OperationError
+		cancel         bool
+		timeout        time.Duration
+		wantPolls      int
+		wantErr        string
+	}{
+		"done immediately": {},
+		"polled until done": {
+			pendingPolls: 3,
+			wantPolls:    3,
+		},
+		"operation error": {
+			pendingPolls:   1,
+			operationError: &sqladmin.OperationError{Code: "INTERNAL_ERROR", Message: "user creation failed"},
+			wantPolls:      1,
+			wantErr:        "operation operation-1 failed: INTERNAL_ERROR: user creation failed",
+		},
+		"context canceled": {
+			pendingPolls: 1,
+			cancel:       true,
+			wantErr:      "context canceled",
+		},
+		"timed out": {
+			pendingPolls: 3,
+			timeout:      -time.Second,
+			wantErr:      "timed out waiting for operation operation-1",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+				api.pendingPolls = tt.pendingPolls
+				api.operationError = tt.operationError
+				operationTimeout = initialTimeout
+				if tt.timeout != 0 {
+					operationTimeout = tt.timeout
+				}
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				if tt.cancel {
+					cancel()
+				}
+				sqlService, err := api.runtime(nil).newSQLAdminService(context.Background(), nil)
+				require.NoError(t, err)
+
+				api.mu.Lock()
+				op := api.newOperation()
+				api.mu.Unlock()
+				err = waitForOperation(ctx, sqlService, "project", op)
+				if tt.wantErr != "" {
+					require.ErrorContains(t, err, tt.wantErr)
+				} else {
+					require.NoError(t, err)
+				}
+				if !tt.cancel {
+					assert.Equal(t, tt.wantPolls, api.requestCount(http.MethodGet, "/operations/operation-1"))
+				}
+			},
+		)
+	}
+}
+
+// TestCredentialsDriver verifies a driver is registered once for each base driver and credentials.
+func TestCredentialsDriver(t *testing.T) {
+	driver, err := credentialsDriver(sqlDriverPostgresIam, nil)
+	require.NoError(t, err)
+	assert.Equal(t, sqlDriverPostgresIam, driver)
+
+	driver, err = credentialsDriver(sqlDriverMySQLIam, []byte(testCredentialsJSON))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(driver, sqlDriverMySQLIam+"-"))
+	assert.Contains(t, sql.Drivers(), driver)
+	assert.NotContains(t, driver, "bootstrap")
+
+	again, err := credentialsDriver(sqlDriverMySQLIam, []byte(testCredentialsJSON))
+	require.NoError(t, err)
+	assert.Equal(t, driver, again)
+
+	otherJSON := strings.Replace(testCredentialsJSON, "bootstrap", "other", 1)
+	other, err := credentialsDriver(sqlDriverMySQLIam, []byte(otherJSON))
+	require.NoError(t, err)
+	assert.NotEqual(t, driver, other)
+
+	_, err = credentialsDriver("unknown", []byte(testCredentialsJSON))
+	require.ErrorContains(t, err, "unknown Cloud SQL driver")
+}
+
+// TestParseInstanceLabels verifies parsing of the labels input.
+func TestParseInstanceLabels(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		"empty":          {value: "", want: map[string]string{}},
+		"single":         {value: "env=prod", want: map[string]string{"env": "prod"}},
+		"several":        {value: " env=prod, team=data ,", want: map[string]string{"env": "prod", "team": "data"}},
+		"empty value":    {value: "team=", want: map[string]string{"team": ""}},
+		"missing value":  {value: "env", wantErr: true},
+		"missing key":    {value: "=prod", wantErr: true},
+		"malformed pair": {value: "env=prod,team", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				got, err := parseInstanceLabels(tt.value)
+				if tt.wantErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
@@ -22,6 +22,7 @@ func init() {
 }
 
 var _ blackstart.Module = &database{}
+var _ blackstart.Preflighter = &database{}
 var requiredCloudSQLDatabaseParameters = []string{inputInstance, inputDatabase}
 
 // NewCloudSqlDatabase creates a new instance of the Cloud SQL database module.
@@ -55,6 +56,8 @@ Cloud SQL Admin API.
 - This module does not manage database ownership or privileges.
 - Cloud SQL for SQL Server is not supported by this module.
 - '''charset''' and '''collation''' are only supported for MySQL. When unset, Cloud SQL API defaults are used.
+- Databases cannot be renamed. A changed '''database''' creates a new database, and the database of the
+  previous name is kept.
 `,
 		),
 		Requirements: []string{
@@ -72,6 +75,7 @@ Cloud SQL Admin API.
 				Description: "Database name to manage.",
 				Type:        reflect.TypeFor[string](),
 				Required:    true,
+				Immutable:   true,
 			},
 			inputProject: {
 				Description: "Google Cloud project ID. If not provided, the current project will be used.",
@@ -79,7 +83,12 @@ Cloud SQL Admin API.
 				Required:    false,
 			},
 			inputRegion: {
-				Description: "Google Cloud region for the Cloud SQL instance. Accepted for consistency with other Cloud SQL modules.",
+				Description: "Google Cloud region of the Cloud SQL instance. When provided, the instance must be in the region. If not provided, the region is inferred from the instance.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLabels: {
+				Description: "Comma-separated `key=value` user labels the Cloud SQL instance must have, such as `env=prod,team=data`. Guards against managing an unintended instance with the same name.",
 				Type:        reflect.TypeFor[string](),
 				Required:    false,
 			},
@@ -114,6 +123,7 @@ inputs:
   charset: utf8mb4
   collation: utf8mb4_0900_ai_ci`,
 		},
+		SerializationKey: instanceSerializationKey,
 	}
 }
 
@@ -137,6 +147,12 @@ func (d *database) Validate(op blackstart.Operation) error {
 	if err := validateOptionalStaticStringInput(op, inputCollation); err != nil {
 		return err
 	}
+	if err := validateOptionalStaticStringInput(op, inputRegion); err != nil {
+		return err
+	}
+	if err := validateLabels(op); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -146,6 +162,7 @@ func (d *database) Check(ctx blackstart.ModuleContext) (bool, error) {
 	if err := d.setup(ctx); err != nil {
 		return false, err
 	}
+	ctx.Resource(cloudSQLResourceId(d.target.project, d.target.instance, d.target.database))
 
 	exists, err := d.databaseExists(ctx)
 	if err != nil {
@@ -156,7 +173,7 @@ func (d *database) Check(ctx blackstart.ModuleContext) (bool, error) {
 	if ctx.DoesNotExist() {
 		res = !exists
 	} else {
-		res = exists
+		res = exists && !ctx.Tainted()
 	}
 
 	if res && !ctx.DoesNotExist() {
@@ -173,18 +190,31 @@ func (d *database) Set(ctx blackstart.ModuleContext) error {
 	if err := d.setup(ctx); err != nil {
 		return err
 	}
+	ctx.Resource(cloudSQLResourceId(d.target.project, d.target.instance, d.target.database))
 
 	if ctx.DoesNotExist() {
 		return d.deleteDatabase(ctx)
 	}
 
-	if err := d.createDatabase(ctx); err != nil {
+	// A tainted database is not replaced, since it holds data.
+	exists, err := d.databaseExists(ctx)
+	if err != nil {
 		return err
 	}
+	if !exists {
+		if err = d.createDatabase(ctx); err != nil {
+			return err
+		}
+	}
 
 	return ctx.Output(outputDatabase, d.target.database)
 }
 
+// Preflight verifies that the Cloud SQL Admin API is reachable and the target instance exists.
+func (d *database) Preflight(ctx blackstart.ModuleContext) error {
+	return d.setup(ctx)
+}
+
 // setup initializes the target configuration and SQL Admin service for the database module.
 func (d *database) setup(ctx blackstart.ModuleContext) error {
 	var err error
@@ -223,6 +253,10 @@ func (d *database) setup(ctx blackstart.ModuleContext) error {
 	if err != nil {
 		return err
 	}
+	d.target.labels, err = contextLabels(ctx)
+	if err != nil {
+		return err
+	}
 
 	d.charset, err = blackstart.ContextInputAs[string](ctx, inputCharset, false)
 	if err != nil {
@@ -234,15 +268,18 @@ func (d *database) setup(ctx blackstart.ModuleContext) error {
 	}
 
 	d.runtime = cloudSQLRuntimeOrDefault(d.runtime)
-	d.sqlService, err = d.runtime.newSQLAdminService(ctx)
+	d.sqlService, err = d.runtime.sqlAdminService(ctx, d.target.creds)
 	if err != nil {
 		return fmt.Errorf("failed to create SQL Admin service: %w", err)
 	}
 
-	instance, err := d.sqlService.Instances.Get(d.target.project, d.target.instance).Context(ctx).Do()
+	instance, err := getCloudSQLInstance(ctx, d.sqlService, d.target.project, d.target.instance)
 	if err != nil {
 		return fmt.Errorf("failed to get instance %s in project %s: %w", d.target.instance, d.target.project, err)
 	}
+	if err = d.target.matchInstance(ctx, d.sqlService, instance); err != nil {
+		return err
+	}
 	d.target.engine = instanceEngine(instance.DatabaseVersion)
 	d.target.databaseVersion = instance.DatabaseVersion
 	d.target.region = instance.Region
@@ -292,7 +329,11 @@ func (d *database) createDatabase(ctx context.Context) error {
 		db.Collation = d.collation
 	}
 
-	result, err := d.sqlService.Databases.Insert(d.target.project, d.target.instance, db).Context(ctx).Do()
+	result, err := retryOperationInProgress(
+		ctx, func() (*sqladmin.Operation, error) {
+			return d.sqlService.Databases.Insert(d.target.project, d.target.instance, db).Context(ctx).Do()
+		},
+	)
 	if err != nil {
 		return err
 	}
@@ -302,16 +343,20 @@ func (d *database) createDatabase(ctx context.Context) error {
 	if result.HTTPStatusCode < 200 || result.HTTPStatusCode >= 300 {
 		return fmt.Errorf("status code error while inserting database: %d", result.HTTPStatusCode)
 	}
-	return nil
+	return waitForOperation(ctx, d.sqlService, d.target.project, result)
 }
 
 // deleteDatabase deletes the target Cloud SQL database.
 func (d *database) deleteDatabase(ctx context.Context) error {
-	result, err := d.sqlService.Databases.Delete(
-		d.target.project,
-		d.target.instance,
-		d.target.database,
-	).Context(ctx).Do()
+	result, err := retryOperationInProgress(
+		ctx, func() (*sqladmin.Operation, error) {
+			return d.sqlService.Databases.Delete(
+				d.target.project,
+				d.target.instance,
+				d.target.database,
+			).Context(ctx).Do()
+		},
+	)
 	if err != nil {
 		return err
 	}
@@ -321,7 +366,7 @@ func (d *database) deleteDatabase(ctx context.Context) error {
 	if result.HTTPStatusCode < 200 || result.HTTPStatusCode >= 300 {
 		return fmt.Errorf("status code error while deleting database: %d", result.HTTPStatusCode)
 	}
-	return nil
+	return waitForOperation(ctx, d.sqlService, d.target.project, result)
 }
 
 // validateStaticStringInput validates a required static string input when it is statically known.
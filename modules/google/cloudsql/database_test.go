@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/sqladmin/v1"
 
 	"github.com/pezops/blackstart"
@@ -142,11 +143,30 @@ func TestDatabaseCheckWithFakeAdminAPI(t *testing.T) {
 				got, err := (&database{runtime: api.runtime(nil)}).Check(ctx)
 				require.NoError(t, err)
 				require.Equal(t, tt.want, got)
+				require.Equal(t, []string{"project:instance:app"}, blackstart.ContextResources(ctx))
 			},
 		)
 	}
 }
 
+// TestDatabaseContextSQLAdminAPI verifies that the SQLAdminAPI of the context replaces the runtime.
+func TestDatabaseContextSQLAdminAPI(t *testing.T) {
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	api.databases = []*sqladmin.Database{{Name: "app", Instance: "instance", Project: "project"}}
+	op := testCloudSQLDatabaseOperation("app")
+	ctx := blackstart.OpContext(context.WithValue(context.Background(), SQLAdminAPIKey, SQLAdminAPI(api)), &op)
+
+	failing := &cloudSQLRuntime{
+		newSQLAdminService: func(context.Context, *google.Credentials) (*sqladmin.Service, error) {
+			return nil, errors.New("runtime client used")
+		},
+	}
+	got, err := (&database{runtime: failing}).Check(ctx)
+	require.NoError(t, err)
+	require.True(t, got)
+	require.Contains(t, api.requests, "GET /v1/projects/project/instances/instance/databases/app")
+}
+
 // TestDatabaseSetWithFakeAdminAPI verifies database create and delete calls against the fake Admin API.
 func TestDatabaseSetWithFakeAdminAPI(t *testing.T) {
 	tests := map[string]struct {
@@ -202,6 +222,14 @@ func TestDatabaseSetWithFakeAdminAPI(t *testing.T) {
 				require.Empty(t, api.insertedDatabases[0].Collation)
 			},
 		},
+		"keeps existing tainted database": {
+			version:   "POSTGRES_17",
+			databases: []*sqladmin.Database{{Name: "app", Instance: "instance", Project: "project"}},
+			configure: func(op *blackstart.Operation) {
+				op.Tainted = true
+			},
+			wantDatabases: []*sqladmin.Database{{Name: "app", Instance: "instance", Project: "project"}},
+		},
 		"deletes existing database": {
 			version:       "POSTGRES_17",
 			databases:     []*sqladmin.Database{{Name: "app", Instance: "instance", Project: "project"}},
@@ -279,6 +307,9 @@ func TestDatabaseAPIFailures(t *testing.T) {
 				api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
 				api.databases = []*sqladmin.Database{{Name: "app", Instance: "instance", Project: "project"}}
 				api.fail[tt.method+" /v1/projects/project"+tt.pathSuffix] = http.StatusInternalServerError
+				if name == "database insert" {
+					api.databases = nil
+				}
 				op := testCloudSQLDatabaseOperation("app")
 				if name == "database delete" {
 					op.DoesNotExist = true
@@ -496,3 +527,14 @@ func TestDatabaseDeleteFailureIsNotCollision(t *testing.T) {
 	require.Error(t, err)
 	require.False(t, errors.Is(err, ErrMySQLUserCollision))
 }
+
+// TestDatabasePreflight verifies preflight reports setup failures without changing the instance.
+func TestDatabasePreflight(t *testing.T) {
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	op := testCloudSQLDatabaseOperation("app")
+	require.NoError(t, (&database{runtime: api.runtime(nil)}).Preflight(blackstart.OpContext(context.Background(), &op)))
+
+	api = newFakeCloudSQLAdmin(t, "SQLSERVER_2022_STANDARD")
+	err := (&database{runtime: api.runtime(nil)}).Preflight(blackstart.OpContext(context.Background(), &op))
+	require.ErrorContains(t, err, `the Cloud SQL engine "SQLSERVER" is not supported by google_cloudsql_database`)
+}
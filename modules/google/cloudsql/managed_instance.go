@@ -25,6 +25,7 @@ func init() {
 }
 
 var _ blackstart.Module = &managedInstance{}
+var _ blackstart.Preflighter = &managedInstance{}
 var _ io.Closer = &managedInstance{}
 var requiredCloudSqlManagedInstanceParameters = []string{inputInstance}
 
@@ -69,6 +70,8 @@ instance is then usable for further operations.
 **Notes**
 
 - This module does not create or delete the Cloud SQL instance, it only manages the IAM user access.
+- Instances reachable only through Private Service Connect are supported with the '''PSC''' connection type. A
+  custom DNS name for the instance can be set with '''dns_name'''.
 - The module uses a temporary built-in user to perform the role management operations. This user is
   created and deleted as needed.
 - When the module is set to not exist, the current workload identity is removed from the 
@@ -79,6 +82,10 @@ instance is then usable for further operations.
   of the target object. Otherwise, the Blackstart service account will need to be granted the same 
   permission '''WITH GRANT OPTION''' on the target object to be able to manage permissions for other users.
 - Cloud SQL for SQL Server does not support IAM authentication for database operations and is not supported by this module.
+- By default, the module uses the ambient Application Default Credentials. Set '''credentials''' to manage
+  an instance, such as one in another project, with other credentials. The Cloud SQL Admin API calls and the
+  database connections both use these credentials.
+- Read replicas do not accept user or role changes. By default, targeting a read replica is an error. Set '''replica_policy''' to '''FOLLOW_PRIMARY''' to manage the primary instance instead.
 - Cloud SQL for MySQL 5.6 is not supported because [IAM database authentication is not supported for MySQL 5.6](https://docs.cloud.google.com/sql/docs/mysql/iam-authentication#restrictions).
 - Cloud SQL for MySQL 5.7 IAM users are supported by '''google_cloudsql_user''', but managed-instance administration requires the [role support available in MySQL 8+](https://docs.cloud.google.com/sql/docs/mysql/users#mysql-8.0-user-privileges).
 `,
@@ -100,6 +107,16 @@ instance is then usable for further operations.
 				Type:        reflect.TypeFor[string](),
 				Required:    false,
 			},
+			inputRegion: {
+				Description: "Google Cloud region of the Cloud SQL instance. When provided, the instance must be in the region. If not provided, the region is inferred from the instance.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLabels: {
+				Description: "Comma-separated `key=value` user labels the Cloud SQL instance must have, such as `env=prod,team=data`. Guards against managing an unintended instance with the same name.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
 			inputDatabase: {
 				Description: "Database name to connect to and return in the managed connection. Defaults to `postgres` for PostgreSQL and no database for MySQL.",
 				Type:        reflect.TypeFor[string](),
@@ -111,16 +128,34 @@ instance is then usable for further operations.
 				Required:    false,
 			},
 			inputConnectionType: {
-				Description: "Type of connection to use. Must be one of: `PUBLIC_IP`, or `PRIVATE_IP`.",
+				Description: "Type of connection to use. Must be one of: `PUBLIC_IP`, `PRIVATE_IP`, or `PSC`.",
 				Type:        reflect.TypeFor[string](),
 				Required:    false,
 				Default:     "PRIVATE_IP",
 			},
+			inputDNSName: {
+				Description: "Custom DNS name used to connect to the instance instead of the instance connection name. The name must have a TXT record that resolves to the instance connection name.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputReplicaPolicy: {
+				Description: "Behavior when the instance is a read replica. `FAIL` returns an error and `FOLLOW_PRIMARY` manages the primary instance instead. Must be one of: `FAIL` or `FOLLOW_PRIMARY`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     replicaPolicyFail,
+			},
+			inputCredentials: {
+				Description: "Google Cloud credentials JSON, such as a service account key or workload identity federation configuration, used instead of the default credentials. The identity of the credentials is managed when `user` is not provided. When `project` is not provided, the project of the credentials is used.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Sensitive:   true,
+			},
 		},
 		Outputs: map[string]blackstart.OutputValue{
 			outputConnection: {
-				Description: "Database connection to the managed Cloud SQL instance authenticated as the managing user.",
-				Type:        reflect.TypeFor[*sql.DB](),
+				Description: "Database connection to the managed Cloud SQL instance authenticated as the managing " +
+					"user. It is not set when `doesNotExist` is true.",
+				Type: reflect.TypeFor[*sql.DB](),
 			},
 		},
 		Examples: map[string]string{
@@ -128,6 +163,13 @@ instance is then usable for further operations.
 module: google_cloudsql_managed_instance
 inputs:
   instance: my-cloudsql-instance`,
+			"Manage an instance in another project": `id: manage-other-instance
+module: google_cloudsql_managed_instance
+inputs:
+  instance: my-cloudsql-instance
+  credentials:
+    fromFile:
+      path: /var/run/secrets/other-project/credentials.json`,
 			"Manage instance and grant table privileges": `operations:
   - id: manage-instance
     module: google_cloudsql_managed_instance
@@ -189,15 +231,32 @@ func (m *managedInstance) Validate(op blackstart.Operation) error {
 		if err != nil {
 			return fmt.Errorf("invalid connection_type: %w", err)
 		}
-		if !slices.Contains([]string{"PUBLIC_IP", "PRIVATE_IP", ""}, strings.ToUpper(connectionType)) {
+		if !slices.Contains([]string{"PUBLIC_IP", "PRIVATE_IP", "PSC", ""}, strings.ToUpper(connectionType)) {
 			return fmt.Errorf(
-				"invalid connection_type: %s - must be one of: PUBLIC_IP, PRIVATE_IP",
+				"invalid connection_type: %s - must be one of: PUBLIC_IP, PRIVATE_IP, PSC",
 				connectionType,
 			)
 		}
 	}
 
-	return nil
+	if dn, ok := op.Inputs[inputDNSName]; ok && dn.IsStatic() {
+		if _, err := blackstart.InputAs[string](dn, false); err != nil {
+			return fmt.Errorf("invalid dns_name: %w", err)
+		}
+	}
+
+	if err := validateReplicaPolicy(op); err != nil {
+		return err
+	}
+	if rg, ok := op.Inputs[inputRegion]; ok && rg.IsStatic() {
+		if _, err := blackstart.InputAs[string](rg, false); err != nil {
+			return fmt.Errorf("invalid region: %w", err)
+		}
+	}
+	if err := validateLabels(op); err != nil {
+		return err
+	}
+	return validateCredentials(op)
 }
 
 // Check reports whether the current IAM identity has the requested managed-instance role.
@@ -250,7 +309,8 @@ func (m *managedInstance) Check(ctx blackstart.ModuleContext) (bool, error) {
 	return res, nil
 }
 
-// Set reconciles the current IAM identity's managed-instance role and connection output.
+// Set reconciles the current IAM identity's managed-instance role. Like Check, it outputs the
+// connection of the identity unless the operation ensures the role does not exist.
 func (m *managedInstance) Set(ctx blackstart.ModuleContext) error {
 	err := m.setup(ctx)
 	if err != nil {
@@ -300,6 +360,7 @@ func (m *managedInstance) Set(ctx blackstart.ModuleContext) error {
 		Name:   "temp-user",
 		Module: "google_cloudsql_user",
 	}
+	m.addCredentialsInput(mgmtUserOp.Inputs)
 	mgmtUserModule := user{runtime: m.runtime}
 	mgmtUserMctx := blackstart.OpContext(ctx, &mgmtUserOp)
 	mgmtUserExists, checkErr := mgmtUserModule.Check(mgmtUserMctx)
@@ -321,7 +382,21 @@ func (m *managedInstance) Set(ctx blackstart.ModuleContext) error {
 		return err
 	}
 
-	db, err := m.getConnection(ctx)
+	// The connection is only an output of a managed instance, as in Check, so that dependent
+	// operations do not use the connection of an identity that is no longer managing the instance.
+	if ctx.DoesNotExist() {
+		return nil
+	}
+
+	// IAM database authentication of a new user can fail until the user has propagated.
+	var db *sql.DB
+	err = m.waitForLogin(
+		ctx, iamUser, func() error {
+			var loginErr error
+			db, loginErr = m.getConnection(ctx)
+			return loginErr
+		},
+	)
 	if err != nil {
 		return fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -353,14 +428,24 @@ func (m *managedInstance) setManagedRole(ctx blackstart.ModuleContext, db *sql.D
 	}
 }
 
+// sqlAPIProvider returns the API provider of the queries to an instance of the database engine.
+func sqlAPIProvider(engine string) string {
+	if engine == "MYSQL" {
+		return blackstart.APIProviderMySQL
+	}
+	return blackstart.APIProviderPostgres
+}
+
 // setManagedRolePostgres grants or revokes PostgreSQL managed-instance privileges.
 func setManagedRolePostgres(ctx blackstart.ModuleContext, db *sql.DB, iamIdentity string) error {
 	if !ctx.DoesNotExist() {
+		blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 		if _, err := db.ExecContext(
 			ctx, fmt.Sprintf("GRANT cloudsqlsuperuser TO \"%v\" WITH ADMIN OPTION;", iamIdentity),
 		); err != nil {
 			return fmt.Errorf("failed to grant cloudsqlsuperuser role: %w", err)
 		}
+		blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 		if _, err := db.ExecContext(
 			ctx, fmt.Sprintf("ALTER ROLE \"%v\" WITH INHERIT CREATEROLE CREATEDB;", iamIdentity),
 		); err != nil {
@@ -368,6 +453,7 @@ func setManagedRolePostgres(ctx blackstart.ModuleContext, db *sql.DB, iamIdentit
 		}
 		return nil
 	}
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 	if _, err := db.ExecContext(ctx, fmt.Sprintf("REVOKE cloudsqlsuperuser FROM \"%v\";", iamIdentity)); err != nil {
 		return fmt.Errorf("failed to revoke cloudsqlsuperuser role: %w", err)
 	}
@@ -382,14 +468,17 @@ func setManagedRoleMySQL(ctx blackstart.ModuleContext, db *sql.DB, iamIdentity s
 	}
 	account := fmt.Sprintf("`%s`@`%%`", strings.ReplaceAll(username, "`", "``"))
 	if ctx.DoesNotExist() {
+		blackstart.RecordAPICall(ctx, blackstart.APIProviderMySQL)
 		if _, err = db.ExecContext(ctx, "REVOKE `cloudsqlsuperuser` FROM "+account); err != nil {
 			return fmt.Errorf("failed to revoke MySQL cloudsqlsuperuser role: %w", err)
 		}
 		return nil
 	}
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderMySQL)
 	if _, err = db.ExecContext(ctx, "GRANT `cloudsqlsuperuser` TO "+account+" WITH ADMIN OPTION"); err != nil {
 		return fmt.Errorf("failed to grant MySQL cloudsqlsuperuser role: %w", err)
 	}
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderMySQL)
 	if _, err = db.ExecContext(ctx, "SET DEFAULT ROLE ALL TO "+account); err != nil {
 		return fmt.Errorf("failed to set default MySQL cloudsqlsuperuser role: %w", err)
 	}
@@ -420,15 +509,38 @@ func (m *managedInstance) trackManagedConnection(db *sql.DB) {
 	m.managedConnections = append(m.managedConnections, db)
 }
 
+// Preflight verifies that the Cloud SQL Admin API is reachable and the target instance is
+// supported by the module. The database connection is not verified, since the IAM database user
+// may not be bootstrapped before the first run.
+func (m *managedInstance) Preflight(ctx blackstart.ModuleContext) error {
+	return m.setup(ctx)
+}
+
 // setup initializes the module by reading inputs, creating the target configuration and setting
 // up the SQL Admin service with the appropriate credentials.
 func (m *managedInstance) setup(ctx blackstart.ModuleContext) error {
 	m.target = &connectionConfig{}
+	credentials, err := contextCredentials(ctx)
+	if err != nil {
+		return err
+	}
+	if credentials != nil {
+		m.creds = credentials
+		m.target.creds = credentials
+		m.target.credentialsJSON = credentials.JSON
+	}
+
 	projectInput, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
 	if err != nil {
 		return err
 	}
 	m.target.project = projectInput
+	if m.target.project == "" && credentials != nil {
+		m.target.project, err = cloud.ProjectIdFromCredentials(credentials)
+		if err != nil {
+			return errors.Join(ErrProjectNotProvidedNotFound, err)
+		}
+	}
 	if m.target.project == "" {
 		var creds *google.Credentials
 		m.target.project, creds, err = cloud.CurrentProject(ctx)
@@ -462,8 +574,23 @@ func (m *managedInstance) setup(ctx blackstart.ModuleContext) error {
 	}
 	m.target.database = database
 
+	dnsName, err := blackstart.ContextInputAs[string](ctx, inputDNSName, false)
+	if err != nil {
+		return err
+	}
+	m.target.dnsName = strings.TrimSuffix(strings.TrimSpace(dnsName), ".")
+
+	m.target.region, err = blackstart.ContextInputAs[string](ctx, inputRegion, false)
+	if err != nil {
+		return err
+	}
+	m.target.labels, err = contextLabels(ctx)
+	if err != nil {
+		return err
+	}
+
 	m.runtime = cloudSQLRuntimeOrDefault(m.runtime)
-	m.sqlService, err = m.runtime.newSQLAdminService(ctx)
+	m.sqlService, err = m.runtime.sqlAdminService(ctx, m.target.creds)
 	if err != nil {
 		return fmt.Errorf("failed to create SQL Admin service: %w", err)
 	}
@@ -471,6 +598,20 @@ func (m *managedInstance) setup(ctx blackstart.ModuleContext) error {
 	if err != nil {
 		return err
 	}
+	if err = m.target.matchInstance(ctx, m.sqlService, instanceResource); err != nil {
+		return err
+	}
+	replicaPolicy, err := contextReplicaPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	m.target.project, instanceResource, err = resolvePrimaryInstance(
+		ctx, m.sqlService, m.target.project, instanceResource, replicaPolicy,
+	)
+	if err != nil {
+		return err
+	}
+	m.target.instance = instanceResource.Name
 	m.target.region = instanceResource.Region
 	m.target.identifier = fmt.Sprintf("%s:%s:%s", m.target.project, instanceResource.Region, m.target.instance)
 	m.target.engine = instanceEngine(instanceResource.DatabaseVersion)
@@ -523,7 +664,7 @@ func (m *managedInstance) setup(ctx blackstart.ModuleContext) error {
 // getConnection returns an active database connection to the target instance.
 func (m *managedInstance) getConnection(ctx blackstart.ModuleContext) (*sql.DB, error) {
 	var username string
-	dbConnIdentifier, err := m.target.connectionIdentifier(ctx)
+	dbConnIdentifier, err := m.target.dialName(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get connection identifier: %w", err)
 	}
@@ -555,6 +696,7 @@ func (m *managedInstance) getConnection(ctx blackstart.ModuleContext) (*sql.DB,
 	}
 
 	var result int
+	blackstart.RecordAPICall(ctx, sqlAPIProvider(m.target.engine))
 	err = db.QueryRowContext(ctx, "SELECT 1").Scan(&result)
 	if err != nil {
 		_ = db.Close()
@@ -573,14 +715,19 @@ func (m *managedInstance) getDriver(ctx blackstart.ModuleContext) (string, error
 	switch strings.ToUpper(driver) {
 	case "PUBLIC_IP":
 		if m.target.engine == "MYSQL" {
-			return sqlDriverMySQLIam, nil
+			return credentialsDriver(sqlDriverMySQLIam, m.target.credentialsJSON)
 		}
-		return sqlDriverPostgresIam, nil
+		return credentialsDriver(sqlDriverPostgresIam, m.target.credentialsJSON)
 	case "PRIVATE_IP", "":
 		if m.target.engine == "MYSQL" {
-			return sqlDriverMySQLIamPrivateIp, nil
+			return credentialsDriver(sqlDriverMySQLIamPrivateIp, m.target.credentialsJSON)
+		}
+		return credentialsDriver(sqlDriverPostgresIamPrivateIp, m.target.credentialsJSON)
+	case "PSC":
+		if m.target.engine == "MYSQL" {
+			return credentialsDriver(sqlDriverMySQLIamPsc, m.target.credentialsJSON)
 		}
-		return sqlDriverPostgresIamPrivateIp, nil
+		return credentialsDriver(sqlDriverPostgresIamPsc, m.target.credentialsJSON)
 	default:
 		return "", fmt.Errorf("invalid connection_type: %s", driver)
 	}
@@ -596,14 +743,19 @@ func (m *managedInstance) getBuiltinDriver(ctx blackstart.ModuleContext) (string
 	switch strings.ToUpper(driver) {
 	case "PUBLIC_IP":
 		if m.target.engine == "MYSQL" {
-			return sqlDriverMySQL, nil
+			return credentialsDriver(sqlDriverMySQL, m.target.credentialsJSON)
 		}
-		return sqlDriverPostgres, nil
+		return credentialsDriver(sqlDriverPostgres, m.target.credentialsJSON)
 	case "PRIVATE_IP", "":
 		if m.target.engine == "MYSQL" {
-			return sqlDriverMySQLPrivateIp, nil
+			return credentialsDriver(sqlDriverMySQLPrivateIp, m.target.credentialsJSON)
+		}
+		return credentialsDriver(sqlDriverPostgresPrivateIp, m.target.credentialsJSON)
+	case "PSC":
+		if m.target.engine == "MYSQL" {
+			return credentialsDriver(sqlDriverMySQLPsc, m.target.credentialsJSON)
 		}
-		return sqlDriverPostgresPrivateIp, nil
+		return credentialsDriver(sqlDriverPostgresPsc, m.target.credentialsJSON)
 	default:
 		return "", fmt.Errorf("invalid connection_type: %s", driver)
 	}
@@ -638,6 +790,7 @@ func (m *managedInstance) tempAdminDb(ctx blackstart.ModuleContext) (*sql.DB, fu
 		Name:   "temp-user",
 		Module: "google_cloudsql_user",
 	}
+	m.addCredentialsInput(tempUserOp.Inputs)
 	tempUserModule := user{runtime: m.runtime}
 	tempUserMctx := blackstart.OpContext(ctx, &tempUserOp)
 	err := tempUserModule.Set(tempUserMctx)
@@ -645,7 +798,8 @@ func (m *managedInstance) tempAdminDb(ctx blackstart.ModuleContext) (*sql.DB, fu
 		return nil, closer, err
 	}
 
-	tempInstanceIndentifier, err := tempUserModule.target.connectionIdentifier(ctx)
+	tempUserModule.target.dnsName = m.target.dnsName
+	tempInstanceIndentifier, err := tempUserModule.target.dialName(ctx)
 	if err != nil {
 		return nil, closer, fmt.Errorf("failed to get temporary connection identifier: %w", err)
 	}
@@ -676,12 +830,53 @@ func (m *managedInstance) tempAdminDb(ctx blackstart.ModuleContext) (*sql.DB, fu
 		return tempDb.Close()
 	}
 
+	err = m.waitForLogin(
+		ctx, adminUser, func() error {
+			return tempDb.PingContext(ctx)
+		},
+	)
+	if err != nil {
+		_ = closer()
+		return nil, func() error { return nil }, fmt.Errorf("failed to log in as temporary user: %w", err)
+	}
+
 	return tempDb, closer, nil
 }
 
+// waitForLogin calls login until it succeeds. A new Cloud SQL user may fail to log in until the
+// user, and the IAM bindings of IAM users, have propagated, so failed logins are retried until the
+// propagation timeout. Other errors are returned without retrying.
+func (m *managedInstance) waitForLogin(ctx blackstart.ModuleContext, user string, login func() error) error {
+	var loginErr error
+	err := blackstart.WaitForPropagation(
+		ctx, "database login of "+user, func() (bool, error) {
+			loginErr = login()
+			if loginErr == nil {
+				return true, nil
+			}
+			if isManagedInstanceBootstrapConnectionError(loginErr, m.target.engine) {
+				return false, nil
+			}
+			return false, loginErr
+		},
+	)
+	if errors.Is(err, blackstart.ErrNotPropagated) {
+		return fmt.Errorf("%w: %w", err, loginErr)
+	}
+	return err
+}
+
+// addCredentialsInput adds the credentials of the credentials input to the inputs of a user
+// operation, so users are managed with the same credentials as the instance.
+func (m *managedInstance) addCredentialsInput(inputs map[string]blackstart.Input) {
+	if len(m.target.credentialsJSON) > 0 {
+		inputs[inputCredentials] = blackstart.NewInputFromValue(string(m.target.credentialsJSON))
+	}
+}
+
 // getInstance returns the target Cloud SQL instance metadata.
 func (m *managedInstance) getInstance(ctx blackstart.ModuleContext) (*sqladmin.DatabaseInstance, error) {
-	instance, err := m.sqlService.Instances.Get(m.target.project, m.target.instance).Context(ctx).Do()
+	instance, err := getCloudSQLInstance(ctx, m.sqlService, m.target.project, m.target.instance)
 	if err != nil {
 		if apiErr, ok := errors.AsType[*googleapi.Error](err); ok && apiErr.Code == 404 {
 			return nil, fmt.Errorf("instance %s does not exist in project %s", m.target.instance, m.target.project)
@@ -695,10 +890,13 @@ func (m *managedInstance) getInstance(ctx blackstart.ModuleContext) (*sqladmin.D
 // instance.
 func checkIfSuperuser(ctx context.Context, db *sql.DB, engine ...string) (bool, error) {
 	query := checkPostgresCloudSqlSuperuserRoleQuery
+	provider := blackstart.APIProviderPostgres
 	if len(engine) > 0 && engine[0] == "MYSQL" {
 		query = checkMySQLCloudSqlSuperuserRoleQuery
+		provider = blackstart.APIProviderMySQL
 	}
 	var exists int
+	blackstart.RecordAPICall(ctx, provider)
 	err := db.QueryRowContext(ctx, query).Scan(&exists)
 	if errors.Is(err, sql.ErrNoRows) {
 		return false, nil
@@ -525,6 +525,11 @@ func TestManagedInstanceMySQLDrivers(t *testing.T) {
 			iamDriver:      sqlDriverMySQLIamPrivateIp,
 			builtinDriver:  sqlDriverMySQLPrivateIp,
 		},
+		"private service connect": {
+			connectionType: "PSC",
+			iamDriver:      sqlDriverMySQLIamPsc,
+			builtinDriver:  sqlDriverMySQLPsc,
+		},
 	}
 
 	for name, tt := range tests {
@@ -551,6 +556,88 @@ func TestManagedInstanceMySQLDrivers(t *testing.T) {
 	}
 }
 
+// TestManagedInstancePostgresPSCDrivers verifies PostgreSQL drivers for Private Service Connect.
+func TestManagedInstancePostgresPSCDrivers(t *testing.T) {
+	op := blackstart.Operation{
+		Inputs: map[string]blackstart.Input{
+			inputConnectionType: blackstart.NewInputFromValue("psc"),
+		},
+		Module: "google_cloudsql_managed_instance",
+	}
+	ctx := blackstart.OpContext(context.Background(), &op)
+	m := managedInstance{target: &connectionConfig{engine: "POSTGRES"}}
+
+	iamDriver, err := m.getDriver(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, sqlDriverPostgresIamPsc, iamDriver)
+
+	builtinDriver, err := m.getBuiltinDriver(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, sqlDriverPostgresPsc, builtinDriver)
+}
+
+// TestManagedInstanceCheckWithDNSName verifies a custom DNS name replaces the connection
+// identifier when connecting to the instance.
+func TestManagedInstanceCheckWithDNSName(t *testing.T) {
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	opener := newQueuedDBOpener(t)
+	_, mock := opener.expect(
+		sqlDriverPostgresIamPsc,
+		cloudsqlPostgresIamDsn("prod-db.example.internal", "postgres", "person@example.com"),
+	)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1")).WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(checkPostgresCloudSqlSuperuserRoleQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+	mock.ExpectClose()
+
+	op := testManagedInstanceOperation("person@example.com")
+	op.Inputs[inputConnectionType] = blackstart.NewInputFromValue("PSC")
+	op.Inputs[inputDNSName] = blackstart.NewInputFromValue("prod-db.example.internal.")
+	ctx := blackstart.OpContext(context.Background(), &op)
+	module := &managedInstance{
+		creds:   &google.Credentials{ProjectID: "project"},
+		runtime: api.runtime(opener.open),
+	}
+	got, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, got)
+	require.NoError(t, module.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+	opener.verify()
+}
+
+// TestManagedInstanceCheckWithCredentials verifies the credentials input replaces the default
+// credentials for the project, the managed user, and the database connection.
+func TestManagedInstanceCheckWithCredentials(t *testing.T) {
+	driver, err := credentialsDriver(sqlDriverPostgresIam, []byte(testCredentialsJSON))
+	require.NoError(t, err)
+
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	opener := newQueuedDBOpener(t)
+	_, mock := opener.expect(
+		driver,
+		cloudsqlPostgresIamDsn("project:us-central1:instance", "postgres", "bootstrap@project.iam"),
+	)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT 1")).WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(checkPostgresCloudSqlSuperuserRoleQuery)).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(1))
+	mock.ExpectClose()
+
+	op := testManagedInstanceOperation("")
+	delete(op.Inputs, inputProject)
+	delete(op.Inputs, inputUser)
+	op.Inputs[inputCredentials] = blackstart.NewInputFromValue(testCredentialsJSON)
+	ctx := blackstart.OpContext(context.Background(), &op)
+	module := &managedInstance{runtime: api.runtime(opener.open)}
+	got, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, got)
+	require.Equal(t, "project", module.target.project)
+	require.NoError(t, module.Close())
+	require.NoError(t, mock.ExpectationsWereMet())
+	opener.verify()
+}
+
 // TestManagedInstanceValidate verifies static input validation and permits runtime inputs.
 func TestManagedInstanceValidate(t *testing.T) {
 	tests := map[string]struct {
@@ -592,7 +679,7 @@ func TestManagedInstanceValidate(t *testing.T) {
 			configure: func(op *blackstart.Operation) {
 				op.Inputs[inputConnectionType] = blackstart.NewInputFromValue("DIRECT")
 			},
-			wantErr: "invalid connection_type: DIRECT - must be one of: PUBLIC_IP, PRIVATE_IP",
+			wantErr: "invalid connection_type: DIRECT - must be one of: PUBLIC_IP, PRIVATE_IP, PSC",
 		},
 		"invalid connection type type": {
 			configure: func(op *blackstart.Operation) {
@@ -600,6 +687,57 @@ func TestManagedInstanceValidate(t *testing.T) {
 			},
 			wantErr: "invalid connection_type:",
 		},
+		"valid private service connect": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputConnectionType] = blackstart.NewInputFromValue("PSC")
+				op.Inputs[inputDNSName] = blackstart.NewInputFromValue("prod-db.example.internal")
+			},
+		},
+		"invalid dns name type": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputDNSName] = blackstart.NewInputFromValue(map[string]string{"name": "db"})
+			},
+			wantErr: "invalid dns_name:",
+		},
+		"valid lowercase replica policy": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputReplicaPolicy] = blackstart.NewInputFromValue("follow_primary")
+			},
+		},
+		"invalid replica policy value": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputReplicaPolicy] = blackstart.NewInputFromValue("IGNORE")
+			},
+			wantErr: "invalid replica_policy: IGNORE - must be one of: FAIL, FOLLOW_PRIMARY",
+		},
+		"valid labels": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputRegion] = blackstart.NewInputFromValue("us-central1")
+				op.Inputs[inputLabels] = blackstart.NewInputFromValue("env=prod,team=data")
+			},
+		},
+		"invalid labels": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputLabels] = blackstart.NewInputFromValue("env")
+			},
+			wantErr: `invalid labels: "env" must be in the form key=value`,
+		},
+		"valid credentials": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputCredentials] = blackstart.NewInputFromValue(testCredentialsJSON)
+			},
+		},
+		"runtime credentials skips static validation": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputCredentials] = blackstart.NewInputFromDep("create-key", "key")
+			},
+		},
+		"invalid credentials": {
+			configure: func(op *blackstart.Operation) {
+				op.Inputs[inputCredentials] = blackstart.NewInputFromValue(`{"type": "unknown"}`)
+			},
+			wantErr: "invalid credentials: unsupported credentials type",
+		},
 	}
 
 	for name, tt := range tests {
@@ -680,6 +818,8 @@ func TestManagedInstanceCheckWithMocks(t *testing.T) {
 				got, err := module.Check(ctx)
 				require.NoError(t, err)
 				require.Equal(t, tt.want, got)
+				_, hasConnection := blackstart.ContextOutputs(ctx)[outputConnection]
+				require.Equal(t, tt.want && !tt.doesNotExist, hasConnection)
 				require.NoError(t, module.Close())
 				require.NoError(t, mock.ExpectationsWereMet())
 				opener.verify()
@@ -798,6 +938,7 @@ func TestManagedInstanceSetMySQLWithMocks(t *testing.T) {
 		runtime: api.runtime(opener.open),
 	}
 	require.NoError(t, module.Set(ctx))
+	require.Contains(t, blackstart.ContextOutputs(ctx), outputConnection)
 	require.NoError(t, module.Close())
 	require.NoError(t, tempMock.ExpectationsWereMet())
 	require.NoError(t, managedMock.ExpectationsWereMet())
@@ -810,6 +951,45 @@ func TestManagedInstanceSetMySQLWithMocks(t *testing.T) {
 	require.Equal(t, "person@example.com", api.users[0].IamEmail)
 }
 
+// TestManagedInstanceSetWaitsForIAMLogin verifies failed IAM logins of a new management user are
+// retried until the user has propagated.
+func TestManagedInstanceSetWaitsForIAMLogin(t *testing.T) {
+	api := newFakeCloudSQLAdmin(t, "MYSQL_8_4")
+	opener := newQueuedDBOpener(t)
+	_, tempMock := opener.expectContaining(
+		sqlDriverMySQL,
+		"blackstart:",
+		"@cloudsql-mysql(project:us-central1:instance)/mysql",
+	)
+	tempMock.ExpectExec(regexp.QuoteMeta("GRANT `cloudsqlsuperuser` TO `person`@`%` WITH ADMIN OPTION")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tempMock.ExpectExec(regexp.QuoteMeta("SET DEFAULT ROLE ALL TO `person`@`%`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	tempMock.ExpectClose()
+
+	driver, dsn, _ := expectedManagedConnection("MYSQL_8_4", "person@example.com")
+	_, deniedMock := opener.expect(driver, dsn)
+	deniedMock.ExpectQuery(regexp.QuoteMeta("SELECT 1")).
+		WillReturnError(&gomysql.MySQLError{Number: 1045, Message: "access denied"})
+	deniedMock.ExpectClose()
+	_, managedMock := opener.expect(driver, dsn)
+	managedMock.ExpectQuery(regexp.QuoteMeta("SELECT 1")).WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(1))
+	managedMock.ExpectClose()
+
+	op := testManagedInstanceOperation("person@example.com")
+	ctx := blackstart.OpContext(context.Background(), &op)
+	module := &managedInstance{
+		creds:   &google.Credentials{ProjectID: "project"},
+		runtime: api.runtime(opener.open),
+	}
+	require.NoError(t, module.Set(ctx))
+	require.NoError(t, module.Close())
+	require.NoError(t, tempMock.ExpectationsWereMet())
+	require.NoError(t, deniedMock.ExpectationsWereMet())
+	require.NoError(t, managedMock.ExpectationsWereMet())
+	opener.verify()
+}
+
 // TestManagedInstanceSetDoesNotExistRevokesWithoutDeletingIAMUser verifies unmanagement behavior.
 func TestManagedInstanceSetDoesNotExistRevokesWithoutDeletingIAMUser(t *testing.T) {
 	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
@@ -824,11 +1004,6 @@ func TestManagedInstanceSetDoesNotExistRevokesWithoutDeletingIAMUser(t *testing.
 		WillReturnResult(sqlmock.NewResult(0, 0))
 	tempMock.ExpectClose()
 
-	driver, dsn, _ := expectedManagedConnection("POSTGRES_17", "person@example.com")
-	_, managedMock := opener.expect(driver, dsn)
-	managedMock.ExpectQuery(regexp.QuoteMeta("SELECT 1")).WillReturnRows(sqlmock.NewRows([]string{"result"}).AddRow(1))
-	managedMock.ExpectClose()
-
 	op := testManagedInstanceOperation("person@example.com")
 	op.DoesNotExist = true
 	ctx := blackstart.OpContext(context.Background(), &op)
@@ -837,9 +1012,11 @@ func TestManagedInstanceSetDoesNotExistRevokesWithoutDeletingIAMUser(t *testing.
 		runtime: api.runtime(opener.open),
 	}
 	require.NoError(t, module.Set(ctx))
+	// As in Check, the connection is not an output when the role does not exist.
+	require.Empty(t, blackstart.ContextOutputs(ctx))
 	require.NoError(t, module.Close())
 	require.NoError(t, tempMock.ExpectationsWereMet())
-	require.NoError(t, managedMock.ExpectationsWereMet())
+	opener.verify()
 	require.Len(t, api.users, 1)
 	require.Equal(t, "person@example.com", api.users[0].Name)
 }
@@ -879,6 +1056,7 @@ func TestManagedInstanceSetupFailures(t *testing.T) {
 		version        string
 		disableIAM     bool
 		instanceStatus int
+		replicaOf      string
 		wantErr        string
 	}{
 		"unsupported mysql": {
@@ -904,6 +1082,11 @@ func TestManagedInstanceSetupFailures(t *testing.T) {
 			instanceStatus: 404,
 			wantErr:        "instance instance does not exist in project project",
 		},
+		"read replica": {
+			version:   "POSTGRES_17",
+			replicaOf: "project:primary",
+			wantErr:   "instance instance in project project is a read replica of project:primary",
+		},
 	}
 	for name, tt := range tests {
 		t.Run(
@@ -915,6 +1098,10 @@ func TestManagedInstanceSetupFailures(t *testing.T) {
 				if tt.instanceStatus != 0 {
 					api.fail["GET /v1/projects/project/instances/instance"] = tt.instanceStatus
 				}
+				if tt.replicaOf != "" {
+					api.instance.InstanceType = "READ_REPLICA_INSTANCE"
+					api.instance.MasterInstanceName = tt.replicaOf
+				}
 				op := testManagedInstanceOperation("person@example.com")
 				ctx := blackstart.OpContext(context.Background(), &op)
 				module := &managedInstance{
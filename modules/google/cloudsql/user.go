@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"slices"
 	"strings"
 
 	"github.com/pezops/blackstart/util"
 	"golang.org/x/oauth2/google"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/sqladmin/v1"
 
 	"github.com/pezops/blackstart"
@@ -23,6 +25,8 @@ func init() {
 }
 
 var _ blackstart.Module = &user{}
+var _ blackstart.Preflighter = &user{}
+var _ blackstart.BatchChecker = &user{}
 var requiredUserParameters = []string{inputInstance, inputUser, inputUserType}
 
 // ErrMySQLUserCollision indicates an IAM user conflicts with an existing MySQL local username.
@@ -61,6 +65,8 @@ Ensures that a Cloud SQL user exists with the specified parameters. In alignment
 - Cloud SQL for MySQL 5.7+ IAM users are supported.
 - Cloud SQL for MySQL stores IAM database usernames as the lowercase portion before '''@'''. IAM identities with the same local part cannot coexist on one MySQL instance.
 - A built-in MySQL user or different IAM user type with the same local database username is reported as a conflict instead of being replaced.
+- By default, the Cloud SQL Admin API is called with the ambient Application Default Credentials. Set '''credentials''' to manage users in projects the default credentials cannot access.
+- Users are replicated from the primary instance and cannot be managed on read replicas. By default, targeting a read replica is an error. Set '''replica_policy''' to '''FOLLOW_PRIMARY''' to manage the user on the primary instance instead.
 `,
 		),
 		Requirements: []string{
@@ -82,7 +88,12 @@ Ensures that a Cloud SQL user exists with the specified parameters. In alignment
 				Required:    false,
 			},
 			inputRegion: {
-				Description: "Google Cloud region for the Cloud SQL instance. If not provided, the region will be inferred from the instance ID.",
+				Description: "Google Cloud region of the Cloud SQL instance. When provided, the instance must be in the region. If not provided, the region is inferred from the instance.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLabels: {
+				Description: "Comma-separated `key=value` user labels the Cloud SQL instance must have, such as `env=prod,team=data`. Guards against managing an unintended instance with the same name.",
 				Type:        reflect.TypeFor[string](),
 				Required:    false,
 			},
@@ -90,11 +101,25 @@ Ensures that a Cloud SQL user exists with the specified parameters. In alignment
 				Description: "Username for the Cloud SQL user.",
 				Type:        reflect.TypeFor[string](),
 				Required:    true,
+				Immutable:   true,
 			},
 			inputUserType: {
 				Description: "Type of the user to create. Must be one of: `CLOUD_IAM_USER`, `CLOUD_IAM_SERVICE_ACCOUNT`.",
 				Type:        reflect.TypeFor[string](),
 				Required:    true,
+				Immutable:   true,
+			},
+			inputReplicaPolicy: {
+				Description: "Behavior when the instance is a read replica. `FAIL` returns an error and `FOLLOW_PRIMARY` manages the user on the primary instance instead. Must be one of: `FAIL` or `FOLLOW_PRIMARY`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     replicaPolicyFail,
+			},
+			inputCredentials: {
+				Description: "Google Cloud credentials JSON, such as a service account key or workload identity federation configuration, used instead of the default credentials. When `project` is not provided, the project of the credentials is used.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Sensitive:   true,
 			},
 		},
 		Outputs: map[string]blackstart.OutputValue{
@@ -110,7 +135,17 @@ inputs:
   instance: my-cloudsql-instance
   user: my-iam-user@example.com
   user_type: CLOUD_IAM_USER`,
+			"Create a user in another project": `id: create-app-user
+module: google_cloudsql_user
+inputs:
+  instance: my-cloudsql-instance
+  user: app@other-project.iam.gserviceaccount.com
+  user_type: CLOUD_IAM_SERVICE_ACCOUNT
+  credentials:
+    fromFile:
+      path: /var/run/secrets/other-project/credentials.json`,
 		},
+		SerializationKey: instanceSerializationKey,
 	}
 }
 
@@ -122,6 +157,16 @@ func (c *user) Validate(op blackstart.Operation) error {
 		}
 	}
 
+	if err := validateReplicaPolicy(op); err != nil {
+		return err
+	}
+	if err := validateCredentials(op); err != nil {
+		return err
+	}
+	if err := validateLabels(op); err != nil {
+		return err
+	}
+
 	userTypeInput := op.Inputs[inputUserType]
 	if !userTypeInput.IsStatic() {
 		return nil
@@ -151,30 +196,71 @@ func (c *user) Check(ctx blackstart.ModuleContext) (bool, error) {
 		return false, err
 	}
 
-	// List users for the given instance
-	usersList, err := c.sqlService.Users.List(c.target.project, c.target.instance).Context(ctx).Do()
+	u, err := c.user(ctx)
 	if err != nil {
-		return false, fmt.Errorf("failed to list users: %w", err)
+		return false, err
+	}
+	ctx.Resource(cloudSQLResourceId(c.target.project, c.target.instance, u.Name))
+
+	existing, err := c.lookupUser(ctx, u)
+	if err != nil {
+		return false, err
+	}
+	return c.checkUser(ctx, u, existing)
+}
+
+// CheckMany reports whether the target Cloud SQL users of the contexts are in the requested state.
+// The users of each instance are listed once for all of the contexts, instead of reading each user.
+func (c *user) CheckMany(ctxs []blackstart.ModuleContext) ([]bool, error) {
+	results := make([]bool, len(ctxs))
+	instanceUsers := make(map[string][]*sqladmin.User)
+	for i, ctx := range ctxs {
+		if err := c.setup(ctx); err != nil {
+			return nil, err
+		}
+		u, err := c.user(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ctx.Resource(cloudSQLResourceId(c.target.project, c.target.instance, u.Name))
+
+		key := c.target.project + ":" + c.target.instance
+		users, listed := instanceUsers[key]
+		if !listed {
+			usersList, listErr := c.sqlService.Users.List(c.target.project, c.target.instance).Context(ctx).Do()
+			if listErr != nil {
+				return nil, fmt.Errorf("failed to list users: %w", listErr)
+			}
+			users = usersList.Items
+			instanceUsers[key] = users
+		}
+		name, err := c.lookupName(u)
+		if err != nil {
+			return nil, err
+		}
+		if results[i], err = c.checkUser(ctx, u, c.findUser(users, name, u.Host)); err != nil {
+			return nil, err
+		}
 	}
+	return results, nil
+}
 
+// checkUser reports whether the existing Cloud SQL user, or nil if it does not exist, is in the
+// requested state, and outputs the database username when it is.
+func (c *user) checkUser(ctx blackstart.ModuleContext, u, existing *sqladmin.User) (bool, error) {
 	if ctx.Tainted() {
 		return false, nil
 	}
 
-	u, err := c.user(ctx)
-	if err != nil {
-		return false, err
-	}
-	if err = validateMySQLUserCollision(usersList, u.Name, c.target.userType, c.target.engine); err != nil {
+	if err := validateMySQLUserCollision(existing, u.Name, c.target.userType, c.target.engine); err != nil {
 		return false, err
 	}
 
 	var res bool
 	if ctx.DoesNotExist() {
-		res = !cloudSqlUserExists(usersList, u.Name, c.target.engine)
-
+		res = existing == nil
 	} else {
-		res = cloudSqlUserIsCorrect(usersList, u.Name, c.target.userType, c.target.engine)
+		res = cloudSqlUserIsCorrect(existing, c.target.userType)
 	}
 
 	if res && !ctx.DoesNotExist() {
@@ -182,13 +268,11 @@ func (c *user) Check(ctx blackstart.ModuleContext) (bool, error) {
 		if outputErr != nil {
 			return false, outputErr
 		}
-		err = ctx.Output(outputUser, outputName)
-		if err != nil {
+		if err := ctx.Output(outputUser, outputName); err != nil {
 			return false, err
 		}
 	}
 	return res, nil
-
 }
 
 // Set reconciles the target Cloud SQL user to the requested state.
@@ -210,6 +294,7 @@ func (c *user) Set(ctx blackstart.ModuleContext) error {
 	if err != nil {
 		return err
 	}
+	ctx.Resource(cloudSQLResourceId(c.target.project, c.target.instance, u.Name))
 
 	outputName, err := c.databaseUsername(u.Name)
 	if err != nil {
@@ -229,8 +314,23 @@ func (c *user) Set(ctx blackstart.ModuleContext) error {
 	if ctx.Tainted() {
 		_ = c.deleteUser(ctx, u)
 	}
-	return c.createUser(ctx, u)
+	if err = c.createUser(ctx, u); err != nil {
+		return err
+	}
+
+	// The Admin API may not return a new user right away, so dependent operations wait for it.
+	return blackstart.WaitForPropagation(
+		ctx, fmt.Sprintf("Cloud SQL user %s", u.Name), func() (bool, error) {
+			existing, lookupErr := c.lookupUser(ctx, u)
+			return existing != nil, lookupErr
+		},
+	)
+}
 
+// Preflight verifies that the Cloud SQL Admin API is reachable and the target instance is
+// supported by the module.
+func (c *user) Preflight(ctx blackstart.ModuleContext) error {
+	return c.setup(ctx)
 }
 
 // setup initializes the target connectionConfig and sqladmin.Service for the user module.
@@ -244,14 +344,26 @@ func (c *user) setup(mctx blackstart.ModuleContext) error {
 
 	// Create a new SQL Admin Service
 	c.runtime = cloudSQLRuntimeOrDefault(c.runtime)
-	c.sqlService, err = c.runtime.newSQLAdminService(mctx)
+	c.sqlService, err = c.runtime.sqlAdminService(mctx, c.target.creds)
 	if err != nil {
 		return fmt.Errorf("failed to create SQL Admin service: %w", err)
 	}
-	instance, err := c.sqlService.Instances.Get(c.target.project, c.target.instance).Context(mctx).Do()
+	instance, err := getCloudSQLInstance(mctx, c.sqlService, c.target.project, c.target.instance)
 	if err != nil {
 		return fmt.Errorf("failed to get instance %s in project %s: %w", c.target.instance, c.target.project, err)
 	}
+	if err = c.target.matchInstance(mctx, c.sqlService, instance); err != nil {
+		return err
+	}
+	replicaPolicy, err := contextReplicaPolicy(mctx)
+	if err != nil {
+		return err
+	}
+	c.target.project, instance, err = resolvePrimaryInstance(mctx, c.sqlService, c.target.project, instance, replicaPolicy)
+	if err != nil {
+		return err
+	}
+	c.target.instance = instance.Name
 	c.target.engine = instanceEngine(instance.DatabaseVersion)
 	c.target.databaseVersion = instance.DatabaseVersion
 	c.target.region = instance.Region
@@ -357,14 +469,14 @@ func normalizeCloudSQLServiceAccountUsername(username, project string) (string,
 func (c *user) createUser(ctx context.Context, user *sqladmin.User) error {
 	// Check if the user already exists. If we are being called we need to delete the user first
 	// (if they exist) because the check has failed.
-	userList, err := c.sqlService.Users.List(c.target.project, c.target.instance).Context(ctx).Do()
+	existing, err := c.lookupUser(ctx, user)
 	if err != nil {
 		return err
 	}
-	if err = validateMySQLUserCollision(userList, user.Name, c.target.userType, c.target.engine); err != nil {
+	if err = validateMySQLUserCollision(existing, user.Name, c.target.userType, c.target.engine); err != nil {
 		return err
 	}
-	if cloudSqlUserExists(userList, c.target.user, c.target.engine) {
+	if existing != nil {
 		err = c.deleteUser(ctx, user)
 		if err != nil {
 			return err
@@ -372,8 +484,11 @@ func (c *user) createUser(ctx context.Context, user *sqladmin.User) error {
 	}
 
 	// Insert the user
-	insertCall := c.sqlService.Users.Insert(c.target.project, c.target.instance, user)
-	result, err := insertCall.Context(ctx).Do()
+	result, err := retryOperationInProgress(
+		ctx, func() (*sqladmin.Operation, error) {
+			return c.sqlService.Users.Insert(c.target.project, c.target.instance, user).Context(ctx).Do()
+		},
+	)
 
 	if err != nil {
 		return err
@@ -387,18 +502,22 @@ func (c *user) createUser(ctx context.Context, user *sqladmin.User) error {
 		return fmt.Errorf("status code error while inserting user: %d", result.HTTPStatusCode)
 	}
 
-	return nil
+	return waitForOperation(ctx, c.sqlService, c.target.project, result)
 }
 
 // deleteUser deletes the user from Cloud SQL.
 func (c *user) deleteUser(ctx context.Context, user *sqladmin.User) error {
 	// Delete the user
-	deleteCall := c.sqlService.Users.Delete(c.target.project, c.target.instance)
-	deleteCall.Name(user.Name)
-	if c.target.engine == "MYSQL" && c.target.userType == userBuiltIn {
-		deleteCall.Host(user.Host)
-	}
-	result, err := deleteCall.Context(ctx).Do()
+	result, err := retryOperationInProgress(
+		ctx, func() (*sqladmin.Operation, error) {
+			deleteCall := c.sqlService.Users.Delete(c.target.project, c.target.instance)
+			deleteCall.Name(user.Name)
+			if c.target.engine == "MYSQL" && c.target.userType == userBuiltIn {
+				deleteCall.Host(user.Host)
+			}
+			return deleteCall.Context(ctx).Do()
+		},
+	)
 
 	if err != nil {
 		return err
@@ -412,79 +531,100 @@ func (c *user) deleteUser(ctx context.Context, user *sqladmin.User) error {
 		return fmt.Errorf("status code error while deleting user: %d", result.HTTPStatusCode)
 	}
 
-	return nil
+	return waitForOperation(ctx, c.sqlService, c.target.project, result)
 }
 
-// cloudSqlUserIsCorrect checks if the target user is in the list of users, and if it is the
-// correct type.
-func cloudSqlUserIsCorrect(
-	usersList *sqladmin.UsersListResponse, targetUser string, targetUserType string, engine ...string,
-) bool {
-	isBuiltIn := targetUserType == userBuiltIn
-	if isBuiltIn {
-		// The API returns a blank string for built-in users
-		targetUserType = ""
+// lookupUser returns the existing Cloud SQL user with the name of the user, or nil if it does not
+// exist. MySQL IAM users are looked up by the local database username Cloud SQL stores them under.
+// The user is read with Users.Get, and the users of the instance are listed instead when the API
+// rejects the request as invalid, such as for names it cannot address.
+func (c *user) lookupUser(ctx context.Context, user *sqladmin.User) (*sqladmin.User, error) {
+	name, err := c.lookupName(user)
+	if err != nil {
+		return nil, err
 	}
 
-	targetName := targetUser
-	if len(engine) > 0 && engine[0] == "MYSQL" && !isBuiltIn {
-		var err error
-		targetName, err = mysqlIamUser(targetUser)
-		if err != nil {
-			return false
-		}
+	getCall := c.sqlService.Users.Get(c.target.project, c.target.instance, name)
+	if c.target.engine == "MYSQL" && user.Host != "" {
+		getCall.Host(user.Host)
 	}
-	for _, u := range usersList.Items {
-		if u.Name == targetName {
-			return u.Type == targetUserType
-		}
+	existing, err := getCall.Context(ctx).Do()
+	if err == nil {
+		return existing, nil
+	}
+	apiErr, ok := errors.AsType[*googleapi.Error](err)
+	if ok && apiErr.Code == http.StatusNotFound {
+		return nil, nil
+	}
+	if !ok || apiErr.Code != http.StatusBadRequest {
+		return nil, fmt.Errorf("failed to get user %s: %w", name, err)
+	}
+
+	usersList, err := c.sqlService.Users.List(c.target.project, c.target.instance).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
 	}
-	return false
+	return c.findUser(usersList.Items, name, user.Host), nil
 }
 
-// cloudSqlUserExists checks if the connectionConfig user is in the list of users.
-func cloudSqlUserExists(usersList *sqladmin.UsersListResponse, targetUser string, engine ...string) bool {
-	if usersList == nil {
-		return false
+// lookupName returns the name Cloud SQL stores the user under. MySQL IAM users are stored under
+// their local database username.
+func (c *user) lookupName(user *sqladmin.User) (string, error) {
+	if c.target.engine == "MYSQL" && c.target.userType != userBuiltIn {
+		return mysqlIamUser(user.Name)
 	}
-	targetName := targetUser
-	if len(engine) > 0 && engine[0] == "MYSQL" {
-		if normalized, err := mysqlIamUser(targetUser); err == nil {
-			targetName = normalized
+	return user.Name, nil
+}
+
+// findUser returns the user with the name in the listed users of an instance, or nil if there is
+// none. MySQL users are also matched by host when the host is set.
+func (c *user) findUser(users []*sqladmin.User, name, host string) *sqladmin.User {
+	for _, u := range users {
+		if u.Name != name {
+			continue
 		}
-	}
-	for _, user := range usersList.Items {
-		if user.Name == targetName {
-			return true
+		if c.target.engine == "MYSQL" && host != "" && u.Host != host {
+			continue
 		}
+		return u
+	}
+	return nil
+}
+
+// cloudSqlUserIsCorrect checks if the existing user is of the target user type.
+func cloudSqlUserIsCorrect(existing *sqladmin.User, targetUserType string) bool {
+	if existing == nil {
+		return false
 	}
-	return false
+	if targetUserType == userBuiltIn {
+		// The API returns a blank string for built-in users
+		targetUserType = ""
+	}
+	return existing.Type == targetUserType
 }
 
 // validateMySQLUserCollision rejects MySQL users that conflict with the target local username.
 func validateMySQLUserCollision(
-	usersList *sqladmin.UsersListResponse, targetUser string, targetUserType string, engine string,
+	existing *sqladmin.User, targetUser string, targetUserType string, engine string,
 ) error {
-	if engine != "MYSQL" || targetUserType == userBuiltIn || usersList == nil {
+	if engine != "MYSQL" || targetUserType == userBuiltIn || existing == nil {
 		return nil
 	}
 	targetName, err := mysqlIamUser(targetUser)
 	if err != nil {
 		return err
 	}
-	for _, existingUser := range usersList.Items {
-		if existingUser.Name == targetName && existingUser.Type != targetUserType {
-			existingType := existingUser.Type
-			if existingType == "" {
-				existingType = userBuiltIn
-			}
-			return fmt.Errorf(
-				"%w: user %q conflicts with existing %s user of the same local name",
-				ErrMySQLUserCollision,
-				targetName,
-				existingType,
-			)
+	if existing.Name == targetName && existing.Type != targetUserType {
+		existingType := existing.Type
+		if existingType == "" {
+			existingType = userBuiltIn
 		}
+		return fmt.Errorf(
+			"%w: user %q conflicts with existing %s user of the same local name",
+			ErrMySQLUserCollision,
+			targetName,
+			existingType,
+		)
 	}
 	return nil
 }
@@ -527,10 +667,25 @@ func createTargetConnectionConfig(mctx blackstart.ModuleContext) (*connectionCon
 		return nil, fmt.Errorf("instance cannot be empty")
 	}
 
+	creds, err := contextCredentials(mctx)
+	if err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		target.creds = creds
+		target.credentialsJSON = creds.JSON
+	}
+
 	project, err := blackstart.ContextInputAs[string](mctx, inputProject, false)
 	if err == nil {
 		target.project = project
 	}
+	if target.project == "" && creds != nil {
+		target.project, err = cloud.ProjectIdFromCredentials(creds)
+		if err != nil {
+			return nil, errors.Join(ErrProjectNotProvidedNotFound, err)
+		}
+	}
 	if target.project == "" {
 		var creds *google.Credentials
 		target.project, creds, err = cloud.CurrentProject(mctx)
@@ -546,6 +701,10 @@ func createTargetConnectionConfig(mctx blackstart.ModuleContext) (*connectionCon
 	if err == nil {
 		target.region = region
 	}
+	target.labels, err = contextLabels(mctx)
+	if err != nil {
+		return nil, err
+	}
 
 	return &target, nil
 }
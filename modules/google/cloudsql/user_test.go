@@ -227,49 +227,219 @@ func TestMySQLUserBuiltin(t *testing.T) {
 	require.Equal(t, false, res)
 }
 
-func TestCloudSqlMySQLUserMatching(t *testing.T) {
-	users := &sqladmin.UsersListResponse{
-		Items: []*sqladmin.User{
-			{Name: "person", Type: userCloudIamUser},
-			{Name: "blackstart", Type: ""},
-		},
-	}
-
+func TestCloudSqlUserIsCorrect(t *testing.T) {
 	tests := map[string]struct {
-		targetUser string
+		existing   *sqladmin.User
 		targetType string
-		exists     bool
 		correct    bool
 	}{
-		"IAM user matches local name": {
-			targetUser: "person@example.com",
+		"IAM user": {
+			existing:   &sqladmin.User{Name: "person", Type: userCloudIamUser},
 			targetType: userCloudIamUser,
-			exists:     true,
 			correct:    true,
 		},
-		"built-in user matches unchanged name": {
-			targetUser: "blackstart",
+		"built-in user": {
+			existing:   &sqladmin.User{Name: "blackstart", Type: ""},
 			targetType: userBuiltIn,
-			exists:     true,
 			correct:    true,
 		},
 		"different IAM type is incorrect": {
-			targetUser: "person@example.com",
+			existing:   &sqladmin.User{Name: "person", Type: userCloudIamUser},
 			targetType: userCloudIamServiceAccount,
-			exists:     true,
+		},
+		"missing user is incorrect": {
+			targetType: userCloudIamUser,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				require.Equal(t, tt.correct, cloudSqlUserIsCorrect(tt.existing, tt.targetType))
+			},
+		)
+	}
+}
+
+// TestUserLookupWithFakeAdminAPI verifies users are read with a targeted get, and listed when the
+// get request is rejected.
+func TestUserLookupWithFakeAdminAPI(t *testing.T) {
+	tests := map[string]struct {
+		version   string
+		userName  string
+		getStatus int
+		wantName  string
+		wantList  int
+		wantErr   bool
+	}{
+		"postgres user": {
+			version:  "POSTGRES_17",
+			userName: "person@example.com",
+			wantName: "person@example.com",
+		},
+		"mysql IAM user by local name": {
+			version:  "MYSQL_8_4",
+			userName: "person@example.com",
+			wantName: "person",
+		},
+		"missing user": {
+			version:  "POSTGRES_17",
+			userName: "other@example.com",
+		},
+		"rejected get falls back to list": {
+			version:   "POSTGRES_17",
+			userName:  "person@example.com",
+			getStatus: http.StatusBadRequest,
+			wantName:  "person@example.com",
+			wantList:  1,
+		},
+		"get failure": {
+			version:   "POSTGRES_17",
+			userName:  "person@example.com",
+			getStatus: http.StatusInternalServerError,
+			wantErr:   true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				api := newFakeCloudSQLAdmin(t, tt.version)
+				api.users = []*sqladmin.User{
+					{Name: "person@example.com", Type: userCloudIamUser},
+					{Name: "person", IamEmail: "person@example.com", Type: userCloudIamUser},
+				}
+				if tt.getStatus != 0 {
+					api.fail[http.MethodGet+" /v1/projects/project/instances/instance/users/"+tt.wantName] = tt.getStatus
+					api.fail[http.MethodGet+" /v1/projects/project/instances/instance/users/"+tt.userName] = tt.getStatus
+				}
+				op := testCloudSQLUserOperation(tt.userName, userCloudIamUser)
+				ctx := blackstart.OpContext(context.Background(), &op)
+				module := &user{runtime: api.runtime(nil)}
+				require.NoError(t, module.setup(ctx))
+				u, err := module.user(ctx)
+				require.NoError(t, err)
+
+				existing, err := module.lookupUser(ctx, u)
+				if tt.wantErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				if tt.wantName == "" {
+					require.Nil(t, existing)
+				} else {
+					require.NotNil(t, existing)
+					require.Equal(t, tt.wantName, existing.Name)
+				}
+				require.Equal(t, tt.wantList, api.requestCount(http.MethodGet, "/instances/instance/users"))
+			},
+		)
+	}
+}
+
+// TestUserSetupWithCredentials verifies the project defaults to the project of the credentials
+// input.
+func TestUserSetupWithCredentials(t *testing.T) {
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	op := testCloudSQLUserOperation("person@example.com", userCloudIamUser)
+	delete(op.Inputs, inputProject)
+	op.Inputs[inputCredentials] = blackstart.NewInputFromValue(testCredentialsJSON)
+	ctx := blackstart.OpContext(context.Background(), &op)
+	module := &user{runtime: api.runtime(nil)}
+	require.NoError(t, module.setup(ctx))
+	require.Equal(t, "project", module.target.project)
+	require.Equal(t, []byte(testCredentialsJSON), module.target.credentialsJSON)
+
+	op.Inputs[inputCredentials] = blackstart.NewInputFromValue(`{"type": "service_account"`)
+	ctx = blackstart.OpContext(context.Background(), &op)
+	require.ErrorContains(t, (&user{runtime: api.runtime(nil)}).setup(ctx), "input 'credentials' is invalid")
+}
+
+// TestUserSetupInstanceFilters verifies the region and labels inputs must match the instance, and
+// that instances matching them are listed when they do not.
+func TestUserSetupInstanceFilters(t *testing.T) {
+	tests := map[string]struct {
+		region  string
+		labels  string
+		wantErr []string
+	}{
+		"matching region and labels": {
+			region: "us-central1",
+			labels: "env=prod",
+		},
+		"other region": {
+			region: "us-east1",
+			wantErr: []string{
+				"instance instance in project project does not match: region is us-central1, not us-east1",
+				"matching instances: instance-east (us-east1)",
+			},
+		},
+		"other labels": {
+			labels: "env=staging, team=data",
+			wantErr: []string{
+				`label env is "prod", not "staging"; label team is not set`,
+				"no instances in project project match",
+			},
 		},
 	}
 
 	for name, tt := range tests {
 		t.Run(
 			name, func(t *testing.T) {
-				require.Equal(t, tt.exists, cloudSqlUserExists(users, tt.targetUser, "MYSQL"))
-				require.Equal(t, tt.correct, cloudSqlUserIsCorrect(users, tt.targetUser, tt.targetType, "MYSQL"))
+				api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+				api.instance.Settings.UserLabels = map[string]string{"env": "prod"}
+				api.others = []*sqladmin.DatabaseInstance{
+					{
+						Name:     "instance-east",
+						Region:   "us-east1",
+						Settings: &sqladmin.Settings{UserLabels: map[string]string{"env": "prod"}},
+					},
+				}
+				op := testCloudSQLUserOperation("person@example.com", userCloudIamUser)
+				if tt.region != "" {
+					op.Inputs[inputRegion] = blackstart.NewInputFromValue(tt.region)
+				}
+				if tt.labels != "" {
+					op.Inputs[inputLabels] = blackstart.NewInputFromValue(tt.labels)
+				}
+				ctx := blackstart.OpContext(context.Background(), &op)
+				err := (&user{runtime: api.runtime(nil)}).setup(ctx)
+				if len(tt.wantErr) == 0 {
+					require.NoError(t, err)
+					return
+				}
+				for _, want := range tt.wantErr {
+					require.ErrorContains(t, err, want)
+				}
 			},
 		)
 	}
 }
 
+// TestUserSetRetriesOperationInProgress verifies user mutations are retried while another
+// operation is in progress on the instance.
+func TestUserSetRetriesOperationInProgress(t *testing.T) {
+	initial := operationPollInitialInterval
+	operationPollInitialInterval = time.Millisecond
+	t.Cleanup(func() { operationPollInitialInterval = initial })
+
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	api.inProgress[http.MethodPost+" /v1/projects/project/instances/instance/users"] = 2
+	op := testCloudSQLUserOperation("person@example.com", userCloudIamUser)
+	require.NoError(t, (&user{runtime: api.runtime(nil)}).Set(blackstart.OpContext(context.Background(), &op)))
+	require.Equal(t, 3, api.requestCount(http.MethodPost, "/users"))
+	require.Len(t, api.users, 1)
+
+	api.inProgress[http.MethodDelete+" /v1/projects/project/instances/instance/users"] = operationInProgressRetries + 1
+	op.DoesNotExist = true
+	err := (&user{runtime: api.runtime(nil)}).Set(blackstart.OpContext(context.Background(), &op))
+	require.Error(t, err)
+	require.True(t, isOperationInProgress(err))
+	require.Equal(t, operationInProgressRetries+1, api.requestCount(http.MethodDelete, "/users"))
+	require.Len(t, api.users, 1)
+}
+
 func TestMySQLDatabaseUsername(t *testing.T) {
 	u := user{
 		target: &connectionConfig{
@@ -320,38 +490,30 @@ func TestMySQLUserAPIShape(t *testing.T) {
 
 func TestValidateMySQLUserCollision(t *testing.T) {
 	tests := map[string]struct {
-		users      *sqladmin.UsersListResponse
+		existing   *sqladmin.User
 		targetType string
 		engine     string
 		wantErr    bool
 	}{
 		"matching IAM user": {
-			users: &sqladmin.UsersListResponse{Items: []*sqladmin.User{
-				{Name: "person", Type: userCloudIamUser},
-			}},
+			existing:   &sqladmin.User{Name: "person", Type: userCloudIamUser},
 			targetType: userCloudIamUser,
 			engine:     "MYSQL",
 		},
 		"built-in local name collision": {
-			users: &sqladmin.UsersListResponse{Items: []*sqladmin.User{
-				{Name: "person", Type: ""},
-			}},
+			existing:   &sqladmin.User{Name: "person", Type: ""},
 			targetType: userCloudIamUser,
 			engine:     "MYSQL",
 			wantErr:    true,
 		},
 		"different IAM type collision": {
-			users: &sqladmin.UsersListResponse{Items: []*sqladmin.User{
-				{Name: "person", Type: userCloudIamServiceAccount},
-			}},
+			existing:   &sqladmin.User{Name: "person", Type: userCloudIamServiceAccount},
 			targetType: userCloudIamUser,
 			engine:     "MYSQL",
 			wantErr:    true,
 		},
 		"postgres ignores local names": {
-			users: &sqladmin.UsersListResponse{Items: []*sqladmin.User{
-				{Name: "person", Type: ""},
-			}},
+			existing:   &sqladmin.User{Name: "person", Type: ""},
 			targetType: userCloudIamUser,
 			engine:     "POSTGRES",
 		},
@@ -360,7 +522,7 @@ func TestValidateMySQLUserCollision(t *testing.T) {
 	for name, tt := range tests {
 		t.Run(
 			name, func(t *testing.T) {
-				err := validateMySQLUserCollision(tt.users, "person@example.com", tt.targetType, tt.engine)
+				err := validateMySQLUserCollision(tt.existing, "person@example.com", tt.targetType, tt.engine)
 				if tt.wantErr {
 					require.Error(t, err)
 					require.ErrorIs(t, err, ErrMySQLUserCollision)
@@ -449,6 +611,64 @@ func TestUserCheckWithFakeAdminAPI(t *testing.T) {
 	}
 }
 
+// TestUserCheckManyWithFakeAdminAPI verifies the users of an instance are listed once to check
+// several users, with the same results as Check.
+func TestUserCheckManyWithFakeAdminAPI(t *testing.T) {
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	api.users = []*sqladmin.User{
+		{Name: "person@example.com", Type: userCloudIamUser},
+		{Name: "app@project.iam", Type: userCloudIamServiceAccount},
+		{Name: "removed@example.com", Type: userCloudIamUser},
+	}
+
+	existing := testCloudSQLUserOperation("person@example.com", userCloudIamUser)
+	serviceAccount := testCloudSQLUserOperation("app", userCloudIamServiceAccount)
+	missing := testCloudSQLUserOperation("other@example.com", userCloudIamUser)
+	removed := testCloudSQLUserOperation("removed@example.com", userCloudIamUser)
+	removed.DoesNotExist = true
+	ops := []*blackstart.Operation{&existing, &serviceAccount, &missing, &removed}
+	ctxs := make([]blackstart.ModuleContext, len(ops))
+	for i, op := range ops {
+		ctxs[i] = blackstart.OpContext(context.Background(), op)
+	}
+
+	got, err := (&user{runtime: api.runtime(nil)}).CheckMany(ctxs)
+	require.NoError(t, err)
+	require.Equal(t, []bool{true, true, false, false}, got)
+	require.Equal(t, 1, api.requestCount(http.MethodGet, "/instances/instance/users"))
+	require.Zero(t, api.requestCount(http.MethodGet, "/instances/instance/users/person@example.com"))
+
+	for i, op := range ops {
+		want, checkErr := (&user{runtime: api.runtime(nil)}).Check(blackstart.OpContext(context.Background(), op))
+		require.NoError(t, checkErr)
+		require.Equal(t, want, got[i], op.Inputs[inputUser].Any())
+	}
+	require.Equal(t, 1, api.requestCount(http.MethodGet, "/instances/instance/users"))
+}
+
+// TestUserReplicaPolicyWithFakeAdminAPI verifies read replicas are rejected or resolved to the
+// primary instance.
+func TestUserReplicaPolicyWithFakeAdminAPI(t *testing.T) {
+	api := newFakeCloudSQLAdmin(t, "POSTGRES_17")
+	api.addReplica("replica")
+	api.users = []*sqladmin.User{{Name: "person@example.com", Type: userCloudIamUser}}
+
+	op := testCloudSQLUserOperation("person@example.com", userCloudIamUser)
+	op.Inputs[inputInstance] = blackstart.NewInputFromValue("replica")
+	_, err := (&user{runtime: api.runtime(nil)}).Check(blackstart.OpContext(context.Background(), &op))
+	require.ErrorContains(t, err, "instance replica in project project is a read replica of project:instance")
+
+	op.Inputs[inputReplicaPolicy] = blackstart.NewInputFromValue(replicaPolicyFollowPrimary)
+	module := &user{runtime: api.runtime(nil)}
+	got, err := module.Check(blackstart.OpContext(context.Background(), &op))
+	require.NoError(t, err)
+	require.True(t, got)
+	require.Equal(t, "instance", module.target.instance)
+	require.Equal(t, "project:us-central1:instance", module.target.identifier)
+	require.Equal(t, 1, api.requestCount(http.MethodGet, "/instances/instance/users/person@example.com"))
+	require.Zero(t, api.requestCount(http.MethodGet, "/instances/instance/users"))
+}
+
 // TestUserSetWithFakeAdminAPI verifies user creation, replacement, deletion, and collision behavior.
 func TestUserSetWithFakeAdminAPI(t *testing.T) {
 	tests := map[string]struct {
@@ -570,9 +790,9 @@ func TestUserAdminAPIFailures(t *testing.T) {
 				return err
 			},
 		},
-		"user list": {
+		"user get": {
 			method:     http.MethodGet,
-			pathSuffix: "/instances/instance/users",
+			pathSuffix: "/instances/instance/users/person@example.com",
 			call: func(module *user, ctx blackstart.ModuleContext) error {
 				_, err := module.Check(ctx)
 				return err
@@ -0,0 +1,112 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/api/firebaserules/v1"
+	"google.golang.org/api/firestore/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject         = "project"
+	inputDatabase        = "database"
+	inputCollectionGroup = "collection_group"
+	inputFields          = "fields"
+	inputQueryScope      = "query_scope"
+	inputAPIScope        = "api_scope"
+	inputRules           = "rules"
+
+	outputIndex   = "index"
+	outputRuleset = "ruleset"
+	outputRelease = "release"
+
+	// defaultDatabase is the ID of the default database of a project.
+	defaultDatabase = "(default)"
+)
+
+// Firestore index operations return long-running operations. They are polled with exponential
+// backoff between these intervals until they are done.
+var (
+	operationPollInitialInterval = 2 * time.Second
+	operationPollMaxInterval     = 30 * time.Second
+)
+
+func init() {
+	blackstart.RegisterPathName("firestore", "Firestore")
+}
+
+// firestoreRuntime provides the injectable Firestore and Firebase Rules API dependencies.
+type firestoreRuntime struct {
+	newService      func(context.Context) (*firestore.Service, error)
+	newRulesService func(context.Context) (*firebaserules.Service, error)
+}
+
+// defaultFirestoreRuntime creates the production Firestore runtime.
+func defaultFirestoreRuntime() *firestoreRuntime {
+	return &firestoreRuntime{
+		newService: func(ctx context.Context) (*firestore.Service, error) {
+			return cloud.NewService(ctx, firestore.NewService, nil, firestore.DatastoreScope)
+		},
+		newRulesService: func(ctx context.Context) (*firebaserules.Service, error) {
+			return cloud.NewService(ctx, firebaserules.NewService, nil, firebaserules.FirebaseScope)
+		},
+	}
+}
+
+// firestoreRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
+func firestoreRuntimeOrDefault(runtime *firestoreRuntime) *firestoreRuntime {
+	if runtime == nil {
+		return defaultFirestoreRuntime()
+	}
+	return runtime
+}
+
+// waitForOperation polls a Firestore operation until it is done and returns any error reported by
+// the operation.
+func waitForOperation(ctx context.Context, svc *firestore.Service, op *firestore.GoogleLongrunningOperation) error {
+	polling := cloud.OperationPolling{InitialInterval: operationPollInitialInterval, MaxInterval: operationPollMaxInterval}
+	return cloud.WaitForOperation(
+		ctx, op, polling, func(ctx context.Context, name string) (*firestore.GoogleLongrunningOperation, error) {
+			return svc.Projects.Databases.Operations.Get(name).Context(ctx).Do()
+		},
+	)
+}
+
+// databaseTarget is a Firestore database resolved from the project and database inputs.
+type databaseTarget struct {
+	project string
+	id      string
+}
+
+// name returns the resource name of the database.
+func (t databaseTarget) name() string {
+	return fmt.Sprintf("projects/%s/databases/%s", t.project, t.id)
+}
+
+// contextDatabase resolves the database of the project and database inputs. The project defaults
+// to the current project and the database to the default database.
+func contextDatabase(ctx blackstart.ModuleContext) (databaseTarget, error) {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return databaseTarget{}, err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return databaseTarget{}, err
+		}
+	}
+	id, err := blackstart.ContextInputAs[string](ctx, inputDatabase, false)
+	if err != nil {
+		return databaseTarget{}, err
+	}
+	if id == "" {
+		id = defaultDatabase
+	}
+	return databaseTarget{project: project, id: id}, nil
+}
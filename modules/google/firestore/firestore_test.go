@@ -0,0 +1,214 @@
+package firestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/firebaserules/v1"
+	"google.golang.org/api/firestore/v1"
+	"google.golang.org/api/option"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+const (
+	testDatabase        = "projects/app-project/databases/(default)"
+	testCollectionGroup = testDatabase + "/collectionGroups/orders"
+)
+
+// fakeFirestore implements the Firestore index and Firebase Rules REST operations used by the
+// modules.
+type fakeFirestore struct {
+	t        *testing.T
+	server   *httptest.Server
+	indexes  map[string][]*firestore.GoogleFirestoreAdminV1Index
+	rulesets map[string]*firebaserules.Ruleset
+	releases map[string]*firebaserules.Release
+	// Operations reports operations as running until PendingPolls polls were made.
+	cloudtest.Operations
+	created  int
+	requests []string
+	mu       sync.Mutex
+}
+
+// newFakeFirestore starts a stateful fake Firestore and Firebase Rules API server.
+func newFakeFirestore(t *testing.T) *fakeFirestore {
+	t.Helper()
+	operationPollInitialInterval = time.Millisecond
+	f := &fakeFirestore{
+		t:        t,
+		indexes:  map[string][]*firestore.GoogleFirestoreAdminV1Index{},
+		rulesets: map[string]*firebaserules.Ruleset{},
+		releases: map[string]*firebaserules.Release{},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a Firestore runtime connected to the fake API.
+func (f *fakeFirestore) runtime() *firestoreRuntime {
+	return &firestoreRuntime{
+		newService: func(ctx context.Context) (*firestore.Service, error) {
+			return firestore.NewService(ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication())
+		},
+		newRulesService: func(ctx context.Context) (*firebaserules.Service, error) {
+			return firebaserules.NewService(
+				ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication(),
+			)
+		},
+	}
+}
+
+// requestCount returns the number of requests with the method and path suffix.
+func (f *fakeFirestore) requestCount(method, suffix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, method+" ") && strings.HasSuffix(r, suffix) {
+			count++
+		}
+	}
+	return count
+}
+
+// serveHTTP handles the API operations used by the unit tests.
+func (f *fakeFirestore) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	switch {
+	case r.Method == http.MethodGet && strings.Contains(path, "/operations/"):
+		cloudtest.WriteJSON(f.t, w, f.Poll(path))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/indexes"):
+		var index firestore.GoogleFirestoreAdminV1Index
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&index))
+		parent := strings.TrimSuffix(path, "/indexes")
+		f.created++
+		index.Name = fmt.Sprintf("%s/indexes/index-%d", parent, f.created)
+		index.State = "READY"
+		if f.PendingPolls > 0 {
+			index.State = "CREATING"
+		}
+		// Firestore appends the document name with the direction of the last field.
+		last := index.Fields[len(index.Fields)-1]
+		if last.FieldPath != documentNameField {
+			order := last.Order
+			if order == "" {
+				order = orderAscending
+			}
+			index.Fields = append(index.Fields, &firestore.GoogleFirestoreAdminV1IndexField{
+				FieldPath: documentNameField, Order: order,
+			})
+		}
+		f.indexes[parent] = append(f.indexes[parent], &index)
+		cloudtest.WriteJSON(f.t, w, f.Start(fmt.Sprintf("%s/operations/%d", testDatabase, f.created)))
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/indexes"):
+		parent := strings.TrimSuffix(path, "/indexes")
+		cloudtest.WriteJSON(f.t, w, &firestore.GoogleFirestoreAdminV1ListIndexesResponse{Indexes: f.indexes[parent]})
+	case strings.Contains(path, "/indexes/"):
+		parent, _, _ := strings.Cut(path, "/indexes/")
+		for n, index := range f.indexes[parent] {
+			if index.Name != path {
+				continue
+			}
+			if r.Method == http.MethodDelete {
+				f.indexes[parent] = append(f.indexes[parent][:n], f.indexes[parent][n+1:]...)
+				cloudtest.WriteJSON(f.t, w, &firestore.Empty{})
+				return
+			}
+			// An index is built when its operation is done.
+			if f.PendingPolls <= 0 {
+				index.State = "READY"
+			}
+			cloudtest.WriteJSON(f.t, w, index)
+			return
+		}
+		http.Error(w, "index not found", http.StatusNotFound)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/rulesets"):
+		var ruleset firebaserules.Ruleset
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&ruleset))
+		ruleset.Name = fmt.Sprintf("%s/ruleset-%d", path, len(f.rulesets)+1)
+		f.rulesets[ruleset.Name] = &ruleset
+		cloudtest.WriteJSON(f.t, w, &ruleset)
+	case r.Method == http.MethodGet && strings.Contains(path, "/rulesets/"):
+		ruleset, ok := f.rulesets[path]
+		if !ok {
+			http.Error(w, "ruleset not found", http.StatusNotFound)
+			return
+		}
+		cloudtest.WriteJSON(f.t, w, ruleset)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/releases"):
+		var release firebaserules.Release
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&release))
+		require.NotContains(f.t, f.releases, release.Name)
+		f.releases[release.Name] = &release
+		cloudtest.WriteJSON(f.t, w, &release)
+	case r.Method == http.MethodPatch && strings.Contains(path, "/releases/"):
+		var req firebaserules.UpdateReleaseRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(f.t, path, req.Release.Name)
+		require.Contains(f.t, f.releases, path)
+		f.releases[path] = req.Release
+		cloudtest.WriteJSON(f.t, w, req.Release)
+	case r.Method == http.MethodGet && strings.Contains(path, "/releases/"):
+		release, ok := f.releases[path]
+		if !ok {
+			http.Error(w, "release not found", http.StatusNotFound)
+			return
+		}
+		cloudtest.WriteJSON(f.t, w, release)
+	default:
+		f.t.Errorf("unexpected API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testContext creates a module context for the operation that records outputs.
+func testContext(op *blackstart.Operation) *outputContext {
+	return &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), op),
+		outputs:       map[string]any{},
+	}
+}
+
+func TestWaitForOperation(t *testing.T) {
+	fake := newFakeFirestore(t)
+	fake.PendingPolls = 3
+	svc, err := fake.runtime().newService(context.Background())
+	require.NoError(t, err)
+
+	op := &firestore.GoogleLongrunningOperation{Name: testDatabase + "/operations/1"}
+	require.NoError(t, waitForOperation(context.Background(), svc, op))
+	require.Equal(t, 3, fake.requestCount(http.MethodGet, "/operations/1"))
+
+	failed := &firestore.GoogleLongrunningOperation{
+		Name: "operations/2", Done: true, Error: &firestore.Status{Code: 9, Message: "index exists"},
+	}
+	require.EqualError(
+		t, waitForOperation(context.Background(), svc, failed), "operation operations/2 failed: 9: index exists",
+	)
+}
@@ -0,0 +1,504 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/firestore/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	queryScopeCollection          = "COLLECTION"
+	queryScopeCollectionGroup     = "COLLECTION_GROUP"
+	queryScopeCollectionRecursive = "COLLECTION_RECURSIVE"
+
+	apiScopeAny       = "ANY_API"
+	apiScopeDatastore = "DATASTORE_MODE_API"
+
+	orderAscending    = "ASCENDING"
+	orderDescending   = "DESCENDING"
+	arrayContains     = "CONTAINS"
+	indexStateReady   = "READY"
+	indexStateRepair  = "NEEDS_REPAIR"
+	documentNameField = "__name__"
+
+	fieldKeyPath        = "field_path"
+	fieldKeyOrder       = "order"
+	fieldKeyArrayConfig = "array_config"
+)
+
+func init() {
+	blackstart.RegisterModule("google_firestore_index", NewIndex)
+}
+
+var _ blackstart.Module = &index{}
+
+// index manages a composite index of a Firestore database.
+type index struct {
+	runtime *firestoreRuntime
+	svc     *firestore.Service
+	target  *indexTarget
+}
+
+// indexTarget is the desired index resolved from the module inputs.
+type indexTarget struct {
+	database        databaseTarget
+	collectionGroup string
+	fields          []indexField
+	queryScope      string
+	apiScope        string
+}
+
+// parent returns the resource name of the collection group of the index.
+func (t *indexTarget) parent() string {
+	return t.database.name() + "/collectionGroups/" + t.collectionGroup
+}
+
+// matches reports whether an index of the collection group has the fields and scopes of the target.
+// Firestore appends the document name to the fields of an index created without it, so a trailing
+// document name field is ignored when the target does not end with it.
+func (t *indexTarget) matches(existing *firestore.GoogleFirestoreAdminV1Index) bool {
+	apiScope := existing.ApiScope
+	if apiScope == "" {
+		apiScope = apiScopeAny
+	}
+	if existing.QueryScope != t.queryScope || apiScope != t.apiScope {
+		return false
+	}
+	fields := existing.Fields
+	if n := len(fields); n == len(t.fields)+1 && fields[n-1].FieldPath == documentNameField {
+		fields = fields[:n-1]
+	}
+	return slices.EqualFunc(
+		fields, t.fields, func(f *firestore.GoogleFirestoreAdminV1IndexField, want indexField) bool {
+			return f.FieldPath == want.path && f.Order == want.order && f.ArrayConfig == want.arrayConfig
+		},
+	)
+}
+
+// indexField is a field of a composite index, ordered or configured for array membership.
+type indexField struct {
+	path        string
+	order       string
+	arrayConfig string
+}
+
+func NewIndex() blackstart.Module {
+	return &index{}
+}
+
+func (i *index) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_firestore_index",
+		Name: "Google Firestore Index",
+		Description: util.CleanString(
+			`
+Ensures a composite index exists on a collection group of a Firestore database, and waits until it
+is built. Databases in Datastore mode are supported with the '''DATASTORE_MODE_API''' API scope,
+where the collection group is the kind of the entities.
+
+Each field of '''fields''' is a map with the keys:
+
+- '''field_path''': path of the field, such as '''status''' or '''address.city'''.
+- '''order''': '''ASCENDING''' or '''DESCENDING'''.
+- '''array_config''': '''CONTAINS''', for fields used with '''array-contains''' queries.
+
+Each field must set either '''order''' or '''array_config'''.
+
+**Notes**
+
+- Indexes cannot be changed. An index is identified by its collection group, fields, and scopes, so
+  changing any of them creates a new index. The previous index is not deleted; use an operation with
+  '''doesNotExist''' to delete it.
+- Firestore appends the document name, '''__name__''', to the fields of an index with the direction
+  of the last field. It only needs to be set when it is ordered differently.
+- Building an index can take several minutes for collections with many documents. An index that
+  failed to build and needs repair is deleted and created again.
+- When '''doesNotExist''' is set, the index is deleted.
+`,
+		),
+		Requirements: []string{
+			"The Firestore API (`firestore.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/datastore.indexAdmin` in the project.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Project of the database. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputDatabase: {
+				Description: "ID of the database.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultDatabase,
+			},
+			inputCollectionGroup: {
+				Description: "ID of the collection group of the index, such as `orders`, or the kind in Datastore mode.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputFields: {
+				Description: "Fields of the index, in order, each with a `field_path` and an `order` or `array_config`.",
+				Type:        reflect.TypeFor[[]map[string]any](),
+				Required:    true,
+			},
+			inputQueryScope: {
+				Description: "Query scope of the index: `COLLECTION`, `COLLECTION_GROUP`, or `COLLECTION_RECURSIVE` for ancestor queries in Datastore mode.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     queryScopeCollection,
+			},
+			inputAPIScope: {
+				Description: "API scope of the index: `ANY_API` for Firestore, or `DATASTORE_MODE_API` for Datastore mode.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     apiScopeAny,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputIndex: {
+				Description: "Resource name of the index, `projects/<project>/databases/<database>/collectionGroups/<collection>/indexes/<index>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Orders by Status": `id: orders-by-status
+module: google_firestore_index
+inputs:
+  project: app-project
+  collection_group: orders
+  fields:
+    - field_path: status
+      order: ASCENDING
+    - field_path: createdAt
+      order: DESCENDING`,
+			"Datastore Mode Ancestor Index": `id: tasks-by-priority
+module: google_firestore_index
+inputs:
+  collection_group: Task
+  query_scope: COLLECTION_RECURSIVE
+  api_scope: DATASTORE_MODE_API
+  fields:
+    - field_path: priority
+      order: DESCENDING
+    - field_path: tags
+      array_config: CONTAINS`,
+		},
+	}
+}
+
+func (i *index) Validate(op blackstart.Operation) error {
+	if err := cloud.RequiredString(op, inputCollectionGroup); err != nil {
+		return err
+	}
+	input, ok := op.Inputs[inputFields]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", inputFields)
+	}
+	if input.IsStatic() {
+		raw, err := blackstart.InputAs[[]map[string]any](input, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputFields, err)
+		}
+		if _, err = parseFields(raw); err != nil {
+			return err
+		}
+	}
+	scopes := map[string]func(string) (string, error){
+		inputQueryScope: normalizeQueryScope,
+		inputAPIScope:   normalizeAPIScope,
+	}
+	for key, normalize := range scopes {
+		input, ok = op.Inputs[key]
+		if !ok || !input.IsStatic() {
+			continue
+		}
+		value, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		if _, err = normalize(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the index exists and is built.
+func (i *index) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := i.setup(ctx); err != nil {
+		return false, err
+	}
+
+	existing, err := i.find(ctx)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		ctx.Resource(existing.Name)
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || existing.State != indexStateReady || ctx.Tainted() {
+		return false, nil
+	}
+	return true, ctx.Output(outputIndex, existing.Name)
+}
+
+// Set creates the index when it does not exist, and waits until it is built.
+func (i *index) Set(ctx blackstart.ModuleContext) error {
+	if err := i.setup(ctx); err != nil {
+		return err
+	}
+
+	existing, err := i.find(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		ctx.Resource(existing.Name)
+		return i.delete(ctx, existing.Name)
+	}
+
+	if existing != nil && existing.State == indexStateRepair {
+		if err = i.delete(ctx, existing.Name); err != nil {
+			return err
+		}
+		existing = nil
+	}
+
+	if existing == nil {
+		if existing, err = i.create(ctx); err != nil {
+			return err
+		}
+	}
+	ctx.Resource(existing.Name)
+	if existing.State != indexStateReady {
+		if err = i.waitForIndex(ctx, existing.Name); err != nil {
+			return err
+		}
+	}
+	return ctx.Output(outputIndex, existing.Name)
+}
+
+// indexes returns the indexes service of the Firestore API.
+func (i *index) indexes() *firestore.ProjectsDatabasesCollectionGroupsIndexesService {
+	return i.svc.Projects.Databases.CollectionGroups.Indexes
+}
+
+// setup resolves the target index from the inputs and creates the Firestore service.
+func (i *index) setup(ctx blackstart.ModuleContext) error {
+	target := &indexTarget{}
+	var err error
+	if target.database, err = contextDatabase(ctx); err != nil {
+		return err
+	}
+	if target.collectionGroup, err = blackstart.ContextInputAs[string](ctx, inputCollectionGroup, true); err != nil {
+		return err
+	}
+	rawFields, err := blackstart.ContextInputAs[[]map[string]any](ctx, inputFields, true)
+	if err != nil {
+		return err
+	}
+	if target.fields, err = parseFields(rawFields); err != nil {
+		return err
+	}
+	queryScope, err := blackstart.ContextInputAs[string](ctx, inputQueryScope, false)
+	if err != nil {
+		return err
+	}
+	if target.queryScope, err = normalizeQueryScope(queryScope); err != nil {
+		return err
+	}
+	apiScope, err := blackstart.ContextInputAs[string](ctx, inputAPIScope, false)
+	if err != nil {
+		return err
+	}
+	if target.apiScope, err = normalizeAPIScope(apiScope); err != nil {
+		return err
+	}
+	i.target = target
+
+	i.runtime = firestoreRuntimeOrDefault(i.runtime)
+	i.svc, err = i.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Firestore service: %w", err)
+	}
+	return nil
+}
+
+// find returns the index of the collection group that matches the target, or nil if none exists.
+func (i *index) find(ctx context.Context) (*firestore.GoogleFirestoreAdminV1Index, error) {
+	var found *firestore.GoogleFirestoreAdminV1Index
+	err := i.indexes().List(i.target.parent()).Context(ctx).Pages(
+		ctx, func(res *firestore.GoogleFirestoreAdminV1ListIndexesResponse) error {
+			for _, existing := range res.Indexes {
+				if found == nil && i.target.matches(existing) {
+					found = existing
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil && !cloud.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to list indexes of %s: %w", i.target.parent(), err)
+	}
+	return found, nil
+}
+
+// create creates the index, waits for the operation that builds it, and returns the index.
+func (i *index) create(ctx context.Context) (*firestore.GoogleFirestoreAdminV1Index, error) {
+	desired := &firestore.GoogleFirestoreAdminV1Index{
+		QueryScope: i.target.queryScope,
+		ApiScope:   i.target.apiScope,
+		Fields:     make([]*firestore.GoogleFirestoreAdminV1IndexField, 0, len(i.target.fields)),
+	}
+	for _, f := range i.target.fields {
+		desired.Fields = append(
+			desired.Fields,
+			&firestore.GoogleFirestoreAdminV1IndexField{FieldPath: f.path, Order: f.order, ArrayConfig: f.arrayConfig},
+		)
+	}
+	op, err := i.indexes().Create(i.target.parent(), desired).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create index of %s: %w", i.target.parent(), err)
+	}
+	if err = waitForOperation(ctx, i.svc, op); err != nil {
+		return nil, fmt.Errorf("failed to create index of %s: %w", i.target.parent(), err)
+	}
+
+	// The operation does not return the name of the index, so the index is found again.
+	created, err := i.find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if created == nil {
+		return nil, fmt.Errorf("index of %s was not found after it was created", i.target.parent())
+	}
+	return created, nil
+}
+
+// delete deletes the index with the name.
+func (i *index) delete(ctx context.Context, name string) error {
+	_, err := i.indexes().Delete(name).Context(ctx).Do()
+	if err != nil && !cloud.IsNotFound(err) {
+		return fmt.Errorf("failed to delete index %s: %w", name, err)
+	}
+	return nil
+}
+
+// waitForIndex polls an index until it is built. An index is being built when it was created by an
+// operation that was not waited for, such as one of an interrupted run.
+func (i *index) waitForIndex(ctx context.Context, name string) error {
+	interval := operationPollInitialInterval
+	for {
+		existing, err := i.indexes().Get(name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to get index %s: %w", name, err)
+		}
+		switch existing.State {
+		case indexStateReady:
+			return nil
+		case indexStateRepair:
+			return fmt.Errorf("index %s failed to build and needs repair", name)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed waiting for index %s: %w", name, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, operationPollMaxInterval)
+	}
+}
+
+// parseFields returns the fields of a fields input.
+func parseFields(raw []map[string]any) ([]indexField, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("invalid %s: at least one field must be set", inputFields)
+	}
+	fields := make([]indexField, 0, len(raw))
+	for n, values := range raw {
+		var f indexField
+		for key, value := range values {
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s[%d]: %s must be a string", inputFields, n, key)
+			}
+			switch key {
+			case fieldKeyPath:
+				f.path = strings.TrimSpace(s)
+			case fieldKeyOrder:
+				f.order = strings.ToUpper(strings.TrimSpace(s))
+			case fieldKeyArrayConfig:
+				f.arrayConfig = strings.ToUpper(strings.TrimSpace(s))
+			default:
+				return nil, fmt.Errorf(
+					"invalid %s[%d]: unknown key %s, expected %s, %s, or %s",
+					inputFields, n, key, fieldKeyPath, fieldKeyOrder, fieldKeyArrayConfig,
+				)
+			}
+		}
+		switch {
+		case f.path == "":
+			return nil, fmt.Errorf("invalid %s[%d]: %s must be set", inputFields, n, fieldKeyPath)
+		case (f.order == "") == (f.arrayConfig == ""):
+			return nil, fmt.Errorf(
+				"invalid %s[%d]: field %s must set one of %s or %s", inputFields, n, f.path, fieldKeyOrder,
+				fieldKeyArrayConfig,
+			)
+		case f.order != "" && f.order != orderAscending && f.order != orderDescending:
+			return nil, fmt.Errorf(
+				"invalid %s[%d]: order %q of field %s, expected %s or %s", inputFields, n, f.order, f.path,
+				orderAscending, orderDescending,
+			)
+		case f.arrayConfig != "" && f.arrayConfig != arrayContains:
+			return nil, fmt.Errorf(
+				"invalid %s[%d]: array_config %q of field %s, expected %s", inputFields, n, f.arrayConfig, f.path,
+				arrayContains,
+			)
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// normalizeQueryScope returns the query scope of a query_scope input, which defaults to COLLECTION.
+func normalizeQueryScope(scope string) (string, error) {
+	switch scope = strings.ToUpper(strings.TrimSpace(scope)); scope {
+	case "":
+		return queryScopeCollection, nil
+	case queryScopeCollection, queryScopeCollectionGroup, queryScopeCollectionRecursive:
+		return scope, nil
+	default:
+		return "", fmt.Errorf(
+			"invalid %s: %q, expected %s, %s, or %s", inputQueryScope, scope, queryScopeCollection,
+			queryScopeCollectionGroup, queryScopeCollectionRecursive,
+		)
+	}
+}
+
+// normalizeAPIScope returns the API scope of an api_scope input, which defaults to ANY_API.
+func normalizeAPIScope(scope string) (string, error) {
+	switch scope = strings.ToUpper(strings.TrimSpace(scope)); scope {
+	case "":
+		return apiScopeAny, nil
+	case apiScopeAny, apiScopeDatastore:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("invalid %s: %q, expected %s or %s", inputAPIScope, scope, apiScopeAny, apiScopeDatastore)
+	}
+}
@@ -0,0 +1,227 @@
+package firestore
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/firestore/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+// testIndexOperation returns an index operation for the orders collection group.
+func testIndexOperation() *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "orders-by-status",
+		Module: "google_firestore_index",
+		Inputs: map[string]blackstart.Input{
+			inputProject:         blackstart.NewInputFromValue("app-project"),
+			inputCollectionGroup: blackstart.NewInputFromValue("orders"),
+			inputFields: blackstart.NewInputFromValue(
+				[]any{
+					map[string]any{"field_path": "status", "order": "ascending"},
+					map[string]any{"field_path": "createdAt", "order": "DESCENDING"},
+				},
+			),
+		},
+	}
+}
+
+func TestIndex_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "empty collection group", key: inputCollectionGroup, value: "", wantErr: "invalid collection_group"},
+		{name: "no fields", key: inputFields, value: []any{}, wantErr: "at least one field must be set"},
+		{
+			name: "field without path", key: inputFields, value: []any{map[string]any{"order": "ASCENDING"}},
+			wantErr: "invalid fields[0]: field_path must be set",
+		},
+		{
+			name: "order and array config", key: inputFields,
+			value:   []any{map[string]any{"field_path": "tags", "order": "ASCENDING", "array_config": "CONTAINS"}},
+			wantErr: "field tags must set one of order or array_config",
+		},
+		{
+			name: "invalid order", key: inputFields, value: []any{map[string]any{"field_path": "a", "order": "up"}},
+			wantErr: `invalid fields[0]: order "UP" of field a`,
+		},
+		{
+			name: "unknown key", key: inputFields, value: []any{map[string]any{"field_path": "a", "direction": "ASC"}},
+			wantErr: "unknown key direction",
+		},
+		{name: "collection group scope", key: inputQueryScope, value: "collection_group"},
+		{name: "invalid query scope", key: inputQueryScope, value: "DATABASE", wantErr: `invalid query_scope: "DATABASE"`},
+		{name: "datastore api scope", key: inputAPIScope, value: "DATASTORE_MODE_API"},
+		{name: "invalid api scope", key: inputAPIScope, value: "MONGODB", wantErr: `invalid api_scope: "MONGODB"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testIndexOperation()
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewIndex().Validate(*op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+
+	op := testIndexOperation()
+	delete(op.Inputs, inputFields)
+	require.ErrorContains(t, NewIndex().Validate(*op), "missing required parameter: fields")
+}
+
+func TestIndex_Create(t *testing.T) {
+	fake := newFakeFirestore(t)
+	fake.PendingPolls = 2
+	op := testIndexOperation()
+	module := &index{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, ctx.outputs)
+
+	require.NoError(t, module.Set(ctx))
+	created := fake.indexes[testCollectionGroup]
+	require.Len(t, created, 1)
+	require.Equal(t, testCollectionGroup+"/indexes/index-1", ctx.outputs[outputIndex])
+	require.Equal(t, queryScopeCollection, created[0].QueryScope)
+	require.Equal(t, apiScopeAny, created[0].ApiScope)
+	require.Equal(t, "status", created[0].Fields[0].FieldPath)
+	require.Equal(t, orderAscending, created[0].Fields[0].Order)
+
+	// The document name appended by Firestore does not change the index.
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// An index with other fields is a different index.
+	op.Inputs[inputFields] = blackstart.NewInputFromValue(
+		[]any{
+			map[string]any{"field_path": "status", "order": "ASCENDING"},
+			map[string]any{"field_path": "createdAt", "order": "ASCENDING"},
+		},
+	)
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestIndex_WaitsForBuild(t *testing.T) {
+	fake := newFakeFirestore(t)
+	fake.PendingPolls = 2
+	fake.indexes[testCollectionGroup] = []*firestore.GoogleFirestoreAdminV1Index{
+		{
+			Name:       testCollectionGroup + "/indexes/building",
+			QueryScope: queryScopeCollection,
+			State:      "CREATING",
+			Fields: []*firestore.GoogleFirestoreAdminV1IndexField{
+				{FieldPath: "status", Order: orderAscending},
+				{FieldPath: "createdAt", Order: orderDescending},
+				{FieldPath: documentNameField, Order: orderDescending},
+			},
+		},
+	}
+	op := testIndexOperation()
+	module := &index{runtime: fake.runtime()}
+
+	// An index that is being built is not ready.
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	fake.PendingPolls = 0
+	ctx := testContext(op)
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, testCollectionGroup+"/indexes/building", ctx.outputs[outputIndex])
+	require.Zero(t, fake.requestCount(http.MethodPost, "/indexes"))
+	require.Len(t, fake.indexes[testCollectionGroup], 1)
+}
+
+func TestIndex_RecreatesIndexThatNeedsRepair(t *testing.T) {
+	fake := newFakeFirestore(t)
+	fake.indexes[testCollectionGroup] = []*firestore.GoogleFirestoreAdminV1Index{
+		{
+			Name:       testCollectionGroup + "/indexes/broken",
+			QueryScope: queryScopeCollection,
+			State:      indexStateRepair,
+			Fields: []*firestore.GoogleFirestoreAdminV1IndexField{
+				{FieldPath: "status", Order: orderAscending},
+				{FieldPath: "createdAt", Order: orderDescending},
+			},
+		},
+	}
+	op := testIndexOperation()
+	module := &index{runtime: fake.runtime()}
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete, "/indexes/broken"))
+	require.Len(t, fake.indexes[testCollectionGroup], 1)
+	require.Equal(t, testCollectionGroup+"/indexes/index-1", fake.indexes[testCollectionGroup][0].Name)
+}
+
+func TestIndex_DatastoreMode(t *testing.T) {
+	fake := newFakeFirestore(t)
+	op := testIndexOperation()
+	op.Inputs[inputCollectionGroup] = blackstart.NewInputFromValue("Task")
+	op.Inputs[inputQueryScope] = blackstart.NewInputFromValue(queryScopeCollectionRecursive)
+	op.Inputs[inputAPIScope] = blackstart.NewInputFromValue(apiScopeDatastore)
+	op.Inputs[inputFields] = blackstart.NewInputFromValue(
+		[]any{
+			map[string]any{"field_path": "priority", "order": "DESCENDING"},
+			map[string]any{"field_path": "tags", "array_config": "contains"},
+		},
+	)
+	module := &index{runtime: fake.runtime()}
+
+	require.NoError(t, module.Set(testContext(op)))
+	created := fake.indexes[testDatabase+"/collectionGroups/Task"]
+	require.Len(t, created, 1)
+	require.Equal(t, apiScopeDatastore, created[0].ApiScope)
+	require.Equal(t, arrayContains, created[0].Fields[1].ArrayConfig)
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The same fields with the Firestore API scope are a different index.
+	op.Inputs[inputAPIScope] = blackstart.NewInputFromValue(apiScopeAny)
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestIndex_DoesNotExist(t *testing.T) {
+	fake := newFakeFirestore(t)
+	op := testIndexOperation()
+	module := &index{runtime: fake.runtime()}
+	require.NoError(t, module.Set(testContext(op)))
+
+	op.DoesNotExist = true
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Empty(t, fake.indexes[testCollectionGroup])
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete, "/indexes/index-1"))
+}
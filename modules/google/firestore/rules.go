@@ -0,0 +1,235 @@
+package firestore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/firebaserules/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	// rulesRelease is the name of the release of the rules of the default database. The releases of
+	// other databases are named with the ID of the database appended.
+	rulesRelease = "cloud.firestore"
+
+	// rulesFile is the name of the file of the source of a ruleset.
+	rulesFile = "firestore.rules"
+)
+
+func init() {
+	blackstart.RegisterModule("google_firestore_rules", NewRules)
+}
+
+var _ blackstart.Module = &rules{}
+
+// rules manages the security rules released for a Firestore database.
+type rules struct {
+	runtime  *firestoreRuntime
+	svc      *firebaserules.Service
+	database databaseTarget
+	source   string
+}
+
+func NewRules() blackstart.Module {
+	return &rules{}
+}
+
+func (r *rules) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_firestore_rules",
+		Name: "Google Firestore Security Rules",
+		Description: util.CleanString(
+			`
+Ensures the security rules of a Firestore database are released. When the released rules differ
+from '''rules''', a new ruleset is created from them and released to the database.
+
+**Notes**
+
+- Rules are compared to the source of the released ruleset, ignoring leading and trailing
+  whitespace. Rules changed outside of Blackstart, such as in the Firebase console, are replaced.
+- Previous rulesets are kept, so a release can be rolled back in the Firebase console. Firebase
+  limits a project to 2500 rulesets; unused rulesets can be deleted in the Firebase console.
+- Security rules only apply to databases in Firestore Native mode.
+- '''doesNotExist''' is not supported, since a database always has released rules.
+`,
+		),
+		Requirements: []string{
+			"The Firebase Rules API (`firebaserules.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/firebaserules.admin` in the project.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Project of the database. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputDatabase: {
+				Description: "ID of the database.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultDatabase,
+			},
+			inputRules: {
+				Description: "Source of the security rules, the content of a `firestore.rules` file.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputRuleset: {
+				Description: "Resource name of the released ruleset, `projects/<project>/rulesets/<ruleset>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputRelease: {
+				Description: "Resource name of the release of the rules of the database, such as `projects/<project>/releases/cloud.firestore`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Authenticated Users": `id: firestore-rules
+module: google_firestore_rules
+inputs:
+  project: app-project
+  rules: |
+    rules_version = '2';
+    service cloud.firestore {
+      match /databases/{database}/documents {
+        match /users/{userId}/{document=**} {
+          allow read, write: if request.auth != null && request.auth.uid == userId;
+        }
+      }
+    }`,
+		},
+	}
+}
+
+func (r *rules) Validate(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported, the rules of a database cannot be removed")
+	}
+	return cloud.RequiredString(op, inputRules)
+}
+
+// Check reports whether the rules are released for the database.
+func (r *rules) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := r.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(r.releaseName())
+
+	release, err := r.getRelease(ctx)
+	if err != nil || release == nil || ctx.Tainted() {
+		return false, err
+	}
+	ruleset, err := r.svc.Projects.Rulesets.Get(release.RulesetName).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get ruleset %s: %w", release.RulesetName, err)
+	}
+	if !r.sameSource(ruleset) {
+		return false, nil
+	}
+	return true, r.output(ctx, release.RulesetName)
+}
+
+// Set creates a ruleset from the rules and releases it for the database.
+func (r *rules) Set(ctx blackstart.ModuleContext) error {
+	if err := r.setup(ctx); err != nil {
+		return err
+	}
+	name := r.releaseName()
+	ctx.Resource(name)
+
+	ruleset, err := r.svc.Projects.Rulesets.Create(
+		r.projectName(), &firebaserules.Ruleset{
+			Source: &firebaserules.Source{Files: []*firebaserules.File{{Name: rulesFile, Content: r.source}}},
+		},
+	).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to create ruleset for %s: %w", r.database.name(), err)
+	}
+
+	existing, err := r.getRelease(ctx)
+	if err != nil {
+		return err
+	}
+	release := &firebaserules.Release{Name: name, RulesetName: ruleset.Name}
+	if existing == nil {
+		_, err = r.svc.Projects.Releases.Create(r.projectName(), release).Context(ctx).Do()
+	} else {
+		_, err = r.svc.Projects.Releases.Patch(name, &firebaserules.UpdateReleaseRequest{Release: release}).
+			Context(ctx).Do()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to release ruleset %s as %s: %w", ruleset.Name, name, err)
+	}
+	return r.output(ctx, ruleset.Name)
+}
+
+// setup resolves the database and rules from the inputs and creates the Firebase Rules service.
+func (r *rules) setup(ctx blackstart.ModuleContext) error {
+	var err error
+	if r.database, err = contextDatabase(ctx); err != nil {
+		return err
+	}
+	if r.source, err = blackstart.ContextInputAs[string](ctx, inputRules, true); err != nil {
+		return err
+	}
+
+	r.runtime = firestoreRuntimeOrDefault(r.runtime)
+	r.svc, err = r.runtime.newRulesService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Firebase Rules service: %w", err)
+	}
+	return nil
+}
+
+// projectName returns the resource name of the project of the database.
+func (r *rules) projectName() string {
+	return "projects/" + r.database.project
+}
+
+// releaseName returns the resource name of the release of the rules of the database.
+func (r *rules) releaseName() string {
+	name := r.projectName() + "/releases/" + rulesRelease
+	if r.database.id != defaultDatabase {
+		name += "/" + r.database.id
+	}
+	return name
+}
+
+// getRelease returns the release of the rules of the database, or nil if it does not exist.
+func (r *rules) getRelease(ctx context.Context) (*firebaserules.Release, error) {
+	release, err := r.svc.Projects.Releases.Get(r.releaseName()).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get release %s: %w", r.releaseName(), err)
+	}
+	return release, nil
+}
+
+// sameSource reports whether a ruleset has a single file with the rules.
+func (r *rules) sameSource(ruleset *firebaserules.Ruleset) bool {
+	if ruleset.Source == nil || len(ruleset.Source.Files) != 1 {
+		return false
+	}
+	return strings.TrimSpace(ruleset.Source.Files[0].Content) == strings.TrimSpace(r.source)
+}
+
+// output emits the outputs of the released rules.
+func (r *rules) output(ctx blackstart.ModuleContext, ruleset string) error {
+	if err := ctx.Output(outputRuleset, ruleset); err != nil {
+		return err
+	}
+	return ctx.Output(outputRelease, r.releaseName())
+}
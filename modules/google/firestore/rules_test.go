@@ -0,0 +1,99 @@
+package firestore
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/firebaserules/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+const testRules = `rules_version = '2';
+service cloud.firestore {
+  match /databases/{database}/documents {
+    match /{document=**} {
+      allow read, write: if request.auth != null;
+    }
+  }
+}
+`
+
+// testRulesOperation returns a rules operation for the default database.
+func testRulesOperation() *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "firestore-rules",
+		Module: "google_firestore_rules",
+		Inputs: map[string]blackstart.Input{
+			inputProject: blackstart.NewInputFromValue("app-project"),
+			inputRules:   blackstart.NewInputFromValue(testRules),
+		},
+	}
+}
+
+func TestRules_Validate(t *testing.T) {
+	require.NoError(t, NewRules().Validate(*testRulesOperation()))
+
+	op := testRulesOperation()
+	op.Inputs[inputRules] = blackstart.NewInputFromValue("")
+	require.ErrorContains(t, NewRules().Validate(*op), "invalid rules")
+
+	op = testRulesOperation()
+	op.DoesNotExist = true
+	require.ErrorContains(t, NewRules().Validate(*op), "doesNotExist is not supported")
+}
+
+func TestRules_Release(t *testing.T) {
+	fake := newFakeFirestore(t)
+	op := testRulesOperation()
+	module := &rules{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, ctx.outputs)
+
+	require.NoError(t, module.Set(ctx))
+	release := fake.releases["projects/app-project/releases/cloud.firestore"]
+	require.NotNil(t, release)
+	require.Equal(t, "projects/app-project/rulesets/ruleset-1", release.RulesetName)
+	require.Equal(t, release.RulesetName, ctx.outputs[outputRuleset])
+	require.Equal(t, "projects/app-project/releases/cloud.firestore", ctx.outputs[outputRelease])
+	files := fake.rulesets[release.RulesetName].Source.Files
+	require.Equal(t, []*firebaserules.File{{Name: rulesFile, Content: testRules}}, files)
+
+	// Surrounding whitespace does not change the rules.
+	op.Inputs[inputRules] = blackstart.NewInputFromValue("\n" + testRules + "\n\n")
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Changed rules are released as a new ruleset.
+	op.Inputs[inputRules] = blackstart.NewInputFromValue(testRules + "// reviewed\n")
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, module.Set(testContext(op)))
+	release = fake.releases["projects/app-project/releases/cloud.firestore"]
+	require.Equal(t, "projects/app-project/rulesets/ruleset-2", release.RulesetName)
+	require.Equal(t, 1, fake.requestCount(http.MethodPatch, "/releases/cloud.firestore"))
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestRules_NamedDatabase(t *testing.T) {
+	fake := newFakeFirestore(t)
+	op := testRulesOperation()
+	op.Inputs[inputDatabase] = blackstart.NewInputFromValue("orders")
+	module := &rules{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, "projects/app-project/releases/cloud.firestore/orders", ctx.outputs[outputRelease])
+	require.Contains(t, fake.releases, "projects/app-project/releases/cloud.firestore/orders")
+	require.NotContains(t, fake.releases, "projects/app-project/releases/cloud.firestore")
+}
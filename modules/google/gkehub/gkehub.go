@@ -0,0 +1,94 @@
+package gkehub
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/gkehub/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject          = "project"
+	inputLocation         = "location"
+	inputMembership       = "membership"
+	inputCluster          = "cluster"
+	inputWorkloadIdentity = "workload_identity"
+
+	outputMembership           = "membership"
+	outputUniqueId             = "unique_id"
+	outputWorkloadIdentityPool = "workload_identity_pool"
+	outputIdentityProvider     = "identity_provider"
+
+	// defaultLocation is the location of memberships of GKE clusters.
+	defaultLocation = "global"
+
+	// containerResourcePrefix is the prefix of the full resource name of GKE clusters.
+	containerResourcePrefix = "//container.googleapis.com/"
+
+	// containerIssuerPrefix is the prefix of the OIDC issuer of GKE clusters.
+	containerIssuerPrefix = "https://container.googleapis.com/v1/"
+)
+
+// GKE Hub mutations return long-running operations. They are polled with exponential backoff
+// between these intervals until they are done.
+var (
+	operationPollInitialInterval = 1 * time.Second
+	operationPollMaxInterval     = 10 * time.Second
+)
+
+func init() {
+	blackstart.RegisterPathName("gkehub", "GKE Hub")
+}
+
+// gkeHubRuntime provides the injectable GKE Hub API dependency.
+type gkeHubRuntime struct {
+	newService func(context.Context) (*gkehub.Service, error)
+}
+
+// defaultGKEHubRuntime creates the production GKE Hub runtime.
+func defaultGKEHubRuntime() *gkeHubRuntime {
+	return &gkeHubRuntime{
+		newService: func(ctx context.Context) (*gkehub.Service, error) {
+			return cloud.NewService(ctx, gkehub.NewService, nil, gkehub.CloudPlatformScope)
+		},
+	}
+}
+
+// gkeHubRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
+func gkeHubRuntimeOrDefault(runtime *gkeHubRuntime) *gkeHubRuntime {
+	if runtime == nil {
+		return defaultGKEHubRuntime()
+	}
+	return runtime
+}
+
+// clusterPath normalizes a GKE cluster to its relative resource name,
+// projects/<project>/locations/<location>/clusters/<cluster>. The full resource name with the
+// //container.googleapis.com/ prefix is also accepted.
+func clusterPath(cluster string) (string, error) {
+	path := strings.TrimPrefix(strings.TrimSpace(cluster), containerResourcePrefix)
+	parts := strings.Split(path, "/")
+	if len(parts) != 6 || parts[0] != "projects" || parts[2] != "locations" || parts[4] != "clusters" ||
+		parts[1] == "" || parts[3] == "" || parts[5] == "" {
+		return "", fmt.Errorf(
+			"cluster must be in the form projects/<project>/locations/<location>/clusters/<cluster>: %s", cluster,
+		)
+	}
+	return path, nil
+}
+
+// waitForOperation polls a GKE Hub operation until it is done and returns any error reported by
+// the operation.
+func waitForOperation(ctx context.Context, svc *gkehub.Service, op *gkehub.Operation) error {
+	polling := cloud.OperationPolling{InitialInterval: operationPollInitialInterval, MaxInterval: operationPollMaxInterval}
+	return cloud.WaitForOperation(
+		ctx, op, polling, func(ctx context.Context, name string) (*gkehub.Operation, error) {
+			return svc.Projects.Locations.Operations.Get(name).Context(ctx).Do()
+		},
+	)
+}
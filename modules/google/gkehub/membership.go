@@ -0,0 +1,356 @@
+package gkehub
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/api/gkehub/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_gkehub_membership", NewMembership)
+}
+
+var _ blackstart.Module = &membership{}
+
+// membership manages the fleet membership of a GKE cluster.
+type membership struct {
+	runtime *gkeHubRuntime
+	svc     *gkehub.Service
+	target  *membershipTarget
+}
+
+// membershipTarget is the desired state of a membership resolved from the module inputs.
+type membershipTarget struct {
+	name             string
+	parent           string
+	id               string
+	cluster          string
+	workloadIdentity bool
+}
+
+func NewMembership() blackstart.Module {
+	return &membership{}
+}
+
+func (m *membership) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_gkehub_membership",
+		Name: "Google GKE Hub Membership",
+		Description: util.CleanString(
+			`
+Registers a GKE cluster to a fleet by ensuring its GKE Hub membership exists, and outputs the
+membership identity for fleet Workload Identity bindings.
+
+**Notes**
+
+- Fleet Workload Identity is enabled by default. The '''workload_identity_pool''' and
+  '''identity_provider''' outputs are empty when it is disabled.
+- The cluster of an existing membership cannot be changed. The operation fails when the membership
+  is registered to a different cluster.
+- With '''doesNotExist''', the membership is deleted and the cluster is unregistered from the fleet.
+`,
+		),
+		Requirements: []string{
+			"The GKE Hub API (`gkehub.googleapis.com`) must be enabled in the fleet host project.",
+			"The Google identity must have `roles/gkehub.admin` in the fleet host project, and `container.clusters.get` on the cluster.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Fleet host project of the membership. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLocation: {
+				Description: "Location of the membership",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultLocation,
+			},
+			inputMembership: {
+				Description: "ID of the membership, such as the cluster name",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputCluster: {
+				Description: "GKE cluster to register, as `projects/<project>/locations/<location>/clusters/<cluster>`",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+				Immutable:   true,
+			},
+			inputWorkloadIdentity: {
+				Description: "Enable fleet Workload Identity for the membership",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     true,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputMembership: {
+				Description: "Full resource name of the membership",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputUniqueId: {
+				Description: "Unique ID of the membership",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputWorkloadIdentityPool: {
+				Description: "Workload Identity pool of the fleet, such as `<project>.svc.id.goog`",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputIdentityProvider: {
+				Description: "Identity provider of the membership for fleet Workload Identity",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Register Cluster to Fleet": `id: fleet-membership
+module: google_gkehub_membership
+inputs:
+  project: fleet-host-project
+  membership: prod-cluster
+  cluster: projects/prod-project/locations/us-central1/clusters/prod-cluster`,
+		},
+	}
+}
+
+func (m *membership) Validate(op blackstart.Operation) error {
+	for _, p := range []string{inputMembership, inputCluster} {
+		input, ok := op.Inputs[p]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", p)
+		}
+		if !input.IsStatic() {
+			continue
+		}
+		value, err := blackstart.InputAs[string](input, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", p, err)
+		}
+		if value == "" {
+			return fmt.Errorf("%s cannot be empty", p)
+		}
+	}
+
+	if clusterInput := op.Inputs[inputCluster]; clusterInput.IsStatic() {
+		cluster, err := blackstart.InputAs[string](clusterInput, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputCluster, err)
+		}
+		if _, err = clusterPath(cluster); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the membership is in the requested state.
+func (m *membership) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := m.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(m.target.name)
+
+	existing, err := m.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if err = m.verifyCluster(existing); err != nil {
+		return false, err
+	}
+	if membershipIssuer(existing) != m.desiredIssuer() {
+		return false, nil
+	}
+	return true, outputMembershipValues(ctx, existing)
+}
+
+// Set reconciles the membership to the requested state.
+func (m *membership) Set(ctx blackstart.ModuleContext) error {
+	if err := m.setup(ctx); err != nil {
+		return err
+	}
+	ctx.Resource(m.target.name)
+
+	existing, err := m.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		op, err := m.svc.Projects.Locations.Memberships.Delete(m.target.name).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to delete membership %s: %w", m.target.name, err)
+		}
+		return waitForOperation(ctx, m.svc, op)
+	}
+
+	authority := &gkehub.Authority{Issuer: m.desiredIssuer()}
+	if existing == nil {
+		op, err := m.svc.Projects.Locations.Memberships.Create(
+			m.target.parent, &gkehub.Membership{
+				Endpoint: &gkehub.MembershipEndpoint{
+					GkeCluster: &gkehub.GkeCluster{ResourceLink: containerResourcePrefix + m.target.cluster},
+				},
+				Authority: authority,
+			},
+		).MembershipId(m.target.id).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create membership %s: %w", m.target.name, err)
+		}
+		if err = waitForOperation(ctx, m.svc, op); err != nil {
+			return err
+		}
+	} else {
+		if err = m.verifyCluster(existing); err != nil {
+			return err
+		}
+		op, err := m.svc.Projects.Locations.Memberships.Patch(
+			m.target.name, &gkehub.Membership{Authority: authority},
+		).UpdateMask("authority").Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to update membership %s: %w", m.target.name, err)
+		}
+		if err = waitForOperation(ctx, m.svc, op); err != nil {
+			return err
+		}
+	}
+
+	// The identity of the membership is set by GKE Hub, so it is read after the change.
+	updated, err := m.get(ctx)
+	if err != nil {
+		return err
+	}
+	if updated == nil {
+		return fmt.Errorf("membership %s was not found after it was registered", m.target.name)
+	}
+	return outputMembershipValues(ctx, updated)
+}
+
+// setup resolves the target membership from the inputs and creates the GKE Hub service.
+func (m *membership) setup(ctx blackstart.ModuleContext) error {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	location, err := blackstart.ContextInputAs[string](ctx, inputLocation, false)
+	if err != nil {
+		return err
+	}
+	if location == "" {
+		location = defaultLocation
+	}
+	id, err := blackstart.ContextInputAs[string](ctx, inputMembership, true)
+	if err != nil {
+		return err
+	}
+	cluster, err := blackstart.ContextInputAs[string](ctx, inputCluster, true)
+	if err != nil {
+		return err
+	}
+	cluster, err = clusterPath(cluster)
+	if err != nil {
+		return err
+	}
+	workloadIdentity, err := blackstart.ContextInputAs[bool](ctx, inputWorkloadIdentity, false)
+	if err != nil {
+		return err
+	}
+
+	parent := fmt.Sprintf("projects/%s/locations/%s", project, location)
+	m.target = &membershipTarget{
+		name:             parent + "/memberships/" + id,
+		parent:           parent,
+		id:               id,
+		cluster:          cluster,
+		workloadIdentity: workloadIdentity,
+	}
+
+	m.runtime = gkeHubRuntimeOrDefault(m.runtime)
+	m.svc, err = m.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create GKE Hub service: %w", err)
+	}
+	return nil
+}
+
+// get returns the membership, or nil if it does not exist.
+func (m *membership) get(ctx context.Context) (*gkehub.Membership, error) {
+	existing, err := m.svc.Projects.Locations.Memberships.Get(m.target.name).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get membership %s: %w", m.target.name, err)
+	}
+	return existing, nil
+}
+
+// verifyCluster returns an error when the membership is registered to a different cluster.
+func (m *membership) verifyCluster(existing *gkehub.Membership) error {
+	want := containerResourcePrefix + m.target.cluster
+	var got string
+	if existing.Endpoint != nil && existing.Endpoint.GkeCluster != nil {
+		got = existing.Endpoint.GkeCluster.ResourceLink
+	}
+	if got != want {
+		return fmt.Errorf("membership %s is registered to %q instead of %q", m.target.name, got, want)
+	}
+	return nil
+}
+
+// desiredIssuer returns the OIDC issuer of the membership, or an empty string when fleet Workload
+// Identity is disabled.
+func (m *membership) desiredIssuer() string {
+	if !m.target.workloadIdentity {
+		return ""
+	}
+	return containerIssuerPrefix + m.target.cluster
+}
+
+// membershipIssuer returns the OIDC issuer of the membership.
+func membershipIssuer(existing *gkehub.Membership) string {
+	if existing.Authority == nil {
+		return ""
+	}
+	return existing.Authority.Issuer
+}
+
+// outputMembershipValues emits the identity of the membership.
+func outputMembershipValues(ctx blackstart.ModuleContext, existing *gkehub.Membership) error {
+	var pool, provider string
+	if existing.Authority != nil {
+		pool, provider = existing.Authority.WorkloadIdentityPool, existing.Authority.IdentityProvider
+	}
+	outputs := map[string]string{
+		outputMembership:           existing.Name,
+		outputUniqueId:             existing.UniqueId,
+		outputWorkloadIdentityPool: pool,
+		outputIdentityProvider:     provider,
+	}
+	for key, value := range outputs {
+		if err := ctx.Output(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+package gkehub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/gkehub/v1"
+	"google.golang.org/api/option"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+const testMembershipName = "projects/fleet/locations/global/memberships/prod"
+
+// fakeGKEHub implements the GKE Hub REST operations used by the membership module.
+type fakeGKEHub struct {
+	t          *testing.T
+	server     *httptest.Server
+	membership *gkehub.Membership
+	requests   []string
+	patchMasks []string
+	// Operations reports operations as running until PendingPolls polls were made.
+	cloudtest.Operations
+	mu sync.Mutex
+}
+
+// newFakeGKEHub starts a stateful fake GKE Hub API server.
+func newFakeGKEHub(t *testing.T) *fakeGKEHub {
+	t.Helper()
+	f := &fakeGKEHub{t: t}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a GKE Hub runtime connected to the fake API.
+func (f *fakeGKEHub) runtime() *gkeHubRuntime {
+	return &gkeHubRuntime{
+		newService: func(ctx context.Context) (*gkehub.Service, error) {
+			return gkehub.NewService(ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication())
+		},
+	}
+}
+
+// serveHTTP handles the GKE Hub API operations used by the unit tests.
+func (f *fakeGKEHub) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	switch {
+	case r.Method == http.MethodGet && strings.Contains(path, "/operations/"):
+		cloudtest.WriteJSON(f.t, w, f.Poll(path))
+	case r.Method == http.MethodGet && path == testMembershipName:
+		if f.membership == nil {
+			http.Error(w, "membership not found", http.StatusNotFound)
+			return
+		}
+		cloudtest.WriteJSON(f.t, w, f.membership)
+	case r.Method == http.MethodPost && path == "projects/fleet/locations/global/memberships":
+		var m gkehub.Membership
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&m))
+		require.Equal(f.t, "prod", r.URL.Query().Get("membershipId"))
+		m.Name = testMembershipName
+		m.UniqueId = "unique-id"
+		setIdentity(&m)
+		f.membership = &m
+		cloudtest.WriteJSON(f.t, w, f.operation())
+	case r.Method == http.MethodPatch && path == testMembershipName:
+		var m gkehub.Membership
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&m))
+		f.patchMasks = append(f.patchMasks, r.URL.Query().Get("updateMask"))
+		f.membership.Authority = m.Authority
+		setIdentity(f.membership)
+		cloudtest.WriteJSON(f.t, w, f.operation())
+	case r.Method == http.MethodDelete && path == testMembershipName:
+		f.membership = nil
+		cloudtest.WriteJSON(f.t, w, f.operation())
+	default:
+		f.t.Errorf("unexpected GKE Hub API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// operation returns the operation of a mutation, which is done after PendingPolls polls.
+func (f *fakeGKEHub) operation() cloudtest.Operation {
+	return f.Start("projects/fleet/locations/global/operations/op-1")
+}
+
+// requestCount returns the number of requests received with the method.
+func (f *fakeGKEHub) requestCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, request := range f.requests {
+		if strings.HasPrefix(request, method+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+// setIdentity sets the fleet Workload Identity fields GKE Hub derives for a membership with an
+// issuer.
+func setIdentity(m *gkehub.Membership) {
+	if m.Authority == nil || m.Authority.Issuer == "" {
+		m.Authority = nil
+		return
+	}
+	m.Authority.WorkloadIdentityPool = "fleet.svc.id.goog"
+	m.Authority.IdentityProvider = "https://gkehub.googleapis.com/" + testMembershipName
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testMembershipOperation creates a membership operation for the prod cluster.
+func testMembershipOperation() blackstart.Operation {
+	return blackstart.Operation{
+		Id:     "membership",
+		Module: "google_gkehub_membership",
+		Inputs: map[string]blackstart.Input{
+			inputProject:    blackstart.NewInputFromValue("fleet"),
+			inputMembership: blackstart.NewInputFromValue("prod"),
+			inputCluster:    blackstart.NewInputFromValue("projects/prod/locations/us-central1/clusters/prod"),
+		},
+	}
+}
+
+func TestMembership_Validate(t *testing.T) {
+	tests := map[string]struct {
+		inputs  map[string]blackstart.Input
+		wantErr bool
+	}{
+		"valid": {
+			inputs: testMembershipOperation().Inputs,
+		},
+		"full cluster resource name": {
+			inputs: map[string]blackstart.Input{
+				inputMembership: blackstart.NewInputFromValue("prod"),
+				inputCluster: blackstart.NewInputFromValue(
+					"//container.googleapis.com/projects/prod/locations/us-central1/clusters/prod",
+				),
+			},
+		},
+		"missing cluster": {
+			inputs: map[string]blackstart.Input{
+				inputMembership: blackstart.NewInputFromValue("prod"),
+			},
+			wantErr: true,
+		},
+		"invalid cluster": {
+			inputs: map[string]blackstart.Input{
+				inputMembership: blackstart.NewInputFromValue("prod"),
+				inputCluster:    blackstart.NewInputFromValue("prod-cluster"),
+			},
+			wantErr: true,
+		},
+		"empty membership": {
+			inputs: map[string]blackstart.Input{
+				inputMembership: blackstart.NewInputFromValue(""),
+				inputCluster:    blackstart.NewInputFromValue("projects/prod/locations/us-central1/clusters/prod"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				err := NewMembership().Validate(blackstart.Operation{Inputs: tt.inputs})
+				if tt.wantErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+			},
+		)
+	}
+}
+
+func TestMembership_CheckSet(t *testing.T) {
+	initial := operationPollInitialInterval
+	operationPollInitialInterval = time.Millisecond
+	t.Cleanup(func() { operationPollInitialInterval = initial })
+
+	api := newFakeGKEHub(t)
+	api.PendingPolls = 2
+	op := testMembershipOperation()
+
+	ctx := blackstart.OpContext(context.Background(), &op)
+	module := &membership{runtime: api.runtime()}
+	got, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, got)
+
+	require.NoError(t, module.Set(ctx))
+	require.NotNil(t, api.membership)
+	require.Equal(
+		t, "//container.googleapis.com/projects/prod/locations/us-central1/clusters/prod",
+		api.membership.Endpoint.GkeCluster.ResourceLink,
+	)
+	require.Equal(
+		t, "https://container.googleapis.com/v1/projects/prod/locations/us-central1/clusters/prod",
+		api.membership.Authority.Issuer,
+	)
+	require.Zero(t, api.PendingPolls)
+
+	outputs := &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), &op),
+		outputs:       map[string]any{},
+	}
+	got, err = (&membership{runtime: api.runtime()}).Check(outputs)
+	require.NoError(t, err)
+	require.True(t, got)
+	require.Equal(
+		t, map[string]any{
+			outputMembership:           testMembershipName,
+			outputUniqueId:             "unique-id",
+			outputWorkloadIdentityPool: "fleet.svc.id.goog",
+			outputIdentityProvider:     "https://gkehub.googleapis.com/" + testMembershipName,
+		}, outputs.outputs,
+	)
+
+	// Disabling fleet Workload Identity removes the issuer of the existing membership.
+	op.Inputs[inputWorkloadIdentity] = blackstart.NewInputFromValue(false)
+	ctx = blackstart.OpContext(context.Background(), &op)
+	got, err = (&membership{runtime: api.runtime()}).Check(ctx)
+	require.NoError(t, err)
+	require.False(t, got)
+	require.NoError(t, (&membership{runtime: api.runtime()}).Set(ctx))
+	require.Equal(t, []string{"authority"}, api.patchMasks)
+	require.Nil(t, api.membership.Authority)
+
+	op.DoesNotExist = true
+	ctx = blackstart.OpContext(context.Background(), &op)
+	got, err = (&membership{runtime: api.runtime()}).Check(ctx)
+	require.NoError(t, err)
+	require.False(t, got)
+	require.NoError(t, (&membership{runtime: api.runtime()}).Set(ctx))
+	require.Nil(t, api.membership)
+	got, err = (&membership{runtime: api.runtime()}).Check(ctx)
+	require.NoError(t, err)
+	require.True(t, got)
+}
+
+func TestMembership_DifferentCluster(t *testing.T) {
+	api := newFakeGKEHub(t)
+	api.membership = &gkehub.Membership{
+		Name: testMembershipName,
+		Endpoint: &gkehub.MembershipEndpoint{
+			GkeCluster: &gkehub.GkeCluster{
+				ResourceLink: "//container.googleapis.com/projects/prod/locations/us-east1/clusters/other",
+			},
+		},
+	}
+	op := testMembershipOperation()
+	ctx := blackstart.OpContext(context.Background(), &op)
+
+	_, err := (&membership{runtime: api.runtime()}).Check(ctx)
+	require.ErrorContains(t, err, "is registered to")
+	require.ErrorContains(t, (&membership{runtime: api.runtime()}).Set(ctx), "is registered to")
+	require.Zero(t, api.requestCount(http.MethodPatch))
+}
+
+func TestClusterPath(t *testing.T) {
+	tests := map[string]struct {
+		cluster string
+		want    string
+		wantErr bool
+	}{
+		"relative name": {
+			cluster: "projects/p/locations/us-central1/clusters/c",
+			want:    "projects/p/locations/us-central1/clusters/c",
+		},
+		"full resource name": {
+			cluster: "//container.googleapis.com/projects/p/locations/us-central1/clusters/c",
+			want:    "projects/p/locations/us-central1/clusters/c",
+		},
+		"cluster name only": {
+			cluster: "c",
+			wantErr: true,
+		},
+		"zones": {
+			cluster: "projects/p/zones/us-central1-a/clusters/c",
+			wantErr: true,
+		},
+		"empty cluster": {
+			cluster: "projects/p/locations/us-central1/clusters/",
+			wantErr: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				got, err := clusterPath(tt.cluster)
+				if tt.wantErr {
+					require.Error(t, err)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
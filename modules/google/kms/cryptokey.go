@@ -0,0 +1,497 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+
+	"google.golang.org/api/cloudkms/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+// minRotationPeriod is the shortest automatic rotation period accepted by Cloud KMS.
+const minRotationPeriod = 24 * time.Hour
+
+// now returns the current time. Tests replace it to pin the next rotation time.
+var now = time.Now
+
+func init() {
+	blackstart.RegisterModule("google_kms_cryptokey", NewCryptoKey)
+}
+
+var _ blackstart.Module = &cryptoKey{}
+
+// cryptoKey manages a Cloud KMS crypto key, its rotation, and the IAM roles granted on it.
+type cryptoKey struct {
+	runtime *kmsRuntime
+	svc     *cloudkms.Service
+	target  *cryptoKeyTarget
+}
+
+// cryptoKeyTarget is the desired state of a crypto key resolved from the module inputs.
+type cryptoKeyTarget struct {
+	keyRing         string
+	id              string
+	purpose         string
+	algorithm       string
+	protectionLevel string
+	rotationPeriod  *time.Duration
+	labels          map[string]string
+	bindings        map[string][]string
+}
+
+// name returns the resource name of the crypto key.
+func (t *cryptoKeyTarget) name() string {
+	return t.keyRing + "/cryptoKeys/" + t.id
+}
+
+func NewCryptoKey() blackstart.Module {
+	return &cryptoKey{}
+}
+
+func (c *cryptoKey) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_kms_cryptokey",
+		Name: "Google Cloud KMS Crypto Key",
+		Description: util.CleanString(
+			`
+Ensures a Cloud KMS crypto key exists in a key ring with the labels and automatic rotation period,
+and grants IAM roles on the key, such as to allow a service account to encrypt and decrypt data
+encryption keys for envelope encryption.
+
+**Notes**
+
+- The purpose, algorithm, and protection level of an existing key cannot be changed. The operation
+  fails when they differ.
+- Automatic rotation is only supported for '''ENCRYPT_DECRYPT''' keys. When '''rotation_period''' is
+  not set, the rotation of the key is not changed.
+- Labels that are not set in '''labels''' and members granted a role by other IAM bindings of the
+  key are not removed.
+- Crypto keys cannot be deleted in Cloud KMS. When '''doesNotExist''' is set, all versions of the
+  key are scheduled for destruction and its automatic rotation is disabled, so the key can no
+  longer be used.
+`,
+		),
+		Requirements: []string{
+			"The Cloud KMS API (`cloudkms.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/cloudkms.admin` on the key ring.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputKeyRing: {
+				Description: "Resource name of the key ring, `projects/<project>/locations/<location>/keyRings/<keyring>`, such as the `keyring` output of `google_kms_keyring`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputKey: {
+				Description: "ID of the crypto key, such as `app-data`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputPurpose: {
+				Description: "Purpose of the key, such as `ENCRYPT_DECRYPT`, `ASYMMETRIC_SIGN`, or `MAC`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     purposeEncryptDecrypt,
+				Immutable:   true,
+			},
+			inputAlgorithm: {
+				Description: "Algorithm of the key versions, such as `GOOGLE_SYMMETRIC_ENCRYPTION` or `EC_SIGN_P256_SHA256`. Defaults to `GOOGLE_SYMMETRIC_ENCRYPTION` for `ENCRYPT_DECRYPT` keys and is required for other purposes.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Immutable:   true,
+			},
+			inputProtectionLevel: {
+				Description: "Protection level of the key versions, `SOFTWARE` or `HSM`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultProtectionLevel,
+				Immutable:   true,
+			},
+			inputRotationPeriod: {
+				Description: "Period of the automatic rotation of the key, such as `2160h` for 90 days. Must be at least `24h`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLabels: {
+				Description: "Labels the key must have, as a map of label keys to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+			inputIAMBindings: {
+				Description: fmt.Sprintf(iamBindingsInput, "crypto key"),
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputCryptoKey: {
+				Description: "Resource name of the crypto key, `projects/<project>/locations/<location>/keyRings/<keyring>/cryptoKeys/<key>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Envelope Encryption Key": `id: app-data-key
+module: google_kms_cryptokey
+inputs:
+  keyring:
+    fromDependency:
+      id: app-keyring
+      output: keyring
+  key: app-data
+  rotation_period: 2160h
+  labels:
+    team: payments
+  iam_bindings:
+    roles/cloudkms.cryptoKeyEncrypterDecrypter:
+      - serviceAccount:app@app-project.iam.gserviceaccount.com`,
+		},
+	}
+}
+
+func (c *cryptoKey) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputKeyRing, inputKey} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", key)
+		}
+		if input.IsStatic() {
+			value, err := blackstart.InputAs[string](input, true)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if value == "" {
+				return fmt.Errorf("%s cannot be empty", key)
+			}
+		}
+	}
+	if input, ok := op.Inputs[inputRotationPeriod]; ok && input.IsStatic() {
+		if _, err := inputRotation(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputLabels]; ok && input.IsStatic() {
+		if _, err := inputLabelsMap(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputIAMBindings]; ok && input.IsStatic() {
+		if _, err := inputBindings(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the crypto key is in the requested state.
+func (c *cryptoKey) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := c.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(c.target.name())
+
+	existing, err := c.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return true, nil
+		}
+		versions, vErr := c.destroyableVersions(ctx)
+		return len(versions) == 0 && existing.RotationPeriod == "", vErr
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if err = c.verifyImmutable(existing); err != nil {
+		return false, err
+	}
+	if _, mask := c.update(existing); len(mask) > 0 {
+		return false, nil
+	}
+	if len(c.target.bindings) > 0 {
+		policy, pErr := c.keys().GetIamPolicy(c.target.name()).Context(ctx).Do()
+		if pErr != nil {
+			return false, fmt.Errorf("failed to get IAM policy of crypto key %s: %w", c.target.name(), pErr)
+		}
+		if grantBindings(policy, c.target.bindings) {
+			return false, nil
+		}
+	}
+	return true, ctx.Output(outputCryptoKey, c.target.name())
+}
+
+// Set reconciles the crypto key to the requested state.
+func (c *cryptoKey) Set(ctx blackstart.ModuleContext) error {
+	if err := c.setup(ctx); err != nil {
+		return err
+	}
+	name := c.target.name()
+	ctx.Resource(name)
+
+	existing, err := c.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		return c.disable(ctx, existing)
+	}
+
+	if existing == nil {
+		created := &cloudkms.CryptoKey{
+			Purpose: c.target.purpose,
+			Labels:  c.target.labels,
+			VersionTemplate: &cloudkms.CryptoKeyVersionTemplate{
+				Algorithm:       c.target.algorithm,
+				ProtectionLevel: c.target.protectionLevel,
+			},
+		}
+		if c.target.rotationPeriod != nil {
+			created.RotationPeriod = rotationPeriod(*c.target.rotationPeriod)
+			created.NextRotationTime = nextRotationTime(*c.target.rotationPeriod)
+		}
+		existing, err = c.keys().Create(c.target.keyRing, created).CryptoKeyId(c.target.id).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create crypto key %s: %w", name, err)
+		}
+	}
+	if err = c.verifyImmutable(existing); err != nil {
+		return err
+	}
+
+	if patch, mask := c.update(existing); len(mask) > 0 {
+		_, err = c.keys().Patch(name, patch).UpdateMask(strings.Join(mask, ",")).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to update crypto key %s: %w", name, err)
+		}
+	}
+	if len(c.target.bindings) > 0 {
+		policy, pErr := c.keys().GetIamPolicy(name).Context(ctx).Do()
+		if pErr != nil {
+			return fmt.Errorf("failed to get IAM policy of crypto key %s: %w", name, pErr)
+		}
+		// The etag of the policy makes the update fail instead of overwriting a concurrent change.
+		if grantBindings(policy, c.target.bindings) {
+			_, err = c.keys().SetIamPolicy(name, &cloudkms.SetIamPolicyRequest{Policy: policy}).Context(ctx).Do()
+			if err != nil {
+				return fmt.Errorf("failed to set IAM policy of crypto key %s: %w", name, err)
+			}
+		}
+	}
+	return ctx.Output(outputCryptoKey, name)
+}
+
+// keys returns the crypto keys service of the Cloud KMS API.
+func (c *cryptoKey) keys() *cloudkms.ProjectsLocationsKeyRingsCryptoKeysService {
+	return c.svc.Projects.Locations.KeyRings.CryptoKeys
+}
+
+// setup resolves the target crypto key from the inputs and creates the Cloud KMS service.
+func (c *cryptoKey) setup(ctx blackstart.ModuleContext) error {
+	target := &cryptoKeyTarget{}
+	var err error
+	if target.keyRing, err = blackstart.ContextInputAs[string](ctx, inputKeyRing, true); err != nil {
+		return err
+	}
+	target.keyRing = strings.TrimSuffix(target.keyRing, "/")
+	if target.id, err = blackstart.ContextInputAs[string](ctx, inputKey, true); err != nil {
+		return err
+	}
+	if target.purpose, err = blackstart.ContextInputAs[string](ctx, inputPurpose, false); err != nil {
+		return err
+	}
+	if target.purpose == "" {
+		target.purpose = purposeEncryptDecrypt
+	}
+	if target.algorithm, err = blackstart.ContextInputAs[string](ctx, inputAlgorithm, false); err != nil {
+		return err
+	}
+	if target.algorithm == "" {
+		if target.purpose != purposeEncryptDecrypt {
+			return fmt.Errorf("%s is required for keys with purpose %s", inputAlgorithm, target.purpose)
+		}
+		target.algorithm = defaultSymmetricAlgorithm
+	}
+	target.protectionLevel, err = blackstart.ContextInputAs[string](ctx, inputProtectionLevel, false)
+	if err != nil {
+		return err
+	}
+	if target.protectionLevel == "" {
+		target.protectionLevel = defaultProtectionLevel
+	}
+	if input, iErr := ctx.Input(inputRotationPeriod); iErr == nil && input.Any() != nil {
+		if target.rotationPeriod, err = inputRotation(input); err != nil {
+			return err
+		}
+		if target.rotationPeriod != nil && target.purpose != purposeEncryptDecrypt {
+			return fmt.Errorf("%s is only supported for keys with purpose %s", inputRotationPeriod, purposeEncryptDecrypt)
+		}
+	}
+	if input, iErr := ctx.Input(inputLabels); iErr == nil && input.Any() != nil {
+		if target.labels, err = inputLabelsMap(input); err != nil {
+			return err
+		}
+	}
+	if target.bindings, err = contextBindings(ctx); err != nil {
+		return err
+	}
+	c.target = target
+
+	c.runtime = kmsRuntimeOrDefault(c.runtime)
+	c.svc, err = c.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud KMS service: %w", err)
+	}
+	return nil
+}
+
+// get returns the crypto key, or nil if it does not exist.
+func (c *cryptoKey) get(ctx context.Context) (*cloudkms.CryptoKey, error) {
+	existing, err := c.keys().Get(c.target.name()).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get crypto key %s: %w", c.target.name(), err)
+	}
+	return existing, nil
+}
+
+// verifyImmutable returns an error when the purpose, algorithm, or protection level of the crypto
+// key differ from the inputs.
+func (c *cryptoKey) verifyImmutable(existing *cloudkms.CryptoKey) error {
+	if existing.Purpose != c.target.purpose {
+		return fmt.Errorf(
+			"crypto key %s has purpose %s instead of %s", c.target.name(), existing.Purpose, c.target.purpose,
+		)
+	}
+	if template := existing.VersionTemplate; template != nil {
+		if template.Algorithm != "" && template.Algorithm != c.target.algorithm {
+			return fmt.Errorf(
+				"crypto key %s has algorithm %s instead of %s", c.target.name(), template.Algorithm,
+				c.target.algorithm,
+			)
+		}
+		if template.ProtectionLevel != "" && template.ProtectionLevel != c.target.protectionLevel {
+			return fmt.Errorf(
+				"crypto key %s has protection level %s instead of %s", c.target.name(), template.ProtectionLevel,
+				c.target.protectionLevel,
+			)
+		}
+	}
+	return nil
+}
+
+// update returns the patch and update mask that bring the crypto key to the desired state. The
+// mask is empty when the key is already in the desired state.
+func (c *cryptoKey) update(existing *cloudkms.CryptoKey) (*cloudkms.CryptoKey, []string) {
+	patch := &cloudkms.CryptoKey{}
+	var mask []string
+	if period := c.target.rotationPeriod; period != nil {
+		current, err := time.ParseDuration(existing.RotationPeriod)
+		if err != nil || current != *period {
+			patch.RotationPeriod = rotationPeriod(*period)
+			mask = append(mask, "rotationPeriod")
+			if existing.NextRotationTime == "" {
+				patch.NextRotationTime = nextRotationTime(*period)
+				mask = append(mask, "nextRotationTime")
+			}
+		}
+	}
+	for key, value := range c.target.labels {
+		if current, ok := existing.Labels[key]; !ok || current != value {
+			patch.Labels = maps.Clone(existing.Labels)
+			if patch.Labels == nil {
+				patch.Labels = make(map[string]string, len(c.target.labels))
+			}
+			maps.Copy(patch.Labels, c.target.labels)
+			mask = append(mask, "labels")
+			break
+		}
+	}
+	return patch, mask
+}
+
+// destroyableVersions returns the names of the versions of the crypto key that are not destroyed
+// or scheduled for destruction.
+func (c *cryptoKey) destroyableVersions(ctx context.Context) ([]string, error) {
+	var names []string
+	err := c.keys().CryptoKeyVersions.List(c.target.name()).Context(ctx).Pages(
+		ctx, func(resp *cloudkms.ListCryptoKeyVersionsResponse) error {
+			for _, v := range resp.CryptoKeyVersions {
+				if v.State == "ENABLED" || v.State == "DISABLED" {
+					names = append(names, v.Name)
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of crypto key %s: %w", c.target.name(), err)
+	}
+	return names, nil
+}
+
+// disable disables the automatic rotation of the crypto key and schedules all of its versions for
+// destruction.
+func (c *cryptoKey) disable(ctx context.Context, existing *cloudkms.CryptoKey) error {
+	name := c.target.name()
+	if existing.RotationPeriod != "" || existing.NextRotationTime != "" {
+		_, err := c.keys().Patch(name, &cloudkms.CryptoKey{}).UpdateMask("rotationPeriod,nextRotationTime").
+			Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to disable rotation of crypto key %s: %w", name, err)
+		}
+	}
+	versions, err := c.destroyableVersions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, version := range versions {
+		_, err = c.keys().CryptoKeyVersions.Destroy(version, &cloudkms.DestroyCryptoKeyVersionRequest{}).
+			Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to destroy crypto key version %s: %w", version, err)
+		}
+	}
+	return nil
+}
+
+// inputRotation returns the rotation period of a rotation_period input, or nil when it is empty.
+func inputRotation(input blackstart.Input) (*time.Duration, error) {
+	raw, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputRotationPeriod, err)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputRotationPeriod, err)
+	}
+	if period < minRotationPeriod {
+		return nil, fmt.Errorf("invalid %s: %s is shorter than %s", inputRotationPeriod, raw, minRotationPeriod)
+	}
+	return &period, nil
+}
+
+// rotationPeriod formats a rotation period as a duration of the Cloud KMS API, in seconds.
+func rotationPeriod(period time.Duration) string {
+	return fmt.Sprintf("%ds", int64(period.Seconds()))
+}
+
+// nextRotationTime returns the time of the first automatic rotation of a key with the period.
+func nextRotationTime(period time.Duration) string {
+	return now().UTC().Add(period).Format(time.RFC3339)
+}
@@ -0,0 +1,202 @@
+package kms
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/cloudkms/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+// testCryptoKeyOperation creates a crypto key operation for the app-data key.
+func testCryptoKeyOperation() blackstart.Operation {
+	return blackstart.Operation{
+		Id:     "key",
+		Module: "google_kms_cryptokey",
+		Inputs: map[string]blackstart.Input{
+			inputKeyRing:        blackstart.NewInputFromValue(testKeyRing),
+			inputKey:            blackstart.NewInputFromValue("app-data"),
+			inputRotationPeriod: blackstart.NewInputFromValue("2160h"),
+			inputLabels:         blackstart.NewInputFromValue(map[string]any{"team": "payments"}),
+			inputIAMBindings: blackstart.NewInputFromValue(
+				map[string]any{
+					"roles/cloudkms.cryptoKeyEncrypterDecrypter": []any{
+						"serviceAccount:app@app-project.iam.gserviceaccount.com",
+					},
+				},
+			),
+		},
+	}
+}
+
+// pinNow pins the current time of the module for the duration of the test.
+func pinNow(t *testing.T) time.Time {
+	t.Helper()
+	pinned := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	previous := now
+	now = func() time.Time { return pinned }
+	t.Cleanup(func() { now = previous })
+	return pinned
+}
+
+func TestCryptoKey_Validate(t *testing.T) {
+	module := NewCryptoKey()
+	require.NoError(t, module.Validate(testCryptoKeyOperation()))
+
+	op := testCryptoKeyOperation()
+	delete(op.Inputs, inputKeyRing)
+	require.ErrorContains(t, module.Validate(op), "missing required parameter: keyring")
+
+	op = testCryptoKeyOperation()
+	op.Inputs[inputRotationPeriod] = blackstart.NewInputFromValue("12h")
+	require.ErrorContains(t, module.Validate(op), "is shorter than 24h0m0s")
+
+	op = testCryptoKeyOperation()
+	op.Inputs[inputRotationPeriod] = blackstart.NewInputFromValue("90d")
+	require.ErrorContains(t, module.Validate(op), "invalid rotation_period")
+
+	op = testCryptoKeyOperation()
+	op.Inputs[inputLabels] = blackstart.NewInputFromValue(map[string]any{"team": []any{"a"}})
+	require.ErrorContains(t, module.Validate(op), "must be a scalar value")
+}
+
+func TestCryptoKey_CreateAndCheck(t *testing.T) {
+	pinned := pinNow(t)
+	fake := newFakeKMS(t)
+	op := testCryptoKeyOperation()
+	module := &cryptoKey{runtime: fake.runtime()}
+
+	ctx := testContext(&op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, testCryptoKey, ctx.outputs[outputCryptoKey])
+	key := fake.keys[testCryptoKey]
+	require.Equal(t, purposeEncryptDecrypt, key.Purpose)
+	require.Equal(t, defaultSymmetricAlgorithm, key.VersionTemplate.Algorithm)
+	require.Equal(t, defaultProtectionLevel, key.VersionTemplate.ProtectionLevel)
+	require.Equal(t, "7776000s", key.RotationPeriod)
+	require.Equal(t, pinned.Add(2160*time.Hour).Format(time.RFC3339), key.NextRotationTime)
+	require.Equal(t, map[string]string{"team": "payments"}, key.Labels)
+	require.Len(t, fake.policies[testCryptoKey].Bindings, 1)
+	require.Empty(t, fake.masks)
+
+	ok, err = module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestCryptoKey_UpdatesRotationAndLabels(t *testing.T) {
+	pinNow(t)
+	fake := newFakeKMS(t)
+	fake.keys[testCryptoKey] = &cloudkms.CryptoKey{
+		Name:             testCryptoKey,
+		Purpose:          purposeEncryptDecrypt,
+		RotationPeriod:   "31536000s",
+		NextRotationTime: "2026-06-01T00:00:00Z",
+		Labels:           map[string]string{"team": "web", "cost-center": "42"},
+		VersionTemplate: &cloudkms.CryptoKeyVersionTemplate{
+			Algorithm:       defaultSymmetricAlgorithm,
+			ProtectionLevel: defaultProtectionLevel,
+		},
+	}
+	op := testCryptoKeyOperation()
+	delete(op.Inputs, inputIAMBindings)
+	module := &cryptoKey{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(&op)))
+	// The next rotation time of a key with rotation is kept.
+	require.Equal(t, []string{"rotationPeriod,labels"}, fake.masks)
+	key := fake.keys[testCryptoKey]
+	require.Equal(t, "7776000s", key.RotationPeriod)
+	require.Equal(t, "2026-06-01T00:00:00Z", key.NextRotationTime)
+	require.Equal(t, map[string]string{"team": "payments", "cost-center": "42"}, key.Labels)
+
+	ok, err = module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestCryptoKey_ImmutableMismatch(t *testing.T) {
+	fake := newFakeKMS(t)
+	fake.keys[testCryptoKey] = &cloudkms.CryptoKey{
+		Name:    testCryptoKey,
+		Purpose: purposeEncryptDecrypt,
+		VersionTemplate: &cloudkms.CryptoKeyVersionTemplate{
+			Algorithm:       defaultSymmetricAlgorithm,
+			ProtectionLevel: "HSM",
+		},
+	}
+	op := testCryptoKeyOperation()
+	module := &cryptoKey{runtime: fake.runtime()}
+
+	_, err := module.Check(testContext(&op))
+	require.ErrorContains(t, err, "has protection level HSM instead of SOFTWARE")
+	require.ErrorContains(t, module.Set(testContext(&op)), "instead of")
+	require.Empty(t, fake.masks)
+}
+
+func TestCryptoKey_AsymmetricKey(t *testing.T) {
+	fake := newFakeKMS(t)
+	op := testCryptoKeyOperation()
+	op.Inputs[inputPurpose] = blackstart.NewInputFromValue("ASYMMETRIC_SIGN")
+	module := &cryptoKey{runtime: fake.runtime()}
+
+	_, err := module.Check(testContext(&op))
+	require.ErrorContains(t, err, "algorithm is required for keys with purpose ASYMMETRIC_SIGN")
+
+	op.Inputs[inputAlgorithm] = blackstart.NewInputFromValue("EC_SIGN_P256_SHA256")
+	_, err = module.Check(testContext(&op))
+	require.ErrorContains(t, err, "rotation_period is only supported for keys with purpose ENCRYPT_DECRYPT")
+
+	delete(op.Inputs, inputRotationPeriod)
+	require.NoError(t, module.Set(testContext(&op)))
+	require.Equal(t, "EC_SIGN_P256_SHA256", fake.keys[testCryptoKey].VersionTemplate.Algorithm)
+	require.Empty(t, fake.keys[testCryptoKey].RotationPeriod)
+}
+
+func TestCryptoKey_DoesNotExist(t *testing.T) {
+	fake := newFakeKMS(t)
+	fake.keys[testCryptoKey] = &cloudkms.CryptoKey{
+		Name:             testCryptoKey,
+		Purpose:          purposeEncryptDecrypt,
+		RotationPeriod:   "7776000s",
+		NextRotationTime: "2026-06-01T00:00:00Z",
+	}
+	fake.versions[testCryptoKey] = []*cloudkms.CryptoKeyVersion{
+		{Name: testCryptoKey + "/cryptoKeyVersions/1", State: "DESTROYED"},
+		{Name: testCryptoKey + "/cryptoKeyVersions/2", State: "DISABLED"},
+		{Name: testCryptoKey + "/cryptoKeyVersions/3", State: "ENABLED"},
+	}
+	op := testCryptoKeyOperation()
+	op.DoesNotExist = true
+	module := &cryptoKey{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(&op)))
+	require.Equal(t, []string{"rotationPeriod,nextRotationTime"}, fake.masks)
+	require.Empty(t, fake.keys[testCryptoKey].RotationPeriod)
+	require.Equal(t, 2, fake.requestCount(http.MethodPost, ":destroy"))
+	require.Equal(t, "DESTROYED", fake.versions[testCryptoKey][0].State)
+
+	ok, err = module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	delete(fake.keys, testCryptoKey)
+	ok, err = module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
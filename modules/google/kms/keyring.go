@@ -0,0 +1,227 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"google.golang.org/api/cloudkms/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_kms_keyring", NewKeyRing)
+}
+
+var _ blackstart.Module = &keyRing{}
+
+// keyRing manages a Cloud KMS key ring and the IAM roles granted on it.
+type keyRing struct {
+	runtime  *kmsRuntime
+	svc      *cloudkms.Service
+	name     string
+	parent   string
+	id       string
+	bindings map[string][]string
+}
+
+func NewKeyRing() blackstart.Module {
+	return &keyRing{}
+}
+
+func (k *keyRing) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_kms_keyring",
+		Name: "Google Cloud KMS Key Ring",
+		Description: util.CleanString(
+			`
+Ensures a Cloud KMS key ring exists, and grants IAM roles on the key ring, such as to allow a
+service account to use all keys of the key ring.
+
+**Notes**
+
+- Key rings cannot be deleted in Cloud KMS, so '''doesNotExist''' is not supported.
+- Members granted a role by other IAM bindings of the key ring are not removed.
+`,
+		),
+		Requirements: []string{
+			"The Cloud KMS API (`cloudkms.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/cloudkms.admin` in the project or on the key ring.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Google Cloud project of the key ring. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputLocation: {
+				Description: "Location of the key ring, such as `global` or `europe-west1`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+				Immutable:   true,
+			},
+			inputKeyRing: {
+				Description: "ID of the key ring, such as `app`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputIAMBindings: {
+				Description: fmt.Sprintf(iamBindingsInput, "key ring"),
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputKeyRing: {
+				Description: "Resource name of the key ring, `projects/<project>/locations/<location>/keyRings/<keyring>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Application Key Ring": `id: app-keyring
+module: google_kms_keyring
+inputs:
+  project: app-project
+  location: europe-west1
+  keyring: app
+  iam_bindings:
+    roles/cloudkms.viewer:
+      - group:security@example.com`,
+		},
+	}
+}
+
+func (k *keyRing) Validate(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported, key rings cannot be deleted")
+	}
+	for _, key := range []string{inputLocation, inputKeyRing} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", key)
+		}
+		if input.IsStatic() {
+			value, err := blackstart.InputAs[string](input, true)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+			if value == "" {
+				return fmt.Errorf("%s cannot be empty", key)
+			}
+		}
+	}
+	if input, ok := op.Inputs[inputIAMBindings]; ok && input.IsStatic() {
+		if _, err := inputBindings(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the key ring exists and the IAM roles are granted.
+func (k *keyRing) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := k.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(k.name)
+
+	existing, err := k.get(ctx)
+	if err != nil || existing == nil || ctx.Tainted() {
+		return false, err
+	}
+	if len(k.bindings) > 0 {
+		policy, pErr := k.svc.Projects.Locations.KeyRings.GetIamPolicy(k.name).Context(ctx).Do()
+		if pErr != nil {
+			return false, fmt.Errorf("failed to get IAM policy of key ring %s: %w", k.name, pErr)
+		}
+		if grantBindings(policy, k.bindings) {
+			return false, nil
+		}
+	}
+	return true, ctx.Output(outputKeyRing, k.name)
+}
+
+// Set creates the key ring when it does not exist, and grants the missing IAM roles.
+func (k *keyRing) Set(ctx blackstart.ModuleContext) error {
+	if err := k.setup(ctx); err != nil {
+		return err
+	}
+	ctx.Resource(k.name)
+
+	existing, err := k.get(ctx)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		_, err = k.svc.Projects.Locations.KeyRings.Create(k.parent, &cloudkms.KeyRing{}).KeyRingId(k.id).
+			Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to create key ring %s: %w", k.name, err)
+		}
+	}
+	if len(k.bindings) > 0 {
+		policy, pErr := k.svc.Projects.Locations.KeyRings.GetIamPolicy(k.name).Context(ctx).Do()
+		if pErr != nil {
+			return fmt.Errorf("failed to get IAM policy of key ring %s: %w", k.name, pErr)
+		}
+		// The etag of the policy makes the update fail instead of overwriting a concurrent change.
+		if grantBindings(policy, k.bindings) {
+			_, err = k.svc.Projects.Locations.KeyRings.SetIamPolicy(
+				k.name, &cloudkms.SetIamPolicyRequest{Policy: policy},
+			).Context(ctx).Do()
+			if err != nil {
+				return fmt.Errorf("failed to set IAM policy of key ring %s: %w", k.name, err)
+			}
+		}
+	}
+	return ctx.Output(outputKeyRing, k.name)
+}
+
+// setup resolves the key ring from the inputs and creates the Cloud KMS service.
+func (k *keyRing) setup(ctx blackstart.ModuleContext) error {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	location, err := blackstart.ContextInputAs[string](ctx, inputLocation, true)
+	if err != nil {
+		return err
+	}
+	k.id, err = blackstart.ContextInputAs[string](ctx, inputKeyRing, true)
+	if err != nil {
+		return err
+	}
+	k.parent = fmt.Sprintf("projects/%s/locations/%s", project, location)
+	k.name = k.parent + "/keyRings/" + k.id
+	if k.bindings, err = contextBindings(ctx); err != nil {
+		return err
+	}
+
+	k.runtime = kmsRuntimeOrDefault(k.runtime)
+	k.svc, err = k.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud KMS service: %w", err)
+	}
+	return nil
+}
+
+// get returns the key ring, or nil if it does not exist.
+func (k *keyRing) get(ctx context.Context) (*cloudkms.KeyRing, error) {
+	existing, err := k.svc.Projects.Locations.KeyRings.Get(k.name).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get key ring %s: %w", k.name, err)
+	}
+	return existing, nil
+}
@@ -0,0 +1,87 @@
+package kms
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/cloudkms/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+// testKeyRingOperation creates a key ring operation for the app key ring.
+func testKeyRingOperation() blackstart.Operation {
+	return blackstart.Operation{
+		Id:     "keyring",
+		Module: "google_kms_keyring",
+		Inputs: map[string]blackstart.Input{
+			inputProject:  blackstart.NewInputFromValue("app-project"),
+			inputLocation: blackstart.NewInputFromValue("europe-west1"),
+			inputKeyRing:  blackstart.NewInputFromValue("app"),
+			inputIAMBindings: blackstart.NewInputFromValue(
+				map[string]any{"roles/cloudkms.viewer": []any{"group:security@example.com"}},
+			),
+		},
+	}
+}
+
+func TestKeyRing_Validate(t *testing.T) {
+	module := NewKeyRing()
+	require.NoError(t, module.Validate(testKeyRingOperation()))
+
+	op := testKeyRingOperation()
+	delete(op.Inputs, inputLocation)
+	require.ErrorContains(t, module.Validate(op), "missing required parameter: location")
+
+	op = testKeyRingOperation()
+	op.DoesNotExist = true
+	require.ErrorContains(t, module.Validate(op), "key rings cannot be deleted")
+
+	op = testKeyRingOperation()
+	op.Inputs[inputIAMBindings] = blackstart.NewInputFromValue(map[string]any{"roles/x": []any{"ops"}})
+	require.ErrorContains(t, module.Validate(op), "invalid iam_bindings")
+}
+
+func TestKeyRing_CreateAndCheck(t *testing.T) {
+	fake := newFakeKMS(t)
+	op := testKeyRingOperation()
+	module := &keyRing{runtime: fake.runtime()}
+
+	ctx := testContext(&op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, testKeyRing, ctx.outputs[outputKeyRing])
+	require.Contains(t, fake.keyRings, testKeyRing)
+	require.Equal(
+		t, []*cloudkms.Binding{{Role: "roles/cloudkms.viewer", Members: []string{"group:security@example.com"}}},
+		fake.policies[testKeyRing].Bindings,
+	)
+
+	ctx = testContext(&op)
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, testKeyRing, ctx.outputs[outputKeyRing])
+
+	// The IAM policy is not changed when the roles are granted.
+	require.NoError(t, module.Set(testContext(&op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodPost, ":setIamPolicy"))
+	require.Equal(t, 1, fake.requestCount(http.MethodPost, "/keyRings"))
+}
+
+func TestKeyRing_WithoutBindings(t *testing.T) {
+	fake := newFakeKMS(t)
+	fake.keyRings[testKeyRing] = &cloudkms.KeyRing{Name: testKeyRing}
+	op := testKeyRingOperation()
+	delete(op.Inputs, inputIAMBindings)
+	module := &keyRing{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(&op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Zero(t, fake.requestCount(http.MethodGet, ":getIamPolicy"))
+}
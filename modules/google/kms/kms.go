@@ -0,0 +1,176 @@
+package kms
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/cloudkms/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject         = "project"
+	inputLocation        = "location"
+	inputKeyRing         = "keyring"
+	inputKey             = "key"
+	inputPurpose         = "purpose"
+	inputAlgorithm       = "algorithm"
+	inputProtectionLevel = "protection_level"
+	inputRotationPeriod  = "rotation_period"
+	inputLabels          = "labels"
+	inputIAMBindings     = "iam_bindings"
+
+	outputKeyRing   = "keyring"
+	outputCryptoKey = "crypto_key"
+
+	purposeEncryptDecrypt = "ENCRYPT_DECRYPT"
+
+	// defaultSymmetricAlgorithm is the algorithm of ENCRYPT_DECRYPT keys created without an
+	// algorithm input.
+	defaultSymmetricAlgorithm = "GOOGLE_SYMMETRIC_ENCRYPTION"
+
+	defaultProtectionLevel = "SOFTWARE"
+)
+
+func init() {
+	blackstart.RegisterPathName("kms", "Cloud KMS")
+}
+
+// kmsRuntime provides the injectable Cloud KMS API dependency.
+type kmsRuntime struct {
+	newService func(context.Context) (*cloudkms.Service, error)
+}
+
+// defaultKMSRuntime creates the production Cloud KMS runtime.
+func defaultKMSRuntime() *kmsRuntime {
+	return &kmsRuntime{
+		newService: func(ctx context.Context) (*cloudkms.Service, error) {
+			return cloud.NewService(ctx, cloudkms.NewService, nil, cloudkms.CloudPlatformScope)
+		},
+	}
+}
+
+// kmsRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
+func kmsRuntimeOrDefault(runtime *kmsRuntime) *kmsRuntime {
+	if runtime == nil {
+		return defaultKMSRuntime()
+	}
+	return runtime
+}
+
+// iamBindingsInput is the description of the iam_bindings input of the modules.
+const iamBindingsInput = "IAM roles to grant on the %s, as a map of roles to lists of members, such as " +
+	"`roles/cloudkms.cryptoKeyEncrypterDecrypter: [serviceAccount:app@project.iam.gserviceaccount.com]`. " +
+	"Members granted a role by other bindings are not removed."
+
+// inputBindings returns the members of each role of an iam_bindings input. Members use the IAM
+// format, such as `serviceAccount:app@project.iam.gserviceaccount.com` or `group:ops@example.com`.
+func inputBindings(input blackstart.Input) (map[string][]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputIAMBindings, err)
+	}
+	bindings := make(map[string][]string, len(raw))
+	for role, value := range raw {
+		// Custom roles are in the form projects/<project>/roles/<role>.
+		if !strings.Contains(role, "roles/") {
+			return nil, fmt.Errorf(
+				"invalid %s: role %q is not an IAM role, such as roles/<role>", inputIAMBindings, role,
+			)
+		}
+		var values []any
+		switch v := value.(type) {
+		case []any:
+			values = v
+		case []string:
+			for _, member := range v {
+				values = append(values, member)
+			}
+		default:
+			return nil, fmt.Errorf("invalid %s: members of role %s must be a list", inputIAMBindings, role)
+		}
+		for _, v := range values {
+			member, ok := v.(string)
+			if !ok || !validMember(member) {
+				return nil, fmt.Errorf(
+					"invalid %s: member %v of role %s must have a type, such as serviceAccount:",
+					inputIAMBindings, v, role,
+				)
+			}
+			bindings[role] = append(bindings[role], member)
+		}
+	}
+	return bindings, nil
+}
+
+// validMember reports whether an IAM member has a type prefix or is a special identifier.
+func validMember(member string) bool {
+	if member == "allUsers" || member == "allAuthenticatedUsers" {
+		return true
+	}
+	kind, value, found := strings.Cut(member, ":")
+	return found && kind != "" && value != ""
+}
+
+// contextBindings returns the bindings of the iam_bindings input of the context, or nil when the
+// input is not set.
+func contextBindings(ctx blackstart.ModuleContext) (map[string][]string, error) {
+	input, err := ctx.Input(inputIAMBindings)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	return inputBindings(input)
+}
+
+// grantBindings adds the members of the bindings that are missing in the unconditional bindings
+// of the policy. It reports whether the policy was changed.
+func grantBindings(policy *cloudkms.Policy, bindings map[string][]string) bool {
+	changed := false
+	// Roles are granted in order, so policies are changed deterministically.
+	for _, role := range slices.Sorted(maps.Keys(bindings)) {
+		var binding *cloudkms.Binding
+		for _, b := range policy.Bindings {
+			if b.Role == role && b.Condition == nil {
+				binding = b
+				break
+			}
+		}
+		for _, member := range bindings[role] {
+			if binding != nil && slices.ContainsFunc(
+				binding.Members, func(m string) bool { return strings.EqualFold(m, member) },
+			) {
+				continue
+			}
+			if binding == nil {
+				binding = &cloudkms.Binding{Role: role}
+				policy.Bindings = append(policy.Bindings, binding)
+			}
+			binding.Members = append(binding.Members, member)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// inputLabelsMap returns the labels of a labels input. Label values must be scalar values.
+func inputLabelsMap(input blackstart.Input) (map[string]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputLabels, err)
+	}
+	labels := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string, bool, int, int64, float64:
+			labels[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("invalid %s: value of label %s must be a scalar value", inputLabels, key)
+		}
+	}
+	return labels, nil
+}
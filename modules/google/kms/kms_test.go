@@ -0,0 +1,255 @@
+package kms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+const (
+	testLocation  = "projects/app-project/locations/europe-west1"
+	testKeyRing   = testLocation + "/keyRings/app"
+	testCryptoKey = testKeyRing + "/cryptoKeys/app-data"
+)
+
+// fakeKMS implements the Cloud KMS REST operations used by the modules.
+type fakeKMS struct {
+	t        *testing.T
+	server   *httptest.Server
+	keyRings map[string]*cloudkms.KeyRing
+	keys     map[string]*cloudkms.CryptoKey
+	versions map[string][]*cloudkms.CryptoKeyVersion
+	policies map[string]*cloudkms.Policy
+	masks    []string
+	requests []string
+	mu       sync.Mutex
+}
+
+// newFakeKMS starts a stateful fake Cloud KMS API server.
+func newFakeKMS(t *testing.T) *fakeKMS {
+	t.Helper()
+	f := &fakeKMS{
+		t:        t,
+		keyRings: map[string]*cloudkms.KeyRing{},
+		keys:     map[string]*cloudkms.CryptoKey{},
+		versions: map[string][]*cloudkms.CryptoKeyVersion{},
+		policies: map[string]*cloudkms.Policy{},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a Cloud KMS runtime connected to the fake API.
+func (f *fakeKMS) runtime() *kmsRuntime {
+	return &kmsRuntime{
+		newService: func(ctx context.Context) (*cloudkms.Service, error) {
+			return cloudkms.NewService(ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication())
+		},
+	}
+}
+
+// serveHTTP handles the Cloud KMS API operations used by the unit tests.
+func (f *fakeKMS) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	resource, method, _ := strings.Cut(path, ":")
+	switch {
+	case r.Method == http.MethodGet && method == "getIamPolicy":
+		policy, ok := f.policies[resource]
+		if !ok {
+			policy = &cloudkms.Policy{Etag: "etag-1"}
+		}
+		cloudtest.WriteJSON(f.t, w, policy)
+	case r.Method == http.MethodPost && method == "setIamPolicy":
+		var req cloudkms.SetIamPolicyRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(f.t, "etag-1", req.Policy.Etag)
+		req.Policy.Etag = "etag-2"
+		f.policies[resource] = req.Policy
+		cloudtest.WriteJSON(f.t, w, req.Policy)
+	case r.Method == http.MethodPost && method == "destroy":
+		for _, versions := range f.versions {
+			for _, v := range versions {
+				if v.Name == resource {
+					v.State = "DESTROY_SCHEDULED"
+					cloudtest.WriteJSON(f.t, w, v)
+					return
+				}
+			}
+		}
+		http.Error(w, "version not found", http.StatusNotFound)
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/cryptoKeyVersions"):
+		key := strings.TrimSuffix(path, "/cryptoKeyVersions")
+		cloudtest.WriteJSON(f.t, w, &cloudkms.ListCryptoKeyVersionsResponse{CryptoKeyVersions: f.versions[key]})
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/keyRings"):
+		name := path + "/" + r.URL.Query().Get("keyRingId")
+		f.keyRings[name] = &cloudkms.KeyRing{Name: name}
+		cloudtest.WriteJSON(f.t, w, f.keyRings[name])
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/cryptoKeys"):
+		var key cloudkms.CryptoKey
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&key))
+		key.Name = path + "/" + r.URL.Query().Get("cryptoKeyId")
+		f.keys[key.Name] = &key
+		f.versions[key.Name] = []*cloudkms.CryptoKeyVersion{
+			{Name: key.Name + "/cryptoKeyVersions/1", State: "ENABLED"},
+		}
+		cloudtest.WriteJSON(f.t, w, &key)
+	case r.Method == http.MethodPatch:
+		var patch cloudkms.CryptoKey
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&patch))
+		key, ok := f.keys[path]
+		if !ok {
+			http.Error(w, "crypto key not found", http.StatusNotFound)
+			return
+		}
+		mask := r.URL.Query().Get("updateMask")
+		f.masks = append(f.masks, mask)
+		for _, field := range strings.Split(mask, ",") {
+			switch field {
+			case "rotationPeriod":
+				key.RotationPeriod = patch.RotationPeriod
+			case "nextRotationTime":
+				key.NextRotationTime = patch.NextRotationTime
+			case "labels":
+				key.Labels = patch.Labels
+			}
+		}
+		cloudtest.WriteJSON(f.t, w, key)
+	case r.Method == http.MethodGet:
+		if keyRing, ok := f.keyRings[path]; ok {
+			cloudtest.WriteJSON(f.t, w, keyRing)
+			return
+		}
+		if key, ok := f.keys[path]; ok {
+			cloudtest.WriteJSON(f.t, w, key)
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		f.t.Errorf("unexpected Cloud KMS API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// requestCount returns the number of requests received with the method and suffix.
+func (f *fakeKMS) requestCount(method, suffix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, request := range f.requests {
+		if strings.HasPrefix(request, method+" ") && strings.HasSuffix(request, suffix) {
+			count++
+		}
+	}
+	return count
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testContext creates a module context for the operation that records outputs.
+func testContext(op *blackstart.Operation) *outputContext {
+	return &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), op),
+		outputs:       map[string]any{},
+	}
+}
+
+func TestInputBindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			value: map[string]any{
+				"roles/cloudkms.viewer":              []any{"group:ops@example.com", "allAuthenticatedUsers"},
+				"projects/app-project/roles/keyUser": []string{"user:dev@example.com"},
+			},
+			want: map[string][]string{
+				"roles/cloudkms.viewer":              {"group:ops@example.com", "allAuthenticatedUsers"},
+				"projects/app-project/roles/keyUser": {"user:dev@example.com"},
+			},
+		},
+		{
+			name:    "not a role",
+			value:   map[string]any{"cloudkms.viewer": []any{"group:ops@example.com"}},
+			wantErr: `role "cloudkms.viewer" is not an IAM role`,
+		},
+		{
+			name:    "member without type",
+			value:   map[string]any{"roles/cloudkms.viewer": []any{"ops@example.com"}},
+			wantErr: "must have a type",
+		},
+		{
+			name:    "members not a list",
+			value:   map[string]any{"roles/cloudkms.viewer": "group:ops@example.com"},
+			wantErr: "must be a list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				got, err := inputBindings(blackstart.NewInputFromValue(tt.value))
+				if tt.wantErr != "" {
+					require.ErrorContains(t, err, tt.wantErr)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			},
+		)
+	}
+}
+
+func TestGrantBindings(t *testing.T) {
+	policy := &cloudkms.Policy{
+		Bindings: []*cloudkms.Binding{
+			{Role: "roles/cloudkms.viewer", Members: []string{"group:Ops@example.com"}},
+			{
+				Role:      "roles/cloudkms.cryptoKeyDecrypter",
+				Members:   []string{"user:dev@example.com"},
+				Condition: &cloudkms.Expr{Expression: "request.time < timestamp('2030-01-01T00:00:00Z')"},
+			},
+		},
+	}
+	bindings := map[string][]string{
+		"roles/cloudkms.viewer":             {"group:ops@example.com"},
+		"roles/cloudkms.cryptoKeyDecrypter": {"user:dev@example.com"},
+	}
+
+	require.True(t, grantBindings(policy, bindings))
+	// Conditional bindings do not grant the role, so an unconditional binding is added.
+	require.Len(t, policy.Bindings, 3)
+	require.Equal(t, "roles/cloudkms.cryptoKeyDecrypter", policy.Bindings[2].Role)
+	require.Nil(t, policy.Bindings[2].Condition)
+	require.Equal(t, []string{"group:Ops@example.com"}, policy.Bindings[0].Members)
+
+	require.False(t, grantBindings(policy, bindings))
+}
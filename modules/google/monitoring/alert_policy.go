@@ -0,0 +1,470 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	defaultCombiner = "OR"
+
+	// markdownMimeType is the format of the documentation of alert policies.
+	markdownMimeType = "text/markdown"
+)
+
+// combiners are the ways the conditions of an alert policy are combined.
+var combiners = []string{"AND", "OR", "AND_WITH_MATCHING_RESOURCE"}
+
+// severities are the severities of alert policies.
+var severities = []string{"CRITICAL", "ERROR", "WARNING"}
+
+func init() {
+	blackstart.RegisterModule("google_monitoring_alert_policy", NewAlertPolicy)
+}
+
+var _ blackstart.Module = &alertPolicy{}
+
+// alertPolicy manages a Cloud Monitoring alert policy identified by its display name.
+type alertPolicy struct {
+	runtime *monitoringRuntime
+	svc     *monitoring.Service
+	target  *policyTarget
+}
+
+// policyTarget is the desired state of an alert policy resolved from the module inputs. Channels,
+// documentation, and severity are nil when they are not managed.
+type policyTarget struct {
+	project       string
+	displayName   string
+	conditions    []alertCondition
+	combiner      string
+	channels      []string
+	documentation *string
+	enabled       bool
+	severity      *string
+	userLabels    map[string]string
+}
+
+func NewAlertPolicy() blackstart.Module {
+	return &alertPolicy{}
+}
+
+func (a *alertPolicy) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_monitoring_alert_policy",
+		Name: "Google Cloud Monitoring Alert Policy",
+		Description: util.CleanString(
+			`
+Ensures a Cloud Monitoring alert policy opens incidents when its conditions are met and notifies
+its notification channels. The policy is identified by its '''display_name''', which must be unique
+in the project.
+
+Each item of '''conditions''' is a map with the keys:
+
+- '''display_name''': Name of the condition, required.
+- '''filter''': Monitoring filter of the time series of the condition, such as
+  '''metric.type="monitoring.googleapis.com/uptime_check/check_passed"'''.
+- '''promql''': PromQL query of the condition, instead of '''filter'''.
+- '''comparison''': Comparison of the time series with the threshold, one of '''GT''', '''GE''',
+  '''LT''', '''LE''', '''EQ''', or '''NE'''. Required for threshold conditions.
+- '''threshold''': Value the time series is compared with. Defaults to 0.
+- '''absent''': Whether the condition is met when the time series has no data, instead of a
+  comparison.
+- '''duration''': How long the condition must be met to open an incident, such as '''5m'''.
+  Defaults to 0.
+- '''aligner''', '''reducer''', '''alignment_period''', and '''group_by''': Aggregation of the
+  time series, such as '''ALIGN_RATE''' and '''REDUCE_SUM'''. The alignment period defaults to
+  '''1m'''.
+
+**Notes**
+
+- The conditions of the policy are replaced with the conditions of the inputs.
+- When '''notification_channels''', '''documentation''', or '''severity''' are not set, they are not
+  managed.
+- User labels that are not set in the inputs are not changed.
+- When '''doesNotExist''' is set, the alert policy is deleted.
+`,
+		),
+		Requirements: []string{
+			"The Cloud Monitoring API (`monitoring.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/monitoring.alertPolicyEditor` in the project.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Project of the alert policy. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputDisplayName: {
+				Description: "Display name of the alert policy, unique in the project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputConditions: {
+				Description: "Conditions of the alert policy, as a list of maps with `display_name`, `filter` or `promql`, and `comparison` and `threshold` or `absent`.",
+				Type:        reflect.TypeFor[[]map[string]any](),
+				Required:    true,
+			},
+			inputCombiner: {
+				Description: "How the conditions are combined to open an incident, one of `AND`, `OR`, or `AND_WITH_MATCHING_RESOURCE`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultCombiner,
+			},
+			inputNotificationChannels: {
+				Description: "Resource names of the notification channels of the alert policy, such as the `channel` output of `google_monitoring_notification_channel`.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+			inputDocumentation: {
+				Description: "Markdown documentation sent with the notifications of the alert policy.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputSeverity: {
+				Description: "Severity of the incidents of the alert policy, one of `CRITICAL`, `ERROR`, or `WARNING`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputEnabled: {
+				Description: "Whether the alert policy opens incidents.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     true,
+			},
+			inputUserLabels: {
+				Description: "User labels the alert policy must have, as a map of label keys to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputAlertPolicy: {
+				Description: "Resource name of the alert policy, `projects/<project>/alertPolicies/<id>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Uptime Check Failure": `id: app-down-alert
+module: google_monitoring_alert_policy
+inputs:
+  project: app-project
+  display_name: app.example.com is down
+  severity: CRITICAL
+  documentation: The health endpoint of app.example.com is failing.
+  notification_channels:
+    fromDependency:
+      id: oncall-email
+      output: channel
+  conditions:
+    - display_name: Uptime check failing
+      filter: >-
+        metric.type="monitoring.googleapis.com/uptime_check/check_passed"
+        AND resource.type="uptime_url"
+        AND metric.label.check_id="app-example-com-health-a1b2c3"
+      comparison: GT
+      threshold: 1
+      duration: 5m
+      aligner: ALIGN_NEXT_OLDER
+      reducer: REDUCE_COUNT_FALSE
+      group_by:
+        - resource.label.host`,
+			"PromQL Error Rate": `id: api-error-rate
+module: google_monitoring_alert_policy
+inputs:
+  project: app-project
+  display_name: API error rate
+  severity: WARNING
+  conditions:
+    - display_name: Error rate above 5%
+      promql: >-
+        sum(rate(http_requests_total{code=~"5.."}[5m]))
+        / sum(rate(http_requests_total[5m])) > 0.05
+      duration: 10m`,
+		},
+	}
+}
+
+func (a *alertPolicy) Validate(op blackstart.Operation) error {
+	if err := cloud.RequiredString(op, inputDisplayName); err != nil {
+		return err
+	}
+	input, ok := op.Inputs[inputConditions]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", inputConditions)
+	}
+	if input.IsStatic() {
+		raw, err := blackstart.InputAs[[]map[string]any](input, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputConditions, err)
+		}
+		if _, err = parseConditions(raw); err != nil {
+			return err
+		}
+	}
+	choices := map[string][]string{inputCombiner: combiners, inputSeverity: severities}
+	for _, key := range []string{inputCombiner, inputSeverity} {
+		if in, found := op.Inputs[key]; found && in.IsStatic() {
+			if _, err := inputChoice(key, in, choices[key]); err != nil {
+				return err
+			}
+		}
+	}
+	if in, found := op.Inputs[inputNotificationChannels]; found && in.IsStatic() {
+		if _, err := blackstart.InputAs[[]string](in, false); err != nil {
+			return fmt.Errorf("invalid %s: %w", inputNotificationChannels, err)
+		}
+	}
+	return validateLabels(op, inputUserLabels)
+}
+
+// Check reports whether the alert policy is in the requested state.
+func (a *alertPolicy) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := a.setup(ctx); err != nil {
+		return false, err
+	}
+
+	existing, err := a.find(ctx)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		ctx.Resource(existing.Name)
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if _, mask := a.update(existing); mask != "" {
+		return false, nil
+	}
+	return true, ctx.Output(outputAlertPolicy, existing.Name)
+}
+
+// Set reconciles the alert policy to the requested state.
+func (a *alertPolicy) Set(ctx blackstart.ModuleContext) error {
+	if err := a.setup(ctx); err != nil {
+		return err
+	}
+
+	existing, err := a.find(ctx)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		ctx.Resource(existing.Name)
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		_, err = a.svc.Projects.AlertPolicies.Delete(existing.Name).Context(ctx).Do()
+		if err != nil && !cloud.IsNotFound(err) {
+			return fmt.Errorf("failed to delete alert policy %s: %w", existing.Name, err)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		policy := a.policy()
+		policy.DisplayName = a.target.displayName
+		created, cErr := a.svc.Projects.AlertPolicies.Create(a.target.project, policy).Context(ctx).Do()
+		if cErr != nil {
+			return fmt.Errorf("failed to create alert policy %q: %w", a.target.displayName, cErr)
+		}
+		ctx.Resource(created.Name)
+		return ctx.Output(outputAlertPolicy, created.Name)
+	}
+
+	if patch, mask := a.update(existing); mask != "" {
+		_, err = a.svc.Projects.AlertPolicies.Patch(existing.Name, patch).UpdateMask(mask).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to update alert policy %s: %w", existing.Name, err)
+		}
+	}
+	return ctx.Output(outputAlertPolicy, existing.Name)
+}
+
+// setup resolves the target alert policy from the inputs and creates the Cloud Monitoring service.
+func (a *alertPolicy) setup(ctx blackstart.ModuleContext) error {
+	project, err := contextProject(ctx)
+	if err != nil {
+		return err
+	}
+	target := &policyTarget{project: project, combiner: defaultCombiner}
+	if target.displayName, err = blackstart.ContextInputAs[string](ctx, inputDisplayName, true); err != nil {
+		return err
+	}
+	rawConditions, err := blackstart.ContextInputAs[[]map[string]any](ctx, inputConditions, true)
+	if err != nil {
+		return err
+	}
+	if target.conditions, err = parseConditions(rawConditions); err != nil {
+		return err
+	}
+	if input, iErr := ctx.Input(inputCombiner); iErr == nil && input.Any() != nil {
+		if target.combiner, err = inputChoice(inputCombiner, input, combiners); err != nil {
+			return err
+		}
+	}
+	if input, iErr := ctx.Input(inputNotificationChannels); iErr == nil && input.Any() != nil {
+		channels, cErr := blackstart.InputAs[[]string](input, false)
+		if cErr != nil {
+			return fmt.Errorf("invalid %s: %w", inputNotificationChannels, cErr)
+		}
+		target.channels = make([]string, 0, len(channels))
+		for _, channel := range channels {
+			target.channels = append(target.channels, strings.TrimSpace(channel))
+		}
+	}
+	if input, iErr := ctx.Input(inputDocumentation); iErr == nil && input.Any() != nil {
+		documentation, dErr := blackstart.InputAs[string](input, false)
+		if dErr != nil {
+			return fmt.Errorf("invalid %s: %w", inputDocumentation, dErr)
+		}
+		target.documentation = &documentation
+	}
+	if input, iErr := ctx.Input(inputSeverity); iErr == nil && input.Any() != nil {
+		severity, sErr := inputChoice(inputSeverity, input, severities)
+		if sErr != nil {
+			return sErr
+		}
+		target.severity = &severity
+	}
+	if target.enabled, err = contextEnabled(ctx); err != nil {
+		return err
+	}
+	if target.userLabels, err = contextLabels(ctx, inputUserLabels); err != nil {
+		return err
+	}
+	a.target = target
+
+	a.runtime = monitoringRuntimeOrDefault(a.runtime)
+	a.svc, err = a.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud Monitoring service: %w", err)
+	}
+	return nil
+}
+
+// find returns the alert policy with the display name, or nil if it does not exist.
+func (a *alertPolicy) find(ctx context.Context) (*monitoring.AlertPolicy, error) {
+	var found []*monitoring.AlertPolicy
+	err := a.svc.Projects.AlertPolicies.List(a.target.project).Pages(
+		ctx, func(resp *monitoring.ListAlertPoliciesResponse) error {
+			for _, policy := range resp.AlertPolicies {
+				if policy.DisplayName == a.target.displayName {
+					found = append(found, policy)
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alert policies of %s: %w", a.target.project, err)
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf(
+		"found %d alert policies named %q in %s, display names must be unique", len(found),
+		a.target.displayName, a.target.project,
+	)
+}
+
+// policy returns the configuration of the alert policy that can be updated.
+func (a *alertPolicy) policy() *monitoring.AlertPolicy {
+	policy := &monitoring.AlertPolicy{
+		Combiner:             a.target.combiner,
+		NotificationChannels: a.target.channels,
+		Enabled:              a.target.enabled,
+		UserLabels:           a.target.userLabels,
+		ForceSendFields:      []string{"Enabled"},
+	}
+	for _, c := range a.target.conditions {
+		policy.Conditions = append(policy.Conditions, c.apiCondition())
+	}
+	if a.target.documentation != nil {
+		policy.Documentation = &monitoring.Documentation{
+			Content:  *a.target.documentation,
+			MimeType: markdownMimeType,
+		}
+	}
+	if a.target.severity != nil {
+		policy.Severity = *a.target.severity
+	}
+	return policy
+}
+
+// update returns the patch and the update mask that bring the alert policy to the desired state.
+// The mask is empty when the policy is already in the desired state.
+func (a *alertPolicy) update(existing *monitoring.AlertPolicy) (*monitoring.AlertPolicy, string) {
+	patch := a.policy()
+	var mask []string
+	if existing.Combiner != a.target.combiner {
+		mask = append(mask, "combiner")
+	}
+	if !a.sameConditions(existing.Conditions) {
+		mask = append(mask, "conditions")
+	}
+	if a.target.channels != nil && !sameItems(existing.NotificationChannels, a.target.channels) {
+		mask = append(mask, "notification_channels")
+	}
+	if a.target.documentation != nil &&
+		(existing.Documentation == nil || existing.Documentation.Content != *a.target.documentation) {
+		mask = append(mask, "documentation")
+	}
+	if existing.Enabled != a.target.enabled {
+		mask = append(mask, "enabled")
+	}
+	if a.target.severity != nil && existing.Severity != *a.target.severity {
+		mask = append(mask, "severity")
+	}
+	if missingLabels(existing.UserLabels, a.target.userLabels) {
+		patch.UserLabels = mergeLabels(existing.UserLabels, a.target.userLabels)
+		mask = append(mask, "user_labels")
+	}
+	return patch, strings.Join(mask, ",")
+}
+
+// sameConditions reports whether the conditions of the alert policy are the conditions of the
+// target, in order.
+func (a *alertPolicy) sameConditions(conditions []*monitoring.Condition) bool {
+	if len(conditions) != len(a.target.conditions) {
+		return false
+	}
+	for n, c := range a.target.conditions {
+		if !c.matches(conditions[n]) {
+			return false
+		}
+	}
+	return true
+}
+
+// inputChoice returns the value of a string input in upper case, which must be one of the choices.
+func inputChoice(key string, input blackstart.Input, choices []string) (string, error) {
+	raw, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", key, err)
+	}
+	value := strings.ToUpper(strings.TrimSpace(raw))
+	if !slices.Contains(choices, value) {
+		return "", fmt.Errorf("invalid %s: %q, expected one of %s", key, raw, strings.Join(choices, ", "))
+	}
+	return value, nil
+}
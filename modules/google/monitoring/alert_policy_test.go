@@ -0,0 +1,273 @@
+package monitoring
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/pezops/blackstart"
+)
+
+const testUptimeFilter = `metric.type="monitoring.googleapis.com/uptime_check/check_passed" AND metric.label.check_id="app"`
+
+// testPolicyOperation returns an alert policy operation on a failing uptime check.
+func testPolicyOperation() *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "app-down-alert",
+		Module: "google_monitoring_alert_policy",
+		Inputs: map[string]blackstart.Input{
+			inputProject:     blackstart.NewInputFromValue("app-project"),
+			inputDisplayName: blackstart.NewInputFromValue("app.example.com is down"),
+			inputConditions: blackstart.NewInputFromValue(
+				[]any{
+					map[string]any{
+						"display_name": "Uptime check failing",
+						"filter":       testUptimeFilter,
+						"comparison":   "gt",
+						"threshold":    1,
+						"duration":     "5m",
+						"aligner":      "ALIGN_NEXT_OLDER",
+						"reducer":      "REDUCE_COUNT_FALSE",
+						"group_by":     []any{"resource.label.host"},
+					},
+				},
+			),
+			inputNotificationChannels: blackstart.NewInputFromValue(testProject + "/notificationChannels/oncall"),
+			inputSeverity:             blackstart.NewInputFromValue("critical"),
+		},
+	}
+}
+
+// testCondition returns a condition of the conditions input with the key set to the value.
+func testCondition(key string, value any) []any {
+	condition := map[string]any{"display_name": "c", "filter": "f", "comparison": "GT", "threshold": 1.5}
+	if value == nil {
+		delete(condition, key)
+	} else {
+		condition[key] = value
+	}
+	return []any{condition}
+}
+
+func TestAlertPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "no conditions", key: inputConditions, value: []any{}, wantErr: "at least one condition must be set"},
+		{
+			name: "condition without name", key: inputConditions, value: testCondition("display_name", nil),
+			wantErr: "invalid conditions[0]: display_name must be set",
+		},
+		{
+			name: "filter and promql", key: inputConditions, value: testCondition("promql", "up == 0"),
+			wantErr: "condition c must set one of filter or promql",
+		},
+		{
+			name: "threshold without comparison", key: inputConditions, value: testCondition("comparison", nil),
+			wantErr: "threshold condition c must set comparison",
+		},
+		{
+			name: "invalid comparison", key: inputConditions, value: testCondition("comparison", "ABOVE"),
+			wantErr: `comparison "ABOVE", expected one of GT, GE, LT, LE, EQ, NE`,
+		},
+		{
+			name: "absent with comparison", key: inputConditions, value: testCondition("absent", true),
+			wantErr: "absent condition c cannot set comparison, threshold",
+		},
+		{
+			name: "promql with aggregation", key: inputConditions,
+			value: []any{
+				map[string]any{"display_name": "c", "promql": "up == 0", "aligner": "ALIGN_MEAN"},
+			},
+			wantErr: "condition c with promql cannot set aligner",
+		},
+		{
+			name: "invalid threshold", key: inputConditions, value: testCondition("threshold", "high"),
+			wantErr: "threshold must be a number",
+		},
+		{
+			name: "invalid duration", key: inputConditions, value: testCondition("duration", "soon"),
+			wantErr: "invalid conditions[0]: invalid duration",
+		},
+		{
+			name: "invalid reducer", key: inputConditions, value: testCondition("reducer", "SUM"),
+			wantErr: `reducer "SUM", expected a reducer such as REDUCE_SUM`,
+		},
+		{
+			name: "unknown key", key: inputConditions, value: testCondition("trigger", 1),
+			wantErr: "unknown key trigger",
+		},
+		{name: "combiner", key: inputCombiner, value: "and"},
+		{name: "invalid combiner", key: inputCombiner, value: "XOR", wantErr: `invalid combiner: "XOR"`},
+		{name: "invalid severity", key: inputSeverity, value: "INFO", wantErr: `invalid severity: "INFO"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testPolicyOperation()
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewAlertPolicy().Validate(*op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+
+	op := testPolicyOperation()
+	delete(op.Inputs, inputConditions)
+	require.ErrorContains(t, NewAlertPolicy().Validate(*op), "missing required parameter: conditions")
+}
+
+func TestAlertPolicy_Create(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	op := testPolicyOperation()
+	module := &alertPolicy{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	var created monitoring.AlertPolicy
+	fake.get(collectionPolicies, 0, &created)
+	require.Equal(t, created.Name, ctx.outputs[outputAlertPolicy])
+	require.Equal(t, defaultCombiner, created.Combiner)
+	require.Equal(t, "CRITICAL", created.Severity)
+	require.True(t, created.Enabled)
+	require.Equal(t, []string{testProject + "/notificationChannels/oncall"}, created.NotificationChannels)
+	require.Nil(t, created.Documentation)
+	require.Len(t, created.Conditions, 1)
+	threshold := created.Conditions[0].ConditionThreshold
+	require.NotNil(t, threshold)
+	require.Equal(t, "COMPARISON_GT", threshold.Comparison)
+	require.Equal(t, 1.0, threshold.ThresholdValue)
+	require.Equal(t, "300s", threshold.Duration)
+	require.Equal(
+		t, []*monitoring.Aggregation{
+			{
+				AlignmentPeriod:    "60s",
+				PerSeriesAligner:   "ALIGN_NEXT_OLDER",
+				CrossSeriesReducer: "REDUCE_COUNT_FALSE",
+				GroupByFields:      []string{"resource.label.host"},
+			},
+		}, threshold.Aggregations,
+	)
+
+	// The trigger set by the API is not managed.
+	condition := fake.resources[collectionPolicies][0]["conditions"].([]any)[0].(map[string]any)
+	condition["conditionThreshold"].(map[string]any)["trigger"] = map[string]any{"count": 1}
+	ctx = testContext(op)
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created.Name, ctx.outputs[outputAlertPolicy])
+}
+
+func TestAlertPolicy_Update(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	fake.add(
+		collectionPolicies, &monitoring.AlertPolicy{
+			DisplayName: "app.example.com is down",
+			Combiner:    defaultCombiner,
+			Enabled:     true,
+			Severity:    "WARNING",
+			Conditions: []*monitoring.Condition{
+				{
+					DisplayName:     "Uptime check failing",
+					ConditionAbsent: &monitoring.MetricAbsence{Filter: testUptimeFilter, Duration: "300s"},
+				},
+			},
+			NotificationChannels: []string{testProject + "/notificationChannels/oncall"},
+			Documentation:        &monitoring.Documentation{Content: "Check the logs.", MimeType: markdownMimeType},
+			UserLabels:           map[string]string{"owner": "platform"},
+		},
+	)
+	op := testPolicyOperation()
+	op.Inputs[inputDocumentation] = blackstart.NewInputFromValue("Check the health endpoint.")
+	op.Inputs[inputUserLabels] = blackstart.NewInputFromValue(map[string]any{"env": "prod"})
+	module := &alertPolicy{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, []string{"conditions,documentation,severity,user_labels"}, fake.masks)
+	var updated monitoring.AlertPolicy
+	fake.get(collectionPolicies, 0, &updated)
+	require.Nil(t, updated.Conditions[0].ConditionAbsent)
+	require.NotNil(t, updated.Conditions[0].ConditionThreshold)
+	require.Equal(t, "Check the health endpoint.", updated.Documentation.Content)
+	require.Equal(t, "CRITICAL", updated.Severity)
+	require.Equal(t, map[string]string{"owner": "platform", "env": "prod"}, updated.UserLabels)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Notification channels are not managed when they are not set.
+	delete(op.Inputs, inputNotificationChannels)
+	fake.resources[collectionPolicies][0]["notificationChannels"] = []any{"projects/app-project/notificationChannels/other"}
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.Inputs[inputNotificationChannels] = blackstart.NewInputFromValue([]any{})
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, "notification_channels", fake.masks[1])
+	var cleared monitoring.AlertPolicy
+	fake.get(collectionPolicies, 0, &cleared)
+	require.Empty(t, cleared.NotificationChannels)
+}
+
+func TestAlertPolicy_PromQL(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	op := testPolicyOperation()
+	op.Inputs[inputConditions] = blackstart.NewInputFromValue(
+		[]any{map[string]any{"display_name": "Error rate", "promql": "rate(errors[5m]) > 1", "duration": "10m"}},
+	)
+	op.Inputs[inputEnabled] = blackstart.NewInputFromValue(false)
+	module := &alertPolicy{runtime: fake.runtime()}
+
+	require.NoError(t, module.Set(testContext(op)))
+	var created monitoring.AlertPolicy
+	fake.get(collectionPolicies, 0, &created)
+	require.False(t, created.Enabled)
+	require.Equal(
+		t, &monitoring.PrometheusQueryLanguageCondition{Query: "rate(errors[5m]) > 1", Duration: "600s"},
+		created.Conditions[0].ConditionPrometheusQueryLanguage,
+	)
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestAlertPolicy_DoesNotExist(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	name := fake.add(collectionPolicies, &monitoring.AlertPolicy{DisplayName: "app.example.com is down"})
+	op := testPolicyOperation()
+	op.DoesNotExist = true
+	module := &alertPolicy{runtime: fake.runtime()}
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete, name))
+	require.Empty(t, fake.resources[collectionPolicies])
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
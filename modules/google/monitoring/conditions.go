@@ -0,0 +1,278 @@
+package monitoring
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/monitoring/v3"
+)
+
+// Keys of the conditions of an alert policy.
+const (
+	conditionKeyDisplayName     = "display_name"
+	conditionKeyFilter          = "filter"
+	conditionKeyPromQL          = "promql"
+	conditionKeyComparison      = "comparison"
+	conditionKeyThreshold       = "threshold"
+	conditionKeyAbsent          = "absent"
+	conditionKeyDuration        = "duration"
+	conditionKeyAligner         = "aligner"
+	conditionKeyAlignmentPeriod = "alignment_period"
+	conditionKeyReducer         = "reducer"
+	conditionKeyGroupBy         = "group_by"
+)
+
+// defaultAlignmentPeriod is the alignment period of conditions with an aligner or a reducer and no
+// alignment period.
+const defaultAlignmentPeriod = time.Minute
+
+// comparisons are the comparisons of threshold conditions.
+var comparisons = []string{"GT", "GE", "LT", "LE", "EQ", "NE"}
+
+// alertCondition is a condition of an alert policy resolved from an item of the conditions input.
+// A condition is a PromQL condition when promql is set, an absence condition when absent is set,
+// and a threshold condition otherwise.
+type alertCondition struct {
+	displayName     string
+	filter          string
+	promql          string
+	comparison      string
+	threshold       float64
+	absent          bool
+	duration        time.Duration
+	aligner         string
+	alignmentPeriod time.Duration
+	reducer         string
+	groupBy         []string
+}
+
+// parseConditions parses the items of the conditions input.
+func parseConditions(raw []map[string]any) ([]alertCondition, error) {
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("invalid %s: at least one condition must be set", inputConditions)
+	}
+	conditions := make([]alertCondition, 0, len(raw))
+	for n, values := range raw {
+		c, err := parseCondition(values)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s[%d]: %w", inputConditions, n, err)
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions, nil
+}
+
+// parseCondition parses an item of the conditions input.
+func parseCondition(values map[string]any) (alertCondition, error) {
+	var c alertCondition
+	var err error
+	for key, value := range values {
+		switch key {
+		case conditionKeyDisplayName, conditionKeyFilter, conditionKeyPromQL, conditionKeyComparison,
+			conditionKeyDuration, conditionKeyAligner, conditionKeyAlignmentPeriod, conditionKeyReducer:
+			s, ok := value.(string)
+			if !ok {
+				return c, fmt.Errorf("%s must be a string", key)
+			}
+			err = c.setString(key, strings.TrimSpace(s))
+		case conditionKeyThreshold:
+			switch v := value.(type) {
+			case int:
+				c.threshold = float64(v)
+			case int64:
+				c.threshold = float64(v)
+			case float64:
+				c.threshold = v
+			default:
+				return c, fmt.Errorf("%s must be a number", key)
+			}
+		case conditionKeyAbsent:
+			absent, ok := value.(bool)
+			if !ok {
+				return c, fmt.Errorf("%s must be a boolean", key)
+			}
+			c.absent = absent
+		case conditionKeyGroupBy:
+			items, ok := value.([]any)
+			if !ok {
+				return c, fmt.Errorf("%s must be a list of labels", key)
+			}
+			for _, item := range items {
+				label, isString := item.(string)
+				if !isString {
+					return c, fmt.Errorf("%s must be a list of labels", key)
+				}
+				c.groupBy = append(c.groupBy, label)
+			}
+		default:
+			return c, fmt.Errorf("unknown key %s", key)
+		}
+		if err != nil {
+			return c, err
+		}
+	}
+	return c, c.validate(values)
+}
+
+// setString sets a string key of the condition.
+func (c *alertCondition) setString(key, value string) error {
+	var err error
+	switch key {
+	case conditionKeyDisplayName:
+		c.displayName = value
+	case conditionKeyFilter:
+		c.filter = value
+	case conditionKeyPromQL:
+		c.promql = value
+	case conditionKeyComparison:
+		c.comparison = strings.TrimPrefix(strings.ToUpper(value), "COMPARISON_")
+		if !slices.Contains(comparisons, c.comparison) {
+			return fmt.Errorf("%s %q, expected one of %s", key, value, strings.Join(comparisons, ", "))
+		}
+	case conditionKeyDuration:
+		c.duration, err = parseDuration(key, value)
+	case conditionKeyAligner:
+		c.aligner = strings.ToUpper(value)
+		if !strings.HasPrefix(c.aligner, "ALIGN_") {
+			return fmt.Errorf("%s %q, expected an aligner such as ALIGN_RATE", key, value)
+		}
+	case conditionKeyAlignmentPeriod:
+		c.alignmentPeriod, err = parseDuration(key, value)
+	case conditionKeyReducer:
+		c.reducer = strings.ToUpper(value)
+		if !strings.HasPrefix(c.reducer, "REDUCE_") {
+			return fmt.Errorf("%s %q, expected a reducer such as REDUCE_SUM", key, value)
+		}
+	}
+	return err
+}
+
+// validate returns an error when the keys of the condition do not describe a single kind of
+// condition.
+func (c *alertCondition) validate(values map[string]any) error {
+	set := func(keys ...string) []string {
+		var found []string
+		for _, key := range keys {
+			if _, ok := values[key]; ok {
+				found = append(found, key)
+			}
+		}
+		return found
+	}
+	switch {
+	case c.displayName == "":
+		return fmt.Errorf("%s must be set", conditionKeyDisplayName)
+	case (c.filter == "") == (c.promql == ""):
+		return fmt.Errorf(
+			"condition %s must set one of %s or %s", c.displayName, conditionKeyFilter, conditionKeyPromQL,
+		)
+	}
+	if c.promql != "" {
+		keys := set(
+			conditionKeyComparison, conditionKeyThreshold, conditionKeyAbsent, conditionKeyAligner,
+			conditionKeyAlignmentPeriod, conditionKeyReducer, conditionKeyGroupBy,
+		)
+		if len(keys) > 0 {
+			return fmt.Errorf(
+				"condition %s with %s cannot set %s", c.displayName, conditionKeyPromQL, strings.Join(keys, ", "),
+			)
+		}
+		return nil
+	}
+	if c.absent {
+		if keys := set(conditionKeyComparison, conditionKeyThreshold); len(keys) > 0 {
+			return fmt.Errorf(
+				"absent condition %s cannot set %s", c.displayName, strings.Join(keys, ", "),
+			)
+		}
+	} else if c.comparison == "" {
+		return fmt.Errorf("threshold condition %s must set %s", c.displayName, conditionKeyComparison)
+	}
+	if (c.aligner != "" || c.reducer != "") && c.alignmentPeriod == 0 {
+		c.alignmentPeriod = defaultAlignmentPeriod
+	}
+	return nil
+}
+
+// aggregations returns the aggregations of the condition, or nil when it has no aligner and no
+// reducer.
+func (c *alertCondition) aggregations() []*monitoring.Aggregation {
+	if c.aligner == "" && c.reducer == "" {
+		return nil
+	}
+	return []*monitoring.Aggregation{
+		{
+			AlignmentPeriod:    apiDuration(c.alignmentPeriod),
+			PerSeriesAligner:   c.aligner,
+			CrossSeriesReducer: c.reducer,
+			GroupByFields:      c.groupBy,
+		},
+	}
+}
+
+// apiCondition returns the condition in the form of the API.
+func (c *alertCondition) apiCondition() *monitoring.Condition {
+	condition := &monitoring.Condition{DisplayName: c.displayName}
+	duration := apiDuration(c.duration)
+	switch {
+	case c.promql != "":
+		condition.ConditionPrometheusQueryLanguage = &monitoring.PrometheusQueryLanguageCondition{
+			Query:    c.promql,
+			Duration: duration,
+		}
+	case c.absent:
+		condition.ConditionAbsent = &monitoring.MetricAbsence{
+			Filter:       c.filter,
+			Duration:     duration,
+			Aggregations: c.aggregations(),
+		}
+	default:
+		condition.ConditionThreshold = &monitoring.MetricThreshold{
+			Filter:          c.filter,
+			Comparison:      "COMPARISON_" + c.comparison,
+			ThresholdValue:  c.threshold,
+			Duration:        duration,
+			Aggregations:    c.aggregations(),
+			ForceSendFields: []string{"ThresholdValue"},
+		}
+	}
+	return condition
+}
+
+// matches reports whether a condition of an alert policy is the condition. Settings of the
+// condition that are not managed, such as its trigger, are not compared.
+func (c *alertCondition) matches(condition *monitoring.Condition) bool {
+	if condition.DisplayName != c.displayName {
+		return false
+	}
+	switch {
+	case c.promql != "":
+		promql := condition.ConditionPrometheusQueryLanguage
+		return promql != nil && promql.Query == c.promql && sameDuration(promql.Duration, c.duration)
+	case c.absent:
+		absent := condition.ConditionAbsent
+		return absent != nil && absent.Filter == c.filter && sameDuration(absent.Duration, c.duration) &&
+			c.sameAggregations(absent.Aggregations)
+	default:
+		threshold := condition.ConditionThreshold
+		return threshold != nil && threshold.Filter == c.filter &&
+			threshold.Comparison == "COMPARISON_"+c.comparison && threshold.ThresholdValue == c.threshold &&
+			sameDuration(threshold.Duration, c.duration) && c.sameAggregations(threshold.Aggregations)
+	}
+}
+
+// sameAggregations reports whether the aggregations of a condition are the aggregations of the
+// condition.
+func (c *alertCondition) sameAggregations(aggregations []*monitoring.Aggregation) bool {
+	if c.aligner == "" && c.reducer == "" {
+		return len(aggregations) == 0
+	}
+	if len(aggregations) != 1 {
+		return false
+	}
+	a := aggregations[0]
+	return a.PerSeriesAligner == c.aligner && a.CrossSeriesReducer == c.reducer &&
+		sameDuration(a.AlignmentPeriod, c.alignmentPeriod) && slices.Equal(a.GroupByFields, c.groupBy)
+}
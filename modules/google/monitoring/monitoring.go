@@ -0,0 +1,195 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject         = "project"
+	inputDisplayName     = "display_name"
+	inputDescription     = "description"
+	inputEnabled         = "enabled"
+	inputUserLabels      = "user_labels"
+	inputType            = "type"
+	inputLabels          = "labels"
+	inputSensitiveLabels = "sensitive_labels"
+
+	inputHost        = "host"
+	inputPath        = "path"
+	inputPort        = "port"
+	inputUseSSL      = "use_ssl"
+	inputValidateSSL = "validate_ssl"
+	inputPeriod      = "period"
+	inputTimeout     = "timeout"
+	inputContent     = "content"
+	inputRegions     = "regions"
+
+	inputConditions           = "conditions"
+	inputCombiner             = "combiner"
+	inputNotificationChannels = "notification_channels"
+	inputDocumentation        = "documentation"
+	inputSeverity             = "severity"
+
+	outputChannel     = "channel"
+	outputUptimeCheck = "uptime_check"
+	outputCheckID     = "check_id"
+	outputAlertPolicy = "alert_policy"
+)
+
+func init() {
+	blackstart.RegisterPathName("monitoring", "Cloud Monitoring")
+}
+
+// monitoringRuntime provides the injectable Cloud Monitoring API dependencies.
+type monitoringRuntime struct {
+	newService func(context.Context) (*monitoring.Service, error)
+}
+
+// defaultMonitoringRuntime creates the production Cloud Monitoring runtime.
+func defaultMonitoringRuntime() *monitoringRuntime {
+	return &monitoringRuntime{
+		newService: func(ctx context.Context) (*monitoring.Service, error) {
+			return cloud.NewService(ctx, monitoring.NewService, nil, monitoring.MonitoringScope)
+		},
+	}
+}
+
+// monitoringRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
+func monitoringRuntimeOrDefault(runtime *monitoringRuntime) *monitoringRuntime {
+	if runtime == nil {
+		return defaultMonitoringRuntime()
+	}
+	return runtime
+}
+
+// contextProject returns the resource name of the project input, `projects/<project>`. The project
+// defaults to the current project.
+func contextProject(ctx blackstart.ModuleContext) (string, error) {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return "", err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "projects/" + project, nil
+}
+
+// contextEnabled returns the value of the enabled input, which defaults to true.
+func contextEnabled(ctx blackstart.ModuleContext) (bool, error) {
+	input, err := ctx.Input(inputEnabled)
+	if err != nil || input.Any() == nil {
+		return true, nil
+	}
+	enabled, err := blackstart.InputAs[bool](input, false)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", inputEnabled, err)
+	}
+	return enabled, nil
+}
+
+// inputLabelValues returns the labels of a map input. Label values must be scalar values.
+func inputLabelValues(key string, input blackstart.Input) (map[string]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	labels := make(map[string]string, len(raw))
+	for k, value := range raw {
+		switch v := value.(type) {
+		case string, bool, int, int64, float64:
+			labels[k] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("invalid %s: value of label %s must be a scalar value", key, k)
+		}
+	}
+	return labels, nil
+}
+
+// validateLabels validates a static map input of labels.
+func validateLabels(op blackstart.Operation, key string) error {
+	if input, ok := op.Inputs[key]; ok && input.IsStatic() {
+		if _, err := inputLabelValues(key, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contextLabels returns the labels of a map input, or nil when the input is not set.
+func contextLabels(ctx blackstart.ModuleContext, key string) (map[string]string, error) {
+	input, err := ctx.Input(key)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	return inputLabelValues(key, input)
+}
+
+// missingLabels reports whether labels lacks any of the wanted labels or has a different value.
+func missingLabels(labels, wanted map[string]string) bool {
+	for key, value := range wanted {
+		if current, ok := labels[key]; !ok || current != value {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeLabels returns the labels with the wanted labels added, or nil when both are empty.
+func mergeLabels(labels, wanted map[string]string) map[string]string {
+	if len(labels) == 0 && len(wanted) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(labels)+len(wanted))
+	maps.Copy(merged, labels)
+	maps.Copy(merged, wanted)
+	return merged
+}
+
+// parseDuration parses a duration input, such as `5m`, that must be zero or positive.
+func parseDuration(key, raw string) (time.Duration, error) {
+	d, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s: %s must not be negative", key, raw)
+	}
+	return d, nil
+}
+
+// apiDuration formats a duration in the seconds format of the API, such as `300s`.
+func apiDuration(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d.Seconds()))
+}
+
+// sameDuration reports whether a duration of the API is equal to the duration. An empty duration
+// of the API is zero.
+func sameDuration(raw string, d time.Duration) bool {
+	if raw == "" {
+		return d == 0
+	}
+	current, err := time.ParseDuration(raw)
+	return err == nil && current == d
+}
+
+// sameItems reports whether two lists have the same items, in any order.
+func sameItems(current, wanted []string) bool {
+	current, wanted = slices.Clone(current), slices.Clone(wanted)
+	slices.Sort(current)
+	slices.Sort(wanted)
+	return slices.Equal(current, wanted)
+}
@@ -0,0 +1,200 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/monitoring/v3"
+	"google.golang.org/api/option"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+const testProject = "projects/app-project"
+
+// Collections of the fake Cloud Monitoring API.
+const (
+	collectionChannels = "notificationChannels"
+	collectionUptime   = "uptimeCheckConfigs"
+	collectionPolicies = "alertPolicies"
+)
+
+// fakeMonitoring implements the Cloud Monitoring REST operations used by the modules. Resources
+// are stored as decoded JSON objects, so patches apply the fields of their update mask.
+type fakeMonitoring struct {
+	t         *testing.T
+	server    *httptest.Server
+	resources map[string][]map[string]any
+	created   int
+	requests  []string
+	masks     []string
+	mu        sync.Mutex
+}
+
+// newFakeMonitoring starts a stateful fake Cloud Monitoring API server.
+func newFakeMonitoring(t *testing.T) *fakeMonitoring {
+	t.Helper()
+	f := &fakeMonitoring{t: t, resources: map[string][]map[string]any{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a Cloud Monitoring runtime connected to the fake API.
+func (f *fakeMonitoring) runtime() *monitoringRuntime {
+	return &monitoringRuntime{
+		newService: func(ctx context.Context) (*monitoring.Service, error) {
+			return monitoring.NewService(ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication())
+		},
+	}
+}
+
+// add stores a resource of a collection of the test project and returns its resource name.
+func (f *fakeMonitoring) add(collection string, resource any) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var object map[string]any
+	data, err := json.Marshal(resource)
+	require.NoError(f.t, err)
+	require.NoError(f.t, json.Unmarshal(data, &object))
+	f.created++
+	name := fmt.Sprintf("%s/%s/%s-%d", testProject, collection, collection, f.created)
+	object["name"] = name
+	f.resources[collection] = append(f.resources[collection], object)
+	return name
+}
+
+// get decodes the resource of a collection at the index into out.
+func (f *fakeMonitoring) get(collection string, n int, out any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	require.Greater(f.t, len(f.resources[collection]), n)
+	data, err := json.Marshal(f.resources[collection][n])
+	require.NoError(f.t, err)
+	require.NoError(f.t, json.Unmarshal(data, out))
+}
+
+// requestCount returns the number of requests with the method and path suffix.
+func (f *fakeMonitoring) requestCount(method, suffix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, method+" ") && strings.HasSuffix(r, suffix) {
+			count++
+		}
+	}
+	return count
+}
+
+// serveHTTP handles the API operations used by the unit tests.
+func (f *fakeMonitoring) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v3/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	parts := strings.Split(path, "/")
+	if len(parts) < 3 || parts[0] != "projects" {
+		f.t.Errorf("unexpected API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+		return
+	}
+	collection := parts[2]
+	switch {
+	case r.Method == http.MethodGet && len(parts) == 3:
+		items := f.resources[collection]
+		if items == nil {
+			items = []map[string]any{}
+		}
+		cloudtest.WriteJSON(f.t, w, map[string]any{collection: items})
+	case r.Method == http.MethodPost && len(parts) == 3:
+		var object map[string]any
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&object))
+		f.created++
+		object["name"] = fmt.Sprintf("%s/%s-%d", path, collection, f.created)
+		f.resources[collection] = append(f.resources[collection], object)
+		cloudtest.WriteJSON(f.t, w, object)
+	case len(parts) == 4:
+		for n, object := range f.resources[collection] {
+			if object["name"] != path {
+				continue
+			}
+			switch r.Method {
+			case http.MethodDelete:
+				f.resources[collection] = append(f.resources[collection][:n], f.resources[collection][n+1:]...)
+				cloudtest.WriteJSON(f.t, w, map[string]any{})
+			case http.MethodPatch:
+				var patch map[string]any
+				require.NoError(f.t, json.NewDecoder(r.Body).Decode(&patch))
+				mask := r.URL.Query().Get("updateMask")
+				f.masks = append(f.masks, mask)
+				for _, field := range strings.Split(mask, ",") {
+					key := camelCase(field)
+					if value, ok := patch[key]; ok {
+						object[key] = value
+					} else {
+						delete(object, key)
+					}
+				}
+				cloudtest.WriteJSON(f.t, w, object)
+			default:
+				cloudtest.WriteJSON(f.t, w, object)
+			}
+			return
+		}
+		http.Error(w, "resource not found", http.StatusNotFound)
+	default:
+		f.t.Errorf("unexpected API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// camelCase returns the JSON name of a field of an update mask, such as `userLabels` for
+// `user_labels`.
+func camelCase(field string) string {
+	words := strings.Split(field, "_")
+	for n := 1; n < len(words); n++ {
+		words[n] = strings.ToUpper(words[n][:1]) + words[n][1:]
+	}
+	return strings.Join(words, "")
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testContext creates a module context for the operation that records outputs.
+func testContext(op *blackstart.Operation) *outputContext {
+	return &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), op),
+		outputs:       map[string]any{},
+	}
+}
+
+func TestSameDuration(t *testing.T) {
+	d, err := parseDuration(inputPeriod, "5m")
+	require.NoError(t, err)
+	require.Equal(t, "300s", apiDuration(d))
+	require.True(t, sameDuration("300s", d))
+	require.True(t, sameDuration("", 0))
+	require.False(t, sameDuration("60s", d))
+
+	_, err = parseDuration(inputPeriod, "-1m")
+	require.EqualError(t, err, "invalid period: -1m must not be negative")
+}
@@ -0,0 +1,345 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_monitoring_notification_channel", NewNotificationChannel)
+}
+
+var _ blackstart.Module = &notificationChannel{}
+
+// notificationChannel manages a Cloud Monitoring notification channel identified by its type and
+// display name.
+type notificationChannel struct {
+	runtime *monitoringRuntime
+	svc     *monitoring.Service
+	target  *channelTarget
+}
+
+// channelTarget is the desired state of a notification channel resolved from the module inputs.
+type channelTarget struct {
+	project         string
+	displayName     string
+	channelType     string
+	description     *string
+	enabled         bool
+	labels          map[string]string
+	sensitiveLabels map[string]string
+	userLabels      map[string]string
+}
+
+func NewNotificationChannel() blackstart.Module {
+	return &notificationChannel{}
+}
+
+func (n *notificationChannel) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_monitoring_notification_channel",
+		Name: "Google Cloud Monitoring Notification Channel",
+		Description: util.CleanString(
+			`
+Ensures a Cloud Monitoring notification channel exists, such as an email address, a Slack channel,
+or a PagerDuty service, so alert policies can notify it. The channel is identified by its
+'''type''' and '''display_name''', which must be unique in the project.
+
+The configuration of the channel is set with '''labels''', which depend on the type of the channel,
+such as '''email_address''' for '''email''' channels or '''channel_name''' for '''slack''' channels.
+Labels with secret values, such as '''auth_token''' or '''service_key''', are set with
+'''sensitive_labels'''.
+
+**Notes**
+
+- Cloud Monitoring does not return the values of sensitive labels, so they are set when the channel
+  is created or its other labels change, but changes of only sensitive labels are not detected.
+- Labels and user labels that are not set in the inputs are not changed.
+- Channels that require verification, such as SMS channels, must be verified in the Google Cloud
+  console before they receive notifications.
+- When '''doesNotExist''' is set, the channel is deleted. A channel that is used by alert policies
+  is not deleted and the operation fails.
+`,
+		),
+		Requirements: []string{
+			"The Cloud Monitoring API (`monitoring.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/monitoring.notificationChannelEditor` in the project.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Project of the notification channel. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputDisplayName: {
+				Description: "Display name of the notification channel, unique for its type in the project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputType: {
+				Description: "Type of the notification channel, such as `email`, `slack`, `pagerduty`, or `webhook_tokenauth`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputLabels: {
+				Description: "Configuration of the channel, as a map of the labels of the channel type to their values, such as `email_address`.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+			inputSensitiveLabels: {
+				Description: "Configuration of the channel with secret values, such as `auth_token`.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+				Sensitive:   true,
+			},
+			inputDescription: {
+				Description: "Description of the notification channel. If not set, the description is not managed.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputEnabled: {
+				Description: "Whether notifications are sent to the channel.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     true,
+			},
+			inputUserLabels: {
+				Description: "User labels the notification channel must have, as a map of label keys to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputChannel: {
+				Description: "Resource name of the notification channel, `projects/<project>/notificationChannels/<id>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"On-call Email": `id: oncall-email
+module: google_monitoring_notification_channel
+inputs:
+  project: app-project
+  display_name: On-call
+  type: email
+  labels:
+    email_address: oncall@example.com`,
+			"Slack Channel": `id: alerts-slack
+module: google_monitoring_notification_channel
+inputs:
+  project: app-project
+  display_name: Production alerts
+  type: slack
+  labels:
+    channel_name: "#prod-alerts"
+  sensitive_labels:
+    fromFile:
+      path: /var/run/secrets/slack/labels.json
+      format: json`,
+		},
+	}
+}
+
+func (n *notificationChannel) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputDisplayName, inputType} {
+		if err := cloud.RequiredString(op, key); err != nil {
+			return err
+		}
+	}
+	for _, key := range []string{inputLabels, inputSensitiveLabels, inputUserLabels} {
+		if err := validateLabels(op, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the notification channel is in the requested state.
+func (n *notificationChannel) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := n.setup(ctx); err != nil {
+		return false, err
+	}
+
+	existing, err := n.find(ctx)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		ctx.Resource(existing.Name)
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if _, mask := n.update(existing); len(mask) > 0 {
+		return false, nil
+	}
+	return true, ctx.Output(outputChannel, existing.Name)
+}
+
+// Set reconciles the notification channel to the requested state.
+func (n *notificationChannel) Set(ctx blackstart.ModuleContext) error {
+	if err := n.setup(ctx); err != nil {
+		return err
+	}
+
+	existing, err := n.find(ctx)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		ctx.Resource(existing.Name)
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		_, err = n.svc.Projects.NotificationChannels.Delete(existing.Name).Context(ctx).Do()
+		if err != nil && !cloud.IsNotFound(err) {
+			return fmt.Errorf("failed to delete notification channel %s: %w", existing.Name, err)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		channel := &monitoring.NotificationChannel{
+			DisplayName:     n.target.displayName,
+			Type:            n.target.channelType,
+			Enabled:         n.target.enabled,
+			Labels:          mergeLabels(n.target.labels, n.target.sensitiveLabels),
+			UserLabels:      n.target.userLabels,
+			ForceSendFields: []string{"Enabled"},
+		}
+		if n.target.description != nil {
+			channel.Description = *n.target.description
+		}
+		created, cErr := n.svc.Projects.NotificationChannels.Create(n.target.project, channel).Context(ctx).Do()
+		if cErr != nil {
+			return fmt.Errorf("failed to create notification channel %q: %w", n.target.displayName, cErr)
+		}
+		ctx.Resource(created.Name)
+		return ctx.Output(outputChannel, created.Name)
+	}
+
+	if patch, mask := n.update(existing); len(mask) > 0 {
+		_, err = n.svc.Projects.NotificationChannels.Patch(existing.Name, patch).
+			UpdateMask(mask).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to update notification channel %s: %w", existing.Name, err)
+		}
+	}
+	return ctx.Output(outputChannel, existing.Name)
+}
+
+// setup resolves the target notification channel from the inputs and creates the Cloud Monitoring
+// service.
+func (n *notificationChannel) setup(ctx blackstart.ModuleContext) error {
+	project, err := contextProject(ctx)
+	if err != nil {
+		return err
+	}
+	target := &channelTarget{project: project}
+	if target.displayName, err = blackstart.ContextInputAs[string](ctx, inputDisplayName, true); err != nil {
+		return err
+	}
+	if target.channelType, err = blackstart.ContextInputAs[string](ctx, inputType, true); err != nil {
+		return err
+	}
+	if input, iErr := ctx.Input(inputDescription); iErr == nil && input.Any() != nil {
+		description, dErr := blackstart.InputAs[string](input, false)
+		if dErr != nil {
+			return fmt.Errorf("invalid %s: %w", inputDescription, dErr)
+		}
+		target.description = &description
+	}
+	if target.enabled, err = contextEnabled(ctx); err != nil {
+		return err
+	}
+	if target.labels, err = contextLabels(ctx, inputLabels); err != nil {
+		return err
+	}
+	if target.sensitiveLabels, err = contextLabels(ctx, inputSensitiveLabels); err != nil {
+		return err
+	}
+	if target.userLabels, err = contextLabels(ctx, inputUserLabels); err != nil {
+		return err
+	}
+	n.target = target
+
+	n.runtime = monitoringRuntimeOrDefault(n.runtime)
+	n.svc, err = n.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud Monitoring service: %w", err)
+	}
+	return nil
+}
+
+// find returns the notification channel with the type and display name, or nil if it does not
+// exist.
+func (n *notificationChannel) find(ctx context.Context) (*monitoring.NotificationChannel, error) {
+	var found []*monitoring.NotificationChannel
+	filter := fmt.Sprintf("type = %q", n.target.channelType)
+	err := n.svc.Projects.NotificationChannels.List(n.target.project).Filter(filter).Pages(
+		ctx, func(resp *monitoring.ListNotificationChannelsResponse) error {
+			for _, channel := range resp.NotificationChannels {
+				if channel.Type == n.target.channelType && channel.DisplayName == n.target.displayName {
+					found = append(found, channel)
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notification channels of %s: %w", n.target.project, err)
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf(
+		"found %d %s notification channels named %q in %s, display names must be unique", len(found),
+		n.target.channelType, n.target.displayName, n.target.project,
+	)
+}
+
+// update returns the patch and the update mask that bring the notification channel to the desired
+// state. The mask is empty when the channel is already in the desired state.
+func (n *notificationChannel) update(
+	existing *monitoring.NotificationChannel,
+) (*monitoring.NotificationChannel, string) {
+	patch := &monitoring.NotificationChannel{}
+	var mask []string
+	if n.target.description != nil && existing.Description != *n.target.description {
+		patch.Description = *n.target.description
+		patch.ForceSendFields = append(patch.ForceSendFields, "Description")
+		mask = append(mask, "description")
+	}
+	if existing.Enabled != n.target.enabled {
+		patch.Enabled = n.target.enabled
+		patch.ForceSendFields = append(patch.ForceSendFields, "Enabled")
+		mask = append(mask, "enabled")
+	}
+	// Sensitive labels are sent with the labels, since the values returned for them are obfuscated.
+	if missingLabels(existing.Labels, n.target.labels) {
+		patch.Labels = mergeLabels(mergeLabels(existing.Labels, n.target.labels), n.target.sensitiveLabels)
+		mask = append(mask, "labels")
+	}
+	if missingLabels(existing.UserLabels, n.target.userLabels) {
+		patch.UserLabels = mergeLabels(existing.UserLabels, n.target.userLabels)
+		mask = append(mask, "user_labels")
+	}
+	return patch, strings.Join(mask, ",")
+}
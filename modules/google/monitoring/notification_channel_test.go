@@ -0,0 +1,178 @@
+package monitoring
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/pezops/blackstart"
+)
+
+// testChannelOperation returns a notification channel operation for a Slack channel.
+func testChannelOperation() *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "alerts-slack",
+		Module: "google_monitoring_notification_channel",
+		Inputs: map[string]blackstart.Input{
+			inputProject:         blackstart.NewInputFromValue("app-project"),
+			inputDisplayName:     blackstart.NewInputFromValue("Production alerts"),
+			inputType:            blackstart.NewInputFromValue("slack"),
+			inputLabels:          blackstart.NewInputFromValue(map[string]any{"channel_name": "#prod-alerts"}),
+			inputSensitiveLabels: blackstart.NewInputFromValue(map[string]any{"auth_token": "xoxb-secret"}),
+		},
+	}
+}
+
+func TestNotificationChannel_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{
+			name: "empty display name", key: inputDisplayName, value: "",
+			wantErr: "invalid display_name: value cannot be empty",
+		},
+		{name: "empty type", key: inputType, value: "", wantErr: "invalid type: value cannot be empty"},
+		{name: "numeric label", key: inputLabels, value: map[string]any{"number": 5}},
+		{
+			name: "nested label", key: inputLabels, value: map[string]any{"nested": map[string]any{"a": "b"}},
+			wantErr: "invalid labels: value of label nested must be a scalar value",
+		},
+		{name: "invalid user labels", key: inputUserLabels, value: "team", wantErr: "invalid user_labels"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testChannelOperation()
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewNotificationChannel().Validate(*op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+
+	op := testChannelOperation()
+	delete(op.Inputs, inputType)
+	require.ErrorContains(t, NewNotificationChannel().Validate(*op), "missing required parameter: type")
+}
+
+func TestNotificationChannel_Create(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	// A channel of another type with the same display name is a different channel.
+	fake.add(collectionChannels, &monitoring.NotificationChannel{DisplayName: "Production alerts", Type: "email"})
+	op := testChannelOperation()
+	module := &notificationChannel{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, ctx.outputs)
+
+	require.NoError(t, module.Set(ctx))
+	var created monitoring.NotificationChannel
+	fake.get(collectionChannels, 1, &created)
+	require.Equal(t, created.Name, ctx.outputs[outputChannel])
+	require.Equal(t, "slack", created.Type)
+	require.True(t, created.Enabled)
+	require.Equal(t, map[string]string{"channel_name": "#prod-alerts", "auth_token": "xoxb-secret"}, created.Labels)
+
+	// Cloud Monitoring obfuscates the values of sensitive labels.
+	fake.resources[collectionChannels][1]["labels"] = map[string]any{
+		"channel_name": "#prod-alerts", "auth_token": "**************cret",
+	}
+	ctx = testContext(op)
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created.Name, ctx.outputs[outputChannel])
+}
+
+func TestNotificationChannel_Update(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	name := fake.add(
+		collectionChannels, &monitoring.NotificationChannel{
+			DisplayName: "Production alerts",
+			Type:        "slack",
+			Description: "Alerts",
+			Enabled:     false,
+			Labels:      map[string]string{"channel_name": "#alerts", "team": "sre"},
+			UserLabels:  map[string]string{"owner": "platform"},
+		},
+	)
+	op := testChannelOperation()
+	op.Inputs[inputUserLabels] = blackstart.NewInputFromValue(map[string]any{"env": "prod"})
+	module := &notificationChannel{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// The description is not managed when it is not set.
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, []string{"enabled,labels,user_labels"}, fake.masks)
+	var updated monitoring.NotificationChannel
+	fake.get(collectionChannels, 0, &updated)
+	require.Equal(t, name, updated.Name)
+	require.True(t, updated.Enabled)
+	require.Equal(t, "Alerts", updated.Description)
+	require.Equal(
+		t, map[string]string{"channel_name": "#prod-alerts", "team": "sre", "auth_token": "xoxb-secret"},
+		updated.Labels,
+	)
+	require.Equal(t, map[string]string{"owner": "platform", "env": "prod"}, updated.UserLabels)
+
+	op.Inputs[inputDescription] = blackstart.NewInputFromValue("")
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, "description", fake.masks[1])
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestNotificationChannel_DoesNotExist(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	name := fake.add(collectionChannels, &monitoring.NotificationChannel{DisplayName: "Production alerts", Type: "slack"})
+	op := testChannelOperation()
+	op.DoesNotExist = true
+	module := &notificationChannel{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete, name))
+	require.Empty(t, fake.resources[collectionChannels])
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestNotificationChannel_DuplicateDisplayName(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	for range 2 {
+		fake.add(collectionChannels, &monitoring.NotificationChannel{DisplayName: "Production alerts", Type: "slack"})
+	}
+	module := &notificationChannel{runtime: fake.runtime()}
+
+	_, err := module.Check(testContext(testChannelOperation()))
+	require.EqualError(
+		t, err,
+		`found 2 slack notification channels named "Production alerts" in projects/app-project, display names must be unique`,
+	)
+}
@@ -0,0 +1,581 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	// uptimeURLResource is the monitored resource type of uptime checks of a host.
+	uptimeURLResource = "uptime_url"
+
+	defaultUptimePath    = "/"
+	defaultUptimePeriod  = time.Minute
+	defaultUptimeTimeout = 10 * time.Second
+	maxUptimeTimeout     = time.Minute
+
+	// containsString is the content matcher of uptime checks whose response must contain the
+	// content.
+	containsString = "CONTAINS_STRING"
+)
+
+// uptimePeriods are the supported periods of uptime checks.
+var uptimePeriods = []time.Duration{time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute}
+
+// uptimeRegions are the regions uptime checks can be run from.
+var uptimeRegions = []string{
+	"USA", "EUROPE", "SOUTH_AMERICA", "ASIA_PACIFIC", "USA_OREGON", "USA_IOWA", "USA_VIRGINIA",
+}
+
+func init() {
+	blackstart.RegisterModule("google_monitoring_uptime_check", NewUptimeCheck)
+}
+
+var _ blackstart.Module = &uptimeCheck{}
+
+// uptimeCheck manages a Cloud Monitoring HTTP uptime check of a host identified by its display
+// name.
+type uptimeCheck struct {
+	runtime *monitoringRuntime
+	svc     *monitoring.Service
+	target  *uptimeTarget
+}
+
+// uptimeTarget is the desired state of an uptime check resolved from the module inputs.
+type uptimeTarget struct {
+	project     string
+	displayName string
+	host        string
+	path        string
+	port        int64
+	useSSL      bool
+	validateSSL bool
+	period      time.Duration
+	timeout     time.Duration
+	content     string
+	regions     []string
+	enabled     bool
+	userLabels  map[string]string
+}
+
+func NewUptimeCheck() blackstart.Module {
+	return &uptimeCheck{}
+}
+
+func (u *uptimeCheck) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_monitoring_uptime_check",
+		Name: "Google Cloud Monitoring Uptime Check",
+		Description: util.CleanString(
+			`
+Ensures a Cloud Monitoring uptime check requests a URL of a host over HTTP or HTTPS from locations
+around the world. The check is identified by its '''display_name''', which must be unique in the
+project.
+
+The '''check_id''' output is used in the filters of alert policies on the uptime check, such as
+'''metric.type="monitoring.googleapis.com/uptime_check/check_passed" AND
+metric.label.check_id="<check_id>"'''.
+
+**Notes**
+
+- The host of an existing uptime check cannot be changed. The operation fails when the check
+  requests a different host; delete the check with '''doesNotExist''' to recreate it.
+- '''period''' must be one of '''1m''', '''5m''', '''10m''', or '''15m'''.
+- '''regions''' must include at least three locations. If not set, the check runs from all regions.
+- When '''doesNotExist''' is set, the uptime check is deleted.
+`,
+		),
+		Requirements: []string{
+			"The Cloud Monitoring API (`monitoring.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/monitoring.uptimeCheckConfigEditor` in the project.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Project of the uptime check. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputDisplayName: {
+				Description: "Display name of the uptime check, unique in the project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputHost: {
+				Description: "Host name or IP address the check requests, such as `app.example.com`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+				Immutable:   true,
+			},
+			inputPath: {
+				Description: "Path of the URL the check requests.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultUptimePath,
+			},
+			inputPort: {
+				Description: "Port of the URL the check requests. Defaults to 443 with SSL and 80 without.",
+				Type:        reflect.TypeFor[int](),
+				Required:    false,
+			},
+			inputUseSSL: {
+				Description: "Whether the check requests the URL over HTTPS.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     true,
+			},
+			inputValidateSSL: {
+				Description: "Whether the check fails when the certificate of the host is not valid. Only used with SSL.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     true,
+			},
+			inputPeriod: {
+				Description: "How often the check is run, one of `1m`, `5m`, `10m`, or `15m`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     "1m",
+			},
+			inputTimeout: {
+				Description: "How long the check waits for a response, up to `60s`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     "10s",
+			},
+			inputContent: {
+				Description: "Content the response must contain for the check to pass. If not set, the content is not checked.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputRegions: {
+				Description: "Regions the check is run from, such as `USA`, `EUROPE`, `SOUTH_AMERICA`, or `ASIA_PACIFIC`.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+			inputEnabled: {
+				Description: "Whether the check is run.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     true,
+			},
+			inputUserLabels: {
+				Description: "User labels the uptime check must have, as a map of label keys to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputUptimeCheck: {
+				Description: "Resource name of the uptime check, `projects/<project>/uptimeCheckConfigs/<id>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputCheckID: {
+				Description: "ID of the uptime check, used as the `check_id` label of its metrics.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Health Endpoint": `id: app-uptime
+module: google_monitoring_uptime_check
+inputs:
+  project: app-project
+  display_name: app.example.com health
+  host: app.example.com
+  path: /healthz
+  period: 5m
+  content: ok`,
+		},
+	}
+}
+
+func (u *uptimeCheck) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputDisplayName, inputHost} {
+		if err := cloud.RequiredString(op, key); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputPeriod]; ok && input.IsStatic() {
+		if _, err := inputPeriodValue(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputTimeout]; ok && input.IsStatic() {
+		if _, err := inputTimeoutValue(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputPort]; ok && input.IsStatic() {
+		if _, err := inputPortValue(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputRegions]; ok && input.IsStatic() {
+		if _, err := inputRegionValues(input); err != nil {
+			return err
+		}
+	}
+	return validateLabels(op, inputUserLabels)
+}
+
+// Check reports whether the uptime check is in the requested state.
+func (u *uptimeCheck) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := u.setup(ctx); err != nil {
+		return false, err
+	}
+
+	existing, err := u.find(ctx)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		ctx.Resource(existing.Name)
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if err = u.verifyHost(existing); err != nil {
+		return false, err
+	}
+	if _, mask := u.update(existing); mask != "" {
+		return false, nil
+	}
+	return true, u.output(ctx, existing.Name)
+}
+
+// Set reconciles the uptime check to the requested state.
+func (u *uptimeCheck) Set(ctx blackstart.ModuleContext) error {
+	if err := u.setup(ctx); err != nil {
+		return err
+	}
+
+	existing, err := u.find(ctx)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		ctx.Resource(existing.Name)
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		_, err = u.svc.Projects.UptimeCheckConfigs.Delete(existing.Name).Context(ctx).Do()
+		if err != nil && !cloud.IsNotFound(err) {
+			return fmt.Errorf("failed to delete uptime check %s: %w", existing.Name, err)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		config := u.config()
+		config.DisplayName = u.target.displayName
+		config.MonitoredResource = &monitoring.MonitoredResource{
+			Type: uptimeURLResource,
+			Labels: map[string]string{
+				"host":       u.target.host,
+				"project_id": strings.TrimPrefix(u.target.project, "projects/"),
+			},
+		}
+		created, cErr := u.svc.Projects.UptimeCheckConfigs.Create(u.target.project, config).Context(ctx).Do()
+		if cErr != nil {
+			return fmt.Errorf("failed to create uptime check %q: %w", u.target.displayName, cErr)
+		}
+		ctx.Resource(created.Name)
+		return u.output(ctx, created.Name)
+	}
+	if err = u.verifyHost(existing); err != nil {
+		return err
+	}
+
+	if patch, mask := u.update(existing); mask != "" {
+		_, err = u.svc.Projects.UptimeCheckConfigs.Patch(existing.Name, patch).UpdateMask(mask).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to update uptime check %s: %w", existing.Name, err)
+		}
+	}
+	return u.output(ctx, existing.Name)
+}
+
+// setup resolves the target uptime check from the inputs and creates the Cloud Monitoring service.
+func (u *uptimeCheck) setup(ctx blackstart.ModuleContext) error {
+	project, err := contextProject(ctx)
+	if err != nil {
+		return err
+	}
+	target := &uptimeTarget{
+		project: project,
+		path:    defaultUptimePath,
+		useSSL:  true,
+		period:  defaultUptimePeriod,
+		timeout: defaultUptimeTimeout,
+	}
+	if target.displayName, err = blackstart.ContextInputAs[string](ctx, inputDisplayName, true); err != nil {
+		return err
+	}
+	if target.host, err = blackstart.ContextInputAs[string](ctx, inputHost, true); err != nil {
+		return err
+	}
+	urlPath, err := blackstart.ContextInputAs[string](ctx, inputPath, false)
+	if err != nil {
+		return err
+	}
+	if urlPath != "" {
+		target.path = urlPath
+	}
+	if input, iErr := ctx.Input(inputPort); iErr == nil && input.Any() != nil {
+		if target.port, err = inputPortValue(input); err != nil {
+			return err
+		}
+	}
+	if input, iErr := ctx.Input(inputUseSSL); iErr == nil && input.Any() != nil {
+		if target.useSSL, err = blackstart.InputAs[bool](input, false); err != nil {
+			return fmt.Errorf("invalid %s: %w", inputUseSSL, err)
+		}
+	}
+	target.validateSSL = target.useSSL
+	if input, iErr := ctx.Input(inputValidateSSL); iErr == nil && input.Any() != nil && target.useSSL {
+		if target.validateSSL, err = blackstart.InputAs[bool](input, false); err != nil {
+			return fmt.Errorf("invalid %s: %w", inputValidateSSL, err)
+		}
+	}
+	if input, iErr := ctx.Input(inputPeriod); iErr == nil && input.Any() != nil {
+		if target.period, err = inputPeriodValue(input); err != nil {
+			return err
+		}
+	}
+	if input, iErr := ctx.Input(inputTimeout); iErr == nil && input.Any() != nil {
+		if target.timeout, err = inputTimeoutValue(input); err != nil {
+			return err
+		}
+	}
+	if target.content, err = blackstart.ContextInputAs[string](ctx, inputContent, false); err != nil {
+		return err
+	}
+	if input, iErr := ctx.Input(inputRegions); iErr == nil && input.Any() != nil {
+		if target.regions, err = inputRegionValues(input); err != nil {
+			return err
+		}
+	}
+	if target.enabled, err = contextEnabled(ctx); err != nil {
+		return err
+	}
+	if target.userLabels, err = contextLabels(ctx, inputUserLabels); err != nil {
+		return err
+	}
+	u.target = target
+
+	u.runtime = monitoringRuntimeOrDefault(u.runtime)
+	u.svc, err = u.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Cloud Monitoring service: %w", err)
+	}
+	return nil
+}
+
+// find returns the uptime check with the display name, or nil if it does not exist.
+func (u *uptimeCheck) find(ctx context.Context) (*monitoring.UptimeCheckConfig, error) {
+	var found []*monitoring.UptimeCheckConfig
+	err := u.svc.Projects.UptimeCheckConfigs.List(u.target.project).Pages(
+		ctx, func(resp *monitoring.ListUptimeCheckConfigsResponse) error {
+			for _, config := range resp.UptimeCheckConfigs {
+				if config.DisplayName == u.target.displayName {
+					found = append(found, config)
+				}
+			}
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list uptime checks of %s: %w", u.target.project, err)
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf(
+		"found %d uptime checks named %q in %s, display names must be unique", len(found),
+		u.target.displayName, u.target.project,
+	)
+}
+
+// verifyHost returns an error when the uptime check requests a different host.
+func (u *uptimeCheck) verifyHost(existing *monitoring.UptimeCheckConfig) error {
+	var host string
+	if existing.MonitoredResource != nil {
+		host = existing.MonitoredResource.Labels["host"]
+	}
+	if host != u.target.host {
+		return fmt.Errorf(
+			"uptime check %s requests host %q instead of %q, the host cannot be changed", existing.Name, host,
+			u.target.host,
+		)
+	}
+	return nil
+}
+
+// config returns the configuration of the uptime check that can be updated.
+func (u *uptimeCheck) config() *monitoring.UptimeCheckConfig {
+	config := &monitoring.UptimeCheckConfig{
+		HttpCheck: &monitoring.HttpCheck{
+			Path:            u.target.path,
+			Port:            u.target.port,
+			UseSsl:          u.target.useSSL,
+			ValidateSsl:     u.target.validateSSL,
+			RequestMethod:   "GET",
+			ForceSendFields: []string{"UseSsl", "ValidateSsl"},
+		},
+		Period:          apiDuration(u.target.period),
+		Timeout:         apiDuration(u.target.timeout),
+		SelectedRegions: u.target.regions,
+		Disabled:        !u.target.enabled,
+		UserLabels:      u.target.userLabels,
+		ForceSendFields: []string{"Disabled"},
+	}
+	if u.target.content != "" {
+		config.ContentMatchers = []*monitoring.ContentMatcher{{Content: u.target.content, Matcher: containsString}}
+	}
+	return config
+}
+
+// update returns the patch and the update mask that bring the uptime check to the desired state.
+// The mask is empty when the check is already in the desired state.
+func (u *uptimeCheck) update(existing *monitoring.UptimeCheckConfig) (*monitoring.UptimeCheckConfig, string) {
+	patch := u.config()
+	var mask []string
+	if !u.sameHTTPCheck(existing.HttpCheck) {
+		mask = append(mask, "http_check")
+	}
+	if !sameDuration(existing.Period, u.target.period) {
+		mask = append(mask, "period")
+	}
+	if !sameDuration(existing.Timeout, u.target.timeout) {
+		mask = append(mask, "timeout")
+	}
+	if !u.sameContent(existing.ContentMatchers) {
+		mask = append(mask, "content_matchers")
+	}
+	if !sameItems(existing.SelectedRegions, u.target.regions) {
+		mask = append(mask, "selected_regions")
+	}
+	if existing.Disabled == u.target.enabled {
+		mask = append(mask, "disabled")
+	}
+	if missingLabels(existing.UserLabels, u.target.userLabels) {
+		patch.UserLabels = mergeLabels(existing.UserLabels, u.target.userLabels)
+		mask = append(mask, "user_labels")
+	}
+	return patch, strings.Join(mask, ",")
+}
+
+// sameHTTPCheck reports whether the HTTP check requests the URL of the target. A port that is not
+// set is not compared, since the API sets the default port of the protocol.
+func (u *uptimeCheck) sameHTTPCheck(check *monitoring.HttpCheck) bool {
+	if check == nil {
+		return false
+	}
+	checkPath := check.Path
+	if checkPath == "" {
+		checkPath = defaultUptimePath
+	}
+	return checkPath == u.target.path && check.UseSsl == u.target.useSSL &&
+		check.ValidateSsl == u.target.validateSSL && (u.target.port == 0 || check.Port == u.target.port)
+}
+
+// sameContent reports whether the content matchers of the uptime check match the content.
+func (u *uptimeCheck) sameContent(matchers []*monitoring.ContentMatcher) bool {
+	if u.target.content == "" {
+		return len(matchers) == 0
+	}
+	if len(matchers) != 1 {
+		return false
+	}
+	matcher := matchers[0].Matcher
+	return matchers[0].Content == u.target.content && (matcher == "" || matcher == containsString)
+}
+
+// output emits the outputs of the uptime check.
+func (u *uptimeCheck) output(ctx blackstart.ModuleContext, name string) error {
+	if err := ctx.Output(outputUptimeCheck, name); err != nil {
+		return err
+	}
+	return ctx.Output(outputCheckID, path.Base(name))
+}
+
+// inputPeriodValue returns the period of a period input, which must be a supported period.
+func inputPeriodValue(input blackstart.Input) (time.Duration, error) {
+	raw, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", inputPeriod, err)
+	}
+	period, err := parseDuration(inputPeriod, raw)
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(uptimePeriods, period) {
+		return 0, fmt.Errorf("invalid %s: %q, expected one of 1m, 5m, 10m, or 15m", inputPeriod, raw)
+	}
+	return period, nil
+}
+
+// inputTimeoutValue returns the timeout of a timeout input, which must be between 1s and 60s.
+func inputTimeoutValue(input blackstart.Input) (time.Duration, error) {
+	raw, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", inputTimeout, err)
+	}
+	timeout, err := parseDuration(inputTimeout, raw)
+	if err != nil {
+		return 0, err
+	}
+	if timeout < time.Second || timeout > maxUptimeTimeout || timeout%time.Second != 0 {
+		return 0, fmt.Errorf("invalid %s: %q, expected whole seconds between 1s and 60s", inputTimeout, raw)
+	}
+	return timeout, nil
+}
+
+// inputPortValue returns the port of a port input.
+func inputPortValue(input blackstart.Input) (int64, error) {
+	port, err := blackstart.InputAs[int](input, false)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", inputPort, err)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid %s: %d is not a valid port", inputPort, port)
+	}
+	return int64(port), nil
+}
+
+// inputRegionValues returns the regions of a regions input, in upper case.
+func inputRegionValues(input blackstart.Input) ([]string, error) {
+	raw, err := blackstart.InputAs[[]string](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputRegions, err)
+	}
+	regions := make([]string, 0, len(raw))
+	for _, region := range raw {
+		region = strings.ToUpper(strings.TrimSpace(region))
+		if !slices.Contains(uptimeRegions, region) {
+			return nil, fmt.Errorf(
+				"invalid %s: %q, expected one of %s", inputRegions, region, strings.Join(uptimeRegions, ", "),
+			)
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
@@ -0,0 +1,158 @@
+package monitoring
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/monitoring/v3"
+
+	"github.com/pezops/blackstart"
+)
+
+// testUptimeOperation returns an uptime check operation for the health endpoint of a host.
+func testUptimeOperation() *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "app-uptime",
+		Module: "google_monitoring_uptime_check",
+		Inputs: map[string]blackstart.Input{
+			inputProject:     blackstart.NewInputFromValue("app-project"),
+			inputDisplayName: blackstart.NewInputFromValue("app.example.com health"),
+			inputHost:        blackstart.NewInputFromValue("app.example.com"),
+			inputPath:        blackstart.NewInputFromValue("/healthz"),
+			inputPeriod:      blackstart.NewInputFromValue("5m"),
+			inputContent:     blackstart.NewInputFromValue("ok"),
+		},
+	}
+}
+
+func TestUptimeCheck_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "empty host", key: inputHost, value: "", wantErr: "invalid host: value cannot be empty"},
+		{name: "invalid period", key: inputPeriod, value: "2m", wantErr: `invalid period: "2m", expected one of`},
+		{name: "timeout", key: inputTimeout, value: "30s"},
+		{name: "long timeout", key: inputTimeout, value: "2m", wantErr: "expected whole seconds between 1s and 60s"},
+		{name: "partial timeout", key: inputTimeout, value: "1500ms", wantErr: "expected whole seconds"},
+		{name: "invalid port", key: inputPort, value: 70000, wantErr: "invalid port: 70000 is not a valid port"},
+		{name: "regions", key: inputRegions, value: []any{"usa", "europe", "asia_pacific"}},
+		{name: "invalid region", key: inputRegions, value: []any{"MARS"}, wantErr: `invalid regions: "MARS"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testUptimeOperation()
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewUptimeCheck().Validate(*op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+}
+
+func TestUptimeCheck_Create(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	op := testUptimeOperation()
+	module := &uptimeCheck{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	var created monitoring.UptimeCheckConfig
+	fake.get(collectionUptime, 0, &created)
+	require.Equal(t, created.Name, ctx.outputs[outputUptimeCheck])
+	require.Equal(t, "uptimeCheckConfigs-1", ctx.outputs[outputCheckID])
+	require.Equal(t, uptimeURLResource, created.MonitoredResource.Type)
+	require.Equal(
+		t, map[string]string{"host": "app.example.com", "project_id": "app-project"},
+		created.MonitoredResource.Labels,
+	)
+	require.Equal(t, "/healthz", created.HttpCheck.Path)
+	require.True(t, created.HttpCheck.UseSsl)
+	require.True(t, created.HttpCheck.ValidateSsl)
+	require.Equal(t, "300s", created.Period)
+	require.Equal(t, "10s", created.Timeout)
+	require.Equal(t, []*monitoring.ContentMatcher{{Content: "ok", Matcher: containsString}}, created.ContentMatchers)
+	require.False(t, created.Disabled)
+
+	// The API sets the default port of the protocol.
+	fake.resources[collectionUptime][0]["httpCheck"].(map[string]any)["port"] = 443
+	ctx = testContext(op)
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "uptimeCheckConfigs-1", ctx.outputs[outputCheckID])
+}
+
+func TestUptimeCheck_Update(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	fake.add(
+		collectionUptime, &monitoring.UptimeCheckConfig{
+			DisplayName: "app.example.com health",
+			MonitoredResource: &monitoring.MonitoredResource{
+				Type: uptimeURLResource, Labels: map[string]string{"host": "app.example.com"},
+			},
+			HttpCheck:       &monitoring.HttpCheck{Path: "/healthz", UseSsl: true, ValidateSsl: true, Port: 443},
+			Period:          "60s",
+			Timeout:         "10s",
+			SelectedRegions: []string{"EUROPE", "USA", "ASIA_PACIFIC"},
+			UserLabels:      map[string]string{"owner": "platform"},
+		},
+	)
+	op := testUptimeOperation()
+	op.Inputs[inputRegions] = blackstart.NewInputFromValue([]any{"usa", "europe", "asia_pacific"})
+	op.Inputs[inputEnabled] = blackstart.NewInputFromValue(false)
+	module := &uptimeCheck{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, []string{"period,content_matchers,disabled"}, fake.masks)
+	var updated monitoring.UptimeCheckConfig
+	fake.get(collectionUptime, 0, &updated)
+	require.Equal(t, "300s", updated.Period)
+	require.True(t, updated.Disabled)
+	require.Equal(t, map[string]string{"owner": "platform"}, updated.UserLabels)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The host of an uptime check cannot be changed.
+	op.Inputs[inputHost] = blackstart.NewInputFromValue("api.example.com")
+	err = module.Set(testContext(op))
+	require.ErrorContains(t, err, `requests host "app.example.com" instead of "api.example.com"`)
+	require.Len(t, fake.masks, 1)
+}
+
+func TestUptimeCheck_DoesNotExist(t *testing.T) {
+	fake := newFakeMonitoring(t)
+	name := fake.add(collectionUptime, &monitoring.UptimeCheckConfig{DisplayName: "app.example.com health"})
+	op := testUptimeOperation()
+	op.DoesNotExist = true
+	module := &uptimeCheck{runtime: fake.runtime()}
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete, name))
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,289 @@
+package serviceusage
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/serviceusage/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_project_service", NewProjectService)
+}
+
+var _ blackstart.Module = &projectService{}
+
+// projectService manages the enabled APIs of a Google Cloud project.
+type projectService struct {
+	runtime  *serviceUsageRuntime
+	svc      *serviceusage.Service
+	project  string
+	services []string
+}
+
+func NewProjectService() blackstart.Module {
+	return &projectService{}
+}
+
+func (p *projectService) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_project_service",
+		Name: "Google Project Service",
+		Description: util.CleanString(
+			`
+Ensures Google Cloud APIs are enabled in a project, such as the Cloud SQL Admin and Secret Manager
+APIs used by later operations of the workflow.
+
+**Notes**
+
+- Services are enabled in batches of up to 20 services, and the operation waits until they are
+  enabled.
+- Services that are enabled in the project but not listed in '''services''' are not changed.
+- With '''doesNotExist''', the services are disabled. Disabling a service that other enabled
+  services depend on fails, unless '''disable_dependent_services''' is set.
+`,
+		),
+		Requirements: []string{
+			"The Service Usage API (`serviceusage.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/serviceusage.serviceUsageAdmin` in the project.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Google Cloud project to enable the services in. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputServices: {
+				Description: "Names of the services, such as `sqladmin.googleapis.com`.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    true,
+			},
+			inputDisableDependentServices: {
+				Description: "With `doesNotExist`, also disable the enabled services that depend on the services.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputProject: {
+				Description: "Google Cloud project the services are enabled in.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputServices: {
+				Description: "Names of the enabled services.",
+				Type:        reflect.TypeFor[[]string](),
+			},
+		},
+		Examples: map[string]string{
+			"Application APIs": `id: app-apis
+module: google_project_service
+inputs:
+  project: app-project
+  services:
+    - sqladmin.googleapis.com
+    - secretmanager.googleapis.com
+    - artifactregistry.googleapis.com`,
+		},
+	}
+}
+
+func (p *projectService) Validate(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputServices]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", inputServices)
+	}
+	if input.IsStatic() {
+		services, err := blackstart.InputAs[[]string](input, true)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputServices, err)
+		}
+		if _, err = serviceNames(services); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether all services are enabled, or all are disabled with doesNotExist.
+func (p *projectService) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := p.setup(ctx); err != nil {
+		return false, err
+	}
+	p.resources(ctx)
+
+	enabled, err := p.enabled(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return len(enabled) == 0, nil
+	}
+	if len(enabled) < len(p.services) || ctx.Tainted() {
+		return false, nil
+	}
+	return true, p.outputs(ctx)
+}
+
+// Set enables the services that are not enabled, or disables the enabled services with
+// doesNotExist.
+func (p *projectService) Set(ctx blackstart.ModuleContext) error {
+	if err := p.setup(ctx); err != nil {
+		return err
+	}
+	p.resources(ctx)
+
+	enabled, err := p.enabled(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.DoesNotExist() {
+		return p.disable(ctx, enabled)
+	}
+
+	var missing []string
+	for _, service := range p.services {
+		if !enabled[service] {
+			missing = append(missing, service)
+		}
+	}
+	for batch := range slices.Chunk(missing, maxBatchEnable) {
+		op, bErr := p.svc.Services.BatchEnable(
+			"projects/"+p.project, &serviceusage.BatchEnableServicesRequest{ServiceIds: batch},
+		).Context(ctx).Do()
+		if bErr != nil {
+			return fmt.Errorf("failed to enable services %s: %w", strings.Join(batch, ", "), bErr)
+		}
+		if err = waitForOperation(ctx, p.svc, op); err != nil {
+			return fmt.Errorf("failed to enable services %s: %w", strings.Join(batch, ", "), err)
+		}
+	}
+	return p.outputs(ctx)
+}
+
+// disable disables the enabled services. The Service Usage API has no batch disable, so services
+// are disabled one at a time.
+func (p *projectService) disable(ctx blackstart.ModuleContext, enabled map[string]bool) error {
+	disableDependents, err := blackstart.ContextInputAs[bool](ctx, inputDisableDependentServices, false)
+	if err != nil {
+		return err
+	}
+	for _, service := range p.services {
+		if !enabled[service] {
+			continue
+		}
+		op, dErr := p.svc.Services.Disable(
+			p.serviceName(service), &serviceusage.DisableServiceRequest{
+				DisableDependentServices: disableDependents,
+			},
+		).Context(ctx).Do()
+		if dErr != nil {
+			return fmt.Errorf("failed to disable service %s: %w", service, dErr)
+		}
+		if err = waitForOperation(ctx, p.svc, op); err != nil {
+			return fmt.Errorf("failed to disable service %s: %w", service, err)
+		}
+	}
+	return nil
+}
+
+// setup resolves the project and services from the inputs and creates the Service Usage service.
+func (p *projectService) setup(ctx blackstart.ModuleContext) error {
+	var err error
+	p.project, err = blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return err
+	}
+	if p.project == "" {
+		p.project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	services, err := blackstart.ContextInputAs[[]string](ctx, inputServices, true)
+	if err != nil {
+		return err
+	}
+	if p.services, err = serviceNames(services); err != nil {
+		return err
+	}
+
+	p.runtime = serviceUsageRuntimeOrDefault(p.runtime)
+	p.svc, err = p.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Service Usage service: %w", err)
+	}
+	return nil
+}
+
+// enabled returns the services that are enabled in the project. Services are read in batches of up
+// to 30 services.
+func (p *projectService) enabled(ctx context.Context) (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	for batch := range slices.Chunk(p.services, maxBatchGet) {
+		names := make([]string, 0, len(batch))
+		for _, service := range batch {
+			names = append(names, p.serviceName(service))
+		}
+		res, err := p.svc.Services.BatchGet("projects/" + p.project).Names(names...).Context(ctx).Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get services of project %s: %w", p.project, err)
+		}
+		for _, s := range res.Services {
+			if s.State != stateEnabled {
+				continue
+			}
+			// The API names services by the project number, so services are matched by their name.
+			_, service, _ := strings.Cut(s.Name, "/services/")
+			if slices.Contains(batch, service) {
+				enabled[service] = true
+			}
+		}
+	}
+	return enabled, nil
+}
+
+// serviceName returns the resource name of a service of the project.
+func (p *projectService) serviceName(service string) string {
+	return fmt.Sprintf("projects/%s/services/%s", p.project, service)
+}
+
+// resources reports the services of the project as managed resources.
+func (p *projectService) resources(ctx blackstart.ModuleContext) {
+	for _, service := range p.services {
+		ctx.Resource(p.serviceName(service))
+	}
+}
+
+// outputs sets the outputs of the enabled services.
+func (p *projectService) outputs(ctx blackstart.ModuleContext) error {
+	if err := ctx.Output(outputProject, p.project); err != nil {
+		return err
+	}
+	return ctx.Output(outputServices, slices.Clone(p.services))
+}
+
+// serviceNames validates the service names and returns them without duplicates, in their order.
+func serviceNames(services []string) ([]string, error) {
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		service = strings.TrimSpace(service)
+		if service == "" {
+			return nil, fmt.Errorf("%s cannot contain empty names", inputServices)
+		}
+		if !strings.Contains(service, ".") || strings.Contains(service, "/") {
+			return nil, fmt.Errorf("service %q must be a service name, such as sqladmin.googleapis.com", service)
+		}
+		if !slices.Contains(names, service) {
+			names = append(names, service)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,244 @@
+package serviceusage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/api/serviceusage/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+// fakeServiceUsage implements the Service Usage REST operations used by the project service
+// module.
+type fakeServiceUsage struct {
+	t       *testing.T
+	server  *httptest.Server
+	enabled map[string]bool
+	// batches are the services of each batch enable request.
+	batches [][]string
+	// disabled are the disable requests, with the dependent services flag.
+	disabled []string
+	// Operations reports operations as running until PendingPolls polls were made.
+	cloudtest.Operations
+	mu sync.Mutex
+}
+
+// newFakeServiceUsage starts a stateful fake Service Usage API server.
+func newFakeServiceUsage(t *testing.T, enabled ...string) *fakeServiceUsage {
+	t.Helper()
+	f := &fakeServiceUsage{t: t, enabled: map[string]bool{}}
+	for _, service := range enabled {
+		f.enabled[service] = true
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a Service Usage runtime connected to the fake API.
+func (f *fakeServiceUsage) runtime() *serviceUsageRuntime {
+	return &serviceUsageRuntime{
+		newService: func(ctx context.Context) (*serviceusage.Service, error) {
+			return serviceusage.NewService(
+				ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication(),
+			)
+		},
+	}
+}
+
+// serveHTTP handles the Service Usage API operations used by the unit tests.
+func (f *fakeServiceUsage) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "operations/"):
+		cloudtest.WriteJSON(f.t, w, f.Poll(path))
+	case r.Method == http.MethodGet && path == "projects/app-project/services:batchGet":
+		names := r.URL.Query()["names"]
+		require.LessOrEqual(f.t, len(names), maxBatchGet)
+		res := &serviceusage.BatchGetServicesResponse{}
+		for _, name := range names {
+			_, service, _ := strings.Cut(name, "/services/")
+			state := "DISABLED"
+			if f.enabled[service] {
+				state = stateEnabled
+			}
+			// The API names services by the project number.
+			res.Services = append(
+				res.Services, &serviceusage.GoogleApiServiceusageV1Service{
+					Name: "projects/123456/services/" + service, State: state,
+				},
+			)
+		}
+		cloudtest.WriteJSON(f.t, w, res)
+	case r.Method == http.MethodPost && path == "projects/app-project/services:batchEnable":
+		var req serviceusage.BatchEnableServicesRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.LessOrEqual(f.t, len(req.ServiceIds), maxBatchEnable)
+		f.batches = append(f.batches, req.ServiceIds)
+		for _, service := range req.ServiceIds {
+			f.enabled[service] = true
+		}
+		cloudtest.WriteJSON(f.t, w, f.Start(fmt.Sprintf("operations/enable-%d", len(f.batches))))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, ":disable"):
+		var req serviceusage.DisableServiceRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		_, service, _ := strings.Cut(strings.TrimSuffix(path, ":disable"), "/services/")
+		f.disabled = append(f.disabled, fmt.Sprintf("%s:%t", service, req.DisableDependentServices))
+		delete(f.enabled, service)
+		cloudtest.WriteJSON(f.t, w, &serviceusage.Operation{Name: "operations/disable", Done: true})
+	default:
+		f.t.Errorf("unexpected Service Usage API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testContext creates a module context for the operation that records outputs.
+func testContext(op *blackstart.Operation) *outputContext {
+	return &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), op),
+		outputs:       map[string]any{},
+	}
+}
+
+// testOperation returns a project service operation with the services.
+func testOperation(services ...string) *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "apis",
+		Module: "google_project_service",
+		Inputs: map[string]blackstart.Input{
+			inputProject:  blackstart.NewInputFromValue("app-project"),
+			inputServices: blackstart.NewInputFromValue(services),
+		},
+	}
+}
+
+func TestProjectService_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		services any
+		wantErr  string
+	}{
+		{name: "valid", services: []any{"sqladmin.googleapis.com", "run.googleapis.com"}},
+		{name: "empty list", services: []any{}, wantErr: "invalid services"},
+		{name: "empty name", services: []any{"sqladmin.googleapis.com", " "}, wantErr: "cannot contain empty names"},
+		{name: "short name", services: []any{"sqladmin"}, wantErr: `service "sqladmin" must be a service name`},
+		{
+			name:     "resource name",
+			services: []any{"projects/app-project/services/sqladmin.googleapis.com"},
+			wantErr:  "must be a service name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := blackstart.Operation{
+					Inputs: map[string]blackstart.Input{inputServices: blackstart.NewInputFromValue(tt.services)},
+				}
+				err := NewProjectService().Validate(op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+
+	err := NewProjectService().Validate(blackstart.Operation{Inputs: map[string]blackstart.Input{}})
+	require.ErrorContains(t, err, "missing required parameter: services")
+}
+
+func TestProjectService_Enable(t *testing.T) {
+	operationPollInitialInterval = time.Millisecond
+	fake := newFakeServiceUsage(t, "run.googleapis.com")
+	fake.PendingPolls = 2
+	op := testOperation("sqladmin.googleapis.com", "run.googleapis.com", "secretmanager.googleapis.com")
+	m := &projectService{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, ctx.outputs)
+
+	require.NoError(t, m.Set(ctx))
+	// Only the services that are not enabled are enabled.
+	require.Equal(t, [][]string{{"sqladmin.googleapis.com", "secretmanager.googleapis.com"}}, fake.batches)
+	require.Equal(t, "app-project", ctx.outputs[outputProject])
+	require.Equal(
+		t, []string{"sqladmin.googleapis.com", "run.googleapis.com", "secretmanager.googleapis.com"},
+		ctx.outputs[outputServices],
+	)
+
+	ctx = testContext(op)
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "app-project", ctx.outputs[outputProject])
+}
+
+func TestProjectService_EnableBatches(t *testing.T) {
+	fake := newFakeServiceUsage(t)
+	services := make([]string, 45)
+	for i := range services {
+		services[i] = fmt.Sprintf("api%d.googleapis.com", i)
+	}
+	op := testOperation(services...)
+	m := &projectService{runtime: fake.runtime()}
+
+	require.NoError(t, m.Set(testContext(op)))
+	require.Len(t, fake.batches, 3)
+	require.Len(t, fake.batches[0], maxBatchEnable)
+	require.Len(t, fake.batches[2], 5)
+
+	ok, err := m.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestProjectService_DoesNotExist(t *testing.T) {
+	fake := newFakeServiceUsage(t, "sqladmin.googleapis.com", "run.googleapis.com")
+	op := testOperation("sqladmin.googleapis.com", "secretmanager.googleapis.com")
+	op.DoesNotExist = true
+	op.Inputs[inputDisableDependentServices] = blackstart.NewInputFromValue(true)
+	m := &projectService{runtime: fake.runtime()}
+
+	ok, err := m.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, m.Set(testContext(op)))
+	// Only the enabled services are disabled, and other services are not changed.
+	require.Equal(t, []string{"sqladmin.googleapis.com:true"}, fake.disabled)
+	require.True(t, fake.enabled["run.googleapis.com"])
+
+	ok, err = m.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
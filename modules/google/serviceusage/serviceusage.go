@@ -0,0 +1,67 @@
+package serviceusage
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/serviceusage/v1"
+
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject                  = "project"
+	inputServices                 = "services"
+	inputDisableDependentServices = "disable_dependent_services"
+
+	outputProject  = "project"
+	outputServices = "services"
+
+	stateEnabled = "ENABLED"
+
+	// maxBatchEnable is the maximum number of services the Service Usage API enables in one request.
+	maxBatchEnable = 20
+	// maxBatchGet is the maximum number of services the Service Usage API gets in one request.
+	maxBatchGet = 30
+)
+
+// Service Usage mutations return long-running operations. They are polled with exponential backoff
+// between these intervals until they are done.
+var (
+	operationPollInitialInterval = 2 * time.Second
+	operationPollMaxInterval     = 15 * time.Second
+)
+
+// serviceUsageRuntime provides the injectable Service Usage API dependency.
+type serviceUsageRuntime struct {
+	newService func(context.Context) (*serviceusage.Service, error)
+}
+
+// defaultServiceUsageRuntime creates the production Service Usage runtime.
+func defaultServiceUsageRuntime() *serviceUsageRuntime {
+	return &serviceUsageRuntime{
+		newService: func(ctx context.Context) (*serviceusage.Service, error) {
+			return cloud.NewService(ctx, serviceusage.NewService, nil, serviceusage.CloudPlatformScope)
+		},
+	}
+}
+
+// serviceUsageRuntimeOrDefault returns runtime when configured, or the production runtime
+// otherwise.
+func serviceUsageRuntimeOrDefault(runtime *serviceUsageRuntime) *serviceUsageRuntime {
+	if runtime == nil {
+		return defaultServiceUsageRuntime()
+	}
+	return runtime
+}
+
+// waitForOperation polls a Service Usage operation until it is done and returns any error reported
+// by the operation.
+func waitForOperation(ctx context.Context, svc *serviceusage.Service, op *serviceusage.Operation) error {
+	polling := cloud.OperationPolling{InitialInterval: operationPollInitialInterval, MaxInterval: operationPollMaxInterval}
+	return cloud.WaitForOperation(
+		ctx, op, polling, func(ctx context.Context, name string) (*serviceusage.Operation, error) {
+			return svc.Operations.Get(name).Context(ctx).Do()
+		},
+	)
+}
@@ -0,0 +1,364 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_spanner_database", NewDatabase)
+}
+
+var _ blackstart.Module = &database{}
+
+// database manages a Spanner database and its schema.
+type database struct {
+	runtime *spannerRuntime
+	svc     *spanner.Service
+	target  *databaseTarget
+}
+
+// databaseTarget is the desired state of a database resolved from the module inputs.
+type databaseTarget struct {
+	instance string
+	id       string
+	dialect  string
+	ddl      []ddlStatement
+}
+
+// name returns the resource name of the database.
+func (t *databaseTarget) name() string {
+	return t.instance + "/databases/" + t.id
+}
+
+// createStatement returns the statement that creates the database, with the quotes of the dialect.
+func (t *databaseTarget) createStatement() string {
+	if t.dialect == dialectPostgreSQL {
+		return fmt.Sprintf(`CREATE DATABASE "%s"`, t.id)
+	}
+	return fmt.Sprintf("CREATE DATABASE `%s`", t.id)
+}
+
+func NewDatabase() blackstart.Module {
+	return &database{}
+}
+
+func (d *database) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_spanner_database",
+		Name: "Google Spanner Database",
+		Description: util.CleanString(
+			`
+Ensures a Spanner database exists in an instance, and applies the DDL statements of its schema that
+are not applied yet.
+
+Statements are compared to the schema the database reports, so they are applied once:
+
+- '''CREATE''' statements, such as '''CREATE TABLE''', '''CREATE INDEX''', or '''CREATE ROLE''', are
+  applied when the database has no object of the same kind and name.
+- '''ALTER TABLE ... ADD COLUMN''' statements are applied when the table has no column of the name.
+- '''GRANT''' statements are applied when the database has no identical statement, ignoring case and
+  whitespace. They must grant privileges as the database reports them, one statement for each object.
+
+Other statements, such as '''DROP''' or '''ALTER''' statements that change existing objects, cannot
+be compared to the schema and are not supported. Pending statements are applied in order in one
+schema update.
+
+**Notes**
+
+- Objects of the schema that are not created by '''ddl''' are not changed.
+- The dialect of an existing database cannot be changed. The operation fails when the database has
+  a different dialect.
+- When '''doesNotExist''' is set, the database is dropped. Databases with drop protection are not
+  dropped and the operation fails.
+`,
+		),
+		Requirements: []string{
+			"The Spanner API (`spanner.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/spanner.databaseAdmin` on the instance.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputInstance: {
+				Description: "Resource name of the instance, `projects/<project>/instances/<instance>`, such as the `instance` output of `google_spanner_instance`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputDatabase: {
+				Description: "ID of the database, such as `orders`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputDialect: {
+				Description: "SQL dialect of the database, `GOOGLE_STANDARD_SQL` or `POSTGRESQL`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     dialectGoogleSQL,
+				Immutable:   true,
+			},
+			inputDDL: {
+				Description: "DDL statements of the schema of the database, applied in order when they are not applied yet.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputDatabase: {
+				Description: "Resource name of the database, `projects/<project>/instances/<instance>/databases/<database>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputDialect: {
+				Description: "SQL dialect of the database.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Orders Database": `id: orders-database
+module: google_spanner_database
+inputs:
+  instance:
+    fromDependency:
+      id: spanner-instance
+      output: instance
+  database: orders
+  ddl:
+    - |
+      CREATE TABLE Orders (
+        OrderId STRING(36) NOT NULL,
+        CustomerId STRING(36) NOT NULL,
+        CreatedAt TIMESTAMP NOT NULL OPTIONS (allow_commit_timestamp = true),
+      ) PRIMARY KEY (OrderId)
+    - CREATE INDEX OrdersByCustomer ON Orders(CustomerId)
+    - ALTER TABLE Orders ADD COLUMN Total NUMERIC
+    - CREATE ROLE order_reader
+    - GRANT SELECT ON TABLE Orders TO ROLE order_reader`,
+		},
+	}
+}
+
+func (d *database) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputInstance, inputDatabase} {
+		if err := cloud.RequiredString(op, key); err != nil {
+			return err
+		}
+	}
+	if input := op.Inputs[inputInstance]; input.IsStatic() {
+		value, _ := blackstart.InputAs[string](input, true)
+		if _, err := instanceName(value); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputDialect]; ok && input.IsStatic() {
+		dialect, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputDialect, err)
+		}
+		if _, err = normalizeDialect(dialect); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputDDL]; ok && input.IsStatic() {
+		statements, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", inputDDL, err)
+		}
+		if _, err = parseDDL(statements); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the database exists with all the statements of its schema applied.
+func (d *database) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := d.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(d.target.name())
+
+	existing, err := d.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if err = d.verifyDialect(existing); err != nil {
+		return false, err
+	}
+	pending, err := d.pending(ctx)
+	if err != nil || len(pending) > 0 {
+		return false, err
+	}
+	return true, d.output(ctx)
+}
+
+// Set creates the database when it does not exist, and applies the pending statements of its
+// schema.
+func (d *database) Set(ctx blackstart.ModuleContext) error {
+	if err := d.setup(ctx); err != nil {
+		return err
+	}
+	name := d.target.name()
+	ctx.Resource(name)
+
+	existing, err := d.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		_, err = d.databases().DropDatabase(name).Context(ctx).Do()
+		if err != nil && !cloud.IsNotFound(err) {
+			return fmt.Errorf("failed to drop database %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		// The schema is applied with a separate update, since PostgreSQL databases cannot be
+		// created with extra statements.
+		op, cErr := d.databases().Create(
+			d.target.instance, &spanner.CreateDatabaseRequest{
+				CreateStatement: d.target.createStatement(), DatabaseDialect: d.target.dialect,
+			},
+		).Context(ctx).Do()
+		if cErr != nil {
+			return fmt.Errorf("failed to create database %s: %w", name, cErr)
+		}
+		if err = waitForOperation(ctx, d.svc, op); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", name, err)
+		}
+	} else if err = d.verifyDialect(existing); err != nil {
+		return err
+	}
+
+	pending, err := d.pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		op, uErr := d.databases().UpdateDdl(name, &spanner.UpdateDatabaseDdlRequest{Statements: pending}).
+			Context(ctx).Do()
+		if uErr != nil {
+			return fmt.Errorf("failed to update schema of database %s: %w", name, uErr)
+		}
+		if err = waitForOperation(ctx, d.svc, op); err != nil {
+			return fmt.Errorf("failed to update schema of database %s: %w", name, err)
+		}
+	}
+	return d.output(ctx)
+}
+
+// databases returns the databases service of the Spanner API.
+func (d *database) databases() *spanner.ProjectsInstancesDatabasesService {
+	return d.svc.Projects.Instances.Databases
+}
+
+// setup resolves the target database from the inputs and creates the Spanner service.
+func (d *database) setup(ctx blackstart.ModuleContext) error {
+	target := &databaseTarget{}
+	raw, err := blackstart.ContextInputAs[string](ctx, inputInstance, true)
+	if err != nil {
+		return err
+	}
+	if target.instance, err = instanceName(raw); err != nil {
+		return err
+	}
+	if target.id, err = blackstart.ContextInputAs[string](ctx, inputDatabase, true); err != nil {
+		return err
+	}
+	dialect, err := blackstart.ContextInputAs[string](ctx, inputDialect, false)
+	if err != nil {
+		return err
+	}
+	if target.dialect, err = normalizeDialect(dialect); err != nil {
+		return err
+	}
+	statements, err := blackstart.ContextInputAs[[]string](ctx, inputDDL, false)
+	if err != nil {
+		return err
+	}
+	if target.ddl, err = parseDDL(statements); err != nil {
+		return err
+	}
+	d.target = target
+
+	d.runtime = spannerRuntimeOrDefault(d.runtime)
+	d.svc, err = d.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Spanner service: %w", err)
+	}
+	return nil
+}
+
+// get returns the database, or nil if it does not exist.
+func (d *database) get(ctx context.Context) (*spanner.Database, error) {
+	existing, err := d.databases().Get(d.target.name()).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get database %s: %w", d.target.name(), err)
+	}
+	return existing, nil
+}
+
+// verifyDialect returns an error when the database has a different dialect.
+func (d *database) verifyDialect(existing *spanner.Database) error {
+	dialect := existing.DatabaseDialect
+	if dialect == "" || dialect == "DATABASE_DIALECT_UNSPECIFIED" {
+		dialect = dialectGoogleSQL
+	}
+	if dialect != d.target.dialect {
+		return fmt.Errorf("database %s has dialect %s instead of %s", d.target.name(), dialect, d.target.dialect)
+	}
+	return nil
+}
+
+// pending returns the statements of the ddl input that are not applied to the database.
+func (d *database) pending(ctx context.Context) ([]string, error) {
+	if len(d.target.ddl) == 0 {
+		return nil, nil
+	}
+	res, err := d.databases().GetDdl(d.target.name()).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema of database %s: %w", d.target.name(), err)
+	}
+	return pendingStatements(d.target.ddl, res.Statements), nil
+}
+
+// output emits the outputs of the database.
+func (d *database) output(ctx blackstart.ModuleContext) error {
+	if err := ctx.Output(outputDatabase, d.target.name()); err != nil {
+		return err
+	}
+	return ctx.Output(outputDialect, d.target.dialect)
+}
+
+// normalizeDialect returns the dialect of a dialect input, which defaults to GOOGLE_STANDARD_SQL.
+func normalizeDialect(dialect string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(dialect)) {
+	case "", dialectGoogleSQL:
+		return dialectGoogleSQL, nil
+	case dialectPostgreSQL:
+		return dialectPostgreSQL, nil
+	default:
+		return "", fmt.Errorf(
+			"invalid %s: %q, expected %s or %s", inputDialect, dialect, dialectGoogleSQL, dialectPostgreSQL,
+		)
+	}
+}
@@ -0,0 +1,150 @@
+package spanner
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+// testDDL is the schema of the orders database.
+var testDDL = []any{
+	"CREATE TABLE Orders (\n  OrderId STRING(36) NOT NULL,\n  CustomerId STRING(36) NOT NULL,\n) PRIMARY KEY (OrderId)",
+	"CREATE INDEX OrdersByCustomer ON Orders(CustomerId)",
+	"CREATE ROLE order_reader",
+	"GRANT SELECT ON TABLE Orders TO ROLE order_reader",
+}
+
+// testDatabaseOperation returns a database operation for the orders database.
+func testDatabaseOperation(ddl ...any) *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "orders-database",
+		Module: "google_spanner_database",
+		Inputs: map[string]blackstart.Input{
+			inputInstance: blackstart.NewInputFromValue(testInstance),
+			inputDatabase: blackstart.NewInputFromValue("orders"),
+			inputDDL:      blackstart.NewInputFromValue(ddl),
+		},
+	}
+}
+
+func TestDatabase_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "instance id", key: inputInstance, value: "app", wantErr: "must be a resource name"},
+		{name: "invalid dialect", key: inputDialect, value: "MYSQL", wantErr: `invalid dialect: "MYSQL"`},
+		{name: "postgresql dialect", key: inputDialect, value: "postgresql"},
+		{
+			name: "unsupported statement", key: inputDDL, value: []any{"DROP INDEX OrdersByCustomer"},
+			wantErr: "invalid ddl: statement",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testDatabaseOperation(testDDL...)
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewDatabase().Validate(*op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+}
+
+func TestDatabase_CreateAndApplySchema(t *testing.T) {
+	fake := newFakeSpanner(t)
+	fake.PendingPolls = 1
+	op := testDatabaseOperation(testDDL...)
+	module := &database{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, testDatabase, ctx.outputs[outputDatabase])
+	require.Equal(t, dialectGoogleSQL, ctx.outputs[outputDialect])
+	require.Equal(t, dialectGoogleSQL, fake.databases[testDatabase].DatabaseDialect)
+	require.Len(t, fake.updates, 1)
+	require.Len(t, fake.updates[0], 4)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Only the statements that are not applied are applied.
+	op = testDatabaseOperation(
+		append(
+			testDDL, "ALTER TABLE Orders ADD COLUMN Total NUMERIC", "CREATE INDEX OrdersByTotal ON Orders(Total)",
+		)...,
+	)
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(
+		t, []string{"ALTER TABLE Orders ADD COLUMN Total NUMERIC", "CREATE INDEX OrdersByTotal ON Orders(Total)"},
+		fake.updates[1],
+	)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDatabase_PostgreSQL(t *testing.T) {
+	fake := newFakeSpanner(t)
+	op := testDatabaseOperation()
+	op.Inputs[inputDialect] = blackstart.NewInputFromValue("postgresql")
+	module := &database{runtime: fake.runtime()}
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, dialectPostgreSQL, fake.databases[testDatabase].DatabaseDialect)
+	// No schema update is made without statements.
+	require.Empty(t, fake.updates)
+
+	// The dialect of an existing database cannot be changed.
+	op.Inputs[inputDialect] = blackstart.NewInputFromValue(dialectGoogleSQL)
+	_, err := module.Check(testContext(op))
+	require.EqualError(
+		t, err,
+		"database projects/app-project/instances/app/databases/orders has dialect POSTGRESQL instead of GOOGLE_STANDARD_SQL",
+	)
+}
+
+func TestDatabase_DoesNotExist(t *testing.T) {
+	fake := newFakeSpanner(t)
+	fake.databases[testDatabase] = &spanner.Database{Name: testDatabase, State: "READY"}
+	op := testDatabaseOperation()
+	op.DoesNotExist = true
+	module := &database{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Empty(t, fake.databases)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete))
+}
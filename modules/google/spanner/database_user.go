@@ -0,0 +1,290 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_spanner_database_user", NewDatabaseUser)
+}
+
+var _ blackstart.Module = &databaseUser{}
+
+// databaseUser grants IAM roles and database roles on a Spanner database to a member.
+type databaseUser struct {
+	runtime  *spannerRuntime
+	svc      *spanner.Service
+	database string
+	member   string
+	grants   []grant
+}
+
+func NewDatabaseUser() blackstart.Module {
+	return &databaseUser{}
+}
+
+func (u *databaseUser) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_spanner_database_user",
+		Name: "Google Spanner Database User",
+		Description: util.CleanString(
+			`
+Grants IAM roles on a Spanner database to a member, such as the service account of an application.
+
+IAM roles, such as '''roles/spanner.databaseUser''', are granted with '''roles'''. Database roles of
+fine-grained access control, created with '''CREATE ROLE''' statements, are granted with
+'''database_roles'''. The member is granted '''roles/spanner.fineGrainedAccessUser''', and
+'''roles/spanner.databaseRoleUser''' with a condition on the name of each database role.
+
+**Notes**
+
+- Members granted a role by other IAM bindings of the database are not removed.
+- When '''doesNotExist''' is set, the member is removed from the bindings of the roles and database
+  roles. Bindings without members are removed.
+`,
+		),
+		Requirements: []string{
+			"The Spanner API (`spanner.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/spanner.databaseAdmin` on the database.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputDatabase: {
+				Description: "Resource name of the database, `projects/<project>/instances/<instance>/databases/<database>`, such as the `database` output of `google_spanner_database`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputMember: {
+				Description: "IAM member granted the roles, such as `serviceAccount:app@project.iam.gserviceaccount.com` or `group:ops@example.com`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputRoles: {
+				Description: "IAM roles granted on the database, such as `roles/spanner.databaseReader`.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+			inputDatabaseRoles: {
+				Description: "Database roles of fine-grained access control granted to the member.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputDatabase: {
+				Description: "Resource name of the database.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputMember: {
+				Description: "IAM member granted the roles.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Application Access": `id: orders-app-user
+module: google_spanner_database_user
+inputs:
+  database:
+    fromDependency:
+      id: orders-database
+      output: database
+  member: serviceAccount:orders@app-project.iam.gserviceaccount.com
+  roles:
+    - roles/spanner.databaseUser`,
+			"Fine-Grained Access": `id: orders-reader
+module: google_spanner_database_user
+inputs:
+  database:
+    fromDependency:
+      id: orders-database
+      output: database
+  member: group:analysts@example.com
+  database_roles:
+    - order_reader`,
+		},
+	}
+}
+
+func (u *databaseUser) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputDatabase, inputMember} {
+		if err := cloud.RequiredString(op, key); err != nil {
+			return err
+		}
+	}
+	if input := op.Inputs[inputDatabase]; input.IsStatic() {
+		value, _ := blackstart.InputAs[string](input, true)
+		if _, err := databaseName(value); err != nil {
+			return err
+		}
+	}
+	if input := op.Inputs[inputMember]; input.IsStatic() {
+		value, _ := blackstart.InputAs[string](input, true)
+		if !validMember(value) {
+			return fmt.Errorf("invalid %s: %q must have a type, such as serviceAccount:", inputMember, value)
+		}
+	}
+	var lists [2][]string
+	for i, key := range []string{inputRoles, inputDatabaseRoles} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			continue
+		}
+		if !input.IsStatic() {
+			return nil
+		}
+		values, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", key, err)
+		}
+		lists[i] = values
+	}
+	_, err := inputGrants(lists[0], lists[1])
+	return err
+}
+
+// Check reports whether the member is granted the roles, or is not granted them when the
+// operation is marked doesNotExist.
+func (u *databaseUser) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := u.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(u.database + "/members/" + u.member)
+
+	policy, err := u.policy(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return !revokeMember(policy, u.grants, u.member), nil
+	}
+	if ctx.Tainted() || grantMember(policy, u.grants, u.member) {
+		return false, nil
+	}
+	return true, u.output(ctx)
+}
+
+// Set grants the missing roles to the member, or removes the member from the roles when the
+// operation is marked doesNotExist.
+func (u *databaseUser) Set(ctx blackstart.ModuleContext) error {
+	if err := u.setup(ctx); err != nil {
+		return err
+	}
+	ctx.Resource(u.database + "/members/" + u.member)
+
+	policy, err := u.policy(ctx)
+	if err != nil {
+		return err
+	}
+	var changed bool
+	if ctx.DoesNotExist() {
+		changed = revokeMember(policy, u.grants, u.member)
+	} else {
+		changed = grantMember(policy, u.grants, u.member)
+	}
+	if changed {
+		// Conditional bindings require version 3 of the policy. The etag of the policy makes the
+		// update fail instead of overwriting a concurrent change.
+		policy.Version = policyVersion
+		_, err = u.svc.Projects.Instances.Databases.SetIamPolicy(
+			u.database, &spanner.SetIamPolicyRequest{Policy: policy},
+		).Context(ctx).Do()
+		if err != nil {
+			return fmt.Errorf("failed to set IAM policy of database %s: %w", u.database, err)
+		}
+	}
+	if ctx.DoesNotExist() {
+		return nil
+	}
+	return u.output(ctx)
+}
+
+// setup resolves the database, member, and grants from the inputs and creates the Spanner
+// service.
+func (u *databaseUser) setup(ctx blackstart.ModuleContext) error {
+	raw, err := blackstart.ContextInputAs[string](ctx, inputDatabase, true)
+	if err != nil {
+		return err
+	}
+	if u.database, err = databaseName(raw); err != nil {
+		return err
+	}
+	if u.member, err = blackstart.ContextInputAs[string](ctx, inputMember, true); err != nil {
+		return err
+	}
+	if !validMember(u.member) {
+		return fmt.Errorf("invalid %s: %q must have a type, such as serviceAccount:", inputMember, u.member)
+	}
+	roles, err := blackstart.ContextInputAs[[]string](ctx, inputRoles, false)
+	if err != nil {
+		return err
+	}
+	databaseRoles, err := blackstart.ContextInputAs[[]string](ctx, inputDatabaseRoles, false)
+	if err != nil {
+		return err
+	}
+	if u.grants, err = inputGrants(roles, databaseRoles); err != nil {
+		return err
+	}
+
+	u.runtime = spannerRuntimeOrDefault(u.runtime)
+	u.svc, err = u.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Spanner service: %w", err)
+	}
+	return nil
+}
+
+// policy returns the IAM policy of the database, with its conditional bindings.
+func (u *databaseUser) policy(ctx context.Context) (*spanner.Policy, error) {
+	policy, err := u.svc.Projects.Instances.Databases.GetIamPolicy(
+		u.database, &spanner.GetIamPolicyRequest{
+			Options: &spanner.GetPolicyOptions{RequestedPolicyVersion: policyVersion},
+		},
+	).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IAM policy of database %s: %w", u.database, err)
+	}
+	return policy, nil
+}
+
+// output emits the outputs of the database user.
+func (u *databaseUser) output(ctx blackstart.ModuleContext) error {
+	if err := ctx.Output(outputDatabase, u.database); err != nil {
+		return err
+	}
+	return ctx.Output(outputMember, u.member)
+}
+
+// inputGrants returns the grants of the roles and database roles of the roles and database_roles
+// inputs.
+func inputGrants(roles, databaseRoles []string) ([]grant, error) {
+	if len(roles) == 0 && len(databaseRoles) == 0 {
+		return nil, fmt.Errorf("at least one of %s or %s must be set", inputRoles, inputDatabaseRoles)
+	}
+	grants := make([]grant, 0, len(roles)+len(databaseRoles)+1)
+	for _, role := range roles {
+		// Custom roles are in the form projects/<project>/roles/<role>.
+		if !strings.Contains(role, "roles/") {
+			return nil, fmt.Errorf("invalid %s: %q is not an IAM role, such as roles/<role>", inputRoles, role)
+		}
+		grants = append(grants, grant{role: role})
+	}
+	if len(databaseRoles) > 0 {
+		grants = append(grants, grant{role: roleFineGrainedAccessUser})
+	}
+	for _, role := range databaseRoles {
+		if role == "" || strings.ContainsAny(role, "/\" ") {
+			return nil, fmt.Errorf("invalid %s: %q is not the name of a database role", inputDatabaseRoles, role)
+		}
+		grants = append(grants, databaseRoleGrant(role))
+	}
+	return grants, nil
+}
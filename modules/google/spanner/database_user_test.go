@@ -0,0 +1,127 @@
+package spanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+const testMember = "serviceAccount:orders@app-project.iam.gserviceaccount.com"
+
+// testDatabaseUserOperation returns a database user operation for the orders service account.
+func testDatabaseUserOperation() *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "orders-user",
+		Module: "google_spanner_database_user",
+		Inputs: map[string]blackstart.Input{
+			inputDatabase:      blackstart.NewInputFromValue(testDatabase),
+			inputMember:        blackstart.NewInputFromValue(testMember),
+			inputRoles:         blackstart.NewInputFromValue([]any{"roles/spanner.databaseReader"}),
+			inputDatabaseRoles: blackstart.NewInputFromValue([]any{"order_reader"}),
+		},
+	}
+}
+
+func TestDatabaseUser_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "database id", key: inputDatabase, value: "orders", wantErr: "must be a resource name"},
+		{name: "member without type", key: inputMember, value: "app@example.com", wantErr: "must have a type"},
+		{name: "invalid role", key: inputRoles, value: []any{"spanner.databaseReader"}, wantErr: "is not an IAM role"},
+		{
+			name: "invalid database role", key: inputDatabaseRoles, value: []any{"databaseRoles/reader"},
+			wantErr: "is not the name of a database role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testDatabaseUserOperation()
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewDatabaseUser().Validate(*op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+
+	op := testDatabaseUserOperation()
+	delete(op.Inputs, inputRoles)
+	delete(op.Inputs, inputDatabaseRoles)
+	require.ErrorContains(t, NewDatabaseUser().Validate(*op), "at least one of roles or database_roles must be set")
+}
+
+func TestDatabaseUser_Grant(t *testing.T) {
+	fake := newFakeSpanner(t)
+	fake.policies[testDatabase] = &spanner.Policy{
+		Etag: "etag-1",
+		Bindings: []*spanner.Binding{
+			{Role: "roles/spanner.databaseReader", Members: []string{"group:ops@example.com"}},
+		},
+	}
+	op := testDatabaseUserOperation()
+	module := &databaseUser{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, ctx.outputs)
+
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, testDatabase, ctx.outputs[outputDatabase])
+	require.Equal(t, testMember, ctx.outputs[outputMember])
+	bindings := fake.policies[testDatabase].Bindings
+	require.Len(t, bindings, 3)
+	require.Equal(t, []string{"group:ops@example.com", testMember}, bindings[0].Members)
+	require.Equal(t, roleFineGrainedAccessUser, bindings[1].Role)
+	require.Equal(t, roleDatabaseRoleUser, bindings[2].Role)
+	require.Equal(t, "database role order_reader", bindings[2].Condition.Title)
+
+	// The policy has a new etag, so the fake only accepts a new update with etag-1.
+	fake.policies[testDatabase].Etag = "etag-1"
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDatabaseUser_DoesNotExist(t *testing.T) {
+	fake := newFakeSpanner(t)
+	op := testDatabaseUserOperation()
+	module := &databaseUser{runtime: fake.runtime()}
+	require.NoError(t, module.Set(testContext(op)))
+	fake.policies[testDatabase].Bindings[0].Members = append(
+		fake.policies[testDatabase].Bindings[0].Members, "group:ops@example.com",
+	)
+	fake.policies[testDatabase].Etag = "etag-1"
+
+	op.DoesNotExist = true
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	// Other members of the roles are kept, and bindings without members are removed.
+	bindings := fake.policies[testDatabase].Bindings
+	require.Len(t, bindings, 1)
+	require.Equal(t, []string{"group:ops@example.com"}, bindings[0].Members)
+
+	fake.policies[testDatabase].Etag = "etag-1"
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,204 @@
+package spanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ddlStatement is a DDL statement of a database schema, with the object it creates.
+type ddlStatement struct {
+	// text is the statement as it is applied, without a trailing semicolon.
+	text string
+	// kind is the kind of object the statement creates, such as `TABLE` or `INDEX`, or `COLUMN`
+	// for statements that add columns to tables. It is empty for GRANT statements.
+	kind string
+	// name is the name of the object, or of the table of a column.
+	name string
+	// column is the name of the column added to a table.
+	column string
+}
+
+// objectKinds are the kinds of schema objects of two words, such as `CHANGE STREAM`.
+var objectKinds = map[string]string{
+	"CHANGE":   "STREAM",
+	"SEARCH":   "INDEX",
+	"VECTOR":   "INDEX",
+	"PROPERTY": "GRAPH",
+	"LOCALITY": "GROUP",
+}
+
+// parseStatement parses a DDL statement. CREATE statements are identified by the object they
+// create, `ALTER TABLE ... ADD COLUMN` statements by the column they add, and GRANT statements by
+// their text, so statements can be compared to the schema of a database. Other statements cannot
+// be compared and are not supported.
+func parseStatement(raw string) (ddlStatement, error) {
+	text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(raw), ";"))
+	tokens := strings.Fields(text)
+	if len(tokens) == 0 {
+		return ddlStatement{}, fmt.Errorf("statements cannot be empty")
+	}
+	stmt := ddlStatement{text: text}
+	upper := make([]string, len(tokens))
+	for i, token := range tokens {
+		upper[i] = strings.ToUpper(token)
+	}
+
+	switch {
+	case upper[0] == "GRANT":
+		return stmt, nil
+	case upper[0] == "CREATE":
+		i := 1
+		for i < len(upper) && (upper[i] == "OR" || upper[i] == "REPLACE" || upper[i] == "UNIQUE" ||
+			upper[i] == "NULL_FILTERED") {
+			i++
+		}
+		if i < len(upper) {
+			stmt.kind = upper[i]
+			if second, ok := objectKinds[stmt.kind]; ok && i+1 < len(upper) && upper[i+1] == second {
+				stmt.kind += " " + second
+				i++
+			}
+			i = skipIfNotExists(upper, i+1)
+			if i < len(tokens) {
+				stmt.name = identifier(tokens[i])
+			}
+		}
+		if stmt.name == "" {
+			return ddlStatement{}, fmt.Errorf("statement %q does not name the object it creates", summary(tokens))
+		}
+		return stmt, nil
+	case len(upper) >= 5 && upper[0] == "ALTER" && upper[1] == "TABLE" && upper[3] == "ADD":
+		i := 4
+		if upper[i] == "COLUMN" {
+			i++
+		}
+		i = skipIfNotExists(upper, i)
+		if i < len(tokens) && !isConstraint(upper[i]) {
+			stmt.kind = "COLUMN"
+			stmt.name = identifier(tokens[2])
+			stmt.column = identifier(tokens[i])
+			return stmt, nil
+		}
+	}
+	return ddlStatement{}, fmt.Errorf(
+		"statement %q is not supported, only CREATE, GRANT, and ALTER TABLE ... ADD COLUMN statements are applied",
+		summary(tokens),
+	)
+}
+
+// summary returns the first tokens of a statement for error messages.
+func summary(tokens []string) string {
+	if len(tokens) > 4 {
+		return strings.Join(tokens[:4], " ") + " ..."
+	}
+	return strings.Join(tokens, " ")
+}
+
+// normalizeStatement returns the statement with single spaces and in lower case, to compare GRANT
+// statements to the statements the API reports.
+func normalizeStatement(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// skipIfNotExists returns the index of the token after an `IF NOT EXISTS` clause at index i, or i
+// when there is no clause.
+func skipIfNotExists(upper []string, i int) int {
+	if i+2 < len(upper) && upper[i] == "IF" && upper[i+1] == "NOT" && upper[i+2] == "EXISTS" {
+		return i + 3
+	}
+	return i
+}
+
+// isConstraint reports whether the token after ADD starts a constraint instead of a column.
+func isConstraint(token string) bool {
+	switch token {
+	case "CONSTRAINT", "FOREIGN", "CHECK", "PRIMARY", "UNIQUE", "ROW":
+		return true
+	}
+	return false
+}
+
+// identifier returns the name of an identifier token without quotes and the parameters that
+// follow it, such as `Singers(` in `CREATE TABLE Singers(`.
+func identifier(token string) string {
+	token, _, _ = strings.Cut(token, "(")
+	return strings.Trim(token, "`\"")
+}
+
+// schemaObjects indexes the objects, columns, and GRANT statements of the DDL of a database.
+type schemaObjects struct {
+	objects map[string]bool
+	columns map[string]bool
+	grants  map[string]bool
+}
+
+// objectKey returns the key of an object in the index. Names are compared without case.
+func objectKey(kind, name string) string {
+	return kind + " " + strings.ToLower(name)
+}
+
+// newSchemaObjects indexes the DDL statements of a database as the Spanner API reports them.
+// Statements that cannot be parsed are ignored.
+func newSchemaObjects(statements []string) *schemaObjects {
+	s := &schemaObjects{objects: map[string]bool{}, columns: map[string]bool{}, grants: map[string]bool{}}
+	for _, raw := range statements {
+		stmt, err := parseStatement(raw)
+		if err != nil {
+			continue
+		}
+		if stmt.kind == "" {
+			s.grants[normalizeStatement(stmt.text)] = true
+			continue
+		}
+		s.objects[objectKey(stmt.kind, stmt.name)] = true
+		if stmt.kind != "TABLE" {
+			continue
+		}
+		// The API reports each column of a table on its own line after the CREATE TABLE line.
+		lines := strings.Split(strings.TrimSpace(raw), "\n")
+		for _, line := range lines[1:] {
+			if fields := strings.Fields(line); len(fields) > 0 {
+				s.columns[objectKey(stmt.name, identifier(strings.TrimSuffix(fields[0], ",")))] = true
+			}
+		}
+	}
+	return s
+}
+
+// applied reports whether the schema has the object, column, or grant of the statement.
+func (s *schemaObjects) applied(stmt ddlStatement) bool {
+	switch stmt.kind {
+	case "":
+		return s.grants[normalizeStatement(stmt.text)]
+	case "COLUMN":
+		return s.columns[objectKey(stmt.name, stmt.column)]
+	default:
+		return s.objects[objectKey(stmt.kind, stmt.name)]
+	}
+}
+
+// parseDDL parses the statements of a ddl input.
+func parseDDL(statements []string) ([]ddlStatement, error) {
+	parsed := make([]ddlStatement, 0, len(statements))
+	for _, raw := range statements {
+		stmt, err := parseStatement(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", inputDDL, err)
+		}
+		parsed = append(parsed, stmt)
+	}
+	return parsed, nil
+}
+
+// pendingStatements returns the statements that are not applied to a database with the DDL, in
+// order.
+func pendingStatements(desired []ddlStatement, current []string) []string {
+	schema := newSchemaObjects(current)
+	var pending []string
+	for _, stmt := range desired {
+		if !schema.applied(stmt) {
+			pending = append(pending, stmt.text)
+		}
+	}
+	return pending
+}
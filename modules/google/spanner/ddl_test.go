@@ -0,0 +1,113 @@
+package spanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    ddlStatement
+		wantErr string
+	}{
+		{
+			name: "table",
+			raw:  "CREATE TABLE Orders (\n  OrderId STRING(36) NOT NULL,\n) PRIMARY KEY (OrderId);",
+			want: ddlStatement{kind: "TABLE", name: "Orders"},
+		},
+		{
+			name: "table without space and quoted",
+			raw:  "create table if not exists `Orders`(OrderId STRING(36)) PRIMARY KEY (OrderId)",
+			want: ddlStatement{kind: "TABLE", name: "Orders"},
+		},
+		{
+			name: "unique index",
+			raw:  "CREATE UNIQUE NULL_FILTERED INDEX OrdersByCustomer ON Orders(CustomerId)",
+			want: ddlStatement{kind: "INDEX", name: "OrdersByCustomer"},
+		},
+		{
+			name: "change stream",
+			raw:  "CREATE CHANGE STREAM OrderChanges FOR Orders",
+			want: ddlStatement{kind: "CHANGE STREAM", name: "OrderChanges"},
+		},
+		{
+			name: "view",
+			raw:  "CREATE OR REPLACE VIEW RecentOrders SQL SECURITY INVOKER AS SELECT OrderId FROM Orders",
+			want: ddlStatement{kind: "VIEW", name: "RecentOrders"},
+		},
+		{
+			name: "postgresql table",
+			raw:  `CREATE TABLE "orders" (order_id varchar(36) NOT NULL, PRIMARY KEY(order_id))`,
+			want: ddlStatement{kind: "TABLE", name: "orders"},
+		},
+		{
+			name: "add column",
+			raw:  "ALTER TABLE Orders ADD COLUMN IF NOT EXISTS Total NUMERIC",
+			want: ddlStatement{kind: "COLUMN", name: "Orders", column: "Total"},
+		},
+		{
+			name: "grant",
+			raw:  "GRANT SELECT ON TABLE Orders TO ROLE order_reader",
+			want: ddlStatement{},
+		},
+		{name: "empty", raw: " ; ", wantErr: "statements cannot be empty"},
+		{name: "create without name", raw: "CREATE TABLE", wantErr: `statement "CREATE TABLE" does not name`},
+		{
+			name: "drop", raw: "DROP TABLE Orders",
+			wantErr: `statement "DROP TABLE Orders" is not supported`,
+		},
+		{
+			name: "add constraint", raw: "ALTER TABLE Orders ADD CONSTRAINT FK_Customer FOREIGN KEY (CustomerId)",
+			wantErr: `statement "ALTER TABLE Orders ADD ..." is not supported`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				stmt, err := parseStatement(tt.raw)
+				if tt.wantErr != "" {
+					require.ErrorContains(t, err, tt.wantErr)
+					return
+				}
+				require.NoError(t, err)
+				require.Equal(t, tt.want.kind, stmt.kind)
+				require.Equal(t, tt.want.name, stmt.name)
+				require.Equal(t, tt.want.column, stmt.column)
+			},
+		)
+	}
+}
+
+func TestPendingStatements(t *testing.T) {
+	current := []string{
+		"CREATE TABLE Orders (\n  OrderId STRING(36) NOT NULL,\n  CustomerId STRING(36) NOT NULL,\n) PRIMARY KEY(OrderId)",
+		"CREATE INDEX OrdersByCustomer ON Orders(CustomerId)",
+		"CREATE ROLE order_reader",
+		"GRANT SELECT ON TABLE Orders TO ROLE order_reader",
+	}
+	desired, err := parseDDL(
+		[]string{
+			"CREATE TABLE orders (OrderId STRING(36) NOT NULL) PRIMARY KEY (OrderId)",
+			"ALTER TABLE Orders ADD COLUMN CustomerId STRING(36) NOT NULL",
+			"ALTER TABLE Orders ADD COLUMN Total NUMERIC",
+			"CREATE INDEX OrdersByCustomer ON Orders(CustomerId)",
+			"CREATE INDEX OrdersByTotal ON Orders(Total);",
+			"grant select on table Orders\n  to role order_reader",
+			"GRANT SELECT ON TABLE Customers TO ROLE order_reader",
+		},
+	)
+	require.NoError(t, err)
+
+	require.Equal(
+		t, []string{
+			"ALTER TABLE Orders ADD COLUMN Total NUMERIC",
+			"CREATE INDEX OrdersByTotal ON Orders(Total)",
+			"GRANT SELECT ON TABLE Customers TO ROLE order_reader",
+		},
+		pendingStatements(desired, current),
+	)
+}
@@ -0,0 +1,382 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"reflect"
+	"strings"
+
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("google_spanner_instance", NewInstance)
+}
+
+var _ blackstart.Module = &instance{}
+
+// instance manages a Spanner instance and its compute capacity.
+type instance struct {
+	runtime *spannerRuntime
+	svc     *spanner.Service
+	target  *instanceTarget
+}
+
+// instanceTarget is the desired state of an instance resolved from the module inputs.
+type instanceTarget struct {
+	project         string
+	id              string
+	config          string
+	displayName     string
+	nodes           int64
+	processingUnits int64
+	labels          map[string]string
+}
+
+// name returns the resource name of the instance.
+func (t *instanceTarget) name() string {
+	return fmt.Sprintf("projects/%s/instances/%s", t.project, t.id)
+}
+
+// configName returns the resource name of the instance configuration.
+func (t *instanceTarget) configName() string {
+	if strings.Contains(t.config, "/") {
+		return t.config
+	}
+	return fmt.Sprintf("projects/%s/instanceConfigs/%s", t.project, t.config)
+}
+
+func NewInstance() blackstart.Module {
+	return &instance{}
+}
+
+func (i *instance) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "google_spanner_instance",
+		Name: "Google Spanner Instance",
+		Description: util.CleanString(
+			`
+Ensures a Spanner instance exists with the display name, compute capacity, and labels.
+
+The compute capacity is set with either '''nodes''' or '''processing_units'''. When neither is set,
+instances are created with 100 processing units and the capacity of existing instances is not
+changed.
+
+**Notes**
+
+- The configuration of an existing instance cannot be changed. The operation fails when the
+  instance has a different configuration.
+- Labels that are not set in '''labels''' are not removed.
+- When '''doesNotExist''' is set, the instance and all of its databases are deleted.
+`,
+		),
+		Requirements: []string{
+			"The Spanner API (`spanner.googleapis.com`) must be enabled in the project.",
+			"The Google identity must have `roles/spanner.admin` in the project.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputProject: {
+				Description: "Google Cloud project of the instance. Defaults to the current project.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputInstance: {
+				Description: "ID of the instance, such as `app`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputConfig: {
+				Description: "Configuration of the instance, such as `regional-europe-west1` or `nam-eur-asia1`, or its resource name.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+				Immutable:   true,
+			},
+			inputDisplayName: {
+				Description: "Display name of the instance. Defaults to the instance ID.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputNodes: {
+				Description: "Number of nodes of the instance. Cannot be set with `processing_units`.",
+				Type:        reflect.TypeFor[int](),
+				Required:    false,
+			},
+			inputProcessingUnits: {
+				Description: "Processing units of the instance, in multiples of 100 up to 1000, and of 1000 above. Cannot be set with `nodes`.",
+				Type:        reflect.TypeFor[int](),
+				Required:    false,
+			},
+			inputLabels: {
+				Description: "Labels the instance must have, as a map of label keys to values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputInstance: {
+				Description: "Resource name of the instance, `projects/<project>/instances/<instance>`.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Regional Instance": `id: spanner-instance
+module: google_spanner_instance
+inputs:
+  project: app-project
+  instance: app
+  config: regional-europe-west1
+  processing_units: 300
+  labels:
+    team: payments`,
+		},
+	}
+}
+
+func (i *instance) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputInstance, inputConfig} {
+		if err := cloud.RequiredString(op, key); err != nil {
+			return err
+		}
+	}
+	nodes, nodesOk := op.Inputs[inputNodes]
+	units, unitsOk := op.Inputs[inputProcessingUnits]
+	if nodesOk && unitsOk {
+		return fmt.Errorf("%s and %s cannot both be set", inputNodes, inputProcessingUnits)
+	}
+	if nodesOk && nodes.IsStatic() {
+		if _, err := inputCapacity(nodes, inputNodes); err != nil {
+			return err
+		}
+	}
+	if unitsOk && units.IsStatic() {
+		if _, err := inputCapacity(units, inputProcessingUnits); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputLabels]; ok && input.IsStatic() {
+		if _, err := inputLabelValues(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check reports whether the instance is in the requested state.
+func (i *instance) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if err := i.setup(ctx); err != nil {
+		return false, err
+	}
+	ctx.Resource(i.target.name())
+
+	existing, err := i.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return existing == nil, nil
+	}
+	if existing == nil || ctx.Tainted() {
+		return false, nil
+	}
+	if err = i.verifyConfig(existing); err != nil {
+		return false, err
+	}
+	if _, mask := i.update(existing); len(mask) > 0 {
+		return false, nil
+	}
+	return true, ctx.Output(outputInstance, i.target.name())
+}
+
+// Set reconciles the instance to the requested state.
+func (i *instance) Set(ctx blackstart.ModuleContext) error {
+	if err := i.setup(ctx); err != nil {
+		return err
+	}
+	name := i.target.name()
+	ctx.Resource(name)
+
+	existing, err := i.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if existing == nil {
+			return nil
+		}
+		_, err = i.svc.Projects.Instances.Delete(name).Context(ctx).Do()
+		if err != nil && !cloud.IsNotFound(err) {
+			return fmt.Errorf("failed to delete instance %s: %w", name, err)
+		}
+		return nil
+	}
+
+	if existing == nil {
+		created := &spanner.Instance{
+			Config:          i.target.configName(),
+			DisplayName:     i.target.displayName,
+			NodeCount:       i.target.nodes,
+			ProcessingUnits: i.target.processingUnits,
+			Labels:          i.target.labels,
+		}
+		if created.NodeCount == 0 && created.ProcessingUnits == 0 {
+			created.ProcessingUnits = defaultProcessingUnits
+		}
+		op, cErr := i.svc.Projects.Instances.Create(
+			"projects/"+i.target.project, &spanner.CreateInstanceRequest{InstanceId: i.target.id, Instance: created},
+		).Context(ctx).Do()
+		if cErr != nil {
+			return fmt.Errorf("failed to create instance %s: %w", name, cErr)
+		}
+		if err = waitForOperation(ctx, i.svc, op); err != nil {
+			return fmt.Errorf("failed to create instance %s: %w", name, err)
+		}
+		return ctx.Output(outputInstance, name)
+	}
+	if err = i.verifyConfig(existing); err != nil {
+		return err
+	}
+
+	if patch, mask := i.update(existing); len(mask) > 0 {
+		op, pErr := i.svc.Projects.Instances.Patch(
+			name, &spanner.UpdateInstanceRequest{Instance: patch, FieldMask: strings.Join(mask, ",")},
+		).Context(ctx).Do()
+		if pErr != nil {
+			return fmt.Errorf("failed to update instance %s: %w", name, pErr)
+		}
+		if err = waitForOperation(ctx, i.svc, op); err != nil {
+			return fmt.Errorf("failed to update instance %s: %w", name, err)
+		}
+	}
+	return ctx.Output(outputInstance, name)
+}
+
+// setup resolves the target instance from the inputs and creates the Spanner service.
+func (i *instance) setup(ctx blackstart.ModuleContext) error {
+	project, err := blackstart.ContextInputAs[string](ctx, inputProject, false)
+	if err != nil {
+		return err
+	}
+	if project == "" {
+		project, _, err = cloud.CurrentProject(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	target := &instanceTarget{project: project}
+	if target.id, err = blackstart.ContextInputAs[string](ctx, inputInstance, true); err != nil {
+		return err
+	}
+	if target.config, err = blackstart.ContextInputAs[string](ctx, inputConfig, true); err != nil {
+		return err
+	}
+	if target.displayName, err = blackstart.ContextInputAs[string](ctx, inputDisplayName, false); err != nil {
+		return err
+	}
+	if target.displayName == "" {
+		target.displayName = target.id
+	}
+	if input, iErr := ctx.Input(inputNodes); iErr == nil && input.Any() != nil {
+		if target.nodes, err = inputCapacity(input, inputNodes); err != nil {
+			return err
+		}
+	}
+	if input, iErr := ctx.Input(inputProcessingUnits); iErr == nil && input.Any() != nil {
+		if target.processingUnits, err = inputCapacity(input, inputProcessingUnits); err != nil {
+			return err
+		}
+	}
+	if target.nodes > 0 && target.processingUnits > 0 {
+		return fmt.Errorf("%s and %s cannot both be set", inputNodes, inputProcessingUnits)
+	}
+	if input, iErr := ctx.Input(inputLabels); iErr == nil && input.Any() != nil {
+		if target.labels, err = inputLabelValues(input); err != nil {
+			return err
+		}
+	}
+	i.target = target
+
+	i.runtime = spannerRuntimeOrDefault(i.runtime)
+	i.svc, err = i.runtime.newService(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to create Spanner service: %w", err)
+	}
+	return nil
+}
+
+// get returns the instance, or nil if it does not exist.
+func (i *instance) get(ctx context.Context) (*spanner.Instance, error) {
+	existing, err := i.svc.Projects.Instances.Get(i.target.name()).Context(ctx).Do()
+	if err != nil {
+		if cloud.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get instance %s: %w", i.target.name(), err)
+	}
+	return existing, nil
+}
+
+// verifyConfig returns an error when the instance has a different configuration.
+func (i *instance) verifyConfig(existing *spanner.Instance) error {
+	if path.Base(existing.Config) != path.Base(i.target.config) {
+		return fmt.Errorf(
+			"instance %s has configuration %s instead of %s", i.target.name(), path.Base(existing.Config),
+			path.Base(i.target.config),
+		)
+	}
+	return nil
+}
+
+// update returns the patch and field mask that bring the instance to the desired state. The mask
+// is empty when the instance is already in the desired state.
+func (i *instance) update(existing *spanner.Instance) (*spanner.Instance, []string) {
+	patch := &spanner.Instance{}
+	var mask []string
+	if existing.DisplayName != i.target.displayName {
+		patch.DisplayName = i.target.displayName
+		mask = append(mask, "displayName")
+	}
+	if i.target.nodes > 0 && existing.NodeCount != i.target.nodes {
+		patch.NodeCount = i.target.nodes
+		mask = append(mask, "nodeCount")
+	}
+	if i.target.processingUnits > 0 && existing.ProcessingUnits != i.target.processingUnits {
+		patch.ProcessingUnits = i.target.processingUnits
+		mask = append(mask, "processingUnits")
+	}
+	for key, value := range i.target.labels {
+		if current, ok := existing.Labels[key]; !ok || current != value {
+			patch.Labels = maps.Clone(existing.Labels)
+			if patch.Labels == nil {
+				patch.Labels = make(map[string]string, len(i.target.labels))
+			}
+			maps.Copy(patch.Labels, i.target.labels)
+			mask = append(mask, "labels")
+			break
+		}
+	}
+	return patch, mask
+}
+
+// inputCapacity returns the compute capacity of a nodes or processing_units input.
+func inputCapacity(input blackstart.Input, key string) (int64, error) {
+	value, err := blackstart.InputAs[int](input, false)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("invalid %s: %d must be positive", key, value)
+	}
+	if key == inputProcessingUnits && (value < 1000 && value%100 != 0 || value > 1000 && value%1000 != 0) {
+		return 0, fmt.Errorf(
+			"invalid %s: %d must be a multiple of 100 up to 1000, and a multiple of 1000 above", key, value,
+		)
+	}
+	return int64(value), nil
+}
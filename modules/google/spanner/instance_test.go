@@ -0,0 +1,167 @@
+package spanner
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+// testInstanceOperation returns an instance operation for the app instance.
+func testInstanceOperation() *blackstart.Operation {
+	return &blackstart.Operation{
+		Id:     "spanner-instance",
+		Module: "google_spanner_instance",
+		Inputs: map[string]blackstart.Input{
+			inputProject:         blackstart.NewInputFromValue("app-project"),
+			inputInstance:        blackstart.NewInputFromValue("app"),
+			inputConfig:          blackstart.NewInputFromValue("regional-europe-west1"),
+			inputProcessingUnits: blackstart.NewInputFromValue(300),
+			inputLabels:          blackstart.NewInputFromValue(map[string]any{"team": "payments"}),
+		},
+	}
+}
+
+func TestInstance_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   any
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "empty instance", key: inputInstance, value: "", wantErr: "invalid instance"},
+		{name: "nodes and processing units", key: inputNodes, value: 1, wantErr: "cannot both be set"},
+		{
+			name: "invalid processing units", key: inputProcessingUnits, value: 1500,
+			wantErr: "invalid processing_units: 1500 must be a multiple of 100 up to 1000",
+		},
+		{name: "negative processing units", key: inputProcessingUnits, value: -100, wantErr: "must be positive"},
+		{name: "invalid labels", key: inputLabels, value: map[string]any{"team": []any{"a"}}, wantErr: "scalar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := testInstanceOperation()
+				if tt.key != "" {
+					op.Inputs[tt.key] = blackstart.NewInputFromValue(tt.value)
+				}
+				err := NewInstance().Validate(*op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+
+	op := testInstanceOperation()
+	delete(op.Inputs, inputConfig)
+	require.ErrorContains(t, NewInstance().Validate(*op), "missing required parameter: config")
+}
+
+func TestInstance_Create(t *testing.T) {
+	fake := newFakeSpanner(t)
+	fake.PendingPolls = 2
+	op := testInstanceOperation()
+	module := &instance{runtime: fake.runtime()}
+
+	ctx := testContext(op)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Empty(t, ctx.outputs)
+
+	require.NoError(t, module.Set(ctx))
+	require.Equal(t, testInstance, ctx.outputs[outputInstance])
+	created := fake.instances[testInstance]
+	require.NotNil(t, created)
+	require.Equal(t, "projects/app-project/instanceConfigs/regional-europe-west1", created.Config)
+	require.Equal(t, "app", created.DisplayName)
+	require.Equal(t, int64(300), created.ProcessingUnits)
+	require.Equal(t, map[string]string{"team": "payments"}, created.Labels)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestInstance_Update(t *testing.T) {
+	fake := newFakeSpanner(t)
+	fake.instances[testInstance] = &spanner.Instance{
+		Name:            testInstance,
+		Config:          "projects/app-project/instanceConfigs/regional-europe-west1",
+		DisplayName:     "app",
+		NodeCount:       1,
+		ProcessingUnits: 1000,
+		Labels:          map[string]string{"env": "prod"},
+	}
+	op := testInstanceOperation()
+	op.Inputs[inputDisplayName] = blackstart.NewInputFromValue("App")
+	delete(op.Inputs, inputProcessingUnits)
+	op.Inputs[inputNodes] = blackstart.NewInputFromValue(2)
+	module := &instance{runtime: fake.runtime()}
+
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Equal(t, []string{"displayName,nodeCount,labels"}, fake.masks)
+	updated := fake.instances[testInstance]
+	require.Equal(t, "App", updated.DisplayName)
+	require.Equal(t, int64(2), updated.NodeCount)
+	// Labels that are not set are kept.
+	require.Equal(t, map[string]string{"env": "prod", "team": "payments"}, updated.Labels)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// The capacity of an instance is not changed when it is not set.
+	delete(op.Inputs, inputNodes)
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestInstance_ConfigMismatch(t *testing.T) {
+	fake := newFakeSpanner(t)
+	fake.instances[testInstance] = &spanner.Instance{
+		Name: testInstance, Config: "projects/app-project/instanceConfigs/regional-us-central1", DisplayName: "app",
+	}
+	module := &instance{runtime: fake.runtime()}
+
+	_, err := module.Check(testContext(testInstanceOperation()))
+	require.EqualError(
+		t, err,
+		"instance projects/app-project/instances/app has configuration regional-us-central1 instead of regional-europe-west1",
+	)
+	require.Error(t, module.Set(testContext(testInstanceOperation())))
+	require.Zero(t, fake.requestCount(http.MethodPatch))
+}
+
+func TestInstance_DoesNotExist(t *testing.T) {
+	fake := newFakeSpanner(t)
+	op := testInstanceOperation()
+	module := &instance{runtime: fake.runtime()}
+	require.NoError(t, module.Set(testContext(op)))
+
+	op.DoesNotExist = true
+	ok, err := module.Check(testContext(op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, module.Set(testContext(op)))
+	require.Empty(t, fake.instances)
+
+	ok, err = module.Check(testContext(op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, fake.requestCount(http.MethodDelete))
+}
@@ -0,0 +1,221 @@
+package spanner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud"
+)
+
+const (
+	inputProject         = "project"
+	inputInstance        = "instance"
+	inputConfig          = "config"
+	inputDisplayName     = "display_name"
+	inputNodes           = "nodes"
+	inputProcessingUnits = "processing_units"
+	inputLabels          = "labels"
+	inputDatabase        = "database"
+	inputDialect         = "dialect"
+	inputDDL             = "ddl"
+	inputMember          = "member"
+	inputRoles           = "roles"
+	inputDatabaseRoles   = "database_roles"
+
+	outputInstance = "instance"
+	outputDatabase = "database"
+	outputDialect  = "dialect"
+	outputMember   = "member"
+
+	dialectGoogleSQL  = "GOOGLE_STANDARD_SQL"
+	dialectPostgreSQL = "POSTGRESQL"
+
+	// defaultProcessingUnits is the compute capacity of instances created without a nodes or
+	// processing_units input.
+	defaultProcessingUnits = 100
+
+	// roleFineGrainedAccessUser allows a member to use the database roles it is granted.
+	roleFineGrainedAccessUser = "roles/spanner.fineGrainedAccessUser"
+	// roleDatabaseRoleUser grants database roles with a condition on the name of the role.
+	roleDatabaseRoleUser = "roles/spanner.databaseRoleUser"
+
+	// policyVersion is the IAM policy version that supports conditional bindings.
+	policyVersion = 3
+)
+
+// Spanner mutations return long-running operations. They are polled with exponential backoff
+// between these intervals until they are done.
+var (
+	operationPollInitialInterval = 2 * time.Second
+	operationPollMaxInterval     = 15 * time.Second
+)
+
+func init() {
+	blackstart.RegisterPathName("spanner", "Spanner")
+}
+
+// spannerRuntime provides the injectable Spanner API dependency.
+type spannerRuntime struct {
+	newService func(context.Context) (*spanner.Service, error)
+}
+
+// defaultSpannerRuntime creates the production Spanner runtime.
+func defaultSpannerRuntime() *spannerRuntime {
+	return &spannerRuntime{
+		newService: func(ctx context.Context) (*spanner.Service, error) {
+			return cloud.NewService(ctx, spanner.NewService, nil, spanner.SpannerAdminScope)
+		},
+	}
+}
+
+// spannerRuntimeOrDefault returns runtime when configured, or the production runtime otherwise.
+func spannerRuntimeOrDefault(runtime *spannerRuntime) *spannerRuntime {
+	if runtime == nil {
+		return defaultSpannerRuntime()
+	}
+	return runtime
+}
+
+// waitForOperation polls a Spanner operation until it is done and returns any error reported by
+// the operation.
+func waitForOperation(ctx context.Context, svc *spanner.Service, op *spanner.Operation) error {
+	polling := cloud.OperationPolling{InitialInterval: operationPollInitialInterval, MaxInterval: operationPollMaxInterval}
+	return cloud.WaitForOperation(
+		ctx, op, polling, func(ctx context.Context, name string) (*spanner.Operation, error) {
+			// Operations of instances and databases are polled by their name, which includes the
+			// resource of the operation.
+			return svc.Projects.Instances.Operations.Get(name).Context(ctx).Do()
+		},
+	)
+}
+
+// resourceName validates the resource name of an instance or database, such as
+// `projects/<project>/instances/<instance>`, and returns it without a trailing slash. The kinds
+// are the collections of the name, such as `projects` and `instances`.
+func resourceName(key, name string, kinds ...string) (string, error) {
+	name = strings.TrimSuffix(strings.TrimSpace(name), "/")
+	parts := strings.Split(name, "/")
+	valid := len(parts) == 2*len(kinds)
+	for i := 0; valid && i < len(kinds); i++ {
+		valid = parts[2*i] == kinds[i] && parts[2*i+1] != ""
+	}
+	if !valid {
+		format := make([]string, 0, len(kinds))
+		for _, kind := range kinds {
+			format = append(format, fmt.Sprintf("%s/<%s>", kind, strings.TrimSuffix(kind, "s")))
+		}
+		return "", fmt.Errorf("invalid %s: %q must be a resource name, %s", key, name, strings.Join(format, "/"))
+	}
+	return name, nil
+}
+
+// instanceName returns the resource name of an instance input.
+func instanceName(name string) (string, error) {
+	return resourceName(inputInstance, name, "projects", "instances")
+}
+
+// databaseName returns the resource name of a database input.
+func databaseName(name string) (string, error) {
+	return resourceName(inputDatabase, name, "projects", "instances", "databases")
+}
+
+// inputLabelValues returns the labels of a labels input. Label values must be scalar values.
+func inputLabelValues(input blackstart.Input) (map[string]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", inputLabels, err)
+	}
+	labels := make(map[string]string, len(raw))
+	for key, value := range raw {
+		switch v := value.(type) {
+		case string, bool, int, int64, float64:
+			labels[key] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("invalid %s: value of label %s must be a scalar value", inputLabels, key)
+		}
+	}
+	return labels, nil
+}
+
+// validMember reports whether an IAM member has a type prefix, such as `serviceAccount:`.
+func validMember(member string) bool {
+	kind, value, found := strings.Cut(member, ":")
+	return found && kind != "" && value != ""
+}
+
+// grant is a role granted to a member in the IAM policy of a database. Database roles are granted
+// with a condition on the name of the role.
+type grant struct {
+	role      string
+	condition *spanner.Expr
+}
+
+// matches reports whether the binding grants the role with the same condition.
+func (g grant) matches(b *spanner.Binding) bool {
+	if b.Role != g.role || (b.Condition == nil) != (g.condition == nil) {
+		return false
+	}
+	return g.condition == nil || b.Condition.Expression == g.condition.Expression
+}
+
+// databaseRoleGrant returns the grant of a database role, which is conditional on the name of the
+// role.
+func databaseRoleGrant(role string) grant {
+	return grant{
+		role: roleDatabaseRoleUser,
+		condition: &spanner.Expr{
+			Title: "database role " + role,
+			Expression: fmt.Sprintf(
+				`resource.type == "spanner.googleapis.com/DatabaseRole" && resource.name.endsWith("/databaseRoles/%s")`,
+				role,
+			),
+		},
+	}
+}
+
+// hasMember reports whether the binding includes the member. Members are compared without case.
+func hasMember(b *spanner.Binding, member string) bool {
+	return slices.ContainsFunc(b.Members, func(m string) bool { return strings.EqualFold(m, member) })
+}
+
+// grantMember adds the member to the bindings of the grants of the policy that do not include it.
+// It reports whether the policy was changed.
+func grantMember(policy *spanner.Policy, grants []grant, member string) bool {
+	changed := false
+	for _, g := range grants {
+		idx := slices.IndexFunc(policy.Bindings, g.matches)
+		if idx >= 0 && hasMember(policy.Bindings[idx], member) {
+			continue
+		}
+		if idx < 0 {
+			policy.Bindings = append(policy.Bindings, &spanner.Binding{Role: g.role, Condition: g.condition})
+			idx = len(policy.Bindings) - 1
+		}
+		policy.Bindings[idx].Members = append(policy.Bindings[idx].Members, member)
+		changed = true
+	}
+	return changed
+}
+
+// revokeMember removes the member from the bindings of the grants of the policy. Bindings without
+// members are removed. It reports whether the policy was changed.
+func revokeMember(policy *spanner.Policy, grants []grant, member string) bool {
+	changed := false
+	for _, g := range grants {
+		for _, b := range policy.Bindings {
+			if !g.matches(b) || !hasMember(b, member) {
+				continue
+			}
+			b.Members = slices.DeleteFunc(b.Members, func(m string) bool { return strings.EqualFold(m, member) })
+			changed = true
+		}
+	}
+	policy.Bindings = slices.DeleteFunc(policy.Bindings, func(b *spanner.Binding) bool { return len(b.Members) == 0 })
+	return changed
+}
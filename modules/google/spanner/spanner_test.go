@@ -0,0 +1,277 @@
+package spanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/api/spanner/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/google/cloud/cloudtest"
+)
+
+const (
+	testInstance = "projects/app-project/instances/app"
+	testDatabase = testInstance + "/databases/orders"
+)
+
+// fakeSpanner implements the Spanner REST operations used by the modules.
+type fakeSpanner struct {
+	t         *testing.T
+	server    *httptest.Server
+	instances map[string]*spanner.Instance
+	databases map[string]*spanner.Database
+	ddl       map[string][]string
+	policies  map[string]*spanner.Policy
+	// updates are the statements of each schema update.
+	updates [][]string
+	// masks are the field masks of each instance update.
+	masks []string
+	// Operations reports operations as running until PendingPolls polls were made.
+	cloudtest.Operations
+	operations int
+	requests   []string
+	mu         sync.Mutex
+}
+
+// newFakeSpanner starts a stateful fake Spanner API server.
+func newFakeSpanner(t *testing.T) *fakeSpanner {
+	t.Helper()
+	operationPollInitialInterval = time.Millisecond
+	f := &fakeSpanner{
+		t:         t,
+		instances: map[string]*spanner.Instance{},
+		databases: map[string]*spanner.Database{},
+		ddl:       map[string][]string{},
+		policies:  map[string]*spanner.Policy{},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// runtime returns a Spanner runtime connected to the fake API.
+func (f *fakeSpanner) runtime() *spannerRuntime {
+	return &spannerRuntime{
+		newService: func(ctx context.Context) (*spanner.Service, error) {
+			return spanner.NewService(ctx, option.WithEndpoint(f.server.URL+"/"), option.WithoutAuthentication())
+		},
+	}
+}
+
+// requestCount returns the number of requests with the method.
+func (f *fakeSpanner) requestCount(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, r := range f.requests {
+		if strings.HasPrefix(r, method+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+// operation returns a long-running operation of the resource.
+func (f *fakeSpanner) operation(resource string) cloudtest.Operation {
+	f.operations++
+	return f.Start(fmt.Sprintf("%s/operations/%d", resource, f.operations))
+}
+
+// serveHTTP handles the Spanner API operations used by the unit tests.
+func (f *fakeSpanner) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	f.requests = append(f.requests, r.Method+" "+path)
+	resource, method, _ := strings.Cut(path, ":")
+	switch {
+	case r.Method == http.MethodGet && strings.Contains(path, "/operations/"):
+		cloudtest.WriteJSON(f.t, w, f.Poll(path))
+	case r.Method == http.MethodPost && method == "getIamPolicy":
+		var req spanner.GetIamPolicyRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(f.t, int64(policyVersion), req.Options.RequestedPolicyVersion)
+		policy, ok := f.policies[resource]
+		if !ok {
+			policy = &spanner.Policy{Etag: "etag-1"}
+		}
+		cloudtest.WriteJSON(f.t, w, policy)
+	case r.Method == http.MethodPost && method == "setIamPolicy":
+		var req spanner.SetIamPolicyRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(f.t, "etag-1", req.Policy.Etag)
+		require.Equal(f.t, int64(policyVersion), req.Policy.Version)
+		req.Policy.Etag = "etag-2"
+		f.policies[resource] = req.Policy
+		cloudtest.WriteJSON(f.t, w, req.Policy)
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/instances"):
+		var req spanner.CreateInstanceRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		req.Instance.Name = path + "/" + req.InstanceId
+		f.instances[req.Instance.Name] = req.Instance
+		cloudtest.WriteJSON(f.t, w, f.operation(req.Instance.Name))
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/databases"):
+		var req spanner.CreateDatabaseRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		id := strings.Trim(strings.TrimPrefix(req.CreateStatement, "CREATE DATABASE "), "`\"")
+		name := path + "/" + id
+		f.databases[name] = &spanner.Database{Name: name, DatabaseDialect: req.DatabaseDialect, State: "READY"}
+		cloudtest.WriteJSON(f.t, w, f.operation(name))
+	case r.Method == http.MethodGet && strings.HasSuffix(path, "/ddl"):
+		database := strings.TrimSuffix(path, "/ddl")
+		cloudtest.WriteJSON(f.t, w, &spanner.GetDatabaseDdlResponse{Statements: f.ddl[database]})
+	case r.Method == http.MethodPatch && strings.HasSuffix(path, "/ddl"):
+		var req spanner.UpdateDatabaseDdlRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		database := strings.TrimSuffix(path, "/ddl")
+		f.updates = append(f.updates, req.Statements)
+		for _, stmt := range req.Statements {
+			f.applyStatement(database, stmt)
+		}
+		cloudtest.WriteJSON(f.t, w, f.operation(database))
+	case r.Method == http.MethodPatch:
+		var req spanner.UpdateInstanceRequest
+		require.NoError(f.t, json.NewDecoder(r.Body).Decode(&req))
+		existing, ok := f.instances[path]
+		if !ok {
+			http.Error(w, "instance not found", http.StatusNotFound)
+			return
+		}
+		f.masks = append(f.masks, req.FieldMask)
+		for _, field := range strings.Split(req.FieldMask, ",") {
+			switch field {
+			case "displayName":
+				existing.DisplayName = req.Instance.DisplayName
+			case "nodeCount":
+				existing.NodeCount = req.Instance.NodeCount
+				existing.ProcessingUnits = 1000 * req.Instance.NodeCount
+			case "processingUnits":
+				existing.ProcessingUnits = req.Instance.ProcessingUnits
+				existing.NodeCount = req.Instance.ProcessingUnits / 1000
+			case "labels":
+				existing.Labels = req.Instance.Labels
+			}
+		}
+		cloudtest.WriteJSON(f.t, w, f.operation(path))
+	case r.Method == http.MethodDelete:
+		delete(f.instances, path)
+		delete(f.databases, path)
+		cloudtest.WriteJSON(f.t, w, &spanner.Empty{})
+	case r.Method == http.MethodGet:
+		if existing, ok := f.instances[path]; ok {
+			cloudtest.WriteJSON(f.t, w, existing)
+			return
+		}
+		if existing, ok := f.databases[path]; ok {
+			cloudtest.WriteJSON(f.t, w, existing)
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		f.t.Errorf("unexpected Spanner API request: %s %s", r.Method, path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+	}
+}
+
+// applyStatement applies a DDL statement to the schema of a database. Columns added to a table
+// are added to its CREATE TABLE statement, as the API reports them.
+func (f *fakeSpanner) applyStatement(database, stmt string) {
+	tokens := strings.Fields(stmt)
+	if len(tokens) > 5 && strings.EqualFold(tokens[0], "ALTER") && strings.EqualFold(tokens[4], "COLUMN") {
+		for i, existing := range f.ddl[database] {
+			if strings.HasPrefix(existing, "CREATE TABLE "+tokens[2]+" (") {
+				first, rest, _ := strings.Cut(existing, "\n")
+				column := "  " + strings.Join(tokens[5:], " ") + ","
+				f.ddl[database][i] = first + "\n" + column + "\n" + rest
+				return
+			}
+		}
+		f.t.Errorf("table %s of statement %q does not exist", tokens[2], stmt)
+		return
+	}
+	f.ddl[database] = append(f.ddl[database], stmt)
+}
+
+// outputContext records the outputs of a module.
+type outputContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+func (c *outputContext) Output(key string, value any) error {
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// testContext creates a module context for the operation that records outputs.
+func testContext(op *blackstart.Operation) *outputContext {
+	return &outputContext{
+		ModuleContext: blackstart.OpContext(context.Background(), op),
+		outputs:       map[string]any{},
+	}
+}
+
+func TestWaitForOperation(t *testing.T) {
+	fake := newFakeSpanner(t)
+	fake.PendingPolls = 3
+	svc, err := fake.runtime().newService(context.Background())
+	require.NoError(t, err)
+
+	op := &spanner.Operation{Name: testInstance + "/operations/1"}
+	require.NoError(t, waitForOperation(context.Background(), svc, op))
+	require.Equal(t, 3, fake.requestCount(http.MethodGet))
+
+	failed := &spanner.Operation{Name: "operations/2", Done: true, Error: &spanner.Status{Code: 9, Message: "busy"}}
+	require.EqualError(t, waitForOperation(context.Background(), svc, failed), "operation operations/2 failed: 9: busy")
+}
+
+func TestResourceName(t *testing.T) {
+	name, err := instanceName(testInstance + "/")
+	require.NoError(t, err)
+	require.Equal(t, testInstance, name)
+
+	_, err = instanceName("app")
+	require.EqualError(t, err, `invalid instance: "app" must be a resource name, projects/<project>/instances/<instance>`)
+
+	_, err = databaseName(testInstance + "/tables/orders")
+	require.ErrorContains(t, err, "projects/<project>/instances/<instance>/databases/<database>")
+}
+
+func TestGrantMember(t *testing.T) {
+	member := "serviceAccount:app@app-project.iam.gserviceaccount.com"
+	grants := []grant{{role: "roles/spanner.databaseReader"}, databaseRoleGrant("order_reader")}
+	policy := &spanner.Policy{
+		Bindings: []*spanner.Binding{
+			{Role: "roles/spanner.databaseReader", Members: []string{"group:ops@example.com"}},
+			// A binding of the role with a different condition is not changed.
+			{
+				Role: roleDatabaseRoleUser, Members: []string{"group:ops@example.com"},
+				Condition: databaseRoleGrant("order_writer").condition,
+			},
+		},
+	}
+
+	require.True(t, grantMember(policy, grants, member))
+	require.Len(t, policy.Bindings, 3)
+	require.Equal(t, []string{"group:ops@example.com", member}, policy.Bindings[0].Members)
+	require.Equal(t, []string{"group:ops@example.com"}, policy.Bindings[1].Members)
+	require.Equal(t, []string{member}, policy.Bindings[2].Members)
+	require.Contains(t, policy.Bindings[2].Condition.Expression, `endsWith("/databaseRoles/order_reader")`)
+	require.False(t, grantMember(policy, grants, strings.ToUpper(member)))
+
+	require.True(t, revokeMember(policy, grants, member))
+	require.Len(t, policy.Bindings, 2)
+	require.Equal(t, []string{"group:ops@example.com"}, policy.Bindings[0].Members)
+	require.False(t, revokeMember(policy, grants, member))
+}
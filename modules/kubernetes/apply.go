@@ -0,0 +1,135 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+// fieldManager is the field manager of the fields Blackstart sets with server-side apply.
+const fieldManager = "blackstart"
+
+const (
+	conflictPolicyForce = "force"
+	conflictPolicyFail  = "fail"
+)
+
+var conflictPolicies = map[string]string{
+	conflictPolicyForce: conflictPolicyForce,
+	conflictPolicyFail:  conflictPolicyFail,
+}
+
+var conflictPolicyDocs = util.CleanString(
+	`
+**Conflict Policies**
+
+Changes are made with server-side apply using the '''blackstart''' field manager, so only the fields 
+set by Blackstart are owned by it, and other controllers may manage other fields of the same 
+resource. The conflict policy controls how fields that are owned by another field manager are 
+handled:
+
+- '''force''' - Ownership of the conflicting fields is taken over from the other field managers. This is the default conflict policy.
+- '''fail''' - The operation fails when a field is owned by another field manager.
+
+`,
+)
+
+// validateConflictPolicy validates the static conflict policy input of an operation.
+func validateConflictPolicy(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputConflictPolicy]
+	if !ok || !input.IsStatic() {
+		return nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return fmt.Errorf("input '%s' is invalid: %w", inputConflictPolicy, err)
+	}
+	return checkConflictPolicy(value)
+}
+
+// checkConflictPolicy returns an error when the conflict policy is not supported. An empty policy
+// is the default policy.
+func checkConflictPolicy(policy string) error {
+	policy = strings.TrimSpace(policy)
+	if _, ok := conflictPolicies[policy]; policy != "" && !ok {
+		return fmt.Errorf("input '%s' has invalid value '%s'", inputConflictPolicy, policy)
+	}
+	return nil
+}
+
+// contextApplyOptions returns the server-side apply options for the conflict policy input of a
+// module.
+func contextApplyOptions(ctx blackstart.ModuleContext) (metav1.ApplyOptions, error) {
+	policy, err := blackstart.ContextInputAs[string](ctx, inputConflictPolicy, false)
+	if err != nil {
+		return metav1.ApplyOptions{}, err
+	}
+	if err = checkConflictPolicy(policy); err != nil {
+		return metav1.ApplyOptions{}, err
+	}
+	return metav1.ApplyOptions{
+		FieldManager: fieldManager,
+		Force:        strings.TrimSpace(policy) != conflictPolicyFail,
+	}, nil
+}
+
+// applyError adds a hint to apply errors caused by fields owned by other field managers.
+func applyError(err error) error {
+	if apierrors.IsConflict(err) {
+		return fmt.Errorf(
+			"%w; set input '%s' to '%s' to take ownership of the fields", err, inputConflictPolicy, conflictPolicyForce,
+		)
+	}
+	return err
+}
+
+// staleResourceVersion reports whether a write failed because the resource was changed since it was
+// read. Conflicts with fields owned by other field managers are not caused by a stale read, so
+// retrying them does not help.
+func staleResourceVersion(err error) bool {
+	if !apierrors.IsConflict(err) {
+		return false
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Type == metav1.CauseTypeFieldManagerConflict {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// removeKeyPatch returns a JSON patch that removes a key from a field of a ConfigMap or Secret,
+// such as `data` or `binaryData`.
+func removeKeyPatch(field, key string) []byte {
+	path := "/" + field + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+	patch, _ := json.Marshal([]map[string]string{{"op": "remove", "path": path}})
+	return patch
+}
+
+// deleteRetryingConflicts deletes a resource with the delete function of its client. The delete is
+// retried on conflicts with other writers, and a resource that is already deleted is not an error.
+func deleteRetryingConflicts(
+	ctx context.Context, name string, del func(context.Context, string, metav1.DeleteOptions) error,
+) error {
+	return retry.RetryOnConflict(
+		retry.DefaultRetry, func() error {
+			err := del(ctx, name, metav1.DeleteOptions{})
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		},
+	)
+}
@@ -0,0 +1,247 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestValidateConflictPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  map[string]blackstart.Input
+		wantErr string
+	}{
+		{name: "not set", inputs: map[string]blackstart.Input{}},
+		{name: "force", inputs: map[string]blackstart.Input{inputConflictPolicy: blackstart.NewInputFromValue("force")}},
+		{name: "fail", inputs: map[string]blackstart.Input{inputConflictPolicy: blackstart.NewInputFromValue("fail")}},
+		{
+			name:    "invalid",
+			inputs:  map[string]blackstart.Input{inputConflictPolicy: blackstart.NewInputFromValue("merge")},
+			wantErr: "input 'conflict_policy' has invalid value 'merge'",
+		},
+		{
+			name:   "dependency",
+			inputs: map[string]blackstart.Input{inputConflictPolicy: blackstart.NewInputFromDep("policy", "value")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				err := validateConflictPolicy(blackstart.Operation{Inputs: tt.inputs})
+				if tt.wantErr != "" {
+					assert.ErrorContains(t, err, tt.wantErr)
+					return
+				}
+				assert.NoError(t, err)
+			},
+		)
+	}
+}
+
+func TestContextApplyOptions(t *testing.T) {
+	opts, err := contextApplyOptions(blackstart.InputsToContext(context.Background(), nil))
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ApplyOptions{FieldManager: fieldManager, Force: true}, opts)
+
+	opts, err = contextApplyOptions(
+		blackstart.InputsToContext(
+			context.Background(), map[string]blackstart.Input{inputConflictPolicy: blackstart.NewInputFromValue("fail")},
+		),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, metav1.ApplyOptions{FieldManager: fieldManager, Force: false}, opts)
+}
+
+func TestRemoveKeyPatch(t *testing.T) {
+	assert.JSONEq(t, `[{"op":"remove","path":"/data/app.conf"}]`, string(removeKeyPatch("data", "app.conf")))
+	assert.JSONEq(t, `[{"op":"remove","path":"/data/a~1b~0c"}]`, string(removeKeyPatch("data", "a/b~c")))
+	assert.JSONEq(
+		t, `[{"op":"remove","path":"/binaryData/keystore.p12"}]`, string(removeKeyPatch("binaryData", "keystore.p12")),
+	)
+}
+
+// TestConfigMapModules_ServerSideApply verifies that ConfigMap keys set by Blackstart are kept
+// across operations, and that keys owned by other field managers are only taken over when the
+// conflict policy allows it.
+func TestConfigMapModules_ServerSideApply(t *testing.T) {
+	clientset := fake.NewClientset()
+	cmi := clientset.CoreV1().ConfigMaps("test-namespace")
+	_, err := cmi.Create(
+		context.Background(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-configmap", Namespace: "test-namespace"},
+			Data:       map[string]string{"external": "other"},
+		}, metav1.CreateOptions{FieldManager: "other-controller"},
+	)
+	require.NoError(t, err)
+
+	// setConfigMap runs the kubernetes_configmap module and returns its ConfigMap output.
+	setConfigMap := func(inputs map[string]blackstart.Input) *configMap {
+		inputs[inputName] = blackstart.NewInputFromValue("test-configmap")
+		inputs[inputNamespace] = blackstart.NewInputFromValue("test-namespace")
+		inputs[inputClient] = blackstart.NewInputFromValue(clientset)
+		ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+		require.NoError(t, NewConfigMapModule().Set(ctx))
+		cm, ok := ctx.outputs[outputConfigMap].(*configMap)
+		require.True(t, ok)
+		return cm
+	}
+	// setValue runs the kubernetes_configmap_value module for the key.
+	setValue := func(cm *configMap, key, value, policy string) error {
+		inputs := map[string]blackstart.Input{
+			inputConfigMap:    blackstart.NewInputFromValue(cm),
+			inputKey:          blackstart.NewInputFromValue(key),
+			inputValue:        blackstart.NewInputFromValue(value),
+			inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite),
+		}
+		if policy != "" {
+			inputs[inputConflictPolicy] = blackstart.NewInputFromValue(policy)
+		}
+		return NewConfigMapValueModule().Set(blackstart.InputsToContext(context.Background(), inputs))
+	}
+	// data returns the current data of the ConfigMap.
+	data := func() map[string]string {
+		cm, getErr := cmi.Get(context.Background(), "test-configmap", metav1.GetOptions{})
+		require.NoError(t, getErr)
+		return cm.Data
+	}
+
+	cm := setConfigMap(map[string]blackstart.Input{})
+	require.NoError(t, setValue(cm, "first", "1", ""))
+	require.NoError(t, setValue(cm, "second", "2", ""))
+	assert.Equal(t, map[string]string{"external": "other", "first": "1", "second": "2"}, data())
+
+	err = setValue(cm, "external", "blackstart", conflictPolicyFail)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "set input 'conflict_policy' to 'force'")
+	assert.Equal(t, "other", data()["external"])
+
+	require.NoError(t, setValue(cm, "external", "blackstart", conflictPolicyForce))
+	assert.Equal(t, "blackstart", data()["external"])
+
+	// Applying the ConfigMap itself keeps the keys set by value operations.
+	cm = setConfigMap(map[string]blackstart.Input{inputImmutable: blackstart.NewInputFromValue(new(bool))})
+	assert.Equal(t, map[string]string{"external": "blackstart", "first": "1", "second": "2"}, data())
+
+	ctx := blackstart.InputsToContext(
+		context.Background(), map[string]blackstart.Input{
+			inputConfigMap: blackstart.NewInputFromValue(cm),
+			inputKey:       blackstart.NewInputFromValue("first"),
+		}, blackstart.DoesNotExistFlag,
+	)
+	require.NoError(t, NewConfigMapValueModule().Set(ctx))
+	assert.Equal(t, map[string]string{"external": "blackstart", "second": "2"}, data())
+}
+
+func TestSecretValueModule_ServerSideApplyConflict(t *testing.T) {
+	clientset := fake.NewClientset()
+	si := clientset.CoreV1().Secrets("test-namespace")
+	existing, err := si.Create(
+		context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+			Data:       map[string][]byte{"external": []byte("other")},
+		}, metav1.CreateOptions{FieldManager: "other-controller"},
+	)
+	require.NoError(t, err)
+	sec := &secret{si: si, s: existing}
+
+	// setValue runs the kubernetes_secret_value module for the key.
+	setValue := func(key, policy string) error {
+		inputs := map[string]blackstart.Input{
+			inputSecret:         blackstart.NewInputFromValue(sec),
+			inputKey:            blackstart.NewInputFromValue(key),
+			inputValue:          blackstart.NewInputFromValue("blackstart"),
+			inputUpdatePolicy:   blackstart.NewInputFromValue(updatePolicyOverwrite),
+			inputConflictPolicy: blackstart.NewInputFromValue(policy),
+		}
+		return NewSecretValueModule().Set(blackstart.InputsToContext(context.Background(), inputs))
+	}
+
+	require.NoError(t, setValue("owned", conflictPolicyFail))
+	assert.ErrorContains(t, setValue("external", conflictPolicyFail), "set input 'conflict_policy' to 'force'")
+	require.NoError(t, setValue("external", conflictPolicyForce))
+
+	current, err := si.Get(context.Background(), "test-secret", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(
+		t, map[string][]byte{"external": []byte("blackstart"), "owned": []byte("blackstart")}, current.Data,
+	)
+}
+
+func TestSecretValueModule_StaleSecret(t *testing.T) {
+	clientset := fake.NewClientset()
+	si := clientset.CoreV1().Secrets("test-namespace")
+	existing, err := si.Create(
+		context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "test-namespace"},
+		}, metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	// setValue runs the kubernetes_secret_value module for the key with a Secret read before any
+	// of the keys were set, like operations of workflows run in parallel.
+	setValue := func(key string) error {
+		inputs := map[string]blackstart.Input{
+			inputSecret:       blackstart.NewInputFromValue(&secret{si: si, s: existing.DeepCopy()}),
+			inputKey:          blackstart.NewInputFromValue(key),
+			inputValue:        blackstart.NewInputFromValue("blackstart"),
+			inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite),
+		}
+		return NewSecretValueModule().Set(blackstart.InputsToContext(context.Background(), inputs))
+	}
+
+	// The fields owned by Blackstart are applied with each key, so a write with a stale Secret
+	// would remove the keys set since it was read.
+	require.NoError(t, setValue("first"))
+	require.NoError(t, setValue("second"))
+
+	// An apply that conflicts with a change made after the latest Secret was read is retried.
+	conflicts := 2
+	clientset.PrependReactor(
+		"patch", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if conflicts == 0 {
+				return false, nil, nil
+			}
+			conflicts--
+			return true, nil, apierrors.NewConflict(
+				schema.GroupResource{Resource: "secrets"}, "test-secret", errors.New("object was modified"),
+			)
+		},
+	)
+	require.NoError(t, setValue("third"))
+	assert.Zero(t, conflicts)
+
+	current, err := si.Get(context.Background(), "test-secret", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(
+		t, map[string][]byte{
+			"first":  []byte("blackstart"),
+			"second": []byte("blackstart"),
+			"third":  []byte("blackstart"),
+		}, current.Data,
+	)
+}
+
+func TestStaleResourceVersion(t *testing.T) {
+	gr := schema.GroupResource{Resource: "secrets"}
+	assert.True(t, staleResourceVersion(apierrors.NewConflict(gr, "test-secret", errors.New("object was modified"))))
+	assert.False(t, staleResourceVersion(apierrors.NewNotFound(gr, "test-secret")))
+
+	fieldConflict := apierrors.NewApplyConflict(
+		[]metav1.StatusCause{{Type: metav1.CauseTypeFieldManagerConflict, Field: ".data.key"}}, "conflict",
+	)
+	assert.False(t, staleResourceVersion(fieldConflict))
+}
@@ -16,6 +16,7 @@ func init() {
 }
 
 var _ blackstart.Module = &clientModule{}
+var _ blackstart.Preflighter = &clientModule{}
 
 func NewClientModule() blackstart.Module {
 	return &clientModule{}
@@ -25,9 +26,17 @@ type clientModule struct{}
 
 func (c *clientModule) Info() blackstart.ModuleInfo {
 	return blackstart.ModuleInfo{
-		Id:          "kubernetes_client",
-		Name:        "Kubernetes Client",
-		Description: "Establishes a connection to a Kubernetes cluster and provides a client for other modules to use.",
+		Id:   "kubernetes_client",
+		Name: "Kubernetes Client",
+		Description: util.CleanString(
+			`
+Establishes a connection to a Kubernetes cluster and provides a client for other modules to use.
+
+Kubernetes modules that do not set the '''client''' input use a client provided by the runtime,
+which uses the in-cluster configuration or the current context of the kubeconfig. This module is
+only needed to use another kubeconfig context, such as in workflows that manage several clusters.
+`,
+		),
 		Requirements: []string{
 			"A valid Kubernetes kubeconfig or in-cluster identity must be available.",
 			"If `context` is provided, that kubeconfig context must exist.",
@@ -54,6 +63,7 @@ module: kubernetes_client
 inputs:
   context: prod-cluster`,
 		},
+		OutputsOnly: true,
 	}
 }
 
@@ -66,13 +76,36 @@ func (c *clientModule) Check(_ blackstart.ModuleContext) (bool, error) {
 }
 
 func (c *clientModule) Set(ctx blackstart.ModuleContext) error {
+	clientset, err := connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Set the client output
+	err = ctx.Output(outputClient, clientsetAsInterface(clientset))
+	if err != nil {
+		return fmt.Errorf("failed to set client output: %w", err)
+	}
+
+	return nil
+}
+
+// Preflight verifies that the Kubernetes API server is reachable with the configured context.
+func (c *clientModule) Preflight(ctx blackstart.ModuleContext) error {
+	_, err := connect(ctx)
+	return err
+}
+
+// connect creates a Kubernetes clientset for the configured context and verifies the connection to
+// the cluster.
+func connect(ctx blackstart.ModuleContext) (*kubernetes.Clientset, error) {
 	var kubeContext string
 	var config *rest.Config
 	var err error
 
 	kubeContext, err = blackstart.ContextInputAs[string](ctx, inputContext, false)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Attempt to do in-cluster configuration if no context is provided
@@ -82,27 +115,23 @@ func (c *clientModule) Set(ctx blackstart.ModuleContext) error {
 		config, err = util.GetK8sClientConfigWithContext(kubeContext)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to get Kubernetes client config: %w", err)
+		return nil, fmt.Errorf("failed to get Kubernetes client config: %w", err)
 	}
 
+	// Requests are counted as API calls of the operation whose context they are made with.
+	config.Wrap(blackstart.CountAPICallsOf(blackstart.APIProviderKubernetes))
+
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
 	}
 
 	// Make sure the connection is working
 	_, err = clientset.Discovery().ServerVersion()
 	if err != nil {
-		return fmt.Errorf("failed to connect to Kubernetes cluster: %w", err)
-	}
-
-	// Set the client output
-	err = ctx.Output(outputClient, clientsetAsInterface(clientset))
-	if err != nil {
-		return fmt.Errorf("failed to set client output: %w", err)
+		return nil, fmt.Errorf("failed to connect to Kubernetes cluster: %w", err)
 	}
-
-	return nil
+	return clientset, nil
 }
 
 func clientsetAsInterface(clientset *kubernetes.Clientset) kubernetes.Interface {
@@ -1,14 +1,19 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"unicode/utf8"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/client-go/kubernetes"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/pezops/blackstart"
 	"github.com/pezops/blackstart/util"
@@ -29,14 +34,130 @@ type configMap struct {
 	cm  *corev1.ConfigMap ``
 }
 
-func (c *configMap) Update(ctx blackstart.ModuleContext) error {
-	var err error
-	c.cm, err = c.cmi.Update(ctx, c.cm, metav1.UpdateOptions{})
-	return err
+// Value returns the value of a key of the ConfigMap from its data or binary data, and whether the
+// key exists.
+func (c *configMap) Value(key string) ([]byte, bool) {
+	if value, ok := c.cm.Data[key]; ok {
+		return []byte(value), true
+	}
+	value, ok := c.cm.BinaryData[key]
+	return value, ok
+}
+
+// ApplyValue sets a key of the ConfigMap with server-side apply. Values that are valid UTF-8 are
+// set in the data of the ConfigMap, and other values in its binary data. The other fields owned by
+// the Blackstart field manager are applied with the key, so they are kept.
+func (c *configMap) ApplyValue(ctx blackstart.ModuleContext, key string, value []byte, opts metav1.ApplyOptions) error {
+	err := c.apply(
+		ctx, opts, func(_ *corev1.ConfigMap, cfg *applycorev1.ConfigMapApplyConfiguration) {
+			// A key must not be set in both the data and binary data of a ConfigMap.
+			if utf8.Valid(value) {
+				delete(cfg.BinaryData, key)
+				cfg.WithData(map[string]string{key: string(value)})
+			} else {
+				delete(cfg.Data, key)
+				cfg.WithBinaryData(map[string][]byte{key: value})
+			}
+		},
+	)
+	if err != nil {
+		return applyError(err)
+	}
+	return c.updateContentHash(ctx)
+}
+
+// apply applies the fields of the ConfigMap owned by the Blackstart field manager with the changes
+// made by update. The owned fields are read from the latest ConfigMap and applied against its
+// resource version, so a key set by another writer since the ConfigMap was read is not lost. When
+// the ConfigMap is changed between the read and the apply, the apply is retried.
+func (c *configMap) apply(
+	ctx blackstart.ModuleContext, opts metav1.ApplyOptions,
+	update func(current *corev1.ConfigMap, cfg *applycorev1.ConfigMapApplyConfiguration),
+) error {
+	return retry.OnError(
+		retry.DefaultRetry, staleResourceVersion, func() error {
+			current, err := c.cmi.Get(ctx, c.cm.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			cfg, err := applycorev1.ExtractConfigMap(current, fieldManager)
+			if err != nil {
+				return fmt.Errorf(
+					"unable to extract managed fields of ConfigMap '%s/%s': %w", current.Namespace, current.Name, err,
+				)
+			}
+			update(current, cfg)
+			if current.ResourceVersion != "" {
+				cfg.WithResourceVersion(current.ResourceVersion)
+			}
+			cm, err := c.cmi.Apply(ctx, cfg, opts)
+			if err != nil {
+				return err
+			}
+			c.cm = cm
+			return nil
+		},
+	)
+}
+
+// RemoveValue removes a key from the data or binary data of the ConfigMap. The key is removed with
+// a JSON patch, since server-side apply does not remove keys that are also owned by other field
+// managers. The patch is retried with the latest ConfigMap on conflicts with other writers.
+func (c *configMap) RemoveValue(ctx blackstart.ModuleContext, key string) error {
+	err := retry.RetryOnConflict(
+		retry.DefaultRetry, func() error {
+			current, err := c.cmi.Get(ctx, c.cm.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			c.cm = current
+			if _, ok := c.Value(key); !ok {
+				return nil
+			}
+			field := "data"
+			if _, ok := current.Data[key]; !ok {
+				field = "binaryData"
+			}
+			cm, err := c.cmi.Patch(
+				ctx, current.Name, types.JSONPatchType, removeKeyPatch(field, key),
+				metav1.PatchOptions{FieldManager: fieldManager},
+			)
+			if err != nil {
+				return err
+			}
+			c.cm = cm
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return c.updateContentHash(ctx)
 }
 
+// updateContentHash updates the content hash annotation of the ConfigMap after its values changed,
+// when the ConfigMap has the annotation. The annotation is owned by Blackstart, so ownership of it
+// is always taken.
+func (c *configMap) updateContentHash(ctx blackstart.ModuleContext) error {
+	if !hasContentHash(c.cm.Annotations) || c.cm.Annotations[contentHashAnnotation] == configMapContentHash(c.cm) {
+		return nil
+	}
+	err := c.apply(
+		ctx, metav1.ApplyOptions{FieldManager: fieldManager, Force: true},
+		func(current *corev1.ConfigMap, cfg *applycorev1.ConfigMapApplyConfiguration) {
+			cfg.WithAnnotations(map[string]string{contentHashAnnotation: configMapContentHash(current)})
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update content hash of ConfigMap '%s/%s': %w", c.cm.Namespace, c.cm.Name, err)
+	}
+	return nil
+}
+
+// Delete deletes the ConfigMap resource from Kubernetes. The delete is retried on conflicts with
+// other writers.
 func (c *configMap) Delete(ctx blackstart.ModuleContext) error {
-	return c.cmi.Delete(ctx, c.cm.Name, metav1.DeleteOptions{})
+	return deleteRetryingConflicts(ctx, c.cm.Name, c.cmi.Delete)
 }
 
 type configMapModule struct{}
@@ -56,37 +177,61 @@ Manages a Kubernetes ConfigMap resource, but not content.
 - Once a ConfigMap is set to be immutable, values cannot be set or changed. Do not set a ConfigMap to be 
   immutable before setting the values. See [Immutable ConfigMaps](https://kubernetes.io/docs/concepts/configuration/configmap/#configmap-immutable) 
   for more information.
+- When '''content_hash''' is enabled, the '''blackstart.pezops.github.io/content-hash''' annotation holds a
+  SHA-256 hash of the keys and values of the ConfigMap. The '''kubernetes_configmap_value''' module updates the
+  annotation when it changes a key, so controllers that watch the annotation, such as a reloader, can
+  roll out workloads that use the ConfigMap.
+- With '''doesNotExist''', the ConfigMap is deleted. With '''wait_for_deletion''', Set waits until it is
+  removed, such as while finalizers run, so later checks do not see the ConfigMap while it is terminating.
 `,
-		),
+		) + "\n\n" + conflictPolicyDocs,
 		Requirements: []string{
 			"The target namespace must exist.",
 			"The Kubernetes identity must be authorized for ConfigMap operations in the target namespace.",
-			"Required ConfigMap verbs: `get`, `create`, `update`, `patch`, `delete`.",
+			"Required ConfigMap verbs: `get`, `patch`, `delete`.",
 		},
-		Inputs: map[string]blackstart.InputValue{
-			inputName: {
-				Description: "Name of the ConfigMap",
-				Type:        reflect.TypeFor[string](),
-				Required:    true,
-			},
-			inputNamespace: {
-				Description: "Namespace where the ConfigMap exists",
-				Type:        reflect.TypeFor[string](),
-				Required:    false,
-				Default:     "default",
-			},
-			inputClient: {
-				Description: "Kubernetes client interface to use for API calls",
-				Type:        reflect.TypeFor[kubernetes.Interface](),
-				Required:    true,
-			},
-			inputImmutable: {
-				Description: "Make the ConfigMap immutable. Ignored if not set (default).",
-				Type:        reflect.TypeFor[*bool](),
-				Required:    false,
-				Default:     nil,
+		Inputs: withDeletionInputs(
+			"ConfigMap", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the ConfigMap",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputNamespace: {
+					Description: "Namespace where the ConfigMap exists. Defaults to `default`, or to the namespace Blackstart runs in when `--k8s-default-namespace-from-runtime` is enabled.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime, such as `system:serviceaccount:<namespace>:<name>`. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputImmutable: {
+					Description: "Make the ConfigMap immutable. Ignored if not set (default).",
+					Type:        reflect.TypeFor[*bool](),
+					Required:    false,
+					Default:     nil,
+				},
+				inputContentHash: {
+					Description: "Maintain the `blackstart.pezops.github.io/content-hash` annotation with a hash of the content of the ConfigMap.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputConflictPolicy: {
+					Description: "Conflict policy for fields owned by other field managers: `force` or `fail`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     conflictPolicyForce,
+				},
 			},
-		},
+		),
 		Outputs: map[string]blackstart.OutputValue{
 			outputConfigMap: {
 				Description: "The ConfigMap resource",
@@ -103,17 +248,23 @@ inputs:
       output: client
   name: my-config
   namespace: default`,
+			"Runtime-Provided Client": `id: create-configmap
+module: kubernetes_configmap
+inputs:
+  name: my-configmap
+  namespace: myapp
+  impersonate: system:serviceaccount:myapp:blackstart`,
+			"ConfigMap with Content Hash": `id: app-configmap
+module: kubernetes_configmap
+inputs:
+  name: app-config
+  namespace: myapp
+  content_hash: true`,
 			"Configure ConfigMap to be Immutable": `operations:
-  - id: k8s_client
-    module: kubernetes_client
   - id: myapp_configmap
     module: kubernetes_configmap
     name: MyApp ConfigMap
     inputs:
-      client:
-        fromDependency:
-          id: k8s_client
-          output: client
       namespace: myapp
       name: myapp-config
   - id: myapp_db_host
@@ -137,10 +288,6 @@ inputs:
   - id: myapp_configmap_immutable
     module: kubernetes_configmap
     inputs:
-      client:
-        fromDependency:
-          id: k8s_client
-          output: client
       namespace: myapp
       name: myapp-config
       immutable: true
@@ -167,13 +314,13 @@ func (c *configMapModule) Validate(op blackstart.Operation) error {
 		}
 	}
 
-	// Client is required
-	_, ok = op.Inputs[inputClient]
-	if !ok {
-		return fmt.Errorf("input '%s' must be provided", inputClient)
+	if err := validateConflictPolicy(op); err != nil {
+		return err
 	}
-
-	return nil
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
 }
 
 func (c *configMapModule) Check(ctx blackstart.ModuleContext) (bool, error) {
@@ -182,22 +329,18 @@ func (c *configMapModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 		return false, nil
 	}
 
-	clientInput, err := ctx.Input(inputClient)
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
 	if err != nil {
-		return false, fmt.Errorf("failed to get client input: %w", err)
-	}
-
-	cc, ok := clientInput.Any().(kubernetes.Interface)
-	if !ok {
-		return false, fmt.Errorf("client input is not a Kubernetes clientset")
+		return false, err
 	}
 
-	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	namespace, err := contextNamespace(ctx)
 	if err != nil {
 		return false, err
 	}
+	ctx.Resource(namespace + "/" + name)
 
-	namespace, err := blackstart.ContextInputAs[string](ctx, inputNamespace, true)
+	cc, err := contextClient(ctx, namespace)
 	if err != nil {
 		return false, err
 	}
@@ -239,6 +382,14 @@ func (c *configMapModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 			}
 		}
 
+		desiredContentHash, hashErr := blackstart.ContextInputAs[bool](ctx, inputContentHash, false)
+		if hashErr != nil {
+			return false, hashErr
+		}
+		if desiredContentHash && cm.Annotations[contentHashAnnotation] != configMapContentHash(cm) {
+			return false, nil
+		}
+
 		err = ctx.Output("configmap", &configMap{cmi: cmi, cm: cm})
 		if err != nil {
 			return false, err
@@ -249,22 +400,18 @@ func (c *configMapModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 }
 
 func (c *configMapModule) Set(ctx blackstart.ModuleContext) error {
-	clientInput, err := ctx.Input(inputClient)
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
 	if err != nil {
-		return fmt.Errorf("failed to get client input: %w", err)
-	}
-
-	client, ok := clientInput.Any().(kubernetes.Interface)
-	if !ok {
-		return fmt.Errorf("client input is not a Kubernetes clientset")
+		return err
 	}
 
-	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	namespace, err := contextNamespace(ctx)
 	if err != nil {
 		return err
 	}
+	ctx.Resource(namespace + "/" + name)
 
-	namespace, err := blackstart.ContextInputAs[string](ctx, inputNamespace, true)
+	client, err := contextClient(ctx, namespace)
 	if err != nil {
 		return err
 	}
@@ -286,75 +433,62 @@ func (c *configMapModule) Set(ctx blackstart.ModuleContext) error {
 
 		if cm != nil {
 			// ConfigMap exists, delete it
-			err = cmi.Delete(ctx, name, metav1.DeleteOptions{})
-			return err
+			if err = deleteRetryingConflicts(ctx, name, cmi.Delete); err != nil {
+				return err
+			}
+			return waitForDeletion(
+				ctx, fmt.Sprintf("ConfigMap %s/%s", namespace, name), func(c context.Context) (metav1.Object, error) {
+					return cmi.Get(c, name, metav1.GetOptions{})
+				},
+			)
 		}
 		return fmt.Errorf("could not determine if ConfigMap '%s/%s' exists", namespace, name)
 	}
 
-	// Try to get the ConfigMap
-	cm, err := cmi.Get(ctx, name, metav1.GetOptions{})
+	opts, err := contextApplyOptions(ctx)
+	if err != nil {
+		return err
+	}
+	desiredImmutablePtr, err := blackstart.ContextInputAs[*bool](ctx, inputImmutable, false)
+	if err != nil {
+		return err
+	}
+	desiredContentHash, err := blackstart.ContextInputAs[bool](ctx, inputContentHash, false)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// ConfigMap doesn't exist
-			newCm := &corev1.ConfigMap{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      name,
-					Namespace: namespace,
-				},
-				Data: map[string]string{},
-			}
-
-			// Only set immutable if the input is provided and not nil
-			desiredImmutablePtr, immutableErr := blackstart.ContextInputAs[*bool](ctx, inputImmutable, false)
-			if immutableErr != nil {
-				return immutableErr
-			}
-
-			if desiredImmutablePtr != nil {
-				desiredImmutable := *desiredImmutablePtr
-				newCm.Immutable = &desiredImmutable
-			}
-
-			_, err = cmi.Create(ctx, newCm, metav1.CreateOptions{})
-			if err != nil {
-				return err
-			}
-
-			return ctx.Output("configmap", &configMap{cmi: cmi, cm: newCm})
-		}
 		return err
 	}
 
-	// ConfigMap should exist
-	if cm != nil {
-		needsUpdate := false
-
-		// Check if we need to update the immutable field (only if immutable input is provided)
-		desiredImmutablePtr, immutableErr := blackstart.ContextInputAs[*bool](ctx, inputImmutable, false)
-		if immutableErr != nil {
-			return immutableErr
-		}
-		if desiredImmutablePtr != nil {
-			desiredImmutable := *desiredImmutablePtr
-			currentImmutable := cm.Immutable
-
-			if currentImmutable == nil || desiredImmutable != *currentImmutable {
-				needsUpdate = true
-				cm.Immutable = &desiredImmutable
-			}
-		}
-
-		// Update the ConfigMap if needed
-		if needsUpdate {
-			cm, err = cmi.Update(ctx, cm, metav1.UpdateOptions{})
-			if err != nil {
-				return err
-			}
-		}
-
+	// Only the fields owned by Blackstart are applied, so the ConfigMap is created when it does not
+	// exist, and keys set by value operations are kept.
+	cm, err := cmi.Get(ctx, name, metav1.GetOptions{})
+	var cfg *applycorev1.ConfigMapApplyConfiguration
+	hash := contentHash(nil, nil)
+	switch {
+	case apierrors.IsNotFound(err):
+		cfg = applycorev1.ConfigMap(name, namespace)
+	case err != nil:
+		return err
+	case (desiredImmutablePtr == nil || (cm.Immutable != nil && *cm.Immutable == *desiredImmutablePtr)) &&
+		(!desiredContentHash || cm.Annotations[contentHashAnnotation] == configMapContentHash(cm)):
 		return ctx.Output("configmap", &configMap{cmi: cmi, cm: cm})
+	default:
+		hash = configMapContentHash(cm)
+		cfg, err = applycorev1.ExtractConfigMap(cm, fieldManager)
+		if err != nil {
+			return fmt.Errorf("unable to extract managed fields of ConfigMap '%s/%s': %w", namespace, name, err)
+		}
 	}
 
-	return fmt.Errorf("could not determine if ConfigMap '%s/%s' exists", namespace, name)
+	// Only set immutable if the input is provided and not nil
+	if desiredImmutablePtr != nil {
+		cfg.WithImmutable(*desiredImmutablePtr)
+	}
+	if desiredContentHash {
+		cfg.WithAnnotations(map[string]string{contentHashAnnotation: hash})
+	}
+	cm, err = cmi.Apply(ctx, cfg, opts)
+	if err != nil {
+		return applyError(err)
+	}
+	return ctx.Output("configmap", &configMap{cmi: cmi, cm: cm})
 }
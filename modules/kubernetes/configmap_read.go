@@ -0,0 +1,96 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("kubernetes_configmap_read", NewConfigMapReadModule)
+}
+
+var _ blackstart.Module = &configMapReadModule{}
+
+func NewConfigMapReadModule() blackstart.Module {
+	return &configMapReadModule{}
+}
+
+type configMapReadModule struct{}
+
+func (c *configMapReadModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_configmap_read",
+		Name: "Kubernetes ConfigMap Read",
+		Description: util.CleanString(
+			`
+Reads the keys of an existing Kubernetes ConfigMap and outputs their values, such as the endpoint
+of a service provisioned outside of Blackstart. The ConfigMap is never created or changed. Keys in
+both the '''data''' and '''binaryData''' of the ConfigMap can be read.
+
+**Notes**
+
+- The operation fails when the ConfigMap or a requested key does not exist.
+- '''doesNotExist''' is not supported.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized to read ConfigMaps in the target namespace.",
+			"Required ConfigMap verbs for this module: `get`.",
+		},
+		Inputs:  readInputs("ConfigMap"),
+		Outputs: readOutputs("ConfigMap", false),
+		Examples: map[string]string{
+			"Read a Service Endpoint": `id: read-db-host
+module: kubernetes_configmap_read
+inputs:
+  namespace: platform
+  name: database-endpoints
+  key: host`,
+		},
+		OutputsOnly: true,
+	}
+}
+
+func (c *configMapReadModule) Validate(op blackstart.Operation) error {
+	return validateReadInputs(op)
+}
+
+func (c *configMapReadModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	// The ConfigMap is only read, so the operation is always in the desired state once the values
+	// are output.
+	return true, readConfigMapValues(ctx)
+}
+
+func (c *configMapReadModule) Set(ctx blackstart.ModuleContext) error {
+	return readConfigMapValues(ctx)
+}
+
+// readConfigMapValues reads the ConfigMap of a kubernetes_configmap_read operation and outputs the
+// values of the requested keys.
+func readConfigMapValues(ctx blackstart.ModuleContext) error {
+	cc, namespace, name, err := contextReadResource(ctx)
+	if err != nil {
+		return err
+	}
+	cm, err := cc.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to read ConfigMap '%s/%s': the ConfigMap does not exist", namespace, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ConfigMap '%s/%s': %w", namespace, name, err)
+	}
+
+	data := make(map[string][]byte, len(cm.Data)+len(cm.BinaryData))
+	for key, value := range cm.BinaryData {
+		data[key] = value
+	}
+	for key, value := range cm.Data {
+		data[key] = []byte(value)
+	}
+	return outputReadValues(ctx, fmt.Sprintf("ConfigMap '%s/%s'", namespace, name), data)
+}
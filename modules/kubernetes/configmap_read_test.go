@@ -0,0 +1,59 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestConfigMapReadModule_Info(t *testing.T) {
+	info := NewConfigMapReadModule().Info()
+
+	assert.Equal(t, "kubernetes_configmap_read", info.Id)
+	assert.False(t, info.Outputs[outputValue].Sensitive)
+	assert.False(t, info.Outputs[outputValues].Sensitive)
+}
+
+func TestConfigMapReadModule_Check(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "database-endpoints", Namespace: "platform"},
+			Data:       map[string]string{"host": "db.platform.svc", "port": "5432"},
+			BinaryData: map[string][]byte{"ca.der": {0x30, 0x82}},
+		},
+	)
+	module := NewConfigMapReadModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputNamespace: blackstart.NewInputFromValue("platform"),
+		inputName:      blackstart.NewInputFromValue("database-endpoints"),
+		inputKey:       blackstart.NewInputFromValue("host"),
+		inputKeys:      blackstart.NewInputFromValue([]string{"port"}),
+	}
+	require.NoError(t, module.Validate(blackstart.Operation{Module: "kubernetes_configmap_read", Inputs: inputs}))
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "db.platform.svc", ctx.outputs[outputValue])
+	assert.Equal(t, map[string]string{"host": "db.platform.svc", "port": "5432"}, ctx.outputs[outputValues])
+
+	// Keys in the binary data are read.
+	inputs[inputKey] = blackstart.NewInputFromValue("ca.der")
+	inputs[inputEncoding] = blackstart.NewInputFromValue(valueEncodingBase64)
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, "MII=", ctx.outputs[outputValue])
+
+	inputs[inputName] = blackstart.NewInputFromValue("missing")
+	_, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	assert.EqualError(t, err, "failed to read ConfigMap 'platform/missing': the ConfigMap does not exist")
+}
@@ -57,11 +57,21 @@ func TestConfigMapModule_Validate(t *testing.T) {
 			expectError: false,
 		},
 		{
-			name: "missing client",
+			name: "missing client uses runtime client",
 			inputs: map[string]blackstart.Input{
 				inputName:      blackstart.NewInputFromValue("test-configmap"),
 				inputNamespace: blackstart.NewInputFromValue("test-namespace"),
 			},
+			expectError: false,
+		},
+		{
+			name: "impersonate with client",
+			inputs: map[string]blackstart.Input{
+				inputClient:      blackstart.NewInputFromValue(fakeClientset),
+				inputImpersonate: blackstart.NewInputFromValue("system:serviceaccount:test-namespace:app"),
+				inputName:        blackstart.NewInputFromValue("test-configmap"),
+				inputNamespace:   blackstart.NewInputFromValue("test-namespace"),
+			},
 			expectError: true,
 		},
 		{
@@ -497,3 +507,98 @@ func TestConfigMapModule_Immutable(t *testing.T) {
 		},
 	)
 }
+
+func TestConfigMapModule_ContentHash(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewConfigMapModule()
+	valueModule := NewConfigMapValueModule()
+	cmInputs := func() map[string]blackstart.Input {
+		return map[string]blackstart.Input{
+			inputClient:      blackstart.NewInputFromValue(clientset),
+			inputName:        blackstart.NewInputFromValue("app-config"),
+			inputNamespace:   blackstart.NewInputFromValue("test-namespace"),
+			inputContentHash: blackstart.NewInputFromValue(true),
+		}
+	}
+	getConfigMap := func() *corev1.ConfigMap {
+		cm, err := clientset.CoreV1().ConfigMaps("test-namespace").Get(
+			context.Background(), "app-config", metav1.GetOptions{},
+		)
+		require.NoError(t, err)
+		return cm
+	}
+
+	// The annotation is set when the ConfigMap is created.
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), cmInputs())}
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, contentHash(nil, nil), getConfigMap().Annotations[contentHashAnnotation])
+	cm := ctx.outputs[outputConfigMap].(*configMap)
+
+	// Value operations update the annotation when they change a key.
+	valueInputs := map[string]blackstart.Input{
+		inputConfigMap:    blackstart.NewInputFromValue(cm),
+		inputKey:          blackstart.NewInputFromValue("db_host"),
+		inputValue:        blackstart.NewInputFromValue("db.example.com"),
+		inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite),
+	}
+	require.NoError(t, valueModule.Set(blackstart.InputsToContext(context.Background(), valueInputs)))
+	expected := contentHash(map[string]string{"db_host": "db.example.com"}, nil)
+	assert.Equal(t, expected, getConfigMap().Annotations[contentHashAnnotation])
+	assert.Equal(t, expected, cm.cm.Annotations[contentHashAnnotation])
+
+	ok, err := module.Check(blackstart.InputsToContext(context.Background(), cmInputs()))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Changes by others are detected and the annotation is updated.
+	changed := getConfigMap()
+	changed.Data["db_port"] = "5432"
+	_, err = clientset.CoreV1().ConfigMaps("test-namespace").Update(context.Background(), changed, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), cmInputs()))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(blackstart.InputsToContext(context.Background(), cmInputs())))
+	assert.Equal(t, configMapContentHash(changed), getConfigMap().Annotations[contentHashAnnotation])
+	assert.Equal(t, "5432", getConfigMap().Data["db_port"])
+
+	// Removing a key updates the annotation.
+	cm.cm = getConfigMap()
+	require.NoError(
+		t,
+		valueModule.Set(blackstart.InputsToContext(context.Background(), valueInputs, blackstart.DoesNotExistFlag)),
+	)
+	assert.Equal(
+		t, contentHash(map[string]string{"db_port": "5432"}, nil), getConfigMap().Annotations[contentHashAnnotation],
+	)
+
+	// ConfigMaps without the annotation are not annotated by value operations.
+	plain := &configMap{cmi: clientset.CoreV1().ConfigMaps("test-namespace")}
+	plain.cm, err = plain.cmi.Create(
+		context.Background(),
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "test-namespace"}},
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+	valueInputs[inputConfigMap] = blackstart.NewInputFromValue(plain)
+	require.NoError(t, valueModule.Set(blackstart.InputsToContext(context.Background(), valueInputs)))
+	assert.NotContains(t, plain.cm.Annotations, contentHashAnnotation)
+}
+
+func TestConfigMapModule_DeleteRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}},
+	)
+	deletes := conflictOnFirst(clientset, "delete", "", "configmaps")
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("app"),
+		inputNamespace: blackstart.NewInputFromValue("default"),
+	}
+
+	ctx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	require.NoError(t, NewConfigMapModule().Set(ctx))
+	assert.Equal(t, 2, deletes())
+	_, err := clientset.CoreV1().ConfigMaps("default").Get(context.Background(), "app", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
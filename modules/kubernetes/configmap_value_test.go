@@ -2,6 +2,9 @@ package kubernetes
 
 import (
 	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,6 +34,16 @@ func TestConfigMapValueModule_Info(t *testing.T) {
 	assert.True(t, exists)
 }
 
+func TestConfigMapValueModule_SerializationKey(t *testing.T) {
+	value := &configMap{cm: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test-namespace"}}}
+	ctx := blackstart.InputsToContext(
+		context.Background(), map[string]blackstart.Input{inputConfigMap: blackstart.NewInputFromValue(value)},
+	)
+	key, err := NewConfigMapValueModule().Info().SerializationKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "kubernetes_configmap:test-namespace/app", key)
+}
+
 func TestConfigMapValueModule_Validate(t *testing.T) {
 	module := NewConfigMapValueModule()
 	fakeClientset := fake.NewClientset()
@@ -915,3 +928,262 @@ func TestConfigMapValueModule(t *testing.T) {
 		},
 	)
 }
+
+func TestConfigMapValueModule_ImmutableConfigMap(t *testing.T) {
+	clientset := fake.NewClientset()
+	immutable := true
+	initialConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-configmap",
+			Namespace: "test-namespace",
+		},
+		Data:      map[string]string{"existing-key": "existing-value"},
+		Immutable: &immutable,
+	}
+	_, err := clientset.CoreV1().ConfigMaps("test-namespace").Create(
+		context.Background(),
+		initialConfigMap,
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	module := NewConfigMapValueModule()
+	contextFor := func(key, value, updatePolicy string, flags ...blackstart.ModuleContextFlag) blackstart.ModuleContext {
+		inputs := map[string]blackstart.Input{
+			inputConfigMap: blackstart.NewInputFromValue(&configMap{
+				cm:  initialConfigMap,
+				cmi: clientset.CoreV1().ConfigMaps("test-namespace"),
+			}),
+			inputKey:          blackstart.NewInputFromValue(key),
+			inputValue:        blackstart.NewInputFromValue(value),
+			inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicy),
+		}
+		return blackstart.InputsToContext(context.Background(), inputs, flags...)
+	}
+
+	// Keys already in the desired state pass the check.
+	ok, err := module.Check(contextFor("existing-key", "existing-value", updatePolicyOverwrite))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = module.Check(contextFor("missing-key", "", updatePolicyOverwrite, blackstart.DoesNotExistFlag))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	for name, ctx := range map[string]blackstart.ModuleContext{
+		"changed value": contextFor("existing-key", "new-value", updatePolicyOverwrite),
+		"missing key":   contextFor("new-key", "new-value", updatePolicyPreserveAny),
+		"removed key":   contextFor("existing-key", "", updatePolicyOverwrite, blackstart.DoesNotExistFlag),
+	} {
+		t.Run(
+			name, func(t *testing.T) {
+				_, err = module.Check(ctx)
+				require.ErrorIs(t, err, errImmutable)
+				assert.ErrorContains(t, err, "ConfigMap test-namespace/test-configmap is immutable")
+
+				err = module.Set(ctx)
+				require.ErrorIs(t, err, errImmutable)
+			},
+		)
+	}
+
+	cm, err := clientset.CoreV1().ConfigMaps("test-namespace").Get(
+		context.Background(), "test-configmap", metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"existing-key": "existing-value"}, cm.Data)
+}
+
+func TestConfigMapValueModule_BinaryValues(t *testing.T) {
+	clientset := fake.NewClientset()
+	initialConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-configmap",
+			Namespace: "test-namespace",
+		},
+		Data: map[string]string{},
+	}
+	_, err := clientset.CoreV1().ConfigMaps("test-namespace").Create(
+		context.Background(),
+		initialConfigMap,
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	cm := &configMap{
+		cm:  initialConfigMap,
+		cmi: clientset.CoreV1().ConfigMaps("test-namespace"),
+	}
+	keystore := []byte{0x30, 0x82, 0xff, 0x00, 0xfe}
+	module := NewConfigMapValueModule()
+	contextFor := func(inputs map[string]blackstart.Input, flags ...blackstart.ModuleContextFlag) *capturingModuleContext {
+		inputs[inputConfigMap] = blackstart.NewInputFromValue(cm)
+		inputs[inputUpdatePolicy] = blackstart.NewInputFromValue(updatePolicyOverwrite)
+		return &capturingModuleContext{
+			ModuleContext: blackstart.InputsToContext(context.Background(), inputs, flags...),
+		}
+	}
+
+	// Binary values are decoded from base64 and stored in the binary data.
+	base64Inputs := func() map[string]blackstart.Input {
+		return map[string]blackstart.Input{
+			inputKey:      blackstart.NewInputFromValue("keystore.p12"),
+			inputValue:    blackstart.NewInputFromValue(base64.StdEncoding.EncodeToString(keystore)),
+			inputEncoding: blackstart.NewInputFromValue(valueEncodingBase64),
+		}
+	}
+	ok, err := module.Check(contextFor(base64Inputs()))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ctx := contextFor(base64Inputs())
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(keystore), ctx.outputs[outputValue])
+	assert.Equal(t, keystore, cm.cm.BinaryData["keystore.p12"])
+	assert.NotContains(t, cm.cm.Data, "keystore.p12")
+
+	ctx = contextFor(base64Inputs())
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(keystore), ctx.outputs[outputValue])
+
+	// Values read from a file are used as-is.
+	path := filepath.Join(t.TempDir(), "keystore.p12")
+	require.NoError(t, os.WriteFile(path, keystore, 0o600))
+	ok, err = module.Check(
+		contextFor(
+			map[string]blackstart.Input{
+				inputKey:  blackstart.NewInputFromValue("keystore.p12"),
+				inputPath: blackstart.NewInputFromValue(path),
+			},
+		),
+	)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// UTF-8 values replace binary values in the data.
+	pemPath := filepath.Join(t.TempDir(), "ca.crt")
+	pem := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n"
+	require.NoError(t, os.WriteFile(pemPath, []byte(pem), 0o600))
+	ctx = contextFor(
+		map[string]blackstart.Input{
+			inputKey:  blackstart.NewInputFromValue("keystore.p12"),
+			inputPath: blackstart.NewInputFromValue(pemPath),
+		},
+	)
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, pem, ctx.outputs[outputValue])
+	assert.Equal(t, pem, cm.cm.Data["keystore.p12"])
+	assert.NotContains(t, cm.cm.BinaryData, "keystore.p12")
+
+	// Keys in the binary data are removed.
+	cm.cm.BinaryData = map[string][]byte{"truststore.jks": keystore}
+	_, err = clientset.CoreV1().ConfigMaps("test-namespace").Update(context.Background(), cm.cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	removeInputs := map[string]blackstart.Input{inputKey: blackstart.NewInputFromValue("truststore.jks")}
+	require.NoError(t, module.Set(contextFor(removeInputs, blackstart.DoesNotExistFlag)))
+	assert.NotContains(t, cm.cm.BinaryData, "truststore.jks")
+}
+
+func TestConfigMapValueModule_ValidateValueSources(t *testing.T) {
+	module := NewConfigMapValueModule()
+	tests := []struct {
+		name   string
+		inputs map[string]blackstart.Input
+		err    string
+	}{
+		{
+			name: "path",
+			inputs: map[string]blackstart.Input{
+				inputPath:         blackstart.NewInputFromValue("/etc/ca/ca.crt"),
+				inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite),
+			},
+		},
+		{
+			name: "base64 value",
+			inputs: map[string]blackstart.Input{
+				inputValue:    blackstart.NewInputFromValue("AAECAw=="),
+				inputEncoding: blackstart.NewInputFromValue(valueEncodingBase64),
+			},
+		},
+		{
+			name: "value and path",
+			inputs: map[string]blackstart.Input{
+				inputValue: blackstart.NewInputFromValue("value"),
+				inputPath:  blackstart.NewInputFromValue("/etc/ca/ca.crt"),
+			},
+			err: "input 'path' cannot be used with input 'value'",
+		},
+		{
+			name:   "empty path",
+			inputs: map[string]blackstart.Input{inputPath: blackstart.NewInputFromValue("")},
+			err:    "input 'path' is invalid",
+		},
+		{
+			name: "invalid encoding",
+			inputs: map[string]blackstart.Input{
+				inputValue:    blackstart.NewInputFromValue("value"),
+				inputEncoding: blackstart.NewInputFromValue("hex"),
+			},
+			err: "input 'encoding' has invalid value 'hex'",
+		},
+		{
+			name: "invalid base64",
+			inputs: map[string]blackstart.Input{
+				inputValue:    blackstart.NewInputFromValue("not base64!"),
+				inputEncoding: blackstart.NewInputFromValue(valueEncodingBase64),
+			},
+			err: "input 'value' is not valid base64",
+		},
+		{
+			name:   "missing value and path",
+			inputs: map[string]blackstart.Input{inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite)},
+			err:    "input 'value' or 'path' must be provided",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				tt.inputs[inputConfigMap] = blackstart.NewInputFromValue(&configMap{})
+				tt.inputs[inputKey] = blackstart.NewInputFromValue("ca.crt")
+				err := module.Validate(blackstart.Operation{Inputs: tt.inputs})
+				if tt.err == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.err)
+			},
+		)
+	}
+}
+
+func TestConfigMapValueModule_RemoveRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			BinaryData: map[string][]byte{"cert": {0xff}},
+			Data:       map[string]string{"other": "kept"},
+		},
+	)
+	patches := conflictOnFirst(clientset, "patch", "", "configmaps")
+	// The key was moved to the binary data by another writer since the ConfigMap was read.
+	configMapObj := &configMap{
+		cm: &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Data:       map[string]string{"cert": "text"},
+		},
+		cmi: clientset.CoreV1().ConfigMaps("default"),
+	}
+	inputs := map[string]blackstart.Input{
+		inputConfigMap: blackstart.NewInputFromValue(configMapObj),
+		inputKey:       blackstart.NewInputFromValue("cert"),
+	}
+
+	ctx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	require.NoError(t, NewConfigMapValueModule().Set(ctx))
+	assert.Equal(t, 2, patches())
+	cm, err := clientset.CoreV1().ConfigMaps("default").Get(context.Background(), "app", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, cm.BinaryData)
+	assert.Equal(t, map[string]string{"other": "kept"}, cm.Data)
+}
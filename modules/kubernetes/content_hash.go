@@ -0,0 +1,49 @@
+package kubernetes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// contentHashAnnotation is the annotation of a ConfigMap or Secret that holds the hash of its
+// content, for controllers that restart workloads when the configuration changes.
+const contentHashAnnotation = "blackstart.pezops.github.io/content-hash"
+
+// contentHash returns the hex-encoded SHA-256 hash of the keys and values of a ConfigMap or
+// Secret. Keys are hashed in sorted order, so the hash does not depend on the order of the maps.
+func contentHash(data map[string]string, binaryData map[string][]byte) string {
+	h := sha256.New()
+	for _, key := range sets.List(sets.KeySet(data).Union(sets.KeySet(binaryData))) {
+		value, ok := binaryData[key]
+		if !ok {
+			value = []byte(data[key])
+		}
+		// Each key and value is terminated by a NUL byte, so different keys and values cannot
+		// produce the same input.
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write(value)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// configMapContentHash returns the content hash of the data and binary data of a ConfigMap.
+func configMapContentHash(cm *corev1.ConfigMap) string {
+	return contentHash(cm.Data, cm.BinaryData)
+}
+
+// secretContentHash returns the content hash of the data of a Secret.
+func secretContentHash(s *corev1.Secret) string {
+	return contentHash(nil, s.Data)
+}
+
+// hasContentHash reports whether a resource has the content hash annotation, which is then kept up
+// to date when its values change.
+func hasContentHash(annotations map[string]string) bool {
+	_, ok := annotations[contentHashAnnotation]
+	return ok
+}
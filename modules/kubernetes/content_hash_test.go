@@ -0,0 +1,28 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestContentHash(t *testing.T) {
+	empty := contentHash(nil, nil)
+	assert.Len(t, empty, 64)
+	assert.Equal(t, empty, contentHash(map[string]string{}, map[string][]byte{}))
+
+	hash := contentHash(map[string]string{"a": "1", "b": "2"}, nil)
+	assert.Equal(t, hash, contentHash(map[string]string{"b": "2", "a": "1"}, nil))
+	assert.NotEqual(t, hash, contentHash(map[string]string{"a": "1", "b": "3"}, nil))
+	assert.NotEqual(t, hash, contentHash(map[string]string{"a": "1b", "": "2"}, nil))
+
+	// The hash does not depend on whether a value is stored in the data or the binary data.
+	assert.Equal(t, hash, contentHash(map[string]string{"a": "1"}, map[string][]byte{"b": []byte("2")}))
+	assert.Equal(
+		t,
+		hash,
+		secretContentHash(&corev1.Secret{Data: map[string][]byte{"a": []byte("1"), "b": []byte("2")}}),
+	)
+	assert.Equal(t, hash, configMapContentHash(&corev1.ConfigMap{Data: map[string]string{"a": "1", "b": "2"}}))
+}
@@ -0,0 +1,418 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("kubernetes_crd", NewCRDModule)
+}
+
+var _ blackstart.Module = &crdModule{}
+
+func NewCRDModule() blackstart.Module {
+	return &crdModule{}
+}
+
+// crdPath is the API path of CustomResourceDefinitions. The Kubernetes clients of modules only
+// include the built-in API groups, so CustomResourceDefinitions are read and applied with the REST
+// client of the Kubernetes client.
+const crdPath = "/apis/apiextensions.k8s.io/v1/customresourcedefinitions"
+
+// kubeVersionPattern matches Kubernetes API versions, such as v1, v2beta1, or v1alpha3.
+var kubeVersionPattern = regexp.MustCompile(`^v[1-9][0-9]*((alpha|beta)[1-9][0-9]*)?$`)
+
+type crdModule struct{}
+
+func (c *crdModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_crd",
+		Name: "Kubernetes CustomResourceDefinition",
+		Description: util.CleanString(
+			`
+Ensures a Kubernetes CustomResourceDefinition (CRD) is established and serves a minimum API version.
+Workflows can use it to wait for the APIs of third-party operators before creating their custom
+resources, and optionally to install the CRD from a manifest.
+
+**Notes**
+
+- Versions are compared in Kubernetes version order, where '''v1''' is newer than '''v1beta2''', which
+  is newer than '''v1alpha1'''. The CRD must serve '''min_version''' or a newer version.
+- Without '''manifest''', the module does not change the cluster. Set waits for the CRD to be
+  installed, such as by an operator, for up to the propagation timeout.
+- With '''manifest''', the CRD is applied with server-side apply when it is missing, does not serve
+  '''min_version''', or does not serve the versions of the manifest.
+- With '''doesNotExist''', the CRD is deleted, which also deletes all of its custom resources. A CRD
+  is only deleted when '''manifest''' is set. With '''wait_for_deletion''', Set waits until the CRD and
+  its custom resources are removed.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized for CustomResourceDefinition operations.",
+			"Required CustomResourceDefinition verbs: `get`, and `patch` and `delete` when `manifest` is set.",
+		},
+		Inputs: withDeletionInputs(
+			"CustomResourceDefinition", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the CustomResourceDefinition, such as `certificates.cert-manager.io`",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputMinVersion: {
+					Description: "Minimum API version the CustomResourceDefinition must serve, such as `v1`. Any served version is accepted when not set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputManifest: {
+					Description: "CustomResourceDefinition manifest in YAML or JSON to apply when the CustomResourceDefinition is missing or outdated",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputCRD: {
+				Description: "Name of the CustomResourceDefinition",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputAPIVersion: {
+				Description: "Group and newest served version of the CustomResourceDefinition, such as `cert-manager.io/v1`, for the `apiVersion` of custom resources",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Wait for cert-manager": `id: cert-manager-crds
+module: kubernetes_crd
+inputs:
+  name: certificates.cert-manager.io
+  min_version: v1`,
+			"Install a CRD": `id: backup-crd
+module: kubernetes_crd
+inputs:
+  name: backups.example.com
+  min_version: v1
+  manifest:
+    fromFile:
+      path: /etc/blackstart/crds/backups.yaml`,
+		},
+	}
+}
+
+func (c *crdModule) Validate(op blackstart.Operation) error {
+	nameInput, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputName)
+	}
+	var name string
+	if nameInput.IsStatic() {
+		var err error
+		name, err = blackstart.InputAs[string](nameInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputName, err)
+		}
+	}
+
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+
+	if versionInput, ok := op.Inputs[inputMinVersion]; ok && versionInput.IsStatic() {
+		minVersion, err := blackstart.InputAs[string](versionInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputMinVersion, err)
+		}
+		if err = validateKubeVersion(minVersion); err != nil {
+			return err
+		}
+	}
+
+	if manifestInput, ok := op.Inputs[inputManifest]; ok && manifestInput.IsStatic() {
+		manifest, err := blackstart.InputAs[string](manifestInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputManifest, err)
+		}
+		// The name of the manifest can only be compared when both inputs are static.
+		if _, _, err = parseCRDManifest(manifest, name); err != nil {
+			return err
+		}
+	}
+
+	return validateClientInputs(op)
+}
+
+func (c *crdModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	desired, err := contextCRD(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(desired.name)
+
+	rc, err := contextRESTClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	existing, err := getCRD(ctx, rc, desired.name)
+	if apierrors.IsNotFound(err) {
+		return ctx.DoesNotExist(), nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return false, nil
+	}
+
+	apiVersion, served := crdServed(existing, desired.minVersion)
+	if !served || (desired.manifest != nil && !crdManifestServed(existing, desired.manifest)) {
+		return false, nil
+	}
+	return true, crdOutputs(ctx, existing.Name, apiVersion)
+}
+
+func (c *crdModule) Set(ctx blackstart.ModuleContext) error {
+	desired, err := contextCRD(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Resource(desired.name)
+
+	rc, err := contextRESTClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if desired.manifest == nil {
+			return fmt.Errorf(
+				"CustomResourceDefinition %s is only deleted when input '%s' is set", desired.name, inputManifest,
+			)
+		}
+		err = rc.Delete().AbsPath(crdPath, desired.name).Do(ctx).Error()
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete CustomResourceDefinition %s: %w", desired.name, err)
+		}
+		return waitForDeletion(
+			ctx, fmt.Sprintf("CustomResourceDefinition %s", desired.name), func(c context.Context) (metav1.Object, error) {
+				return getCRD(c, rc, desired.name)
+			},
+		)
+	}
+
+	if desired.manifest != nil {
+		err = rc.Patch(types.ApplyPatchType).
+			AbsPath(crdPath, desired.name).
+			Param("fieldManager", fieldManager).
+			Param("force", "true").
+			Body(desired.manifestJSON).
+			Do(ctx).
+			Error()
+		if err != nil {
+			return fmt.Errorf("failed to apply CustomResourceDefinition %s: %w", desired.name, err)
+		}
+	}
+
+	// A new CustomResourceDefinition is established by the API server shortly after it is created,
+	// and one installed by an operator may not exist yet.
+	var apiVersion string
+	err = blackstart.WaitForPropagation(
+		ctx, "CustomResourceDefinition "+desired.name, func() (bool, error) {
+			existing, getErr := getCRD(ctx, rc, desired.name)
+			if apierrors.IsNotFound(getErr) {
+				return false, nil
+			}
+			if getErr != nil {
+				return false, getErr
+			}
+			var served bool
+			apiVersion, served = crdServed(existing, desired.minVersion)
+			return served, nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return crdOutputs(ctx, desired.name, apiVersion)
+}
+
+// desiredCRD is the CustomResourceDefinition described by the inputs of the module.
+type desiredCRD struct {
+	name         string
+	minVersion   string
+	manifest     *apiextensionsv1.CustomResourceDefinition
+	manifestJSON []byte
+}
+
+// contextCRD returns the CustomResourceDefinition described by the inputs of the module.
+func contextCRD(ctx blackstart.ModuleContext) (*desiredCRD, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, err
+	}
+	minVersion, err := blackstart.ContextInputAs[string](ctx, inputMinVersion, false)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateKubeVersion(minVersion); err != nil {
+		return nil, err
+	}
+	desired := &desiredCRD{name: name, minVersion: minVersion}
+
+	manifest, err := blackstart.ContextInputAs[string](ctx, inputManifest, false)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != "" {
+		desired.manifest, desired.manifestJSON, err = parseCRDManifest(manifest, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return desired, nil
+}
+
+// contextRESTClient returns the REST client of the Kubernetes client of the module, which is used
+// for API groups that are not included in the client.
+func contextRESTClient(ctx blackstart.ModuleContext) (rest.Interface, error) {
+	cc, err := contextClient(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	rc := cc.Discovery().RESTClient()
+	if rc == nil || reflect.ValueOf(rc).IsNil() {
+		return nil, fmt.Errorf("the Kubernetes client does not provide a REST client")
+	}
+	return rc, nil
+}
+
+// getCRD returns the CustomResourceDefinition with the name.
+func getCRD(ctx context.Context, rc rest.Interface, name string) (*apiextensionsv1.CustomResourceDefinition, error) {
+	body, err := rc.Get().AbsPath(crdPath, name).DoRaw(ctx)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get CustomResourceDefinition %s: %w", name, err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err = json.Unmarshal(body, crd); err != nil {
+		return nil, fmt.Errorf("failed to decode CustomResourceDefinition %s: %w", name, err)
+	}
+	return crd, nil
+}
+
+// parseCRDManifest parses a CustomResourceDefinition manifest in YAML or JSON and returns it with
+// its JSON encoding. When name is set, the manifest must be for the CustomResourceDefinition with
+// the name.
+func parseCRDManifest(manifest, name string) (*apiextensionsv1.CustomResourceDefinition, []byte, error) {
+	manifestJSON, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		return nil, nil, fmt.Errorf("input '%s' is not valid YAML or JSON: %w", inputManifest, err)
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err = json.Unmarshal(manifestJSON, crd); err != nil {
+		return nil, nil, fmt.Errorf("input '%s' is not a CustomResourceDefinition: %w", inputManifest, err)
+	}
+	gvk := apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition")
+	if crd.GroupVersionKind() != gvk {
+		return nil, nil, fmt.Errorf(
+			"input '%s' must have apiVersion %s and kind %s", inputManifest, gvk.GroupVersion(), gvk.Kind,
+		)
+	}
+	if name != "" && crd.Name != name {
+		return nil, nil, fmt.Errorf(
+			"input '%s' is for CustomResourceDefinition %q, not %q", inputManifest, crd.Name, name,
+		)
+	}
+	return crd, manifestJSON, nil
+}
+
+// validateKubeVersion returns an error when the version is set and is not a Kubernetes API version.
+func validateKubeVersion(v string) error {
+	if v != "" && !kubeVersionPattern.MatchString(v) {
+		return fmt.Errorf("input '%s' must be a Kubernetes API version, such as v1 or v1beta1: %s", inputMinVersion, v)
+	}
+	return nil
+}
+
+// crdServed reports whether the CustomResourceDefinition is established and serves the minimum
+// version or a newer version. The API version of the newest served version is returned.
+func crdServed(crd *apiextensionsv1.CustomResourceDefinition, minVersion string) (string, bool) {
+	if !crdEstablished(crd) {
+		return "", false
+	}
+	newest := ""
+	for _, v := range crd.Spec.Versions {
+		if v.Served && (newest == "" || version.CompareKubeAwareVersionStrings(v.Name, newest) > 0) {
+			newest = v.Name
+		}
+	}
+	if newest == "" || (minVersion != "" && version.CompareKubeAwareVersionStrings(newest, minVersion) < 0) {
+		return "", false
+	}
+	return crd.Spec.Group + "/" + newest, true
+}
+
+// crdEstablished reports whether the API server serves the resources of the
+// CustomResourceDefinition.
+func crdEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// crdManifestServed reports whether the existing CustomResourceDefinition has the versions of the
+// manifest, and serves the same versions as the manifest.
+func crdManifestServed(existing, manifest *apiextensionsv1.CustomResourceDefinition) bool {
+	served := make(map[string]bool, len(existing.Spec.Versions))
+	for _, v := range existing.Spec.Versions {
+		served[v.Name] = v.Served
+	}
+	for _, v := range manifest.Spec.Versions {
+		existingServed, ok := served[v.Name]
+		if !ok || existingServed != v.Served {
+			return false
+		}
+	}
+	return true
+}
+
+// crdOutputs sets the outputs of the module for the CustomResourceDefinition.
+func crdOutputs(ctx blackstart.ModuleContext, name, apiVersion string) error {
+	if err := ctx.Output(outputCRD, name); err != nil {
+		return err
+	}
+	return ctx.Output(outputAPIVersion, apiVersion)
+}
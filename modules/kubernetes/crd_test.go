@@ -0,0 +1,326 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pezops/blackstart"
+)
+
+const testCRDManifest = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: backups.example.com
+spec:
+  group: example.com
+  names:
+    kind: Backup
+    plural: backups
+  scope: Namespaced
+  versions:
+    - name: v1beta1
+      served: true
+      storage: false
+    - name: v1
+      served: true
+      storage: true
+`
+
+// fakeCRDServer serves the CustomResourceDefinition API used by the CRD module.
+type fakeCRDServer struct {
+	t       *testing.T
+	crds    map[string]*apiextensionsv1.CustomResourceDefinition
+	applies []string
+	mu      sync.Mutex
+}
+
+// newFakeCRDClient starts a fake CustomResourceDefinition API and returns a client connected to it.
+func newFakeCRDClient(t *testing.T) (*fakeCRDServer, kubernetes.Interface) {
+	t.Helper()
+	f := &fakeCRDServer{t: t, crds: map[string]*apiextensionsv1.CustomResourceDefinition{}}
+	server := httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(server.Close)
+	cc, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	require.NoError(t, err)
+	return f, cc
+}
+
+// serveHTTP handles requests for CustomResourceDefinitions. Applied CustomResourceDefinitions are
+// established right away.
+func (f *fakeCRDServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	name, ok := strings.CutPrefix(r.URL.Path, crdPath+"/")
+	if !ok {
+		f.t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		http.Error(w, "unexpected request", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		crd, ok := f.crds[name]
+		if !ok {
+			f.writeNotFound(w, name)
+			return
+		}
+		f.writeJSON(w, crd)
+	case http.MethodPatch:
+		require.Equal(f.t, "application/apply-patch+yaml", r.Header.Get("Content-Type"))
+		require.Equal(f.t, fieldManager, r.URL.Query().Get("fieldManager"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(f.t, err)
+		crd := &apiextensionsv1.CustomResourceDefinition{}
+		require.NoError(f.t, yaml.Unmarshal(body, crd))
+		crd.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{
+			{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+		}
+		f.crds[name] = crd
+		f.applies = append(f.applies, name)
+		f.writeJSON(w, crd)
+	case http.MethodDelete:
+		if _, ok = f.crds[name]; !ok {
+			f.writeNotFound(w, name)
+			return
+		}
+		delete(f.crds, name)
+		f.writeJSON(w, map[string]string{"kind": "Status", "apiVersion": "v1", "status": "Success"})
+	default:
+		f.t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		http.Error(w, "unexpected request", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeNotFound writes the not found status of a CustomResourceDefinition.
+func (f *fakeCRDServer) writeNotFound(w http.ResponseWriter, name string) {
+	status := apierrors.NewNotFound(
+		schema.GroupResource{Group: "apiextensions.k8s.io", Resource: "customresourcedefinitions"}, name,
+	).ErrStatus
+	status.Kind = "Status"
+	status.APIVersion = "v1"
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	require.NoError(f.t, json.NewEncoder(w).Encode(status))
+}
+
+// writeJSON writes a JSON response.
+func (f *fakeCRDServer) writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	require.NoError(f.t, json.NewEncoder(w).Encode(value))
+}
+
+// install adds an established CustomResourceDefinition serving the versions, as an operator would.
+func (f *fakeCRDServer) install(name, group string, versions ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{Group: group},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{
+			Conditions: []apiextensionsv1.CustomResourceDefinitionCondition{
+				{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+			},
+		},
+	}
+	crd.Name = name
+	for _, v := range versions {
+		crd.Spec.Versions = append(crd.Spec.Versions, apiextensionsv1.CustomResourceDefinitionVersion{Name: v, Served: true})
+	}
+	f.crds[name] = crd
+}
+
+// shortPropagationContext returns a module context that does not wait for changes to propagate.
+func shortPropagationContext(inputs map[string]blackstart.Input) *capturingModuleContext {
+	ctx := context.WithValue(
+		context.Background(), blackstart.ConfigKey, &blackstart.RuntimeConfig{PropagationTimeout: time.Millisecond},
+	)
+	return &capturingModuleContext{ModuleContext: blackstart.InputsToContext(ctx, inputs)}
+}
+
+func TestCRDModule_Validate(t *testing.T) {
+	module := NewCRDModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "valid inputs",
+			inputs: map[string]blackstart.Input{
+				inputName:       blackstart.NewInputFromValue("backups.example.com"),
+				inputMinVersion: blackstart.NewInputFromValue("v1beta1"),
+				inputManifest:   blackstart.NewInputFromValue(testCRDManifest),
+			},
+		},
+		{
+			name: "missing name",
+			inputs: map[string]blackstart.Input{
+				inputMinVersion: blackstart.NewInputFromValue("v1"),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid version",
+			inputs: map[string]blackstart.Input{
+				inputName:       blackstart.NewInputFromValue("backups.example.com"),
+				inputMinVersion: blackstart.NewInputFromValue("1.0"),
+			},
+			expectError: true,
+		},
+		{
+			name: "manifest for another CRD",
+			inputs: map[string]blackstart.Input{
+				inputName:     blackstart.NewInputFromValue("restores.example.com"),
+				inputManifest: blackstart.NewInputFromValue(testCRDManifest),
+			},
+			expectError: true,
+		},
+		{
+			name: "manifest of another kind",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("backups.example.com"),
+				inputManifest: blackstart.NewInputFromValue(
+					"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: backups.example.com\n",
+				),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(blackstart.Operation{Module: "kubernetes_crd", Id: "test", Inputs: test.inputs})
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestCRDModule_Gate(t *testing.T) {
+	api, clientset := newFakeCRDClient(t)
+	module := NewCRDModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:     blackstart.NewInputFromValue(clientset),
+		inputName:       blackstart.NewInputFromValue("certificates.cert-manager.io"),
+		inputMinVersion: blackstart.NewInputFromValue("v1"),
+	}
+
+	// Without a manifest, Set waits for the CRD and fails when it is not installed.
+	ctx := shortPropagationContext(inputs)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.ErrorIs(t, module.Set(ctx), blackstart.ErrNotPropagated)
+
+	// A CRD serving only an older version does not pass the gate.
+	api.install("certificates.cert-manager.io", "cert-manager.io", "v1alpha2", "v1beta1")
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.ErrorIs(t, module.Set(ctx), blackstart.ErrNotPropagated)
+
+	api.install("certificates.cert-manager.io", "cert-manager.io", "v1beta1", "v1")
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "cert-manager.io/v1", ctx.outputs[outputAPIVersion])
+	assert.Equal(t, "certificates.cert-manager.io", ctx.outputs[outputCRD])
+	assert.Empty(t, api.applies)
+
+	// CRDs are not deleted without a manifest.
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.ErrorContains(t, module.Set(dneCtx), "only deleted when input 'manifest' is set")
+}
+
+func TestCRDModule_Manifest(t *testing.T) {
+	api, clientset := newFakeCRDClient(t)
+	module := NewCRDModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:     blackstart.NewInputFromValue(clientset),
+		inputName:       blackstart.NewInputFromValue("backups.example.com"),
+		inputMinVersion: blackstart.NewInputFromValue("v1"),
+		inputManifest:   blackstart.NewInputFromValue(testCRDManifest),
+	}
+
+	ctx := shortPropagationContext(inputs)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, []string{"backups.example.com"}, api.applies)
+	assert.Equal(t, "example.com/v1", ctx.outputs[outputAPIVersion])
+
+	ok, err = module.Check(shortPropagationContext(inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// A CRD that no longer serves a version of the manifest is applied again.
+	api.install("backups.example.com", "example.com", "v1")
+	ctx = shortPropagationContext(inputs)
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(ctx))
+	assert.Len(t, api.applies, 2)
+	assert.Len(t, api.crds["backups.example.com"].Spec.Versions, 2)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	assert.Empty(t, api.crds)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestCRDServed(t *testing.T) {
+	crd := &apiextensionsv1.CustomResourceDefinition{
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Served: true},
+				{Name: "v1beta2", Served: true},
+				{Name: "v1", Served: false},
+			},
+		},
+	}
+
+	_, served := crdServed(crd, "")
+	assert.False(t, served, "a CRD that is not established is not served")
+
+	crd.Status.Conditions = []apiextensionsv1.CustomResourceDefinitionCondition{
+		{Type: apiextensionsv1.Established, Status: apiextensionsv1.ConditionTrue},
+	}
+	apiVersion, served := crdServed(crd, "v1beta1")
+	assert.True(t, served)
+	assert.Equal(t, "example.com/v1beta2", apiVersion)
+
+	_, served = crdServed(crd, "v1")
+	assert.False(t, served, "v1 is not served")
+}
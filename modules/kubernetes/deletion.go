@@ -0,0 +1,120 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/pezops/blackstart"
+)
+
+// deletionPollInterval is the interval at which a deleted resource is checked until it is gone.
+var deletionPollInterval = time.Second
+
+// withDeletionInputs adds the inputs that control waiting for the deletion of a resource with
+// doesNotExist to the inputs of a module.
+func withDeletionInputs(kind string, inputs map[string]blackstart.InputValue) map[string]blackstart.InputValue {
+	inputs[inputWaitForDeletion] = blackstart.InputValue{
+		Description: fmt.Sprintf(
+			"With `doesNotExist`, wait until the %s is removed, such as while finalizers run, before the operation completes.",
+			kind,
+		),
+		Type:     reflect.TypeFor[bool](),
+		Required: false,
+		Default:  false,
+	}
+	inputs[inputDeletionTimeout] = blackstart.InputValue{
+		Description: "Maximum time to wait for the deletion with `wait_for_deletion`, such as `5m`. Defaults to the propagation timeout.",
+		Type:        reflect.TypeFor[string](),
+		Required:    false,
+	}
+	return inputs
+}
+
+// validateDeletionInputs validates the static deletion timeout input of an operation.
+func validateDeletionInputs(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputDeletionTimeout]
+	if !ok || !input.IsStatic() {
+		return nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return fmt.Errorf("input '%s' is invalid: %w", inputDeletionTimeout, err)
+	}
+	_, err = parseDeletionTimeout(value)
+	return err
+}
+
+// parseDeletionTimeout parses a deletion timeout input. An empty value is a zero timeout, which
+// means the propagation timeout is used.
+func parseDeletionTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("input '%s' is invalid: %w", inputDeletionTimeout, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("input '%s' must be positive", inputDeletionTimeout)
+	}
+	return timeout, nil
+}
+
+// waitForDeletion waits until a deleted resource is gone when the wait_for_deletion input is set.
+// Get returns the resource, or a not found error once it is gone. When the resource still exists
+// after the timeout, the error lists its remaining finalizers.
+func waitForDeletion(
+	ctx blackstart.ModuleContext, description string, get func(context.Context) (metav1.Object, error),
+) error {
+	enabled, err := blackstart.ContextInputAs[bool](ctx, inputWaitForDeletion, false)
+	if err != nil || !enabled {
+		return err
+	}
+	value, err := blackstart.ContextInputAs[string](ctx, inputDeletionTimeout, false)
+	if err != nil {
+		return err
+	}
+	timeout, err := parseDeletionTimeout(value)
+	if err != nil {
+		return err
+	}
+	if timeout == 0 {
+		timeout = blackstart.PropagationTimeout(ctx)
+	}
+
+	var finalizers []string
+	err = wait.PollUntilContextTimeout(
+		ctx, deletionPollInterval, timeout, true, func(pollCtx context.Context) (bool, error) {
+			obj, getErr := get(pollCtx)
+			if apierrors.IsNotFound(getErr) {
+				return true, nil
+			}
+			if getErr != nil {
+				return false, getErr
+			}
+			finalizers = obj.GetFinalizers()
+			ctx.Logger().Debug("waiting for deletion", "resource", description, "finalizers", finalizers)
+			return false, nil
+		},
+	)
+	if err == nil {
+		return nil
+	}
+	if !wait.Interrupted(err) {
+		return fmt.Errorf("failed waiting for deletion of %s: %w", description, err)
+	}
+	if len(finalizers) > 0 {
+		return fmt.Errorf(
+			"%s was not deleted after %s, remaining finalizers: %s", description, timeout, strings.Join(finalizers, ", "),
+		)
+	}
+	return fmt.Errorf("%s was not deleted after %s", description, timeout)
+}
@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestValidateDeletionInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout any
+		wantErr string
+	}{
+		{name: "duration", timeout: "30s"},
+		{name: "empty", timeout: ""},
+		{name: "invalid", timeout: "soon", wantErr: "is invalid"},
+		{name: "negative", timeout: "-1m", wantErr: "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				op := blackstart.Operation{
+					Inputs: map[string]blackstart.Input{
+						inputDeletionTimeout: blackstart.NewInputFromValue(tt.timeout),
+					},
+				}
+				err := validateDeletionInputs(op)
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+}
+
+func TestConfigMapModule_WaitForDeletion(t *testing.T) {
+	previous := deletionPollInterval
+	deletionPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { deletionPollInterval = previous })
+
+	clientset := fake.NewClientset(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "app-config",
+				Namespace:  "test-namespace",
+				Finalizers: []string{"example.com/cleanup"},
+			},
+		},
+	)
+	// The fake clientset removes objects immediately, so deletes are ignored to leave the ConfigMap
+	// terminating until its finalizer is removed.
+	clientset.PrependReactor(
+		"delete", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, nil
+		},
+	)
+	module := NewConfigMapModule()
+	inputs := func(wait bool) map[string]blackstart.Input {
+		return map[string]blackstart.Input{
+			inputClient:          blackstart.NewInputFromValue(clientset),
+			inputName:            blackstart.NewInputFromValue("app-config"),
+			inputNamespace:       blackstart.NewInputFromValue("test-namespace"),
+			inputWaitForDeletion: blackstart.NewInputFromValue(wait),
+			inputDeletionTimeout: blackstart.NewInputFromValue("100ms"),
+		}
+	}
+
+	// Without waiting, Set returns while the ConfigMap still exists.
+	require.NoError(t, module.Set(
+		blackstart.InputsToContext(context.Background(), inputs(false), blackstart.DoesNotExistFlag),
+	))
+	ok, err := module.Check(
+		blackstart.InputsToContext(context.Background(), inputs(false), blackstart.DoesNotExistFlag),
+	)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// The wait times out and reports the finalizer that blocks the deletion.
+	err = module.Set(blackstart.InputsToContext(context.Background(), inputs(true), blackstart.DoesNotExistFlag))
+	require.ErrorContains(t, err, "ConfigMap test-namespace/app-config was not deleted after 100ms")
+	require.ErrorContains(t, err, "remaining finalizers: example.com/cleanup")
+
+	// The wait completes once the ConfigMap is removed.
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = clientset.Tracker().Delete(
+			corev1.SchemeGroupVersion.WithResource("configmaps"), "test-namespace", "app-config",
+		)
+	}()
+	require.NoError(t, module.Set(
+		blackstart.InputsToContext(context.Background(), inputs(true), blackstart.DoesNotExistFlag),
+	))
+	_, err = clientset.CoreV1().ConfigMaps("test-namespace").Get(
+		context.Background(), "app-config", metav1.GetOptions{},
+	)
+	assert.True(t, apierrors.IsNotFound(err))
+}
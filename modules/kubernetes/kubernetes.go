@@ -1,24 +1,86 @@
 package kubernetes
 
-import "github.com/pezops/blackstart/util"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
 
 const (
-	inputName         = "name"
-	inputNamespace    = "namespace"
-	inputKey          = "key"
-	inputValue        = "value"
-	inputClient       = "client"
-	inputConfigMap    = "configmap"
-	inputSecret       = "secret"
-	inputImmutable    = "immutable"
-	inputType         = "type"
-	inputContext      = "context"
-	inputUpdatePolicy = "update_policy"
-
-	outputConfigMap = "configmap"
-	outputSecret    = "secret"
-	outputClient    = "client"
-	outputValue     = "value"
+	inputName             = "name"
+	inputNamespace        = "namespace"
+	inputKey              = "key"
+	inputKeys             = "keys"
+	inputValue            = "value"
+	inputClient           = "client"
+	inputImpersonate      = "impersonate"
+	inputConfigMap        = "configmap"
+	inputSecret           = "secret"
+	inputImmutable        = "immutable"
+	inputType             = "type"
+	inputContext          = "context"
+	inputUpdatePolicy     = "update_policy"
+	inputSelector         = "selector"
+	inputEffect           = "effect"
+	inputPreemptionPolicy = "preemption_policy"
+	inputGlobalDefault    = "global_default"
+	inputDescription      = "description"
+	inputMinAvailable     = "min_available"
+	inputMaxUnavailable   = "max_unavailable"
+	inputMinVersion       = "min_version"
+	inputManifest         = "manifest"
+	inputConflictPolicy   = "conflict_policy"
+	inputPath             = "path"
+	inputEncoding         = "encoding"
+	inputContentHash      = "content_hash"
+	inputWaitForDeletion  = "wait_for_deletion"
+	inputDeletionTimeout  = "deletion_timeout"
+	inputPolicy           = "policy"
+	inputSpec             = "spec"
+	inputHard             = "hard"
+	inputScopes           = "scopes"
+	inputDefault          = "default"
+	inputDefaultRequest   = "default_request"
+	inputMax              = "max"
+	inputMin              = "min"
+	inputProvisioner      = "provisioner"
+	inputParameters       = "parameters"
+	inputReclaimPolicy    = "reclaim_policy"
+	inputBindingMode      = "volume_binding_mode"
+	inputVolumeExpansion  = "allow_volume_expansion"
+	inputDefaultClass     = "default_class"
+	inputCreate           = "create"
+	inputStorageClass     = "storage_class"
+	inputCapacity         = "capacity"
+	inputCount            = "count"
+	inputAccessModes      = "access_modes"
+
+	outputConfigMap           = "configmap"
+	outputSecret              = "secret"
+	outputClient              = "client"
+	outputValue               = "value"
+	outputValues              = "values"
+	outputNodes               = "nodes"
+	outputPriorityClass       = "priority_class"
+	outputPodDisruptionBudget = "pod_disruption_budget"
+	outputCRD                 = "crd"
+	outputAPIVersion          = "api_version"
+	outputNetworkPolicy       = "network_policy"
+	outputResourceQuota       = "resource_quota"
+	outputLimitRange          = "limit_range"
+	outputStorageClass        = "storage_class"
+	outputPersistentVolumes   = "persistent_volumes"
 )
 
 const (
@@ -35,6 +97,16 @@ var updatePolicies = map[string]string{
 	updatePolicyFail:        updatePolicyFail,
 }
 
+const (
+	valueEncodingText   = "text"
+	valueEncodingBase64 = "base64"
+)
+
+var valueEncodings = map[string]string{
+	valueEncodingText:   valueEncodingText,
+	valueEncodingBase64: valueEncodingBase64,
+}
+
 var updatePolicyDocs = util.CleanString(
 	`
 **Update Policies**
@@ -49,3 +121,173 @@ and Secrets. The following update policies are supported:
 
 `,
 )
+
+// contextNamespace returns the namespace input of a module. When the input is not set, the default
+// namespace from the runtime configuration is used.
+func contextNamespace(ctx blackstart.ModuleContext) (string, error) {
+	namespace, err := blackstart.ContextInputAs[string](ctx, inputNamespace, false)
+	if err != nil {
+		return "", err
+	}
+	if namespace != "" {
+		return namespace, nil
+	}
+	return blackstart.DefaultK8sNamespace(ctx)
+}
+
+// validateClientInputs validates the client and impersonate inputs of a module. Both are optional,
+// but the impersonate input only applies to the client provided by the runtime.
+func validateClientInputs(op blackstart.Operation) error {
+	_, hasClient := op.Inputs[inputClient]
+	_, hasImpersonate := op.Inputs[inputImpersonate]
+	if hasClient && hasImpersonate {
+		return fmt.Errorf("input '%s' cannot be used with input '%s'", inputImpersonate, inputClient)
+	}
+	return nil
+}
+
+// contextClient returns the Kubernetes client of a module for the namespace. The client input is
+// used when it is set, for workflows that wire a client from the kubernetes_client module.
+// Otherwise, the client is provided by the runtime, impersonating the impersonate input if set.
+func contextClient(ctx blackstart.ModuleContext, namespace string) (kubernetes.Interface, error) {
+	if clientInput, err := ctx.Input(inputClient); err == nil && clientInput.Any() != nil {
+		cc, ok := clientInput.Any().(kubernetes.Interface)
+		if !ok {
+			return nil, fmt.Errorf("client input is not a Kubernetes clientset")
+		}
+		return cc, nil
+	}
+
+	impersonate, err := blackstart.ContextInputAs[string](ctx, inputImpersonate, false)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := ctx.GetKubeClient(namespace, impersonate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kubernetes client: %w", err)
+	}
+	return cc, nil
+}
+
+// validateSelectorInput validates the label selector input of a module when it is static.
+func validateSelectorInput(op blackstart.Operation) error {
+	selectorInput, ok := op.Inputs[inputSelector]
+	if !ok || !selectorInput.IsStatic() {
+		return nil
+	}
+	selector, err := blackstart.InputAs[string](selectorInput, false)
+	if err != nil {
+		return fmt.Errorf("input '%s' is invalid: %w", inputSelector, err)
+	}
+	if _, err = labels.Parse(selector); err != nil {
+		return fmt.Errorf("input '%s' is not a valid label selector: %w", inputSelector, err)
+	}
+	return nil
+}
+
+// inputResourceList returns the resource quantities of a map input by resource name, such as
+// `cpu: 500m` or `pods: 10`.
+func inputResourceList(input blackstart.Input, key string) (corev1.ResourceList, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("input '%s' is invalid: %w", key, err)
+	}
+	list := make(corev1.ResourceList, len(raw))
+	for name, value := range raw {
+		switch value.(type) {
+		case string, int, int64, float64:
+		default:
+			return nil, fmt.Errorf("input '%s' has invalid quantity for %s: %v", key, name, value)
+		}
+		quantity, qErr := resource.ParseQuantity(fmt.Sprint(value))
+		if qErr != nil {
+			return nil, fmt.Errorf("input '%s' has invalid quantity for %s: %w", key, name, qErr)
+		}
+		list[corev1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
+
+// contextResourceList returns the resource quantities of a map input, or nil when it is not set.
+func contextResourceList(ctx blackstart.ModuleContext, key string) (corev1.ResourceList, error) {
+	input, err := ctx.Input(key)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	return inputResourceList(input, key)
+}
+
+// contextNodes returns the nodes matching the selector input of a module, or all nodes when the
+// selector is not set, with the client for cluster-scoped resources.
+func contextNodes(ctx blackstart.ModuleContext) (kubernetes.Interface, []corev1.Node, error) {
+	selector, err := blackstart.ContextInputAs[string](ctx, inputSelector, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err = labels.Parse(selector); err != nil {
+		return nil, nil, fmt.Errorf("input '%s' is not a valid label selector: %w", inputSelector, err)
+	}
+
+	cc, err := contextClient(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes, err := cc.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	return cc, nodes.Items, nil
+}
+
+// outputNodeNames emits the names of the nodes of a node module.
+func outputNodeNames(ctx blackstart.ModuleContext, nodes []corev1.Node) error {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return ctx.Output(outputNodes, names)
+}
+
+// contextIntOrString returns an optional integer or percentage input of a module, or nil when the
+// input is not set.
+func contextIntOrString(ctx blackstart.ModuleContext, key string) (*intstr.IntOrString, error) {
+	input, err := ctx.Input(key)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	return parseIntOrString(key, input.Any())
+}
+
+// parseIntOrString parses a non-negative integer, or a percentage such as "50%", of an input. A
+// string holding an integer is parsed as the integer.
+func parseIntOrString(key string, value any) (*intstr.IntOrString, error) {
+	var s string
+	switch v := value.(type) {
+	case int:
+		s = strconv.Itoa(v)
+	case float64:
+		// Numbers of workflows decoded from JSON are float64.
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("input '%s' must be a non-negative integer or a percentage: %v", key, v)
+		}
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return nil, fmt.Errorf("input '%s' must be an integer or a percentage, got %T", key, value)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+	if err != nil || n < 0 || n > math.MaxInt32 {
+		return nil, fmt.Errorf("input '%s' must be a non-negative integer or a percentage: %s", key, s)
+	}
+	if strings.HasSuffix(s, "%") {
+		if n > 100 {
+			return nil, fmt.Errorf("input '%s' must be a percentage between 0%% and 100%%: %s", key, s)
+		}
+		result := intstr.FromString(strconv.Itoa(n) + "%")
+		return &result, nil
+	}
+	result := intstr.FromInt32(int32(n))
+	return &result, nil
+}
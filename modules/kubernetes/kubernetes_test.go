@@ -0,0 +1,184 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestContextNamespace(t *testing.T) {
+	runtimeCtx := context.WithValue(
+		context.Background(),
+		blackstart.ConfigKey,
+		&blackstart.RuntimeConfig{DefaultNamespaceFromRuntime: true, RuntimeNamespace: "team-a"},
+	)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		inputs   map[string]blackstart.Input
+		expected string
+	}{
+		{
+			name:     "default namespace",
+			ctx:      context.Background(),
+			inputs:   map[string]blackstart.Input{},
+			expected: "default",
+		},
+		{
+			name:     "runtime namespace",
+			ctx:      runtimeCtx,
+			inputs:   map[string]blackstart.Input{},
+			expected: "team-a",
+		},
+		{
+			name:     "explicit namespace",
+			ctx:      runtimeCtx,
+			inputs:   map[string]blackstart.Input{inputNamespace: blackstart.NewInputFromValue("team-b")},
+			expected: "team-b",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				ns, err := contextNamespace(blackstart.InputsToContext(tt.ctx, tt.inputs))
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, ns)
+			},
+		)
+	}
+}
+
+// testKubeClientProvider provides a fake client for one namespace and records the impersonated
+// identity of the last request.
+type testKubeClientProvider struct {
+	namespace   string
+	client      kubernetes.Interface
+	impersonate string
+}
+
+func (p *testKubeClientProvider) KubeClient(_ context.Context, namespace, impersonate string) (
+	kubernetes.Interface, error,
+) {
+	if namespace != p.namespace {
+		return nil, fmt.Errorf("namespace %q may not be used by modules", namespace)
+	}
+	p.impersonate = impersonate
+	return p.client, nil
+}
+
+func TestContextClient(t *testing.T) {
+	inputClientset := fake.NewClientset()
+	provider := &testKubeClientProvider{namespace: "team-a", client: fake.NewClientset()}
+	providerCtx := context.WithValue(context.Background(), blackstart.KubeClientProviderKey, provider)
+
+	// The client input takes precedence over the runtime client.
+	cc, err := contextClient(
+		blackstart.InputsToContext(
+			providerCtx, map[string]blackstart.Input{inputClient: blackstart.NewInputFromValue(inputClientset)},
+		), "team-a",
+	)
+	require.NoError(t, err)
+	assert.Same(t, inputClientset, cc)
+
+	cc, err = contextClient(
+		blackstart.InputsToContext(
+			providerCtx,
+			map[string]blackstart.Input{
+				inputImpersonate: blackstart.NewInputFromValue("system:serviceaccount:team-a:app"),
+			},
+		), "team-a",
+	)
+	require.NoError(t, err)
+	assert.Same(t, provider.client, cc)
+	assert.Equal(t, "system:serviceaccount:team-a:app", provider.impersonate)
+
+	_, err = contextClient(blackstart.InputsToContext(providerCtx, map[string]blackstart.Input{}), "team-b")
+	assert.ErrorContains(t, err, "may not be used by modules")
+
+	_, err = contextClient(blackstart.InputsToContext(context.Background(), map[string]blackstart.Input{}), "team-a")
+	assert.ErrorIs(t, err, blackstart.ErrKubeClientUnavailable)
+}
+
+// TestWorkflow_RuntimeClient runs Kubernetes modules without a kubernetes_client operation or
+// client inputs, as in a single cluster workflow, so the client of the runtime is used.
+func TestWorkflow_RuntimeClient(t *testing.T) {
+	provider := &testKubeClientProvider{namespace: "team-a", client: fake.NewClientset()}
+	ctx := context.WithValue(context.Background(), blackstart.KubeClientProviderKey, provider)
+
+	wf := blackstart.Workflow{
+		Name: "kubernetes-runtime-client",
+		Operations: []blackstart.Operation{
+			{
+				Id:     "app-secret",
+				Module: "kubernetes_secret",
+				Inputs: map[string]blackstart.Input{
+					inputNamespace: blackstart.NewInputFromValue("team-a"),
+					inputName:      blackstart.NewInputFromValue("app"),
+				},
+			},
+			{
+				Id:        "app-password",
+				Module:    "kubernetes_secret_value",
+				DependsOn: []string{"app-secret"},
+				Inputs: map[string]blackstart.Input{
+					inputSecret: blackstart.NewInputFromDep("app-secret", outputSecret),
+					inputKey:    blackstart.NewInputFromValue("password"),
+					inputValue:  blackstart.NewInputFromValue("hunter2"),
+				},
+			},
+		},
+	}
+
+	result := wf.Run(ctx)
+	require.NoError(t, result.Err)
+	s, err := provider.client.CoreV1().Secrets("team-a").Get(context.Background(), "app", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hunter2"), s.Data["password"])
+	assert.Empty(t, provider.impersonate)
+}
+
+// conflictOnFirstUpdate makes the first update of the resource with the fake clientset fail with a
+// conflict, as if another controller changed the object. It returns a function reporting the
+// number of updates.
+func conflictOnFirstUpdate(clientset *fake.Clientset, group, resource string) func() int {
+	return conflictOnFirst(clientset, "update", group, resource)
+}
+
+// conflictOnFirst makes the first request with the verb for the resource with the fake clientset
+// fail with a conflict. It returns a function reporting the number of requests with the verb.
+func conflictOnFirst(clientset *fake.Clientset, verb, group, resource string) func() int {
+	requests := 0
+	clientset.PrependReactor(
+		verb, resource, func(action k8stesting.Action) (bool, runtime.Object, error) {
+			requests++
+			if requests > 1 {
+				return false, nil, nil
+			}
+			var name string
+			switch a := action.(type) {
+			case k8stesting.UpdateAction:
+				name = a.GetObject().(interface{ GetName() string }).GetName()
+			case interface{ GetName() string }:
+				name = a.GetName()
+			}
+			return true, nil, apierrors.NewConflict(
+				schema.GroupResource{Group: group, Resource: resource}, name, fmt.Errorf("object was modified"),
+			)
+		},
+	)
+	return func() int { return requests }
+}
@@ -0,0 +1,363 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+// limitTypes are the supported types of the limits of a LimitRange.
+var limitTypes = map[string]corev1.LimitType{
+	string(corev1.LimitTypeContainer):             corev1.LimitTypeContainer,
+	string(corev1.LimitTypePod):                   corev1.LimitTypePod,
+	string(corev1.LimitTypePersistentVolumeClaim): corev1.LimitTypePersistentVolumeClaim,
+}
+
+func init() {
+	blackstart.RegisterModule("kubernetes_limit_range", NewLimitRangeModule)
+}
+
+var _ blackstart.Module = &limitRangeModule{}
+
+func NewLimitRangeModule() blackstart.Module {
+	return &limitRangeModule{}
+}
+
+type limitRangeModule struct{}
+
+func (l *limitRangeModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_limit_range",
+		Name: "Kubernetes LimitRange",
+		Description: util.CleanString(
+			`
+Ensures a Kubernetes LimitRange exists in a namespace, setting the default requests and limits of
+containers that do not set their own, and the minimum and maximum resources of containers, pods, or
+PersistentVolumeClaims.
+
+**Notes**
+
+- The LimitRange has one limit of the '''type''' input. Use one LimitRange for each type.
+- '''default''' and '''default_request''' are only supported for '''Container''' limits.
+- Like the API server, a missing default limit defaults to the maximum, and a missing default
+  request defaults to the default limit or the minimum.
+- Quantities are compared by value, and the limits of an existing LimitRange are updated when they
+  differ.
+- With '''doesNotExist''', the LimitRange is deleted. With '''wait_for_deletion''', Set waits until it is
+  removed, such as while finalizers run.
+`,
+		),
+		Requirements: []string{
+			"The target namespace must exist.",
+			"The Kubernetes identity must be authorized for LimitRange operations in the target namespace.",
+			"Required LimitRange verbs: `get`, `create`, `update`, `delete`.",
+		},
+		Inputs: withDeletionInputs(
+			"LimitRange", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the LimitRange",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputNamespace: {
+					Description: "Namespace of the LimitRange. Defaults to `default`, or to the namespace Blackstart runs in when `--k8s-default-namespace-from-runtime` is enabled.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputType: {
+					Description: "Type of the resources limited: `Container`, `Pod`, or `PersistentVolumeClaim`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     string(corev1.LimitTypeContainer),
+				},
+				inputDefault: {
+					Description: "Default limits of containers by resource name, such as `memory: 512Mi`",
+					Type:        reflect.TypeFor[map[string]any](),
+					Required:    false,
+				},
+				inputDefaultRequest: {
+					Description: "Default requests of containers by resource name, such as `cpu: 100m`",
+					Type:        reflect.TypeFor[map[string]any](),
+					Required:    false,
+				},
+				inputMax: {
+					Description: "Maximum resources by resource name, such as `memory: 2Gi` or `storage: 100Gi`",
+					Type:        reflect.TypeFor[map[string]any](),
+					Required:    false,
+				},
+				inputMin: {
+					Description: "Minimum resources by resource name, such as `cpu: 10m` or `storage: 1Gi`",
+					Type:        reflect.TypeFor[map[string]any](),
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputLimitRange: {
+				Description: "Name of the LimitRange",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Container Defaults": `id: container-defaults
+module: kubernetes_limit_range
+inputs:
+  name: container-defaults
+  namespace: tenant-a
+  default:
+    memory: 512Mi
+  default_request:
+    cpu: 100m
+    memory: 256Mi
+  max:
+    memory: 2Gi`,
+			"Volume Size": `id: volume-size
+module: kubernetes_limit_range
+inputs:
+  name: volume-size
+  namespace: tenant-a
+  type: PersistentVolumeClaim
+  max:
+    storage: 100Gi`,
+		},
+	}
+}
+
+func (l *limitRangeModule) Validate(op blackstart.Operation) error {
+	nameInput, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputName)
+	}
+	if nameInput.IsStatic() {
+		if _, err := blackstart.InputAs[string](nameInput, true); err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputName, err)
+		}
+	}
+
+	limitType := corev1.LimitTypeContainer
+	typeInput, hasType := op.Inputs[inputType]
+	if hasType && typeInput.IsStatic() {
+		typeName, err := blackstart.InputAs[string](typeInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputType, err)
+		}
+		if limitType, ok = limitTypes[typeName]; !ok {
+			return fmt.Errorf("input '%s' has unsupported limit type: %s", inputType, typeName)
+		}
+	}
+
+	limits := 0
+	for _, key := range []string{inputDefault, inputDefaultRequest, inputMax, inputMin} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			continue
+		}
+		limits++
+		if (key == inputDefault || key == inputDefaultRequest) && hasType && typeInput.IsStatic() &&
+			limitType != corev1.LimitTypeContainer {
+			return fmt.Errorf("input '%s' is only supported for %s limits", key, corev1.LimitTypeContainer)
+		}
+		if input.IsStatic() {
+			if _, err := inputResourceList(input, key); err != nil {
+				return err
+			}
+		}
+	}
+	if limits == 0 {
+		return fmt.Errorf(
+			"at least one of input '%s', '%s', '%s', and '%s' must be provided",
+			inputDefault, inputDefaultRequest, inputMax, inputMin,
+		)
+	}
+
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+func (l *limitRangeModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	desired, err := contextLimitRange(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return false, err
+	}
+	existing, err := cc.CoreV1().LimitRanges(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ctx.DoesNotExist(), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get LimitRange %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+	if ctx.DoesNotExist() {
+		return false, nil
+	}
+
+	if !apiequality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		return false, nil
+	}
+	return true, ctx.Output(outputLimitRange, existing.Name)
+}
+
+func (l *limitRangeModule) Set(ctx blackstart.ModuleContext) error {
+	desired, err := contextLimitRange(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return err
+	}
+	lri := cc.CoreV1().LimitRanges(desired.Namespace)
+
+	existing, err := lri.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if ctx.DoesNotExist() {
+			return nil
+		}
+		if _, err = lri.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create LimitRange %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return ctx.Output(outputLimitRange, desired.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get LimitRange %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+
+	if ctx.DoesNotExist() {
+		err = lri.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete LimitRange %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return waitForDeletion(
+			ctx, fmt.Sprintf("LimitRange %s/%s", desired.Namespace, desired.Name),
+			func(c context.Context) (metav1.Object, error) {
+				return lri.Get(c, desired.Name, metav1.GetOptions{})
+			},
+		)
+	}
+
+	if !apiequality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		// The update is retried with the latest LimitRange on conflicts with other changes.
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				current, getErr := lri.Get(ctx, desired.Name, metav1.GetOptions{})
+				if getErr != nil {
+					return getErr
+				}
+				current.Spec = desired.Spec
+				_, updateErr := lri.Update(ctx, current, metav1.UpdateOptions{})
+				return updateErr
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update LimitRange %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	}
+	return ctx.Output(outputLimitRange, existing.Name)
+}
+
+// contextLimitRange returns the LimitRange described by the inputs of the module.
+func contextLimitRange(ctx blackstart.ModuleContext) (*corev1.LimitRange, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := contextNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	typeName, err := blackstart.ContextInputAs[string](ctx, inputType, false)
+	if err != nil {
+		return nil, err
+	}
+	if typeName == "" {
+		typeName = string(corev1.LimitTypeContainer)
+	}
+	limitType, ok := limitTypes[typeName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported limit type: %s", typeName)
+	}
+
+	item := corev1.LimitRangeItem{Type: limitType}
+	for key, list := range map[string]*corev1.ResourceList{
+		inputDefault:        &item.Default,
+		inputDefaultRequest: &item.DefaultRequest,
+		inputMax:            &item.Max,
+		inputMin:            &item.Min,
+	} {
+		if *list, err = contextResourceList(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	if limitType != corev1.LimitTypeContainer && (len(item.Default) > 0 || len(item.DefaultRequest) > 0) {
+		return nil, fmt.Errorf(
+			"input '%s' and input '%s' are only supported for %s limits",
+			inputDefault, inputDefaultRequest, corev1.LimitTypeContainer,
+		)
+	}
+
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{defaultLimitRangeItem(item)}},
+	}, nil
+}
+
+// defaultLimitRangeItem sets the defaults the API server sets on a LimitRange limit, so the desired
+// limit can be compared with an existing LimitRange. The default limits of containers default to the
+// maximum, and the default requests to the default limits or the minimum.
+func defaultLimitRangeItem(item corev1.LimitRangeItem) corev1.LimitRangeItem {
+	if item.Type != corev1.LimitTypeContainer {
+		return item
+	}
+	for name, quantity := range item.Max {
+		if _, ok := item.Default[name]; !ok {
+			if item.Default == nil {
+				item.Default = corev1.ResourceList{}
+			}
+			item.Default[name] = quantity.DeepCopy()
+		}
+	}
+	for _, defaults := range []corev1.ResourceList{item.Default, item.Min} {
+		for name, quantity := range defaults {
+			if _, ok := item.DefaultRequest[name]; !ok {
+				if item.DefaultRequest == nil {
+					item.DefaultRequest = corev1.ResourceList{}
+				}
+				item.DefaultRequest[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	return item
+}
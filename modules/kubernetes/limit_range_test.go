@@ -0,0 +1,179 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestLimitRangeModule_Validate(t *testing.T) {
+	module := NewLimitRangeModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "container defaults",
+			inputs: map[string]blackstart.Input{
+				inputName:           blackstart.NewInputFromValue("container-defaults"),
+				inputDefault:        blackstart.NewInputFromValue(map[string]any{"memory": "512Mi"}),
+				inputDefaultRequest: blackstart.NewInputFromValue(map[string]any{"cpu": "100m"}),
+			},
+		},
+		{
+			name: "volume maximum",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("volume-size"),
+				inputType: blackstart.NewInputFromValue("PersistentVolumeClaim"),
+				inputMax:  blackstart.NewInputFromValue(map[string]any{"storage": "100Gi"}),
+			},
+		},
+		{
+			name: "no limits",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("container-defaults"),
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported type",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("container-defaults"),
+				inputType: blackstart.NewInputFromValue("Node"),
+				inputMax:  blackstart.NewInputFromValue(map[string]any{"cpu": 2}),
+			},
+			expectError: true,
+		},
+		{
+			name: "pod defaults",
+			inputs: map[string]blackstart.Input{
+				inputName:    blackstart.NewInputFromValue("pod-defaults"),
+				inputType:    blackstart.NewInputFromValue("Pod"),
+				inputDefault: blackstart.NewInputFromValue(map[string]any{"cpu": 1}),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid quantity",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("container-defaults"),
+				inputMin:  blackstart.NewInputFromValue(map[string]any{"cpu": "a few"}),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_limit_range", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestLimitRangeModule_CheckSet(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewLimitRangeModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("container-defaults"),
+		inputNamespace: blackstart.NewInputFromValue("tenant-a"),
+		inputMax:       blackstart.NewInputFromValue(map[string]any{"memory": "2Gi"}),
+		inputMin:       blackstart.NewInputFromValue(map[string]any{"cpu": "10m"}),
+	}
+	limits := func() corev1.LimitRangeItem {
+		lr, err := clientset.CoreV1().LimitRanges("tenant-a").Get(
+			context.Background(), "container-defaults", metav1.GetOptions{},
+		)
+		require.NoError(t, err)
+		require.Len(t, lr.Spec.Limits, 1)
+		return lr.Spec.Limits[0]
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	item := limits()
+	assert.Equal(t, corev1.LimitTypeContainer, item.Type)
+	assert.Equal(t, "10m", item.Min.Cpu().String())
+	assert.Equal(t, "container-defaults", ctx.outputs[outputLimitRange])
+
+	// The defaults set by the API server are included, so the default limit is the maximum and the
+	// default requests are the default limit and the minimum.
+	assert.Equal(t, "2Gi", item.Default.Memory().String())
+	assert.Equal(t, "2Gi", item.DefaultRequest.Memory().String())
+	assert.Equal(t, "10m", item.DefaultRequest.Cpu().String())
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	inputs[inputDefaultRequest] = blackstart.NewInputFromValue(map[string]any{"memory": "256Mi"})
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(t, resource.MustParse("256Mi"), limits().DefaultRequest[corev1.ResourceMemory])
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestLimitRangeModule_SetRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "volume-size", Namespace: "tenant-a"},
+			Spec: corev1.LimitRangeSpec{
+				Limits: []corev1.LimitRangeItem{
+					{
+						Type: corev1.LimitTypePersistentVolumeClaim,
+						Max:  corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+					},
+				},
+			},
+		},
+	)
+	updates := conflictOnFirstUpdate(clientset, "", "limitranges")
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("volume-size"),
+		inputNamespace: blackstart.NewInputFromValue("tenant-a"),
+		inputType:      blackstart.NewInputFromValue("PersistentVolumeClaim"),
+		inputMax:       blackstart.NewInputFromValue(map[string]any{"storage": "100Gi"}),
+	}
+
+	require.NoError(t, NewLimitRangeModule().Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(t, 2, updates())
+	lr, err := clientset.CoreV1().LimitRanges("tenant-a").Get(context.Background(), "volume-size", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "100Gi", lr.Spec.Limits[0].Max.Storage().String())
+}
@@ -0,0 +1,439 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	networkPolicyDenyAll            = "deny_all"
+	networkPolicyDenyIngress        = "deny_ingress"
+	networkPolicyDenyEgress         = "deny_egress"
+	networkPolicyAllowDNS           = "allow_dns"
+	networkPolicyAllowSameNamespace = "allow_same_namespace"
+)
+
+// networkPolicyPresets are the baseline policies of the policy input.
+var networkPolicyPresets = map[string]func(selector metav1.LabelSelector) networkingv1.NetworkPolicySpec{
+	networkPolicyDenyAll: func(selector metav1.LabelSelector) networkingv1.NetworkPolicySpec {
+		return networkingv1.NetworkPolicySpec{
+			PodSelector: selector,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		}
+	},
+	networkPolicyDenyIngress: func(selector metav1.LabelSelector) networkingv1.NetworkPolicySpec {
+		return networkingv1.NetworkPolicySpec{
+			PodSelector: selector,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		}
+	},
+	networkPolicyDenyEgress: func(selector metav1.LabelSelector) networkingv1.NetworkPolicySpec {
+		return networkingv1.NetworkPolicySpec{
+			PodSelector: selector,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		}
+	},
+	networkPolicyAllowDNS: func(selector metav1.LabelSelector) networkingv1.NetworkPolicySpec {
+		udp, tcp := corev1.ProtocolUDP, corev1.ProtocolTCP
+		port := intstr.FromInt32(53)
+		return networkingv1.NetworkPolicySpec{
+			PodSelector: selector,
+			Egress: []networkingv1.NetworkPolicyEgressRule{
+				{
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &port}, {Protocol: &tcp, Port: &port}},
+					To: []networkingv1.NetworkPolicyPeer{
+						{
+							NamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{corev1.LabelMetadataName: metav1.NamespaceSystem},
+							},
+							PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+						},
+					},
+				},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		}
+	},
+	networkPolicyAllowSameNamespace: func(selector metav1.LabelSelector) networkingv1.NetworkPolicySpec {
+		return networkingv1.NetworkPolicySpec{
+			PodSelector: selector,
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{From: []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{}}}},
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		}
+	},
+}
+
+func init() {
+	blackstart.RegisterModule("kubernetes_network_policy", NewNetworkPolicyModule)
+}
+
+var _ blackstart.Module = &networkPolicyModule{}
+
+func NewNetworkPolicyModule() blackstart.Module {
+	return &networkPolicyModule{}
+}
+
+type networkPolicyModule struct{}
+
+func (n *networkPolicyModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_network_policy",
+		Name: "Kubernetes NetworkPolicy",
+		Description: util.CleanString(
+			`
+Ensures a Kubernetes NetworkPolicy exists, such as the baseline policies of a new namespace that
+deny traffic by default and allow DNS lookups.
+
+A baseline policy is selected with '''policy''':
+
+- '''deny_all''' - Denies all ingress and egress traffic of the pods.
+- '''deny_ingress''' - Denies all ingress traffic of the pods.
+- '''deny_egress''' - Denies all egress traffic of the pods.
+- '''allow_dns''' - Allows egress traffic of the pods to the cluster DNS pods, labeled
+  '''k8s-app=kube-dns''' in the '''kube-system''' namespace, on port 53.
+- '''allow_same_namespace''' - Allows ingress traffic to the pods from pods in the same namespace.
+
+Other policies are set with '''spec''', the NetworkPolicy spec in YAML or JSON.
+
+**Notes**
+
+- Exactly one of '''policy''' and '''spec''' must be set.
+- The spec of an existing NetworkPolicy is updated when it differs.
+- With '''doesNotExist''', the NetworkPolicy is deleted. With '''wait_for_deletion''', Set waits until
+  it is removed, such as while finalizers run.
+`,
+		),
+		Requirements: []string{
+			"The target namespace must exist.",
+			"The cluster network plugin must enforce NetworkPolicies.",
+			"The Kubernetes identity must be authorized for NetworkPolicy operations in the target namespace.",
+			"Required NetworkPolicy verbs: `get`, `create`, `update`, `delete`.",
+		},
+		Inputs: withDeletionInputs(
+			"NetworkPolicy", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the NetworkPolicy",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputNamespace: {
+					Description: "Namespace of the NetworkPolicy. Defaults to `default`, or to the namespace Blackstart runs in when `--k8s-default-namespace-from-runtime` is enabled.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputPolicy: {
+					Description: "Baseline policy. One of `deny_all`, `deny_ingress`, `deny_egress`, `allow_dns`, or `allow_same_namespace`. Cannot be used with `spec`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputSelector: {
+					Description: "Label selector of the pods the baseline policy applies to, such as `app=api`. Defaults to all pods in the namespace. Cannot be used with `spec`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputSpec: {
+					Description: "NetworkPolicy spec in YAML or JSON. Cannot be used with `policy`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputNetworkPolicy: {
+				Description: "Name of the NetworkPolicy",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Default Deny": `id: default-deny
+module: kubernetes_network_policy
+inputs:
+  name: default-deny
+  namespace: tenant-a
+  policy: deny_all`,
+			"Allow DNS": `id: allow-dns
+module: kubernetes_network_policy
+inputs:
+  name: allow-dns
+  namespace: tenant-a
+  policy: allow_dns`,
+			"Allow Ingress Controller": `id: allow-ingress-controller
+module: kubernetes_network_policy
+inputs:
+  name: allow-ingress-controller
+  namespace: tenant-a
+  spec: |
+    podSelector:
+      matchLabels:
+        app: web
+    ingress:
+      - from:
+          - namespaceSelector:
+              matchLabels:
+                kubernetes.io/metadata.name: ingress
+        ports:
+          - port: 8080
+    policyTypes:
+      - Ingress`,
+		},
+	}
+}
+
+func (n *networkPolicyModule) Validate(op blackstart.Operation) error {
+	nameInput, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputName)
+	}
+	if nameInput.IsStatic() {
+		if _, err := blackstart.InputAs[string](nameInput, true); err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputName, err)
+		}
+	}
+
+	policyInput, hasPolicy := op.Inputs[inputPolicy]
+	specInput, hasSpec := op.Inputs[inputSpec]
+	if hasPolicy == hasSpec {
+		return fmt.Errorf("exactly one of input '%s' and input '%s' must be provided", inputPolicy, inputSpec)
+	}
+	if _, ok = op.Inputs[inputSelector]; ok && hasSpec {
+		return fmt.Errorf("input '%s' cannot be used with input '%s'", inputSelector, inputSpec)
+	}
+	if hasPolicy && policyInput.IsStatic() {
+		policy, err := blackstart.InputAs[string](policyInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputPolicy, err)
+		}
+		if _, ok = networkPolicyPresets[policy]; !ok {
+			return fmt.Errorf("input '%s' has invalid value '%s'", inputPolicy, policy)
+		}
+	}
+	if hasSpec && specInput.IsStatic() {
+		spec, err := blackstart.InputAs[string](specInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputSpec, err)
+		}
+		if _, err = parseNetworkPolicySpec(spec); err != nil {
+			return err
+		}
+	}
+	if err := validateSelectorInput(op); err != nil {
+		return err
+	}
+
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+func (n *networkPolicyModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	desired, err := contextNetworkPolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return false, err
+	}
+	existing, err := cc.NetworkingV1().NetworkPolicies(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ctx.DoesNotExist(), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get NetworkPolicy %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+	if ctx.DoesNotExist() {
+		return false, nil
+	}
+
+	if !apiequality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		return false, nil
+	}
+	return true, ctx.Output(outputNetworkPolicy, existing.Name)
+}
+
+func (n *networkPolicyModule) Set(ctx blackstart.ModuleContext) error {
+	desired, err := contextNetworkPolicy(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return err
+	}
+	npi := cc.NetworkingV1().NetworkPolicies(desired.Namespace)
+
+	existing, err := npi.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if ctx.DoesNotExist() {
+			return nil
+		}
+		if _, err = npi.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create NetworkPolicy %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return ctx.Output(outputNetworkPolicy, desired.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get NetworkPolicy %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+
+	if ctx.DoesNotExist() {
+		err = npi.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete NetworkPolicy %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return waitForDeletion(
+			ctx, fmt.Sprintf("NetworkPolicy %s/%s", desired.Namespace, desired.Name),
+			func(c context.Context) (metav1.Object, error) {
+				return npi.Get(c, desired.Name, metav1.GetOptions{})
+			},
+		)
+	}
+
+	if !apiequality.Semantic.DeepEqual(existing.Spec, desired.Spec) {
+		// The update is retried with the latest NetworkPolicy on conflicts with other changes.
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				current, getErr := npi.Get(ctx, desired.Name, metav1.GetOptions{})
+				if getErr != nil {
+					return getErr
+				}
+				current.Spec = desired.Spec
+				_, updateErr := npi.Update(ctx, current, metav1.UpdateOptions{})
+				return updateErr
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update NetworkPolicy %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	}
+	return ctx.Output(outputNetworkPolicy, existing.Name)
+}
+
+// contextNetworkPolicy returns the NetworkPolicy described by the inputs of the module.
+func contextNetworkPolicy(ctx blackstart.ModuleContext) (*networkingv1.NetworkPolicy, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := contextNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := blackstart.ContextInputAs[string](ctx, inputPolicy, false)
+	if err != nil {
+		return nil, err
+	}
+	specValue, err := blackstart.ContextInputAs[string](ctx, inputSpec, false)
+	if err != nil {
+		return nil, err
+	}
+	if (policy == "") == (specValue == "") {
+		return nil, fmt.Errorf("exactly one of input '%s' and input '%s' must be set", inputPolicy, inputSpec)
+	}
+
+	var spec networkingv1.NetworkPolicySpec
+	if specValue != "" {
+		if spec, err = parseNetworkPolicySpec(specValue); err != nil {
+			return nil, err
+		}
+	} else {
+		preset, ok := networkPolicyPresets[policy]
+		if !ok {
+			return nil, fmt.Errorf("input '%s' has invalid value '%s'", inputPolicy, policy)
+		}
+		selectorValue, sErr := blackstart.ContextInputAs[string](ctx, inputSelector, false)
+		if sErr != nil {
+			return nil, sErr
+		}
+		selector, sErr := metav1.ParseToLabelSelector(selectorValue)
+		if sErr != nil {
+			return nil, fmt.Errorf("input '%s' is not a valid label selector: %w", inputSelector, sErr)
+		}
+		spec = preset(*selector)
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       defaultNetworkPolicySpec(spec),
+	}, nil
+}
+
+// parseNetworkPolicySpec parses the NetworkPolicy spec of the spec input.
+func parseNetworkPolicySpec(value string) (networkingv1.NetworkPolicySpec, error) {
+	var spec networkingv1.NetworkPolicySpec
+	specJSON, err := yaml.YAMLToJSON([]byte(value))
+	if err != nil {
+		return spec, fmt.Errorf("input '%s' is not valid YAML or JSON: %w", inputSpec, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(specJSON))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("input '%s' is not a NetworkPolicy spec: %w", inputSpec, err)
+	}
+	return spec, nil
+}
+
+// defaultNetworkPolicySpec sets the defaults the API server sets on a NetworkPolicy spec, so the
+// desired spec can be compared with an existing NetworkPolicy. Ports default to TCP, and the policy
+// types default to Ingress, and Egress when the spec has egress rules.
+func defaultNetworkPolicySpec(spec networkingv1.NetworkPolicySpec) networkingv1.NetworkPolicySpec {
+	spec = *spec.DeepCopy()
+	defaultPorts := func(ports []networkingv1.NetworkPolicyPort) {
+		for i := range ports {
+			if ports[i].Protocol == nil {
+				tcp := corev1.ProtocolTCP
+				ports[i].Protocol = &tcp
+			}
+		}
+	}
+	for _, rule := range spec.Ingress {
+		defaultPorts(rule.Ports)
+	}
+	for _, rule := range spec.Egress {
+		defaultPorts(rule.Ports)
+	}
+	if len(spec.PolicyTypes) == 0 {
+		spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+		if len(spec.Egress) > 0 {
+			spec.PolicyTypes = append(spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		}
+	}
+	return spec
+}
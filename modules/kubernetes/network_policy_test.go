@@ -0,0 +1,259 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+const testNetworkPolicySpec = `
+podSelector:
+  matchLabels:
+    app: web
+ingress:
+  - from:
+      - namespaceSelector:
+          matchLabels:
+            kubernetes.io/metadata.name: ingress
+    ports:
+      - port: 8080
+`
+
+func TestNetworkPolicyModule_Validate(t *testing.T) {
+	module := NewNetworkPolicyModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "policy",
+			inputs: map[string]blackstart.Input{
+				inputName:   blackstart.NewInputFromValue("default-deny"),
+				inputPolicy: blackstart.NewInputFromValue("deny_all"),
+			},
+		},
+		{
+			name: "policy with selector",
+			inputs: map[string]blackstart.Input{
+				inputName:     blackstart.NewInputFromValue("allow-dns"),
+				inputPolicy:   blackstart.NewInputFromValue("allow_dns"),
+				inputSelector: blackstart.NewInputFromValue("app=web"),
+			},
+		},
+		{
+			name: "spec",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("web"),
+				inputSpec: blackstart.NewInputFromValue(testNetworkPolicySpec),
+			},
+		},
+		{
+			name: "missing name",
+			inputs: map[string]blackstart.Input{
+				inputPolicy: blackstart.NewInputFromValue("deny_all"),
+			},
+			expectError: true,
+		},
+		{
+			name: "policy and spec",
+			inputs: map[string]blackstart.Input{
+				inputName:   blackstart.NewInputFromValue("web"),
+				inputPolicy: blackstart.NewInputFromValue("deny_all"),
+				inputSpec:   blackstart.NewInputFromValue(testNetworkPolicySpec),
+			},
+			expectError: true,
+		},
+		{
+			name: "no policy or spec",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("web"),
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown policy",
+			inputs: map[string]blackstart.Input{
+				inputName:   blackstart.NewInputFromValue("web"),
+				inputPolicy: blackstart.NewInputFromValue("allow_all"),
+			},
+			expectError: true,
+		},
+		{
+			name: "selector with spec",
+			inputs: map[string]blackstart.Input{
+				inputName:     blackstart.NewInputFromValue("web"),
+				inputSpec:     blackstart.NewInputFromValue(testNetworkPolicySpec),
+				inputSelector: blackstart.NewInputFromValue("app=web"),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid selector",
+			inputs: map[string]blackstart.Input{
+				inputName:     blackstart.NewInputFromValue("web"),
+				inputPolicy:   blackstart.NewInputFromValue("deny_all"),
+				inputSelector: blackstart.NewInputFromValue("app in web"),
+			},
+			expectError: true,
+		},
+		{
+			name: "unknown spec field",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("web"),
+				inputSpec: blackstart.NewInputFromValue("podSelectors: {}"),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_network_policy", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestNetworkPolicyModule_CheckSet(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewNetworkPolicyModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("allow-dns"),
+		inputNamespace: blackstart.NewInputFromValue("tenant-a"),
+		inputPolicy:    blackstart.NewInputFromValue("allow_dns"),
+	}
+	networkPolicy := func() *networkingv1.NetworkPolicy {
+		np, err := clientset.NetworkingV1().NetworkPolicies("tenant-a").Get(
+			context.Background(), "allow-dns", metav1.GetOptions{},
+		)
+		require.NoError(t, err)
+		return np
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	spec := networkPolicy().Spec
+	assert.Empty(t, spec.PodSelector.MatchLabels)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeEgress}, spec.PolicyTypes)
+	require.Len(t, spec.Egress, 1)
+	assert.Equal(t, intstr.FromInt32(53), *spec.Egress[0].Ports[0].Port)
+	assert.Equal(t, corev1.ProtocolUDP, *spec.Egress[0].Ports[0].Protocol)
+	assert.Equal(t, "allow-dns", ctx.outputs[outputNetworkPolicy])
+
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Changing the policy updates the NetworkPolicy in place.
+	delete(inputs, inputPolicy)
+	inputs[inputSpec] = blackstart.NewInputFromValue(testNetworkPolicySpec)
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(ctx))
+	spec = networkPolicy().Spec
+	assert.Equal(t, map[string]string{"app": "web"}, spec.PodSelector.MatchLabels)
+	assert.Empty(t, spec.Egress)
+
+	// The spec is compared with the defaults set by the API server, so the port protocol and policy
+	// types left out of the spec do not cause an update.
+	assert.Equal(t, corev1.ProtocolTCP, *spec.Ingress[0].Ports[0].Protocol)
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, spec.PolicyTypes)
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNetworkPolicyModule_Presets(t *testing.T) {
+	for policy, types := range map[string][]networkingv1.PolicyType{
+		networkPolicyDenyAll:            {networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		networkPolicyDenyIngress:        {networkingv1.PolicyTypeIngress},
+		networkPolicyDenyEgress:         {networkingv1.PolicyTypeEgress},
+		networkPolicyAllowDNS:           {networkingv1.PolicyTypeEgress},
+		networkPolicyAllowSameNamespace: {networkingv1.PolicyTypeIngress},
+	} {
+		t.Run(
+			policy, func(t *testing.T) {
+				clientset := fake.NewClientset()
+				inputs := map[string]blackstart.Input{
+					inputClient:    blackstart.NewInputFromValue(clientset),
+					inputName:      blackstart.NewInputFromValue(policy),
+					inputNamespace: blackstart.NewInputFromValue("tenant-a"),
+					inputPolicy:    blackstart.NewInputFromValue(policy),
+					inputSelector:  blackstart.NewInputFromValue("app=web"),
+				}
+				require.NoError(t, NewNetworkPolicyModule().Set(blackstart.InputsToContext(context.Background(), inputs)))
+
+				np, err := clientset.NetworkingV1().NetworkPolicies("tenant-a").Get(
+					context.Background(), policy, metav1.GetOptions{},
+				)
+				require.NoError(t, err)
+				assert.Equal(t, types, np.Spec.PolicyTypes)
+				assert.Equal(t, map[string]string{"app": "web"}, np.Spec.PodSelector.MatchLabels)
+			},
+		)
+	}
+}
+
+func TestNetworkPolicyModule_SetRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "default-deny", Namespace: "tenant-a"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			},
+		},
+	)
+	updates := conflictOnFirstUpdate(clientset, "networking.k8s.io", "networkpolicies")
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("default-deny"),
+		inputNamespace: blackstart.NewInputFromValue("tenant-a"),
+		inputPolicy:    blackstart.NewInputFromValue("deny_all"),
+	}
+
+	require.NoError(t, NewNetworkPolicyModule().Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(t, 2, updates())
+	np, err := clientset.NetworkingV1().NetworkPolicies("tenant-a").Get(
+		context.Background(), "default-deny", metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		np.Spec.PolicyTypes,
+	)
+}
@@ -0,0 +1,220 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("kubernetes_node_label", NewNodeLabelModule)
+}
+
+var _ blackstart.Module = &nodeLabelModule{}
+
+func NewNodeLabelModule() blackstart.Module {
+	return &nodeLabelModule{}
+}
+
+type nodeLabelModule struct{}
+
+func (n *nodeLabelModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_node_label",
+		Name: "Kubernetes Node Label",
+		Description: util.CleanString(
+			`
+Ensures a label is set on the Kubernetes nodes matching a label selector, such as labeling the
+initial nodes of a cluster for system workloads.
+
+**Notes**
+
+- Only the nodes matching the selector when the operation runs are labeled. Nodes added later are
+  labeled the next time the workflow runs.
+- Setting the label fails when no node matches the selector, so the workflow is retried until the
+  nodes have joined the cluster.
+- With '''doesNotExist''', the label is removed from the matching nodes.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized for Node operations.",
+			"Required Node verbs: `list`, `patch`.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputSelector: {
+				Description: "Label selector of the nodes to label, such as `node.kubernetes.io/instance-type=e2-standard-4`. Defaults to all nodes.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputKey: {
+				Description: "Key of the label",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputValue: {
+				Description: "Value of the label. Defaults to an empty value.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputClient: {
+				Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+				Type:        reflect.TypeFor[kubernetes.Interface](),
+				Required:    false,
+			},
+			inputImpersonate: {
+				Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputNodes: {
+				Description: "Names of the nodes matching the selector",
+				Type:        reflect.TypeFor[[]string](),
+			},
+		},
+		Examples: map[string]string{
+			"Label System Nodes": `id: label-system-nodes
+module: kubernetes_node_label
+inputs:
+  selector: cloud.google.com/gke-nodepool=system
+  key: workload-type
+  value: system`,
+		},
+	}
+}
+
+func (n *nodeLabelModule) Validate(op blackstart.Operation) error {
+	keyInput, ok := op.Inputs[inputKey]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputKey)
+	}
+	if keyInput.IsStatic() {
+		key, err := blackstart.InputAs[string](keyInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputKey, err)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("input '%s' is not a valid label key: %s", inputKey, strings.Join(errs, "; "))
+		}
+	}
+
+	if valueInput, ok := op.Inputs[inputValue]; ok && valueInput.IsStatic() {
+		value, err := blackstart.InputAs[string](valueInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputValue, err)
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("input '%s' is not a valid label value: %s", inputValue, strings.Join(errs, "; "))
+		}
+	}
+
+	if err := validateSelectorInput(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+func (n *nodeLabelModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	key, err := blackstart.ContextInputAs[string](ctx, inputKey, true)
+	if err != nil {
+		return false, err
+	}
+	value, err := blackstart.ContextInputAs[string](ctx, inputValue, false)
+	if err != nil {
+		return false, err
+	}
+
+	_, nodes, err := contextNodes(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, node := range nodes {
+		ctx.Resource(node.Name + "/" + key)
+	}
+
+	if len(nodes) == 0 && !ctx.DoesNotExist() {
+		return false, nil
+	}
+	for _, node := range nodes {
+		if !nodeLabelConverged(node, key, value, ctx.DoesNotExist()) {
+			return false, nil
+		}
+	}
+	if ctx.DoesNotExist() {
+		return true, nil
+	}
+	return true, outputNodeNames(ctx, nodes)
+}
+
+func (n *nodeLabelModule) Set(ctx blackstart.ModuleContext) error {
+	key, err := blackstart.ContextInputAs[string](ctx, inputKey, true)
+	if err != nil {
+		return err
+	}
+	value, err := blackstart.ContextInputAs[string](ctx, inputValue, false)
+	if err != nil {
+		return err
+	}
+
+	cc, nodes, err := contextNodes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 && !ctx.DoesNotExist() {
+		selector, _ := blackstart.ContextInputAs[string](ctx, inputSelector, false)
+		return fmt.Errorf("no nodes match selector %q", selector)
+	}
+
+	// A null label value removes the label with a JSON merge patch.
+	var patchValue *string
+	if !ctx.DoesNotExist() {
+		patchValue = &value
+	}
+	patch, err := json.Marshal(
+		map[string]any{"metadata": map[string]any{"labels": map[string]*string{key: patchValue}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		ctx.Resource(node.Name + "/" + key)
+		if nodeLabelConverged(node, key, value, ctx.DoesNotExist()) {
+			continue
+		}
+		_, err = cc.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to label node %s: %w", node.Name, err)
+		}
+	}
+
+	if ctx.DoesNotExist() {
+		return nil
+	}
+	return outputNodeNames(ctx, nodes)
+}
+
+// nodeLabelConverged reports whether the label of the node is in the desired state: set to the value,
+// or absent when doesNotExist is set.
+func nodeLabelConverged(node corev1.Node, key, value string, doesNotExist bool) bool {
+	current, ok := node.Labels[key]
+	if doesNotExist {
+		return !ok
+	}
+	return ok && current == value
+}
@@ -0,0 +1,159 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+// testNode returns a node with the labels.
+func testNode(name string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestNodeLabelModule_Validate(t *testing.T) {
+	module := NewNodeLabelModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "valid inputs",
+			inputs: map[string]blackstart.Input{
+				inputSelector: blackstart.NewInputFromValue("pool=system"),
+				inputKey:      blackstart.NewInputFromValue("example.com/workload"),
+				inputValue:    blackstart.NewInputFromValue("system"),
+			},
+		},
+		{
+			name: "missing key",
+			inputs: map[string]blackstart.Input{
+				inputValue: blackstart.NewInputFromValue("system"),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid key",
+			inputs: map[string]blackstart.Input{
+				inputKey: blackstart.NewInputFromValue("not a key"),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid value",
+			inputs: map[string]blackstart.Input{
+				inputKey:   blackstart.NewInputFromValue("workload"),
+				inputValue: blackstart.NewInputFromValue("not a value"),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid selector",
+			inputs: map[string]blackstart.Input{
+				inputSelector: blackstart.NewInputFromValue("pool in system"),
+				inputKey:      blackstart.NewInputFromValue("workload"),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_node_label", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestNodeLabelModule_CheckSet(t *testing.T) {
+	clientset := fake.NewClientset(
+		testNode("system-1", map[string]string{"pool": "system"}),
+		testNode("system-2", map[string]string{"pool": "system", "workload": "other"}),
+		testNode("apps-1", map[string]string{"pool": "apps"}),
+	)
+	module := NewNodeLabelModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:   blackstart.NewInputFromValue(clientset),
+		inputSelector: blackstart.NewInputFromValue("pool=system"),
+		inputKey:      blackstart.NewInputFromValue("workload"),
+		inputValue:    blackstart.NewInputFromValue("system"),
+	}
+
+	nodeLabels := func(name string) map[string]string {
+		node, err := clientset.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return node.Labels
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, "system", nodeLabels("system-1")["workload"])
+	assert.Equal(t, "system", nodeLabels("system-2")["workload"])
+	assert.NotContains(t, nodeLabels("apps-1"), "workload")
+	assert.ElementsMatch(t, []string{"system-1", "system-2"}, ctx.outputs[outputNodes])
+
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// The label is removed from the matching nodes only.
+	require.NoError(
+		t, clientset.Tracker().Update(
+			corev1.SchemeGroupVersion.WithResource("nodes"),
+			testNode("apps-1", map[string]string{"pool": "apps", "workload": "apps"}), "",
+		),
+	)
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	assert.NotContains(t, nodeLabels("system-1"), "workload")
+	assert.Equal(t, map[string]string{"pool": "system"}, nodeLabels("system-2"))
+	assert.Equal(t, "apps", nodeLabels("apps-1")["workload"])
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestNodeLabelModule_NoMatchingNodes(t *testing.T) {
+	clientset := fake.NewClientset(testNode("apps-1", map[string]string{"pool": "apps"}))
+	module := NewNodeLabelModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:   blackstart.NewInputFromValue(clientset),
+		inputSelector: blackstart.NewInputFromValue("pool=system"),
+		inputKey:      blackstart.NewInputFromValue("workload"),
+	}
+
+	ctx := blackstart.InputsToContext(context.Background(), inputs)
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.ErrorContains(t, module.Set(ctx), "no nodes match selector")
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,287 @@
+package kubernetes
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("kubernetes_node_taint", NewNodeTaintModule)
+}
+
+var _ blackstart.Module = &nodeTaintModule{}
+
+func NewNodeTaintModule() blackstart.Module {
+	return &nodeTaintModule{}
+}
+
+// taintEffects are the supported taint effects.
+var taintEffects = map[string]corev1.TaintEffect{
+	string(corev1.TaintEffectNoSchedule):       corev1.TaintEffectNoSchedule,
+	string(corev1.TaintEffectPreferNoSchedule): corev1.TaintEffectPreferNoSchedule,
+	string(corev1.TaintEffectNoExecute):        corev1.TaintEffectNoExecute,
+}
+
+type nodeTaintModule struct{}
+
+func (n *nodeTaintModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_node_taint",
+		Name: "Kubernetes Node Taint",
+		Description: util.CleanString(
+			`
+Ensures a taint is set on the Kubernetes nodes matching a label selector, such as reserving the
+initial nodes of a cluster for system workloads.
+
+**Notes**
+
+- A taint is identified by its key and effect. An existing taint with the same key and effect but a
+  different value is replaced.
+- Only the nodes matching the selector when the operation runs are tainted. Nodes added later are
+  tainted the next time the workflow runs.
+- Setting the taint fails when no node matches the selector, so the workflow is retried until the
+  nodes have joined the cluster.
+- With '''doesNotExist''', the taint is removed from the matching nodes.
+- A '''NoExecute''' taint evicts pods without a matching toleration, which may include Blackstart.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized for Node operations.",
+			"Required Node verbs: `list`, `get`, `update`.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputSelector: {
+				Description: "Label selector of the nodes to taint, such as `cloud.google.com/gke-nodepool=system`. Defaults to all nodes.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputKey: {
+				Description: "Key of the taint",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputValue: {
+				Description: "Value of the taint. Defaults to an empty value.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputEffect: {
+				Description: "Effect of the taint: `NoSchedule`, `PreferNoSchedule`, or `NoExecute`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputClient: {
+				Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+				Type:        reflect.TypeFor[kubernetes.Interface](),
+				Required:    false,
+			},
+			inputImpersonate: {
+				Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputNodes: {
+				Description: "Names of the nodes matching the selector",
+				Type:        reflect.TypeFor[[]string](),
+			},
+		},
+		Examples: map[string]string{
+			"Reserve System Nodes": `id: taint-system-nodes
+module: kubernetes_node_taint
+inputs:
+  selector: cloud.google.com/gke-nodepool=system
+  key: workload-type
+  value: system
+  effect: NoSchedule`,
+		},
+	}
+}
+
+func (n *nodeTaintModule) Validate(op blackstart.Operation) error {
+	keyInput, ok := op.Inputs[inputKey]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputKey)
+	}
+	if keyInput.IsStatic() {
+		key, err := blackstart.InputAs[string](keyInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputKey, err)
+		}
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("input '%s' is not a valid taint key: %s", inputKey, strings.Join(errs, "; "))
+		}
+	}
+
+	if valueInput, ok := op.Inputs[inputValue]; ok && valueInput.IsStatic() {
+		value, err := blackstart.InputAs[string](valueInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputValue, err)
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("input '%s' is not a valid taint value: %s", inputValue, strings.Join(errs, "; "))
+		}
+	}
+
+	effectInput, ok := op.Inputs[inputEffect]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputEffect)
+	}
+	if effectInput.IsStatic() {
+		effect, err := blackstart.InputAs[string](effectInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputEffect, err)
+		}
+		if _, ok = taintEffects[effect]; !ok {
+			return fmt.Errorf("input '%s' has unsupported taint effect: %s", inputEffect, effect)
+		}
+	}
+
+	if err := validateSelectorInput(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+func (n *nodeTaintModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	taint, err := contextTaint(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	_, nodes, err := contextNodes(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, node := range nodes {
+		ctx.Resource(taintResource(node, taint))
+	}
+
+	if len(nodes) == 0 && !ctx.DoesNotExist() {
+		return false, nil
+	}
+	for _, node := range nodes {
+		if !nodeTaintConverged(node, taint, ctx.DoesNotExist()) {
+			return false, nil
+		}
+	}
+	if ctx.DoesNotExist() {
+		return true, nil
+	}
+	return true, outputNodeNames(ctx, nodes)
+}
+
+func (n *nodeTaintModule) Set(ctx blackstart.ModuleContext) error {
+	taint, err := contextTaint(ctx)
+	if err != nil {
+		return err
+	}
+
+	cc, nodes, err := contextNodes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 && !ctx.DoesNotExist() {
+		selector, _ := blackstart.ContextInputAs[string](ctx, inputSelector, false)
+		return fmt.Errorf("no nodes match selector %q", selector)
+	}
+
+	for _, node := range nodes {
+		ctx.Resource(taintResource(node, taint))
+		if nodeTaintConverged(node, taint, ctx.DoesNotExist()) {
+			continue
+		}
+
+		// Taints are a list, so the node is updated and the update is retried on conflicts with
+		// other changes to the node.
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				current, getErr := cc.CoreV1().Nodes().Get(ctx, node.Name, metav1.GetOptions{})
+				if getErr != nil {
+					return getErr
+				}
+				if nodeTaintConverged(*current, taint, ctx.DoesNotExist()) {
+					return nil
+				}
+				current.Spec.Taints = withTaint(current.Spec.Taints, taint, ctx.DoesNotExist())
+				_, updateErr := cc.CoreV1().Nodes().Update(ctx, current, metav1.UpdateOptions{})
+				return updateErr
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to taint node %s: %w", node.Name, err)
+		}
+	}
+
+	if ctx.DoesNotExist() {
+		return nil
+	}
+	return outputNodeNames(ctx, nodes)
+}
+
+// contextTaint returns the taint described by the inputs of the module.
+func contextTaint(ctx blackstart.ModuleContext) (corev1.Taint, error) {
+	key, err := blackstart.ContextInputAs[string](ctx, inputKey, true)
+	if err != nil {
+		return corev1.Taint{}, err
+	}
+	value, err := blackstart.ContextInputAs[string](ctx, inputValue, false)
+	if err != nil {
+		return corev1.Taint{}, err
+	}
+	effectName, err := blackstart.ContextInputAs[string](ctx, inputEffect, true)
+	if err != nil {
+		return corev1.Taint{}, err
+	}
+	effect, ok := taintEffects[effectName]
+	if !ok {
+		return corev1.Taint{}, fmt.Errorf("unsupported taint effect: %s", effectName)
+	}
+	return corev1.Taint{Key: key, Value: value, Effect: effect}, nil
+}
+
+// taintResource returns the resource identifier of the taint on the node.
+func taintResource(node corev1.Node, taint corev1.Taint) string {
+	return node.Name + "/" + taint.Key + ":" + string(taint.Effect)
+}
+
+// nodeTaintConverged reports whether the taint of the node is in the desired state: set with the
+// value, or absent when doesNotExist is set.
+func nodeTaintConverged(node corev1.Node, taint corev1.Taint, doesNotExist bool) bool {
+	for _, t := range node.Spec.Taints {
+		if t.MatchTaint(&taint) {
+			return !doesNotExist && t.Value == taint.Value
+		}
+	}
+	return doesNotExist
+}
+
+// withTaint returns the taints with the taint set, replacing a taint with the same key and effect,
+// or with the taint removed when doesNotExist is set.
+func withTaint(taints []corev1.Taint, taint corev1.Taint, doesNotExist bool) []corev1.Taint {
+	result := make([]corev1.Taint, 0, len(taints)+1)
+	for _, t := range taints {
+		if !t.MatchTaint(&taint) {
+			result = append(result, t)
+		}
+	}
+	if !doesNotExist {
+		result = append(result, taint)
+	}
+	return result
+}
@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestNodeTaintModule_Validate(t *testing.T) {
+	module := NewNodeTaintModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "valid inputs",
+			inputs: map[string]blackstart.Input{
+				inputSelector: blackstart.NewInputFromValue("pool=system"),
+				inputKey:      blackstart.NewInputFromValue("workload"),
+				inputValue:    blackstart.NewInputFromValue("system"),
+				inputEffect:   blackstart.NewInputFromValue("NoSchedule"),
+			},
+		},
+		{
+			name: "missing effect",
+			inputs: map[string]blackstart.Input{
+				inputKey: blackstart.NewInputFromValue("workload"),
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported effect",
+			inputs: map[string]blackstart.Input{
+				inputKey:    blackstart.NewInputFromValue("workload"),
+				inputEffect: blackstart.NewInputFromValue("NoRun"),
+			},
+			expectError: true,
+		},
+		{
+			name: "missing key",
+			inputs: map[string]blackstart.Input{
+				inputEffect: blackstart.NewInputFromValue("NoSchedule"),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_node_taint", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestNodeTaintModule_CheckSet(t *testing.T) {
+	replaced := testNode("system-2", map[string]string{"pool": "system"})
+	replaced.Spec.Taints = []corev1.Taint{
+		{Key: "workload", Value: "other", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "workload", Value: "other", Effect: corev1.TaintEffectNoExecute},
+	}
+	clientset := fake.NewClientset(
+		testNode("system-1", map[string]string{"pool": "system"}),
+		replaced,
+		testNode("apps-1", map[string]string{"pool": "apps"}),
+	)
+	module := NewNodeTaintModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:   blackstart.NewInputFromValue(clientset),
+		inputSelector: blackstart.NewInputFromValue("pool=system"),
+		inputKey:      blackstart.NewInputFromValue("workload"),
+		inputValue:    blackstart.NewInputFromValue("system"),
+		inputEffect:   blackstart.NewInputFromValue("NoSchedule"),
+	}
+
+	nodeTaints := func(name string) []corev1.Taint {
+		node, err := clientset.CoreV1().Nodes().Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return node.Spec.Taints
+	}
+	taint := corev1.Taint{Key: "workload", Value: "system", Effect: corev1.TaintEffectNoSchedule}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, []corev1.Taint{taint}, nodeTaints("system-1"))
+	assert.ElementsMatch(
+		t, []corev1.Taint{taint, {Key: "workload", Value: "other", Effect: corev1.TaintEffectNoExecute}},
+		nodeTaints("system-2"),
+	)
+	assert.Empty(t, nodeTaints("apps-1"))
+	assert.ElementsMatch(t, []string{"system-1", "system-2"}, ctx.outputs[outputNodes])
+
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	assert.Empty(t, nodeTaints("system-1"))
+	assert.Equal(
+		t, []corev1.Taint{{Key: "workload", Value: "other", Effect: corev1.TaintEffectNoExecute}},
+		nodeTaints("system-2"),
+	)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
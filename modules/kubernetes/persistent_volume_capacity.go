@@ -0,0 +1,333 @@
+package kubernetes
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+// accessModes are the supported access modes of a PersistentVolume.
+var accessModes = map[string]corev1.PersistentVolumeAccessMode{
+	string(corev1.ReadWriteOnce):    corev1.ReadWriteOnce,
+	string(corev1.ReadOnlyMany):     corev1.ReadOnlyMany,
+	string(corev1.ReadWriteMany):    corev1.ReadWriteMany,
+	string(corev1.ReadWriteOncePod): corev1.ReadWriteOncePod,
+}
+
+func init() {
+	blackstart.RegisterModule("kubernetes_persistent_volume_capacity", NewPersistentVolumeCapacityModule)
+}
+
+var _ blackstart.Module = &persistentVolumeCapacityModule{}
+
+func NewPersistentVolumeCapacityModule() blackstart.Module {
+	return &persistentVolumeCapacityModule{}
+}
+
+type persistentVolumeCapacityModule struct{}
+
+func (p *persistentVolumeCapacityModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_persistent_volume_capacity",
+		Name: "Kubernetes PersistentVolume Capacity",
+		Description: util.CleanString(
+			`
+Verifies enough available Kubernetes PersistentVolumes exist for the claims of later operations,
+such as the volumes of a database provisioned statically on local disks. The module does not
+change the cluster. Set fails with the reasons matching volumes are not available, so a bootstrap
+stops before creating workloads whose claims cannot be bound.
+
+**Notes**
+
+- A PersistentVolume matches when it is '''Available''', is in '''storage_class''', has at least
+  '''capacity''', supports all '''access_modes''', and matches '''selector'''.
+- Volumes provisioned dynamically are created for claims on demand, so they are not checked by this
+  module. Use '''kubernetes_storage_class''' to verify their provisioner instead.
+- '''doesNotExist''' is not supported.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized to list PersistentVolumes.",
+			"Required PersistentVolume verbs: `list`.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputStorageClass: {
+				Description: "StorageClass of the PersistentVolumes. Defaults to volumes without a StorageClass.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputCapacity: {
+				Description: "Minimum capacity of each PersistentVolume, such as `100Gi`",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputCount: {
+				Description: "Number of PersistentVolumes that must be available",
+				Type:        reflect.TypeFor[int](),
+				Required:    false,
+				Default:     1,
+			},
+			inputAccessModes: {
+				Description: "Access modes each PersistentVolume must support, such as `ReadWriteOnce`",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+			inputSelector: {
+				Description: "Label selector of the PersistentVolumes, such as `disk=ssd`",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputClient: {
+				Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+				Type:        reflect.TypeFor[kubernetes.Interface](),
+				Required:    false,
+			},
+			inputImpersonate: {
+				Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputPersistentVolumes: {
+				Description: "Names of the available PersistentVolumes that match, sorted by name",
+				Type:        reflect.TypeFor[[]string](),
+			},
+		},
+		Examples: map[string]string{
+			"Local Disks": `id: database-volumes
+module: kubernetes_persistent_volume_capacity
+inputs:
+  storage_class: local-ssd
+  capacity: 500Gi
+  count: 3
+  access_modes:
+    - ReadWriteOnce`,
+		},
+	}
+}
+
+func (p *persistentVolumeCapacityModule) Validate(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported by %s", op.Module)
+	}
+
+	capacityInput, ok := op.Inputs[inputCapacity]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputCapacity)
+	}
+	if capacityInput.IsStatic() {
+		capacity, err := blackstart.InputAs[string](capacityInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputCapacity, err)
+		}
+		if _, err = parseCapacity(capacity); err != nil {
+			return err
+		}
+	}
+
+	if countInput, ok := op.Inputs[inputCount]; ok && countInput.IsStatic() {
+		count, err := blackstart.InputAs[int](countInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputCount, err)
+		}
+		if count < 1 {
+			return fmt.Errorf("input '%s' must be at least 1", inputCount)
+		}
+	}
+
+	if modesInput, ok := op.Inputs[inputAccessModes]; ok && modesInput.IsStatic() {
+		modes, err := blackstart.InputAs[[]string](modesInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputAccessModes, err)
+		}
+		if _, err = accessModesOf(modes); err != nil {
+			return err
+		}
+	}
+
+	if err := validateSelectorInput(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+func (p *persistentVolumeCapacityModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+	result, err := findPersistentVolumes(ctx)
+	if err != nil {
+		return false, err
+	}
+	if len(result.available) < result.count {
+		return false, nil
+	}
+	return true, ctx.Output(outputPersistentVolumes, result.available)
+}
+
+func (p *persistentVolumeCapacityModule) Set(ctx blackstart.ModuleContext) error {
+	result, err := findPersistentVolumes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(result.available) < result.count {
+		return result.err()
+	}
+	return ctx.Output(outputPersistentVolumes, result.available)
+}
+
+// persistentVolumeSearch is the result of finding the PersistentVolumes that match the inputs of
+// the module, with the number of volumes of the StorageClass excluded for each reason.
+type persistentVolumeSearch struct {
+	storageClass string
+	capacity     resource.Quantity
+	count        int
+	available    []string
+
+	unavailable   int
+	tooSmall      int
+	missingAccess int
+	total         int
+}
+
+// findPersistentVolumes lists the PersistentVolumes matching the inputs of the module.
+func findPersistentVolumes(ctx blackstart.ModuleContext) (*persistentVolumeSearch, error) {
+	storageClass, err := blackstart.ContextInputAs[string](ctx, inputStorageClass, false)
+	if err != nil {
+		return nil, err
+	}
+	capacityValue, err := blackstart.ContextInputAs[string](ctx, inputCapacity, true)
+	if err != nil {
+		return nil, err
+	}
+	capacity, err := parseCapacity(capacityValue)
+	if err != nil {
+		return nil, err
+	}
+	count, err := blackstart.ContextInputAs[int](ctx, inputCount, false)
+	if err != nil {
+		return nil, err
+	}
+	if count < 1 {
+		count = 1
+	}
+	modeNames, err := blackstart.ContextInputAs[[]string](ctx, inputAccessModes, false)
+	if err != nil {
+		return nil, err
+	}
+	modes, err := accessModesOf(modeNames)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := blackstart.ContextInputAs[string](ctx, inputSelector, false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = labels.Parse(selector); err != nil {
+		return nil, fmt.Errorf("input '%s' is not a valid label selector: %w", inputSelector, err)
+	}
+	if storageClass != "" {
+		ctx.Resource(storageClass)
+	}
+
+	cc, err := contextClient(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	pvs, err := cc.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PersistentVolumes: %w", err)
+	}
+
+	result := &persistentVolumeSearch{storageClass: storageClass, capacity: capacity, count: count}
+	for _, pv := range pvs.Items {
+		if pv.Spec.StorageClassName != storageClass {
+			continue
+		}
+		result.total++
+		size := pv.Spec.Capacity[corev1.ResourceStorage]
+		switch {
+		case pv.Status.Phase != corev1.VolumeAvailable:
+			result.unavailable++
+		case size.Cmp(capacity) < 0:
+			result.tooSmall++
+		case !hasAccessModes(pv.Spec.AccessModes, modes):
+			result.missingAccess++
+		default:
+			result.available = append(result.available, pv.Name)
+		}
+	}
+	slices.Sort(result.available)
+	return result, nil
+}
+
+// err describes why not enough PersistentVolumes are available.
+func (r *persistentVolumeSearch) err() error {
+	class := "without a StorageClass"
+	if r.storageClass != "" {
+		class = "in StorageClass " + r.storageClass
+	}
+	var reasons []string
+	if r.unavailable > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d not Available", r.unavailable))
+	}
+	if r.tooSmall > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d smaller than %s", r.tooSmall, r.capacity.String()))
+	}
+	if r.missingAccess > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d without the access modes", r.missingAccess))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "none exist")
+	}
+	return fmt.Errorf(
+		"%d of %d PersistentVolumes %s with at least %s are available; of %d PersistentVolumes: %s",
+		len(r.available), r.count, class, r.capacity.String(), r.total, strings.Join(reasons, ", "),
+	)
+}
+
+// parseCapacity parses the capacity input of the module.
+func parseCapacity(value string) (resource.Quantity, error) {
+	capacity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("input '%s' is not a valid quantity: %w", inputCapacity, err)
+	}
+	if capacity.Sign() <= 0 {
+		return resource.Quantity{}, fmt.Errorf("input '%s' must be positive", inputCapacity)
+	}
+	return capacity, nil
+}
+
+// accessModesOf returns the PersistentVolume access modes with the names.
+func accessModesOf(names []string) ([]corev1.PersistentVolumeAccessMode, error) {
+	var modes []corev1.PersistentVolumeAccessMode
+	for _, name := range names {
+		mode, ok := accessModes[name]
+		if !ok {
+			return nil, fmt.Errorf("input '%s' has unsupported access mode: %s", inputAccessModes, name)
+		}
+		modes = append(modes, mode)
+	}
+	return modes, nil
+}
+
+// hasAccessModes reports whether the access modes of a PersistentVolume include all the modes.
+func hasAccessModes(supported, modes []corev1.PersistentVolumeAccessMode) bool {
+	for _, mode := range modes {
+		if !slices.Contains(supported, mode) {
+			return false
+		}
+	}
+	return true
+}
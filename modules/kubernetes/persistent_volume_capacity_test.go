@@ -0,0 +1,149 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestPersistentVolumeCapacityModule_Validate(t *testing.T) {
+	module := NewPersistentVolumeCapacityModule()
+
+	tests := []struct {
+		name         string
+		inputs       map[string]blackstart.Input
+		doesNotExist bool
+		expectError  bool
+	}{
+		{
+			name: "capacity and access modes",
+			inputs: map[string]blackstart.Input{
+				inputStorageClass: blackstart.NewInputFromValue("local-ssd"),
+				inputCapacity:     blackstart.NewInputFromValue("500Gi"),
+				inputCount:        blackstart.NewInputFromValue(3),
+				inputAccessModes:  blackstart.NewInputFromValue([]any{"ReadWriteOnce"}),
+			},
+		},
+		{
+			name:        "missing capacity",
+			inputs:      map[string]blackstart.Input{},
+			expectError: true,
+		},
+		{
+			name: "invalid capacity",
+			inputs: map[string]blackstart.Input{
+				inputCapacity: blackstart.NewInputFromValue("500 GB"),
+			},
+			expectError: true,
+		},
+		{
+			name: "zero count",
+			inputs: map[string]blackstart.Input{
+				inputCapacity: blackstart.NewInputFromValue("500Gi"),
+				inputCount:    blackstart.NewInputFromValue(0),
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported access mode",
+			inputs: map[string]blackstart.Input{
+				inputCapacity:    blackstart.NewInputFromValue("500Gi"),
+				inputAccessModes: blackstart.NewInputFromValue([]any{"ReadWriteSome"}),
+			},
+			expectError: true,
+		},
+		{
+			name: "does not exist",
+			inputs: map[string]blackstart.Input{
+				inputCapacity: blackstart.NewInputFromValue("500Gi"),
+			},
+			doesNotExist: true,
+			expectError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{
+						Module:       "kubernetes_persistent_volume_capacity",
+						Id:           "test",
+						Inputs:       test.inputs,
+						DoesNotExist: test.doesNotExist,
+					},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestPersistentVolumeCapacityModule_CheckSet(t *testing.T) {
+	pv := func(
+		name, class, size string, phase corev1.PersistentVolumePhase, modes ...corev1.PersistentVolumeAccessMode,
+	) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"disk": "ssd"}},
+			Spec: corev1.PersistentVolumeSpec{
+				StorageClassName: class,
+				Capacity:         corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				AccessModes:      modes,
+			},
+			Status: corev1.PersistentVolumeStatus{Phase: phase},
+		}
+	}
+	clientset := fake.NewClientset(
+		pv("local-b", "local-ssd", "1Ti", corev1.VolumeAvailable, corev1.ReadWriteOnce),
+		pv("local-a", "local-ssd", "500Gi", corev1.VolumeAvailable, corev1.ReadWriteOnce),
+		pv("local-c", "local-ssd", "500Gi", corev1.VolumeBound, corev1.ReadWriteOnce),
+		pv("local-d", "local-ssd", "100Gi", corev1.VolumeAvailable, corev1.ReadWriteOnce),
+		pv("local-e", "local-ssd", "500Gi", corev1.VolumeAvailable, corev1.ReadOnlyMany),
+		pv("nfs-a", "nfs", "1Ti", corev1.VolumeAvailable, corev1.ReadWriteMany),
+	)
+	module := NewPersistentVolumeCapacityModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:       blackstart.NewInputFromValue(clientset),
+		inputStorageClass: blackstart.NewInputFromValue("local-ssd"),
+		inputCapacity:     blackstart.NewInputFromValue("500Gi"),
+		inputCount:        blackstart.NewInputFromValue(2),
+		inputAccessModes:  blackstart.NewInputFromValue([]any{"ReadWriteOnce"}),
+		inputSelector:     blackstart.NewInputFromValue("disk=ssd"),
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"local-a", "local-b"}, ctx.outputs[outputPersistentVolumes])
+
+	inputs[inputCount] = blackstart.NewInputFromValue(3)
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.EqualError(
+		t, module.Set(ctx),
+		"2 of 3 PersistentVolumes in StorageClass local-ssd with at least 500Gi are available; of 5 "+
+			"PersistentVolumes: 1 not Available, 1 smaller than 500Gi, 1 without the access modes",
+	)
+
+	inputs[inputStorageClass] = blackstart.NewInputFromValue("")
+	assert.EqualError(
+		t, module.Set(blackstart.InputsToContext(context.Background(), inputs)),
+		"0 of 3 PersistentVolumes without a StorageClass with at least 500Gi are available; of 0 "+
+			"PersistentVolumes: none exist",
+	)
+}
@@ -0,0 +1,327 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("kubernetes_pod_disruption_budget", NewPodDisruptionBudgetModule)
+}
+
+var _ blackstart.Module = &podDisruptionBudgetModule{}
+
+func NewPodDisruptionBudgetModule() blackstart.Module {
+	return &podDisruptionBudgetModule{}
+}
+
+type podDisruptionBudgetModule struct{}
+
+func (p *podDisruptionBudgetModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_pod_disruption_budget",
+		Name: "Kubernetes PodDisruptionBudget",
+		Description: util.CleanString(
+			`
+Ensures a Kubernetes PodDisruptionBudget exists for the pods matching a label selector, limiting
+how many of them voluntary disruptions such as node drains can evict at once.
+
+**Notes**
+
+- Exactly one of '''min_available''' and '''max_unavailable''' must be set. Each is a number of pods,
+  such as '''1''', or a percentage of the matching pods, such as '''50%'''.
+- The selector, '''min_available''', and '''max_unavailable''' of an existing PodDisruptionBudget are
+  updated when they differ.
+- With '''doesNotExist''', the PodDisruptionBudget is deleted. With '''wait_for_deletion''', Set waits until
+  it is removed, such as while finalizers run.
+`,
+		),
+		Requirements: []string{
+			"The target namespace must exist.",
+			"The Kubernetes identity must be authorized for PodDisruptionBudget operations in the target namespace.",
+			"Required PodDisruptionBudget verbs: `get`, `create`, `update`, `delete`.",
+		},
+		Inputs: withDeletionInputs(
+			"PodDisruptionBudget", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the PodDisruptionBudget",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputNamespace: {
+					Description: "Namespace of the PodDisruptionBudget. Defaults to `default`, or to the namespace Blackstart runs in when `--k8s-default-namespace-from-runtime` is enabled.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputSelector: {
+					Description: "Label selector of the pods, such as `app=ingress`",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputMinAvailable: {
+					Description: "Number or percentage of the pods that must remain available. Cannot be used with `max_unavailable`.",
+					Types:       []reflect.Type{reflect.TypeFor[int](), reflect.TypeFor[string]()},
+					Required:    false,
+				},
+				inputMaxUnavailable: {
+					Description: "Number or percentage of the pods that can be unavailable. Cannot be used with `min_available`.",
+					Types:       []reflect.Type{reflect.TypeFor[int](), reflect.TypeFor[string]()},
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputPodDisruptionBudget: {
+				Description: "Name of the PodDisruptionBudget",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Ingress Availability": `id: ingress-pdb
+module: kubernetes_pod_disruption_budget
+inputs:
+  name: ingress
+  namespace: ingress
+  selector: app.kubernetes.io/name=ingress-nginx
+  min_available: 1`,
+			"Percentage Unavailable": `id: dns-pdb
+module: kubernetes_pod_disruption_budget
+inputs:
+  name: coredns
+  namespace: kube-system
+  selector: k8s-app=kube-dns
+  max_unavailable: 25%`,
+		},
+	}
+}
+
+func (p *podDisruptionBudgetModule) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputName, inputSelector} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("input '%s' must be provided", key)
+		}
+		if input.IsStatic() {
+			if _, err := blackstart.InputAs[string](input, true); err != nil {
+				return fmt.Errorf("input '%s' is invalid: %w", key, err)
+			}
+		}
+	}
+	if err := validateSelectorInput(op); err != nil {
+		return err
+	}
+
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+
+	minInput, hasMin := op.Inputs[inputMinAvailable]
+	maxInput, hasMax := op.Inputs[inputMaxUnavailable]
+	if hasMin == hasMax {
+		return fmt.Errorf("exactly one of input '%s' and input '%s' must be provided", inputMinAvailable, inputMaxUnavailable)
+	}
+	for key, input := range map[string]blackstart.Input{inputMinAvailable: minInput, inputMaxUnavailable: maxInput} {
+		if input == nil || !input.IsStatic() {
+			continue
+		}
+		if _, err := parseIntOrString(key, input.Any()); err != nil {
+			return err
+		}
+	}
+
+	return validateClientInputs(op)
+}
+
+func (p *podDisruptionBudgetModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	desired, err := contextPodDisruptionBudget(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return false, err
+	}
+	existing, err := cc.PolicyV1().PodDisruptionBudgets(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ctx.DoesNotExist(), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get PodDisruptionBudget %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+	if ctx.DoesNotExist() {
+		return false, nil
+	}
+
+	converged, err := podDisruptionBudgetConverged(existing, desired)
+	if err != nil || !converged {
+		return false, err
+	}
+	return true, ctx.Output(outputPodDisruptionBudget, existing.Name)
+}
+
+func (p *podDisruptionBudgetModule) Set(ctx blackstart.ModuleContext) error {
+	desired, err := contextPodDisruptionBudget(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return err
+	}
+	pdbi := cc.PolicyV1().PodDisruptionBudgets(desired.Namespace)
+
+	existing, err := pdbi.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if ctx.DoesNotExist() {
+			return nil
+		}
+		if _, err = pdbi.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create PodDisruptionBudget %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return ctx.Output(outputPodDisruptionBudget, desired.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get PodDisruptionBudget %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+
+	if ctx.DoesNotExist() {
+		err = pdbi.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PodDisruptionBudget %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return waitForDeletion(
+			ctx, fmt.Sprintf("PodDisruptionBudget %s/%s", desired.Namespace, desired.Name),
+			func(c context.Context) (metav1.Object, error) {
+				return pdbi.Get(c, desired.Name, metav1.GetOptions{})
+			},
+		)
+	}
+
+	converged, err := podDisruptionBudgetConverged(existing, desired)
+	if err != nil {
+		return err
+	}
+	if !converged {
+		// The update is retried with the latest PodDisruptionBudget on conflicts with other changes.
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				current, getErr := pdbi.Get(ctx, desired.Name, metav1.GetOptions{})
+				if getErr != nil {
+					return getErr
+				}
+				current.Spec.Selector = desired.Spec.Selector
+				current.Spec.MinAvailable = desired.Spec.MinAvailable
+				current.Spec.MaxUnavailable = desired.Spec.MaxUnavailable
+				_, updateErr := pdbi.Update(ctx, current, metav1.UpdateOptions{})
+				return updateErr
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update PodDisruptionBudget %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	}
+	return ctx.Output(outputPodDisruptionBudget, existing.Name)
+}
+
+// contextPodDisruptionBudget returns the PodDisruptionBudget described by the inputs of the module.
+func contextPodDisruptionBudget(ctx blackstart.ModuleContext) (*policyv1.PodDisruptionBudget, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := contextNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selectorValue, err := blackstart.ContextInputAs[string](ctx, inputSelector, true)
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.ParseToLabelSelector(selectorValue)
+	if err != nil {
+		return nil, fmt.Errorf("input '%s' is not a valid label selector: %w", inputSelector, err)
+	}
+	minAvailable, err := contextIntOrString(ctx, inputMinAvailable)
+	if err != nil {
+		return nil, err
+	}
+	maxUnavailable, err := contextIntOrString(ctx, inputMaxUnavailable)
+	if err != nil {
+		return nil, err
+	}
+	if (minAvailable == nil) == (maxUnavailable == nil) {
+		return nil, fmt.Errorf(
+			"exactly one of input '%s' and input '%s' must be set", inputMinAvailable, inputMaxUnavailable,
+		)
+	}
+
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       selector,
+			MinAvailable:   minAvailable,
+			MaxUnavailable: maxUnavailable,
+		},
+	}, nil
+}
+
+// podDisruptionBudgetConverged reports whether the selector and availability of the existing
+// PodDisruptionBudget match the desired PodDisruptionBudget.
+func podDisruptionBudgetConverged(existing, desired *policyv1.PodDisruptionBudget) (bool, error) {
+	if !intOrStringEqual(existing.Spec.MinAvailable, desired.Spec.MinAvailable) ||
+		!intOrStringEqual(existing.Spec.MaxUnavailable, desired.Spec.MaxUnavailable) {
+		return false, nil
+	}
+	if existing.Spec.Selector == nil {
+		return false, nil
+	}
+
+	// Selectors are compared in their canonical string form, which does not depend on how the
+	// selector was written.
+	existingSelector, err := metav1.LabelSelectorAsSelector(existing.Spec.Selector)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse selector of PodDisruptionBudget %s: %w", existing.Name, err)
+	}
+	desiredSelector, err := metav1.LabelSelectorAsSelector(desired.Spec.Selector)
+	if err != nil {
+		return false, err
+	}
+	return existingSelector.String() == desiredSelector.String(), nil
+}
+
+// intOrStringEqual reports whether two optional integer or percentage values are equal.
+func intOrStringEqual(a, b *intstr.IntOrString) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
@@ -0,0 +1,208 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestPodDisruptionBudgetModule_Validate(t *testing.T) {
+	module := NewPodDisruptionBudgetModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "min available",
+			inputs: map[string]blackstart.Input{
+				inputName:         blackstart.NewInputFromValue("ingress"),
+				inputSelector:     blackstart.NewInputFromValue("app=ingress"),
+				inputMinAvailable: blackstart.NewInputFromValue(1),
+			},
+		},
+		{
+			name: "min available decoded from JSON",
+			inputs: map[string]blackstart.Input{
+				inputName:         blackstart.NewInputFromValue("ingress"),
+				inputSelector:     blackstart.NewInputFromValue("app=ingress"),
+				inputMinAvailable: blackstart.NewInputFromValue(float64(2)),
+			},
+		},
+		{
+			name: "fractional min available",
+			inputs: map[string]blackstart.Input{
+				inputName:         blackstart.NewInputFromValue("ingress"),
+				inputSelector:     blackstart.NewInputFromValue("app=ingress"),
+				inputMinAvailable: blackstart.NewInputFromValue(1.5),
+			},
+			expectError: true,
+		},
+		{
+			name: "max unavailable percentage",
+			inputs: map[string]blackstart.Input{
+				inputName:           blackstart.NewInputFromValue("ingress"),
+				inputSelector:       blackstart.NewInputFromValue("app=ingress"),
+				inputMaxUnavailable: blackstart.NewInputFromValue("25%"),
+			},
+		},
+		{
+			name: "missing selector",
+			inputs: map[string]blackstart.Input{
+				inputName:         blackstart.NewInputFromValue("ingress"),
+				inputMinAvailable: blackstart.NewInputFromValue(1),
+			},
+			expectError: true,
+		},
+		{
+			name: "both availability inputs",
+			inputs: map[string]blackstart.Input{
+				inputName:           blackstart.NewInputFromValue("ingress"),
+				inputSelector:       blackstart.NewInputFromValue("app=ingress"),
+				inputMinAvailable:   blackstart.NewInputFromValue(1),
+				inputMaxUnavailable: blackstart.NewInputFromValue(1),
+			},
+			expectError: true,
+		},
+		{
+			name: "no availability input",
+			inputs: map[string]blackstart.Input{
+				inputName:     blackstart.NewInputFromValue("ingress"),
+				inputSelector: blackstart.NewInputFromValue("app=ingress"),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid percentage",
+			inputs: map[string]blackstart.Input{
+				inputName:         blackstart.NewInputFromValue("ingress"),
+				inputSelector:     blackstart.NewInputFromValue("app=ingress"),
+				inputMinAvailable: blackstart.NewInputFromValue("150%"),
+			},
+			expectError: true,
+		},
+		{
+			name: "negative number",
+			inputs: map[string]blackstart.Input{
+				inputName:         blackstart.NewInputFromValue("ingress"),
+				inputSelector:     blackstart.NewInputFromValue("app=ingress"),
+				inputMinAvailable: blackstart.NewInputFromValue(-1),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_pod_disruption_budget", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestPodDisruptionBudgetModule_CheckSet(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewPodDisruptionBudgetModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:       blackstart.NewInputFromValue(clientset),
+		inputName:         blackstart.NewInputFromValue("ingress"),
+		inputNamespace:    blackstart.NewInputFromValue("ingress"),
+		inputSelector:     blackstart.NewInputFromValue("tier=edge,app=ingress"),
+		inputMinAvailable: blackstart.NewInputFromValue("1"),
+	}
+	pdb := func() *policyv1.PodDisruptionBudget {
+		pdb, err := clientset.PolicyV1().PodDisruptionBudgets("ingress").Get(
+			context.Background(), "ingress", metav1.GetOptions{},
+		)
+		require.NoError(t, err)
+		return pdb
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, intstr.FromInt32(1), *pdb().Spec.MinAvailable)
+	assert.Nil(t, pdb().Spec.MaxUnavailable)
+	assert.Equal(t, map[string]string{"app": "ingress", "tier": "edge"}, pdb().Spec.Selector.MatchLabels)
+	assert.Equal(t, "ingress", ctx.outputs[outputPodDisruptionBudget])
+
+	// The selector is compared independently of the order of its requirements.
+	inputs[inputSelector] = blackstart.NewInputFromValue("app=ingress,tier=edge")
+	inputs[inputMinAvailable] = blackstart.NewInputFromValue(1)
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Switching to max_unavailable updates the PodDisruptionBudget in place.
+	delete(inputs, inputMinAvailable)
+	inputs[inputMaxUnavailable] = blackstart.NewInputFromValue("50%")
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(ctx))
+	assert.Nil(t, pdb().Spec.MinAvailable)
+	assert.Equal(t, intstr.FromString("50%"), *pdb().Spec.MaxUnavailable)
+
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestPodDisruptionBudgetModule_SetRetriesConflicts(t *testing.T) {
+	minAvailable := intstr.FromInt32(1)
+	clientset := fake.NewClientset(
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "ingress"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &minAvailable,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "ingress"}},
+			},
+		},
+	)
+	updates := conflictOnFirstUpdate(clientset, "policy", "poddisruptionbudgets")
+	inputs := map[string]blackstart.Input{
+		inputClient:       blackstart.NewInputFromValue(clientset),
+		inputName:         blackstart.NewInputFromValue("ingress"),
+		inputNamespace:    blackstart.NewInputFromValue("ingress"),
+		inputSelector:     blackstart.NewInputFromValue("app=ingress"),
+		inputMinAvailable: blackstart.NewInputFromValue("2"),
+	}
+
+	require.NoError(t, NewPodDisruptionBudgetModule().Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(t, 2, updates())
+	pdb, err := clientset.PolicyV1().PodDisruptionBudgets("ingress").Get(
+		context.Background(), "ingress", metav1.GetOptions{},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, intstr.FromInt32(2), *pdb.Spec.MinAvailable)
+}
@@ -0,0 +1,323 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("kubernetes_priority_class", NewPriorityClassModule)
+}
+
+var _ blackstart.Module = &priorityClassModule{}
+
+func NewPriorityClassModule() blackstart.Module {
+	return &priorityClassModule{}
+}
+
+// maxUserPriority is the highest value of a PriorityClass not reserved for system classes.
+const maxUserPriority = 1000000000
+
+// preemptionPolicies are the supported preemption policies.
+var preemptionPolicies = map[string]corev1.PreemptionPolicy{
+	string(corev1.PreemptLowerPriority): corev1.PreemptLowerPriority,
+	string(corev1.PreemptNever):         corev1.PreemptNever,
+}
+
+type priorityClassModule struct{}
+
+func (p *priorityClassModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_priority_class",
+		Name: "Kubernetes PriorityClass",
+		Description: util.CleanString(
+			`
+Ensures a Kubernetes PriorityClass exists with the value and preemption policy, such as a priority
+for platform workloads that must be scheduled before applications.
+
+**Notes**
+
+- The value and preemption policy of a PriorityClass cannot be changed. When either differs, the
+  PriorityClass is deleted and created again. Existing pods keep the priority they were admitted
+  with.
+- Only one PriorityClass in a cluster can be the global default.
+- With '''doesNotExist''', the PriorityClass is deleted. With '''wait_for_deletion''', Set waits until it is
+  removed, such as while finalizers run.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized for PriorityClass operations.",
+			"Required PriorityClass verbs: `get`, `create`, `update`, `delete`.",
+		},
+		Inputs: withDeletionInputs(
+			"PriorityClass", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the PriorityClass",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputValue: {
+					Description: fmt.Sprintf("Priority of pods using the PriorityClass, up to %d", maxUserPriority),
+					Type:        reflect.TypeFor[int](),
+					Required:    true,
+				},
+				inputPreemptionPolicy: {
+					Description: "Preemption policy of pods using the PriorityClass: `PreemptLowerPriority` or `Never`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     string(corev1.PreemptLowerPriority),
+				},
+				inputGlobalDefault: {
+					Description: "Use the PriorityClass for pods without a priority class name",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputDescription: {
+					Description: "Description of when the PriorityClass should be used",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputPriorityClass: {
+				Description: "Name of the PriorityClass",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Platform Priority": `id: platform-priority
+module: kubernetes_priority_class
+inputs:
+  name: platform-critical
+  value: 100000
+  description: Platform components scheduled before applications`,
+		},
+	}
+}
+
+func (p *priorityClassModule) Validate(op blackstart.Operation) error {
+	nameInput, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputName)
+	}
+	if nameInput.IsStatic() {
+		if _, err := blackstart.InputAs[string](nameInput, true); err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputName, err)
+		}
+	}
+
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+
+	valueInput, ok := op.Inputs[inputValue]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputValue)
+	}
+	if valueInput.IsStatic() {
+		value, err := blackstart.InputAs[int](valueInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputValue, err)
+		}
+		if err = validatePriority(value); err != nil {
+			return err
+		}
+	}
+
+	if policyInput, ok := op.Inputs[inputPreemptionPolicy]; ok && policyInput.IsStatic() {
+		policy, err := blackstart.InputAs[string](policyInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputPreemptionPolicy, err)
+		}
+		if _, ok = preemptionPolicies[policy]; !ok {
+			return fmt.Errorf("input '%s' has unsupported preemption policy: %s", inputPreemptionPolicy, policy)
+		}
+	}
+
+	return validateClientInputs(op)
+}
+
+func (p *priorityClassModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	desired, err := contextPriorityClass(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(desired.Name)
+
+	cc, err := contextClient(ctx, "")
+	if err != nil {
+		return false, err
+	}
+	existing, err := cc.SchedulingV1().PriorityClasses().Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ctx.DoesNotExist(), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get PriorityClass %s: %w", desired.Name, err)
+	}
+	if ctx.DoesNotExist() {
+		return false, nil
+	}
+
+	if priorityClassReplaced(existing, desired) || priorityClassUpdated(existing, desired) {
+		return false, nil
+	}
+	return true, ctx.Output(outputPriorityClass, existing.Name)
+}
+
+func (p *priorityClassModule) Set(ctx blackstart.ModuleContext) error {
+	desired, err := contextPriorityClass(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Resource(desired.Name)
+
+	cc, err := contextClient(ctx, "")
+	if err != nil {
+		return err
+	}
+	pci := cc.SchedulingV1().PriorityClasses()
+
+	existing, err := pci.Get(ctx, desired.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get PriorityClass %s: %w", desired.Name, err)
+	}
+	if apierrors.IsNotFound(err) {
+		existing = nil
+	}
+
+	if existing != nil && (ctx.DoesNotExist() || priorityClassReplaced(existing, desired)) {
+		err = pci.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete PriorityClass %s: %w", desired.Name, err)
+		}
+		existing = nil
+	}
+	if ctx.DoesNotExist() {
+		return waitForDeletion(
+			ctx, fmt.Sprintf("PriorityClass %s", desired.Name), func(c context.Context) (metav1.Object, error) {
+				return pci.Get(c, desired.Name, metav1.GetOptions{})
+			},
+		)
+	}
+
+	if existing == nil {
+		if _, err = pci.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create PriorityClass %s: %w", desired.Name, err)
+		}
+		return ctx.Output(outputPriorityClass, desired.Name)
+	}
+
+	if priorityClassUpdated(existing, desired) {
+		// The update is retried with the latest PriorityClass on conflicts with other changes.
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				current, getErr := pci.Get(ctx, desired.Name, metav1.GetOptions{})
+				if getErr != nil {
+					return getErr
+				}
+				current.GlobalDefault = desired.GlobalDefault
+				current.Description = desired.Description
+				_, updateErr := pci.Update(ctx, current, metav1.UpdateOptions{})
+				return updateErr
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update PriorityClass %s: %w", desired.Name, err)
+		}
+	}
+	return ctx.Output(outputPriorityClass, existing.Name)
+}
+
+// contextPriorityClass returns the PriorityClass described by the inputs of the module.
+func contextPriorityClass(ctx blackstart.ModuleContext) (*schedulingv1.PriorityClass, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, err
+	}
+	value, err := blackstart.ContextInputAs[int](ctx, inputValue, true)
+	if err != nil {
+		return nil, err
+	}
+	if err = validatePriority(value); err != nil {
+		return nil, err
+	}
+	policyName, err := blackstart.ContextInputAs[string](ctx, inputPreemptionPolicy, false)
+	if err != nil {
+		return nil, err
+	}
+	if policyName == "" {
+		policyName = string(corev1.PreemptLowerPriority)
+	}
+	policy, ok := preemptionPolicies[policyName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported preemption policy: %s", policyName)
+	}
+	globalDefault, err := blackstart.ContextInputAs[bool](ctx, inputGlobalDefault, false)
+	if err != nil {
+		return nil, err
+	}
+	description, err := blackstart.ContextInputAs[string](ctx, inputDescription, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schedulingv1.PriorityClass{
+		ObjectMeta:       metav1.ObjectMeta{Name: name},
+		Value:            int32(value),
+		PreemptionPolicy: &policy,
+		GlobalDefault:    globalDefault,
+		Description:      description,
+	}, nil
+}
+
+// validatePriority returns an error when the value is not a valid priority for a PriorityClass
+// that is not a system class.
+func validatePriority(value int) error {
+	if value < math.MinInt32 || value > maxUserPriority {
+		return fmt.Errorf("input '%s' must be between %d and %d", inputValue, math.MinInt32, maxUserPriority)
+	}
+	return nil
+}
+
+// priorityClassReplaced reports whether the PriorityClass differs in fields that cannot be updated.
+func priorityClassReplaced(existing, desired *schedulingv1.PriorityClass) bool {
+	existingPolicy := corev1.PreemptLowerPriority
+	if existing.PreemptionPolicy != nil {
+		existingPolicy = *existing.PreemptionPolicy
+	}
+	return existing.Value != desired.Value || existingPolicy != *desired.PreemptionPolicy
+}
+
+// priorityClassUpdated reports whether the PriorityClass differs in fields that can be updated.
+func priorityClassUpdated(existing, desired *schedulingv1.PriorityClass) bool {
+	return existing.GlobalDefault != desired.GlobalDefault || existing.Description != desired.Description
+}
@@ -0,0 +1,162 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestPriorityClassModule_Validate(t *testing.T) {
+	module := NewPriorityClassModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "valid inputs",
+			inputs: map[string]blackstart.Input{
+				inputName:             blackstart.NewInputFromValue("platform"),
+				inputValue:            blackstart.NewInputFromValue(100000),
+				inputPreemptionPolicy: blackstart.NewInputFromValue("Never"),
+			},
+		},
+		{
+			name: "missing value",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("platform"),
+			},
+			expectError: true,
+		},
+		{
+			name: "system priority value",
+			inputs: map[string]blackstart.Input{
+				inputName:  blackstart.NewInputFromValue("platform"),
+				inputValue: blackstart.NewInputFromValue(2000000000),
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported preemption policy",
+			inputs: map[string]blackstart.Input{
+				inputName:             blackstart.NewInputFromValue("platform"),
+				inputValue:            blackstart.NewInputFromValue(1000),
+				inputPreemptionPolicy: blackstart.NewInputFromValue("Always"),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_priority_class", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestPriorityClassModule_CheckSet(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewPriorityClassModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:      blackstart.NewInputFromValue(clientset),
+		inputName:        blackstart.NewInputFromValue("platform"),
+		inputValue:       blackstart.NewInputFromValue(100000),
+		inputDescription: blackstart.NewInputFromValue("Platform components"),
+	}
+	priorityClass := func() *schedulingv1.PriorityClass {
+		pc, err := clientset.SchedulingV1().PriorityClasses().Get(context.Background(), "platform", metav1.GetOptions{})
+		require.NoError(t, err)
+		return pc
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, int32(100000), priorityClass().Value)
+	assert.Equal(t, corev1.PreemptLowerPriority, *priorityClass().PreemptionPolicy)
+	assert.Equal(t, "platform", ctx.outputs[outputPriorityClass])
+
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// The description is updated in place.
+	inputs[inputDescription] = blackstart.NewInputFromValue("Platform services")
+	inputs[inputGlobalDefault] = blackstart.NewInputFromValue(true)
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, "Platform services", priorityClass().Description)
+	assert.True(t, priorityClass().GlobalDefault)
+
+	// The value and preemption policy are immutable, so the PriorityClass is replaced.
+	inputs[inputValue] = blackstart.NewInputFromValue(200000)
+	inputs[inputPreemptionPolicy] = blackstart.NewInputFromValue("Never")
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, int32(200000), priorityClass().Value)
+	assert.Equal(t, corev1.PreemptNever, *priorityClass().PreemptionPolicy)
+	assert.Equal(t, "Platform services", priorityClass().Description)
+
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestPriorityClassModule_SetRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&schedulingv1.PriorityClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "platform"},
+			Value:       100000,
+			Description: "Platform components",
+		},
+	)
+	updates := conflictOnFirstUpdate(clientset, "scheduling.k8s.io", "priorityclasses")
+	inputs := map[string]blackstart.Input{
+		inputClient:      blackstart.NewInputFromValue(clientset),
+		inputName:        blackstart.NewInputFromValue("platform"),
+		inputValue:       blackstart.NewInputFromValue(100000),
+		inputDescription: blackstart.NewInputFromValue("Platform services"),
+	}
+
+	require.NoError(t, NewPriorityClassModule().Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(t, 2, updates())
+	pc, err := clientset.SchedulingV1().PriorityClasses().Get(context.Background(), "platform", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "Platform services", pc.Description)
+}
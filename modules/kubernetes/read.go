@@ -0,0 +1,179 @@
+package kubernetes
+
+import (
+	"fmt"
+	"reflect"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/pezops/blackstart"
+)
+
+// readInputs returns the inputs of a module that reads the keys of an existing resource of the
+// kind, such as a Secret or ConfigMap.
+func readInputs(kind string) map[string]blackstart.InputValue {
+	return map[string]blackstart.InputValue{
+		inputName: {
+			Description: fmt.Sprintf("Name of the %s", kind),
+			Type:        reflect.TypeFor[string](),
+			Required:    true,
+		},
+		inputNamespace: {
+			Description: fmt.Sprintf(
+				"Namespace of the %s. Defaults to `default`, or to the namespace Blackstart runs in when "+
+					"`--k8s-default-namespace-from-runtime` is enabled.", kind,
+			),
+			Type:     reflect.TypeFor[string](),
+			Required: false,
+		},
+		inputKey: {
+			Description: "Key to read into the `value` output.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+		},
+		inputKeys: {
+			Description: fmt.Sprintf(
+				"Keys to read into the `values` output. If neither `key` nor `keys` is set, every key of the %s "+
+					"is read.", kind,
+			),
+			Type:     reflect.TypeFor[[]string](),
+			Required: false,
+		},
+		inputEncoding: {
+			Description: "Encoding of the outputs: `text` or `base64`.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+			Default:     valueEncodingText,
+		},
+		inputClient: {
+			Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+			Type:        reflect.TypeFor[kubernetes.Interface](),
+			Required:    false,
+		},
+		inputImpersonate: {
+			Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+		},
+	}
+}
+
+// readOutputs returns the outputs of a module that reads the keys of an existing resource.
+// Outputs read from a Secret are sensitive.
+func readOutputs(kind string, sensitive bool) map[string]blackstart.OutputValue {
+	return map[string]blackstart.OutputValue{
+		outputValue: {
+			Description: "Value of the `key` input, encoded with `encoding`. Only set when `key` is set.",
+			Type:        reflect.TypeFor[string](),
+			Sensitive:   sensitive,
+		},
+		outputValues: {
+			Description: fmt.Sprintf("Values of the keys read from the %s by key, encoded with `encoding`.", kind),
+			Type:        reflect.TypeFor[map[string]string](),
+			Sensitive:   sensitive,
+		},
+	}
+}
+
+// validateReadInputs validates the inputs of a module that reads the keys of an existing resource.
+// The resource is never changed, so doesNotExist is not supported.
+func validateReadInputs(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported by %s", op.Module)
+	}
+
+	nameInput, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputName)
+	}
+	if nameInput.IsStatic() {
+		name, err := blackstart.InputAs[string](nameInput, true)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputName, err)
+		}
+		if name == "" {
+			return fmt.Errorf("input '%s' must be non-empty", inputName)
+		}
+	}
+
+	if keysInput, ok := op.Inputs[inputKeys]; ok && keysInput.IsStatic() {
+		keys, err := blackstart.InputAs[[]string](keysInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputKeys, err)
+		}
+		for _, key := range keys {
+			if key == "" {
+				return fmt.Errorf("input '%s' must not contain empty keys", inputKeys)
+			}
+		}
+	}
+
+	if _, _, err := operationValueEncoding(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+// contextReadResource returns the namespace and name of the resource read by a module, and the
+// client for the namespace.
+func contextReadResource(ctx blackstart.ModuleContext) (kubernetes.Interface, string, string, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, "", "", err
+	}
+	namespace, err := contextNamespace(ctx)
+	if err != nil {
+		return nil, "", "", err
+	}
+	ctx.Resource(namespace + "/" + name)
+
+	cc, err := contextClient(ctx, namespace)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return cc, namespace, name, nil
+}
+
+// outputReadValues emits the values of the keys requested by the key and keys inputs, or of every
+// key when neither is set. The description identifies the resource in errors, such as
+// `Secret 'default/app'`.
+func outputReadValues(ctx blackstart.ModuleContext, description string, data map[string][]byte) error {
+	encoding, err := contextValueEncoding(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := blackstart.ContextInputAs[string](ctx, inputKey, false)
+	if err != nil {
+		return err
+	}
+	var keys []string
+	if input, iErr := ctx.Input(inputKeys); iErr == nil && input.Any() != nil {
+		if keys, err = blackstart.InputAs[[]string](input, false); err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputKeys, err)
+		}
+	}
+	if key != "" {
+		keys = append(keys, key)
+	}
+	if key == "" && keys == nil {
+		for k := range data {
+			keys = append(keys, k)
+		}
+	}
+
+	values := make(map[string]string, len(keys))
+	for _, k := range keys {
+		value, ok := data[k]
+		if !ok {
+			return fmt.Errorf("key '%s' not found in %s", k, description)
+		}
+		values[k] = encodeValue(encoding, value)
+	}
+
+	if key != "" {
+		if err = ctx.Output(outputValue, values[key]); err != nil {
+			return err
+		}
+	}
+	return ctx.Output(outputValues, values)
+}
@@ -0,0 +1,304 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+// resourceQuotaScopes are the supported scopes of a ResourceQuota.
+var resourceQuotaScopes = map[string]corev1.ResourceQuotaScope{
+	string(corev1.ResourceQuotaScopeTerminating):               corev1.ResourceQuotaScopeTerminating,
+	string(corev1.ResourceQuotaScopeNotTerminating):            corev1.ResourceQuotaScopeNotTerminating,
+	string(corev1.ResourceQuotaScopeBestEffort):                corev1.ResourceQuotaScopeBestEffort,
+	string(corev1.ResourceQuotaScopeNotBestEffort):             corev1.ResourceQuotaScopeNotBestEffort,
+	string(corev1.ResourceQuotaScopePriorityClass):             corev1.ResourceQuotaScopePriorityClass,
+	string(corev1.ResourceQuotaScopeCrossNamespacePodAffinity): corev1.ResourceQuotaScopeCrossNamespacePodAffinity,
+}
+
+func init() {
+	blackstart.RegisterModule("kubernetes_resource_quota", NewResourceQuotaModule)
+}
+
+var _ blackstart.Module = &resourceQuotaModule{}
+
+func NewResourceQuotaModule() blackstart.Module {
+	return &resourceQuotaModule{}
+}
+
+type resourceQuotaModule struct{}
+
+func (r *resourceQuotaModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_resource_quota",
+		Name: "Kubernetes ResourceQuota",
+		Description: util.CleanString(
+			`
+Ensures a Kubernetes ResourceQuota exists in a namespace, limiting the total resources that the
+namespace can use, such as the CPU and memory requested by its pods or the number of its services.
+
+**Notes**
+
+- Quantities are compared by value, so '''1''' and '''1000m''' are the same CPU quantity.
+- The hard limits and scopes of an existing ResourceQuota are updated when they differ. Resources
+  that are limited but not in '''hard''' are removed.
+- With '''doesNotExist''', the ResourceQuota is deleted. With '''wait_for_deletion''', Set waits until it
+  is removed, such as while finalizers run.
+`,
+		),
+		Requirements: []string{
+			"The target namespace must exist.",
+			"The Kubernetes identity must be authorized for ResourceQuota operations in the target namespace.",
+			"Required ResourceQuota verbs: `get`, `create`, `update`, `delete`.",
+		},
+		Inputs: withDeletionInputs(
+			"ResourceQuota", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the ResourceQuota",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputNamespace: {
+					Description: "Namespace of the ResourceQuota. Defaults to `default`, or to the namespace Blackstart runs in when `--k8s-default-namespace-from-runtime` is enabled.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputHard: {
+					Description: "Hard limits by resource name, such as `requests.cpu: 4` or `count/services: 10`",
+					Type:        reflect.TypeFor[map[string]any](),
+					Required:    true,
+				},
+				inputScopes: {
+					Description: "Scopes of the pods the quota applies to, such as `NotBestEffort`. Defaults to all pods.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputResourceQuota: {
+				Description: "Name of the ResourceQuota",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Tenant Quota": `id: tenant-quota
+module: kubernetes_resource_quota
+inputs:
+  name: compute
+  namespace: tenant-a
+  hard:
+    requests.cpu: 4
+    requests.memory: 8Gi
+    limits.memory: 16Gi
+    pods: 50`,
+		},
+	}
+}
+
+func (r *resourceQuotaModule) Validate(op blackstart.Operation) error {
+	nameInput, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputName)
+	}
+	if nameInput.IsStatic() {
+		if _, err := blackstart.InputAs[string](nameInput, true); err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputName, err)
+		}
+	}
+
+	hardInput, ok := op.Inputs[inputHard]
+	if !ok {
+		return fmt.Errorf("input '%s' must be provided", inputHard)
+	}
+	if hardInput.IsStatic() {
+		hard, err := inputResourceList(hardInput, inputHard)
+		if err != nil {
+			return err
+		}
+		if len(hard) == 0 {
+			return fmt.Errorf("input '%s' must not be empty", inputHard)
+		}
+	}
+
+	if scopesInput, ok := op.Inputs[inputScopes]; ok && scopesInput.IsStatic() {
+		scopes, err := blackstart.InputAs[[]string](scopesInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputScopes, err)
+		}
+		if _, err = resourceQuotaScopesOf(scopes); err != nil {
+			return err
+		}
+	}
+
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+func (r *resourceQuotaModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	desired, err := contextResourceQuota(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return false, err
+	}
+	existing, err := cc.CoreV1().ResourceQuotas(desired.Namespace).Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ctx.DoesNotExist(), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get ResourceQuota %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+	if ctx.DoesNotExist() {
+		return false, nil
+	}
+
+	if !resourceQuotaMatches(existing, desired) {
+		return false, nil
+	}
+	return true, ctx.Output(outputResourceQuota, existing.Name)
+}
+
+func (r *resourceQuotaModule) Set(ctx blackstart.ModuleContext) error {
+	desired, err := contextResourceQuota(ctx)
+	if err != nil {
+		return err
+	}
+	ctx.Resource(desired.Namespace + "/" + desired.Name)
+
+	cc, err := contextClient(ctx, desired.Namespace)
+	if err != nil {
+		return err
+	}
+	rqi := cc.CoreV1().ResourceQuotas(desired.Namespace)
+
+	existing, err := rqi.Get(ctx, desired.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if ctx.DoesNotExist() {
+			return nil
+		}
+		if _, err = rqi.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create ResourceQuota %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return ctx.Output(outputResourceQuota, desired.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get ResourceQuota %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+
+	if ctx.DoesNotExist() {
+		err = rqi.Delete(ctx, desired.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ResourceQuota %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+		return waitForDeletion(
+			ctx, fmt.Sprintf("ResourceQuota %s/%s", desired.Namespace, desired.Name),
+			func(c context.Context) (metav1.Object, error) {
+				return rqi.Get(c, desired.Name, metav1.GetOptions{})
+			},
+		)
+	}
+
+	if !resourceQuotaMatches(existing, desired) {
+		// The update is retried with the latest ResourceQuota on conflicts with other changes, such as
+		// the quota controller updating the usage in its status.
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				current, getErr := rqi.Get(ctx, desired.Name, metav1.GetOptions{})
+				if getErr != nil {
+					return getErr
+				}
+				current.Spec.Hard = desired.Spec.Hard
+				current.Spec.Scopes = desired.Spec.Scopes
+				_, updateErr := rqi.Update(ctx, current, metav1.UpdateOptions{})
+				return updateErr
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update ResourceQuota %s/%s: %w", desired.Namespace, desired.Name, err)
+		}
+	}
+	return ctx.Output(outputResourceQuota, existing.Name)
+}
+
+// contextResourceQuota returns the ResourceQuota described by the inputs of the module.
+func contextResourceQuota(ctx blackstart.ModuleContext) (*corev1.ResourceQuota, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := contextNamespace(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hard, err := contextResourceList(ctx, inputHard)
+	if err != nil {
+		return nil, err
+	}
+	if len(hard) == 0 {
+		return nil, fmt.Errorf("input '%s' must not be empty", inputHard)
+	}
+	scopeNames, err := blackstart.ContextInputAs[[]string](ctx, inputScopes, false)
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := resourceQuotaScopesOf(scopeNames)
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard, Scopes: scopes},
+	}, nil
+}
+
+// resourceQuotaScopesOf returns the ResourceQuota scopes with the names.
+func resourceQuotaScopesOf(names []string) ([]corev1.ResourceQuotaScope, error) {
+	var scopes []corev1.ResourceQuotaScope
+	for _, name := range names {
+		scope, ok := resourceQuotaScopes[name]
+		if !ok {
+			return nil, fmt.Errorf("input '%s' has unsupported scope: %s", inputScopes, name)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// resourceQuotaMatches reports whether the hard limits and scopes of the ResourceQuota match the
+// desired ResourceQuota. Quantities are compared by value.
+func resourceQuotaMatches(existing, desired *corev1.ResourceQuota) bool {
+	return apiequality.Semantic.DeepEqual(existing.Spec.Hard, desired.Spec.Hard) &&
+		apiequality.Semantic.DeepEqual(existing.Spec.Scopes, desired.Spec.Scopes)
+}
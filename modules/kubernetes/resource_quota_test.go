@@ -0,0 +1,167 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestResourceQuotaModule_Validate(t *testing.T) {
+	module := NewResourceQuotaModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "hard limits",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("compute"),
+				inputHard: blackstart.NewInputFromValue(map[string]any{"requests.cpu": 4, "requests.memory": "8Gi"}),
+			},
+		},
+		{
+			name: "scopes",
+			inputs: map[string]blackstart.Input{
+				inputName:   blackstart.NewInputFromValue("compute"),
+				inputHard:   blackstart.NewInputFromValue(map[string]any{"pods": 10}),
+				inputScopes: blackstart.NewInputFromValue([]any{"NotBestEffort"}),
+			},
+		},
+		{
+			name: "missing hard",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("compute"),
+			},
+			expectError: true,
+		},
+		{
+			name: "empty hard",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("compute"),
+				inputHard: blackstart.NewInputFromValue(map[string]any{}),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid quantity",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("compute"),
+				inputHard: blackstart.NewInputFromValue(map[string]any{"requests.memory": "8 GB"}),
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported scope",
+			inputs: map[string]blackstart.Input{
+				inputName:   blackstart.NewInputFromValue("compute"),
+				inputHard:   blackstart.NewInputFromValue(map[string]any{"pods": 10}),
+				inputScopes: blackstart.NewInputFromValue([]any{"Spot"}),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_resource_quota", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestResourceQuotaModule_CheckSet(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewResourceQuotaModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("compute"),
+		inputNamespace: blackstart.NewInputFromValue("tenant-a"),
+		inputHard:      blackstart.NewInputFromValue(map[string]any{"requests.cpu": 4, "pods": 50}),
+	}
+	quota := func() *corev1.ResourceQuota {
+		rq, err := clientset.CoreV1().ResourceQuotas("tenant-a").Get(context.Background(), "compute", metav1.GetOptions{})
+		require.NoError(t, err)
+		return rq
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, module.Set(ctx))
+	cpu := quota().Spec.Hard[corev1.ResourceRequestsCPU]
+	assert.Equal(t, "4", cpu.String())
+	assert.Equal(t, int64(50), quota().Spec.Hard.Pods().Value())
+	assert.Equal(t, "compute", ctx.outputs[outputResourceQuota])
+
+	// Quantities are compared by value.
+	inputs[inputHard] = blackstart.NewInputFromValue(map[string]any{"requests.cpu": "4000m", "pods": "50"})
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Changed limits and scopes update the ResourceQuota, removing resources no longer limited.
+	inputs[inputHard] = blackstart.NewInputFromValue(map[string]any{"requests.memory": "8Gi"})
+	inputs[inputScopes] = blackstart.NewInputFromValue([]any{"NotBestEffort"})
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(
+		t, corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("8Gi")}, quota().Spec.Hard,
+	)
+	assert.Equal(t, []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotBestEffort}, quota().Spec.Scopes)
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestResourceQuotaModule_SetRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "tenant-a"},
+			Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("10")}},
+		},
+	)
+	updates := conflictOnFirstUpdate(clientset, "", "resourcequotas")
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("compute"),
+		inputNamespace: blackstart.NewInputFromValue("tenant-a"),
+		inputHard:      blackstart.NewInputFromValue(map[string]any{"pods": 20}),
+	}
+
+	require.NoError(t, NewResourceQuotaModule().Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(t, 2, updates())
+	rq, err := clientset.CoreV1().ResourceQuotas("tenant-a").Get(context.Background(), "compute", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), rq.Spec.Hard.Pods().Value())
+}
@@ -1,6 +1,7 @@
 package kubernetes
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 
@@ -8,8 +9,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	applycorev1 "k8s.io/client-go/applyconfigurations/core/v1"
 	"k8s.io/client-go/kubernetes"
 	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/pezops/blackstart"
 )
@@ -35,16 +39,109 @@ type secret struct {
 	s *corev1.Secret
 }
 
-// Update updates the Secret resource in Kubernetes to match the current state of the Secret resource.
-func (s *secret) Update(ctx blackstart.ModuleContext) error {
-	var err error
-	s.s, err = s.si.Update(ctx, s.s, metav1.UpdateOptions{})
-	return err
+// ApplyValue sets a key of the Secret with server-side apply. The other fields owned by the
+// Blackstart field manager are applied with the key, so they are kept.
+func (s *secret) ApplyValue(ctx blackstart.ModuleContext, key string, value []byte, opts metav1.ApplyOptions) error {
+	err := s.apply(
+		ctx, opts, func(_ *corev1.Secret, cfg *applycorev1.SecretApplyConfiguration) {
+			cfg.WithData(map[string][]byte{key: value})
+		},
+	)
+	if err != nil {
+		return applyError(err)
+	}
+	return s.updateContentHash(ctx)
+}
+
+// apply applies the fields of the Secret owned by the Blackstart field manager with the changes
+// made by update. The owned fields are read from the latest Secret and applied against its resource
+// version, so a key set by another writer since the Secret was read, such as an operation of
+// another workflow, is not lost. When the Secret is changed between the read and the apply, the
+// apply is retried.
+func (s *secret) apply(
+	ctx blackstart.ModuleContext, opts metav1.ApplyOptions,
+	update func(current *corev1.Secret, cfg *applycorev1.SecretApplyConfiguration),
+) error {
+	return retry.OnError(
+		retry.DefaultRetry, staleResourceVersion, func() error {
+			current, err := s.si.Get(ctx, s.s.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			cfg, err := applycorev1.ExtractSecret(current, fieldManager)
+			if err != nil {
+				return fmt.Errorf(
+					"unable to extract managed fields of Secret '%s/%s': %w", current.Namespace, current.Name, err,
+				)
+			}
+			update(current, cfg)
+			if current.ResourceVersion != "" {
+				cfg.WithResourceVersion(current.ResourceVersion)
+			}
+			sec, err := s.si.Apply(ctx, cfg, opts)
+			if err != nil {
+				return err
+			}
+			s.s = sec
+			return nil
+		},
+	)
+}
+
+// RemoveValue removes a key from the Secret. The key is removed with a JSON patch, since
+// server-side apply does not remove keys that are also owned by other field managers. The patch is
+// retried with the latest Secret on conflicts with other writers.
+func (s *secret) RemoveValue(ctx blackstart.ModuleContext, key string) error {
+	err := retry.RetryOnConflict(
+		retry.DefaultRetry, func() error {
+			current, err := s.si.Get(ctx, s.s.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			s.s = current
+			if _, ok := current.Data[key]; !ok {
+				return nil
+			}
+			sec, err := s.si.Patch(
+				ctx, current.Name, types.JSONPatchType, removeKeyPatch("data", key),
+				metav1.PatchOptions{FieldManager: fieldManager},
+			)
+			if err != nil {
+				return err
+			}
+			s.s = sec
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+	return s.updateContentHash(ctx)
+}
+
+// updateContentHash updates the content hash annotation of the Secret after its values changed,
+// when the Secret has the annotation. The annotation is owned by Blackstart, so ownership of it is
+// always taken.
+func (s *secret) updateContentHash(ctx blackstart.ModuleContext) error {
+	if !hasContentHash(s.s.Annotations) || s.s.Annotations[contentHashAnnotation] == secretContentHash(s.s) {
+		return nil
+	}
+	err := s.apply(
+		ctx, metav1.ApplyOptions{FieldManager: fieldManager, Force: true},
+		func(current *corev1.Secret, cfg *applycorev1.SecretApplyConfiguration) {
+			cfg.WithAnnotations(map[string]string{contentHashAnnotation: secretContentHash(current)})
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update content hash of Secret '%s/%s': %w", s.s.Namespace, s.s.Name, err)
+	}
+	return nil
 }
 
-// Delete deletes the Secret resource from Kubernetes.
+// Delete deletes the Secret resource from Kubernetes. The delete is retried on conflicts with other
+// writers.
 func (s *secret) Delete(ctx blackstart.ModuleContext) error {
-	return s.si.Delete(ctx, s.s.Name, metav1.DeleteOptions{})
+	return deleteRetryingConflicts(ctx, s.s.Name, s.si.Delete)
 }
 
 // secretModule is a Blackstart module that manages a Kubernetes Secret resource.
@@ -65,43 +162,67 @@ Manages a Kubernetes Secret resource, but not content.
 - Once a Secret is set to be immutable, values cannot be set or changed. Do not set a Secret to be 
   immutable before setting the values. See [Immutable Secrets](https://kubernetes.io/docs/concepts/configuration/secret/#secret-immutable) 
   for more information.
+- When '''content_hash''' is enabled, the '''blackstart.pezops.github.io/content-hash''' annotation holds a
+  SHA-256 hash of the keys and values of the Secret. The '''kubernetes_secret_value''' module updates the
+  annotation when it changes a key, so controllers that watch the annotation, such as a reloader, can
+  roll out workloads that use the Secret.
+- With '''doesNotExist''', the Secret is deleted. With '''wait_for_deletion''', Set waits until it is
+  removed, such as while finalizers run, so later checks do not see the Secret while it is terminating.
 `,
-		),
+		) + "\n\n" + conflictPolicyDocs,
 		Requirements: []string{
 			"The target namespace must exist.",
 			"The configured Kubernetes identity must be authorized for Secret operations in the target namespace.",
-			"Required Secret verbs: `get`, `create`, `update`, `patch`, `delete`.",
+			"Required Secret verbs: `get`, `patch`, `delete`.",
 		},
-		Inputs: map[string]blackstart.InputValue{
-			inputName: {
-				Description: "Name of the Secret",
-				Type:        reflect.TypeFor[string](),
-				Required:    true,
-			},
-			inputNamespace: {
-				Description: "Namespace where the Secret exists",
-				Type:        reflect.TypeFor[string](),
-				Required:    false,
-				Default:     "default",
-			},
-			inputClient: {
-				Description: "Kubernetes client interface to use for API calls",
-				Type:        reflect.TypeFor[kubernetes.Interface](),
-				Required:    true,
-			},
-			inputType: {
-				Description: "Type of the Secret (e.g., Opaque, kubernetes.io/tls, kubernetes.io/dockerconfigjson)",
-				Type:        reflect.TypeFor[string](),
-				Required:    false,
-				Default:     "Opaque",
-			},
-			inputImmutable: {
-				Description: "Make the Secret immutable. Ignored if not set (default).",
-				Type:        reflect.TypeFor[*bool](),
-				Required:    false,
-				Default:     nil,
+		Inputs: withDeletionInputs(
+			"Secret", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the Secret",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputNamespace: {
+					Description: "Namespace where the Secret exists. Defaults to `default`, or to the namespace Blackstart runs in when `--k8s-default-namespace-from-runtime` is enabled.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime, such as `system:serviceaccount:<namespace>:<name>`. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputType: {
+					Description: "Type of the Secret (e.g., Opaque, kubernetes.io/tls, kubernetes.io/dockerconfigjson)",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     "Opaque",
+				},
+				inputImmutable: {
+					Description: "Make the Secret immutable. Ignored if not set (default).",
+					Type:        reflect.TypeFor[*bool](),
+					Required:    false,
+					Default:     nil,
+				},
+				inputContentHash: {
+					Description: "Maintain the `blackstart.pezops.github.io/content-hash` annotation with a hash of the content of the Secret.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputConflictPolicy: {
+					Description: "Conflict policy for fields owned by other field managers: `force` or `fail`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     conflictPolicyForce,
+				},
 			},
-		},
+		),
 		Outputs: map[string]blackstart.OutputValue{
 			outputSecret: {
 				Description: "The Secret resource",
@@ -118,17 +239,23 @@ inputs:
       output: client
   name: my-secret
   namespace: default`,
+			"Runtime-Provided Client": `id: create-secret
+module: kubernetes_secret
+inputs:
+  name: my-secret
+  namespace: myapp
+  impersonate: system:serviceaccount:myapp:blackstart`,
+			"Secret with Content Hash": `id: app-secret
+module: kubernetes_secret
+inputs:
+  name: app-secret
+  namespace: myapp
+  content_hash: true`,
 			"Configure Secret to be Immutable": `operations:
-  - id: k8s_client
-    module: kubernetes_client
   - id: myapp_secret
     module: kubernetes_secret
     name: MyApp Secret
     inputs:
-      client:
-        fromDependency:
-          id: k8s_client
-          output: client
       namespace: myapp
       name: myapp-secret
   - id: myapp_db_host
@@ -152,10 +279,6 @@ inputs:
   - id: myapp_secret_immutable
     module: kubernetes_secret
     inputs:
-      client:
-        fromDependency:
-          id: k8s_client
-          output: client
       namespace: myapp
       name: myapp-secret
       immutable: true
@@ -182,13 +305,13 @@ func (s *secretModule) Validate(op blackstart.Operation) error {
 		}
 	}
 
-	// Client is required
-	_, ok = op.Inputs[inputClient]
-	if !ok {
-		return fmt.Errorf("input '%s' must be provided", inputClient)
+	if err := validateConflictPolicy(op); err != nil {
+		return err
 	}
-
-	return nil
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
 }
 
 func (s *secretModule) Check(ctx blackstart.ModuleContext) (bool, error) {
@@ -197,22 +320,18 @@ func (s *secretModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 		return false, nil
 	}
 
-	clientInput, err := ctx.Input(inputClient)
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
 	if err != nil {
-		return false, fmt.Errorf("failed to get client input: %w", err)
-	}
-
-	cc, ok := clientInput.Any().(kubernetes.Interface)
-	if !ok {
-		return false, fmt.Errorf("client input is not a Kubernetes clientset")
+		return false, err
 	}
 
-	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	namespace, err := contextNamespace(ctx)
 	if err != nil {
 		return false, err
 	}
+	ctx.Resource(namespace + "/" + name)
 
-	namespace, err := blackstart.ContextInputAs[string](ctx, inputNamespace, true)
+	cc, err := contextClient(ctx, namespace)
 	if err != nil {
 		return false, err
 	}
@@ -269,6 +388,14 @@ func (s *secretModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 			}
 		}
 
+		desiredContentHash, hashErr := blackstart.ContextInputAs[bool](ctx, inputContentHash, false)
+		if hashErr != nil {
+			return false, hashErr
+		}
+		if desiredContentHash && sec.Annotations[contentHashAnnotation] != secretContentHash(sec) {
+			return false, nil
+		}
+
 		err = ctx.Output("secret", &secret{si: si, s: sec})
 		if err != nil {
 			return false, err
@@ -279,22 +406,18 @@ func (s *secretModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 }
 
 func (s *secretModule) Set(ctx blackstart.ModuleContext) error {
-	clientInput, err := ctx.Input(inputClient)
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
 	if err != nil {
-		return fmt.Errorf("failed to get client input: %w", err)
-	}
-
-	client, ok := clientInput.Any().(kubernetes.Interface)
-	if !ok {
-		return fmt.Errorf("client input is not a Kubernetes clientset")
+		return err
 	}
 
-	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	namespace, err := contextNamespace(ctx)
 	if err != nil {
 		return err
 	}
+	ctx.Resource(namespace + "/" + name)
 
-	namespace, err := blackstart.ContextInputAs[string](ctx, inputNamespace, true)
+	client, err := contextClient(ctx, namespace)
 	if err != nil {
 		return err
 	}
@@ -316,91 +439,68 @@ func (s *secretModule) Set(ctx blackstart.ModuleContext) error {
 
 		if sec != nil {
 			// Secret exists, delete it
-			return si.Delete(ctx, name, metav1.DeleteOptions{})
+			if err = deleteRetryingConflicts(ctx, name, si.Delete); err != nil {
+				return err
+			}
+			return waitForDeletion(
+				ctx, fmt.Sprintf("Secret %s/%s", namespace, name), func(c context.Context) (metav1.Object, error) {
+					return si.Get(c, name, metav1.GetOptions{})
+				},
+			)
 		}
 		return fmt.Errorf("could not determine if Secret '%s/%s' exists", namespace, name)
 	}
 
-	// Try to get the Secret
-	sec, err := si.Get(ctx, name, metav1.GetOptions{})
+	opts, err := contextApplyOptions(ctx)
+	if err != nil {
+		return err
+	}
+	desiredType, err := blackstart.ContextInputAs[string](ctx, inputType, false)
+	if err != nil {
+		return err
+	}
+	desiredImmutablePtr, err := blackstart.ContextInputAs[*bool](ctx, inputImmutable, false)
+	if err != nil {
+		return err
+	}
+	desiredContentHash, err := blackstart.ContextInputAs[bool](ctx, inputContentHash, false)
 	if err != nil {
-		if apierrors.IsNotFound(err) {
-			// Secret doesn't exist
-			newSec := &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      name,
-					Namespace: namespace,
-				},
-				Data: map[string][]byte{},
-			}
-
-			// Set the secret type
-			desiredType, typeErr := blackstart.ContextInputAs[string](ctx, inputType, false)
-			if typeErr != nil {
-				return typeErr
-			}
-			newSec.Type = corev1.SecretType(desiredType)
-
-			// Only set immutable if the input is provided and not nil
-			desiredImmutablePtr, immutableErr := blackstart.ContextInputAs[*bool](ctx, inputImmutable, false)
-			if immutableErr != nil {
-				return immutableErr
-			}
-
-			if desiredImmutablePtr != nil {
-				desiredImmutable := *desiredImmutablePtr
-				newSec.Immutable = &desiredImmutable
-			}
-
-			_, err = si.Create(ctx, newSec, metav1.CreateOptions{})
-			if err != nil {
-				return err
-			}
-			return ctx.Output("secret", &secret{si: si, s: newSec})
-		}
 		return err
 	}
 
-	// Secret should exist
-	if sec != nil {
-		needsUpdate := false
-
-		// Check if type needs to be updated
-		desiredTypeValue, typeErr := blackstart.ContextInputAs[string](ctx, inputType, false)
-		if typeErr != nil {
-			return typeErr
-		}
-		desiredType := corev1.SecretType(desiredTypeValue)
-		if sec.Type != desiredType {
-			needsUpdate = true
-			sec.Type = desiredType
-		}
-
-		// Check if we need to update the immutable field (only if immutable input is provided)
-		desiredImmutablePtr, immutableErr := blackstart.ContextInputAs[*bool](ctx, inputImmutable, false)
-		if immutableErr != nil {
-			return immutableErr
-		}
-		if desiredImmutablePtr != nil {
-			desiredImmutable := *desiredImmutablePtr
-			currentImmutable := sec.Immutable
-
-			if currentImmutable == nil || desiredImmutable != *currentImmutable {
-				needsUpdate = true
-				sec.Immutable = &desiredImmutable
-			}
-		}
-
-		// Update the Secret if needed
-		if needsUpdate {
-			sec, err = si.Update(ctx, sec, metav1.UpdateOptions{})
-			if err != nil {
-				return err
-			}
-		}
-
+	// Only the fields owned by Blackstart are applied, so the Secret is created when it does not
+	// exist, and keys set by value operations are kept.
+	sec, err := si.Get(ctx, name, metav1.GetOptions{})
+	var cfg *applycorev1.SecretApplyConfiguration
+	hash := contentHash(nil, nil)
+	switch {
+	case apierrors.IsNotFound(err):
+		cfg = applycorev1.Secret(name, namespace)
+	case err != nil:
+		return err
+	case sec.Type == corev1.SecretType(desiredType) &&
+		(desiredImmutablePtr == nil || (sec.Immutable != nil && *sec.Immutable == *desiredImmutablePtr)) &&
+		(!desiredContentHash || sec.Annotations[contentHashAnnotation] == secretContentHash(sec)):
 		return ctx.Output("secret", &secret{si: si, s: sec})
+	default:
+		hash = secretContentHash(sec)
+		cfg, err = applycorev1.ExtractSecret(sec, fieldManager)
+		if err != nil {
+			return fmt.Errorf("unable to extract managed fields of Secret '%s/%s': %w", namespace, name, err)
+		}
 	}
 
-	return fmt.Errorf("could not determine if Secret '%s/%s' exists", namespace, name)
+	cfg.WithType(corev1.SecretType(desiredType))
+	// Only set immutable if the input is provided and not nil
+	if desiredImmutablePtr != nil {
+		cfg.WithImmutable(*desiredImmutablePtr)
+	}
+	if desiredContentHash {
+		cfg.WithAnnotations(map[string]string{contentHashAnnotation: hash})
+	}
+	sec, err = si.Apply(ctx, cfg, opts)
+	if err != nil {
+		return applyError(err)
+	}
+	return ctx.Output("secret", &secret{si: si, s: sec})
 }
@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("kubernetes_secret_read", NewSecretReadModule)
+}
+
+var _ blackstart.Module = &secretReadModule{}
+
+func NewSecretReadModule() blackstart.Module {
+	return &secretReadModule{}
+}
+
+type secretReadModule struct{}
+
+func (s *secretReadModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_secret_read",
+		Name: "Kubernetes Secret Read",
+		Description: util.CleanString(
+			`
+Reads the keys of an existing Kubernetes Secret and outputs their values, such as an API key
+provisioned outside of Blackstart that is used to build a connection string. The Secret is never
+created or changed, and the outputs are sensitive.
+
+**Notes**
+
+- The operation fails when the Secret or a requested key does not exist.
+- '''doesNotExist''' is not supported.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized to read Secrets in the target namespace.",
+			"Required Secret verbs for this module: `get`.",
+		},
+		Inputs:  readInputs("Secret"),
+		Outputs: readOutputs("Secret", true),
+		Examples: map[string]string{
+			"Read an API Key": `id: read-api-key
+module: kubernetes_secret_read
+inputs:
+  namespace: payments
+  name: vendor-credentials
+  key: api-key`,
+			"Build a DSN from an Existing Password": `operations:
+  - id: db-credentials
+    module: kubernetes_secret_read
+    inputs:
+      namespace: myapp
+      name: db-credentials
+      keys:
+        - username
+        - password
+  - id: db-dsn
+    module: util_template
+    dependsOn:
+      - db-credentials
+    inputs:
+      template: >-
+        postgres://{{ index (workflowOutput "db-credentials" "values") "username" }}:{{
+        index (workflowOutput "db-credentials" "values") "password" }}@db.myapp.svc:5432/app`,
+		},
+		OutputsOnly: true,
+	}
+}
+
+func (s *secretReadModule) Validate(op blackstart.Operation) error {
+	return validateReadInputs(op)
+}
+
+func (s *secretReadModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	// The Secret is only read, so the operation is always in the desired state once the values
+	// are output.
+	return true, readSecretValues(ctx)
+}
+
+func (s *secretReadModule) Set(ctx blackstart.ModuleContext) error {
+	return readSecretValues(ctx)
+}
+
+// readSecretValues reads the Secret of a kubernetes_secret_read operation and outputs the values
+// of the requested keys.
+func readSecretValues(ctx blackstart.ModuleContext) error {
+	cc, namespace, name, err := contextReadResource(ctx)
+	if err != nil {
+		return err
+	}
+	sec, err := cc.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to read Secret '%s/%s': the Secret does not exist", namespace, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get Secret '%s/%s': %w", namespace, name, err)
+	}
+	return outputReadValues(ctx, fmt.Sprintf("Secret '%s/%s'", namespace, name), sec.Data)
+}
@@ -0,0 +1,135 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestSecretReadModule_Info(t *testing.T) {
+	info := NewSecretReadModule().Info()
+
+	assert.Equal(t, "kubernetes_secret_read", info.Id)
+	assert.True(t, info.Outputs[outputValue].Sensitive)
+	assert.True(t, info.Outputs[outputValues].Sensitive)
+}
+
+func TestSecretReadModule_Validate(t *testing.T) {
+	module := NewSecretReadModule()
+
+	tests := []struct {
+		name         string
+		inputs       map[string]blackstart.Input
+		doesNotExist bool
+		expectError  bool
+	}{
+		{
+			name: "valid inputs",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("vendor-credentials"),
+				inputKeys: blackstart.NewInputFromValue([]string{"username", "password"}),
+			},
+		},
+		{
+			name:        "missing name",
+			inputs:      map[string]blackstart.Input{inputKey: blackstart.NewInputFromValue("api-key")},
+			expectError: true,
+		},
+		{
+			name: "empty key in keys",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("vendor-credentials"),
+				inputKeys: blackstart.NewInputFromValue([]string{"username", ""}),
+			},
+			expectError: true,
+		},
+		{
+			name: "invalid encoding",
+			inputs: map[string]blackstart.Input{
+				inputName:     blackstart.NewInputFromValue("vendor-credentials"),
+				inputEncoding: blackstart.NewInputFromValue("hex"),
+			},
+			expectError: true,
+		},
+		{
+			name:         "does not exist",
+			inputs:       map[string]blackstart.Input{inputName: blackstart.NewInputFromValue("vendor-credentials")},
+			doesNotExist: true,
+			expectError:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{
+						Module:       "kubernetes_secret_read",
+						Id:           "test",
+						Inputs:       test.inputs,
+						DoesNotExist: test.doesNotExist,
+					},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestSecretReadModule_Check(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "vendor-credentials", Namespace: "payments"},
+			Data: map[string][]byte{
+				"api-key":  []byte("abc123"),
+				"username": []byte("app"),
+				"password": {0xff, 0x00},
+			},
+		},
+	)
+	module := NewSecretReadModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputNamespace: blackstart.NewInputFromValue("payments"),
+		inputName:      blackstart.NewInputFromValue("vendor-credentials"),
+		inputKey:       blackstart.NewInputFromValue("api-key"),
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "abc123", ctx.outputs[outputValue])
+	assert.Equal(t, map[string]string{"api-key": "abc123"}, ctx.outputs[outputValues])
+
+	// Every key is read when neither key nor keys is set.
+	delete(inputs, inputKey)
+	inputs[inputEncoding] = blackstart.NewInputFromValue(valueEncodingBase64)
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	require.NoError(t, module.Set(ctx))
+	assert.NotContains(t, ctx.outputs, outputValue)
+	assert.Equal(
+		t,
+		map[string]string{"api-key": "YWJjMTIz", "username": "YXBw", "password": "/wA="},
+		ctx.outputs[outputValues],
+	)
+
+	inputs[inputKeys] = blackstart.NewInputFromValue([]string{"username", "token"})
+	_, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	assert.EqualError(t, err, "key 'token' not found in Secret 'payments/vendor-credentials'")
+
+	inputs[inputName] = blackstart.NewInputFromValue("missing")
+	_, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	assert.EqualError(t, err, "failed to read Secret 'payments/missing': the Secret does not exist")
+}
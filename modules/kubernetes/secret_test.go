@@ -59,11 +59,21 @@ func TestSecretModule_Validate(t *testing.T) {
 			expectError: false,
 		},
 		{
-			name: "missing client",
+			name: "missing client uses runtime client",
 			inputs: map[string]blackstart.Input{
 				inputName:      blackstart.NewInputFromValue("test-secret"),
 				inputNamespace: blackstart.NewInputFromValue("test-namespace"),
 			},
+			expectError: false,
+		},
+		{
+			name: "impersonate with client",
+			inputs: map[string]blackstart.Input{
+				inputClient:      blackstart.NewInputFromValue(fakeClientset),
+				inputImpersonate: blackstart.NewInputFromValue("system:serviceaccount:test-namespace:app"),
+				inputName:        blackstart.NewInputFromValue("test-secret"),
+				inputNamespace:   blackstart.NewInputFromValue("test-namespace"),
+			},
 			expectError: true,
 		},
 		{
@@ -580,3 +590,79 @@ func TestSecretModule_Immutable(t *testing.T) {
 		},
 	)
 }
+
+func TestSecretModule_ContentHash(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewSecretModule()
+	valueModule := NewSecretValueModule()
+	secretInputs := func() map[string]blackstart.Input {
+		return map[string]blackstart.Input{
+			inputClient:      blackstart.NewInputFromValue(clientset),
+			inputName:        blackstart.NewInputFromValue("app-secret"),
+			inputNamespace:   blackstart.NewInputFromValue("test-namespace"),
+			inputType:        blackstart.NewInputFromValue("Opaque"),
+			inputContentHash: blackstart.NewInputFromValue(true),
+		}
+	}
+	getSecret := func() *corev1.Secret {
+		sec, err := clientset.CoreV1().Secrets("test-namespace").Get(
+			context.Background(), "app-secret", metav1.GetOptions{},
+		)
+		require.NoError(t, err)
+		return sec
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), secretInputs())}
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, contentHash(nil, nil), getSecret().Annotations[contentHashAnnotation])
+	sec := ctx.outputs[outputSecret].(*secret)
+
+	valueInputs := map[string]blackstart.Input{
+		inputSecret:       blackstart.NewInputFromValue(sec),
+		inputKey:          blackstart.NewInputFromValue("password"),
+		inputValue:        blackstart.NewInputFromValue("s3cret"),
+		inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite),
+	}
+	require.NoError(t, valueModule.Set(blackstart.InputsToContext(context.Background(), valueInputs)))
+	expected := contentHash(nil, map[string][]byte{"password": []byte("s3cret")})
+	assert.Equal(t, expected, getSecret().Annotations[contentHashAnnotation])
+
+	ok, err := module.Check(blackstart.InputsToContext(context.Background(), secretInputs()))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	changed := getSecret()
+	changed.Data["password"] = []byte("changed")
+	_, err = clientset.CoreV1().Secrets("test-namespace").Update(context.Background(), changed, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), secretInputs()))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(blackstart.InputsToContext(context.Background(), secretInputs())))
+	assert.Equal(t, secretContentHash(changed), getSecret().Annotations[contentHashAnnotation])
+
+	sec.s = getSecret()
+	require.NoError(
+		t,
+		valueModule.Set(blackstart.InputsToContext(context.Background(), valueInputs, blackstart.DoesNotExistFlag)),
+	)
+	assert.Equal(t, contentHash(nil, nil), getSecret().Annotations[contentHashAnnotation])
+}
+
+func TestSecretModule_DeleteRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}},
+	)
+	deletes := conflictOnFirst(clientset, "delete", "", "secrets")
+	inputs := map[string]blackstart.Input{
+		inputClient:    blackstart.NewInputFromValue(clientset),
+		inputName:      blackstart.NewInputFromValue("app"),
+		inputNamespace: blackstart.NewInputFromValue("default"),
+	}
+
+	ctx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	require.NoError(t, NewSecretModule().Set(ctx))
+	assert.Equal(t, 2, deletes())
+	_, err := clientset.CoreV1().Secrets("default").Get(context.Background(), "app", metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err))
+}
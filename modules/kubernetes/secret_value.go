@@ -1,9 +1,14 @@
 package kubernetes
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
 
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/pezops/blackstart"
 )
 
@@ -12,6 +17,7 @@ func init() {
 }
 
 var _ blackstart.Module = &secretValueModule{}
+var _ blackstart.BatchChecker = &secretValueModule{}
 
 func NewSecretValueModule() blackstart.Module {
 	return &secretValueModule{}
@@ -21,12 +27,16 @@ type secretValueModule struct{}
 
 func (s *secretValueModule) Info() blackstart.ModuleInfo {
 	return blackstart.ModuleInfo{
-		Id:          "kubernetes_secret_value",
-		Name:        "Kubernetes Secret Value",
-		Description: "Manages key-value pairs in a Kubernetes Secret resource.\n\n" + updatePolicyDocs,
+		Id:   "kubernetes_secret_value",
+		Name: "Kubernetes Secret Value",
+		Description: "Manages key-value pairs in a Kubernetes Secret resource. Keys of an immutable Secret cannot be " +
+			"changed, so the operation fails with an error when a key of an immutable Secret would be set or removed. " +
+			"Operations that change keys of the same Secret, including operations of other workflows, are run one at a time, " +
+			"and each key is written against the latest Secret, so keys set by other operations are kept.\n\n" +
+			valueSourceDocs + "\n\n" + updatePolicyDocs + "\n\n" + conflictPolicyDocs,
 		Requirements: []string{
 			"The Kubernetes identity must be authorized to read and update Secrets in the target namespace.",
-			"Required Secret verbs for this module: `get`, `update`.",
+			"Required Secret verbs for this module: `get`, `patch`.",
 		},
 		Inputs: map[string]blackstart.InputValue{
 			inputSecret: {
@@ -40,9 +50,21 @@ func (s *secretValueModule) Info() blackstart.ModuleInfo {
 				Required:    true,
 			},
 			inputValue: {
-				Description: "Value to set for the key. Required unless `update_policy` is `preserve_any`. Empty strings are allowed.",
+				Description: "Value to set for the key. Required unless `path` is set or `update_policy` is `preserve_any`. Empty strings are allowed.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Sensitive:   true,
+			},
+			inputPath: {
+				Description: "Path of a file to read the value from, such as a file mounted into the Blackstart pod. Cannot be used with `value`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputEncoding: {
+				Description: "Encoding of the `value` input and output: `text` or `base64`.",
 				Type:        reflect.TypeFor[string](),
 				Required:    false,
+				Default:     valueEncodingText,
 			},
 			inputUpdatePolicy: {
 				Description: "Update policy for the key-value pair",
@@ -50,13 +72,21 @@ func (s *secretValueModule) Info() blackstart.ModuleInfo {
 				Required:    false,
 				Default:     updatePolicyPreserveAny,
 			},
+			inputConflictPolicy: {
+				Description: "Conflict policy for a key owned by another field manager: `force` or `fail`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     conflictPolicyForce,
+			},
 		},
 		Outputs: map[string]blackstart.OutputValue{
 			outputValue: {
-				Description: "Current value stored for the key after reconciliation.",
+				Description: "Current value stored for the key after reconciliation, encoded with `encoding`.",
 				Type:        reflect.TypeFor[string](),
+				Sensitive:   true,
 			},
 		},
+		SerializationKey: secretSerializationKey,
 		Examples: map[string]string{
 			"Read Secret Value": `id: read-secret-value
 module: kubernetes_secret_value
@@ -75,6 +105,17 @@ inputs:
       output: secret
   key: DATABASE_PASSWORD
   value: supersecretpassword
+  update_policy: overwrite`,
+			"Set Secret Value from Binary File": `id: set-keystore
+module: kubernetes_secret_value
+inputs:
+  secret:
+    fromDependency:
+      id: app-secret
+      output: secret
+  key: keystore.p12
+  path: /etc/blackstart/keystore/keystore.p12
+  encoding: base64
   update_policy: overwrite`,
 		},
 	}
@@ -110,20 +151,64 @@ func (s *secretValueModule) Validate(op blackstart.Operation) error {
 		return err
 	}
 
-	return nil
+	return validateConflictPolicy(op)
 }
 
 func (s *secretValueModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	sec, err := contextSecret(ctx)
+	if err != nil {
+		return false, err
+	}
+	return checkSecretValue(ctx, sec)
+}
+
+// CheckMany reports whether the keys of the contexts are in the desired state. Each Secret is read
+// once for all of the contexts that use it, so the keys are checked against its latest values,
+// including changes made since it was read by the kubernetes_secret operation.
+func (s *secretValueModule) CheckMany(ctxs []blackstart.ModuleContext) ([]bool, error) {
+	results := make([]bool, len(ctxs))
+	read := make(map[*secret]struct{})
+	for i, ctx := range ctxs {
+		sec, err := contextSecret(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := read[sec]; !ok {
+			latest, getErr := sec.si.Get(ctx, sec.s.Name, metav1.GetOptions{})
+			switch {
+			case getErr == nil:
+				sec.s = latest
+			case apierrors.IsNotFound(getErr):
+				// The Secret was deleted since it was read, so it has no keys.
+				sec.s = &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: sec.s.Name, Namespace: sec.s.Namespace}}
+			default:
+				return nil, fmt.Errorf("failed to get Secret '%s/%s': %w", sec.s.Namespace, sec.s.Name, getErr)
+			}
+			read[sec] = struct{}{}
+		}
+		if results[i], err = checkSecretValue(ctx, sec); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// contextSecret returns the Secret of the secret input.
+func contextSecret(ctx blackstart.ModuleContext) (*secret, error) {
 	secInput, err := ctx.Input(inputSecret)
 	if err != nil {
-		return false, fmt.Errorf("failed to get Secret: %w", err)
+		return nil, fmt.Errorf("failed to get Secret: %w", err)
 	}
 
 	sec, ok := secInput.Any().(*secret)
 	if !ok {
-		return false, fmt.Errorf("client input is not a Secret")
+		return nil, fmt.Errorf("client input is not a Secret")
 	}
+	return sec, nil
+}
 
+// checkSecretValue reports whether a key of the Secret is in the desired state of the context.
+func checkSecretValue(ctx blackstart.ModuleContext, sec *secret) (bool, error) {
 	key, err := blackstart.ContextInputAs[string](ctx, inputKey, true)
 	if err != nil {
 		return false, err
@@ -134,7 +219,12 @@ func (s *secretValueModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 		return false, err
 	}
 
-	desiredValue, hasValue, err := contextOptionalValue(ctx)
+	encoding, err := contextValueEncoding(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	desiredValue, hasValue, err := contextDesiredValue(ctx, encoding)
 	if err != nil {
 		return false, err
 	}
@@ -142,6 +232,18 @@ func (s *secretValueModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 		return false, err
 	}
 
+	ok, err := secretValueInState(ctx, sec, key, updatePolicy, encoding, desiredValue)
+	if err == nil && !ok && isImmutable(sec.s.Immutable) {
+		return false, immutableValueError("Secret", sec.s.Namespace, sec.s.Name, key)
+	}
+	return ok, err
+}
+
+// secretValueInState reports whether a key of the Secret is in the desired state for the
+// update policy.
+func secretValueInState(
+	ctx blackstart.ModuleContext, sec *secret, key, updatePolicy, encoding string, desiredValue []byte,
+) (bool, error) {
 	if ctx.Tainted() {
 		return false, nil
 	}
@@ -156,32 +258,31 @@ func (s *secretValueModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 		return false, nil
 	}
 
-	actualValueBytes, exists := sec.s.Data[key]
+	actualValue, exists := sec.s.Data[key]
 	if !exists {
 		return false, nil
 	}
-	actualValue := string(actualValueBytes)
 
 	switch updatePolicy {
 	case updatePolicyOverwrite:
-		if actualValue == desiredValue {
-			return true, outputSecretValue(ctx, actualValue)
+		if bytes.Equal(actualValue, desiredValue) {
+			return true, outputSecretValue(ctx, encoding, actualValue)
 		}
 		return false, nil
 	case updatePolicyPreserve:
-		if actualValue != "" {
-			return true, outputSecretValue(ctx, actualValue)
+		if len(actualValue) != 0 {
+			return true, outputSecretValue(ctx, encoding, actualValue)
 		}
 		return false, nil
 	case updatePolicyPreserveAny:
-		return true, outputSecretValue(ctx, actualValue)
+		return true, outputSecretValue(ctx, encoding, actualValue)
 	case updatePolicyFail:
-		if actualValue != desiredValue {
+		if !bytes.Equal(actualValue, desiredValue) {
 			return false, fmt.Errorf(
 				"key '%s' had a value changed, but updating the value is not allowed due to the update policy", key,
 			)
 		}
-		return true, outputSecretValue(ctx, actualValue)
+		return true, outputSecretValue(ctx, encoding, actualValue)
 	}
 	return false, fmt.Errorf("unhandled update policy: %s", updatePolicy)
 }
@@ -202,21 +303,23 @@ func (s *secretValueModule) Set(ctx blackstart.ModuleContext) error {
 		return err
 	}
 
-	desiredValue, hasValue, err := contextOptionalValue(ctx)
+	encoding, err := contextValueEncoding(ctx)
 	if err != nil {
 		return err
 	}
 
-	// Secret exists, update the value
-	if sec.s.Data == nil {
-		sec.s.Data = make(map[string][]byte)
+	desiredValue, hasValue, err := contextDesiredValue(ctx, encoding)
+	if err != nil {
+		return err
 	}
 
 	// If DoesNotExist is true, ensure the key doesn't exist
 	if ctx.DoesNotExist() {
 		if _, exists := sec.s.Data[key]; exists {
-			delete(sec.s.Data, key)
-			return sec.Update(ctx)
+			if isImmutable(sec.s.Immutable) {
+				return immutableValueError("Secret", sec.s.Namespace, sec.s.Name, key)
+			}
+			return sec.RemoveValue(ctx, key)
 		}
 		return nil
 	}
@@ -224,17 +327,36 @@ func (s *secretValueModule) Set(ctx blackstart.ModuleContext) error {
 	if err = requireSetValueInput(hasValue); err != nil {
 		return err
 	}
+	if isImmutable(sec.s.Immutable) {
+		return immutableValueError("Secret", sec.s.Namespace, sec.s.Name, key)
+	}
 
-	// Secret exists, update the value
-	sec.s.Data[key] = []byte(desiredValue)
-
-	if err = sec.Update(ctx); err != nil {
+	opts, err := contextApplyOptions(ctx)
+	if err != nil {
+		return err
+	}
+	if err = sec.ApplyValue(ctx, key, desiredValue, opts); err != nil {
 		return err
 	}
-	return outputSecretValue(ctx, desiredValue)
+	return outputSecretValue(ctx, encoding, desiredValue)
+}
+
+// outputSecretValue emits the value output for a Secret key, encoded with the value encoding.
+func outputSecretValue(ctx blackstart.ModuleContext, encoding string, value []byte) error {
+	return ctx.Output(outputValue, encodeValue(encoding, value))
 }
 
-// outputSecretValue emits the value output for a Secret key.
-func outputSecretValue(ctx blackstart.ModuleContext, value string) error {
-	return ctx.Output(outputValue, value)
+// secretSerializationKey returns the serialization key of operations that change keys of a
+// Secret. Operations on the same Secret, including operations of workflows run in parallel, are
+// run one at a time, so each write is made against the changes of the previous one.
+func secretSerializationKey(ctx blackstart.ModuleContext) (string, error) {
+	input, err := ctx.Input(inputSecret)
+	if err != nil {
+		return "", err
+	}
+	sec, ok := input.Any().(*secret)
+	if !ok || sec.s == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("kubernetes_secret:%s/%s", sec.s.Namespace, sec.s.Name), nil
 }
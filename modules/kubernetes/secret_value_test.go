@@ -2,6 +2,9 @@ package kubernetes
 
 import (
 	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -9,6 +12,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/pezops/blackstart"
 )
@@ -31,6 +35,16 @@ func TestSecretValueModule_Info(t *testing.T) {
 	assert.True(t, exists)
 }
 
+func TestSecretValueModule_SerializationKey(t *testing.T) {
+	value := &secret{s: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test-namespace"}}}
+	ctx := blackstart.InputsToContext(
+		context.Background(), map[string]blackstart.Input{inputSecret: blackstart.NewInputFromValue(value)},
+	)
+	key, err := NewSecretValueModule().Info().SerializationKey(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "kubernetes_secret:test-namespace/app", key)
+}
+
 func TestSecretValueModule_Validate(t *testing.T) {
 	module := NewSecretValueModule()
 	fakeClientset := fake.NewClientset()
@@ -544,6 +558,59 @@ func TestSecretValueModule_CheckOutputsSupportedValueStates(t *testing.T) {
 	}
 }
 
+func TestSecretValueModule_CheckMany(t *testing.T) {
+	clientset := fake.NewClientset()
+	newSecret := func(name string, data map[string][]byte) *secret {
+		sec, err := clientset.CoreV1().Secrets("test-namespace").Create(
+			context.Background(),
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-namespace"}, Data: data},
+			metav1.CreateOptions{},
+		)
+		require.NoError(t, err)
+		return &secret{s: sec, si: clientset.CoreV1().Secrets("test-namespace")}
+	}
+	app := newSecret("app", map[string][]byte{"user": []byte("app"), "password": []byte("old")})
+	other := newSecret("other", map[string][]byte{"token": []byte("abc")})
+
+	// The password is changed after the Secret was read, such as by another workflow.
+	changed := app.s.DeepCopy()
+	changed.Data["password"] = []byte("new")
+	_, err := clientset.CoreV1().Secrets("test-namespace").Update(context.Background(), changed, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	clientset.ClearActions()
+
+	check := func(sec *secret, key, value string) blackstart.ModuleContext {
+		return blackstart.InputsToContext(
+			context.Background(), map[string]blackstart.Input{
+				inputSecret:       blackstart.NewInputFromValue(sec),
+				inputKey:          blackstart.NewInputFromValue(key),
+				inputValue:        blackstart.NewInputFromValue(value),
+				inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite),
+			},
+		)
+	}
+	results, err := NewSecretValueModule().(blackstart.BatchChecker).CheckMany(
+		[]blackstart.ModuleContext{
+			check(app, "user", "app"),
+			check(app, "password", "new"),
+			check(app, "missing", "value"),
+			check(other, "token", "abc"),
+		},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true, false, true}, results)
+
+	// Each Secret is read once for all of its keys.
+	var gets []string
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "get" {
+			gets = append(gets, action.(k8stesting.GetAction).GetName())
+		}
+	}
+	assert.Equal(t, []string{"app", "other"}, gets)
+	assert.Len(t, clientset.Actions(), 2)
+}
+
 func TestSecretValueModule_Set(t *testing.T) {
 	// Create a fake Kubernetes clientset
 	clientset := fake.NewClientset()
@@ -836,3 +903,134 @@ func TestSecretValueModule(t *testing.T) {
 		},
 	)
 }
+
+func TestSecretValueModule_ImmutableSecret(t *testing.T) {
+	clientset := fake.NewClientset()
+	immutable := true
+	initialSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "test-namespace",
+		},
+		Data:      map[string][]byte{"existing-key": []byte("existing-value")},
+		Immutable: &immutable,
+	}
+	_, err := clientset.CoreV1().Secrets("test-namespace").Create(
+		context.Background(),
+		initialSecret,
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	module := NewSecretValueModule()
+	contextFor := func(key, value, updatePolicy string, flags ...blackstart.ModuleContextFlag) blackstart.ModuleContext {
+		inputs := map[string]blackstart.Input{
+			inputSecret: blackstart.NewInputFromValue(&secret{
+				s:  initialSecret,
+				si: clientset.CoreV1().Secrets("test-namespace"),
+			}),
+			inputKey:          blackstart.NewInputFromValue(key),
+			inputValue:        blackstart.NewInputFromValue(value),
+			inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicy),
+		}
+		return blackstart.InputsToContext(context.Background(), inputs, flags...)
+	}
+
+	ok, err := module.Check(contextFor("existing-key", "existing-value", updatePolicyOverwrite))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	for name, ctx := range map[string]blackstart.ModuleContext{
+		"changed value": contextFor("existing-key", "new-value", updatePolicyOverwrite),
+		"removed key":   contextFor("existing-key", "", updatePolicyOverwrite, blackstart.DoesNotExistFlag),
+	} {
+		t.Run(
+			name, func(t *testing.T) {
+				_, err = module.Check(ctx)
+				require.ErrorIs(t, err, errImmutable)
+				assert.ErrorContains(t, err, "Secret test-namespace/test-secret is immutable")
+
+				err = module.Set(ctx)
+				require.ErrorIs(t, err, errImmutable)
+			},
+		)
+	}
+}
+
+func TestSecretValueModule_BinaryValues(t *testing.T) {
+	clientset := fake.NewClientset()
+	initialSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-secret",
+			Namespace: "test-namespace",
+		},
+		Data: map[string][]byte{},
+	}
+	_, err := clientset.CoreV1().Secrets("test-namespace").Create(
+		context.Background(),
+		initialSecret,
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	sec := &secret{
+		s:  initialSecret,
+		si: clientset.CoreV1().Secrets("test-namespace"),
+	}
+	keystore := []byte{0x30, 0x82, 0xff, 0x00, 0xfe}
+	path := filepath.Join(t.TempDir(), "keystore.p12")
+	require.NoError(t, os.WriteFile(path, keystore, 0o600))
+
+	module := NewSecretValueModule()
+	contextFor := func() *capturingModuleContext {
+		inputs := map[string]blackstart.Input{
+			inputSecret:       blackstart.NewInputFromValue(sec),
+			inputKey:          blackstart.NewInputFromValue("keystore.p12"),
+			inputPath:         blackstart.NewInputFromValue(path),
+			inputEncoding:     blackstart.NewInputFromValue(valueEncodingBase64),
+			inputUpdatePolicy: blackstart.NewInputFromValue(updatePolicyOverwrite),
+		}
+		return &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	}
+
+	ok, err := module.Check(contextFor())
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ctx := contextFor()
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, keystore, sec.s.Data["keystore.p12"])
+	assert.Equal(t, base64.StdEncoding.EncodeToString(keystore), ctx.outputs[outputValue])
+
+	ctx = contextFor()
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(keystore), ctx.outputs[outputValue])
+
+	require.NoError(t, os.Remove(path))
+	_, err = module.Check(contextFor())
+	require.ErrorContains(t, err, "failed to read input 'path'")
+}
+
+func TestSecretValueModule_RemoveRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("hunter2"), "user": []byte("app")},
+		},
+	)
+	patches := conflictOnFirst(clientset, "patch", "", "secrets")
+	s, err := clientset.CoreV1().Secrets("default").Get(context.Background(), "app", metav1.GetOptions{})
+	require.NoError(t, err)
+	inputs := map[string]blackstart.Input{
+		inputSecret: blackstart.NewInputFromValue(&secret{s: s, si: clientset.CoreV1().Secrets("default")}),
+		inputKey:    blackstart.NewInputFromValue("password"),
+	}
+
+	ctx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	require.NoError(t, NewSecretValueModule().Set(ctx))
+	assert.Equal(t, 2, patches())
+	s, err = clientset.CoreV1().Secrets("default").Get(context.Background(), "app", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"user": []byte("app")}, s.Data)
+}
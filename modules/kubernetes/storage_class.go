@@ -0,0 +1,482 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+// defaultStorageClassAnnotation marks the StorageClass used by claims without a storage class name.
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// reclaimPolicies are the supported reclaim policies of a StorageClass.
+var reclaimPolicies = map[string]corev1.PersistentVolumeReclaimPolicy{
+	string(corev1.PersistentVolumeReclaimDelete): corev1.PersistentVolumeReclaimDelete,
+	string(corev1.PersistentVolumeReclaimRetain): corev1.PersistentVolumeReclaimRetain,
+}
+
+// volumeBindingModes are the supported volume binding modes of a StorageClass.
+var volumeBindingModes = map[string]storagev1.VolumeBindingMode{
+	string(storagev1.VolumeBindingImmediate):            storagev1.VolumeBindingImmediate,
+	string(storagev1.VolumeBindingWaitForFirstConsumer): storagev1.VolumeBindingWaitForFirstConsumer,
+}
+
+func init() {
+	blackstart.RegisterModule("kubernetes_storage_class", NewStorageClassModule)
+}
+
+var _ blackstart.Module = &storageClassModule{}
+
+func NewStorageClassModule() blackstart.Module {
+	return &storageClassModule{}
+}
+
+type storageClassModule struct{}
+
+func (s *storageClassModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "kubernetes_storage_class",
+		Name: "Kubernetes StorageClass",
+		Description: util.CleanString(
+			`
+Verifies a Kubernetes StorageClass exists with the expected provisioner and parameters, and
+optionally creates it. Workflows can use it to fail early with a clear error when the storage that
+later operations depend on is missing, such as a CSI driver that is not installed.
+
+**Notes**
+
+- Without '''create''', the module does not change the cluster. Set fails with the differences when
+  the StorageClass is missing or does not match the inputs.
+- Only the inputs that are set are verified. The StorageClass may have parameters that are not in
+  '''parameters''', and parameters are compared as strings, so '''true''' and '''"true"''' are the
+  same.
+- The provisioner, parameters, reclaim policy, and volume binding mode of a StorageClass cannot be
+  changed. With '''create''', Set fails when they differ instead of replacing a StorageClass that
+  volumes may use. Volume expansion and the default class are updated.
+- With '''doesNotExist''', the StorageClass is deleted. A StorageClass is only deleted when
+  '''create''' is set. With '''wait_for_deletion''', Set waits until it is removed.
+`,
+		),
+		Requirements: []string{
+			"The Kubernetes identity must be authorized for StorageClass operations.",
+			"Required StorageClass verbs: `get`, and `create`, `update`, and `delete` when `create` is set.",
+		},
+		Inputs: withDeletionInputs(
+			"StorageClass", map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the StorageClass",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputProvisioner: {
+					Description: "Provisioner of the StorageClass, such as `pd.csi.storage.gke.io` or `ebs.csi.aws.com`",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputParameters: {
+					Description: "Parameters of the provisioner by name, such as `type: pd-ssd`",
+					Type:        reflect.TypeFor[map[string]any](),
+					Required:    false,
+				},
+				inputReclaimPolicy: {
+					Description: "Reclaim policy of volumes provisioned for the StorageClass: `Delete` or `Retain`. Defaults to `Delete` when the StorageClass is created.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputBindingMode: {
+					Description: "When volumes are provisioned and bound: `Immediate` or `WaitForFirstConsumer`. Defaults to `Immediate` when the StorageClass is created.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputVolumeExpansion: {
+					Description: "Whether claims of the StorageClass can be expanded",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+				},
+				inputDefaultClass: {
+					Description: "Whether the StorageClass is the default for claims without a storage class name",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+				},
+				inputCreate: {
+					Description: "Create the StorageClass when it is missing, and update the fields that can be changed. Otherwise, the StorageClass is only verified.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputClient: {
+					Description: "Kubernetes client interface to use for API calls. Defaults to a client provided by the runtime.",
+					Type:        reflect.TypeFor[kubernetes.Interface](),
+					Required:    false,
+				},
+				inputImpersonate: {
+					Description: "User or service account to impersonate with the client provided by the runtime. Cannot be used with `client`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputStorageClass: {
+				Description: "Name of the StorageClass",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Verify Storage": `id: ssd-storage
+module: kubernetes_storage_class
+inputs:
+  name: premium-rwo
+  provisioner: pd.csi.storage.gke.io
+  parameters:
+    type: pd-ssd`,
+			"Create Storage": `id: ssd-storage
+module: kubernetes_storage_class
+inputs:
+  name: fast
+  provisioner: ebs.csi.aws.com
+  parameters:
+    type: gp3
+    csi.storage.k8s.io/fstype: ext4
+  volume_binding_mode: WaitForFirstConsumer
+  allow_volume_expansion: true
+  create: true`,
+		},
+	}
+}
+
+func (s *storageClassModule) Validate(op blackstart.Operation) error {
+	for _, key := range []string{inputName, inputProvisioner} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("input '%s' must be provided", key)
+		}
+		if input.IsStatic() {
+			if _, err := blackstart.InputAs[string](input, true); err != nil {
+				return fmt.Errorf("input '%s' is invalid: %w", key, err)
+			}
+		}
+	}
+
+	if parametersInput, ok := op.Inputs[inputParameters]; ok && parametersInput.IsStatic() {
+		if _, err := inputParameterMap(parametersInput); err != nil {
+			return err
+		}
+	}
+
+	if policyInput, ok := op.Inputs[inputReclaimPolicy]; ok && policyInput.IsStatic() {
+		policy, err := blackstart.InputAs[string](policyInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputReclaimPolicy, err)
+		}
+		if _, ok = reclaimPolicies[policy]; policy != "" && !ok {
+			return fmt.Errorf("input '%s' has unsupported reclaim policy: %s", inputReclaimPolicy, policy)
+		}
+	}
+
+	if modeInput, ok := op.Inputs[inputBindingMode]; ok && modeInput.IsStatic() {
+		mode, err := blackstart.InputAs[string](modeInput, false)
+		if err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputBindingMode, err)
+		}
+		if _, ok = volumeBindingModes[mode]; mode != "" && !ok {
+			return fmt.Errorf("input '%s' has unsupported volume binding mode: %s", inputBindingMode, mode)
+		}
+	}
+
+	if err := validateDeletionInputs(op); err != nil {
+		return err
+	}
+	return validateClientInputs(op)
+}
+
+func (s *storageClassModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	desired, err := contextStorageClass(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(desired.class.Name)
+
+	cc, err := contextClient(ctx, "")
+	if err != nil {
+		return false, err
+	}
+	existing, err := cc.StorageV1().StorageClasses().Get(ctx, desired.class.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ctx.DoesNotExist(), nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get StorageClass %s: %w", desired.class.Name, err)
+	}
+	if ctx.DoesNotExist() {
+		return false, nil
+	}
+
+	fixed, updated := storageClassDifferences(existing, desired)
+	if len(fixed) > 0 || len(updated) > 0 {
+		return false, nil
+	}
+	return true, ctx.Output(outputStorageClass, existing.Name)
+}
+
+func (s *storageClassModule) Set(ctx blackstart.ModuleContext) error {
+	desired, err := contextStorageClass(ctx)
+	if err != nil {
+		return err
+	}
+	name := desired.class.Name
+	ctx.Resource(name)
+
+	cc, err := contextClient(ctx, "")
+	if err != nil {
+		return err
+	}
+	sci := cc.StorageV1().StorageClasses()
+
+	existing, err := sci.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if ctx.DoesNotExist() {
+			return nil
+		}
+		if !desired.create {
+			return fmt.Errorf(
+				"StorageClass %s does not exist; install its provisioner %s or set input '%s' to create it",
+				name, desired.class.Provisioner, inputCreate,
+			)
+		}
+		if _, err = sci.Create(ctx, desired.class, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create StorageClass %s: %w", name, err)
+		}
+		return ctx.Output(outputStorageClass, name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get StorageClass %s: %w", name, err)
+	}
+
+	if ctx.DoesNotExist() {
+		if !desired.create {
+			return fmt.Errorf("StorageClass %s is only deleted when input '%s' is set", name, inputCreate)
+		}
+		if err = deleteRetryingConflicts(ctx, name, sci.Delete); err != nil {
+			return fmt.Errorf("failed to delete StorageClass %s: %w", name, err)
+		}
+		return waitForDeletion(
+			ctx, fmt.Sprintf("StorageClass %s", name), func(c context.Context) (metav1.Object, error) {
+				return sci.Get(c, name, metav1.GetOptions{})
+			},
+		)
+	}
+
+	fixed, updated := storageClassDifferences(existing, desired)
+	if !desired.create && len(fixed)+len(updated) > 0 {
+		return fmt.Errorf(
+			"StorageClass %s does not match: %s", name, strings.Join(slices.Concat(fixed, updated), "; "),
+		)
+	}
+	if len(fixed) > 0 {
+		return fmt.Errorf(
+			"StorageClass %s cannot be changed to match: %s; delete it or use another name",
+			name, strings.Join(fixed, "; "),
+		)
+	}
+
+	if len(updated) > 0 {
+		// The update is retried with the latest StorageClass on conflicts with other changes.
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				current, getErr := sci.Get(ctx, name, metav1.GetOptions{})
+				if getErr != nil {
+					return getErr
+				}
+				if desired.class.AllowVolumeExpansion != nil {
+					current.AllowVolumeExpansion = desired.class.AllowVolumeExpansion
+				}
+				if desired.defaultClass != nil {
+					setDefaultStorageClass(current, *desired.defaultClass)
+				}
+				_, updateErr := sci.Update(ctx, current, metav1.UpdateOptions{})
+				return updateErr
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update StorageClass %s: %w", name, err)
+		}
+	}
+	return ctx.Output(outputStorageClass, existing.Name)
+}
+
+// desiredStorageClass is the StorageClass described by the inputs of the module. Only the fields
+// of inputs that are set are verified.
+type desiredStorageClass struct {
+	class        *storagev1.StorageClass
+	defaultClass *bool
+	create       bool
+}
+
+// contextStorageClass returns the StorageClass described by the inputs of the module.
+func contextStorageClass(ctx blackstart.ModuleContext) (*desiredStorageClass, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return nil, err
+	}
+	provisioner, err := blackstart.ContextInputAs[string](ctx, inputProvisioner, true)
+	if err != nil {
+		return nil, err
+	}
+	var parameters map[string]string
+	if input, inputErr := ctx.Input(inputParameters); inputErr == nil && input.Any() != nil {
+		if parameters, err = inputParameterMap(input); err != nil {
+			return nil, err
+		}
+	}
+	policyName, err := blackstart.ContextInputAs[string](ctx, inputReclaimPolicy, false)
+	if err != nil {
+		return nil, err
+	}
+	modeName, err := blackstart.ContextInputAs[string](ctx, inputBindingMode, false)
+	if err != nil {
+		return nil, err
+	}
+	expansion, err := blackstart.ContextInputAs[*bool](ctx, inputVolumeExpansion, false)
+	if err != nil {
+		return nil, err
+	}
+	defaultClass, err := blackstart.ContextInputAs[*bool](ctx, inputDefaultClass, false)
+	if err != nil {
+		return nil, err
+	}
+	create, err := blackstart.ContextInputAs[bool](ctx, inputCreate, false)
+	if err != nil {
+		return nil, err
+	}
+
+	class := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: name},
+		Provisioner:          provisioner,
+		Parameters:           parameters,
+		AllowVolumeExpansion: expansion,
+	}
+	if policyName != "" {
+		policy, ok := reclaimPolicies[policyName]
+		if !ok {
+			return nil, fmt.Errorf("unsupported reclaim policy: %s", policyName)
+		}
+		class.ReclaimPolicy = &policy
+	}
+	if modeName != "" {
+		mode, ok := volumeBindingModes[modeName]
+		if !ok {
+			return nil, fmt.Errorf("unsupported volume binding mode: %s", modeName)
+		}
+		class.VolumeBindingMode = &mode
+	}
+	if defaultClass != nil {
+		setDefaultStorageClass(class, *defaultClass)
+	}
+	return &desiredStorageClass{class: class, defaultClass: defaultClass, create: create}, nil
+}
+
+// inputParameterMap returns the provisioner parameters of a map input. YAML decodes values such as
+// `true` or `3000` as booleans and numbers, which are parameters in their string form.
+func inputParameterMap(input blackstart.Input) (map[string]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("input '%s' is invalid: %w", inputParameters, err)
+	}
+	parameters := make(map[string]string, len(raw))
+	for name, value := range raw {
+		switch v := value.(type) {
+		case string:
+			parameters[name] = v
+		case bool, int, int64, float64:
+			parameters[name] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("input '%s' has invalid value for %s: %v", inputParameters, name, value)
+		}
+	}
+	return parameters, nil
+}
+
+// setDefaultStorageClass marks the StorageClass as the default class, or removes the mark.
+func setDefaultStorageClass(class *storagev1.StorageClass, isDefault bool) {
+	if !isDefault {
+		delete(class.Annotations, defaultStorageClassAnnotation)
+		return
+	}
+	if class.Annotations == nil {
+		class.Annotations = map[string]string{}
+	}
+	class.Annotations[defaultStorageClassAnnotation] = "true"
+}
+
+// storageClassDifferences describes how the StorageClass differs from the desired StorageClass, in
+// the fields that cannot be changed and in the fields that can be updated.
+func storageClassDifferences(existing *storagev1.StorageClass, desired *desiredStorageClass) ([]string, []string) {
+	var fixed, updated []string
+	want := desired.class
+	if existing.Provisioner != want.Provisioner {
+		fixed = append(fixed, fmt.Sprintf("provisioner is %s, expected %s", existing.Provisioner, want.Provisioner))
+	}
+	for _, key := range slices.Sorted(maps.Keys(want.Parameters)) {
+		value, ok := existing.Parameters[key]
+		switch {
+		case !ok:
+			fixed = append(fixed, fmt.Sprintf("parameter %s is not set, expected %q", key, want.Parameters[key]))
+		case value != want.Parameters[key]:
+			fixed = append(fixed, fmt.Sprintf("parameter %s is %q, expected %q", key, value, want.Parameters[key]))
+		}
+	}
+	if want.ReclaimPolicy != nil {
+		policy := corev1.PersistentVolumeReclaimDelete
+		if existing.ReclaimPolicy != nil {
+			policy = *existing.ReclaimPolicy
+		}
+		if policy != *want.ReclaimPolicy {
+			fixed = append(fixed, fmt.Sprintf("reclaim policy is %s, expected %s", policy, *want.ReclaimPolicy))
+		}
+	}
+	if want.VolumeBindingMode != nil {
+		mode := storagev1.VolumeBindingImmediate
+		if existing.VolumeBindingMode != nil {
+			mode = *existing.VolumeBindingMode
+		}
+		if mode != *want.VolumeBindingMode {
+			fixed = append(fixed, fmt.Sprintf("volume binding mode is %s, expected %s", mode, *want.VolumeBindingMode))
+		}
+	}
+	if want.AllowVolumeExpansion != nil {
+		expansion := existing.AllowVolumeExpansion != nil && *existing.AllowVolumeExpansion
+		if expansion != *want.AllowVolumeExpansion {
+			updated = append(
+				updated, fmt.Sprintf("volume expansion is %t, expected %t", expansion, *want.AllowVolumeExpansion),
+			)
+		}
+	}
+	if desired.defaultClass != nil {
+		isDefault, _ := strconv.ParseBool(existing.Annotations[defaultStorageClassAnnotation])
+		if isDefault != *desired.defaultClass {
+			updated = append(
+				updated, fmt.Sprintf("default class is %t, expected %t", isDefault, *desired.defaultClass),
+			)
+		}
+	}
+	return fixed, updated
+}
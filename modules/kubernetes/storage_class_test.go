@@ -0,0 +1,222 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestStorageClassModule_Validate(t *testing.T) {
+	module := NewStorageClassModule()
+
+	tests := []struct {
+		name        string
+		inputs      map[string]blackstart.Input
+		expectError bool
+	}{
+		{
+			name: "provisioner and parameters",
+			inputs: map[string]blackstart.Input{
+				inputName:        blackstart.NewInputFromValue("fast"),
+				inputProvisioner: blackstart.NewInputFromValue("ebs.csi.aws.com"),
+				inputParameters:  blackstart.NewInputFromValue(map[string]any{"type": "gp3", "encrypted": true}),
+				inputBindingMode: blackstart.NewInputFromValue("WaitForFirstConsumer"),
+			},
+		},
+		{
+			name: "missing provisioner",
+			inputs: map[string]blackstart.Input{
+				inputName: blackstart.NewInputFromValue("fast"),
+			},
+			expectError: true,
+		},
+		{
+			name: "nested parameter",
+			inputs: map[string]blackstart.Input{
+				inputName:        blackstart.NewInputFromValue("fast"),
+				inputProvisioner: blackstart.NewInputFromValue("ebs.csi.aws.com"),
+				inputParameters:  blackstart.NewInputFromValue(map[string]any{"type": map[string]any{"name": "gp3"}}),
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported reclaim policy",
+			inputs: map[string]blackstart.Input{
+				inputName:          blackstart.NewInputFromValue("fast"),
+				inputProvisioner:   blackstart.NewInputFromValue("ebs.csi.aws.com"),
+				inputReclaimPolicy: blackstart.NewInputFromValue("Recycle"),
+			},
+			expectError: true,
+		},
+		{
+			name: "unsupported volume binding mode",
+			inputs: map[string]blackstart.Input{
+				inputName:        blackstart.NewInputFromValue("fast"),
+				inputProvisioner: blackstart.NewInputFromValue("ebs.csi.aws.com"),
+				inputBindingMode: blackstart.NewInputFromValue("Later"),
+			},
+			expectError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := module.Validate(
+					blackstart.Operation{Module: "kubernetes_storage_class", Id: "test", Inputs: test.inputs},
+				)
+				if test.expectError {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+				}
+			},
+		)
+	}
+}
+
+func TestStorageClassModule_Verify(t *testing.T) {
+	retain := "Retain"
+	clientset := fake.NewClientset(
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "premium-rwo"},
+			Provisioner: "pd.csi.storage.gke.io",
+			Parameters:  map[string]string{"type": "pd-ssd", "replication-type": "none"},
+		},
+	)
+	module := NewStorageClassModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:      blackstart.NewInputFromValue(clientset),
+		inputName:        blackstart.NewInputFromValue("premium-rwo"),
+		inputProvisioner: blackstart.NewInputFromValue("pd.csi.storage.gke.io"),
+		inputParameters:  blackstart.NewInputFromValue(map[string]any{"type": "pd-ssd"}),
+	}
+
+	// Parameters that are not in the inputs are not verified.
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "premium-rwo", ctx.outputs[outputStorageClass])
+
+	inputs[inputParameters] = blackstart.NewInputFromValue(map[string]any{"type": "pd-balanced"})
+	inputs[inputReclaimPolicy] = blackstart.NewInputFromValue(retain)
+	ctx = &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err = module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	err = module.Set(ctx)
+	require.Error(t, err)
+	assert.EqualError(
+		t, err, `StorageClass premium-rwo does not match: parameter type is "pd-ssd", expected "pd-balanced"; `+
+			"reclaim policy is Delete, expected Retain",
+	)
+
+	// The StorageClass is not changed without the create input.
+	sc, err := clientset.StorageV1().StorageClasses().Get(context.Background(), "premium-rwo", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "pd-ssd", sc.Parameters["type"])
+
+	inputs[inputName] = blackstart.NewInputFromValue("missing")
+	err = module.Set(blackstart.InputsToContext(context.Background(), inputs))
+	assert.ErrorContains(t, err, "StorageClass missing does not exist; install its provisioner pd.csi.storage.gke.io")
+
+	err = module.Set(blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag))
+	assert.NoError(t, err)
+	inputs[inputName] = blackstart.NewInputFromValue("premium-rwo")
+	err = module.Set(blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag))
+	assert.ErrorContains(t, err, "only deleted when input 'create' is set")
+}
+
+func TestStorageClassModule_Create(t *testing.T) {
+	clientset := fake.NewClientset()
+	module := NewStorageClassModule()
+	inputs := map[string]blackstart.Input{
+		inputClient:          blackstart.NewInputFromValue(clientset),
+		inputName:            blackstart.NewInputFromValue("fast"),
+		inputProvisioner:     blackstart.NewInputFromValue("ebs.csi.aws.com"),
+		inputParameters:      blackstart.NewInputFromValue(map[string]any{"type": "gp3", "iops": 3000}),
+		inputBindingMode:     blackstart.NewInputFromValue("WaitForFirstConsumer"),
+		inputVolumeExpansion: blackstart.NewInputFromValue(true),
+		inputCreate:          blackstart.NewInputFromValue(true),
+	}
+	storageClass := func() *storagev1.StorageClass {
+		sc, err := clientset.StorageV1().StorageClasses().Get(context.Background(), "fast", metav1.GetOptions{})
+		require.NoError(t, err)
+		return sc
+	}
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.InputsToContext(context.Background(), inputs)}
+	ok, err := module.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(ctx))
+	assert.Equal(t, map[string]string{"type": "gp3", "iops": "3000"}, storageClass().Parameters)
+	assert.Equal(t, storagev1.VolumeBindingWaitForFirstConsumer, *storageClass().VolumeBindingMode)
+	assert.True(t, *storageClass().AllowVolumeExpansion)
+	assert.Equal(t, "fast", ctx.outputs[outputStorageClass])
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Volume expansion and the default class are updated.
+	inputs[inputVolumeExpansion] = blackstart.NewInputFromValue(false)
+	inputs[inputDefaultClass] = blackstart.NewInputFromValue(true)
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.False(t, *storageClass().AllowVolumeExpansion)
+	assert.Equal(t, "true", storageClass().Annotations[defaultStorageClassAnnotation])
+	ok, err = module.Check(blackstart.InputsToContext(context.Background(), inputs))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Parameters cannot be changed.
+	inputs[inputParameters] = blackstart.NewInputFromValue(map[string]any{"type": "io2"})
+	err = module.Set(blackstart.InputsToContext(context.Background(), inputs))
+	assert.EqualError(
+		t, err,
+		`StorageClass fast cannot be changed to match: parameter type is "gp3", expected "io2"; `+
+			"delete it or use another name",
+	)
+
+	dneCtx := blackstart.InputsToContext(context.Background(), inputs, blackstart.DoesNotExistFlag)
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	require.NoError(t, module.Set(dneCtx))
+	ok, err = module.Check(dneCtx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestStorageClassModule_SetRetriesConflicts(t *testing.T) {
+	clientset := fake.NewClientset(
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "fast"},
+			Provisioner: "ebs.csi.aws.com",
+		},
+	)
+	updates := conflictOnFirstUpdate(clientset, "storage.k8s.io", "storageclasses")
+	inputs := map[string]blackstart.Input{
+		inputClient:       blackstart.NewInputFromValue(clientset),
+		inputName:         blackstart.NewInputFromValue("fast"),
+		inputProvisioner:  blackstart.NewInputFromValue("ebs.csi.aws.com"),
+		inputDefaultClass: blackstart.NewInputFromValue(true),
+		inputCreate:       blackstart.NewInputFromValue(true),
+	}
+
+	require.NoError(t, NewStorageClassModule().Set(blackstart.InputsToContext(context.Background(), inputs)))
+	assert.Equal(t, 2, updates())
+	sc, err := clientset.StorageV1().StorageClasses().Get(context.Background(), "fast", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "true", sc.Annotations[defaultStorageClassAnnotation])
+}
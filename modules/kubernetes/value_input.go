@@ -1,11 +1,14 @@
 package kubernetes
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
 )
 
 // operationUpdatePolicy returns the static update policy for validation when it is known.
@@ -31,12 +34,34 @@ func operationUpdatePolicy(op blackstart.Operation) (string, bool, error) {
 	return updatePolicy, true, nil
 }
 
-// validateValueInput validates a value input while allowing empty strings.
+// validateValueInput validates the value, path, and encoding inputs while allowing empty strings.
 func validateValueInput(op blackstart.Operation, updatePolicy string, policyKnown bool) error {
+	encoding, encodingKnown, err := operationValueEncoding(op)
+	if err != nil {
+		return err
+	}
+
 	input, exists := op.Inputs[inputValue]
+	pathInput, hasPath := op.Inputs[inputPath]
+	if exists && hasPath {
+		return fmt.Errorf("input '%s' cannot be used with input '%s'", inputPath, inputValue)
+	}
+	if hasPath {
+		if !pathInput.IsStatic() {
+			return nil
+		}
+		if _, err = blackstart.InputAs[string](pathInput, true); err != nil {
+			return fmt.Errorf("input '%s' is invalid: %w", inputPath, err)
+		}
+		return nil
+	}
+
 	if !exists {
 		if policyKnown && updatePolicy != updatePolicyPreserveAny {
-			return fmt.Errorf("input '%s' must be provided unless update_policy is '%s'", inputValue, updatePolicyPreserveAny)
+			return fmt.Errorf(
+				"input '%s' or '%s' must be provided unless update_policy is '%s'",
+				inputValue, inputPath, updatePolicyPreserveAny,
+			)
 		}
 		return nil
 	}
@@ -46,12 +71,76 @@ func validateValueInput(op blackstart.Operation, updatePolicy string, policyKnow
 	if input.Any() == nil {
 		return fmt.Errorf("input '%s' must not be null", inputValue)
 	}
-	if _, err := blackstart.InputAs[string](input, false); err != nil {
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
 		return fmt.Errorf("input '%s' is invalid: %w", inputValue, err)
 	}
+	if encodingKnown {
+		if _, err = decodeValue(encoding, value); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// operationValueEncoding returns the static value encoding for validation when it is known.
+func operationValueEncoding(op blackstart.Operation) (string, bool, error) {
+	input, exists := op.Inputs[inputEncoding]
+	if !exists {
+		return valueEncodingText, true, nil
+	}
+	if !input.IsStatic() {
+		return "", false, nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return "", true, fmt.Errorf("input '%s' is invalid: %w", inputEncoding, err)
+	}
+	encoding, err := parseValueEncoding(value)
+	return encoding, true, err
+}
+
+// parseValueEncoding returns the value encoding of an encoding input, defaulting to text.
+func parseValueEncoding(value string) (string, error) {
+	encoding := strings.TrimSpace(value)
+	if encoding == "" {
+		encoding = valueEncodingText
+	}
+	if _, ok := valueEncodings[encoding]; !ok {
+		return "", fmt.Errorf("input '%s' has invalid value '%s'", inputEncoding, encoding)
+	}
+	return encoding, nil
+}
+
+// decodeValue decodes a value input with the value encoding.
+func decodeValue(encoding, value string) ([]byte, error) {
+	if encoding != valueEncodingBase64 {
+		return []byte(value), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("input '%s' is not valid base64: %w", inputValue, err)
+	}
+	return decoded, nil
+}
+
+// encodeValue encodes a stored value for the value output with the value encoding.
+func encodeValue(encoding string, value []byte) string {
+	if encoding == valueEncodingBase64 {
+		return base64.StdEncoding.EncodeToString(value)
+	}
+	return string(value)
+}
+
+// contextValueEncoding returns the runtime value encoding from a module context.
+func contextValueEncoding(ctx blackstart.ModuleContext) (string, error) {
+	value, err := blackstart.ContextInputAs[string](ctx, inputEncoding, false)
+	if err != nil {
+		return "", err
+	}
+	return parseValueEncoding(value)
+}
+
 // contextUpdatePolicy returns the runtime update policy from a module context.
 func contextUpdatePolicy(ctx blackstart.ModuleContext) (string, error) {
 	updatePolicy := updatePolicyPreserveAny
@@ -87,6 +176,33 @@ func contextOptionalValue(ctx blackstart.ModuleContext) (string, bool, error) {
 	return value, true, nil
 }
 
+// contextDesiredValue returns the desired value of a key, read from the file of the path input or
+// decoded from the value input with the encoding. The second result reports whether a desired
+// value is set.
+func contextDesiredValue(ctx blackstart.ModuleContext, encoding string) ([]byte, bool, error) {
+	path, err := blackstart.ContextInputAs[string](ctx, inputPath, false)
+	if err != nil {
+		return nil, false, err
+	}
+	if path != "" {
+		value, rErr := os.ReadFile(path)
+		if rErr != nil {
+			return nil, true, fmt.Errorf("failed to read input '%s': %w", inputPath, rErr)
+		}
+		return value, true, nil
+	}
+
+	value, hasValue, err := contextOptionalValue(ctx)
+	if err != nil || !hasValue {
+		return nil, hasValue, err
+	}
+	decoded, err := decodeValue(encoding, value)
+	if err != nil {
+		return nil, true, err
+	}
+	return decoded, true, nil
+}
+
 // requireValueInput enforces value presence for policies that need a desired value.
 func requireValueInput(updatePolicy string, hasValue bool) error {
 	if hasValue {
@@ -95,7 +211,9 @@ func requireValueInput(updatePolicy string, hasValue bool) error {
 	if updatePolicy == updatePolicyPreserveAny {
 		return nil
 	}
-	return fmt.Errorf("input '%s' must be provided unless update_policy is '%s'", inputValue, updatePolicyPreserveAny)
+	return fmt.Errorf(
+		"input '%s' or '%s' must be provided unless update_policy is '%s'", inputValue, inputPath, updatePolicyPreserveAny,
+	)
 }
 
 // requireSetValueInput enforces value presence when Set needs to write a key.
@@ -103,5 +221,40 @@ func requireSetValueInput(hasValue bool) error {
 	if hasValue {
 		return nil
 	}
-	return fmt.Errorf("input '%s' must be provided when setting a missing key", inputValue)
+	return fmt.Errorf("input '%s' or '%s' must be provided when setting a missing key", inputValue, inputPath)
+}
+
+// errImmutable is returned when a value module would change a key of an immutable ConfigMap or
+// Secret, which the Kubernetes API rejects.
+var errImmutable = errors.New("resource is immutable")
+
+// isImmutable reports whether the immutable field of a ConfigMap or Secret is set to true.
+func isImmutable(immutable *bool) bool {
+	return immutable != nil && *immutable
 }
+
+// immutableValueError returns an error for a key of an immutable ConfigMap or Secret that cannot
+// be changed, with the steps to resolve it.
+func immutableValueError(kind, namespace, name, key string) error {
+	return fmt.Errorf(
+		"%w: key '%s' cannot be changed because %s %s/%s is immutable; delete and recreate the %s "+
+			"with the desired data, or set its values before making it immutable",
+		errImmutable, key, kind, namespace, name, kind,
+	)
+}
+
+var valueSourceDocs = util.CleanString(
+	`
+**Values**
+
+The value of a key is set with '''value''', or read from the file at '''path''' each time the operation
+runs, such as a CA bundle or keystore mounted into the Blackstart pod. File contents are used
+as-is, so binary files are supported.
+
+Binary content can be provided in '''value''' as base64 by setting '''encoding''' to '''base64'''. The
+decoded bytes are stored, and the '''value''' output is base64 encoded as well. Multi-line
+documents, such as PEM certificates, can be provided with a YAML block scalar. Values are not
+templated, so they are stored exactly as provided.
+
+`,
+)
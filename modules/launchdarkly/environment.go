@@ -0,0 +1,286 @@
+package launchdarkly
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const defaultEnvironmentColor = "7B42BC"
+
+// colorPattern matches the hex colors of environments, without the leading `#`.
+var colorPattern = regexp.MustCompile(`^[0-9A-Fa-f]{6}$`)
+
+func init() {
+	blackstart.RegisterModule("launchdarkly_environment", NewEnvironment)
+}
+
+var _ blackstart.Module = &environment{}
+
+// NewEnvironment creates a module that manages an environment of a LaunchDarkly project.
+func NewEnvironment() blackstart.Module {
+	return &environment{}
+}
+
+// environment implements the launchdarkly_environment module.
+type environment struct{}
+
+// environmentMetadata is the environment information returned by the LaunchDarkly API.
+type environmentMetadata struct {
+	ID        string `json:"_id"`
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+	APIKey    string `json:"apiKey"`
+	MobileKey string `json:"mobileKey"`
+}
+
+// desiredEnvironment is the desired state of an environment read from module inputs.
+type desiredEnvironment struct {
+	project string
+	key     string
+	name    string
+	color   string
+}
+
+// path returns the API path of the environment.
+func (e desiredEnvironment) path() string {
+	return projectPath(e.project) + "/environments/" + url.PathEscape(e.key)
+}
+
+// matches reports whether the environment has the desired name and color.
+func (e desiredEnvironment) matches(current environmentMetadata) bool {
+	return current.Name == e.name && strings.EqualFold(current.Color, e.color)
+}
+
+func (m *environment) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "launchdarkly_environment",
+		Name: "LaunchDarkly environment",
+		Description: util.CleanString(
+			`
+Ensures an environment exists in a LaunchDarkly project, and outputs the SDK keys of the
+environment so they can be stored in a secret of the application, for example with the
+'''kubernetes_secret_value''' module. Environments are identified by their key, and the name and
+color of an existing environment are updated when they differ.
+
+**Notes**
+
+- The keys of the environment are not rotated by this module.
+- When '''doesNotExist''' is set, the environment is deleted, including the flag settings of the
+  environment.
+`,
+		),
+		Requirements: []string{
+			"A LaunchDarkly API access token with a role that can create and update environments of the project.",
+			"If the `token` input is not set, the `LAUNCHDARKLY_ACCESS_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputProject: {
+					Description: "Key of the project of the environment.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputKey: {
+					Description: "Key of the environment, such as `staging`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputName: {
+					Description: "Name of the environment. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputColor: {
+					Description: "Color of the environment in the LaunchDarkly UI, as a hex color without the leading `#`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     defaultEnvironmentColor,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputKey: {
+				Description: "Key of the environment.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputSDKKey: {
+				Description: "Server-side SDK key of the environment.",
+				Type:        reflect.TypeFor[string](),
+				Sensitive:   true,
+			},
+			outputMobileKey: {
+				Description: "Mobile key of the environment.",
+				Type:        reflect.TypeFor[string](),
+				Sensitive:   true,
+			},
+			outputClientSideID: {
+				Description: "Client-side ID of the environment, used by browser SDKs.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Staging environment with the SDK key in a Secret": `operations:
+  - id: app-flags-staging
+    module: launchdarkly_environment
+    inputs:
+      project: app
+      key: staging
+      name: Staging
+  - id: app-secret
+    module: kubernetes_secret
+    inputs:
+      namespace: app
+      name: app-launchdarkly
+  - id: app-sdk-key
+    module: kubernetes_secret_value
+    inputs:
+      secret:
+        fromDependency:
+          id: app-secret
+          output: secret
+      key: sdk-key
+      value:
+        fromDependency:
+          id: app-flags-staging
+          output: sdk_key`,
+		},
+	}
+}
+
+func (m *environment) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputProject, inputKey); err != nil {
+		return err
+	}
+	if op.DoesNotExist {
+		return nil
+	}
+	if err := restapi.ValidateRequiredStrings(op, inputName); err != nil {
+		return err
+	}
+	if input, ok := op.Inputs[inputColor]; ok && input.IsStatic() {
+		color, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputColor, err)
+		}
+		if !colorPattern.MatchString(color) {
+			return fmt.Errorf(
+				"parameter %s must be a hex color without the leading #, such as %s", inputColor, defaultEnvironmentColor,
+			)
+		}
+	}
+	return nil
+}
+
+func (m *environment) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextEnvironment(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var current environmentMetadata
+	exists, err := c.get(ctx, desired.path(), &current)
+	if err != nil {
+		return false, fmt.Errorf("failed to get environment %s of project %s: %w", desired.key, desired.project, err)
+	}
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists || !desired.matches(current) {
+		return false, nil
+	}
+	return true, outputEnvironment(ctx, current)
+}
+
+func (m *environment) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextEnvironment(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if err = c.delete(ctx, desired.path()); err != nil {
+			return fmt.Errorf("failed to delete environment %s of project %s: %w", desired.key, desired.project, err)
+		}
+		return nil
+	}
+
+	var current environmentMetadata
+	exists, err := c.get(ctx, desired.path(), &current)
+	if err != nil {
+		return fmt.Errorf("failed to get environment %s of project %s: %w", desired.key, desired.project, err)
+	}
+	if !exists {
+		body := map[string]any{"key": desired.key, "name": desired.name, "color": desired.color}
+		err = c.Do(ctx, http.MethodPost, projectPath(desired.project)+"/environments", body, &current)
+		if err != nil {
+			return fmt.Errorf("failed to create environment %s of project %s: %w", desired.key, desired.project, err)
+		}
+		return outputEnvironment(ctx, current)
+	}
+
+	patch := []patchOperation{replace("name", desired.name), replace("color", desired.color)}
+	if err = c.Do(ctx, http.MethodPatch, desired.path(), patch, &current); err != nil {
+		return fmt.Errorf("failed to update environment %s of project %s: %w", desired.key, desired.project, err)
+	}
+	return outputEnvironment(ctx, current)
+}
+
+// contextEnvironment reads the desired environment from module inputs. Only the project and key
+// are read when the environment is deleted.
+func contextEnvironment(ctx blackstart.ModuleContext) (desiredEnvironment, error) {
+	var e desiredEnvironment
+	if err := contextStrings(ctx, map[string]*string{inputProject: &e.project, inputKey: &e.key}); err != nil {
+		return desiredEnvironment{}, err
+	}
+	if ctx.DoesNotExist() {
+		return e, nil
+	}
+
+	var err error
+	if e.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredEnvironment{}, err
+	}
+	if e.color, err = blackstart.ContextInputAs[string](ctx, inputColor, false); err != nil {
+		return desiredEnvironment{}, err
+	}
+	if e.color == "" {
+		e.color = defaultEnvironmentColor
+	}
+	if !colorPattern.MatchString(e.color) {
+		return desiredEnvironment{}, fmt.Errorf("input '%s' has invalid value '%s'", inputColor, e.color)
+	}
+	return e, nil
+}
+
+// outputEnvironment emits the outputs of an environment.
+func outputEnvironment(ctx blackstart.ModuleContext, e environmentMetadata) error {
+	outputs := map[string]any{
+		outputKey:          e.Key,
+		outputSDKKey:       e.APIKey,
+		outputMobileKey:    e.MobileKey,
+		outputClientSideID: e.ID,
+	}
+	for key, value := range outputs {
+		if err := ctx.Output(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
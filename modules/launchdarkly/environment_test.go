@@ -0,0 +1,93 @@
+package launchdarkly
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestEnvironment_Validate(t *testing.T) {
+	m := NewEnvironment()
+	f := newFakeLaunchDarkly(t)
+
+	op := fakeOperation(f, "launchdarkly_environment", map[string]any{inputProject: "app", inputKey: "staging"})
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: name")
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+
+	op = fakeOperation(
+		f, "launchdarkly_environment", map[string]any{
+			inputProject: "app", inputKey: "staging", inputName: "Staging", inputColor: "#7B42BC",
+		},
+	)
+	require.ErrorContains(t, m.Validate(*op), "must be a hex color")
+}
+
+func TestEnvironment_CreateUpdateDelete(t *testing.T) {
+	f := newFakeLaunchDarkly(t)
+	f.add("/projects", map[string]any{"key": "app", "name": "App"})
+	m := NewEnvironment()
+	op := fakeOperation(
+		f, "launchdarkly_environment", map[string]any{inputProject: "app", inputKey: "staging", inputName: "Staging"},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	created := f.resources["/projects/app/environments/staging"]
+	require.Equal(t, defaultEnvironmentColor, created["color"])
+	require.Equal(t, "staging", ctx.outputs[outputKey])
+	require.Equal(t, created["apiKey"], ctx.outputs[outputSDKKey])
+	require.Equal(t, created["mobileKey"], ctx.outputs[outputMobileKey])
+	require.Equal(t, created["_id"], ctx.outputs[outputClientSideID])
+
+	// The keys are output when the environment is in the desired state, since they are read from
+	// the environment.
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created["apiKey"], ctx.outputs[outputSDKKey])
+
+	// Colors are compared without case.
+	created["color"] = "7b42bc"
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op = fakeOperation(
+		f, "launchdarkly_environment", map[string]any{
+			inputProject: "app", inputKey: "staging", inputName: "Staging", inputColor: "F5A623",
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(t, "F5A623", created["color"])
+
+	op = fakeOperation(f, "launchdarkly_environment", map[string]any{inputProject: "app", inputKey: "staging"})
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.NotContains(t, f.resources, "/projects/app/environments/staging")
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestEnvironment_MissingProject(t *testing.T) {
+	f := newFakeLaunchDarkly(t)
+	m := NewEnvironment()
+	op := fakeOperation(
+		f, "launchdarkly_environment", map[string]any{inputProject: "app", inputKey: "staging", inputName: "Staging"},
+	)
+	err := m.Set(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, "failed to create environment staging of project app")
+}
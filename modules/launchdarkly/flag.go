@@ -0,0 +1,270 @@
+package launchdarkly
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("launchdarkly_flag", NewFlag)
+}
+
+var _ blackstart.Module = &flag{}
+
+// NewFlag creates a module that manages a feature flag of a LaunchDarkly project.
+func NewFlag() blackstart.Module {
+	return &flag{}
+}
+
+// flag implements the launchdarkly_flag module.
+type flag struct{}
+
+// flagMetadata is the feature flag information returned by the LaunchDarkly API.
+type flagMetadata struct {
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Temporary   bool     `json:"temporary"`
+	Tags        []string `json:"tags"`
+}
+
+// desiredFlag is the desired state of a feature flag read from module inputs.
+type desiredFlag struct {
+	project     string
+	key         string
+	name        string
+	description *string
+	temporary   bool
+	tags        []string
+}
+
+// path returns the API path of the flag.
+func (f desiredFlag) path() string {
+	return "/flags/" + url.PathEscape(f.project) + "/" + url.PathEscape(f.key)
+}
+
+// patch returns the JSON patch that updates the flag to the desired state, or nil when the flag is
+// in the desired state. The description and tags are only compared when they are set.
+func (f desiredFlag) patch(current flagMetadata) []patchOperation {
+	var patch []patchOperation
+	if current.Name != f.name {
+		patch = append(patch, replace("name", f.name))
+	}
+	if f.description != nil && current.Description != *f.description {
+		patch = append(patch, replace("description", *f.description))
+	}
+	if current.Temporary != f.temporary {
+		patch = append(patch, replace("temporary", f.temporary))
+	}
+	if f.tags != nil && !sameTags(current.Tags, f.tags) {
+		patch = append(patch, replace("tags", f.tags))
+	}
+	return patch
+}
+
+// sameTags reports whether two lists have the same tags, ignoring the order.
+func sameTags(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+func (m *flag) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "launchdarkly_flag",
+		Name: "LaunchDarkly flag",
+		Description: util.CleanString(
+			`
+Ensures a boolean feature flag exists in a LaunchDarkly project, so new environments and
+applications start with the flags they evaluate. Flags are identified by their key, and the name,
+description, temporary setting, and tags of an existing flag are updated when they differ.
+
+**Notes**
+
+- New flags are created with the '''true''' and '''false''' variations and are off in every
+  environment. Targeting of the flag is not managed by this module.
+- When '''doesNotExist''' is set, the flag is deleted from every environment of the project.
+`,
+		),
+		Requirements: []string{
+			"A LaunchDarkly API access token with a role that can create and update flags of the project.",
+			"If the `token` input is not set, the `LAUNCHDARKLY_ACCESS_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputProject: {
+					Description: "Key of the project of the flag.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputKey: {
+					Description: "Key of the flag, which applications use to evaluate it.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputName: {
+					Description: "Name of the flag. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputDescription: {
+					Description: "Description of the flag. If not set, the description is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputTemporary: {
+					Description: "Whether the flag is temporary and is expected to be removed after a release.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputTags: {
+					Description: "Tags of the flag. If not set, the tags are not managed.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputKey: {
+				Description: "Key of the flag.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Release flag": `id: new-checkout-flag
+module: launchdarkly_flag
+inputs:
+  project: app
+  key: new-checkout
+  name: New checkout
+  description: Enables the new checkout flow
+  temporary: true
+  tags:
+    - checkout`,
+		},
+	}
+}
+
+func (m *flag) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputProject, inputKey); err != nil {
+		return err
+	}
+	if op.DoesNotExist {
+		return nil
+	}
+	return restapi.ValidateRequiredStrings(op, inputName)
+}
+
+func (m *flag) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextFlag(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var current flagMetadata
+	exists, err := c.get(ctx, desired.path(), &current)
+	if err != nil {
+		return false, fmt.Errorf("failed to get flag %s of project %s: %w", desired.key, desired.project, err)
+	}
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists || desired.patch(current) != nil {
+		return false, nil
+	}
+	return true, ctx.Output(outputKey, current.Key)
+}
+
+func (m *flag) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextFlag(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if err = c.delete(ctx, desired.path()); err != nil {
+			return fmt.Errorf("failed to delete flag %s of project %s: %w", desired.key, desired.project, err)
+		}
+		return nil
+	}
+
+	var current flagMetadata
+	exists, err := c.get(ctx, desired.path(), &current)
+	if err != nil {
+		return fmt.Errorf("failed to get flag %s of project %s: %w", desired.key, desired.project, err)
+	}
+	if !exists {
+		body := map[string]any{"key": desired.key, "name": desired.name, "temporary": desired.temporary}
+		if desired.description != nil {
+			body["description"] = *desired.description
+		}
+		if desired.tags != nil {
+			body["tags"] = desired.tags
+		}
+		err = c.Do(ctx, http.MethodPost, "/flags/"+url.PathEscape(desired.project), body, &current)
+		if err != nil {
+			return fmt.Errorf("failed to create flag %s of project %s: %w", desired.key, desired.project, err)
+		}
+		return ctx.Output(outputKey, current.Key)
+	}
+
+	if patch := desired.patch(current); patch != nil {
+		if err = c.Do(ctx, http.MethodPatch, desired.path(), patch, &current); err != nil {
+			return fmt.Errorf("failed to update flag %s of project %s: %w", desired.key, desired.project, err)
+		}
+	}
+	return ctx.Output(outputKey, current.Key)
+}
+
+// contextFlag reads the desired flag from module inputs. Only the project and key are read when the
+// flag is deleted.
+func contextFlag(ctx blackstart.ModuleContext) (desiredFlag, error) {
+	var f desiredFlag
+	if err := contextStrings(ctx, map[string]*string{inputProject: &f.project, inputKey: &f.key}); err != nil {
+		return desiredFlag{}, err
+	}
+	if ctx.DoesNotExist() {
+		return f, nil
+	}
+
+	var err error
+	if f.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredFlag{}, err
+	}
+	if input, iErr := ctx.Input(inputDescription); iErr == nil && input.Any() != nil {
+		description, dErr := blackstart.InputAs[string](input, false)
+		if dErr != nil {
+			return desiredFlag{}, fmt.Errorf("invalid input %s: %w", inputDescription, dErr)
+		}
+		f.description = &description
+	}
+	if f.temporary, err = blackstart.ContextInputAs[bool](ctx, inputTemporary, false); err != nil {
+		return desiredFlag{}, err
+	}
+	if input, iErr := ctx.Input(inputTags); iErr == nil && input.Any() != nil {
+		if f.tags, err = blackstart.InputAs[[]string](input, false); err != nil {
+			return desiredFlag{}, fmt.Errorf("invalid input %s: %w", inputTags, err)
+		}
+		if f.tags == nil {
+			f.tags = []string{}
+		}
+	}
+	return f, nil
+}
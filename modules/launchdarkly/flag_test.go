@@ -0,0 +1,85 @@
+package launchdarkly
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestFlag_Validate(t *testing.T) {
+	m := NewFlag()
+	f := newFakeLaunchDarkly(t)
+
+	op := fakeOperation(f, "launchdarkly_flag", map[string]any{inputProject: "app", inputKey: "new-checkout"})
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: name")
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+}
+
+func TestFlag_CreateUpdateDelete(t *testing.T) {
+	f := newFakeLaunchDarkly(t)
+	f.add("/projects", map[string]any{"key": "app", "name": "App"})
+	m := NewFlag()
+	op := fakeOperation(
+		f, "launchdarkly_flag", map[string]any{
+			inputProject: "app", inputKey: "new-checkout", inputName: "New checkout", inputTemporary: true,
+			inputTags: []any{"checkout"},
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "new-checkout", ctx.outputs[outputKey])
+
+	created := f.resources["/flags/app/new-checkout"]
+	require.Equal(t, "New checkout", created["name"])
+	require.Equal(t, true, created["temporary"])
+	require.Equal(t, []any{"checkout"}, created["tags"])
+	require.NotContains(t, created, "description")
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Tags are compared without order, and the description is only managed when it is set.
+	created["tags"] = []any{"checkout", "web"}
+	created["description"] = "Set in the UI"
+	op = fakeOperation(
+		f, "launchdarkly_flag", map[string]any{
+			inputProject: "app", inputKey: "new-checkout", inputName: "New checkout", inputTemporary: true,
+			inputTags: []any{"web", "checkout"},
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Only the fields that differ are patched.
+	op = fakeOperation(
+		f, "launchdarkly_flag", map[string]any{
+			inputProject: "app", inputKey: "new-checkout", inputName: "New checkout", inputDescription: "Checkout",
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(t, "Checkout", created["description"])
+	require.Equal(t, false, created["temporary"])
+	require.Equal(t, []any{"checkout", "web"}, created["tags"])
+
+	op = fakeOperation(f, "launchdarkly_flag", map[string]any{inputProject: "app", inputKey: "new-checkout"})
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.NotContains(t, f.resources, "/flags/app/new-checkout")
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
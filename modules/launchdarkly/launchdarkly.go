@@ -0,0 +1,144 @@
+package launchdarkly
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputToken       = "token"
+	inputAPIURL      = restapi.InputAPIURL
+	inputProject     = "project"
+	inputKey         = "key"
+	inputName        = "name"
+	inputColor       = "color"
+	inputDescription = "description"
+	inputTemporary   = "temporary"
+	inputTags        = "tags"
+
+	outputKey          = "key"
+	outputSDKKey       = "sdk_key"
+	outputMobileKey    = "mobile_key"
+	outputClientSideID = "client_side_id"
+)
+
+const (
+	defaultAPIURL = "https://app.launchdarkly.com"
+	tokenEnvVar   = "LAUNCHDARKLY_ACCESS_TOKEN"
+
+	// apiPrefix is the path prefix of the LaunchDarkly REST API.
+	apiPrefix = "/api/v2"
+)
+
+func init() {
+	blackstart.RegisterPathName("launchdarkly", "LaunchDarkly")
+}
+
+// errorMessage returns the message of a LaunchDarkly API error response.
+func errorMessage(body io.Reader) string {
+	var payload struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return ""
+	}
+	if payload.Code == "" {
+		return payload.Message
+	}
+	return payload.Code + ": " + payload.Message
+}
+
+// patchOperation is an operation of a JSON patch, which LaunchDarkly uses to update resources.
+type patchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// replace returns the JSON patch operation that replaces the value of a field.
+func replace(field string, value any) patchOperation {
+	return patchOperation{Op: "replace", Path: "/" + field, Value: value}
+}
+
+// credentials configures the token and api_url inputs of the LaunchDarkly modules.
+var credentials = restapi.Credentials{
+	Input:             inputToken,
+	Description:       "LaunchDarkly API access token used to authenticate API requests.",
+	EnvVar:            tokenEnvVar,
+	APIURLDescription: "LaunchDarkly base URL. Set this for other instances, for example `https://app.launchdarkly.us`.",
+	DefaultAPIURL:     defaultAPIURL,
+}
+
+// client is a LaunchDarkly REST API client. Request paths are relative to the /api/v2 prefix.
+type client struct {
+	*restapi.Client
+}
+
+// newClient creates a LaunchDarkly REST API client for the given base URL and token.
+func newClient(baseURL, token string) *client {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", token)
+	return &client{
+		restapi.NewClient(
+			restapi.Config{
+				API:          "launchdarkly",
+				BaseURL:      strings.TrimRight(baseURL, "/") + apiPrefix,
+				Header:       header,
+				ErrorMessage: errorMessage,
+			},
+		),
+	}
+}
+
+// get reads a resource into out. It returns false when the resource does not exist.
+func (c *client) get(ctx context.Context, path string, out any) (bool, error) {
+	err := c.Do(ctx, http.MethodGet, path, nil, out)
+	if restapi.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// delete deletes a resource. A resource that does not exist is already deleted.
+func (c *client) delete(ctx context.Context, path string) error {
+	err := c.Do(ctx, http.MethodDelete, path, nil, nil)
+	if restapi.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// projectPath returns the API path of a project.
+func projectPath(project string) string {
+	return "/projects/" + url.PathEscape(project)
+}
+
+// contextStrings reads required string inputs from the module context.
+func contextStrings(ctx blackstart.ModuleContext, values map[string]*string) error {
+	for key, value := range values {
+		var err error
+		if *value, err = blackstart.ContextInputAs[string](ctx, key, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contextClient builds a LaunchDarkly API client from the token and api_url module inputs. When no
+// token input is provided, the LAUNCHDARKLY_ACCESS_TOKEN environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*client, error) {
+	token, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(apiURL, token), nil
+}
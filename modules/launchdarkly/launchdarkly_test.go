@@ -0,0 +1,170 @@
+package launchdarkly
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeLaunchDarkly implements LaunchDarkly API endpoints that create, get, update with a JSON
+// patch, and delete projects, environments, and flags stored in memory by their path.
+type fakeLaunchDarkly struct {
+	server    *httptest.Server
+	resources map[string]map[string]any
+	requests  []string
+	nextID    int
+	mu        sync.Mutex
+}
+
+// newFakeLaunchDarkly starts a fake LaunchDarkly API server.
+func newFakeLaunchDarkly(t *testing.T) *fakeLaunchDarkly {
+	t.Helper()
+	f := &fakeLaunchDarkly{resources: map[string]map[string]any{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// add stores a resource in a collection, such as `/projects`, and returns it.
+func (f *fakeLaunchDarkly) add(collection string, resource map[string]any) map[string]any {
+	f.nextID++
+	if strings.HasSuffix(collection, "/environments") {
+		resource["_id"] = fmt.Sprintf("client-%d", f.nextID)
+		resource["apiKey"] = fmt.Sprintf("sdk-%d", f.nextID)
+		resource["mobileKey"] = fmt.Sprintf("mob-%d", f.nextID)
+	}
+	f.resources[collection+"/"+resource["key"].(string)] = resource
+	return resource
+}
+
+// parent returns the path of the project of a collection of environments or flags, or an empty
+// string for the collection of projects.
+func (f *fakeLaunchDarkly) parent(collection string) string {
+	if project, ok := strings.CutPrefix(collection, "/flags/"); ok {
+		return projectPath(project)
+	}
+	if strings.HasSuffix(collection, "/environments") {
+		return strings.TrimSuffix(collection, "/environments")
+	}
+	return ""
+}
+
+func (f *fakeLaunchDarkly) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, apiPrefix)
+	f.requests = append(f.requests, r.Method+" "+path)
+
+	if r.Header.Get("Authorization") != "test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":"unauthorized","message":"Invalid access token"}`))
+		return
+	}
+
+	if r.Method == http.MethodPost {
+		if parent := f.parent(path); parent != "" && f.resources[parent] == nil {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"not_found","message":"Unknown project"}`))
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if _, ok := f.resources[path+"/"+body["key"].(string)]; ok {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"code":"conflict","message":"key already exists"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(f.add(path, body))
+		return
+	}
+
+	resource, ok := f.resources[path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"not_found","message":"Unknown resource"}`))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		_ = json.NewEncoder(w).Encode(resource)
+	case http.MethodPatch:
+		var patch []patchOperation
+		_ = json.NewDecoder(r.Body).Decode(&patch)
+		for _, op := range patch {
+			resource[strings.TrimPrefix(op.Path, "/")] = op.Value
+		}
+		_ = json.NewEncoder(w).Encode(resource)
+	case http.MethodDelete:
+		for key := range f.resources {
+			if key == path || strings.HasPrefix(key, path+"/") {
+				delete(f.resources, key)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// fakeOperation returns an operation of a module targeting the fake server.
+func fakeOperation(f *fakeLaunchDarkly, module string, inputs map[string]any) *blackstart.Operation {
+	return credentials.TestOperation(module, f.server.URL, "test-token", inputs)
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestErrorMessage(t *testing.T) {
+	require.Equal(
+		t,
+		"not_found: Unknown resource",
+		errorMessage(bytes.NewBufferString(`{"code":"not_found","message":"Unknown resource"}`)),
+	)
+	require.Equal(t, "Invalid request", errorMessage(bytes.NewBufferString(`{"message":"Invalid request"}`)))
+	require.Empty(t, errorMessage(bytes.NewBufferString(`not json`)))
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	f := newFakeLaunchDarkly(t)
+	_, err := newClient(f.server.URL, "wrong-token").get(context.Background(), projectPath("app"), &projectMetadata{})
+	require.EqualError(
+		t, err, "launchdarkly api request failed with status 401: unauthorized: Invalid access token",
+	)
+}
+
+func TestContextClient_TokenFromEnvironment(t *testing.T) {
+	f := newFakeLaunchDarkly(t)
+	op := fakeOperation(f, "launchdarkly_project", nil)
+	delete(op.Inputs, inputToken)
+
+	t.Setenv(tokenEnvVar, "")
+	_, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, tokenEnvVar)
+
+	t.Setenv(tokenEnvVar, "test-token")
+	c, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "test-token", c.Header.Get("Authorization"))
+	require.Equal(t, f.server.URL+apiPrefix, c.BaseURL)
+}
@@ -0,0 +1,171 @@
+package launchdarkly
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("launchdarkly_project", NewProject)
+}
+
+var _ blackstart.Module = &project{}
+
+// NewProject creates a module that manages a LaunchDarkly project.
+func NewProject() blackstart.Module {
+	return &project{}
+}
+
+// project implements the launchdarkly_project module.
+type project struct{}
+
+// projectMetadata is the project information returned by the LaunchDarkly API.
+type projectMetadata struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+func (m *project) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "launchdarkly_project",
+		Name: "LaunchDarkly project",
+		Description: util.CleanString(
+			`
+Ensures a LaunchDarkly project exists with the name. Projects are identified by their key, and the
+name of an existing project is updated when it differs.
+
+Environments of the project are managed with the '''launchdarkly_environment''' module, and flags
+with the '''launchdarkly_flag''' module.
+
+**Notes**
+
+- LaunchDarkly creates the '''production''' and '''test''' environments in new projects.
+- When '''doesNotExist''' is set, the project is deleted, including its environments and flags.
+`,
+		),
+		Requirements: []string{
+			"A LaunchDarkly API access token with a role that can create and update projects.",
+			"If the `token` input is not set, the `LAUNCHDARKLY_ACCESS_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputKey: {
+					Description: "Key of the project, such as `app`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputName: {
+					Description: "Name of the project. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputKey: {
+				Description: "Key of the project.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Application project": `id: app-flags-project
+module: launchdarkly_project
+inputs:
+  key: app
+  name: App`,
+		},
+	}
+}
+
+func (m *project) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputKey); err != nil {
+		return err
+	}
+	if op.DoesNotExist {
+		return nil
+	}
+	return restapi.ValidateRequiredStrings(op, inputName)
+}
+
+func (m *project) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	key, name, err := contextProject(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var current projectMetadata
+	exists, err := c.get(ctx, projectPath(key), &current)
+	if err != nil {
+		return false, fmt.Errorf("failed to get project %s: %w", key, err)
+	}
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists || current.Name != name {
+		return false, nil
+	}
+	return true, ctx.Output(outputKey, current.Key)
+}
+
+func (m *project) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	key, name, err := contextProject(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if err = c.delete(ctx, projectPath(key)); err != nil {
+			return fmt.Errorf("failed to delete project %s: %w", key, err)
+		}
+		return nil
+	}
+
+	var current projectMetadata
+	exists, err := c.get(ctx, projectPath(key), &current)
+	if err != nil {
+		return fmt.Errorf("failed to get project %s: %w", key, err)
+	}
+	if !exists {
+		body := map[string]any{"key": key, "name": name}
+		if err = c.Do(ctx, http.MethodPost, "/projects", body, &current); err != nil {
+			return fmt.Errorf("failed to create project %s: %w", key, err)
+		}
+		return ctx.Output(outputKey, current.Key)
+	}
+
+	patch := []patchOperation{replace("name", name)}
+	if err = c.Do(ctx, http.MethodPatch, projectPath(key), patch, &current); err != nil {
+		return fmt.Errorf("failed to update project %s: %w", key, err)
+	}
+	return ctx.Output(outputKey, current.Key)
+}
+
+// contextProject reads the key and name of the project from module inputs. The name is not read
+// when the project is deleted.
+func contextProject(ctx blackstart.ModuleContext) (string, string, error) {
+	key, err := blackstart.ContextInputAs[string](ctx, inputKey, true)
+	if err != nil {
+		return "", "", err
+	}
+	if ctx.DoesNotExist() {
+		return key, "", nil
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return "", "", err
+	}
+	return key, name, nil
+}
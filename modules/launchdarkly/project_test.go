@@ -0,0 +1,56 @@
+package launchdarkly
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestProject_Validate(t *testing.T) {
+	m := NewProject()
+	f := newFakeLaunchDarkly(t)
+
+	op := fakeOperation(f, "launchdarkly_project", map[string]any{inputKey: "app"})
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: name")
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+}
+
+func TestProject_CreateUpdateDelete(t *testing.T) {
+	f := newFakeLaunchDarkly(t)
+	m := NewProject()
+	op := fakeOperation(f, "launchdarkly_project", map[string]any{inputKey: "app", inputName: "App"})
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "app", ctx.outputs[outputKey])
+	require.Equal(t, "App", f.resources["/projects/app"]["name"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op = fakeOperation(f, "launchdarkly_project", map[string]any{inputKey: "app", inputName: "Application"})
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PATCH /projects/app")
+	require.Equal(t, "Application", f.resources["/projects/app"]["name"])
+
+	op = fakeOperation(f, "launchdarkly_project", map[string]any{inputKey: "app"})
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.resources)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+}
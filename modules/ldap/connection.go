@@ -0,0 +1,255 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+var requiredConnectionParameters = []string{inputURL, inputBindDN, inputBindPassword}
+var _ blackstart.Module = &connectionModule{}
+var _ io.Closer = &connectionModule{}
+
+func init() {
+	blackstart.RegisterModule("ldap_connection", NewConnection)
+}
+
+// NewConnection creates a module that connects to an LDAP directory, such as Active Directory,
+// over TLS.
+func NewConnection() blackstart.Module {
+	return &connectionModule{}
+}
+
+// connectionModule implements the ldap_connection module.
+type connectionModule struct {
+	conn *ldap.Conn
+}
+
+func (c *connectionModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "ldap_connection",
+		Name: "LDAP connection",
+		Description: util.CleanString(
+			`
+Connection to an LDAP directory, such as Active Directory or OpenLDAP, authenticated with a simple
+bind. Connections use LDAPS, or StartTLS on an '''ldap://''' URL when '''start_tls''' is set, so the
+bind credentials are never sent in plaintext. The server certificate is verified with the system
+CAs and the additional CAs of the Blackstart configuration.
+
+The bind password should come from a secret source, such as a '''kubernetes_secret_value''' operation
+or a '''fromFile''' input of a mounted Secret, rather than the workflow.
+
+The connection is used by the '''ldap_group''' and '''ldap_group_member''' modules.
+`,
+		),
+		Requirements: []string{
+			"The directory must be reachable from the Blackstart runtime.",
+			"The bind account must be authorized to read and write the managed groups.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputURL: {
+				Description: "URL of the directory, for example `ldaps://dc1.example.com:636`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputBindDN: {
+				Description: "DN of the account to bind as. Active Directory also accepts a user principal name, such as `svc-blackstart@example.com`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputBindPassword: {
+				Description: "Password of the bind account.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+				Sensitive:   true,
+			},
+			inputStartTLS: {
+				Description: "Upgrade an `ldap://` connection with StartTLS. Required for `ldap://` URLs.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputConnection: {
+				Description: "Connection to the directory, bound as the bind account.",
+				Type:        reflect.TypeFor[ldap.Client](),
+			},
+		},
+		Examples: map[string]string{
+			"Connect to Active Directory": `operations:
+  - id: ldap-password
+    module: kubernetes_secret_value
+    inputs:
+      secret:
+        fromDependency:
+          id: ldap-secret
+          output: secret
+      key: password
+
+  - id: connect-ldap
+    module: ldap_connection
+    inputs:
+      url: ldaps://dc1.example.com:636
+      bind_dn: CN=svc-blackstart,OU=Service Accounts,DC=example,DC=com
+      bind_password:
+        fromDependency:
+          id: ldap-password
+          output: value`,
+		},
+		OutputsOnly: true,
+	}
+}
+
+func (c *connectionModule) Validate(op blackstart.Operation) error {
+	for _, p := range requiredConnectionParameters {
+		input, ok := op.Inputs[p]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", p)
+		}
+		if input.IsStatic() {
+			if _, err := blackstart.InputAs[string](input, true); err != nil {
+				return fmt.Errorf("parameter %s is invalid: %w", p, err)
+			}
+		}
+	}
+
+	urlInput := op.Inputs[inputURL]
+	startTLSInput, hasStartTLS := op.Inputs[inputStartTLS]
+	if !urlInput.IsStatic() || (hasStartTLS && !startTLSInput.IsStatic()) {
+		return nil
+	}
+	startTLS := false
+	if hasStartTLS {
+		var err error
+		if startTLS, err = blackstart.InputAs[bool](startTLSInput, false); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputStartTLS, err)
+		}
+	}
+	rawURL, err := blackstart.InputAs[string](urlInput, true)
+	if err != nil {
+		return fmt.Errorf("parameter %s is invalid: %w", inputURL, err)
+	}
+	_, err = parseURL(rawURL, startTLS)
+	return err
+}
+
+func (c *connectionModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	_, _, err := contextConnectionTarget(ctx)
+	if err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (c *connectionModule) Set(ctx blackstart.ModuleContext) error {
+	u, startTLS, err := contextConnectionTarget(ctx)
+	if err != nil {
+		return err
+	}
+	bindDN, err := blackstart.ContextInputAs[string](ctx, inputBindDN, true)
+	if err != nil {
+		return err
+	}
+	bindPassword, err := blackstart.ContextInputAs[string](ctx, inputBindPassword, true)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := connectionTLSConfig(u.Hostname())
+	if err != nil {
+		return err
+	}
+
+	conn, err := ldap.DialURL(u.String(), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", u.Host, err)
+	}
+	if startTLS {
+		if err = conn.StartTLS(tlsConfig); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("failed to start TLS with %s: %w", u.Host, err)
+		}
+	}
+	if err = conn.Bind(bindDN, bindPassword); err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("failed to bind as %s: %w", bindDN, err)
+	}
+
+	c.conn = conn
+	if err = ctx.Output(outputConnection, ldap.Client(c.conn)); err != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close releases the active connection held by the module.
+func (c *connectionModule) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// contextConnectionTarget reads the URL of the directory and whether to use StartTLS from module
+// inputs.
+func contextConnectionTarget(ctx blackstart.ModuleContext) (*url.URL, bool, error) {
+	rawURL, err := blackstart.ContextInputAs[string](ctx, inputURL, true)
+	if err != nil {
+		return nil, false, err
+	}
+	startTLS, err := blackstart.ContextInputAs[bool](ctx, inputStartTLS, false)
+	if err != nil {
+		return nil, false, err
+	}
+	u, err := parseURL(rawURL, startTLS)
+	if err != nil {
+		return nil, false, err
+	}
+	return u, startTLS, nil
+}
+
+// parseURL parses the URL of a directory. Plaintext `ldap://` URLs are only allowed with StartTLS,
+// so bind credentials are not sent without TLS.
+func parseURL(rawURL string, startTLS bool) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s is invalid: %w", inputURL, err)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("parameter %s must include a host", inputURL)
+	}
+	switch u.Scheme {
+	case "ldaps":
+		if startTLS {
+			return nil, fmt.Errorf("parameter %s cannot be used with an ldaps:// URL", inputStartTLS)
+		}
+	case "ldap":
+		if !startTLS {
+			return nil, fmt.Errorf("parameter %s must be set to use an ldap:// URL", inputStartTLS)
+		}
+	default:
+		return nil, fmt.Errorf("parameter %s has unsupported scheme '%s'", inputURL, u.Scheme)
+	}
+	return u, nil
+}
+
+// connectionTLSConfig returns the TLS configuration of connections to the server, trusting the
+// additional CAs of blackstart.GetHTTPConfig.
+func connectionTLSConfig(serverName string) (*tls.Config, error) {
+	rootCAs, err := blackstart.GetHTTPConfig().RootCAs()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName, RootCAs: rootCAs}, nil
+}
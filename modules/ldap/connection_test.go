@@ -0,0 +1,54 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestConnection_Validate(t *testing.T) {
+	m := NewConnection()
+	operation := func(inputs map[string]any) blackstart.Operation {
+		op := blackstart.Operation{
+			Id:     "connect-ldap",
+			Module: "ldap_connection",
+			Inputs: map[string]blackstart.Input{
+				inputURL:          blackstart.NewInputFromValue("ldaps://dc1.example.com:636"),
+				inputBindDN:       blackstart.NewInputFromValue("CN=svc-blackstart,DC=example,DC=com"),
+				inputBindPassword: blackstart.NewInputFromValue("secret"),
+			},
+		}
+		for k, v := range inputs {
+			op.Inputs[k] = blackstart.NewInputFromValue(v)
+		}
+		return op
+	}
+
+	require.NoError(t, m.Validate(operation(nil)))
+	require.NoError(t, m.Validate(operation(map[string]any{inputURL: "ldap://dc1.example.com", inputStartTLS: true})))
+	require.ErrorContains(
+		t, m.Validate(operation(map[string]any{inputURL: "ldap://dc1.example.com"})), "start_tls must be set",
+	)
+	require.ErrorContains(
+		t, m.Validate(operation(map[string]any{inputStartTLS: true})), "cannot be used with an ldaps:// URL",
+	)
+	require.ErrorContains(
+		t, m.Validate(operation(map[string]any{inputURL: "https://dc1.example.com"})), "unsupported scheme 'https'",
+	)
+	require.ErrorContains(t, m.Validate(operation(map[string]any{inputURL: "ldaps://"})), "must include a host")
+
+	op := operation(nil)
+	delete(op.Inputs, inputBindPassword)
+	require.ErrorContains(t, m.Validate(op), "missing required parameter: bind_password")
+}
+
+func TestConnectionTLSConfig(t *testing.T) {
+	config, err := connectionTLSConfig("dc1.example.com")
+	require.NoError(t, err)
+	require.Equal(t, "dc1.example.com", config.ServerName)
+	require.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	require.False(t, config.InsecureSkipVerify)
+}
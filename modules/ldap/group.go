@@ -0,0 +1,351 @@
+package ldap
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+var requiredGroupParameters = []string{inputConnection, inputDN}
+var _ blackstart.Module = &groupModule{}
+
+func init() {
+	blackstart.RegisterModule("ldap_group", NewGroup)
+}
+
+// NewGroup creates a module that manages a group entry of an LDAP directory.
+func NewGroup() blackstart.Module {
+	return &groupModule{}
+}
+
+// groupModule implements the ldap_group module.
+type groupModule struct{}
+
+// group is the desired state of a group entry.
+type group struct {
+	dn              string
+	objectClasses   []string
+	attributes      map[string][]string
+	initialMembers  []string
+	memberAttribute string
+}
+
+func (g *groupModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "ldap_group",
+		Name: "LDAP group",
+		Description: util.CleanString(
+			`
+Ensures a group exists in an LDAP directory, such as Active Directory or OpenLDAP, with the object
+classes and attributes. Missing object classes are added and differing attributes are replaced.
+Attributes that are not set in '''attributes''' are not changed.
+
+Members of the group are managed with the '''ldap_group_member''' module. Object classes that
+require a member, such as '''groupOfNames''', need '''initial_members''' when the group is created.
+The initial members are only used to create the group and are not checked afterwards.
+
+**Notes**
+
+- Active Directory groups use the '''group''' object class and require the '''sAMAccountName''' attribute.
+- When '''doesNotExist''' is set, the group is deleted.
+`,
+		),
+		Requirements: []string{
+			"A valid `connection` input from the `ldap_connection` module.",
+			"The bind account must be authorized to create, modify, and delete groups under the parent DN.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputConnection: {
+				Description: "Connection to the directory.",
+				Type:        reflect.TypeFor[ldap.Client](),
+				Required:    true,
+			},
+			inputDN: {
+				Description: "DN of the group, for example `CN=app-admins,OU=Groups,DC=example,DC=com`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputObjectClasses: {
+				Description: "Object classes of the group.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+				Default:     defaultObjectClasses,
+			},
+			inputAttributes: {
+				Description: "Attributes of the group. Each attribute has a single value or a list of values.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+			inputInitialMembers: {
+				Description: "DNs of the members the group is created with, for object classes that require a member.",
+				Type:        reflect.TypeFor[[]string](),
+				Required:    false,
+			},
+			inputMemberAttribute: {
+				Description: "Attribute of the group that lists its members, such as `member` or `uniqueMember`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultMemberAttribute,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputDN: {
+				Description: "DN of the group.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Active Directory group": `id: app-admins
+module: ldap_group
+inputs:
+  connection:
+    fromDependency:
+      id: connect-ldap
+      output: connection
+  dn: CN=app-admins,OU=Groups,DC=example,DC=com
+  object_classes:
+    - top
+    - group
+  attributes:
+    sAMAccountName: app-admins
+    description: Administrators of the app`,
+			"OpenLDAP group": `id: app-admins
+module: ldap_group
+inputs:
+  connection:
+    fromDependency:
+      id: connect-ldap
+      output: connection
+  dn: cn=app-admins,ou=groups,dc=example,dc=com
+  initial_members:
+    - uid=svc-app,ou=services,dc=example,dc=com`,
+		},
+	}
+}
+
+func (g *groupModule) Validate(op blackstart.Operation) error {
+	for _, p := range requiredGroupParameters {
+		if _, ok := op.Inputs[p]; !ok {
+			return fmt.Errorf("missing required parameter: %s", p)
+		}
+	}
+	if input := op.Inputs[inputDN]; input.IsStatic() {
+		dn, err := blackstart.InputAs[string](input, true)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputDN, err)
+		}
+		if _, err = ldap.ParseDN(dn); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputDN, err)
+		}
+	}
+	if input, ok := op.Inputs[inputObjectClasses]; ok && input.IsStatic() {
+		objectClasses, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputObjectClasses, err)
+		}
+		if len(objectClasses) == 0 {
+			return fmt.Errorf("parameter %s must not be empty", inputObjectClasses)
+		}
+	}
+	if input, ok := op.Inputs[inputAttributes]; ok && input.IsStatic() {
+		if _, err := inputGroupAttributes(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *groupModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	conn, desired, err := contextGroup(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	entry, err := getEntry(conn, desired.dn, desired.entryAttributes())
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return entry == nil, nil
+	}
+	if ctx.Tainted() || entry == nil || len(desired.changes(entry)) > 0 {
+		return false, nil
+	}
+	return true, ctx.Output(outputDN, desired.dn)
+}
+
+func (g *groupModule) Set(ctx blackstart.ModuleContext) error {
+	conn, desired, err := contextGroup(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		err = conn.Del(ldap.NewDelRequest(desired.dn, nil))
+		if err != nil && !isNoSuchObject(err) {
+			return fmt.Errorf("failed to delete group %s: %w", desired.dn, err)
+		}
+		return nil
+	}
+
+	entry, err := getEntry(conn, desired.dn, desired.entryAttributes())
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		if err = conn.Add(desired.addRequest()); err != nil {
+			return fmt.Errorf("failed to create group %s: %w", desired.dn, err)
+		}
+		return ctx.Output(outputDN, desired.dn)
+	}
+
+	if changes := desired.changes(entry); len(changes) > 0 {
+		req := ldap.NewModifyRequest(desired.dn, nil)
+		req.Changes = changes
+		if err = conn.Modify(req); err != nil {
+			return fmt.Errorf("failed to update group %s: %w", desired.dn, err)
+		}
+	}
+	return ctx.Output(outputDN, desired.dn)
+}
+
+// entryAttributes returns the attributes of the group entry that are compared to the desired
+// state.
+func (d group) entryAttributes() []string {
+	return append([]string{attributeObjectClass}, sortedKeys(d.attributes)...)
+}
+
+// changes returns the modifications of an existing entry that are needed to reach the desired
+// state: object classes that are missing are added, and attributes with different values are
+// replaced.
+func (d group) changes(entry *ldap.Entry) []ldap.Change {
+	var changes []ldap.Change
+	current := entry.GetEqualFoldAttributeValues(attributeObjectClass)
+	var missing []string
+	for _, objectClass := range d.objectClasses {
+		if !containsFold(current, objectClass) {
+			missing = append(missing, objectClass)
+		}
+	}
+	if len(missing) > 0 {
+		changes = append(
+			changes, ldap.Change{
+				Operation:    ldap.AddAttribute,
+				Modification: ldap.PartialAttribute{Type: attributeObjectClass, Vals: missing},
+			},
+		)
+	}
+
+	for _, name := range sortedKeys(d.attributes) {
+		if !sameValues(entry.GetEqualFoldAttributeValues(name), d.attributes[name]) {
+			changes = append(
+				changes, ldap.Change{
+					Operation:    ldap.ReplaceAttribute,
+					Modification: ldap.PartialAttribute{Type: name, Vals: d.attributes[name]},
+				},
+			)
+		}
+	}
+	return changes
+}
+
+// addRequest returns the request that creates the group.
+func (d group) addRequest() *ldap.AddRequest {
+	req := ldap.NewAddRequest(d.dn, nil)
+	req.Attribute(attributeObjectClass, d.objectClasses)
+	for _, name := range sortedKeys(d.attributes) {
+		req.Attribute(name, d.attributes[name])
+	}
+	if len(d.initialMembers) > 0 {
+		req.Attribute(d.memberAttribute, d.initialMembers)
+	}
+	return req
+}
+
+// sortedKeys returns the attribute names in a stable order for requests.
+func sortedKeys(attributes map[string][]string) []string {
+	keys := make([]string, 0, len(attributes))
+	for name := range attributes {
+		keys = append(keys, name)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// inputGroupAttributes returns the values of each attribute of an attributes input.
+func inputGroupAttributes(input blackstart.Input) (map[string][]string, error) {
+	raw, err := blackstart.InputAs[map[string]any](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %s is invalid: %w", inputAttributes, err)
+	}
+	attributes := make(map[string][]string, len(raw))
+	for name, value := range raw {
+		if strings.EqualFold(name, attributeObjectClass) {
+			return nil, fmt.Errorf("parameter %s must not set %s, use %s", inputAttributes, name, inputObjectClasses)
+		}
+		values, vErr := attributeValues(name, value)
+		if vErr != nil {
+			return nil, fmt.Errorf("parameter %s is invalid: %w", inputAttributes, vErr)
+		}
+		attributes[name] = values
+	}
+	return attributes, nil
+}
+
+// contextGroup reads the connection and the desired group from module inputs.
+func contextGroup(ctx blackstart.ModuleContext) (ldap.Client, group, error) {
+	conn, err := blackstart.ContextInputAs[ldap.Client](ctx, inputConnection, true)
+	if err != nil {
+		return nil, group{}, err
+	}
+	dn, err := blackstart.ContextInputAs[string](ctx, inputDN, true)
+	if err != nil {
+		return nil, group{}, err
+	}
+	objectClasses, err := blackstart.ContextInputAs[[]string](ctx, inputObjectClasses, false)
+	if err != nil {
+		return nil, group{}, err
+	}
+	if len(objectClasses) == 0 {
+		objectClasses = defaultObjectClasses
+	}
+	attributes := map[string][]string{}
+	if input, iErr := ctx.Input(inputAttributes); iErr == nil && input.Any() != nil {
+		if attributes, err = inputGroupAttributes(input); err != nil {
+			return nil, group{}, err
+		}
+	}
+	initialMembers, err := blackstart.ContextInputAs[[]string](ctx, inputInitialMembers, false)
+	if err != nil {
+		return nil, group{}, err
+	}
+	memberAttribute, err := contextMemberAttribute(ctx)
+	if err != nil {
+		return nil, group{}, err
+	}
+	return conn, group{
+		dn:              dn,
+		objectClasses:   objectClasses,
+		attributes:      attributes,
+		initialMembers:  initialMembers,
+		memberAttribute: memberAttribute,
+	}, nil
+}
+
+// contextMemberAttribute returns the member attribute input, defaulting to `member`.
+func contextMemberAttribute(ctx blackstart.ModuleContext) (string, error) {
+	memberAttribute, err := blackstart.ContextInputAs[string](ctx, inputMemberAttribute, false)
+	if err != nil {
+		return "", err
+	}
+	if memberAttribute == "" {
+		memberAttribute = defaultMemberAttribute
+	}
+	return memberAttribute, nil
+}
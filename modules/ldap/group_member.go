@@ -0,0 +1,177 @@
+package ldap
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+var requiredGroupMemberParameters = []string{inputConnection, inputGroup, inputMember}
+var _ blackstart.Module = &groupMemberModule{}
+
+func init() {
+	blackstart.RegisterModule("ldap_group_member", NewGroupMember)
+}
+
+// NewGroupMember creates a module that manages the membership of an entry in an LDAP group.
+func NewGroupMember() blackstart.Module {
+	return &groupMemberModule{}
+}
+
+// groupMemberModule implements the ldap_group_member module.
+type groupMemberModule struct{}
+
+func (g *groupMemberModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "ldap_group_member",
+		Name: "LDAP group member",
+		Description: util.CleanString(
+			`
+Ensures an entry, such as a service account, is a member of a group in an LDAP directory, such as
+Active Directory or OpenLDAP. Membership is checked with an LDAP compare of the member attribute of
+the group, so DNs that differ only in case or spacing are matched by the directory. Other members of
+the group are not changed.
+
+**Notes**
+
+- When '''doesNotExist''' is set, the entry is removed from the group. A group that does not exist has no members.
+- Nested memberships are not considered, only direct members of the group.
+`,
+		),
+		Requirements: []string{
+			"A valid `connection` input from the `ldap_connection` module.",
+			"The bind account must be authorized to read and write the member attribute of the group.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputConnection: {
+				Description: "Connection to the directory.",
+				Type:        reflect.TypeFor[ldap.Client](),
+				Required:    true,
+			},
+			inputGroup: {
+				Description: "DN of the group.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputMember: {
+				Description: "DN of the member, for example `CN=svc-app,OU=Service Accounts,DC=example,DC=com`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputMemberAttribute: {
+				Description: "Attribute of the group that lists its members, such as `member` or `uniqueMember`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     defaultMemberAttribute,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{},
+		Examples: map[string]string{
+			"Add a service account to a group": `id: app-admins-svc-app
+module: ldap_group_member
+inputs:
+  connection:
+    fromDependency:
+      id: connect-ldap
+      output: connection
+  group:
+    fromDependency:
+      id: app-admins
+      output: dn
+  member: CN=svc-app,OU=Service Accounts,DC=example,DC=com`,
+		},
+	}
+}
+
+func (g *groupMemberModule) Validate(op blackstart.Operation) error {
+	for _, p := range requiredGroupMemberParameters {
+		input, ok := op.Inputs[p]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", p)
+		}
+		if p == inputConnection || !input.IsStatic() {
+			continue
+		}
+		dn, err := blackstart.InputAs[string](input, true)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", p, err)
+		}
+		if _, err = ldap.ParseDN(dn); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (g *groupMemberModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	conn, groupDN, memberDN, memberAttribute, err := contextGroupMember(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	isMember, err := conn.Compare(groupDN, memberAttribute, memberDN)
+	if isNoSuchObject(err) {
+		if ctx.DoesNotExist() {
+			return true, nil
+		}
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check membership of %s in group %s: %w", memberDN, groupDN, err)
+	}
+	if ctx.DoesNotExist() {
+		return !isMember, nil
+	}
+	return isMember && !ctx.Tainted(), nil
+}
+
+func (g *groupMemberModule) Set(ctx blackstart.ModuleContext) error {
+	conn, groupDN, memberDN, memberAttribute, err := contextGroupMember(ctx)
+	if err != nil {
+		return err
+	}
+
+	req := ldap.NewModifyRequest(groupDN, nil)
+	if ctx.DoesNotExist() {
+		req.Delete(memberAttribute, []string{memberDN})
+		err = conn.Modify(req)
+		// The member is already removed when the group or the value does not exist.
+		if err != nil && !isNoSuchObject(err) && !ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchAttribute) {
+			return fmt.Errorf("failed to remove %s from group %s: %w", memberDN, groupDN, err)
+		}
+		return nil
+	}
+
+	req.Add(memberAttribute, []string{memberDN})
+	err = conn.Modify(req)
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultAttributeOrValueExists) {
+		return fmt.Errorf("failed to add %s to group %s: %w", memberDN, groupDN, err)
+	}
+	return nil
+}
+
+// contextGroupMember reads the connection, the group and member DNs, and the member attribute
+// from module inputs.
+func contextGroupMember(ctx blackstart.ModuleContext) (ldap.Client, string, string, string, error) {
+	conn, err := blackstart.ContextInputAs[ldap.Client](ctx, inputConnection, true)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	groupDN, err := blackstart.ContextInputAs[string](ctx, inputGroup, true)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	memberDN, err := blackstart.ContextInputAs[string](ctx, inputMember, true)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	memberAttribute, err := contextMemberAttribute(ctx)
+	if err != nil {
+		return nil, "", "", "", err
+	}
+	return conn, groupDN, memberDN, memberAttribute, nil
+}
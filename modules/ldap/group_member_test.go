@@ -0,0 +1,109 @@
+package ldap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+const testMemberDN = "CN=svc-app,OU=Service Accounts,DC=example,DC=com"
+
+// groupMemberOperation returns an ldap_group_member operation using the fake directory.
+func groupMemberOperation(f *fakeDirectory, inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{
+		Id:     "group-member",
+		Module: "ldap_group_member",
+		Inputs: map[string]blackstart.Input{
+			inputConnection: blackstart.NewInputFromValue(f),
+			inputGroup:      blackstart.NewInputFromValue(testGroupDN),
+			inputMember:     blackstart.NewInputFromValue(testMemberDN),
+		},
+	}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+func TestGroupMember_Add(t *testing.T) {
+	f := newFakeDirectory(
+		map[string]map[string][]string{testGroupDN: {"member": {"CN=other,DC=example,DC=com"}}},
+	)
+	m := NewGroupMember()
+	op := groupMemberOperation(f, nil)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(
+		t, []string{"CN=other,DC=example,DC=com", testMemberDN},
+		f.entries["cn=app-admins,ou=groups,dc=example,dc=com"]["member"],
+	)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Adding an existing member succeeds.
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+}
+
+func TestGroupMember_MemberAttribute(t *testing.T) {
+	f := newFakeDirectory(map[string]map[string][]string{testGroupDN: {"uniqueMember": {}}})
+	m := NewGroupMember()
+	op := groupMemberOperation(f, map[string]any{inputMemberAttribute: "uniqueMember"})
+
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(t, []string{testMemberDN}, f.entries["cn=app-admins,ou=groups,dc=example,dc=com"]["uniqueMember"])
+}
+
+func TestGroupMember_MissingGroup(t *testing.T) {
+	f := newFakeDirectory(nil)
+	m := NewGroupMember()
+	op := groupMemberOperation(f, nil)
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ErrorContains(t, m.Set(blackstart.OpContext(context.Background(), op)), "failed to add")
+
+	op.DoesNotExist = true
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestGroupMember_Remove(t *testing.T) {
+	f := newFakeDirectory(map[string]map[string][]string{testGroupDN: {"member": {testMemberDN}}})
+	m := NewGroupMember()
+	op := groupMemberOperation(f, map[string]any{inputMember: "cn=svc-app,ou=service accounts,dc=example,dc=com"})
+	op.DoesNotExist = true
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.entries["cn=app-admins,ou=groups,dc=example,dc=com"]["member"])
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+}
+
+func TestGroupMember_Validate(t *testing.T) {
+	f := newFakeDirectory(nil)
+	m := NewGroupMember()
+
+	require.ErrorContains(
+		t, m.Validate(*groupMemberOperation(f, map[string]any{inputMember: "svc-app"})), "parameter member is invalid",
+	)
+	op := groupMemberOperation(f, nil)
+	delete(op.Inputs, inputGroup)
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: group")
+}
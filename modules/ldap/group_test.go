@@ -0,0 +1,126 @@
+package ldap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+const testGroupDN = "CN=app-admins,OU=Groups,DC=example,DC=com"
+
+// groupOperation returns an ldap_group operation using the fake directory.
+func groupOperation(f *fakeDirectory, inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{
+		Id:     "group",
+		Module: "ldap_group",
+		Inputs: map[string]blackstart.Input{
+			inputConnection: blackstart.NewInputFromValue(f),
+			inputDN:         blackstart.NewInputFromValue(testGroupDN),
+		},
+	}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+func TestGroup_Create(t *testing.T) {
+	f := newFakeDirectory(nil)
+	m := NewGroup()
+	op := groupOperation(
+		f, map[string]any{
+			inputObjectClasses:  []any{"top", "group"},
+			inputAttributes:     map[string]any{"sAMAccountName": "app-admins", "description": "Admins"},
+			inputInitialMembers: []any{"CN=svc-app,DC=example,DC=com"},
+		},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, testGroupDN, ctx.outputs[outputDN])
+	require.Equal(
+		t, map[string][]string{
+			"objectClass":    {"top", "group"},
+			"sAMAccountName": {"app-admins"},
+			"description":    {"Admins"},
+			"member":         {"CN=svc-app,DC=example,DC=com"},
+		}, f.entries["cn=app-admins,ou=groups,dc=example,dc=com"],
+	)
+
+	// Initial members are not compared after the group is created.
+	f.entries["cn=app-admins,ou=groups,dc=example,dc=com"]["member"] = nil
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, testGroupDN, ctx.outputs[outputDN])
+}
+
+func TestGroup_Update(t *testing.T) {
+	f := newFakeDirectory(
+		map[string]map[string][]string{
+			testGroupDN: {"objectclass": {"top"}, "description": {"Old"}, "member": {"CN=a,DC=example,DC=com"}},
+		},
+	)
+	m := NewGroup()
+	op := groupOperation(f, map[string]any{inputAttributes: map[string]any{"description": "Admins"}})
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(
+		t, map[string][]string{
+			"objectclass": {"top", "groupOfNames"},
+			"description": {"Admins"},
+			"member":      {"CN=a,DC=example,DC=com"},
+		}, f.entries["cn=app-admins,ou=groups,dc=example,dc=com"],
+	)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestGroup_Delete(t *testing.T) {
+	f := newFakeDirectory(map[string]map[string][]string{testGroupDN: {"objectClass": {"top", "groupOfNames"}}})
+	m := NewGroup()
+	op := groupOperation(f, nil)
+	op.DoesNotExist = true
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.entries)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+}
+
+func TestGroup_Validate(t *testing.T) {
+	f := newFakeDirectory(nil)
+	m := NewGroup()
+
+	require.ErrorContains(t, m.Validate(*groupOperation(f, map[string]any{inputDN: "not a dn"})), "parameter dn is invalid")
+	require.ErrorContains(
+		t, m.Validate(*groupOperation(f, map[string]any{inputObjectClasses: []any{}})), "must not be empty",
+	)
+	require.ErrorContains(
+		t, m.Validate(*groupOperation(f, map[string]any{inputAttributes: map[string]any{"objectClass": "group"}})),
+		"must not set objectClass",
+	)
+	op := groupOperation(f, nil)
+	delete(op.Inputs, inputConnection)
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: connection")
+}
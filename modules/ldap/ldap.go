@@ -0,0 +1,116 @@
+package ldap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/pezops/blackstart"
+)
+
+const (
+	inputURL             = "url"
+	inputBindDN          = "bind_dn"
+	inputBindPassword    = "bind_password"
+	inputStartTLS        = "start_tls"
+	inputConnection      = "connection"
+	inputDN              = "dn"
+	inputObjectClasses   = "object_classes"
+	inputAttributes      = "attributes"
+	inputInitialMembers  = "initial_members"
+	inputMemberAttribute = "member_attribute"
+	inputGroup           = "group"
+	inputMember          = "member"
+
+	outputConnection = "connection"
+	outputDN         = "dn"
+)
+
+const (
+	attributeObjectClass   = "objectClass"
+	defaultMemberAttribute = "member"
+)
+
+var defaultObjectClasses = []string{"top", "groupOfNames"}
+
+func init() {
+	blackstart.RegisterPathName("ldap", "LDAP")
+}
+
+// isNoSuchObject reports whether an LDAP error is returned because an entry does not exist.
+func isNoSuchObject(err error) bool {
+	return ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject)
+}
+
+// getEntry returns the entry with the DN and the attributes, or nil if the entry does not exist.
+func getEntry(conn ldap.Client, dn string, attributes []string) (*ldap.Entry, error) {
+	res, err := conn.Search(
+		ldap.NewSearchRequest(
+			dn, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false, "(objectClass=*)", attributes, nil,
+		),
+	)
+	if isNoSuchObject(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entry %s: %w", dn, err)
+	}
+	if len(res.Entries) == 0 {
+		return nil, nil
+	}
+	return res.Entries[0], nil
+}
+
+// attributeValues returns the values of an attribute input, which is a single value or a list of
+// values. Numbers and booleans are converted to strings.
+func attributeValues(name string, value any) ([]string, error) {
+	var items []any
+	switch v := value.(type) {
+	case []any:
+		items = v
+	case []string:
+		for _, s := range v {
+			items = append(items, s)
+		}
+	default:
+		items = []any{v}
+	}
+
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			values = append(values, v)
+		case int, int64, float64, bool:
+			values = append(values, fmt.Sprint(v))
+		default:
+			return nil, fmt.Errorf("attribute %s has unsupported value type %T", name, item)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("attribute %s must have at least one value", name)
+	}
+	return values, nil
+}
+
+// sameValues reports whether two lists contain the same attribute values in any order.
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// containsFold reports whether a list contains a value, ignoring case.
+func containsFold(values []string, value string) bool {
+	return slices.ContainsFunc(
+		values, func(v string) bool {
+			return strings.EqualFold(v, value)
+		},
+	)
+}
@@ -0,0 +1,159 @@
+package ldap
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeDirectory implements the LDAP operations used by the modules with entries stored in memory.
+// DNs and attribute names are matched without case, like a directory.
+type fakeDirectory struct {
+	ldap.Client
+	entries  map[string]map[string][]string
+	requests []string
+}
+
+// newFakeDirectory creates a fake directory with the entries.
+func newFakeDirectory(entries map[string]map[string][]string) *fakeDirectory {
+	f := &fakeDirectory{entries: map[string]map[string][]string{}}
+	for dn, attributes := range entries {
+		f.entries[strings.ToLower(dn)] = attributes
+	}
+	return f
+}
+
+func (f *fakeDirectory) entry(dn string) (map[string][]string, error) {
+	attributes, ok := f.entries[strings.ToLower(dn)]
+	if !ok {
+		return nil, ldap.NewError(ldap.LDAPResultNoSuchObject, errors.New("no such object"))
+	}
+	return attributes, nil
+}
+
+// attributeName returns the stored name of an attribute of an entry, ignoring case.
+func attributeName(attributes map[string][]string, name string) string {
+	for stored := range attributes {
+		if strings.EqualFold(stored, name) {
+			return stored
+		}
+	}
+	return name
+}
+
+func (f *fakeDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	f.requests = append(f.requests, "search "+req.BaseDN)
+	attributes, err := f.entry(req.BaseDN)
+	if err != nil {
+		return nil, err
+	}
+	return &ldap.SearchResult{Entries: []*ldap.Entry{ldap.NewEntry(req.BaseDN, attributes)}}, nil
+}
+
+func (f *fakeDirectory) Add(req *ldap.AddRequest) error {
+	f.requests = append(f.requests, "add "+req.DN)
+	if _, err := f.entry(req.DN); err == nil {
+		return ldap.NewError(ldap.LDAPResultEntryAlreadyExists, errors.New("entry already exists"))
+	}
+	attributes := map[string][]string{}
+	for _, attribute := range req.Attributes {
+		attributes[attribute.Type] = attribute.Vals
+	}
+	f.entries[strings.ToLower(req.DN)] = attributes
+	return nil
+}
+
+func (f *fakeDirectory) Del(req *ldap.DelRequest) error {
+	f.requests = append(f.requests, "delete "+req.DN)
+	if _, err := f.entry(req.DN); err != nil {
+		return err
+	}
+	delete(f.entries, strings.ToLower(req.DN))
+	return nil
+}
+
+func (f *fakeDirectory) Modify(req *ldap.ModifyRequest) error {
+	f.requests = append(f.requests, "modify "+req.DN)
+	attributes, err := f.entry(req.DN)
+	if err != nil {
+		return err
+	}
+	for _, change := range req.Changes {
+		name := attributeName(attributes, change.Modification.Type)
+		switch change.Operation {
+		case ldap.AddAttribute:
+			for _, value := range change.Modification.Vals {
+				if containsFold(attributes[name], value) {
+					return ldap.NewError(ldap.LDAPResultAttributeOrValueExists, errors.New("value exists"))
+				}
+				attributes[name] = append(attributes[name], value)
+			}
+		case ldap.DeleteAttribute:
+			for _, value := range change.Modification.Vals {
+				i := slices.IndexFunc(
+					attributes[name], func(v string) bool {
+						return strings.EqualFold(v, value)
+					},
+				)
+				if i < 0 {
+					return ldap.NewError(ldap.LDAPResultNoSuchAttribute, errors.New("no such attribute"))
+				}
+				attributes[name] = slices.Delete(attributes[name], i, i+1)
+			}
+		case ldap.ReplaceAttribute:
+			attributes[name] = change.Modification.Vals
+		}
+	}
+	return nil
+}
+
+func (f *fakeDirectory) Compare(dn, attribute, value string) (bool, error) {
+	f.requests = append(f.requests, "compare "+dn)
+	attributes, err := f.entry(dn)
+	if err != nil {
+		return false, err
+	}
+	return containsFold(attributes[attributeName(attributes, attribute)], value), nil
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestAttributeValues(t *testing.T) {
+	values, err := attributeValues("description", "Administrators")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Administrators"}, values)
+
+	values, err = attributeValues("groupType", []any{-2147483646, "x", true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"-2147483646", "x", "true"}, values)
+
+	_, err = attributeValues("description", []any{})
+	require.ErrorContains(t, err, "at least one value")
+	_, err = attributeValues("description", map[string]any{"a": "b"})
+	require.ErrorContains(t, err, "unsupported value type")
+}
+
+func TestSameValues(t *testing.T) {
+	require.True(t, sameValues([]string{"a", "b"}, []string{"b", "a"}))
+	require.False(t, sameValues([]string{"a"}, []string{"a", "b"}))
+	require.False(t, sameValues([]string{"a", "b"}, []string{"a", "c"}))
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
@@ -86,6 +87,7 @@ func (c *connectionModule) Info() blackstart.ModuleInfo {
 				Description: "Password to connect to the MySQL database.",
 				Type:        reflect.TypeFor[string](),
 				Required:    false,
+				Sensitive:   true,
 			},
 			inputTLS: {
 				Description: "TLS mode to use when connecting to the MySQL database. Examples: `false`, `true`, `skip-verify`, or a registered TLS config name.",
@@ -108,6 +110,7 @@ inputs:
   database: app
   username: admin`,
 		},
+		OutputsOnly: true,
 	}
 }
 
@@ -147,11 +150,24 @@ func (c *connectionModule) Set(ctx blackstart.ModuleContext) error {
 	cfg.TLSConfig = c.target.tls
 	cfg.ParseTime = true
 
+	// Connections to a host that is down fail right away while its circuit is open.
+	endpoint := "mysql://" + cfg.Addr
+	if err := blackstart.EndpointAllowed(ctx, endpoint); err != nil {
+		return err
+	}
 	db, err := sql.Open("mysql", cfg.FormatDSN())
 	if err != nil {
 		return fmt.Errorf("error connecting to database: %w", err)
 	}
-	if err = db.PingContext(ctx); err != nil {
+	err = db.PingContext(ctx)
+	var mysqlErr *gomysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// The server responded, so the endpoint is reachable.
+		blackstart.RecordEndpointResult(ctx, endpoint, nil)
+	} else {
+		blackstart.RecordEndpointResult(ctx, endpoint, err)
+	}
+	if err != nil {
 		_ = db.Close()
 		return fmt.Errorf("error pinging database: %w", err)
 	}
@@ -242,6 +242,7 @@ func (g *grantModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 
 		for _, existsQuery := range existsQueries {
 			var exists bool
+			blackstart.RecordAPICall(ctx, blackstart.APIProviderMySQL)
 			if err = g.db.QueryRowContext(ctx, existsQuery.query, existsQuery.params...).Scan(&exists); err != nil {
 				return false, fmt.Errorf("error checking grant: %w", err)
 			}
@@ -285,6 +286,7 @@ func (g *grantModule) Set(ctx blackstart.ModuleContext) error {
 			return err
 		}
 
+		blackstart.RecordAPICall(ctx, blackstart.APIProviderMySQL)
 		if _, err = g.db.ExecContext(ctx, stmt); err != nil {
 			return fmt.Errorf("error applying grant: %w", err)
 		}
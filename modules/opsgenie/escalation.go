@@ -0,0 +1,371 @@
+package opsgenie
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	recipientTypeUser     = "user"
+	recipientTypeSchedule = "schedule"
+
+	ruleCondition  = "if-not-acked"
+	ruleNotifyType = "default"
+)
+
+func init() {
+	blackstart.RegisterModule("opsgenie_escalation", NewEscalation)
+}
+
+var _ blackstart.Module = &escalation{}
+
+// NewEscalation creates a module that manages an Opsgenie escalation.
+func NewEscalation() blackstart.Module {
+	return &escalation{}
+}
+
+// escalation implements the opsgenie_escalation module.
+type escalation struct{}
+
+// escalationMetadata is the escalation information returned by the Opsgenie API.
+type escalationMetadata struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	OwnerTeam   *teamReference   `json:"ownerTeam,omitempty"`
+	Rules       []escalationRule `json:"rules"`
+}
+
+// teamReference is a reference to the team that owns an Opsgenie object.
+type teamReference struct {
+	ID string `json:"id"`
+}
+
+// escalationRule notifies a recipient when an alert is not acknowledged after a delay.
+type escalationRule struct {
+	Condition  string    `json:"condition"`
+	NotifyType string    `json:"notifyType"`
+	Delay      ruleDelay `json:"delay"`
+	Recipient  recipient `json:"recipient"`
+}
+
+// ruleDelay is the delay of an escalation rule.
+type ruleDelay struct {
+	TimeAmount int    `json:"timeAmount"`
+	TimeUnit   string `json:"timeUnit,omitempty"`
+}
+
+// recipient is a user, identified by username, or a schedule, identified by name.
+type recipient struct {
+	Type     string `json:"type"`
+	Username string `json:"username,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+// key returns the type and identifier of the recipient, such as `user:alice@example.com`.
+func (r recipient) key() string {
+	if r.Type == recipientTypeUser {
+		return r.Type + ":" + r.Username
+	}
+	return r.Type + ":" + r.Name
+}
+
+// desiredEscalation is the desired state of an escalation read from module inputs.
+type desiredEscalation struct {
+	name        string
+	description *string
+	team        string
+	users       []string
+	schedules   []string
+	delay       int
+}
+
+// recipients returns the recipients of the escalation, users first.
+func (e desiredEscalation) recipients() []recipient {
+	var recipients []recipient
+	for _, username := range e.users {
+		recipients = append(recipients, recipient{Type: recipientTypeUser, Username: username})
+	}
+	for _, name := range e.schedules {
+		recipients = append(recipients, recipient{Type: recipientTypeSchedule, Name: name})
+	}
+	return recipients
+}
+
+// matches reports whether the escalation has the desired description, owner team, and a rule with
+// the desired delay for each recipient.
+func (e desiredEscalation) matches(current escalationMetadata) bool {
+	if !sameDescription(current.Description, e.description) {
+		return false
+	}
+	if e.team != "" && (current.OwnerTeam == nil || current.OwnerTeam.ID != e.team) {
+		return false
+	}
+	var currentKeys, desiredKeys []string
+	for _, rule := range current.Rules {
+		if rule.Condition != ruleCondition || rule.Delay.TimeAmount != e.delay {
+			return false
+		}
+		currentKeys = append(currentKeys, rule.Recipient.key())
+	}
+	for _, r := range e.recipients() {
+		desiredKeys = append(desiredKeys, r.key())
+	}
+	slices.Sort(currentKeys)
+	slices.Sort(desiredKeys)
+	return slices.Equal(currentKeys, desiredKeys)
+}
+
+// body returns the request body that creates or updates the escalation.
+func (e desiredEscalation) body() map[string]any {
+	var rules []escalationRule
+	for _, r := range e.recipients() {
+		rules = append(
+			rules, escalationRule{
+				Condition:  ruleCondition,
+				NotifyType: ruleNotifyType,
+				Delay:      ruleDelay{TimeAmount: e.delay, TimeUnit: "minutes"},
+				Recipient:  r,
+			},
+		)
+	}
+	body := map[string]any{"name": e.name, "rules": rules}
+	if e.description != nil {
+		body["description"] = *e.description
+	}
+	if e.team != "" {
+		body["ownerTeam"] = teamReference{ID: e.team}
+	}
+	return body
+}
+
+func (m *escalation) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "opsgenie_escalation",
+		Name: "Opsgenie escalation",
+		Description: util.CleanString(
+			`
+Ensures an Opsgenie escalation exists that notifies users and on-call schedules, such as the on-call
+rotation of the team that owns a new application. Escalations are matched by name.
+
+The escalation has a rule for each recipient, which notifies the recipient when an alert is not
+acknowledged after the escalation delay. The rules, owner team, and description of an existing
+escalation are updated when they differ.
+
+**Notes**
+
+- Users are identified by their username, such as '''alice@example.com''', and schedules by their
+  name.
+- When '''doesNotExist''' is set, the escalation is deleted.
+`,
+		),
+		Requirements: []string{
+			"An Opsgenie API key with the Read and Create and Update and Delete access rights.",
+			"If the `api_key` input is not set, the `OPSGENIE_API_KEY` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the escalation.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputDescription: {
+					Description: "Description of the escalation. If not set, the description is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputTeam: {
+					Description: "ID of the team that owns the escalation. If not set, the owner team is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputUsers: {
+					Description: "Usernames of the users to notify. At least one user or schedule is required.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+				inputSchedules: {
+					Description: "Names of the on-call schedules to notify. At least one user or schedule is required.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+				inputEscalationDelay: {
+					Description: "Minutes after an alert is created before the recipients are notified if it is not acknowledged.",
+					Type:        reflect.TypeFor[int](),
+					Required:    false,
+					Default:     0,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the escalation.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Team on-call escalation": `id: app-escalation
+module: opsgenie_escalation
+inputs:
+  name: App On-Call
+  description: Escalation of the app team
+  team: 8418d193-2dab-4490-b331-8c02cdd196b7
+  schedules:
+    - App Schedule
+  users:
+    - alice@example.com
+  escalation_delay: 5`,
+		},
+	}
+}
+
+func (m *escalation) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputName); err != nil {
+		return err
+	}
+	_, hasUsers := op.Inputs[inputUsers]
+	_, hasSchedules := op.Inputs[inputSchedules]
+	if !hasUsers && !hasSchedules && !op.DoesNotExist {
+		return fmt.Errorf("at least one of parameter %s and parameter %s must be provided", inputUsers, inputSchedules)
+	}
+	if input, ok := op.Inputs[inputEscalationDelay]; ok && input.IsStatic() {
+		if _, err := escalationDelay(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *escalation) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextEscalation(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := getEscalation(ctx, c, desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !desired.matches(*current) {
+		return false, nil
+	}
+	return true, ctx.Output(outputID, current.ID)
+}
+
+func (m *escalation) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextEscalation(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := getEscalation(ctx, c, desired.name)
+	if err != nil {
+		return err
+	}
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, escalationPath(current.ID, "id"), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete escalation %s: %w", desired.name, err)
+		}
+		return nil
+	}
+
+	var result response[escalationMetadata]
+	if current == nil {
+		if err = c.Do(ctx, http.MethodPost, "/v2/escalations", desired.body(), &result); err != nil {
+			return fmt.Errorf("failed to create escalation %s: %w", desired.name, err)
+		}
+		return ctx.Output(outputID, result.Data.ID)
+	}
+	if err = c.Do(ctx, http.MethodPatch, escalationPath(current.ID, "id"), desired.body(), &result); err != nil {
+		return fmt.Errorf("failed to update escalation %s: %w", desired.name, err)
+	}
+	return ctx.Output(outputID, current.ID)
+}
+
+// escalationPath returns the API path of an escalation identified by its ID or name.
+func escalationPath(identifier, identifierType string) string {
+	return "/v2/escalations/" + url.PathEscape(identifier) + "?identifierType=" + identifierType
+}
+
+// getEscalation returns the escalation with the name, if any.
+func getEscalation(ctx blackstart.ModuleContext, c *restapi.Client, name string) (*escalationMetadata, error) {
+	var result response[escalationMetadata]
+	err := c.Do(ctx, http.MethodGet, escalationPath(name, "name"), nil, &result)
+	if restapi.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get escalation %s: %w", name, err)
+	}
+	return &result.Data, nil
+}
+
+// escalationDelay returns the escalation delay of an input, which must not be negative.
+func escalationDelay(input blackstart.Input) (int, error) {
+	delay, err := blackstart.InputAs[int](input, false)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s is invalid: %w", inputEscalationDelay, err)
+	}
+	if delay < 0 {
+		return 0, fmt.Errorf("parameter %s must not be negative", inputEscalationDelay)
+	}
+	return delay, nil
+}
+
+// contextEscalation reads the desired escalation from module inputs.
+func contextEscalation(ctx blackstart.ModuleContext) (desiredEscalation, error) {
+	var e desiredEscalation
+	var err error
+	if e.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredEscalation{}, err
+	}
+	if ctx.DoesNotExist() {
+		return e, nil
+	}
+	if e.description, err = contextDescription(ctx); err != nil {
+		return desiredEscalation{}, err
+	}
+	if e.team, err = blackstart.ContextInputAs[string](ctx, inputTeam, false); err != nil {
+		return desiredEscalation{}, err
+	}
+	if e.users, err = blackstart.ContextInputAs[[]string](ctx, inputUsers, false); err != nil {
+		return desiredEscalation{}, err
+	}
+	if e.schedules, err = blackstart.ContextInputAs[[]string](ctx, inputSchedules, false); err != nil {
+		return desiredEscalation{}, err
+	}
+	if len(e.users) == 0 && len(e.schedules) == 0 {
+		return desiredEscalation{}, fmt.Errorf(
+			"at least one of input '%s' and input '%s' must be set", inputUsers, inputSchedules,
+		)
+	}
+	if input, iErr := ctx.Input(inputEscalationDelay); iErr == nil && input.Any() != nil {
+		if e.delay, err = escalationDelay(input); err != nil {
+			return desiredEscalation{}, err
+		}
+	}
+	return e, nil
+}
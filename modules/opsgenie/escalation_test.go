@@ -0,0 +1,88 @@
+package opsgenie
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestEscalation_Validate(t *testing.T) {
+	m := NewEscalation()
+	f := newFakeOpsgenie(t)
+
+	op := fakeOperation(f, "opsgenie_escalation", map[string]any{inputName: "App On-Call"})
+	require.ErrorContains(t, m.Validate(*op), "at least one of parameter users and parameter schedules")
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+
+	op = fakeOperation(
+		f, "opsgenie_escalation", map[string]any{
+			inputName: "App On-Call", inputUsers: []string{"alice@example.com"}, inputEscalationDelay: -1,
+		},
+	)
+	require.ErrorContains(t, m.Validate(*op), "must not be negative")
+}
+
+func TestEscalation_CreateUpdateDelete(t *testing.T) {
+	f := newFakeOpsgenie(t)
+	m := NewEscalation()
+	inputs := map[string]any{
+		inputName:      "App On-Call",
+		inputUsers:     []string{"alice@example.com"},
+		inputSchedules: []string{"App Schedule"},
+	}
+	op := fakeOperation(f, "opsgenie_escalation", inputs)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	created := f.collections["/v2/escalations"][0]
+	require.Equal(t, ctx.outputs[outputID], created["id"])
+	require.NotContains(t, created, "description")
+	require.NotContains(t, created, "ownerTeam")
+	rules := created["rules"].([]any)
+	require.Len(t, rules, 2)
+	require.Equal(
+		t, map[string]any{"type": "user", "username": "alice@example.com"}, rules[0].(map[string]any)["recipient"],
+	)
+	require.Equal(
+		t, map[string]any{"type": "schedule", "name": "App Schedule"}, rules[1].(map[string]any)["recipient"],
+	)
+
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created["id"], ctx.outputs[outputID])
+
+	// A different delay and owner team update the existing escalation.
+	inputs[inputEscalationDelay] = 10
+	inputs[inputTeam] = "team-1"
+	op = fakeOperation(f, "opsgenie_escalation", inputs)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PATCH /v2/escalations/"+created["id"].(string))
+	require.Len(t, f.collections["/v2/escalations"], 1)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.DoesNotExist = true
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.collections["/v2/escalations"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,231 @@
+package opsgenie
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const integrationTypeAPI = "API"
+
+func init() {
+	blackstart.RegisterModule("opsgenie_integration", NewIntegration)
+}
+
+var _ blackstart.Module = &integration{}
+
+// NewIntegration creates a module that manages an Opsgenie integration.
+func NewIntegration() blackstart.Module {
+	return &integration{}
+}
+
+// integration implements the opsgenie_integration module.
+type integration struct{}
+
+// integrationMetadata is the integration information returned by the Opsgenie API. The API key is
+// only returned when an integration is created or read by its ID.
+type integrationMetadata struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	TeamID string `json:"teamId"`
+	APIKey string `json:"apiKey"`
+}
+
+// desiredIntegration is the desired state of an integration read from module inputs.
+type desiredIntegration struct {
+	name            string
+	team            string
+	integrationType string
+}
+
+func (m *integration) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "opsgenie_integration",
+		Name: "Opsgenie integration",
+		Description: util.CleanString(
+			`
+Ensures an Opsgenie integration exists that alerts are sent to, and outputs its API key. The API key
+is used to create alerts, so it can be stored in the secrets of the application or its monitoring,
+such as with the '''kubernetes_secret_value''' module. Integrations are matched by name.
+
+**Notes**
+
+- The type and team of an integration cannot be changed. When they change from the last successful
+  run of a workflow with a state store, or the operation is tainted, the integration is replaced and
+  a new API key is output. Otherwise, Set fails when an integration with the name has a different
+  type or team.
+- When '''doesNotExist''' is set, the integration is deleted.
+`,
+		),
+		Requirements: []string{
+			"An Opsgenie API key with the Configuration access right.",
+			"If the `api_key` input is not set, the `OPSGENIE_API_KEY` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the integration.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputTeam: {
+					Description: "ID of the team that owns the integration. If not set, the integration is global.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Immutable:   true,
+				},
+				inputType: {
+					Description: "Type of the integration, such as `API` or `Prometheus`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     integrationTypeAPI,
+					Immutable:   true,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the integration.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputAPIKey: {
+				Description: "API key used to send alerts to the integration.",
+				Type:        reflect.TypeFor[string](),
+				Sensitive:   true,
+			},
+		},
+		Examples: map[string]string{
+			"API integration": `id: app-opsgenie-integration
+module: opsgenie_integration
+inputs:
+  name: app
+  team: 8418d193-2dab-4490-b331-8c02cdd196b7`,
+		},
+	}
+}
+
+func (m *integration) Validate(op blackstart.Operation) error {
+	return restapi.ValidateRequiredStrings(op, inputName)
+}
+
+func (m *integration) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextIntegration(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := findIntegration(ctx, c, desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || current.Type != desired.integrationType || current.TeamID != desired.team {
+		return false, nil
+	}
+	return true, outputIntegration(ctx, *current)
+}
+
+func (m *integration) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextIntegration(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := findIntegration(ctx, c, desired.name)
+	if err != nil {
+		return err
+	}
+	// A tainted integration is replaced, so a new API key is returned.
+	if current != nil && (ctx.DoesNotExist() || ctx.Tainted()) {
+		err = c.Do(ctx, http.MethodDelete, "/v2/integrations/"+url.PathEscape(current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete integration %s: %w", desired.name, err)
+		}
+		current = nil
+	}
+	if ctx.DoesNotExist() {
+		return nil
+	}
+
+	if current != nil {
+		if current.Type != desired.integrationType || current.TeamID != desired.team {
+			return fmt.Errorf(
+				"integration %s has type %s and team '%s', which cannot be changed to type %s and team '%s'",
+				desired.name, current.Type, current.TeamID, desired.integrationType, desired.team,
+			)
+		}
+		return outputIntegration(ctx, *current)
+	}
+
+	body := map[string]any{"name": desired.name, "type": desired.integrationType}
+	if desired.team != "" {
+		body["ownerTeam"] = teamReference{ID: desired.team}
+	}
+	var result response[integrationMetadata]
+	if err = c.Do(ctx, http.MethodPost, "/v2/integrations", body, &result); err != nil {
+		return fmt.Errorf("failed to create integration %s: %w", desired.name, err)
+	}
+	return outputIntegration(ctx, result.Data)
+}
+
+// findIntegration returns the integration with the name, if any, including its API key.
+func findIntegration(ctx blackstart.ModuleContext, c *restapi.Client, name string) (*integrationMetadata, error) {
+	var list response[[]integrationMetadata]
+	if err := c.Do(ctx, http.MethodGet, "/v2/integrations", nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list integrations: %w", err)
+	}
+	for _, i := range list.Data {
+		if i.Name != name {
+			continue
+		}
+		var result response[integrationMetadata]
+		if err := c.Do(ctx, http.MethodGet, "/v2/integrations/"+url.PathEscape(i.ID), nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to get integration %s: %w", name, err)
+		}
+		return &result.Data, nil
+	}
+	return nil, nil
+}
+
+// contextIntegration reads the desired integration from module inputs.
+func contextIntegration(ctx blackstart.ModuleContext) (desiredIntegration, error) {
+	var i desiredIntegration
+	var err error
+	if i.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredIntegration{}, err
+	}
+	if i.team, err = blackstart.ContextInputAs[string](ctx, inputTeam, false); err != nil {
+		return desiredIntegration{}, err
+	}
+	if i.integrationType, err = blackstart.ContextInputAs[string](ctx, inputType, false); err != nil {
+		return desiredIntegration{}, err
+	}
+	if i.integrationType == "" {
+		i.integrationType = integrationTypeAPI
+	}
+	return i, nil
+}
+
+// outputIntegration emits the outputs of an integration.
+func outputIntegration(ctx blackstart.ModuleContext, i integrationMetadata) error {
+	if err := ctx.Output(outputID, i.ID); err != nil {
+		return err
+	}
+	return ctx.Output(outputAPIKey, i.APIKey)
+}
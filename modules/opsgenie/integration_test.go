@@ -0,0 +1,59 @@
+package opsgenie
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestIntegration_CreateExistingDelete(t *testing.T) {
+	f := newFakeOpsgenie(t)
+	m := NewIntegration()
+	op := fakeOperation(f, "opsgenie_integration", map[string]any{inputName: "app", inputTeam: "team-1"})
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	created := f.collections["/v2/integrations"][0]
+	require.Equal(t, integrationTypeAPI, created["type"])
+	require.Equal(t, "team-1", created["teamId"])
+	require.Equal(t, created["id"], ctx.outputs[outputID])
+	require.Equal(t, created["apiKey"], ctx.outputs[outputAPIKey])
+
+	// The existing integration is read by its ID to output its API key again.
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created["apiKey"], ctx.outputs[outputAPIKey])
+	require.Contains(t, f.requests, "GET /v2/integrations/"+created["id"].(string))
+
+	// An integration with the name and a different type cannot be changed.
+	op.Inputs[inputType] = blackstart.NewInputFromValue("Prometheus")
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ErrorContains(t, m.Set(blackstart.OpContext(context.Background(), op)), "cannot be changed")
+
+	// A tainted integration is replaced with the new type.
+	op.Tainted = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Len(t, f.collections["/v2/integrations"], 1)
+	require.Equal(t, "Prometheus", f.collections["/v2/integrations"][0]["type"])
+	require.NotEqual(t, created["id"], f.collections["/v2/integrations"][0]["id"])
+	op.Tainted = false
+
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.collections["/v2/integrations"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,85 @@
+package opsgenie
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputAPIKey          = "api_key"
+	inputAPIURL          = restapi.InputAPIURL
+	inputName            = "name"
+	inputDescription     = "description"
+	inputTeam            = "team"
+	inputUsers           = "users"
+	inputSchedules       = "schedules"
+	inputEscalationDelay = "escalation_delay"
+	inputType            = "type"
+
+	outputID     = "id"
+	outputAPIKey = "api_key"
+)
+
+const (
+	defaultAPIURL = "https://api.opsgenie.com"
+	apiKeyEnvVar  = "OPSGENIE_API_KEY"
+)
+
+func init() {
+	blackstart.RegisterPathName("opsgenie", "Opsgenie")
+}
+
+// credentials configures the api_key and api_url inputs of the Opsgenie modules.
+var credentials = restapi.Credentials{
+	Input:             inputAPIKey,
+	Description:       "Opsgenie API key used to authenticate API requests.",
+	EnvVar:            apiKeyEnvVar,
+	APIURLDescription: "Opsgenie API base URL. Set this for accounts in the EU region, for example `https://api.eu.opsgenie.com`.",
+	DefaultAPIURL:     defaultAPIURL,
+}
+
+// response is the envelope of Opsgenie API responses.
+type response[T any] struct {
+	Data T `json:"data"`
+}
+
+// newClient creates an Opsgenie REST API client for the given base URL and API key.
+func newClient(baseURL, apiKey string) *restapi.Client {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", "GenieKey "+apiKey)
+	return restapi.NewClient(restapi.Config{API: "opsgenie", BaseURL: baseURL, Header: header})
+}
+
+// contextClient builds an Opsgenie API client from the api_key and api_url module inputs. When no
+// API key input is provided, the OPSGENIE_API_KEY environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*restapi.Client, error) {
+	apiKey, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(apiURL, apiKey), nil
+}
+
+// contextDescription returns the optional description input, or nil when the description is not
+// managed.
+func contextDescription(ctx blackstart.ModuleContext) (*string, error) {
+	input, err := ctx.Input(inputDescription)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input %s: %w", inputDescription, err)
+	}
+	return &value, nil
+}
+
+// sameDescription reports whether the current description matches the desired description. The
+// description is only compared when it is set.
+func sameDescription(current string, desired *string) bool {
+	return desired == nil || current == *desired
+}
@@ -0,0 +1,170 @@
+package opsgenie
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeOpsgenie implements Opsgenie API endpoints that list, create, get, update, and delete
+// escalations, services, and integrations stored in memory. Objects are stored by collection path,
+// such as `/v2/escalations`, and responses are wrapped in the `data` envelope.
+type fakeOpsgenie struct {
+	server      *httptest.Server
+	collections map[string][]map[string]any
+	requests    []string
+	nextID      int
+	mu          sync.Mutex
+}
+
+// newFakeOpsgenie starts a fake Opsgenie API server.
+func newFakeOpsgenie(t *testing.T) *fakeOpsgenie {
+	t.Helper()
+	f := &fakeOpsgenie{
+		collections: map[string][]map[string]any{
+			"/v2/escalations":  {},
+			"/v1/services":     {},
+			"/v2/integrations": {},
+		},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// add stores an object in a collection and returns its ID.
+func (f *fakeOpsgenie) add(collection string, object map[string]any) string {
+	f.nextID++
+	id := fmt.Sprintf("id-%d", f.nextID)
+	object["id"] = id
+	if collection == "/v2/integrations" {
+		object["apiKey"] = fmt.Sprintf("key-%d", f.nextID)
+	}
+	f.collections[collection] = append(f.collections[collection], object)
+	return id
+}
+
+func (f *fakeOpsgenie) find(collection, identifier, field string) (int, map[string]any) {
+	for i, item := range f.collections[collection] {
+		if item[field] == identifier {
+			return i, item
+		}
+	}
+	return -1, nil
+}
+
+func (f *fakeOpsgenie) write(w http.ResponseWriter, status int, data any) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"data": data, "requestId": "test"})
+}
+
+func (f *fakeOpsgenie) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "GenieKey test-key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message":"Could not authenticate"}`))
+		return
+	}
+
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+
+	if items, ok := f.collections[r.URL.Path]; ok {
+		switch r.Method {
+		case http.MethodGet:
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+			limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+			if err != nil {
+				limit = len(items)
+			}
+			start := min(offset, len(items))
+			var page []map[string]any
+			for _, item := range items[start:min(start+limit, len(items))] {
+				listed := maps.Clone(item)
+				delete(listed, "apiKey")
+				page = append(page, listed)
+			}
+			f.write(w, http.StatusOK, page)
+		case http.MethodPost:
+			if team, ok := body["ownerTeam"].(map[string]any); ok && r.URL.Path == "/v2/integrations" {
+				body["teamId"] = team["id"]
+				delete(body, "ownerTeam")
+			}
+			f.add(r.URL.Path, body)
+			f.write(w, http.StatusCreated, body)
+		}
+		return
+	}
+
+	collection, identifier := path.Dir(r.URL.Path), path.Base(r.URL.Path)
+	field := "id"
+	if r.URL.Query().Get("identifierType") == "name" {
+		field = "name"
+	}
+	i, item := f.find(collection, identifier, field)
+	if item == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"Not found"}`))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		f.write(w, http.StatusOK, item)
+	case http.MethodPatch:
+		maps.Copy(item, body)
+		f.write(w, http.StatusOK, map[string]any{"id": item["id"], "name": item["name"]})
+	case http.MethodDelete:
+		f.collections[collection] = append(f.collections[collection][:i], f.collections[collection][i+1:]...)
+		f.write(w, http.StatusOK, nil)
+	}
+}
+
+// fakeOperation returns an operation of a module targeting the fake server.
+func fakeOperation(f *fakeOpsgenie, module string, inputs map[string]any) *blackstart.Operation {
+	return credentials.TestOperation(module, f.server.URL, "test-key", inputs)
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestContextClient_APIKeyFromEnvironment(t *testing.T) {
+	f := newFakeOpsgenie(t)
+	op := fakeOperation(f, "opsgenie_service", nil)
+	delete(op.Inputs, inputAPIKey)
+
+	t.Setenv(apiKeyEnvVar, "")
+	_, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, apiKeyEnvVar)
+
+	t.Setenv(apiKeyEnvVar, "test-key")
+	c, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "GenieKey test-key", c.Header.Get("Authorization"))
+	require.Equal(t, f.server.URL, c.BaseURL)
+}
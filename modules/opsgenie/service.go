@@ -0,0 +1,225 @@
+package opsgenie
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("opsgenie_service", NewService)
+}
+
+var _ blackstart.Module = &service{}
+
+// NewService creates a module that manages an Opsgenie service.
+func NewService() blackstart.Module {
+	return &service{}
+}
+
+// service implements the opsgenie_service module.
+type service struct{}
+
+// serviceMetadata is the service information returned by the Opsgenie API.
+type serviceMetadata struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TeamID      string `json:"teamId"`
+}
+
+// desiredService is the desired state of a service read from module inputs.
+type desiredService struct {
+	name        string
+	description *string
+	team        string
+}
+
+// body returns the request body that creates or updates the service.
+func (s desiredService) body() map[string]any {
+	body := map[string]any{"name": s.name}
+	if s.description != nil {
+		body["description"] = *s.description
+	}
+	return body
+}
+
+func (m *service) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "opsgenie_service",
+		Name: "Opsgenie service",
+		Description: util.CleanString(
+			`
+Ensures an Opsgenie service exists for an application, owned by the team whose routing rules and
+escalations are notified of its incidents. Services are matched by name. The description of an
+existing service is updated when it differs.
+
+**Notes**
+
+- The team of a service cannot be changed. Set fails when a service with the name is owned by a
+  different team.
+- When '''doesNotExist''' is set, the service is deleted.
+`,
+		),
+		Requirements: []string{
+			"An Opsgenie API key with the Read and Create and Update and Delete access rights.",
+			"If the `api_key` input is not set, the `OPSGENIE_API_KEY` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the service.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputDescription: {
+					Description: "Description of the service. If not set, the description is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputTeam: {
+					Description: "ID of the team that owns the service. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the service.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Application service": `id: app-service
+module: opsgenie_service
+inputs:
+  name: app
+  description: Application service
+  team: 8418d193-2dab-4490-b331-8c02cdd196b7`,
+		},
+	}
+}
+
+func (m *service) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputName); err != nil {
+		return err
+	}
+	if op.DoesNotExist {
+		return nil
+	}
+	return restapi.ValidateRequiredStrings(op, inputTeam)
+}
+
+func (m *service) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextService(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := findService(ctx, c, desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || current.TeamID != desired.team ||
+		!sameDescription(current.Description, desired.description) {
+		return false, nil
+	}
+	return true, ctx.Output(outputID, current.ID)
+}
+
+func (m *service) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextService(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := findService(ctx, c, desired.name)
+	if err != nil {
+		return err
+	}
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, "/v1/services/"+url.PathEscape(current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete service %s: %w", desired.name, err)
+		}
+		return nil
+	}
+
+	if current == nil {
+		body := desired.body()
+		body["teamId"] = desired.team
+		var result response[serviceMetadata]
+		if err = c.Do(ctx, http.MethodPost, "/v1/services", body, &result); err != nil {
+			return fmt.Errorf("failed to create service %s: %w", desired.name, err)
+		}
+		return ctx.Output(outputID, result.Data.ID)
+	}
+	if current.TeamID != desired.team {
+		return fmt.Errorf(
+			"service %s is owned by team %s, which cannot be changed to %s", desired.name, current.TeamID, desired.team,
+		)
+	}
+	if err = c.Do(ctx, http.MethodPatch, "/v1/services/"+url.PathEscape(current.ID), desired.body(), nil); err != nil {
+		return fmt.Errorf("failed to update service %s: %w", desired.name, err)
+	}
+	return ctx.Output(outputID, current.ID)
+}
+
+// findService returns the service with the name, if any. Services are listed by page, because the
+// Opsgenie API does not get services by name.
+func findService(ctx blackstart.ModuleContext, c *restapi.Client, name string) (*serviceMetadata, error) {
+	for offset := 0; ; offset += restapi.PageSize {
+		var result response[[]serviceMetadata]
+		path := fmt.Sprintf("/v1/services?limit=%d&offset=%d", restapi.PageSize, offset)
+		if err := c.Do(ctx, http.MethodGet, path, nil, &result); err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		for i, svc := range result.Data {
+			if svc.Name == name {
+				return &result.Data[i], nil
+			}
+		}
+		if len(result.Data) < restapi.PageSize {
+			return nil, nil
+		}
+	}
+}
+
+// contextService reads the desired service from module inputs.
+func contextService(ctx blackstart.ModuleContext) (desiredService, error) {
+	var s desiredService
+	var err error
+	if s.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredService{}, err
+	}
+	if ctx.DoesNotExist() {
+		return s, nil
+	}
+	if s.description, err = contextDescription(ctx); err != nil {
+		return desiredService{}, err
+	}
+	if s.team, err = blackstart.ContextInputAs[string](ctx, inputTeam, true); err != nil {
+		return desiredService{}, err
+	}
+	return s, nil
+}
@@ -0,0 +1,75 @@
+package opsgenie
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+func TestService_Validate(t *testing.T) {
+	m := NewService()
+	f := newFakeOpsgenie(t)
+
+	op := fakeOperation(f, "opsgenie_service", map[string]any{inputName: "app"})
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: team")
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+}
+
+func TestService_CreateUpdateDelete(t *testing.T) {
+	f := newFakeOpsgenie(t)
+	for i := range restapi.PageSize {
+		f.add("/v1/services", map[string]any{"name": fmt.Sprintf("service-%d", i), "teamId": "team-1"})
+	}
+	m := NewService()
+	op := fakeOperation(f, "opsgenie_service", map[string]any{inputName: "app", inputTeam: "team-1"})
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	created := f.collections["/v1/services"][restapi.PageSize]
+	require.Equal(t, ctx.outputs[outputID], created["id"])
+	require.Equal(t, "team-1", created["teamId"])
+
+	// The service is found on the second page.
+	f.requests = nil
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created["id"], ctx.outputs[outputID])
+	require.Equal(t, []string{"GET /v1/services", "GET /v1/services"}, f.requests)
+
+	// A different description updates the existing service.
+	op.Inputs[inputDescription] = blackstart.NewInputFromValue("Application service")
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PATCH /v1/services/"+created["id"].(string))
+	require.Equal(t, "Application service", created["description"])
+
+	// The team of an existing service cannot be changed.
+	op.Inputs[inputTeam] = blackstart.NewInputFromValue("team-2")
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ErrorContains(t, m.Set(blackstart.OpContext(context.Background(), op)), "cannot be changed")
+
+	op = fakeOperation(f, "opsgenie_service", map[string]any{inputName: "app"})
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Len(t, f.collections["/v1/services"], restapi.PageSize)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,369 @@
+package pagerduty
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	defaultEscalationDelay = 30
+	maxNumLoops            = 9
+
+	targetTypeUser     = "user"
+	targetTypeSchedule = "schedule"
+)
+
+func init() {
+	blackstart.RegisterModule("pagerduty_escalation_policy", NewEscalationPolicy)
+}
+
+var _ blackstart.Module = &escalationPolicy{}
+
+// NewEscalationPolicy creates a module that manages a PagerDuty escalation policy.
+func NewEscalationPolicy() blackstart.Module {
+	return &escalationPolicy{}
+}
+
+// escalationPolicy implements the pagerduty_escalation_policy module.
+type escalationPolicy struct{}
+
+// escalationPolicyMetadata is the escalation policy information returned by the PagerDuty API.
+type escalationPolicyMetadata struct {
+	ID              string           `json:"id"`
+	Name            string           `json:"name"`
+	Description     string           `json:"description"`
+	NumLoops        int              `json:"num_loops"`
+	EscalationRules []escalationRule `json:"escalation_rules"`
+	HTMLURL         string           `json:"html_url"`
+}
+
+// escalationRule is a level of an escalation policy.
+type escalationRule struct {
+	EscalationDelayInMinutes int         `json:"escalation_delay_in_minutes"`
+	Targets                  []reference `json:"targets"`
+}
+
+// desiredEscalationPolicy is the desired state of an escalation policy read from module inputs.
+type desiredEscalationPolicy struct {
+	name            string
+	description     *string
+	users           []string
+	schedules       []string
+	escalationDelay int
+	numLoops        int
+}
+
+// targets returns the targets of the escalation rule, such as `user:PUSER01`, in a stable order.
+func (p desiredEscalationPolicy) targets() []string {
+	var targets []string
+	for _, id := range p.users {
+		targets = append(targets, targetTypeUser+":"+id)
+	}
+	for _, id := range p.schedules {
+		targets = append(targets, targetTypeSchedule+":"+id)
+	}
+	slices.Sort(targets)
+	return targets
+}
+
+// matches reports whether the escalation policy has the desired description, loops, and a single
+// escalation rule with the desired delay and targets.
+func (p desiredEscalationPolicy) matches(current escalationPolicyMetadata) bool {
+	if !sameDescription(current.Description, p.description) || current.NumLoops != p.numLoops {
+		return false
+	}
+	if len(current.EscalationRules) != 1 || current.EscalationRules[0].EscalationDelayInMinutes != p.escalationDelay {
+		return false
+	}
+	var targets []string
+	for _, target := range current.EscalationRules[0].Targets {
+		targets = append(targets, strings.TrimSuffix(target.Type, "_reference")+":"+target.ID)
+	}
+	slices.Sort(targets)
+	return slices.Equal(targets, p.targets())
+}
+
+// body returns the request body that creates or updates the escalation policy.
+func (p desiredEscalationPolicy) body() map[string]any {
+	rule := escalationRule{EscalationDelayInMinutes: p.escalationDelay}
+	for _, id := range p.users {
+		rule.Targets = append(rule.Targets, reference{ID: id, Type: targetTypeUser + "_reference"})
+	}
+	for _, id := range p.schedules {
+		rule.Targets = append(rule.Targets, reference{ID: id, Type: targetTypeSchedule + "_reference"})
+	}
+	policy := map[string]any{
+		"type":             "escalation_policy",
+		"name":             p.name,
+		"num_loops":        p.numLoops,
+		"escalation_rules": []escalationRule{rule},
+	}
+	if p.description != nil {
+		policy["description"] = *p.description
+	}
+	return map[string]any{"escalation_policy": policy}
+}
+
+func (m *escalationPolicy) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "pagerduty_escalation_policy",
+		Name: "PagerDuty escalation policy",
+		Description: util.CleanString(
+			`
+Ensures a PagerDuty escalation policy exists that notifies users and on-call schedules, such as the
+on-call rotation of the team that owns a new application. Escalation policies are matched by name.
+
+The policy has a single escalation rule that notifies all of its targets. The rule, loops, and
+description of an existing policy are updated when they differ.
+
+**Notes**
+
+- Users and schedules are identified by their PagerDuty IDs, such as '''PUSER01'''.
+- When '''doesNotExist''' is set, the escalation policy is deleted. PagerDuty does not delete
+  escalation policies that are used by services.
+`,
+		),
+		Requirements: []string{
+			"A PagerDuty REST API key with write access.",
+			"If the `token` input is not set, the `PAGERDUTY_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the escalation policy.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputDescription: {
+					Description: "Description of the escalation policy. If not set, the description is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputUsers: {
+					Description: "IDs of the users to notify. At least one user or schedule is required.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+				inputSchedules: {
+					Description: "IDs of the on-call schedules to notify. At least one user or schedule is required.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+				inputEscalationDelay: {
+					Description: "Minutes before an unacknowledged incident is escalated again.",
+					Type:        reflect.TypeFor[int](),
+					Required:    false,
+					Default:     defaultEscalationDelay,
+				},
+				inputNumLoops: {
+					Description: fmt.Sprintf("Number of times the escalation rule repeats, up to %d.", maxNumLoops),
+					Type:        reflect.TypeFor[int](),
+					Required:    false,
+					Default:     0,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the escalation policy.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputHTMLURL: {
+				Description: "URL of the escalation policy in PagerDuty.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Team on-call escalation": `id: app-escalation-policy
+module: pagerduty_escalation_policy
+inputs:
+  name: App On-Call
+  description: Escalation policy of the app team
+  schedules:
+    - PSCHED1
+  users:
+    - PUSER01
+  escalation_delay: 15
+  num_loops: 2`,
+		},
+	}
+}
+
+func (m *escalationPolicy) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputName); err != nil {
+		return err
+	}
+	_, hasUsers := op.Inputs[inputUsers]
+	_, hasSchedules := op.Inputs[inputSchedules]
+	if !hasUsers && !hasSchedules && !op.DoesNotExist {
+		return fmt.Errorf("at least one of parameter %s and parameter %s must be provided", inputUsers, inputSchedules)
+	}
+	if input, ok := op.Inputs[inputEscalationDelay]; ok && input.IsStatic() {
+		if _, err := escalationDelay(input); err != nil {
+			return err
+		}
+	}
+	if input, ok := op.Inputs[inputNumLoops]; ok && input.IsStatic() {
+		if _, err := numLoops(input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *escalationPolicy) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextEscalationPolicy(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := findEscalationPolicy(ctx, c, desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !desired.matches(*current) {
+		return false, nil
+	}
+	return true, outputEscalationPolicy(ctx, *current)
+}
+
+func (m *escalationPolicy) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextEscalationPolicy(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := findEscalationPolicy(ctx, c, desired.name)
+	if err != nil {
+		return err
+	}
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, "/escalation_policies/"+url.PathEscape(current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete escalation policy %s: %w", desired.name, err)
+		}
+		return nil
+	}
+
+	var result struct {
+		EscalationPolicy escalationPolicyMetadata `json:"escalation_policy"`
+	}
+	if current == nil {
+		if err = c.Do(ctx, http.MethodPost, "/escalation_policies", desired.body(), &result); err != nil {
+			return fmt.Errorf("failed to create escalation policy %s: %w", desired.name, err)
+		}
+		return outputEscalationPolicy(ctx, result.EscalationPolicy)
+	}
+	path := "/escalation_policies/" + url.PathEscape(current.ID)
+	if err = c.Do(ctx, http.MethodPut, path, desired.body(), &result); err != nil {
+		return fmt.Errorf("failed to update escalation policy %s: %w", desired.name, err)
+	}
+	return outputEscalationPolicy(ctx, result.EscalationPolicy)
+}
+
+// findEscalationPolicy returns the escalation policy with the name, if any.
+func findEscalationPolicy(ctx blackstart.ModuleContext, c *restapi.Client, name string) (
+	*escalationPolicyMetadata, error,
+) {
+	policy, err := findByName(
+		ctx, c, "/escalation_policies", "escalation_policies", name, func(p escalationPolicyMetadata) string {
+			return p.Name
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list escalation policies: %w", err)
+	}
+	return policy, nil
+}
+
+// escalationDelay returns the escalation delay of an input, which must be a positive number of
+// minutes.
+func escalationDelay(input blackstart.Input) (int, error) {
+	delay, err := blackstart.InputAs[int](input, false)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s is invalid: %w", inputEscalationDelay, err)
+	}
+	if delay < 1 {
+		return 0, fmt.Errorf("parameter %s must be at least 1 minute", inputEscalationDelay)
+	}
+	return delay, nil
+}
+
+// numLoops returns the number of loops of an input, from 0 to maxNumLoops.
+func numLoops(input blackstart.Input) (int, error) {
+	loops, err := blackstart.InputAs[int](input, false)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s is invalid: %w", inputNumLoops, err)
+	}
+	if loops < 0 || loops > maxNumLoops {
+		return 0, fmt.Errorf("parameter %s must be between 0 and %d", inputNumLoops, maxNumLoops)
+	}
+	return loops, nil
+}
+
+// contextEscalationPolicy reads the desired escalation policy from module inputs.
+func contextEscalationPolicy(ctx blackstart.ModuleContext) (desiredEscalationPolicy, error) {
+	p := desiredEscalationPolicy{escalationDelay: defaultEscalationDelay}
+	var err error
+	if p.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredEscalationPolicy{}, err
+	}
+	if ctx.DoesNotExist() {
+		return p, nil
+	}
+	if p.description, err = contextDescription(ctx); err != nil {
+		return desiredEscalationPolicy{}, err
+	}
+	if p.users, err = blackstart.ContextInputAs[[]string](ctx, inputUsers, false); err != nil {
+		return desiredEscalationPolicy{}, err
+	}
+	if p.schedules, err = blackstart.ContextInputAs[[]string](ctx, inputSchedules, false); err != nil {
+		return desiredEscalationPolicy{}, err
+	}
+	if len(p.users) == 0 && len(p.schedules) == 0 {
+		return desiredEscalationPolicy{}, fmt.Errorf(
+			"at least one of input '%s' and input '%s' must be set", inputUsers, inputSchedules,
+		)
+	}
+	if input, iErr := ctx.Input(inputEscalationDelay); iErr == nil && input.Any() != nil {
+		if p.escalationDelay, err = escalationDelay(input); err != nil {
+			return desiredEscalationPolicy{}, err
+		}
+	}
+	if input, iErr := ctx.Input(inputNumLoops); iErr == nil && input.Any() != nil {
+		if p.numLoops, err = numLoops(input); err != nil {
+			return desiredEscalationPolicy{}, err
+		}
+	}
+	return p, nil
+}
+
+// outputEscalationPolicy emits the outputs of an escalation policy.
+func outputEscalationPolicy(ctx blackstart.ModuleContext, p escalationPolicyMetadata) error {
+	if err := ctx.Output(outputID, p.ID); err != nil {
+		return err
+	}
+	return ctx.Output(outputHTMLURL, p.HTMLURL)
+}
@@ -0,0 +1,95 @@
+package pagerduty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestEscalationPolicy_Validate(t *testing.T) {
+	m := NewEscalationPolicy()
+	f := newFakePagerDuty(t)
+
+	op := fakeOperation(f, "pagerduty_escalation_policy", map[string]any{inputName: "App On-Call"})
+	require.ErrorContains(t, m.Validate(*op), "at least one of parameter users and parameter schedules")
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+
+	op = fakeOperation(
+		f, "pagerduty_escalation_policy", map[string]any{
+			inputName: "App On-Call", inputUsers: []string{"PUSER01"}, inputEscalationDelay: 0,
+		},
+	)
+	require.ErrorContains(t, m.Validate(*op), "at least 1 minute")
+
+	op = fakeOperation(
+		f, "pagerduty_escalation_policy", map[string]any{
+			inputName: "App On-Call", inputSchedules: []string{"PSCHED1"}, inputNumLoops: 10,
+		},
+	)
+	require.ErrorContains(t, m.Validate(*op), "between 0 and 9")
+}
+
+func TestEscalationPolicy_CreateUpdateDelete(t *testing.T) {
+	f := newFakePagerDuty(t)
+	m := NewEscalationPolicy()
+	inputs := map[string]any{
+		inputName:      "App On-Call",
+		inputUsers:     []string{"PUSER01"},
+		inputSchedules: []string{"PSCHED1"},
+	}
+	op := fakeOperation(f, "pagerduty_escalation_policy", inputs)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	created := f.collections["escalation_policies"][0]
+	require.Equal(t, ctx.outputs[outputID], created["id"])
+	require.Equal(t, "App On-Call", created["name"])
+	require.NotContains(t, created, "description")
+	rules := created["escalation_rules"].([]any)
+	require.Len(t, rules, 1)
+	rule := rules[0].(map[string]any)
+	require.EqualValues(t, defaultEscalationDelay, rule["escalation_delay_in_minutes"])
+	require.ElementsMatch(
+		t, []any{
+			map[string]any{"id": "PUSER01", "type": "user_reference"},
+			map[string]any{"id": "PSCHED1", "type": "schedule_reference"},
+		}, rule["targets"],
+	)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// A different delay updates the existing escalation policy.
+	inputs[inputEscalationDelay] = 10
+	inputs[inputDescription] = "Escalation policy of the app team"
+	op = fakeOperation(f, "pagerduty_escalation_policy", inputs)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PUT /escalation_policies/"+created["id"].(string))
+	require.Len(t, f.collections["escalation_policies"], 1)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.DoesNotExist = true
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.collections["escalation_policies"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,164 @@
+package pagerduty
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputToken            = "token"
+	inputAPIURL           = restapi.InputAPIURL
+	inputName             = "name"
+	inputDescription      = "description"
+	inputUsers            = "users"
+	inputSchedules        = "schedules"
+	inputEscalationDelay  = "escalation_delay"
+	inputNumLoops         = "num_loops"
+	inputEscalationPolicy = "escalation_policy"
+	inputService          = "service"
+	inputType             = "type"
+
+	outputID             = "id"
+	outputHTMLURL        = "html_url"
+	outputIntegrationKey = "integration_key"
+)
+
+const (
+	defaultAPIURL = "https://api.pagerduty.com"
+	tokenEnvVar   = "PAGERDUTY_TOKEN"
+)
+
+func init() {
+	blackstart.RegisterPathName("pagerduty", "PagerDuty")
+}
+
+// errorMessage returns the message of a PagerDuty API error response, followed by the details of
+// the error, such as the fields that failed validation.
+func errorMessage(body io.Reader) string {
+	var payload struct {
+		Error struct {
+			Message string   `json:"message"`
+			Errors  []string `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return ""
+	}
+	if len(payload.Error.Errors) == 0 {
+		return payload.Error.Message
+	}
+	return payload.Error.Message + ": " + strings.Join(payload.Error.Errors, ", ")
+}
+
+// reference is a reference to another PagerDuty object, such as the escalation policy of a service.
+type reference struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+}
+
+// credentials configures the token and api_url inputs of the PagerDuty modules.
+var credentials = restapi.Credentials{
+	Input:             inputToken,
+	Description:       "PagerDuty REST API key used to authenticate API requests.",
+	EnvVar:            tokenEnvVar,
+	APIURLDescription: "PagerDuty REST API base URL. Set this for accounts in the EU service region, for example `https://api.eu.pagerduty.com`.",
+	DefaultAPIURL:     defaultAPIURL,
+}
+
+// newClient creates a PagerDuty REST API client for the given base URL and token.
+func newClient(baseURL, token string) *restapi.Client {
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.pagerduty+json;version=2")
+	header.Set("Authorization", "Token token="+token)
+	return restapi.NewClient(
+		restapi.Config{API: "pagerduty", BaseURL: baseURL, Header: header, ErrorMessage: errorMessage},
+	)
+}
+
+// listAll returns the items of every page of a PagerDuty API list endpoint. PagerDuty returns the
+// items of a page in the field named after the collection, such as `services`.
+func listAll[T any](ctx context.Context, c *restapi.Client, path, collection string, query url.Values) ([]T, error) {
+	var all []T
+	for offset := 0; ; offset += restapi.PageSize {
+		q := url.Values{}
+		maps.Copy(q, query)
+		q.Set("limit", fmt.Sprint(restapi.PageSize))
+		q.Set("offset", fmt.Sprint(offset))
+
+		var page map[string]json.RawMessage
+		if err := c.Do(ctx, http.MethodGet, path+"?"+q.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+		var items []T
+		if err := json.Unmarshal(page[collection], &items); err != nil {
+			return nil, fmt.Errorf("failed to decode pagerduty api response: %w", err)
+		}
+		all = append(all, items...)
+
+		var more bool
+		if raw, ok := page["more"]; ok {
+			if err := json.Unmarshal(raw, &more); err != nil {
+				return nil, fmt.Errorf("failed to decode pagerduty api response: %w", err)
+			}
+		}
+		if !more || len(items) == 0 {
+			return all, nil
+		}
+	}
+}
+
+// findByName returns the object of a collection with the exact name, if any. PagerDuty matches the
+// query filter of list endpoints to any part of the name, so the names of the results are compared.
+func findByName[T any](ctx context.Context, c *restapi.Client, path, collection, name string, nameOf func(T) string) (
+	*T, error,
+) {
+	items, err := listAll[T](ctx, c, path, collection, url.Values{"query": {name}})
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		if nameOf(items[i]) == name {
+			return &items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// contextDescription returns the optional description input, or nil when the description is not
+// managed.
+func contextDescription(ctx blackstart.ModuleContext) (*string, error) {
+	input, err := ctx.Input(inputDescription)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input %s: %w", inputDescription, err)
+	}
+	return &value, nil
+}
+
+// sameDescription reports whether the current description matches the desired description. The
+// description is only compared when it is set.
+func sameDescription(current string, desired *string) bool {
+	return desired == nil || current == *desired
+}
+
+// contextClient builds a PagerDuty API client from the token and api_url module inputs. When no
+// token input is provided, the PAGERDUTY_TOKEN environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*restapi.Client, error) {
+	token, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(apiURL, token), nil
+}
@@ -0,0 +1,222 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+// singular maps the collections of the fake server to the field of a single object in requests and
+// responses.
+var singular = map[string]string{
+	"escalation_policies": "escalation_policy",
+	"services":            "service",
+}
+
+// fakePagerDuty implements PagerDuty API endpoints that list, create, get, update, and delete
+// escalation policies and services stored in memory, and that create integrations of services.
+type fakePagerDuty struct {
+	server      *httptest.Server
+	collections map[string][]map[string]any
+	requests    []string
+	nextID      int
+	mu          sync.Mutex
+}
+
+// newFakePagerDuty starts a fake PagerDuty API server.
+func newFakePagerDuty(t *testing.T) *fakePagerDuty {
+	t.Helper()
+	f := &fakePagerDuty{collections: map[string][]map[string]any{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// add stores an object in a collection and returns its ID.
+func (f *fakePagerDuty) add(collection string, object map[string]any) string {
+	f.nextID++
+	id := fmt.Sprintf("P%05d", f.nextID)
+	object["id"] = id
+	object["html_url"] = "https://example.pagerduty.com/" + collection + "/" + id
+	f.collections[collection] = append(f.collections[collection], object)
+	return id
+}
+
+func (f *fakePagerDuty) find(collection, id string) (int, map[string]any) {
+	for i, item := range f.collections[collection] {
+		if item["id"] == id {
+			return i, item
+		}
+	}
+	return -1, nil
+}
+
+func (f *fakePagerDuty) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Token token=test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"Unauthorized","code":2006}}`))
+		return
+	}
+
+	var body map[string]map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	collection := segments[0]
+	field, ok := singular[collection]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		query := r.URL.Query()
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		var matched []map[string]any
+		for _, item := range f.collections[collection] {
+			if strings.Contains(item["name"].(string), query.Get("query")) {
+				matched = append(matched, item)
+			}
+		}
+		start := min(offset, len(matched))
+		end := min(start+limit, len(matched))
+		_ = json.NewEncoder(w).Encode(
+			map[string]any{collection: append([]map[string]any{}, matched[start:end]...), "more": end < len(matched)},
+		)
+		return
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		f.add(collection, body[field])
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{field: body[field]})
+		return
+	}
+
+	i, item := f.find(collection, segments[1])
+	if item == nil {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":{"message":"Not Found","code":2100}}`))
+		return
+	}
+	if len(segments) == 3 && segments[2] == "integrations" && r.Method == http.MethodPost {
+		integration := body["integration"]
+		f.nextID++
+		integration["id"] = fmt.Sprintf("P%05d", f.nextID)
+		integration["integration_key"] = fmt.Sprintf("key-%d", f.nextID)
+		integrations, _ := item["integrations"].([]map[string]any)
+		item["integrations"] = append(integrations, integration)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"integration": integration})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		_ = json.NewEncoder(w).Encode(map[string]any{field: item})
+	case http.MethodPut:
+		for k, v := range body[field] {
+			item[k] = v
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{field: item})
+	case http.MethodDelete:
+		f.collections[collection] = append(f.collections[collection][:i], f.collections[collection][i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// fakeOperation returns an operation of a module targeting the fake server.
+func fakeOperation(f *fakePagerDuty, module string, inputs map[string]any) *blackstart.Operation {
+	return credentials.TestOperation(module, f.server.URL, "test-token", inputs)
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestErrorMessage(t *testing.T) {
+	require.Equal(t, "Not Found", errorMessage(bytes.NewBufferString(`{"error":{"message":"Not Found","code":2100}}`)))
+	require.Equal(
+		t,
+		"Invalid Input Provided: Name has already been taken.",
+		errorMessage(
+			bytes.NewBufferString(
+				`{"error":{"message":"Invalid Input Provided","code":2001,"errors":["Name has already been taken."]}}`,
+			),
+		),
+	)
+	require.Empty(t, errorMessage(bytes.NewBufferString(`not json`)))
+}
+
+func TestListAll(t *testing.T) {
+	f := newFakePagerDuty(t)
+	for i := range restapi.PageSize + 5 {
+		f.add("services", map[string]any{"name": fmt.Sprintf("service-%d", i)})
+	}
+
+	items, err := listAll[serviceMetadata](
+		context.Background(), newClient(f.server.URL, "test-token"), "/services", "services", nil,
+	)
+	require.NoError(t, err)
+	require.Len(t, items, restapi.PageSize+5)
+	require.Equal(t, []string{"GET /services", "GET /services"}, f.requests)
+}
+
+func TestFindByName(t *testing.T) {
+	f := newFakePagerDuty(t)
+	f.add("services", map[string]any{"name": "app-worker"})
+	id := f.add("services", map[string]any{"name": "app"})
+
+	c := newClient(f.server.URL, "test-token")
+	nameOf := func(s serviceMetadata) string { return s.Name }
+	svc, err := findByName(context.Background(), c, "/services", "services", "app", nameOf)
+	require.NoError(t, err)
+	require.NotNil(t, svc)
+	require.Equal(t, id, svc.ID)
+
+	svc, err = findByName(context.Background(), c, "/services", "services", "ap", nameOf)
+	require.NoError(t, err)
+	require.Nil(t, svc)
+}
+
+func TestContextClient_TokenFromEnvironment(t *testing.T) {
+	f := newFakePagerDuty(t)
+	op := fakeOperation(f, "pagerduty_service", nil)
+	delete(op.Inputs, inputToken)
+
+	t.Setenv(tokenEnvVar, "")
+	_, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, tokenEnvVar)
+
+	t.Setenv(tokenEnvVar, "test-token")
+	c, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "Token token=test-token", c.Header.Get("Authorization"))
+	require.Equal(t, f.server.URL, c.BaseURL)
+}
@@ -0,0 +1,241 @@
+package pagerduty
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("pagerduty_service", NewService)
+}
+
+var _ blackstart.Module = &service{}
+
+// NewService creates a module that manages a PagerDuty service.
+func NewService() blackstart.Module {
+	return &service{}
+}
+
+// service implements the pagerduty_service module.
+type service struct{}
+
+// serviceMetadata is the service information returned by the PagerDuty API.
+type serviceMetadata struct {
+	ID               string    `json:"id"`
+	Name             string    `json:"name"`
+	Description      string    `json:"description"`
+	EscalationPolicy reference `json:"escalation_policy"`
+	HTMLURL          string    `json:"html_url"`
+}
+
+// desiredService is the desired state of a service read from module inputs.
+type desiredService struct {
+	name             string
+	description      *string
+	escalationPolicy string
+}
+
+// matches reports whether the service has the desired escalation policy and description.
+func (s desiredService) matches(current serviceMetadata) bool {
+	return current.EscalationPolicy.ID == s.escalationPolicy && sameDescription(current.Description, s.description)
+}
+
+// body returns the request body that creates or updates the service.
+func (s desiredService) body() map[string]any {
+	svc := map[string]any{
+		"type": "service",
+		"name": s.name,
+		"escalation_policy": reference{
+			ID:   s.escalationPolicy,
+			Type: "escalation_policy_reference",
+		},
+	}
+	if s.description != nil {
+		svc["description"] = *s.description
+	}
+	return map[string]any{"service": svc}
+}
+
+func (m *service) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "pagerduty_service",
+		Name: "PagerDuty service",
+		Description: util.CleanString(
+			`
+Ensures a PagerDuty service exists for an application, with the escalation policy that is notified
+of its incidents. Services are matched by name. The escalation policy and description of an
+existing service are updated when they differ.
+
+Alerts are sent to the service through an integration, which is managed with the
+'''pagerduty_service_integration''' module.
+
+**Notes**
+
+- When '''doesNotExist''' is set, the service is deleted, including its integrations and incidents.
+`,
+		),
+		Requirements: []string{
+			"A PagerDuty REST API key with write access.",
+			"If the `token` input is not set, the `PAGERDUTY_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the service.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputDescription: {
+					Description: "Description of the service. If not set, the description is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputEscalationPolicy: {
+					Description: "ID of the escalation policy of the service. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the service.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputHTMLURL: {
+				Description: "URL of the service in PagerDuty.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Application service": `id: app-service
+module: pagerduty_service
+inputs:
+  name: app
+  description: Application service
+  escalation_policy:
+    fromDependency:
+      id: app-escalation-policy
+      output: id`,
+		},
+	}
+}
+
+func (m *service) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputName); err != nil {
+		return err
+	}
+	if op.DoesNotExist {
+		return nil
+	}
+	return restapi.ValidateRequiredStrings(op, inputEscalationPolicy)
+}
+
+func (m *service) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextService(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := findService(ctx, c, desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !desired.matches(*current) {
+		return false, nil
+	}
+	return true, outputService(ctx, *current)
+}
+
+func (m *service) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextService(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := findService(ctx, c, desired.name)
+	if err != nil {
+		return err
+	}
+	if ctx.DoesNotExist() {
+		if current == nil {
+			return nil
+		}
+		err = c.Do(ctx, http.MethodDelete, "/services/"+url.PathEscape(current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete service %s: %w", desired.name, err)
+		}
+		return nil
+	}
+
+	var result struct {
+		Service serviceMetadata `json:"service"`
+	}
+	if current == nil {
+		if err = c.Do(ctx, http.MethodPost, "/services", desired.body(), &result); err != nil {
+			return fmt.Errorf("failed to create service %s: %w", desired.name, err)
+		}
+		return outputService(ctx, result.Service)
+	}
+	if err = c.Do(ctx, http.MethodPut, "/services/"+url.PathEscape(current.ID), desired.body(), &result); err != nil {
+		return fmt.Errorf("failed to update service %s: %w", desired.name, err)
+	}
+	return outputService(ctx, result.Service)
+}
+
+// findService returns the service with the name, if any.
+func findService(ctx blackstart.ModuleContext, c *restapi.Client, name string) (*serviceMetadata, error) {
+	svc, err := findByName(
+		ctx, c, "/services", "services", name, func(s serviceMetadata) string {
+			return s.Name
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+	return svc, nil
+}
+
+// contextService reads the desired service from module inputs.
+func contextService(ctx blackstart.ModuleContext) (desiredService, error) {
+	var s desiredService
+	var err error
+	if s.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredService{}, err
+	}
+	if ctx.DoesNotExist() {
+		return s, nil
+	}
+	if s.description, err = contextDescription(ctx); err != nil {
+		return desiredService{}, err
+	}
+	if s.escalationPolicy, err = blackstart.ContextInputAs[string](ctx, inputEscalationPolicy, true); err != nil {
+		return desiredService{}, err
+	}
+	return s, nil
+}
+
+// outputService emits the outputs of a service.
+func outputService(ctx blackstart.ModuleContext, s serviceMetadata) error {
+	if err := ctx.Output(outputID, s.ID); err != nil {
+		return err
+	}
+	return ctx.Output(outputHTMLURL, s.HTMLURL)
+}
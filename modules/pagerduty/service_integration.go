@@ -0,0 +1,247 @@
+package pagerduty
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+const (
+	integrationTypeEventsV2 = "events_api_v2_inbound_integration"
+	integrationTypeEventsV1 = "generic_events_api_inbound_integration"
+)
+
+var integrationTypes = map[string]struct{}{
+	integrationTypeEventsV2: {},
+	integrationTypeEventsV1: {},
+}
+
+func init() {
+	blackstart.RegisterModule("pagerduty_service_integration", NewServiceIntegration)
+}
+
+var _ blackstart.Module = &serviceIntegration{}
+
+// NewServiceIntegration creates a module that manages an integration of a PagerDuty service.
+func NewServiceIntegration() blackstart.Module {
+	return &serviceIntegration{}
+}
+
+// serviceIntegration implements the pagerduty_service_integration module.
+type serviceIntegration struct{}
+
+// integrationMetadata is the integration information returned by the PagerDuty API.
+type integrationMetadata struct {
+	ID             string `json:"id"`
+	Type           string `json:"type"`
+	Name           string `json:"name"`
+	IntegrationKey string `json:"integration_key"`
+}
+
+func (m *serviceIntegration) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "pagerduty_service_integration",
+		Name: "PagerDuty service integration",
+		Description: util.CleanString(
+			`
+Ensures a PagerDuty service has an integration that alerts are sent to, and outputs its integration
+key. The integration key is the routing key of the Events API, so it can be stored in the secrets
+of the application or its monitoring, such as with the '''kubernetes_secret_value''' module.
+Integrations are matched by name.
+
+**Notes**
+
+- The type of an integration cannot be changed. Set fails when an integration with the name has a
+  different type.
+- PagerDuty does not allow integrations to be deleted through the API, so '''doesNotExist''' is not
+  supported. Integrations are deleted with their service.
+`,
+		),
+		Requirements: []string{
+			"A PagerDuty REST API key with write access.",
+			"If the `token` input is not set, the `PAGERDUTY_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputService: {
+					Description: "ID of the service.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputName: {
+					Description: "Name of the integration.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputType: {
+					Description: "Type of the integration. One of `events_api_v2_inbound_integration` or `generic_events_api_inbound_integration` for the Events API v1.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+					Default:     integrationTypeEventsV2,
+					Immutable:   true,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the integration.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputIntegrationKey: {
+				Description: "Integration key used to send events to the service.",
+				Type:        reflect.TypeFor[string](),
+				Sensitive:   true,
+			},
+		},
+		Examples: map[string]string{
+			"Events API integration": `id: app-pagerduty-integration
+module: pagerduty_service_integration
+inputs:
+  service:
+    fromDependency:
+      id: app-service
+      output: id
+  name: Alertmanager`,
+		},
+	}
+}
+
+func (m *serviceIntegration) Validate(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported, PagerDuty integrations are deleted with their service")
+	}
+	if err := restapi.ValidateRequiredStrings(op, inputService, inputName); err != nil {
+		return err
+	}
+	input, ok := op.Inputs[inputType]
+	if !ok || !input.IsStatic() {
+		return nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return fmt.Errorf("parameter %s is invalid: %w", inputType, err)
+	}
+	if _, ok = integrationTypes[value]; value != "" && !ok {
+		return fmt.Errorf("parameter %s has invalid value '%s'", inputType, value)
+	}
+	return nil
+}
+
+func (m *serviceIntegration) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	serviceID, name, integrationType, err := contextServiceIntegration(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := findIntegration(ctx, c, serviceID, name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.Tainted() || current == nil || current.Type != integrationType {
+		return false, nil
+	}
+	return true, outputIntegration(ctx, *current)
+}
+
+func (m *serviceIntegration) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	serviceID, name, integrationType, err := contextServiceIntegration(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := findIntegration(ctx, c, serviceID, name)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		if current.Type != integrationType {
+			return fmt.Errorf(
+				"integration %s of service %s has type %s, which cannot be changed to %s",
+				name, serviceID, current.Type, integrationType,
+			)
+		}
+		return outputIntegration(ctx, *current)
+	}
+
+	body := map[string]any{
+		"integration": map[string]any{
+			"type":    integrationType,
+			"name":    name,
+			"service": reference{ID: serviceID, Type: "service_reference"},
+		},
+	}
+	var result struct {
+		Integration integrationMetadata `json:"integration"`
+	}
+	path := "/services/" + url.PathEscape(serviceID) + "/integrations"
+	if err = c.Do(ctx, http.MethodPost, path, body, &result); err != nil {
+		return fmt.Errorf("failed to create integration %s of service %s: %w", name, serviceID, err)
+	}
+	return outputIntegration(ctx, result.Integration)
+}
+
+// findIntegration returns the integration of a service with the name, if any.
+func findIntegration(ctx blackstart.ModuleContext, c *restapi.Client, serviceID, name string) (
+	*integrationMetadata, error,
+) {
+	var result struct {
+		Service struct {
+			Integrations []integrationMetadata `json:"integrations"`
+		} `json:"service"`
+	}
+	path := "/services/" + url.PathEscape(serviceID) + "?" + url.Values{"include[]": {"integrations"}}.Encode()
+	if err := c.Do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", serviceID, err)
+	}
+	for i, integration := range result.Service.Integrations {
+		if integration.Name == name {
+			return &result.Service.Integrations[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// contextServiceIntegration reads the service ID, name, and type of the integration from module
+// inputs.
+func contextServiceIntegration(ctx blackstart.ModuleContext) (string, string, string, error) {
+	serviceID, err := blackstart.ContextInputAs[string](ctx, inputService, true)
+	if err != nil {
+		return "", "", "", err
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return "", "", "", err
+	}
+	integrationType, err := blackstart.ContextInputAs[string](ctx, inputType, false)
+	if err != nil {
+		return "", "", "", err
+	}
+	if integrationType == "" {
+		integrationType = integrationTypeEventsV2
+	}
+	if _, ok := integrationTypes[integrationType]; !ok {
+		return "", "", "", fmt.Errorf("input '%s' has invalid value '%s'", inputType, integrationType)
+	}
+	return serviceID, name, integrationType, nil
+}
+
+// outputIntegration emits the outputs of an integration.
+func outputIntegration(ctx blackstart.ModuleContext, i integrationMetadata) error {
+	if err := ctx.Output(outputID, i.ID); err != nil {
+		return err
+	}
+	return ctx.Output(outputIntegrationKey, i.IntegrationKey)
+}
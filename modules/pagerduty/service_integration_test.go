@@ -0,0 +1,98 @@
+package pagerduty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/state"
+)
+
+func TestServiceIntegration_Validate(t *testing.T) {
+	m := NewServiceIntegration()
+	f := newFakePagerDuty(t)
+
+	op := fakeOperation(f, "pagerduty_service_integration", map[string]any{inputService: "PSVC", inputName: "app"})
+	require.NoError(t, m.Validate(*op))
+	op.Inputs[inputType] = blackstart.NewInputFromValue("email")
+	require.ErrorContains(t, m.Validate(*op), "invalid value 'email'")
+
+	op = fakeOperation(f, "pagerduty_service_integration", map[string]any{inputService: "PSVC", inputName: "app"})
+	op.DoesNotExist = true
+	require.ErrorContains(t, m.Validate(*op), "doesNotExist is not supported")
+}
+
+func TestServiceIntegration_CreateExisting(t *testing.T) {
+	f := newFakePagerDuty(t)
+	serviceID := f.add("services", map[string]any{"name": "app"})
+	m := NewServiceIntegration()
+	op := fakeOperation(
+		f, "pagerduty_service_integration", map[string]any{inputService: serviceID, inputName: "Alertmanager"},
+	)
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	_, svc := f.find("services", serviceID)
+	integrations := svc["integrations"].([]map[string]any)
+	require.Len(t, integrations, 1)
+	require.Equal(t, integrationTypeEventsV2, integrations[0]["type"])
+	require.Equal(t, integrations[0]["integration_key"], ctx.outputs[outputIntegrationKey])
+
+	// The existing integration is found by name and its key is output again.
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, integrations[0]["integration_key"], ctx.outputs[outputIntegrationKey])
+
+	// An integration with the name and a different type cannot be changed.
+	op.Inputs[inputType] = blackstart.NewInputFromValue(integrationTypeEventsV1)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.ErrorContains(t, m.Set(blackstart.OpContext(context.Background(), op)), "cannot be changed")
+}
+
+// TestServiceIntegration_ImmutableType runs a workflow with a state store, so a changed type taints
+// the operation until an integration of the new type is created.
+func TestServiceIntegration_ImmutableType(t *testing.T) {
+	f := newFakePagerDuty(t)
+	serviceID := f.add("services", map[string]any{"name": "app"})
+	ctx := context.WithValue(context.Background(), blackstart.StateStoreKey, state.NewMemoryStore())
+	run := func(integrationType string) blackstart.WorkflowResult {
+		op := fakeOperation(
+			f, "pagerduty_service_integration",
+			map[string]any{inputService: serviceID, inputName: "Alertmanager", inputType: integrationType},
+		)
+		wf := blackstart.Workflow{Name: "pagerduty-immutable", Operations: []blackstart.Operation{*op}}
+		return wf.Run(ctx)
+	}
+	integrations := func() []map[string]any {
+		_, svc := f.find("services", serviceID)
+		return svc["integrations"].([]map[string]any)
+	}
+
+	require.NoError(t, run(integrationTypeEventsV2).Err)
+	require.Len(t, integrations(), 1)
+
+	// The integration cannot be replaced, so the tainted operation fails.
+	require.ErrorContains(t, run(integrationTypeEventsV1).Err, "cannot be changed")
+	require.Equal(t, integrationTypeEventsV2, integrations()[0]["type"])
+
+	// The operation stays tainted until the integration is removed and created with the new type.
+	_, svc := f.find("services", serviceID)
+	svc["integrations"] = []map[string]any{}
+	require.NoError(t, run(integrationTypeEventsV1).Err)
+	require.Len(t, integrations(), 1)
+	require.Equal(t, integrationTypeEventsV1, integrations()[0]["type"])
+
+	// The new type is recorded, so the next run does not create another integration.
+	require.NoError(t, run(integrationTypeEventsV1).Err)
+	require.Len(t, integrations(), 1)
+}
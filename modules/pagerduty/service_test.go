@@ -0,0 +1,70 @@
+package pagerduty
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestService_Validate(t *testing.T) {
+	m := NewService()
+	f := newFakePagerDuty(t)
+
+	op := fakeOperation(f, "pagerduty_service", map[string]any{inputName: "app"})
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: escalation_policy")
+	op.DoesNotExist = true
+	require.NoError(t, m.Validate(*op))
+}
+
+func TestService_CreateUpdateDelete(t *testing.T) {
+	f := newFakePagerDuty(t)
+	m := NewService()
+	op := fakeOperation(f, "pagerduty_service", map[string]any{inputName: "app", inputEscalationPolicy: "PPOLICY"})
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	created := f.collections["services"][0]
+	require.Equal(t, ctx.outputs[outputID], created["id"])
+	require.Equal(t, created["html_url"], ctx.outputs[outputHTMLURL])
+	require.Equal(
+		t, map[string]any{"id": "PPOLICY", "type": "escalation_policy_reference"}, created["escalation_policy"],
+	)
+
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, created["id"], ctx.outputs[outputID])
+
+	// A different escalation policy and description update the existing service.
+	op = fakeOperation(
+		f, "pagerduty_service", map[string]any{
+			inputName: "app", inputEscalationPolicy: "PPOLICY2", inputDescription: "Application service",
+		},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PUT /services/"+created["id"].(string))
+	require.Equal(t, "Application service", created["description"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op = fakeOperation(f, "pagerduty_service", map[string]any{inputName: "app"})
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.collections["services"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
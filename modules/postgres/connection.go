@@ -85,6 +85,7 @@ func (c *connectionModule) Info() blackstart.ModuleInfo {
 				Description: "password to connect to the PostgreSQL database.",
 				Type:        reflect.TypeFor[string](),
 				Required:    false,
+				Sensitive:   true,
 			},
 			inputSslMode: {
 				Description: "SSL mode to use when connecting to the PostgreSQL database. Options are 'disable', 'prefer', 'require', 'verify-ca', 'verify-full'.",
@@ -107,6 +108,7 @@ inputs:
   database: mydb
   username: admin`,
 		},
+		OutputsOnly: true,
 	}
 }
 
@@ -144,16 +146,26 @@ func (c *connectionModule) Set(ctx blackstart.ModuleContext) error {
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		c.target.host, c.target.port, c.target.username, c.target.password, c.target.database, c.target.sslMode,
 	)
+	// Connections to a host that is down fail right away while its circuit is open.
+	endpoint := fmt.Sprintf("postgres://%s:%d", c.target.host, c.target.port)
+	if err := blackstart.EndpointAllowed(ctx, endpoint); err != nil {
+		return err
+	}
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		return fmt.Errorf("error connecting to database: %w", err)
 	}
 	err = db.PingContext(ctx)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// The server responded, so the endpoint is reachable.
+		blackstart.RecordEndpointResult(ctx, endpoint, nil)
+		_ = db.Close()
+		return fmt.Errorf("error pinging database: %s", pqErr.Message)
+	}
+	blackstart.RecordEndpointResult(ctx, endpoint, err)
 	if err != nil {
-		var pqErr *pq.Error
-		if errors.As(err, &pqErr) {
-			return fmt.Errorf("error pinging database: %s", pqErr.Message)
-		}
+		_ = db.Close()
 		return fmt.Errorf("error pinging database: %w", err)
 	}
 	c.db = db
@@ -162,6 +174,7 @@ func (c *connectionModule) Set(ctx blackstart.ModuleContext) error {
 		c.db = nil
 		return err
 	}
+	connectionTargets.Store(db, fmt.Sprintf("%s:%d/%s", c.target.host, c.target.port, c.target.database))
 	return nil
 }
 
@@ -170,6 +183,7 @@ func (c *connectionModule) Close() error {
 	if c.db == nil {
 		return nil
 	}
+	connectionTargets.Delete(c.db)
 	err := c.db.Close()
 	c.db = nil
 	return err
@@ -240,6 +240,7 @@ inputs:
   for_role: admin
   revoke_mode: RESTRICT`,
 		},
+		SerializationKey: connectionSerializationKey,
 	}
 }
 
@@ -351,6 +352,7 @@ func (m *defaultPrivilegesModule) setup(mctx blackstart.ModuleContext) error {
 
 func (m *defaultPrivilegesModule) resolveCurrentRole(mctx blackstart.ModuleContext) (string, error) {
 	var currentRole string
+	blackstart.RecordAPICall(mctx, blackstart.APIProviderPostgres)
 	err := m.db.QueryRowContext(mctx, "SELECT current_user").Scan(&currentRole)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve current role: %w", err)
@@ -559,6 +561,7 @@ func defaultPrivilegeExists(
 	if grantee == "PUBLIC" {
 		granteeOID = 0
 	} else {
+		blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 		err := db.QueryRowContext(
 			ctx,
 			`SELECT oid FROM pg_roles WHERE rolname = $1`,
@@ -589,6 +592,7 @@ WHERE d.defaclrole = (SELECT oid FROM pg_roles WHERE rolname = $1)
 `
 
 	var exists bool
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 	err := db.QueryRowContext(
 		ctx,
 		query,
@@ -773,6 +777,7 @@ func (m *defaultPrivilegesModule) Set(mctx blackstart.ModuleContext) error {
 
 	for _, target := range targets {
 		query := buildAlterDefaultPrivilegesSQL(target, mctx.DoesNotExist())
+		blackstart.RecordAPICall(mctx, blackstart.APIProviderPostgres)
 		if _, err := m.db.ExecContext(mctx, query); err != nil {
 			return fmt.Errorf("failed to apply default privilege statement %q: %w", query, err)
 		}
@@ -820,6 +820,7 @@ inputs:
   scope: PARAMETER
   resource: work_mem`,
 		},
+		SerializationKey: connectionSerializationKey,
 	}
 }
 
@@ -959,6 +960,7 @@ func (g *grantModule) Check(ctx blackstart.ModuleContext) (bool, error) {
 		exists := true
 		for _, existsQuery := range existsQueries {
 			var queryExists bool
+			blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 			err = g.db.QueryRowContext(ctx, existsQuery.query, existsQuery.params...).Scan(&queryExists)
 			if err != nil {
 				if isPQInvalidParameterValueError(err) {
@@ -1083,6 +1085,7 @@ func (g *grantModule) Set(ctx blackstart.ModuleContext) error {
 			if revokeErr != nil {
 				return fmt.Errorf("error getting revoke query: %w", revokeErr)
 			}
+			blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 			_, err = g.db.ExecContext(ctx, query, queryParams...)
 			if err != nil {
 				return fmt.Errorf("error revoking grant: %w", err)
@@ -1095,6 +1098,7 @@ func (g *grantModule) Set(ctx blackstart.ModuleContext) error {
 			return fmt.Errorf("error getting grant query: %w", queryErr)
 		}
 
+		blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 		_, err = g.db.ExecContext(ctx, query, queryParams...)
 		if err != nil {
 			return fmt.Errorf("error setting grant: %w", err)
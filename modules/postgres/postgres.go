@@ -1,6 +1,12 @@
 package postgres
 
-import "github.com/pezops/blackstart"
+import (
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/pezops/blackstart"
+)
 
 const (
 	inputHost            = "host"
@@ -30,3 +36,23 @@ const (
 func init() {
 	blackstart.RegisterPathName("postgres", "PostgreSQL")
 }
+
+// connectionTargets maps the connections opened by postgres_connection operations to the server
+// and database they connect to, so operations using different connections to one database share
+// a serialization key.
+var connectionTargets sync.Map
+
+// connectionSerializationKey returns the serialization key of operations that change a PostgreSQL
+// database. Concurrent changes of grants, default privileges, and roles of one database fail with
+// errors such as "tuple concurrently updated", so these operations are run one at a time.
+// Connections that were not opened by a postgres_connection operation are keyed by connection.
+func connectionSerializationKey(ctx blackstart.ModuleContext) (string, error) {
+	db, err := blackstart.ContextInputAs[*sql.DB](ctx, inputConnection, true)
+	if err != nil {
+		return "", err
+	}
+	if target, ok := connectionTargets.Load(db); ok {
+		return fmt.Sprintf("postgres:%s", target), nil
+	}
+	return fmt.Sprintf("postgres:%p", db), nil
+}
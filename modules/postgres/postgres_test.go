@@ -2,10 +2,16 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"log"
 	"os"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
 )
 
 func TestMain(m *testing.M) {
@@ -32,3 +38,40 @@ func TestMain(m *testing.M) {
 
 	os.Exit(code)
 }
+
+// TestConnectionSerializationKey verifies operations using connections to the same database share
+// a serialization key.
+func TestConnectionSerializationKey(t *testing.T) {
+	keyOf := func(db *sql.DB) string {
+		op := blackstart.Operation{
+			Id:     "grant",
+			Module: "postgres_grant",
+			Inputs: map[string]blackstart.Input{inputConnection: blackstart.NewInputFromValue(db)},
+		}
+		key, err := connectionSerializationKey(blackstart.OpContext(context.Background(), &op))
+		require.NoError(t, err)
+		return key
+	}
+	first, err := sql.Open("postgres", "host=db.example.com dbname=app")
+	require.NoError(t, err)
+	defer func() { _ = first.Close() }()
+	second, err := sql.Open("postgres", "host=db.example.com dbname=app")
+	require.NoError(t, err)
+	defer func() { _ = second.Close() }()
+
+	// Connections that were not opened by a postgres_connection operation are keyed by connection.
+	assert.NotEqual(t, keyOf(first), keyOf(second))
+
+	connectionTargets.Store(first, "db.example.com:5432/app")
+	connectionTargets.Store(second, "db.example.com:5432/app")
+	defer connectionTargets.Delete(first)
+	defer connectionTargets.Delete(second)
+	assert.Equal(t, "postgres:db.example.com:5432/app", keyOf(first))
+	assert.Equal(t, keyOf(first), keyOf(second))
+
+	for _, info := range []blackstart.ModuleInfo{
+		NewPostgresGrant().Info(), NewPostgresDefaultPrivileges().Info(), NewPostgresRole().Info(),
+	} {
+		assert.NotNil(t, info.SerializationKey, info.Id)
+	}
+}
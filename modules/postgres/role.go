@@ -59,6 +59,11 @@ func (r roleModule) Info() blackstart.ModuleInfo {
 			"The executing database user must be a member of a role with `CREATEROLE`.",
 		},
 		Inputs: map[string]blackstart.InputValue{
+			inputConnection: {
+				Description: "database connection to the managed PostgreSQL instance.",
+				Type:        reflect.TypeFor[*sql.DB](),
+				Required:    true,
+			},
 			inputName: {
 				Description: "Id of the Role to manage.",
 				Type:        reflect.TypeFor[string](),
@@ -99,9 +104,10 @@ inputs:
     fromDependency:
       id: manage-instance
       output: connection
-  Name: my-new-Role
-  Login: true`,
+  name: my-new-Role
+  login: true`,
 		},
+		SerializationKey: connectionSerializationKey,
 	}
 }
 
@@ -240,6 +246,7 @@ func (r roleModule) checkRoleExists(ctx context.Context) (bool, error) {
 
 	// Execute the query
 	var exists bool
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 	err = r.db.QueryRowContext(ctx, getRoleQuery, queryParams...).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("error checking grant: %w", err)
@@ -257,6 +264,7 @@ func (r roleModule) checkRoleCorrectOptions(ctx context.Context) (bool, error) {
 
 	// Execute the query to check if the correct Role exists
 	var exists bool
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 	err = r.db.QueryRowContext(ctx, getRoleWithOptionsQuery, queryParams...).Scan(&exists)
 	if err != nil {
 		return false, fmt.Errorf("error checking Role: %w", err)
@@ -277,6 +285,7 @@ func (r roleModule) dropRole(ctx context.Context) error {
 		return err
 	}
 
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 	_, err = r.db.ExecContext(ctx, queryBuffer.String())
 	if err != nil {
 		return fmt.Errorf("error dropping Role: %w", err)
@@ -297,6 +306,7 @@ func (r roleModule) createRole(ctx context.Context) error {
 		return err
 	}
 
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 	_, err = r.db.ExecContext(ctx, queryBuffer.String())
 	if err != nil {
 		return fmt.Errorf("error creating Role: %w", err)
@@ -317,6 +327,7 @@ func (r roleModule) updateRole(ctx context.Context) error {
 		return err
 	}
 
+	blackstart.RecordAPICall(ctx, blackstart.APIProviderPostgres)
 	_, err = r.db.ExecContext(ctx, queryBuffer.String())
 	if err != nil {
 		return fmt.Errorf("error updating Role: %w", err)
@@ -0,0 +1,430 @@
+package s3
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("s3_bucket", NewBucket)
+}
+
+var _ blackstart.Module = &bucket{}
+
+// NewBucket creates a module that manages an S3 bucket.
+func NewBucket() blackstart.Module {
+	return &bucket{}
+}
+
+// bucket implements the s3_bucket module.
+type bucket struct{}
+
+// lifecycleConfiguration is the lifecycle input of a bucket, in the format of the S3 API.
+type lifecycleConfiguration struct {
+	Rules []types.LifecycleRule
+}
+
+// bucketConfig is the desired configuration of a bucket. Unset fields are not managed.
+type bucketConfig struct {
+	versioning *bool
+	lifecycle  *lifecycleConfiguration
+	policy     string
+}
+
+func (m *bucket) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "s3_bucket",
+		Name: "S3 bucket",
+		Description: util.CleanString(
+			`
+Ensures an S3 bucket exists with the configured versioning, lifecycle rules, and bucket policy. The
+module works with Amazon S3 and S3-compatible storage, such as MinIO or Ceph, by setting the
+'''endpoint''' input.
+
+Versioning, lifecycle rules, and the bucket policy are only managed when their inputs are set. The
+'''lifecycle''' input is a lifecycle configuration with a '''Rules''' list in the format of the S3
+API, as YAML or JSON, and replaces the lifecycle configuration of the bucket. The '''policy''' input
+is a bucket policy JSON document. Policies are compared after parsing, so formatting differences do
+not cause updates.
+
+When '''doesNotExist''' is set, the bucket is deleted. S3 only deletes empty buckets, so any objects
+must be removed first.
+`,
+		),
+		Requirements: []string{
+			"Credentials with permission to create and configure the bucket. For Amazon S3, the `s3:CreateBucket`, `s3:ListBucket`, `s3:GetBucketVersioning`, `s3:PutBucketVersioning`, `s3:GetLifecycleConfiguration`, `s3:PutLifecycleConfiguration`, `s3:GetBucketPolicy`, and `s3:PutBucketPolicy` permissions are required, and `s3:DeleteBucket` to delete the bucket.",
+			"If the `access_key_id` and `secret_access_key` inputs are not set, credentials are loaded from the default AWS sources, such as the `AWS_ACCESS_KEY_ID` and `AWS_SECRET_ACCESS_KEY` environment variables.",
+		},
+		Inputs: clientInputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the bucket.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputVersioning: {
+					Description: "Whether object versioning is enabled. When `false`, versioning is suspended if it was enabled. If not set, versioning is not managed.",
+					Type:        reflect.TypeFor[*bool](),
+					Required:    false,
+				},
+				inputLifecycle: {
+					Description: "Lifecycle configuration of the bucket, as YAML or JSON. If not set, lifecycle rules are not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputPolicy: {
+					Description: "Bucket policy JSON document. If not set, the bucket policy is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputName: {
+				Description: "Name of the bucket.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Versioned bucket with lifecycle rules": `id: backups-bucket
+module: s3_bucket
+inputs:
+  name: example-backups
+  region: eu-west-1
+  versioning: true
+  lifecycle: |
+    Rules:
+      - ID: expire-old-versions
+        Status: Enabled
+        Filter:
+          Prefix: ""
+        NoncurrentVersionExpiration:
+          NoncurrentDays: 30`,
+			"MinIO bucket with a policy": `id: assets-bucket
+module: s3_bucket
+inputs:
+  endpoint: https://minio.example.internal:9000
+  region: us-east-1
+  path_style: true
+  access_key_id: blackstart
+  secret_access_key:
+    fromDependency:
+      id: minio-credentials
+      output: value
+  name: assets
+  policy: |
+    {
+      "Version": "2012-10-17",
+      "Statement": [{
+        "Effect": "Allow",
+        "Principal": {"AWS": ["*"]},
+        "Action": ["s3:GetObject"],
+        "Resource": ["arn:aws:s3:::assets/public/*"]
+      }]
+    }`,
+		},
+	}
+}
+
+func (m *bucket) Validate(op blackstart.Operation) error {
+	if err := validateClientInputs(op); err != nil {
+		return err
+	}
+
+	input, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", inputName)
+	}
+	if input.IsStatic() {
+		if _, err := blackstart.InputAs[string](input, true); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputName, err)
+		}
+	}
+
+	if input, ok = op.Inputs[inputLifecycle]; ok && input.IsStatic() {
+		value, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputLifecycle, err)
+		}
+		if _, err = parseLifecycle(value); err != nil {
+			return err
+		}
+	}
+
+	if input, ok = op.Inputs[inputPolicy]; ok && input.IsStatic() {
+		value, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputPolicy, err)
+		}
+		if _, err = parsePolicy(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *bucket) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return false, err
+	}
+
+	exists, err := bucketExists(ctx, c, name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists {
+		return false, nil
+	}
+
+	desired, err := contextBucketConfig(ctx)
+	if err != nil {
+		return false, err
+	}
+	versioningOK, err := versioningMatches(ctx, c, name, desired.versioning)
+	if err != nil || !versioningOK {
+		return false, err
+	}
+	lifecycleOK, err := lifecycleMatches(ctx, c, name, desired.lifecycle)
+	if err != nil || !lifecycleOK {
+		return false, err
+	}
+	policyOK, err := policyMatches(ctx, c, name, desired.policy)
+	if err != nil || !policyOK {
+		return false, err
+	}
+	return true, ctx.Output(outputName, name)
+}
+
+func (m *bucket) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		_, err = c.DeleteBucket(ctx, &s3.DeleteBucketInput{Bucket: aws.String(name)})
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete bucket %s: %w", name, err)
+		}
+		return nil
+	}
+
+	desired, err := contextBucketConfig(ctx)
+	if err != nil {
+		return err
+	}
+	exists, err := bucketExists(ctx, c, name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		input := &s3.CreateBucketInput{Bucket: aws.String(name)}
+		// Buckets in us-east-1 are created without a location constraint.
+		if region := c.Options().Region; region != "" && region != "us-east-1" {
+			input.CreateBucketConfiguration = &types.CreateBucketConfiguration{
+				LocationConstraint: types.BucketLocationConstraint(region),
+			}
+		}
+		if _, err = c.CreateBucket(ctx, input); err != nil {
+			return fmt.Errorf("failed to create bucket %s: %w", name, err)
+		}
+	}
+
+	if ok, vErr := versioningMatches(ctx, c, name, desired.versioning); vErr != nil {
+		return vErr
+	} else if !ok {
+		status := types.BucketVersioningStatusSuspended
+		if *desired.versioning {
+			status = types.BucketVersioningStatusEnabled
+		}
+		_, err = c.PutBucketVersioning(
+			ctx, &s3.PutBucketVersioningInput{
+				Bucket:                  aws.String(name),
+				VersioningConfiguration: &types.VersioningConfiguration{Status: status},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set versioning of bucket %s: %w", name, err)
+		}
+	}
+
+	if ok, lErr := lifecycleMatches(ctx, c, name, desired.lifecycle); lErr != nil {
+		return lErr
+	} else if !ok {
+		_, err = c.PutBucketLifecycleConfiguration(
+			ctx, &s3.PutBucketLifecycleConfigurationInput{
+				Bucket:                 aws.String(name),
+				LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: desired.lifecycle.Rules},
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set lifecycle configuration of bucket %s: %w", name, err)
+		}
+	}
+
+	if ok, pErr := policyMatches(ctx, c, name, desired.policy); pErr != nil {
+		return pErr
+	} else if !ok {
+		_, err = c.PutBucketPolicy(ctx, &s3.PutBucketPolicyInput{Bucket: aws.String(name), Policy: aws.String(desired.policy)})
+		if err != nil {
+			return fmt.Errorf("failed to set policy of bucket %s: %w", name, err)
+		}
+	}
+	return ctx.Output(outputName, name)
+}
+
+// contextBucketConfig reads the desired bucket configuration from module inputs.
+func contextBucketConfig(ctx blackstart.ModuleContext) (bucketConfig, error) {
+	versioning, err := blackstart.ContextInputAs[*bool](ctx, inputVersioning, false)
+	if err != nil {
+		return bucketConfig{}, err
+	}
+	lifecycleInput, err := blackstart.ContextInputAs[string](ctx, inputLifecycle, false)
+	if err != nil {
+		return bucketConfig{}, err
+	}
+	lifecycle, err := parseLifecycle(lifecycleInput)
+	if err != nil {
+		return bucketConfig{}, err
+	}
+	policy, err := blackstart.ContextInputAs[string](ctx, inputPolicy, false)
+	if err != nil {
+		return bucketConfig{}, err
+	}
+	if _, err = parsePolicy(policy); err != nil {
+		return bucketConfig{}, err
+	}
+	return bucketConfig{versioning: versioning, lifecycle: lifecycle, policy: strings.TrimSpace(policy)}, nil
+}
+
+// parseLifecycle parses a YAML or JSON lifecycle configuration. An empty value returns nil, as
+// lifecycle rules are not managed.
+func parseLifecycle(value string) (*lifecycleConfiguration, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var lifecycle lifecycleConfiguration
+	if err := yaml.UnmarshalStrict([]byte(value), &lifecycle); err != nil {
+		return nil, fmt.Errorf("input '%s' is not a valid lifecycle configuration: %w", inputLifecycle, err)
+	}
+	for i, rule := range lifecycle.Rules {
+		if rule.Status == "" {
+			return nil, fmt.Errorf("input '%s' rule %d is missing a status", inputLifecycle, i)
+		}
+	}
+	return &lifecycle, nil
+}
+
+// parsePolicy parses a bucket policy JSON document. An empty value returns nil, as the bucket
+// policy is not managed.
+func parsePolicy(value string) (any, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	var policy any
+	if err := json.Unmarshal([]byte(value), &policy); err != nil {
+		return nil, fmt.Errorf("input '%s' is not a valid JSON document: %w", inputPolicy, err)
+	}
+	return policy, nil
+}
+
+// bucketExists reports whether the bucket exists and is accessible.
+func bucketExists(ctx blackstart.ModuleContext, c *s3.Client, name string) (bool, error) {
+	_, err := c.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(name)})
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get bucket %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// versioningMatches reports whether the versioning state of the bucket matches the desired state.
+// A bucket that never had versioning enabled matches a desired state of false.
+func versioningMatches(ctx blackstart.ModuleContext, c *s3.Client, name string, desired *bool) (bool, error) {
+	if desired == nil {
+		return true, nil
+	}
+	out, err := c.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(name)})
+	if err != nil {
+		return false, fmt.Errorf("failed to get versioning of bucket %s: %w", name, err)
+	}
+	return *desired == (out.Status == types.BucketVersioningStatusEnabled), nil
+}
+
+// lifecycleMatches reports whether the lifecycle rules of the bucket match the desired rules.
+func lifecycleMatches(
+	ctx blackstart.ModuleContext, c *s3.Client, name string, desired *lifecycleConfiguration,
+) (bool, error) {
+	if desired == nil {
+		return true, nil
+	}
+	var current []types.LifecycleRule
+	out, err := c.GetBucketLifecycleConfiguration(
+		ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(name)},
+	)
+	if err != nil && !isNotFound(err) {
+		return false, fmt.Errorf("failed to get lifecycle configuration of bucket %s: %w", name, err)
+	}
+	if err == nil {
+		current = out.Rules
+	}
+	if len(current) == 0 && len(desired.Rules) == 0 {
+		return true, nil
+	}
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+	desiredJSON, err := json.Marshal(desired.Rules)
+	if err != nil {
+		return false, err
+	}
+	return string(currentJSON) == string(desiredJSON), nil
+}
+
+// policyMatches reports whether the policy of the bucket is equivalent to the desired policy
+// document.
+func policyMatches(ctx blackstart.ModuleContext, c *s3.Client, name, desired string) (bool, error) {
+	if desired == "" {
+		return true, nil
+	}
+	out, err := c.GetBucketPolicy(ctx, &s3.GetBucketPolicyInput{Bucket: aws.String(name)})
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get policy of bucket %s: %w", name, err)
+	}
+	var current, want any
+	if err = json.Unmarshal([]byte(aws.ToString(out.Policy)), &current); err != nil {
+		return false, nil
+	}
+	if err = json.Unmarshal([]byte(desired), &want); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(current, want), nil
+}
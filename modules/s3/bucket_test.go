@@ -0,0 +1,117 @@
+package s3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testLifecycle = `
+Rules:
+  - ID: expire-tmp
+    Status: Enabled
+    Filter:
+      Prefix: tmp/
+    Expiration:
+      Days: 7
+`
+
+const testPolicy = `{
+  "Version": "2012-10-17",
+  "Statement": [{"Effect": "Allow", "Principal": "*", "Action": "s3:GetObject", "Resource": "arn:aws:s3:::assets/*"}]
+}`
+
+func TestBucket_Create(t *testing.T) {
+	f := newFakeS3(t)
+	m := NewBucket()
+	op := s3Operation(
+		f, "s3_bucket", map[string]any{
+			inputName:       "assets",
+			inputVersioning: true,
+			inputLifecycle:  testLifecycle,
+			inputPolicy:     testPolicy,
+		},
+	)
+
+	ok, outputs := runModule(t, m, op)
+	require.False(t, ok)
+	require.Equal(t, "assets", outputs[outputName])
+	b := f.buckets["assets"]
+	require.NotNil(t, b)
+	require.Equal(t, "Enabled", b.versioning)
+	require.Contains(t, string(b.lifecycle), "<ID>expire-tmp</ID>")
+	require.JSONEq(t, testPolicy, string(b.policy))
+
+	ok, outputs = runModule(t, m, op)
+	require.True(t, ok)
+	require.Equal(t, "assets", outputs[outputName])
+}
+
+func TestBucket_UpdatesDrift(t *testing.T) {
+	f := newFakeS3(t)
+	f.buckets["assets"] = &fakeBucket{
+		versioning: "Enabled",
+		policy:     []byte(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"s3:GetObject","Resource":"arn:aws:s3:::assets/*"}]}`),
+		objects:    map[string]fakeObject{},
+	}
+	m := NewBucket()
+
+	// The policy differs only in formatting, so only versioning is updated.
+	op := s3Operation(f, "s3_bucket", map[string]any{inputName: "assets", inputVersioning: false, inputPolicy: testPolicy})
+	ok, _ := runModule(t, m, op)
+	require.False(t, ok)
+	require.Equal(t, "Suspended", f.buckets["assets"].versioning)
+	require.NotContains(t, f.calls, "PUT assets?policy")
+	require.NotContains(t, f.calls, "PUT assets")
+
+	ok, _ = runModule(t, m, op)
+	require.True(t, ok)
+}
+
+func TestBucket_UnmanagedSettings(t *testing.T) {
+	f := newFakeS3(t)
+	f.buckets["assets"] = &fakeBucket{versioning: "Enabled", objects: map[string]fakeObject{}}
+	m := NewBucket()
+
+	ok, _ := runModule(t, m, s3Operation(f, "s3_bucket", map[string]any{inputName: "assets"}))
+	require.True(t, ok)
+	require.Equal(t, []string{"HEAD assets"}, f.calls)
+}
+
+func TestBucket_Delete(t *testing.T) {
+	f := newFakeS3(t)
+	f.buckets["assets"] = &fakeBucket{objects: map[string]fakeObject{}}
+	m := NewBucket()
+	op := s3Operation(f, "s3_bucket", map[string]any{inputName: "assets"})
+	op.DoesNotExist = true
+
+	ok, _ := runModule(t, m, op)
+	require.False(t, ok)
+	require.NotContains(t, f.buckets, "assets")
+
+	ok, _ = runModule(t, m, op)
+	require.True(t, ok)
+}
+
+func TestBucket_Validate(t *testing.T) {
+	f := newFakeS3(t)
+	m := NewBucket()
+
+	require.NoError(t, m.Validate(*s3Operation(f, "s3_bucket", map[string]any{inputName: "assets", inputLifecycle: testLifecycle})))
+	require.ErrorContains(
+		t,
+		m.Validate(*s3Operation(f, "s3_bucket", map[string]any{inputName: "assets", inputLifecycle: "Rulez: []"})),
+		"not a valid lifecycle configuration",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(*s3Operation(f, "s3_bucket", map[string]any{inputName: "assets", inputLifecycle: "Rules: [{ID: a}]"})),
+		"missing a status",
+	)
+	require.ErrorContains(
+		t,
+		m.Validate(*s3Operation(f, "s3_bucket", map[string]any{inputName: "assets", inputPolicy: "{"})),
+		"not a valid JSON document",
+	)
+	require.ErrorContains(t, m.Validate(*s3Operation(f, "s3_bucket", nil)), "missing required parameter: name")
+}
@@ -0,0 +1,245 @@
+package s3
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("s3_object", NewObject)
+}
+
+var _ blackstart.Module = &object{}
+
+// NewObject creates a module that manages an S3 object.
+func NewObject() blackstart.Module {
+	return &object{}
+}
+
+// object implements the s3_object module.
+type object struct{}
+
+func (m *object) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "s3_object",
+		Name: "S3 object",
+		Description: util.CleanString(
+			`
+Ensures an S3 object exists with the given content. The module works with Amazon S3 and
+S3-compatible storage, such as MinIO or Ceph, by setting the '''endpoint''' input. It is intended to
+seed small objects, such as configuration files, before applications start.
+
+The existing object is downloaded and compared with the desired content, and is only written when it
+differs. When '''content_type''' is set, the content type of the object is also compared.
+
+When '''doesNotExist''' is set, the object is deleted. In a versioned bucket, deleting the object
+adds a delete marker and keeps previous versions.
+`,
+		),
+		Requirements: []string{
+			"Credentials with permission to read and write the object. For Amazon S3, the `s3:GetObject` and `s3:PutObject` permissions are required, and `s3:DeleteObject` to delete the object.",
+			"If the `access_key_id` and `secret_access_key` inputs are not set, credentials are loaded from the default AWS sources, such as the `AWS_ACCESS_KEY_ID` and `AWS_SECRET_ACCESS_KEY` environment variables.",
+		},
+		Inputs: clientInputs(
+			map[string]blackstart.InputValue{
+				inputBucket: {
+					Description: "Name of the bucket.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputKey: {
+					Description: "Key of the object.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputContent: {
+					Description: "Content of the object. Required unless `doesNotExist` is set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputContentType: {
+					Description: "Content type of the object, for example `application/json`. If not set, the content type is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputURI: {
+				Description: "URI of the object, in the form `s3://bucket/key`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputETag: {
+				Description: "Entity tag of the object.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputVersionID: {
+				Description: "Version ID of the object. Empty when versioning is not enabled for the bucket.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Seed a configuration file": `id: app-config-object
+module: s3_object
+inputs:
+  endpoint: https://minio.example.internal:9000
+  path_style: true
+  bucket:
+    fromDependency:
+      id: assets-bucket
+      output: name
+  key: config/app.json
+  content_type: application/json
+  content: |
+    {"feature_flags": {"new_ui": true}}`,
+		},
+	}
+}
+
+func (m *object) Validate(op blackstart.Operation) error {
+	if err := validateClientInputs(op); err != nil {
+		return err
+	}
+	for _, key := range []string{inputBucket, inputKey} {
+		input, ok := op.Inputs[key]
+		if !ok {
+			return fmt.Errorf("missing required parameter: %s", key)
+		}
+		if input.IsStatic() {
+			if _, err := blackstart.InputAs[string](input, true); err != nil {
+				return fmt.Errorf("parameter %s is invalid: %w", key, err)
+			}
+		}
+	}
+	if _, ok := op.Inputs[inputContent]; !ok && !op.DoesNotExist {
+		return fmt.Errorf("missing required parameter: %s", inputContent)
+	}
+	return nil
+}
+
+func (m *object) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	bucketName, key, err := contextObjectLocation(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	out, err := c.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+	if err != nil && !isNotFound(err) {
+		return false, fmt.Errorf("failed to get object s3://%s/%s: %w", bucketName, key, err)
+	}
+	exists := err == nil
+	if ctx.DoesNotExist() {
+		if exists {
+			_ = out.Body.Close()
+		}
+		return !exists, nil
+	}
+	if !exists {
+		return false, nil
+	}
+	defer func() {
+		_ = out.Body.Close()
+	}()
+	if ctx.Tainted() {
+		return false, nil
+	}
+
+	content, err := blackstart.ContextInputAs[string](ctx, inputContent, true)
+	if err != nil {
+		return false, err
+	}
+	contentType, err := blackstart.ContextInputAs[string](ctx, inputContentType, false)
+	if err != nil {
+		return false, err
+	}
+	if contentType != "" && contentType != aws.ToString(out.ContentType) {
+		return false, nil
+	}
+	current, err := io.ReadAll(out.Body)
+	if err != nil {
+		return false, fmt.Errorf("failed to read object s3://%s/%s: %w", bucketName, key, err)
+	}
+	if !bytes.Equal(current, []byte(content)) {
+		return false, nil
+	}
+	return true, outputObject(ctx, bucketName, key, aws.ToString(out.ETag), aws.ToString(out.VersionId))
+}
+
+func (m *object) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	bucketName, key, err := contextObjectLocation(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		_, err = c.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucketName), Key: aws.String(key)})
+		if err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to delete object s3://%s/%s: %w", bucketName, key, err)
+		}
+		return nil
+	}
+
+	content, err := blackstart.ContextInputAs[string](ctx, inputContent, true)
+	if err != nil {
+		return err
+	}
+	contentType, err := blackstart.ContextInputAs[string](ctx, inputContentType, false)
+	if err != nil {
+		return err
+	}
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+		Body:   strings.NewReader(content),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	out, err := c.PutObject(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to write object s3://%s/%s: %w", bucketName, key, err)
+	}
+	return outputObject(ctx, bucketName, key, aws.ToString(out.ETag), aws.ToString(out.VersionId))
+}
+
+// contextObjectLocation reads the bucket and key of the object from module inputs.
+func contextObjectLocation(ctx blackstart.ModuleContext) (string, string, error) {
+	bucketName, err := blackstart.ContextInputAs[string](ctx, inputBucket, true)
+	if err != nil {
+		return "", "", err
+	}
+	key, err := blackstart.ContextInputAs[string](ctx, inputKey, true)
+	if err != nil {
+		return "", "", err
+	}
+	return bucketName, key, nil
+}
+
+// outputObject sets the outputs of the module for the object. The quotes around the entity tag
+// returned by S3 are removed.
+func outputObject(ctx blackstart.ModuleContext, bucketName, key, etag, versionID string) error {
+	if err := ctx.Output(outputURI, fmt.Sprintf("s3://%s/%s", bucketName, key)); err != nil {
+		return err
+	}
+	if err := ctx.Output(outputETag, strings.Trim(etag, `"`)); err != nil {
+		return err
+	}
+	return ctx.Output(outputVersionID, versionID)
+}
@@ -0,0 +1,87 @@
+package s3
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObject_CreateAndUpdate(t *testing.T) {
+	f := newFakeS3(t)
+	f.buckets["assets"] = &fakeBucket{versioning: "Enabled", objects: map[string]fakeObject{}}
+	m := NewObject()
+	op := s3Operation(
+		f, "s3_object", map[string]any{
+			inputBucket:      "assets",
+			inputKey:         "config/app.json",
+			inputContent:     `{"debug": false}`,
+			inputContentType: "application/json",
+		},
+	)
+
+	ok, outputs := runModule(t, m, op)
+	require.False(t, ok)
+	require.Equal(t, "s3://assets/config/app.json", outputs[outputURI])
+	require.Equal(t, "v1", outputs[outputVersionID])
+	require.Equal(t, etag([]byte(`{"debug": false}`)), `"`+outputs[outputETag].(string)+`"`)
+	require.Equal(t, "application/json", f.buckets["assets"].objects["config/app.json"].contentType)
+
+	ok, outputs = runModule(t, m, op)
+	require.True(t, ok)
+	require.Equal(t, "s3://assets/config/app.json", outputs[outputURI])
+
+	f.buckets["assets"].objects["config/app.json"] = fakeObject{body: []byte(`{"debug": true}`), contentType: "application/json"}
+	ok, _ = runModule(t, m, op)
+	require.False(t, ok)
+	require.Equal(t, `{"debug": false}`, string(f.buckets["assets"].objects["config/app.json"].body))
+}
+
+func TestObject_ContentTypeMismatch(t *testing.T) {
+	f := newFakeS3(t)
+	f.buckets["assets"] = &fakeBucket{
+		objects: map[string]fakeObject{"app.json": {body: []byte("{}"), contentType: "binary/octet-stream"}},
+	}
+	m := NewObject()
+
+	op := s3Operation(f, "s3_object", map[string]any{inputBucket: "assets", inputKey: "app.json", inputContent: "{}"})
+	ok, _ := runModule(t, m, op)
+	require.True(t, ok)
+
+	op = s3Operation(
+		f, "s3_object", map[string]any{
+			inputBucket: "assets", inputKey: "app.json", inputContent: "{}", inputContentType: "application/json",
+		},
+	)
+	ok, _ = runModule(t, m, op)
+	require.False(t, ok)
+	require.Equal(t, "application/json", f.buckets["assets"].objects["app.json"].contentType)
+}
+
+func TestObject_Delete(t *testing.T) {
+	f := newFakeS3(t)
+	f.buckets["assets"] = &fakeBucket{objects: map[string]fakeObject{"app.json": {body: []byte("{}")}}}
+	m := NewObject()
+	op := s3Operation(f, "s3_object", map[string]any{inputBucket: "assets", inputKey: "app.json"})
+	op.DoesNotExist = true
+
+	ok, _ := runModule(t, m, op)
+	require.False(t, ok)
+	require.Empty(t, f.buckets["assets"].objects)
+
+	ok, _ = runModule(t, m, op)
+	require.True(t, ok)
+}
+
+func TestObject_Validate(t *testing.T) {
+	f := newFakeS3(t)
+	m := NewObject()
+
+	require.ErrorContains(
+		t, m.Validate(*s3Operation(f, "s3_object", map[string]any{inputBucket: "assets", inputKey: "app.json"})),
+		"missing required parameter: content",
+	)
+	require.ErrorContains(
+		t, m.Validate(*s3Operation(f, "s3_object", map[string]any{inputKey: "app.json", inputContent: "{}"})),
+		"missing required parameter: bucket",
+	)
+}
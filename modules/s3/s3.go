@@ -0,0 +1,162 @@
+package s3
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/pezops/blackstart"
+)
+
+const (
+	inputEndpoint        = "endpoint"
+	inputRegion          = "region"
+	inputPathStyle       = "path_style"
+	inputAccessKeyID     = "access_key_id"
+	inputSecretAccessKey = "secret_access_key"
+	inputName            = "name"
+	inputVersioning      = "versioning"
+	inputLifecycle       = "lifecycle"
+	inputPolicy          = "policy"
+	inputBucket          = "bucket"
+	inputKey             = "key"
+	inputContent         = "content"
+	inputContentType     = "content_type"
+
+	outputName      = "name"
+	outputURI       = "uri"
+	outputETag      = "etag"
+	outputVersionID = "version_id"
+)
+
+func init() {
+	blackstart.RegisterPathName("s3", "S3")
+}
+
+// clientInputs returns the inputs of a module merged with the inputs used to configure the S3
+// client.
+func clientInputs(inputs map[string]blackstart.InputValue) map[string]blackstart.InputValue {
+	merged := map[string]blackstart.InputValue{
+		inputEndpoint: {
+			Description: "S3 API endpoint URL, for S3-compatible storage such as MinIO or Ceph. Defaults to the AWS endpoint of the region.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+		},
+		inputRegion: {
+			Description: "Region of the bucket. Defaults to the region of the AWS configuration, such as the `AWS_REGION` environment variable.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+		},
+		inputPathStyle: {
+			Description: "Use path-style addressing (`https://endpoint/bucket/key`) instead of virtual-hosted-style addressing. Most S3-compatible storage requires path-style addressing.",
+			Type:        reflect.TypeFor[bool](),
+			Required:    false,
+			Default:     false,
+		},
+		inputAccessKeyID: {
+			Description: "Access key ID used to authenticate requests. Defaults to the credentials of the AWS configuration.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+		},
+		inputSecretAccessKey: {
+			Description: "Secret access key used to authenticate requests. Required when `access_key_id` is set.",
+			Type:        reflect.TypeFor[string](),
+			Required:    false,
+			Sensitive:   true,
+		},
+	}
+	maps.Copy(merged, inputs)
+	return merged
+}
+
+// validateClientInputs validates the static credential inputs of a module. The access key ID and
+// secret access key must be set together.
+func validateClientInputs(op blackstart.Operation) error {
+	_, hasKeyID := op.Inputs[inputAccessKeyID]
+	_, hasSecret := op.Inputs[inputSecretAccessKey]
+	if hasKeyID != hasSecret {
+		return fmt.Errorf("inputs '%s' and '%s' must be set together", inputAccessKeyID, inputSecretAccessKey)
+	}
+	return nil
+}
+
+// contextClient builds an S3 client from the client inputs of a module. The AWS configuration and
+// credentials are loaded from the default sources, such as the environment and shared configuration
+// files, unless the inputs override them. Requests use the outbound HTTP configuration of
+// blackstart.GetHTTPConfig.
+func contextClient(ctx blackstart.ModuleContext) (*s3.Client, error) {
+	endpoint, err := blackstart.ContextInputAs[string](ctx, inputEndpoint, false)
+	if err != nil {
+		return nil, err
+	}
+	region, err := blackstart.ContextInputAs[string](ctx, inputRegion, false)
+	if err != nil {
+		return nil, err
+	}
+	pathStyle, err := blackstart.ContextInputAs[bool](ctx, inputPathStyle, false)
+	if err != nil {
+		return nil, err
+	}
+	accessKeyID, err := blackstart.ContextInputAs[string](ctx, inputAccessKeyID, false)
+	if err != nil {
+		return nil, err
+	}
+	secretAccessKey, err := blackstart.ContextInputAs[string](ctx, inputSecretAccessKey, false)
+	if err != nil {
+		return nil, err
+	}
+
+	httpConfig := blackstart.GetHTTPConfig()
+	if _, err = httpConfig.RootCAs(); err != nil {
+		return nil, err
+	}
+	httpClient := awshttp.NewBuildableClient().WithTransportOptions(
+		func(t *http.Transport) {
+			// The CAs were checked above, so applying the configuration does not fail.
+			_ = httpConfig.Apply(t)
+		},
+	)
+	loadOpts := []func(*awsconfig.LoadOptions) error{awsconfig.WithHTTPClient(httpClient)}
+	if region != "" {
+		loadOpts = append(loadOpts, awsconfig.WithRegion(region))
+	}
+	if accessKeyID != "" {
+		loadOpts = append(
+			loadOpts,
+			awsconfig.WithCredentialsProvider(
+				credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, ""),
+			),
+		)
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return s3.NewFromConfig(
+		cfg, func(o *s3.Options) {
+			o.UsePathStyle = pathStyle
+			if endpoint != "" {
+				o.BaseEndpoint = aws.String(endpoint)
+				// S3-compatible storage does not always support the default checksums of the SDK.
+				o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+				o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+			}
+		},
+	), nil
+}
+
+// isNotFound reports whether err is an S3 API 404 response, such as a missing bucket, object,
+// lifecycle configuration, or bucket policy.
+func isNotFound(err error) bool {
+	var respErr *awshttp.ResponseError
+	return errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound
+}
@@ -0,0 +1,220 @@
+package s3
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeBucket is a bucket stored by fakeS3.
+type fakeBucket struct {
+	versioning string
+	lifecycle  []byte
+	policy     []byte
+	objects    map[string]fakeObject
+}
+
+// fakeObject is an object stored by fakeS3.
+type fakeObject struct {
+	body        []byte
+	contentType string
+}
+
+// fakeS3 serves the path-style bucket and object requests of the S3 API used by the modules.
+type fakeS3 struct {
+	server  *httptest.Server
+	buckets map[string]*fakeBucket
+	calls   []string
+	mu      sync.Mutex
+}
+
+// newFakeS3 starts a fake S3 API server.
+func newFakeS3(t *testing.T) *fakeS3 {
+	t.Helper()
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	f := &fakeS3{buckets: map[string]*fakeBucket{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeS3) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+	body, _ := io.ReadAll(r.Body)
+	b := f.buckets[name]
+
+	call := r.Method + " " + name
+	for _, sub := range []string{"versioning", "lifecycle", "policy"} {
+		if query.Has(sub) {
+			call += "?" + sub
+		}
+	}
+	if key != "" {
+		call += "/" + key
+	}
+	f.calls = append(f.calls, call)
+
+	if b == nil && !(r.Method == http.MethodPut && key == "" && len(query) == 0) {
+		writeError(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch {
+	case key != "":
+		f.handleObject(w, r, b, key, body)
+	case query.Has("versioning"):
+		if r.Method == http.MethodPut {
+			var config struct {
+				Status string
+			}
+			_ = xml.Unmarshal(body, &config)
+			b.versioning = config.Status
+			return
+		}
+		_, _ = fmt.Fprintf(w, "<VersioningConfiguration><Status>%s</Status></VersioningConfiguration>", b.versioning)
+	case query.Has("lifecycle"):
+		f.handleDocument(w, r, &b.lifecycle, body, "NoSuchLifecycleConfiguration")
+	case query.Has("policy"):
+		f.handleDocument(w, r, &b.policy, body, "NoSuchBucketPolicy")
+	case r.Method == http.MethodPut:
+		f.buckets[name] = &fakeBucket{objects: map[string]fakeObject{}}
+	case r.Method == http.MethodDelete:
+		if len(b.objects) > 0 {
+			writeError(w, r, http.StatusConflict, "BucketNotEmpty")
+			return
+		}
+		delete(f.buckets, name)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodHead:
+	default:
+		writeError(w, r, http.StatusBadRequest, "InvalidRequest")
+	}
+}
+
+// handleDocument serves a bucket subresource that is stored as the raw request body.
+func (f *fakeS3) handleDocument(w http.ResponseWriter, r *http.Request, doc *[]byte, body []byte, notFound string) {
+	switch r.Method {
+	case http.MethodPut:
+		*doc = body
+	case http.MethodDelete:
+		*doc = nil
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		if *doc == nil {
+			writeError(w, r, http.StatusNotFound, notFound)
+			return
+		}
+		_, _ = w.Write(*doc)
+	}
+}
+
+func (f *fakeS3) handleObject(w http.ResponseWriter, r *http.Request, b *fakeBucket, key string, body []byte) {
+	switch r.Method {
+	case http.MethodPut:
+		b.objects[key] = fakeObject{body: body, contentType: r.Header.Get("Content-Type")}
+		w.Header().Set("ETag", etag(body))
+		if b.versioning == "Enabled" {
+			w.Header().Set("x-amz-version-id", "v1")
+		}
+	case http.MethodDelete:
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		obj, ok := b.objects[key]
+		if !ok {
+			writeError(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("ETag", etag(obj.body))
+		w.Header().Set("Content-Type", obj.contentType)
+		_, _ = w.Write(obj.body)
+	}
+}
+
+// writeError writes an S3 API error response.
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+	}
+}
+
+// etag returns the quoted MD5 entity tag of an object body.
+func etag(body []byte) string {
+	sum := md5.Sum(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// s3Operation returns an operation of the module targeting the fake server.
+func s3Operation(f *fakeS3, module string, inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{
+		Id:     "s3",
+		Module: module,
+		Inputs: map[string]blackstart.Input{
+			inputEndpoint:        blackstart.NewInputFromValue(f.server.URL),
+			inputRegion:          blackstart.NewInputFromValue("us-east-1"),
+			inputPathStyle:       blackstart.NewInputFromValue(true),
+			inputAccessKeyID:     blackstart.NewInputFromValue("test"),
+			inputSecretAccessKey: blackstart.NewInputFromValue("test"),
+		},
+	}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// runModule runs Check and, if needed, Set for the operation and returns whether Check passed and
+// the outputs of the run.
+func runModule(t *testing.T, m blackstart.Module, op *blackstart.Operation) (bool, map[string]any) {
+	t.Helper()
+	require.NoError(t, m.Validate(*op))
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	if !ok {
+		ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+		require.NoError(t, m.Set(ctx))
+	}
+	return ok, ctx.outputs
+}
+
+func TestValidateClientInputs(t *testing.T) {
+	f := newFakeS3(t)
+	op := s3Operation(f, "s3_bucket", map[string]any{inputName: "assets"})
+	require.NoError(t, validateClientInputs(*op))
+
+	delete(op.Inputs, inputSecretAccessKey)
+	require.ErrorContains(t, validateClientInputs(*op), "must be set together")
+}
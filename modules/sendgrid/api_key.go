@@ -0,0 +1,253 @@
+package sendgrid
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+// scopeMailSend is the scope of API keys that only send email.
+const scopeMailSend = "mail.send"
+
+func init() {
+	blackstart.RegisterModule("sendgrid_api_key", NewAPIKey)
+}
+
+var _ blackstart.Module = &apiKey{}
+
+// NewAPIKey creates a module that manages a SendGrid API key.
+func NewAPIKey() blackstart.Module {
+	return &apiKey{}
+}
+
+// apiKey implements the sendgrid_api_key module.
+type apiKey struct{}
+
+// apiKeyMetadata is the API key information returned by the SendGrid API. The key itself is only
+// returned when the API key is created.
+type apiKeyMetadata struct {
+	APIKeyID string   `json:"api_key_id"`
+	Name     string   `json:"name"`
+	Scopes   []string `json:"scopes"`
+	APIKey   string   `json:"api_key"`
+}
+
+// implicitScopes are scopes that SendGrid adds to API keys, which are ignored when scopes are
+// compared.
+var implicitScopes = map[string]struct{}{
+	"2fa_exempt":                   {},
+	"2fa_required":                 {},
+	"sender_verification_eligible": {},
+	"sender_verification_exempt":   {},
+}
+
+// sameScopes reports whether an API key has the desired scopes, ignoring their order and the
+// scopes that SendGrid adds.
+func sameScopes(current, desired []string) bool {
+	for _, scope := range desired {
+		if !slices.Contains(current, scope) {
+			return false
+		}
+	}
+	for _, scope := range current {
+		if _, ok := implicitScopes[scope]; !ok && !slices.Contains(desired, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *apiKey) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "sendgrid_api_key",
+		Name: "SendGrid API key",
+		Description: util.CleanString(
+			`
+Ensures a SendGrid API key exists with the scopes, such as a key that an application uses to send
+email. API keys are matched by name. The scopes of an existing API key are updated when they differ.
+
+**Notes**
+
+- SendGrid only returns the key when it is created, so '''api_key''' is empty when the API key
+  already exists. Store the key with an update policy that preserves existing values, such as the
+  default '''preserve_any''' policy of the '''kubernetes_secret_value''' module. Taint the operation
+  to create a new key.
+- When '''doesNotExist''' is set, the API key is deleted.
+`,
+		),
+		Requirements: []string{
+			"A SendGrid API key with the `api_keys.create`, `api_keys.read`, `api_keys.update`, and `api_keys.delete` scopes. The scopes of a new API key must be scopes of this key.",
+			"If the `api_key` input is not set, the `SENDGRID_API_KEY` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the API key.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputScopes: {
+					Description: "Scopes of the API key, such as `mail.send`.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+					Default:     []string{scopeMailSend},
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the API key.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputAPIKey: {
+				Description: "The API key. Only set when the API key is created.",
+				Type:        reflect.TypeFor[string](),
+				Sensitive:   true,
+			},
+		},
+		Examples: map[string]string{
+			"Application mail key": `id: app-sendgrid-key
+module: sendgrid_api_key
+inputs:
+  name: app
+  scopes:
+    - mail.send`,
+		},
+	}
+}
+
+func (m *apiKey) Validate(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputName); err != nil {
+		return err
+	}
+	if input, ok := op.Inputs[inputScopes]; ok && input.IsStatic() {
+		scopes, err := blackstart.InputAs[[]string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputScopes, err)
+		}
+		if len(scopes) == 0 {
+			return fmt.Errorf("parameter %s must not be empty", inputScopes)
+		}
+	}
+	return nil
+}
+
+func (m *apiKey) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	name, scopes, err := contextAPIKey(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := findAPIKey(ctx, c, name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !sameScopes(current.Scopes, scopes) {
+		return false, nil
+	}
+	return true, outputAPIKeyMetadata(ctx, *current)
+}
+
+func (m *apiKey) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	name, scopes, err := contextAPIKey(ctx)
+	if err != nil {
+		return err
+	}
+
+	current, err := findAPIKey(ctx, c, name)
+	if err != nil {
+		return err
+	}
+	// A tainted API key is replaced, so a new key is returned.
+	if current != nil && (ctx.DoesNotExist() || ctx.Tainted()) {
+		err = c.Do(ctx, http.MethodDelete, "/api_keys/"+url.PathEscape(current.APIKeyID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete API key %s: %w", name, err)
+		}
+		current = nil
+	}
+	if ctx.DoesNotExist() {
+		return nil
+	}
+
+	body := map[string]any{"name": name, "scopes": scopes}
+	var result apiKeyMetadata
+	if current == nil {
+		if err = c.Do(ctx, http.MethodPost, "/api_keys", body, &result); err != nil {
+			return fmt.Errorf("failed to create API key %s: %w", name, err)
+		}
+		return outputAPIKeyMetadata(ctx, result)
+	}
+	if err = c.Do(ctx, http.MethodPut, "/api_keys/"+url.PathEscape(current.APIKeyID), body, &result); err != nil {
+		return fmt.Errorf("failed to update API key %s: %w", name, err)
+	}
+	result.APIKey = ""
+	return outputAPIKeyMetadata(ctx, result)
+}
+
+// findAPIKey returns the API key with the name and its scopes, if any.
+func findAPIKey(ctx blackstart.ModuleContext, c *restapi.Client, name string) (*apiKeyMetadata, error) {
+	var list struct {
+		Result []apiKeyMetadata `json:"result"`
+	}
+	if err := c.Do(ctx, http.MethodGet, fmt.Sprintf("/api_keys?limit=%d", restapi.PageSize), nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	i := slices.IndexFunc(
+		list.Result, func(k apiKeyMetadata) bool {
+			return k.Name == name
+		},
+	)
+	if i < 0 {
+		return nil, nil
+	}
+
+	// The list of API keys does not include their scopes.
+	var current apiKeyMetadata
+	err := c.Do(ctx, http.MethodGet, "/api_keys/"+url.PathEscape(list.Result[i].APIKeyID), nil, &current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key %s: %w", name, err)
+	}
+	return &current, nil
+}
+
+// contextAPIKey reads the name and scopes of the API key from module inputs.
+func contextAPIKey(ctx blackstart.ModuleContext) (string, []string, error) {
+	name, err := blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return "", nil, err
+	}
+	scopes, err := blackstart.ContextInputAs[[]string](ctx, inputScopes, false)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(scopes) == 0 {
+		scopes = []string{scopeMailSend}
+	}
+	return name, scopes, nil
+}
+
+// outputAPIKeyMetadata emits the outputs of an API key.
+func outputAPIKeyMetadata(ctx blackstart.ModuleContext, k apiKeyMetadata) error {
+	if err := ctx.Output(outputID, k.APIKeyID); err != nil {
+		return err
+	}
+	return ctx.Output(outputAPIKey, k.APIKey)
+}
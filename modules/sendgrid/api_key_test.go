@@ -0,0 +1,70 @@
+package sendgrid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestSameScopes(t *testing.T) {
+	require.True(t, sameScopes([]string{"mail.send", "2fa_required"}, []string{"mail.send"}))
+	require.True(t, sameScopes([]string{"stats.read", "mail.send"}, []string{"mail.send", "stats.read"}))
+	require.False(t, sameScopes([]string{"mail.send"}, []string{"mail.send", "stats.read"}))
+	require.False(t, sameScopes([]string{"mail.send", "stats.read"}, []string{"mail.send"}))
+}
+
+func TestAPIKey_CreateUpdateDelete(t *testing.T) {
+	f := newFakeSendGrid(t)
+	m := NewAPIKey()
+	op := fakeOperation(f, "sendgrid_api_key", map[string]any{inputName: "app"})
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Len(t, f.apiKeys, 1)
+	require.Equal(t, f.apiKeys[0]["api_key_id"], ctx.outputs[outputID])
+	require.Equal(t, "SG.secret-1", ctx.outputs[outputAPIKey])
+
+	// The key is not returned for an existing API key.
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "", ctx.outputs[outputAPIKey])
+
+	op = fakeOperation(
+		f, "sendgrid_api_key", map[string]any{inputName: "app", inputScopes: []string{"mail.send", "stats.read"}},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "PUT /v3/api_keys/key-1")
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.apiKeys)
+}
+
+func TestAPIKey_TaintedReplacesKey(t *testing.T) {
+	f := newFakeSendGrid(t)
+	m := NewAPIKey()
+	op := fakeOperation(f, "sendgrid_api_key", map[string]any{inputName: "app"})
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+
+	op.Tainted = true
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Contains(t, f.requests, "DELETE /v3/api_keys/key-1")
+	require.Len(t, f.apiKeys, 1)
+	require.Equal(t, "SG.secret-2", ctx.outputs[outputAPIKey])
+}
@@ -0,0 +1,289 @@
+package sendgrid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("sendgrid_domain_authentication", NewDomainAuthentication)
+}
+
+var _ blackstart.Module = &domainAuthentication{}
+
+// NewDomainAuthentication creates a module that manages an authenticated sending domain of
+// SendGrid.
+func NewDomainAuthentication() blackstart.Module {
+	return &domainAuthentication{}
+}
+
+// domainAuthentication implements the sendgrid_domain_authentication module.
+type domainAuthentication struct{}
+
+// domainMetadata is the authenticated domain information returned by the SendGrid API.
+type domainMetadata struct {
+	ID                int64                `json:"id"`
+	Domain            string               `json:"domain"`
+	Subdomain         string               `json:"subdomain"`
+	Valid             bool                 `json:"valid"`
+	AutomaticSecurity bool                 `json:"automatic_security"`
+	DNS               map[string]dnsRecord `json:"dns"`
+}
+
+// dnsRecord is a DNS record that SendGrid requires for an authenticated domain.
+type dnsRecord struct {
+	Valid bool   `json:"valid"`
+	Type  string `json:"type"`
+	Host  string `json:"host"`
+	Data  string `json:"data"`
+}
+
+// records returns the DNS records of the domain, ordered by their name in the SendGrid API, such as
+// `dkim1` and `mail_cname`.
+func (d domainMetadata) records() []map[string]string {
+	names := make([]string, 0, len(d.DNS))
+	for name := range d.DNS {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	records := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		r := d.DNS[name]
+		records = append(
+			records, map[string]string{
+				"name": name,
+				"type": strings.ToUpper(r.Type),
+				"host": r.Host,
+				"data": r.Data,
+			},
+		)
+	}
+	return records
+}
+
+// desiredDomain is the desired state of an authenticated domain read from module inputs.
+type desiredDomain struct {
+	domain            string
+	subdomain         string
+	automaticSecurity bool
+}
+
+// find returns the authenticated domain with the domain and, when it is set, the subdomain.
+func (d desiredDomain) find(domains []domainMetadata) *domainMetadata {
+	for i := range domains {
+		if !strings.EqualFold(domains[i].Domain, d.domain) {
+			continue
+		}
+		if d.subdomain == "" || strings.EqualFold(domains[i].Subdomain, d.subdomain) {
+			return &domains[i]
+		}
+	}
+	return nil
+}
+
+func (m *domainAuthentication) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "sendgrid_domain_authentication",
+		Name: "SendGrid domain authentication",
+		Description: util.CleanString(
+			`
+Ensures a sending domain is authenticated in SendGrid and outputs the DNS records that must be
+published for it, such as the DKIM records. The records can be created with a DNS module, and the
+domain is then validated with the '''sendgrid_domain_validation''' module.
+
+Domains are matched by the domain and, when it is set, the subdomain. SendGrid generates a
+subdomain when '''subdomain''' is not set.
+
+**Notes**
+
+- With '''automatic_security''', SendGrid manages the DKIM keys and SPF record, and the DNS records
+  are CNAME records. Otherwise, the records are MX and TXT records that contain the DKIM public key.
+- '''automatic_security''' cannot be changed, so a domain with a different setting is deleted and
+  authenticated again. The new DNS records must be published before the domain can be validated.
+- Each DNS record output has the '''name''' of the record in SendGrid, and the '''type''',
+  '''host''', and '''data''' of the record.
+- When '''doesNotExist''' is set, the authenticated domain is deleted.
+`,
+		),
+		Requirements: []string{
+			"A SendGrid API key with the `whitelabel.create`, `whitelabel.read`, `whitelabel.update`, and `whitelabel.delete` scopes.",
+			"If the `api_key` input is not set, the `SENDGRID_API_KEY` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputDomain: {
+					Description: "Domain that email is sent from, such as `example.com`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputSubdomain: {
+					Description: "Subdomain of the domain used for the return path of email, such as `em`. Generated by SendGrid if not set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputAutomaticSecurity: {
+					Description: "If true, SendGrid manages the DKIM keys and SPF record with CNAME records.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     true,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputID: {
+				Description: "ID of the authenticated domain.",
+				Type:        reflect.TypeFor[int64](),
+			},
+			outputDNSRecords: {
+				Description: "DNS records of the domain, each with a `name`, `type`, `host`, and `data`.",
+				Type:        reflect.TypeFor[[]map[string]string](),
+			},
+			outputValid: {
+				Description: "Whether SendGrid validated the DNS records of the domain.",
+				Type:        reflect.TypeFor[bool](),
+			},
+		},
+		Examples: map[string]string{
+			"Sending domain": `id: sendgrid-domain
+module: sendgrid_domain_authentication
+inputs:
+  domain: example.com
+  subdomain: em`,
+		},
+	}
+}
+
+func (m *domainAuthentication) Validate(op blackstart.Operation) error {
+	return restapi.ValidateRequiredStrings(op, inputDomain)
+}
+
+func (m *domainAuthentication) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextDomain(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	domains, err := listDomains(ctx, c, desired.domain)
+	if err != nil {
+		return false, err
+	}
+	current := desired.find(domains)
+
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || current.AutomaticSecurity != desired.automaticSecurity {
+		return false, nil
+	}
+	return true, outputDomain(ctx, *current)
+}
+
+func (m *domainAuthentication) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextDomain(ctx)
+	if err != nil {
+		return err
+	}
+
+	domains, err := listDomains(ctx, c, desired.domain)
+	if err != nil {
+		return err
+	}
+	current := desired.find(domains)
+
+	if current != nil && (ctx.DoesNotExist() || current.AutomaticSecurity != desired.automaticSecurity) {
+		err = c.Do(ctx, http.MethodDelete, fmt.Sprintf("/whitelabel/domains/%d", current.ID), nil, nil)
+		if err != nil && !restapi.IsNotFound(err) {
+			return fmt.Errorf("failed to delete authenticated domain %s: %w", desired.domain, err)
+		}
+		current = nil
+	}
+	if ctx.DoesNotExist() {
+		return nil
+	}
+	if current != nil {
+		return outputDomain(ctx, *current)
+	}
+
+	body := map[string]any{"domain": desired.domain, "automatic_security": desired.automaticSecurity}
+	if desired.subdomain != "" {
+		body["subdomain"] = desired.subdomain
+	}
+	var created domainMetadata
+	if err = c.Do(ctx, http.MethodPost, "/whitelabel/domains", body, &created); err != nil {
+		return fmt.Errorf("failed to authenticate domain %s: %w", desired.domain, err)
+	}
+	return outputDomain(ctx, created)
+}
+
+// listDomains returns the authenticated domains of a domain.
+func listDomains(ctx context.Context, c *restapi.Client, domain string) ([]domainMetadata, error) {
+	var all []domainMetadata
+	for offset := 0; ; offset += restapi.PageSize {
+		query := url.Values{
+			"domain": {domain},
+			"limit":  {fmt.Sprint(restapi.PageSize)},
+			"offset": {fmt.Sprint(offset)},
+		}
+		var domains []domainMetadata
+		if err := c.Do(ctx, http.MethodGet, "/whitelabel/domains?"+query.Encode(), nil, &domains); err != nil {
+			return nil, fmt.Errorf("failed to list authenticated domains of %s: %w", domain, err)
+		}
+		all = append(all, domains...)
+		if len(domains) < restapi.PageSize {
+			return all, nil
+		}
+	}
+}
+
+// contextDomain reads the desired authenticated domain from module inputs.
+func contextDomain(ctx blackstart.ModuleContext) (desiredDomain, error) {
+	domain, err := blackstart.ContextInputAs[string](ctx, inputDomain, true)
+	if err != nil {
+		return desiredDomain{}, err
+	}
+	subdomain, err := blackstart.ContextInputAs[string](ctx, inputSubdomain, false)
+	if err != nil {
+		return desiredDomain{}, err
+	}
+	automaticSecurity, err := blackstart.ContextInputAs[*bool](ctx, inputAutomaticSecurity, false)
+	if err != nil {
+		return desiredDomain{}, err
+	}
+	return desiredDomain{
+		domain:            strings.TrimSpace(domain),
+		subdomain:         strings.TrimSpace(subdomain),
+		automaticSecurity: automaticSecurity == nil || *automaticSecurity,
+	}, nil
+}
+
+// outputDomain emits the outputs of an authenticated domain.
+func outputDomain(ctx blackstart.ModuleContext, d domainMetadata) error {
+	outputs := map[string]any{
+		outputID:         d.ID,
+		outputDNSRecords: d.records(),
+		outputValid:      d.Valid,
+	}
+	for key, value := range outputs {
+		if err := ctx.Output(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
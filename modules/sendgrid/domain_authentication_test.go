@@ -0,0 +1,93 @@
+package sendgrid
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestDomainAuthentication_Create(t *testing.T) {
+	f := newFakeSendGrid(t)
+	m := NewDomainAuthentication()
+	op := fakeOperation(
+		f, "sendgrid_domain_authentication", map[string]any{inputDomain: "example.com", inputSubdomain: "em"},
+	)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+
+	require.Len(t, f.domains, 1)
+	require.Equal(t, true, f.domains[0]["automatic_security"])
+	require.Equal(t, int64(1), ctx.outputs[outputID])
+	require.Equal(t, false, ctx.outputs[outputValid])
+	require.Equal(
+		t, []map[string]string{
+			{
+				"name": "dkim1", "type": "CNAME", "host": "s1._domainkey.example.com",
+				"data": "s1.domainkey.u1.wl.sendgrid.net",
+			},
+			{"name": "mail_cname", "type": "CNAME", "host": "em.example.com", "data": "u1.wl.sendgrid.net"},
+		}, ctx.outputs[outputDNSRecords],
+	)
+
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, int64(1), ctx.outputs[outputID])
+}
+
+func TestDomainAuthentication_MatchesSubdomain(t *testing.T) {
+	f := newFakeSendGrid(t)
+	f.addDomain("example.com", "mail", true)
+	m := NewDomainAuthentication()
+
+	// Without a subdomain, any authenticated domain of the domain matches.
+	op := fakeOperation(f, "sendgrid_domain_authentication", map[string]any{inputDomain: "example.com"})
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op = fakeOperation(
+		f, "sendgrid_domain_authentication", map[string]any{inputDomain: "example.com", inputSubdomain: "em"},
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Len(t, f.domains, 2)
+}
+
+func TestDomainAuthentication_ReplaceAndDelete(t *testing.T) {
+	f := newFakeSendGrid(t)
+	f.addDomain("example.com", "em", true)
+	m := NewDomainAuthentication()
+
+	// Automatic security cannot be changed, so the domain is authenticated again.
+	op := fakeOperation(
+		f, "sendgrid_domain_authentication", map[string]any{
+			inputDomain: "example.com", inputSubdomain: "em", inputAutomaticSecurity: false,
+		},
+	)
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.requests, "DELETE /v3/whitelabel/domains/1")
+	require.Len(t, f.domains, 1)
+	require.Equal(t, false, f.domains[0]["automatic_security"])
+
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.domains)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,145 @@
+package sendgrid
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("sendgrid_domain_validation", NewDomainValidation)
+}
+
+var _ blackstart.Module = &domainValidation{}
+
+// NewDomainValidation creates a module that validates an authenticated sending domain of SendGrid.
+func NewDomainValidation() blackstart.Module {
+	return &domainValidation{}
+}
+
+// domainValidation implements the sendgrid_domain_validation module.
+type domainValidation struct{}
+
+// validationResult is the result of validating the DNS records of an authenticated domain.
+type validationResult struct {
+	Valid             bool `json:"valid"`
+	ValidationResults map[string]struct {
+		Valid  bool   `json:"valid"`
+		Reason string `json:"reason"`
+	} `json:"validation_results"`
+}
+
+// reasons returns the reasons that DNS records of the domain are not valid, ordered by record.
+func (r validationResult) reasons() string {
+	var reasons []string
+	for name, result := range r.ValidationResults {
+		if !result.Valid {
+			reasons = append(reasons, name+": "+result.Reason)
+		}
+	}
+	slices.Sort(reasons)
+	return strings.Join(reasons, "; ")
+}
+
+func (m *domainValidation) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "sendgrid_domain_validation",
+		Name: "SendGrid domain validation",
+		Description: util.CleanString(
+			`
+Ensures SendGrid has validated the DNS records of an authenticated sending domain, so email can be
+sent from the domain. Use this module after the DNS records output by the
+'''sendgrid_domain_authentication''' module are published.
+
+Set asks SendGrid to validate the domain until the records are found, for up to the propagation
+timeout, as DNS changes can take time to propagate.
+
+**Notes**
+
+- '''doesNotExist''' is not supported. Delete the authenticated domain to remove it.
+`,
+		),
+		Requirements: []string{
+			"A SendGrid API key with the `whitelabel.read` and `whitelabel.update` scopes.",
+			"If the `api_key` input is not set, the `SENDGRID_API_KEY` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputDomainID: {
+					Description: "ID of the authenticated domain.",
+					Type:        reflect.TypeFor[int64](),
+					Required:    true,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{},
+		Examples: map[string]string{
+			"Validate a sending domain": `id: sendgrid-domain-validation
+module: sendgrid_domain_validation
+inputs:
+  domain_id:
+    fromDependency:
+      id: sendgrid-domain
+      output: id`,
+		},
+	}
+}
+
+func (m *domainValidation) Validate(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported, delete the authenticated domain instead")
+	}
+	if _, ok := op.Inputs[inputDomainID]; !ok {
+		return fmt.Errorf("missing required parameter: %s", inputDomainID)
+	}
+	return nil
+}
+
+func (m *domainValidation) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	id, err := blackstart.ContextInputAs[int64](ctx, inputDomainID, true)
+	if err != nil {
+		return false, err
+	}
+
+	var current domainMetadata
+	if err = c.Do(ctx, http.MethodGet, fmt.Sprintf("/whitelabel/domains/%d", id), nil, &current); err != nil {
+		return false, fmt.Errorf("failed to get authenticated domain %d: %w", id, err)
+	}
+	return current.Valid && !ctx.Tainted(), nil
+}
+
+func (m *domainValidation) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	id, err := blackstart.ContextInputAs[int64](ctx, inputDomainID, true)
+	if err != nil {
+		return err
+	}
+
+	var result validationResult
+	err = blackstart.WaitForPropagation(
+		ctx, fmt.Sprintf("validation of authenticated domain %d", id), func() (bool, error) {
+			result = validationResult{}
+			path := fmt.Sprintf("/whitelabel/domains/%d/validate", id)
+			if vErr := c.Do(ctx, http.MethodPost, path, nil, &result); vErr != nil {
+				return false, fmt.Errorf("failed to validate authenticated domain %d: %w", id, vErr)
+			}
+			return result.Valid, nil
+		},
+	)
+	if err != nil && !result.Valid && len(result.ValidationResults) > 0 {
+		return fmt.Errorf("%w: %s", err, result.reasons())
+	}
+	return err
+}
@@ -0,0 +1,40 @@
+package sendgrid
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestDomainValidation(t *testing.T) {
+	f := newFakeSendGrid(t)
+	f.addDomain("example.com", "em", true)
+	m := NewDomainValidation()
+	op := fakeOperation(f, "sendgrid_domain_validation", map[string]any{inputDomainID: int64(1)})
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// The DNS records are not published, so the validation fails with the reasons of SendGrid.
+	ctx := context.WithValue(
+		context.Background(), blackstart.ConfigKey, &blackstart.RuntimeConfig{PropagationTimeout: time.Millisecond},
+	)
+	err = m.Set(blackstart.OpContext(ctx, op))
+	require.ErrorIs(t, err, blackstart.ErrNotPropagated)
+	require.ErrorContains(t, err, "mail_cname: CNAME not found")
+
+	f.validDomains["example.com"] = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.DoesNotExist = true
+	require.ErrorContains(t, m.Validate(*op), "doesNotExist is not supported")
+}
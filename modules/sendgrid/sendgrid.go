@@ -0,0 +1,94 @@
+package sendgrid
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputAPIKey            = "api_key"
+	inputAPIURL            = restapi.InputAPIURL
+	inputDomain            = "domain"
+	inputSubdomain         = "subdomain"
+	inputAutomaticSecurity = "automatic_security"
+	inputDomainID          = "domain_id"
+	inputName              = "name"
+	inputScopes            = "scopes"
+
+	outputID         = "id"
+	outputDNSRecords = "dns_records"
+	outputValid      = "valid"
+	outputAPIKey     = "api_key"
+)
+
+const (
+	defaultAPIURL = "https://api.sendgrid.com"
+	apiKeyEnvVar  = "SENDGRID_API_KEY"
+)
+
+func init() {
+	blackstart.RegisterPathName("sendgrid", "SendGrid")
+}
+
+// errorMessage returns the messages of a SendGrid API error response, prefixed by the field of each
+// error when it is set.
+func errorMessage(body io.Reader) string {
+	var payload struct {
+		Errors []struct {
+			Field   *string `json:"field"`
+			Message string  `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return ""
+	}
+	messages := make([]string, 0, len(payload.Errors))
+	for _, e := range payload.Errors {
+		if e.Field != nil && *e.Field != "" {
+			messages = append(messages, *e.Field+": "+e.Message)
+			continue
+		}
+		messages = append(messages, e.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// credentials configures the api_key and api_url inputs of the SendGrid modules.
+var credentials = restapi.Credentials{
+	Input:             inputAPIKey,
+	Description:       "SendGrid API key used to authenticate API requests.",
+	EnvVar:            apiKeyEnvVar,
+	APIURLDescription: "SendGrid API base URL. Set this for EU regional subusers, for example `https://api.eu.sendgrid.com`.",
+	DefaultAPIURL:     defaultAPIURL,
+}
+
+// newClient creates a SendGrid v3 API client for the given base URL and API key. Request paths are
+// relative to the v3 API, such as `/api_keys`.
+func newClient(baseURL, apiKey string) *restapi.Client {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", "Bearer "+apiKey)
+	return restapi.NewClient(
+		restapi.Config{
+			API:          "sendgrid",
+			BaseURL:      strings.TrimRight(baseURL, "/") + "/v3",
+			Header:       header,
+			ErrorMessage: errorMessage,
+		},
+	)
+}
+
+// contextClient builds a SendGrid API client from the api_key and api_url module inputs. When no
+// API key input is provided, the SENDGRID_API_KEY environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*restapi.Client, error) {
+	apiKey, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(apiURL, apiKey), nil
+}
@@ -0,0 +1,215 @@
+package sendgrid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeSendGrid implements SendGrid API endpoints of authenticated domains and API keys stored in
+// memory. Domains become valid when validated if they are listed in validDomains.
+type fakeSendGrid struct {
+	server       *httptest.Server
+	domains      []map[string]any
+	apiKeys      []map[string]any
+	validDomains map[string]bool
+	requests     []string
+	nextID       int
+	mu           sync.Mutex
+}
+
+// newFakeSendGrid starts a fake SendGrid API server.
+func newFakeSendGrid(t *testing.T) *fakeSendGrid {
+	t.Helper()
+	f := &fakeSendGrid{validDomains: map[string]bool{}}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// addDomain stores an authenticated domain with the DNS records of automatic security and returns
+// it.
+func (f *fakeSendGrid) addDomain(domain, subdomain string, automaticSecurity bool) map[string]any {
+	f.nextID++
+	if subdomain == "" {
+		subdomain = fmt.Sprintf("em%d", f.nextID)
+	}
+	d := map[string]any{
+		"id":                 f.nextID,
+		"domain":             domain,
+		"subdomain":          subdomain,
+		"valid":              false,
+		"automatic_security": automaticSecurity,
+		"dns": map[string]any{
+			"mail_cname": map[string]any{
+				"type": "cname", "host": subdomain + "." + domain, "data": "u1.wl.sendgrid.net",
+			},
+			"dkim1": map[string]any{
+				"type": "cname", "host": "s1._domainkey." + domain, "data": "s1.domainkey.u1.wl.sendgrid.net",
+			},
+		},
+	}
+	f.domains = append(f.domains, d)
+	return d
+}
+
+func (f *fakeSendGrid) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+
+	if r.Header.Get("Authorization") != "Bearer test-key" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"errors":[{"field":null,"message":"authorization required"}]}`))
+		return
+	}
+
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	p := strings.TrimPrefix(r.URL.Path, "/v3")
+
+	switch {
+	case p == "/whitelabel/domains" && r.Method == http.MethodGet:
+		matched := []map[string]any{}
+		for _, d := range f.domains {
+			if d["domain"] == r.URL.Query().Get("domain") {
+				matched = append(matched, d)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(matched)
+		return
+	case p == "/whitelabel/domains" && r.Method == http.MethodPost:
+		subdomain, _ := body["subdomain"].(string)
+		d := f.addDomain(body["domain"].(string), subdomain, body["automatic_security"].(bool))
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(d)
+		return
+	case p == "/api_keys" && r.Method == http.MethodGet:
+		result := []map[string]any{}
+		for _, k := range f.apiKeys {
+			result = append(result, map[string]any{"api_key_id": k["api_key_id"], "name": k["name"]})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": result})
+		return
+	case p == "/api_keys" && r.Method == http.MethodPost:
+		f.nextID++
+		body["api_key_id"] = fmt.Sprintf("key-%d", f.nextID)
+		body["scopes"] = append(body["scopes"].([]any), "sender_verification_eligible")
+		f.apiKeys = append(f.apiKeys, body)
+		created := map[string]any{"api_key": fmt.Sprintf("SG.secret-%d", f.nextID)}
+		for k, v := range body {
+			created[k] = v
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(created)
+		return
+	}
+
+	if id, ok := strings.CutPrefix(p, "/whitelabel/domains/"); ok {
+		id, validate := strings.CutSuffix(id, "/validate")
+		for i, d := range f.domains {
+			if strconv.Itoa(d["id"].(int)) != id {
+				continue
+			}
+			switch {
+			case validate:
+				d["valid"] = f.validDomains[d["domain"].(string)]
+				results := map[string]any{"mail_cname": map[string]any{"valid": d["valid"], "reason": "CNAME not found"}}
+				_ = json.NewEncoder(w).Encode(map[string]any{"id": d["id"], "valid": d["valid"], "validation_results": results})
+			case r.Method == http.MethodGet:
+				_ = json.NewEncoder(w).Encode(d)
+			case r.Method == http.MethodDelete:
+				f.domains = append(f.domains[:i], f.domains[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+	}
+	if id, ok := strings.CutPrefix(p, "/api_keys/"); ok {
+		for i, k := range f.apiKeys {
+			if k["api_key_id"] != id {
+				continue
+			}
+			switch r.Method {
+			case http.MethodGet:
+				_ = json.NewEncoder(w).Encode(k)
+			case http.MethodPut:
+				k["name"], k["scopes"] = body["name"], body["scopes"]
+				_ = json.NewEncoder(w).Encode(k)
+			case http.MethodDelete:
+				f.apiKeys = append(f.apiKeys[:i], f.apiKeys[i+1:]...)
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	_, _ = w.Write([]byte(`{"errors":[{"field":null,"message":"resource not found"}]}`))
+}
+
+// fakeOperation returns an operation of a module targeting the fake server.
+func fakeOperation(f *fakeSendGrid, module string, inputs map[string]any) *blackstart.Operation {
+	return credentials.TestOperation(module, f.server.URL, "test-key", inputs)
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestErrorMessage(t *testing.T) {
+	require.Equal(
+		t,
+		"domain: invalid domain, automatic_security: must be a boolean",
+		errorMessage(
+			bytes.NewBufferString(
+				`{"errors":[{"field":"domain","message":"invalid domain"},`+
+					`{"field":"automatic_security","message":"must be a boolean"}]}`,
+			),
+		),
+	)
+	require.Equal(
+		t,
+		"access forbidden",
+		errorMessage(bytes.NewBufferString(`{"errors":[{"field":null,"message":"access forbidden"}]}`)),
+	)
+	require.Empty(t, errorMessage(bytes.NewBufferString(`not json`)))
+}
+
+func TestContextClient_APIKeyFromEnvironment(t *testing.T) {
+	f := newFakeSendGrid(t)
+	op := fakeOperation(f, "sendgrid_api_key", nil)
+	delete(op.Inputs, inputAPIKey)
+
+	t.Setenv(apiKeyEnvVar, "")
+	_, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, apiKeyEnvVar)
+
+	t.Setenv(apiKeyEnvVar, "test-key")
+	c, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "Bearer test-key", c.Header.Get("Authorization"))
+	require.Equal(t, f.server.URL+"/v3", c.BaseURL)
+}
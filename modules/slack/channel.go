@@ -0,0 +1,344 @@
+package slack
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+// channelNamePattern matches the channel names accepted by Slack.
+var channelNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,79}$`)
+
+func init() {
+	blackstart.RegisterModule("slack_channel", NewChannel)
+}
+
+var _ blackstart.Module = &channelModule{}
+
+// NewChannel creates a module that manages a Slack channel.
+func NewChannel() blackstart.Module {
+	return &channelModule{}
+}
+
+// channelModule implements the slack_channel module.
+type channelModule struct{}
+
+// channel is the subset of the Slack conversation object used by the module.
+type channel struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	IsPrivate  bool   `json:"is_private"`
+	IsArchived bool   `json:"is_archived"`
+	Topic      struct {
+		Value string `json:"value"`
+	} `json:"topic"`
+	Purpose struct {
+		Value string `json:"value"`
+	} `json:"purpose"`
+}
+
+// channelSpec is the desired channel state read from module inputs.
+type channelSpec struct {
+	name    string
+	private bool
+	topic   *string
+	purpose *string
+}
+
+func (m *channelModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "slack_channel",
+		Name: "Slack channel",
+		Description: util.CleanString(
+			`
+Ensures a Slack channel exists, optionally with a topic and purpose. The channel ID is available as
+an output for downstream operations, such as storing it in a Kubernetes Secret for an application
+that posts messages with a bot token.
+
+When '''doesNotExist''' is set, the channel is archived. An archived channel with the same name is
+unarchived when the channel is required again.
+
+**Notes**
+
+- Slack does not provide an API to create incoming webhooks. Incoming webhook URLs are only issued
+  when a Slack app is installed through OAuth, so they must be created outside of Blackstart. An
+  existing webhook URL can be stored in a Secret with '''kubernetes_secret_value'''.
+- Slack does not allow changing a channel between public and private with the Web API. If a channel
+  exists with a different visibility than requested, the operation fails.
+`,
+		),
+		Requirements: []string{
+			"A Slack bot or user token with the `channels:read`, `channels:manage`, `groups:read`, and `groups:write` scopes.",
+			"If the `token` input is not set, the `SLACK_TOKEN` environment variable is used.",
+		},
+		Inputs: credentials.Inputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Channel name, without the leading `#`. Must be lowercase and contain only letters, numbers, hyphens, and underscores.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputPrivate: {
+					Description: "Create the channel as a private channel.",
+					Type:        reflect.TypeFor[bool](),
+					Required:    false,
+					Default:     false,
+				},
+				inputTopic: {
+					Description: "Channel topic. The topic is not managed when not set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputPurpose: {
+					Description: "Channel purpose. The purpose is not managed when not set.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputChannelID: {
+				Description: "ID of the Slack channel.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputName: {
+				Description: "Name of the Slack channel.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Create a channel for a new environment": `id: alerts-channel
+module: slack_channel
+inputs:
+  name: alerts-staging
+  topic: Alerts for the staging environment`,
+			"Store the channel ID in a Secret": `operations:
+  - id: alerts-channel
+    module: slack_channel
+    inputs:
+      name: alerts-staging
+
+  - id: app-secret
+    module: kubernetes_secret
+    inputs:
+      client:
+        fromDependency:
+          id: k8s-client
+          output: client
+      name: app-slack
+
+  - id: app-secret-channel
+    module: kubernetes_secret_value
+    inputs:
+      secret:
+        fromDependency:
+          id: app-secret
+          output: secret
+      key: SLACK_CHANNEL_ID
+      value:
+        fromDependency:
+          id: alerts-channel
+          output: channel_id
+      update_policy: overwrite`,
+		},
+	}
+}
+
+func (m *channelModule) Validate(op blackstart.Operation) error {
+	input, ok := op.Inputs[inputName]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", inputName)
+	}
+	if input.IsStatic() {
+		name, err := blackstart.InputAs[string](input, true)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputName, err)
+		}
+		if !channelNamePattern.MatchString(name) {
+			return fmt.Errorf("parameter %s is invalid: %q is not a valid Slack channel name", inputName, name)
+		}
+	}
+	if input, ok = op.Inputs[inputPrivate]; ok && input.IsStatic() {
+		if _, err := blackstart.InputAs[bool](input, false); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputPrivate, err)
+		}
+	}
+	return nil
+}
+
+func (m *channelModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	spec, err := contextChannelSpec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	ch, err := findChannel(ctx, c, spec.name)
+	if err != nil {
+		return false, err
+	}
+	exists := ch != nil && !ch.IsArchived
+
+	if ctx.DoesNotExist() {
+		return !exists, nil
+	}
+	if ctx.Tainted() || !exists {
+		return false, nil
+	}
+	if ch.IsPrivate != spec.private {
+		return false, fmt.Errorf(
+			"channel %s exists with private=%t, but private=%t was requested", spec.name, ch.IsPrivate, spec.private,
+		)
+	}
+	if spec.topic != nil && ch.Topic.Value != *spec.topic {
+		return false, nil
+	}
+	if spec.purpose != nil && ch.Purpose.Value != *spec.purpose {
+		return false, nil
+	}
+	return true, outputChannel(ctx, ch)
+}
+
+func (m *channelModule) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	spec, err := contextChannelSpec(ctx)
+	if err != nil {
+		return err
+	}
+
+	ch, err := findChannel(ctx, c, spec.name)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if ch == nil || ch.IsArchived {
+			return nil
+		}
+		return c.call(ctx, "conversations.archive", url.Values{"channel": {ch.ID}}, nil)
+	}
+
+	switch {
+	case ch == nil:
+		var created struct {
+			Channel channel `json:"channel"`
+		}
+		params := url.Values{"name": {spec.name}, "is_private": {strconv.FormatBool(spec.private)}}
+		if err = c.call(ctx, "conversations.create", params, &created); err != nil {
+			return err
+		}
+		ch = &created.Channel
+	case ch.IsArchived:
+		if err = c.call(ctx, "conversations.unarchive", url.Values{"channel": {ch.ID}}, nil); err != nil {
+			return err
+		}
+	}
+
+	if ch.IsPrivate != spec.private {
+		return fmt.Errorf(
+			"channel %s exists with private=%t, but private=%t was requested", spec.name, ch.IsPrivate, spec.private,
+		)
+	}
+	if spec.topic != nil && ch.Topic.Value != *spec.topic {
+		params := url.Values{"channel": {ch.ID}, "topic": {*spec.topic}}
+		if err = c.call(ctx, "conversations.setTopic", params, nil); err != nil {
+			return err
+		}
+	}
+	if spec.purpose != nil && ch.Purpose.Value != *spec.purpose {
+		params := url.Values{"channel": {ch.ID}, "purpose": {*spec.purpose}}
+		if err = c.call(ctx, "conversations.setPurpose", params, nil); err != nil {
+			return err
+		}
+	}
+	return outputChannel(ctx, ch)
+}
+
+// findChannel returns the public or private channel with the given name, including archived
+// channels. A nil channel is returned when no channel matches.
+func findChannel(ctx blackstart.ModuleContext, c *client, name string) (*channel, error) {
+	cursor := ""
+	for {
+		params := url.Values{
+			"types":            {"public_channel,private_channel"},
+			"exclude_archived": {"false"},
+			"limit":            {"200"},
+		}
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+		var page struct {
+			Channels         []channel `json:"channels"`
+			ResponseMetadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}
+		if err := c.call(ctx, "conversations.list", params, &page); err != nil {
+			return nil, err
+		}
+		for i := range page.Channels {
+			if page.Channels[i].Name == name {
+				return &page.Channels[i], nil
+			}
+		}
+		cursor = page.ResponseMetadata.NextCursor
+		if cursor == "" {
+			return nil, nil
+		}
+	}
+}
+
+// contextChannelSpec reads the desired channel state from module inputs.
+func contextChannelSpec(ctx blackstart.ModuleContext) (channelSpec, error) {
+	var spec channelSpec
+	var err error
+	spec.name, err = blackstart.ContextInputAs[string](ctx, inputName, true)
+	if err != nil {
+		return spec, err
+	}
+	spec.private, err = blackstart.ContextInputAs[bool](ctx, inputPrivate, false)
+	if err != nil {
+		return spec, err
+	}
+	spec.topic, err = optionalString(ctx, inputTopic)
+	if err != nil {
+		return spec, err
+	}
+	spec.purpose, err = optionalString(ctx, inputPurpose)
+	if err != nil {
+		return spec, err
+	}
+	return spec, nil
+}
+
+// optionalString returns a pointer to a string input, or nil when the input is not set.
+func optionalString(ctx blackstart.ModuleContext, key string) (*string, error) {
+	input, err := ctx.Input(key)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input %s: %w", key, err)
+	}
+	return &value, nil
+}
+
+// outputChannel emits the channel outputs.
+func outputChannel(ctx blackstart.ModuleContext, ch *channel) error {
+	if err := ctx.Output(outputChannelID, ch.ID); err != nil {
+		return err
+	}
+	return ctx.Output(outputName, ch.Name)
+}
@@ -0,0 +1,201 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// fakeSlack implements the Slack conversations API methods used by the module.
+type fakeSlack struct {
+	server   *httptest.Server
+	channels []*channel
+	calls    []string
+	pageSize int
+	mu       sync.Mutex
+}
+
+// newFakeSlack starts a fake Slack Web API server.
+func newFakeSlack(t *testing.T) *fakeSlack {
+	t.Helper()
+	f := &fakeSlack{pageSize: 200}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeSlack) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	method := strings.TrimPrefix(r.URL.Path, "/")
+	f.calls = append(f.calls, method)
+	_ = r.ParseForm()
+
+	if r.Header.Get("Authorization") != "Bearer xoxb-test" {
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": false, "error": "invalid_auth"})
+		return
+	}
+
+	resp := map[string]any{"ok": true}
+	switch method {
+	case "conversations.list":
+		start := 0
+		if c := r.Form.Get("cursor"); c != "" {
+			_, _ = fmt.Sscanf(c, "page-%d", &start)
+		}
+		end := min(start+f.pageSize, len(f.channels))
+		resp["channels"] = f.channels[start:end]
+		next := ""
+		if end < len(f.channels) {
+			next = fmt.Sprintf("page-%d", end)
+		}
+		resp["response_metadata"] = map[string]string{"next_cursor": next}
+	case "conversations.create":
+		ch := &channel{
+			ID:        fmt.Sprintf("C%03d", len(f.channels)+1),
+			Name:      r.Form.Get("name"),
+			IsPrivate: r.Form.Get("is_private") == "true",
+		}
+		f.channels = append(f.channels, ch)
+		resp["channel"] = ch
+	case "conversations.archive", "conversations.unarchive":
+		ch := f.byID(r.Form.Get("channel"))
+		ch.IsArchived = method == "conversations.archive"
+	case "conversations.setTopic":
+		f.byID(r.Form.Get("channel")).Topic.Value = r.Form.Get("topic")
+	case "conversations.setPurpose":
+		f.byID(r.Form.Get("channel")).Purpose.Value = r.Form.Get("purpose")
+	default:
+		resp = map[string]any{"ok": false, "error": "unknown_method"}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeSlack) byID(id string) *channel {
+	for _, ch := range f.channels {
+		if ch.ID == id {
+			return ch
+		}
+	}
+	return &channel{}
+}
+
+// channelOperation returns a slack_channel operation targeting the fake server.
+func channelOperation(f *fakeSlack, inputs map[string]any) *blackstart.Operation {
+	op := credentials.TestOperation("slack_channel", f.server.URL, "xoxb-test", inputs)
+	if _, ok := op.Inputs[inputName]; !ok {
+		op.Inputs[inputName] = blackstart.NewInputFromValue("alerts-staging")
+	}
+	return op
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestChannel_Create(t *testing.T) {
+	f := newFakeSlack(t)
+	m := NewChannel()
+	op := channelOperation(f, map[string]any{inputTopic: "Staging alerts"})
+	require.NoError(t, m.Validate(*op))
+
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "C001", ctx.outputs[outputChannelID])
+	require.Equal(t, "Staging alerts", f.channels[0].Topic.Value)
+
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "alerts-staging", ctx.outputs[outputName])
+}
+
+func TestChannel_FindsChannelOnLaterPage(t *testing.T) {
+	f := newFakeSlack(t)
+	f.pageSize = 1
+	f.channels = []*channel{{ID: "C001", Name: "general"}, {ID: "C002", Name: "alerts-staging"}}
+	m := NewChannel()
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), channelOperation(f, nil)))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, strings.Count(strings.Join(f.calls, ","), "conversations.list"))
+}
+
+func TestChannel_PrivateMismatch(t *testing.T) {
+	f := newFakeSlack(t)
+	f.channels = []*channel{{ID: "C001", Name: "alerts-staging"}}
+	m := NewChannel()
+
+	_, err := m.Check(blackstart.OpContext(context.Background(), channelOperation(f, map[string]any{inputPrivate: true})))
+	require.ErrorContains(t, err, "exists with private=false")
+}
+
+func TestChannel_ArchiveAndUnarchive(t *testing.T) {
+	f := newFakeSlack(t)
+	f.channels = []*channel{{ID: "C001", Name: "alerts-staging"}}
+	m := NewChannel()
+
+	op := channelOperation(f, nil)
+	op.DoesNotExist = true
+	ctx := blackstart.OpContext(context.Background(), op)
+	ok, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.True(t, f.channels[0].IsArchived)
+
+	op.DoesNotExist = false
+	ctx = blackstart.OpContext(context.Background(), op)
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(ctx))
+	require.False(t, f.channels[0].IsArchived)
+	require.Len(t, f.channels, 1)
+}
+
+func TestChannel_Validate(t *testing.T) {
+	f := newFakeSlack(t)
+	m := NewChannel()
+
+	require.NoError(t, m.Validate(*channelOperation(f, nil)))
+	require.ErrorContains(
+		t, m.Validate(*channelOperation(f, map[string]any{inputName: "#Alerts Staging"})), "not a valid Slack channel name",
+	)
+	op := channelOperation(f, nil)
+	delete(op.Inputs, inputName)
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: name")
+}
+
+func TestChannel_APIError(t *testing.T) {
+	f := newFakeSlack(t)
+	m := NewChannel()
+
+	_, err := m.Check(blackstart.OpContext(context.Background(), channelOperation(f, map[string]any{inputToken: "bad"})))
+	require.ErrorContains(t, err, "invalid_auth")
+}
@@ -0,0 +1,127 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputToken   = "token"
+	inputAPIURL  = restapi.InputAPIURL
+	inputName    = "name"
+	inputPrivate = "private"
+	inputTopic   = "topic"
+	inputPurpose = "purpose"
+
+	outputChannelID = "channel_id"
+	outputName      = "name"
+)
+
+const (
+	defaultAPIURL = "https://slack.com/api"
+	tokenEnvVar   = "SLACK_TOKEN"
+)
+
+func init() {
+	blackstart.RegisterPathName("slack", "Slack")
+}
+
+// credentials configures the token and api_url inputs of the Slack modules.
+var credentials = restapi.Credentials{
+	Input:             inputToken,
+	Description:       "Slack token used to authenticate API requests.",
+	EnvVar:            tokenEnvVar,
+	APIURLDescription: "Slack Web API base URL.",
+	DefaultAPIURL:     defaultAPIURL,
+}
+
+// apiError is returned when a Slack Web API method responds with `ok: false`.
+type apiError struct {
+	Method string
+	Code   string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("slack api method %s failed: %s", e.Method, e.Code)
+}
+
+// client is a minimal Slack Web API client.
+type client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// newClient creates a Slack Web API client for the given base URL and token.
+func newClient(baseURL, token string) *client {
+	if baseURL == "" {
+		baseURL = defaultAPIURL
+	}
+	return &client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Transport: blackstart.CountAPICalls(blackstart.APIProviderSlack, nil)},
+	}
+}
+
+// call invokes a Slack Web API method with form-encoded parameters and decodes the response into
+// out. Responses with `ok: false` are returned as an *apiError.
+func (c *client) call(ctx context.Context, method string, params url.Values, out any) error {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, c.baseURL+"/"+method, strings.NewReader(params.Encode()),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("User-Agent", blackstart.UserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("slack api method %s failed: %w", method, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("slack api method %s failed with status %d", method, resp.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err = json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return fmt.Errorf("failed to decode slack api response: %w", err)
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err = json.Unmarshal(raw, &status); err != nil {
+		return fmt.Errorf("failed to decode slack api response: %w", err)
+	}
+	if !status.OK {
+		return &apiError{Method: method, Code: status.Error}
+	}
+	if out != nil {
+		if err = json.Unmarshal(raw, out); err != nil {
+			return fmt.Errorf("failed to decode slack api response: %w", err)
+		}
+	}
+	return nil
+}
+
+// contextClient builds a Slack Web API client from the token and api_url module inputs. When no
+// token input is provided, the SLACK_TOKEN environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*client, error) {
+	token, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(apiURL, token), nil
+}
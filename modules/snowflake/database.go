@@ -0,0 +1,181 @@
+package snowflake
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("snowflake_database", NewDatabase)
+}
+
+var _ blackstart.Module = &database{}
+
+// NewDatabase creates a module that manages a Snowflake database.
+func NewDatabase() blackstart.Module {
+	return &database{}
+}
+
+// database implements the snowflake_database module.
+type database struct{}
+
+// desiredDatabase is the desired state of a database read from module inputs.
+type desiredDatabase struct {
+	name       string
+	comment    *string
+	usageRoles []string
+}
+
+func (m *database) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "snowflake_database",
+		Name: "Snowflake database",
+		Description: util.CleanString(
+			`
+Ensures a Snowflake database exists with the comment, and grants the '''USAGE''' privilege on the
+database to roles, such as the roles of service users that load or query data. Statements are run
+with the Snowflake SQL API.
+
+Names are quoted identifiers, so they are case-sensitive. Roles created without quotes, such as the
+system roles, have uppercase names like '''SYSADMIN'''.
+
+**Notes**
+
+- Privileges granted to other roles are not changed.
+- When '''doesNotExist''' is set, the database is dropped with its schemas and tables.
+`,
+		),
+		Requirements: []string{
+			"The role of the token must have the `CREATE DATABASE` privilege on the account to create databases, and ownership of the database to change or drop it, or to grant privileges on it.",
+			"If the `token` input is not set, the `SNOWFLAKE_TOKEN` environment variable is used.",
+		},
+		Inputs: moduleInputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the database.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputComment: {
+					Description: "Comment of the database. If not set, the comment is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputUsageRoles: {
+					Description: "Roles granted the `USAGE` privilege on the database.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputName: {
+				Description: "Name of the database.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Analytics database": `id: analytics-database
+module: snowflake_database
+inputs:
+  api_url: https://myorg-myaccount.snowflakecomputing.com
+  name: ANALYTICS
+  comment: Application events
+  usage_roles:
+    - LOADER`,
+		},
+	}
+}
+
+func (m *database) Validate(op blackstart.Operation) error {
+	return validateInputs(op)
+}
+
+func (m *database) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextDatabase(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := showObject(ctx, c, "DATABASES", desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !sameComment(current["comment"], desired.comment) {
+		return false, nil
+	}
+	granted, err := usageRoles(ctx, c, "DATABASE", desired.name)
+	if err != nil {
+		return false, err
+	}
+	if len(missingNames(granted, desired.usageRoles)) > 0 {
+		return false, nil
+	}
+	return true, ctx.Output(outputName, desired.name)
+}
+
+func (m *database) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextDatabase(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if err = execute(ctx, c, "DROP DATABASE IF EXISTS "+identifier(desired.name)); err != nil {
+			return fmt.Errorf("failed to drop database %s: %w", desired.name, err)
+		}
+		return nil
+	}
+
+	current, err := showObject(ctx, c, "DATABASES", desired.name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case current == nil:
+		statement := "CREATE DATABASE IF NOT EXISTS " + identifier(desired.name) + commentProperty(desired.comment)
+		if err = execute(ctx, c, statement); err != nil {
+			return fmt.Errorf("failed to create database %s: %w", desired.name, err)
+		}
+	case !sameComment(current["comment"], desired.comment):
+		statement := "ALTER DATABASE " + identifier(desired.name) + " SET" + commentProperty(desired.comment)
+		if err = execute(ctx, c, statement); err != nil {
+			return fmt.Errorf("failed to update database %s: %w", desired.name, err)
+		}
+	}
+
+	if err = grantUsage(ctx, c, "DATABASE", desired.name, desired.usageRoles); err != nil {
+		return err
+	}
+	return ctx.Output(outputName, desired.name)
+}
+
+// contextDatabase reads the desired database from module inputs.
+func contextDatabase(ctx blackstart.ModuleContext) (desiredDatabase, error) {
+	var d desiredDatabase
+	var err error
+	if d.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredDatabase{}, err
+	}
+	if d.comment, err = contextComment(ctx); err != nil {
+		return desiredDatabase{}, err
+	}
+	if d.usageRoles, err = blackstart.ContextInputAs[[]string](ctx, inputUsageRoles, false); err != nil {
+		return desiredDatabase{}, err
+	}
+	return d, nil
+}
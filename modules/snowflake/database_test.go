@@ -0,0 +1,98 @@
+package snowflake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestDatabase_Validate(t *testing.T) {
+	m := NewDatabase()
+	f := newFakeSnowflake(t)
+
+	require.NoError(t, m.Validate(*fakeOperation(f, "snowflake_database", map[string]any{inputName: "ANALYTICS"})))
+	require.ErrorContains(
+		t, m.Validate(*fakeOperation(f, "snowflake_database", nil)), "missing required parameter: name",
+	)
+
+	op := fakeOperation(f, "snowflake_database", map[string]any{inputName: "ANALYTICS", inputTokenType: "PASSWORD"})
+	require.ErrorContains(t, m.Validate(*op), "invalid value 'PASSWORD'")
+
+	delete(op.Inputs, inputAPIURL)
+	require.ErrorContains(t, m.Validate(*op), "missing required parameter: api_url")
+}
+
+func TestDatabase_CreateUpdateDelete(t *testing.T) {
+	f := newFakeSnowflake(t)
+	f.objects["DATABASE"]["analytics"] = map[string]string{}
+	m := NewDatabase()
+	inputs := map[string]any{
+		inputName:       "ANALYTICS",
+		inputComment:    "Application's events",
+		inputUsageRoles: []string{"LOADER"},
+	}
+	op := fakeOperation(f, "snowflake_database", inputs)
+	require.NoError(t, m.Validate(*op))
+
+	// The database with another case is a different database.
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "ANALYTICS", ctx.outputs[outputName])
+	require.Equal(t, "Application's events", f.objects["DATABASE"]["ANALYTICS"]["comment"])
+	require.Contains(t, f.statements, `GRANT USAGE ON DATABASE "ANALYTICS" TO ROLE "LOADER"`)
+
+	ctx = &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	ok, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "ANALYTICS", ctx.outputs[outputName])
+
+	// A different comment and another role update the database, and existing grants are kept.
+	inputs[inputComment] = "Events"
+	inputs[inputUsageRoles] = []string{"LOADER", "ANALYST"}
+	op = fakeOperation(f, "snowflake_database", inputs)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	f.statements = nil
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(
+		t,
+		[]string{
+			`SHOW DATABASES LIKE 'ANALYTICS'`,
+			`ALTER DATABASE "ANALYTICS" SET COMMENT = 'Events'`,
+			`SHOW GRANTS ON DATABASE "ANALYTICS"`,
+			`GRANT USAGE ON DATABASE "ANALYTICS" TO ROLE "ANALYST"`,
+		},
+		f.statements,
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.DoesNotExist = true
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.NotContains(t, f.objects["DATABASE"], "ANALYTICS")
+	require.Contains(t, f.objects["DATABASE"], "analytics")
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestDatabase_BadCredentials(t *testing.T) {
+	f := newFakeSnowflake(t)
+	m := NewDatabase()
+	op := fakeOperation(f, "snowflake_database", map[string]any{inputName: "ANALYTICS", inputToken: "wrong"})
+
+	_, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, "390303: Invalid OAuth access token.")
+}
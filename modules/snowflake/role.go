@@ -0,0 +1,240 @@
+package snowflake
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+	"github.com/pezops/blackstart/util"
+)
+
+func init() {
+	blackstart.RegisterModule("snowflake_role", NewRole)
+}
+
+var _ blackstart.Module = &role{}
+
+// NewRole creates a module that manages a Snowflake role.
+func NewRole() blackstart.Module {
+	return &role{}
+}
+
+// role implements the snowflake_role module.
+type role struct{}
+
+// desiredRole is the desired state of a role read from module inputs.
+type desiredRole struct {
+	name        string
+	comment     *string
+	users       []string
+	parentRoles []string
+}
+
+// roleGrantees are the users and roles that a role is granted to.
+type roleGrantees struct {
+	users map[string]bool
+	roles map[string]bool
+}
+
+func (m *role) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "snowflake_role",
+		Name: "Snowflake role",
+		Description: util.CleanString(
+			`
+Ensures a Snowflake role exists with the comment, and grants the role to users, such as service
+users, and to parent roles. Privileges on databases and warehouses are granted to the role with the
+'''usage_roles''' input of the '''snowflake_database''' and '''snowflake_warehouse''' modules.
+Statements are run with the Snowflake SQL API.
+
+Names are quoted identifiers, so they are case-sensitive. Users and roles created without quotes,
+such as the system roles, have uppercase names like '''SYSADMIN'''.
+
+**Notes**
+
+- The role is not revoked from other users and roles.
+- When '''doesNotExist''' is set, the role is dropped.
+`,
+		),
+		Requirements: []string{
+			"The role of the token must have the `CREATE ROLE` privilege on the account to create roles, such as the `USERADMIN` role, and ownership of the role to change or drop it, or to grant it.",
+			"If the `token` input is not set, the `SNOWFLAKE_TOKEN` environment variable is used.",
+		},
+		Inputs: moduleInputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the role.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputComment: {
+					Description: "Comment of the role. If not set, the comment is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputUsers: {
+					Description: "Users granted the role.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+				inputParentRoles: {
+					Description: "Roles granted the role, such as `SYSADMIN` so system administrators can manage the objects the role owns.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputName: {
+				Description: "Name of the role.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Loader role": `id: loader-role
+module: snowflake_role
+inputs:
+  api_url: https://myorg-myaccount.snowflakecomputing.com
+  name: LOADER
+  comment: Loads application events
+  users:
+    - LOADER_SERVICE
+  parent_roles:
+    - SYSADMIN`,
+		},
+	}
+}
+
+func (m *role) Validate(op blackstart.Operation) error {
+	return validateInputs(op)
+}
+
+func (m *role) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextRole(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := showObject(ctx, c, "ROLES", desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || !sameComment(current["comment"], desired.comment) {
+		return false, nil
+	}
+	grantees, err := roleGranteesOf(ctx, c, desired.name)
+	if err != nil {
+		return false, err
+	}
+	if len(missingNames(grantees.users, desired.users)) > 0 ||
+		len(missingNames(grantees.roles, desired.parentRoles)) > 0 {
+		return false, nil
+	}
+	return true, ctx.Output(outputName, desired.name)
+}
+
+func (m *role) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextRole(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if err = execute(ctx, c, "DROP ROLE IF EXISTS "+identifier(desired.name)); err != nil {
+			return fmt.Errorf("failed to drop role %s: %w", desired.name, err)
+		}
+		return nil
+	}
+
+	current, err := showObject(ctx, c, "ROLES", desired.name)
+	if err != nil {
+		return err
+	}
+	switch {
+	case current == nil:
+		statement := "CREATE ROLE IF NOT EXISTS " + identifier(desired.name) + commentProperty(desired.comment)
+		if err = execute(ctx, c, statement); err != nil {
+			return fmt.Errorf("failed to create role %s: %w", desired.name, err)
+		}
+	case !sameComment(current["comment"], desired.comment):
+		statement := "ALTER ROLE " + identifier(desired.name) + " SET" + commentProperty(desired.comment)
+		if err = execute(ctx, c, statement); err != nil {
+			return fmt.Errorf("failed to update role %s: %w", desired.name, err)
+		}
+	}
+
+	grantees, err := roleGranteesOf(ctx, c, desired.name)
+	if err != nil {
+		return err
+	}
+	for _, user := range missingNames(grantees.users, desired.users) {
+		if err = grantRole(ctx, c, desired.name, "USER", user); err != nil {
+			return err
+		}
+	}
+	for _, parent := range missingNames(grantees.roles, desired.parentRoles) {
+		if err = grantRole(ctx, c, desired.name, "ROLE", parent); err != nil {
+			return err
+		}
+	}
+	return ctx.Output(outputName, desired.name)
+}
+
+// roleGranteesOf returns the users and roles that a role is granted to.
+func roleGranteesOf(ctx context.Context, c *restapi.Client, name string) (roleGrantees, error) {
+	rows, err := query(ctx, c, "SHOW GRANTS OF ROLE "+identifier(name))
+	if err != nil {
+		return roleGrantees{}, fmt.Errorf("failed to show grants of role %s: %w", name, err)
+	}
+	grantees := roleGrantees{users: map[string]bool{}, roles: map[string]bool{}}
+	for _, r := range rows {
+		switch r["granted_to"] {
+		case "USER":
+			grantees.users[r["grantee_name"]] = true
+		case "ROLE":
+			grantees.roles[r["grantee_name"]] = true
+		}
+	}
+	return grantees, nil
+}
+
+// grantRole grants a role to a user or role.
+func grantRole(ctx context.Context, c *restapi.Client, name, granteeType, grantee string) error {
+	statement := fmt.Sprintf("GRANT ROLE %s TO %s %s", identifier(name), granteeType, identifier(grantee))
+	if err := execute(ctx, c, statement); err != nil {
+		return fmt.Errorf("failed to grant role %s to %s %s: %w", name, granteeType, grantee, err)
+	}
+	return nil
+}
+
+// contextRole reads the desired role from module inputs.
+func contextRole(ctx blackstart.ModuleContext) (desiredRole, error) {
+	var r desiredRole
+	var err error
+	if r.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredRole{}, err
+	}
+	if r.comment, err = contextComment(ctx); err != nil {
+		return desiredRole{}, err
+	}
+	if r.users, err = blackstart.ContextInputAs[[]string](ctx, inputUsers, false); err != nil {
+		return desiredRole{}, err
+	}
+	if r.parentRoles, err = blackstart.ContextInputAs[[]string](ctx, inputParentRoles, false); err != nil {
+		return desiredRole{}, err
+	}
+	return r, nil
+}
@@ -0,0 +1,66 @@
+package snowflake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestRole_CreateGrantDelete(t *testing.T) {
+	f := newFakeSnowflake(t)
+	m := NewRole()
+	inputs := map[string]any{
+		inputName:        "LOADER",
+		inputUsers:       []string{"LOADER_SERVICE"},
+		inputParentRoles: []string{"SYSADMIN"},
+	}
+	op := fakeOperation(f, "snowflake_role", inputs)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "LOADER", ctx.outputs[outputName])
+	require.Contains(t, f.statements, `CREATE ROLE IF NOT EXISTS "LOADER"`)
+	require.Contains(t, f.statements, `GRANT ROLE "LOADER" TO USER "LOADER_SERVICE"`)
+	require.Contains(t, f.statements, `GRANT ROLE "LOADER" TO ROLE "SYSADMIN"`)
+
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Another user is granted the role, and the role is not granted again to the existing user.
+	inputs[inputUsers] = []string{"LOADER_SERVICE", "BACKFILL_SERVICE"}
+	inputs[inputComment] = "Loads application events"
+	op = fakeOperation(f, "snowflake_role", inputs)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	f.statements = nil
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Equal(
+		t,
+		[]string{
+			`SHOW ROLES LIKE 'LOADER'`,
+			`ALTER ROLE "LOADER" SET COMMENT = 'Loads application events'`,
+			`SHOW GRANTS OF ROLE "LOADER"`,
+			`GRANT ROLE "LOADER" TO USER "BACKFILL_SERVICE"`,
+		},
+		f.statements,
+	)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.objects["ROLE"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,324 @@
+package snowflake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/internal/restapi"
+)
+
+const (
+	inputToken       = "token"
+	inputTokenType   = "token_type"
+	inputAPIURL      = restapi.InputAPIURL
+	inputName        = "name"
+	inputComment     = "comment"
+	inputUsageRoles  = "usage_roles"
+	inputSize        = "size"
+	inputAutoSuspend = "auto_suspend"
+	inputUsers       = "users"
+	inputParentRoles = "parent_roles"
+
+	outputName = "name"
+)
+
+const (
+	tokenEnvVar = "SNOWFLAKE_TOKEN"
+
+	// statementsPath is the path of the SQL API endpoint that runs statements.
+	statementsPath = "/api/v2/statements"
+
+	// statementTimeout is the number of seconds a statement may run before Snowflake cancels it.
+	statementTimeout = 60
+
+	// codeAsyncExecution is the response code of a statement that is still running.
+	codeAsyncExecution = "333334"
+)
+
+const (
+	tokenTypeProgrammatic = "PROGRAMMATIC_ACCESS_TOKEN"
+	tokenTypeOAuth        = "OAUTH"
+	tokenTypeKeyPairJWT   = "KEYPAIR_JWT"
+)
+
+// tokenTypes are the supported values of the token_type input.
+var tokenTypes = map[string]struct{}{
+	tokenTypeProgrammatic: {},
+	tokenTypeOAuth:        {},
+	tokenTypeKeyPairJWT:   {},
+}
+
+// pollInterval is the time between requests for the status of a running statement.
+var pollInterval = time.Second
+
+func init() {
+	blackstart.RegisterPathName("snowflake", "Snowflake")
+}
+
+// errorMessage returns the message of a Snowflake SQL API error response.
+func errorMessage(body io.Reader) string {
+	var payload struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return ""
+	}
+	if payload.Code == "" {
+		return payload.Message
+	}
+	return payload.Code + ": " + payload.Message
+}
+
+// credentials configures the token and api_url inputs of the Snowflake modules. Snowflake accounts
+// have their own URL, so the api_url input is required.
+var credentials = restapi.Credentials{
+	Input:       inputToken,
+	Description: "Snowflake token used to authenticate SQL API requests.",
+	EnvVar:      tokenEnvVar,
+}
+
+// moduleInputs returns the inputs of a Snowflake module merged with the token, token_type, and
+// api_url inputs.
+func moduleInputs(inputs map[string]blackstart.InputValue) map[string]blackstart.InputValue {
+	merged := credentials.Inputs(
+		map[string]blackstart.InputValue{
+			inputAPIURL: {
+				Description: "Snowflake account URL, for example `https://myorg-myaccount.snowflakecomputing.com`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputTokenType: {
+				Description: "Type of the token. One of `PROGRAMMATIC_ACCESS_TOKEN`, `OAUTH`, or `KEYPAIR_JWT`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     tokenTypeProgrammatic,
+			},
+		},
+	)
+	maps.Copy(merged, inputs)
+	return merged
+}
+
+// validateInputs validates the inputs shared by the Snowflake modules.
+func validateInputs(op blackstart.Operation) error {
+	if err := restapi.ValidateRequiredStrings(op, inputAPIURL, inputName); err != nil {
+		return err
+	}
+	return restapi.ValidateEnum(op, inputTokenType, tokenTypes)
+}
+
+// newClient creates a Snowflake SQL API client for the given account URL and token.
+func newClient(baseURL, token, tokenType string) *restapi.Client {
+	header := http.Header{}
+	header.Set("Accept", "application/json")
+	header.Set("Authorization", "Bearer "+token)
+	header.Set("X-Snowflake-Authorization-Token-Type", tokenType)
+	return restapi.NewClient(
+		restapi.Config{API: "snowflake", BaseURL: baseURL, Header: header, ErrorMessage: errorMessage},
+	)
+}
+
+// contextClient builds a Snowflake SQL API client from the token, token_type, and api_url module
+// inputs. When no token input is provided, the SNOWFLAKE_TOKEN environment variable is used.
+func contextClient(ctx blackstart.ModuleContext) (*restapi.Client, error) {
+	token, apiURL, err := credentials.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if apiURL == "" {
+		return nil, fmt.Errorf("missing required input %s", inputAPIURL)
+	}
+	tokenType, err := blackstart.ContextInputAs[string](ctx, inputTokenType, false)
+	if err != nil {
+		return nil, err
+	}
+	if tokenType == "" {
+		tokenType = tokenTypeProgrammatic
+	}
+	if _, ok := tokenTypes[tokenType]; !ok {
+		return nil, fmt.Errorf("input '%s' has invalid value '%s'", inputTokenType, tokenType)
+	}
+	return newClient(apiURL, token, tokenType), nil
+}
+
+// statementRequest is the body of a request that runs a statement.
+type statementRequest struct {
+	Statement string `json:"statement"`
+	Timeout   int    `json:"timeout"`
+}
+
+// statementResponse is the result of a statement. Values of the result set are returned as
+// strings, or null.
+type statementResponse struct {
+	Code               string `json:"code"`
+	Message            string `json:"message"`
+	StatementHandle    string `json:"statementHandle"`
+	StatementStatusURL string `json:"statementStatusUrl"`
+	ResultSetMetaData  struct {
+		RowType []struct {
+			Name string `json:"name"`
+		} `json:"rowType"`
+		PartitionInfo []struct{} `json:"partitionInfo"`
+	} `json:"resultSetMetaData"`
+	Data [][]*string `json:"data"`
+}
+
+// row is a row of a result set by column name. Null values are empty strings.
+type row map[string]string
+
+// query runs a statement and returns the rows of its result set. Statements that are still running
+// when the request completes are polled until they finish, and results with several partitions are
+// read in full.
+func query(ctx context.Context, c *restapi.Client, statement string) ([]row, error) {
+	var result statementResponse
+	body := statementRequest{Statement: statement, Timeout: statementTimeout}
+	if err := c.Do(ctx, http.MethodPost, statementsPath, body, &result); err != nil {
+		return nil, err
+	}
+	for result.Code == codeAsyncExecution {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+		path := result.StatementStatusURL
+		result = statementResponse{}
+		if err := c.Do(ctx, http.MethodGet, path, nil, &result); err != nil {
+			return nil, err
+		}
+	}
+
+	data := result.Data
+	for i := 1; i < len(result.ResultSetMetaData.PartitionInfo); i++ {
+		var partition statementResponse
+		path := fmt.Sprintf("%s/%s?partition=%d", statementsPath, result.StatementHandle, i)
+		if err := c.Do(ctx, http.MethodGet, path, nil, &partition); err != nil {
+			return nil, err
+		}
+		data = append(data, partition.Data...)
+	}
+
+	rows := make([]row, 0, len(data))
+	for _, values := range data {
+		r := row{}
+		for i, column := range result.ResultSetMetaData.RowType {
+			if i < len(values) && values[i] != nil {
+				r[strings.ToLower(column.Name)] = *values[i]
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// execute runs a statement that does not return rows.
+func execute(ctx context.Context, c *restapi.Client, statement string) error {
+	_, err := query(ctx, c, statement)
+	return err
+}
+
+// identifier returns a quoted identifier. Quoted identifiers are case-sensitive.
+func identifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// literal returns a string literal.
+func literal(value string) string {
+	return "'" + strings.ReplaceAll(strings.ReplaceAll(value, `\`, `\\`), "'", "''") + "'"
+}
+
+// showObject returns the row of the object of a kind with the name, such as the database returned
+// by `SHOW DATABASES`, or nil when the object does not exist.
+func showObject(ctx context.Context, c *restapi.Client, kind, name string) (row, error) {
+	rows, err := query(ctx, c, fmt.Sprintf("SHOW %s LIKE %s", kind, literal(name)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to show %s: %w", strings.ToLower(kind), err)
+	}
+	// LIKE matches without case and with wildcards, so rows are compared with the name.
+	for _, r := range rows {
+		if r["name"] == name {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// usageRoles returns the roles granted the USAGE privilege on an object, such as a database.
+func usageRoles(ctx context.Context, c *restapi.Client, objectType, name string) (map[string]bool, error) {
+	rows, err := query(ctx, c, fmt.Sprintf("SHOW GRANTS ON %s %s", objectType, identifier(name)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to show grants on %s %s: %w", strings.ToLower(objectType), name, err)
+	}
+	roles := map[string]bool{}
+	for _, r := range rows {
+		if r["privilege"] == "USAGE" && r["granted_to"] == "ROLE" {
+			roles[r["grantee_name"]] = true
+		}
+	}
+	return roles, nil
+}
+
+// missingNames returns the desired users or roles that are not granted.
+func missingNames(granted map[string]bool, desired []string) []string {
+	var missing []string
+	for _, role := range desired {
+		if !granted[role] {
+			missing = append(missing, role)
+		}
+	}
+	return missing
+}
+
+// grantUsage grants the USAGE privilege on an object to the roles that are not granted it.
+func grantUsage(ctx context.Context, c *restapi.Client, objectType, name string, roles []string) error {
+	granted, err := usageRoles(ctx, c, objectType, name)
+	if err != nil {
+		return err
+	}
+	for _, role := range missingNames(granted, roles) {
+		statement := fmt.Sprintf("GRANT USAGE ON %s %s TO ROLE %s", objectType, identifier(name), identifier(role))
+		if err = execute(ctx, c, statement); err != nil {
+			return fmt.Errorf(
+				"failed to grant usage on %s %s to role %s: %w", strings.ToLower(objectType), name, role, err,
+			)
+		}
+	}
+	return nil
+}
+
+// contextComment returns the optional comment input, or nil when the comment is not managed.
+func contextComment(ctx blackstart.ModuleContext) (*string, error) {
+	input, err := ctx.Input(inputComment)
+	if err != nil || input.Any() == nil {
+		return nil, nil
+	}
+	value, err := blackstart.InputAs[string](input, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid input %s: %w", inputComment, err)
+	}
+	return &value, nil
+}
+
+// sameComment reports whether the current comment matches the desired comment. The comment is only
+// compared when it is set.
+func sameComment(current string, desired *string) bool {
+	return desired == nil || current == *desired
+}
+
+// commentProperty returns the COMMENT property of a CREATE or ALTER statement, or an empty string
+// when the comment is not managed.
+func commentProperty(comment *string) string {
+	if comment == nil {
+		return ""
+	}
+	return " COMMENT = " + literal(*comment)
+}
@@ -0,0 +1,277 @@
+package snowflake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+var (
+	showPattern        = regexp.MustCompile(`^SHOW (DATABASES|WAREHOUSES|ROLES) LIKE '(.*)'$`)
+	createPattern      = regexp.MustCompile(`^CREATE (DATABASE|WAREHOUSE|ROLE) IF NOT EXISTS "(.*?)"(.*)$`)
+	alterPattern       = regexp.MustCompile(`^ALTER (DATABASE|WAREHOUSE|ROLE) "(.*?)" SET(.*)$`)
+	dropPattern        = regexp.MustCompile(`^DROP (DATABASE|WAREHOUSE|ROLE) IF EXISTS "(.*?)"$`)
+	showGrantsPattern  = regexp.MustCompile(`^SHOW GRANTS ON (DATABASE|WAREHOUSE) "(.*?)"$`)
+	showGrantsOfRole   = regexp.MustCompile(`^SHOW GRANTS OF ROLE "(.*?)"$`)
+	grantUsagePattern  = regexp.MustCompile(`^GRANT USAGE ON (DATABASE|WAREHOUSE) "(.*?)" TO ROLE "(.*?)"$`)
+	grantRolePattern   = regexp.MustCompile(`^GRANT ROLE "(.*?)" TO (USER|ROLE) "(.*?)"$`)
+	propertyPattern    = regexp.MustCompile(`(\w+) = ('(?:[^']|'')*'|\S+)`)
+	objectKindsByShown = map[string]string{"DATABASES": "DATABASE", "WAREHOUSES": "WAREHOUSE", "ROLES": "ROLE"}
+)
+
+// fakeGrant is a privilege or role granted to a user or role.
+type fakeGrant struct {
+	privilege   string
+	objectType  string
+	objectName  string
+	grantedTo   string
+	granteeName string
+}
+
+// fakeSnowflake implements the Snowflake SQL API endpoint that runs statements. It runs the
+// statements of the Snowflake modules against databases, warehouses, and roles stored in memory by
+// object type and name, with their properties in the columns shown by `SHOW`.
+type fakeSnowflake struct {
+	server     *httptest.Server
+	objects    map[string]map[string]map[string]string
+	grants     []fakeGrant
+	statements []string
+
+	// async is the number of statements that respond as still running before their result.
+	async int
+	mu    sync.Mutex
+}
+
+// newFakeSnowflake starts a fake Snowflake SQL API server.
+func newFakeSnowflake(t *testing.T) *fakeSnowflake {
+	t.Helper()
+	f := &fakeSnowflake{
+		objects: map[string]map[string]map[string]string{"DATABASE": {}, "WAREHOUSE": {}, "ROLE": {}},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.server.Close)
+
+	interval := pollInterval
+	pollInterval = 0
+	t.Cleanup(func() { pollInterval = interval })
+	return f
+}
+
+// result writes a statement result with the columns and rows.
+func (f *fakeSnowflake) result(w http.ResponseWriter, columns []string, rows [][]string) {
+	response := map[string]any{"code": "090001", "statementHandle": "handle"}
+	var rowType []map[string]string
+	for _, column := range columns {
+		rowType = append(rowType, map[string]string{"name": column})
+	}
+	response["resultSetMetaData"] = map[string]any{"rowType": rowType, "partitionInfo": []any{map[string]any{}}}
+	if rows == nil {
+		rows = [][]string{}
+	}
+	response["data"] = rows
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func (f *fakeSnowflake) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" ||
+		r.Header.Get("X-Snowflake-Authorization-Token-Type") != tokenTypeProgrammatic {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":"390303","message":"Invalid OAuth access token."}`))
+		return
+	}
+
+	if r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, statementsPath+"/") {
+		// The status of a finished statement is its result, which is the status message for the
+		// statements that run asynchronously in tests.
+		f.result(w, []string{"status"}, [][]string{{"Statement executed successfully."}})
+		return
+	}
+
+	var body statementRequest
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	statement := body.Statement
+	f.statements = append(f.statements, statement)
+
+	if f.async > 0 {
+		f.async--
+		w.WriteHeader(http.StatusAccepted)
+		_ = json.NewEncoder(w).Encode(
+			map[string]string{
+				"code":               codeAsyncExecution,
+				"statementHandle":    "handle",
+				"statementStatusUrl": statementsPath + "/handle",
+			},
+		)
+		f.run(statement)
+		return
+	}
+
+	if columns, rows, err := f.run(statement); err != nil {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_ = json.NewEncoder(w).Encode(map[string]string{"code": "002003", "message": err.Error()})
+	} else {
+		f.result(w, columns, rows)
+	}
+}
+
+// run runs a statement and returns the columns and rows of its result.
+func (f *fakeSnowflake) run(statement string) ([]string, [][]string, error) {
+	if m := showPattern.FindStringSubmatch(statement); m != nil {
+		columns := []string{"name", "comment", "size", "auto_suspend"}
+		var rows [][]string
+		for name, object := range f.objects[objectKindsByShown[m[1]]] {
+			if strings.EqualFold(name, strings.ReplaceAll(m[2], "''", "'")) {
+				rows = append(rows, []string{name, object["comment"], object["size"], object["auto_suspend"]})
+			}
+		}
+		return columns, rows, nil
+	}
+	if m := createPattern.FindStringSubmatch(statement); m != nil {
+		if _, ok := f.objects[m[1]][m[2]]; !ok {
+			object := map[string]string{}
+			if m[1] == "WAREHOUSE" {
+				object["size"] = "X-Small"
+				object["auto_suspend"] = "600"
+			}
+			f.setProperties(object, m[3])
+			f.objects[m[1]][m[2]] = object
+		}
+		return nil, nil, nil
+	}
+	if m := alterPattern.FindStringSubmatch(statement); m != nil {
+		object, ok := f.objects[m[1]][m[2]]
+		if !ok {
+			return nil, nil, fmt.Errorf("%s '%s' does not exist", strings.ToLower(m[1]), m[2])
+		}
+		f.setProperties(object, m[3])
+		return nil, nil, nil
+	}
+	if m := dropPattern.FindStringSubmatch(statement); m != nil {
+		delete(f.objects[m[1]], m[2])
+		return nil, nil, nil
+	}
+	if m := showGrantsPattern.FindStringSubmatch(statement); m != nil {
+		var rows [][]string
+		for _, g := range f.grants {
+			if g.objectType == m[1] && g.objectName == m[2] {
+				rows = append(rows, []string{g.privilege, g.objectType, g.objectName, g.grantedTo, g.granteeName})
+			}
+		}
+		return []string{"privilege", "granted_on", "name", "granted_to", "grantee_name"}, rows, nil
+	}
+	if m := showGrantsOfRole.FindStringSubmatch(statement); m != nil {
+		var rows [][]string
+		for _, g := range f.grants {
+			if g.privilege == "ROLE" && g.objectName == m[1] {
+				rows = append(rows, []string{g.objectName, g.grantedTo, g.granteeName})
+			}
+		}
+		return []string{"role", "granted_to", "grantee_name"}, rows, nil
+	}
+	if m := grantUsagePattern.FindStringSubmatch(statement); m != nil {
+		f.grants = append(
+			f.grants, fakeGrant{
+				privilege: "USAGE", objectType: m[1], objectName: m[2], grantedTo: "ROLE", granteeName: m[3],
+			},
+		)
+		return nil, nil, nil
+	}
+	if m := grantRolePattern.FindStringSubmatch(statement); m != nil {
+		if _, ok := f.objects["ROLE"][m[1]]; !ok {
+			return nil, nil, fmt.Errorf("role '%s' does not exist", m[1])
+		}
+		f.grants = append(
+			f.grants, fakeGrant{privilege: "ROLE", objectName: m[1], grantedTo: m[2], granteeName: m[3]},
+		)
+		return nil, nil, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported statement: %s", statement)
+}
+
+// setProperties sets the properties of a CREATE or ALTER statement on an object, using the
+// columns and values shown by `SHOW`.
+func (f *fakeSnowflake) setProperties(object map[string]string, properties string) {
+	for _, m := range propertyPattern.FindAllStringSubmatch(properties, -1) {
+		switch m[1] {
+		case "COMMENT":
+			object["comment"] = strings.ReplaceAll(strings.Trim(m[2], "'"), "''", "'")
+		case "WAREHOUSE_SIZE":
+			object["size"] = warehouseSizes[m[2]]
+		case "AUTO_SUSPEND":
+			object["auto_suspend"] = m[2]
+		}
+	}
+}
+
+// fakeOperation returns an operation of a module targeting the fake server.
+func fakeOperation(f *fakeSnowflake, module string, inputs map[string]any) *blackstart.Operation {
+	return credentials.TestOperation(module, f.server.URL, "test-token", inputs)
+}
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+func TestQuery_AsyncStatement(t *testing.T) {
+	f := newFakeSnowflake(t)
+	f.async = 1
+	c := newClient(f.server.URL, "test-token", tokenTypeProgrammatic)
+
+	rows, err := query(context.Background(), c, "CREATE DATABASE IF NOT EXISTS \"ANALYTICS\"")
+	require.NoError(t, err)
+	require.Equal(t, []row{{"status": "Statement executed successfully."}}, rows)
+	require.Contains(t, f.objects["DATABASE"], "ANALYTICS")
+
+	_, err = query(context.Background(), c, "SELECT 1")
+	require.ErrorContains(t, err, "002003: unsupported statement")
+}
+
+func TestContextClient(t *testing.T) {
+	f := newFakeSnowflake(t)
+	op := fakeOperation(f, "snowflake_database", nil)
+	delete(op.Inputs, inputToken)
+
+	t.Setenv(tokenEnvVar, "")
+	_, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.ErrorContains(t, err, tokenEnvVar)
+
+	t.Setenv(tokenEnvVar, "test-token")
+	c, err := contextClient(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, "Bearer test-token", c.Header.Get("Authorization"))
+	require.Equal(t, tokenTypeProgrammatic, c.Header.Get("X-Snowflake-Authorization-Token-Type"))
+
+	op.Inputs[inputTokenType] = blackstart.NewInputFromValue(tokenTypeOAuth)
+	c, err = contextClient(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.Equal(t, tokenTypeOAuth, c.Header.Get("X-Snowflake-Authorization-Token-Type"))
+}
+
+func TestIdentifierAndLiteral(t *testing.T) {
+	require.Equal(t, `"my ""db"""`, identifier(`my "db"`))
+	require.Equal(t, `'it''s a \\ test'`, literal(`it's a \ test`))
+}
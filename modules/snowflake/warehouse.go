@@ -0,0 +1,280 @@
+package snowflake
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/util"
+)
+
+// warehouseSizes maps the sizes of the size input to the sizes shown by `SHOW WAREHOUSES`.
+var warehouseSizes = map[string]string{
+	"XSMALL":   "X-Small",
+	"SMALL":    "Small",
+	"MEDIUM":   "Medium",
+	"LARGE":    "Large",
+	"XLARGE":   "X-Large",
+	"XXLARGE":  "2X-Large",
+	"XXXLARGE": "3X-Large",
+	"X4LARGE":  "4X-Large",
+	"X5LARGE":  "5X-Large",
+	"X6LARGE":  "6X-Large",
+}
+
+func init() {
+	blackstart.RegisterModule("snowflake_warehouse", NewWarehouse)
+}
+
+var _ blackstart.Module = &warehouse{}
+
+// NewWarehouse creates a module that manages a Snowflake warehouse.
+func NewWarehouse() blackstart.Module {
+	return &warehouse{}
+}
+
+// warehouse implements the snowflake_warehouse module.
+type warehouse struct{}
+
+// desiredWarehouse is the desired state of a warehouse read from module inputs. The size and auto
+// suspend time are nil when they are not managed.
+type desiredWarehouse struct {
+	name        string
+	comment     *string
+	size        *string
+	autoSuspend *int
+	usageRoles  []string
+}
+
+func (m *warehouse) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   "snowflake_warehouse",
+		Name: "Snowflake warehouse",
+		Description: util.CleanString(
+			`
+Ensures a Snowflake warehouse exists with the size, auto suspend time, and comment, and grants the
+'''USAGE''' privilege on the warehouse to roles, so service users with the roles can run queries.
+New warehouses are created suspended. Statements are run with the Snowflake SQL API.
+
+Names are quoted identifiers, so they are case-sensitive. Roles created without quotes, such as the
+system roles, have uppercase names like '''SYSADMIN'''.
+
+**Notes**
+
+- Privileges granted to other roles are not changed.
+- When '''doesNotExist''' is set, the warehouse is dropped.
+`,
+		),
+		Requirements: []string{
+			"The role of the token must have the `CREATE WAREHOUSE` privilege on the account to create warehouses, and ownership of the warehouse to change or drop it, or to grant privileges on it.",
+			"If the `token` input is not set, the `SNOWFLAKE_TOKEN` environment variable is used.",
+		},
+		Inputs: moduleInputs(
+			map[string]blackstart.InputValue{
+				inputName: {
+					Description: "Name of the warehouse.",
+					Type:        reflect.TypeFor[string](),
+					Required:    true,
+				},
+				inputSize: {
+					Description: "Size of the warehouse, such as `XSMALL`, `SMALL`, `MEDIUM`, or `LARGE`. If not set, the size is not managed, and new warehouses are `XSMALL`.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputAutoSuspend: {
+					Description: "Seconds of inactivity after which the warehouse is suspended. `0` never suspends the warehouse. If not set, the auto suspend time is not managed.",
+					Type:        reflect.TypeFor[int](),
+					Required:    false,
+				},
+				inputComment: {
+					Description: "Comment of the warehouse. If not set, the comment is not managed.",
+					Type:        reflect.TypeFor[string](),
+					Required:    false,
+				},
+				inputUsageRoles: {
+					Description: "Roles granted the `USAGE` privilege on the warehouse.",
+					Type:        reflect.TypeFor[[]string](),
+					Required:    false,
+				},
+			},
+		),
+		Outputs: map[string]blackstart.OutputValue{
+			outputName: {
+				Description: "Name of the warehouse.",
+				Type:        reflect.TypeFor[string](),
+			},
+		},
+		Examples: map[string]string{
+			"Loading warehouse": `id: loading-warehouse
+module: snowflake_warehouse
+inputs:
+  api_url: https://myorg-myaccount.snowflakecomputing.com
+  name: LOADING
+  size: SMALL
+  auto_suspend: 60
+  usage_roles:
+    - LOADER`,
+		},
+	}
+}
+
+func (m *warehouse) Validate(op blackstart.Operation) error {
+	if err := validateInputs(op); err != nil {
+		return err
+	}
+	if input, ok := op.Inputs[inputSize]; ok && input.IsStatic() {
+		size, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputSize, err)
+		}
+		if _, ok = warehouseSizes[strings.ToUpper(size)]; !ok {
+			return fmt.Errorf("parameter %s has invalid value '%s'", inputSize, size)
+		}
+	}
+	if input, ok := op.Inputs[inputAutoSuspend]; ok && input.IsStatic() {
+		seconds, err := blackstart.InputAs[int](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputAutoSuspend, err)
+		}
+		if seconds < 0 {
+			return fmt.Errorf("parameter %s must not be negative", inputAutoSuspend)
+		}
+	}
+	return nil
+}
+
+func (m *warehouse) Check(ctx blackstart.ModuleContext) (bool, error) {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return false, err
+	}
+	desired, err := contextWarehouse(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	current, err := showObject(ctx, c, "WAREHOUSES", desired.name)
+	if err != nil {
+		return false, err
+	}
+	if ctx.DoesNotExist() {
+		return current == nil, nil
+	}
+	if ctx.Tainted() || current == nil || warehouseProperties(current, desired) != "" {
+		return false, nil
+	}
+	granted, err := usageRoles(ctx, c, "WAREHOUSE", desired.name)
+	if err != nil {
+		return false, err
+	}
+	if len(missingNames(granted, desired.usageRoles)) > 0 {
+		return false, nil
+	}
+	return true, ctx.Output(outputName, desired.name)
+}
+
+func (m *warehouse) Set(ctx blackstart.ModuleContext) error {
+	c, err := contextClient(ctx)
+	if err != nil {
+		return err
+	}
+	desired, err := contextWarehouse(ctx)
+	if err != nil {
+		return err
+	}
+
+	if ctx.DoesNotExist() {
+		if err = execute(ctx, c, "DROP WAREHOUSE IF EXISTS "+identifier(desired.name)); err != nil {
+			return fmt.Errorf("failed to drop warehouse %s: %w", desired.name, err)
+		}
+		return nil
+	}
+
+	current, err := showObject(ctx, c, "WAREHOUSES", desired.name)
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		statement := "CREATE WAREHOUSE IF NOT EXISTS " + identifier(desired.name) +
+			warehouseProperties(nil, desired) + " INITIALLY_SUSPENDED = TRUE"
+		if err = execute(ctx, c, statement); err != nil {
+			return fmt.Errorf("failed to create warehouse %s: %w", desired.name, err)
+		}
+	} else if properties := warehouseProperties(current, desired); properties != "" {
+		if err = execute(ctx, c, "ALTER WAREHOUSE "+identifier(desired.name)+" SET"+properties); err != nil {
+			return fmt.Errorf("failed to update warehouse %s: %w", desired.name, err)
+		}
+	}
+
+	if err = grantUsage(ctx, c, "WAREHOUSE", desired.name, desired.usageRoles); err != nil {
+		return err
+	}
+	return ctx.Output(outputName, desired.name)
+}
+
+// warehouseProperties returns the properties of a CREATE or ALTER WAREHOUSE statement for the
+// managed properties that differ from the current warehouse, or all managed properties when the
+// current warehouse is nil. It returns an empty string when the warehouse is in the desired state.
+func warehouseProperties(current row, desired desiredWarehouse) string {
+	var properties string
+	if desired.size != nil && (current == nil || current["size"] != warehouseSizes[*desired.size]) {
+		properties += " WAREHOUSE_SIZE = " + *desired.size
+	}
+	if desired.autoSuspend != nil &&
+		(current == nil || !sameAutoSuspend(current["auto_suspend"], *desired.autoSuspend)) {
+		properties += " AUTO_SUSPEND = " + strconv.Itoa(*desired.autoSuspend)
+	}
+	if current == nil || !sameComment(current["comment"], desired.comment) {
+		properties += commentProperty(desired.comment)
+	}
+	return properties
+}
+
+// sameAutoSuspend reports whether the auto suspend time shown for a warehouse matches the desired
+// seconds. Warehouses that are never suspended show `0` or null.
+func sameAutoSuspend(current string, desired int) bool {
+	if current == "" || current == "null" {
+		current = "0"
+	}
+	return current == strconv.Itoa(desired)
+}
+
+// contextWarehouse reads the desired warehouse from module inputs.
+func contextWarehouse(ctx blackstart.ModuleContext) (desiredWarehouse, error) {
+	var w desiredWarehouse
+	var err error
+	if w.name, err = blackstart.ContextInputAs[string](ctx, inputName, true); err != nil {
+		return desiredWarehouse{}, err
+	}
+	if w.comment, err = contextComment(ctx); err != nil {
+		return desiredWarehouse{}, err
+	}
+	if w.usageRoles, err = blackstart.ContextInputAs[[]string](ctx, inputUsageRoles, false); err != nil {
+		return desiredWarehouse{}, err
+	}
+
+	if input, iErr := ctx.Input(inputSize); iErr == nil && input.Any() != nil {
+		size, sErr := blackstart.InputAs[string](input, false)
+		if sErr != nil {
+			return desiredWarehouse{}, fmt.Errorf("invalid input %s: %w", inputSize, sErr)
+		}
+		size = strings.ToUpper(size)
+		if _, ok := warehouseSizes[size]; !ok {
+			return desiredWarehouse{}, fmt.Errorf("input '%s' has invalid value '%s'", inputSize, size)
+		}
+		w.size = &size
+	}
+	if input, iErr := ctx.Input(inputAutoSuspend); iErr == nil && input.Any() != nil {
+		seconds, sErr := blackstart.InputAs[int](input, false)
+		if sErr != nil {
+			return desiredWarehouse{}, fmt.Errorf("invalid input %s: %w", inputAutoSuspend, sErr)
+		}
+		if seconds < 0 {
+			return desiredWarehouse{}, fmt.Errorf("input '%s' must not be negative", inputAutoSuspend)
+		}
+		w.autoSuspend = &seconds
+	}
+	return w, nil
+}
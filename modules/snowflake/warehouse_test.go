@@ -0,0 +1,74 @@
+package snowflake
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestWarehouse_Validate(t *testing.T) {
+	m := NewWarehouse()
+	f := newFakeSnowflake(t)
+
+	op := fakeOperation(f, "snowflake_warehouse", map[string]any{inputName: "LOADING", inputSize: "small"})
+	require.NoError(t, m.Validate(*op))
+
+	op = fakeOperation(f, "snowflake_warehouse", map[string]any{inputName: "LOADING", inputSize: "HUGE"})
+	require.ErrorContains(t, m.Validate(*op), "parameter size has invalid value 'HUGE'")
+
+	op = fakeOperation(f, "snowflake_warehouse", map[string]any{inputName: "LOADING", inputAutoSuspend: -1})
+	require.ErrorContains(t, m.Validate(*op), "must not be negative")
+}
+
+func TestWarehouse_CreateUpdateDelete(t *testing.T) {
+	f := newFakeSnowflake(t)
+	m := NewWarehouse()
+	inputs := map[string]any{inputName: "LOADING", inputUsageRoles: []string{"LOADER"}}
+	op := fakeOperation(f, "snowflake_warehouse", inputs)
+	require.NoError(t, m.Validate(*op))
+
+	ok, err := m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	ctx := &capturingModuleContext{ModuleContext: blackstart.OpContext(context.Background(), op)}
+	require.NoError(t, m.Set(ctx))
+	require.Equal(t, "LOADING", ctx.outputs[outputName])
+	require.Contains(t, f.statements, `CREATE WAREHOUSE IF NOT EXISTS "LOADING" INITIALLY_SUSPENDED = TRUE`)
+	require.Contains(t, f.statements, `GRANT USAGE ON WAREHOUSE "LOADING" TO ROLE "LOADER"`)
+
+	// The size and auto suspend time are not managed when they are not set.
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	inputs[inputSize] = "small"
+	inputs[inputAutoSuspend] = 60
+	op = fakeOperation(f, "snowflake_warehouse", inputs)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Contains(t, f.statements, `ALTER WAREHOUSE "LOADING" SET WAREHOUSE_SIZE = SMALL AUTO_SUSPEND = 60`)
+	require.Equal(t, "Small", f.objects["WAREHOUSE"]["LOADING"]["size"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// Warehouses that are never suspended show a null auto suspend time.
+	inputs[inputAutoSuspend] = 0
+	f.objects["WAREHOUSE"]["LOADING"]["auto_suspend"] = ""
+	op = fakeOperation(f, "snowflake_warehouse", inputs)
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	op.DoesNotExist = true
+	require.NoError(t, m.Set(blackstart.OpContext(context.Background(), op)))
+	require.Empty(t, f.objects["WAREHOUSE"])
+	ok, err = m.Check(blackstart.OpContext(context.Background(), op))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
@@ -0,0 +1,466 @@
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pezops/blackstart"
+	blackstartutil "github.com/pezops/blackstart/util"
+)
+
+const (
+	moduleIDParse = "util_parse"
+	inputValue    = "value"
+	inputEncoding = "encoding"
+	inputFormat   = "format"
+	inputPath     = "path"
+	inputPaths    = "paths"
+	outputDecoded = "decoded"
+	outputValue   = "value"
+	outputValues  = "values"
+
+	encodingText   = "text"
+	encodingBase64 = "base64"
+
+	formatText = "text"
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+var (
+	parseEncodings = []string{encodingText, encodingBase64}
+	parseFormats   = []string{formatText, formatJSON, formatYAML}
+)
+
+func init() {
+	blackstart.RegisterModule(moduleIDParse, NewParse)
+}
+
+// NewParse creates a module that decodes a string and extracts fields of JSON or YAML documents.
+func NewParse() blackstart.Module {
+	return &parseModule{}
+}
+
+type parseModule struct{}
+
+func (m *parseModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   moduleIDParse,
+		Name: "Parse",
+		Description: blackstartutil.CleanString(
+			`
+Decodes a string and extracts fields of a JSON or YAML document, bridging modules whose outputs and
+inputs do not line up. For example, the '''password''' field of a JSON secret payload read by a lookup
+module can be passed to a module that expects the password alone.
+
+The value is first decoded with '''encoding''', then parsed with '''format'''. Fields are selected
+with paths such as '''.password''', '''.hosts[0].name''', or '''.["app.kubernetes.io/name"]'''. String
+fields are output as is, and other fields with their JSON encoding.
+
+**Notes**
+
+- When '''value''' is sensitive, such as a value read from a Secret, all outputs are sensitive.
+- '''doesNotExist''' is not supported.
+`,
+		),
+		Inputs: map[string]blackstart.InputValue{
+			inputValue: {
+				Description: "String to decode and parse.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputEncoding: {
+				Description: "Encoding of the value: `text` or `base64`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     encodingText,
+			},
+			inputFormat: {
+				Description: "Format of the decoded value: `text`, `json`, or `yaml`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     formatText,
+			},
+			inputPath: {
+				Description: "Path of the field to read into the `value` output, such as `.password`. Requires `json` or `yaml` format.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputPaths: {
+				Description: "Paths of the fields to read into the `values` output, by output key. Requires `json` or `yaml` format.",
+				Type:        reflect.TypeFor[map[string]any](),
+				Required:    false,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputDecoded: {
+				Description: "Value decoded with `encoding`.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputValue: {
+				Description: "Field at `path`, or the decoded value when `path` is not set.",
+				Type:        reflect.TypeFor[string](),
+			},
+			outputValues: {
+				Description: "Fields at `paths`, by output key.",
+				Type:        reflect.TypeFor[map[string]string](),
+			},
+		},
+		Examples: map[string]string{
+			"Extract a password from a JSON secret payload": `operations:
+  - id: db-secret
+    module: kubernetes_secret_read
+    inputs:
+      name: db-credentials
+      key: credentials.json
+
+  - id: db-password
+    module: util_parse
+    inputs:
+      value:
+        fromDependency:
+          id: db-secret
+          output: value
+      format: json
+      path: .password`,
+			"Decode a base64 YAML document": `id: kubeconfig-cluster
+module: util_parse
+inputs:
+  value: Y2x1c3RlcnM6CiAgLSBuYW1lOiBwcm9kCg==
+  encoding: base64
+  format: yaml
+  paths:
+    cluster: .clusters[0].name`,
+		},
+		OutputsOnly: true,
+	}
+}
+
+func (m *parseModule) Validate(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported by %s", moduleIDParse)
+	}
+	if _, ok := op.Inputs[inputValue]; !ok {
+		return fmt.Errorf("missing required parameter: %s", inputValue)
+	}
+
+	for key, allowed := range map[string][]string{inputEncoding: parseEncodings, inputFormat: parseFormats} {
+		if input, ok := op.Inputs[key]; ok && input.IsStatic() {
+			value, err := blackstart.InputAs[string](input, false)
+			if err != nil {
+				return fmt.Errorf("parameter %s is invalid: %w", key, err)
+			}
+			if !slices.Contains(allowed, value) {
+				return fmt.Errorf("parameter %s must be one of %s", key, strings.Join(allowed, ", "))
+			}
+		}
+	}
+
+	paths := make(map[string]string)
+	if input, ok := op.Inputs[inputPath]; ok && input.IsStatic() {
+		path, err := blackstart.InputAs[string](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputPath, err)
+		}
+		paths[inputPath] = path
+	}
+	if input, ok := op.Inputs[inputPaths]; ok && input.IsStatic() {
+		raw, err := blackstart.InputAs[map[string]any](input, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputPaths, err)
+		}
+		values, err := stringPaths(raw)
+		if err != nil {
+			return err
+		}
+		for key, path := range values {
+			paths[inputPaths+"."+key] = path
+		}
+	}
+	for key, path := range paths {
+		if _, err := parsePath(path); err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", key, err)
+		}
+	}
+
+	if input, ok := op.Inputs[inputFormat]; (!ok || input.IsStatic()) && len(paths) > 0 {
+		format := formatText
+		if ok {
+			format, _ = blackstart.InputAs[string](input, false)
+		}
+		if format == formatText {
+			return fmt.Errorf("parameters %s and %s require format %s or %s", inputPath, inputPaths, formatJSON, formatYAML)
+		}
+	}
+	return nil
+}
+
+func (m *parseModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.DoesNotExist() {
+		return false, fmt.Errorf("doesNotExist is not supported by %s", moduleIDParse)
+	}
+	if ctx.Tainted() {
+		return false, nil
+	}
+	return true, outputParsed(ctx)
+}
+
+func (m *parseModule) Set(ctx blackstart.ModuleContext) error {
+	if ctx.DoesNotExist() {
+		return fmt.Errorf("doesNotExist is not supported by %s", moduleIDParse)
+	}
+	return outputParsed(ctx)
+}
+
+// outputParsed decodes and parses the value input and emits the outputs of the module. Parsing has
+// no side effects, so the check and the set of an operation emit the same outputs.
+func outputParsed(ctx blackstart.ModuleContext) error {
+	value, err := blackstart.ContextInputAs[string](ctx, inputValue, true)
+	if err != nil {
+		return err
+	}
+	encoding, err := blackstart.ContextInputAs[string](ctx, inputEncoding, false)
+	if err != nil {
+		return err
+	}
+	format, err := blackstart.ContextInputAs[string](ctx, inputFormat, false)
+	if err != nil {
+		return err
+	}
+	path, err := blackstart.ContextInputAs[string](ctx, inputPath, false)
+	if err != nil {
+		return err
+	}
+	rawPaths, err := blackstart.ContextInputAs[map[string]any](ctx, inputPaths, false)
+	if err != nil {
+		return err
+	}
+	paths, err := stringPaths(rawPaths)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := decodeValue(value, encoding)
+	if err != nil {
+		return err
+	}
+	if err = ctx.Output(outputDecoded, decoded); err != nil {
+		return err
+	}
+
+	if format == "" || format == formatText {
+		if path != "" || len(paths) > 0 {
+			return fmt.Errorf("parameters %s and %s require format %s or %s", inputPath, inputPaths, formatJSON, formatYAML)
+		}
+		return ctx.Output(outputValue, decoded)
+	}
+
+	doc, err := parseDocument(decoded, format)
+	if err != nil {
+		return err
+	}
+	field := decoded
+	if path != "" {
+		if field, err = selectField(doc, path); err != nil {
+			return err
+		}
+	}
+	if err = ctx.Output(outputValue, field); err != nil {
+		return err
+	}
+	values := make(map[string]string, len(paths))
+	for key, p := range paths {
+		if values[key], err = selectField(doc, p); err != nil {
+			return fmt.Errorf("path %s of %s: %w", key, inputPaths, err)
+		}
+	}
+	return ctx.Output(outputValues, values)
+}
+
+// stringPaths returns the paths input of the module by output key. Each path must be a string.
+func stringPaths(raw map[string]any) (map[string]string, error) {
+	paths := make(map[string]string, len(raw))
+	for key, value := range raw {
+		path, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("parameter %s has a non-string path for %s", inputPaths, key)
+		}
+		paths[key] = path
+	}
+	return paths, nil
+}
+
+// decodeValue decodes a value with the encoding. Standard and URL-safe base64, with or without
+// padding, are accepted.
+func decodeValue(value, encoding string) (string, error) {
+	switch encoding {
+	case "", encodingText:
+		return value, nil
+	case encodingBase64:
+		trimmed := strings.TrimSpace(value)
+		for _, enc := range []*base64.Encoding{
+			base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+		} {
+			if data, err := enc.DecodeString(trimmed); err == nil {
+				return string(data), nil
+			}
+		}
+		return "", fmt.Errorf("value is not valid base64")
+	default:
+		return "", fmt.Errorf("unsupported encoding %q", encoding)
+	}
+}
+
+// parseDocument parses a JSON or YAML document. JSON numbers keep their original formatting.
+func parseDocument(value, format string) (any, error) {
+	var doc any
+	switch format {
+	case formatJSON:
+		decoder := json.NewDecoder(strings.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("value is not valid JSON: %w", err)
+		}
+		if decoder.More() {
+			return nil, fmt.Errorf("value is not valid JSON: unexpected data after the document")
+		}
+	case formatYAML:
+		if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+			return nil, fmt.Errorf("value is not valid YAML: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+	return doc, nil
+}
+
+// pathSegment is a segment of a field path, either a map key or a list index.
+type pathSegment struct {
+	key   string
+	index int
+	isKey bool
+}
+
+// parsePath parses a field path, such as `.hosts[0].name` or `.["app.kubernetes.io/name"]`. An
+// empty path or `.` selects the whole document.
+func parsePath(path string) ([]pathSegment, error) {
+	var segments []pathSegment
+	rest := strings.TrimSpace(path)
+	if rest == "." {
+		return nil, nil
+	}
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `.[`) || strings.HasPrefix(rest, "["):
+			rest = strings.TrimPrefix(strings.TrimPrefix(rest, "."), "[")
+			end := strings.Index(rest, "]")
+			if strings.HasPrefix(rest, `"`) {
+				closing := strings.Index(rest[1:], `"`)
+				if closing < 0 || !strings.HasPrefix(rest[closing+2:], "]") {
+					return nil, fmt.Errorf("path %q has an unterminated key", path)
+				}
+				segments = append(segments, pathSegment{key: rest[1 : closing+1], isKey: true})
+				rest = rest[closing+3:]
+				continue
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("path %q has an unterminated index", path)
+			}
+			index, err := strconv.Atoi(rest[:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("path %q has an invalid index %q", path, rest[:end])
+			}
+			segments = append(segments, pathSegment{index: index})
+			rest = rest[end+1:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+			segments = append(segments, pathSegment{key: rest[:end], isKey: true})
+			rest = rest[end:]
+		default:
+			return nil, fmt.Errorf("path %q must start with '.'", path)
+		}
+	}
+	return segments, nil
+}
+
+// selectField returns the field of a document at the path, as a string. String fields are returned
+// as is, and other fields with their JSON encoding.
+func selectField(doc any, path string) (string, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return "", err
+	}
+	current := doc
+	for i, segment := range segments {
+		switch node := current.(type) {
+		case map[string]any:
+			if !segment.isKey {
+				return "", fmt.Errorf("path %q: %s is an object, not a list", path, fieldPath(segments[:i]))
+			}
+			value, ok := node[segment.key]
+			if !ok {
+				return "", fmt.Errorf("path %q: field %s not found", path, fieldPath(segments[:i+1]))
+			}
+			current = value
+		case []any:
+			if segment.isKey {
+				return "", fmt.Errorf("path %q: %s is a list, not an object", path, fieldPath(segments[:i]))
+			}
+			if segment.index >= len(node) {
+				return "", fmt.Errorf("path %q: %s has %d items", path, fieldPath(segments[:i]), len(node))
+			}
+			current = node[segment.index]
+		default:
+			return "", fmt.Errorf("path %q: %s is not an object or a list", path, fieldPath(segments[:i]))
+		}
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err = encoder.Encode(current); err != nil {
+		return "", fmt.Errorf("path %q: %w", path, err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// fieldPath formats the segments of a path for errors.
+func fieldPath(segments []pathSegment) string {
+	if len(segments) == 0 {
+		return "the document"
+	}
+	var b strings.Builder
+	for _, segment := range segments {
+		if segment.isKey {
+			if strings.ContainsAny(segment.key, `.[]"`) {
+				fmt.Fprintf(&b, ".[%q]", segment.key)
+			} else {
+				b.WriteString("." + segment.key)
+			}
+			continue
+		}
+		fmt.Fprintf(&b, "[%d]", segment.index)
+	}
+	return b.String()
+}
@@ -0,0 +1,238 @@
+package util_test
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/util"
+)
+
+// parseOperation returns a util_parse operation with the inputs.
+func parseOperation(inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{Id: "parse", Module: "util_parse", Inputs: map[string]blackstart.Input{}}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+func TestParseModule_Validate(t *testing.T) {
+	m := util.NewParse()
+
+	tests := []struct {
+		name    string
+		inputs  map[string]any
+		wantErr string
+	}{
+		{
+			name:   "json path",
+			inputs: map[string]any{"value": "{}", "format": "json", "path": ".password"},
+		},
+		{
+			name: "yaml paths",
+			inputs: map[string]any{
+				"value": "a: b", "format": "yaml", "paths": map[string]any{"host": `.["app.example.com/host"]`},
+			},
+		},
+		{
+			name:   "base64 text",
+			inputs: map[string]any{"value": "YQ==", "encoding": "base64"},
+		},
+		{
+			name:    "missing value",
+			inputs:  map[string]any{"format": "json"},
+			wantErr: "missing required parameter: value",
+		},
+		{
+			name:    "unsupported format",
+			inputs:  map[string]any{"value": "{}", "format": "toml"},
+			wantErr: "parameter format must be one of text, json, yaml",
+		},
+		{
+			name:    "unsupported encoding",
+			inputs:  map[string]any{"value": "{}", "encoding": "hex"},
+			wantErr: "parameter encoding must be one of text, base64",
+		},
+		{
+			name:    "invalid path",
+			inputs:  map[string]any{"value": "{}", "format": "json", "path": "password"},
+			wantErr: `parameter path is invalid: path "password" must start with '.'`,
+		},
+		{
+			name:    "path of text",
+			inputs:  map[string]any{"value": "{}", "path": ".password"},
+			wantErr: "parameters path and paths require format json or yaml",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := m.Validate(*parseOperation(test.inputs))
+				if test.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				assert.EqualError(t, err, test.wantErr)
+			},
+		)
+	}
+
+	op := parseOperation(map[string]any{"value": "{}"})
+	op.DoesNotExist = true
+	assert.EqualError(t, m.Validate(*op), "doesNotExist is not supported by util_parse")
+}
+
+func TestParseModule_Outputs(t *testing.T) {
+	payload := `{"password": "s3cr3t", "port": 5432, "hosts": [{"name": "db-0"}, {"name": "db-1"}],
+"labels": {"app.kubernetes.io/name": "db"}, "options": {"ssl": true}}`
+
+	tests := []struct {
+		name        string
+		inputs      map[string]any
+		wantValue   string
+		wantValues  map[string]string
+		wantDecoded string
+	}{
+		{
+			name:        "json path",
+			inputs:      map[string]any{"value": payload, "format": "json", "path": ".password"},
+			wantValue:   "s3cr3t",
+			wantValues:  map[string]string{},
+			wantDecoded: payload,
+		},
+		{
+			name: "base64 json paths",
+			inputs: map[string]any{
+				"value":    base64.StdEncoding.EncodeToString([]byte(payload)),
+				"encoding": "base64",
+				"format":   "json",
+				"paths": map[string]any{
+					"port":    ".port",
+					"host":    ".hosts[1].name",
+					"app":     `.labels["app.kubernetes.io/name"]`,
+					"options": ".options",
+				},
+			},
+			wantValue: payload,
+			wantValues: map[string]string{
+				"port": "5432", "host": "db-1", "app": "db", "options": `{"ssl":true}`,
+			},
+			wantDecoded: payload,
+		},
+		{
+			name:        "yaml path",
+			inputs:      map[string]any{"value": "clusters:\n  - name: prod\n", "format": "yaml", "path": ".clusters[0]"},
+			wantValue:   `{"name":"prod"}`,
+			wantValues:  map[string]string{},
+			wantDecoded: "clusters:\n  - name: prod\n",
+		},
+		{
+			name:        "base64 text",
+			inputs:      map[string]any{"value": "aGVsbG8", "encoding": "base64"},
+			wantValue:   "hello",
+			wantDecoded: "hello",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				m := util.NewParse()
+				ctx := &capturingModuleContext{
+					ModuleContext: blackstart.OpContext(context.Background(), parseOperation(test.inputs)),
+				}
+				ok, err := m.Check(ctx)
+				require.NoError(t, err)
+				assert.True(t, ok)
+				assert.Equal(t, test.wantDecoded, ctx.outputs["decoded"])
+				assert.Equal(t, test.wantValue, ctx.outputs["value"])
+				if test.wantValues != nil {
+					assert.Equal(t, test.wantValues, ctx.outputs["values"])
+				}
+			},
+		)
+	}
+}
+
+func TestParseModule_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		inputs  map[string]any
+		wantErr string
+	}{
+		{
+			name:    "invalid base64",
+			inputs:  map[string]any{"value": "not base64!", "encoding": "base64"},
+			wantErr: "value is not valid base64",
+		},
+		{
+			name:    "invalid json",
+			inputs:  map[string]any{"value": "{", "format": "json"},
+			wantErr: "value is not valid JSON",
+		},
+		{
+			name:    "trailing json",
+			inputs:  map[string]any{"value": "{} {}", "format": "json"},
+			wantErr: "unexpected data after the document",
+		},
+		{
+			name:    "missing field",
+			inputs:  map[string]any{"value": `{"a": {"b": 1}}`, "format": "json", "path": ".a.c"},
+			wantErr: `path ".a.c": field .a.c not found`,
+		},
+		{
+			name:    "index of object",
+			inputs:  map[string]any{"value": `{"a": {"b": 1}}`, "format": "json", "path": ".a[0]"},
+			wantErr: `path ".a[0]": .a is an object, not a list`,
+		},
+		{
+			name:    "index out of range",
+			inputs:  map[string]any{"value": `{"a": [1]}`, "format": "json", "path": ".a[2]"},
+			wantErr: `path ".a[2]": .a has 1 items`,
+		},
+		{
+			name:    "field of scalar",
+			inputs:  map[string]any{"value": `{"a": 1}`, "format": "json", "path": ".a.b"},
+			wantErr: `path ".a.b": .a is not an object or a list`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				err := util.NewParse().Set(blackstart.OpContext(context.Background(), parseOperation(test.inputs)))
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.wantErr)
+			},
+		)
+	}
+}
+
+func TestParseModule_SensitiveValue(t *testing.T) {
+	wf := blackstart.Workflow{
+		Name: "parse-sensitive",
+		Operations: []blackstart.Operation{
+			{
+				Id:     "parse",
+				Module: "util_parse",
+				Inputs: map[string]blackstart.Input{
+					"value":  blackstart.NewSensitiveInputFromValue(`{"password": "s3cr3t"}`),
+					"format": blackstart.NewInputFromValue("json"),
+					"path":   blackstart.NewInputFromValue(".password"),
+				},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	require.Len(t, res.Operations, 1)
+	assert.Equal(t, blackstart.MaskedValue, res.Operations[0].Outputs["value"])
+	assert.Equal(t, blackstart.MaskedValue, res.Operations[0].Outputs["decoded"])
+}
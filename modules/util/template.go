@@ -63,6 +63,8 @@ func (m *templateModule) Info() blackstart.ModuleInfo {
     inputs:
       template: 'blackstart-sa@{{ workflowOutput "identity" "project_id" }}.iam'`,
 		},
+		OutputsOnly:          true,
+		ReadsWorkflowOutputs: true,
 	}
 }
 
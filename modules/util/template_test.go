@@ -35,6 +35,10 @@ func (m *testValueEmitterModule) Info() blackstart.ModuleInfo {
 				Type:     reflect.TypeFor[string](),
 				Required: true,
 			},
+			"password": {
+				Type:      reflect.TypeFor[string](),
+				Sensitive: true,
+			},
 		},
 		Outputs: map[string]blackstart.OutputValue{
 			"username": {
@@ -43,6 +47,10 @@ func (m *testValueEmitterModule) Info() blackstart.ModuleInfo {
 			"project_id": {
 				Type: reflect.TypeFor[string](),
 			},
+			"password": {
+				Type:      reflect.TypeFor[string](),
+				Sensitive: true,
+			},
 		},
 	}
 }
@@ -60,9 +68,16 @@ func (m *testValueEmitterModule) Set(ctx blackstart.ModuleContext) error {
 	if err != nil {
 		return err
 	}
+	password, err := blackstart.ContextInputAs[string](ctx, "password", false)
+	if err != nil {
+		return err
+	}
 	if err := ctx.Output("username", username); err != nil {
 		return err
 	}
+	if err := ctx.Output("password", password); err != nil {
+		return err
+	}
 	return ctx.Output("project_id", projectID)
 }
 
@@ -142,6 +157,62 @@ func TestTemplateModule_RendersWorkflowOutputs(t *testing.T) {
 	require.NoError(t, result.Err)
 }
 
+func TestTemplateModule_SensitiveWorkflowOutputs(t *testing.T) {
+	wf := blackstart.Workflow{
+		Name: "util-template-sensitive-output",
+		Operations: []blackstart.Operation{
+			{
+				Id:     "producer",
+				Module: testEmitterModuleID,
+				Inputs: map[string]blackstart.Input{
+					"username":   blackstart.NewInputFromValue("app"),
+					"project_id": blackstart.NewInputFromValue("test-cr-249905"),
+					"password":   blackstart.NewInputFromValue("hunter2"),
+				},
+			},
+			{
+				Id:        "templater",
+				Module:    "util_template",
+				DependsOn: []string{"producer"},
+				Inputs: map[string]blackstart.Input{
+					"template": blackstart.NewInputFromValue(
+						`postgres://{{ workflowOutput "producer" "username" }}:{{ workflowOutput "producer" "password" }}@db`,
+					),
+				},
+			},
+			{
+				Id:        "assert",
+				Module:    testAssertModuleID,
+				DependsOn: []string{"templater"},
+				Inputs: map[string]blackstart.Input{
+					"value":    blackstart.NewInputFromDep("templater", "result"),
+					"expected": blackstart.NewInputFromValue("postgres://app:hunter2@db"),
+				},
+			},
+			{
+				Id:        "public",
+				Module:    "util_template",
+				DependsOn: []string{"producer"},
+				Inputs: map[string]blackstart.Input{
+					"template": blackstart.NewInputFromValue(`{{ workflowOutput "producer" "username" }}@db`),
+				},
+			},
+		},
+	}
+
+	result := wf.Run(context.Background())
+	require.NoError(t, result.Err)
+	recorded := make(map[string]blackstart.OperationResult, len(result.Operations))
+	for _, op := range result.Operations {
+		recorded[op.Id] = op
+	}
+	// The rendered template holds the password, so it is masked like the password output, and in
+	// the inputs of operations using it.
+	require.Equal(t, map[string]string{"result": blackstart.MaskedValue}, recorded["templater"].Outputs)
+	require.Equal(t, blackstart.MaskedValue, recorded["assert"].Inputs["value"])
+	require.Equal(t, map[string]string{"result": "app@db"}, recorded["public"].Outputs)
+}
+
 func TestTemplateModule_MissingWorkflowOutputFails(t *testing.T) {
 	wf := blackstart.Workflow{
 		Name: "util-template-missing-output",
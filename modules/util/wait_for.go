@@ -0,0 +1,421 @@
+package util
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pezops/blackstart"
+	blackstartutil "github.com/pezops/blackstart/util"
+)
+
+const (
+	moduleIDWaitFor = "util_wait_for"
+	inputHost       = "host"
+	inputPort       = "port"
+	inputCheck      = "check"
+	inputTimeout    = "timeout"
+	inputInterval   = "interval"
+	inputServerName = "server_name"
+	inputTLSVerify  = "tls_verify"
+	outputAddresses = "addresses"
+
+	checkTCP = "tcp"
+	checkDNS = "dns"
+	checkTLS = "tls"
+
+	defaultWaitTimeout  = 5 * time.Minute
+	defaultWaitInterval = 5 * time.Second
+
+	// maxProbeTimeout bounds a single probe, so an endpoint that does not respond is probed again
+	// on the next interval.
+	maxProbeTimeout = 10 * time.Second
+)
+
+var waitChecks = map[string]struct{}{checkTCP: {}, checkDNS: {}, checkTLS: {}}
+
+func init() {
+	blackstart.RegisterModule(moduleIDWaitFor, NewWaitFor)
+}
+
+// NewWaitFor creates a module that waits until a network endpoint is ready.
+func NewWaitFor() blackstart.Module {
+	return &waitForModule{}
+}
+
+type waitForModule struct{}
+
+// waitTarget is the endpoint and readiness check of a util_wait_for operation.
+type waitTarget struct {
+	host       string
+	port       int
+	check      string
+	timeout    time.Duration
+	interval   time.Duration
+	serverName string
+	tlsVerify  bool
+}
+
+// String returns the endpoint and check of the target for logs and errors.
+func (t waitTarget) String() string {
+	if t.check == checkDNS {
+		return fmt.Sprintf("dns %s", t.host)
+	}
+	return fmt.Sprintf("%s %s", t.check, net.JoinHostPort(t.host, strconv.Itoa(t.port)))
+}
+
+func (m *waitForModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id:   moduleIDWaitFor,
+		Name: "Wait For",
+		Description: blackstartutil.CleanString(
+			`
+Waits until a network endpoint is ready before dependent operations run, such as a database right
+after Private Service Access or a load balancer is provisioned. The endpoint is ready when its host
+resolves with '''dns''', when a TCP connection to the port succeeds with '''tcp''', or when a TLS
+handshake with the port succeeds with '''tls'''.
+
+The check of the operation probes the endpoint once, and the set waits for the endpoint, probing it
+every '''interval''' until it is ready. The operation fails when the endpoint is not ready before the
+'''timeout'''.
+
+**Notes**
+
+- Connections are made directly, without the outbound proxies of the runtime configuration.
+- TLS certificates are verified with the system CAs and the additional CAs of the runtime
+  configuration.
+- '''doesNotExist''' is not supported.
+`,
+		),
+		Requirements: []string{
+			"Blackstart must be able to resolve the host, and to connect to the port for the `tcp` and `tls` checks.",
+		},
+		Inputs: map[string]blackstart.InputValue{
+			inputHost: {
+				Description: "Host name or IP address of the endpoint.",
+				Type:        reflect.TypeFor[string](),
+				Required:    true,
+			},
+			inputPort: {
+				Description: "Port of the endpoint. Required for the `tcp` and `tls` checks.",
+				Type:        reflect.TypeFor[int](),
+				Required:    false,
+			},
+			inputCheck: {
+				Description: "Readiness check of the endpoint: `tcp`, `dns`, or `tls`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     checkTCP,
+			},
+			inputTimeout: {
+				Description: "Maximum time to wait for the endpoint, such as `10m`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     "5m",
+			},
+			inputInterval: {
+				Description: "Time between probes of the endpoint, such as `10s`.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+				Default:     "5s",
+			},
+			inputServerName: {
+				Description: "Server name of the TLS handshake. Defaults to the host.",
+				Type:        reflect.TypeFor[string](),
+				Required:    false,
+			},
+			inputTLSVerify: {
+				Description: "Verify the certificate of the endpoint in the TLS handshake.",
+				Type:        reflect.TypeFor[bool](),
+				Required:    false,
+				Default:     true,
+			},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			outputAddresses: {
+				Description: "IP addresses the host resolved to when the endpoint was ready.",
+				Type:        reflect.TypeFor[[]string](),
+			},
+		},
+		Examples: map[string]string{
+			"Wait for a Cloud SQL private IP": `operations:
+  - id: wait-for-db
+    module: util_wait_for
+    inputs:
+      host: 10.20.0.3
+      port: 5432
+      timeout: 10m
+  - id: connect-db
+    module: postgres_connection
+    dependsOn:
+      - wait-for-db
+    inputs:
+      host: 10.20.0.3
+      database: mydb
+      username: admin`,
+			"Wait for a DNS record": `id: wait-for-record
+module: util_wait_for
+inputs:
+  host: api.internal.example.com
+  check: dns`,
+			"Wait for a TLS endpoint": `id: wait-for-lb
+module: util_wait_for
+inputs:
+  host: api.example.com
+  port: 443
+  check: tls
+  interval: 15s
+  timeout: 15m`,
+		},
+	}
+}
+
+func (m *waitForModule) Validate(op blackstart.Operation) error {
+	if op.DoesNotExist {
+		return fmt.Errorf("doesNotExist is not supported by %s", moduleIDWaitFor)
+	}
+
+	hostInput, ok := op.Inputs[inputHost]
+	if !ok {
+		return fmt.Errorf("missing required parameter: %s", inputHost)
+	}
+	if hostInput.IsStatic() {
+		host, err := blackstart.InputAs[string](hostInput, true)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputHost, err)
+		}
+		if strings.TrimSpace(host) == "" {
+			return fmt.Errorf("parameter %s must not be empty", inputHost)
+		}
+	}
+
+	check := checkTCP
+	checkInput, ok := op.Inputs[inputCheck]
+	if ok && checkInput.IsStatic() {
+		raw, err := blackstart.InputAs[string](checkInput, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputCheck, err)
+		}
+		if check, err = parseWaitCheck(raw); err != nil {
+			return err
+		}
+	}
+	portInput, hasPort := op.Inputs[inputPort]
+	if !hasPort && check != checkDNS && (!ok || checkInput.IsStatic()) {
+		return fmt.Errorf("missing required parameter: %s", inputPort)
+	}
+	if hasPort && portInput.IsStatic() {
+		port, err := blackstart.InputAs[int](portInput, false)
+		if err != nil {
+			return fmt.Errorf("parameter %s is invalid: %w", inputPort, err)
+		}
+		if err = validatePort(port); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range []string{inputTimeout, inputInterval} {
+		if input, ok := op.Inputs[key]; ok && input.IsStatic() {
+			value, err := blackstart.InputAs[string](input, false)
+			if err != nil {
+				return fmt.Errorf("parameter %s is invalid: %w", key, err)
+			}
+			if _, err = parseWaitDuration(key, value, 0); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *waitForModule) Check(ctx blackstart.ModuleContext) (bool, error) {
+	if ctx.DoesNotExist() {
+		return false, fmt.Errorf("doesNotExist is not supported by %s", moduleIDWaitFor)
+	}
+	if ctx.Tainted() {
+		return false, nil
+	}
+	target, err := contextWaitTarget(ctx)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(target.String())
+
+	addresses, err := probe(ctx, target)
+	if err != nil {
+		ctx.Logger().Debug("endpoint is not ready", "endpoint", target.String(), "error", err)
+		return false, nil
+	}
+	return true, ctx.Output(outputAddresses, addresses)
+}
+
+func (m *waitForModule) Set(ctx blackstart.ModuleContext) error {
+	if ctx.DoesNotExist() {
+		return fmt.Errorf("doesNotExist is not supported by %s", moduleIDWaitFor)
+	}
+	target, err := contextWaitTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(target.timeout)
+	for {
+		addresses, probeErr := probe(ctx, target)
+		if probeErr == nil {
+			return ctx.Output(outputAddresses, addresses)
+		}
+		if !time.Now().Add(target.interval).Before(deadline) {
+			return fmt.Errorf("endpoint %s was not ready after %s: %w", target, target.timeout, probeErr)
+		}
+
+		ctx.Logger().Info(
+			"waiting for endpoint", "endpoint", target.String(), "retry_in", target.interval, "error", probeErr,
+		)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed waiting for endpoint %s: %w", target, ctx.Err())
+		case <-time.After(target.interval):
+		}
+	}
+}
+
+// probe checks the endpoint once and returns the addresses the host resolved to when it is ready.
+func probe(ctx context.Context, target waitTarget) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, min(target.interval, maxProbeTimeout))
+	defer cancel()
+
+	addresses, err := net.DefaultResolver.LookupHost(ctx, target.host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", target.host, err)
+	}
+	if target.check == checkDNS {
+		return addresses, nil
+	}
+
+	address := net.JoinHostPort(target.host, strconv.Itoa(target.port))
+	dialer := &net.Dialer{}
+	if target.check == checkTCP {
+		conn, dialErr := dialer.DialContext(ctx, "tcp", address)
+		if dialErr != nil {
+			return nil, dialErr
+		}
+		_ = conn.Close()
+		return addresses, nil
+	}
+
+	rootCAs, err := blackstart.GetHTTPConfig().RootCAs()
+	if err != nil {
+		return nil, err
+	}
+	tlsDialer := &tls.Dialer{
+		NetDialer: dialer,
+		Config: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         target.serverName,
+			RootCAs:            rootCAs,
+			InsecureSkipVerify: !target.tlsVerify,
+		},
+	}
+	conn, err := tlsDialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	_ = conn.Close()
+	return addresses, nil
+}
+
+// contextWaitTarget reads the endpoint and readiness check from module inputs.
+func contextWaitTarget(ctx blackstart.ModuleContext) (waitTarget, error) {
+	var t waitTarget
+	var err error
+	if t.host, err = blackstart.ContextInputAs[string](ctx, inputHost, true); err != nil {
+		return waitTarget{}, err
+	}
+	t.host = strings.TrimSpace(t.host)
+
+	check, err := blackstart.ContextInputAs[string](ctx, inputCheck, false)
+	if err != nil {
+		return waitTarget{}, err
+	}
+	if t.check, err = parseWaitCheck(check); err != nil {
+		return waitTarget{}, err
+	}
+	if t.check != checkDNS {
+		if t.port, err = blackstart.ContextInputAs[int](ctx, inputPort, true); err != nil {
+			return waitTarget{}, err
+		}
+		if err = validatePort(t.port); err != nil {
+			return waitTarget{}, err
+		}
+	}
+
+	timeout, err := blackstart.ContextInputAs[string](ctx, inputTimeout, false)
+	if err != nil {
+		return waitTarget{}, err
+	}
+	if t.timeout, err = parseWaitDuration(inputTimeout, timeout, defaultWaitTimeout); err != nil {
+		return waitTarget{}, err
+	}
+	interval, err := blackstart.ContextInputAs[string](ctx, inputInterval, false)
+	if err != nil {
+		return waitTarget{}, err
+	}
+	if t.interval, err = parseWaitDuration(inputInterval, interval, defaultWaitInterval); err != nil {
+		return waitTarget{}, err
+	}
+
+	if t.serverName, err = blackstart.ContextInputAs[string](ctx, inputServerName, false); err != nil {
+		return waitTarget{}, err
+	}
+	if t.serverName == "" {
+		t.serverName = t.host
+	}
+	t.tlsVerify = true
+	if input, iErr := ctx.Input(inputTLSVerify); iErr == nil && input.Any() != nil {
+		if t.tlsVerify, err = blackstart.InputAs[bool](input, false); err != nil {
+			return waitTarget{}, fmt.Errorf("invalid input %s: %w", inputTLSVerify, err)
+		}
+	}
+	return t, nil
+}
+
+// parseWaitCheck returns the readiness check of a check input, defaulting to tcp.
+func parseWaitCheck(value string) (string, error) {
+	check := strings.ToLower(strings.TrimSpace(value))
+	if check == "" {
+		return checkTCP, nil
+	}
+	if _, ok := waitChecks[check]; !ok {
+		return "", fmt.Errorf("parameter %s must be one of tcp, dns, or tls, got %q", inputCheck, value)
+	}
+	return check, nil
+}
+
+// parseWaitDuration parses a positive duration input, returning the default when it is empty.
+func parseWaitDuration(key, value string, def time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("parameter %s is invalid: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("parameter %s must be positive", key)
+	}
+	return d, nil
+}
+
+// validatePort validates the port of an endpoint.
+func validatePort(port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("parameter %s must be between 1 and 65535, got %d", inputPort, port)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package util_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/modules/util"
+)
+
+// capturingModuleContext records module outputs while preserving normal context behavior.
+type capturingModuleContext struct {
+	blackstart.ModuleContext
+	outputs map[string]any
+}
+
+// Output records the output value and delegates to the wrapped ModuleContext.
+func (c *capturingModuleContext) Output(key string, value any) error {
+	if c.outputs == nil {
+		c.outputs = map[string]any{}
+	}
+	c.outputs[key] = value
+	return c.ModuleContext.Output(key, value)
+}
+
+// waitForOperation returns a util_wait_for operation with the inputs.
+func waitForOperation(inputs map[string]any) *blackstart.Operation {
+	op := &blackstart.Operation{Id: "wait", Module: "util_wait_for", Inputs: map[string]blackstart.Input{}}
+	for k, v := range inputs {
+		op.Inputs[k] = blackstart.NewInputFromValue(v)
+	}
+	return op
+}
+
+// waitForContext returns a context that captures the outputs of a util_wait_for operation.
+func waitForContext(inputs map[string]any) *capturingModuleContext {
+	return &capturingModuleContext{
+		ModuleContext: blackstart.OpContext(context.Background(), waitForOperation(inputs)),
+	}
+}
+
+// closedPort returns a local port with no listener.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+	return port
+}
+
+func TestWaitForModule_Validate(t *testing.T) {
+	m := util.NewWaitFor()
+
+	tests := []struct {
+		name    string
+		inputs  map[string]any
+		wantErr string
+	}{
+		{name: "tcp", inputs: map[string]any{"host": "db.example.com", "port": 5432}},
+		{name: "dns without port", inputs: map[string]any{"host": "db.example.com", "check": "DNS"}},
+		{name: "missing host", inputs: map[string]any{"port": 5432}, wantErr: "missing required parameter: host"},
+		{
+			name:    "tcp without port",
+			inputs:  map[string]any{"host": "db.example.com"},
+			wantErr: "missing required parameter: port",
+		},
+		{
+			name:    "invalid check",
+			inputs:  map[string]any{"host": "db.example.com", "port": 5432, "check": "icmp"},
+			wantErr: "parameter check must be one of tcp, dns, or tls",
+		},
+		{
+			name:    "invalid port",
+			inputs:  map[string]any{"host": "db.example.com", "port": 70000},
+			wantErr: "parameter port must be between 1 and 65535",
+		},
+		{
+			name:    "invalid timeout",
+			inputs:  map[string]any{"host": "db.example.com", "port": 5432, "timeout": "soon"},
+			wantErr: "parameter timeout is invalid",
+		},
+		{
+			name:    "negative interval",
+			inputs:  map[string]any{"host": "db.example.com", "port": 5432, "interval": "-1s"},
+			wantErr: "parameter interval must be positive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				err := m.Validate(*waitForOperation(tt.inputs))
+				if tt.wantErr == "" {
+					require.NoError(t, err)
+					return
+				}
+				require.ErrorContains(t, err, tt.wantErr)
+			},
+		)
+	}
+
+	op := waitForOperation(map[string]any{"host": "db.example.com", "port": 5432})
+	op.DoesNotExist = true
+	require.ErrorContains(t, m.Validate(*op), "doesNotExist is not supported")
+}
+
+func TestWaitForModule_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	m := util.NewWaitFor()
+
+	ctx := waitForContext(map[string]any{"host": "127.0.0.1", "port": l.Addr().(*net.TCPAddr).Port})
+	ready, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ready)
+	require.Equal(t, []string{"127.0.0.1"}, ctx.outputs["addresses"])
+
+	ctx = waitForContext(map[string]any{"host": "127.0.0.1", "port": closedPort(t)})
+	ready, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ready)
+}
+
+func TestWaitForModule_SetTimesOut(t *testing.T) {
+	m := util.NewWaitFor()
+	ctx := waitForContext(
+		map[string]any{"host": "127.0.0.1", "port": closedPort(t), "timeout": "300ms", "interval": "50ms"},
+	)
+	err := m.Set(ctx)
+	require.ErrorContains(t, err, "was not ready after 300ms")
+	require.Nil(t, ctx.outputs)
+}
+
+func TestWaitForModule_TLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	require.NoError(t, err)
+	m := util.NewWaitFor()
+
+	// The certificate of the test server is not trusted.
+	ctx := waitForContext(map[string]any{"host": "127.0.0.1", "port": port, "check": "tls"})
+	ready, err := m.Check(ctx)
+	require.NoError(t, err)
+	require.False(t, ready)
+
+	ctx = waitForContext(map[string]any{"host": "127.0.0.1", "port": port, "check": "tls", "tls_verify": false})
+	ready, err = m.Check(ctx)
+	require.NoError(t, err)
+	require.True(t, ready)
+}
+
+func TestWaitForModule_DNS(t *testing.T) {
+	m := util.NewWaitFor()
+	ctx := waitForContext(map[string]any{"host": "localhost", "check": "dns"})
+	require.NoError(t, m.Set(ctx))
+	require.NotEmpty(t, ctx.outputs["addresses"])
+}
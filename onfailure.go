@@ -0,0 +1,104 @@
+package blackstart
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Failure policies of operations, set with Operation.OnFailure. The operations that depend on a
+// failed operation are skipped with every policy that lets the run continue, since the outputs of
+// the failed operation are not available.
+const (
+	// OnFailureAbort stops the run when the operation fails. It is the default policy.
+	OnFailureAbort = "abort"
+
+	// OnFailureContinue lets the run continue when the operation fails, and the run succeeds. It is
+	// meant for operations that are not critical, such as seeding dashboards.
+	OnFailureContinue = "continue"
+
+	// OnFailureIsolate lets the operations that do not depend on the operation continue when it
+	// fails, and the run fails once they are completed.
+	OnFailureIsolate = "isolate"
+)
+
+// validOnFailure returns an error when the failure policy of an operation is not known.
+func validOnFailure(policy string) error {
+	switch policy {
+	case "", OnFailureAbort, OnFailureContinue, OnFailureIsolate:
+		return nil
+	}
+	return fmt.Errorf(
+		"unknown onFailure policy %q, expected %q, %q, or %q", policy, OnFailureAbort, OnFailureContinue,
+		OnFailureIsolate,
+	)
+}
+
+// failedOperation is an operation whose failure did not stop the run.
+type failedOperation struct {
+	op  *Operation
+	err error
+}
+
+// continuesAfter reports whether the run continues after the operation failed with the error. The
+// failure of an operation of a forEach instance that would abort the run only stops its instance. A
+// cancelled run, such as after the timeout of the workflow, always stops.
+func (we *workflowExecution) continuesAfter(ctx context.Context, op *Operation, err error) bool {
+	if context.Cause(ctx) != nil {
+		return false
+	}
+	switch {
+	case op.OnFailure == OnFailureContinue || op.OnFailure == OnFailureIsolate:
+		we.logger.Warn(
+			"operation failed, continuing with operations that do not depend on it", "module", op.Module,
+			"id", op.Id, "onFailure", op.OnFailure, "error", err,
+		)
+	case op.Instance != "":
+		we.logger.Warn(
+			"operation failed, skipping the other operations of its instance", "module", op.Module,
+			"id", op.Id, "instance", op.Instance, "error", err,
+		)
+	default:
+		return false
+	}
+	return true
+}
+
+// abortsInstance reports whether a failure of the operation stops the other operations of its
+// forEach instance.
+func abortsInstance(op *Operation) bool {
+	return op.Instance != "" && op.OnFailure != OnFailureContinue && op.OnFailure != OnFailureIsolate
+}
+
+// failsRun reports whether a failure of the operation that did not stop the run fails the run once
+// the other operations are completed.
+func failsRun(op *Operation) bool {
+	return op.OnFailure == OnFailureIsolate || abortsInstance(op)
+}
+
+// isolatedFailures returns the error of a run with operations that failed with the isolate policy
+// or stopped their forEach instance, and the first of these operations. The error is nil when no
+// such operation failed.
+func isolatedFailures(failed []failedOperation) (*Operation, error) {
+	var isolated []failedOperation
+	for _, f := range failed {
+		if failsRun(f.op) {
+			isolated = append(isolated, f)
+		}
+	}
+	switch len(isolated) {
+	case 0:
+		return nil, nil
+	case 1:
+		// A single failure is returned as is, as if the operation had stopped the run.
+		return isolated[0].op, isolated[0].err
+	}
+	// The errors are wrapped, so errors such as ErrCircuitOpen are still found in the error of the
+	// run.
+	args := []any{len(isolated)}
+	for _, f := range isolated {
+		args = append(args, fmt.Errorf("operation %q: %w", f.op.Id, f.err))
+	}
+	format := "%d operations failed: " + strings.TrimPrefix(strings.Repeat("; %w", len(isolated)), "; ")
+	return isolated[0].op, fmt.Errorf(format, args...)
+}
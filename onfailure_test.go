@@ -0,0 +1,144 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// onFailureWorkflow returns a workflow whose operation a fails to set with the failure policy. b
+// depends on a, c depends on b, and d does not depend on any operation.
+func onFailureWorkflow(policy string) Workflow {
+	inputs := map[string]Input{
+		testCheckResult: NewInputFromValue(true),
+		testSetResult:   NewInputFromValue(true),
+	}
+	return Workflow{
+		Name: "on-failure-test",
+		Operations: []Operation{
+			{Id: "a", Module: "cleanup_test_module", Inputs: inputs, OnFailure: policy},
+			{Id: "b", Module: "cleanup_test_module", DependsOn: []string{"a"}, Inputs: inputs},
+			{Id: "c", Module: "cleanup_test_module", DependsOn: []string{"b"}, Inputs: inputs},
+			{Id: "d", Module: "cleanup_test_module", Inputs: inputs},
+		},
+		InjectedFailures: map[string]string{"a": InjectFailureSet},
+	}
+}
+
+// operationIds returns the identifiers of the operation results.
+func operationIds(results []OperationResult) []string {
+	ids := make([]string, 0, len(results))
+	for _, res := range results {
+		ids = append(ids, res.Id)
+	}
+	return ids
+}
+
+func TestWorkflowExecution_OnFailure(t *testing.T) {
+	for _, policy := range []string{"", OnFailureAbort} {
+		wf := onFailureWorkflow(policy)
+		res := wf.Run(context.Background())
+		require.ErrorIs(t, res.Err, ErrInjectedFailure)
+		require.NotNil(t, res.Op)
+		assert.Equal(t, "a", res.Op.Id)
+		assert.Equal(t, []string{"a"}, operationIds(res.Operations))
+		assert.ErrorIs(t, res.Operations[0].Err, ErrInjectedFailure)
+	}
+
+	t.Run(
+		"continue", func(t *testing.T) {
+			wf := onFailureWorkflow(OnFailureContinue)
+			res := wf.Run(context.Background())
+			require.NoError(t, res.Err)
+			assert.Equal(t, []string{"a", "b", "c", "d"}, operationIds(res.Operations))
+			assert.ErrorIs(t, res.Operations[0].Err, ErrInjectedFailure)
+			// The dependents of the failed operation are skipped, transitively.
+			assert.True(t, res.Operations[1].Skipped)
+			assert.True(t, res.Operations[2].Skipped)
+			assert.False(t, res.Operations[3].Skipped)
+			assert.NoError(t, res.Operations[3].Err)
+			assert.Equal(t, 3, res.CompletedOperations)
+		},
+	)
+
+	t.Run(
+		"isolate", func(t *testing.T) {
+			wf := onFailureWorkflow(OnFailureIsolate)
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrInjectedFailure)
+			assert.EqualError(t, res.Err, `injected failure: set of operation "a"`)
+			assert.Equal(t, phaseExecute, res.Phase)
+			require.NotNil(t, res.Op)
+			assert.Equal(t, "a", res.Op.Id)
+			// The operations that do not depend on the failed operation are completed first.
+			assert.Equal(t, []string{"a", "b", "c", "d"}, operationIds(res.Operations))
+			assert.True(t, res.Operations[1].Skipped)
+			assert.False(t, res.Operations[3].Skipped)
+		},
+	)
+
+	t.Run(
+		"several isolated failures", func(t *testing.T) {
+			wf := onFailureWorkflow(OnFailureIsolate)
+			wf.Operations[3].OnFailure = OnFailureIsolate
+			wf.InjectedFailures["d"] = InjectFailureCheck
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrInjectedFailure)
+			assert.EqualError(
+				t, res.Err, `2 operations failed: operation "a": injected failure: set of operation "a"; `+
+					`operation "d": injected failure: check of operation "d"`,
+			)
+			require.NotNil(t, res.Op)
+			assert.Equal(t, "a", res.Op.Id)
+		},
+	)
+
+	t.Run(
+		"continued failure of an isolated branch", func(t *testing.T) {
+			// A continued failure does not fail the run, but an isolated failure does.
+			wf := onFailureWorkflow(OnFailureContinue)
+			wf.Operations[3].OnFailure = OnFailureIsolate
+			wf.InjectedFailures["d"] = InjectFailureSet
+			res := wf.Run(context.Background())
+			require.ErrorIs(t, res.Err, ErrInjectedFailure)
+			assert.EqualError(t, res.Err, `injected failure: set of operation "d"`)
+			require.NotNil(t, res.Op)
+			assert.Equal(t, "d", res.Op.Id)
+		},
+	)
+
+	t.Run(
+		"invalid policy", func(t *testing.T) {
+			wf := onFailureWorkflow("ignore")
+			res := wf.Run(context.Background())
+			assert.Equal(t, phaseSetup, res.Phase)
+			assert.EqualError(
+				t, res.Err,
+				`invalid operation "a": unknown onFailure policy "ignore", expected "abort", "continue", or "isolate"`,
+			)
+		},
+	)
+}
+
+func TestWorkflowExecution_OnFailureTimeout(t *testing.T) {
+	// A timed out operation stops the run, whatever its failure policy.
+	wf := Workflow{
+		Name: "on-failure-timeout",
+		Operations: []Operation{
+			{Id: "wait", Module: "wait_test_module", OnFailure: OnFailureContinue},
+			{
+				Id: "after", Module: "slow_test_module",
+				Inputs: map[string]Input{"duration": NewInputFromValue("1ms")},
+			},
+		},
+		Timeout: 20 * time.Millisecond,
+	}
+	res := wf.Run(context.Background())
+	require.ErrorIs(t, res.Err, ErrWorkflowTimeout)
+	require.NotNil(t, res.Op)
+	assert.Equal(t, "wait", res.Op.Id)
+	assert.Equal(t, []string{"wait"}, operationIds(res.Operations))
+}
@@ -1,6 +1,7 @@
 package blackstart
 
 import (
+	"fmt"
 	"log/slog"
 	"slices"
 )
@@ -25,6 +26,10 @@ type Operation struct {
 	// context about the operation.
 	Description string
 
+	// Labels are free-form key and value pairs of the operation, such as `tier: db`. Runs may be
+	// limited to the operations with or without a label.
+	Labels map[string]string
+
 	// DependsOn is a list of operation IDs that this operation depends on. The operations that
 	// this operation depends on will be executed before this operation.
 	DependsOn []string
@@ -38,6 +43,16 @@ type Operation struct {
 	// should be deleted if they still exist.
 	DoesNotExist bool
 
+	// OnFailure is the failure policy of the operation, OnFailureAbort, OnFailureContinue, or
+	// OnFailureIsolate. An empty policy aborts the run.
+	OnFailure string
+
+	// Instance is the name of the forEach instance the operation was created for. A failure of an
+	// operation of an instance that would abort the run only stops the other operations of the
+	// instance. The operations of the other instances continue, and the run fails once they are
+	// completed.
+	Instance string
+
 	// Tainted is a special parameter that can be used to indicate that the resource is tainted and
 	// should be replaced. This is useful for resources that always must be updated so that
 	// attributes / output values are known by Blackstart. This should not be configured by users,
@@ -51,6 +66,9 @@ type Operation struct {
 // before creating the directed graph of dependencies. The setup will walk through moduleContext and add
 // any implicit dependencies to the DependsOn list of operations for the current operation.
 func (o *Operation) setup() error {
+	if err := validOnFailure(o.OnFailure); err != nil {
+		return fmt.Errorf("invalid operation %q: %w", o.Id, err)
+	}
 	for _, v := range o.Inputs {
 		if v.IsStatic() {
 			continue
@@ -76,28 +94,40 @@ func (o *Operation) execute(mctx ModuleContext, logger *slog.Logger) error {
 }
 
 func (o *Operation) executeWithModule(m Module, mctx ModuleContext, logger *slog.Logger) error {
-	var err error
-	var check bool
+	check, err := o.checkWithModule(m, mctx, logger)
+	if err != nil {
+		return err
+	}
+	return o.setUnlessChecked(m, mctx, logger, check)
+}
 
+// checkWithModule runs the check of an operation and returns whether it passed.
+func (o *Operation) checkWithModule(m Module, mctx ModuleContext, logger *slog.Logger) (bool, error) {
 	logger.Info("operation check", "module", o.Module, "id", o.Id)
-	check, err = m.Check(mctx)
+	check, err := m.Check(mctx)
 	if err != nil {
 		logger.Warn(
 			"operation check failed",
 			"module", o.Module,
 			"id", o.Id,
-			"inputs", o.Inputs,
+			"inputs", loggedInputs(o, m.Info()),
 			"error", err,
 		)
-		return err
+		return false, err
 	}
+	return check, nil
+}
+
+// setUnlessChecked completes an operation with the result of its check. Set is called when the
+// check did not pass.
+func (o *Operation) setUnlessChecked(m Module, mctx ModuleContext, logger *slog.Logger, check bool) error {
 	if check {
 		logger.Info("operation check passed", "module", o.Module, "id", o.Id)
 		return nil
 	}
 
 	logger.Info("operation set", "module", o.Module, "id", o.Id)
-	err = m.Set(mctx)
+	err := m.Set(mctx)
 	if err != nil {
 		logger.Warn("operation set failed", "module", o.Module, "id", o.Id, "error", err)
 		return err
@@ -0,0 +1,63 @@
+package blackstart
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotPropagated is returned by WaitForPropagation when a change has not taken effect before the
+// propagation timeout.
+var ErrNotPropagated = errors.New("change did not propagate")
+
+// defaultPropagationTimeout is the time modules wait for changes to propagate when the runtime
+// configuration does not set a timeout.
+const defaultPropagationTimeout = 2 * time.Minute
+
+// Changes are checked with exponential backoff between these intervals until they have propagated.
+var (
+	propagationPollInitialInterval = 1 * time.Second
+	propagationPollMaxInterval     = 10 * time.Second
+)
+
+// PropagationTimeout returns how long modules wait for changes to eventually consistent APIs to
+// take effect, from the runtime configuration.
+func PropagationTimeout(ctx ModuleContext) time.Duration {
+	config, _ := ctx.Value(ConfigKey).(*RuntimeConfig)
+	if config == nil || config.PropagationTimeout <= 0 {
+		return defaultPropagationTimeout
+	}
+	return config.PropagationTimeout
+}
+
+// WaitForPropagation calls check until it reports that a change has taken effect, for APIs that
+// are eventually consistent, such as Google IAM, where a change made by Set may not be visible to
+// reads or usable by dependent operations right away. Check is polled with exponential backoff for
+// up to the PropagationTimeout. An error returned by check stops waiting and is returned. When the
+// change has not propagated in time, an error wrapping ErrNotPropagated and the description of the
+// change is returned.
+func WaitForPropagation(ctx ModuleContext, description string, check func() (bool, error)) error {
+	timeout := PropagationTimeout(ctx)
+	deadline := time.Now().Add(timeout)
+	interval := propagationPollInitialInterval
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		if !time.Now().Add(interval).Before(deadline) {
+			return fmt.Errorf("%w after %s: %s", ErrNotPropagated, timeout, description)
+		}
+
+		ctx.Logger().Debug("waiting for change to propagate", "change", description, "retry_in", interval)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed waiting for %s: %w", description, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = min(interval*2, propagationPollMaxInterval)
+	}
+}
@@ -0,0 +1,84 @@
+package blackstart
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForPropagation(t *testing.T) {
+	initial := propagationPollInitialInterval
+	propagationPollInitialInterval = time.Millisecond
+	t.Cleanup(func() { propagationPollInitialInterval = initial })
+
+	// configured returns a module context with the propagation timeout configured.
+	configured := func(ctx context.Context, timeout time.Duration) ModuleContext {
+		ctx = context.WithValue(ctx, ConfigKey, &RuntimeConfig{PropagationTimeout: timeout})
+		return InputsToContext(ctx, nil)
+	}
+
+	t.Run(
+		"propagated", func(t *testing.T) {
+			calls := 0
+			err := WaitForPropagation(
+				configured(context.Background(), time.Minute), "change", func() (bool, error) {
+					calls++
+					return calls == 3, nil
+				},
+			)
+			require.NoError(t, err)
+			assert.Equal(t, 3, calls)
+		},
+	)
+
+	t.Run(
+		"check error", func(t *testing.T) {
+			calls := 0
+			checkErr := errors.New("check failed")
+			err := WaitForPropagation(
+				configured(context.Background(), time.Minute), "change", func() (bool, error) {
+					calls++
+					return false, checkErr
+				},
+			)
+			require.ErrorIs(t, err, checkErr)
+			assert.Equal(t, 1, calls)
+		},
+	)
+
+	t.Run(
+		"timed out", func(t *testing.T) {
+			err := WaitForPropagation(
+				configured(context.Background(), 5*time.Millisecond), "user binding", func() (bool, error) {
+					return false, nil
+				},
+			)
+			require.ErrorIs(t, err, ErrNotPropagated)
+			assert.ErrorContains(t, err, "after 5ms: user binding")
+		},
+	)
+
+	t.Run(
+		"context canceled", func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			err := WaitForPropagation(
+				configured(ctx, time.Minute), "change", func() (bool, error) {
+					return false, nil
+				},
+			)
+			require.ErrorIs(t, err, context.Canceled)
+		},
+	)
+}
+
+func TestPropagationTimeout(t *testing.T) {
+	assert.Equal(t, defaultPropagationTimeout, PropagationTimeout(InputsToContext(context.Background(), nil)))
+
+	ctx := context.WithValue(context.Background(), ConfigKey, &RuntimeConfig{PropagationTimeout: time.Minute})
+	assert.Equal(t, time.Minute, PropagationTimeout(InputsToContext(ctx, nil)))
+}
@@ -0,0 +1,270 @@
+package blackstart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Kinds of the resources that outputs are published to.
+const (
+	PublishConfigMap = "ConfigMap"
+	PublishSecret    = "Secret"
+)
+
+// publishManagedByLabel is the label set on the ConfigMaps and Secrets created to publish outputs.
+const publishManagedByLabel = "app.kubernetes.io/managed-by"
+
+// PublishOutputs writes outputs of operations to the data of a ConfigMap or Secret after a
+// successful run, so other controllers and Helm charts can consume the results of a workflow.
+type PublishOutputs struct {
+	// Kind is the kind of the resource, PublishConfigMap or PublishSecret.
+	Kind string
+
+	// Name is the name of the ConfigMap or Secret. It is created when it does not exist.
+	Name string
+
+	// Namespace is the namespace of the ConfigMap or Secret. It defaults to the namespace of the
+	// workflow.
+	Namespace string
+
+	// Outputs are the operation outputs written to the data of the resource.
+	Outputs []PublishedOutput
+}
+
+// PublishedOutput is an operation output written to a data key of a ConfigMap or Secret.
+type PublishedOutput struct {
+	// Key is the data key the output is written to.
+	Key string
+
+	// OperationId is the identifier of the operation with the output.
+	OperationId string
+
+	// OutputKey is the key of the output.
+	OutputKey string
+}
+
+// namespace returns the namespace of the resource the outputs are published to.
+func (p PublishOutputs) namespace(w *Workflow) string {
+	if p.Namespace != "" {
+		return p.Namespace
+	}
+	return w.Namespace
+}
+
+// checkPublishOutputs validates the published outputs of the workflow. The outputs must be
+// declared by the modules of the operations, and sensitive outputs may only be published to a
+// Secret.
+func (we *workflowExecution) checkPublishOutputs() error {
+	for _, p := range we.w.PublishOutputs {
+		if p.Kind != PublishConfigMap && p.Kind != PublishSecret {
+			return fmt.Errorf("outputs published to %s have unsupported kind %q", p.Name, p.Kind)
+		}
+		if p.Name == "" {
+			return fmt.Errorf("outputs published to a %s have no name", p.Kind)
+		}
+		if p.namespace(we.w) == "" {
+			return fmt.Errorf("outputs published to %s %s have no namespace", p.Kind, p.Name)
+		}
+		keys := make(map[string]struct{}, len(p.Outputs))
+		for _, out := range p.Outputs {
+			if _, ok := keys[out.Key]; ok {
+				return fmt.Errorf("key %q is published to %s %s more than once", out.Key, p.Kind, p.Name)
+			}
+			keys[out.Key] = struct{}{}
+			info, ok := we.moduleInfo[out.OperationId]
+			if !ok {
+				return fmt.Errorf("published output %q uses unknown operation %q", out.Key, out.OperationId)
+			}
+			output, ok := info.Outputs[out.OutputKey]
+			if !ok {
+				return fmt.Errorf(
+					"published output %q uses unknown output %q of operation %q", out.Key, out.OutputKey,
+					out.OperationId,
+				)
+			}
+			if output.Sensitive && p.Kind != PublishSecret {
+				return fmt.Errorf(
+					"published output %q is sensitive and can only be published to a Secret", out.Key,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// publishOutputs writes the published outputs of a successful run to their ConfigMaps and Secrets.
+func (we *workflowExecution) publishOutputs(ctx context.Context) error {
+	provider, ok := ctx.Value(KubeClientProviderKey).(KubeClientProvider)
+	if len(we.w.PublishOutputs) > 0 && (!ok || provider == nil) {
+		return fmt.Errorf("unable to publish outputs: %w", ErrKubeClientUnavailable)
+	}
+	for _, p := range we.w.PublishOutputs {
+		data, err := we.publishedData(p)
+		if err != nil {
+			return err
+		}
+		namespace := p.namespace(we.w)
+		client, err := provider.KubeClient(ctx, namespace, "")
+		if err != nil {
+			return fmt.Errorf("unable to publish outputs to %s %s: %w", p.Kind, p.Name, err)
+		}
+		err = retry.RetryOnConflict(
+			retry.DefaultRetry, func() error {
+				if p.Kind == PublishSecret {
+					return publishSecret(ctx, client, namespace, p.Name, data)
+				}
+				return publishConfigMap(ctx, client, namespace, p.Name, data)
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("unable to publish outputs to %s %s/%s: %w", p.Kind, namespace, p.Name, err)
+		}
+		we.logger.Info(
+			"outputs published", "kind", p.Kind, "name", p.Name, "namespace", namespace,
+			"keys", slices.Sorted(maps.Keys(data)),
+		)
+	}
+	return nil
+}
+
+// publishedData returns the data of the published outputs. Outputs that are derived from sensitive
+// values may only be published to a Secret.
+func (we *workflowExecution) publishedData(p PublishOutputs) (map[string]string, error) {
+	data := make(map[string]string, len(p.Outputs))
+	for _, out := range p.Outputs {
+		mctx, ok := we.opCtxs[out.OperationId]
+		if !ok {
+			return nil, fmt.Errorf("published output %q: operation %q did not run", out.Key, out.OperationId)
+		}
+		if p.Kind != PublishSecret && sensitiveOutput(out.OperationId, out.OutputKey, we.moduleInfo, we.opCtxs) {
+			return nil, fmt.Errorf(
+				"published output %q is derived from a sensitive value and can only be published to a Secret",
+				out.Key,
+			)
+		}
+		value, err := mctx.getOutput(out.OutputKey)
+		if err != nil {
+			return nil, fmt.Errorf("published output %q: %w", out.Key, err)
+		}
+		if data[out.Key], err = publishedValue(value); err != nil {
+			return nil, fmt.Errorf("published output %q: %w", out.Key, err)
+		}
+	}
+	return data, nil
+}
+
+// publishedValue formats an output value for the data of a ConfigMap or Secret. Strings are
+// published as is, and scalars, slices, and maps with their JSON encoding.
+func publishedValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Slice, reflect.Array, reflect.Map:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("values of type %T cannot be published", value)
+	}
+}
+
+// publishConfigMap writes data to a ConfigMap, creating it when it does not exist. Other keys of an
+// existing ConfigMap are kept.
+func publishConfigMap(
+	ctx context.Context, client kubernetes.Interface, namespace, name string, data map[string]string,
+) error {
+	configMaps := client.CoreV1().ConfigMaps(namespace)
+	cm, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: namespace, Labels: map[string]string{publishManagedByLabel: "blackstart"},
+			},
+			Data: data,
+		}
+		_, err = configMaps.Create(ctx, cm, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = make(map[string]string, len(data))
+	} else if mapsContain(cm.Data, data) {
+		return nil
+	}
+	maps.Copy(cm.Data, data)
+	_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+	return err
+}
+
+// publishSecret writes data to a Secret, creating it when it does not exist. Other keys of an
+// existing Secret are kept.
+func publishSecret(
+	ctx context.Context, client kubernetes.Interface, namespace, name string, data map[string]string,
+) error {
+	secrets := client.CoreV1().Secrets(namespace)
+	secret, err := secrets.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name, Namespace: namespace, Labels: map[string]string{publishManagedByLabel: "blackstart"},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: make(map[string][]byte, len(data)),
+		}
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+		_, err = secrets.Create(ctx, secret, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	current := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		current[key] = string(value)
+	}
+	if mapsContain(current, data) {
+		return nil
+	}
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte, len(data))
+	}
+	for key, value := range data {
+		secret.Data[key] = []byte(value)
+	}
+	_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+	return err
+}
+
+// mapsContain reports whether m has all the entries of sub.
+func mapsContain(m, sub map[string]string) bool {
+	for key, value := range sub {
+		if current, ok := m[key]; !ok || current != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,180 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// publishTestProvider provides the same fake client for all namespaces.
+type publishTestProvider struct {
+	client kubernetes.Interface
+}
+
+func (p *publishTestProvider) KubeClient(_ context.Context, _, _ string) (kubernetes.Interface, error) {
+	return p.client, nil
+}
+
+func publishTestWorkflow(publish ...PublishOutputs) Workflow {
+	return Workflow{
+		Name:      "publish-test",
+		Namespace: "apps",
+		Operations: []Operation{
+			{
+				Id:     "db",
+				Module: "record_test_module",
+				Inputs: map[string]Input{
+					"name":     NewInputFromValue("app"),
+					"password": NewInputFromValue("hunter2"),
+				},
+			},
+		},
+		PublishOutputs: publish,
+	}
+}
+
+func TestWorkflowExecution_PublishOutputs(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "apps"},
+			Data:       map[string][]byte{"other": []byte("kept")},
+		},
+	)
+	ctx := context.WithValue(context.Background(), KubeClientProviderKey, &publishTestProvider{client: clientset})
+	wf := publishTestWorkflow(
+		PublishOutputs{
+			Kind: PublishConfigMap,
+			Name: "db",
+			Outputs: []PublishedOutput{
+				{Key: "DATABASE_NAME", OperationId: "db", OutputKey: "name"},
+			},
+		},
+		PublishOutputs{
+			Kind: PublishSecret,
+			Name: "db-credentials",
+			Outputs: []PublishedOutput{
+				{Key: "TOKEN", OperationId: "db", OutputKey: "token"},
+			},
+		},
+	)
+
+	res := wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, phasePublish, res.Phase)
+
+	cm, err := clientset.CoreV1().ConfigMaps("apps").Get(ctx, "db", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"DATABASE_NAME": "app"}, cm.Data)
+	assert.Equal(t, "blackstart", cm.Labels[publishManagedByLabel])
+	secret, err := clientset.CoreV1().Secrets("apps").Get(ctx, "db-credentials", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"other": []byte("kept"), "TOKEN": []byte("app:hunter2")}, secret.Data)
+
+	// Outputs are not published in check-only runs, since the operations are not set.
+	wf.CheckOnly = true
+	wf.Operations[0].Inputs["name"] = NewInputFromValue("changed")
+	res = wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, phaseExecute, res.Phase)
+	cm, err = clientset.CoreV1().ConfigMaps("apps").Get(ctx, "db", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "app", cm.Data["DATABASE_NAME"])
+}
+
+func TestWorkflowExecution_PublishOutputsValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		publish PublishOutputs
+		wantErr string
+	}{
+		{
+			name: "unknown operation",
+			publish: PublishOutputs{
+				Kind: PublishConfigMap, Name: "db",
+				Outputs: []PublishedOutput{{Key: "NAME", OperationId: "missing", OutputKey: "name"}},
+			},
+			wantErr: `published output "NAME" uses unknown operation "missing"`,
+		},
+		{
+			name: "unknown output",
+			publish: PublishOutputs{
+				Kind: PublishConfigMap, Name: "db",
+				Outputs: []PublishedOutput{{Key: "NAME", OperationId: "db", OutputKey: "missing"}},
+			},
+			wantErr: `published output "NAME" uses unknown output "missing" of operation "db"`,
+		},
+		{
+			name: "sensitive output in a ConfigMap",
+			publish: PublishOutputs{
+				Kind: PublishConfigMap, Name: "db",
+				Outputs: []PublishedOutput{{Key: "TOKEN", OperationId: "db", OutputKey: "token"}},
+			},
+			wantErr: `published output "TOKEN" is sensitive and can only be published to a Secret`,
+		},
+		{
+			name: "duplicate key",
+			publish: PublishOutputs{
+				Kind: PublishSecret, Name: "db",
+				Outputs: []PublishedOutput{
+					{Key: "NAME", OperationId: "db", OutputKey: "name"},
+					{Key: "NAME", OperationId: "db", OutputKey: "token"},
+				},
+			},
+			wantErr: `key "NAME" is published to Secret db more than once`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(
+			test.name, func(t *testing.T) {
+				wf := publishTestWorkflow(test.publish)
+				res := wf.Run(context.Background())
+				require.Error(t, res.Err)
+				assert.Equal(t, phaseValidate, res.Phase)
+				assert.EqualError(t, res.Err, test.wantErr)
+				assert.Empty(t, res.Operations)
+			},
+		)
+	}
+}
+
+func TestWorkflowExecution_PublishOutputsDerivedSensitive(t *testing.T) {
+	clientset := fake.NewClientset()
+	ctx := context.WithValue(context.Background(), KubeClientProviderKey, &publishTestProvider{client: clientset})
+	wf := publishTestWorkflow(
+		PublishOutputs{
+			Kind:    PublishConfigMap,
+			Name:    "db",
+			Outputs: []PublishedOutput{{Key: "NAME", OperationId: "db", OutputKey: "name"}},
+		},
+	)
+	wf.Operations[0].Inputs["name"] = NewSensitiveInputFromValue("decrypted")
+
+	res := wf.Run(ctx)
+	assert.Equal(t, phasePublish, res.Phase)
+	assert.EqualError(
+		t, res.Err,
+		`published output "NAME" is derived from a sensitive value and can only be published to a Secret`,
+	)
+	_, err := clientset.CoreV1().ConfigMaps("apps").Get(ctx, "db", metav1.GetOptions{})
+	assert.Error(t, err)
+}
+
+func TestPublishedValue(t *testing.T) {
+	for value, want := range map[any]string{"text": "text", 3: "3", true: "true"} {
+		got, err := publishedValue(value)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	got, err := publishedValue([]string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, `["a","b"]`, got)
+	_, err = publishedValue(context.Background())
+	assert.ErrorContains(t, err, "cannot be published")
+}
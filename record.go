@@ -0,0 +1,172 @@
+package blackstart
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"unicode/utf8"
+)
+
+// MaskedValue replaces sensitive values in the recorded inputs and outputs of operations.
+const MaskedValue = "********"
+
+// recordedValueLimit is the maximum length of a recorded input or output value. Longer values are
+// truncated, so large values such as certificate chains do not bloat the workflow status.
+const recordedValueLimit = 256
+
+// recordedInputs returns the resolved inputs of an operation as recorded in the run result. Inputs
+// are masked when the module marks them as sensitive, when they were created as sensitive values,
+// or when they are taken from a sensitive output of a dependency.
+func recordedInputs(
+	op *Operation, mctx *moduleContext, moduleInfo map[string]ModuleInfo, opCtxs map[string]*moduleContext,
+) map[string]string {
+	if len(mctx.inputValues) == 0 {
+		return nil
+	}
+	inputs := moduleInfo[op.Id].Inputs
+	recorded := make(map[string]string, len(mctx.inputValues))
+	for key, input := range mctx.inputValues {
+		if inputs[key].Sensitive || sensitiveInput(op.Inputs[key], moduleInfo, opCtxs) {
+			recorded[key] = MaskedValue
+			continue
+		}
+		recorded[key] = recordedValue(input.Any())
+	}
+	return recorded
+}
+
+// sensitiveInput reports whether an operation input holds a sensitive value or is taken from a
+// sensitive output of a dependency. The contexts of the operations that have run are used to find
+// outputs derived from sensitive values, and may be nil before the operations run.
+func sensitiveInput(input Input, moduleInfo map[string]ModuleInfo, opCtxs map[string]*moduleContext) bool {
+	if input == nil {
+		return false
+	}
+	if mi, ok := input.(*moduleInput); ok && mi.sensitive {
+		return true
+	}
+	if input.IsStatic() {
+		return false
+	}
+	return sensitiveOutput(input.DependencyId(), input.OutputKey(), moduleInfo, opCtxs)
+}
+
+// sensitiveOutput reports whether an output of an operation is sensitive, because the module marks
+// it as sensitive or because the outputs of the operation are derived from sensitive values.
+func sensitiveOutput(
+	id, key string, moduleInfo map[string]ModuleInfo, opCtxs map[string]*moduleContext,
+) bool {
+	if moduleInfo[id].Outputs[key].Sensitive {
+		return true
+	}
+	mctx, ok := opCtxs[id]
+	return ok && mctx.derivedSensitive
+}
+
+// recordedOutputs returns the outputs of an operation as recorded in the run result. Outputs the
+// module marks as sensitive are masked, and all outputs are masked when they are derived from
+// sensitive values the module does not know are sensitive, such as a template rendered with a
+// password.
+func recordedOutputs(mctx *moduleContext, info ModuleInfo) map[string]string {
+	if len(mctx.outputValues) == 0 {
+		return nil
+	}
+	recorded := make(map[string]string, len(mctx.outputValues))
+	for key, value := range mctx.outputValues {
+		if info.Outputs[key].Sensitive || mctx.derivedSensitive {
+			recorded[key] = MaskedValue
+			continue
+		}
+		recorded[key] = recordedValue(value)
+	}
+	return recorded
+}
+
+// executionPlan returns the resolved execution plan of a workflow run from the sorted operation
+// identifiers. Static input values are recorded like resolved inputs, and dependency inputs are
+// recorded by their source.
+func executionPlan(
+	sortedIds []string, operations map[string]*Operation, moduleInfo map[string]ModuleInfo,
+) []PlannedOperation {
+	plan := make([]PlannedOperation, 0, len(sortedIds))
+	for _, id := range sortedIds {
+		op := operations[id]
+		planned := PlannedOperation{
+			Id:           op.Id,
+			Module:       op.Module,
+			DependsOn:    slices.Compact(slices.Sorted(slices.Values(operationDependencies(op)))),
+			DoesNotExist: op.DoesNotExist,
+		}
+		if len(op.Inputs) > 0 {
+			planned.Inputs = make(map[string]PlannedInput, len(op.Inputs))
+		}
+		for key, input := range op.Inputs {
+			switch {
+			case !input.IsStatic():
+				planned.Inputs[key] = PlannedInput{DependencyId: input.DependencyId(), OutputKey: input.OutputKey()}
+			case moduleInfo[id].Inputs[key].Sensitive || sensitiveInput(input, moduleInfo, nil):
+				planned.Inputs[key] = PlannedInput{Value: MaskedValue}
+			default:
+				planned.Inputs[key] = PlannedInput{Value: recordedValue(input.Any())}
+			}
+		}
+		plan = append(plan, planned)
+	}
+	return plan
+}
+
+// loggedInputs returns the configured inputs of an operation for logs. Static values are masked
+// like recorded inputs, and inputs from dependencies are logged by their source, since their values
+// are not known to the operation.
+func loggedInputs(op *Operation, info ModuleInfo) map[string]string {
+	if len(op.Inputs) == 0 {
+		return nil
+	}
+	logged := make(map[string]string, len(op.Inputs))
+	for key, input := range op.Inputs {
+		switch {
+		case !input.IsStatic():
+			logged[key] = fmt.Sprintf("fromDependency %s.%s", input.DependencyId(), input.OutputKey())
+		case info.Inputs[key].Sensitive || sensitiveInput(input, nil, nil):
+			logged[key] = MaskedValue
+		default:
+			logged[key] = recordedValue(input.Any())
+		}
+	}
+	return logged
+}
+
+// recordedValue formats an input or output value for the run result. Strings are recorded as is,
+// and scalars, slices, and maps with their JSON encoding. Other values, such as API clients, are
+// recorded as their type only.
+func recordedValue(value any) string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		s = v
+	default:
+		switch reflect.TypeOf(value).Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Slice, reflect.Array, reflect.Map:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return fmt.Sprintf("<%T>", value)
+			}
+			s = string(data)
+		default:
+			return fmt.Sprintf("<%T>", value)
+		}
+	}
+	if len(s) > recordedValueLimit {
+		cut := recordedValueLimit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		s = s[:cut] + "..."
+	}
+	return s
+}
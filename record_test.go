@@ -0,0 +1,65 @@
+package blackstart
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordedValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		expected string
+	}{
+		{name: "nil", value: nil, expected: "null"},
+		{name: "string", value: "plain text", expected: "plain text"},
+		{name: "int", value: 42, expected: "42"},
+		{name: "bool", value: true, expected: "true"},
+		{name: "slice", value: []string{"a", "b"}, expected: `["a","b"]`},
+		{name: "map", value: map[string]any{"k": 1}, expected: `{"k":1}`},
+		{name: "struct", value: struct{ A int }{A: 1}, expected: "<struct { A int }>"},
+		{name: "pointer", value: &Workflow{}, expected: "<*blackstart.Workflow>"},
+		{
+			name:     "truncated",
+			value:    strings.Repeat("x", recordedValueLimit+10),
+			expected: strings.Repeat("x", recordedValueLimit) + "...",
+		},
+		{
+			name:     "truncated at rune boundary",
+			value:    strings.Repeat("x", recordedValueLimit-1) + "é",
+			expected: strings.Repeat("x", recordedValueLimit-1) + "...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				assert.Equal(t, tt.expected, recordedValue(tt.value))
+			},
+		)
+	}
+}
+
+func TestLoggedInputs(t *testing.T) {
+	op := &Operation{
+		Id:     "a",
+		Module: "record_test_module",
+		Inputs: map[string]Input{
+			"name":     NewInputFromValue("app"),
+			"password": NewInputFromValue("hunter2"),
+			"secret":   NewSensitiveInputFromValue("decrypted"),
+			"token":    NewInputFromDep("b", "token"),
+		},
+	}
+	assert.Equal(
+		t, map[string]string{
+			"name":     "app",
+			"password": MaskedValue,
+			"secret":   MaskedValue,
+			"token":    "fromDependency b.token",
+		}, loggedInputs(op, (&recordTestModule{}).Info()),
+	)
+	assert.Nil(t, loggedInputs(&Operation{}, ModuleInfo{}))
+}
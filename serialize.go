@@ -0,0 +1,89 @@
+package blackstart
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// operationLocks serializes operations that share a serialization key across all workflows run
+// by the process.
+var operationLocks = newKeyedLocks()
+
+// keyedLocks is a set of locks identified by string keys. A lock exists only while it is held.
+type keyedLocks struct {
+	mu   sync.Mutex
+	held map[string]chan struct{}
+}
+
+func newKeyedLocks() *keyedLocks {
+	return &keyedLocks{held: make(map[string]chan struct{})}
+}
+
+// lock acquires the locks for all keys and returns a function that releases them. Keys are
+// acquired in sorted order so that callers locking several keys cannot deadlock. If the context
+// is canceled while waiting, the locks acquired so far are released and the context error is
+// returned.
+func (l *keyedLocks) lock(ctx context.Context, keys []string) (func(), error) {
+	keys = slices.Compact(slices.Sorted(slices.Values(keys)))
+	for i, key := range keys {
+		if err := l.lockKey(ctx, key); err != nil {
+			l.unlock(keys[:i])
+			return nil, err
+		}
+	}
+	return func() { l.unlock(keys) }, nil
+}
+
+// lockKey waits until the key is not held by another caller and then holds it.
+func (l *keyedLocks) lockKey(ctx context.Context, key string) error {
+	for {
+		l.mu.Lock()
+		released, ok := l.held[key]
+		if !ok {
+			l.held[key] = make(chan struct{})
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// unlock releases the locks of all keys.
+func (l *keyedLocks) unlock(keys []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if released, ok := l.held[key]; ok {
+			close(released)
+			delete(l.held, key)
+		}
+	}
+}
+
+// serializeOperations acquires the serialization locks of the operations with the given module
+// contexts and returns a function that releases them. Operations of modules that do not declare a
+// serialization key, and operations with an empty key, are not serialized.
+func serializeOperations(ctx context.Context, info ModuleInfo, mctxs ...ModuleContext) (func(), error) {
+	if info.SerializationKey == nil {
+		return func() {}, nil
+	}
+	keys := make([]string, 0, len(mctxs))
+	for _, mctx := range mctxs {
+		key, err := info.SerializationKey(mctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine serialization key: %w", err)
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return operationLocks.lock(ctx, keys)
+}
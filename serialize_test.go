@@ -0,0 +1,64 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyedLocks_SameKeyWaits(t *testing.T) {
+	l := newKeyedLocks()
+	unlock, err := l.lock(context.Background(), []string{"a"})
+	require.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		unlockB, lockErr := l.lock(context.Background(), []string{"b", "a"})
+		if lockErr == nil {
+			unlockB()
+		}
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock on a held key was acquired")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("lock was not acquired after release")
+	}
+	assert.Empty(t, l.held)
+}
+
+func TestKeyedLocks_DuplicateAndDistinctKeys(t *testing.T) {
+	l := newKeyedLocks()
+	unlockA, err := l.lock(context.Background(), []string{"a", "a"})
+	require.NoError(t, err)
+	unlockB, err := l.lock(context.Background(), []string{"b"})
+	require.NoError(t, err)
+	unlockA()
+	unlockB()
+	assert.Empty(t, l.held)
+}
+
+func TestKeyedLocks_ContextCanceled(t *testing.T) {
+	l := newKeyedLocks()
+	unlock, err := l.lock(context.Background(), []string{"b"})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = l.lock(ctx, []string{"a", "b"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The lock on "a" acquired before waiting on "b" is released.
+	unlock()
+	assert.Empty(t, l.held)
+}
@@ -0,0 +1,156 @@
+package blackstart
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// ServiceCheckTCP is the kind of service checks that open a TCP connection to the address
+	// parameter, such as the host and port of a PostgreSQL server.
+	ServiceCheckTCP = "tcp"
+
+	// ServiceCheckKubernetes is the kind of service checks that request the version of the
+	// Kubernetes API server with a client of the runtime. The namespace parameter selects the
+	// namespace the client is requested for.
+	ServiceCheckKubernetes = "kubernetes"
+)
+
+// DefaultServiceCheckTimeout is the timeout of a service check that does not set one.
+const DefaultServiceCheckTimeout = 10 * time.Second
+
+// ServiceCheck is a connectivity check of a service used by the operations of a workflow, such as
+// the Kubernetes API server or a database host. Service checks run in the Preflight phase before
+// the operations are set up, so a workflow with an unreachable service fails before its first
+// operation instead of with the error of a module.
+type ServiceCheck struct {
+	// Name identifies the check in errors and logs. It must be unique within the workflow.
+	Name string
+
+	// Kind is the kind of the check, such as ServiceCheckTCP. Modules register further kinds with
+	// RegisterServiceCheck.
+	Kind string
+
+	// Params are the parameters of the check, such as the address of a ServiceCheckTCP check.
+	Params map[string]string
+
+	// Timeout limits the duration of the check. Zero uses DefaultServiceCheckTimeout.
+	Timeout time.Duration
+}
+
+// ServiceChecker verifies that a service is reachable with the parameters of a service check. It
+// must not make changes and should return once the service responded.
+type ServiceChecker func(ctx context.Context, params map[string]string) error
+
+var registeredServiceCheckers = map[string]ServiceChecker{
+	ServiceCheckTCP:        checkTCPService,
+	ServiceCheckKubernetes: checkKubernetesService,
+}
+
+// RegisterServiceCheck is used by modules to register the checker of a kind of service checks,
+// such as a check of the credentials of a cloud provider.
+func RegisterServiceCheck(kind string, checker ServiceChecker) {
+	if checker == nil {
+		panic(fmt.Errorf("invalid service check registration %q: checker is nil", kind))
+	}
+	registeredServiceCheckers[kind] = checker
+}
+
+// ServiceCheckKinds returns the registered kinds of service checks, sorted by name.
+func ServiceCheckKinds() []string {
+	kinds := make([]string, 0, len(registeredServiceCheckers))
+	for kind := range registeredServiceCheckers {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// checkServiceChecks validates the service checks of the workflow. Each check must have a unique
+// name and a registered kind.
+func (we *workflowExecution) checkServiceChecks() error {
+	names := make(map[string]struct{}, len(we.w.ServiceChecks))
+	for _, check := range we.w.ServiceChecks {
+		if check.Name == "" {
+			return fmt.Errorf("service check of kind %q has no name", check.Kind)
+		}
+		if _, ok := names[check.Name]; ok {
+			return fmt.Errorf("duplicate service check %q in workflow", check.Name)
+		}
+		names[check.Name] = struct{}{}
+		if _, ok := registeredServiceCheckers[check.Kind]; !ok {
+			return fmt.Errorf(
+				"service check %q has unknown kind %q: expected one of %s",
+				check.Name, check.Kind, strings.Join(ServiceCheckKinds(), ", "),
+			)
+		}
+	}
+	return nil
+}
+
+// runServiceChecks runs the service checks of the workflow in order. All checks are run, and
+// failures are combined into a single error.
+func (we *workflowExecution) runServiceChecks(ctx context.Context) error {
+	if err := we.checkServiceChecks(); err != nil {
+		return err
+	}
+	var failures []string
+	for _, check := range we.w.ServiceChecks {
+		timeout := check.Timeout
+		if timeout <= 0 {
+			timeout = DefaultServiceCheckTimeout
+		}
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		we.logger.Debug("service check", "check", check.Name, "kind", check.Kind)
+		err := registeredServiceCheckers[check.Kind](checkCtx, check.Params)
+		cancel()
+		if err != nil {
+			we.logger.Warn("service check failed", "check", check.Name, "kind", check.Kind, "error", err)
+			failures = append(failures, fmt.Sprintf("service check %q: %v", check.Name, err))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"preflight failed for %d service check(s): %s", len(failures), strings.Join(failures, "; "),
+	)
+}
+
+// checkTCPService opens a TCP connection to the address parameter and closes it.
+func checkTCPService(ctx context.Context, params map[string]string) error {
+	address := strings.TrimSpace(params["address"])
+	if address == "" {
+		return fmt.Errorf("parameter address is required")
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// checkKubernetesService requests the version of the Kubernetes API server with a client of the
+// KubeClientProvider of the runtime. Clients without a REST client, such as fake clients, are
+// checked without the deadline of the context.
+func checkKubernetesService(ctx context.Context, params map[string]string) error {
+	provider, ok := ctx.Value(KubeClientProviderKey).(KubeClientProvider)
+	if !ok || provider == nil {
+		return ErrKubeClientUnavailable
+	}
+	client, err := provider.KubeClient(ctx, params["namespace"], "")
+	if err != nil {
+		return err
+	}
+	rc := client.Discovery().RESTClient()
+	if rc == nil {
+		_, err = client.Discovery().ServerVersion()
+		return err
+	}
+	return rc.Get().AbsPath("/version").Do(ctx).Error()
+}
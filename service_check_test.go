@@ -0,0 +1,113 @@
+package blackstart
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// closedAddress returns the address of a TCP port that no longer accepts connections.
+func closedAddress(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := l.Addr().String()
+	require.NoError(t, l.Close())
+	return address
+}
+
+func TestWorkflowExecution_ServiceChecks(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+
+	preflightTestSets.Store(0)
+	wf := Workflow{
+		Name: "service-checks",
+		ServiceChecks: []ServiceCheck{
+			{Name: "database", Kind: ServiceCheckTCP, Params: map[string]string{"address": l.Addr().String()}},
+			{Name: "cluster", Kind: ServiceCheckKubernetes},
+		},
+		Operations: []Operation{{Id: "a", Module: "preflight_test_module"}},
+	}
+	ctx := context.WithValue(
+		context.Background(), KubeClientProviderKey, &publishTestProvider{client: fake.NewClientset()},
+	)
+
+	res := wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, int32(1), preflightTestSets.Load())
+
+	// Failed checks stop the run before the operations are set up, so the unknown module of an
+	// operation is not reported.
+	wf.ServiceChecks = append(
+		wf.ServiceChecks,
+		ServiceCheck{Name: "replica", Kind: ServiceCheckTCP, Params: map[string]string{"address": closedAddress(t)}},
+		ServiceCheck{Name: "no-address", Kind: ServiceCheckTCP},
+	)
+	wf.Operations = append(wf.Operations, Operation{Id: "b", Module: "unknown_module"})
+	res = wf.Run(context.Background())
+	require.Error(t, res.Err)
+	assert.Equal(t, phasePreflight, res.Phase)
+	assert.Nil(t, res.Op)
+	assert.Equal(t, 2, res.TotalOperations)
+	assert.Zero(t, res.CompletedOperations)
+	assert.Contains(t, res.Err.Error(), "preflight failed for 3 service check(s)")
+	assert.Contains(t, res.Err.Error(), `service check "cluster": `+ErrKubeClientUnavailable.Error())
+	assert.Contains(t, res.Err.Error(), `service check "replica": `)
+	assert.Contains(t, res.Err.Error(), `service check "no-address": parameter address is required`)
+	assert.NotContains(t, res.Err.Error(), `"database"`)
+	assert.Equal(t, int32(1), preflightTestSets.Load())
+}
+
+func TestWorkflowExecution_InvalidServiceChecks(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []ServiceCheck
+		want   string
+	}{
+		{
+			name:   "no name",
+			checks: []ServiceCheck{{Kind: ServiceCheckTCP}},
+			want:   `service check of kind "tcp" has no name`,
+		},
+		{
+			name: "duplicate name",
+			checks: []ServiceCheck{
+				{Name: "db", Kind: ServiceCheckTCP},
+				{Name: "db", Kind: ServiceCheckKubernetes},
+			},
+			want: `duplicate service check "db" in workflow`,
+		},
+		{
+			name:   "unknown kind",
+			checks: []ServiceCheck{{Name: "db", Kind: "ping"}},
+			want:   `service check "db" has unknown kind "ping": expected one of `,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				wf := Workflow{
+					Name:          "service-checks",
+					ServiceChecks: tt.checks,
+					Operations:    []Operation{{Id: "a", Module: "preflight_test_module"}},
+				}
+
+				// Validating the workflow does not run the checks.
+				res := wf.Validate(context.Background())
+				assert.Equal(t, phaseValidate, res.Phase)
+				assert.ErrorContains(t, res.Err, tt.want)
+
+				res = wf.Run(context.Background())
+				assert.Equal(t, phasePreflight, res.Phase)
+				assert.ErrorContains(t, res.Err, tt.want)
+			},
+		)
+	}
+}
@@ -0,0 +1,294 @@
+package blackstart
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// operationState is the state of an operation stored in the StateStore between runs.
+type operationState struct {
+	// InputsHash is the hash of the resolved inputs of the last successful run of the operation.
+	InputsHash string `json:"inputsHash,omitempty"`
+
+	// Succeeded is the time the operation last completed successfully with InputsHash.
+	Succeeded time.Time `json:"succeeded,omitempty"`
+
+	// Resources are the resources reported by the last successful run of the operation. They are
+	// reported again as managed resources when the operation is skipped.
+	Resources []string `json:"resources,omitempty"`
+
+	// ImmutableInputs are the hashes of the immutable inputs of the last successful run of the
+	// operation, by input key. They are kept when the operation fails, so a changed immutable input
+	// taints the operation until it is replaced.
+	ImmutableInputs map[string]string `json:"immutableInputs,omitempty"`
+}
+
+// inputsHash returns a hash of the module, the doesNotExist flag, and the resolved inputs of an
+// operation. Inputs are hashed in key order, so the hash is stable between runs. Scalars, slices,
+// and maps are hashed with their JSON encoding. Other values, such as the resources output by other
+// modules, are hashed with all of their fields, since the JSON encoding of a struct omits its
+// unexported fields.
+func inputsHash(op *Operation, mctx *moduleContext) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s\x00%t\x00", op.Module, op.DoesNotExist)
+	for _, key := range slices.Sorted(maps.Keys(mctx.inputValues)) {
+		_, _ = fmt.Fprintf(h, "%s\x00", key)
+		writeHashedValue(h, mctx.inputValues[key].Any())
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeHashedValue writes a value to a hash. Scalars, slices, and maps are written with their JSON
+// encoding, and other values with all of their fields.
+func writeHashedValue(w io.Writer, value any) {
+	data, err := json.Marshal(value)
+	if err != nil || !jsonHashable(reflect.ValueOf(value)) {
+		writeValue(w, reflect.ValueOf(value), make(map[uintptr]struct{}))
+		return
+	}
+	_, _ = w.Write(data)
+}
+
+// immutableInputs returns the hashes of the resolved inputs an operation's module marks as
+// immutable, by input key. It returns nil when the module has no immutable inputs.
+func immutableInputs(info ModuleInfo, mctx *moduleContext) map[string]string {
+	var hashes map[string]string
+	for key, input := range info.Inputs {
+		if !input.Immutable {
+			continue
+		}
+		if hashes == nil {
+			hashes = make(map[string]string)
+		}
+		h := sha256.New()
+		if value, ok := mctx.inputValues[key]; ok {
+			writeHashedValue(h, value.Any())
+		}
+		hashes[key] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes
+}
+
+// jsonHashable reports whether the JSON encoding of a value holds all of its contents: it is a
+// scalar, or a slice or map of them.
+func jsonHashable(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid, reflect.Bool, reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if !jsonHashable(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		for it := v.MapRange(); it.Next(); {
+			if !jsonHashable(it.Value()) {
+				return false
+			}
+		}
+		return true
+	case reflect.Interface:
+		return jsonHashable(v.Elem())
+	}
+	return false
+}
+
+// writeValue writes the type and contents of a value, including unexported struct fields and the
+// values that pointers refer to. Map entries are written in the order of their keys. Struct fields
+// of interface types, such as the API clients of a resource, functions, and channels are written as
+// their type only, and pointers already written as a cycle marker.
+func writeValue(w io.Writer, v reflect.Value, seen map[uintptr]struct{}) {
+	if !v.IsValid() {
+		_, _ = io.WriteString(w, "nil")
+		return
+	}
+	_, _ = fmt.Fprintf(w, "%s(", v.Type())
+	defer func() { _, _ = io.WriteString(w, ")") }()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			_, _ = io.WriteString(w, "nil")
+			return
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			_, _ = io.WriteString(w, "cycle")
+			return
+		}
+		seen[v.Pointer()] = struct{}{}
+		writeValue(w, v.Elem(), seen)
+	case reflect.Interface:
+		writeValue(w, v.Elem(), seen)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			_, _ = fmt.Fprintf(w, "%s:", v.Type().Field(i).Name)
+			if f := v.Field(i); f.Kind() == reflect.Interface && !f.IsNil() {
+				_, _ = fmt.Fprintf(w, "%s", f.Elem().Type())
+				continue
+			}
+			writeValue(w, v.Field(i), seen)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			_, _ = io.WriteString(w, "nil")
+			return
+		}
+		for i := range v.Len() {
+			writeValue(w, v.Index(i), seen)
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			_, _ = io.WriteString(w, "nil")
+			return
+		}
+		entries := make(map[string]reflect.Value, v.Len())
+		for it := v.MapRange(); it.Next(); {
+			var key strings.Builder
+			writeValue(&key, it.Key(), seen)
+			entries[key.String()] = it.Value()
+		}
+		for _, key := range slices.Sorted(maps.Keys(entries)) {
+			_, _ = fmt.Fprintf(w, "%s:", key)
+			writeValue(w, entries[key], seen)
+		}
+	case reflect.Bool:
+		_, _ = fmt.Fprint(w, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, _ = fmt.Fprint(w, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, _ = fmt.Fprint(w, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		_, _ = fmt.Fprint(w, v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		_, _ = fmt.Fprint(w, v.Complex())
+	case reflect.String:
+		_, _ = fmt.Fprintf(w, "%q", v.String())
+	default:
+		// Functions, channels, and unsafe pointers have no comparable contents.
+	}
+}
+
+// skippable returns the operations whose check may be skipped when their inputs are unchanged.
+// Operations that other operations depend on are never skipped, since a skipped operation does not
+// produce outputs. Tainted operations are never skipped.
+func skippable(operations map[string]*Operation) map[string]bool {
+	dependedOn := make(map[string]struct{})
+	for _, op := range operations {
+		for _, dep := range operationDependencies(op) {
+			dependedOn[dep] = struct{}{}
+		}
+	}
+	result := make(map[string]bool, len(operations))
+	for id, op := range operations {
+		_, ok := dependedOn[id]
+		result[id] = !ok && !op.Tainted
+	}
+	return result
+}
+
+// unchanged reports whether the operation last succeeded with the same inputs within the
+// SkipUnchangedFor duration of the workflow, and returns the resources reported by that run. Errors
+// reading the state are logged and the operation is treated as changed.
+func (we *workflowExecution) unchanged(
+	ctx context.Context, store StateStore, op *Operation, hash string,
+) ([]string, bool) {
+	state, ok := we.operationState(ctx, store, op)
+	if !ok || state.InputsHash != hash || time.Since(state.Succeeded) >= we.w.SkipUnchangedFor {
+		return nil, false
+	}
+	return state.Resources, true
+}
+
+// operationState reads the stored state of an operation. Errors reading the state are logged and
+// reported as no state.
+func (we *workflowExecution) operationState(
+	ctx context.Context, store StateStore, op *Operation,
+) (operationState, bool) {
+	var state operationState
+	data, err := store.Get(ctx, we.w.StateKey(op.Id))
+	if err != nil {
+		if !errors.Is(err, ErrStateNotFound) {
+			we.logger.Warn("unable to read operation state", "module", op.Module, "id", op.Id, "error", err)
+		}
+		return state, false
+	}
+	if err = json.Unmarshal(data, &state); err != nil {
+		we.logger.Warn("unable to decode operation state", "module", op.Module, "id", op.Id, "error", err)
+		return state, false
+	}
+	return state, true
+}
+
+// taintChangedInputs taints an operation when an immutable input differs from the last successful
+// run of the operation, so the module replaces the resource. The stored hashes are kept in the
+// context to be recorded again if the operation fails. Operations without a stored state are not
+// tainted.
+func (we *workflowExecution) taintChangedInputs(
+	ctx context.Context, store StateStore, op *Operation, mctx *moduleContext,
+) {
+	if store == nil || op.DoesNotExist {
+		return
+	}
+	current := immutableInputs(we.moduleInfo[op.Id], mctx)
+	if len(current) == 0 {
+		return
+	}
+	state, ok := we.operationState(ctx, store, op)
+	if !ok || len(state.ImmutableInputs) == 0 {
+		return
+	}
+	mctx.storedImmutableInputs = state.ImmutableInputs
+	var changed []string
+	for key, hash := range current {
+		if stored, ok := state.ImmutableInputs[key]; ok && stored != hash {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return
+	}
+	slices.Sort(changed)
+	we.logger.Warn(
+		"operation tainted, immutable inputs changed", "module", op.Module, "id", op.Id, "inputs", changed,
+	)
+	mctx.tainted = true
+}
+
+// recordInputs stores the inputs hash, immutable input hashes, and resources of an operation after
+// it was run. The state is cleared when the operation failed, so the next run checks the operation,
+// except for the immutable input hashes of the last successful run. Errors writing the state are
+// logged.
+func (we *workflowExecution) recordInputs(
+	ctx context.Context, store StateStore, op *Operation, mctx *moduleContext, hash string, succeeded bool,
+) {
+	state := operationState{ImmutableInputs: mctx.storedImmutableInputs}
+	if succeeded {
+		state = operationState{
+			InputsHash:      hash,
+			Succeeded:       time.Now().UTC(),
+			Resources:       mctx.resources,
+			ImmutableInputs: immutableInputs(we.moduleInfo[op.Id], mctx),
+		}
+	}
+	data, err := json.Marshal(state)
+	if err == nil {
+		err = store.Put(ctx, we.w.StateKey(op.Id), data)
+	}
+	if err != nil {
+		we.logger.Warn("unable to store operation state", "module", op.Module, "id", op.Id, "error", err)
+	}
+}
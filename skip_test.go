@@ -0,0 +1,262 @@
+package blackstart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapStateStore is a StateStore that keeps state in a map.
+type mapStateStore struct {
+	mu     sync.Mutex
+	states map[StateKey][]byte
+}
+
+func (s *mapStateStore) Get(_ context.Context, key StateKey) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.states[key]
+	if !ok {
+		return nil, ErrStateNotFound
+	}
+	return value, nil
+}
+
+func (s *mapStateStore) Put(_ context.Context, key StateKey, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = value
+	return nil
+}
+
+func skipTestWorkflow(resource string) *Workflow {
+	return &Workflow{
+		Name:             "skip-test",
+		SkipUnchangedFor: time.Hour,
+		Operations: []Operation{
+			{Id: "a", Module: "resource_test_module", Inputs: map[string]Input{"resource": NewInputFromValue("ns/a")}},
+			{
+				Id:        "b",
+				Module:    "resource_test_module",
+				DependsOn: []string{"a"},
+				Inputs:    map[string]Input{"resource": NewInputFromValue(resource)},
+			},
+		},
+	}
+}
+
+func skippedOperations(res WorkflowResult) []string {
+	var skipped []string
+	for _, op := range res.Operations {
+		if op.Skipped {
+			skipped = append(skipped, op.Id)
+		}
+	}
+	return skipped
+}
+
+func TestWorkflowExecution_SkipUnchanged(t *testing.T) {
+	store := &mapStateStore{states: make(map[StateKey][]byte)}
+	ctx := context.WithValue(context.Background(), StateStoreKey, store)
+
+	res := skipTestWorkflow("ns/b").Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Empty(t, skippedOperations(res))
+
+	// The operation that others depend on is always run, and the resources of the skipped
+	// operation are still reported.
+	res = skipTestWorkflow("ns/b").Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, []string{"b"}, skippedOperations(res))
+	assert.Equal(t, 2, res.CompletedOperations)
+	assert.Equal(
+		t, []ManagedResource{
+			{Id: "ns/a", Module: "resource_test_module", OperationId: "a"},
+			{Id: "ns/b", Module: "resource_test_module", OperationId: "b"},
+		}, res.ManagedResources,
+	)
+
+	// Changed inputs are run.
+	res = skipTestWorkflow("ns/c").Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Empty(t, skippedOperations(res))
+
+	// Operations that last succeeded before the skip duration are run.
+	var state operationState
+	key := StateKey{Workflow: "skip-test", Operation: "b"}
+	require.NoError(t, json.Unmarshal(store.states[key], &state))
+	state.Succeeded = state.Succeeded.Add(-2 * time.Hour)
+	store.states[key], _ = json.Marshal(state)
+	res = skipTestWorkflow("ns/c").Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Empty(t, skippedOperations(res))
+
+	// Skipping is disabled without a skip duration or a state store.
+	wf := skipTestWorkflow("ns/c")
+	wf.SkipUnchangedFor = 0
+	res = wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Empty(t, skippedOperations(res))
+	res = skipTestWorkflow("ns/c").Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Empty(t, skippedOperations(res))
+}
+
+func TestWorkflowExecution_SkipUnchangedTainted(t *testing.T) {
+	store := &mapStateStore{states: make(map[StateKey][]byte)}
+	ctx := context.WithValue(context.Background(), StateStoreKey, store)
+
+	res := skipTestWorkflow("ns/b").Run(ctx)
+	require.NoError(t, res.Err)
+	wf := skipTestWorkflow("ns/b")
+	wf.Operations[1].Tainted = true
+	res = wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Empty(t, skippedOperations(res))
+}
+
+// testResource is a resource output by a module with unexported fields, like the Secret of the
+// kubernetes_secret module.
+type testResource struct {
+	client fmt.Stringer
+	data   map[string][]byte
+}
+
+func TestInputsHash(t *testing.T) {
+	op := &Operation{Id: "a", Module: "resource_test_module"}
+	hash := func(value any) string {
+		mctx := newModuleContext(context.Background(), op)
+		mctx.setInput("resource", value)
+		return inputsHash(op, mctx)
+	}
+
+	secret := func(password string, client fmt.Stringer) *testResource {
+		return &testResource{
+			client: client,
+			data:   map[string][]byte{"user": []byte("app"), "password": []byte(password)},
+		}
+	}
+	// Equal resources have the same hash, even when they are different objects with different
+	// clients of the same type.
+	assert.Equal(t, hash(secret("old", time.Second)), hash(secret("old", 2*time.Second)))
+	// A changed value of the resource changes the hash.
+	assert.NotEqual(t, hash(secret("old", time.Second)), hash(secret("new", time.Second)))
+	assert.NotEqual(t, hash(secret("old", time.Second)), hash(&testResource{}))
+
+	assert.Equal(t, hash(map[string]any{"a": 1, "b": []string{"x"}}), hash(map[string]any{"b": []string{"x"}, "a": 1}))
+	assert.NotEqual(t, hash("a"), hash("b"))
+
+	// Cyclic values are hashed.
+	type node struct{ next *node }
+	n := &node{}
+	n.next = n
+	assert.NotEmpty(t, hash(n))
+}
+
+// immutableTestModule outputs whether its operation is tainted when it is checked. Its name input is
+// immutable, and Set fails when the fail input is set.
+type immutableTestModule struct{}
+
+func init() {
+	RegisterModule("immutable_test_module", func() Module { return &immutableTestModule{} })
+}
+
+func (m *immutableTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "immutable_test_module",
+		Inputs: map[string]InputValue{
+			"name": {Type: reflect.TypeFor[string](), Required: true, Immutable: true},
+			"size": {Type: reflect.TypeFor[int](), Required: false, Default: 1},
+			"fail": {Type: reflect.TypeFor[bool](), Required: false, Default: false},
+		},
+		Outputs: map[string]OutputValue{
+			"tainted": {Type: reflect.TypeFor[bool]()},
+		},
+	}
+}
+
+func (m *immutableTestModule) Validate(_ Operation) error { return nil }
+func (m *immutableTestModule) Check(ctx ModuleContext) (bool, error) {
+	return false, ctx.Output("tainted", ctx.Tainted())
+}
+func (m *immutableTestModule) Set(ctx ModuleContext) error {
+	fail, err := ContextInputAs[bool](ctx, "fail", false)
+	if err != nil {
+		return err
+	}
+	if fail {
+		return fmt.Errorf("set failed")
+	}
+	return nil
+}
+
+func immutableTestWorkflow(name string, size int, fail bool) *Workflow {
+	return &Workflow{
+		Name: "immutable-test",
+		Operations: []Operation{
+			{
+				Id:     "a",
+				Module: "immutable_test_module",
+				Inputs: map[string]Input{
+					"name": NewInputFromValue(name),
+					"size": NewInputFromValue(size),
+					"fail": NewInputFromValue(fail),
+				},
+			},
+		},
+	}
+}
+
+func TestWorkflowExecution_ImmutableInputs(t *testing.T) {
+	store := &mapStateStore{states: make(map[StateKey][]byte)}
+	ctx := context.WithValue(context.Background(), StateStoreKey, store)
+	tainted := func(res WorkflowResult) string {
+		require.Len(t, res.Operations, 1)
+		return res.Operations[0].Outputs["tainted"]
+	}
+
+	// The first run has no state to compare with.
+	res := immutableTestWorkflow("a", 1, false).Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "false", tainted(res))
+	require.Contains(t, store.states, StateKey{Workflow: "immutable-test", Operation: "a"})
+
+	// Inputs that are not immutable may change.
+	res = immutableTestWorkflow("a", 2, false).Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "false", tainted(res))
+
+	// A changed immutable input taints the operation until it succeeds.
+	res = immutableTestWorkflow("b", 2, true).Run(ctx)
+	require.Error(t, res.Err)
+	assert.Equal(t, "true", tainted(res))
+	res = immutableTestWorkflow("b", 2, false).Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "true", tainted(res))
+	res = immutableTestWorkflow("b", 2, false).Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "false", tainted(res))
+
+	// Check-only runs report the drift but do not record the new value.
+	wf := immutableTestWorkflow("c", 2, false)
+	wf.CheckOnly = true
+	res = wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "true", tainted(res))
+	assert.True(t, res.Operations[0].Drifted)
+	res = wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Equal(t, "true", tainted(res))
+
+	// Changes are not detected without a state store.
+	res = immutableTestWorkflow("c", 2, false).Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, "false", tainted(res))
+}
@@ -0,0 +1,57 @@
+package blackstart
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrStateNotFound is returned by a StateStore when no state is stored for a key.
+var ErrStateNotFound = errors.New("state not found")
+
+// StateKey identifies the state of an operation of a workflow.
+type StateKey struct {
+	// Namespace is the Kubernetes namespace of the workflow. It is empty for file-based workflows.
+	Namespace string
+
+	// Workflow is the name of the workflow.
+	Workflow string
+
+	// Operation is the identifier of the operation.
+	Operation string
+}
+
+// StateStore persists the state of workflow operations between runs. The state of an operation is
+// an opaque value owned by the feature that writes it. Implementations must be safe for concurrent
+// use, since workflows run in parallel.
+// --8<-- [start:StateStore]
+type StateStore interface {
+	// Get returns the state stored for the key. ErrStateNotFound is returned when no state is
+	// stored.
+	Get(ctx context.Context, key StateKey) ([]byte, error)
+
+	// Put stores the state for the key, replacing any stored state.
+	Put(ctx context.Context, key StateKey, value []byte) error
+}
+
+// --8<-- [end:StateStore]
+
+// ContextStateStore returns the StateStore configured for the run, or nil if no state store is
+// configured.
+func ContextStateStore(ctx context.Context) StateStore {
+	store, _ := ctx.Value(StateStoreKey).(StateStore)
+	return store
+}
+
+// stateStore returns the StateStore used by the run. Replayed runs do not read or write the stored
+// state, so they do not depend on or change the state of the recorded workflow.
+func (we *workflowExecution) stateStore(ctx context.Context) StateStore {
+	if we.w.Replay != nil {
+		return nil
+	}
+	return ContextStateStore(ctx)
+}
+
+// StateKey returns the key of the state of an operation of the workflow.
+func (w *Workflow) StateKey(operation string) StateKey {
+	return StateKey{Namespace: w.Namespace, Workflow: w.Name, Operation: operation}
+}
@@ -0,0 +1,112 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pezops/blackstart"
+)
+
+// configMapPrefix is the prefix of the names of ConfigMaps storing workflow state.
+const configMapPrefix = "blackstart-state-"
+
+// configMapWorkflowLabel is the label of state ConfigMaps set to the name of the workflow.
+const configMapWorkflowLabel = "blackstart.pezops.github.io/workflow"
+
+var _ blackstart.StateStore = &ConfigMapStore{}
+
+// ConfigMapStore is a StateStore that keeps the state of each workflow in a ConfigMap named
+// blackstart-state-<workflow> in the namespace of the workflow. State is stored as binary data
+// keyed by operation identifier.
+type ConfigMapStore struct {
+	client client.Client
+}
+
+// NewConfigMapStore creates a ConfigMapStore using the Kubernetes client.
+func NewConfigMapStore(c client.Client) *ConfigMapStore {
+	return &ConfigMapStore{client: c}
+}
+
+// Get returns the state stored in the ConfigMap of the workflow.
+func (s *ConfigMapStore) Get(ctx context.Context, key blackstart.StateKey) ([]byte, error) {
+	var cm corev1.ConfigMap
+	err := s.client.Get(ctx, client.ObjectKey{Namespace: key.Namespace, Name: configMapName(key)}, &cm)
+	if apierrors.IsNotFound(err) {
+		return nil, blackstart.ErrStateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting state ConfigMap: %w", err)
+	}
+	value, ok := cm.BinaryData[configMapKey(key.Operation)]
+	if !ok {
+		return nil, blackstart.ErrStateNotFound
+	}
+	return slices.Clone(value), nil
+}
+
+// Put stores the state in the ConfigMap of the workflow, creating the ConfigMap if it does not
+// exist.
+func (s *ConfigMapStore) Put(ctx context.Context, key blackstart.StateKey, value []byte) error {
+	if key.Namespace == "" {
+		return fmt.Errorf("the %s state store requires workflows from Kubernetes", StoreConfigMap)
+	}
+	name := client.ObjectKey{Namespace: key.Namespace, Name: configMapName(key)}
+	err := retry.RetryOnConflict(
+		retry.DefaultBackoff, func() error {
+			var cm corev1.ConfigMap
+			err := s.client.Get(ctx, name, &cm)
+			if apierrors.IsNotFound(err) {
+				cm = corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name.Name,
+						Namespace: name.Namespace,
+						Labels:    map[string]string{configMapWorkflowLabel: key.Workflow},
+					},
+					BinaryData: map[string][]byte{configMapKey(key.Operation): slices.Clone(value)},
+				}
+				return s.client.Create(ctx, &cm)
+			}
+			if err != nil {
+				return err
+			}
+			if cm.BinaryData == nil {
+				cm.BinaryData = make(map[string][]byte)
+			}
+			cm.BinaryData[configMapKey(key.Operation)] = slices.Clone(value)
+			return s.client.Update(ctx, &cm)
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error updating state ConfigMap: %w", err)
+	}
+	return nil
+}
+
+// configMapName returns the name of the ConfigMap storing the state of the workflow of a key.
+func configMapName(key blackstart.StateKey) string {
+	return configMapPrefix + key.Workflow
+}
+
+// configMapKey returns the ConfigMap key of an operation. ConfigMap keys may only contain
+// alphanumeric characters, '-', '_', and '.', so other characters and '_' are escaped as '_'
+// followed by their hexadecimal value. For example, "db/user" is stored as "db_2fuser".
+func configMapKey(operation string) string {
+	var b strings.Builder
+	for _, c := range []byte(operation) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '.':
+			b.WriteByte(c)
+		default:
+			_, _ = fmt.Fprintf(&b, "_%02x", c)
+		}
+	}
+	return b.String()
+}
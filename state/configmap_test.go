@@ -0,0 +1,37 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/pezops/blackstart"
+)
+
+func TestConfigMapStore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	testStore(t, NewConfigMapStore(c), "team-a")
+
+	var cm corev1.ConfigMap
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "blackstart-state-demo"}, &cm))
+	assert.Equal(t, "demo", cm.Labels[configMapWorkflowLabel])
+	assert.Equal(t, []byte("second"), cm.BinaryData["db_2fuser"])
+	assert.Equal(t, []byte("other"), cm.BinaryData["db_5fuser"])
+
+	err := NewConfigMapStore(c).Put(context.Background(), blackstart.StateKey{Workflow: "demo", Operation: "op"}, nil)
+	assert.ErrorContains(t, err, "requires workflows from Kubernetes")
+}
+
+func TestConfigMapKey(t *testing.T) {
+	assert.Equal(t, "create-db.v2", configMapKey("create-db.v2"))
+	assert.Equal(t, "tenant_2fdb_5fuser", configMapKey("tenant/db_user"))
+}
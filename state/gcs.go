@@ -0,0 +1,67 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	gcsapi "google.golang.org/api/storage/v1"
+
+	"github.com/pezops/blackstart"
+)
+
+var _ blackstart.StateStore = &GCSStore{}
+
+// GCSStore is a StateStore that keeps state in Google Cloud Storage objects named
+// <prefix>/<namespace>/<workflow>/<operation>.
+type GCSStore struct {
+	service *gcsapi.Service
+	bucket  string
+	prefix  string
+}
+
+// NewGCSStore creates a GCSStore for the bucket and object name prefix. Application default
+// credentials are used unless other options are given.
+func NewGCSStore(ctx context.Context, bucket, prefix string, opts ...option.ClientOption) (*GCSStore, error) {
+	opts = append([]option.ClientOption{option.WithUserAgent(blackstart.UserAgent)}, opts...)
+	svc, err := gcsapi.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS service: %w", err)
+	}
+	return &GCSStore{service: svc, bucket: bucket, prefix: prefix}, nil
+}
+
+// Get returns the contents of the state object of the key.
+func (s *GCSStore) Get(ctx context.Context, key blackstart.StateKey) ([]byte, error) {
+	name := objectName(s.prefix, key)
+	resp, err := s.service.Objects.Get(s.bucket, name).Context(ctx).Download()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return nil, blackstart.ErrStateNotFound
+		}
+		return nil, fmt.Errorf("error reading state object gs://%s/%s: %w", s.bucket, name, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	return io.ReadAll(resp.Body)
+}
+
+// Put writes the state object of the key.
+func (s *GCSStore) Put(ctx context.Context, key blackstart.StateKey, value []byte) error {
+	name := objectName(s.prefix, key)
+	_, err := s.service.Objects.Insert(s.bucket, &gcsapi.Object{Name: name}).
+		Media(bytes.NewReader(value)).
+		Context(ctx).
+		Do()
+	if err != nil {
+		return fmt.Errorf("error writing state object gs://%s/%s: %w", s.bucket, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+// fakeGCS serves the object download and multipart upload requests of the Storage JSON API.
+// Objects are kept by name.
+func fakeGCS(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				switch {
+				case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/bucket/o/"):
+					name, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/storage/v1/b/bucket/o/"))
+					require.NoError(t, err)
+					body, ok := objects[name]
+					if !ok {
+						http.Error(w, `{"error": {"code": 404, "message": "not found"}}`, http.StatusNotFound)
+						return
+					}
+					_, _ = w.Write(body)
+				case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/bucket/o":
+					_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+					require.NoError(t, err)
+					reader := multipart.NewReader(r.Body, params["boundary"])
+					metadata, err := reader.NextPart()
+					require.NoError(t, err)
+					var object struct {
+						Name string `json:"name"`
+					}
+					require.NoError(t, json.NewDecoder(metadata).Decode(&object))
+					media, err := reader.NextPart()
+					require.NoError(t, err)
+					body, err := io.ReadAll(media)
+					require.NoError(t, err)
+					objects[object.Name] = body
+					_, _ = w.Write([]byte(`{}`))
+				default:
+					http.Error(w, "unexpected request", http.StatusBadRequest)
+				}
+			},
+		),
+	)
+}
+
+func TestGCSStore(t *testing.T) {
+	server := fakeGCS(t)
+	defer server.Close()
+
+	store, err := NewGCSStore(
+		context.Background(), "bucket", "state",
+		option.WithEndpoint(server.URL+"/storage/v1/"),
+		option.WithoutAuthentication(),
+	)
+	require.NoError(t, err)
+	testStore(t, store, "team-a")
+}
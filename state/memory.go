@@ -0,0 +1,42 @@
+package state
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/pezops/blackstart"
+)
+
+var _ blackstart.StateStore = &MemoryStore{}
+
+// MemoryStore is a StateStore that keeps state in memory. State is lost when the process exits, so
+// it is only useful for a long-running controller or for testing.
+type MemoryStore struct {
+	mu     sync.Mutex
+	values map[blackstart.StateKey][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{values: make(map[blackstart.StateKey][]byte)}
+}
+
+// Get returns a copy of the state stored for the key.
+func (s *MemoryStore) Get(_ context.Context, key blackstart.StateKey) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	if !ok {
+		return nil, blackstart.ErrStateNotFound
+	}
+	return slices.Clone(value), nil
+}
+
+// Put stores a copy of the state for the key.
+func (s *MemoryStore) Put(_ context.Context, key blackstart.StateKey, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = slices.Clone(value)
+	return nil
+}
@@ -0,0 +1,83 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/pezops/blackstart"
+)
+
+var _ blackstart.StateStore = &S3Store{}
+
+// S3Store is a StateStore that keeps state in Amazon S3 objects with keys
+// <prefix>/<namespace>/<workflow>/<operation>.
+type S3Store struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3Store creates an S3Store for the bucket and object key prefix. The AWS configuration and
+// credentials are loaded from the default sources, such as the environment and shared
+// configuration files. Requests use the outbound HTTP configuration of blackstart.GetHTTPConfig.
+// Options may be given to customize the S3 client.
+func NewS3Store(ctx context.Context, bucket, prefix string, optFns ...func(*s3.Options)) (*S3Store, error) {
+	httpConfig := blackstart.GetHTTPConfig()
+	if _, err := httpConfig.RootCAs(); err != nil {
+		return nil, err
+	}
+	httpClient := awshttp.NewBuildableClient().WithTransportOptions(
+		func(t *http.Transport) {
+			// The CAs were checked above, so applying the configuration does not fail.
+			_ = httpConfig.Apply(t)
+		},
+	)
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithHTTPClient(httpClient))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return &S3Store{client: s3.NewFromConfig(cfg, optFns...), bucket: bucket, prefix: prefix}, nil
+}
+
+// Get returns the contents of the state object of the key.
+func (s *S3Store) Get(ctx context.Context, key blackstart.StateKey) ([]byte, error) {
+	name := objectName(s.prefix, key)
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(s.bucket), Key: aws.String(name)})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, blackstart.ErrStateNotFound
+		}
+		return nil, fmt.Errorf("error reading state object s3://%s/%s: %w", s.bucket, name, err)
+	}
+	defer func() {
+		_ = out.Body.Close()
+	}()
+	return io.ReadAll(out.Body)
+}
+
+// Put writes the state object of the key.
+func (s *S3Store) Put(ctx context.Context, key blackstart.StateKey, value []byte) error {
+	name := objectName(s.prefix, key)
+	_, err := s.client.PutObject(
+		ctx, &s3.PutObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(name),
+			Body:   bytes.NewReader(value),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error writing state object s3://%s/%s: %w", s.bucket, name, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package state
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves the path-style GetObject and PutObject requests of the S3 API.
+func fakeS3(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	return httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				key := strings.TrimPrefix(r.URL.EscapedPath(), "/bucket/")
+				switch r.Method {
+				case http.MethodGet:
+					body, ok := objects[key]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`))
+						return
+					}
+					_, _ = w.Write(body)
+				case http.MethodPut:
+					body, err := io.ReadAll(r.Body)
+					require.NoError(t, err)
+					objects[key] = body
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			},
+		),
+	)
+}
+
+func TestS3Store(t *testing.T) {
+	server := fakeS3(t)
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+
+	store, err := NewS3Store(
+		context.Background(), "bucket", "state", func(o *s3.Options) {
+			o.BaseEndpoint = aws.String(server.URL)
+			o.UsePathStyle = true
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		},
+	)
+	require.NoError(t, err)
+	testStore(t, store, "team-a")
+}
@@ -0,0 +1,89 @@
+// Package state provides the StateStore implementations used to persist the state of workflow
+// operations between runs.
+package state
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pezops/blackstart"
+)
+
+const (
+	// StoreStatus stores state in the status of the Workflow resource.
+	StoreStatus = "status"
+
+	// StoreConfigMap stores state in a ConfigMap per workflow.
+	StoreConfigMap = "configmap"
+
+	// StoreMemory stores state in memory for the lifetime of the process.
+	StoreMemory = "memory"
+)
+
+// New creates the StateStore selected by spec. The spec is one of "status", "configmap",
+// "memory", "gs://<bucket>/<prefix>", or "s3://<bucket>/<prefix>". An empty spec does not
+// configure a state store and returns nil. The Kubernetes client is required for the status and
+// configmap stores.
+func New(ctx context.Context, spec string, c client.Client) (blackstart.StateStore, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "":
+		return nil, nil
+	case strings.EqualFold(spec, StoreMemory):
+		return NewMemoryStore(), nil
+	case strings.EqualFold(spec, StoreStatus):
+		if c == nil {
+			return nil, fmt.Errorf("the %s state store requires workflows from Kubernetes", StoreStatus)
+		}
+		return NewStatusStore(c), nil
+	case strings.EqualFold(spec, StoreConfigMap):
+		if c == nil {
+			return nil, fmt.Errorf("the %s state store requires workflows from Kubernetes", StoreConfigMap)
+		}
+		return NewConfigMapStore(c), nil
+	case strings.HasPrefix(spec, "gs://"):
+		bucket, prefix, err := parseBucketSpec(spec, "gs://")
+		if err != nil {
+			return nil, err
+		}
+		return NewGCSStore(ctx, bucket, prefix)
+	case strings.HasPrefix(spec, "s3://"):
+		bucket, prefix, err := parseBucketSpec(spec, "s3://")
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Store(ctx, bucket, prefix)
+	default:
+		return nil, fmt.Errorf(
+			"invalid state store %q: expected %s, %s, %s, gs://<bucket>/<prefix>, or s3://<bucket>/<prefix>",
+			spec, StoreStatus, StoreConfigMap, StoreMemory,
+		)
+	}
+}
+
+// parseBucketSpec parses a <scheme><bucket>/<prefix> state store value. The prefix is optional.
+func parseBucketSpec(spec, scheme string) (bucket, prefix string, err error) {
+	bucket, prefix, _ = strings.Cut(strings.TrimPrefix(spec, scheme), "/")
+	if strings.TrimSpace(bucket) == "" {
+		return "", "", fmt.Errorf("invalid state store %q: expected %s<bucket>/<prefix>", spec, scheme)
+	}
+	return bucket, strings.Trim(prefix, "/"), nil
+}
+
+// objectName returns the name of the object storing the state of a key. Each part of the key is
+// escaped, so operation identifiers of workflow instances do not add path segments. The namespace
+// of file-based workflows is stored as "_", which is not a valid namespace name.
+func objectName(prefix string, key blackstart.StateKey) string {
+	namespace := key.Namespace
+	if namespace == "" {
+		namespace = "_"
+	}
+	return path.Join(
+		prefix, url.PathEscape(namespace), url.PathEscape(key.Workflow), url.PathEscape(key.Operation),
+	)
+}
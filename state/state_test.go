@@ -0,0 +1,84 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// testStore verifies the behavior shared by all StateStore implementations.
+func testStore(t *testing.T, store blackstart.StateStore, namespace string) {
+	t.Helper()
+	ctx := context.Background()
+	key := blackstart.StateKey{Namespace: namespace, Workflow: "demo", Operation: "db/user"}
+	other := blackstart.StateKey{Namespace: namespace, Workflow: "demo", Operation: "db_user"}
+
+	_, err := store.Get(ctx, key)
+	require.ErrorIs(t, err, blackstart.ErrStateNotFound)
+
+	require.NoError(t, store.Put(ctx, key, []byte("first")))
+	value, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), value)
+
+	require.NoError(t, store.Put(ctx, key, []byte("second")))
+	require.NoError(t, store.Put(ctx, other, []byte("other")))
+	value, err = store.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), value)
+	value, err = store.Get(ctx, other)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("other"), value)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore(), "")
+}
+
+func TestNew(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := New(ctx, "", nil)
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	store, err = New(ctx, "Memory", nil)
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	_, err = New(ctx, "status", nil)
+	assert.ErrorContains(t, err, "requires workflows from Kubernetes")
+	_, err = New(ctx, "configmap", nil)
+	assert.ErrorContains(t, err, "requires workflows from Kubernetes")
+	_, err = New(ctx, "gs:///prefix", nil)
+	assert.ErrorContains(t, err, "expected gs://<bucket>/<prefix>")
+	_, err = New(ctx, "redis://localhost", nil)
+	assert.ErrorContains(t, err, `invalid state store "redis://localhost"`)
+}
+
+func TestParseBucketSpec(t *testing.T) {
+	bucket, prefix, err := parseBucketSpec("s3://bucket/state/prod/", "s3://")
+	require.NoError(t, err)
+	assert.Equal(t, "bucket", bucket)
+	assert.Equal(t, "state/prod", prefix)
+
+	bucket, prefix, err = parseBucketSpec("gs://bucket", "gs://")
+	require.NoError(t, err)
+	assert.Equal(t, "bucket", bucket)
+	assert.Equal(t, "", prefix)
+}
+
+func TestObjectName(t *testing.T) {
+	assert.Equal(
+		t, "state/team-a/demo/db%2Fuser",
+		objectName("state", blackstart.StateKey{Namespace: "team-a", Workflow: "demo", Operation: "db/user"}),
+	)
+	assert.Equal(
+		t, "_/test%20workflow/op",
+		objectName("", blackstart.StateKey{Workflow: "test workflow", Operation: "op"}),
+	)
+}
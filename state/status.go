@@ -0,0 +1,82 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+var _ blackstart.StateStore = &StatusStore{}
+
+// StatusStore is a StateStore that keeps state in the status of the Workflow resource, keyed by
+// operation identifier. State is removed with the Workflow. Resource size limits apply, so this
+// store is suited to small values.
+type StatusStore struct {
+	client client.Client
+}
+
+// NewStatusStore creates a StatusStore using the Kubernetes client.
+func NewStatusStore(c client.Client) *StatusStore {
+	return &StatusStore{client: c}
+}
+
+// Get returns the state stored in the status of the Workflow.
+func (s *StatusStore) Get(ctx context.Context, key blackstart.StateKey) ([]byte, error) {
+	var wf v1alpha1.Workflow
+	if err := s.client.Get(ctx, workflowName(key), &wf); err != nil {
+		return nil, fmt.Errorf("error getting workflow for state: %w", err)
+	}
+	value, ok := wf.Status.State[key.Operation]
+	if !ok {
+		return nil, blackstart.ErrStateNotFound
+	}
+	return slices.Clone(value), nil
+}
+
+// Put stores the state in the status of the Workflow. Only the state of the operation is written,
+// with a merge patch, so operations that store state at the same time do not conflict and other
+// fields of the status are kept. Transient API errors are retried.
+func (s *StatusStore) Put(ctx context.Context, key blackstart.StateKey, value []byte) error {
+	patch, err := json.Marshal(
+		map[string]any{"status": map[string]any{"state": map[string][]byte{key.Operation: value}}},
+	)
+	if err != nil {
+		return fmt.Errorf("error encoding workflow state: %w", err)
+	}
+	wf := &v1alpha1.Workflow{}
+	wf.Namespace, wf.Name = key.Namespace, key.Workflow
+	err = retry.OnError(
+		retry.DefaultBackoff, RetriableStatusError, func() error {
+			return s.client.Status().Patch(ctx, wf, client.RawPatch(types.MergePatchType, patch))
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error updating workflow state: %w", err)
+	}
+	return nil
+}
+
+// RetriableStatusError reports whether a status write of a Workflow failed with a transient API
+// error.
+func RetriableStatusError(err error) bool {
+	return apierrors.IsConflict(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
+
+// workflowName returns the name of the Workflow resource of a key.
+func workflowName(key blackstart.StateKey) types.NamespacedName {
+	return types.NamespacedName{Namespace: key.Namespace, Name: key.Workflow}
+}
@@ -0,0 +1,86 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/pezops/blackstart"
+	"github.com/pezops/blackstart/api/v1alpha1"
+)
+
+func TestStatusStore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	wf := &v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "team-a"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(wf).WithStatusSubresource(wf).Build()
+
+	testStore(t, NewStatusStore(c), "team-a")
+
+	var latest v1alpha1.Workflow
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "demo"}, &latest))
+	assert.Equal(t, []byte("second"), latest.Status.State["db/user"])
+
+	_, err := NewStatusStore(c).Get(context.Background(), blackstart.StateKey{Namespace: "team-a", Workflow: "missing"})
+	assert.ErrorContains(t, err, "error getting workflow for state")
+}
+
+func TestStatusStore_PatchesOperationState(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	wf := &v1alpha1.Workflow{
+		ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "team-a"},
+		Status: v1alpha1.WorkflowStatus{
+			Phase: "Running",
+			State: map[string][]byte{"kept": []byte("value")},
+		},
+	}
+	var patches atomic.Int32
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(wf).WithStatusSubresource(wf).WithInterceptorFuncs(
+		interceptor.Funcs{
+			SubResourcePatch: func(
+				ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch,
+				opts ...client.SubResourcePatchOption,
+			) error {
+				// The first write fails with a transient error and is retried.
+				if patches.Add(1) == 1 {
+					return apierrors.NewServiceUnavailable("try again")
+				}
+				return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+			},
+		},
+	).Build()
+	store := NewStatusStore(c)
+
+	var wg sync.WaitGroup
+	for _, op := range []string{"a", "b", "c", "d"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, store.Put(context.Background(), blackstart.StateKey{
+				Namespace: "team-a", Workflow: "demo", Operation: op,
+			}, []byte(op)))
+		}()
+	}
+	wg.Wait()
+
+	var latest v1alpha1.Workflow
+	require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "team-a", Name: "demo"}, &latest))
+	assert.Equal(t, "Running", latest.Status.Phase)
+	assert.Equal(
+		t, map[string][]byte{
+			"kept": []byte("value"), "a": []byte("a"), "b": []byte("b"), "c": []byte("c"), "d": []byte("d"),
+		}, latest.Status.State,
+	)
+	assert.Equal(t, int32(5), patches.Load())
+}
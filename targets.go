@@ -0,0 +1,45 @@
+package blackstart
+
+import (
+	"fmt"
+)
+
+// checkTargets validates that each target of the workflow is an operation of the workflow.
+func (we *workflowExecution) checkTargets(operations map[string]*Operation) error {
+	for _, id := range we.w.Targets {
+		if _, ok := operations[id]; !ok {
+			return fmt.Errorf("target %q is not an operation of the workflow", id)
+		}
+	}
+	return nil
+}
+
+// targetedIds returns the operations of sortedIds that are targets of the workflow or that a target
+// depends on, directly or transitively, in the same order. All operations are returned when the
+// workflow has no targets.
+func (we *workflowExecution) targetedIds(sortedIds []string, operations map[string]*Operation) []string {
+	if len(we.w.Targets) == 0 {
+		return sortedIds
+	}
+	targeted := make(map[string]struct{})
+	pending := append([]string(nil), we.w.Targets...)
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, ok := targeted[id]; ok {
+			continue
+		}
+		targeted[id] = struct{}{}
+		if op, ok := operations[id]; ok {
+			pending = append(pending, operationDependencies(op)...)
+		}
+	}
+
+	ids := make([]string, 0, len(targeted))
+	for _, id := range sortedIds {
+		if _, ok := targeted[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
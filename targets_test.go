@@ -0,0 +1,65 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowExecution_Targets(t *testing.T) {
+	batchTestCalls.batches = nil
+	batchTestCalls.sets = nil
+	wf := labelsTestWorkflow()
+	// The db-user operation depends on the app operation, and the failing cache operation is not
+	// run.
+	wf.Targets = []string{"db-user"}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 2, res.CompletedOperations)
+	assert.Equal(t, 4, res.TotalOperations)
+	ids := make([]string, 0, len(res.Operations))
+	for _, op := range res.Operations {
+		ids = append(ids, op.Id)
+	}
+	assert.ElementsMatch(t, []string{"app", "db-user"}, ids)
+	assert.Equal(t, []string{"app", "app"}, batchTestCalls.sets)
+
+	// Targets are combined with their dependencies.
+	batchTestCalls.sets = nil
+	wf = labelsTestWorkflow()
+	wf.Targets = []string{"db", "db-user"}
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 3, res.CompletedOperations)
+	assert.ElementsMatch(t, []string{"app", "db", "app"}, batchTestCalls.sets)
+}
+
+func TestWorkflowExecution_TargetsInvalid(t *testing.T) {
+	wf := labelsTestWorkflow()
+	wf.Targets = []string{"db", "dbb"}
+	res := wf.Validate(context.Background())
+	require.EqualError(t, res.Err, `target "dbb" is not an operation of the workflow`)
+	assert.Equal(t, phaseValidate, res.Phase)
+}
+
+func TestWorkflowExecution_TargetedIds(t *testing.T) {
+	wf := labelsTestWorkflow()
+	operations := make(map[string]*Operation)
+	for i := range wf.Operations {
+		operations[wf.Operations[i].Id] = &wf.Operations[i]
+	}
+	wf.Operations[1].DependsOn = []string{"cache"}
+	sortedIds := []string{"cache", "app", "db", "db-user"}
+
+	we := newWorkflowExecution(&wf, NewTestingLogger())
+	assert.Equal(t, sortedIds, we.targetedIds(sortedIds, operations))
+
+	wf.Targets = []string{"db"}
+	assert.Equal(t, []string{"cache", "db"}, we.targetedIds(sortedIds, operations))
+
+	wf.Targets = []string{"db-user", "cache"}
+	assert.Equal(t, []string{"cache", "app", "db-user"}, we.targetedIds(sortedIds, operations))
+}
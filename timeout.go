@@ -0,0 +1,89 @@
+package blackstart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWorkflowTimeout is returned when a workflow run does not complete within the timeout of the
+// workflow.
+var ErrWorkflowTimeout = errors.New("workflow timed out")
+
+// timeoutGrace is how long a run waits after its timeout for the operation in flight to return. An
+// operation whose module ignores the cancellation of its context is abandoned after the grace
+// period, so the run still ends and does not block the scheduler or the shutdown of the process.
+var timeoutGrace = 30 * time.Second
+
+// inFlightOperation is the operation of a run that is currently checked, set, or preflighted.
+type inFlightOperation struct {
+	phase string
+	op    *Operation
+}
+
+// enter records the operation of a run that is in flight.
+func (we *workflowExecution) enter(phase string, op *Operation) {
+	we.inFlight.Store(&inFlightOperation{phase: phase, op: op})
+}
+
+// executeWithTimeout executes the workflow within the timeout of the workflow. When the timeout
+// expires, the run fails with ErrWorkflowTimeout and the operation in flight.
+func (we *workflowExecution) executeWithTimeout(ctx context.Context) WorkflowResult {
+	if we.w.Timeout <= 0 {
+		return we.execute(ctx)
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, we.w.Timeout, ErrWorkflowTimeout)
+	defer cancel()
+
+	done := make(chan WorkflowResult, 1)
+	go func() {
+		done <- we.execute(ctx)
+	}()
+
+	var result WorkflowResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		grace := time.NewTimer(timeoutGrace)
+		defer grace.Stop()
+		select {
+		case result = <-done:
+		case <-grace.C:
+			result = we.abandonedResult(ctx)
+		}
+	}
+	if result.Err == nil || !errors.Is(context.Cause(ctx), ErrWorkflowTimeout) {
+		return result
+	}
+	result.Err = we.timeoutError(result)
+	return result
+}
+
+// abandonedResult returns the result of a run whose operation in flight did not return within the
+// grace period after the context of the run was cancelled.
+func (we *workflowExecution) abandonedResult(ctx context.Context) WorkflowResult {
+	result := WorkflowResult{Phase: phaseSetup, TotalOperations: len(we.w.Operations), Err: context.Cause(ctx)}
+	if current := we.inFlight.Load(); current != nil {
+		result.Phase = current.phase
+		result.Op = current.op
+		we.logger.Error(
+			"operation did not return after the run was cancelled, abandoning it", "module", current.op.Module,
+			"id", current.op.Id, "grace", timeoutGrace.String(),
+		)
+	}
+	return result
+}
+
+// timeoutError returns the error of a run that failed because its timeout expired, naming the
+// operation that was in flight.
+func (we *workflowExecution) timeoutError(result WorkflowResult) error {
+	msg := fmt.Sprintf("after %s", we.w.Timeout)
+	if result.Op != nil {
+		msg += fmt.Sprintf(" at operation %q", result.Op.Id)
+	}
+	if errors.Is(result.Err, ErrWorkflowTimeout) {
+		return fmt.Errorf("%w %s", ErrWorkflowTimeout, msg)
+	}
+	return fmt.Errorf("%w %s: %w", ErrWorkflowTimeout, msg, result.Err)
+}
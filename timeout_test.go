@@ -0,0 +1,101 @@
+package blackstart
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitTestModule sets its operation when its context is done, returning the error of the context.
+type waitTestModule struct{}
+
+func init() {
+	RegisterModule("wait_test_module", func() Module { return &waitTestModule{} })
+}
+
+func (m *waitTestModule) Info() ModuleInfo {
+	return ModuleInfo{Id: "wait_test_module"}
+}
+
+func (m *waitTestModule) Validate(_ Operation) error { return nil }
+func (m *waitTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *waitTestModule) Set(ctx ModuleContext) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWorkflowTimeout_CancelsOperation(t *testing.T) {
+	wf := slowTestWorkflow("1ms")
+	wf.Operations = append(
+		wf.Operations,
+		Operation{Id: "wait", Module: "wait_test_module", DependsOn: []string{"slow"}},
+		Operation{Id: "after", Module: "slow_test_module", DependsOn: []string{"wait"},
+			Inputs: map[string]Input{"duration": NewInputFromValue("1ms")}},
+	)
+	wf.Timeout = 50 * time.Millisecond
+
+	res := wf.Run(context.Background())
+	require.ErrorIs(t, res.Err, ErrWorkflowTimeout)
+	require.ErrorIs(t, res.Err, context.DeadlineExceeded)
+	assert.Equal(t, `workflow timed out after 50ms at operation "wait": context deadline exceeded`, res.Err.Error())
+	assert.Equal(t, phaseExecute, res.Phase)
+	require.NotNil(t, res.Op)
+	assert.Equal(t, "wait", res.Op.Id)
+	assert.Equal(t, 1, res.CompletedOperations)
+	require.Len(t, res.Operations, 2)
+	assert.Equal(t, "wait", res.Operations[1].Id)
+}
+
+func TestWorkflowTimeout_StopsBeforeNextOperation(t *testing.T) {
+	// The slow module ignores the cancellation, so the run stops before the next operation.
+	wf := slowTestWorkflow("100ms")
+	wf.Operations = append(
+		wf.Operations, Operation{Id: "next", Module: "wait_test_module", DependsOn: []string{"slow"}},
+	)
+	wf.Timeout = 20 * time.Millisecond
+
+	res := wf.Run(context.Background())
+	require.ErrorIs(t, res.Err, ErrWorkflowTimeout)
+	assert.Equal(t, `workflow timed out after 20ms at operation "next"`, res.Err.Error())
+	require.NotNil(t, res.Op)
+	assert.Equal(t, "next", res.Op.Id)
+	assert.Equal(t, 1, res.CompletedOperations)
+}
+
+func TestWorkflowTimeout_AbandonsOperation(t *testing.T) {
+	grace := timeoutGrace
+	timeoutGrace = 20 * time.Millisecond
+	t.Cleanup(func() { timeoutGrace = grace })
+
+	wf := slowTestWorkflow("500ms")
+	wf.Timeout = 20 * time.Millisecond
+	start := time.Now()
+	res := wf.Run(context.Background())
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	require.ErrorIs(t, res.Err, ErrWorkflowTimeout)
+	assert.Equal(t, `workflow timed out after 20ms at operation "slow"`, res.Err.Error())
+	assert.Equal(t, phaseExecute, res.Phase)
+	require.NotNil(t, res.Op)
+	assert.Equal(t, "slow", res.Op.Id)
+	assert.Equal(t, 1, res.TotalOperations)
+}
+
+func TestWorkflowTimeout_CompletedRun(t *testing.T) {
+	wf := slowTestWorkflow("1ms")
+	wf.Timeout = time.Minute
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 1, res.CompletedOperations)
+
+	// A cancelled run without a timeout is not reported as timed out.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res = wf.Run(ctx)
+	require.ErrorIs(t, res.Err, context.Canceled)
+	assert.NotErrorIs(t, res.Err, ErrWorkflowTimeout)
+}
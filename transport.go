@@ -0,0 +1,144 @@
+package blackstart
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// HTTPConfig is the proxy and trusted CA configuration of outbound HTTP clients.
+type HTTPConfig struct {
+	// Proxy returns the proxy URL for a request, or nil if the request is not proxied.
+	Proxy func(*http.Request) (*url.URL, error)
+
+	// CACerts are PEM encoded certificates of CAs that are trusted in addition to the system CAs.
+	CACerts []byte
+}
+
+var (
+	httpConfigMu sync.RWMutex
+	httpConfig   = &HTTPConfig{Proxy: http.ProxyFromEnvironment}
+	// httpTransport is the transport of outbound HTTP clients, configured with httpConfig.
+	httpTransport = baseTransport.Clone()
+
+	// baseTransport is a copy of the original http.DefaultTransport that SetHTTPConfig configures.
+	baseTransport = http.DefaultTransport.(*http.Transport).Clone()
+)
+
+// NewHTTPConfig creates the outbound HTTP configuration from the runtime configuration. Proxies
+// are read from the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables unless they are
+// set in the runtime configuration. The CA certificate files must contain PEM encoded
+// certificates.
+func NewHTTPConfig(config *RuntimeConfig) (*HTTPConfig, error) {
+	proxyConfig := httpproxy.FromEnvironment()
+	var caFiles []string
+	if config != nil {
+		if v := strings.TrimSpace(config.HTTPProxy); v != "" {
+			proxyConfig.HTTPProxy = v
+		}
+		if v := strings.TrimSpace(config.HTTPSProxy); v != "" {
+			proxyConfig.HTTPSProxy = v
+		}
+		if v := strings.TrimSpace(config.NoProxy); v != "" {
+			proxyConfig.NoProxy = v
+		}
+		caFiles = config.CACertFiles
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+
+	var caCerts []byte
+	for _, name := range caFiles {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificate file: %w", err)
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("no PEM encoded certificates found in CA certificate file %s", name)
+		}
+		caCerts = append(caCerts, b...)
+		caCerts = append(caCerts, '\n')
+	}
+
+	return &HTTPConfig{
+		Proxy: func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		},
+		CACerts: caCerts,
+	}, nil
+}
+
+// RootCAs returns the system CAs with the additional trusted CAs, or nil if there are no
+// additional CAs and the system CAs should be used.
+func (c *HTTPConfig) RootCAs() (*x509.CertPool, error) {
+	if len(c.CACerts) == 0 {
+		return nil, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(c.CACerts) {
+		return nil, fmt.Errorf("no PEM encoded CA certificates found")
+	}
+	return pool, nil
+}
+
+// Apply sets the proxy and trusted CAs of the transport.
+func (c *HTTPConfig) Apply(t *http.Transport) error {
+	t.Proxy = c.Proxy
+	pool, err := c.RootCAs()
+	if err != nil {
+		return err
+	}
+	if pool != nil {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		t.TLSClientConfig.RootCAs = pool
+	}
+	return nil
+}
+
+// SetHTTPConfig sets the outbound HTTP configuration used by modules and state stores, and the
+// transport returned by HTTPTransport. The REST API, Google API, Slack, and Azure clients use the
+// transport, and Kubernetes and S3 clients read the configuration with GetHTTPConfig.
+// http.DefaultTransport is not changed.
+func SetHTTPConfig(c *HTTPConfig) error {
+	t := baseTransport.Clone()
+	if err := c.Apply(t); err != nil {
+		return err
+	}
+	httpConfigMu.Lock()
+	defer httpConfigMu.Unlock()
+	httpConfig = c
+	httpTransport = t
+	return nil
+}
+
+// HTTPTransport returns the transport of outbound HTTP clients, which has the proxy and trusted CA
+// configuration set with SetHTTPConfig. The transport is shared by all clients and must not be
+// changed.
+func HTTPTransport() *http.Transport {
+	httpConfigMu.RLock()
+	defer httpConfigMu.RUnlock()
+	return httpTransport
+}
+
+// GetHTTPConfig returns the outbound HTTP configuration set with SetHTTPConfig. Without a
+// configuration, proxies are read from the environment and the system CAs are trusted.
+func GetHTTPConfig() *HTTPConfig {
+	httpConfigMu.RLock()
+	defer httpConfigMu.RUnlock()
+	return httpConfig
+}
@@ -0,0 +1,104 @@
+package blackstart
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPConfig_Proxy(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:3128")
+	t.Setenv("HTTP_PROXY", "")
+	t.Setenv("NO_PROXY", "")
+
+	c, err := NewHTTPConfig(nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	proxy, err := c.Proxy(req)
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	assert.Equal(t, "env-proxy:3128", proxy.Host)
+
+	c, err = NewHTTPConfig(
+		&RuntimeConfig{HTTPSProxy: "http://config-proxy:8080", NoProxy: ".internal.example.com"},
+	)
+	require.NoError(t, err)
+	proxy, err = c.Proxy(req)
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	assert.Equal(t, "config-proxy:8080", proxy.Host)
+
+	req, _ = http.NewRequest(http.MethodGet, "https://api.internal.example.com", nil)
+	proxy, err = c.Proxy(req)
+	require.NoError(t, err)
+	assert.Nil(t, proxy)
+}
+
+func TestNewHTTPConfig_CACerts(t *testing.T) {
+	server := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+	)
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, caPEM, 0o600))
+
+	c, err := NewHTTPConfig(&RuntimeConfig{CACertFiles: []string{caFile}})
+	require.NoError(t, err)
+	transport := baseTransport.Clone()
+	require.NoError(t, c.Apply(transport))
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Without the additional CA, the certificate of the server is not trusted.
+	c, err = NewHTTPConfig(&RuntimeConfig{})
+	require.NoError(t, err)
+	transport = baseTransport.Clone()
+	require.NoError(t, c.Apply(transport))
+	_, err = (&http.Client{Transport: transport}).Get(server.URL)
+	assert.Error(t, err)
+
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidFile, []byte("not a certificate"), 0o600))
+	_, err = NewHTTPConfig(&RuntimeConfig{CACertFiles: []string{invalidFile}})
+	assert.ErrorContains(t, err, "no PEM encoded certificates")
+
+	_, err = NewHTTPConfig(&RuntimeConfig{CACertFiles: []string{filepath.Join(dir, "missing.pem")}})
+	assert.ErrorContains(t, err, "unable to read CA certificate file")
+}
+
+func TestSetHTTPConfig(t *testing.T) {
+	defaultTransport := http.DefaultTransport
+	previous, previousTransport := GetHTTPConfig(), HTTPTransport()
+	t.Cleanup(
+		func() {
+			httpConfigMu.Lock()
+			defer httpConfigMu.Unlock()
+			httpConfig, httpTransport = previous, previousTransport
+		},
+	)
+
+	c, err := NewHTTPConfig(&RuntimeConfig{HTTPSProxy: "http://config-proxy:8080"})
+	require.NoError(t, err)
+	require.NoError(t, SetHTTPConfig(c))
+	assert.Same(t, c, GetHTTPConfig())
+
+	// The proxy is set on the dedicated transport of the clients, not on http.DefaultTransport.
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	proxy, err := HTTPTransport().Proxy(req)
+	require.NoError(t, err)
+	require.NotNil(t, proxy)
+	assert.Equal(t, "config-proxy:8080", proxy.Host)
+	assert.Same(t, defaultTransport, http.DefaultTransport)
+	assert.NotSame(t, defaultTransport, HTTPTransport())
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/pezops/blackstart"
+)
+
+var _ blackstart.KubeClientProvider = &KubeClientProvider{}
+
+// KubeClientProvider is the KubeClientProvider of the runtime. Clients use the default
+// kubeconfig loading rules or the in-cluster configuration, and are created once for each
+// impersonated identity. When namespaces are set, clients are only provided for those namespaces and
+// for cluster-scoped resources. Clients impersonating an identity are only provided for the allowed
+// identities, so a workflow cannot act as any identity the runtime may impersonate.
+type KubeClientProvider struct {
+	namespaces  []string
+	impersonate []string
+	newConfig   func() (*rest.Config, error)
+
+	mu      sync.Mutex
+	clients map[string]kubernetes.Interface
+}
+
+// NewKubeClientProvider creates a KubeClientProvider that provides clients for the namespaces, or
+// for all namespaces when none are given, and that may impersonate the identities. No identity may
+// be impersonated when none are given. The client configuration is loaded on first use.
+func NewKubeClientProvider(namespaces, impersonate []string) *KubeClientProvider {
+	return &KubeClientProvider{
+		namespaces:  trimmedValues(namespaces),
+		impersonate: trimmedValues(impersonate),
+		newConfig:   GetK8sClientConfig,
+		clients:     make(map[string]kubernetes.Interface),
+	}
+}
+
+// NewKubeClientProviderForConfig creates a KubeClientProvider like NewKubeClientProvider, whose
+// clients use the client configuration instead of the default kubeconfig loading rules.
+func NewKubeClientProviderForConfig(config *rest.Config, namespaces, impersonate []string) *KubeClientProvider {
+	p := NewKubeClientProvider(namespaces, impersonate)
+	p.newConfig = func() (*rest.Config, error) { return config, nil }
+	return p
+}
+
+// trimmedValues returns the values with surrounding whitespace removed, skipping empty values.
+func trimmedValues(values []string) []string {
+	var trimmed []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
+
+// KubeClient returns a client for use in the namespace. Requests are counted as API calls of the
+// operation whose context they are made with, and reads of single objects are cached for the
+// workflow run, so only the first read of an object in a run is counted.
+func (p *KubeClientProvider) KubeClient(_ context.Context, namespace, impersonate string) (kubernetes.Interface, error) {
+	if namespace != "" && len(p.namespaces) > 0 && !slices.Contains(p.namespaces, namespace) {
+		return nil, fmt.Errorf(
+			"namespace %q may not be used by modules; allowed namespaces: %s",
+			namespace, strings.Join(p.namespaces, ", "),
+		)
+	}
+	if impersonate != "" && !slices.Contains(p.impersonate, impersonate) {
+		return nil, fmt.Errorf(
+			"identity %q may not be impersonated by modules; add it to %s", impersonate,
+			blackstart.KubeImpersonateUsersEnv,
+		)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.clients[impersonate]; ok {
+		return c, nil
+	}
+	config, err := p.newConfig()
+	if err != nil {
+		return nil, err
+	}
+	config = rest.CopyConfig(config)
+	if impersonate != "" {
+		config.Impersonate = rest.ImpersonationConfig{UserName: impersonate}
+	}
+	config.Wrap(blackstart.CountAPICallsOf(blackstart.APIProviderKubernetes))
+	config.Wrap(blackstart.CacheKubeReads)
+	c, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes clientset: %w", err)
+	}
+	p.clients[impersonate] = c
+	return c, nil
+}
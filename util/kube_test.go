@@ -0,0 +1,35 @@
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+)
+
+func TestKubeClientProvider_Impersonate(t *testing.T) {
+	p := NewKubeClientProvider([]string{"team-a"}, []string{" system:serviceaccount:team-a:app ", ""})
+	p.newConfig = func() (*rest.Config, error) { return &rest.Config{Host: "https://127.0.0.1:6443"}, nil }
+
+	c, err := p.KubeClient(context.Background(), "team-a", "system:serviceaccount:team-a:app")
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+
+	_, err = p.KubeClient(context.Background(), "team-a", "system:serviceaccount:kube-system:admin")
+	assert.ErrorContains(t, err, `identity "system:serviceaccount:kube-system:admin" may not be impersonated`)
+	assert.ErrorContains(t, err, "BLACKSTART_K8S_IMPERSONATE_USERS")
+
+	_, err = p.KubeClient(context.Background(), "team-b", "system:serviceaccount:team-a:app")
+	assert.ErrorContains(t, err, `namespace "team-b" may not be used by modules`)
+
+	// Without allowed identities, only the identity of the runtime is used.
+	p = NewKubeClientProvider(nil, nil)
+	p.newConfig = func() (*rest.Config, error) { return &rest.Config{Host: "https://127.0.0.1:6443"}, nil }
+	_, err = p.KubeClient(context.Background(), "team-a", "system:serviceaccount:team-a:app")
+	assert.ErrorContains(t, err, "may not be impersonated")
+	c, err = p.KubeClient(context.Background(), "team-a", "")
+	require.NoError(t, err)
+	assert.NotNil(t, c)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 
@@ -122,5 +123,33 @@ func GetK8sClientConfigWithContext(kubeContext string) (*rest.Config, error) {
 	}
 
 	config = rest.AddUserAgent(config, blackstart.UserAgent)
+	if err := ApplyHTTPConfig(config, blackstart.GetHTTPConfig()); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
+
+// ApplyHTTPConfig sets the proxy of a Kubernetes client config, and adds the additional trusted CAs
+// to the CA of the cluster. Clusters without a CA and insecure clusters are not changed, since
+// their certificates are verified with the system CAs or not at all.
+func ApplyHTTPConfig(config *rest.Config, httpConfig *blackstart.HTTPConfig) error {
+	config.Proxy = httpConfig.Proxy
+	if len(httpConfig.CACerts) == 0 || config.Insecure {
+		return nil
+	}
+	caData := config.CAData
+	if len(caData) == 0 && config.CAFile != "" {
+		b, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return fmt.Errorf("unable to read Kubernetes CA file: %w", err)
+		}
+		caData = b
+	}
+	if len(caData) == 0 {
+		return nil
+	}
+	caData = append(append(slices.Clip(caData), '\n'), httpConfig.CACerts...)
+	config.CAData = caData
+	config.CAFile = ""
+	return nil
+}
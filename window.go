@@ -0,0 +1,49 @@
+package blackstart
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// MaintenanceWindow is a recurring period in which operations of a workflow may change resources.
+// Outside the window, operations are checked but not set.
+type MaintenanceWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+	location *time.Location
+}
+
+// NewMaintenanceWindow creates a maintenance window that opens on a cron schedule, such as
+// "0 2 * * SAT", and stays open for the duration. The schedule is evaluated in the IANA time zone,
+// or in UTC if the time zone is empty.
+func NewMaintenanceWindow(schedule string, duration time.Duration, timeZone string) (*MaintenanceWindow, error) {
+	s, err := cron.ParseStandard(strings.TrimSpace(schedule))
+	if err != nil {
+		return nil, fmt.Errorf("invalid maintenance window schedule %q: %w", schedule, err)
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid maintenance window duration %v: must be greater than 0", duration)
+	}
+	location := time.UTC
+	if tz := strings.TrimSpace(timeZone); tz != "" {
+		location, err = time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("invalid maintenance window time zone %q: %w", timeZone, err)
+		}
+	}
+	return &MaintenanceWindow{schedule: s, duration: duration, location: location}, nil
+}
+
+// Open reports whether the window is open at the time.
+func (mw *MaintenanceWindow) Open(t time.Time) bool {
+	start := mw.schedule.Next(t.Add(-mw.duration).In(mw.location))
+	return !start.After(t)
+}
+
+// NextOpen returns the next time after t that the window opens.
+func (mw *MaintenanceWindow) NextOpen(t time.Time) time.Time {
+	return mw.schedule.Next(t.In(mw.location))
+}
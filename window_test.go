@@ -0,0 +1,42 @@
+package blackstart
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenanceWindow(t *testing.T) {
+	mw, err := NewMaintenanceWindow("0 2 * * SAT", 4*time.Hour, "Europe/Berlin")
+	require.NoError(t, err)
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	saturday := time.Date(2026, 3, 7, 0, 0, 0, 0, berlin)
+	assert.False(t, mw.Open(saturday.Add(time.Hour+59*time.Minute)))
+	assert.True(t, mw.Open(saturday.Add(2*time.Hour)))
+	assert.True(t, mw.Open(saturday.Add(5*time.Hour+59*time.Minute).UTC()))
+	assert.False(t, mw.Open(saturday.Add(6*time.Hour)))
+	assert.Equal(t, saturday.Add(2*time.Hour+7*24*time.Hour), mw.NextOpen(saturday.Add(3*time.Hour)).In(berlin))
+
+	_, err = NewMaintenanceWindow("every saturday", time.Hour, "")
+	assert.ErrorContains(t, err, "invalid maintenance window schedule")
+	_, err = NewMaintenanceWindow("0 2 * * SAT", 0, "")
+	assert.ErrorContains(t, err, "invalid maintenance window duration")
+	_, err = NewMaintenanceWindow("0 2 * * SAT", time.Hour, "Mars/Olympus")
+	assert.ErrorContains(t, err, "invalid maintenance window time zone")
+}
+
+func TestWorkflow_NextRunAfter(t *testing.T) {
+	now := time.Date(2026, 3, 6, 23, 0, 0, 0, time.UTC)
+	wf := &Workflow{ReconcileInterval: 24 * time.Hour}
+	assert.Equal(t, now.Add(24*time.Hour), wf.NextRunAfter(now))
+
+	// A run is also scheduled when the maintenance window opens.
+	mw, err := NewMaintenanceWindow("0 2 * * SAT", 4*time.Hour, "")
+	require.NoError(t, err)
+	wf.MaintenanceWindow = mw
+	assert.Equal(t, now.Add(3*time.Hour), wf.NextRunAfter(now))
+}
@@ -6,18 +6,30 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"math/rand/v2"
 	"reflect"
+	"slices"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 var ErrOperationCycle = errors.New("operation cycle detected")
 
+// ErrMaxDeletionsExceeded is returned when a workflow run has more deletions than allowed.
+var ErrMaxDeletionsExceeded = errors.New("maximum deletions exceeded")
+
+// DefaultMaxRetryBackoff is the maximum delay before the next run after consecutive failed runs
+// of a workflow that does not set one.
+const DefaultMaxRetryBackoff = time.Hour
+
 const (
-	phaseSetup    = "Setup"
-	phaseValidate = "Validate"
-	phaseExecute  = "Execute"
+	phaseSetup     = "Setup"
+	phaseValidate  = "Validate"
+	phasePreflight = "Preflight"
+	phaseExecute   = "Execute"
+	phasePublish   = "Publish"
 )
 
 type workflowOutputResolver func(operationID, outputKey string) (any, error)
@@ -41,11 +53,91 @@ type Workflow struct {
 	// ReconcileInterval is the configured reconcile cadence for controller mode.
 	ReconcileInterval time.Duration `yaml:"reconcileInterval,omitempty"`
 
+	// CheckInterval is the cadence of check-only runs between the runs scheduled with
+	// ReconcileInterval in controller mode. Zero disables check-only runs.
+	CheckInterval time.Duration `yaml:"checkInterval,omitempty"`
+
+	// CheckOnly runs the check of each operation without calling Set. Operations whose checks do
+	// not pass are reported as drifted. Operations that depend on a drifted operation are not
+	// checked, since the outputs of the drifted operation are not available.
+	CheckOnly bool `yaml:"-"`
+
+	// MaintenanceWindow limits when operations are set. Outside the window, operations are checked
+	// and operations whose checks do not pass are reported as pending the window. A nil window
+	// allows operations to be set at any time.
+	MaintenanceWindow *MaintenanceWindow `yaml:"-"`
+
 	// Operations is an ordered list of operations that will be executed in the Workflow.
 	Operations []Operation `yaml:"operations"`
 
+	// MaxDeletions limits the number of operations with DoesNotExist set. When the limit is
+	// exceeded, the Workflow fails before any operation is executed. A nil value does not limit
+	// deletions.
+	MaxDeletions *int `yaml:"maxDeletions,omitempty"`
+
+	// SkipUnchangedFor skips the check of an operation when its resolved inputs are unchanged and
+	// it last succeeded within the duration. Operations that other operations depend on are always
+	// run. It requires a StateStore in the run context. Zero disables skipping.
+	SkipUnchangedFor time.Duration `yaml:"skipUnchangedFor,omitempty"`
+
+	// MaxRetryBackoff is the maximum delay before the next run in controller mode after
+	// consecutive failed runs. Zero disables the backoff, so failed runs are retried every
+	// ReconcileInterval.
+	MaxRetryBackoff time.Duration `yaml:"maxRetryBackoff,omitempty"`
+
+	// Timeout limits the duration of a run. When it expires, the context of the operation in flight
+	// is cancelled and the run fails with ErrWorkflowTimeout. Zero does not limit runs.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// ApprovedDeletions approves a run with more deletions than MaxDeletions when it is equal to
+	// the number of deletions in the run.
+	ApprovedDeletions int `yaml:"approvedDeletions,omitempty"`
+
+	// Record records the interactions of the modules of the run in the fixture, replacing the
+	// operations it holds.
+	Record *Fixture `yaml:"-"`
+
+	// Replay runs the workflow against the interactions of the fixture instead of its modules, so
+	// no system is changed. Operations that were not recorded, or whose inputs changed since the
+	// recording, fail with ErrFixtureMismatch. Service checks, preflight checks, skipping unchanged
+	// operations, and publishing outputs are disabled, since they reach the real systems.
+	Replay *Fixture `yaml:"-"`
+
+	// InjectedFailures forces operations to fail to test the failure handling of the workflow. It
+	// maps operation identifiers to the phase that fails, InjectFailureCheck or InjectFailureSet.
+	InjectedFailures map[string]string `yaml:"-"`
+
+	// OnlyLabels and SkipLabels select the operations that are set in a partial run, as `key=value`
+	// labels. An operation is selected when it has any label of OnlyLabels, or OnlyLabels is empty,
+	// and none of SkipLabels. Operations that are not selected are checked but not set, so their
+	// outputs are available to the selected operations.
+	OnlyLabels []string `yaml:"-"`
+	SkipLabels []string `yaml:"-"`
+
+	// Targets limits a run to the operations with these identifiers and the operations they depend
+	// on, directly or transitively. Other operations are neither checked nor set. An empty list
+	// runs all operations.
+	Targets []string `yaml:"-"`
+
+	// ServiceChecks are connectivity checks of the services used by the operations, such as the
+	// Kubernetes API server. They run in the Preflight phase before the operations are set up.
+	ServiceChecks []ServiceCheck `yaml:"-"`
+
+	// PublishOutputs write outputs of operations to ConfigMaps and Secrets after a successful run
+	// in which all operations were set.
+	PublishOutputs []PublishOutputs `yaml:"-"`
+
+	// OnStuckOperation is called when an operation has run longer than the stuck operation
+	// threshold of the runtime configuration, such as to report it in the workflow status. It is
+	// called from another goroutine while the operation runs.
+	OnStuckOperation func(StuckOperation) `yaml:"-"`
+
 	// Source is the original source of the workflow definition, if available.
 	Source any
+
+	// Warnings describe problems of the workflow definition that do not prevent it from running,
+	// such as deprecated keys. They are logged at the start of each run.
+	Warnings []string `yaml:"-"`
 }
 
 // --8<-- [end:Workflow]
@@ -58,6 +150,153 @@ type WorkflowResult struct {
 	Err                 error
 	TotalOperations     int
 	CompletedOperations int
+
+	// ManagedResources are the resources reported by completed operations, in execution order.
+	ManagedResources []ManagedResource
+
+	// Operations are the metrics of the executed operations, in execution order. A failed
+	// operation is included as the last entry.
+	Operations []OperationResult
+
+	// Plan is the resolved execution plan of the run, in execution order. It is set once the
+	// operations are validated, so it is also set for runs that fail in a later phase.
+	Plan []PlannedOperation
+
+	// Lint are warnings about operations and dependencies of the workflow that are valid but
+	// likely unintended, such as unused outputs. They are set with Plan.
+	Lint []string
+
+	// Instances are the results of the forEach instances of the workflow, in the order of the
+	// operations. It is empty for workflows without instances.
+	Instances []InstanceResult
+}
+
+// PlannedOperation is an operation of the resolved execution plan of a workflow run.
+type PlannedOperation struct {
+	// Id is the identifier of the operation.
+	Id string
+
+	// Module is the identifier of the module of the operation.
+	Module string
+
+	// DependsOn are the identifiers of the operations the operation depends on, either explicitly
+	// or with dependency inputs.
+	DependsOn []string
+
+	// DoesNotExist is true when the operation ensures that its resource does not exist.
+	DoesNotExist bool
+
+	// Inputs are the sources of the inputs of the operation.
+	Inputs map[string]PlannedInput
+}
+
+// PlannedInput is the source of an input of a planned operation. Either the value is set, or the
+// input is taken from the output of a dependency.
+type PlannedInput struct {
+	// Value is the static value of the input, with sensitive values masked.
+	Value string
+
+	// DependencyId is the identifier of the operation the input is taken from.
+	DependencyId string
+
+	// OutputKey is the output of the dependency the input is taken from.
+	OutputKey string
+}
+
+// OperationResult contains the metrics of an operation executed in a workflow run.
+type OperationResult struct {
+	// Id is the identifier of the operation.
+	Id string
+
+	// Module is the identifier of the module of the operation.
+	Module string
+
+	// Duration is the wall time of the check and set of the operation. It does not include time
+	// waiting for operations with the same serialization key or the time of a batch check.
+	Duration time.Duration
+
+	// APICalls is the number of external API calls recorded by the operation.
+	APICalls int64
+
+	// ProviderAPICalls is the number of external API calls recorded by the operation by provider,
+	// such as APIProviderGoogle. It is nil when the operation recorded no calls.
+	ProviderAPICalls map[string]int64
+
+	// Skipped is true when the operation was not run because its inputs were unchanged since its
+	// last successful run, or because a dependency was not set or failed.
+	Skipped bool
+
+	// Drifted is true when the check of the operation did not pass in a check-only run.
+	Drifted bool
+
+	// PendingWindow is true when the check of the operation did not pass outside the maintenance
+	// window of the workflow, and the set of the operation was deferred.
+	PendingWindow bool
+
+	// Blocked is true when the operation failed because the circuit of an endpoint it uses is open
+	// after consecutive failures, so it was not attempted until the cool-down passes.
+	Blocked bool
+
+	// Filtered is true when the operation was not selected by the label filters of the run, so it
+	// was checked but not set.
+	Filtered bool
+
+	// Err is the error of the operation when it failed.
+	Err error
+
+	// Inputs are the resolved input values of the operation, with sensitive values masked. They are
+	// not set for skipped operations.
+	Inputs map[string]string
+
+	// Outputs are the output values of the operation, with sensitive values masked.
+	Outputs map[string]string
+}
+
+// ManagedResource identifies a resource reported by a module with ModuleContext.Resource.
+type ManagedResource struct {
+	// Id is the canonical identifier of the resource reported by the module.
+	Id string
+
+	// Module is the identifier of the module that manages the resource.
+	Module string
+
+	// OperationId is the identifier of the operation that reported the resource.
+	OperationId string
+}
+
+// setsAllowed reports whether operations of the workflow may be set at the time.
+func (w *Workflow) setsAllowed(t time.Time) bool {
+	return !w.CheckOnly && (w.MaintenanceWindow == nil || w.MaintenanceWindow.Open(t))
+}
+
+// NextRunAfter returns the time of the next scheduled run of the workflow after a run ended at t.
+// Runs are scheduled every ReconcileInterval, and when the maintenance window opens.
+func (w *Workflow) NextRunAfter(t time.Time) time.Time {
+	next := t.Add(w.ReconcileInterval)
+	if w.MaintenanceWindow != nil {
+		if open := w.MaintenanceWindow.NextOpen(t); open.Before(next) {
+			next = open
+		}
+	}
+	return next
+}
+
+// RetryBackoff returns the delay before the next run of the workflow after a number of
+// consecutive failed runs. From the second consecutive failure, ReconcileInterval is doubled for
+// each further failure up to MaxRetryBackoff, less a random jitter of up to a tenth, so workflows
+// failing against the same system do not retry in lockstep. The delay is never shorter than
+// ReconcileInterval.
+func (w *Workflow) RetryBackoff(failures int) time.Duration {
+	if failures < 2 || w.MaxRetryBackoff <= w.ReconcileInterval || w.ReconcileInterval <= 0 {
+		return w.ReconcileInterval
+	}
+	backoff := w.ReconcileInterval
+	for i := 1; i < failures && backoff < w.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, w.MaxRetryBackoff)
+	backoff -= rand.N(backoff/10 + 1)
+	return max(backoff, w.ReconcileInterval)
 }
 
 // ContextWorkflowOutput resolves an operation output from the current workflow
@@ -75,22 +314,56 @@ func ContextWorkflowOutput(ctx context.Context, operationID, outputKey string) (
 
 // Run will execute the Workflow using the provided context.
 func (w *Workflow) Run(ctx context.Context) WorkflowResult {
-	l := ctx.Value(LoggerKey)
-	logger, ok := l.(*slog.Logger)
-	if !ok {
-		logger = NewLogger(nil)
-	}
-	we := newWorkflowExecution(w, logger)
+	we := newWorkflowExecution(w, contextLogger(ctx))
 	we.logger.Info("starting workflow execution")
+	for _, warning := range w.Warnings {
+		we.logger.Warn("workflow definition warning", "warning", warning)
+	}
+	// Module loggers are derived from the workflow logger, and lookups cached by modules are
+	// shared by the operations of this run.
+	ctx = context.WithValue(ctx, LoggerKey, we.logger)
+	ctx = withRunCache(ctx)
+	we.events = newRunEvents(ctx, we)
+	we.events.runStarted()
+	result := we.executeWithTimeout(ctx)
+	result.Instances = instanceResults(w.Operations, result)
+	we.events.runFinished(result)
+	return result
+}
+
+// Validate sets up and validates the operations of the Workflow without checking or setting them.
+// The result holds the execution plan and the lint warnings of a valid workflow.
+func (w *Workflow) Validate(ctx context.Context) WorkflowResult {
+	we := newWorkflowExecution(w, contextLogger(ctx))
+	we.validateOnly = true
+	for _, warning := range w.Warnings {
+		we.logger.Warn("workflow definition warning", "warning", warning)
+	}
 	return we.execute(ctx)
 }
 
+// contextLogger returns the logger of the context, or a default logger when it has none.
+func contextLogger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(LoggerKey).(*slog.Logger); ok {
+		return logger
+	}
+	return NewLogger(nil)
+}
+
 // workflowExecution manages the execution of a Workflow. It keeps track of the operations,
 // their contexts, and the overall state of the execution.
 type workflowExecution struct {
 	w      *Workflow
 	opCtxs map[string]*moduleContext
 	logger *slog.Logger
+	// moduleInfo is the module information of each operation, by operation ID.
+	moduleInfo map[string]ModuleInfo
+	// validateOnly stops the execution after the validate phase.
+	validateOnly bool
+	// events emits the events of the run, or is nil when no event sink is configured.
+	events *runEvents
+	// inFlight is the operation that is currently run, reported when the run times out.
+	inFlight atomic.Pointer[inFlightOperation]
 }
 
 // execute runs the workflow by setting up operations, validating them, and executing them
@@ -99,6 +372,17 @@ func (we *workflowExecution) execute(ctx context.Context) WorkflowResult {
 	var err error
 	var result WorkflowResult
 
+	// Service checks run first, so an unreachable service fails the run before any module is
+	// created.
+	if !we.validateOnly && len(we.w.ServiceChecks) > 0 && we.w.Replay == nil {
+		result.Phase = phasePreflight
+		if err = we.runServiceChecks(ctx); err != nil {
+			result.TotalOperations = len(we.w.Operations)
+			result.Err = err
+			return result
+		}
+	}
+
 	result.Phase = phaseSetup
 	if duplicateID, duplicateOp := findDuplicateOperationID(we.w.Operations); duplicateOp != nil {
 		result.Op = duplicateOp
@@ -148,6 +432,16 @@ func (we *workflowExecution) execute(ctx context.Context) WorkflowResult {
 		moduleInfo[op.Id] = m.Info()
 
 	}
+	we.moduleInfo = moduleInfo
+
+	if err = we.useFixture(modules, operations); err != nil {
+		result.Err = err
+		return result
+	}
+	if err = we.injectFailures(modules); err != nil {
+		result.Err = err
+		return result
+	}
 
 	// Topologically sort operations based on their dependencies.
 	sortedIds, err := opoSort(we.w.Operations)
@@ -188,70 +482,551 @@ func (we *workflowExecution) execute(ctx context.Context) WorkflowResult {
 			return result
 		}
 	}
+	if err = we.checkServiceChecks(); err != nil {
+		result.Op = nil
+		result.Err = err
+		return result
+	}
+	if err = we.checkLabelFilters(); err != nil {
+		result.Op = nil
+		result.Err = err
+		return result
+	}
+	if err = we.checkTargets(operations); err != nil {
+		result.Op = nil
+		result.Err = err
+		return result
+	}
+	if err = we.checkPublishOutputs(); err != nil {
+		result.Op = nil
+		result.Err = err
+		return result
+	}
+	result.Plan = executionPlan(sortedIds, operations, moduleInfo)
+	result.Lint = lintWorkflow(sortedIds, operations, moduleInfo, we.w.PublishOutputs)
+	for _, warning := range result.Lint {
+		we.logger.Warn("workflow lint warning", "warning", warning)
+	}
+
+	if err = we.checkDeletions(); err != nil {
+		result.Op = nil
+		result.Err = err
+		return result
+	}
+	if we.validateOnly {
+		return result
+	}
+
+	// Operations that are not targets and that no target depends on are not run.
+	if len(we.w.Targets) > 0 {
+		sortedIds = we.targetedIds(sortedIds, operations)
+		we.logger.Info(
+			"targeted run, only the targets and their dependencies are run", "targets", we.w.Targets,
+			"operations", len(sortedIds),
+		)
+	}
+
+	result.Phase = phasePreflight
+	// Run preflight checks for all operations before any changes are made.
+	if failedOp, preflightErr := we.preflight(ctx, sortedIds, operations, modules); preflightErr != nil {
+		result.Op = failedOp
+		result.Err = preflightErr
+		return result
+	}
 
 	result.Phase = phaseExecute
-	// Execute each operation in sorted order.
-	operationContexts := make(map[string]ModuleContext)
-	for _, id := range sortedIds {
+	if len(we.w.OnlyLabels) > 0 || len(we.w.SkipLabels) > 0 {
+		we.logger.Info(
+			"partial run, operations not selected by labels are not set", "onlyLabels", we.w.OnlyLabels,
+			"skipLabels", we.w.SkipLabels,
+		)
+	}
+	// Execute each operation in sorted order. Check results of operations whose modules support
+	// batch checks may already be known from an earlier batch.
+	completed := make(map[string]struct{}, len(sortedIds))
+	batchChecks := make(map[string]bool)
+	// A failed batch check is the failed check of each operation of the batch.
+	batchErrs := make(map[string]error)
+	// Operations with unchanged inputs are skipped when a state store is configured.
+	store := we.stateStore(ctx)
+	var skip map[string]bool
+	// Recorded runs do not skip operations, so the fixture holds all operations.
+	if store != nil && we.w.SkipUnchangedFor > 0 && !we.w.CheckOnly && we.w.Record == nil {
+		skip = skippable(operations)
+		// Operations with published outputs are always run, so their outputs are available.
+		for _, p := range we.w.PublishOutputs {
+			for _, out := range p.Outputs {
+				skip[out.OperationId] = false
+			}
+		}
+	}
+	// Operations that were not set, because they drifted in a check-only run, are pending the
+	// maintenance window, or failed with a failure policy that lets the run continue, and the
+	// operations depending on them, have no outputs for later operations.
+	unavailable := make(map[string]struct{})
+	// failedBranch holds the operations that failed without stopping the run and the operations
+	// depending on them.
+	failedBranch := make(map[string]struct{})
+	// failedInstances holds the forEach instances with a failed operation whose failure stopped
+	// the other operations of the instance.
+	failedInstances := make(map[string]struct{})
+	var failed []failedOperation
+	for i, id := range sortedIds {
 		op := operations[id]
 		result.Op = op
-		allowedDeps := make(map[string]struct{}, len(op.DependsOn))
-		for _, depID := range op.DependsOn {
-			allowedDeps[depID] = struct{}{}
-		}
-		resolver := workflowOutputResolver(
-			func(operationID, outputKey string) (any, error) {
-				if _, ok := allowedDeps[operationID]; !ok {
-					return nil, fmt.Errorf(
-						"operation %q is not a declared dependency for operation %q",
-						operationID,
-						op.Id,
-					)
-				}
-				opCtx, ok := we.opCtxs[operationID]
-				if !ok {
-					return nil, fmt.Errorf("operation %q not found in workflow context", operationID)
-				}
-				var value any
-				value, err = opCtx.getOutput(outputKey)
-				if err != nil {
-					return nil, fmt.Errorf(
-						"output %q from operation %q not found in workflow context: %w",
-						outputKey,
-						operationID,
-						err,
-					)
-				}
-				return value, nil
-			},
-		)
-		opCtx := context.WithValue(ctx, workflowOutputResolverContextKey{}, resolver)
-		mctx := newModuleContext(opCtx, op)
-		we.opCtxs[id] = mctx
-
-		err = we.setupOperationContext(mctx, op)
-		if err != nil {
-			result.Err = fmt.Errorf("error setting up context: %w", err)
+		// A cancelled run, such as after the timeout of the workflow, does not start further
+		// operations, even when the module of the last operation ignored the cancellation.
+		if err = context.Cause(ctx); err != nil {
+			result.Err = err
 			return result
 		}
-
-		operationContexts[id] = mctx
+		we.enter(phaseExecute, op)
 		m, ok := modules[op.Id]
 		if !ok {
 			result.Err = fmt.Errorf("unable to find module for operation '%s'", op.Id)
 			return result
 		}
-		err = op.executeWithModule(m, mctx, we.logger)
+
+		_, instanceFailed := failedInstances[op.Instance]
+		if instanceFailed || dependsOnAny(op, unavailable) {
+			switch {
+			case instanceFailed:
+				we.logger.Warn(
+					"operation skipped, operation of its instance failed", "module", op.Module, "id", op.Id,
+					"instance", op.Instance,
+				)
+				failedBranch[id] = struct{}{}
+			case dependsOnAny(op, failedBranch):
+				we.logger.Warn("operation skipped, dependency failed", "module", op.Module, "id", op.Id)
+				failedBranch[id] = struct{}{}
+			default:
+				we.logger.Info("operation not checked, dependency not set", "module", op.Module, "id", op.Id)
+			}
+			opResult := OperationResult{Id: op.Id, Module: op.Module, Skipped: true}
+			we.events.operationFinished(opResult, nil)
+			result.Operations = append(result.Operations, opResult)
+			unavailable[id] = struct{}{}
+			result.CompletedOperations += 1
+			continue
+		}
+
+		var mctx *moduleContext
+		var hash string
+		if _, checked := batchChecks[id]; skip[id] && !checked {
+			mctx, err = we.newOperationContext(ctx, op)
+			if err != nil {
+				result.Err = fmt.Errorf("error setting up context: %w", err)
+				return result
+			}
+			hash = inputsHash(op, mctx)
+			if resources, unchanged := we.unchanged(ctx, store, op, hash); unchanged {
+				we.logger.Info("operation skipped, inputs unchanged", "module", op.Module, "id", op.Id)
+				opResult := OperationResult{Id: op.Id, Module: op.Module, Skipped: true}
+				we.events.operationFinished(opResult, nil)
+				result.Operations = append(result.Operations, opResult)
+				completed[id] = struct{}{}
+				result.CompletedOperations += 1
+				mctx.resources = resources
+				result.ManagedResources = append(result.ManagedResources, managedResources(op, mctx)...)
+				continue
+			}
+		}
+
+		info := moduleInfo[id]
+		if _, checked := batchChecks[id]; !checked {
+			if bc, isBatch := m.(BatchChecker); isBatch {
+				batch := readyBatch(sortedIds[i:], operations, completed)
+				stopWatching := we.watchOperation(ctx, op)
+				err = we.checkBatch(ctx, bc, info, batch, batchChecks, batchErrs)
+				stopWatching()
+				if err != nil {
+					result.Err = err
+					return result
+				}
+			}
+		}
+
+		check, checked := batchChecks[id]
+		if checked {
+			mctx = we.opCtxs[id]
+		} else if mctx == nil {
+			mctx, err = we.newOperationContext(ctx, op)
+			if err != nil {
+				result.Err = fmt.Errorf("error setting up context: %w", err)
+				return result
+			}
+		}
+		if skip[id] && hash == "" {
+			hash = inputsHash(op, mctx)
+		}
+
+		// Operations sharing a serialization key are not run at the same time.
+		var unlock func()
+		unlock, err = serializeOperations(ctx, info, mctx)
 		if err != nil {
 			result.Err = err
 			return result
 		}
+		// Operation logs honor the log level of the module.
+		opLogger := moduleLogger(we.logger, op.Module)
+		start := time.Now()
+		// Operations that are not selected by the label filters are only checked.
+		filtered := !we.w.selected(op)
+		setsAllowed := we.w.setsAllowed(start) && !filtered
+		stopWatching := we.watchOperation(ctx, op)
+		we.events.operationStarted(op)
+		// Operations checked in a batch are only set.
+		if checked {
+			err = batchErrs[id]
+		} else {
+			check, err = op.checkWithModule(m, mctx, opLogger)
+		}
+		if err == nil {
+			we.events.operationChecked(op, check)
+		}
+		if err == nil && setsAllowed {
+			err = op.setUnlessChecked(m, mctx, opLogger, check)
+			if err == nil && !check {
+				we.events.operationSet(op)
+			}
+		}
+		stopWatching()
+		unlock()
+		opResult := we.operationResult(op, mctx, moduleInfo, time.Since(start))
+		if filtered {
+			opResult.Filtered = true
+			// A failed check of an operation that is not selected does not fail a partial run, so
+			// operations can be skipped while their service is unavailable.
+			if err != nil {
+				we.logger.Warn(
+					"check of operation not selected by labels failed", "module", op.Module, "id", op.Id,
+					"error", err,
+				)
+				err, check = nil, false
+			}
+		}
+		notSet := !setsAllowed && err == nil && !check
+		switch {
+		case notSet && we.w.CheckOnly:
+			we.logger.Warn("operation drifted", "module", op.Module, "id", op.Id)
+			opResult.Drifted = true
+		case notSet && filtered:
+			we.logger.Info("operation not set, not selected by labels", "module", op.Module, "id", op.Id)
+		case notSet:
+			we.logger.Info("operation set deferred to the maintenance window", "module", op.Module, "id", op.Id)
+			opResult.PendingWindow = true
+		}
+		if errors.Is(err, ErrCircuitOpen) {
+			we.logger.Warn("operation blocked", "module", op.Module, "id", op.Id, "error", err)
+			opResult.Blocked = true
+		}
+		opResult.Err = err
+		we.events.operationFinished(opResult, err)
+		result.Operations = append(result.Operations, opResult)
+		// The state is also recorded for operations with immutable inputs, so changes of them are
+		// detected in the next run.
+		if hash != "" || (store != nil && !we.w.CheckOnly && len(immutableInputs(info, mctx)) > 0) {
+			we.recordInputs(ctx, store, op, mctx, hash, err == nil && !notSet)
+		}
+		if err != nil {
+			if !we.continuesAfter(ctx, op, err) {
+				result.Err = err
+				return result
+			}
+			failed = append(failed, failedOperation{op: op, err: err})
+			unavailable[id] = struct{}{}
+			failedBranch[id] = struct{}{}
+			if abortsInstance(op) {
+				failedInstances[op.Instance] = struct{}{}
+			}
+			continue
+		}
 		result.CompletedOperations += 1
+		if notSet {
+			unavailable[id] = struct{}{}
+			continue
+		}
+		completed[id] = struct{}{}
+		result.ManagedResources = append(result.ManagedResources, managedResources(op, mctx)...)
+	}
+	if failedOp, isolatedErr := isolatedFailures(failed); isolatedErr != nil {
+		result.Op = failedOp
+		result.Err = isolatedErr
+		return result
 	}
 
+	if len(we.w.PublishOutputs) == 0 || we.w.CheckOnly {
+		return result
+	}
+	// Outputs are only published when all operations were set, so consumers do not read outputs of
+	// a partially applied workflow.
+	if len(unavailable) > 0 {
+		we.logger.Info("outputs not published, operations not set", "operations", len(unavailable))
+		return result
+	}
+	if len(we.w.Targets) > 0 {
+		we.logger.Info("outputs not published, targeted run")
+		return result
+	}
+	if we.w.Replay != nil {
+		we.logger.Info("outputs not published, replayed run")
+		return result
+	}
+	result.Phase = phasePublish
+	if err = we.publishOutputs(ctx); err != nil {
+		result.Err = err
+	}
 	return result
 }
 
+// managedResources returns the resources reported by a completed operation. Resources of
+// operations that ensure a resource does not exist are no longer managed.
+func managedResources(op *Operation, mctx *moduleContext) []ManagedResource {
+	if op.DoesNotExist {
+		return nil
+	}
+	resources := make([]ManagedResource, 0, len(mctx.resources))
+	for _, resource := range mctx.resources {
+		resources = append(resources, ManagedResource{Id: resource, Module: op.Module, OperationId: op.Id})
+	}
+	return resources
+}
+
+// operationResult returns the metrics and the recorded inputs and outputs of an executed
+// operation, and logs the metrics.
+func (we *workflowExecution) operationResult(
+	op *Operation, mctx *moduleContext, moduleInfo map[string]ModuleInfo, duration time.Duration,
+) OperationResult {
+	res := OperationResult{
+		Id:               op.Id,
+		Module:           op.Module,
+		Duration:         duration,
+		APICalls:         mctx.apiCalls.total(),
+		ProviderAPICalls: mctx.apiCalls.byProvider(),
+		Inputs:           recordedInputs(op, mctx, moduleInfo, we.opCtxs),
+		Outputs:          recordedOutputs(mctx, moduleInfo[op.Id]),
+	}
+	we.logger.Info(
+		"operation finished",
+		"module", op.Module,
+		"id", op.Id,
+		"duration", duration,
+		"api_calls", res.APICalls,
+		"provider_api_calls", res.ProviderAPICalls,
+	)
+	return res
+}
+
+// preflight runs the preflight checks of all operations whose modules implement Preflighter. All
+// operations are checked, and failures are combined into a single error. The first failed operation
+// is returned with the error.
+func (we *workflowExecution) preflight(
+	ctx context.Context, sortedIds []string, operations map[string]*Operation, modules map[string]Module,
+) (*Operation, error) {
+	var failedOp *Operation
+	var failures []string
+	for _, id := range sortedIds {
+		op := operations[id]
+		pf, ok := modules[id].(Preflighter)
+		if !ok || !staticInputs(op) {
+			continue
+		}
+		moduleLogger(we.logger, op.Module).Debug("operation preflight", "module", op.Module, "id", op.Id)
+		we.enter(phasePreflight, op)
+		if err := pf.Preflight(newModuleContext(ctx, op)); err != nil {
+			we.logger.Warn("operation preflight failed", "module", op.Module, "id", op.Id, "error", err)
+			if failedOp == nil {
+				failedOp = op
+			}
+			failures = append(failures, fmt.Sprintf("operation %q: %v", op.Id, err))
+		}
+	}
+	if len(failures) == 0 {
+		return nil, nil
+	}
+	return failedOp, fmt.Errorf(
+		"preflight failed for %d operation(s): %s", len(failures), strings.Join(failures, "; "),
+	)
+}
+
+// checkDeletions returns an error when the workflow has more deletions than its MaxDeletions limit
+// and the number of deletions is not approved.
+func (we *workflowExecution) checkDeletions() error {
+	if we.w.MaxDeletions == nil {
+		return nil
+	}
+	deletions := 0
+	for _, op := range we.w.Operations {
+		if op.DoesNotExist {
+			deletions++
+		}
+	}
+	if deletions <= *we.w.MaxDeletions {
+		return nil
+	}
+	if deletions == we.w.ApprovedDeletions {
+		we.logger.Warn(
+			"running approved deletions exceeding the maximum",
+			"deletions", deletions,
+			"max_deletions", *we.w.MaxDeletions,
+		)
+		return nil
+	}
+	return fmt.Errorf(
+		"%w: workflow has %d operations with doesNotExist set, exceeding the maximum of %d",
+		ErrMaxDeletionsExceeded, deletions, *we.w.MaxDeletions,
+	)
+}
+
+// operationDependencies returns the identifiers of the operations an operation depends on, either
+// with DependsOn or with dependency inputs.
+func operationDependencies(op *Operation) []string {
+	deps := slices.Clone(op.DependsOn)
+	for _, input := range op.Inputs {
+		if !input.IsStatic() {
+			deps = append(deps, input.DependencyId())
+		}
+	}
+	return deps
+}
+
+// dependsOnAny reports whether an operation depends on any of the operations in ids.
+func dependsOnAny(op *Operation, ids map[string]struct{}) bool {
+	for _, dep := range operationDependencies(op) {
+		if _, ok := ids[dep]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// staticInputs reports whether all inputs of an operation are static values.
+func staticInputs(op *Operation) bool {
+	for _, input := range op.Inputs {
+		if !input.IsStatic() {
+			return false
+		}
+	}
+	return true
+}
+
+// newOperationContext creates the module context for an operation. Dependency outputs are set as
+// inputs of the context, and the context is registered so that later operations may read its
+// outputs.
+func (we *workflowExecution) newOperationContext(ctx context.Context, op *Operation) (*moduleContext, error) {
+	allowedDeps := make(map[string]struct{}, len(op.DependsOn))
+	for _, depID := range op.DependsOn {
+		allowedDeps[depID] = struct{}{}
+	}
+	var mctx *moduleContext
+	resolver := workflowOutputResolver(
+		func(operationID, outputKey string) (any, error) {
+			if _, ok := allowedDeps[operationID]; !ok {
+				return nil, fmt.Errorf(
+					"operation %q is not a declared dependency for operation %q",
+					operationID,
+					op.Id,
+				)
+			}
+			opCtx, ok := we.opCtxs[operationID]
+			if !ok {
+				return nil, fmt.Errorf("operation %q not found in workflow context", operationID)
+			}
+			value, err := opCtx.getOutput(outputKey)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"output %q from operation %q not found in workflow context: %w",
+					outputKey,
+					operationID,
+					err,
+				)
+			}
+			// Outputs the module computes from a sensitive workflow output may hold the value.
+			if sensitiveOutput(operationID, outputKey, we.moduleInfo, we.opCtxs) {
+				mctx.derivedSensitive = true
+			}
+			return value, nil
+		},
+	)
+	opCtx := context.WithValue(ctx, workflowOutputResolverContextKey{}, resolver)
+	mctx = newModuleContext(opCtx, op)
+	we.opCtxs[op.Id] = mctx
+
+	if err := we.setupOperationContext(mctx, op); err != nil {
+		return nil, err
+	}
+	we.taintChangedInputs(ctx, we.stateStore(ctx), op, mctx)
+	return mctx, nil
+}
+
+// readyBatch returns the operations that may be checked together with the first operation of the
+// remaining sorted operations. The batch is the run of operations that directly follow the first
+// operation, use the same module, and whose dependencies are all completed. Operations after an
+// operation of another module or with a pending dependency are not added, so no other operation runs
+// between checking an operation and setting it, except the operations of the batch.
+func readyBatch(remaining []string, operations map[string]*Operation, completed map[string]struct{}) []*Operation {
+	first := operations[remaining[0]]
+	batch := []*Operation{first}
+	for _, id := range remaining[1:] {
+		op := operations[id]
+		if op.Module != first.Module {
+			break
+		}
+		for _, dep := range operationDependencies(op) {
+			if _, ok := completed[dep]; !ok {
+				return batch
+			}
+		}
+		batch = append(batch, op)
+	}
+	return batch
+}
+
+// checkBatch checks a batch of operations with a single CheckMany call and records the check result
+// of each operation. When CheckMany fails, its error is recorded as the check error of each
+// operation, so the failure policy of each operation decides whether the run continues.
+func (we *workflowExecution) checkBatch(
+	ctx context.Context, bc BatchChecker, info ModuleInfo, batch []*Operation, results map[string]bool,
+	errs map[string]error,
+) error {
+	ctxs := make([]ModuleContext, len(batch))
+	ids := make([]string, len(batch))
+	for i, op := range batch {
+		mctx, err := we.newOperationContext(ctx, op)
+		if err != nil {
+			return fmt.Errorf("error setting up context for operation %q: %w", op.Id, err)
+		}
+		ctxs[i] = mctx
+		ids[i] = op.Id
+	}
+
+	unlock, err := serializeOperations(ctx, info, ctxs...)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	we.logger.Info("operation batch check", "module", batch[0].Module, "ids", ids)
+	checks, err := bc.CheckMany(ctxs)
+	if err == nil && len(checks) != len(batch) {
+		err = fmt.Errorf(
+			"module %q returned %d batch check results for %d operations", batch[0].Module, len(checks), len(batch),
+		)
+	}
+	if err != nil {
+		we.logger.Warn("operation batch check failed", "module", batch[0].Module, "ids", ids, "error", err)
+	}
+	for i, id := range ids {
+		if err != nil {
+			results[id] = false
+			errs[id] = err
+			continue
+		}
+		results[id] = checks[i]
+	}
+	return nil
+}
+
 func closeWorkflowModules(modules map[string]Module) error {
 	if len(modules) == 0 {
 		return nil
@@ -308,9 +1083,7 @@ func checkInputsOutputs(op *Operation, info ModuleInfo, opsInfo map[string]Modul
 		}
 
 		if input.IsStatic() {
-			value := input.Any()
-			supportedTypes := param.SupportedTypes()
-			if !matchesAnyType(value, supportedTypes) {
+			if !param.Accepts(input.Any()) {
 				return fmt.Errorf(
 					"input %q for operation %q is static but is not assignable to expected type(s) %s",
 					name, op.Id, param.TypeDisplay(),
@@ -336,13 +1109,18 @@ func checkInputsOutputs(op *Operation, info ModuleInfo, opsInfo map[string]Modul
 					outputKey, depId, name, op.Id,
 				)
 			}
-			supportedTypes := param.SupportedTypes()
-			if !containsExactType(output.Type, supportedTypes) {
+			if !param.AcceptsOutput(output) {
 				return fmt.Errorf(
 					"input %q for operation %q does not match expected type(s) %s from dependency %q",
 					name, op.Id, param.TypeDisplay(), depId,
 				)
 			}
+			if value, hasDefault := DependencyDefault(input); hasDefault && !param.Accepts(value) {
+				return fmt.Errorf(
+					"default of input %q for operation %q is not assignable to expected type(s) %s",
+					name, op.Id, param.TypeDisplay(),
+				)
+			}
 		}
 	}
 	return nil
@@ -366,10 +1144,23 @@ func (we *workflowExecution) setupOperationContext(mctx *moduleContext, op *Oper
 			}
 			depOutput, err := depOpCtx.getOutput(input.OutputKey())
 			if err != nil {
-				return err
+				value, hasDefault := DependencyDefault(input)
+				if !hasDefault {
+					return err
+				}
+				we.logger.Info(
+					"dependency output not produced, using default", "module", op.Module, "id", op.Id,
+					"input", k, "dependency", input.DependencyId(), "output", input.OutputKey(),
+				)
+				depOutput = value
 			}
 			mctx.setInput(k, depOutput)
 		}
+		// A module that does not mark an input as sensitive does not know that it holds a sensitive
+		// value, so the outputs derived from it are treated as sensitive.
+		if !we.moduleInfo[op.Id].Inputs[k].Sensitive && sensitiveInput(input, we.moduleInfo, we.opCtxs) {
+			mctx.derivedSensitive = true
+		}
 	}
 
 	return nil
@@ -430,13 +1221,15 @@ func (g *dependencyGraph) topoSort() ([]string, error) {
 }
 
 // opoSort will topologically sort a set of operations by their id into a linear execution plan.
+// Operations are ordered after their dependsOn operations and the operations whose outputs their
+// inputs use, regardless of the order they are declared in.
 func opoSort(ops []Operation) ([]string, error) {
 	g := &dependencyGraph{
 		ops: make([]string, len(ops)),
 	}
 	for i, op := range ops {
 		g.ops[i] = op.Id
-		for _, dep := range op.DependsOn {
+		for _, dep := range operationDependencies(&op) {
 			g.addDep(op.Id, dep)
 		}
 	}
@@ -0,0 +1,240 @@
+// Package workflow provides a fluent builder of Blackstart workflows for Go programs that generate
+// workflows dynamically, such as tenant onboarding services.
+//
+//	wf, err := workflow.New("tenant-a").
+//		Op("db", "google_cloudsql_database").Input("instance", "main").Input("database", "tenant_a").
+//		Op("secret", "kubernetes_secret").Input("name", "tenant-a-db").DependsOn("db").
+//		Build()
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/pezops/blackstart"
+)
+
+// OutputRef references an output of another operation of the workflow. Inputs set to an OutputRef
+// read the output when the operation runs, and the operation depends on the referenced operation.
+type OutputRef struct {
+	// Id is the identifier of the operation with the output.
+	Id string
+
+	// Output is the key of the output.
+	Output string
+}
+
+// Output returns a reference to the output of the operation with the id, for use as an input value.
+func Output(id, output string) OutputRef {
+	return OutputRef{Id: id, Output: output}
+}
+
+// Builder builds a workflow. Problems of the definition, such as duplicate operation IDs, are
+// collected while building and returned together by Build.
+type Builder struct {
+	wf   blackstart.Workflow
+	errs []error
+}
+
+// New returns a Builder of a workflow with the name.
+func New(name string) *Builder {
+	b := &Builder{wf: blackstart.Workflow{Name: name}}
+	if name == "" {
+		b.errs = append(b.errs, errors.New("workflow name is required"))
+	}
+	return b
+}
+
+// Description sets the description of the workflow.
+func (b *Builder) Description(description string) *Builder {
+	b.wf.Description = description
+	return b
+}
+
+// Namespace sets the Kubernetes namespace of the workflow, which is the default namespace of its
+// published outputs.
+func (b *Builder) Namespace(namespace string) *Builder {
+	b.wf.Namespace = namespace
+	return b
+}
+
+// ReconcileInterval sets the reconcile cadence of the workflow in controller mode.
+func (b *Builder) ReconcileInterval(interval time.Duration) *Builder {
+	b.wf.ReconcileInterval = interval
+	return b
+}
+
+// MaxDeletions limits the number of operations of the workflow with DoesNotExist set.
+func (b *Builder) MaxDeletions(limit int) *Builder {
+	b.wf.MaxDeletions = &limit
+	return b
+}
+
+// ServiceCheck adds a service check of the kind to the workflow, such as
+// blackstart.ServiceCheckTCP. Params are given as key and value pairs.
+func (b *Builder) ServiceCheck(name, kind string, params ...string) *Builder {
+	check := blackstart.ServiceCheck{Name: name, Kind: kind}
+	if len(params)%2 != 0 {
+		b.errs = append(b.errs, fmt.Errorf("service check %q: params must be key and value pairs", name))
+	}
+	for i := 0; i+1 < len(params); i += 2 {
+		if check.Params == nil {
+			check.Params = make(map[string]string)
+		}
+		check.Params[params[i]] = params[i+1]
+	}
+	b.wf.ServiceChecks = append(b.wf.ServiceChecks, check)
+	return b
+}
+
+// Op adds an operation of the module to the workflow and returns its OpBuilder. Operations run in
+// the order of their dependencies, not in the order they are added.
+func (b *Builder) Op(id, module string) *OpBuilder {
+	switch {
+	case id == "":
+		b.errs = append(b.errs, fmt.Errorf("operation of module %q has no id", module))
+	case module == "":
+		b.errs = append(b.errs, fmt.Errorf("operation %q has no module", id))
+	}
+	for _, op := range b.wf.Operations {
+		if id != "" && op.Id == id {
+			b.errs = append(b.errs, fmt.Errorf("duplicate operation id %q in workflow", id))
+			break
+		}
+	}
+	b.wf.Operations = append(b.wf.Operations, blackstart.Operation{Id: id, Module: module})
+	return &OpBuilder{b: b, index: len(b.wf.Operations) - 1}
+}
+
+// Build validates the workflow and returns it. The operations are validated by their modules,
+// which must be registered, such as by importing the module packages. All problems found while
+// building are returned together.
+func (b *Builder) Build() (*blackstart.Workflow, error) {
+	errs := slices.Clone(b.errs)
+	if len(b.wf.Operations) == 0 {
+		errs = append(errs, errors.New("workflow has no operations"))
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid workflow %q: %w", b.wf.Name, errors.Join(errs...))
+	}
+
+	wf := b.wf
+	// The operations are copied, so the builder can be changed without changing built workflows.
+	wf.Operations = make([]blackstart.Operation, len(b.wf.Operations))
+	for i, op := range b.wf.Operations {
+		op.DependsOn = slices.Clone(op.DependsOn)
+		op.Inputs = maps.Clone(op.Inputs)
+		op.Labels = maps.Clone(op.Labels)
+		wf.Operations[i] = op
+	}
+	wf.ServiceChecks = slices.Clone(b.wf.ServiceChecks)
+	// Lint warnings are not errors, so the validation is not logged.
+	ctx := context.WithValue(context.Background(), blackstart.LoggerKey, slog.New(slog.DiscardHandler))
+	if res := wf.Validate(ctx); res.Err != nil {
+		return nil, fmt.Errorf("invalid workflow %q: %w", wf.Name, res.Err)
+	}
+	return &wf, nil
+}
+
+// OpBuilder sets the properties of an operation added with Op.
+type OpBuilder struct {
+	b     *Builder
+	index int
+}
+
+// operation returns the operation being built.
+func (o *OpBuilder) operation() *blackstart.Operation {
+	return &o.b.wf.Operations[o.index]
+}
+
+// Name sets the human-readable name of the operation.
+func (o *OpBuilder) Name(name string) *OpBuilder {
+	o.operation().Name = name
+	return o
+}
+
+// Description sets the description of the operation.
+func (o *OpBuilder) Description(description string) *OpBuilder {
+	o.operation().Description = description
+	return o
+}
+
+// Label sets a label of the operation, to select it for partial runs.
+func (o *OpBuilder) Label(key, value string) *OpBuilder {
+	op := o.operation()
+	if op.Labels == nil {
+		op.Labels = make(map[string]string)
+	}
+	op.Labels[key] = value
+	return o
+}
+
+// Input sets an input of the operation. The value is an OutputRef to read the output of another
+// operation, a blackstart.Input, or a static value.
+func (o *OpBuilder) Input(key string, value any) *OpBuilder {
+	var input blackstart.Input
+	switch v := value.(type) {
+	case OutputRef:
+		input = blackstart.NewInputFromDep(v.Id, v.Output)
+	case blackstart.Input:
+		input = v
+	default:
+		input = blackstart.NewInputFromValue(v)
+	}
+	return o.setInput(key, input)
+}
+
+// SensitiveInput sets an input of the operation to a static value that is masked when the inputs
+// of the operation are recorded, such as a password.
+func (o *OpBuilder) SensitiveInput(key string, value any) *OpBuilder {
+	return o.setInput(key, blackstart.NewSensitiveInputFromValue(value))
+}
+
+// setInput sets an input of the operation. An input may only be set once.
+func (o *OpBuilder) setInput(key string, input blackstart.Input) *OpBuilder {
+	op := o.operation()
+	if _, ok := op.Inputs[key]; ok {
+		o.b.errs = append(o.b.errs, fmt.Errorf("operation %q: input %q is set more than once", op.Id, key))
+		return o
+	}
+	if op.Inputs == nil {
+		op.Inputs = make(map[string]blackstart.Input)
+	}
+	op.Inputs[key] = input
+	return o
+}
+
+// DependsOn adds operations that must run before the operation, in addition to the operations
+// whose outputs are read by its inputs.
+func (o *OpBuilder) DependsOn(ids ...string) *OpBuilder {
+	op := o.operation()
+	for _, id := range ids {
+		if id == op.Id {
+			o.b.errs = append(o.b.errs, fmt.Errorf("operation %q depends on itself", op.Id))
+			continue
+		}
+		op.DependsOn = append(op.DependsOn, id)
+	}
+	return o
+}
+
+// DoesNotExist marks the resource of the operation for deletion.
+func (o *OpBuilder) DoesNotExist() *OpBuilder {
+	o.operation().DoesNotExist = true
+	return o
+}
+
+// Op adds another operation to the workflow, as Builder.Op.
+func (o *OpBuilder) Op(id, module string) *OpBuilder {
+	return o.b.Op(id, module)
+}
+
+// Build validates the workflow and returns it, as Builder.Build.
+func (o *OpBuilder) Build() (*blackstart.Workflow, error) {
+	return o.b.Build()
+}
@@ -0,0 +1,172 @@
+package workflow
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pezops/blackstart"
+)
+
+// builderTestModule outputs its value input.
+type builderTestModule struct{}
+
+func init() {
+	blackstart.RegisterModule("builder_test_module", func() blackstart.Module { return &builderTestModule{} })
+}
+
+func (m *builderTestModule) Info() blackstart.ModuleInfo {
+	return blackstart.ModuleInfo{
+		Id: "builder_test_module",
+		Inputs: map[string]blackstart.InputValue{
+			"value":    {Type: reflect.TypeFor[string](), Required: true},
+			"password": {Type: reflect.TypeFor[string](), Default: ""},
+		},
+		Outputs: map[string]blackstart.OutputValue{
+			"value": {Type: reflect.TypeFor[string]()},
+		},
+	}
+}
+
+func (m *builderTestModule) Validate(_ blackstart.Operation) error { return nil }
+func (m *builderTestModule) Check(_ blackstart.ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *builderTestModule) Set(ctx blackstart.ModuleContext) error {
+	value, err := blackstart.ContextInputAs[string](ctx, "value", true)
+	if err != nil {
+		return err
+	}
+	return ctx.Output("value", value)
+}
+
+func TestBuilder(t *testing.T) {
+	b := New("tenant-a").
+		Description("onboarding of tenant a").
+		Namespace("tenants").
+		ReconcileInterval(10*time.Minute).
+		MaxDeletions(1).
+		ServiceCheck("db", blackstart.ServiceCheckTCP, "address", "db.internal:5432")
+	wf, err := b.
+		Op("secret", "builder_test_module").Input("value", Output("db", "value")).DependsOn("setup").
+		Op("db", "builder_test_module").Name("database").Label("tier", "db").Input("value", "tenant_a").
+		SensitiveInput("password", "hunter2").
+		Op("setup", "builder_test_module").Input("value", blackstart.NewInputFromValue("setup")).
+		Op("old", "builder_test_module").Input("value", "old").DoesNotExist().
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, "tenant-a", wf.Name)
+	assert.Equal(t, "onboarding of tenant a", wf.Description)
+	assert.Equal(t, "tenants", wf.Namespace)
+	assert.Equal(t, 10*time.Minute, wf.ReconcileInterval)
+	require.NotNil(t, wf.MaxDeletions)
+	assert.Equal(t, 1, *wf.MaxDeletions)
+	assert.Equal(
+		t, []blackstart.ServiceCheck{
+			{Name: "db", Kind: blackstart.ServiceCheckTCP, Params: map[string]string{"address": "db.internal:5432"}},
+		}, wf.ServiceChecks,
+	)
+	require.Len(t, wf.Operations, 4)
+
+	secret := wf.Operations[0]
+	assert.Equal(t, []string{"setup"}, secret.DependsOn)
+	assert.False(t, secret.Inputs["value"].IsStatic())
+	assert.Equal(t, "db", secret.Inputs["value"].DependencyId())
+	assert.Equal(t, "value", secret.Inputs["value"].OutputKey())
+	db := wf.Operations[1]
+	assert.Equal(t, "database", db.Name)
+	assert.Equal(t, map[string]string{"tier": "db"}, db.Labels)
+	assert.Equal(t, "tenant_a", db.Inputs["value"].Any())
+	assert.Equal(t, "hunter2", db.Inputs["password"].Any())
+	assert.Equal(t, "setup", wf.Operations[2].Inputs["value"].Any())
+	assert.True(t, wf.Operations[3].DoesNotExist)
+
+	// Changes to the builder do not change a built workflow.
+	b.Op("later", "builder_test_module").Input("value", "later")
+	assert.Len(t, wf.Operations, 4)
+}
+
+func TestBuilder_Run(t *testing.T) {
+	wf, err := New("run").
+		Op("b", "builder_test_module").Input("value", Output("a", "value")).
+		Op("a", "builder_test_module").Input("value", "hello").
+		Build()
+	require.NoError(t, err)
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 2, res.CompletedOperations)
+	require.Len(t, res.Plan, 2)
+	assert.Equal(t, "a", res.Plan[0].Id)
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (*blackstart.Workflow, error)
+		want  []string
+	}{
+		{
+			name:  "no operations",
+			build: New("empty").Build,
+			want:  []string{`invalid workflow "empty": workflow has no operations`},
+		},
+		{
+			name: "definition errors",
+			build: New("").
+				ServiceCheck("db", blackstart.ServiceCheckTCP, "address").
+				Op("a", "builder_test_module").Input("value", "x").Input("value", "y").DependsOn("a").
+				Op("a", "").
+				Op("", "builder_test_module").
+				Build,
+			want: []string{
+				"workflow name is required",
+				`operation "a": input "value" is set more than once`,
+				`operation "a" depends on itself`,
+				`operation "a" has no module`,
+				`duplicate operation id "a" in workflow`,
+				`operation of module "builder_test_module" has no id`,
+				`service check "db": params must be key and value pairs`,
+			},
+		},
+		{
+			name: "unknown module",
+			build: New("unknown").
+				Op("a", "missing_module").
+				Build,
+			want: []string{`invalid workflow "unknown": unable to instantiate module for operation`},
+		},
+		{
+			name: "missing required input",
+			build: New("required").
+				Op("a", "builder_test_module").
+				Build,
+			want: []string{`invalid workflow "required": `, `"value"`},
+		},
+		{
+			name: "unknown dependency",
+			build: New("dependency").
+				Op("a", "builder_test_module").Input("value", Output("missing", "value")).
+				Build,
+			want: []string{`invalid workflow "dependency": `, "missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(
+			tt.name, func(t *testing.T) {
+				wf, err := tt.build()
+				require.Error(t, err)
+				assert.Nil(t, wf)
+				for _, want := range tt.want {
+					assert.ErrorContains(t, err, want)
+				}
+			},
+		)
+	}
+}
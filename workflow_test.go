@@ -1,11 +1,15 @@
 package blackstart
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,6 +50,315 @@ func (m *cleanupTestModule) Close() error {
 	return nil
 }
 
+// batchTestModule records CheckMany and Set calls to verify batched checks. CheckMany fails when the
+// batch_error input of an operation is set.
+type batchTestModule struct{}
+
+var batchTestCalls struct {
+	sync.Mutex
+	batches [][]string
+	sets    []string
+}
+
+func init() {
+	RegisterModule("batch_test_module", func() Module { return &batchTestModule{} })
+}
+
+func (m *batchTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "batch_test_module",
+		Inputs: map[string]InputValue{
+			"name": {
+				Type:     reflect.TypeFor[string](),
+				Required: true,
+			},
+			testCheckResult: {
+				Type:     reflect.TypeFor[bool](),
+				Required: true,
+			},
+			"batch_error": {
+				Type:    reflect.TypeFor[string](),
+				Default: "",
+			},
+		},
+		Outputs: map[string]OutputValue{
+			"name": {Type: reflect.TypeFor[string]()},
+		},
+	}
+}
+
+func (m *batchTestModule) Validate(_ Operation) error { return nil }
+func (m *batchTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, errors.New("check must not be called for batched operations")
+}
+func (m *batchTestModule) CheckMany(ctxs []ModuleContext) ([]bool, error) {
+	batchTestCalls.Lock()
+	defer batchTestCalls.Unlock()
+	names := make([]string, len(ctxs))
+	results := make([]bool, len(ctxs))
+	for i, ctx := range ctxs {
+		if batchErr, _ := ContextInputAs[string](ctx, "batch_error", false); batchErr != "" {
+			return nil, errors.New(batchErr)
+		}
+		names[i], _ = ContextInputAs[string](ctx, "name", true)
+		results[i], _ = ContextInputAs[bool](ctx, testCheckResult, true)
+		if results[i] {
+			_ = ctx.Output("name", names[i])
+		}
+	}
+	batchTestCalls.batches = append(batchTestCalls.batches, names)
+	return results, nil
+}
+func (m *batchTestModule) Set(ctx ModuleContext) error {
+	batchTestCalls.Lock()
+	defer batchTestCalls.Unlock()
+	name, _ := ContextInputAs[string](ctx, "name", true)
+	batchTestCalls.sets = append(batchTestCalls.sets, name)
+	return ctx.Output("name", name)
+}
+
+// preflightTestModule fails its preflight check when the preflight_error input is set.
+type preflightTestModule struct{}
+
+var preflightTestSets atomic.Int32
+
+func init() {
+	RegisterModule("preflight_test_module", func() Module { return &preflightTestModule{} })
+}
+
+func (m *preflightTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "preflight_test_module",
+		Inputs: map[string]InputValue{
+			"preflight_error": {
+				Type:     reflect.TypeFor[string](),
+				Required: false,
+			},
+		},
+		Outputs: map[string]OutputValue{
+			"result": {Type: reflect.TypeFor[string]()},
+		},
+	}
+}
+
+func (m *preflightTestModule) Validate(_ Operation) error { return nil }
+func (m *preflightTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *preflightTestModule) Set(ctx ModuleContext) error {
+	preflightTestSets.Add(1)
+	return ctx.Output("result", "")
+}
+func (m *preflightTestModule) Preflight(ctx ModuleContext) error {
+	msg, err := ContextInputAs[string](ctx, "preflight_error", false)
+	if err != nil {
+		return err
+	}
+	if msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// serialTestModule records the highest number of concurrent Set calls for operations sharing its
+// serialization key.
+type serialTestModule struct{}
+
+var serialTestActive, serialTestMaxActive atomic.Int32
+
+func init() {
+	RegisterModule("serial_test_module", func() Module { return &serialTestModule{} })
+}
+
+func (m *serialTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "serial_test_module",
+		Inputs: map[string]InputValue{
+			"target": {
+				Type:     reflect.TypeFor[string](),
+				Required: true,
+			},
+		},
+		SerializationKey: func(ctx ModuleContext) (string, error) {
+			return ContextInputAs[string](ctx, "target", true)
+		},
+	}
+}
+
+func (m *serialTestModule) Validate(_ Operation) error { return nil }
+func (m *serialTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *serialTestModule) Set(_ ModuleContext) error {
+	active := serialTestActive.Add(1)
+	defer serialTestActive.Add(-1)
+	for {
+		maxActive := serialTestMaxActive.Load()
+		if active <= maxActive || serialTestMaxActive.CompareAndSwap(maxActive, active) {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	return nil
+}
+
+// resourceTestModule reports the value of its resource input as a managed resource from both
+// Check and Set.
+type resourceTestModule struct{}
+
+func init() {
+	RegisterModule("resource_test_module", func() Module { return &resourceTestModule{} })
+}
+
+func (m *resourceTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "resource_test_module",
+		Inputs: map[string]InputValue{
+			"resource": {
+				Type:     reflect.TypeFor[string](),
+				Required: true,
+			},
+		},
+	}
+}
+
+func (m *resourceTestModule) Validate(_ Operation) error { return nil }
+func (m *resourceTestModule) Check(ctx ModuleContext) (bool, error) {
+	resource, err := ContextInputAs[string](ctx, "resource", true)
+	if err != nil {
+		return false, err
+	}
+	ctx.Resource(resource)
+	return false, nil
+}
+func (m *resourceTestModule) Set(ctx ModuleContext) error {
+	resource, err := ContextInputAs[string](ctx, "resource", true)
+	if err != nil {
+		return err
+	}
+	ctx.Resource(resource)
+	return nil
+}
+
+// metricsTestModule records the number of API calls set in its calls input.
+type metricsTestModule struct{}
+
+func init() {
+	RegisterModule("metrics_test_module", func() Module { return &metricsTestModule{} })
+}
+
+func (m *metricsTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "metrics_test_module",
+		Inputs: map[string]InputValue{
+			"calls": {
+				Type:     reflect.TypeFor[int](),
+				Required: true,
+			},
+		},
+	}
+}
+
+func (m *metricsTestModule) Validate(_ Operation) error { return nil }
+func (m *metricsTestModule) Check(ctx ModuleContext) (bool, error) {
+	ctx.APICall(APIProviderKubernetes)
+	return false, nil
+}
+func (m *metricsTestModule) Set(ctx ModuleContext) error {
+	calls, err := ContextInputAs[int](ctx, "calls", true)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < calls; i++ {
+		ctx.APICall(APIProviderGoogle)
+	}
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+// recordTestModule outputs its name input and a token derived from its sensitive password input.
+type recordTestModule struct{}
+
+func init() {
+	RegisterModule("record_test_module", func() Module { return &recordTestModule{} })
+}
+
+func (m *recordTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "record_test_module",
+		Inputs: map[string]InputValue{
+			"name": {
+				Type:     reflect.TypeFor[string](),
+				Required: true,
+			},
+			"password": {
+				Type:      reflect.TypeFor[string](),
+				Sensitive: true,
+			},
+		},
+		Outputs: map[string]OutputValue{
+			"name": {
+				Type: reflect.TypeFor[string](),
+			},
+			"token": {
+				Type:      reflect.TypeFor[string](),
+				Sensitive: true,
+			},
+		},
+	}
+}
+
+func (m *recordTestModule) Validate(_ Operation) error { return nil }
+func (m *recordTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *recordTestModule) Set(ctx ModuleContext) error {
+	name, err := ContextInputAs[string](ctx, "name", true)
+	if err != nil {
+		return err
+	}
+	password, err := ContextInputAs[string](ctx, "password", false)
+	if err != nil {
+		return err
+	}
+	if err = ctx.Output("name", name); err != nil {
+		return err
+	}
+	return ctx.Output("token", name+":"+password)
+}
+
+// optionalOutputTestModule outputs its value input, and does not produce the output when the input
+// is not set.
+type optionalOutputTestModule struct{}
+
+func init() {
+	RegisterModule("optional_output_test_module", func() Module { return &optionalOutputTestModule{} })
+}
+
+func (m *optionalOutputTestModule) Info() ModuleInfo {
+	return ModuleInfo{
+		Id: "optional_output_test_module",
+		Inputs: map[string]InputValue{
+			"value": {Type: reflect.TypeFor[string]()},
+		},
+		Outputs: map[string]OutputValue{
+			"value": {Type: reflect.TypeFor[string]()},
+		},
+	}
+}
+
+func (m *optionalOutputTestModule) Validate(_ Operation) error { return nil }
+func (m *optionalOutputTestModule) Check(_ ModuleContext) (bool, error) {
+	return false, nil
+}
+func (m *optionalOutputTestModule) Set(ctx ModuleContext) error {
+	value, err := ContextInputAs[string](ctx, "value", false)
+	if err != nil || value == "" {
+		return err
+	}
+	return ctx.Output("value", value)
+}
+
 func ctxMustInput(ctx ModuleContext, key string) Input {
 	in, _ := ctx.Input(key)
 	return in
@@ -328,6 +641,34 @@ func TestWorkflowExecution_DuplicateOperationID(t *testing.T) {
 	require.Equal(t, phaseSetup, res.Phase)
 }
 
+func TestWorkflowExecution_LogsWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &RuntimeConfig{LogFormat: "text", LogLevel: "warn"}
+	ctx := context.WithValue(context.Background(), LoggerKey, newLoggerForWriter(cfg, &buf))
+	wf := Workflow{
+		Name: "warnings",
+		Operations: []Operation{
+			{
+				Id:     "a",
+				Module: "test_module",
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(true),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+		},
+		Warnings: []string{"operation a input b uses the deprecated key from_dependency instead of fromDependency"},
+	}
+
+	res := wf.Run(ctx)
+	require.NoError(t, res.Err)
+	assert.Contains(
+		t, buf.String(),
+		"WARN workflow definition warning workflow=warnings "+
+			"warning=operation a input b uses the deprecated key from_dependency instead of fromDependency",
+	)
+}
+
 func TestWorkflowExecution_CallsOptionalModuleClose(t *testing.T) {
 	cleanupModuleCloseCalls.Store(0)
 	wf := Workflow{
@@ -349,6 +690,609 @@ func TestWorkflowExecution_CallsOptionalModuleClose(t *testing.T) {
 	require.Equal(t, int32(1), cleanupModuleCloseCalls.Load())
 }
 
+func TestWorkflowExecution_BatchChecks(t *testing.T) {
+	batchTestCalls.batches = nil
+	batchTestCalls.sets = nil
+	wf := Workflow{
+		Name: "batch-check-test",
+		Operations: []Operation{
+			{
+				Id:     "a",
+				Module: "batch_test_module",
+				Inputs: map[string]Input{
+					"name":          NewInputFromValue("a"),
+					testCheckResult: NewInputFromValue(true),
+				},
+			},
+			{
+				Id:     "b",
+				Module: "batch_test_module",
+				Inputs: map[string]Input{
+					"name":          NewInputFromValue("b"),
+					testCheckResult: NewInputFromValue(false),
+				},
+			},
+			{
+				Id:     "dep",
+				Module: "test_module",
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(true),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+			{
+				Id:        "c",
+				Module:    "batch_test_module",
+				DependsOn: []string{"dep"},
+				Inputs: map[string]Input{
+					"name":          NewInputFromDep("a", "name"),
+					testCheckResult: NewInputFromValue(false),
+				},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 4, res.CompletedOperations)
+	assert.Equal(t, [][]string{{"a", "b"}, {"a"}}, batchTestCalls.batches)
+	assert.Equal(t, []string{"b", "a"}, batchTestCalls.sets)
+
+	// Operations are not checked before unrelated operations between them have run.
+	batchTestCalls.batches = nil
+	batchTestCalls.sets = nil
+	wf.Operations = []Operation{
+		wf.Operations[0],
+		{
+			Id:     "between",
+			Module: "test_module",
+			Inputs: map[string]Input{
+				testCheckResult: NewInputFromValue(true),
+				testSetResult:   NewInputFromValue(true),
+			},
+		},
+		wf.Operations[1],
+	}
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, [][]string{{"a"}, {"b"}}, batchTestCalls.batches)
+	assert.Equal(t, []string{"b"}, batchTestCalls.sets)
+}
+
+func TestWorkflowExecution_BatchCheckFailure(t *testing.T) {
+	batchWorkflow := func(policy string) Workflow {
+		return Workflow{
+			Name: "batch-check-failure-test",
+			Operations: []Operation{
+				{
+					Id:     "a",
+					Module: "batch_test_module",
+					Inputs: map[string]Input{
+						"name":          NewInputFromValue("a"),
+						testCheckResult: NewInputFromValue(false),
+						"batch_error":   NewInputFromValue("list failed"),
+					},
+					OnFailure: policy,
+				},
+				{
+					Id:        "dep",
+					Module:    "batch_test_module",
+					DependsOn: []string{"a"},
+					Inputs: map[string]Input{
+						"name":          NewInputFromValue("dep"),
+						testCheckResult: NewInputFromValue(false),
+					},
+				},
+				{
+					Id:     "other",
+					Module: "test_module",
+					Inputs: map[string]Input{
+						testCheckResult: NewInputFromValue(true),
+						testSetResult:   NewInputFromValue(true),
+					},
+				},
+			},
+		}
+	}
+
+	t.Run(
+		"abort", func(t *testing.T) {
+			batchTestCalls.batches = nil
+			batchTestCalls.sets = nil
+			wf := batchWorkflow("")
+			res := wf.Run(context.Background())
+			require.EqualError(t, res.Err, "list failed")
+			require.NotNil(t, res.Op)
+			assert.Equal(t, "a", res.Op.Id)
+			assert.Equal(t, []string{"a"}, operationIds(res.Operations))
+		},
+	)
+
+	t.Run(
+		"continue", func(t *testing.T) {
+			batchTestCalls.batches = nil
+			batchTestCalls.sets = nil
+			wf := batchWorkflow(OnFailureContinue)
+			res := wf.Run(context.Background())
+			require.NoError(t, res.Err)
+			require.Len(t, res.Operations, 3)
+			assert.EqualError(t, res.Operations[0].Err, "list failed")
+			// Operations depending on the failed operation are skipped, and the others are run.
+			ops := make(map[string]OperationResult)
+			for _, op := range res.Operations {
+				ops[op.Id] = op
+			}
+			assert.True(t, ops["dep"].Skipped)
+			assert.False(t, ops["other"].Skipped)
+			assert.NoError(t, ops["other"].Err)
+			assert.Empty(t, batchTestCalls.sets)
+		},
+	)
+}
+
+func TestWorkflowExecution_CheckOnly(t *testing.T) {
+	batchTestCalls.batches = nil
+	batchTestCalls.sets = nil
+	wf := Workflow{
+		Name:      "check-only-test",
+		CheckOnly: true,
+		Operations: []Operation{
+			{
+				Id:     "a",
+				Module: "batch_test_module",
+				Inputs: map[string]Input{
+					"name":          NewInputFromValue("a"),
+					testCheckResult: NewInputFromValue(true),
+				},
+			},
+			{
+				Id:     "b",
+				Module: "batch_test_module",
+				Inputs: map[string]Input{
+					"name":          NewInputFromValue("b"),
+					testCheckResult: NewInputFromValue(false),
+				},
+			},
+			{
+				Id:     "c",
+				Module: "batch_test_module",
+				Inputs: map[string]Input{
+					"name":          NewInputFromDep("b", "name"),
+					testCheckResult: NewInputFromValue(true),
+				},
+			},
+			{
+				Id:     "d",
+				Module: "test_module",
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(false),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+			{
+				Id:        "e",
+				Module:    "test_module",
+				DependsOn: []string{"d"},
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(true),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 5, res.CompletedOperations)
+	assert.Empty(t, batchTestCalls.sets)
+
+	// Operations depending on a drifted operation are not checked.
+	drifted := map[string]bool{}
+	skipped := map[string]bool{}
+	for _, op := range res.Operations {
+		drifted[op.Id] = op.Drifted
+		skipped[op.Id] = op.Skipped
+	}
+	assert.Equal(t, map[string]bool{"a": false, "b": true, "c": false, "d": true, "e": false}, drifted)
+	assert.Equal(t, map[string]bool{"a": false, "b": false, "c": true, "d": false, "e": true}, skipped)
+}
+
+func TestWorkflowExecution_MaintenanceWindow(t *testing.T) {
+	batchTestCalls.batches = nil
+	batchTestCalls.sets = nil
+	// The window opens for one minute, half an hour from now.
+	schedule := fmt.Sprintf("%d * * * *", (time.Now().Minute()+30)%60)
+	mw, err := NewMaintenanceWindow(schedule, time.Minute, "")
+	require.NoError(t, err)
+	wf := Workflow{
+		Name:              "maintenance-window-test",
+		MaintenanceWindow: mw,
+		Operations: []Operation{
+			{
+				Id:     "a",
+				Module: "batch_test_module",
+				Inputs: map[string]Input{
+					"name":          NewInputFromValue("a"),
+					testCheckResult: NewInputFromValue(false),
+				},
+			},
+			{
+				Id:     "b",
+				Module: "test_module",
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(true),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+			{
+				Id:        "c",
+				Module:    "test_module",
+				DependsOn: []string{"a"},
+				Inputs: map[string]Input{
+					testCheckResult: NewInputFromValue(true),
+					testSetResult:   NewInputFromValue(true),
+				},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 3, res.CompletedOperations)
+	assert.Empty(t, batchTestCalls.sets)
+	require.Len(t, res.Operations, 3)
+	for _, op := range res.Operations {
+		assert.Equal(t, op.Id == "a", op.PendingWindow, op.Id)
+		assert.Equal(t, op.Id == "c", op.Skipped, op.Id)
+		assert.False(t, op.Drifted, op.Id)
+	}
+
+	// Without a window, the operation is set.
+	wf.MaintenanceWindow = nil
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, []string{"a"}, batchTestCalls.sets)
+}
+
+func TestWorkflowExecution_Preflight(t *testing.T) {
+	preflightTestSets.Store(0)
+	wf := Workflow{
+		Name: "preflight-test",
+		Operations: []Operation{
+			{
+				Id:     "ok",
+				Module: "preflight_test_module",
+			},
+			{
+				Id:     "unreachable",
+				Module: "preflight_test_module",
+				Inputs: map[string]Input{
+					"preflight_error": NewInputFromValue("connection refused"),
+				},
+			},
+			{
+				Id:     "forbidden",
+				Module: "preflight_test_module",
+				Inputs: map[string]Input{
+					"preflight_error": NewInputFromValue("permission denied"),
+				},
+			},
+			{
+				// Operations with dependency inputs are not preflighted.
+				Id:     "dynamic",
+				Module: "preflight_test_module",
+				Inputs: map[string]Input{
+					"preflight_error": NewInputFromDep("ok", "result"),
+				},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.Error(t, res.Err)
+	assert.Equal(t, phasePreflight, res.Phase)
+	assert.Contains(t, res.Err.Error(), "preflight failed for 2 operation(s)")
+	assert.Contains(t, res.Err.Error(), `operation "unreachable": connection refused`)
+	assert.Contains(t, res.Err.Error(), `operation "forbidden": permission denied`)
+	assert.NotContains(t, res.Err.Error(), "dynamic")
+	assert.Equal(t, int32(0), preflightTestSets.Load())
+	assert.Equal(t, 0, res.CompletedOperations)
+
+	wf.Operations = []Operation{wf.Operations[0], wf.Operations[3]}
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, int32(2), preflightTestSets.Load())
+}
+
+func TestWorkflowExecution_SerializationKey(t *testing.T) {
+	run := func(sameTarget bool, workflows int) int32 {
+		serialTestMaxActive.Store(0)
+		var wg sync.WaitGroup
+		for i := 0; i < workflows; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				target := "db"
+				if !sameTarget {
+					target = fmt.Sprintf("db-%d", i)
+				}
+				wf := Workflow{
+					Name: fmt.Sprintf("serial-%d", i),
+					Operations: []Operation{
+						{
+							Id:     "op",
+							Module: "serial_test_module",
+							Inputs: map[string]Input{"target": NewInputFromValue(target)},
+						},
+					},
+				}
+				assert.NoError(t, wf.Run(context.Background()).Err)
+			}()
+		}
+		wg.Wait()
+		return serialTestMaxActive.Load()
+	}
+
+	// Operations of parallel workflows with the same key do not run at the same time.
+	assert.Equal(t, int32(1), run(true, 4))
+	// Operations with different keys may run at the same time.
+	assert.Greater(t, run(false, 4), int32(1))
+}
+
+func TestWorkflowExecution_MaxDeletions(t *testing.T) {
+	maxDeletions := 1
+	wf := Workflow{
+		Name:         "deletions-test",
+		MaxDeletions: &maxDeletions,
+		Operations: []Operation{
+			{Id: "keep", Module: "preflight_test_module"},
+			{Id: "remove-a", Module: "preflight_test_module", DoesNotExist: true},
+			{Id: "remove-b", Module: "preflight_test_module", DoesNotExist: true},
+		},
+	}
+
+	preflightTestSets.Store(0)
+	res := wf.Run(context.Background())
+	require.ErrorIs(t, res.Err, ErrMaxDeletionsExceeded)
+	assert.Contains(t, res.Err.Error(), "workflow has 2 operations with doesNotExist set, exceeding the maximum of 1")
+	assert.Equal(t, phaseValidate, res.Phase)
+	assert.Equal(t, int32(0), preflightTestSets.Load())
+
+	// An approval only applies to the exact number of deletions.
+	wf.ApprovedDeletions = 3
+	res = wf.Run(context.Background())
+	require.ErrorIs(t, res.Err, ErrMaxDeletionsExceeded)
+
+	wf.ApprovedDeletions = 2
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	assert.Equal(t, 3, res.CompletedOperations)
+
+	maxDeletions = 2
+	wf.ApprovedDeletions = 0
+	res = wf.Run(context.Background())
+	require.NoError(t, res.Err)
+}
+
+func TestWorkflowExecution_ManagedResources(t *testing.T) {
+	wf := Workflow{
+		Name: "resources-test",
+		Operations: []Operation{
+			{Id: "a", Module: "resource_test_module", Inputs: map[string]Input{"resource": NewInputFromValue("ns/a")}},
+			{
+				Id:        "b",
+				Module:    "resource_test_module",
+				DependsOn: []string{"a"},
+				Inputs:    map[string]Input{"resource": NewInputFromValue("ns/b")},
+			},
+			{
+				Id:           "removed",
+				Module:       "resource_test_module",
+				DoesNotExist: true,
+				Inputs:       map[string]Input{"resource": NewInputFromValue("ns/removed")},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	// Resources reported from both Check and Set are only recorded once, and resources of
+	// operations that ensure a resource does not exist are not recorded.
+	assert.Equal(
+		t, []ManagedResource{
+			{Id: "ns/a", Module: "resource_test_module", OperationId: "a"},
+			{Id: "ns/b", Module: "resource_test_module", OperationId: "b"},
+		}, res.ManagedResources,
+	)
+}
+
+func TestWorkflowExecution_OperationMetrics(t *testing.T) {
+	wf := Workflow{
+		Name: "metrics-test",
+		Operations: []Operation{
+			{Id: "a", Module: "metrics_test_module", Inputs: map[string]Input{"calls": NewInputFromValue(2)}},
+			{
+				Id:        "b",
+				Module:    "metrics_test_module",
+				DependsOn: []string{"a"},
+				Inputs:    map[string]Input{"calls": NewInputFromValue(0)},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	require.Len(t, res.Operations, 2)
+	assert.Equal(t, "a", res.Operations[0].Id)
+	assert.Equal(t, "metrics_test_module", res.Operations[0].Module)
+	assert.Equal(t, int64(3), res.Operations[0].APICalls)
+	assert.GreaterOrEqual(t, res.Operations[0].Duration, 10*time.Millisecond)
+	assert.Equal(t, "b", res.Operations[1].Id)
+	assert.Equal(t, int64(1), res.Operations[1].APICalls)
+	assert.Equal(t, map[string]int64{"kubernetes": 1, "google": 2}, res.Operations[0].ProviderAPICalls)
+	assert.Equal(t, map[string]int64{"kubernetes": 1}, res.Operations[1].ProviderAPICalls)
+	assert.Equal(t, map[string]int64{"kubernetes": 2, "google": 2}, res.ProviderAPICalls())
+}
+
+func TestWorkflowExecution_RecordedValues(t *testing.T) {
+	wf := Workflow{
+		Name: "record-test",
+		Operations: []Operation{
+			{
+				Id:     "a",
+				Module: "record_test_module",
+				Inputs: map[string]Input{
+					"name":     NewInputFromValue("app"),
+					"password": NewInputFromValue("hunter2"),
+				},
+			},
+			{
+				Id:     "b",
+				Module: "record_test_module",
+				Inputs: map[string]Input{
+					"name":     NewInputFromDep("a", "name"),
+					"password": NewInputFromDep("a", "token"),
+				},
+			},
+			{Id: "c", Module: "resource_test_module", Inputs: map[string]Input{"resource": NewInputFromDep("a", "token")}},
+			{
+				Id:     "d",
+				Module: "resource_test_module",
+				Inputs: map[string]Input{"resource": NewSensitiveInputFromValue("decrypted")},
+			},
+			{Id: "e", Module: "metrics_test_module", Inputs: map[string]Input{"calls": NewInputFromValue(0)}},
+			{
+				Id:     "f",
+				Module: "record_test_module",
+				Inputs: map[string]Input{"name": NewSensitiveInputFromValue("decrypted")},
+			},
+			{Id: "g", Module: "resource_test_module", Inputs: map[string]Input{"resource": NewInputFromDep("f", "name")}},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	recorded := make(map[string]OperationResult, len(res.Operations))
+	for _, op := range res.Operations {
+		recorded[op.Id] = op
+	}
+	assert.Equal(t, map[string]string{"name": "app", "password": MaskedValue}, recorded["a"].Inputs)
+	assert.Equal(t, map[string]string{"name": "app", "token": MaskedValue}, recorded["a"].Outputs)
+	assert.Equal(t, map[string]string{"name": "app", "password": MaskedValue}, recorded["b"].Inputs)
+	// Inputs taken from sensitive outputs and sensitive static values are masked, even when the
+	// module does not mark the input as sensitive.
+	assert.Equal(t, map[string]string{"resource": MaskedValue}, recorded["c"].Inputs)
+	assert.Equal(t, map[string]string{"resource": MaskedValue}, recorded["d"].Inputs)
+	assert.Equal(t, map[string]string{"calls": "0"}, recorded["e"].Inputs)
+	assert.Nil(t, recorded["e"].Outputs)
+	// Outputs derived from a sensitive value the module does not mark as sensitive are masked, and
+	// so are the inputs taken from them.
+	assert.Equal(t, map[string]string{"name": MaskedValue, "token": MaskedValue}, recorded["f"].Outputs)
+	assert.Equal(t, map[string]string{"resource": MaskedValue}, recorded["g"].Inputs)
+}
+
+func TestWorkflowExecution_DependencyDefault(t *testing.T) {
+	wf := Workflow{
+		Name: "dependency-default-test",
+		Operations: []Operation{
+			{Id: "set", Module: "optional_output_test_module", Inputs: map[string]Input{"value": NewInputFromValue("set")}},
+			{Id: "unset", Module: "optional_output_test_module"},
+			{
+				Id:     "from-set",
+				Module: "resource_test_module",
+				Inputs: map[string]Input{"resource": NewInputFromDepWithDefault("set", "value", "fallback")},
+			},
+			{
+				Id:     "from-unset",
+				Module: "resource_test_module",
+				Inputs: map[string]Input{"resource": NewInputFromDepWithDefault("unset", "value", "fallback")},
+			},
+		},
+	}
+
+	res := wf.Run(context.Background())
+	require.NoError(t, res.Err)
+	// The default is only used when the dependency does not produce the output.
+	assert.Equal(
+		t, []ManagedResource{
+			{Id: "set", Module: "resource_test_module", OperationId: "from-set"},
+			{Id: "fallback", Module: "resource_test_module", OperationId: "from-unset"},
+		}, res.ManagedResources,
+	)
+
+	// Without a default, a missing output fails the run.
+	wf.Operations[3].Inputs["resource"] = NewInputFromDep("unset", "value")
+	res = wf.Run(context.Background())
+	require.EqualError(t, res.Err, "error setting up context: output key does not exist: value")
+	require.NotNil(t, res.Op)
+	assert.Equal(t, "from-unset", res.Op.Id)
+}
+
+func TestWorkflowExecution_Plan(t *testing.T) {
+	wf := Workflow{
+		Name: "plan-test",
+		Operations: []Operation{
+			{
+				Id:     "a",
+				Module: "record_test_module",
+				Inputs: map[string]Input{
+					"name":     NewInputFromValue("app"),
+					"password": NewInputFromValue("hunter2"),
+				},
+			},
+			{
+				Id:     "b",
+				Module: "record_test_module",
+				Inputs: map[string]Input{
+					"name":     NewInputFromDep("a", "name"),
+					"password": NewInputFromDep("a", "token"),
+				},
+			},
+			{
+				Id:        "check",
+				Module:    "preflight_test_module",
+				DependsOn: []string{"b"},
+				Inputs:    map[string]Input{"preflight_error": NewInputFromValue("not ready")},
+			},
+		},
+	}
+
+	// The plan is recorded for runs that fail after the operations are validated.
+	res := wf.Run(context.Background())
+	require.Error(t, res.Err)
+	assert.Equal(t, phasePreflight, res.Phase)
+	assert.Equal(
+		t, []PlannedOperation{
+			{
+				Id:     "a",
+				Module: "record_test_module",
+				Inputs: map[string]PlannedInput{"name": {Value: "app"}, "password": {Value: MaskedValue}},
+			},
+			{
+				Id:        "b",
+				Module:    "record_test_module",
+				DependsOn: []string{"a"},
+				Inputs: map[string]PlannedInput{
+					"name":     {DependencyId: "a", OutputKey: "name"},
+					"password": {DependencyId: "a", OutputKey: "token"},
+				},
+			},
+			{
+				Id:        "check",
+				Module:    "preflight_test_module",
+				DependsOn: []string{"b"},
+				Inputs:    map[string]PlannedInput{"preflight_error": {Value: "not ready"}},
+			},
+		}, res.Plan,
+	)
+
+	// No plan is recorded for runs that fail validation.
+	wf.Operations[2].DependsOn = []string{"missing"}
+	res = wf.Run(context.Background())
+	require.Error(t, res.Err)
+	assert.Nil(t, res.Plan)
+}
+
 // TestOpoSort tests the topological sorting of operations into an expected order.
 func TestOpoSort(t *testing.T) {
 	tests := []struct {
@@ -512,6 +1456,27 @@ func TestOpoSort(t *testing.T) {
 			out:    []string{"test0", "test2", "test3", "test1"},
 			outErr: nil,
 		},
+		// dependencies of inputs
+		{
+			name: "sort_inputs",
+			in: []Operation{
+				{
+					Id:     "test0",
+					Inputs: map[string]Input{"value": NewInputFromDep("test1", "result")},
+				},
+				{
+					Id: "test1",
+					Inputs: map[string]Input{
+						"value": NewInputFromDep("test2", "result"), "other": NewInputFromValue("x"),
+					},
+				},
+				{
+					Id:        "test2",
+					DependsOn: nil,
+				},
+			},
+			out: []string{"test2", "test1", "test0"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -622,6 +1587,35 @@ func TestCheckInputsOutputs_DependencyOutputTypeMismatch(t *testing.T) {
 	require.ErrorContains(t, err, "expected type(s)")
 }
 
+func TestCheckInputsOutputs_DependencyDefaultTypeMismatch(t *testing.T) {
+	op := &Operation{
+		Id: "test-op",
+		Inputs: map[string]Input{
+			"value": NewInputFromDepWithDefault("dep-op", "result", []any{"a", "b"}),
+		},
+	}
+	info := ModuleInfo{
+		Inputs: map[string]InputValue{
+			"value": {Required: true, Type: reflect.TypeFor[string]()},
+		},
+	}
+	opsInfo := map[string]ModuleInfo{
+		"dep-op": {
+			Outputs: map[string]OutputValue{
+				"result": {Type: reflect.TypeFor[string]()},
+			},
+		},
+	}
+
+	err := checkInputsOutputs(op, info, opsInfo)
+	require.EqualError(
+		t, err, `default of input "value" for operation "test-op" is not assignable to expected type(s) string`,
+	)
+
+	op.Inputs["value"] = NewInputFromDepWithDefault("dep-op", "result", "fallback")
+	require.NoError(t, checkInputsOutputs(op, info, opsInfo))
+}
+
 func TestCheckInputsOutputs_StaticAnySliceMatchesStringSlice(t *testing.T) {
 	op := &Operation{
 		Id: "test-op",
@@ -662,3 +1656,24 @@ func TestCheckInputsOutputs_StaticAnySliceMismatchStringSlice(t *testing.T) {
 	require.Error(t, err)
 	require.ErrorContains(t, err, "not assignable")
 }
+
+func TestWorkflow_RetryBackoff(t *testing.T) {
+	wf := &Workflow{ReconcileInterval: 5 * time.Minute, MaxRetryBackoff: time.Hour}
+
+	// A single failure is retried at the reconcile interval.
+	require.Equal(t, 5*time.Minute, wf.RetryBackoff(0))
+	require.Equal(t, 5*time.Minute, wf.RetryBackoff(1))
+
+	// The interval is doubled for each further failure, less up to a tenth of jitter.
+	for failures, want := range map[int]time.Duration{2: 10 * time.Minute, 3: 20 * time.Minute, 20: time.Hour} {
+		got := wf.RetryBackoff(failures)
+		require.LessOrEqual(t, got, want, "failures %d", failures)
+		require.GreaterOrEqual(t, got, want-want/10, "failures %d", failures)
+	}
+
+	// The backoff is disabled when the maximum is not longer than the interval.
+	wf.MaxRetryBackoff = 0
+	require.Equal(t, 5*time.Minute, wf.RetryBackoff(5))
+	wf.MaxRetryBackoff = time.Minute
+	require.Equal(t, 5*time.Minute, wf.RetryBackoff(5))
+}